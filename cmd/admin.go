@@ -21,6 +21,9 @@ func newAdminCommandWithDeployDeps(deployDeps *adminDeployDeps) *cobra.Command {
 	cmd.AddCommand(newAdminDeployCommandWithDeps(deployDeps))
 	cmd.AddCommand(newAdminAttachPolicyCommand())
 	cmd.AddCommand(newAdminSetupCommand())
+	cmd.AddCommand(newAdminEnableSerialConsoleCommand())
+	cmd.AddCommand(newAdminOrphansCommand())
+	addAdminRoleFlags(cmd)
 
 	return cmd
 }
@@ -37,6 +40,9 @@ func newAdminCommandWithAttachPolicyDeps(attachPolicyDeps *adminAttachPolicyDeps
 	cmd.AddCommand(newAdminDeployCommand())
 	cmd.AddCommand(newAdminAttachPolicyCommandWithDeps(attachPolicyDeps))
 	cmd.AddCommand(newAdminSetupCommand())
+	cmd.AddCommand(newAdminEnableSerialConsoleCommand())
+	cmd.AddCommand(newAdminOrphansCommand())
+	addAdminRoleFlags(cmd)
 
 	return cmd
 }
@@ -53,6 +59,9 @@ func newAdminCommandWithSetupDeps(setupDeps *adminSetupDeps) *cobra.Command {
 	cmd.AddCommand(newAdminDeployCommand())
 	cmd.AddCommand(newAdminAttachPolicyCommand())
 	cmd.AddCommand(newAdminSetupCommandWithDeps(setupDeps))
+	cmd.AddCommand(newAdminEnableSerialConsoleCommand())
+	cmd.AddCommand(newAdminOrphansCommand())
+	addAdminRoleFlags(cmd)
 
 	return cmd
 }
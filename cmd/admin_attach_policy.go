@@ -44,12 +44,16 @@ func newAdminAttachPolicyCommandWithDeps(deps *adminAttachPolicyDeps) *cobra.Com
 			if clients == nil {
 				return fmt.Errorf("AWS clients not configured")
 			}
+			scoped, err := adminClientsForCommand(cmd, clients)
+			if err != nil {
+				return err
+			}
 			return runAdminAttachPolicy(cmd, &adminAttachPolicyDeps{
-				ssoListInstances:   clients.ssoAdminClient,
-				ssoListPermSets:    clients.ssoAdminClient,
-				ssoDescribePermSet: clients.ssoAdminClient,
-				ssoAttachPolicy:    clients.ssoAdminClient,
-				ssoProvision:       clients.ssoAdminClient,
+				ssoListInstances:   scoped.ssoAdminClient,
+				ssoListPermSets:    scoped.ssoAdminClient,
+				ssoDescribePermSet: scoped.ssoAdminClient,
+				ssoAttachPolicy:    scoped.ssoAdminClient,
+				ssoProvision:       scoped.ssoAdminClient,
 			})
 		},
 	}
@@ -7,10 +7,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
 	ssoadmintypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/spf13/cobra"
 )
 
@@ -21,9 +21,14 @@ import (
 type mockSSOListInstances struct {
 	output *ssoadmin.ListInstancesOutput
 	err    error
+	// onCall, when set, is called on each ListInstances call.
+	onCall func()
 }
 
 func (m *mockSSOListInstances) ListInstances(ctx context.Context, params *ssoadmin.ListInstancesInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.ListInstancesOutput, error) {
+	if m.onCall != nil {
+		m.onCall()
+	}
 	return m.output, m.err
 }
 
@@ -48,9 +53,14 @@ func (m *mockSSODescribePermSet) DescribePermissionSet(ctx context.Context, para
 type mockSSOAttachPolicy struct {
 	output *ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetOutput
 	err    error
+	// onAttach, when set, is called with each attach input.
+	onAttach func(*ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetInput)
 }
 
 func (m *mockSSOAttachPolicy) AttachCustomerManagedPolicyReferenceToPermissionSet(ctx context.Context, params *ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetOutput, error) {
+	if m.onAttach != nil {
+		m.onAttach(params)
+	}
 	return m.output, m.err
 }
 
@@ -97,9 +107,9 @@ func newTestRootForAdminAttachPolicy(deps *adminAttachPolicyDeps) *cobra.Command
 // TestAdminAttachPolicySuccess verifies that a successful attach-policy prints JSON.
 func TestAdminAttachPolicySuccess(t *testing.T) {
 	const (
-		instanceARN    = "arn:aws:sso:::instance/ssoins-abc123"
-		permSetARN     = "arn:aws:sso:::permissionSet/ssoins-abc123/ps-xyz789"
-		permSetName    = "PowerUserAccess"
+		instanceARN = "arn:aws:sso:::instance/ssoins-abc123"
+		permSetARN  = "arn:aws:sso:::permissionSet/ssoins-abc123/ps-xyz789"
+		permSetName = "PowerUserAccess"
 	)
 
 	deps := &adminAttachPolicyDeps{
@@ -191,13 +201,14 @@ func TestAdminAttachPolicyDefaultFlags(t *testing.T) {
 		permSetARN  = "arn:aws:sso:::permissionSet/ssoins-default/ps-default"
 	)
 
-	var capturedPermSetName string
+	var capturedPermSetARN string
 	var capturedPolicyName string
 
-	// Use a capturing mock on AttachCustomerManagedPolicyReferenceToPermissionSet
-	// to verify the policy name passed in.
-	captureAttach := &capturingSSOAttachPolicy{
+	// Capture the attach input to verify the permission set and policy name
+	// passed in.
+	captureAttach := &mockSSOAttachPolicy{
 		onAttach: func(params *ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetInput) {
+			capturedPermSetARN = aws.ToString(params.PermissionSetArn)
 			if params.CustomerManagedPolicyReference != nil && params.CustomerManagedPolicyReference.Name != nil {
 				capturedPolicyName = *params.CustomerManagedPolicyReference.Name
 			}
@@ -205,11 +216,11 @@ func TestAdminAttachPolicyDefaultFlags(t *testing.T) {
 		output: &ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetOutput{},
 	}
 
-	// Capture the permission set name from DescribePermissionSet calls.
-	captureDescribe := &capturingSSODescribePermSet{
-		returnName:  "PowerUserAccess",
-		returnARN:   permSetARN,
-		onDescribe: func(name string) { capturedPermSetName = name },
+	captureDescribe := &mockSSODescribePermSet{
+		output: &ssoadmin.DescribePermissionSetOutput{PermissionSet: &ssoadmintypes.PermissionSet{
+			PermissionSetArn: aws.String(permSetARN),
+			Name:             aws.String("PowerUserAccess"),
+		}},
 	}
 
 	deps := &adminAttachPolicyDeps{
@@ -244,44 +255,11 @@ func TestAdminAttachPolicyDefaultFlags(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if capturedPermSetName != "PowerUserAccess" {
-		t.Errorf("expected default permission set name %q, got %q", "PowerUserAccess", capturedPermSetName)
+	if capturedPermSetARN != permSetARN {
+		t.Errorf("expected the PowerUserAccess permission set %q, got %q", permSetARN, capturedPermSetARN)
 	}
 	const defaultPolicyName = "mint-pass-instance-role"
 	if capturedPolicyName != defaultPolicyName {
 		t.Errorf("expected default policy name %q, got %q", defaultPolicyName, capturedPolicyName)
 	}
 }
-
-// capturingSSOAttachPolicy captures the input passed to AttachCustomerManagedPolicyReferenceToPermissionSet.
-type capturingSSOAttachPolicy struct {
-	onAttach func(*ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetInput)
-	output   *ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetOutput
-	err      error
-}
-
-func (m *capturingSSOAttachPolicy) AttachCustomerManagedPolicyReferenceToPermissionSet(ctx context.Context, params *ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.AttachCustomerManagedPolicyReferenceToPermissionSetOutput, error) {
-	if m.onAttach != nil {
-		m.onAttach(params)
-	}
-	return m.output, m.err
-}
-
-// capturingSSODescribePermSet is a mock DescribePermissionSetAPI that
-// returns a fixed permission set name and ARN, and calls a callback with the name.
-type capturingSSODescribePermSet struct {
-	returnName string
-	returnARN  string
-	onDescribe func(name string)
-}
-
-func (m *capturingSSODescribePermSet) DescribePermissionSet(ctx context.Context, params *ssoadmin.DescribePermissionSetInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DescribePermissionSetOutput, error) {
-	ps := &ssoadmintypes.PermissionSet{
-		PermissionSetArn: aws.String(m.returnARN),
-		Name:             aws.String(m.returnName),
-	}
-	if m.onDescribe != nil {
-		m.onDescribe(m.returnName)
-	}
-	return &ssoadmin.DescribePermissionSetOutput{PermissionSet: ps}, nil
-}
@@ -7,12 +7,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/spf13/cobra"
 )
 
@@ -23,9 +24,14 @@ import (
 type mockCFNCreate struct {
 	output *cloudformation.CreateStackOutput
 	err    error
+	// onCreateStack, when set, is called with each CreateStack input.
+	onCreateStack func(*cloudformation.CreateStackInput)
 }
 
 func (m *mockCFNCreate) CreateStack(ctx context.Context, params *cloudformation.CreateStackInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateStackOutput, error) {
+	if m.onCreateStack != nil {
+		m.onCreateStack(params)
+	}
 	return m.output, m.err
 }
 
@@ -78,24 +84,6 @@ func (m *mockCFNEvents) DescribeStackEvents(ctx context.Context, params *cloudfo
 	return m.output, m.err
 }
 
-type mockEC2DescribeVPCs struct {
-	output *ec2.DescribeVpcsOutput
-	err    error
-}
-
-func (m *mockEC2DescribeVPCs) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
-	return m.output, m.err
-}
-
-type mockEC2DescribeSubnets struct {
-	output *ec2.DescribeSubnetsOutput
-	err    error
-}
-
-func (m *mockEC2DescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
-	return m.output, m.err
-}
-
 // ---------------------------------------------------------------------------
 // Helper: minimal root command for admin deploy tests
 // ---------------------------------------------------------------------------
@@ -187,13 +175,13 @@ func TestAdminDeploySuccess(t *testing.T) {
 	)
 
 	deps := &adminDeployDeps{
-		cfnCreate:      &mockCFNCreate{output: &cloudformation.CreateStackOutput{}},
-		cfnUpdate:      &mockCFNUpdate{output: &cloudformation.UpdateStackOutput{}},
-		cfnDelete:      noopCFNDelete(),
-		cfnDescribe:    newStackSuccessDescribe(stackName, efsID, sgID, instanceProfileARN, passRoleARN),
-		cfnEvents:      &mockCFNEvents{output: &cloudformation.DescribeStackEventsOutput{}},
-		ec2DescribeVPCs:     &mockEC2DescribeVPCs{output: makeVPCOutput("vpc-111")},
-		ec2DescribeSubnets:  &mockEC2DescribeSubnets{output: makeSubnetOutput("subnet-aaa", "subnet-bbb")},
+		cfnCreate:          &mockCFNCreate{output: &cloudformation.CreateStackOutput{}},
+		cfnUpdate:          &mockCFNUpdate{output: &cloudformation.UpdateStackOutput{}},
+		cfnDelete:          noopCFNDelete(),
+		cfnDescribe:        newStackSuccessDescribe(stackName, efsID, sgID, instanceProfileARN, passRoleARN),
+		cfnEvents:          &mockCFNEvents{output: &cloudformation.DescribeStackEventsOutput{}},
+		ec2DescribeVPCs:    &cmdtest.DescribeVpcs{Output: makeVPCOutput("vpc-111")},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: makeSubnetOutput("subnet-aaa", "subnet-bbb")},
 	}
 
 	var stdout bytes.Buffer
@@ -226,13 +214,13 @@ func TestAdminDeploySuccess(t *testing.T) {
 // TestAdminDeployVPCNotFound verifies that ErrVPCNotFound produces a non-zero exit.
 func TestAdminDeployVPCNotFound(t *testing.T) {
 	deps := &adminDeployDeps{
-		cfnCreate:   &mockCFNCreate{},
-		cfnUpdate:   &mockCFNUpdate{},
-		cfnDelete:   noopCFNDelete(),
-		cfnDescribe: &mockCFNDescribe{outputs: []*cloudformation.DescribeStacksOutput{makeDescribeStacksNotFound()}},
-		cfnEvents:   &mockCFNEvents{},
-		ec2DescribeVPCs:    &mockEC2DescribeVPCs{output: &ec2.DescribeVpcsOutput{Vpcs: []ec2types.Vpc{}}},
-		ec2DescribeSubnets: &mockEC2DescribeSubnets{output: &ec2.DescribeSubnetsOutput{}},
+		cfnCreate:          &mockCFNCreate{},
+		cfnUpdate:          &mockCFNUpdate{},
+		cfnDelete:          noopCFNDelete(),
+		cfnDescribe:        &mockCFNDescribe{outputs: []*cloudformation.DescribeStacksOutput{makeDescribeStacksNotFound()}},
+		cfnEvents:          &mockCFNEvents{},
+		ec2DescribeVPCs:    &cmdtest.DescribeVpcs{Output: &ec2.DescribeVpcsOutput{Vpcs: []ec2types.Vpc{}}},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{}},
 	}
 
 	var stderr bytes.Buffer
@@ -254,7 +242,7 @@ func TestAdminDeployDefaultStackName(t *testing.T) {
 	var capturedStackName string
 
 	// Use a custom mock that captures the stack name passed to CreateStack.
-	cfnCreate := &capturingCFNCreate{
+	cfnCreate := &mockCFNCreate{
 		onCreateStack: func(input *cloudformation.CreateStackInput) {
 			if input.StackName != nil {
 				capturedStackName = *input.StackName
@@ -266,13 +254,13 @@ func TestAdminDeployDefaultStackName(t *testing.T) {
 	describe := newStackSuccessDescribe(defaultStack, "fs-xxx", "sg-yyy", "arn:aws:iam::123:instance-profile/m", "arn:aws:iam::123:policy/p")
 
 	deps := &adminDeployDeps{
-		cfnCreate:   cfnCreate,
-		cfnUpdate:   &mockCFNUpdate{},
-		cfnDelete:   noopCFNDelete(),
-		cfnDescribe: describe,
-		cfnEvents:   &mockCFNEvents{output: &cloudformation.DescribeStackEventsOutput{}},
-		ec2DescribeVPCs:    &mockEC2DescribeVPCs{output: makeVPCOutput("vpc-222")},
-		ec2DescribeSubnets: &mockEC2DescribeSubnets{output: makeSubnetOutput("subnet-ccc")},
+		cfnCreate:          cfnCreate,
+		cfnUpdate:          &mockCFNUpdate{},
+		cfnDelete:          noopCFNDelete(),
+		cfnDescribe:        describe,
+		cfnEvents:          &mockCFNEvents{output: &cloudformation.DescribeStackEventsOutput{}},
+		ec2DescribeVPCs:    &cmdtest.DescribeVpcs{Output: makeVPCOutput("vpc-222")},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: makeSubnetOutput("subnet-ccc")},
 	}
 
 	root := newTestRootForAdminDeploy(deps)
@@ -287,17 +275,3 @@ func TestAdminDeployDefaultStackName(t *testing.T) {
 		t.Errorf("expected stack name %q, got %q", defaultStack, capturedStackName)
 	}
 }
-
-// capturingCFNCreate is a mock CreateStackAPI that invokes a callback on CreateStack.
-type capturingCFNCreate struct {
-	onCreateStack func(*cloudformation.CreateStackInput)
-	output        *cloudformation.CreateStackOutput
-	err           error
-}
-
-func (m *capturingCFNCreate) CreateStack(ctx context.Context, params *cloudformation.CreateStackInput, optFns ...func(*cloudformation.Options)) (*cloudformation.CreateStackOutput, error) {
-	if m.onCreateStack != nil {
-		m.onCreateStack(params)
-	}
-	return m.output, m.err
-}
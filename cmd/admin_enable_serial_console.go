@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+)
+
+// adminEnableSerialConsoleDeps holds the injectable dependencies for the
+// admin enable-serial-console command.
+type adminEnableSerialConsoleDeps struct {
+	status mintaws.GetSerialConsoleAccessStatusAPI
+	enable mintaws.EnableSerialConsoleAccessAPI
+	region string
+}
+
+// newAdminEnableSerialConsoleCommand creates the production admin
+// enable-serial-console command.
+func newAdminEnableSerialConsoleCommand() *cobra.Command {
+	return newAdminEnableSerialConsoleCommandWithDeps(nil)
+}
+
+// newAdminEnableSerialConsoleCommandWithDeps creates the admin
+// enable-serial-console command with explicit dependencies for testing.
+func newAdminEnableSerialConsoleCommandWithDeps(deps *adminEnableSerialConsoleDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enable-serial-console",
+		Short: "Enable EC2 Serial Console access for the account",
+		Long: "Enable EC2 Serial Console access for the AWS account in the current region so users " +
+			"can run mint console. The setting is account-wide and per region.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runAdminEnableSerialConsole(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			scoped, err := adminClientsForCommand(cmd, clients)
+			if err != nil {
+				return err
+			}
+			return runAdminEnableSerialConsole(cmd, &adminEnableSerialConsoleDeps{
+				status: scoped.ec2Client,
+				enable: scoped.ec2Client,
+				region: scoped.region,
+			})
+		},
+	}
+
+	return cmd
+}
+
+// adminEnableSerialConsoleResult is the JSON output of the command.
+type adminEnableSerialConsoleResult struct {
+	Region  string `json:"region"`
+	Enabled bool   `json:"enabled"`
+	Changed bool   `json:"changed"`
+}
+
+// runAdminEnableSerialConsole executes the admin enable-serial-console logic.
+// It is idempotent: an account that already has access is left unchanged.
+func runAdminEnableSerialConsole(cmd *cobra.Command, deps *adminEnableSerialConsoleDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	jsonOutput := false
+	if cliCtx != nil {
+		jsonOutput = cliCtx.JSON
+	}
+
+	status, err := deps.status.GetSerialConsoleAccessStatus(ctx, &ec2.GetSerialConsoleAccessStatusInput{})
+	if err != nil {
+		return fmt.Errorf("checking serial console access: %w", err)
+	}
+
+	result := adminEnableSerialConsoleResult{Region: deps.region, Enabled: true}
+	if !aws.ToBool(status.SerialConsoleAccessEnabled) {
+		out, err := deps.enable.EnableSerialConsoleAccess(ctx, &ec2.EnableSerialConsoleAccessInput{})
+		if err != nil {
+			return fmt.Errorf("enabling serial console access: %w", err)
+		}
+		result.Enabled = aws.ToBool(out.SerialConsoleAccessEnabled)
+		result.Changed = true
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if result.Changed {
+		fmt.Fprintf(cmd.OutOrStdout(), "EC2 Serial Console access enabled in %s.\n", deps.region)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "EC2 Serial Console access is already enabled in %s.\n", deps.region)
+	}
+	return nil
+}
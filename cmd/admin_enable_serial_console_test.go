@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+)
+
+// mockEnableSerialConsole implements mintaws.EnableSerialConsoleAccessAPI.
+type mockEnableSerialConsole struct {
+	called bool
+	err    error
+}
+
+func (m *mockEnableSerialConsole) EnableSerialConsoleAccess(ctx context.Context, params *ec2.EnableSerialConsoleAccessInput, optFns ...func(*ec2.Options)) (*ec2.EnableSerialConsoleAccessOutput, error) {
+	m.called = true
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ec2.EnableSerialConsoleAccessOutput{SerialConsoleAccessEnabled: aws.Bool(true)}, nil
+}
+
+func runAdminEnableSerialConsoleWithDeps(t *testing.T, deps *adminEnableSerialConsoleDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newAdminEnableSerialConsoleCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"enable-serial-console"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestAdminEnableSerialConsole(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		enableErr   error
+		wantCalled  bool
+		wantOutput  string
+		wantErrText string
+	}{
+		{
+			name:       "enables when disabled",
+			wantCalled: true,
+			wantOutput: "EC2 Serial Console access enabled in us-east-1.",
+		},
+		{
+			name:       "already enabled is a no-op",
+			enabled:    true,
+			wantOutput: "already enabled in us-east-1",
+		},
+		{
+			name:        "enable error",
+			enableErr:   errors.New("AccessDenied"),
+			wantCalled:  true,
+			wantErrText: "enabling serial console access: AccessDenied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enable := &mockEnableSerialConsole{err: tt.enableErr}
+			out, err := runAdminEnableSerialConsoleWithDeps(t, &adminEnableSerialConsoleDeps{
+				status: &mockSerialConsoleStatus{enabled: tt.enabled},
+				enable: enable,
+				region: "us-east-1",
+			})
+
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErrText)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if enable.called != tt.wantCalled {
+				t.Errorf("EnableSerialConsoleAccess called = %v, want %v", enable.called, tt.wantCalled)
+			}
+			if tt.wantOutput != "" && !strings.Contains(out, tt.wantOutput) {
+				t.Errorf("output = %q, want %q", out, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestAdminEnableSerialConsoleJSON(t *testing.T) {
+	out, err := runAdminEnableSerialConsoleWithDeps(t, &adminEnableSerialConsoleDeps{
+		status: &mockSerialConsoleStatus{enabled: false},
+		enable: &mockEnableSerialConsole{},
+		region: "eu-west-1",
+	}, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if result["region"] != "eu-west-1" || result["enabled"] != true || result["changed"] != true {
+		t.Errorf("result = %v", result)
+	}
+}
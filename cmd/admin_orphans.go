@@ -0,0 +1,571 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/logging"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// defaultOrphanStoppedDays is how long an instance must have been stopped
+// for mint admin orphans to list it without --stopped-days.
+const defaultOrphanStoppedDays = 30
+
+// Orphaned resource kinds, in the order they are listed and deleted.
+const (
+	orphanKindVolume    = "volume"
+	orphanKindElasticIP = "elastic-ip"
+	orphanKindInstance  = "instance"
+)
+
+// orphanKinds lists every kind, in that order.
+var orphanKinds = []string{orphanKindVolume, orphanKindElasticIP, orphanKindInstance}
+
+// orphanKindLabel names each kind in the delete prompts.
+var orphanKindLabel = map[string]string{
+	orphanKindVolume:    "volume(s)",
+	orphanKindElasticIP: "Elastic IP(s)",
+	orphanKindInstance:  "instance(s)",
+}
+
+// Orphan actions reported for each resource.
+const (
+	orphanActionDelete  = "delete"
+	orphanActionDeleted = "deleted"
+	orphanActionSkip    = "skip"
+	orphanActionFailed  = "failed"
+)
+
+// Monthly cost estimates use us-east-1 on-demand list prices in USD. They
+// are meant to rank what is worth cleaning up, not to match the bill.
+const (
+	hoursPerMonth        = 730
+	elasticIPHourlyUSD   = 0.005
+	gp3IOPSMonthlyUSD    = 0.005 // per provisioned IOPS above the baseline
+	gp3MBpsMonthlyUSD    = 0.04  // per provisioned MB/s above the baseline
+	gp3BaselineIOPS      = 3000
+	gp3BaselineMBps      = 125
+	defaultVolumeGBMonth = 0.08
+)
+
+// volumeGBMonthUSD is the storage price per GiB-month of each volume type.
+var volumeGBMonthUSD = map[ec2types.VolumeType]float64{
+	ec2types.VolumeTypeGp3:      0.08,
+	ec2types.VolumeTypeGp2:      0.10,
+	ec2types.VolumeTypeIo1:      0.125,
+	ec2types.VolumeTypeIo2:      0.125,
+	ec2types.VolumeTypeSt1:      0.045,
+	ec2types.VolumeTypeSc1:      0.015,
+	ec2types.VolumeTypeStandard: 0.05,
+}
+
+// adminOrphansDeps holds the injectable dependencies for the admin orphans
+// command.
+type adminOrphansDeps struct {
+	describe        mintaws.DescribeInstancesAPI
+	describeVolumes mintaws.DescribeVolumesAPI
+	describeAddrs   mintaws.DescribeAddressesAPI
+	deleteVolume    mintaws.DeleteVolumeAPI
+	release         mintaws.ReleaseAddressAPI
+	terminate       mintaws.TerminateInstancesAPI
+	// logger records each deletion in the structured log. Nil skips it.
+	logger logging.Logger
+	now    func() time.Time // nil uses time.Now
+}
+
+// clock returns the current time from deps.now, or time.Now.
+func (d *adminOrphansDeps) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// orphanEntry is one orphaned resource: an unattached volume, an
+// unassociated Elastic IP, or an instance stopped for a long time.
+type orphanEntry struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	VM    string `json:"vm,omitempty"`
+	// Detail is the volume's size and type, the Elastic IP's address, or
+	// the instance's type.
+	Detail string `json:"detail,omitempty"`
+	// AgeDays is days since a volume was created or an instance stopped.
+	// EC2 does not say when an Elastic IP was allocated.
+	AgeDays       *int    `json:"age_days"`
+	EstMonthlyUSD float64 `json:"est_monthly_usd"`
+	Action        string  `json:"action"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// orphanOwnerGroup is the orphaned resources of one mint:owner.
+type orphanOwnerGroup struct {
+	Owner         string        `json:"owner"`
+	EstMonthlyUSD float64       `json:"est_monthly_usd"`
+	Resources     []orphanEntry `json:"resources"`
+}
+
+// orphanReport is the outcome of an orphans run, also used as the JSON
+// output.
+type orphanReport struct {
+	StoppedDays   int                `json:"stopped_days"`
+	Delete        bool               `json:"delete"`
+	EstMonthlyUSD float64            `json:"est_monthly_usd"`
+	Owners        []orphanOwnerGroup `json:"owners"`
+}
+
+// newAdminOrphansCommand creates the production admin orphans command.
+func newAdminOrphansCommand() *cobra.Command {
+	return newAdminOrphansCommandWithDeps(nil)
+}
+
+// newAdminOrphansCommandWithDeps creates the admin orphans command with
+// explicit dependencies for testing.
+func newAdminOrphansCommandWithDeps(deps *adminOrphansDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "orphans",
+		Short: "List and delete orphaned mint resources across the account",
+		Long: "List the mint resources in the account and region that nobody is using, " +
+			"grouped by mint:owner: unattached volumes, Elastic IPs not associated " +
+			"with an instance, and instances stopped longer than --stopped-days. " +
+			"Each is shown with its age and an estimated monthly cost at us-east-1 " +
+			"list prices.\n\n" +
+			"Nothing is deleted unless --delete is given, which asks once per resource " +
+			"type (or not at all with --yes). Resources tagged mint:pending-attach, " +
+			"mint:keep=true, or mint:retained=true are listed but never deleted. " +
+			"Terminating an instance leaves its project volume and Elastic IP, which " +
+			"the next run lists.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runAdminOrphans(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			scoped, err := adminClientsForCommand(cmd, clients)
+			if err != nil {
+				return err
+			}
+			d := &adminOrphansDeps{
+				describe:        scoped.ec2Client,
+				describeVolumes: scoped.ec2Client,
+				describeAddrs:   scoped.ec2Client,
+				deleteVolume:    scoped.ec2Client,
+				release:         scoped.ec2Client,
+				terminate:       scoped.ec2Client,
+			}
+			cliCtx := cli.FromCommand(cmd)
+			logger, err := logging.NewStructuredLogger(structuredLogDir(), cliCtx != nil && cliCtx.Debug)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: deletions will not be logged: %v\n", err)
+			} else {
+				d.logger = logger
+			}
+			return runAdminOrphans(cmd, d)
+		},
+	}
+
+	cmd.Flags().Bool("delete", false, "Delete the listed resources, asking once per resource type (default is a dry run)")
+	cmd.Flags().Int("stopped-days", defaultOrphanStoppedDays, "List instances stopped at least this many days")
+
+	return cmd
+}
+
+// runAdminOrphans executes the admin orphans command logic.
+func runAdminOrphans(cmd *cobra.Command, deps *adminOrphansDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	jsonOutput := false
+	yes := false
+	if cliCtx != nil {
+		jsonOutput = cliCtx.JSON
+		yes = cliCtx.Yes
+	}
+	deleteMode, _ := cmd.Flags().GetBool("delete")
+	stoppedDaysFlag, _ := cmd.Flags().GetInt("stopped-days")
+	if stoppedDaysFlag < 1 {
+		return fmt.Errorf("invalid --stopped-days %d: must be 1 or more", stoppedDaysFlag)
+	}
+
+	now := deps.clock()
+	var entries []orphanEntry
+
+	volumes, err := orphanVolumes(ctx, deps.describeVolumes)
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		entries = append(entries, planOrphanVolume(v, now))
+	}
+
+	out, err := deps.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{Filters: tags.FilterMint()})
+	if err != nil {
+		return fmt.Errorf("describing Elastic IPs: %w", err)
+	}
+	for _, addr := range out.Addresses {
+		if aws.ToString(addr.AssociationId) != "" {
+			continue
+		}
+		entries = append(entries, planOrphanAddress(addr))
+	}
+
+	vms, err := vm.ListAllVMs(ctx, deps.describe)
+	if err != nil {
+		return fmt.Errorf("listing VMs: %w", err)
+	}
+	for _, v := range vms {
+		days := stoppedDays(v, now)
+		if days == nil || *days < stoppedDaysFlag {
+			continue
+		}
+		entries = append(entries, planOrphanInstance(v, *days))
+	}
+
+	// Prompts go to stderr with --json so stdout stays parseable.
+	w := cmd.OutOrStdout()
+	prompts := w
+	if jsonOutput {
+		prompts = cmd.ErrOrStderr()
+	}
+	if !jsonOutput {
+		writeAdminOrphansHuman(w, newOrphanReport(entries, stoppedDaysFlag, deleteMode))
+	}
+
+	failed := 0
+	if deleteMode {
+		p := &fixPrompter{w: prompts, in: bufio.NewScanner(cmd.InOrStdin()), yes: yes}
+		for _, kind := range orphanKinds {
+			var pending []*orphanEntry
+			cost := 0.0
+			for i := range entries {
+				if e := &entries[i]; e.Kind == kind && e.Action == orphanActionDelete {
+					pending = append(pending, e)
+					cost += e.EstMonthlyUSD
+				}
+			}
+			if len(pending) == 0 {
+				continue
+			}
+			if !p.confirm(fmt.Sprintf("\nDelete %d %s (about %s/month)?", len(pending), orphanKindLabel[kind], formatUSD(cost))) {
+				for _, e := range pending {
+					e.Action = orphanActionSkip
+					e.Reason = "not confirmed"
+				}
+				continue
+			}
+			for _, e := range pending {
+				if err := deleteOrphan(ctx, deps, e); err != nil {
+					e.Action = orphanActionFailed
+					e.Reason = err.Error()
+					failed++
+					if !jsonOutput {
+						fmt.Fprintf(w, "Failed to delete %s %s: %v\n", e.Kind, e.ID, err)
+					}
+					continue
+				}
+				e.Action = orphanActionDeleted
+				if !jsonOutput {
+					fmt.Fprintf(w, "Deleted %s %s.\n", e.Kind, e.ID)
+				}
+			}
+		}
+	}
+
+	report := newOrphanReport(entries, stoppedDaysFlag, deleteMode)
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return silentExitError{}
+		}
+		return nil
+	}
+	writeAdminOrphansSummary(w, report)
+	if failed > 0 {
+		return fmt.Errorf("%d resource(s) could not be deleted", failed)
+	}
+	return nil
+}
+
+// orphanVolumes returns the mint volumes not attached to any instance.
+func orphanVolumes(ctx context.Context, client mintaws.DescribeVolumesAPI) ([]ec2types.Volume, error) {
+	input := &ec2.DescribeVolumesInput{
+		Filters: append(tags.FilterMint(), ec2types.Filter{
+			Name:   aws.String("status"),
+			Values: []string{string(ec2types.VolumeStateAvailable)},
+		}),
+	}
+	var volumes []ec2types.Volume
+	for {
+		out, err := client.DescribeVolumes(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("describing volumes: %w", err)
+		}
+		volumes = append(volumes, out.Volumes...)
+		if aws.ToString(out.NextToken) == "" {
+			return volumes, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// planOrphanVolume returns the entry for the unattached volume v.
+func planOrphanVolume(v ec2types.Volume, now time.Time) orphanEntry {
+	m := tags.ToMap(v.Tags)
+	e := orphanEntry{
+		Kind:          orphanKindVolume,
+		ID:            aws.ToString(v.VolumeId),
+		Owner:         m[tags.TagOwner],
+		VM:            m[tags.TagVM],
+		Detail:        fmt.Sprintf("%d GiB %s", aws.ToInt32(v.Size), v.VolumeType),
+		EstMonthlyUSD: volumeMonthlyUSD(v),
+	}
+	if v.CreateTime != nil {
+		days := int(now.Sub(*v.CreateTime) / (24 * time.Hour))
+		e.AgeDays = &days
+	}
+	setOrphanAction(&e, m)
+	return e
+}
+
+// planOrphanAddress returns the entry for the unassociated Elastic IP addr.
+func planOrphanAddress(addr ec2types.Address) orphanEntry {
+	m := tags.ToMap(addr.Tags)
+	e := orphanEntry{
+		Kind:          orphanKindElasticIP,
+		ID:            aws.ToString(addr.AllocationId),
+		Owner:         m[tags.TagOwner],
+		VM:            m[tags.TagVM],
+		Detail:        aws.ToString(addr.PublicIp),
+		EstMonthlyUSD: elasticIPHourlyUSD * hoursPerMonth,
+	}
+	setOrphanAction(&e, m)
+	return e
+}
+
+// planOrphanInstance returns the entry for v, stopped for days. A stopped
+// instance bills for its root volume only, estimated as gp3.
+func planOrphanInstance(v *vm.VM, days int) orphanEntry {
+	e := orphanEntry{
+		Kind:          orphanKindInstance,
+		ID:            v.ID,
+		Owner:         v.Tags[tags.TagOwner],
+		VM:            v.Name,
+		Detail:        v.InstanceType,
+		AgeDays:       &days,
+		EstMonthlyUSD: float64(v.RootVolumeGB) * volumeGBMonthUSD[ec2types.VolumeTypeGp3],
+	}
+	setOrphanAction(&e, v.Tags)
+	return e
+}
+
+// setOrphanAction marks e for deletion unless its tags exempt it.
+func setOrphanAction(e *orphanEntry, m map[string]string) {
+	e.Action = orphanActionSkip
+	switch {
+	case hasTag(m, tags.TagPendingAttach):
+		e.Reason = tags.TagPendingAttach
+	case m[tags.TagKeep] == "true":
+		e.Reason = tags.TagKeep + "=true"
+	case m[tags.TagRetained] == "true":
+		e.Reason = tags.TagRetained + "=true"
+	default:
+		e.Action = orphanActionDelete
+	}
+}
+
+// hasTag reports whether key is set, whatever its value.
+func hasTag(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// volumeMonthlyUSD estimates what v costs a month: its storage, plus
+// provisioned IOPS and throughput above the gp3 baseline.
+func volumeMonthlyUSD(v ec2types.Volume) float64 {
+	rate, ok := volumeGBMonthUSD[v.VolumeType]
+	if !ok {
+		rate = defaultVolumeGBMonth
+	}
+	cost := float64(aws.ToInt32(v.Size)) * rate
+	if v.VolumeType == ec2types.VolumeTypeGp3 {
+		if iops := aws.ToInt32(v.Iops); iops > gp3BaselineIOPS {
+			cost += float64(iops-gp3BaselineIOPS) * gp3IOPSMonthlyUSD
+		}
+		if mbps := aws.ToInt32(v.Throughput); mbps > gp3BaselineMBps {
+			cost += float64(mbps-gp3BaselineMBps) * gp3MBpsMonthlyUSD
+		}
+	}
+	return cost
+}
+
+// deleteOrphan deletes e and records the call in the structured log. The
+// audit log, when enabled, records it through the EC2 client.
+func deleteOrphan(ctx context.Context, deps *adminOrphansDeps, e *orphanEntry) error {
+	start := time.Now()
+	var operation string
+	var err error
+	switch e.Kind {
+	case orphanKindVolume:
+		operation = "DeleteVolume"
+		_, err = deps.deleteVolume.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(e.ID)})
+	case orphanKindElasticIP:
+		operation = "ReleaseAddress"
+		_, err = deps.release.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(e.ID)})
+	case orphanKindInstance:
+		operation = "TerminateInstances"
+		_, err = deps.terminate.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: []string{e.ID}})
+	default:
+		return fmt.Errorf("unknown resource kind %q", e.Kind)
+	}
+	if deps.logger != nil {
+		deps.logger.Log("ec2", operation, time.Since(start), err)
+	}
+	return err
+}
+
+// newOrphanReport groups entries by owner, owners sorted by name and each
+// owner's resources by kind, then ID. Resources without a mint:owner tag
+// are grouped under an empty owner.
+func newOrphanReport(entries []orphanEntry, stoppedDays int, deleteMode bool) *orphanReport {
+	report := &orphanReport{StoppedDays: stoppedDays, Delete: deleteMode, Owners: []orphanOwnerGroup{}}
+	byOwner := make(map[string]*orphanOwnerGroup)
+	var owners []string
+	for _, e := range entries {
+		g, ok := byOwner[e.Owner]
+		if !ok {
+			g = &orphanOwnerGroup{Owner: e.Owner}
+			byOwner[e.Owner] = g
+			owners = append(owners, e.Owner)
+		}
+		g.Resources = append(g.Resources, e)
+		g.EstMonthlyUSD += e.EstMonthlyUSD
+		report.EstMonthlyUSD += e.EstMonthlyUSD
+	}
+	sort.Strings(owners)
+
+	kindOrder := make(map[string]int, len(orphanKinds))
+	for i, k := range orphanKinds {
+		kindOrder[k] = i
+	}
+	for _, owner := range owners {
+		g := byOwner[owner]
+		sort.Slice(g.Resources, func(i, j int) bool {
+			a, b := g.Resources[i], g.Resources[j]
+			if a.Kind != b.Kind {
+				return kindOrder[a.Kind] < kindOrder[b.Kind]
+			}
+			return a.ID < b.ID
+		})
+		report.Owners = append(report.Owners, *g)
+	}
+	return report
+}
+
+// writeAdminOrphansHuman outputs the orphaned resources as one table per
+// owner.
+func writeAdminOrphansHuman(w io.Writer, report *orphanReport) {
+	if len(report.Owners) == 0 {
+		fmt.Fprintln(w, "No orphaned mint resources found.")
+		return
+	}
+
+	for i, g := range report.Owners {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		owner := g.Owner
+		if owner == "" {
+			owner = "(no " + tags.TagOwner + " tag)"
+		}
+		fmt.Fprintf(w, "Owner: %s (about %s/month)\n", owner, formatUSD(g.EstMonthlyUSD))
+
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  TYPE\tID\tVM\tDETAIL\tAGE\tEST/MONTH\tACTION")
+		for _, e := range g.Resources {
+			age := "-"
+			if e.AgeDays != nil {
+				age = formatDays(*e.AgeDays)
+			}
+			action := e.Action
+			if e.Reason != "" {
+				action += ": " + e.Reason
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Kind, e.ID, orDash(e.VM), orDash(e.Detail), age, formatUSD(e.EstMonthlyUSD), action)
+		}
+		tw.Flush()
+	}
+}
+
+// writeAdminOrphansSummary outputs what the run deleted, or would delete.
+func writeAdminOrphansSummary(w io.Writer, report *orphanReport) {
+	if len(report.Owners) == 0 {
+		return
+	}
+	pending, deleted := 0, 0
+	pendingCost, deletedCost := 0.0, 0.0
+	for _, g := range report.Owners {
+		for _, e := range g.Resources {
+			switch e.Action {
+			case orphanActionDelete:
+				pending++
+				pendingCost += e.EstMonthlyUSD
+			case orphanActionDeleted:
+				deleted++
+				deletedCost += e.EstMonthlyUSD
+			}
+		}
+	}
+
+	switch {
+	case report.Delete && deleted > 0:
+		fmt.Fprintf(w, "\nDeleted %d resource(s), saving about %s/month.\n", deleted, formatUSD(deletedCost))
+	case report.Delete:
+		fmt.Fprintln(w, "\nNothing deleted.")
+	case pending == 0:
+		fmt.Fprintln(w, "\nNothing to delete.")
+	default:
+		fmt.Fprintf(w, "\nDry run — would delete %d resource(s), saving about %s/month. Run %s to delete them.\n",
+			pending, formatUSD(pendingCost), hint.Cmd("mint admin orphans --delete"))
+	}
+}
+
+// formatUSD formats an amount of US dollars to the cent.
+func formatUSD(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// orDash returns s, or "-" when s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,447 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// orphansNow is the fixed time the orphans tests run at.
+var orphansNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// orphanEC2 is the EC2 API of the orphans tests. It records what is
+// deleted, and fails deleting anything in failIDs.
+type orphanEC2 struct {
+	volumes     []ec2types.Volume
+	addresses   []ec2types.Address
+	volumesErr  error
+	volumeInput *ec2.DescribeVolumesInput
+	failIDs     map[string]bool
+	deleted     []string
+}
+
+func (m *orphanEC2) DescribeVolumes(_ context.Context, params *ec2.DescribeVolumesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	m.volumeInput = params
+	if m.volumesErr != nil {
+		return nil, m.volumesErr
+	}
+	return &ec2.DescribeVolumesOutput{Volumes: m.volumes}, nil
+}
+
+func (m *orphanEC2) DescribeAddresses(_ context.Context, _ *ec2.DescribeAddressesInput, _ ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	return &ec2.DescribeAddressesOutput{Addresses: m.addresses}, nil
+}
+
+func (m *orphanEC2) remove(id string) error {
+	if m.failIDs[id] {
+		return errors.New("UnauthorizedOperation")
+	}
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *orphanEC2) DeleteVolume(_ context.Context, params *ec2.DeleteVolumeInput, _ ...func(*ec2.Options)) (*ec2.DeleteVolumeOutput, error) {
+	return &ec2.DeleteVolumeOutput{}, m.remove(aws.ToString(params.VolumeId))
+}
+
+func (m *orphanEC2) ReleaseAddress(_ context.Context, params *ec2.ReleaseAddressInput, _ ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error) {
+	return &ec2.ReleaseAddressOutput{}, m.remove(aws.ToString(params.AllocationId))
+}
+
+func (m *orphanEC2) TerminateInstances(_ context.Context, params *ec2.TerminateInstancesInput, _ ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	return &ec2.TerminateInstancesOutput{}, m.remove(params.InstanceIds[0])
+}
+
+// orphanLogger records the operations logged to the structured log.
+type orphanLogger struct {
+	ops []string
+}
+
+func (l *orphanLogger) Log(service, operation string, _ time.Duration, err error) {
+	entry := service + ":" + operation
+	if err != nil {
+		entry += " (error)"
+	}
+	l.ops = append(l.ops, entry)
+}
+
+func (l *orphanLogger) SetStderr(io.Writer) {}
+
+func ownerTags(owner, vmName string, extra ...ec2types.Tag) []ec2types.Tag {
+	var t []ec2types.Tag
+	if owner != "" {
+		t = append(t, ec2types.Tag{Key: aws.String(tags.TagOwner), Value: aws.String(owner)})
+	}
+	if vmName != "" {
+		t = append(t, ec2types.Tag{Key: aws.String(tags.TagVM), Value: aws.String(vmName)})
+	}
+	return append(t, extra...)
+}
+
+// newOrphansFixture returns orphans deps over an account with:
+//   - vol-a: alice's 50 GiB gp3 volume, 40 days old
+//   - vol-pending: alice's volume tagged mint:pending-attach
+//   - vol-untagged: a 10 GiB gp2 volume without mint:owner
+//   - eipalloc-free: bob's unassociated Elastic IP
+//   - eipalloc-keep: bob's unassociated Elastic IP tagged mint:keep=true
+//   - eipalloc-used: an associated Elastic IP, not listed
+//   - i-old: bob's instance stopped 45 days, 200 GB root volume
+//   - i-recent and i-run: stopped 5 days and running, not listed
+func newOrphansFixture() (*adminOrphansDeps, *orphanEC2, *orphanLogger) {
+	ec2Mock := &orphanEC2{
+		volumes: []ec2types.Volume{
+			{VolumeId: aws.String("vol-a"), Size: aws.Int32(50), VolumeType: ec2types.VolumeTypeGp3,
+				CreateTime: aws.Time(orphansNow.Add(-40 * 24 * time.Hour)), Tags: ownerTags("alice", "dev")},
+			{VolumeId: aws.String("vol-pending"), Size: aws.Int32(20), VolumeType: ec2types.VolumeTypeGp3,
+				CreateTime: aws.Time(orphansNow.Add(-time.Hour)),
+				Tags:       ownerTags("alice", "dev", ec2types.Tag{Key: aws.String(tags.TagPendingAttach), Value: aws.String("i-new")})},
+			{VolumeId: aws.String("vol-untagged"), Size: aws.Int32(10), VolumeType: ec2types.VolumeTypeGp2,
+				CreateTime: aws.Time(orphansNow.Add(-100 * 24 * time.Hour))},
+		},
+		addresses: []ec2types.Address{
+			{AllocationId: aws.String("eipalloc-free"), PublicIp: aws.String("54.0.0.1"), Tags: ownerTags("bob", "ci")},
+			{AllocationId: aws.String("eipalloc-keep"), PublicIp: aws.String("54.0.0.2"),
+				Tags: ownerTags("bob", "demo", ec2types.Tag{Key: aws.String(tags.TagKeep), Value: aws.String("true")})},
+			{AllocationId: aws.String("eipalloc-used"), AssociationId: aws.String("eipassoc-1"), PublicIp: aws.String("54.0.0.3"),
+				Tags: ownerTags("carol", "default")},
+		},
+	}
+	old := gcInstance("i-old", "ci", ec2types.InstanceStateNameStopped, "User initiated (2025-01-15 10:00:00 GMT)",
+		ec2types.Tag{Key: aws.String(tags.TagRootVolumeGB), Value: aws.String("200")})
+	old.InstanceType = ec2types.InstanceTypeM6iXlarge
+	old.Tags[1].Value = aws.String("bob")
+	instances := []ec2types.Instance{
+		old,
+		gcInstance("i-recent", "recent", ec2types.InstanceStateNameStopped, "User initiated (2025-02-24 10:00:00 GMT)"),
+		gcInstance("i-run", "busy", ec2types.InstanceStateNameRunning, ""),
+	}
+	logger := &orphanLogger{}
+	deps := &adminOrphansDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{Instances: instances}},
+		}},
+		describeVolumes: ec2Mock,
+		describeAddrs:   ec2Mock,
+		deleteVolume:    ec2Mock,
+		release:         ec2Mock,
+		terminate:       ec2Mock,
+		logger:          logger,
+		now:             func() time.Time { return orphansNow },
+	}
+	return deps, ec2Mock, logger
+}
+
+func runAdminOrphansCommand(t *testing.T, deps *adminOrphansDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newAdminOrphansCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"orphans"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// orphanActions parses a JSON report into each resource's owner, action,
+// and cost, keyed by ID.
+func orphanActions(t *testing.T, out string) (*orphanReport, map[string]orphanEntry) {
+	t.Helper()
+	var report orphanReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	byID := make(map[string]orphanEntry)
+	for _, g := range report.Owners {
+		for _, e := range g.Resources {
+			byID[e.ID] = e
+		}
+	}
+	return &report, byID
+}
+
+func TestAdminOrphansDryRun(t *testing.T) {
+	deps, ec2Mock, logger := newOrphansFixture()
+	out, err := runAdminOrphansCommand(t, deps, "", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	report, byID := orphanActions(t, out)
+
+	var owners []string
+	for _, g := range report.Owners {
+		owners = append(owners, g.Owner)
+	}
+	if want := []string{"", "alice", "bob"}; !reflect.DeepEqual(owners, want) {
+		t.Errorf("owners = %q, want %q", owners, want)
+	}
+
+	tests := []struct {
+		id         string
+		kind       string
+		action     string
+		reason     string
+		ageDays    int
+		monthlyUSD float64
+	}{
+		{id: "vol-a", kind: orphanKindVolume, action: orphanActionDelete, ageDays: 40, monthlyUSD: 4},
+		{id: "vol-pending", kind: orphanKindVolume, action: orphanActionSkip, reason: tags.TagPendingAttach, monthlyUSD: 1.6},
+		{id: "vol-untagged", kind: orphanKindVolume, action: orphanActionDelete, ageDays: 100, monthlyUSD: 1},
+		{id: "eipalloc-free", kind: orphanKindElasticIP, action: orphanActionDelete, ageDays: -1, monthlyUSD: 3.65},
+		{id: "eipalloc-keep", kind: orphanKindElasticIP, action: orphanActionSkip, reason: "mint:keep=true", ageDays: -1, monthlyUSD: 3.65},
+		{id: "i-old", kind: orphanKindInstance, action: orphanActionDelete, ageDays: 45, monthlyUSD: 16},
+	}
+	if len(byID) != len(tests) {
+		t.Errorf("listed %d resources, want %d:\n%s", len(byID), len(tests), out)
+	}
+	for _, tt := range tests {
+		e, ok := byID[tt.id]
+		if !ok {
+			t.Errorf("%s not listed", tt.id)
+			continue
+		}
+		if e.Kind != tt.kind || e.Action != tt.action || e.Reason != tt.reason {
+			t.Errorf("%s = %s %s %q, want %s %s %q", tt.id, e.Kind, e.Action, e.Reason, tt.kind, tt.action, tt.reason)
+		}
+		if diff := e.EstMonthlyUSD - tt.monthlyUSD; diff > 0.001 || diff < -0.001 {
+			t.Errorf("%s cost = %.3f, want %.3f", tt.id, e.EstMonthlyUSD, tt.monthlyUSD)
+		}
+		switch {
+		case tt.ageDays < 0 && e.AgeDays != nil:
+			t.Errorf("%s age = %d, want unknown", tt.id, *e.AgeDays)
+		case tt.ageDays > 0 && (e.AgeDays == nil || *e.AgeDays != tt.ageDays):
+			t.Errorf("%s age = %v, want %d", tt.id, e.AgeDays, tt.ageDays)
+		}
+	}
+
+	if len(ec2Mock.deleted) != 0 || len(logger.ops) != 0 {
+		t.Errorf("dry run deleted %v, logged %v", ec2Mock.deleted, logger.ops)
+	}
+	filters := map[string][]string{}
+	for _, f := range ec2Mock.volumeInput.Filters {
+		filters[aws.ToString(f.Name)] = f.Values
+	}
+	if !reflect.DeepEqual(filters["status"], []string{"available"}) || !reflect.DeepEqual(filters["tag:"+tags.TagMint], []string{"true"}) {
+		t.Errorf("volume filters = %v, want mint=true and status=available", filters)
+	}
+}
+
+func TestAdminOrphansHuman(t *testing.T) {
+	hint.IsTTY = false
+	deps, _, _ := newOrphansFixture()
+	out, err := runAdminOrphansCommand(t, deps, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Owner: (no mint:owner tag) (about $1.00/month)",
+		"Owner: alice (about $5.60/month)",
+		"Owner: bob (about $23.30/month)",
+		"TYPE", "ID", "VM", "DETAIL", "AGE", "EST/MONTH", "ACTION",
+		"50 GiB gp3", "40 days", "$4.00",
+		"54.0.0.1", "m6i.xlarge", "45 days", "$16.00",
+		"skip: mint:pending-attach",
+		"Dry run — would delete 4 resource(s), saving about $24.65/month. Run `mint admin orphans --delete` to delete them.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "alice") > strings.Index(out, "bob") {
+		t.Errorf("owners not sorted:\n%s", out)
+	}
+}
+
+func TestAdminOrphansDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		failIDs     map[string]bool
+		wantDeleted []string
+		wantLogged  []string
+		wantErr     bool
+	}{
+		{
+			name:        "confirm every type",
+			stdin:       "y\ny\nyes\n",
+			wantDeleted: []string{"vol-a", "vol-untagged", "eipalloc-free", "i-old"},
+			wantLogged:  []string{"ec2:DeleteVolume", "ec2:DeleteVolume", "ec2:ReleaseAddress", "ec2:TerminateInstances"},
+		},
+		{
+			name:        "decline some types",
+			stdin:       "n\ny\n\n",
+			wantDeleted: []string{"eipalloc-free"},
+			wantLogged:  []string{"ec2:ReleaseAddress"},
+		},
+		{
+			name:  "no input deletes nothing",
+			stdin: "",
+		},
+		{
+			name:        "yes skips the prompts",
+			args:        []string{"--yes"},
+			wantDeleted: []string{"vol-a", "vol-untagged", "eipalloc-free", "i-old"},
+			wantLogged:  []string{"ec2:DeleteVolume", "ec2:DeleteVolume", "ec2:ReleaseAddress", "ec2:TerminateInstances"},
+		},
+		{
+			name:        "a failed deletion is reported and the rest continue",
+			args:        []string{"--yes"},
+			failIDs:     map[string]bool{"vol-a": true},
+			wantDeleted: []string{"vol-untagged", "eipalloc-free", "i-old"},
+			wantLogged:  []string{"ec2:DeleteVolume (error)", "ec2:DeleteVolume", "ec2:ReleaseAddress", "ec2:TerminateInstances"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, ec2Mock, logger := newOrphansFixture()
+			ec2Mock.failIDs = tt.failIDs
+			out, err := runAdminOrphansCommand(t, deps, tt.stdin, append([]string{"--delete"}, tt.args...)...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v\n%s", err, tt.wantErr, out)
+			}
+			if !reflect.DeepEqual(ec2Mock.deleted, tt.wantDeleted) {
+				t.Errorf("deleted %v, want %v", ec2Mock.deleted, tt.wantDeleted)
+			}
+			if !reflect.DeepEqual(logger.ops, tt.wantLogged) {
+				t.Errorf("logged %v, want %v", logger.ops, tt.wantLogged)
+			}
+			if slices.Contains(tt.args, "--yes") == strings.Contains(out, "[y/N]") {
+				t.Errorf("prompted = %v with args %v:\n%s", strings.Contains(out, "[y/N]"), tt.args, out)
+			}
+			if tt.wantErr && !strings.Contains(out, "Failed to delete volume vol-a: UnauthorizedOperation") {
+				t.Errorf("output does not report the failure:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestAdminOrphansDeleteJSON(t *testing.T) {
+	deps, ec2Mock, _ := newOrphansFixture()
+	ec2Mock.failIDs = map[string]bool{"i-old": true}
+	out, err := runAdminOrphansCommand(t, deps, "y\nn\ny\n", "--delete", "--json")
+	if !errors.As(err, new(silentExitError)) {
+		t.Fatalf("error = %v, want silentExitError", err)
+	}
+	// Prompts share the buffer with stdout here; the report starts at the
+	// first brace.
+	report, byID := orphanActions(t, out[strings.Index(out, "{"):])
+	if !report.Delete || report.StoppedDays != defaultOrphanStoppedDays {
+		t.Errorf("report = %+v", report)
+	}
+	want := map[string]string{
+		"vol-a":         orphanActionDeleted,
+		"vol-untagged":  orphanActionDeleted,
+		"vol-pending":   orphanActionSkip,
+		"eipalloc-free": orphanActionSkip,
+		"eipalloc-keep": orphanActionSkip,
+		"i-old":         orphanActionFailed,
+	}
+	for id, action := range want {
+		if byID[id].Action != action {
+			t.Errorf("%s action = %q, want %q", id, byID[id].Action, action)
+		}
+	}
+	if byID["eipalloc-free"].Reason != "not confirmed" || !strings.Contains(byID["i-old"].Reason, "UnauthorizedOperation") {
+		t.Errorf("reasons = %q, %q", byID["eipalloc-free"].Reason, byID["i-old"].Reason)
+	}
+}
+
+func TestAdminOrphansStoppedDays(t *testing.T) {
+	deps, _, _ := newOrphansFixture()
+	out, err := runAdminOrphansCommand(t, deps, "", "--json", "--stopped-days", "3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	_, byID := orphanActions(t, out)
+	if _, ok := byID["i-recent"]; !ok {
+		t.Errorf("i-recent, stopped 5 days, not listed with --stopped-days 3:\n%s", out)
+	}
+	if _, ok := byID["i-run"]; ok {
+		t.Error("running instance listed")
+	}
+}
+
+func TestAdminOrphansErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		setup   func(m *orphanEC2)
+		wantErr string
+	}{
+		{
+			name:    "invalid threshold",
+			args:    []string{"--stopped-days", "0"},
+			wantErr: "invalid --stopped-days 0",
+		},
+		{
+			name:    "describe volumes fails",
+			setup:   func(m *orphanEC2) { m.volumesErr = errors.New("UnauthorizedOperation") },
+			wantErr: "describing volumes: UnauthorizedOperation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, ec2Mock, _ := newOrphansFixture()
+			if tt.setup != nil {
+				tt.setup(ec2Mock)
+			}
+			out, err := runAdminOrphansCommand(t, deps, "", tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q\n%s", err, tt.wantErr, out)
+			}
+		})
+	}
+}
+
+func TestVolumeMonthlyUSD(t *testing.T) {
+	tests := []struct {
+		name   string
+		volume ec2types.Volume
+		want   float64
+	}{
+		{
+			name:   "gp3 at baseline",
+			volume: ec2types.Volume{Size: aws.Int32(100), VolumeType: ec2types.VolumeTypeGp3, Iops: aws.Int32(3000), Throughput: aws.Int32(125)},
+			want:   8,
+		},
+		{
+			name:   "gp3 with provisioned IOPS and throughput",
+			volume: ec2types.Volume{Size: aws.Int32(100), VolumeType: ec2types.VolumeTypeGp3, Iops: aws.Int32(4000), Throughput: aws.Int32(250)},
+			want:   8 + 5 + 5,
+		},
+		{
+			name:   "gp2",
+			volume: ec2types.Volume{Size: aws.Int32(100), VolumeType: ec2types.VolumeTypeGp2},
+			want:   10,
+		},
+		{
+			name:   "unknown type priced as gp3",
+			volume: ec2types.Volume{Size: aws.Int32(100), VolumeType: "gp9"},
+			want:   8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volumeMonthlyUSD(tt.volume); got-tt.want > 0.001 || tt.want-got > 0.001 {
+				t.Errorf("cost = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
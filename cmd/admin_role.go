@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/aws/retry"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+)
+
+const (
+	// defaultAdminSessionDuration is the admin role session length when
+	// --admin-session-duration is not given. STS allows one hour for any
+	// role; longer sessions need the role's MaxSessionDuration raised.
+	defaultAdminSessionDuration = time.Hour
+
+	minAdminSessionDuration = 15 * time.Minute
+	maxAdminSessionDuration = 12 * time.Hour
+
+	// maxRoleSessionNameLen is the STS limit on RoleSessionName.
+	maxRoleSessionNameLen = 64
+)
+
+// adminClients is the scoped set of SDK clients the admin commands use for
+// infrastructure changes. With an admin role they are built from the
+// assumed role's temporary credentials; the base awsClients keep serving
+// identity and owner resolution either way.
+type adminClients struct {
+	ec2Client      *retry.EC2
+	cfnClient      *cloudformation.Client
+	ssoAdminClient *ssoadmin.Client
+	region         string
+
+	// roleARN is the assumed role, or empty when the base credentials are used.
+	roleARN string
+}
+
+// addAdminRoleFlags registers the role flags shared by every admin command.
+func addAdminRoleFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("admin-role", "", "IAM role ARN to assume for admin operations (overrides admin_role_arn)")
+	cmd.PersistentFlags().Duration("admin-session-duration", defaultAdminSessionDuration,
+		"Session length for --admin-role, up to the role's maximum session duration")
+}
+
+// adminClientsForCommand returns the clients an admin command should use.
+// When --admin-role or admin_role_arn names a role it is assumed with the
+// base credentials; otherwise the base clients are returned unchanged.
+func adminClientsForCommand(cmd *cobra.Command, clients *awsClients) (*adminClients, error) {
+	roleARN := ""
+	duration := defaultAdminSessionDuration
+	if f := cmd.Flags().Lookup("admin-role"); f != nil {
+		roleARN = f.Value.String()
+	}
+	if cmd.Flags().Lookup("admin-session-duration") != nil {
+		duration, _ = cmd.Flags().GetDuration("admin-session-duration")
+	}
+	if roleARN == "" && clients.mintConfig != nil {
+		roleARN = clients.mintConfig.AdminRoleARN
+	}
+
+	if roleARN == "" {
+		return &adminClients{
+			ec2Client:      clients.ec2Client,
+			cfnClient:      clients.cfnClient,
+			ssoAdminClient: clients.ssoAdminClient,
+			region:         clients.region,
+		}, nil
+	}
+
+	if err := config.ValidateRoleARN(roleARN); err != nil {
+		return nil, fmt.Errorf("--admin-role: %w", err)
+	}
+	if duration < minAdminSessionDuration || duration > maxAdminSessionDuration {
+		return nil, fmt.Errorf("--admin-session-duration must be between %s and %s (got %s)",
+			format.FormatDuration(minAdminSessionDuration), format.FormatDuration(maxAdminSessionDuration), format.FormatDuration(duration))
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sessionName := adminSessionName(clients.owner, time.Now())
+	fmt.Fprintf(cmd.ErrOrStderr(), "Assuming admin role %s (session %s)...\n", roleARN, sessionName)
+
+	cfg, err := assumeAdminRole(ctx, clients.cfg, clients.assumeRole, roleARN, sessionName, duration, clients.ownerARN)
+	if err != nil {
+		return nil, err
+	}
+	return &adminClients{
+		ec2Client:      retry.NewEC2(cfg, clients.apiRetry),
+		cfnClient:      cloudformation.NewFromConfig(cfg),
+		ssoAdminClient: ssoadmin.NewFromConfig(cfg),
+		region:         cfg.Region,
+		roleARN:        roleARN,
+	}, nil
+}
+
+// assumeAdminRole assumes roleARN and returns a copy of base that signs
+// with the role's temporary credentials. base itself is not modified.
+func assumeAdminRole(ctx context.Context, base aws.Config, api mintaws.AssumeRoleAPI, roleARN, sessionName string, duration time.Duration, callerARN string) (aws.Config, error) {
+	out, err := api.AssumeRole(ctx, &sts.AssumeRoleInput{
+		RoleArn:         aws.String(roleARN),
+		RoleSessionName: aws.String(sessionName),
+		DurationSeconds: aws.Int32(int32(duration / time.Second)),
+	})
+	if err != nil {
+		return aws.Config{}, assumeRoleError(err, roleARN, duration, callerARN)
+	}
+	if out.Credentials == nil {
+		return aws.Config{}, fmt.Errorf("assume admin role %s: no credentials returned", roleARN)
+	}
+
+	cfg := base.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
+		aws.ToString(out.Credentials.AccessKeyId),
+		aws.ToString(out.Credentials.SecretAccessKey),
+		aws.ToString(out.Credentials.SessionToken),
+	))
+	return cfg, nil
+}
+
+// assumeRoleError turns the common AssumeRole failures into actionable
+// messages: a trust policy that does not admit the caller, and a session
+// longer than the role allows.
+func assumeRoleError(err error, roleARN string, duration time.Duration, callerARN string) error {
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		switch {
+		case ae.ErrorCode() == "AccessDenied":
+			if callerARN == "" {
+				callerARN = "your identity"
+			}
+			return fmt.Errorf("not allowed to assume admin role %s — its trust policy must allow sts:AssumeRole for %s, "+
+				"e.g. {\"Effect\": \"Allow\", \"Principal\": {\"AWS\": \"%s\"}, \"Action\": \"sts:AssumeRole\"}, "+
+				"and your credentials need sts:AssumeRole on the role: %w", roleARN, callerARN, callerARN, err)
+		case ae.ErrorCode() == "ValidationError" && strings.Contains(ae.ErrorMessage(), "DurationSeconds"):
+			return fmt.Errorf("admin session duration %s exceeds the maximum session duration of %s — "+
+				"lower --admin-session-duration or raise the role's MaxSessionDuration: %w",
+				format.FormatDuration(duration), roleARN, err)
+		}
+	}
+	return fmt.Errorf("assume admin role %s: %w", roleARN, err)
+}
+
+// roleSessionNameInvalid matches characters STS does not allow in a
+// RoleSessionName.
+var roleSessionNameInvalid = regexp.MustCompile(`[^\w+=,.@-]`)
+
+// adminSessionName returns the STS session name for an admin role session,
+// mint-admin-<user>-<unix time>, so CloudTrail attributes the admin's
+// changes to the person who ran them.
+func adminSessionName(owner string, now time.Time) string {
+	user := roleSessionNameInvalid.ReplaceAllString(owner, "-")
+	if user == "" {
+		user = "unknown"
+	}
+	suffix := fmt.Sprintf("-%d", now.Unix())
+	if max := maxRoleSessionNameLen - len("mint-admin-") - len(suffix); len(user) > max {
+		user = user[:max]
+	}
+	return "mint-admin-" + user + suffix
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/aws/retry"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+)
+
+const testAdminRole = "arn:aws:iam::123456789012:role/MintAdmin"
+
+// mockAssumeRole records the AssumeRole input and returns fixed credentials.
+type mockAssumeRole struct {
+	input *sts.AssumeRoleInput
+	err   error
+}
+
+func (m *mockAssumeRole) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.input = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     aws.String("ASSUMEDKEY"),
+		SecretAccessKey: aws.String("assumed-secret"),
+		SessionToken:    aws.String("assumed-token"),
+	}}, nil
+}
+
+// baseAWSClients returns awsClients built from static base credentials, as
+// PersistentPreRunE would.
+func baseAWSClients(assume *mockAssumeRole, mintCfg *config.Config) *awsClients {
+	cfg := aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("BASEKEY", "base-secret", ""),
+	}
+	return &awsClients{
+		ec2Client:      retry.NewEC2(cfg, nil),
+		cfnClient:      cloudformation.NewFromConfig(cfg),
+		ssoAdminClient: ssoadmin.NewFromConfig(cfg),
+		owner:          "alice",
+		ownerARN:       "arn:aws:iam::123456789012:user/alice",
+		region:         "us-west-2",
+		mintConfig:     mintCfg,
+		cfg:            cfg,
+		assumeRole:     assume,
+	}
+}
+
+// adminRoleTestCommand returns an admin subcommand with the admin role flags
+// parsed from args.
+func adminRoleTestCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	parent := &cobra.Command{Use: "admin"}
+	addAdminRoleFlags(parent)
+	child := &cobra.Command{Use: "deploy"}
+	parent.AddCommand(child)
+	child.SetErr(&strings.Builder{})
+	if err := child.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags: %v", err)
+	}
+	return child
+}
+
+func accessKeyOf(t *testing.T, provider aws.CredentialsProvider) string {
+	t.Helper()
+	creds, err := provider.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	return creds.AccessKeyID
+}
+
+func TestAdminClientsUseAssumedCredentials(t *testing.T) {
+	assume := &mockAssumeRole{}
+	clients := baseAWSClients(assume, &config.Config{})
+	cmd := adminRoleTestCommand(t, "--admin-role", testAdminRole, "--admin-session-duration", "2h")
+
+	scoped, err := adminClientsForCommand(cmd, clients)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := aws.ToString(assume.input.RoleArn); got != testAdminRole {
+		t.Errorf("RoleArn = %q, want %q", got, testAdminRole)
+	}
+	if got := aws.ToInt32(assume.input.DurationSeconds); got != 7200 {
+		t.Errorf("DurationSeconds = %d, want 7200", got)
+	}
+	if got := aws.ToString(assume.input.RoleSessionName); !strings.HasPrefix(got, "mint-admin-alice-") {
+		t.Errorf("RoleSessionName = %q, want mint-admin-alice-<ts>", got)
+	}
+
+	// Admin clients sign with the assumed role.
+	if got := accessKeyOf(t, scoped.ec2Client.Options().Credentials); got != "ASSUMEDKEY" {
+		t.Errorf("admin ec2 access key = %q, want ASSUMEDKEY", got)
+	}
+	if got := accessKeyOf(t, scoped.cfnClient.Options().Credentials); got != "ASSUMEDKEY" {
+		t.Errorf("admin cloudformation access key = %q, want ASSUMEDKEY", got)
+	}
+	if got := accessKeyOf(t, scoped.ssoAdminClient.Options().Credentials); got != "ASSUMEDKEY" {
+		t.Errorf("admin sso-admin access key = %q, want ASSUMEDKEY", got)
+	}
+	if scoped.region != "us-west-2" {
+		t.Errorf("admin region = %q, want us-west-2", scoped.region)
+	}
+
+	// The base clients in the same invocation keep the base credentials.
+	if got := accessKeyOf(t, clients.ec2Client.Options().Credentials); got != "BASEKEY" {
+		t.Errorf("base ec2 access key = %q, want BASEKEY", got)
+	}
+	if got := accessKeyOf(t, clients.cfg.Credentials); got != "BASEKEY" {
+		t.Errorf("base config access key = %q, want BASEKEY", got)
+	}
+}
+
+func TestAdminClientsRoleFromConfig(t *testing.T) {
+	assume := &mockAssumeRole{}
+	clients := baseAWSClients(assume, &config.Config{AdminRoleARN: testAdminRole})
+
+	scoped, err := adminClientsForCommand(adminRoleTestCommand(t), clients)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assume.input == nil || aws.ToInt32(assume.input.DurationSeconds) != 3600 {
+		t.Fatalf("AssumeRole input = %+v, want admin_role_arn assumed for 1h", assume.input)
+	}
+	if scoped.roleARN != testAdminRole {
+		t.Errorf("roleARN = %q, want %q", scoped.roleARN, testAdminRole)
+	}
+}
+
+func TestAdminClientsWithoutRoleUseBaseClients(t *testing.T) {
+	assume := &mockAssumeRole{}
+	clients := baseAWSClients(assume, &config.Config{})
+
+	scoped, err := adminClientsForCommand(adminRoleTestCommand(t), clients)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assume.input != nil {
+		t.Error("AssumeRole called without an admin role")
+	}
+	if scoped.ec2Client != clients.ec2Client || scoped.cfnClient != clients.cfnClient || scoped.ssoAdminClient != clients.ssoAdminClient {
+		t.Error("expected the base clients when no admin role is set")
+	}
+}
+
+func TestAdminClientsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		err     error
+		wantErr []string
+	}{
+		{
+			name:    "access denied names role and trust policy",
+			args:    []string{"--admin-role", testAdminRole},
+			err:     &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized to perform: sts:AssumeRole"},
+			wantErr: []string{testAdminRole, "trust policy", `"Principal": {"AWS": "arn:aws:iam::123456789012:user/alice"}`},
+		},
+		{
+			name: "duration over role maximum",
+			args: []string{"--admin-role", testAdminRole, "--admin-session-duration", "4h"},
+			err: &smithy.GenericAPIError{Code: "ValidationError",
+				Message: "The requested DurationSeconds exceeds the MaxSessionDuration set for this role."},
+			wantErr: []string{"4h", "maximum session duration", "MaxSessionDuration"},
+		},
+		{
+			name:    "invalid role ARN",
+			args:    []string{"--admin-role", "MintAdmin"},
+			wantErr: []string{"--admin-role", "not an IAM role ARN"},
+		},
+		{
+			name:    "duration out of range",
+			args:    []string{"--admin-role", testAdminRole, "--admin-session-duration", "13h"},
+			wantErr: []string{"--admin-session-duration must be between"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := baseAWSClients(&mockAssumeRole{err: tt.err}, &config.Config{})
+			_, err := adminClientsForCommand(adminRoleTestCommand(t, tt.args...), clients)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminSessionName(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	if got := adminSessionName("alice", now); got != "mint-admin-alice-1760000000" {
+		t.Errorf("adminSessionName = %q", got)
+	}
+	if got := adminSessionName("alice smith/ops", now); got != "mint-admin-alice-smith-ops-1760000000" {
+		t.Errorf("adminSessionName with invalid chars = %q", got)
+	}
+	if got := adminSessionName(strings.Repeat("x", 100), now); len(got) != maxRoleSessionNameLen || !strings.HasSuffix(got, "-1760000000") {
+		t.Errorf("long adminSessionName = %q (len %d)", got, len(got))
+	}
+}
@@ -48,22 +48,26 @@ func newAdminSetupCommandWithDeps(deps *adminSetupDeps) *cobra.Command {
 			if clients == nil {
 				return fmt.Errorf("AWS clients not configured")
 			}
+			scoped, err := adminClientsForCommand(cmd, clients)
+			if err != nil {
+				return err
+			}
 			return runAdminSetup(cmd, &adminSetupDeps{
 				deploy: &adminDeployDeps{
-					cfnCreate:          clients.cfnClient,
-					cfnUpdate:          clients.cfnClient,
-					cfnDelete:          clients.cfnClient,
-					cfnDescribe:        clients.cfnClient,
-					cfnEvents:          clients.cfnClient,
-					ec2DescribeVPCs:    clients.ec2Client,
-					ec2DescribeSubnets: clients.ec2Client,
+					cfnCreate:          scoped.cfnClient,
+					cfnUpdate:          scoped.cfnClient,
+					cfnDelete:          scoped.cfnClient,
+					cfnDescribe:        scoped.cfnClient,
+					cfnEvents:          scoped.cfnClient,
+					ec2DescribeVPCs:    scoped.ec2Client,
+					ec2DescribeSubnets: scoped.ec2Client,
 				},
 				attachPolicy: &adminAttachPolicyDeps{
-					ssoListInstances:   clients.ssoAdminClient,
-					ssoListPermSets:    clients.ssoAdminClient,
-					ssoDescribePermSet: clients.ssoAdminClient,
-					ssoAttachPolicy:    clients.ssoAdminClient,
-					ssoProvision:       clients.ssoAdminClient,
+					ssoListInstances:   scoped.ssoAdminClient,
+					ssoListPermSets:    scoped.ssoAdminClient,
+					ssoDescribePermSet: scoped.ssoAdminClient,
+					ssoAttachPolicy:    scoped.ssoAdminClient,
+					ssoProvision:       scoped.ssoAdminClient,
 				},
 			})
 		},
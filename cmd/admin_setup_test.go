@@ -8,12 +8,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
 	ssoadmintypes "github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/spf13/cobra"
 )
 
@@ -60,8 +61,8 @@ func newSuccessDeployDeps(stackName, efsID, sgID, instanceProfileARN, passRoleAR
 		cfnDelete:          noopCFNDelete(),
 		cfnDescribe:        newStackSuccessDescribe(stackName, efsID, sgID, instanceProfileARN, passRoleARN),
 		cfnEvents:          &mockCFNEvents{output: &cloudformation.DescribeStackEventsOutput{}},
-		ec2DescribeVPCs:    &mockEC2DescribeVPCs{output: makeVPCOutput("vpc-111")},
-		ec2DescribeSubnets: &mockEC2DescribeSubnets{output: makeSubnetOutput("subnet-aaa", "subnet-bbb")},
+		ec2DescribeVPCs:    &cmdtest.DescribeVpcs{Output: makeVPCOutput("vpc-111")},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: makeSubnetOutput("subnet-aaa", "subnet-bbb")},
 	}
 }
 
@@ -237,14 +238,14 @@ func TestAdminSetupDeployFails(t *testing.T) {
 		cfnDescribe: &mockCFNDescribe{outputs: []*cloudformation.DescribeStacksOutput{makeDescribeStacksNotFound()}},
 		cfnEvents:   &mockCFNEvents{},
 		// Empty VPC slice triggers ErrVPCNotFound immediately.
-		ec2DescribeVPCs:    &mockEC2DescribeVPCs{output: &ec2.DescribeVpcsOutput{Vpcs: nil}},
-		ec2DescribeSubnets: &mockEC2DescribeSubnets{output: makeSubnetOutput()},
+		ec2DescribeVPCs:    &cmdtest.DescribeVpcs{Output: &ec2.DescribeVpcsOutput{Vpcs: nil}},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: makeSubnetOutput()},
 	}
 
 	// Track whether attach-policy was called.
 	attachPolicyCalled := false
 	attachDeps := &adminAttachPolicyDeps{
-		ssoListInstances: &callTrackingSSOListInstances{
+		ssoListInstances: &mockSSOListInstances{
 			onCall: func() { attachPolicyCalled = true },
 			output: &ssoadmin.ListInstancesOutput{},
 		},
@@ -266,23 +267,6 @@ func TestAdminSetupDeployFails(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// callTrackingSSOListInstances tracks whether ListInstances was called.
-// ---------------------------------------------------------------------------
-
-type callTrackingSSOListInstances struct {
-	onCall func()
-	output *ssoadmin.ListInstancesOutput
-	err    error
-}
-
-func (m *callTrackingSSOListInstances) ListInstances(ctx context.Context, params *ssoadmin.ListInstancesInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.ListInstancesOutput, error) {
-	if m.onCall != nil {
-		m.onCall()
-	}
-	return m.output, m.err
-}
-
 // TestAdminSetupDeployFailsWithEmptyVPC verifies that when VPC has empty ID,
 // setup returns an error. This is an additional edge case for robustness.
 func TestAdminSetupDeployFailsWithEmptyVPC(t *testing.T) {
@@ -292,10 +276,10 @@ func TestAdminSetupDeployFailsWithEmptyVPC(t *testing.T) {
 		cfnDelete:   noopCFNDelete(),
 		cfnDescribe: &mockCFNDescribe{outputs: []*cloudformation.DescribeStacksOutput{makeDescribeStacksNotFound()}},
 		cfnEvents:   &mockCFNEvents{},
-		ec2DescribeVPCs: &mockEC2DescribeVPCs{
-			err: errors.New("simulated EC2 describe VPCs failure"),
+		ec2DescribeVPCs: &cmdtest.DescribeVpcs{
+			Err: errors.New("simulated EC2 describe VPCs failure"),
 		},
-		ec2DescribeSubnets: &mockEC2DescribeSubnets{output: makeSubnetOutput()},
+		ec2DescribeSubnets: &cmdtest.DescribeSubnets{Output: makeSubnetOutput()},
 	}
 
 	attachDeps := newNoSSOAttachPolicyDeps()
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// The VM agent is what bootstrap.sh installs on a VM: the idle watcher,
+// health reporting, and the files the CLI reads and writes there. Its
+// version is an integer bootstrap.sh writes to agentVersionPath; VMs
+// bootstrapped before the file existed are version 1.
+const (
+	agentVersionPath = "/mint/.mint/agent-version"
+
+	// agentVersionMin is the oldest agent with everything this CLI expects.
+	// Older VMs still work, with a note that some features are missing.
+	agentVersionMin = 2
+	// agentVersionMax is the newest agent this CLI understands. A newer VM
+	// may lay out its files differently, so the CLI will not modify them.
+	agentVersionMax = 3
+)
+
+// agentVersionReadCommand prints the agent version file, or nothing when it
+// does not exist.
+func agentVersionReadCommand() []string {
+	return []string{"cat " + agentVersionPath + " 2>/dev/null || true"}
+}
+
+// parseAgentVersion parses the agent version file. An empty file or no
+// file is version 1.
+func parseAgentVersion(out []byte) (int, error) {
+	s := strings.TrimSpace(string(out))
+	if s == "" {
+		return 1, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid agent version %q in %s", s, agentVersionPath)
+	}
+	return v, nil
+}
+
+// agentVersionOf returns the agent version of the VM, reading it with run
+// at most once per invocation.
+func agentVersionOf(
+	ctx context.Context,
+	run RemoteCommandRunner,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID string,
+	az string,
+	host string,
+	port int,
+	user string,
+) (int, error) {
+	cliCtx := cli.FromContext(ctx)
+	if v, ok := cliCtx.AgentVersion(instanceID); ok {
+		return v, nil
+	}
+	out, err := run(ctx, sendKey, instanceID, az, host, port, user, agentVersionReadCommand())
+	if err != nil {
+		return 0, fmt.Errorf("reading agent version: %w", err)
+	}
+	v, err := parseAgentVersion(out)
+	if err != nil {
+		return 0, err
+	}
+	cliCtx.SetAgentVersion(instanceID, v)
+	return v, nil
+}
+
+// agentVersionRange renders the agent versions this CLI supports.
+func agentVersionRange() string {
+	if agentVersionMin == agentVersionMax {
+		return fmt.Sprintf("v%d", agentVersionMax)
+	}
+	return fmt.Sprintf("v%d–v%d", agentVersionMin, agentVersionMax)
+}
+
+// agentOlderNote is the note printed once for a VM older than the CLI.
+func agentOlderNote(v int) string {
+	return fmt.Sprintf("VM agent v%d is older than this CLI expects (%s); features that rely on a newer agent may be unavailable — run %s to upgrade",
+		v, agentVersionRange(), hint.Cmd("mint recreate"))
+}
+
+// agentNewerNote is the note printed once for a VM newer than the CLI.
+func agentNewerNote(v int) string {
+	return fmt.Sprintf("VM agent v%d is newer than this CLI supports (%s) — upgrade with %s",
+		v, agentVersionRange(), hint.Cmd("mint update"))
+}
+
+// agentTooNewError refuses to modify files on a VM whose agent is newer
+// than the CLI understands.
+func agentTooNewError(v int) error {
+	return fmt.Errorf("VM agent v%d is newer than this CLI supports (%s); refusing to modify files on the VM — upgrade with %s",
+		v, agentVersionRange(), hint.Cmd("mint update"))
+}
+
+// agentGuard checks a VM's agent version before each remote command. The
+// first command to reach a VM reads the version and prints a note to notes
+// when it is out of range. Commands that modify files on the VM
+// (cli.AnnotationRemoteWrites) are refused when the VM is newer than the
+// CLI. A version that cannot be read does not block the command; its own
+// connection reports any problem.
+type agentGuard struct {
+	notes io.Writer
+}
+
+// newAgentGuard returns an agentGuard writing notes to w, or stderr when w
+// is nil.
+func newAgentGuard(w io.Writer) *agentGuard {
+	if w == nil {
+		w = os.Stderr
+	}
+	return &agentGuard{notes: w}
+}
+
+// check returns the error to fail the command with, if any. read is the
+// runner that reads the version file, bypassing the guard.
+func (g *agentGuard) check(
+	ctx context.Context,
+	read RemoteCommandRunner,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID string,
+	az string,
+	host string,
+	port int,
+	user string,
+) error {
+	cliCtx := cli.FromContext(ctx)
+	_, cached := cliCtx.AgentVersion(instanceID)
+	v, err := agentVersionOf(ctx, read, sendKey, instanceID, az, host, port, user)
+	if err != nil {
+		// Cache 0 (unknown) so the read is not retried on every call.
+		cliCtx.SetAgentVersion(instanceID, 0)
+		return nil
+	}
+	if v > agentVersionMax && cliCtx != nil && cliCtx.RemoteWrites {
+		return agentTooNewError(v)
+	}
+	if !cached && v != 0 {
+		switch {
+		case v < agentVersionMin:
+			fmt.Fprintf(g.notes, "Note: %s\n", agentOlderNote(v))
+		case v > agentVersionMax:
+			fmt.Fprintf(g.notes, "Note: %s\n", agentNewerNote(v))
+		}
+	}
+	return nil
+}
+
+// remoteRunner wraps inner with the agent version check.
+func (g *agentGuard) remoteRunner(inner RemoteCommandRunner) RemoteCommandRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		if err := g.check(ctx, inner, sendKey, instanceID, az, host, port, user); err != nil {
+			return nil, err
+		}
+		return inner(ctx, sendKey, instanceID, az, host, port, user, command)
+	}
+}
+
+// streamingRemoteRunner is the StreamingRemoteRunner counterpart of
+// remoteRunner.
+func (g *agentGuard) streamingRemoteRunner(inner StreamingRemoteRunner) StreamingRemoteRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		read := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+			return inner(withoutRemoteStdin(ctx), sendKey, instanceID, az, host, port, user, command, io.Discard)
+		}
+		if err := g.check(ctx, read, sendKey, instanceID, az, host, port, user); err != nil {
+			return nil, err
+		}
+		return inner(ctx, sendKey, instanceID, az, host, port, user, command, stderr)
+	}
+}
+
+// agentVersionSummary describes v for status and doctor.
+func agentVersionSummary(v int) string {
+	switch {
+	case v < agentVersionMin:
+		return fmt.Sprintf("v%d (CLI %s) — older; run %s to upgrade", v, agentVersionRange(), hint.Cmd("mint recreate"))
+	case v > agentVersionMax:
+		return fmt.Sprintf("v%d (CLI %s) — newer; run %s", v, agentVersionRange(), hint.Cmd("mint update"))
+	default:
+		return fmt.Sprintf("v%d (CLI %s)", v, agentVersionRange())
+	}
+}
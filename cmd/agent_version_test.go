@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// agentRunner returns a RemoteCommandRunner whose agent version file holds
+// version, or which fails to read it with readErr. Every other command
+// succeeds with "ok". It records the commands it runs.
+func agentRunner(version string, readErr error) (RemoteCommandRunner, *[]string) {
+	var calls []string
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID, az, host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		calls = append(calls, strings.Join(command, " "))
+		if strings.Join(command, " ") == agentVersionReadCommand()[0] {
+			return []byte(version), readErr
+		}
+		return []byte("ok"), nil
+	}, &calls
+}
+
+// agentCtx returns a context carrying a CLIContext, as root's
+// PersistentPreRunE sets up, with RemoteWrites as given.
+func agentCtx(remoteWrites bool) context.Context {
+	return cli.WithContext(context.Background(), &cli.CLIContext{RemoteWrites: remoteWrites})
+}
+
+func TestParseAgentVersion(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    int
+		wantErr bool
+	}{
+		{out: "", want: 1},
+		{out: "  \n", want: 1},
+		{out: "2\n", want: 2},
+		{out: "17", want: 17},
+		{out: "0", wantErr: true},
+		{out: "v2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAgentVersion([]byte(tt.out))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseAgentVersion(%q) = %d, %v; want %d, err=%v", tt.out, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAgentGuard(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name         string
+		version      string
+		readErr      error
+		remoteWrites bool
+		wantErr      string
+		wantNote     string
+	}{
+		{name: "in range", version: "3\n"},
+		{
+			name:     "absent file is v1",
+			version:  "",
+			wantNote: "Note: VM agent v1 is older than this CLI expects (v2–v3); features that rely on a newer agent may be unavailable — run `mint recreate` to upgrade",
+		},
+		{
+			name:         "older still runs writes",
+			version:      "1\n",
+			remoteWrites: true,
+			wantNote:     "Note: VM agent v1 is older",
+		},
+		{
+			name:     "newer read-only proceeds with note",
+			version:  "4\n",
+			wantNote: "Note: VM agent v4 is newer than this CLI supports (v2–v3) — upgrade with `mint update`",
+		},
+		{
+			name:         "newer refuses writes",
+			version:      "4\n",
+			remoteWrites: true,
+			wantErr:      "VM agent v4 is newer than this CLI supports (v2–v3); refusing to modify files on the VM — upgrade with `mint update`",
+		},
+		{
+			name:         "unreadable version proceeds",
+			readErr:      errors.New("connection refused"),
+			remoteWrites: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner, calls := agentRunner(tt.version, tt.readErr)
+			notes := new(bytes.Buffer)
+			run := newAgentGuard(notes).remoteRunner(inner)
+
+			out, err := run(agentCtx(tt.remoteWrites), nil, "i-1", "", "1.2.3.4", 41122, "ubuntu", []string{"touch /mint/x"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if len(*calls) != 1 {
+					t.Errorf("calls = %v, want only the version read", *calls)
+				}
+				return
+			}
+			if err != nil || string(out) != "ok" {
+				t.Fatalf("run = %q, %v; want the command to run", out, err)
+			}
+			if last := (*calls)[len(*calls)-1]; last != "touch /mint/x" {
+				t.Errorf("last call = %q, want the command", last)
+			}
+			if tt.wantNote == "" && notes.Len() != 0 {
+				t.Errorf("unexpected note: %q", notes.String())
+			}
+			if !strings.Contains(notes.String(), tt.wantNote) {
+				t.Errorf("notes = %q, want %q", notes.String(), tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestAgentGuardReadsOncePerVM(t *testing.T) {
+	inner, calls := agentRunner("1\n", nil)
+	notes := new(bytes.Buffer)
+	run := newAgentGuard(notes).remoteRunner(inner)
+	ctx := agentCtx(false)
+
+	for _, id := range []string{"i-1", "i-1", "i-2"} {
+		if _, err := run(ctx, nil, id, "", "1.2.3.4", 41122, "ubuntu", []string{"true"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reads := 0
+	for _, c := range *calls {
+		if c == agentVersionReadCommand()[0] {
+			reads++
+		}
+	}
+	if reads != 2 {
+		t.Errorf("version read %d times for two VMs, want 2", reads)
+	}
+	if n := strings.Count(notes.String(), "Note:"); n != 2 {
+		t.Errorf("printed %d notes, want one per VM:\n%s", n, notes.String())
+	}
+}
+
+func TestAgentGuardStreaming(t *testing.T) {
+	var stderrs []io.Writer
+	inner := func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID, az, host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		stderrs = append(stderrs, stderr)
+		if command[0] == agentVersionReadCommand()[0] {
+			return []byte("4\n"), nil
+		}
+		return []byte("ok"), nil
+	}
+	run := newAgentGuard(io.Discard).streamingRemoteRunner(inner)
+
+	if _, err := run(agentCtx(true), nil, "i-1", "", "1.2.3.4", 41122, "ubuntu", []string{"rm -rf /mint/projects/x"}, new(bytes.Buffer)); err == nil {
+		t.Fatal("expected a refusal for a newer agent")
+	}
+	if len(stderrs) != 1 || stderrs[0] != io.Discard {
+		t.Errorf("the version read should discard stderr and the command must not run")
+	}
+}
+
+func TestRemoteWriteCommandsAreAnnotated(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		newExtendCommand(),
+		newPruneCommand(),
+		newKeyAddCommand(),
+		newProjectAddCommand(),
+		newProjectRebuildCommand(),
+	} {
+		if cmd.Annotations[cli.AnnotationRemoteWrites] != "true" {
+			t.Errorf("mint %s modifies files on the VM but is not annotated with %s", cmd.Name(), cli.AnnotationRemoteWrites)
+		}
+	}
+}
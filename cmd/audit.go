@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/logging"
+)
+
+// auditDeps holds the injectable dependencies for the audit commands.
+type auditDeps struct {
+	path string
+	now  func() time.Time
+}
+
+// auditLogPath returns where mutating AWS operations are logged when the
+// audit log is enabled.
+func auditLogPath() string {
+	return filepath.Join(config.DefaultConfigDir(), "audit.log")
+}
+
+// structuredLogDir returns where AWS API calls are logged to the structured
+// log, one JSON file per call.
+func structuredLogDir() string {
+	return filepath.Join(config.DefaultConfigDir(), "logs")
+}
+
+// auditLogEnabled reports whether mutating AWS operations are logged: the
+// audit_log config key is true, or MINT_AUDIT_LOG is set to anything but
+// 0 or false.
+func auditLogEnabled(mintCfg *config.Config) bool {
+	switch strings.ToLower(os.Getenv("MINT_AUDIT_LOG")) {
+	case "", "0", "false":
+		return mintCfg != nil && mintCfg.AuditLog
+	}
+	return true
+}
+
+// operationAuditOption opens the audit log at path and returns the SDK API
+// option that appends every mutating AWS operation to it, attributed to
+// the running command, its target VM, and callerARN. Writes are
+// best-effort so that a full disk cannot fail an AWS call that already
+// succeeded; the file stays open until the process exits.
+func operationAuditOption(path string, cliCtx *cli.CLIContext, callerARN string) (func(*middleware.Stack) error, error) {
+	auditor, err := logging.NewAuditLogger(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	var command, vmName string
+	if cliCtx != nil {
+		command, vmName = cliCtx.Command, cliCtx.VM
+	}
+	return mintaws.WithOperationAudit(func(op mintaws.AuditedOperation) {
+		_ = auditor.LogOperation(logging.Operation{
+			Command:      command,
+			VMName:       vmName,
+			CallerARN:    callerARN,
+			Service:      op.Service,
+			Name:         op.Name,
+			ResourcesIn:  op.ResourcesIn,
+			ResourcesOut: op.ResourcesOut,
+			Duration:     op.Duration,
+			Err:          op.Err,
+		})
+	}), nil
+}
+
+// newAuditCommand creates the parent audit command with subcommands.
+func newAuditCommand() *cobra.Command {
+	return newAuditCommandWithDeps(nil)
+}
+
+// newAuditCommandWithDeps creates the audit command tree with explicit
+// dependencies for testing.
+func newAuditCommandWithDeps(deps *auditDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Read the log of AWS changes mint made",
+		Long: "Read the audit log of every mutating AWS operation mint made: the AWS call, " +
+			"the command that made it, the resource IDs it named and returned, how long it took, " +
+			"whether it failed, and the caller identity.\n\n" +
+			"The log is stored at ~/.config/mint/audit.log and is off by default. " +
+			"Enable it with: mint config set audit_log true (or set MINT_AUDIT_LOG=1)",
+	}
+
+	show := &cobra.Command{
+		Use:   "show",
+		Short: "Show logged AWS operations",
+		Long: "Show the AWS operations in the audit log, oldest first. --since limits them to " +
+			"recent ones and --command to those made by one mint command, such as \"up\" or \"project add\".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps == nil {
+				deps = &auditDeps{path: auditLogPath(), now: time.Now}
+			}
+			return runAuditShow(cmd, deps)
+		},
+	}
+	show.Flags().String("since", "", "Only show operations newer than this age (e.g. 24h, 7d, 30m)")
+	show.Flags().String("command", "", "Only show operations made by this mint command (e.g. up, destroy)")
+	cmd.AddCommand(show)
+
+	return cmd
+}
+
+// runAuditShow executes the audit show command logic.
+func runAuditShow(cmd *cobra.Command, deps *auditDeps) error {
+	var since time.Time
+	if s, _ := cmd.Flags().GetString("since"); s != "" {
+		age, err := format.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = deps.now().Add(-age)
+	}
+	command, _ := cmd.Flags().GetString("command")
+
+	entries, err := logging.ReadAuditLog(deps.path)
+	if err != nil {
+		return err
+	}
+	entries = filterAuditOperations(entries, command, since)
+
+	cliCtx := cli.FromCommand(cmd)
+	if cliCtx != nil && cliCtx.JSON {
+		if entries == nil {
+			entries = []logging.AuditEntry{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	writeAuditTable(cmd.OutOrStdout(), entries)
+	return nil
+}
+
+// filterAuditOperations keeps the AWS operation entries made by command, or
+// by any command when it is empty, at or after since, unless it is zero.
+func filterAuditOperations(entries []logging.AuditEntry, command string, since time.Time) []logging.AuditEntry {
+	var out []logging.AuditEntry
+	for _, e := range entries {
+		if e.Type != logging.TypeOperation {
+			continue
+		}
+		if command != "" && e.Command != command {
+			continue
+		}
+		if !since.IsZero() {
+			ts, err := time.Parse(time.RFC3339, e.Timestamp)
+			if err != nil || ts.Before(since.Truncate(time.Second)) {
+				continue
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// writeAuditTable prints entries as an aligned table, oldest first.
+func writeAuditTable(w io.Writer, entries []logging.AuditEntry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No AWS operations logged.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMAND\tVM\tOPERATION\tRESOURCES\tDURATION\tRESULT")
+
+	for _, e := range entries {
+		when := e.Timestamp
+		if ts, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+			when = ts.Local().Format("2006-01-02 15:04:05")
+		}
+
+		command, vmName := e.Command, e.VMName
+		if command == "" {
+			command = "-"
+		}
+		if vmName == "" {
+			vmName = "-"
+		}
+
+		resources := strings.Join(e.ResourcesIn, ",")
+		if len(e.ResourcesOut) > 0 {
+			if resources != "" {
+				resources += " "
+			}
+			resources += "-> " + strings.Join(e.ResourcesOut, ",")
+		}
+		if resources == "" {
+			resources = "-"
+		}
+
+		result := "ok"
+		if e.Error != "" {
+			result = "error: " + e.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s:%s\t%s\t%s\t%s\n",
+			when, command, vmName, e.Service, e.Operation, resources,
+			time.Duration(e.DurationMs)*time.Millisecond, result)
+	}
+
+	tw.Flush()
+}
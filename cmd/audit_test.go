@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/logging"
+)
+
+func writeAuditFixture(t *testing.T, path string, entries ...logging.AuditEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runAuditCmd(t *testing.T, deps *auditDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newAuditCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"audit"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestAuditShowFilters(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	stamp := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditFixture(t, path,
+		logging.AuditEntry{Timestamp: stamp(48 * time.Hour), Type: logging.TypeOperation, Command: "up", VMName: "default",
+			Service: "ec2", Operation: "RunInstances", ResourcesIn: []string{"sg-1"}, ResourcesOut: []string{"i-old"}, DurationMs: 900},
+		logging.AuditEntry{Timestamp: stamp(2 * time.Hour), Type: "command", Command: "destroy", VMName: "default"},
+		logging.AuditEntry{Timestamp: stamp(2 * time.Hour), Type: logging.TypeOperation, Command: "destroy", VMName: "default",
+			Service: "ec2", Operation: "TerminateInstances", ResourcesIn: []string{"i-gone"}, DurationMs: 250},
+		logging.AuditEntry{Timestamp: stamp(time.Hour), Type: logging.TypeOperation, Command: "down", VMName: "staging",
+			Service: "ec2", Operation: "StopInstances", ResourcesIn: []string{"i-staging"}, Error: "UnauthorizedOperation"},
+	)
+	deps := &auditDeps{path: path, now: func() time.Time { return now }}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{"all", nil, []string{"ec2:RunInstances", "sg-1 -> i-old", "900ms", "ec2:TerminateInstances", "error: UnauthorizedOperation"}, nil},
+		{"since", []string{"--since", "24h"}, []string{"i-gone", "i-staging"}, []string{"i-old"}},
+		{"command", []string{"--command", "destroy"}, []string{"i-gone"}, []string{"i-old", "i-staging"}},
+		{"nothing", []string{"--command", "gc"}, []string{"No AWS operations logged."}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runAuditCmd(t, deps, append([]string{"show"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output missing %q:\n%s", w, out)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(out, nw) {
+					t.Errorf("output should not contain %q:\n%s", nw, out)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditShowJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditFixture(t, path,
+		logging.AuditEntry{Timestamp: time.Now().UTC().Format(time.RFC3339), Type: logging.TypeOperation, Command: "up",
+			Service: "ec2", Operation: "CreateVolume", ResourcesOut: []string{"vol-1"}, CallerARN: "arn:aws:iam::123456789012:user/alice"},
+	)
+	out, err := runAuditCmd(t, &auditDeps{path: path, now: time.Now}, "show", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var entries []logging.AuditEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, out)
+	}
+	if len(entries) != 1 || entries[0].Operation != "CreateVolume" || entries[0].CallerARN == "" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	// A missing log is an empty array, not null.
+	out, err = runAuditCmd(t, &auditDeps{path: filepath.Join(t.TempDir(), "none.log"), now: time.Now}, "show", "--json")
+	if err != nil || strings.TrimSpace(out) != "[]" {
+		t.Errorf("missing log = %q, %v, want []", out, err)
+	}
+}
+
+func TestAuditShowRejectsBadSince(t *testing.T) {
+	_, err := runAuditCmd(t, &auditDeps{path: filepath.Join(t.TempDir(), "audit.log"), now: time.Now}, "show", "--since", "soon")
+	if err == nil || !strings.Contains(err.Error(), "invalid --since") {
+		t.Errorf("error = %v, want invalid --since", err)
+	}
+}
+
+func TestAuditLogEnabled(t *testing.T) {
+	on := &config.Config{AuditLog: true}
+	off := &config.Config{}
+	tests := []struct {
+		env  string
+		cfg  *config.Config
+		want bool
+	}{
+		{"", off, false},
+		{"", on, true},
+		{"1", off, true},
+		{"true", off, true},
+		{"0", off, false},
+		{"false", on, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("MINT_AUDIT_LOG", tt.env)
+		if got := auditLogEnabled(tt.cfg); got != tt.want {
+			t.Errorf("MINT_AUDIT_LOG=%q audit_log=%v: enabled = %v, want %v", tt.env, tt.cfg.AuditLog, got, tt.want)
+		}
+	}
+}
@@ -6,41 +6,86 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
 	"github.com/aws/aws-sdk-go-v2/service/efs"
 	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 	"github.com/spf13/cobra"
 
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/aws/retry"
+	"github.com/SpiceLabsHQ/Mint/internal/aws/tunnel"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/identity"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/sshmux"
 )
 
 // awsClients holds pre-initialized AWS SDK clients and resolved identity.
 // Created once in PersistentPreRunE and stored on the command context.
 type awsClients struct {
-	ec2Client      *ec2.Client
+	ec2Client      *retry.EC2
 	icClient       *ec2instanceconnect.Client
 	efsClient      *efs.Client
+	cwClient       *cloudwatch.Client
 	cfnClient      *cloudformation.Client
 	ssoAdminClient *ssoadmin.Client
 	owner          string // resolved owner name (mint:owner tag value)
 	ownerARN       string // resolved owner ARN (mint:owner-arn tag value)
 	region         string // resolved AWS region from SDK config chain
 
+	// apiRetry retries throttled and transiently failing EC2 calls made
+	// through ec2Client, api_retry_attempts times in all.
+	apiRetry *retry.Policy
+
+	// sendKey pushes Instance Connect keys through a coordinator shared by
+	// the whole command, so repeated and concurrent remote calls to one VM
+	// reuse a recent push instead of each calling SendSSHPublicKey.
+	sendKey mintaws.SendSSHPublicKeyAPI
+
 	// mintConfig holds the loaded user preferences for instance type,
 	// volume size, idle timeout, etc.
 	mintConfig *config.Config
+
+	// sshRouter carries remote commands over an Instance Connect Endpoint
+	// tunnel for VMs without a public IP. Nil means direct SSH only.
+	sshRouter *sshRouter
+
+	// agentNotes receives the VM agent version notes printed by the remote
+	// runners. Nil means stderr.
+	agentNotes io.Writer
+
+	// cfg is the base SDK config the clients above were built from. Admin
+	// commands derive a separate config from it when assuming an admin
+	// role, leaving these clients on the base credentials.
+	cfg aws.Config
+
+	// assumeRole obtains admin role credentials with the base credentials.
+	assumeRole mintaws.AssumeRoleAPI
+
+	// sshOptions are the user's SSH settings, applied to every ssh mint
+	// runs: the ssh_* config keys plus any --ssh-arg flags.
+	sshOptions sshconfig.Options
+
+	// sshMux shares one SSH connection per VM between the command's remote
+	// commands. Nil (--no-ssh-mux) connects per command. The root command
+	// closes it when the command ends.
+	sshMux *sshmux.Pool
 }
 
 // awsClientsKey is the context key for storing awsClients.
@@ -91,9 +136,11 @@ var ssoReAuthKeywords = []string{
 }
 
 // isSSOReAuthError reports whether err looks like an SSO token expiry that
-// requires the user to run `aws sso login`. Returns false for nil.
+// requires the user to run `aws sso login`. Returns false for nil, and for
+// a role_arn that cannot be assumed, which surfaces as a failed credential
+// refresh too.
 func isSSOReAuthError(err error) bool {
-	if err == nil {
+	if err == nil || isAssumeRoleDenied(err) {
 		return false
 	}
 	msg := err.Error()
@@ -105,17 +152,70 @@ func isSSOReAuthError(err error) bool {
 	return false
 }
 
+// isAssumeRoleDenied reports whether err is STS refusing to let the base
+// credentials assume role_arn. The refusal is nested inside the operation
+// error of the call whose credential refresh tried the AssumeRole.
+func isAssumeRoleDenied(err error) bool {
+	for err != nil {
+		var oe *smithy.OperationError
+		if !errors.As(err, &oe) {
+			return false
+		}
+		if oe.Operation() == "AssumeRole" {
+			var ae smithy.APIError
+			return errors.As(oe.Err, &ae) && ae.ErrorCode() == "AccessDenied"
+		}
+		err = oe.Err
+	}
+	return false
+}
+
 // credentialErrMessage returns an actionable error message for AWS credential
-// failures. When the error is an SSO token expiry and a profile is known, it
-// directs the user to run `aws sso login --profile <profile>`. Otherwise it
-// returns the generic credential setup message.
+// failures. A role_arn that cannot be assumed is pointed at the role. When
+// the error is an SSO token expiry and a profile is known, it directs the
+// user to run `aws sso login --profile <profile>`. Otherwise it returns the
+// generic credential setup message.
 func credentialErrMessage(err error, profile string) string {
+	var ae smithy.APIError
+	if isAssumeRoleDenied(err) && errors.As(err, &ae) {
+		return fmt.Sprintf("not allowed to assume the role in role_arn (%s) — check role_arn or --role-arn, "+
+			"that the role's trust policy allows sts:AssumeRole for your identity, and external_id if the role requires one",
+			ae.ErrorMessage())
+	}
 	if isSSOReAuthError(err) && profile != "" {
 		return fmt.Sprintf("SSO token expired — run %s", hint.Cmd("aws sso login --profile "+profile))
 	}
 	return fmt.Sprintf("AWS credentials unavailable — run %s, set AWS_PROFILE, or use --profile", hint.Cmd("aws configure"))
 }
 
+// localCommands are the commands that skip AWS client initialization. Keys
+// are command paths without the root command name, so that a subcommand
+// such as "snapshot restore" is not mistaken for "config restore".
+var localCommands = map[string]bool{
+	"version":         true,
+	"config":          true,
+	"config get":      true,
+	"config set":      true,
+	"config restore":  true,
+	"config validate": true,
+	"help":            true,
+	"update":          true,
+	"history":         true,
+	"export-state":    true,
+	"import-state":    true,
+	// doctor initializes its own AWS clients so it can report credential
+	// failures as a check result rather than a fatal startup error.
+	"doctor": true,
+	// ssh-config initializes its own AWS clients only when auto-discovery
+	// is needed (no --hostname/--instance-id/--az flags). Explicit-flag
+	// and --remove invocations do not need AWS at all.
+	"ssh-config": true,
+	// Shell completion must stay fast and quiet; the completions that
+	// need AWS, such as mint code <TAB>, initialize it themselves.
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
+
 // commandNeedsAWS returns true if the command requires AWS client
 // initialization. Commands that operate locally (version, config, ssh-config,
 // completion, help) return false.
@@ -127,19 +227,15 @@ func commandNeedsAWS(cmd *cobra.Command) bool {
 	if strings.Contains(path, " completion") {
 		return false
 	}
-	switch cmd.Name() {
-	case "version", "config", "set", "get", "help", "update",
-		// doctor initializes its own AWS clients so it can report credential
-		// failures as a check result rather than a fatal startup error.
-		"doctor",
-		// ssh-config initializes its own AWS clients only when auto-discovery
-		// is needed (no --hostname/--instance-id/--az flags). Explicit-flag
-		// and --remove invocations do not need AWS at all.
-		"ssh-config":
+	// The hostkey subcommands only touch the local host key store.
+	if strings.Contains(path, " hostkey") {
+		return false
+	}
+	// The audit subcommands only read the local audit log.
+	if strings.Contains(path, " audit") {
 		return false
-	default:
-		return true
 	}
+	return !localCommands[strings.TrimPrefix(path, cmd.Root().Name()+" ")]
 }
 
 // initAWSClients loads the AWS SDK config, creates all SDK clients,
@@ -149,6 +245,9 @@ func initAWSClients(ctx context.Context) (*awsClients, error) {
 	var opts []func(*awscfg.LoadOptions) error
 
 	cliCtx := cli.FromContext(ctx)
+	if cliCtx != nil && cliCtx.Offline {
+		return nil, &networkError{offline: true}
+	}
 
 	// ADR-0012: Wire --debug flag to AWS SDK request/response logging.
 	if cliCtx != nil && cliCtx.Debug {
@@ -160,7 +259,7 @@ func initAWSClients(ctx context.Context) (*awsClients, error) {
 	// Load mint user preferences early so we can wire the profile and region
 	// before calling LoadDefaultConfig. This ensures the SDK uses the
 	// mint-configured values when no environment variables are set.
-	mintCfg, err := config.Load(config.DefaultConfigDir())
+	mintCfg, err := loadConfig(ctx, config.DefaultConfigDir())
 	if err != nil {
 		return nil, fmt.Errorf("load mint config: %w", err)
 	}
@@ -193,6 +292,12 @@ func initAWSClients(ctx context.Context) (*awsClients, error) {
 		return nil, fmt.Errorf("load AWS config: %w", err)
 	}
 
+	// Fail fast when AWS cannot be reached, before the first API call would
+	// wait out the SDK's retries.
+	if err := probeAWS(ctx, cfg); err != nil {
+		return nil, err
+	}
+
 	// Resolve owner identity (ADR-0013).
 	stsClient := sts.NewFromConfig(cfg)
 	resolver := identity.NewResolver(stsClient)
@@ -201,19 +306,182 @@ func initAWSClients(ctx context.Context) (*awsClients, error) {
 		return nil, fmt.Errorf("resolve identity: %w", err)
 	}
 
+	// With role_arn, every client below signs with the role's credentials
+	// and the owner is the role session's.
+	if roleARN := effectiveRoleARN(cliCtx, mintCfg); roleARN != "" {
+		cfg, owner, err = assumeConfiguredRole(ctx, cfg, stsClient, roleARN, mintCfg.ExternalID, owner.Name)
+		if err != nil {
+			return nil, err
+		}
+		stsClient = sts.NewFromConfig(cfg)
+	}
+
+	sshOptions, err := resolveSSHOptions(mintCfg, cliCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log mutating AWS operations from every client built below. The
+	// identity lookup above is read-only and stays out of it.
+	if auditLogEnabled(mintCfg) {
+		auditOption, err := operationAuditOption(auditLogPath(), cliCtx, owner.ARN)
+		if err != nil {
+			return nil, err
+		}
+		cfg.APIOptions = append(cfg.APIOptions, auditOption)
+	}
+
+	apiRetry := retry.NewPolicy(mintCfg.APIRetryAttempts)
+	ec2Client := retry.NewEC2(cfg, apiRetry)
+	icClient := ec2instanceconnect.NewFromConfig(cfg)
+
 	return &awsClients{
-		ec2Client:      ec2.NewFromConfig(cfg),
-		icClient:       ec2instanceconnect.NewFromConfig(cfg),
+		ec2Client:      ec2Client,
+		icClient:       icClient,
+		apiRetry:       apiRetry,
+		sendKey:        mintaws.NewKeyPushCoordinator(icClient),
 		efsClient:      efs.NewFromConfig(cfg),
+		cwClient:       cloudwatch.NewFromConfig(cfg),
 		cfnClient:      cloudformation.NewFromConfig(cfg),
 		ssoAdminClient: ssoadmin.NewFromConfig(cfg),
 		owner:          owner.Name,
 		ownerARN:       owner.ARN,
 		region:         cfg.Region,
 		mintConfig:     mintCfg,
+		sshRouter:      newSSHRouter(ec2Client, ec2Client, tunnel.NewWebSocketDialer(cfg)),
+		sshOptions:     sshOptions,
+		sshMux:         newSSHMuxPool(cliCtx),
+		cfg:            cfg,
+		assumeRole:     stsClient,
 	}, nil
 }
 
+// effectiveRoleARN returns the role every AWS call is made as: --role-arn,
+// else the role_arn config setting. Empty means the base credentials.
+func effectiveRoleARN(cliCtx *cli.CLIContext, mintCfg *config.Config) string {
+	if cliCtx != nil && cliCtx.RoleARN != "" {
+		return cliCtx.RoleARN
+	}
+	if mintCfg != nil {
+		return mintCfg.RoleARN
+	}
+	return ""
+}
+
+// assumeConfiguredRole returns a copy of base that signs with roleARN's
+// credentials, refreshed as they expire, and the owner of the role session.
+// The session is named for baseOwner (see identity.RoleSessionName) so the
+// owner stays the same as with the base credentials.
+func assumeConfiguredRole(ctx context.Context, base aws.Config, api stscreds.AssumeRoleAPIClient, roleARN, externalID, baseOwner string) (aws.Config, *identity.Owner, error) {
+	if err := config.ValidateRoleARN(roleARN); err != nil {
+		return aws.Config{}, nil, fmt.Errorf("role_arn: %w", err)
+	}
+	provider := stscreds.NewAssumeRoleProvider(api, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = identity.RoleSessionName(baseOwner, time.Now())
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	cfg := base.Copy()
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+
+	owner, err := identity.NewResolver(sts.NewFromConfig(cfg)).Resolve(ctx)
+	if err != nil {
+		return aws.Config{}, nil, fmt.Errorf("assume role %s: %w", roleARN, err)
+	}
+	return cfg, owner, nil
+}
+
+// resolveSSHOptions combines the ssh_* config keys with --ssh-arg flags,
+// which are appended after the configured extra arguments.
+func resolveSSHOptions(mintCfg *config.Config, cliCtx *cli.CLIContext) (sshconfig.Options, error) {
+	opts := sshconfig.Options{
+		User:            mintCfg.SSHUser,
+		Port:            mintCfg.SSHPort,
+		ExtraArgs:       append([]string(nil), mintCfg.SSHExtraArgs...),
+		IdentityFile:    mintCfg.SSHIdentityFile,
+		CertificateFile: mintCfg.SSHCertificateFile,
+	}
+	if cliCtx != nil {
+		if err := sshconfig.ValidateExtraArgs(cliCtx.SSHArgs); err != nil {
+			return sshconfig.Options{}, fmt.Errorf("invalid --ssh-arg: %w", err)
+		}
+		opts.ExtraArgs = append(opts.ExtraArgs, cliCtx.SSHArgs...)
+	}
+	if err := sshconfig.ValidateExtraArgs(opts.ExtraArgs); err != nil {
+		return sshconfig.Options{}, fmt.Errorf("invalid ssh_extra_args — fix it with %s: %w", hint.Cmd("mint config set ssh_extra_args"), err)
+	}
+	if cliCtx != nil {
+		forwards, err := vmForwards(mintCfg, cliCtx.VM)
+		if err != nil {
+			return sshconfig.Options{}, err
+		}
+		opts.Forwards = forwards
+	}
+	return opts, nil
+}
+
+// vmForwards returns the port forwards configured for vmName: its
+// [vm.<name>] table's forwards, or the top-level ones. A table ForVM
+// rejects is reported by the commands that provision from it, so its
+// forwards fall back to the top-level ones here.
+func vmForwards(mintCfg *config.Config, vmName string) ([]sshconfig.Forward, error) {
+	specs := mintCfg.Forwards
+	if vmCfg, err := mintCfg.ForVM(vmName); err == nil {
+		specs = vmCfg.Forwards
+	}
+	forwards, err := sshconfig.ParseForwards(specs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid forwards — fix it with %s: %w", hint.Cmd("mint config set forwards"), err)
+	}
+	return forwards, nil
+}
+
+// remoteRunner returns the production RemoteCommandRunner. VMs without a
+// public IP are reached through an Instance Connect Endpoint tunnel, and
+// each VM's agent version is checked before the first command (see
+// agentGuard).
+func (c *awsClients) remoteRunner() RemoteCommandRunner {
+	return newAgentGuard(c.agentNotes).remoteRunner(c.uncheckedRemoteRunner())
+}
+
+// uncheckedRemoteRunner is remoteRunner without the agent version check,
+// for commands that report the version themselves (status, doctor) or
+// replace the agent (recreate).
+func (c *awsClients) uncheckedRemoteRunner() RemoteCommandRunner {
+	runner := remoteRunnerWithOptions(c.sshOptions)
+	if c.sshMux != nil {
+		runner = pooledRemoteRunner(c.sshMux, c.sshOptions)
+	}
+	direct := diagnoseSSHAuth(runner, c.ec2Client, c.sshAuthProber())
+	if c.sshRouter == nil {
+		return direct
+	}
+	return c.sshRouter.remoteRunner(direct)
+}
+
+// sshAuthProber returns the prober that explains refused logins. The
+// diagnosis wraps the direct runner, so routed VMs are probed through the
+// same tunnel as the command that failed.
+func (c *awsClients) sshAuthProber() sshAuthProber {
+	var dialer net.Dialer
+	return newSSHAuthProber(dialer.DialContext)
+}
+
+// streamingRemoteRunner is the StreamingRemoteRunner counterpart of
+// remoteRunner.
+func (c *awsClients) streamingRemoteRunner() StreamingRemoteRunner {
+	runner := streamingRemoteRunnerWithOptions(c.sshOptions)
+	if c.sshMux != nil {
+		runner = pooledStreamingRemoteRunner(c.sshMux, c.sshOptions)
+	}
+	direct := diagnoseStreamingSSHAuth(runner, c.ec2Client, c.sshAuthProber())
+	if c.sshRouter != nil {
+		direct = c.sshRouter.streamingRemoteRunner(direct)
+	}
+	return newAgentGuard(c.agentNotes).streamingRemoteRunner(direct)
+}
+
 // idleTimeout returns the configured idle timeout as a time.Duration.
 func (c *awsClients) idleTimeout() time.Duration {
 	if c.mintConfig == nil {
@@ -221,3 +489,12 @@ func (c *awsClients) idleTimeout() time.Duration {
 	}
 	return time.Duration(c.mintConfig.IdleTimeoutMinutes) * time.Minute
 }
+
+// releaseEIPAfterStoppedDays returns release_eip_after_stopped_days, or 0
+// (disabled) when no config is loaded.
+func (c *awsClients) releaseEIPAfterStoppedDays() int {
+	if c.mintConfig == nil {
+		return 0
+	}
+	return c.mintConfig.ReleaseEIPAfterStoppedDays
+}
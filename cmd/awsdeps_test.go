@@ -3,15 +3,19 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/smithy-go"
 	"github.com/spf13/cobra"
 
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 )
 
 // fakeCmd builds a *cobra.Command with the given Use (name) attached to the
@@ -43,19 +47,29 @@ func TestCommandNeedsAWS(t *testing.T) {
 	}{
 		{"version does not need AWS", fakeCmd("version"), false},
 		{"config does not need AWS", fakeCmd("config"), false},
-		{"set does not need AWS", fakeCmd("set"), false},
-		{"get does not need AWS", fakeCmd("get"), false},
+		{"config set does not need AWS", fakeSubCmd("config", "set"), false},
+		{"config get does not need AWS", fakeSubCmd("config", "get"), false},
+		{"config restore does not need AWS", fakeSubCmd("config", "restore"), false},
+		{"config validate does not need AWS", fakeSubCmd("config", "validate"), false},
+		{"history does not need AWS", fakeCmd("history"), false},
 		{"ssh-config does not need AWS", fakeCmd("ssh-config"), false},
 		{"help does not need AWS", fakeCmd("help"), false},
 		// doctor initialises its own AWS clients so it can report credential
 		// failures as a check result rather than a fatal PersistentPreRunE error.
 		{"doctor does not need AWS", fakeCmd("doctor"), false},
+		{"export-state does not need AWS", fakeCmd("export-state"), false},
+		{"import-state does not need AWS", fakeCmd("import-state"), false},
+		{"hostkey export does not need AWS", fakeSubCmd("hostkey", "export"), false},
+		{"hostkey reset does not need AWS", fakeSubCmd("hostkey", "reset"), false},
 		// completion and its shell subcommands are local-only.
 		{"completion does not need AWS", fakeCmd("completion"), false},
 		{"completion bash does not need AWS", fakeSubCmd("completion", "bash"), false},
 		{"completion zsh does not need AWS", fakeSubCmd("completion", "zsh"), false},
 		{"completion fish does not need AWS", fakeSubCmd("completion", "fish"), false},
 		{"completion powershell does not need AWS", fakeSubCmd("completion", "powershell"), false},
+		// __complete initializes AWS only for completions that need it.
+		{"__complete does not need AWS", fakeCmd(cobra.ShellCompRequestCmd), false},
+		{"__completeNoDesc does not need AWS", fakeCmd(cobra.ShellCompNoDescRequestCmd), false},
 		{"up needs AWS", fakeCmd("up"), true},
 		{"down needs AWS", fakeCmd("down"), true},
 		{"destroy needs AWS", fakeCmd("destroy"), true},
@@ -64,6 +78,11 @@ func TestCommandNeedsAWS(t *testing.T) {
 		{"list needs AWS", fakeCmd("list"), true},
 		{"status needs AWS", fakeCmd("status"), true},
 		{"init needs AWS", fakeCmd("init"), true},
+		// Subcommands that share a name with a local command still need AWS.
+		{"snapshot restore needs AWS", fakeSubCmd("snapshot", "restore"), true},
+		{"vm history needs AWS", fakeSubCmd("vm", "history"), true},
+		{"guard set needs AWS", fakeSubCmd("guard", "set"), true},
+		{"git-identity set needs AWS", fakeSubCmd("git-identity", "set"), true},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +95,34 @@ func TestCommandNeedsAWS(t *testing.T) {
 	}
 }
 
+// TestRootCommandInitializesAWSBySubcommandPath runs the real command tree
+// with --offline, which fails AWS initialization straight away: a command
+// that reaches it reports --offline instead of missing AWS clients.
+func TestRootCommandInitializesAWSBySubcommandPath(t *testing.T) {
+	offlineEnv(t)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"snapshot restore", []string{"snapshot", "restore", "snap-0123456789abcdef0"}},
+		{"vm history", []string{"vm", "history"}},
+		{"guard set", []string{"guard", "set", "deploy", "--reason", "release", "--expires", "6h"}},
+		{"git-identity set", []string{"git-identity", "set", "work", "--user", "Jane Doe", "--email", "jane@acme.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewRootCommand()
+			root.SetOut(io.Discard)
+			root.SetErr(io.Discard)
+			root.SetArgs(append([]string{"--offline"}, tt.args...))
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), "cannot run with --offline") {
+				t.Errorf("error = %v, want AWS initialization to refuse --offline", err)
+			}
+		})
+	}
+}
+
 func TestAWSClientsFromContext_Nil(t *testing.T) {
 	ctx := context.Background()
 	clients := awsClientsFromContext(ctx)
@@ -118,7 +165,6 @@ func TestAWSClientsHasEFSClient(t *testing.T) {
 	}
 }
 
-
 func TestInitAWSClientsDebugMode(t *testing.T) {
 	// Verify that initAWSClients does not panic or error when the debug
 	// flag is set on the CLIContext. We cannot easily inspect the resulting
@@ -350,6 +396,19 @@ func TestAWSClients_IdleTimeout(t *testing.T) {
 	})
 }
 
+// assumeRoleDeniedError is the error initAWSClients returns when the base
+// credentials may not assume role_arn: the AssumeRole refusal nested in the
+// GetCallerIdentity call whose credential refresh attempted it.
+func assumeRoleDeniedError() error {
+	denied := &smithy.OperationError{ServiceID: "STS", OperationName: "AssumeRole", Err: &smithy.GenericAPIError{
+		Code:    "AccessDenied",
+		Message: "User: arn:aws:iam::123456789012:user/ryan is not authorized to perform: sts:AssumeRole",
+	}}
+	return fmt.Errorf("assume role arn:aws:iam::123456789012:role/ProjectDev: sts get-caller-identity: %w",
+		&smithy.OperationError{ServiceID: "STS", OperationName: "GetCallerIdentity",
+			Err: fmt.Errorf("failed to refresh cached credentials, %w", denied)})
+}
+
 func TestIsSSOReAuthError(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -386,6 +445,11 @@ func TestIsSSOReAuthError(t *testing.T) {
 			err:     fmt.Errorf("Error loading SSO Token: open /home/user/.aws/sso/cache/abc.json: no such file"),
 			wantSSO: true,
 		},
+		{
+			name:    "role_arn that cannot be assumed returns false",
+			err:     assumeRoleDeniedError(),
+			wantSSO: false,
+		},
 		{
 			name:    "generic no-creds error returns false",
 			err:     fmt.Errorf("NoCredentialProviders: no valid providers in chain"),
@@ -442,28 +506,34 @@ func TestCredentialErrMessage(t *testing.T) {
 			wantContain: "`aws sso login --profile prod`",
 		},
 		{
-			name:        "SSO error without profile returns generic message",
-			err:         fmt.Errorf("token has expired"),
-			profile:     "",
-			wantExact:   genericMsg,
+			name:      "SSO error without profile returns generic message",
+			err:       fmt.Errorf("token has expired"),
+			profile:   "",
+			wantExact: genericMsg,
 		},
 		{
-			name:        "non-SSO error with profile returns generic message",
-			err:         fmt.Errorf("NoCredentialProviders: no valid providers"),
-			profile:     "some-profile",
-			wantExact:   genericMsg,
+			name:      "non-SSO error with profile returns generic message",
+			err:       fmt.Errorf("NoCredentialProviders: no valid providers"),
+			profile:   "some-profile",
+			wantExact: genericMsg,
 		},
 		{
-			name:        "non-SSO error without profile returns generic message",
-			err:         fmt.Errorf("get credentials: no providers"),
-			profile:     "",
-			wantExact:   genericMsg,
+			name:      "non-SSO error without profile returns generic message",
+			err:       fmt.Errorf("get credentials: no providers"),
+			profile:   "",
+			wantExact: genericMsg,
 		},
 		{
-			name:        "nil error returns generic message",
-			err:         nil,
-			profile:     "any-profile",
-			wantExact:   genericMsg,
+			name:        "role_arn that cannot be assumed points at the role",
+			err:         assumeRoleDeniedError(),
+			profile:     "my-dev",
+			wantContain: "not allowed to assume the role in role_arn (User: arn:aws:iam::123456789012:user/ryan is not authorized to perform: sts:AssumeRole) — check role_arn",
+		},
+		{
+			name:      "nil error returns generic message",
+			err:       nil,
+			profile:   "any-profile",
+			wantExact: genericMsg,
 		},
 	}
 
@@ -479,3 +549,61 @@ func TestCredentialErrMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveSSHOptions(t *testing.T) {
+	cfg := &config.Config{
+		SSHExtraArgs:       []string{"-o", "ProxyJump=bastion.corp"},
+		SSHIdentityFile:    "~/.ssh/corp",
+		SSHCertificateFile: "~/.ssh/corp-cert.pub",
+	}
+
+	opts, err := resolveSSHOptions(cfg, &cli.CLIContext{SSHArgs: []string{"-A"}})
+	if err != nil {
+		t.Fatalf("resolveSSHOptions() error: %v", err)
+	}
+	if got := strings.Join(opts.ExtraArgs, " "); got != "-o ProxyJump=bastion.corp -A" {
+		t.Errorf("ExtraArgs = %q, want config args then --ssh-arg", got)
+	}
+	if opts.IdentityFile != "~/.ssh/corp" || opts.CertificateFile != "~/.ssh/corp-cert.pub" {
+		t.Errorf("files = %q, %q", opts.IdentityFile, opts.CertificateFile)
+	}
+	if len(cfg.SSHExtraArgs) != 2 {
+		t.Errorf("config args modified: %q", cfg.SSHExtraArgs)
+	}
+
+	if _, err := resolveSSHOptions(cfg, &cli.CLIContext{SSHArgs: []string{"-p", "22"}}); err == nil || !strings.Contains(err.Error(), "--ssh-arg") {
+		t.Errorf("conflicting --ssh-arg error = %v", err)
+	}
+	bad := &config.Config{SSHExtraArgs: []string{"-o", "Port=22"}}
+	if _, err := resolveSSHOptions(bad, nil); err == nil || !strings.Contains(err.Error(), "ssh_extra_args") {
+		t.Errorf("conflicting ssh_extra_args error = %v", err)
+	}
+}
+
+func TestResolveSSHOptionsForwards(t *testing.T) {
+	cfg := &config.Config{
+		Forwards:    []string{"8080:80"},
+		VMOverrides: map[string]map[string]string{"web": {"forwards": "3000 5432"}},
+	}
+	tests := []struct {
+		vm   string
+		want []sshconfig.Forward
+	}{
+		{"web", []sshconfig.Forward{{Local: 3000, Remote: 3000}, {Local: 5432, Remote: 5432}}},
+		{"default", []sshconfig.Forward{{Local: 8080, Remote: 80}}},
+	}
+	for _, tt := range tests {
+		opts, err := resolveSSHOptions(cfg, &cli.CLIContext{VM: tt.vm})
+		if err != nil {
+			t.Fatalf("resolveSSHOptions(%s) error: %v", tt.vm, err)
+		}
+		if !slices.Equal(opts.Forwards, tt.want) {
+			t.Errorf("%s forwards = %+v, want %+v", tt.vm, opts.Forwards, tt.want)
+		}
+	}
+
+	bad := &config.Config{Forwards: []string{"99999"}}
+	if _, err := resolveSSHOptions(bad, &cli.CLIContext{VM: "default"}); err == nil || !strings.Contains(err.Error(), "invalid forwards") {
+		t.Errorf("bad forwards error = %v", err)
+	}
+}
@@ -1,33 +1,122 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 )
 
 // printBootstrapFailureHint prints the enriched recovery block for a bootstrap
 // failure. It is shared between the up and recreate output paths so both
-// commands produce consistent guidance.
+// commands produce consistent guidance. A timeout gets the block from
+// printBootstrapTimeoutHint instead, since the VM may still be bootstrapping.
 //
 // Output format (non-TTY):
 //
 //	Bootstrap failed — instance is still running for investigation
 //	  Error:  <error message>
+//	  Failed step:  <step>
 //	  SSH:  `ssh -p 41122 ubuntu@<IP>`
-//	  Logs:  `sudo journalctl -u mint-bootstrap --no-pager`
+//	  Logs:  `mint logs`
 //	  Recover:  `mint recreate`  (rebuild from scratch)
 //	  Cleanup:  `mint destroy`  (tear down completely)
 //
-// When publicIP is empty the SSH line is omitted gracefully.
-func printBootstrapFailureHint(w io.Writer, bootstrapErr error, publicIP string) {
+// The SSH line uses port, the resolved ssh_port. The Logs line uses mint logs,
+// which also reaches a VM without a public IP. When publicIP is empty the
+// SSH line is omitted gracefully, and the failed step line is omitted when
+// bootstrap.sh did not record one.
+func printBootstrapFailureHint(w io.Writer, bootstrapErr error, publicIP string, port int) {
+	var timeoutErr *provision.BootstrapTimeoutError
+	if errors.As(bootstrapErr, &timeoutErr) {
+		printBootstrapTimeoutHint(w, timeoutErr, publicIP, port)
+		return
+	}
+
 	fmt.Fprintf(w, "\nBootstrap failed — instance is still running for investigation\n")
 	fmt.Fprintf(w, "  Error:  %v\n", bootstrapErr)
+	var failedErr *provision.BootstrapFailedError
+	if errors.As(bootstrapErr, &failedErr) && failedErr.Step != "" {
+		fmt.Fprintf(w, "  Failed step:  %s\n", failedErr.Step)
+	}
 	if publicIP != "" {
-		fmt.Fprintln(w, hint.Suggest("SSH", fmt.Sprintf("ssh -p %d %s@%s", defaultSSHPort, defaultSSHUser, publicIP)))
+		fmt.Fprintln(w, hint.Suggest("SSH", fmt.Sprintf("ssh -p %d %s@%s", port, defaultSSHUser, publicIP)))
 	}
-	fmt.Fprintln(w, hint.Suggest("Logs", "sudo journalctl -u mint-bootstrap --no-pager"))
+	fmt.Fprintln(w, hint.Suggest("Logs", "mint logs"))
 	fmt.Fprintf(w, "%s  (rebuild from scratch)\n", hint.Suggest("Recover", "mint recreate"))
 	fmt.Fprintf(w, "%s  (tear down completely)\n", hint.Suggest("Cleanup", "mint destroy"))
 }
+
+// printBootstrapTimeoutHint prints the block shown when bootstrap polling
+// timed out. The script may still be running, so it points at mint status
+// --watch rather than offering to rebuild.
+//
+// Output format (non-TTY):
+//
+//	Bootstrap did not finish in time — the VM may still be bootstrapping
+//	  Error:  <error message>
+//	  Watch:  `mint status --watch`
+//	  SSH:  `ssh -p 41122 ubuntu@<IP>`
+//	  Logs:  `mint logs --follow`
+func printBootstrapTimeoutHint(w io.Writer, timeoutErr *provision.BootstrapTimeoutError, publicIP string, port int) {
+	fmt.Fprintf(w, "\nBootstrap did not finish in time — the VM may still be bootstrapping\n")
+	fmt.Fprintf(w, "  Error:  %v\n", timeoutErr)
+	fmt.Fprintln(w, hint.Suggest("Watch", "mint status --watch"))
+	if publicIP != "" {
+		fmt.Fprintln(w, hint.Suggest("SSH", fmt.Sprintf("ssh -p %d %s@%s", port, defaultSSHUser, publicIP)))
+	}
+	fmt.Fprintln(w, hint.Suggest("Logs", "mint logs --follow"))
+}
+
+// Values of the failure_reason JSON field.
+const (
+	failureReasonBootstrapFailed  = "bootstrap_failed"
+	failureReasonBootstrapTimeout = "bootstrap_timeout"
+)
+
+// bootstrapFailureReason returns the failure_reason JSON value for a
+// bootstrap error, and the failing step when bootstrap.sh recorded one. The
+// reason is empty for errors that are neither a failure nor a timeout
+// reported by the poller.
+func bootstrapFailureReason(err error) (reason, step string) {
+	var failedErr *provision.BootstrapFailedError
+	switch {
+	case errors.As(err, &failedErr):
+		return failureReasonBootstrapFailed, failedErr.Step
+	case errors.Is(err, provision.ErrBootstrapTimeout):
+		return failureReasonBootstrapTimeout, ""
+	}
+	return "", ""
+}
+
+// printUserBootstrapWarning prints the warning block shown when core
+// bootstrap completed but the user-bootstrap.sh hook exited non-zero. The VM
+// is usable, so unlike printBootstrapFailureHint this offers no recovery or
+// cleanup commands.
+//
+// Output format (non-TTY):
+//
+//	Warning: core bootstrap: complete, user hook: failed (exit 3)
+//	  SSH:  `ssh -p 41122 ubuntu@<IP>`
+//	  Logs:  `mint logs`
+func printUserBootstrapWarning(w io.Writer, exitCode int, publicIP string, port int) {
+	status := tags.UserBootstrapStatus{Ran: true, Failed: true, ExitCode: exitCode}
+	fmt.Fprintf(w, "\nWarning: core bootstrap: complete, user hook: %s\n", status)
+	if publicIP != "" {
+		fmt.Fprintln(w, hint.Suggest("SSH", fmt.Sprintf("ssh -p %d %s@%s", port, defaultSSHUser, publicIP)))
+	}
+	fmt.Fprintln(w, hint.Suggest("Logs", "mint logs"))
+}
+
+// userBootstrapExitCode extracts the hook exit code from an error returned by
+// the bootstrap poller. It returns false when err is not a user hook failure.
+func userBootstrapExitCode(err error) (int, bool) {
+	var userErr *provision.UserBootstrapError
+	if errors.As(err, &userErr) {
+		return userErr.ExitCode, true
+	}
+	return 0, false
+}
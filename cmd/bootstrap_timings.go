@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+)
+
+// bootstrapTimingsReadCommand prints the bootstrap timings file, or nothing
+// when it does not exist.
+func bootstrapTimingsReadCommand() []string {
+	return []string{"cat " + bootstrap.TimingsPath + " 2>/dev/null || true"}
+}
+
+// fetchBootstrapTimings reads the phase timings bootstrap.sh recorded on a
+// freshly bootstrapped instance. It is best effort: a VM that cannot be
+// reached, or whose bootstrap predates the timings file, returns nil.
+func fetchBootstrapTimings(
+	ctx context.Context,
+	run RemoteCommandRunner,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID, az, host string,
+	port int,
+	user string,
+) []bootstrap.PhaseTiming {
+	if run == nil || host == "" {
+		return nil
+	}
+	out, err := run(ctx, sendKey, instanceID, az, host, port, user, bootstrapTimingsReadCommand())
+	if err != nil {
+		return nil
+	}
+	return bootstrap.ParseTimings(out)
+}
+
+// slowPhase reports whether p took longer than threshold. A zero threshold
+// flags nothing.
+func slowPhase(p bootstrap.PhaseTiming, threshold time.Duration) bool {
+	return threshold > 0 && p.Duration() > threshold
+}
+
+// printBootstrapTimings prints where bootstrap spent its time, one phase a
+// line, flagging phases over threshold. A phase that never finished is
+// shown as in progress or failed: bootstrap was still in it when the file
+// was read, or died in it.
+func printBootstrapTimings(w io.Writer, timings []bootstrap.PhaseTiming, threshold time.Duration) {
+	if len(timings) == 0 {
+		return
+	}
+	width := len("total")
+	for _, p := range timings {
+		width = max(width, len(p.Phase))
+	}
+
+	fmt.Fprintln(w, "\nBootstrap phases:")
+	var total time.Duration
+	for _, p := range timings {
+		if !p.Complete() {
+			fmt.Fprintf(w, "  %-*s  in progress/failed\n", width, p.Phase)
+			continue
+		}
+		total += p.Duration()
+		line := fmt.Sprintf("  %-*s  %s", width, p.Phase, format.FormatDuration(p.Duration()))
+		if slowPhase(p, threshold) {
+			line += fmt.Sprintf("  ⚠ over %s", format.FormatDuration(threshold))
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "  %-*s  %s\n", width, "total", format.FormatDuration(total))
+}
+
+// bootstrapTimingsJSON returns the bootstrap_timings array of the JSON
+// output, with durations in seconds. An unfinished phase has no end and a
+// duration of null.
+func bootstrapTimingsJSON(timings []bootstrap.PhaseTiming, threshold time.Duration) []map[string]any {
+	out := make([]map[string]any, 0, len(timings))
+	for _, p := range timings {
+		entry := map[string]any{
+			"phase":            p.Phase,
+			"start":            p.Start.UTC().Format(time.RFC3339),
+			"complete":         p.Complete(),
+			"duration_seconds": nil,
+			"slow":             slowPhase(p, threshold),
+		}
+		if p.Complete() {
+			entry["end"] = p.End.UTC().Format(time.RFC3339)
+			entry["duration_seconds"] = int(p.Duration() / time.Second)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+)
+
+const (
+	completeTimings = `[{"phase":"docker","start":"2025-01-20T10:00:00Z","end":"2025-01-20T10:02:10Z"},` +
+		`{"phase":"efs-mount","start":"2025-01-20T10:02:10Z","end":"2025-01-20T10:02:14Z"},` +
+		`{"phase":"user-hook","start":"2025-01-20T10:02:14Z","end":"2025-01-20T10:07:09Z"}]`
+	partialTimings = `[{"phase":"docker","start":"2025-01-20T10:00:00Z","end":"2025-01-20T10:02:10Z"},` +
+		`{"phase":"efs-mount","start":"2025-01-20T10:02:10Z"}]`
+)
+
+func TestFetchBootstrapTimings(t *testing.T) {
+	var gotCommand []string
+	run := func(ctx context.Context, _ mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		gotCommand = command
+		return []byte(completeTimings), nil
+	}
+	timings := fetchBootstrapTimings(context.Background(), run, nil, "i-1", "us-east-1a", "1.2.3.4", defaultSSHPort, defaultSSHUser)
+	if len(timings) != 3 || timings[0].Phase != "docker" || timings[0].Duration() != 130*time.Second {
+		t.Errorf("timings = %+v", timings)
+	}
+	if len(gotCommand) != 1 || !strings.Contains(gotCommand[0], "/mint/.mint/bootstrap-timings.json") {
+		t.Errorf("command = %q", gotCommand)
+	}
+
+	// Absent file and unreachable VM yield no timings.
+	if got := fetchBootstrapTimings(context.Background(), mockRemoteCommandRunner(nil, nil), nil, "i-1", "az", "1.2.3.4", defaultSSHPort, "ubuntu"); got != nil {
+		t.Errorf("absent file: timings = %+v", got)
+	}
+	if got := fetchBootstrapTimings(context.Background(), mockRemoteCommandRunner(nil, errors.New("timeout")), nil, "i-1", "az", "1.2.3.4", defaultSSHPort, "ubuntu"); got != nil {
+		t.Errorf("SSH failure: timings = %+v", got)
+	}
+}
+
+func TestPrintUpHumanBootstrapTimings(t *testing.T) {
+	tests := []struct {
+		name     string
+		timings  string
+		verbose  bool
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:    "complete with slow phase",
+			timings: completeTimings,
+			verbose: true,
+			want: []string{
+				"Bootstrap phases:",
+				"  docker     2m 10s\n",
+				"  efs-mount  4s\n",
+				"  user-hook  4m 55s  ⚠ over 3m\n",
+				"  total      7m 9s\n",
+			},
+		},
+		{
+			name:     "died mid-phase",
+			timings:  partialTimings,
+			verbose:  true,
+			want:     []string{"  docker     2m 10s\n", "  efs-mount  in progress/failed\n"},
+			dontWant: []string{"⚠"},
+		},
+		{
+			name:     "absent file",
+			timings:  "",
+			verbose:  true,
+			dontWant: []string{"Bootstrap phases"},
+		},
+		{
+			name:     "not verbose",
+			timings:  completeTimings,
+			dontWant: []string{"Bootstrap phases"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			result := &provision.ProvisionResult{
+				InstanceID:              "i-new",
+				PublicIP:                "1.2.3.4",
+				BootstrapStatus:         "complete",
+				BootstrapTimings:        bootstrap.ParseTimings([]byte(tt.timings)),
+				BootstrapPhaseThreshold: 3 * time.Minute,
+			}
+			if err := printUpHuman(cmd, result, tt.verbose, defaultSSHPort); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(out, dontWant) {
+					t.Errorf("output has %q:\n%s", dontWant, out)
+				}
+			}
+		})
+	}
+}
+
+func TestPrintUpJSONBootstrapTimings(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	result := &provision.ProvisionResult{
+		InstanceID:              "i-new",
+		BootstrapStatus:         "failed",
+		BootstrapTimings:        bootstrap.ParseTimings([]byte(partialTimings)),
+		BootstrapPhaseThreshold: time.Minute,
+	}
+	if err := printUpJSON(cmd, result); err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		BootstrapTimings []struct {
+			Phase           string `json:"phase"`
+			End             string `json:"end"`
+			Complete        bool   `json:"complete"`
+			DurationSeconds *int   `json:"duration_seconds"`
+			Slow            bool   `json:"slow"`
+		} `json:"bootstrap_timings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	got := data.BootstrapTimings
+	if len(got) != 2 {
+		t.Fatalf("bootstrap_timings = %+v", got)
+	}
+	if got[0].Phase != "docker" || !got[0].Complete || got[0].DurationSeconds == nil || *got[0].DurationSeconds != 130 || !got[0].Slow {
+		t.Errorf("docker = %+v, want a complete, slow 130s phase", got[0])
+	}
+	if got[1].Phase != "efs-mount" || got[1].Complete || got[1].DurationSeconds != nil || got[1].End != "" || got[1].Slow {
+		t.Errorf("efs-mount = %+v, want an incomplete phase", got[1])
+	}
+
+	// Without timings the key is left out.
+	buf.Reset()
+	result.BootstrapTimings = nil
+	if err := printUpJSON(cmd, result); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "bootstrap_timings") {
+		t.Errorf("bootstrap_timings present without timings:\n%s", buf.String())
+	}
+}
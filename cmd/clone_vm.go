@@ -0,0 +1,493 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// cloneVMDeps holds the injectable dependencies for the clone-vm command.
+type cloneVMDeps struct {
+	provisioner         *provision.Provisioner
+	describe            mintaws.DescribeInstancesAPI
+	describeVolumes     mintaws.DescribeVolumesAPI
+	describeAddrs       mintaws.DescribeAddressesAPI
+	createSnapshot      mintaws.CreateSnapshotAPI
+	waitSnapshot        mintaws.WaitSnapshotCompletedAPI
+	createVolume        mintaws.CreateVolumeAPI
+	waitVolumeAvailable mintaws.WaitVolumeAvailableAPI
+	describeFileSystems mintaws.DescribeFileSystemsAPI
+	sendKey             mintaws.SendSSHPublicKeyAPI
+	remoteRun           RemoteCommandRunner // reads the source's idle timeout; nil uses defaultRemoteRunner
+	owner               string
+	ownerARN            string
+	bootstrapScript     []byte
+	bootstrapURL        string               // GitHub raw URL for bootstrap.sh delivery
+	resolveBootstrap    bootstrapResolveFunc // signed manifest lookup; nil uses the embedded hash
+	userBootstrapScript []byte               // Optional user-bootstrap.sh content read from config dir
+	idleTimeout         int                  // Source's idle timeout in minutes from config, when the VM's own cannot be read (0 uses the default)
+	kmsKeyID            string               // kms_key_id from config; encrypts the new root and cloned volumes
+	instanceProfile     string               // instance_profile from config; empty uses the default
+	sshPort             int                  // ssh_port from config; zero uses the default
+	extraTags           map[string]string    // extra_tags from config; added to the snapshot and volume
+	network             networkConfig        // subnet_id, security_group_ids, and vpc_id from config
+}
+
+// newCloneVMCommand creates the production clone-vm command.
+func newCloneVMCommand() *cobra.Command {
+	return newCloneVMCommandWithDeps(nil)
+}
+
+// newCloneVMCommandWithDeps creates the clone-vm command with explicit
+// dependencies for testing. When deps is nil, the command wires real AWS clients.
+func newCloneVMCommandWithDeps(deps *cloneVMDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clone-vm <source-vm> <new-vm>",
+		Short: "Create a new VM from a copy of an existing VM",
+		Long: "Snapshot the source VM's project EBS volume, restore it to a new volume " +
+			"for the new VM, and provision the new VM with the source's instance type, " +
+			"volume size, and idle timeout. The source VM may stay running; the snapshot is then " +
+			"crash-consistent rather than a clean copy.\n\n" +
+			"The idle timeout is read from a running source VM. For a stopped one it comes " +
+			"from the source's config (its [vm.<name>] table, or the top-level idle_timeout), " +
+			"and the output says so.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runCloneVM(cmd, deps, args[0], args[1])
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			cliCtx := cli.FromCommand(cmd)
+			verbose := cliCtx != nil && cliCtx.Verbose
+			sp := progress.NewCommandSpinner(cmd.OutOrStdout(), false)
+			var pollerWriter io.Writer
+			if verbose {
+				pollerWriter = &spinnerWriter{sp: sp}
+			} else {
+				pollerWriter = sp.Writer
+			}
+			poller := provision.NewBootstrapPoller(
+				clients.ec2Client, // DescribeInstancesAPI
+				clients.ec2Client, // StopInstancesAPI
+				clients.ec2Client, // TerminateInstancesAPI
+				clients.ec2Client, // CreateTagsAPI
+				pollerWriter,
+				cmd.InOrStdin(),
+			)
+			var userBootstrapScript []byte
+			userBootstrapPath := filepath.Join(config.DefaultConfigDir(), "user-bootstrap.sh")
+			if data, err := os.ReadFile(userBootstrapPath); err == nil {
+				userBootstrapScript = data
+			}
+			idleTimeout, kmsKeyID, instanceProfile, bootstrapMirror := 0, "", "", ""
+			var extraTags map[string]string
+			if clients.mintConfig != nil {
+				idleTimeout = clients.mintConfig.IdleTimeoutMinutes
+				if sourceCfg, err := clients.mintConfig.ForVM(args[0]); err == nil {
+					idleTimeout = sourceCfg.IdleTimeoutMinutes
+				}
+				bootstrapMirror = clients.mintConfig.BootstrapURL
+				kmsKeyID = clients.mintConfig.KMSKeyID
+				instanceProfile = clients.mintConfig.InstanceProfile
+				extraTags = clients.mintConfig.ExtraTags
+			}
+			// DescribeVolumes and DeleteTags enable pending-attach recovery,
+			// which is how the new VM adopts the cloned volume.
+			provisioner, err := provision.NewProvisioner(provision.EC2Clients(clients.ec2Client),
+				provision.WithWaitRunning(ec2.NewInstanceRunningWaiter(clients.ec2Client)),
+				provision.WithWaitVolumeAvailable(ec2.NewVolumeAvailableWaiter(clients.ec2Client)),
+				provision.WithDescribeVolumes(clients.ec2Client),
+				provision.WithDeleteTags(clients.ec2Client),
+				provision.WithTerminateInstances(clients.ec2Client),
+				provision.WithReleaseAddress(clients.ec2Client),
+				provision.WithBootstrapPoller(poller),
+				provision.WithExtraTags(extraTags),
+			)
+			if err != nil {
+				return err
+			}
+			return runCloneVM(cmd, &cloneVMDeps{
+				provisioner:         provisioner,
+				describe:            clients.ec2Client,
+				describeVolumes:     clients.ec2Client,
+				describeAddrs:       clients.ec2Client,
+				createSnapshot:      clients.ec2Client,
+				waitSnapshot:        ec2.NewSnapshotCompletedWaiter(clients.ec2Client),
+				createVolume:        clients.ec2Client,
+				waitVolumeAvailable: ec2.NewVolumeAvailableWaiter(clients.ec2Client),
+				describeFileSystems: clients.efsClient,
+				sendKey:             clients.sendKey,
+				remoteRun:           clients.remoteRunner(),
+				owner:               clients.owner,
+				ownerARN:            clients.ownerARN,
+				bootstrapScript:     GetBootstrapScript(),
+				bootstrapURL:        bootstrap.ScriptURL(version),
+				resolveBootstrap:    defaultBootstrapResolver(bootstrapMirror),
+				userBootstrapScript: userBootstrapScript,
+				idleTimeout:         idleTimeout,
+				kmsKeyID:            kmsKeyID,
+				instanceProfile:     instanceProfile,
+				extraTags:           extraTags,
+				sshPort:             clients.sshOptions.Port,
+				network:             configuredNetwork(clients.mintConfig),
+			}, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().String("az", "", "Availability zone for the new VM (default: the source volume's AZ)")
+
+	return cmd
+}
+
+// runCloneVM executes the clone-vm command logic: validate both names,
+// snapshot the source project volume, restore it as a pending-attach volume
+// for the destination, then run the normal provisioning path so the new VM
+// adopts the restored volume.
+func runCloneVM(cmd *cobra.Command, deps *cloneVMDeps, sourceName, destName string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	verbose := false
+	jsonOutput := false
+	if cliCtx != nil {
+		verbose = cliCtx.Verbose
+		jsonOutput = cliCtx.JSON
+	}
+
+	if sourceName == destName {
+		return fmt.Errorf("source and destination VM names are both %q — choose a new name for the clone", destName)
+	}
+
+	w := cmd.OutOrStdout()
+
+	source, err := vm.FindVM(ctx, deps.describe, deps.owner, sourceName)
+	if err != nil {
+		return fmt.Errorf("discovering source VM: %w", err)
+	}
+	if source == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", sourceName, hint.Cmd("mint list"))
+	}
+
+	if err := checkCloneDestinationFree(ctx, deps, destName); err != nil {
+		return err
+	}
+
+	sourceVol, err := findCloneSourceVolume(ctx, deps, sourceName)
+	if err != nil {
+		return err
+	}
+	sourceVolID := aws.ToString(sourceVol.VolumeId)
+
+	az, _ := cmd.Flags().GetString("az")
+	if az == "" {
+		az = aws.ToString(sourceVol.AvailabilityZone)
+	}
+
+	// Resolve the bootstrap source before snapshotting so a manifest that
+	// needs a newer CLI fails before anything is created.
+	src, err := resolveBootstrapSource(ctx, deps.resolveBootstrap, deps.bootstrapURL)
+	if err != nil {
+		return err
+	}
+
+	// Skip in JSON mode to avoid corrupting machine-readable output.
+	if source.State == string(ec2types.InstanceStateNameRunning) && !jsonOutput {
+		fmt.Fprintf(w, "Warning: VM %q is running — the snapshot is crash-consistent. Stop it first with %s for a clean copy.\n",
+			sourceName, hint.Cmd("mint down --vm "+sourceName))
+	}
+
+	idleTimeout, idleFromSource, idleNote := cloneIdleTimeout(ctx, deps, source, sourceName)
+	if !jsonOutput {
+		fmt.Fprintln(w, idleNote)
+	}
+
+	sp := progress.NewCommandSpinner(w, jsonOutput)
+	sp.Start(fmt.Sprintf("Snapshotting project volume %s of VM %q...", sourceVolID, sourceName))
+
+	snapshotID, err := createCloneSnapshot(ctx, deps, sourceVolID, sourceName, destName)
+	if err != nil {
+		sp.Fail(err.Error())
+		return err
+	}
+	cliCtx.TouchResource(cli.ResourceSnapshot, snapshotID)
+
+	sp.Update(fmt.Sprintf("Creating volume for VM %q from snapshot %s in %s...", destName, snapshotID, az))
+	clonedVolID, err := createClonedVolume(ctx, deps, sourceVol, snapshotID, az, destName)
+	if err != nil {
+		sp.Fail(err.Error())
+		return err
+	}
+	cliCtx.TouchResource(cli.ResourceVolume, clonedVolID)
+
+	efsID, err := discoverEFS(ctx, deps.describeFileSystems)
+	if err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("discovering EFS: %w", err)
+	}
+
+	cfg := provision.ProvisionConfig{
+		InstanceType:        source.InstanceType,
+		VolumeSize:          aws.ToInt32(sourceVol.Size),
+		VolumeIOPS:          aws.ToInt32(cloneVolumeIOPS(sourceVol)),
+		VolumeThroughput:    aws.ToInt32(cloneVolumeThroughput(sourceVol)),
+		BootstrapScript:     deps.bootstrapScript,
+		BootstrapURL:        deps.bootstrapURL,
+		Bootstrap:           src,
+		EFSID:               efsID,
+		IdleTimeout:         idleTimeout,
+		UserBootstrapScript: deps.userBootstrapScript,
+		IPMode:              source.IPMode,
+		KMSKeyID:            deps.kmsKeyID,
+		InstanceProfile:     deps.instanceProfile,
+		SSHPort:             deps.sshPort,
+	}
+	deps.network.apply(&cfg)
+
+	sp.Update(fmt.Sprintf("Provisioning VM %q...", destName))
+
+	result, err := deps.provisioner.Run(ctx, deps.owner, deps.ownerARN, destName, cfg)
+	if err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("provisioning VM %q (cloned volume %s is tagged pending-attach; %s retries): %w",
+			destName, clonedVolID, hint.Cmd("mint up --vm "+destName), err)
+	}
+	touchProvisionedResources(cliCtx, result)
+
+	sp.Stop("")
+
+	if jsonOutput {
+		idleSource := "config"
+		if idleFromSource {
+			idleSource = "source_vm"
+		}
+		return writeUpJSON(cmd, result, map[string]any{
+			"source_vm":            sourceName,
+			"snapshot_id":          snapshotID,
+			"cloned_volume_id":     clonedVolID,
+			"idle_timeout_minutes": idleTimeout,
+			"idle_timeout_source":  idleSource,
+		})
+	}
+
+	fmt.Fprintf(w, "Cloned VM %q from %q (snapshot %s).\n", destName, sourceName, snapshotID)
+	return printUpHuman(cmd, result, verbose, sshPortOrDefault(deps.sshPort))
+}
+
+// cloneIdleTimeout returns the idle timeout in minutes for the clone and
+// whether it was read from the source VM. A running source's idle monitor
+// settings are read over SSH; otherwise the source's configured timeout is
+// used. The note tells the user which, and why the VM's own was not read.
+func cloneIdleTimeout(ctx context.Context, deps *cloneVMDeps, source *vm.VM, sourceName string) (minutes int, fromSource bool, note string) {
+	configured := deps.idleTimeout
+	if configured == 0 {
+		configured = defaultIdleTimeoutMinutes
+	}
+	fallback := func(reason string) (int, bool, string) {
+		return configured, false, fmt.Sprintf("Idle timeout: %d minutes, from config.toml — %s, so its own setting could not be read.",
+			configured, reason)
+	}
+
+	if source.State != string(ec2types.InstanceStateNameRunning) {
+		return fallback(fmt.Sprintf("VM %q is %s", sourceName, source.State))
+	}
+	remoteRun := deps.remoteRun
+	if remoteRun == nil {
+		remoteRun = defaultRemoteRunner
+	}
+	out, err := remoteRun(ctx, deps.sendKey, source.ID, source.AvailabilityZone, source.PublicIP,
+		sshPortOrDefault(deps.sshPort), defaultSSHUser, []string{"sh", "-c", shellQuote(idleStatusScript())})
+	if err != nil {
+		return fallback(fmt.Sprintf("reading it from VM %q failed (%v)", sourceName, err))
+	}
+	state, err := parseIdleState(out)
+	if err != nil {
+		return fallback(fmt.Sprintf("reading it from VM %q failed (%v)", sourceName, err))
+	}
+	return state.TimeoutMinutes, true, fmt.Sprintf("Idle timeout: %d minutes, copied from VM %q.", state.TimeoutMinutes, sourceName)
+}
+
+// checkCloneDestinationFree refuses the clone when any Mint resource is
+// already tagged for the destination VM name: an instance, a volume (including
+// a leftover pending-attach volume), or an Elastic IP.
+func checkCloneDestinationFree(ctx context.Context, deps *cloneVMDeps, destName string) error {
+	var existing []string
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, destName)
+	if err != nil {
+		return fmt.Errorf("checking destination VM: %w", err)
+	}
+	if found != nil {
+		existing = append(existing, "instance "+found.ID)
+	}
+
+	volOut, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: tags.FilterByOwnerAndVM(deps.owner, destName),
+	})
+	if err != nil {
+		return fmt.Errorf("checking destination volumes: %w", err)
+	}
+	for _, v := range volOut.Volumes {
+		existing = append(existing, "volume "+aws.ToString(v.VolumeId))
+	}
+
+	addrOut, err := deps.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: tags.FilterByOwnerAndVM(deps.owner, destName),
+	})
+	if err != nil {
+		return fmt.Errorf("checking destination Elastic IPs: %w", err)
+	}
+	for _, a := range addrOut.Addresses {
+		existing = append(existing, "Elastic IP "+aws.ToString(a.AllocationId))
+	}
+
+	if len(existing) > 0 {
+		return fmt.Errorf("VM name %q is already in use (%s) — choose another name or run %s first",
+			destName, strings.Join(existing, ", "), hint.Cmd("mint destroy --vm "+destName))
+	}
+	return nil
+}
+
+// findCloneSourceVolume returns the project EBS volume of the source VM.
+func findCloneSourceVolume(ctx context.Context, deps *cloneVMDeps, sourceName string) (ec2types.Volume, error) {
+	filters := append(
+		tags.FilterByOwnerAndVM(deps.owner, sourceName),
+		ec2types.Filter{
+			Name:   aws.String("tag:" + tags.TagComponent),
+			Values: []string{tags.ComponentProjectVolume},
+		},
+	)
+
+	out, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return ec2types.Volume{}, fmt.Errorf("describe volumes: %w", err)
+	}
+	if len(out.Volumes) == 0 {
+		return ec2types.Volume{}, fmt.Errorf("no project volume found for owner %q, vm %q", deps.owner, sourceName)
+	}
+	return out.Volumes[0], nil
+}
+
+// createCloneSnapshot snapshots the source project volume and waits for the
+// snapshot to complete. The snapshot is tagged for the destination VM.
+func createCloneSnapshot(ctx context.Context, deps *cloneVMDeps, volumeID, sourceName, destName string) (string, error) {
+	snapTags, err := tags.MergeExtra(tags.NewTagBuilder(deps.owner, deps.ownerARN, destName).
+		WithComponent(tags.ComponentProjectSnapshot).
+		Build(), deps.extraTags)
+	if err != nil {
+		return "", err
+	}
+	out, err := deps.createSnapshot.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:    aws.String(volumeID),
+		Description: aws.String(fmt.Sprintf("mint clone-vm %s -> %s", sourceName, destName)),
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeSnapshot,
+			Tags:         snapTags,
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating snapshot of %s: %w", volumeID, err)
+	}
+	snapshotID := aws.ToString(out.SnapshotId)
+
+	if deps.waitSnapshot != nil {
+		if err := deps.waitSnapshot.Wait(ctx, &ec2.DescribeSnapshotsInput{
+			SnapshotIds: []string{snapshotID},
+		}, 60*time.Minute); err != nil {
+			return "", fmt.Errorf("waiting for snapshot %s to complete: %w", snapshotID, err)
+		}
+	}
+	return snapshotID, nil
+}
+
+// createClonedVolume restores the snapshot to an encrypted gp3 volume that
+// matches the source's size, IOPS and throughput. The volume is tagged as the destination's project
+// volume with mint:pending-attach so the provisioner attaches it instead of
+// creating a fresh one.
+func createClonedVolume(ctx context.Context, deps *cloneVMDeps, source ec2types.Volume, snapshotID, az, destName string) (string, error) {
+	volTags, err := tags.MergeExtra(append(
+		tags.NewTagBuilder(deps.owner, deps.ownerARN, destName).
+			WithComponent(tags.ComponentProjectVolume).
+			Build(),
+		ec2types.Tag{Key: aws.String(tags.TagPendingAttach), Value: aws.String("true")},
+	), deps.extraTags)
+	if err != nil {
+		return "", err
+	}
+
+	input := &ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String(az),
+		SnapshotId:       aws.String(snapshotID),
+		VolumeType:       ec2types.VolumeTypeGp3,
+		Size:             source.Size,
+		Iops:             cloneVolumeIOPS(source),
+		Throughput:       cloneVolumeThroughput(source),
+		Encrypted:        aws.Bool(true),
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeVolume,
+			Tags:         volTags,
+		}},
+	}
+	if deps.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(deps.kmsKeyID)
+	}
+	out, err := deps.createVolume.CreateVolume(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("creating volume from snapshot %s: %w", snapshotID, err)
+	}
+	volumeID := aws.ToString(out.VolumeId)
+
+	if deps.waitVolumeAvailable != nil {
+		if err := deps.waitVolumeAvailable.Wait(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{volumeID},
+		}, 5*time.Minute); err != nil {
+			return "", fmt.Errorf("waiting for volume %s to become available: %w", volumeID, err)
+		}
+	}
+	return volumeID, nil
+}
+
+// cloneVolumeIOPS returns the provisioned IOPS to copy from the source volume.
+// Only gp3 IOPS carry over; other types report baseline IOPS that gp3 rejects,
+// so nil is returned and the gp3 default applies.
+func cloneVolumeIOPS(v ec2types.Volume) *int32 {
+	if v.VolumeType != ec2types.VolumeTypeGp3 {
+		return nil
+	}
+	return v.Iops
+}
+
+// cloneVolumeThroughput returns the provisioned throughput to copy from the
+// source volume. Like IOPS, only gp3 throughput carries over.
+func cloneVolumeThroughput(v ec2types.Volume) *int32 {
+	if v.VolumeType != ec2types.VolumeTypeGp3 {
+		return nil
+	}
+	return v.Throughput
+}
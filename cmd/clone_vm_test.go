@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// ---------------------------------------------------------------------------
+// Mocks for clone-vm tests
+// ---------------------------------------------------------------------------
+
+type mockWaitSnapshotCompleted struct {
+	err    error
+	called bool
+}
+
+func (m *mockWaitSnapshotCompleted) Wait(ctx context.Context, params *ec2.DescribeSnapshotsInput, maxWaitDur time.Duration, optFns ...func(*ec2.SnapshotCompletedWaiterOptions)) error {
+	m.called = true
+	return m.err
+}
+
+// cloneFixture bundles the deps for a clone-vm run with the mocks tests assert on.
+type cloneFixture struct {
+	deps         *cloneVMDeps
+	snapshot     *cmdtest.CreateSnapshot
+	createVolume *cmdtest.CreateVolume
+	run          *cmdtest.RunInstances
+	attach       *cmdtest.AttachVolume
+	deleteTags   *cmdtest.DeleteTags
+	idle         *cmdtest.RemoteRunner
+}
+
+// newCloneFixture wires a running source VM "default" (m6i.2xlarge, 120 GB /
+// 6000 IOPS / 500 MB/s project volume in us-east-1b, 240-minute idle timeout)
+// and a free destination "dev2". The
+// provisioner sees the cloned volume as dev2's pending-attach volume.
+func newCloneFixture() *cloneFixture {
+	f := &cloneFixture{
+		snapshot:     &cmdtest.CreateSnapshot{Output: &ec2.CreateSnapshotOutput{SnapshotId: aws.String("snap-clone")}},
+		createVolume: &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-clone")}},
+		run: &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+			Instances: []ec2types.Instance{{InstanceId: aws.String("i-clone")}},
+		}},
+		attach:     &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		deleteTags: &cmdtest.DeleteTags{},
+		idle:       &cmdtest.RemoteRunner{Output: []byte("now=1760529600\ntimeout=240\nidle_since=\nextended_until=\n")},
+	}
+
+	source := makeInstanceWithTime("i-src", "default", "testuser", "running", "1.2.3.4", "m6i.2xlarge", "complete", time.Now())
+
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances:      f.run,
+		DescribeSGs:       userAndAdminSecurityGroups(),
+		DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
+			Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+				{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+			},
+		}},
+		CreateVolume: &cmdtest.CreateVolume{Err: fmt.Errorf("provisioner must not create a volume")},
+		AttachVolume: f.attach,
+		AllocateAddr: &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{
+			AllocationId: aws.String("eipalloc-clone"),
+			PublicIp:     aws.String("54.10.20.31"),
+		}},
+		AssociateAddr:  &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs:  &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}},
+		CreateTags:     &cmdtest.CreateTags{},
+		DescribeImages: &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-test", nil
+		}),
+		provision.WithDescribeVolumes(&cmdtest.DescribeVolumesByTag{Key: tags.TagVM, ByValue: map[string][]ec2types.Volume{
+			"dev2": {{VolumeId: aws.String("vol-clone"), AvailabilityZone: aws.String("us-east-1b")}},
+		}}),
+		provision.WithDeleteTags(f.deleteTags),
+	)
+
+	f.deps = &cloneVMDeps{
+		provisioner: p,
+		describe:    &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(source)},
+		describeVolumes: &cmdtest.DescribeVolumesByTag{Key: tags.TagVM, ByValue: map[string][]ec2types.Volume{
+			"default": {{
+				VolumeId:         aws.String("vol-src"),
+				AvailabilityZone: aws.String("us-east-1b"),
+				Size:             aws.Int32(120),
+				Iops:             aws.Int32(6000),
+				Throughput:       aws.Int32(500),
+				VolumeType:       ec2types.VolumeTypeGp3,
+			}},
+		}},
+		describeAddrs:       &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}},
+		createSnapshot:      f.snapshot,
+		waitSnapshot:        &mockWaitSnapshotCompleted{},
+		createVolume:        f.createVolume,
+		describeFileSystems: defaultEFSStub(),
+		remoteRun:           f.idle.Run,
+		owner:               "testuser",
+		ownerARN:            "arn:aws:iam::123:user/testuser",
+		bootstrapScript:     []byte("#!/bin/bash"),
+	}
+	return f
+}
+
+func runCloneVMCommand(t *testing.T, deps *cloneVMDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newCloneVMCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"clone-vm"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func tagValue(ts []ec2types.Tag, key string) (string, bool) {
+	for _, tag := range ts {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value), true
+		}
+	}
+	return "", false
+}
+
+// ---------------------------------------------------------------------------
+// Tests
+// ---------------------------------------------------------------------------
+
+func TestCloneVMMirrorsSourceAndAttachesClonedVolume(t *testing.T) {
+	f := newCloneFixture()
+
+	out, err := runCloneVMCommand(t, f.deps, "default", "dev2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\noutput: %s", err, out)
+	}
+
+	// Snapshot is of the source volume and tagged for the destination.
+	if got := aws.ToString(f.snapshot.Input.VolumeId); got != "vol-src" {
+		t.Errorf("snapshot VolumeId = %q, want vol-src", got)
+	}
+	snapTags := f.snapshot.Input.TagSpecifications[0].Tags
+	if v, _ := tagValue(snapTags, tags.TagVM); v != "dev2" {
+		t.Errorf("snapshot mint:vm = %q, want dev2", v)
+	}
+	if v, _ := tagValue(snapTags, tags.TagComponent); v != tags.ComponentProjectSnapshot {
+		t.Errorf("snapshot mint:component = %q, want %q", v, tags.ComponentProjectSnapshot)
+	}
+
+	// Cloned volume mirrors the source size, IOPS, throughput and AZ and is
+	// pending-attach for dev2.
+	cv := f.createVolume.Input
+	if got := aws.ToString(cv.SnapshotId); got != "snap-clone" {
+		t.Errorf("CreateVolume SnapshotId = %q, want snap-clone", got)
+	}
+	if got := aws.ToString(cv.AvailabilityZone); got != "us-east-1b" {
+		t.Errorf("CreateVolume AZ = %q, want us-east-1b", got)
+	}
+	if got := aws.ToInt32(cv.Size); got != 120 {
+		t.Errorf("CreateVolume Size = %d, want 120", got)
+	}
+	if got := aws.ToInt32(cv.Iops); got != 6000 {
+		t.Errorf("CreateVolume Iops = %d, want 6000", got)
+	}
+	if got := aws.ToInt32(cv.Throughput); got != 500 {
+		t.Errorf("CreateVolume Throughput = %d, want 500", got)
+	}
+	volTags := cv.TagSpecifications[0].Tags
+	if v, _ := tagValue(volTags, tags.TagVM); v != "dev2" {
+		t.Errorf("volume mint:vm = %q, want dev2", v)
+	}
+	if v, _ := tagValue(volTags, tags.TagComponent); v != tags.ComponentProjectVolume {
+		t.Errorf("volume mint:component = %q, want %q", v, tags.ComponentProjectVolume)
+	}
+	if v, ok := tagValue(volTags, tags.TagPendingAttach); !ok || v != "true" {
+		t.Errorf("volume mint:pending-attach = %q (present %v), want true", v, ok)
+	}
+
+	// New instance mirrors the source type and launches next to the volume
+	// without creating its own project volume.
+	ri := f.run.Input()
+	if ri == nil {
+		t.Fatal("RunInstances was not called")
+	}
+	if got := string(ri.InstanceType); got != "m6i.2xlarge" {
+		t.Errorf("InstanceType = %q, want m6i.2xlarge", got)
+	}
+	if got := aws.ToString(ri.SubnetId); got != "subnet-b" {
+		t.Errorf("SubnetId = %q, want subnet-b", got)
+	}
+	for _, bdm := range ri.BlockDeviceMappings {
+		if aws.ToString(bdm.DeviceName) == "/dev/xvdf" {
+			t.Error("RunInstances should not create a project volume when a cloned volume is pending attach")
+		}
+	}
+	if v, _ := tagValue(ri.TagSpecifications[0].Tags, tags.TagVM); v != "dev2" {
+		t.Errorf("instance mint:vm = %q, want dev2", v)
+	}
+	if v, _ := tagValue(ri.TagSpecifications[0].Tags, tags.TagProjectVolumeGB); v != "120" {
+		t.Errorf("instance mint:project-volume-gb = %q, want 120", v)
+	}
+	if v, _ := tagValue(ri.TagSpecifications[0].Tags, tags.TagProjectVolumeThroughput); v != "500" {
+		t.Errorf("instance mint:project-volume-throughput = %q, want 500", v)
+	}
+
+	// Cloned volume is attached and its pending-attach tag removed.
+	if f.attach.Input == nil {
+		t.Fatal("AttachVolume was not called")
+	}
+	if got := aws.ToString(f.attach.Input.VolumeId); got != "vol-clone" {
+		t.Errorf("attached VolumeId = %q, want vol-clone", got)
+	}
+	if got := aws.ToString(f.attach.Input.InstanceId); got != "i-clone" {
+		t.Errorf("attached InstanceId = %q, want i-clone", got)
+	}
+	if len(f.deleteTags.Inputs) != 1 || f.deleteTags.Inputs[0].Resources[0] != "vol-clone" {
+		t.Errorf("expected pending-attach tag removal on vol-clone, got %+v", f.deleteTags.Inputs)
+	}
+
+	for _, want := range []string{"crash-consistent", `Cloned VM "dev2" from "default"`, "vol-clone"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCloneVMCopiesSourceIdleTimeout(t *testing.T) {
+	f := newCloneFixture()
+	f.deps.idleTimeout = 90
+
+	out, err := runCloneVMCommand(t, f.deps, "default", "dev2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\noutput: %s", err, out)
+	}
+	if !strings.Contains(out, `Idle timeout: 240 minutes, copied from VM "default".`) {
+		t.Errorf("output missing the copied idle timeout:\n%s", out)
+	}
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(f.run.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if !strings.Contains(string(ud), `MINT_IDLE_TIMEOUT="240"`) {
+		t.Errorf("UserData missing the source's idle timeout:\n%s", ud)
+	}
+}
+
+func TestCloneVMIdleTimeoutFromConfigWhenSourceUnreadable(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		sshErr   error
+		wantNote string
+	}{
+		{"stopped source", "stopped", nil, `VM "default" is stopped`},
+		{"unreachable source", "running", errors.New("ssh: connect to host 1.2.3.4 port 41122: Connection timed out"), `reading it from VM "default" failed`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCloneFixture()
+			f.deps.idleTimeout = 90
+			f.deps.describe = &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(
+				makeInstanceWithTime("i-src", "default", "testuser", tt.state, "1.2.3.4", "m6i.2xlarge", "complete", time.Now()))}
+			f.idle.Err = tt.sshErr
+
+			out, err := runCloneVMCommand(t, f.deps, "default", "dev2")
+			if err != nil {
+				t.Fatalf("unexpected error: %v\noutput: %s", err, out)
+			}
+			if !strings.Contains(out, "Idle timeout: 90 minutes, from config.toml — "+tt.wantNote) {
+				t.Errorf("output missing the config fallback note:\n%s", out)
+			}
+			ud, _ := base64.StdEncoding.DecodeString(aws.ToString(f.run.Input().UserData))
+			if !strings.Contains(string(ud), `MINT_IDLE_TIMEOUT="90"`) {
+				t.Errorf("UserData missing the configured idle timeout:\n%s", ud)
+			}
+		})
+	}
+}
+
+func TestCloneVMEncryptsClonedVolume(t *testing.T) {
+	f := newCloneFixture()
+	f.deps.kmsKeyID = "alias/mint"
+
+	if out, err := runCloneVMCommand(t, f.deps, "default", "dev2"); err != nil {
+		t.Fatalf("unexpected error: %v\noutput: %s", err, out)
+	}
+
+	in := f.createVolume.Input
+	if !aws.ToBool(in.Encrypted) {
+		t.Error("cloned volume should be encrypted")
+	}
+	if got := aws.ToString(in.KmsKeyId); got != "alias/mint" {
+		t.Errorf("KmsKeyId = %q, want alias/mint", got)
+	}
+}
+
+func TestCloneVMJSONOutput(t *testing.T) {
+	f := newCloneFixture()
+
+	out, err := runCloneVMCommand(t, f.deps, "default", "dev2", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\noutput: %s", err, out)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	want := map[string]string{
+		"source_vm":           "default",
+		"snapshot_id":         "snap-clone",
+		"cloned_volume_id":    "vol-clone",
+		"volume_id":           "vol-clone",
+		"instance_id":         "i-clone",
+		"idle_timeout_source": "source_vm",
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("%s = %v, want %q", k, data[k], v)
+		}
+	}
+	if data["idle_timeout_minutes"] != float64(240) {
+		t.Errorf("idle_timeout_minutes = %v, want 240", data["idle_timeout_minutes"])
+	}
+}
+
+func TestCloneVMAZFlagOverridesSourceAZ(t *testing.T) {
+	f := newCloneFixture()
+
+	// The provisioner fixture expects us-east-1b, so only assert the volume AZ.
+	_, _ = runCloneVMCommand(t, f.deps, "default", "dev2", "--az", "us-east-1a")
+
+	if got := aws.ToString(f.createVolume.Input.AvailabilityZone); got != "us-east-1a" {
+		t.Errorf("CreateVolume AZ = %q, want us-east-1a", got)
+	}
+}
+
+func TestCloneVMRefusesWhenDestinationHasResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(f *cloneFixture)
+		wantMsg string
+	}{
+		{
+			name: "instance",
+			mutate: func(f *cloneFixture) {
+				describe := f.deps.describe.(*cmdtest.TaggedDescribeInstances)
+				describe.Instances = append(describe.Instances, cmdtest.Instances(
+					makeInstanceWithTime("i-dev2", "dev2", "testuser", "stopped", "", "t3.medium", "", time.Now()))...)
+			},
+			wantMsg: "instance i-dev2",
+		},
+		{
+			name: "volume",
+			mutate: func(f *cloneFixture) {
+				f.deps.describeVolumes.(*cmdtest.DescribeVolumesByTag).ByValue["dev2"] = []ec2types.Volume{{VolumeId: aws.String("vol-old")}}
+			},
+			wantMsg: "volume vol-old",
+		},
+		{
+			name: "elastic ip",
+			mutate: func(f *cloneFixture) {
+				f.deps.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
+					Addresses: []ec2types.Address{{AllocationId: aws.String("eipalloc-old")}},
+				}}
+			},
+			wantMsg: "Elastic IP eipalloc-old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCloneFixture()
+			tt.mutate(f)
+
+			_, err := runCloneVMCommand(t, f.deps, "default", "dev2")
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), "already in use") || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("error = %q, want mention of %q", err.Error(), tt.wantMsg)
+			}
+			if f.snapshot.Input != nil {
+				t.Error("no snapshot should be taken when the destination name is taken")
+			}
+		})
+	}
+}
+
+func TestCloneVMSourceNotFound(t *testing.T) {
+	f := newCloneFixture()
+
+	_, err := runCloneVMCommand(t, f.deps, "missing", "dev2")
+	if err == nil || !strings.Contains(err.Error(), `no VM "missing" found`) {
+		t.Fatalf("error = %v, want source-not-found error", err)
+	}
+}
+
+func TestCloneVMSameName(t *testing.T) {
+	f := newCloneFixture()
+
+	_, err := runCloneVMCommand(t, f.deps, "default", "default")
+	if err == nil || !strings.Contains(err.Error(), "choose a new name") {
+		t.Fatalf("error = %v, want same-name error", err)
+	}
+}
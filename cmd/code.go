@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
@@ -23,11 +29,24 @@ type codeDeps struct {
 	owner             string
 	profile           string // AWS profile for ProxyCommand aws CLI
 	region            string // AWS region for ProxyCommand aws CLI
+	sshOptions        sshconfig.Options
 	runner            CommandRunner
 	sshConfigPath     string
 	sshConfigApproved bool
+	startInstances    mintaws.StartInstancesAPI      // nil: a stopped VM is an error
+	waitRunning       mintaws.WaitInstanceRunningAPI // nil skips the wait for running
+	sshWait           time.Duration                  // zero uses defaultReconcileSSHWait
+	sshRetry          time.Duration                  // zero uses reconcileSSHRetryInterval
 }
 
+// codeStartWait bounds how long mint code waits for a VM it started to
+// reach the running state.
+const codeStartWait = 5 * time.Minute
+
+// codeCompletionTimeout bounds the lookup behind mint code <TAB>, so an
+// unreachable VM costs the shell a short pause rather than a hang.
+const codeCompletionTimeout = 5 * time.Second
+
 // newCodeCommand creates the production code command.
 func newCodeCommand() *cobra.Command {
 	return newCodeCommandWithDeps(nil)
@@ -42,9 +61,19 @@ func newCodeCommandWithDeps(deps *codeDeps) *cobra.Command {
 		Long: "Open VS Code with Remote-SSH connected to the VM. " +
 			"Ensures the SSH config entry exists before launching.\n\n" +
 			"If a project name is given, opens /mint/projects/<name> in VS Code.\n" +
+			"If the VM is stopped, offers to start it (--yes starts it without asking) " +
+			"and waits until it answers SSH.\n" +
 			"With no arguments, discovers projects on the VM: auto-opens if exactly one exists, " +
-			"or lists available projects with example commands.",
+			"or lists available projects with example commands.\n\n" +
+			"With --container <project>, opens the project's devcontainer through its " +
+			"Host mint-<vm>-<project> SSH entry, at the container's workspace folder.",
 		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeCodeProjects(cmd, deps, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
 				return runCode(cmd, args, deps)
@@ -68,17 +97,24 @@ func newCodeCommandWithDeps(deps *codeDeps) *cobra.Command {
 			}
 			return runCode(cmd, args, &codeDeps{
 				describe:          clients.ec2Client,
-				sendKey:           clients.icClient,
-				runRemoteCommand:  defaultRemoteRunner,
+				sendKey:           clients.sendKey,
+				runRemoteCommand:  clients.remoteRunner(),
 				owner:             clients.owner,
 				profile:           profile,
 				region:            clients.region,
+				sshOptions:        clients.sshOptions,
 				sshConfigApproved: sshApproved,
+				startInstances:    clients.ec2Client,
+				waitRunning:       ec2.NewInstanceRunningWaiter(clients.ec2Client),
 			})
 		},
 	}
 
 	cmd.Flags().String("path", "/home/ubuntu", "Remote directory to open in VS Code")
+	cmd.Flags().String("container", "", "Open the devcontainer of this project")
+	_ = cmd.RegisterFlagCompletionFunc("container", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeCodeProjects(cmd, deps, toComplete), cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return cmd
 }
@@ -97,6 +133,16 @@ func runCode(cmd *cobra.Command, args []string, deps *codeDeps) error {
 		vmName = cliCtx.VM
 	}
 
+	container, _ := cmd.Flags().GetString("container")
+	if container != "" {
+		if len(args) > 0 || cmd.Flags().Changed("path") {
+			return fmt.Errorf("--container cannot be combined with a project argument or --path")
+		}
+		if err := validateProjectName(container); err != nil {
+			return err
+		}
+	}
+
 	// When a project arg is given and --vm was not explicitly set, try
 	// multi-VM auto-resolution: scan all running VMs to find which one
 	// hosts the project. This avoids requiring --vm when the answer is
@@ -124,13 +170,8 @@ func runCode(cmd *cobra.Command, args []string, deps *codeDeps) error {
 		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
 	}
 
-	// Verify VM is running.
-	if found.State != string(ec2types.InstanceStateNameRunning) {
-		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
-			vmName, found.ID, found.State, hint.Cmd("mint up"))
-	}
-
-	// ADR-0015: Check permission before writing to ~/.ssh/config.
+	// ADR-0015: Check permission before writing to ~/.ssh/config, and
+	// before starting a stopped VM that could then not be opened.
 	if !deps.sshConfigApproved {
 		return fmt.Errorf(
 			"mint needs permission to update ~/.ssh/config — run %s",
@@ -138,6 +179,18 @@ func runCode(cmd *cobra.Command, args []string, deps *codeDeps) error {
 		)
 	}
 
+	// Verify VM is running, offering to start a stopped one.
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		found, err = startCodeVM(ctx, cmd, deps, vmName, found)
+		if err != nil {
+			return err
+		}
+	}
+
+	if container != "" {
+		return launchVSCodeContainer(cmd, ctx, deps, vmName, found, container)
+	}
+
 	// When no positional arg and --path not explicitly set, discover projects
 	// on the VM and provide guidance.
 	pathChanged := cmd.Flags().Changed("path")
@@ -154,6 +207,132 @@ func runCode(cmd *cobra.Command, args []string, deps *codeDeps) error {
 	return launchVSCode(cmd, deps, vmName, found, remotePath)
 }
 
+// startCodeVM starts found, a VM that is not running, once the user agrees
+// (or --yes is set), and waits until it is running and answers SSH. It
+// returns the VM as discovered after the start, since its public IP may
+// have changed. Only a stopped VM can be started; any other state, a
+// declined prompt, or no way to start it is the usual not-running error.
+func startCodeVM(ctx context.Context, cmd *cobra.Command, deps *codeDeps, vmName string, found *vm.VM) (*vm.VM, error) {
+	notRunning := fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+		vmName, found.ID, found.State, hint.Cmd("mint up"))
+	if found.State != string(ec2types.InstanceStateNameStopped) || deps.startInstances == nil {
+		return nil, notRunning
+	}
+
+	w := cmd.OutOrStdout()
+	yes := false
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil {
+		yes = cliCtx.Yes
+	}
+	if !yes {
+		fmt.Fprintf(w, "VM %q (%s) is stopped. Start it and open VS Code? [y/N]: ", vmName, found.ID)
+		scanner := bufio.NewScanner(cmd.InOrStdin())
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return nil, notRunning
+		}
+		if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+			return nil, notRunning
+		}
+	}
+
+	sp := progress.NewCommandSpinner(w, false)
+	sp.Start(fmt.Sprintf("Starting VM %q (%s)...", vmName, found.ID))
+	if _, err := deps.startInstances.StartInstances(ctx, &ec2.StartInstancesInput{
+		InstanceIds: []string{found.ID},
+	}); err != nil {
+		sp.Fail(err.Error())
+		return nil, fmt.Errorf("starting instance %s: %w", found.ID, err)
+	}
+	if deps.waitRunning != nil {
+		if err := deps.waitRunning.Wait(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{found.ID},
+		}, codeStartWait); err != nil {
+			sp.Fail(err.Error())
+			return nil, fmt.Errorf("waiting for instance %s to start: %w", found.ID, err)
+		}
+	}
+
+	started, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		sp.Fail(err.Error())
+		return nil, fmt.Errorf("discovering VM: %w", err)
+	}
+	if started == nil || started.State != string(ec2types.InstanceStateNameRunning) {
+		sp.Stop("")
+		return nil, fmt.Errorf("VM %q (%s) did not reach the running state — check it with %s",
+			vmName, found.ID, hint.Cmd("mint status"))
+	}
+
+	sp.Update("Waiting for SSH...")
+	wait, retry := deps.sshWait, deps.sshRetry
+	if wait == 0 {
+		wait = defaultReconcileSSHWait
+	}
+	if retry == 0 {
+		retry = reconcileSSHRetryInterval
+	}
+	run := func(command ...string) ([]byte, error) {
+		return deps.runRemoteCommand(ctx, deps.sendKey, started.ID, started.AvailabilityZone,
+			started.PublicIP, deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser, command)
+	}
+	if err := waitForSSH(ctx, run, wait, retry); err != nil {
+		sp.Fail(err.Error())
+		return nil, fmt.Errorf("VM %q started but SSH is not reachable yet (%v) — try %s again shortly",
+			vmName, err, hint.Cmd("mint code"))
+	}
+	sp.Stop("")
+	fmt.Fprintf(w, "VM %q started.\n", vmName)
+	return started, nil
+}
+
+// completeCodeProjects lists the projects on the VM for shell completion
+// of mint code. Shell completion skips AWS initialization in
+// PersistentPreRunE, so production clients are set up here, under
+// codeCompletionTimeout. Any failure, such as a stopped or unreachable VM,
+// yields no completions.
+func completeCodeProjects(cmd *cobra.Command, deps *codeDeps, toComplete string) []string {
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, codeCompletionTimeout)
+	defer cancel()
+
+	// The CLI context from PersistentPreRunE predates the flags parsed for
+	// completion, so build a fresh one for --vm and --profile.
+	cliCtx := cli.NewCLIContext(cmd)
+	if deps == nil {
+		clients, err := initAWSClients(cli.WithContext(ctx, cliCtx))
+		if err != nil {
+			return nil
+		}
+		deps = &codeDeps{
+			describe:         clients.ec2Client,
+			sendKey:          clients.sendKey,
+			runRemoteCommand: clients.remoteRunner(),
+			owner:            clients.owner,
+		}
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, cliCtx.VM)
+	if err != nil || found == nil || found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil
+	}
+	out, err := deps.runRemoteCommand(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser, []string{"ls", "-1", "/mint/projects/"})
+	if err != nil {
+		return nil
+	}
+	var projects []string
+	for _, p := range parseProjectNames(string(out)) {
+		if strings.HasPrefix(p, toComplete) {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
+
 // resolveVMForProject handles multi-VM auto-resolution when a project name is
 // given without an explicit --vm flag. It lists all VMs, filters to running,
 // and if multiple running VMs exist, SSH probes each to find which one hosts
@@ -202,7 +381,7 @@ func resolveVMForProject(ctx context.Context, cmd *cobra.Command, project string
 	var matches []*vm.VM
 	for _, v := range running {
 		_, probeErr := deps.runRemoteCommand(ctx, deps.sendKey, v.ID, v.AvailabilityZone,
-			v.PublicIP, defaultSSHPort, defaultSSHUser, probeCmd)
+			v.PublicIP, deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser, probeCmd)
 		if probeErr == nil {
 			// test -d succeeded: project directory exists on this VM.
 			matches = append(matches, v)
@@ -240,7 +419,7 @@ func codeDiscoverProjects(cmd *cobra.Command, ctx context.Context, deps *codeDep
 	// SSH to the VM to list projects.
 	lsCmd := []string{"ls", "-1", "/mint/projects/"}
 	lsOutput, err := deps.runRemoteCommand(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, lsCmd)
+		found.PublicIP, deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser, lsCmd)
 	if err != nil {
 		return fmt.Errorf("listing projects: %w", err)
 	}
@@ -286,26 +465,69 @@ func parseProjectNames(lsOutput string) []string {
 
 // launchVSCode writes the SSH config and execs VS Code with --remote.
 func launchVSCode(cmd *cobra.Command, deps *codeDeps, vmName string, found *vm.VM, remotePath string) error {
-	// Ensure SSH config entry exists.
+	if err := writeCodeSSHConfig(cmd.OutOrStdout(), deps, vmName, found); err != nil {
+		return err
+	}
+
+	// Build VS Code command: code --remote ssh-remote+mint-<vmName> <path>
+	remoteName := fmt.Sprintf("ssh-remote+mint-%s", vmName)
+
+	return codeRunner(deps)("code", "--remote", remoteName, remotePath)
+}
+
+// launchVSCodeContainer syncs the VM's project Host blocks and execs VS Code
+// on the Host of project's devcontainer, at the container's workspace
+// folder.
+func launchVSCodeContainer(cmd *cobra.Command, ctx context.Context, deps *codeDeps, vmName string, found *vm.VM, project string) error {
+	containers, err := listProjectContainers(ctx, deps.runRemoteCommand, deps.sendKey, found, deps.sshOptions.LoginPort(defaultSSHPort))
+	if err != nil {
+		return err
+	}
+	var target *sshconfig.Container
+	for i := range containers {
+		if containers[i].Project == project {
+			target = &containers[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("project %q has no devcontainer on VM %q — run %s to build it",
+			project, vmName, hint.Cmd("mint project rebuild "+project))
+	}
+
+	if err := writeCodeSSHConfig(cmd.OutOrStdout(), deps, vmName, found); err != nil {
+		return err
+	}
+	hosts := &projectHosts{path: deps.sshConfigPath, profile: deps.profile, region: deps.region, sshOptions: deps.sshOptions}
+	if _, err := syncProjectHosts(cmd.OutOrStdout(), hosts, vmName, found, containers); err != nil {
+		return err
+	}
+
+	remoteName := "ssh-remote+" + sshconfig.ProjectHost(vmName, project)
+	return codeRunner(deps)("code", "--remote", remoteName, target.Workspace)
+}
+
+// writeCodeSSHConfig ensures the VM's managed Host block is current,
+// reporting on w what fitting it to the laptop's ssh client changes.
+func writeCodeSSHConfig(w io.Writer, deps *codeDeps, vmName string, found *vm.VM) error {
 	sshConfigPath := deps.sshConfigPath
 	if sshConfigPath == "" {
-		sshConfigPath = defaultSSHConfigPath()
+		sshConfigPath = paths.SSHConfigPath()
 	}
 
-	block := sshconfig.GenerateBlock(vmName, found.PublicIP, defaultSSHUser, defaultSSHPort, found.ID, found.AvailabilityZone, deps.profile, deps.region)
+	opts := fitSSHClient(w, deps.sshOptions, false)
+	block := sshconfig.GenerateBlockWithOptions(vmName, found.PublicIP, opts.LoginUser(defaultSSHUser), opts.LoginPort(defaultSSHPort), found.ID, found.AvailabilityZone, deps.profile, deps.region, opts)
 	if err := sshconfig.WriteManagedBlock(sshConfigPath, vmName, block); err != nil {
 		return fmt.Errorf("write ssh config: %w", err)
 	}
+	return nil
+}
 
-	// Build VS Code command: code --remote ssh-remote+mint-<vmName> <path>
-	remoteName := fmt.Sprintf("ssh-remote+mint-%s", vmName)
-
-	runner := deps.runner
-	if runner == nil {
-		runner = defaultRunner
+// codeRunner returns the runner that execs VS Code.
+func codeRunner(deps *codeDeps) CommandRunner {
+	if deps.runner == nil {
+		return defaultRunner
 	}
-
-	return runner("code", "--remote", remoteName, remotePath)
+	return deps.runner
 }
 
 // resolveCodePath determines the remote directory to open in VS Code.
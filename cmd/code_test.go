@@ -4,44 +4,29 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
 )
 
-// mockRemoteRunnerForCode returns a RemoteCommandRunner that captures invocations
-// and returns the configured output. It allows test assertions on the command
-// that was sent to the remote host.
-func mockRemoteRunnerForCode(stdout string, err error) (RemoteCommandRunner, *[][]string) {
-	var calls [][]string
-	runner := func(
-		ctx context.Context,
-		sendKey mintaws.SendSSHPublicKeyAPI,
-		instanceID, az, host string,
-		port int,
-		user string,
-		command []string,
-	) ([]byte, error) {
-		calls = append(calls, command)
-		return []byte(stdout), err
-	}
-	return runner, &calls
-}
-
 func TestCodeCommand(t *testing.T) {
 	hint.IsTTY = false // Ensure non-TTY mode for consistent test assertions.
 
 	tests := []struct {
 		name              string
-		describe          *mockDescribeForSSH
+		describe          *cmdtest.DescribeInstances
 		owner             string
 		vmName            string
 		projectArg        string // positional arg: mint code <project>
@@ -57,8 +42,8 @@ func TestCodeCommand(t *testing.T) {
 	}{
 		{
 			name: "custom path flag bypasses project discovery",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -74,8 +59,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "non-default vm name in remote",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -92,8 +77,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -103,8 +88,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -114,8 +99,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForSSH{
-				err: fmt.Errorf("connection timeout"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("connection timeout"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -125,8 +110,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "skips ssh config when not approved",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: false,
@@ -137,8 +122,8 @@ func TestCodeCommand(t *testing.T) {
 		// --- Tests for positional project argument (#202) ---
 		{
 			name: "project arg resolves to /mint/projects/<name>",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "myproject",
@@ -154,8 +139,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "project arg with dots and hyphens resolves correctly",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "my-project.v2",
@@ -171,8 +156,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "project arg and --path flag conflict errors",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "myproject",
@@ -184,8 +169,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "invalid project name with shell metacharacters rejected",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "../evil",
@@ -196,8 +181,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "invalid project name with spaces rejected",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "rm -rf /",
@@ -208,8 +193,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "invalid project name with semicolon rejected",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			projectArg:        "foo;bar",
@@ -221,8 +206,8 @@ func TestCodeCommand(t *testing.T) {
 		// --- Tests for no-arg project discovery (#203) ---
 		{
 			name: "no args with zero projects shows hint",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -233,8 +218,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "no args with only lost+found shows hint",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -245,8 +230,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "no args with one project auto-opens VS Code",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -265,8 +250,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "no args with multiple projects lists them",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -277,8 +262,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "no args with multiple projects filters lost+found",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -289,8 +274,8 @@ func TestCodeCommand(t *testing.T) {
 		},
 		{
 			name: "no args with remote command error propagates",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:             "alice",
 			sshConfigApproved: true,
@@ -316,13 +301,13 @@ func TestCodeCommand(t *testing.T) {
 			configDir := t.TempDir()
 			t.Setenv("MINT_CONFIG_DIR", configDir)
 
-			remoteRunner, _ := mockRemoteRunnerForCode(tt.remoteOutput, tt.remoteErr)
+			remoteRunner := mockRemoteCommandRunner([]byte(tt.remoteOutput), tt.remoteErr)
 
 			deps := &codeDeps{
 				describe:          tt.describe,
 				owner:             tt.owner,
 				runner:            runner,
-				sendKey:           &mockSendSSHPublicKey{},
+				sendKey:           &cmdtest.SendSSHPublicKey{},
 				runRemoteCommand:  remoteRunner,
 				sshConfigPath:     sshConfigDir + "/config",
 				sshConfigApproved: tt.sshConfigApproved,
@@ -405,11 +390,11 @@ func TestCodeCommandTooManyArgs(t *testing.T) {
 	t.Setenv("MINT_CONFIG_DIR", configDir)
 
 	deps := &codeDeps{
-		describe: &mockDescribeForSSH{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner:             "alice",
-		sendKey:           &mockSendSSHPublicKey{},
+		sendKey:           &cmdtest.SendSSHPublicKey{},
 		sshConfigApproved: true,
 	}
 
@@ -451,15 +436,15 @@ func TestCodeCommandSkipsSSHConfigWhenNotApproved(t *testing.T) {
 		return nil
 	}
 
-	remoteRunner, _ := mockRemoteRunnerForCode("", nil)
+	remoteRunner := mockRemoteCommandRunner(nil, nil)
 
 	deps := &codeDeps{
-		describe: &mockDescribeForSSH{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner:             "alice",
 		runner:            runner,
-		sendKey:           &mockSendSSHPublicKey{},
+		sendKey:           &cmdtest.SendSSHPublicKey{},
 		runRemoteCommand:  remoteRunner,
 		sshConfigPath:     sshConfigDir + "/config",
 		sshConfigApproved: false,
@@ -517,15 +502,15 @@ func TestCodeCommandWritesSSHConfigWhenApproved(t *testing.T) {
 	}
 
 	// Single project so auto-open triggers VS Code launch.
-	remoteRunner, _ := mockRemoteRunnerForCode("myproject\n", nil)
+	remoteRunner := mockRemoteCommandRunner([]byte("myproject\n"), nil)
 
 	deps := &codeDeps{
-		describe: &mockDescribeForSSH{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner:             "alice",
 		runner:            runner,
-		sendKey:           &mockSendSSHPublicKey{},
+		sendKey:           &cmdtest.SendSSHPublicKey{},
 		runRemoteCommand:  remoteRunner,
 		sshConfigPath:     sshConfigDir + "/config",
 		sshConfigApproved: true,
@@ -577,47 +562,6 @@ func TestCodeCommandWritesSSHConfigWhenApproved(t *testing.T) {
 // Multi-VM auto-resolution tests (#204)
 // ---------------------------------------------------------------------------
 
-// multiVMDescribeForCode implements DescribeInstancesAPI and returns different
-// results for ListVMs (2 tag filters: mint + owner) vs FindVM (3 tag filters:
-// mint + owner + vm). This allows tests to simulate multiple VMs existing while
-// still resolving a single VM by name.
-type multiVMDescribeForCode struct {
-	// listOutput is returned when the call has 2 tag filters (ListVMs path).
-	listOutput *ec2.DescribeInstancesOutput
-	listErr    error
-	// findOutputs maps VM name -> output for FindVM calls (3 tag filters).
-	findOutputs map[string]*ec2.DescribeInstancesOutput
-	findErr     error
-}
-
-func (m *multiVMDescribeForCode) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	// Count tag filters to distinguish ListVMs from FindVM.
-	tagFilterCount := 0
-	vmFilterValue := ""
-	for _, f := range params.Filters {
-		if f.Name != nil && strings.HasPrefix(*f.Name, "tag:") {
-			tagFilterCount++
-			if *f.Name == "tag:mint:vm" && len(f.Values) > 0 {
-				vmFilterValue = f.Values[0]
-			}
-		}
-	}
-
-	// 2 tag filters = ListVMs; 3 tag filters = FindVM.
-	if tagFilterCount == 2 {
-		return m.listOutput, m.listErr
-	}
-	if tagFilterCount == 3 && vmFilterValue != "" {
-		if m.findOutputs != nil {
-			if out, ok := m.findOutputs[vmFilterValue]; ok {
-				return out, m.findErr
-			}
-		}
-		return &ec2.DescribeInstancesOutput{}, m.findErr
-	}
-	return &ec2.DescribeInstancesOutput{}, nil
-}
-
 // makeMultiVMOutput builds a DescribeInstancesOutput containing multiple VMs
 // in a single reservation (as AWS returns them for owner-only filters).
 func makeMultiVMOutput(vms ...struct {
@@ -669,27 +613,6 @@ func perVMRemoteRunner(outputs map[string]string, defaultErr error) (RemoteComma
 	return runner, &calledIDs
 }
 
-// newCodeTestRoot creates a root command with standard persistent flags for
-// code command tests. Using --vm flag or not is controlled by the caller.
-func newCodeTestRoot() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestCodeMultiVMAutoResolution(t *testing.T) {
 	hint.IsTTY = false // Ensure non-TTY mode for consistent test assertions.
 
@@ -708,14 +631,9 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
 		// ListVMs returns one VM. FindVM returns same VM.
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(struct{ id, name, owner, ip, az, state string }{
-				"i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-			}),
-			findOutputs: map[string]*ec2.DescribeInstancesOutput{
-				"default": makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
-			},
-		}
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(struct{ id, name, owner, ip, az, state string }{
+			"i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "running",
+		}))}
 
 		// The remote runner should NOT be called for project probing in single-VM case.
 		remoteRunner, calledIDs := perVMRemoteRunner(nil, nil)
@@ -724,14 +642,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -771,16 +689,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
 		// Two running VMs: "default" and "dev"
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(
-				struct{ id, name, owner, ip, az, state string }{
-					"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
-				},
-			),
-		}
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(
+			struct{ id, name, owner, ip, az, state string }{
+				"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
+			},
+			struct{ id, name, owner, ip, az, state string }{
+				"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
+			},
+		))}
 
 		// Project "myproject" exists only on "dev" VM (i-dev).
 		// The probe checks test -d /mint/projects/<name>; exit code 0 = exists.
@@ -808,14 +724,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -852,16 +768,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		configDir := t.TempDir()
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(
-				struct{ id, name, owner, ip, az, state string }{
-					"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
-				},
-			),
-		}
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(
+			struct{ id, name, owner, ip, az, state string }{
+				"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
+			},
+			struct{ id, name, owner, ip, az, state string }{
+				"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
+			},
+		))}
 
 		// Project found on BOTH VMs.
 		remoteRunner := func(
@@ -879,14 +793,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -923,16 +837,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		configDir := t.TempDir()
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(
-				struct{ id, name, owner, ip, az, state string }{
-					"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
-				},
-			),
-		}
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(
+			struct{ id, name, owner, ip, az, state string }{
+				"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
+			},
+			struct{ id, name, owner, ip, az, state string }{
+				"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
+			},
+		))}
 
 		// Project found on NEITHER VM.
 		remoteRunner := func(
@@ -950,14 +862,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -991,19 +903,17 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		// Three VMs: "default" (running), "dev" (running), "staging" (stopped).
 		// stopped VMs are filtered out by vm.ListVMs (excluded states),
 		// but let's also include a non-running "pending" VM.
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(
-				struct{ id, name, owner, ip, az, state string }{
-					"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-staging", "staging", "alice", "9.10.11.12", "us-east-1c", "stopped",
-				},
-			),
-		}
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(
+			struct{ id, name, owner, ip, az, state string }{
+				"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
+			},
+			struct{ id, name, owner, ip, az, state string }{
+				"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
+			},
+			struct{ id, name, owner, ip, az, state string }{
+				"i-staging", "staging", "alice", "9.10.11.12", "us-east-1c", "stopped",
+			},
+		))}
 
 		// Project exists only on "dev".
 		var probedIDs []string
@@ -1026,14 +936,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -1074,19 +984,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
 		// Multiple VMs exist, but --vm dev is explicit.
-		describe := &multiVMDescribeForCode{
-			listOutput: makeMultiVMOutput(
-				struct{ id, name, owner, ip, az, state string }{
-					"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
-				},
-				struct{ id, name, owner, ip, az, state string }{
-					"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
-				},
-			),
-			findOutputs: map[string]*ec2.DescribeInstancesOutput{
-				"dev": makeRunningInstanceWithAZ("i-dev", "dev", "alice", "5.6.7.8", "us-east-1b"),
+		describe := &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(makeMultiVMOutput(
+			struct{ id, name, owner, ip, az, state string }{
+				"i-default", "default", "alice", "1.2.3.4", "us-east-1a", "running",
 			},
-		}
+			struct{ id, name, owner, ip, az, state string }{
+				"i-dev", "dev", "alice", "5.6.7.8", "us-east-1b", "running",
+			},
+		))}
 
 		// Remote runner should NOT be called for probing (--vm bypasses it).
 		remoteRunner, calledIDs := perVMRemoteRunner(nil, nil)
@@ -1095,14 +1000,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -1143,8 +1048,8 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		configDir := t.TempDir()
 		t.Setenv("MINT_CONFIG_DIR", configDir)
 
-		describe := &multiVMDescribeForCode{
-			listErr: fmt.Errorf("API rate limit"),
+		describe := &cmdtest.TaggedDescribeInstances{
+			Err: fmt.Errorf("API rate limit"),
 		}
 
 		remoteRunner, _ := perVMRemoteRunner(nil, nil)
@@ -1153,14 +1058,14 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 			describe:          describe,
 			owner:             "alice",
 			runner:            runner,
-			sendKey:           &mockSendSSHPublicKey{},
+			sendKey:           &cmdtest.SendSSHPublicKey{},
 			runRemoteCommand:  remoteRunner,
 			sshConfigPath:     sshConfigDir + "/config",
 			sshConfigApproved: true,
 		}
 
 		cmd := newCodeCommandWithDeps(deps)
-		root := newCodeTestRoot()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -1178,3 +1083,169 @@ func TestCodeMultiVMAutoResolution(t *testing.T) {
 		}
 	})
 }
+
+type mockCodeWaitRunning struct {
+	called bool
+}
+
+func (m *mockCodeWaitRunning) Wait(ctx context.Context, params *ec2.DescribeInstancesInput, maxWaitDur time.Duration, optFns ...func(*ec2.InstanceRunningWaiterOptions)) error {
+	m.called = true
+	return nil
+}
+
+// runCodeWithStdin runs mint code with deps, stdin, and args under a root
+// that has the global flags.
+func runCodeWithStdin(t *testing.T, deps *codeDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot(newCodeCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"code"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// stoppedThenRunningCodeDeps wires a VM that is stopped until started, and
+// returns the start and wait mocks, the launched VS Code command, and the
+// remote runner.
+func stoppedThenRunningCodeDeps(t *testing.T) (*codeDeps, *cmdtest.StartInstances, *mockCodeWaitRunning, *capturedCommand, *cmdtest.RemoteRunner) {
+	t.Helper()
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	remote := &cmdtest.RemoteRunner{}
+	start := &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}}
+	wait := &mockCodeWaitRunning{}
+	captured := &capturedCommand{}
+	deps := &codeDeps{
+		describe: &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+			makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			makeRunningInstanceWithAZ("i-abc123", "default", "alice", "5.6.7.8", "us-east-1a"),
+		}},
+		sendKey:           &cmdtest.SendSSHPublicKey{},
+		runRemoteCommand:  remote.Run,
+		owner:             "alice",
+		sshConfigApproved: true,
+		sshConfigPath:     filepath.Join(t.TempDir(), "config"),
+		startInstances:    start,
+		waitRunning:       wait,
+		sshRetry:          time.Millisecond,
+		runner: func(name string, args ...string) error {
+			captured.name, captured.args = name, args
+			return nil
+		},
+	}
+	return deps, start, wait, captured, remote
+}
+
+func TestCodeStartsStoppedVM(t *testing.T) {
+	hint.IsTTY = false
+	deps, start, wait, captured, remote := stoppedThenRunningCodeDeps(t)
+
+	out, err := runCodeWithStdin(t, deps, "", "--yes", "myproject")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !start.Called || start.Input.InstanceIds[0] != "i-abc123" {
+		t.Errorf("StartInstances = %v %+v, want i-abc123 started", start.Called, start.Input)
+	}
+	if !wait.called {
+		t.Error("did not wait for the running state")
+	}
+	if calls := remote.Commands(); len(calls) == 0 || calls[len(calls)-1] != "true" {
+		t.Errorf("remote calls = %q, want the SSH probe", calls)
+	}
+	if captured.name != "code" || !strings.Contains(strings.Join(captured.args, " "), "/mint/projects/myproject") {
+		t.Errorf("launched %s %v, want VS Code on the project", captured.name, captured.args)
+	}
+	if !strings.Contains(out, `VM "default" started.`) {
+		t.Errorf("output missing the start notice:\n%s", out)
+	}
+}
+
+func TestCodeStoppedVMPrompt(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name      string
+		stdin     string
+		wantStart bool
+	}{
+		{"accepted", "y\n", true},
+		{"declined", "n\n", false},
+		{"no input", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, start, _, captured, _ := stoppedThenRunningCodeDeps(t)
+			out, err := runCodeWithStdin(t, deps, tt.stdin, "myproject")
+			if !strings.Contains(out, "Start it and open VS Code? [y/N]") {
+				t.Errorf("output missing the prompt:\n%s", out)
+			}
+			if start.Called != tt.wantStart {
+				t.Errorf("StartInstances called = %v, want %v", start.Called, tt.wantStart)
+			}
+			if tt.wantStart {
+				if err != nil || captured.name != "code" {
+					t.Errorf("err = %v, launched %q", err, captured.name)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "not running (state: stopped)") {
+				t.Errorf("error = %v, want the not-running error", err)
+			}
+		})
+	}
+}
+
+func TestCodeProjectCompletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		describe *ec2.DescribeInstancesOutput
+		remote   string
+		err      error
+		args     []string
+		want     []string
+	}{
+		{"lists projects", makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			"api\nlost+found\nweb\n", nil, []string{"code", ""}, []string{"api", "web"}},
+		{"filters by prefix", makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			"api\nweb\n", nil, []string{"code", "w"}, []string{"web"}},
+		{"completes --container", makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			"api\nweb\n", nil, []string{"code", "--container", ""}, []string{"api", "web"}},
+		{"one project only", makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			"api\nweb\n", nil, []string{"code", "api", ""}, nil},
+		{"stopped VM", makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			"api\n", nil, []string{"code", ""}, nil},
+		{"unreachable VM", makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			"", fmt.Errorf("connection timed out"), []string{"code", ""}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := mockRemoteCommandRunner([]byte(tt.remote), tt.err)
+			deps := &codeDeps{
+				describe:         &cmdtest.DescribeInstances{Output: tt.describe},
+				sendKey:          &cmdtest.SendSSHPublicKey{},
+				runRemoteCommand: remote,
+				owner:            "alice",
+			}
+			root := cmdtest.NewRoot(newCodeCommandWithDeps(deps))
+			var buf bytes.Buffer
+			root.SetOut(&buf)
+			root.SetErr(io.Discard)
+			root.SetArgs(append([]string{cobra.ShellCompNoDescRequestCmd}, tt.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("completion: %v", err)
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if !strings.HasPrefix(line, ":") {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("completions = %q, want %q (output %q)", got, tt.want, buf.String())
+			}
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/spf13/cobra"
 )
 
@@ -13,39 +21,118 @@ func newConfigCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Display current configuration",
-		Long:  "Display all mint configuration values. Uses ~/.config/mint/config.toml.",
-		Args:  cobra.NoArgs,
+		Long: "Display all mint configuration values. Uses ~/.config/mint/config.toml.\n\n" +
+			"Values are shown for the VM selected with --vm: keys its [vm.<name>] table " +
+			"overrides are marked with the table they came from.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			configDir := config.DefaultConfigDir()
-			cfg, err := config.Load(configDir)
+			cfg, err := loadConfig(cmd.Context(), configDir)
 			if err != nil {
 				return err
 			}
 
 			cliCtx := cli.FromCommand(cmd)
+			vmName := "default"
+			if cliCtx != nil {
+				vmName = cliCtx.VM
+			}
+			vmCfg, err := cfg.ForVM(vmName)
+			if err != nil {
+				return fmt.Errorf("config.toml: %w", err)
+			}
+
 			if cliCtx != nil && cliCtx.JSON {
-				return printConfigJSON(cmd, cfg)
+				return printConfigJSON(cmd, vmCfg, vmName)
 			}
 
-			return printConfigHuman(cmd, cfg)
+			return printConfigHuman(cmd, vmCfg, vmName)
 		},
 	}
 
 	cmd.AddCommand(newConfigSetCommand())
 	cmd.AddCommand(newConfigGetCommand())
+	cmd.AddCommand(newConfigRestoreCommand())
+	cmd.AddCommand(newConfigValidateCommand())
 
 	return cmd
 }
 
-func printConfigJSON(cmd *cobra.Command, cfg *config.Config) error {
+// defaultConfigCommands are the read-only commands that run on the default
+// settings when config.toml is corrupted, rather than failing. Keys are
+// command paths without the root command name.
+var defaultConfigCommands = map[string]bool{
+	"config":       true,
+	"config get":   true,
+	"doctor":       true,
+	"history":      true,
+	"list":         true,
+	"project list": true,
+	"status":       true,
+	"version":      true,
+}
+
+// commandRunsOnDefaultConfig reports whether cmd runs on the default
+// settings when config.toml is corrupted.
+func commandRunsOnDefaultConfig(cmd *cobra.Command) bool {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	return defaultConfigCommands[path]
+}
+
+// loadConfig loads the mint config from configDir, or returns the defaults
+// when the root command found config.toml corrupted and let the command
+// run on them.
+func loadConfig(ctx context.Context, configDir string) (*config.Config, error) {
+	if ctx == nil {
+		return config.Load(configDir)
+	}
+	if cliCtx := cli.FromContext(ctx); cliCtx != nil && cliCtx.DefaultConfig {
+		return config.Defaults(), nil
+	}
+	return config.Load(configDir)
+}
+
+func printConfigJSON(cmd *cobra.Command, cfg *config.Config, vmName string) error {
 	data := map[string]any{
+		"vm":                   vmName,
+		"overridden_keys":      cfg.OverriddenKeys(vmName),
 		"region":               cfg.Region,
 		"instance_type":        cfg.InstanceType,
 		"volume_size_gb":       cfg.VolumeSizeGB,
 		"volume_iops":          cfg.VolumeIOPS,
+		"volume_throughput_mb": cfg.VolumeThroughputMB,
+		"idle_timeout":         cfg.IdleTimeoutMinutes * 60, // seconds
 		"idle_timeout_minutes": cfg.IdleTimeoutMinutes,
 		"ssh_config_approved":  cfg.SSHConfigApproved,
 		"aws_profile":          cfg.AWSProfile,
+		"history_enabled":      cfg.HistoryEnabled,
+		"audit_log":            cfg.AuditLog,
+		"ssh_extra_args":       configListJSON(cfg.SSHExtraArgs),
+		"ssh_identity_file":    cfg.SSHIdentityFile,
+		"ssh_certificate_file": cfg.SSHCertificateFile,
+		"ssh_user":             sshUserOrDefault(cfg.SSHUser),
+		"admin_role_arn":       cfg.AdminRoleARN,
+		"role_arn":             cfg.RoleARN,
+		"external_id":          cfg.ExternalID,
+		"destroy_plan_max_age": int(cfg.DestroyPlanMaxAge / time.Second), // seconds
+
+		"release_eip_after_stopped_days": cfg.ReleaseEIPAfterStoppedDays,
+		"bootstrap_phase_threshold":      int(cfg.BootstrapPhaseThreshold / time.Second), // seconds
+		"bootstrap_url":                  cfg.BootstrapURL,
+		"template_repo":                  cfg.TemplateRepo,
+		"template_commit":                cfg.TemplateCommit,
+		"notify":                         cfg.Notify,
+		"ip_mode":                        cfg.IPMode,
+		"hibernate":                      cfg.Hibernate,
+		"kms_key_id":                     cfg.KMSKeyID,
+		"instance_profile":               cfg.InstanceProfile,
+		"ssh_port":                       cfg.SSHPort,
+		"api_retry_attempts":             cfg.APIRetryAttempts,
+		"forwards":                       configListJSON(cfg.Forwards),
+		"subnet_id":                      cfg.SubnetID,
+		"security_group_ids":             configListJSON(cfg.SecurityGroupIDs),
+		"vpc_id":                         cfg.VPCID,
+		"extra_tags":                     extraTagsJSON(cfg.ExtraTags),
 	}
 
 	enc := json.NewEncoder(cmd.OutOrStdout())
@@ -53,8 +140,9 @@ func printConfigJSON(cmd *cobra.Command, cfg *config.Config) error {
 	return enc.Encode(data)
 }
 
-func printConfigHuman(cmd *cobra.Command, cfg *config.Config) error {
+func printConfigHuman(cmd *cobra.Command, cfg *config.Config, vmName string) error {
 	w := cmd.OutOrStdout()
+	source := func(key string) string { return vmConfigSource(cfg, vmName, key) }
 
 	region := cfg.Region
 	if region == "" {
@@ -69,18 +157,164 @@ func printConfigHuman(cmd *cobra.Command, cfg *config.Config) error {
 	_, err := fmt.Fprintf(w,
 		"region               %s\n"+
 			"instance_type        %s\n"+
-			"volume_size_gb       %d\n"+
-			"volume_iops          %d\n"+
-			"idle_timeout_minutes %d\n"+
+			"volume_size_gb       %s\n"+
+			"volume_iops          %s\n"+
+			"volume_throughput_mb %s\n"+
+			"idle_timeout         %s\n"+
 			"ssh_config_approved  %v\n"+
-			"aws_profile          %s\n",
+			"aws_profile          %s\n"+
+			"history_enabled      %v\n"+
+			"audit_log            %v\n"+
+			"ssh_extra_args       %s\n"+
+			"ssh_identity_file    %s\n"+
+			"ssh_certificate_file %s\n"+
+			"ssh_user             %s\n"+
+			"admin_role_arn       %s\n"+
+			"role_arn             %s\n"+
+			"external_id          %s\n"+
+			"destroy_plan_max_age %s\n"+
+			"release_eip_after_stopped_days %s\n"+
+			"bootstrap_phase_threshold %s\n"+
+			"bootstrap_url        %s\n"+
+			"template_repo        %s\n"+
+			"notify               %s\n"+
+			"ip_mode              %s\n"+
+			"hibernate            %s\n"+
+			"kms_key_id           %s\n"+
+			"instance_profile     %s\n"+
+			"ssh_port             %d\n"+
+			"api_retry_attempts   %d\n"+
+			"forwards             %s\n"+
+			"subnet_id            %s\n"+
+			"security_group_ids   %s\n"+
+			"vpc_id               %s\n"+
+			"extra_tags           %s\n",
 		region,
-		cfg.InstanceType,
-		cfg.VolumeSizeGB,
-		cfg.VolumeIOPS,
-		cfg.IdleTimeoutMinutes,
+		cfg.InstanceType+source("instance_type"),
+		format.FormatGiB(cfg.VolumeSizeGB)+source("volume_size_gb"),
+		strconv.Itoa(cfg.VolumeIOPS)+source("volume_iops"),
+		strconv.Itoa(cfg.VolumeThroughputMB)+source("volume_throughput_mb"),
+		format.FormatDuration(time.Duration(cfg.IdleTimeoutMinutes)*time.Minute)+source("idle_timeout"),
 		cfg.SSHConfigApproved,
 		awsProfile,
+		cfg.HistoryEnabled,
+		cfg.AuditLog,
+		orNotSet(strings.Join(cfg.SSHExtraArgs, " ")),
+		orNotSet(cfg.SSHIdentityFile),
+		orNotSet(cfg.SSHCertificateFile),
+		sshUserOrDefault(cfg.SSHUser),
+		orNotSet(cfg.AdminRoleARN),
+		orNotSet(cfg.RoleARN),
+		orNotSet(cfg.ExternalID),
+		format.FormatDuration(cfg.DestroyPlanMaxAge),
+		releaseEIPDays(cfg.ReleaseEIPAfterStoppedDays),
+		format.FormatDuration(cfg.BootstrapPhaseThreshold),
+		orNotSet(cfg.BootstrapURL),
+		templateRepoDisplay(cfg),
+		cfg.Notify,
+		cfg.IPMode+source("ip_mode"),
+		strconv.FormatBool(cfg.Hibernate)+source("hibernate"),
+		kmsKeyIDDisplay(cfg.KMSKeyID),
+		cfg.InstanceProfile,
+		cfg.SSHPort,
+		cfg.APIRetryAttempts,
+		orNotSet(strings.Join(cfg.Forwards, " "))+source("forwards"),
+		orNotSet(cfg.SubnetID),
+		orNotSet(strings.Join(cfg.SecurityGroupIDs, " ")),
+		orNotSet(cfg.VPCID),
+		extraTagsDisplay(cfg.ExtraTags),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return printVMTables(w, cfg)
+}
+
+// printVMTables lists the [vm.<name>] tables of config.toml and the keys
+// each one overrides.
+func printVMTables(w io.Writer, cfg *config.Config) error {
+	names := make([]string, 0, len(cfg.VMOverrides))
+	for name := range cfg.VMOverrides {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	fmt.Fprintf(w, "\nPer-VM overrides (show one with %s):\n", hint.Cmd("mint config --vm <name>"))
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "  [vm.%s]  %s\n", name, strings.Join(cfg.OverriddenKeys(name), ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateRepoDisplay formats template_repo with the commit init applied.
+func templateRepoDisplay(cfg *config.Config) string {
+	if cfg.TemplateRepo == "" || cfg.TemplateCommit == "" {
+		return orNotSet(cfg.TemplateRepo)
+	}
+	return fmt.Sprintf("%s (%s)", cfg.TemplateRepo, shortCommit(cfg.TemplateCommit))
+}
+
+// extraTagsJSON returns the [extra_tags] table for JSON output, an empty
+// object rather than null when it is not set.
+func extraTagsJSON(extra map[string]string) map[string]string {
+	if extra == nil {
+		return map[string]string{}
+	}
+	return extra
+}
+
+// extraTagsDisplay formats the [extra_tags] table as key=value pairs
+// sorted by key.
+func extraTagsDisplay(extra map[string]string) string {
+	pairs := make([]string, 0, len(extra))
+	for key, value := range extra {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return orNotSet(strings.Join(pairs, ", "))
+}
+
+// kmsKeyIDDisplay formats kms_key_id for display.
+func kmsKeyIDDisplay(keyID string) string {
+	if keyID == "" {
+		return "(default EBS key)"
+	}
+	return keyID
+}
+
+// releaseEIPDays formats release_eip_after_stopped_days for display.
+func releaseEIPDays(days int) string {
+	if days == 0 {
+		return "0 (disabled)"
+	}
+	return strconv.Itoa(days)
+}
+
+// sshUserOrDefault returns the configured login user, or the default.
+func sshUserOrDefault(user string) string {
+	if user == "" {
+		return defaultSSHUser
+	}
+	return user
+}
+
+// orNotSet returns s, or "(not set)" when s is empty.
+func orNotSet(s string) string {
+	if s == "" {
+		return "(not set)"
+	}
+	return s
+}
+
+// configListJSON returns a list key's values for JSON output, with an
+// empty list rather than null when none are set.
+func configListJSON(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/spf13/cobra"
 )
 
@@ -43,7 +45,7 @@ func newConfigGetCommand() *cobra.Command {
 			}
 
 			configDir := config.DefaultConfigDir()
-			cfg, err := config.Load(configDir)
+			cfg, err := loadConfig(cmd.Context(), configDir)
 			if err != nil {
 				return err
 			}
@@ -75,9 +77,13 @@ func configValue(cfg *config.Config, key string) string {
 	case "instance_type":
 		return cfg.InstanceType
 	case "volume_size_gb":
-		return strconv.Itoa(cfg.VolumeSizeGB)
+		return format.FormatGiB(cfg.VolumeSizeGB)
 	case "volume_iops":
 		return strconv.Itoa(cfg.VolumeIOPS)
+	case "volume_throughput_mb":
+		return strconv.Itoa(cfg.VolumeThroughputMB)
+	case "idle_timeout":
+		return format.FormatDuration(time.Duration(cfg.IdleTimeoutMinutes) * time.Minute)
 	case "idle_timeout_minutes":
 		return strconv.Itoa(cfg.IdleTimeoutMinutes)
 	case "ssh_config_approved":
@@ -87,6 +93,56 @@ func configValue(cfg *config.Config, key string) string {
 			return "(not set)"
 		}
 		return cfg.AWSProfile
+	case "history_enabled":
+		return strconv.FormatBool(cfg.HistoryEnabled)
+	case "audit_log":
+		return strconv.FormatBool(cfg.AuditLog)
+	case "ssh_extra_args":
+		return orNotSet(strings.Join(cfg.SSHExtraArgs, " "))
+	case "ssh_identity_file":
+		return orNotSet(cfg.SSHIdentityFile)
+	case "ssh_certificate_file":
+		return orNotSet(cfg.SSHCertificateFile)
+	case "ssh_user":
+		return sshUserOrDefault(cfg.SSHUser)
+	case "admin_role_arn":
+		return orNotSet(cfg.AdminRoleARN)
+	case "role_arn":
+		return orNotSet(cfg.RoleARN)
+	case "external_id":
+		return orNotSet(cfg.ExternalID)
+	case "destroy_plan_max_age":
+		return format.FormatDuration(cfg.DestroyPlanMaxAge)
+	case "release_eip_after_stopped_days":
+		return releaseEIPDays(cfg.ReleaseEIPAfterStoppedDays)
+	case "bootstrap_phase_threshold":
+		return format.FormatDuration(cfg.BootstrapPhaseThreshold)
+	case "bootstrap_url":
+		return orNotSet(cfg.BootstrapURL)
+	case "template_repo":
+		return templateRepoDisplay(cfg)
+	case "notify":
+		return cfg.Notify
+	case "ip_mode":
+		return cfg.IPMode
+	case "hibernate":
+		return strconv.FormatBool(cfg.Hibernate)
+	case "kms_key_id":
+		return cfg.KMSKeyID
+	case "instance_profile":
+		return cfg.InstanceProfile
+	case "ssh_port":
+		return strconv.Itoa(cfg.SSHPort)
+	case "api_retry_attempts":
+		return strconv.Itoa(cfg.APIRetryAttempts)
+	case "forwards":
+		return orNotSet(strings.Join(cfg.Forwards, " "))
+	case "subnet_id":
+		return orNotSet(cfg.SubnetID)
+	case "security_group_ids":
+		return orNotSet(strings.Join(cfg.SecurityGroupIDs, " "))
+	case "vpc_id":
+		return orNotSet(cfg.VPCID)
 	default:
 		return ""
 	}
@@ -106,12 +162,66 @@ func configValueRaw(cfg *config.Config, key string) any {
 		return cfg.VolumeSizeGB
 	case "volume_iops":
 		return cfg.VolumeIOPS
+	case "volume_throughput_mb":
+		return cfg.VolumeThroughputMB
+	case "idle_timeout":
+		return cfg.IdleTimeoutMinutes * 60 // seconds
 	case "idle_timeout_minutes":
 		return cfg.IdleTimeoutMinutes
 	case "ssh_config_approved":
 		return cfg.SSHConfigApproved
 	case "aws_profile":
 		return cfg.AWSProfile
+	case "history_enabled":
+		return cfg.HistoryEnabled
+	case "audit_log":
+		return cfg.AuditLog
+	case "ssh_extra_args":
+		return configListJSON(cfg.SSHExtraArgs)
+	case "ssh_identity_file":
+		return cfg.SSHIdentityFile
+	case "ssh_certificate_file":
+		return cfg.SSHCertificateFile
+	case "ssh_user":
+		return sshUserOrDefault(cfg.SSHUser)
+	case "admin_role_arn":
+		return cfg.AdminRoleARN
+	case "role_arn":
+		return cfg.RoleARN
+	case "external_id":
+		return cfg.ExternalID
+	case "destroy_plan_max_age":
+		return int(cfg.DestroyPlanMaxAge / time.Second) // seconds
+	case "release_eip_after_stopped_days":
+		return cfg.ReleaseEIPAfterStoppedDays
+	case "bootstrap_phase_threshold":
+		return int(cfg.BootstrapPhaseThreshold / time.Second) // seconds
+	case "bootstrap_url":
+		return cfg.BootstrapURL
+	case "template_repo":
+		return cfg.TemplateRepo
+	case "notify":
+		return cfg.Notify
+	case "ip_mode":
+		return cfg.IPMode
+	case "hibernate":
+		return cfg.Hibernate
+	case "kms_key_id":
+		return cfg.KMSKeyID
+	case "instance_profile":
+		return cfg.InstanceProfile
+	case "ssh_port":
+		return cfg.SSHPort
+	case "api_retry_attempts":
+		return cfg.APIRetryAttempts
+	case "forwards":
+		return configListJSON(cfg.Forwards)
+	case "subnet_id":
+		return cfg.SubnetID
+	case "security_group_ids":
+		return configListJSON(cfg.SecurityGroupIDs)
+	case "vpc_id":
+		return cfg.VPCID
 	default:
 		return nil
 	}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/spf13/cobra"
+)
+
+func newConfigRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore config.toml from a backup",
+		Long: "Replace ~/.config/mint/config.toml with one of the last 3 versions mint saved " +
+			"before changing it, kept under config.d/backups. Without flags, list the backups. " +
+			"--backup accepts any unique prefix of a backup's ID, such as 2025-01-20T10:11.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configDir := config.DefaultConfigDir()
+			id, _ := cmd.Flags().GetString("backup")
+			latest, _ := cmd.Flags().GetBool("latest")
+			if id != "" && latest {
+				return fmt.Errorf("--backup and --latest cannot be used together")
+			}
+			w := cmd.OutOrStdout()
+
+			if id == "" && !latest {
+				backups, err := config.Backups(configDir)
+				if err != nil {
+					return err
+				}
+				if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+					ids := make([]string, 0, len(backups))
+					for _, b := range backups {
+						ids = append(ids, b.ID)
+					}
+					enc := json.NewEncoder(w)
+					enc.SetIndent("", "  ")
+					return enc.Encode(map[string][]string{"backups": ids})
+				}
+				if len(backups) == 0 {
+					fmt.Fprintln(w, "No config backups yet. mint keeps one each time it changes config.toml.")
+					return nil
+				}
+				fmt.Fprintln(w, "Config backups, newest first:")
+				for _, b := range backups {
+					fmt.Fprintf(w, "  %s\n", b.ID)
+				}
+				fmt.Fprintf(w, "\nRestore one with %s.\n", hint.Cmd("mint config restore --backup "+backups[0].ID))
+				return nil
+			}
+
+			restored, err := config.Restore(configDir, id)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "Restored config.toml from the backup of %s.\n", restored.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("backup", "", "ID of the backup to restore, or a unique prefix of one")
+	cmd.Flags().Bool("latest", false, "Restore the newest backup")
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// runMint runs the root command with args and returns its combined output.
+func runMint(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := NewRootCommand()
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(args)
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// corruptConfig sets up a config dir whose config.toml was truncated after
+// two good saves.
+func corruptConfig(t *testing.T) string {
+	t.Helper()
+	hint.IsTTY = false
+	dir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", dir)
+	for _, region := range []string{"us-east-1", "us-west-2"} {
+		if out, err := runMint(t, "config", "set", "region", region); err != nil {
+			t.Fatalf("config set: %v\n%s", err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("region = \"us-we"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadOnlyCommandRunsOnDefaultsWithCorruptConfig(t *testing.T) {
+	corruptConfig(t)
+
+	out, err := runMint(t, "config")
+	if err != nil {
+		t.Fatalf("config: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Warning: config file ",
+		"config.toml is corrupted (line 1, column",
+		"restore the last good version with `mint config restore --backup ",
+		"Warning: using the default settings for this command.",
+		"instance_type        m6i.xlarge",
+		"region               (not set)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWritingCommandFailsWithCorruptConfig(t *testing.T) {
+	corruptConfig(t)
+
+	out, err := runMint(t, "config", "set", "region", "eu-west-1")
+	if err == nil || !strings.Contains(err.Error(), "mint config restore --backup") {
+		t.Fatalf("error = %v, want restore guidance", err)
+	}
+	if strings.Contains(out, "default settings") {
+		t.Errorf("config set ran on defaults:\n%s", out)
+	}
+}
+
+func TestConfigRestore(t *testing.T) {
+	corruptConfig(t)
+
+	out, err := runMint(t, "config", "restore")
+	if err != nil {
+		t.Fatalf("config restore: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Config backups, newest first:") || !strings.Contains(out, "Restore one with `mint config restore --backup ") {
+		t.Errorf("listing = %s", out)
+	}
+
+	if _, err := runMint(t, "config", "restore", "--latest", "--backup", "x"); err == nil {
+		t.Error("--latest with --backup accepted")
+	}
+
+	out, err = runMint(t, "config", "restore", "--latest")
+	if err != nil {
+		t.Fatalf("config restore --latest: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Restored config.toml from the backup of ") {
+		t.Errorf("output = %s", out)
+	}
+	out, err = runMint(t, "config", "get", "region")
+	if err != nil || strings.TrimSpace(out) != "us-east-1" {
+		t.Errorf("region after restore = %q, %v; want us-east-1", out, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", dir)
+
+	out, err := runMint(t, "config", "validate")
+	if err != nil || !strings.Contains(out, "config.toml is valid.") {
+		t.Errorf("validate with no file = %q, %v", out, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("regoin = \"us-east-1\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err = runMint(t, "config", "validate")
+	if err == nil || !strings.Contains(out, `unknown key "regoin"`) {
+		t.Errorf("validate = %q, %v; want the unknown key", out, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("region = "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = runMint(t, "config", "validate")
+	if err == nil || !strings.Contains(err.Error(), "is corrupted (line 1") {
+		t.Errorf("validate error = %v, want the parse error", err)
+	}
+}
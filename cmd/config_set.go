@@ -19,7 +19,7 @@ import (
 var instanceTypeValidatorOverride config.InstanceTypeValidatorFunc
 
 func newConfigSetCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "set <key> <value>",
 		Short: "Set a configuration value",
 		Long:  "Validate and set a single configuration key in ~/.config/mint/config.toml.",
@@ -35,10 +35,12 @@ func newConfigSetCommand() *cobra.Command {
 
 			// Wire the instance type validator. Tests may inject a mock via
 			// instanceTypeValidatorOverride; production code uses the real
-			// EC2 client when a region is available.
-			if instanceTypeValidatorOverride != nil {
+			// EC2 client when a region is available. --skip-type-validation
+			// leaves only the basic format check.
+			skipTypeValidation, _ := cmd.Flags().GetBool("skip-type-validation")
+			if !skipTypeValidation && instanceTypeValidatorOverride != nil {
 				cfg.InstanceTypeValidator = instanceTypeValidatorOverride
-			} else if cfg.Region != "" {
+			} else if !skipTypeValidation && cfg.Region != "" {
 				// Wire the real EC2 instance type validator when a region is
 				// available. Without a region we cannot query a specific
 				// region's instance type catalog, so cfg.Set falls back to
@@ -58,7 +60,7 @@ func newConfigSetCommand() *cobra.Command {
 				)
 				if err == nil {
 					ec2Client := ec2.NewFromConfig(awsCfg)
-					validator := mintaws.NewInstanceTypeValidator(ec2Client)
+					validator := mintaws.NewInstanceTypeValidator(ec2Client).WithCacheDir(configDir)
 					cfg.InstanceTypeValidator = func(instanceType, region string) error {
 						return validator.Validate(context.Background(), instanceType, region)
 					}
@@ -88,4 +90,8 @@ func newConfigSetCommand() *cobra.Command {
 			return nil
 		},
 	}
+
+	addSkipTypeValidationFlag(cmd)
+
+	return cmd
 }
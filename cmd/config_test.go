@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -31,12 +32,11 @@ func TestConfigCommandDisplaysValues(t *testing.T) {
 		"instance_type",
 		"volume_size_gb",
 		"volume_iops",
-		"idle_timeout_minutes",
+		"idle_timeout         1h",
 		"ssh_config_approved",
 		"m6i.xlarge",
-		"50",
+		"50 GiB",
 		"3000",
-		"60",
 		"false",
 	}
 
@@ -67,7 +67,7 @@ func TestConfigCommandJSONOutput(t *testing.T) {
 		t.Fatalf("config --json output is not valid JSON: %v\nOutput: %s", err, buf.String())
 	}
 
-	expectedKeys := []string{"region", "instance_type", "volume_size_gb", "volume_iops", "idle_timeout_minutes", "ssh_config_approved"}
+	expectedKeys := []string{"region", "instance_type", "volume_size_gb", "volume_iops", "idle_timeout", "idle_timeout_minutes", "ssh_config_approved"}
 	for _, key := range expectedKeys {
 		if _, ok := result[key]; !ok {
 			t.Errorf("JSON output missing key %q", key)
@@ -547,3 +547,88 @@ func TestConfigJSONIncludesVolumeIOPS(t *testing.T) {
 		t.Errorf("JSON volume_iops = %v, want 3000", result["volume_iops"])
 	}
 }
+
+// TestConfigGetIdleTimeout verifies idle_timeout accepts duration forms,
+// renders humanized, and stays numeric (seconds) in JSON.
+func TestConfigGetIdleTimeout(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", dir)
+
+	run := func(args ...string) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		rootCmd := NewRootCommand()
+		rootCmd.SetOut(buf)
+		rootCmd.SetErr(buf)
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v error: %v", args, err)
+		}
+		return buf.String()
+	}
+
+	run("config", "set", "idle_timeout", "90m")
+
+	if got := strings.TrimSpace(run("config", "get", "idle_timeout")); got != "1h 30m" {
+		t.Errorf("config get idle_timeout = %q, want %q", got, "1h 30m")
+	}
+	if got := strings.TrimSpace(run("config", "get", "idle_timeout_minutes")); got != "90" {
+		t.Errorf("config get idle_timeout_minutes = %q, want %q", got, "90")
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal([]byte(run("--json", "config", "get", "idle_timeout")), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if result["idle_timeout"] != float64(5400) {
+		t.Errorf("JSON idle_timeout = %v, want 5400 seconds", result["idle_timeout"])
+	}
+}
+
+func TestConfigCommandMarksVMOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", dir)
+	content := "instance_type = \"m6i.xlarge\"\n\n[vm.gpu]\ninstance_type = \"g5.2xlarge\"\nvolume_size_gb = 200\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	rootCmd := NewRootCommand()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"config", "--vm", "gpu"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("config --vm gpu error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"g5.2xlarge (from [vm.gpu])",
+		"200 GiB (from [vm.gpu])",
+		"idle_timeout         1h\n",
+		"[vm.gpu]  instance_type, volume_size_gb",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	rootCmd = NewRootCommand()
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs([]string{"config", "--vm", "gpu", "--json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("config --vm gpu --json error: %v", err)
+	}
+	var result map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if result["instance_type"] != "g5.2xlarge" || result["vm"] != "gpu" {
+		t.Errorf("JSON instance_type/vm = %v/%v", result["instance_type"], result["vm"])
+	}
+	if keys, _ := result["overridden_keys"].([]any); len(keys) != 2 {
+		t.Errorf("overridden_keys = %v, want 2 keys", result["overridden_keys"])
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check config.toml for errors",
+		Long: "Check ~/.config/mint/config.toml: that it parses, that every key is known, " +
+			"and that every value passes the checks mint config set applies. " +
+			"Exits non-zero when there is a problem.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems, err := config.Validate(config.DefaultConfigDir())
+			if err != nil {
+				return err
+			}
+			w := cmd.OutOrStdout()
+
+			if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+				if problems == nil {
+					problems = []string{}
+				}
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(map[string]any{"valid": len(problems) == 0, "problems": problems}); err != nil {
+					return err
+				}
+				if len(problems) > 0 {
+					return silentExitError{}
+				}
+				return nil
+			}
+
+			if len(problems) == 0 {
+				fmt.Fprintln(w, "config.toml is valid.")
+				return nil
+			}
+			for _, p := range problems {
+				fmt.Fprintf(w, "  %s\n", p)
+			}
+			return fmt.Errorf("config.toml has %d problem(s)", len(problems))
+		},
+	}
+}
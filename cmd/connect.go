@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -33,7 +35,13 @@ type connectDeps struct {
 	hostKeyStore   *sshconfig.HostKeyStore
 	hostKeyScanner HostKeyScanner
 	remoteRun      RemoteCommandRunner
+	sshOptions     sshconfig.Options
 	stdin          io.Reader // for testing the session picker
+
+	// background starts the ssh that carries the port forwards.
+	background BackgroundRunner
+	// portFree reports whether a local port can be bound.
+	portFree func(port int) bool
 }
 
 // newConnectCommand creates the production connect command.
@@ -49,7 +57,11 @@ func newConnectCommandWithDeps(deps *connectDeps) *cobra.Command {
 		Short: "Connect to a tmux session on the VM via mosh",
 		Long: "Connect to a tmux session on the VM using mosh + tmux. " +
 			"If a session name is provided, creates or attaches to that session. " +
-			"If no session name is given, lists available sessions and presents a picker.",
+			"If no session name is given, lists available sessions and presents a picker.\n\n" +
+			"--forward forwards a VM port to localhost for as long as the session is open: " +
+			"--forward 3000 forwards port 3000, --forward 8080:80 forwards local port 8080 to VM port 80. " +
+			"The forwards config key (top-level or in a [vm.<name>] table) adds forwards to every connect. " +
+			"A local port that is already in use is replaced with the next free one, unless --strict-ports is given.",
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -62,16 +74,20 @@ func newConnectCommandWithDeps(deps *connectDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runConnect(cmd, &connectDeps{
 				describe:       clients.ec2Client,
-				sendKey:        clients.icClient,
+				sendKey:        clients.sendKey,
 				owner:          clients.owner,
 				lookupPath:     exec.LookPath,
 				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner: defaultHostKeyScanner,
-				remoteRun:      defaultRemoteRunner,
+				sshOptions:     clients.sshOptions,
+				remoteRun:      clients.remoteRunner(),
 			}, args)
 		},
 	}
 
+	cmd.Flags().StringArray("forward", nil, "Forward a VM port to localhost: PORT or LOCAL:REMOTE (repeatable)")
+	cmd.Flags().Bool("strict-ports", false, "Fail instead of picking another local port when one is in use")
+
 	return cmd
 }
 
@@ -79,6 +95,13 @@ func newConnectCommandWithDeps(deps *connectDeps) *cobra.Command {
 // connects directly. Path B (no session name) lists sessions and presents a
 // picker before connecting.
 func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
+	specs, _ := cmd.Flags().GetStringArray("forward")
+	strict, _ := cmd.Flags().GetBool("strict-ports")
+	forwards, err := connectForwards(deps.sshOptions.Forwards, specs)
+	if err != nil {
+		return err
+	}
+
 	// Check that mosh is installed locally before doing any AWS work.
 	lookup := deps.lookupPath
 	if lookup == nil {
@@ -128,10 +151,21 @@ func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
 		sessionName = selected
 	}
 
+	portFree := deps.portFree
+	if portFree == nil {
+		portFree = localPortFree
+	}
+	forwards, err = bindForwards(cmd.OutOrStdout(), forwards, strict, portFree)
+	if err != nil {
+		return err
+	}
+
+	port := deps.sshOptions.LoginPort(defaultSSHPort)
+
 	// TOFU host key verification (ADR-0019).
 	var knownHostsPath string
 	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
-		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, defaultSSHPort)
+		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, port)
 		if scanErr != nil {
 			return fmt.Errorf("scanning host key: %w", scanErr)
 		}
@@ -167,7 +201,7 @@ func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
 		knownHostsPath = tmpKH.Name()
 		defer os.Remove(knownHostsPath)
 
-		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, defaultSSHPort, hostKeyLine)
+		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, port, hostKeyLine)
 		if _, err := tmpKH.WriteString(hostEntry); err != nil {
 			tmpKH.Close()
 			return fmt.Errorf("writing temp known_hosts: %w", err)
@@ -190,7 +224,7 @@ func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
 	// Push public key via Instance Connect.
 	_, err = deps.sendKey.SendSSHPublicKey(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
 		InstanceId:       aws.String(found.ID),
-		InstanceOSUser:   aws.String(defaultSSHUser),
+		InstanceOSUser:   aws.String(deps.sshOptions.LoginUser(defaultSSHUser)),
 		SSHPublicKey:     aws.String(pubKey),
 		AvailabilityZone: aws.String(found.AvailabilityZone),
 	})
@@ -199,17 +233,45 @@ func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
 	}
 
 	// Build the ssh sub-command string for mosh --ssh="...".
-	sshCmd := fmt.Sprintf("ssh -p %d -i %s", defaultSSHPort, privKeyPath)
+	mintArgs := []string{"-p", strconv.Itoa(port), "-i", privKeyPath}
 	if knownHostsPath != "" {
-		sshCmd += fmt.Sprintf(" -o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s", knownHostsPath)
+		mintArgs = append(mintArgs, "-o", "StrictHostKeyChecking=yes", "-o", "UserKnownHostsFile="+knownHostsPath)
 	} else {
-		sshCmd += " -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+		mintArgs = append(mintArgs, "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null")
+	}
+	sshCmd := "ssh " + strings.Join(mintArgs, " ") + shellQuoteArgs(deps.sshOptions.Args())
+	login := fmt.Sprintf("%s@%s", deps.sshOptions.LoginUser(defaultSSHUser), found.PublicIP)
+
+	// mosh uses ssh only to start mosh-server, so forwards on its ssh
+	// would close with it. They get an ssh of their own that lasts as long
+	// as the session.
+	if len(forwards) > 0 {
+		background := deps.background
+		if background == nil {
+			background = defaultBackgroundRunner
+		}
+		forwardArgs := append([]string{"-N", "-o", "ExitOnForwardFailure=yes"}, mintArgs...)
+		forwardArgs = append(forwardArgs, deps.sshOptions.Args()...)
+		for _, f := range forwards {
+			forwardArgs = append(forwardArgs, "-L", f.Arg())
+		}
+		stop, err := background("ssh", append(forwardArgs, login)...)
+		if err != nil {
+			return fmt.Errorf("starting port forwards: %w", err)
+		}
+		defer stop()
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Connecting to session %q on VM %q\n", sessionName, vmName)
+	for _, f := range forwards {
+		fmt.Fprintf(w, "  Forwarding localhost:%d → VM port %d\n", f.Local, f.Remote)
 	}
 
 	// Build mosh command arguments with tmux attach.
 	moshArgs := []string{
 		fmt.Sprintf("--ssh=%s", sshCmd),
-		fmt.Sprintf("%s@%s", defaultSSHUser, found.PublicIP),
+		login,
 		"--",
 		"tmux", "new-session", "-A", "-s", sessionName,
 	}
@@ -222,6 +284,65 @@ func runConnect(cmd *cobra.Command, deps *connectDeps, args []string) error {
 	return runner("mosh", moshArgs...)
 }
 
+// connectForwards returns the forwards for a connect: configured, then
+// given with --forward. A --forward replaces a configured forward on the
+// same local port.
+func connectForwards(configured []sshconfig.Forward, specs []string) ([]sshconfig.Forward, error) {
+	flagged, err := sshconfig.ParseForwards(specs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --forward: %w", err)
+	}
+	var forwards []sshconfig.Forward
+	for _, c := range configured {
+		if !slices.ContainsFunc(flagged, func(f sshconfig.Forward) bool { return f.Local == c.Local }) {
+			forwards = append(forwards, c)
+		}
+	}
+	return append(forwards, flagged...), nil
+}
+
+// bindForwards checks that each forward's local port is free. A port in
+// use is replaced with the next free one above it, with a notice on w, or
+// fails the connect when strict is set.
+func bindForwards(w io.Writer, forwards []sshconfig.Forward, strict bool, portFree func(int) bool) ([]sshconfig.Forward, error) {
+	taken := make(map[int]bool, len(forwards))
+	for _, f := range forwards {
+		taken[f.Local] = true
+	}
+	out := make([]sshconfig.Forward, 0, len(forwards))
+	for _, f := range forwards {
+		if portFree(f.Local) {
+			out = append(out, f)
+			continue
+		}
+		if strict {
+			return nil, fmt.Errorf("local port %d is in use — free it, or drop --strict-ports to use the next free port", f.Local)
+		}
+		local := f.Local + 1
+		for local <= 65535 && (taken[local] || !portFree(local)) {
+			local++
+		}
+		if local > 65535 {
+			return nil, fmt.Errorf("local port %d is in use and no higher port is free", f.Local)
+		}
+		fmt.Fprintf(w, "Local port %d is in use — forwarding VM port %d to localhost:%d instead\n", f.Local, f.Remote, local)
+		taken[local] = true
+		out = append(out, sshconfig.Forward{Local: local, Remote: f.Remote})
+	}
+	return out, nil
+}
+
+// localPortFree reports whether port can be bound on the loopback
+// interface, where ssh listens for local forwards.
+func localPortFree(port int) bool {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
+
 // pickSession lists tmux sessions on the VM and returns the selected session
 // name. If only one session exists, it is auto-selected. If multiple sessions
 // exist, an interactive picker is presented.
@@ -243,7 +364,7 @@ func pickSession(ctx context.Context, cmd *cobra.Command, deps *connectDeps, fou
 		found.ID,
 		found.AvailabilityZone,
 		found.PublicIP,
-		defaultSSHPort,
+		deps.sshOptions.LoginPort(defaultSSHPort),
 		defaultSSHUser,
 		tmuxCmd,
 	)
@@ -2,31 +2,19 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
-	"github.com/spf13/cobra"
 )
 
-// mockDescribeForConnect implements mintaws.DescribeInstancesAPI for connect tests.
-type mockDescribeForConnect struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeForConnect) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
 // makeRunningInstanceForConnect creates a running instance for connect tests.
 func makeRunningInstanceForConnect(id, vmName, owner, ip, az string) *ec2.DescribeInstancesOutput {
 	return &ec2.DescribeInstancesOutput{
@@ -70,31 +58,11 @@ func makeRunningInstanceForConnectNoAZ(id, vmName, owner, ip string) *ec2.Descri
 	}
 }
 
-// newTestRootForConnect creates a minimal root command for connect tests.
-func newTestRootForConnect() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestConnectCommandWithSessionName(t *testing.T) {
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForConnect
-		sendKey        *mockSendSSHPublicKey
+		describe       *cmdtest.DescribeInstances
+		sendKey        *cmdtest.SendSSHPublicKey
 		owner          string
 		sessionName    string
 		vmName         string
@@ -107,11 +75,11 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 	}{
 		{
 			name: "connects to named session via mosh and tmux",
-			describe: &mockDescribeForConnect{
-				output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			sessionName: "myproject",
@@ -143,10 +111,10 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "mosh binary not found returns error",
-			describe: &mockDescribeForConnect{
-				output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			sessionName:    "myproject",
 			lookupMosh:     func(string) (string, error) { return "", fmt.Errorf("not found") },
@@ -155,10 +123,10 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForConnect{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			sessionName:    "myproject",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -167,8 +135,8 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForConnect{
-				output: &ec2.DescribeInstancesOutput{
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{
 					Reservations: []ec2types.Reservation{{
 						Instances: []ec2types.Instance{{
 							InstanceId:   aws.String("i-abc123"),
@@ -184,7 +152,7 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 					}},
 				},
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			sessionName:    "myproject",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -193,10 +161,10 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForConnect{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			sessionName:    "myproject",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -205,11 +173,11 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "send public key error propagates",
-			describe: &mockDescribeForConnect{
-				output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				err: fmt.Errorf("access denied"),
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Err: fmt.Errorf("access denied"),
 			},
 			owner:          "alice",
 			sessionName:    "myproject",
@@ -219,11 +187,11 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "runner error with named session propagates",
-			describe: &mockDescribeForConnect{
-				output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:          "alice",
 			sessionName:    "myproject",
@@ -234,11 +202,11 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 		},
 		{
 			name: "non-default vm name",
-			describe: &mockDescribeForConnect{
-				output: makeRunningInstanceForConnect("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForConnect("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			sessionName: "work",
@@ -277,7 +245,7 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 			}
 
 			cmd := newConnectCommandWithDeps(deps)
-			root := newTestRootForConnect()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -317,15 +285,15 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 			}
 
 			// Verify SendSSHPublicKey input when called.
-			if tt.sendKey.called && tt.sendKey.input != nil {
-				if aws.ToString(tt.sendKey.input.InstanceId) == "" {
+			if tt.sendKey.Called && tt.sendKey.Input != nil {
+				if aws.ToString(tt.sendKey.Input.InstanceId) == "" {
 					t.Error("SendSSHPublicKey missing instance ID")
 				}
-				if aws.ToString(tt.sendKey.input.InstanceOSUser) != "ubuntu" {
+				if aws.ToString(tt.sendKey.Input.InstanceOSUser) != "ubuntu" {
 					t.Errorf("SendSSHPublicKey OS user = %q, want ubuntu",
-						aws.ToString(tt.sendKey.input.InstanceOSUser))
+						aws.ToString(tt.sendKey.Input.InstanceOSUser))
 				}
-				if aws.ToString(tt.sendKey.input.AvailabilityZone) == "" {
+				if aws.ToString(tt.sendKey.Input.AvailabilityZone) == "" {
 					t.Error("SendSSHPublicKey missing availability zone")
 				}
 			}
@@ -336,11 +304,11 @@ func TestConnectCommandWithSessionName(t *testing.T) {
 func TestConnectCommandNoSessionPickerSingle(t *testing.T) {
 	// When no session name is provided and only one session exists,
 	// it should auto-select that session and connect.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	var captured capturedCommand
@@ -360,7 +328,7 @@ func TestConnectCommandNoSessionPickerSingle(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -384,11 +352,11 @@ func TestConnectCommandNoSessionPickerSingle(t *testing.T) {
 func TestConnectCommandNoSessionPickerMultiple(t *testing.T) {
 	// When multiple sessions exist and no name is provided,
 	// present picker and read from stdin.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	var captured capturedCommand
@@ -413,7 +381,7 @@ func TestConnectCommandNoSessionPickerMultiple(t *testing.T) {
 
 	outBuf := new(bytes.Buffer)
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(outBuf)
 	root.SetErr(outBuf)
@@ -453,10 +421,10 @@ func TestConnectCommandNoSessionsError(t *testing.T) {
 	hint.IsTTY = false
 
 	// When no sessions exist and no name is provided, error with guidance.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	deps := &connectDeps{
 		describe:   describe,
@@ -467,7 +435,7 @@ func TestConnectCommandNoSessionsError(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -487,10 +455,10 @@ func TestConnectCommandNoSessionsError(t *testing.T) {
 
 func TestConnectCommandNoSessionsTmuxNotRunning(t *testing.T) {
 	// When tmux server is not running and no name is provided, error.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	deps := &connectDeps{
 		describe:   describe,
@@ -501,7 +469,7 @@ func TestConnectCommandNoSessionsTmuxNotRunning(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -518,10 +486,10 @@ func TestConnectCommandNoSessionsTmuxNotRunning(t *testing.T) {
 
 func TestConnectCommandPickerInvalidSelection(t *testing.T) {
 	// Invalid selection (out of range) should return an error.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	deps := &connectDeps{
 		describe:   describe,
@@ -534,7 +502,7 @@ func TestConnectCommandPickerInvalidSelection(t *testing.T) {
 
 	outBuf := new(bytes.Buffer)
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(outBuf)
 	root.SetErr(outBuf)
@@ -551,10 +519,10 @@ func TestConnectCommandPickerInvalidSelection(t *testing.T) {
 
 func TestConnectCommandPickerNonNumericSelection(t *testing.T) {
 	// Non-numeric input should return an error.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	deps := &connectDeps{
 		describe:   describe,
@@ -566,7 +534,7 @@ func TestConnectCommandPickerNonNumericSelection(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -582,16 +550,16 @@ func TestConnectCommandPickerNonNumericSelection(t *testing.T) {
 }
 
 func TestConnectCommandEmptyAvailabilityZone(t *testing.T) {
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnectNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnectNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	var captured capturedCommand
 	deps := &connectDeps{
-		describe:   describe,
-		sendKey:    sendKey,
-		owner:      "alice",
+		describe: describe,
+		sendKey:  sendKey,
+		owner:    "alice",
 		runner: func(name string, args ...string) error {
 			captured.name = name
 			captured.args = args
@@ -601,7 +569,7 @@ func TestConnectCommandEmptyAvailabilityZone(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -619,17 +587,17 @@ func TestConnectCommandEmptyAvailabilityZone(t *testing.T) {
 		t.Errorf("mosh should not have been executed, got: %s %v", captured.name, captured.args)
 	}
 	// SendSSHPublicKey should NOT have been called.
-	if sendKey.called {
+	if sendKey.Called {
 		t.Error("SendSSHPublicKey should not have been called when AZ is empty")
 	}
 }
 
 func TestConnectCommandTOFUFirstConnection(t *testing.T) {
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:testfp123", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil)
 
@@ -654,7 +622,7 @@ func TestConnectCommandTOFUFirstConnection(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -688,11 +656,11 @@ func TestConnectCommandTOFUFirstConnection(t *testing.T) {
 func TestConnectCommandTOFUKeyMismatch(t *testing.T) {
 	hint.IsTTY = false
 
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil)
 
@@ -715,7 +683,7 @@ func TestConnectCommandTOFUKeyMismatch(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -740,11 +708,11 @@ func TestConnectCommandTOFUKeyMismatch(t *testing.T) {
 func TestConnectCommandMoshCommandConstruction(t *testing.T) {
 	// Verify the complete mosh command structure including the -- separator
 	// and tmux command.
-	describe := &mockDescribeForConnect{
-		output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	var captured capturedCommand
@@ -763,7 +731,7 @@ func TestConnectCommandMoshCommandConstruction(t *testing.T) {
 	}
 
 	cmd := newConnectCommandWithDeps(deps)
-	root := newTestRootForConnect()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -798,3 +766,111 @@ func TestConnectCommandMoshCommandConstruction(t *testing.T) {
 		t.Errorf("missing tmux command after --, args: %v", captured.args)
 	}
 }
+
+func TestConnectCommandForwards(t *testing.T) {
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	}
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	}
+
+	var forwarded, mosh capturedCommand
+	stopped := false
+	deps := &connectDeps{
+		describe:   describe,
+		sendKey:    sendKey,
+		owner:      "alice",
+		lookupPath: func(string) (string, error) { return "/usr/bin/mosh", nil },
+		sshOptions: sshconfig.Options{Forwards: []sshconfig.Forward{{Local: 3000, Remote: 3000}, {Local: 5432, Remote: 5432}}},
+		runner: func(name string, args ...string) error {
+			mosh = capturedCommand{name: name, args: args}
+			return nil
+		},
+		background: func(name string, args ...string) (func(), error) {
+			forwarded = capturedCommand{name: name, args: args}
+			return func() { stopped = true }, nil
+		},
+		// 3000 and 3001 are taken.
+		portFree: func(port int) bool { return port != 3000 && port != 3001 },
+	}
+
+	root := cmdtest.NewRoot()
+	root.AddCommand(newConnectCommandWithDeps(deps))
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"connect", "web", "--forward", "8080:80", "--forward", "5432:15432"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := strings.Join(forwarded.args, " ")
+	if forwarded.name != "ssh" || !strings.HasPrefix(args, "-N ") || !strings.HasSuffix(args, " ubuntu@1.2.3.4") {
+		t.Errorf("forward command = %s %s", forwarded.name, args)
+	}
+	for _, want := range []string{"-L 3002:localhost:3000", "-L 8080:localhost:80", "-L 5432:localhost:15432", "-p 41122"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("forward args missing %q: %s", want, args)
+		}
+	}
+	if strings.Contains(args, "5432:localhost:5432") {
+		t.Errorf("--forward 5432:15432 should replace the configured 5432: %s", args)
+	}
+	if strings.Contains(strings.Join(mosh.args, " "), "-L") {
+		t.Errorf("mosh's ssh should not forward: %v", mosh.args)
+	}
+	if !stopped {
+		t.Error("forwarding ssh was not stopped when the session ended")
+	}
+
+	for _, want := range []string{
+		"Local port 3000 is in use — forwarding VM port 3000 to localhost:3002 instead",
+		`Connecting to session "web" on VM "default"`,
+		"Forwarding localhost:3002 → VM port 3000",
+		"Forwarding localhost:8080 → VM port 80",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestConnectCommandForwardErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"bad port", []string{"--forward", "70000"}, "invalid --forward"},
+		{"strict ports", []string{"--forward", "3000", "--strict-ports"}, "local port 3000 is in use"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := false
+			deps := &connectDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeRunningInstanceForConnect("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+				},
+				sendKey:    &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				owner:      "alice",
+				lookupPath: func(string) (string, error) { return "/usr/bin/mosh", nil },
+				runner:     func(string, ...string) error { started = true; return nil },
+				background: func(string, ...string) (func(), error) { started = true; return func() {}, nil },
+				portFree:   func(port int) bool { return port != 3000 },
+			}
+			root := cmdtest.NewRoot()
+			root.AddCommand(newConnectCommandWithDeps(deps))
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"connect", "web"}, tt.args...))
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if started {
+				t.Error("connect started ssh or mosh despite the error")
+			}
+		})
+	}
+}
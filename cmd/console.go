@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	ictypes "github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// serialConsolePort is the instance serial port mint connects to. EC2
+// exposes the console on port 0 only.
+const serialConsolePort = 0
+
+// consoleDeps holds the injectable dependencies for the console command.
+type consoleDeps struct {
+	describe     mintaws.DescribeInstancesAPI
+	accessStatus mintaws.GetSerialConsoleAccessStatusAPI
+	sendKey      mintaws.SendSerialConsoleSSHPublicKeyAPI
+	owner        string
+	region       string
+	runner       CommandRunner
+	// sshArgs are the user's extra ssh arguments. The identity and
+	// certificate settings are not applied: the serial console only accepts
+	// the pushed key.
+	sshArgs []string
+}
+
+// newConsoleCommand creates the production console command.
+func newConsoleCommand() *cobra.Command {
+	return newConsoleCommandWithDeps(nil)
+}
+
+// newConsoleCommandWithDeps creates the console command with explicit
+// dependencies for testing.
+func newConsoleCommandWithDeps(deps *consoleDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "console",
+		Short: "Open the VM's serial console",
+		Long: "Connect to the VM's serial console through the EC2 Serial Console. " +
+			"The console works even when networking or sshd on the VM is broken, " +
+			"so it is the recovery path when mint ssh cannot connect.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runConsole(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runConsole(cmd, &consoleDeps{
+				describe:     clients.ec2Client,
+				accessStatus: clients.ec2Client,
+				sendKey:      clients.icClient,
+				owner:        clients.owner,
+				region:       clients.region,
+				sshArgs:      clients.sshOptions.ExtraArgs,
+			})
+		},
+	}
+
+	return cmd
+}
+
+// runConsole executes the console command logic: discover VM, check that
+// serial console access is enabled, push an ephemeral key to the serial
+// console, and exec ssh to the regional serial console endpoint.
+func runConsole(cmd *cobra.Command, deps *consoleDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	verbose := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		verbose = cliCtx.Verbose
+	}
+
+	w := cmd.OutOrStdout()
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+
+	// Bootstrap status is deliberately not checked: the console is for VMs
+	// whose bootstrap or networking is broken.
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	if deps.region == "" {
+		return fmt.Errorf("no AWS region configured — run %s", hint.Cmd("mint config set region <region>"))
+	}
+
+	if err := checkSerialConsoleAccess(ctx, deps.accessStatus, deps.region); err != nil {
+		return err
+	}
+
+	pubKey, privKeyPath, cleanup, err := generateEphemeralKeyPair()
+	if err != nil {
+		return fmt.Errorf("generating ephemeral SSH key: %w", err)
+	}
+	defer cleanup()
+
+	out, err := deps.sendKey.SendSerialConsoleSSHPublicKey(ctx, &ec2instanceconnect.SendSerialConsoleSSHPublicKeyInput{
+		InstanceId:   aws.String(found.ID),
+		SSHPublicKey: aws.String(pubKey),
+		SerialPort:   serialConsolePort,
+	})
+	if err != nil {
+		return serialConsoleKeyError(err, vmName, found, deps.region)
+	}
+
+	endpoint := serialConsoleEndpoint(found.ID, deps.region)
+	if verbose {
+		fmt.Fprintf(w, "Serial console endpoint: %s\n", endpoint)
+		fmt.Fprintf(w, "Key pushed: success=%v request=%s\n", out.Success, aws.ToString(out.RequestId))
+	}
+
+	writeSerialConsoleNotice(w)
+
+	runner := deps.runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	return runner("ssh", serialConsoleSSHArgs(privKeyPath, endpoint, deps.sshArgs)...)
+}
+
+// checkSerialConsoleAccess returns an actionable error when EC2 Serial
+// Console access is disabled for the account in region.
+func checkSerialConsoleAccess(ctx context.Context, client mintaws.GetSerialConsoleAccessStatusAPI, region string) error {
+	status, err := client.GetSerialConsoleAccessStatus(ctx, &ec2.GetSerialConsoleAccessStatusInput{})
+	if err != nil {
+		return fmt.Errorf("checking serial console access: %w", err)
+	}
+	if aws.ToBool(status.SerialConsoleAccessEnabled) {
+		return nil
+	}
+	if status.ManagedBy == ec2types.ManagedByDeclarativePolicy {
+		return fmt.Errorf("EC2 Serial Console access is disabled in %s by an AWS Organizations declarative policy — ask your organization admin to allow it", region)
+	}
+	return serialConsoleDisabledError(region)
+}
+
+// serialConsoleDisabledError explains the account-level switch and how to
+// flip it.
+func serialConsoleDisabledError(region string) error {
+	return fmt.Errorf("EC2 Serial Console access is not enabled for this AWS account in %s — an admin can enable it with %s",
+		region, hint.Cmd("mint admin enable-serial-console"))
+}
+
+// serialConsoleKeyError maps SendSerialConsoleSSHPublicKey failures to the
+// blockers users actually hit.
+func serialConsoleKeyError(err error, vmName string, found *vm.VM, region string) error {
+	var disabled *ictypes.SerialConsoleAccessDisabledException
+	if errors.As(err, &disabled) {
+		return serialConsoleDisabledError(region)
+	}
+
+	var unsupported *ictypes.SerialConsoleSessionUnsupportedException
+	var invalidType *ictypes.EC2InstanceTypeInvalidException
+	if errors.As(err, &unsupported) || errors.As(err, &invalidType) {
+		return fmt.Errorf("VM %q (%s) uses instance type %s, which does not support the EC2 Serial Console — "+
+			"only Nitro-based instance types do; switch with %s",
+			vmName, found.ID, found.InstanceType, hint.Cmd("mint resize <nitro-instance-type>"))
+	}
+
+	var limit *ictypes.SerialConsoleSessionLimitExceededException
+	if errors.As(err, &limit) {
+		return fmt.Errorf("VM %q (%s) already has an open serial console session — close it and try again", vmName, found.ID)
+	}
+
+	return fmt.Errorf("pushing SSH key to serial console: %w", err)
+}
+
+// serialConsoleEndpoint returns the ssh destination for an instance's
+// serial console in region.
+func serialConsoleEndpoint(instanceID, region string) string {
+	return fmt.Sprintf("%s.port%d@serial-console.ec2-instance-connect.%s.aws", instanceID, serialConsolePort, region)
+}
+
+// serialConsoleSSHArgs builds the ssh arguments for a serial console
+// session. The endpoint is a stable AWS host, so its key is accepted on first
+// use and then verified through the user's normal known_hosts. extraArgs
+// (ssh_extra_args and --ssh-arg) go before the endpoint.
+func serialConsoleSSHArgs(privKeyPath, endpoint string, extraArgs []string) []string {
+	args := []string{
+		"-i", privKeyPath,
+		"-o", "IdentitiesOnly=yes",
+		"-o", "StrictHostKeyChecking=accept-new",
+	}
+	args = append(args, extraArgs...)
+	return append(args, endpoint)
+}
+
+// writeSerialConsoleNotice prints what to expect at the getty. The serial
+// console logs in with a password, and the default ubuntu user has none.
+func writeSerialConsoleNotice(w io.Writer) {
+	fmt.Fprintln(w, "Connecting to the serial console. Press Enter if no login prompt appears; type ~. to disconnect.")
+	fmt.Fprintln(w, "The login prompt needs a user with a password. The default ubuntu user has none.")
+	fmt.Fprintf(w, "If you have not set one, do it while SSH still works: %s\n", hint.Cmd("mint ssh -- sudo passwd ubuntu"))
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	ictypes "github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+)
+
+// mockSerialConsoleStatus implements mintaws.GetSerialConsoleAccessStatusAPI.
+type mockSerialConsoleStatus struct {
+	enabled   bool
+	managedBy ec2types.ManagedBy
+	err       error
+}
+
+func (m *mockSerialConsoleStatus) GetSerialConsoleAccessStatus(ctx context.Context, params *ec2.GetSerialConsoleAccessStatusInput, optFns ...func(*ec2.Options)) (*ec2.GetSerialConsoleAccessStatusOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ec2.GetSerialConsoleAccessStatusOutput{
+		SerialConsoleAccessEnabled: aws.Bool(m.enabled),
+		ManagedBy:                  m.managedBy,
+	}, nil
+}
+
+// mockSendSerialKey implements mintaws.SendSerialConsoleSSHPublicKeyAPI.
+type mockSendSerialKey struct {
+	called bool
+	input  *ec2instanceconnect.SendSerialConsoleSSHPublicKeyInput
+	err    error
+}
+
+func (m *mockSendSerialKey) SendSerialConsoleSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSerialConsoleSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSerialConsoleSSHPublicKeyOutput, error) {
+	m.called = true
+	m.input = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &ec2instanceconnect.SendSerialConsoleSSHPublicKeyOutput{
+		Success:   true,
+		RequestId: aws.String("req-123"),
+	}, nil
+}
+
+type consoleRun struct {
+	name string
+	args []string
+}
+
+func runConsoleWithDeps(t *testing.T, deps *consoleDeps, args ...string) (string, *consoleRun, error) {
+	t.Helper()
+	captured := &consoleRun{}
+	if deps.runner == nil {
+		deps.runner = func(name string, args ...string) error {
+			captured.name = name
+			captured.args = args
+			return nil
+		}
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newConsoleCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"console"}, args...))
+	err := root.Execute()
+	return buf.String(), captured, err
+}
+
+func TestConsoleExecsSSHToSerialEndpoint(t *testing.T) {
+	sendKey := &mockSendSerialKey{}
+	deps := &consoleDeps{
+		describe:     &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")},
+		accessStatus: &mockSerialConsoleStatus{enabled: true},
+		sendKey:      sendKey,
+		owner:        "alice",
+		region:       "us-west-2",
+	}
+
+	out, run, err := runConsoleWithDeps(t, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if aws.ToString(sendKey.input.InstanceId) != "i-abc123" || sendKey.input.SerialPort != 0 {
+		t.Errorf("key push input = %+v", sendKey.input)
+	}
+	if !strings.HasPrefix(aws.ToString(sendKey.input.SSHPublicKey), "ssh-ed25519 ") {
+		t.Errorf("pushed key = %q, want an ed25519 public key", aws.ToString(sendKey.input.SSHPublicKey))
+	}
+
+	if run.name != "ssh" {
+		t.Fatalf("runner called with %q, want ssh", run.name)
+	}
+	if len(run.args) != 7 || run.args[0] != "-i" {
+		t.Fatalf("ssh args = %v", run.args)
+	}
+	wantTail := []string{
+		"-o", "IdentitiesOnly=yes",
+		"-o", "StrictHostKeyChecking=accept-new",
+		"i-abc123.port0@serial-console.ec2-instance-connect.us-west-2.aws",
+	}
+	if strings.Join(run.args[2:], " ") != strings.Join(wantTail, " ") {
+		t.Errorf("ssh args = %v, want -i <key> %v", run.args, wantTail)
+	}
+
+	if !strings.Contains(out, "sudo passwd ubuntu") {
+		t.Errorf("output missing password workaround:\n%s", out)
+	}
+	if strings.Contains(out, "Serial console endpoint") {
+		t.Errorf("endpoint shown without --verbose:\n%s", out)
+	}
+}
+
+func TestConsoleVerboseShowsEndpointAndKeyPush(t *testing.T) {
+	deps := &consoleDeps{
+		describe:     &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")},
+		accessStatus: &mockSerialConsoleStatus{enabled: true},
+		sendKey:      &mockSendSerialKey{},
+		owner:        "alice",
+		region:       "us-east-1",
+	}
+
+	out, _, err := runConsoleWithDeps(t, deps, "--verbose")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"Serial console endpoint: i-abc123.port0@serial-console.ec2-instance-connect.us-east-1.aws",
+		"Key pushed: success=true request=req-123",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("verbose output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConsoleBlockers(t *testing.T) {
+	tests := []struct {
+		name      string
+		describe  *cmdtest.DescribeInstances
+		status    *mockSerialConsoleStatus
+		sendErr   error
+		wantErr   string
+		wantNoKey bool
+	}{
+		{
+			name:      "account access disabled",
+			status:    &mockSerialConsoleStatus{enabled: false, managedBy: ec2types.ManagedByAccount},
+			wantErr:   "mint admin enable-serial-console",
+			wantNoKey: true,
+		},
+		{
+			name:      "access blocked by declarative policy",
+			status:    &mockSerialConsoleStatus{enabled: false, managedBy: ec2types.ManagedByDeclarativePolicy},
+			wantErr:   "declarative policy",
+			wantNoKey: true,
+		},
+		{
+			name:    "disabled reported by key push",
+			status:  &mockSerialConsoleStatus{enabled: true},
+			sendErr: &ictypes.SerialConsoleAccessDisabledException{Message: aws.String("disabled")},
+			wantErr: "mint admin enable-serial-console",
+		},
+		{
+			name:    "unsupported instance type",
+			status:  &mockSerialConsoleStatus{enabled: true},
+			sendErr: &ictypes.SerialConsoleSessionUnsupportedException{Message: aws.String("unsupported")},
+			wantErr: "instance type t3.medium, which does not support the EC2 Serial Console",
+		},
+		{
+			name:    "invalid instance type",
+			status:  &mockSerialConsoleStatus{enabled: true},
+			sendErr: &ictypes.EC2InstanceTypeInvalidException{Message: aws.String("invalid")},
+			wantErr: "only Nitro-based instance types",
+		},
+		{
+			name:    "session limit",
+			status:  &mockSerialConsoleStatus{enabled: true},
+			sendErr: &ictypes.SerialConsoleSessionLimitExceededException{Message: aws.String("limit")},
+			wantErr: "already has an open serial console session",
+		},
+		{
+			name:    "other key push error",
+			status:  &mockSerialConsoleStatus{enabled: true},
+			sendErr: errors.New("throttled"),
+			wantErr: "pushing SSH key to serial console: throttled",
+		},
+		{
+			name:      "stopped VM",
+			describe:  &cmdtest.DescribeInstances{Output: makeStoppedInstance("i-abc123", "default", "alice")},
+			status:    &mockSerialConsoleStatus{enabled: true},
+			wantErr:   "is not running",
+			wantNoKey: true,
+		},
+		{
+			name:      "no VM",
+			describe:  &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+			status:    &mockSerialConsoleStatus{enabled: true},
+			wantErr:   "no VM \"default\" found",
+			wantNoKey: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			describe := tt.describe
+			if describe == nil {
+				describe = &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")}
+			}
+			sendKey := &mockSendSerialKey{err: tt.sendErr}
+			execCalled := false
+			deps := &consoleDeps{
+				describe:     describe,
+				accessStatus: tt.status,
+				sendKey:      sendKey,
+				owner:        "alice",
+				region:       "us-east-1",
+				runner: func(name string, args ...string) error {
+					execCalled = true
+					return nil
+				},
+			}
+
+			_, _, err := runConsoleWithDeps(t, deps)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if execCalled {
+				t.Error("ssh executed despite blocker")
+			}
+			if tt.wantNoKey && sendKey.called {
+				t.Error("key pushed despite blocker")
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/spf13/cobra"
@@ -14,6 +16,7 @@ import (
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
@@ -28,8 +31,39 @@ type destroyDeps struct {
 	deleteVolume    mintaws.DeleteVolumeAPI
 	describeAddrs   mintaws.DescribeAddressesAPI
 	releaseAddr     mintaws.ReleaseAddressAPI
+	createTags      mintaws.CreateTagsAPI // tags an Elastic IP kept by --keep-eip
 	removeHostKey   func(vmName string) error
 	owner           string
+	selfDetector    *selfcheck.Detector // nil skips the self-target guard
+	// sendKey and remote read the VM's automation guards. A nil remote
+	// skips the check.
+	sendKey mintaws.SendSSHPublicKeyAPI
+	remote  RemoteCommandRunner
+	sshPort int               // ssh_port from config; zero means defaultSSHPort
+	locker  *provision.Locker // nil takes no operation lock
+
+	// Plan documents (--plan, --apply).
+	describeSnapshots mintaws.DescribeSnapshotsAPI // nil leaves snapshots out of plans
+	ownerARN          string                       // partition and account for plan ARNs
+	region            string
+	planMaxAge        time.Duration    // 0 uses config.DefaultDestroyPlanMaxAge
+	now               func() time.Time // nil uses time.Now
+}
+
+// clock returns the current time from deps.now, or time.Now.
+func (d *destroyDeps) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// maxPlanAge returns how long a destroy plan can be applied.
+func (d *destroyDeps) maxPlanAge() time.Duration {
+	if d.planMaxAge == 0 {
+		return config.DefaultDestroyPlanMaxAge
+	}
+	return d.planMaxAge
 }
 
 // newDestroyCommand creates the production destroy command. It will be wired
@@ -41,12 +75,21 @@ func newDestroyCommand() *cobra.Command {
 // newDestroyCommandWithDeps creates the destroy command with explicit
 // dependencies for testing.
 func newDestroyCommandWithDeps(deps *destroyDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "destroy",
 		Short: "Terminate the VM and clean up all associated resources",
 		Long: "Terminate the VM instance, delete project EBS volumes, and release " +
 			"the Elastic IP. Root EBS is auto-destroyed by EC2. User EFS access " +
-			"point is preserved (user-scoped, persistent across VMs).",
+			"point is preserved (user-scoped, persistent across VMs).\n\n" +
+			"For change management, --plan writes everything that would be deleted " +
+			"to a JSON document and exits without deleting. --apply re-checks that " +
+			"the live resources still match that document and destroys them without " +
+			"prompting. Plans expire after destroy_plan_max_age (default 1h).\n\n" +
+			"--keep-eip leaves the Elastic IP allocated and tagged, and the next mint up " +
+			"of the VM reuses it, so the VM keeps its address across destroy and up.\n\n" +
+			"Active automation guards (see mint guard) block the destroy of a running " +
+			"VM unless --force is used. So does another mint command holding the VM's " +
+			"operation lock, unless --steal-lock is used.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -67,15 +110,58 @@ func newDestroyCommandWithDeps(deps *destroyDeps) *cobra.Command {
 				deleteVolume:    clients.ec2Client,
 				describeAddrs:   clients.ec2Client,
 				releaseAddr:     clients.ec2Client,
+				createTags:      clients.ec2Client,
 				removeHostKey:   hostKeyStore.RemoveKey,
 				owner:           clients.owner,
+				selfDetector:    selfcheck.Default(),
+				sendKey:         clients.sendKey,
+				remote:          clients.remoteRunner(),
+				sshPort:         clients.sshOptions.Port,
+				locker:          newOperationLocker(cmd, clients.ec2Client),
+
+				describeSnapshots: clients.ec2Client,
+				ownerARN:          clients.ownerARN,
+				region:            clients.region,
+				planMaxAge:        clients.mintConfig.DestroyPlanMaxAge,
 			})
 		},
 	}
+
+	addSelfTargetFlag(cmd)
+	cmd.Flags().String("name-prefix", "", "Destroy every VM whose name starts with this prefix (e.g. a mint up --name-prefix batch)")
+	cmd.Flags().String("plan", "", "Write what would be destroyed to this JSON file and exit without deleting")
+	cmd.Flags().String("apply", "", "Destroy the resources of a plan file written by --plan, without prompting")
+	cmd.Flags().Bool("keep-eip", false, "Keep the Elastic IP allocated for the next mint up of the VM to reuse")
+	cmd.Flags().Bool("force", false, "Bypass active automation guards")
+	addStealLockFlag(cmd)
+	addNotifyFlags(cmd)
+
+	return cmd
 }
 
 // runDestroy executes the destroy command logic: discover VM, confirm, destroy.
 func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
+	planPath, _ := cmd.Flags().GetString("plan")
+	applyPath, _ := cmd.Flags().GetString("apply")
+	if planPath != "" && applyPath != "" {
+		return fmt.Errorf("--plan and --apply cannot be used together")
+	}
+	keepEIP, _ := cmd.Flags().GetBool("keep-eip")
+	prefix, _ := cmd.Flags().GetString("name-prefix")
+	if keepEIP && (planPath != "" || applyPath != "" || prefix != "") {
+		return fmt.Errorf("--keep-eip cannot be combined with --plan, --apply, or --name-prefix")
+	}
+
+	if prefix != "" {
+		if planPath != "" || applyPath != "" {
+			return fmt.Errorf("--plan and --apply cannot be combined with --name-prefix")
+		}
+		return runDestroyBatch(cmd, deps, prefix)
+	}
+	if applyPath != "" {
+		return runDestroyApply(cmd, deps, applyPath)
+	}
+
 	ctx := cmd.Context()
 	if ctx == nil {
 		ctx = context.Background()
@@ -85,13 +171,23 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 	vmName := "default"
 	verbose := false
 	yes := false
+	jsonOutput := false
 	if cliCtx != nil {
 		vmName = cliCtx.VM
 		verbose = cliCtx.Verbose
 		yes = cliCtx.Yes
+		jsonOutput = cliCtx.JSON
+	}
+
+	if planPath != "" {
+		return runDestroyPlan(cmd, deps, vmName, planPath)
 	}
 
+	// With --json, stdout is only the result; the prompt goes to stderr.
 	w := cmd.OutOrStdout()
+	if jsonOutput {
+		w = cmd.ErrOrStderr()
+	}
 
 	// Discover VM to show what will be destroyed.
 	if verbose {
@@ -103,18 +199,41 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 		return fmt.Errorf("discovering VM: %w", err)
 	}
 	if found == nil {
+		if err := notOwnedError(ctx, deps.describe, deps.owner, vmName, "destroy"); err != nil {
+			return err
+		}
 		return fmt.Errorf("no VM %q found — nothing to destroy", vmName)
 	}
 
+	if err := guardSelfTarget(ctx, cmd, deps.selfDetector, vmName, found.ID, "terminate this VM and delete its project volume"); err != nil {
+		return err
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if err := checkGuards(ctx, w, deps.remote, deps.sendKey, found, sshPortOrDefault(deps.sshPort), vmName, force); err != nil {
+		return err
+	}
+
+	// Take the lock before prompting, so a command already changing the VM
+	// refuses the destroy up front.
+	release, err := lockVM(ctx, cmd, deps.locker, found, "destroy")
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Show what will be destroyed.
 	fmt.Fprintf(w, "This will permanently destroy VM %q (%s).\n", vmName, found.ID)
 	fmt.Fprintf(w, "  - Instance %s will be terminated (root EBS auto-destroyed)\n", found.ID)
 	fmt.Fprintf(w, "  - Project EBS volumes will be deleted\n")
-	fmt.Fprintf(w, "  - Elastic IP will be released\n")
+	if keepEIP {
+		fmt.Fprintf(w, "  - Elastic IP is kept for the next mint up\n")
+	} else {
+		fmt.Fprintf(w, "  - Elastic IP will be released\n")
+	}
 	fmt.Fprintf(w, "  - User EFS access point is preserved\n")
 
 	// Confirmation: require user to type VM name unless --yes is set.
-	confirmed := yes
 	if !yes {
 		fmt.Fprintf(w, "\nType the VM name %q to confirm: ", vmName)
 		scanner := bufio.NewScanner(cmd.InOrStdin())
@@ -123,14 +242,38 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 			if input != vmName {
 				return fmt.Errorf("confirmation %q does not match VM name %q — destroy aborted", input, vmName)
 			}
-			confirmed = true
 		} else {
 			return fmt.Errorf("no confirmation input received — destroy aborted")
 		}
 	}
 
+	return destroyConfirmed(ctx, cmd, deps, cliCtx, vmName)
+}
+
+// destroyJSON is what mint destroy --json prints.
+type destroyJSON struct {
+	VM                  string   `json:"vm"`
+	InstanceID          string   `json:"instance_id"`
+	VolumesDeleted      int      `json:"volumes_deleted"`
+	EIPReleased         bool     `json:"eip_released"`
+	KeptEIPAllocationID string   `json:"kept_eip_allocation_id,omitempty"`
+	KeptEIPPublicIP     string   `json:"kept_eip_public_ip,omitempty"`
+	Warnings            []string `json:"warnings,omitempty"`
+}
+
+// destroyConfirmed destroys vmName once the destroy has been confirmed,
+// interactively, with --yes, or by an applied plan.
+func destroyConfirmed(ctx context.Context, cmd *cobra.Command, deps *destroyDeps, cliCtx *cli.CLIContext, vmName string) error {
+	jsonOutput := cliCtx != nil && cliCtx.JSON
+	keepEIP, _ := cmd.Flags().GetBool("keep-eip")
+	w := cmd.OutOrStdout()
+	notes := w
+	if jsonOutput {
+		notes = cmd.ErrOrStderr()
+	}
+
 	// Spinner starts AFTER confirmation is obtained.
-	sp := progress.NewCommandSpinner(w, false)
+	sp := progress.NewCommandSpinner(w, jsonOutput)
 	sp.Start("Terminating VM...")
 
 	// Build Destroyer and run. The destroyer handles: terminate instance,
@@ -143,7 +286,7 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 		deps.deleteVolume,
 		deps.describeAddrs,
 		deps.releaseAddr,
-	).WithWaitTerminated(deps.waitTerminated)
+	).WithWaitTerminated(deps.waitTerminated).WithKeepEIP(keepEIP, deps.createTags)
 
 	// Announce the wait phase before the blocking call so the spinner label
 	// reflects the longest-running part of the operation.
@@ -151,11 +294,12 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 		sp.Update("Waiting for termination...")
 	}
 
-	result, err := destroyer.RunWithResult(ctx, deps.owner, vmName, confirmed)
+	result, err := destroyer.RunWithResult(ctx, deps.owner, vmName, true)
 	if err != nil {
 		sp.Fail(err.Error())
 		return err
 	}
+	cliCtx.TouchResource(cli.ResourceInstance, result.InstanceID)
 
 	// Surface the volume deletion phase after RunWithResult returns so the
 	// non-interactive log captures the cleanup that occurred.
@@ -166,17 +310,34 @@ func runDestroy(cmd *cobra.Command, deps *destroyDeps) error {
 	sp.Stop("")
 
 	for _, warn := range result.Warnings {
-		fmt.Fprintf(w, "Warning: %s\n", warn)
+		fmt.Fprintf(notes, "Warning: %s\n", warn)
 	}
 
 	// Clear the stored host key fingerprint so that 'mint up' after this
 	// destroy doesn't hit a TOFU mismatch on the new VM's fresh host key.
 	if deps.removeHostKey != nil {
 		if err := deps.removeHostKey(vmName); err != nil {
-			fmt.Fprintf(w, "Warning: could not clear stored host key fingerprint: %v\n", err)
+			fmt.Fprintf(notes, "Warning: could not clear stored host key fingerprint: %v\n", err)
 		}
 	}
 
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(destroyJSON{
+			VM:                  vmName,
+			InstanceID:          result.InstanceID,
+			VolumesDeleted:      result.VolumesDeleted,
+			EIPReleased:         result.EIPReleased,
+			KeptEIPAllocationID: result.KeptAllocationID,
+			KeptEIPPublicIP:     result.KeptPublicIP,
+			Warnings:            result.Warnings,
+		})
+	}
 	fmt.Fprintf(w, "VM %q (%s) destroyed.\n", vmName, result.InstanceID)
+	if result.KeptAllocationID != "" {
+		fmt.Fprintf(w, "Kept Elastic IP %s (%s) for the next mint up of %q.\n",
+			result.KeptPublicIP, result.KeptAllocationID, vmName)
+	}
 	return nil
 }
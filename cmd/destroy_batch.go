@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// destroyBatchOutcome is the result of destroying one VM of a batch.
+type destroyBatchOutcome struct {
+	vm     *vm.VM
+	result *provision.DestroyResult
+	err    error
+}
+
+// runDestroyBatch destroys every VM whose name starts with prefix, the
+// counterpart of mint up --name-prefix. The prefix must be typed to confirm
+// unless --yes is set. VMs are destroyed concurrently; a failed VM does not
+// stop the others, and the command exits 1 when any VM failed.
+func runDestroyBatch(cmd *cobra.Command, deps *destroyDeps, prefix string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	yes := cliCtx != nil && cliCtx.Yes
+	w := cmd.OutOrStdout()
+
+	if cmd.Flags().Changed("vm") {
+		return fmt.Errorf("--vm cannot be combined with --name-prefix")
+	}
+
+	all, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		return fmt.Errorf("listing VMs: %w", err)
+	}
+	var targets []*vm.VM
+	for _, v := range all {
+		if strings.HasPrefix(v.Name, prefix) {
+			targets = append(targets, v)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no VMs with name prefix %q found — nothing to destroy", prefix)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+
+	for _, v := range targets {
+		if err := guardSelfTarget(ctx, cmd, deps.selfDetector, v.Name, v.ID, "terminate this VM and delete its project volume"); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "This will permanently destroy %d VMs:\n", len(targets))
+	for _, v := range targets {
+		fmt.Fprintf(w, "  - %s (%s)\n", v.Name, v.ID)
+	}
+	fmt.Fprintf(w, "Instances will be terminated, project EBS volumes deleted, and Elastic IPs released.\n")
+	fmt.Fprintf(w, "User EFS access point is preserved.\n")
+
+	if !yes {
+		fmt.Fprintf(w, "\nType the name prefix %q to confirm: ", prefix)
+		scanner := bufio.NewScanner(cmd.InOrStdin())
+		if !scanner.Scan() {
+			return fmt.Errorf("no confirmation input received — destroy aborted")
+		}
+		if input := strings.TrimSpace(scanner.Text()); input != prefix {
+			return fmt.Errorf("confirmation %q does not match name prefix %q — destroy aborted", input, prefix)
+		}
+	}
+
+	outcomes := make([]destroyBatchOutcome, len(targets))
+	provision.RunBounded(len(targets), provision.DefaultBatchConcurrency, func(i int) {
+		destroyer := provision.NewDestroyer(
+			deps.describe,
+			deps.terminate,
+			deps.describeVolumes,
+			deps.detachVolume,
+			deps.deleteVolume,
+			deps.describeAddrs,
+			deps.releaseAddr,
+		).WithWaitTerminated(deps.waitTerminated)
+		result, err := destroyer.RunWithResult(ctx, deps.owner, targets[i].Name, true)
+		outcomes[i] = destroyBatchOutcome{vm: targets[i], result: result, err: err}
+	})
+
+	// Report and clean up serially: the history context and the host key
+	// store are not safe for concurrent writers.
+	fmt.Fprintln(w)
+	failed := 0
+	for _, o := range outcomes {
+		if o.err != nil {
+			failed++
+			fmt.Fprintf(w, "VM %q (%s) failed: %v\n", o.vm.Name, o.vm.ID, o.err)
+			continue
+		}
+		cliCtx.TouchResource(cli.ResourceInstance, o.result.InstanceID)
+		for _, warn := range o.result.Warnings {
+			fmt.Fprintf(w, "Warning: %s: %s\n", o.vm.Name, warn)
+		}
+		if deps.removeHostKey != nil {
+			if err := deps.removeHostKey(o.vm.Name); err != nil {
+				fmt.Fprintf(w, "Warning: %s: could not clear stored host key fingerprint: %v\n", o.vm.Name, err)
+			}
+		}
+		fmt.Fprintf(w, "VM %q (%s) destroyed.\n", o.vm.Name, o.result.InstanceID)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(w, "\n%d of %d VMs failed to destroy.\n", failed, len(outcomes))
+		return silentExitError{}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+)
+
+// newBatchDestroyDeps returns happy destroy deps listing alice's VMs ws-01,
+// ws-02, ws-03, and other.
+func newBatchDestroyDeps(terminate *cmdtest.TerminateInstances) *destroyDeps {
+	deps := newHappyDestroyDeps("alice")
+	deps.describe = &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(
+		makeRunningInstance("i-ws01", "ws-01", "alice"),
+		makeRunningInstance("i-ws02", "ws-02", "alice"),
+		makeRunningInstance("i-ws03", "ws-03", "alice"),
+		makeRunningInstance("i-other", "other", "alice"),
+	)}
+	deps.terminate = terminate
+	return deps
+}
+
+func runDestroyBatchCommand(t *testing.T, deps *destroyDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"destroy"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestDestroyBatchDestroysPrefixMatches(t *testing.T) {
+	terminate := &cmdtest.TerminateInstances{Output: &ec2.TerminateInstancesOutput{}}
+	deps := newBatchDestroyDeps(terminate)
+	var mu sync.Mutex
+	var clearedKeys []string
+	deps.removeHostKey = func(vmName string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		clearedKeys = append(clearedKeys, vmName)
+		return nil
+	}
+
+	out, err := runDestroyBatchCommand(t, deps, "ws-\n", "--name-prefix", "ws-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	sort.Strings(terminate.Terminated)
+	if strings.Join(terminate.Terminated, ",") != "i-ws01,i-ws02,i-ws03" {
+		t.Errorf("terminated = %v, want the three ws- VMs only", terminate.Terminated)
+	}
+	if len(clearedKeys) != 3 {
+		t.Errorf("cleared host keys = %v", clearedKeys)
+	}
+	for _, want := range []string{
+		"This will permanently destroy 3 VMs:",
+		"ws-01 (i-ws01)",
+		`Type the name prefix "ws-" to confirm`,
+		`VM "ws-03" (i-ws03) destroyed.`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "i-other") {
+		t.Errorf("non-matching VM listed:\n%s", out)
+	}
+}
+
+func TestDestroyBatchPartialFailure(t *testing.T) {
+	terminate := &cmdtest.TerminateInstances{Output: &ec2.TerminateInstancesOutput{}, FailIDs: map[string]error{"i-ws02": errors.New("UnauthorizedOperation")}}
+
+	out, err := runDestroyBatchCommand(t, newBatchDestroyDeps(terminate), "", "--name-prefix", "ws-", "--yes")
+	if !errors.As(err, &silentExitError{}) {
+		t.Fatalf("error = %v, want silentExitError", err)
+	}
+	if len(terminate.Terminated) != 2 {
+		t.Errorf("terminated = %v, want the other two VMs", terminate.Terminated)
+	}
+	for _, want := range []string{
+		`VM "ws-02" (i-ws02) failed:`,
+		"UnauthorizedOperation",
+		"1 of 3 VMs failed to destroy.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDestroyBatchRefusals(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdin   string
+		args    []string
+		wantErr string
+	}{
+		{name: "wrong confirmation", stdin: "ws-01\n", args: []string{"--name-prefix", "ws-"}, wantErr: "does not match name prefix"},
+		{name: "no matches", args: []string{"--name-prefix", "lab-", "--yes"}, wantErr: `no VMs with name prefix "lab-" found`},
+		{name: "vm with prefix", args: []string{"--name-prefix", "ws-", "--vm", "dev", "--yes"}, wantErr: "--vm cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminate := &cmdtest.TerminateInstances{Output: &ec2.TerminateInstancesOutput{}}
+			_, err := runDestroyBatchCommand(t, newBatchDestroyDeps(terminate), tt.stdin, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+			if len(terminate.Terminated) != 0 {
+				t.Errorf("terminated %v despite refusal", terminate.Terminated)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// destroyPlanVersion is the format version of destroy plan documents.
+// --apply rejects plans with any other version.
+const destroyPlanVersion = 1
+
+// destroyPlan is the document mint destroy --plan writes: everything the
+// destroy would delete, and what it would preserve, as of CreatedAt.
+// mint destroy --apply deletes exactly these resources or nothing.
+type destroyPlan struct {
+	Version    int                  `json:"version"`
+	CreatedAt  time.Time            `json:"created_at"`
+	ExpiresAt  time.Time            `json:"expires_at"`
+	Owner      string               `json:"owner"`
+	VM         string               `json:"vm"`
+	Region     string               `json:"region,omitempty"`
+	Instance   destroyPlanInstance  `json:"instance"`
+	Volumes    []destroyPlanVolume  `json:"volumes"`
+	ElasticIPs []destroyPlanAddress `json:"elastic_ips"`
+	Preserved  []string             `json:"preserved"`
+	DataLoss   destroyPlanDataLoss  `json:"data_loss"`
+}
+
+type destroyPlanInstance struct {
+	ID    string `json:"id"`
+	ARN   string `json:"arn,omitempty"`
+	State string `json:"state"`
+	Type  string `json:"type"`
+	// RootVolumeGiB is the root volume EC2 deletes with the instance.
+	RootVolumeGiB int `json:"root_volume_gib"`
+}
+
+type destroyPlanVolume struct {
+	ID           string               `json:"id"`
+	ARN          string               `json:"arn,omitempty"`
+	SizeGiB      int32                `json:"size_gib"`
+	State        string               `json:"state"`
+	LastSnapshot *destroyPlanSnapshot `json:"last_snapshot,omitempty"`
+}
+
+type destroyPlanSnapshot struct {
+	ID        string    `json:"id"`
+	StartTime time.Time `json:"start_time"`
+}
+
+type destroyPlanAddress struct {
+	AllocationID string `json:"allocation_id"`
+	ARN          string `json:"arn,omitempty"`
+	PublicIP     string `json:"public_ip"`
+}
+
+// destroyPlanDataLoss estimates the project data the destroy deletes.
+type destroyPlanDataLoss struct {
+	ProjectVolumeGiB int32 `json:"project_volume_gib"`
+	// LastSnapshotAt is the newest snapshot of any project volume, or nil
+	// when none has been snapshotted.
+	LastSnapshotAt  *time.Time `json:"last_snapshot_at,omitempty"`
+	LastSnapshotAge string     `json:"last_snapshot_age,omitempty"`
+	Summary         string     `json:"summary"`
+}
+
+// runDestroyPlan writes the destroy plan for vmName to path without
+// deleting anything.
+func runDestroyPlan(cmd *cobra.Command, deps *destroyDeps, vmName, path string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	plan, err := gatherDestroyPlan(ctx, deps, vmName, deps.clock())
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding destroy plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing destroy plan: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Destroy plan for VM %q written to %s.\n", vmName, path)
+	writeDestroyPlanSummary(w, plan)
+	fmt.Fprintf(w, "\nNothing was deleted. The plan can be applied until %s with %s\n",
+		plan.ExpiresAt.Local().Format(time.RFC1123), hint.Cmd("mint destroy --apply "+path))
+	return nil
+}
+
+// runDestroyApply deletes the resources of the plan at path without
+// prompting, after checking that the plan has not expired and that the live
+// resources still match it.
+func runDestroyApply(cmd *cobra.Command, deps *destroyDeps, path string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	plan, err := readDestroyPlan(path)
+	if err != nil {
+		return err
+	}
+	if plan.Owner != deps.owner {
+		return fmt.Errorf("destroy plan %s was made for owner %q, not %q", path, plan.Owner, deps.owner)
+	}
+	if flag := cmd.Flag("vm"); flag != nil && flag.Changed && flag.Value.String() != plan.VM {
+		return fmt.Errorf("destroy plan %s is for VM %q, not %q", path, plan.VM, flag.Value.String())
+	}
+
+	now := deps.clock()
+	maxAge := deps.maxPlanAge()
+	if age := now.Sub(plan.CreatedAt); age > maxAge {
+		return fmt.Errorf("destroy plan %s expired: it was made %s ago and plans are valid for %s — run %s again",
+			path, format.FormatDuration(age.Truncate(time.Minute)), format.FormatDuration(maxAge),
+			hint.Cmd("mint destroy --plan"))
+	}
+
+	live, err := gatherDestroyPlan(ctx, deps, plan.VM, now)
+	if err != nil {
+		return err
+	}
+	if drift := diffDestroyPlan(plan, live); len(drift) > 0 {
+		return fmt.Errorf("resources changed since the plan was made — destroy aborted:\n  %s\nRun %s again to review the current state",
+			strings.Join(drift, "\n  "), hint.Cmd("mint destroy --plan"))
+	}
+
+	if err := guardSelfTarget(ctx, cmd, deps.selfDetector, plan.VM, plan.Instance.ID, "terminate this VM and delete its project volume"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Applying destroy plan %s for VM %q (%s).\n", path, plan.VM, plan.Instance.ID)
+	return destroyConfirmed(ctx, cmd, deps, cli.FromCommand(cmd), plan.VM)
+}
+
+// readDestroyPlan reads and checks the plan document at path.
+func readDestroyPlan(path string) (*destroyPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading destroy plan: %w", err)
+	}
+	var plan destroyPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing destroy plan %s: %w", path, err)
+	}
+	if plan.Version != destroyPlanVersion {
+		return nil, fmt.Errorf("destroy plan %s has version %d; this mint reads version %d", path, plan.Version, destroyPlanVersion)
+	}
+	if plan.VM == "" || plan.Instance.ID == "" || plan.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("destroy plan %s is incomplete", path)
+	}
+	return &plan, nil
+}
+
+// gatherDestroyPlan looks up everything destroying vmName would delete. It
+// finds project volumes and Elastic IPs by the same tags as the destroyer.
+func gatherDestroyPlan(ctx context.Context, deps *destroyDeps, vmName string, now time.Time) (*destroyPlan, error) {
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		if err := notOwnedError(ctx, deps.describe, deps.owner, vmName, "destroy"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no VM %q found — nothing to destroy", vmName)
+	}
+
+	arns := newEC2ARNs(deps.ownerARN, deps.region)
+	plan := &destroyPlan{
+		Version:   destroyPlanVersion,
+		CreatedAt: now.UTC(),
+		ExpiresAt: now.Add(deps.maxPlanAge()).UTC(),
+		Owner:     deps.owner,
+		VM:        vmName,
+		Region:    deps.region,
+		Instance: destroyPlanInstance{
+			ID:            found.ID,
+			ARN:           arns.arn("instance", found.ID),
+			State:         found.State,
+			Type:          found.InstanceType,
+			RootVolumeGiB: found.RootVolumeGB,
+		},
+		Volumes:    []destroyPlanVolume{},
+		ElasticIPs: []destroyPlanAddress{},
+		Preserved:  []string{"User EFS access point"},
+	}
+
+	volOut, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: componentFilters(deps.owner, vmName, tags.ComponentProjectVolume),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering project volumes: %w", err)
+	}
+	for _, v := range volOut.Volumes {
+		id := aws.ToString(v.VolumeId)
+		pv := destroyPlanVolume{
+			ID:      id,
+			ARN:     arns.arn("volume", id),
+			SizeGiB: aws.ToInt32(v.Size),
+			State:   string(v.State),
+		}
+		if deps.describeSnapshots != nil {
+			snap, err := lastSnapshot(ctx, deps, id)
+			if err != nil {
+				return nil, fmt.Errorf("discovering snapshots of %s: %w", id, err)
+			}
+			pv.LastSnapshot = snap
+		}
+		plan.Volumes = append(plan.Volumes, pv)
+	}
+	sort.Slice(plan.Volumes, func(i, j int) bool { return plan.Volumes[i].ID < plan.Volumes[j].ID })
+
+	addrOut, err := deps.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: componentFilters(deps.owner, vmName, tags.ComponentElasticIP),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering Elastic IP: %w", err)
+	}
+	for _, a := range addrOut.Addresses {
+		id := aws.ToString(a.AllocationId)
+		plan.ElasticIPs = append(plan.ElasticIPs, destroyPlanAddress{
+			AllocationID: id,
+			ARN:          arns.arn("elastic-ip", id),
+			PublicIP:     aws.ToString(a.PublicIp),
+		})
+	}
+	sort.Slice(plan.ElasticIPs, func(i, j int) bool { return plan.ElasticIPs[i].AllocationID < plan.ElasticIPs[j].AllocationID })
+
+	plan.DataLoss = estimateDataLoss(plan.Volumes, now)
+	return plan, nil
+}
+
+// componentFilters selects the resources of one mint component of vmName.
+func componentFilters(owner, vmName, component string) []ec2types.Filter {
+	return append(tags.FilterByOwnerAndVM(owner, vmName), ec2types.Filter{
+		Name:   aws.String("tag:" + tags.TagComponent),
+		Values: []string{component},
+	})
+}
+
+// lastSnapshot returns the newest snapshot of volumeID owned by the account,
+// or nil when there is none.
+func lastSnapshot(ctx context.Context, deps *destroyDeps, volumeID string) (*destroyPlanSnapshot, error) {
+	out, err := deps.describeSnapshots.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+		Filters: []ec2types.Filter{{
+			Name:   aws.String("volume-id"),
+			Values: []string{volumeID},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var newest *destroyPlanSnapshot
+	for _, s := range out.Snapshots {
+		start := aws.ToTime(s.StartTime)
+		if newest == nil || start.After(newest.StartTime) {
+			newest = &destroyPlanSnapshot{ID: aws.ToString(s.SnapshotId), StartTime: start.UTC()}
+		}
+	}
+	return newest, nil
+}
+
+// estimateDataLoss totals the project volumes and finds their newest
+// snapshot, which bounds how much recent work cannot be recovered.
+func estimateDataLoss(volumes []destroyPlanVolume, now time.Time) destroyPlanDataLoss {
+	var loss destroyPlanDataLoss
+	for _, v := range volumes {
+		loss.ProjectVolumeGiB += v.SizeGiB
+		if v.LastSnapshot != nil && (loss.LastSnapshotAt == nil || v.LastSnapshot.StartTime.After(*loss.LastSnapshotAt)) {
+			at := v.LastSnapshot.StartTime
+			loss.LastSnapshotAt = &at
+		}
+	}
+
+	switch {
+	case len(volumes) == 0:
+		loss.Summary = "no project volume; only the root volume is deleted"
+	case loss.LastSnapshotAt == nil:
+		loss.Summary = fmt.Sprintf("%d GiB of project data is deleted and has no snapshot", loss.ProjectVolumeGiB)
+	default:
+		loss.LastSnapshotAge = format.FormatDuration(now.Sub(*loss.LastSnapshotAt).Truncate(time.Minute))
+		loss.Summary = fmt.Sprintf("%d GiB of project data is deleted; changes since the last snapshot %s ago are lost",
+			loss.ProjectVolumeGiB, loss.LastSnapshotAge)
+	}
+	return loss
+}
+
+// diffDestroyPlan lists how live differs from planned. Only the resources
+// and states the destroy acts on are compared.
+func diffDestroyPlan(planned, live *destroyPlan) []string {
+	var drift []string
+	if planned.Instance.ID != live.Instance.ID {
+		drift = append(drift, fmt.Sprintf("instance: planned %s, now %s", planned.Instance.ID, live.Instance.ID))
+	} else if planned.Instance.State != live.Instance.State {
+		drift = append(drift, fmt.Sprintf("instance %s: state %s → %s", live.Instance.ID, planned.Instance.State, live.Instance.State))
+	}
+
+	liveVolumes := make(map[string]destroyPlanVolume, len(live.Volumes))
+	for _, v := range live.Volumes {
+		liveVolumes[v.ID] = v
+	}
+	for _, p := range planned.Volumes {
+		l, ok := liveVolumes[p.ID]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("volume %s: no longer exists", p.ID))
+			continue
+		}
+		delete(liveVolumes, p.ID)
+		if p.State != l.State {
+			drift = append(drift, fmt.Sprintf("volume %s: state %s → %s", p.ID, p.State, l.State))
+		}
+		if p.SizeGiB != l.SizeGiB {
+			drift = append(drift, fmt.Sprintf("volume %s: size %d GiB → %d GiB", p.ID, p.SizeGiB, l.SizeGiB))
+		}
+	}
+	for _, l := range live.Volumes {
+		if _, added := liveVolumes[l.ID]; added {
+			drift = append(drift, fmt.Sprintf("volume %s: not in the plan", l.ID))
+		}
+	}
+
+	liveAddrs := make(map[string]destroyPlanAddress, len(live.ElasticIPs))
+	for _, a := range live.ElasticIPs {
+		liveAddrs[a.AllocationID] = a
+	}
+	for _, p := range planned.ElasticIPs {
+		l, ok := liveAddrs[p.AllocationID]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("Elastic IP %s: no longer exists", p.AllocationID))
+			continue
+		}
+		delete(liveAddrs, p.AllocationID)
+		if p.PublicIP != l.PublicIP {
+			drift = append(drift, fmt.Sprintf("Elastic IP %s: address %s → %s", p.AllocationID, p.PublicIP, l.PublicIP))
+		}
+	}
+	for _, l := range live.ElasticIPs {
+		if _, added := liveAddrs[l.AllocationID]; added {
+			drift = append(drift, fmt.Sprintf("Elastic IP %s: not in the plan", l.AllocationID))
+		}
+	}
+	return drift
+}
+
+// writeDestroyPlanSummary prints the resources of plan, one per line.
+func writeDestroyPlanSummary(w io.Writer, plan *destroyPlan) {
+	fmt.Fprintf(w, "  - Instance %s (%s, %s) will be terminated (%d GiB root volume auto-destroyed)\n",
+		plan.Instance.ID, plan.Instance.Type, plan.Instance.State, plan.Instance.RootVolumeGiB)
+	for _, v := range plan.Volumes {
+		snapshot := "no snapshot"
+		if v.LastSnapshot != nil {
+			snapshot = fmt.Sprintf("last snapshot %s at %s", v.LastSnapshot.ID, v.LastSnapshot.StartTime.Format(time.RFC3339))
+		}
+		fmt.Fprintf(w, "  - Volume %s (%d GiB, %s) will be deleted\n", v.ID, v.SizeGiB, snapshot)
+	}
+	for _, a := range plan.ElasticIPs {
+		fmt.Fprintf(w, "  - Elastic IP %s (%s) will be released\n", a.AllocationID, a.PublicIP)
+	}
+	for _, p := range plan.Preserved {
+		fmt.Fprintf(w, "  - %s is preserved\n", p)
+	}
+	fmt.Fprintf(w, "Data loss: %s\n", plan.DataLoss.Summary)
+}
+
+// ec2ARNs builds EC2 resource ARNs in the caller's partition and account,
+// taken from the owner ARN. Without a parseable owner ARN or a region the
+// ARNs are left empty.
+type ec2ARNs struct {
+	partition, region, account string
+}
+
+func newEC2ARNs(ownerARN, region string) ec2ARNs {
+	parsed, err := arn.Parse(ownerARN)
+	if err != nil || region == "" {
+		return ec2ARNs{}
+	}
+	return ec2ARNs{partition: parsed.Partition, region: region, account: parsed.AccountID}
+}
+
+func (a ec2ARNs) arn(resourceType, id string) string {
+	if a.account == "" {
+		return ""
+	}
+	return arn.ARN{
+		Partition: a.partition,
+		Service:   "ec2",
+		Region:    a.region,
+		AccountID: a.account,
+		Resource:  resourceType + "/" + id,
+	}.String()
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+)
+
+// planTime is when the plans in these tests are made.
+var planTime = time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+
+// newPlanDestroyDeps returns happy destroy deps whose clock reads at.
+func newPlanDestroyDeps(at time.Time) *destroyDeps {
+	deps := newHappyDestroyDeps("alice")
+	deps.describeVolumes = &cmdtest.DescribeVolumes{
+		Output: &ec2.DescribeVolumesOutput{
+			Volumes: []ec2types.Volume{{
+				VolumeId: aws.String("vol-proj1"),
+				Size:     aws.Int32(50),
+				State:    ec2types.VolumeStateInUse,
+			}},
+		},
+	}
+	deps.describeSnapshots = &cmdtest.DescribeSnapshots{
+		Output: &ec2.DescribeSnapshotsOutput{
+			Snapshots: []ec2types.Snapshot{
+				{SnapshotId: aws.String("snap-old"), StartTime: aws.Time(planTime.Add(-10 * 24 * time.Hour))},
+				{SnapshotId: aws.String("snap-new"), StartTime: aws.Time(planTime.Add(-3 * 24 * time.Hour))},
+			},
+		},
+	}
+	deps.ownerARN = "arn:aws:iam::123456789012:user/alice"
+	deps.region = "us-east-1"
+	deps.now = func() time.Time { return at }
+	return deps
+}
+
+func runDestroyWith(t *testing.T, deps *destroyDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs(append([]string{"destroy"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// writeTestPlan runs destroy --plan at planTime and returns the plan path.
+func writeTestPlan(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if out, err := runDestroyWith(t, newPlanDestroyDeps(planTime), "--plan", path); err != nil {
+		t.Fatalf("destroy --plan: %v\n%s", err, out)
+	}
+	return path
+}
+
+func TestDestroyPlanWritesDocument(t *testing.T) {
+	deps := newPlanDestroyDeps(planTime)
+	path := filepath.Join(t.TempDir(), "plan.json")
+
+	out, err := runDestroyWith(t, deps, "--plan", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if deps.terminate.(*cmdtest.TerminateInstances).Called {
+		t.Error("--plan must not terminate the instance")
+	}
+	for _, want := range []string{
+		`Destroy plan for VM "default" written to ` + path,
+		"Volume vol-proj1 (50 GiB, last snapshot snap-new",
+		"Elastic IP eipalloc-abc (1.2.3.4) will be released",
+		"User EFS access point is preserved",
+		"Nothing was deleted.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading plan: %v", err)
+	}
+	var plan destroyPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		t.Fatalf("plan is not JSON: %v", err)
+	}
+	if plan.Version != destroyPlanVersion || plan.Owner != "alice" || plan.VM != "default" {
+		t.Errorf("plan header = %+v", plan)
+	}
+	if !plan.ExpiresAt.Equal(planTime.Add(time.Hour)) {
+		t.Errorf("ExpiresAt = %s, want an hour after planning", plan.ExpiresAt)
+	}
+	if got, want := plan.Instance.ARN, "arn:aws:ec2:us-east-1:123456789012:instance/i-abc123"; got != want {
+		t.Errorf("instance ARN = %q, want %q", got, want)
+	}
+	if len(plan.Volumes) != 1 || plan.Volumes[0].SizeGiB != 50 || plan.Volumes[0].LastSnapshot == nil || plan.Volumes[0].LastSnapshot.ID != "snap-new" {
+		t.Errorf("volumes = %+v", plan.Volumes)
+	}
+	if len(plan.ElasticIPs) != 1 || plan.ElasticIPs[0].ARN != "arn:aws:ec2:us-east-1:123456789012:elastic-ip/eipalloc-abc" {
+		t.Errorf("elastic IPs = %+v", plan.ElasticIPs)
+	}
+	if plan.DataLoss.ProjectVolumeGiB != 50 || plan.DataLoss.LastSnapshotAge != "3d" {
+		t.Errorf("data loss = %+v", plan.DataLoss)
+	}
+}
+
+func TestDestroyApplyCleanPlan(t *testing.T) {
+	path := writeTestPlan(t)
+	deps := newPlanDestroyDeps(planTime.Add(10 * time.Minute))
+
+	// No --yes and no stdin: an applied plan must not prompt.
+	out, err := runDestroyWith(t, deps, "--apply", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !deps.terminate.(*cmdtest.TerminateInstances).Called {
+		t.Error("instance was not terminated")
+	}
+	if got := deps.deleteVolume.(*cmdtest.DeleteVolume).Deleted; !slices.Equal(got, []string{"vol-proj1"}) {
+		t.Errorf("deleted volumes = %v", got)
+	}
+	if got := deps.releaseAddr.(*cmdtest.ReleaseAddress).Released; !slices.Equal(got, []string{"eipalloc-abc"}) {
+		t.Errorf("released addresses = %v", got)
+	}
+	if !strings.Contains(out, `VM "default" (i-abc123) destroyed.`) {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestDestroyApplyRejectsDrift(t *testing.T) {
+	path := writeTestPlan(t)
+
+	tests := []struct {
+		name   string
+		change func(*destroyDeps)
+		want   string
+	}{
+		{
+			name: "instance changed state",
+			change: func(d *destroyDeps) {
+				d.describe = &cmdtest.DescribeInstances{Output: makeStoppedInstance("i-abc123", "default", "alice")}
+			},
+			want: "instance i-abc123: state running → stopped",
+		},
+		{
+			name: "volume added",
+			change: func(d *destroyDeps) {
+				d.describeVolumes = &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+					{VolumeId: aws.String("vol-proj1"), Size: aws.Int32(50), State: ec2types.VolumeStateInUse},
+					{VolumeId: aws.String("vol-proj2"), Size: aws.Int32(100), State: ec2types.VolumeStateAvailable},
+				}}}
+			},
+			want: "volume vol-proj2: not in the plan",
+		},
+		{
+			name: "Elastic IP released",
+			change: func(d *destroyDeps) {
+				d.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}}
+			},
+			want: "Elastic IP eipalloc-abc: no longer exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newPlanDestroyDeps(planTime.Add(time.Minute))
+			tt.change(deps)
+
+			_, err := runDestroyWith(t, deps, "--apply", path)
+			if err == nil || !strings.Contains(err.Error(), "destroy aborted") || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want drift containing %q", err, tt.want)
+			}
+			if deps.terminate.(*cmdtest.TerminateInstances).Called {
+				t.Error("a drifted plan must not terminate anything")
+			}
+		})
+	}
+}
+
+func TestDestroyApplyRejectsExpiredPlan(t *testing.T) {
+	path := writeTestPlan(t)
+
+	deps := newPlanDestroyDeps(planTime.Add(2 * time.Hour))
+	_, err := runDestroyWith(t, deps, "--apply", path)
+	if err == nil || !strings.Contains(err.Error(), "expired: it was made 2h ago and plans are valid for 1h") {
+		t.Fatalf("error = %v, want expired plan", err)
+	}
+	if deps.terminate.(*cmdtest.TerminateInstances).Called {
+		t.Error("an expired plan must not terminate anything")
+	}
+
+	// destroy_plan_max_age extends the window.
+	deps = newPlanDestroyDeps(planTime.Add(2 * time.Hour))
+	deps.planMaxAge = 3 * time.Hour
+	if out, err := runDestroyWith(t, deps, "--apply", path); err != nil {
+		t.Fatalf("plan within destroy_plan_max_age rejected: %v\n%s", err, out)
+	}
+}
+
+func TestDestroyPlanFlagErrors(t *testing.T) {
+	path := writeTestPlan(t)
+
+	tests := []struct {
+		name string
+		deps *destroyDeps
+		args []string
+		want string
+	}{
+		{"plan with apply", newPlanDestroyDeps(planTime), []string{"--plan", "a.json", "--apply", path}, "cannot be used together"},
+		{"apply with name prefix", newPlanDestroyDeps(planTime), []string{"--apply", path, "--name-prefix", "ws-"}, "cannot be combined with --name-prefix"},
+		{"another owner's plan", func() *destroyDeps {
+			d := newPlanDestroyDeps(planTime)
+			d.owner = "bob"
+			return d
+		}(), []string{"--apply", path}, `made for owner "alice", not "bob"`},
+		{"plan for another VM", newPlanDestroyDeps(planTime), []string{"--apply", path, "--vm", "staging"}, `is for VM "default", not "staging"`},
+		{"missing plan", newPlanDestroyDeps(planTime), []string{"--apply", filepath.Join(t.TempDir(), "none.json")}, "reading destroy plan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runDestroyWith(t, tt.deps, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want containing %q", err, tt.want)
+			}
+			if tt.deps.terminate.(*cmdtest.TerminateInstances).Called {
+				t.Error("nothing should be terminated")
+			}
+		})
+	}
+}
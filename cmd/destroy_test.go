@@ -3,87 +3,24 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/spf13/cobra"
 )
 
 // ---------------------------------------------------------------------------
 // Inline mocks for destroy command tests
 // ---------------------------------------------------------------------------
 
-type mockDestroyDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDestroyDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-type mockDestroyTerminateInstances struct {
-	output *ec2.TerminateInstancesOutput
-	err    error
-	called bool
-}
-
-func (m *mockDestroyTerminateInstances) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
-	m.called = true
-	return m.output, m.err
-}
-
-type mockDestroyDescribeVolumes struct {
-	output *ec2.DescribeVolumesOutput
-	err    error
-}
-
-func (m *mockDestroyDescribeVolumes) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
-	return m.output, m.err
-}
-
-type mockDestroyDetachVolume struct {
-	output *ec2.DetachVolumeOutput
-	err    error
-}
-
-func (m *mockDestroyDetachVolume) DetachVolume(ctx context.Context, params *ec2.DetachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DetachVolumeOutput, error) {
-	return m.output, m.err
-}
-
-type mockDestroyDeleteVolume struct {
-	output *ec2.DeleteVolumeOutput
-	err    error
-}
-
-func (m *mockDestroyDeleteVolume) DeleteVolume(ctx context.Context, params *ec2.DeleteVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVolumeOutput, error) {
-	return m.output, m.err
-}
-
-type mockDestroyDescribeAddresses struct {
-	output *ec2.DescribeAddressesOutput
-	err    error
-}
-
-func (m *mockDestroyDescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
-	return m.output, m.err
-}
-
-type mockDestroyReleaseAddress struct {
-	output *ec2.ReleaseAddressOutput
-	err    error
-}
-
-func (m *mockDestroyReleaseAddress) ReleaseAddress(ctx context.Context, params *ec2.ReleaseAddressInput, optFns ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error) {
-	return m.output, m.err
-}
-
 type mockDestroyWaitTerminated struct {
 	err    error
 	called bool
@@ -100,64 +37,43 @@ func (m *mockDestroyWaitTerminated) Wait(ctx context.Context, params *ec2.Descri
 
 func newHappyDestroyDeps(owner string) *destroyDeps {
 	return &destroyDeps{
-		describe: &mockDestroyDescribeInstances{
-			output: makeRunningInstance("i-abc123", "default", owner),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstance("i-abc123", "default", owner),
 		},
-		terminate: &mockDestroyTerminateInstances{
-			output: &ec2.TerminateInstancesOutput{},
+		terminate: &cmdtest.TerminateInstances{
+			Output: &ec2.TerminateInstancesOutput{},
 		},
 		// waitTerminated is nil by default so existing tests run without a waiter.
 		waitTerminated: nil,
-		describeVolumes: &mockDestroyDescribeVolumes{
-			output: &ec2.DescribeVolumesOutput{
+		describeVolumes: &cmdtest.DescribeVolumes{
+			Output: &ec2.DescribeVolumesOutput{
 				Volumes: []ec2types.Volume{{
 					VolumeId: aws.String("vol-proj1"),
 					State:    ec2types.VolumeStateAvailable,
 				}},
 			},
 		},
-		detachVolume: &mockDestroyDetachVolume{
-			output: &ec2.DetachVolumeOutput{},
+		detachVolume: &cmdtest.DetachVolume{
+			Output: &ec2.DetachVolumeOutput{},
 		},
-		deleteVolume: &mockDestroyDeleteVolume{
-			output: &ec2.DeleteVolumeOutput{},
+		deleteVolume: &cmdtest.DeleteVolume{
+			Output: &ec2.DeleteVolumeOutput{},
 		},
-		describeAddrs: &mockDestroyDescribeAddresses{
-			output: &ec2.DescribeAddressesOutput{
+		describeAddrs: &cmdtest.DescribeAddresses{
+			Output: &ec2.DescribeAddressesOutput{
 				Addresses: []ec2types.Address{{
 					AllocationId: aws.String("eipalloc-abc"),
 					PublicIp:     aws.String("1.2.3.4"),
 				}},
 			},
 		},
-		releaseAddr: &mockDestroyReleaseAddress{
-			output: &ec2.ReleaseAddressOutput{},
+		releaseAddr: &cmdtest.ReleaseAddress{
+			Output: &ec2.ReleaseAddressOutput{},
 		},
 		owner: owner,
 	}
 }
 
-// newDestroyTestRoot creates a test root with --yes flag support and stdin override.
-func newDestroyTestRoot(sub *cobra.Command) *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	root.AddCommand(sub)
-	return root
-}
-
 // ---------------------------------------------------------------------------
 // Tests
 // ---------------------------------------------------------------------------
@@ -197,8 +113,8 @@ func TestDestroyCommand(t *testing.T) {
 			name: "no VM found returns error",
 			deps: func() *destroyDeps {
 				d := newHappyDestroyDeps("alice")
-				d.describe = &mockDestroyDescribeInstances{
-					output: &ec2.DescribeInstancesOutput{},
+				d.describe = &cmdtest.DescribeInstances{
+					Output: &ec2.DescribeInstancesOutput{},
 				}
 				return d
 			}(),
@@ -242,9 +158,9 @@ func TestDestroyCommand(t *testing.T) {
 			},
 		},
 		{
-			name: "shows what will be destroyed before confirming",
-			deps: newHappyDestroyDeps("alice"),
-			args: []string{"destroy"},
+			name:  "shows what will be destroyed before confirming",
+			deps:  newHappyDestroyDeps("alice"),
+			args:  []string{"destroy"},
 			stdin: "default\n",
 			wantOutput: []string{
 				"i-abc123",
@@ -255,8 +171,8 @@ func TestDestroyCommand(t *testing.T) {
 			name: "non-default vm name",
 			deps: func() *destroyDeps {
 				d := newHappyDestroyDeps("bob")
-				d.describe = &mockDestroyDescribeInstances{
-					output: makeRunningInstance("i-dev456", "dev", "bob"),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeRunningInstance("i-dev456", "dev", "bob"),
 				}
 				return d
 			}(),
@@ -267,8 +183,8 @@ func TestDestroyCommand(t *testing.T) {
 			name: "describe error propagates",
 			deps: func() *destroyDeps {
 				d := newHappyDestroyDeps("alice")
-				d.describe = &mockDestroyDescribeInstances{
-					err: fmt.Errorf("API throttled"),
+				d.describe = &cmdtest.DescribeInstances{
+					Err: fmt.Errorf("API throttled"),
 				}
 				return d
 			}(),
@@ -280,8 +196,8 @@ func TestDestroyCommand(t *testing.T) {
 			name: "terminate error propagates",
 			deps: func() *destroyDeps {
 				d := newHappyDestroyDeps("alice")
-				d.terminate = &mockDestroyTerminateInstances{
-					err: fmt.Errorf("insufficient permissions"),
+				d.terminate = &cmdtest.TerminateInstances{
+					Err: fmt.Errorf("insufficient permissions"),
 				}
 				return d
 			}(),
@@ -295,7 +211,7 @@ func TestDestroyCommand(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := new(bytes.Buffer)
 			cmd := newDestroyCommandWithDeps(tt.deps)
-			root := newDestroyTestRoot(cmd)
+			root := cmdtest.NewRoot(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
 
@@ -343,7 +259,7 @@ func TestDestroyCommandWaiterCalled(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDestroyCommandWithDeps(deps)
-	root := newDestroyTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"destroy", "--yes"})
@@ -368,7 +284,7 @@ func TestDestroyCommandWaiterErrorPropagates(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDestroyCommandWithDeps(deps)
-	root := newDestroyTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"destroy", "--yes"})
@@ -398,7 +314,7 @@ func TestDestroyCommandRemovesHostKey(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDestroyCommandWithDeps(deps)
-	root := newDestroyTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"destroy", "--yes"})
@@ -417,8 +333,8 @@ func TestDestroyCommandRemovesHostKey(t *testing.T) {
 func TestDestroyCommandRemoveHostKeyNotCalledOnFailure(t *testing.T) {
 	called := false
 	deps := newHappyDestroyDeps("alice")
-	deps.terminate = &mockDestroyTerminateInstances{
-		err: fmt.Errorf("terminate failed"),
+	deps.terminate = &cmdtest.TerminateInstances{
+		Err: fmt.Errorf("terminate failed"),
 	}
 	deps.removeHostKey = func(vmName string) error {
 		called = true
@@ -427,7 +343,7 @@ func TestDestroyCommandRemoveHostKeyNotCalledOnFailure(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDestroyCommandWithDeps(deps)
-	root := newDestroyTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"destroy", "--yes"})
@@ -451,7 +367,7 @@ func TestDestroyCommandRemoveHostKeyErrorIsNonFatal(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDestroyCommandWithDeps(deps)
-	root := newDestroyTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"destroy", "--yes"})
@@ -465,3 +381,55 @@ func TestDestroyCommandRemoveHostKeyErrorIsNonFatal(t *testing.T) {
 		t.Errorf("expected warning about host key removal failure in output, got: %s", output)
 	}
 }
+
+func TestDestroyCommandKeepEIP(t *testing.T) {
+	deps := newHappyDestroyDeps("alice")
+	release := deps.releaseAddr.(*cmdtest.ReleaseAddress)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	root.SetIn(strings.NewReader("default\n"))
+	root.SetArgs([]string{"destroy", "--keep-eip", "--json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr.String())
+	}
+
+	if len(release.Released) != 0 {
+		t.Errorf("released %v, want the Elastic IP kept", release.Released)
+	}
+	if !slices.Contains(deps.deleteVolume.(*cmdtest.DeleteVolume).Deleted, "vol-proj1") {
+		t.Error("the project volume should still be deleted")
+	}
+	if !strings.Contains(stderr.String(), "Elastic IP is kept for the next mint up") {
+		t.Errorf("prompt should say the Elastic IP is kept, got:\n%s", stderr.String())
+	}
+	var result destroyJSON
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout.String())
+	}
+	want := destroyJSON{VM: "default", InstanceID: "i-abc123", VolumesDeleted: 1,
+		KeptEIPAllocationID: "eipalloc-abc", KeptEIPPublicIP: "1.2.3.4"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("JSON = %+v, want %+v", result, want)
+	}
+}
+
+func TestDestroyCommandKeepEIPConflicts(t *testing.T) {
+	for _, args := range [][]string{
+		{"--plan", "plan.json"},
+		{"--apply", "plan.json"},
+		{"--name-prefix", "batch"},
+	} {
+		deps := newHappyDestroyDeps("alice")
+		root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+		root.SetOut(new(bytes.Buffer))
+		root.SetErr(new(bytes.Buffer))
+		root.SetArgs(append([]string{"destroy", "--keep-eip", "--yes"}, args...))
+		err := root.Execute()
+		if err == nil || !strings.Contains(err.Error(), "--keep-eip cannot be combined") {
+			t.Errorf("%v: error = %v, want --keep-eip conflict", args, err)
+		}
+	}
+}
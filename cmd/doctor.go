@@ -1,14 +1,20 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
@@ -16,10 +22,14 @@ import (
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/identity"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
+	"github.com/SpiceLabsHQ/Mint/internal/sg"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
 
@@ -35,15 +45,52 @@ type identityResolverAPI interface {
 type doctorDeps struct {
 	identityResolver  identityResolverAPI
 	describeAddresses mintaws.DescribeAddressesAPI
+	describeVolumes   mintaws.DescribeVolumesAPI
 	describe          mintaws.DescribeInstancesAPI
+	describeStatus    mintaws.DescribeInstanceStatusAPI
+	describeTypes     mintaws.DescribeInstanceTypesAPI
+	describeOfferings mintaws.DescribeInstanceTypeOfferingsAPI
+	describeSubnets   mintaws.DescribeSubnetsAPI
+	describeSGs       mintaws.DescribeSecurityGroupsAPI
+	authorizeIngress  mintaws.AuthorizeSecurityGroupIngressAPI
+	authorizeEgress   mintaws.AuthorizeSecurityGroupEgressAPI
+	releaseAddress    mintaws.ReleaseAddressAPI
 	sendKey           mintaws.SendSSHPublicKeyAPI
 	remoteRun         RemoteCommandRunner
 	configDir         string
 	sshConfigPath     string
 	owner             string
+	// ownerARN is the caller ARN, compared with each VM's mint:owner-arn
+	// to catch identities that normalize to the same owner.
+	ownerARN string
+	// roleARN is the effective role_arn (--role-arn flag or config role_arn).
+	// Empty skips the role check.
+	roleARN string
 	// profile is the effective AWS profile (--profile flag or config aws_profile).
 	// Used by checkCredentials to produce an actionable SSO re-auth message.
 	profile string
+	// region is the AWS SDK's resolved region, offered when --fix sets an
+	// unset region and written into the SSH config block --fix writes.
+	region string
+	// sshOptions are the user's SSH settings, applied to the SSH config
+	// block --fix writes.
+	sshOptions sshconfig.Options
+	// hostKeys is the host key store checked for keys of VMs that no
+	// longer exist. Nil skips the check.
+	hostKeys *sshconfig.HostKeyStore
+	// templateHead resolves the HEAD of the team template recorded in
+	// config. Nil skips the template check.
+	templateHead teamtemplate.HeadResolver
+	// sshVersion reports the laptop's `ssh -V` output. Nil skips the SSH
+	// client check.
+	sshVersion LocalProbe
+	// codeExtensions lists VS Code's extensions with their versions. Nil
+	// skips the Remote-SSH check.
+	codeExtensions LocalProbe
+	// sshPort is the configured ssh_port, named when a VM refuses the
+	// connection and required in the user security group. Zero means
+	// defaultSSHPort.
+	sshPort int
 }
 
 // cachedOwnerResolver is a production implementation of identityResolverAPI
@@ -81,9 +128,15 @@ func newDoctorCommandWithDeps(deps *doctorDeps) *cobra.Command {
 		Use:   "doctor",
 		Short: "Check environment and VM health",
 		Long: "Run environment health checks including AWS credentials, " +
-			"mint configuration, SSH config, EIP quota, and VM-specific checks " +
-			"(health tag, disk usage, component versions). Use --fix to " +
-			"reinstall failed components.",
+			"mint configuration, team template freshness, SSH config, local ssh client " +
+			"and VS Code Remote-SSH versions, EIP quota, managed security group " +
+			"rules, stored host keys, and VM-specific checks (health tag, disk usage, " +
+			"component versions).\n\n" +
+			"Use --fix to remediate what it can: reinstall failed components, add " +
+			"missing security group rules, write the missing SSH config block, set " +
+			"an unset region, release unassociated mint Elastic IPs, and remove host " +
+			"keys of VMs that no longer exist. Each remediation is confirmed first " +
+			"unless --yes is passed. Use --deep to also check each project's container.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -100,11 +153,11 @@ func newDoctorCommandWithDeps(deps *doctorDeps) *cobra.Command {
 			if cliCtx != nil {
 				effectiveProfile = cliCtx.Profile
 			}
-			if effectiveProfile == "" {
-				if mintCfg, err := config.Load(configDir); err == nil {
-					effectiveProfile = mintCfg.AWSProfile
-				}
+			mintCfg, cfgErr := config.Load(configDir)
+			if effectiveProfile == "" && cfgErr == nil {
+				effectiveProfile = mintCfg.AWSProfile
 			}
+			roleARN := effectiveRoleARN(cliCtx, mintCfg)
 
 			// doctor initializes its own AWS clients (commandNeedsAWS returns false
 			// for doctor) so that a credential failure is surfaced as a check result
@@ -115,8 +168,13 @@ func newDoctorCommandWithDeps(deps *doctorDeps) *cobra.Command {
 				return runDoctor(cmd, &doctorDeps{
 					identityResolver: &errorIdentityResolver{err: awsErr},
 					configDir:        configDir,
-					sshConfigPath:    defaultSSHConfigPath(),
+					hostKeys:         sshconfig.NewHostKeyStore(configDir),
+					sshConfigPath:    paths.SSHConfigPath(),
 					profile:          effectiveProfile,
+					roleARN:          roleARN,
+					templateHead:     teamtemplate.GitRemoteHead,
+					sshVersion:       sshVersionProbe,
+					codeExtensions:   codeExtensionsProbe,
 				})
 			}
 			return runDoctor(cmd, &doctorDeps{
@@ -125,18 +183,37 @@ func newDoctorCommandWithDeps(deps *doctorDeps) *cobra.Command {
 					arn:  clients.ownerARN,
 				},
 				describeAddresses: clients.ec2Client,
+				describeVolumes:   clients.ec2Client,
 				describe:          clients.ec2Client,
-				sendKey:           clients.icClient,
-				remoteRun:         defaultRemoteRunner,
+				describeStatus:    clients.ec2Client,
+				describeTypes:     clients.ec2Client,
+				describeOfferings: clients.ec2Client,
+				describeSubnets:   clients.ec2Client,
+				describeSGs:       clients.ec2Client,
+				authorizeIngress:  clients.ec2Client,
+				authorizeEgress:   clients.ec2Client,
+				releaseAddress:    clients.ec2Client,
+				sendKey:           clients.sendKey,
+				remoteRun:         clients.uncheckedRemoteRunner(),
 				configDir:         configDir,
-				sshConfigPath:     defaultSSHConfigPath(),
+				sshConfigPath:     paths.SSHConfigPath(),
 				owner:             clients.owner,
+				ownerARN:          clients.ownerARN,
 				profile:           effectiveProfile,
+				region:            clients.region,
+				sshOptions:        clients.sshOptions,
+				hostKeys:          sshconfig.NewHostKeyStore(configDir),
+				roleARN:           roleARN,
+				templateHead:      teamtemplate.GitRemoteHead,
+				sshVersion:        sshVersionProbe,
+				codeExtensions:    codeExtensionsProbe,
+				sshPort:           clients.sshOptions.Port,
 			})
 		},
 	}
 
-	cmd.Flags().Bool("fix", false, "Re-install components that failed version checks")
+	cmd.Flags().Bool("fix", false, "Remediate failing checks that have a known fix, confirming each unless --yes is passed")
+	cmd.Flags().Bool("deep", false, "Also check each project's container: running, responsive, workspace mount, and restart count")
 
 	return cmd
 }
@@ -146,13 +223,38 @@ type checkResult struct {
 	name    string
 	status  string // "PASS", "FAIL", "WARN"
 	message string
+	// children are grouped sub-checks, such as the --deep checks of one
+	// project. They are printed indented under the result.
+	children []checkResult
+	// fix remediates the problem the check found, asking the user first
+	// through the prompter, and returns what it did. It returns
+	// errFixDeclined when the user declines. Nil when there is no known
+	// remediation.
+	fix func(ctx context.Context, p *fixPrompter) (string, error)
+	// remediation is the outcome of --fix for a FAIL or WARN result:
+	// remediationFixed, remediationSkipped, remediationFailed, or
+	// remediationManual. Empty without --fix.
+	remediation string
+	// remediationDetail is what the fix did, or why it failed.
+	remediationDetail string
+}
+
+// fixable reports whether --fix can remediate the check.
+func (r checkResult) fixable() bool {
+	return r.fix != nil
 }
 
 // checkResultJSON is the JSON representation of a single doctor check.
 type checkResultJSON struct {
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	Detail string `json:"detail"`
+	Name   string            `json:"name"`
+	Status string            `json:"status"`
+	Detail string            `json:"detail"`
+	Checks []checkResultJSON `json:"checks,omitempty"`
+	// Fixable is true when --fix can remediate the check.
+	Fixable bool `json:"fixable,omitempty"`
+	// Remediation is the --fix outcome: fixed, skipped, failed, or manual.
+	Remediation       string `json:"remediation,omitempty"`
+	RemediationDetail string `json:"remediation_detail,omitempty"`
 }
 
 // regionFormatPattern matches valid AWS region formats like us-east-1.
@@ -174,34 +276,93 @@ func runDoctor(cmd *cobra.Command, deps *doctorDeps) error {
 	}
 
 	fixMode, _ := cmd.Flags().GetBool("fix")
+	deep, _ := cmd.Flags().GetBool("deep")
 
 	w := cmd.OutOrStdout()
 	var results []checkResult
 
-	// 1. AWS credential check
+	// 1. AWS credential check, and role_arn when one is set
 	results = append(results, checkCredentials(ctx, deps))
+	if deps.roleARN != "" {
+		results = append(results, checkAssumeRole(ctx, deps))
+	}
+
+	// 2. Config checks (region, volume_size_gb, idle_timeout,
+	//    instance_type against the region's catalog, and whether the
+	//    default subnets' AZs offer it)
+	results = append(results, checkConfig(deps, vmName)...)
+	if deps.describeTypes != nil {
+		typeResult := checkInstanceType(ctx, deps, vmName)
+		results = append(results, typeResult)
+		if typeResult.status != "FAIL" && deps.describeOfferings != nil && deps.describeSubnets != nil {
+			if r, ok := checkInstanceTypeOffering(ctx, deps, vmName); ok {
+				results = append(results, r)
+			}
+		}
+	}
+
+	// 3. SSH config check, and host keys of VMs that no longer exist
+	results = append(results, checkSSHConfig(ctx, deps))
+	if r, ok := checkHostKeys(ctx, deps); ok {
+		results = append(results, r)
+	}
 
-	// 2. Config checks (region, volume_size_gb, idle_timeout_minutes)
-	results = append(results, checkConfig(deps)...)
+	// 3a. Local ssh client and VS Code Remote-SSH versions against the
+	//     options the SSH config uses
+	if deps.sshVersion != nil {
+		results = append(results, checkSSHClient(deps))
+	}
+	if r, ok := checkRemoteSSH(deps); ok {
+		results = append(results, r)
+	}
 
-	// 3. SSH config check
-	results = append(results, checkSSHConfig(deps))
+	// 3b. Team template freshness
+	if deps.templateHead != nil {
+		if r, ok := checkTemplate(ctx, deps); ok {
+			results = append(results, r)
+		}
+	}
 
 	// 4. EIP quota headroom
 	results = append(results, checkEIPQuota(ctx, deps))
 
-	// 5. VM-specific checks (only when describe is available)
+	// 5. Managed security group rules against the internal/sg spec
+	if deps.describeSGs != nil {
+		results = append(results, checkSecurityGroups(ctx, deps, fixMode)...)
+	}
+
+	// 6. Instances whose volumes or Elastic IP are tagged but whose own
+	//    mint tags are incomplete, which hides them from discovery
+	if deps.describe != nil && deps.describeVolumes != nil && deps.describeAddresses != nil {
+		results = append(results, checkResourceTags(ctx, deps))
+		results = append(results, checkExpiredResources(ctx, deps, time.Now()))
+	}
+
+	// 7. VM-specific checks (only when describe is available)
 	if deps.describe != nil {
-		vmResults := runVMChecks(ctx, deps, vmName, fixMode)
+		vmResults := runVMChecks(ctx, deps, vmName, fixMode, deep)
 		results = append(results, vmResults...)
 	}
 
+	if fixMode {
+		// Prompts go to stderr with --json so stdout stays parseable.
+		prompts := w
+		if jsonOutput {
+			prompts = cmd.ErrOrStderr()
+		}
+		yes := cliCtx != nil && cliCtx.Yes
+		applyFixes(ctx, &fixPrompter{w: prompts, in: bufio.NewScanner(cmd.InOrStdin()), yes: yes}, results)
+	}
+
 	if jsonOutput {
 		return printResultsJSON(w, results)
 	}
 
 	// Print results and determine exit status.
 	hasFail := printResults(w, results)
+	if fixMode {
+		printFixSummary(w, results)
+	}
 	if hasFail {
 		return fmt.Errorf("one or more checks failed")
 	}
@@ -211,7 +372,7 @@ func runDoctor(cmd *cobra.Command, deps *doctorDeps) error {
 // runVMChecks discovers VMs and runs health checks on each.
 // When vmName is not "default" (i.e., --vm was specified), only that VM is
 // checked. Otherwise, all running VMs owned by the user are checked.
-func runVMChecks(ctx context.Context, deps *doctorDeps, vmName string, fixMode bool) []checkResult {
+func runVMChecks(ctx context.Context, deps *doctorDeps, vmName string, fixMode, deep bool) []checkResult {
 	var vms []*vm.VM
 	var err error
 
@@ -246,16 +407,32 @@ func runVMChecks(ctx context.Context, deps *doctorDeps, vmName string, fixMode b
 
 	var results []checkResult
 	for _, v := range vms {
-		results = append(results, checkVM(ctx, deps, v, fixMode)...)
+		results = append(results, checkVM(ctx, deps, v, fixMode, deep)...)
 	}
 	return results
 }
 
-// checkVM runs all health checks for a single VM.
-func checkVM(ctx context.Context, deps *doctorDeps, v *vm.VM, fixMode bool) []checkResult {
+// checkVM runs all health checks for a single VM. deep adds the per-project
+// container checks.
+func checkVM(ctx context.Context, deps *doctorDeps, v *vm.VM, fixMode, deep bool) []checkResult {
 	prefix := fmt.Sprintf("vm/%s", v.Name)
 	var results []checkResult
 
+	// An owner collision matters whatever the VM's state.
+	if warning := ownerCollisionWarning(v, deps.ownerARN); warning != "" {
+		results = append(results, checkResult{name: prefix + "/owner", status: "WARN", message: warning})
+	}
+
+	// Scheduled events apply to stopped VMs too, so check them first.
+	if deps.describeStatus != nil {
+		results = append(results, checkScheduledEvents(ctx, deps, v, prefix)...)
+	}
+
+	// Volume encryption does not depend on the VM's state either.
+	if deps.describeVolumes != nil {
+		results = append(results, checkVolumeEncryption(ctx, deps, v, prefix))
+	}
+
 	// Skip non-running VMs.
 	if v.State != string(ec2types.InstanceStateNameRunning) {
 		results = append(results, checkResult{
@@ -269,6 +446,11 @@ func checkVM(ctx context.Context, deps *doctorDeps, v *vm.VM, fixMode bool) []ch
 	// 1. Health tag check.
 	results = append(results, checkHealthTag(v, prefix))
 
+	// User bootstrap hook outcome, only when a hook ran.
+	if v.UserBootstrapStatus != "" {
+		results = append(results, checkUserBootstrapTag(v, prefix))
+	}
+
 	// Skip SSH-based checks if we don't have the SSH deps.
 	if deps.remoteRun == nil || deps.sendKey == nil {
 		return results
@@ -277,18 +459,83 @@ func checkVM(ctx context.Context, deps *doctorDeps, v *vm.VM, fixMode bool) []ch
 	// 2. Disk usage check.
 	results = append(results, checkDiskUsage(ctx, deps, v, prefix))
 
-	// 3. Component version checks.
+	// 2a. NVIDIA driver check, on GPU instance types only.
+	if mintaws.IsGPUInstanceType(v.InstanceType) {
+		results = append(results, checkGPU(ctx, deps, v, prefix))
+	}
+
+	// 3. Agent version check.
+	agent, agentVersion := checkAgentVersion(ctx, deps, v, prefix)
+	results = append(results, agent)
+
+	// 4. Component version checks.
 	components := checkComponents(ctx, deps, v, prefix)
-	results = append(results, components...)
 
-	// 4. Fix mode: reinstall failed components.
+	// 5. Fix mode: reinstall failed components, unless the VM agent is
+	// newer than this CLI and may install them differently.
+	var fixes []checkResult
 	if fixMode {
-		results = append(results, fixFailedComponents(ctx, deps, v, prefix, components)...)
+		if agentVersion > agentVersionMax {
+			fixes = append(fixes, checkResult{
+				name:    prefix + "/fix",
+				status:  "WARN",
+				message: fmt.Sprintf("skipped: %v", agentTooNewError(agentVersion)),
+			})
+		} else {
+			fixes = fixFailedComponents(ctx, deps, v, prefix, components)
+		}
+	}
+	results = append(results, components...)
+	results = append(results, fixes...)
+
+	// 6. Deep mode: project container checks.
+	if deep {
+		results = append(results, checkProjects(ctx, deps, v, prefix)...)
 	}
 
 	return results
 }
 
+// checkScheduledEvents reports each pending EC2 scheduled event for v, such
+// as a retirement, with what to do about it.
+func checkScheduledEvents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) []checkResult {
+	name := prefix + "/events"
+	events, err := vm.ScheduledEvents(ctx, deps.describeStatus, []string{v.ID})
+	if err != nil {
+		return []checkResult{{name: name, status: "WARN", message: fmt.Sprintf("could not check scheduled events: %v", err)}}
+	}
+	if len(events[v.ID]) == 0 {
+		return []checkResult{{name: name, status: "PASS", message: "no scheduled events"}}
+	}
+	var results []checkResult
+	for _, ev := range events[v.ID] {
+		results = append(results, checkResult{name: name, status: "WARN", message: eventWarning(ev)})
+	}
+	return results
+}
+
+// checkVolumeEncryption reports whether v's project volume is encrypted.
+// Volumes created before mint encrypted them by default stay unencrypted
+// until their data is moved onto a restored volume.
+func checkVolumeEncryption(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) checkResult {
+	name := prefix + "/encryption"
+	encrypted, err := projectVolumeEncrypted(ctx, deps.describeVolumes, deps.owner, v.Name)
+	switch {
+	case err != nil:
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check volume encryption: %v", err)}
+	case encrypted == nil:
+		return checkResult{name: name, status: "WARN", message: "project volume not found"}
+	case *encrypted:
+		return checkResult{name: name, status: "PASS", message: "project volume encrypted"}
+	}
+	return checkResult{
+		name:   name,
+		status: "WARN",
+		message: fmt.Sprintf("project volume is not encrypted — %s then %s moves it onto an encrypted volume, and %s encrypts the root volume",
+			hint.Cmd("mint snapshot create"), hint.Cmd("mint snapshot restore"), hint.Cmd("mint recreate")),
+	}
+}
+
 // checkHealthTag reads the mint:health tag and reports its status.
 func checkHealthTag(v *vm.VM, prefix string) checkResult {
 	health, ok := v.Tags[tags.TagHealth]
@@ -322,6 +569,33 @@ func checkHealthTag(v *vm.VM, prefix string) checkResult {
 	}
 }
 
+// checkUserBootstrapTag reads the mint:user-bootstrap tag and reports the
+// outcome of the user-bootstrap.sh hook. A failed hook is a warning: core
+// bootstrap completed and the VM is usable.
+func checkUserBootstrapTag(v *vm.VM, prefix string) checkResult {
+	status, err := tags.ParseUserBootstrapStatus(v.UserBootstrapStatus)
+	if err != nil {
+		return checkResult{
+			name:    prefix + "/user-bootstrap",
+			status:  "WARN",
+			message: err.Error(),
+		}
+	}
+	if status.Failed {
+		return checkResult{
+			name:   prefix + "/user-bootstrap",
+			status: "WARN",
+			message: fmt.Sprintf("core bootstrap: complete, user hook: %s — see %s",
+				status, hint.Cmd("mint logs --vm "+v.Name)),
+		}
+	}
+	return checkResult{
+		name:    prefix + "/user-bootstrap",
+		status:  "PASS",
+		message: status.String(),
+	}
+}
+
 // checkDiskUsage retrieves disk usage via SSH and reports the result.
 func checkDiskUsage(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) checkResult {
 	dfCmd := []string{"df", "--output=pcent", "/"}
@@ -331,7 +605,7 @@ func checkDiskUsage(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix stri
 		v.ID,
 		v.AvailabilityZone,
 		v.PublicIP,
-		defaultSSHPort,
+		sshPortOrDefault(deps.sshPort),
 		defaultSSHUser,
 		dfCmd,
 	)
@@ -340,8 +614,8 @@ func checkDiskUsage(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix stri
 			return checkResult{
 				name:   prefix + "/disk",
 				status: "WARN",
-				message: fmt.Sprintf("cannot connect to VM (port 41122 refused) \u2014 "+
-					"bootstrap may be incomplete, run %s for details", hint.Cmd("mint doctor")),
+				message: fmt.Sprintf("cannot connect to VM (port %d refused) \u2014 "+
+					"bootstrap may be incomplete, run %s for details", sshPortOrDefault(deps.sshPort), hint.Cmd("mint doctor")),
 			}
 		}
 		return checkResult{
@@ -381,6 +655,55 @@ func checkDiskUsage(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix stri
 	}
 }
 
+// nvidiaSMIPattern matches the driver and CUDA versions in the banner
+// nvidia-smi prints.
+var nvidiaSMIPattern = regexp.MustCompile(`Driver Version:\s*(\S+)\s+CUDA Version:\s*(\S+)`)
+
+// checkGPU runs nvidia-smi on the VM and reports the NVIDIA driver and CUDA
+// versions. A failure usually means the driver was installed for a kernel
+// the VM has not booted into yet.
+func checkGPU(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) checkResult {
+	output, err := deps.remoteRun(
+		ctx,
+		deps.sendKey,
+		v.ID,
+		v.AvailabilityZone,
+		v.PublicIP,
+		sshPortOrDefault(deps.sshPort),
+		defaultSSHUser,
+		[]string{"nvidia-smi"},
+	)
+	if err != nil {
+		if isSSHConnectionError(err) {
+			return checkResult{
+				name:    prefix + "/gpu",
+				status:  "WARN",
+				message: fmt.Sprintf("cannot connect to VM (port %d refused)", sshPortOrDefault(deps.sshPort)),
+			}
+		}
+		return checkResult{
+			name:   prefix + "/gpu",
+			status: "FAIL",
+			message: fmt.Sprintf("nvidia-smi failed: %v \u2014 if the driver was installed for a newer kernel, "+
+				"run %s then %s to boot into it", err, hint.Cmd("mint down"), hint.Cmd("mint up")),
+		}
+	}
+
+	m := nvidiaSMIPattern.FindStringSubmatch(string(output))
+	if m == nil {
+		return checkResult{
+			name:    prefix + "/gpu",
+			status:  "WARN",
+			message: "could not parse driver version from nvidia-smi output",
+		}
+	}
+	return checkResult{
+		name:    prefix + "/gpu",
+		status:  "PASS",
+		message: fmt.Sprintf("driver %s, CUDA %s", m[1], m[2]),
+	}
+}
+
 // componentCheck defines a component to check and how to fix it.
 type componentCheck struct {
 	name       string
@@ -425,7 +748,7 @@ func checkComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix str
 			v.ID,
 			v.AvailabilityZone,
 			v.PublicIP,
-			defaultSSHPort,
+			sshPortOrDefault(deps.sshPort),
 			defaultSSHUser,
 			comp.command,
 		)
@@ -434,8 +757,8 @@ func checkComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix str
 				results = append(results, checkResult{
 					name:   prefix + "/" + comp.name,
 					status: "FAIL",
-					message: fmt.Sprintf("cannot connect to VM (port 41122 refused) \u2014 "+
-						"bootstrap may be incomplete, run %s for details", hint.Cmd("mint doctor")),
+					message: fmt.Sprintf("cannot connect to VM (port %d refused) \u2014 "+
+						"bootstrap may be incomplete, run %s for details", sshPortOrDefault(deps.sshPort), hint.Cmd("mint doctor")),
 				})
 			} else {
 				results = append(results, checkResult{
@@ -467,7 +790,31 @@ func checkComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix str
 	return results
 }
 
-// fixFailedComponents attempts to reinstall components that failed checks.
+// checkAgentVersion compares the VM agent version with the range this CLI
+// supports. It also returns the version, or 0 when it could not be read.
+func checkAgentVersion(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) (checkResult, int) {
+	agent, err := agentVersionOf(ctx, deps.remoteRun, deps.sendKey, v.ID, v.AvailabilityZone,
+		v.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser)
+	if err != nil {
+		return checkResult{
+			name:    prefix + "/agent",
+			status:  "WARN",
+			message: fmt.Sprintf("could not check agent version: %v", err),
+		}, 0
+	}
+	status := "PASS"
+	if agent < agentVersionMin || agent > agentVersionMax {
+		status = "WARN"
+	}
+	return checkResult{
+		name:    prefix + "/agent",
+		status:  status,
+		message: agentVersionSummary(agent),
+	}, agent
+}
+
+// fixFailedComponents attempts to reinstall components that failed checks,
+// recording the outcome as each failed check's remediation.
 func fixFailedComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string, componentResults []checkResult) []checkResult {
 	var results []checkResult
 
@@ -476,14 +823,14 @@ func fixFailedComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix
 		checkName := prefix + "/" + comp.name
 
 		// Find the check result for this component.
-		failed := false
-		for _, r := range componentResults {
-			if r.name == checkName && r.status == "FAIL" {
-				failed = true
+		var failed *checkResult
+		for i := range componentResults {
+			if componentResults[i].name == checkName && componentResults[i].status == "FAIL" {
+				failed = &componentResults[i]
 				break
 			}
 		}
-		if !failed {
+		if failed == nil {
 			continue
 		}
 
@@ -494,11 +841,13 @@ func fixFailedComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix
 			v.ID,
 			v.AvailabilityZone,
 			v.PublicIP,
-			defaultSSHPort,
+			sshPortOrDefault(deps.sshPort),
 			defaultSSHUser,
 			comp.fixCommand,
 		)
 		if err != nil {
+			failed.remediation = remediationFailed
+			failed.remediationDetail = fmt.Sprintf("reinstall failed: %v", err)
 			results = append(results, checkResult{
 				name:    prefix + "/" + comp.name + "/fix",
 				status:  "FAIL",
@@ -507,6 +856,8 @@ func fixFailedComponents(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix
 			continue
 		}
 
+		failed.remediation = remediationFixed
+		failed.remediationDetail = "reinstalled successfully"
 		results = append(results, checkResult{
 			name:    prefix + "/" + comp.name + "/fix",
 			status:  "PASS",
@@ -532,15 +883,49 @@ func checkCredentials(ctx context.Context, deps *doctorDeps) checkResult {
 			message: msg,
 		}
 	}
+	// Show the raw ARN beside the owner it normalizes to, so a surprising
+	// owner name can be traced back to the identity.
+	msg := fmt.Sprintf("authenticated as %s", owner.Name)
+	if owner.ARN != "" {
+		msg += fmt.Sprintf(" (%s)", owner.ARN)
+	}
 	return checkResult{
 		name:    "AWS credentials",
 		status:  "PASS",
-		message: fmt.Sprintf("authenticated as %s", owner.Name),
+		message: msg,
+	}
+}
+
+// checkAssumeRole verifies that role_arn can be assumed. The role is assumed
+// while the AWS clients are built, so a resolved identity is the role
+// session's and an AssumeRole refusal is what failed the resolution.
+func checkAssumeRole(ctx context.Context, deps *doctorDeps) checkResult {
+	owner, err := deps.identityResolver.Resolve(ctx)
+	switch {
+	case err == nil:
+		return checkResult{
+			name:    "role_arn",
+			status:  "PASS",
+			message: fmt.Sprintf("assumed %s as %s", deps.roleARN, owner.ARN),
+		}
+	case isAssumeRoleDenied(err):
+		return checkResult{
+			name:    "role_arn",
+			status:  "FAIL",
+			message: credentialErrMessage(err, deps.profile),
+		}
+	default:
+		return checkResult{
+			name:    "role_arn",
+			status:  "WARN",
+			message: fmt.Sprintf("could not check %s: %v", deps.roleARN, err),
+		}
 	}
 }
 
-// checkConfig validates the mint configuration values.
-func checkConfig(deps *doctorDeps) []checkResult {
+// checkConfig validates the mint configuration values, with vmName's
+// [vm.<name>] overrides applied.
+func checkConfig(deps *doctorDeps, vmName string) []checkResult {
 	var results []checkResult
 
 	cfg, err := config.Load(deps.configDir)
@@ -552,6 +937,15 @@ func checkConfig(deps *doctorDeps) []checkResult {
 		})
 		return results
 	}
+	if vmCfg, err := cfg.ForVM(vmName); err != nil {
+		results = append(results, checkResult{
+			name:    "config",
+			status:  "FAIL",
+			message: err.Error(),
+		})
+	} else {
+		cfg = vmCfg
+	}
 
 	// Region check
 	if cfg.Region == "" {
@@ -559,6 +953,7 @@ func checkConfig(deps *doctorDeps) []checkResult {
 			name:    "region",
 			status:  "FAIL",
 			message: fmt.Sprintf("region is not set \u2014 run %s", hint.Cmd("mint config set region <region>")),
+			fix:     fixRegion(deps),
 		})
 	} else if !regionFormatPattern.MatchString(cfg.Region) {
 		results = append(results, checkResult{
@@ -579,39 +974,71 @@ func checkConfig(deps *doctorDeps) []checkResult {
 		results = append(results, checkResult{
 			name:    "volume_size_gb",
 			status:  "FAIL",
-			message: fmt.Sprintf("must be >= 50 (got %d)", cfg.VolumeSizeGB),
+			message: fmt.Sprintf("must be >= 50 (got %d)%s", cfg.VolumeSizeGB, vmConfigSource(cfg, vmName, "volume_size_gb")),
 		})
 	} else {
 		results = append(results, checkResult{
 			name:    "volume_size_gb",
 			status:  "PASS",
-			message: fmt.Sprintf("%d GB", cfg.VolumeSizeGB),
+			message: format.FormatGiB(cfg.VolumeSizeGB) + vmConfigSource(cfg, vmName, "volume_size_gb"),
 		})
 	}
 
-	// idle_timeout_minutes check
+	// idle_timeout check
+	idleTimeout := format.FormatDuration(time.Duration(cfg.IdleTimeoutMinutes)*time.Minute) +
+		vmConfigSource(cfg, vmName, "idle_timeout")
 	if cfg.IdleTimeoutMinutes < 15 {
 		results = append(results, checkResult{
-			name:    "idle_timeout_minutes",
+			name:    "idle_timeout",
 			status:  "FAIL",
-			message: fmt.Sprintf("must be >= 15 (got %d)", cfg.IdleTimeoutMinutes),
+			message: fmt.Sprintf("must be at least 15m (got %s)", idleTimeout),
+		})
+	} else if cfg.LegacyIdleTimeoutKey {
+		results = append(results, checkResult{
+			name:   "idle_timeout",
+			status: "WARN",
+			message: fmt.Sprintf("%s — idle_timeout_minutes is deprecated; run %s to migrate",
+				idleTimeout, hint.Cmd(fmt.Sprintf("mint config set idle_timeout %dm", cfg.IdleTimeoutMinutes))),
 		})
 	} else {
 		results = append(results, checkResult{
-			name:    "idle_timeout_minutes",
+			name:    "idle_timeout",
 			status:  "PASS",
-			message: fmt.Sprintf("%d minutes", cfg.IdleTimeoutMinutes),
+			message: idleTimeout,
 		})
 	}
 
+	// extra_tags check, only when the table is set
+	if len(cfg.ExtraTags) > 0 {
+		results = append(results, checkExtraTags(cfg.ExtraTags))
+	}
+
 	return results
 }
 
-// checkSSHConfig verifies that the SSH managed block exists for the default VM.
-func checkSSHConfig(deps *doctorDeps) checkResult {
+// checkExtraTags validates the [extra_tags] table against the EC2 tag
+// constraints, counting the tags mint sets itself toward the limit.
+func checkExtraTags(extra map[string]string) checkResult {
+	if problems := tags.ValidateExtra(extra); len(problems) > 0 {
+		return checkResult{
+			name:    "extra_tags",
+			status:  "FAIL",
+			message: strings.Join(problems, "; "),
+		}
+	}
+	return checkResult{
+		name:    "extra_tags",
+		status:  "PASS",
+		message: strings.Join(slices.Sorted(maps.Keys(extra)), ", "),
+	}
+}
+
+// checkSSHConfig verifies that the SSH managed block exists for the default
+// VM. When it is missing and the VM is running, --fix writes it.
+func checkSSHConfig(ctx context.Context, deps *doctorDeps) checkResult {
 	sshPath := deps.sshConfigPath
 	if sshPath == "" {
-		sshPath = defaultSSHConfigPath()
+		sshPath = paths.SSHConfigPath()
 	}
 
 	data, err := os.ReadFile(sshPath)
@@ -620,6 +1047,7 @@ func checkSSHConfig(deps *doctorDeps) checkResult {
 			name:    "SSH config",
 			status:  "WARN",
 			message: fmt.Sprintf("SSH config file not found \u2014 run %s to configure SSH automatically", hint.Cmd("mint up")),
+			fix:     fixSSHConfigFor(ctx, deps, sshPath),
 		}
 	}
 
@@ -629,6 +1057,7 @@ func checkSSHConfig(deps *doctorDeps) checkResult {
 			name:    "SSH config",
 			status:  "WARN",
 			message: fmt.Sprintf("no mint managed block found \u2014 run %s to configure SSH automatically", hint.Cmd("mint up")),
+			fix:     fixSSHConfigFor(ctx, deps, sshPath),
 		}
 	}
 
@@ -639,6 +1068,47 @@ func checkSSHConfig(deps *doctorDeps) checkResult {
 	}
 }
 
+// checkTemplate compares the team template commit mint init applied with
+// the template's current HEAD. ok is false when no template is configured.
+func checkTemplate(ctx context.Context, deps *doctorDeps) (checkResult, bool) {
+	cfg, err := config.Load(deps.configDir)
+	if err != nil || cfg.TemplateRepo == "" {
+		return checkResult{}, false
+	}
+	reapply := hint.Cmd("mint init --from-template " + cfg.TemplateRepo)
+	if cfg.TemplateCommit == "" {
+		return checkResult{
+			name:    "template",
+			status:  "WARN",
+			message: fmt.Sprintf("%s has not been applied \u2014 run %s", cfg.TemplateRepo, reapply),
+		}, true
+	}
+	head, err := deps.templateHead(ctx, cfg.TemplateRepo)
+	if err != nil {
+		return checkResult{
+			name:    "template",
+			status:  "WARN",
+			message: fmt.Sprintf("could not check %s for updates: %v", cfg.TemplateRepo, err),
+		}, true
+	}
+	if head != cfg.TemplateCommit {
+		return checkResult{
+			name:   "template",
+			status: "WARN",
+			message: fmt.Sprintf("%s has changed since it was applied (%s \u2192 %s) \u2014 run %s",
+				cfg.TemplateRepo, shortCommit(cfg.TemplateCommit), shortCommit(head), reapply),
+		}, true
+	}
+	return checkResult{
+		name:    "template",
+		status:  "PASS",
+		message: fmt.Sprintf("%s at %s", cfg.TemplateRepo, shortCommit(head)),
+	}, true
+}
+
+// eipDefaultLimit is the default Elastic IP quota of a region.
+const eipDefaultLimit = 5
+
 // checkEIPQuota checks the number of allocated Elastic IPs against the default
 // limit of 5. Warns if >= 4 are allocated. Returns SKIP when AWS clients are
 // unavailable (e.g., no credentials).
@@ -660,51 +1130,241 @@ func checkEIPQuota(ctx context.Context, deps *doctorDeps) checkResult {
 	}
 
 	count := len(out.Addresses)
-	const defaultLimit = 5
 	const warnThreshold = 4
 
 	if count >= warnThreshold {
-		return checkResult{
+		result := checkResult{
 			name:    "EIP quota",
 			status:  "WARN",
-			message: fmt.Sprintf("%d of %d EIPs allocated — nearing limit", count, defaultLimit),
+			message: fmt.Sprintf("%d of %d EIPs allocated — nearing limit", count, eipDefaultLimit),
+		}
+		// Only the owner's unassociated mint Elastic IPs are released.
+		if releasable := releasableAddresses(out.Addresses, deps.owner); len(releasable) > 0 && deps.releaseAddress != nil {
+			result.message += fmt.Sprintf("; %d unassociated mint EIP(s) can be released with %s", len(releasable), hint.Cmd("mint doctor --fix"))
+			result.fix = fixReleaseAddresses(deps, releasable, count)
 		}
+		return result
 	}
 
 	return checkResult{
 		name:    "EIP quota",
 		status:  "PASS",
-		message: fmt.Sprintf("%d of %d EIPs allocated", count, defaultLimit),
+		message: fmt.Sprintf("%d of %d EIPs allocated", count, eipDefaultLimit),
+	}
+}
+
+// checkResourceTags cross-references the owner's tagged volumes and Elastic
+// IPs with the instances they are attached to. An instance missing mint tags
+// is invisible to discovery ("no VM found") even though its resources exist.
+func checkResourceTags(ctx context.Context, deps *doctorDeps) checkResult {
+	const name = "resource tags"
+	refs, order, err := referencedInstances(ctx, deps.describeVolumes, deps.describeAddresses, deps.owner)
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check resource tags: %v", err)}
+	}
+	if len(order) == 0 {
+		return checkResult{name: name, status: "PASS", message: "no attached volumes or Elastic IPs"}
+	}
+
+	out, err := deps.describe.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: order})
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check resource tags: %v", err)}
+	}
+	var problems []string
+	instances := liveInstances(out)
+	for _, inst := range instances {
+		id := aws.ToString(inst.InstanceId)
+		ref := refs[id]
+		current := tags.ToMap(inst.Tags)
+		vmName := inferVMName(deps.owner, current, ref.tags, "default")
+		diff := tags.Diff(current, instanceCanonicalTags(deps.owner, ref.tags[tags.TagOwnerARN], vmName))
+		if diff.Empty() {
+			continue
+		}
+		var keys []string
+		for _, tag := range diff.Missing {
+			keys = append(keys, aws.ToString(tag.Key))
+		}
+		problems = append(problems, fmt.Sprintf("instance %s of VM %q (found via %s) is missing %s — run %s",
+			id, vmName, ref.reason, strings.Join(keys, ", "), hint.Cmd("mint repair tags --instance-id "+id)))
+	}
+	if len(problems) > 0 {
+		return checkResult{name: name, status: "WARN", message: strings.Join(problems, "; ")}
+	}
+	return checkResult{name: name, status: "PASS", message: fmt.Sprintf("%d instance(s) consistent with their volumes and Elastic IPs", len(instances))}
+}
+
+// checkExpiredResources reports the owner's instances, project volumes, and
+// Elastic IPs whose mint:expires has passed, grouped by VM, with the command
+// that destroys each VM. Nothing is deleted.
+func checkExpiredResources(ctx context.Context, deps *doctorDeps, now time.Time) checkResult {
+	const name = "expired resources"
+	type expiredVM struct {
+		since time.Time // the earliest expiry among its resources
+		ids   []string
+	}
+	expired := make(map[string]*expiredVM)
+	add := func(resourceTags map[string]string, id string) {
+		expires, err := tags.ParseExpires(resourceTags[tags.TagExpires])
+		if err != nil || !tags.Expired(expires, now) {
+			return
+		}
+		vmName := resourceTags[tags.TagVM]
+		e := expired[vmName]
+		if e == nil {
+			e = &expiredVM{since: expires}
+			expired[vmName] = e
+		}
+		if expires.Before(e.since) {
+			e.since = expires
+		}
+		e.ids = append(e.ids, id)
+	}
+
+	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check expiry: %v", err)}
+	}
+	for _, v := range vms {
+		add(v.Tags, v.ID)
 	}
+	volOut, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{Filters: tags.FilterByOwner(deps.owner)})
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check expiry: describe volumes: %v", err)}
+	}
+	for _, vol := range volOut.Volumes {
+		add(tags.ToMap(vol.Tags), aws.ToString(vol.VolumeId))
+	}
+	addrOut, err := deps.describeAddresses.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{Filters: tags.FilterByOwner(deps.owner)})
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check expiry: describe addresses: %v", err)}
+	}
+	for _, addr := range addrOut.Addresses {
+		add(tags.ToMap(addr.Tags), aws.ToString(addr.AllocationId))
+	}
+
+	if len(expired) == 0 {
+		return checkResult{name: name, status: "PASS", message: "no expired resources"}
+	}
+	var problems []string
+	for _, vmName := range slices.Sorted(maps.Keys(expired)) {
+		e := expired[vmName]
+		problems = append(problems, fmt.Sprintf("VM %q expired %s ago (%s) — run %s",
+			vmName, format.FormatDuration(now.Sub(e.since).Truncate(time.Minute)),
+			strings.Join(e.ids, ", "), hint.Cmd(destroyCommand(vmName))))
+	}
+	return checkResult{name: name, status: "WARN", message: strings.Join(problems, "; ")}
+}
+
+// checkSecurityGroups diffs the user and admin security groups against the
+// rules in internal/sg. Missing required rules FAIL, or are added in fix
+// mode; rules mint does not recognize WARN and are never removed.
+func checkSecurityGroups(ctx context.Context, deps *doctorDeps, fixMode bool) []checkResult {
+	mintFilter := ec2types.Filter{Name: aws.String("tag:" + tags.TagMint), Values: []string{"true"}}
+	return []checkResult{
+		checkSecurityGroup(ctx, deps, fixMode, "user security group", sg.UserRules(int32(sshPortOrDefault(deps.sshPort))),
+			fmt.Sprintf("not found — run %s", hint.Cmd("mint init")),
+			mintFilter,
+			ec2types.Filter{Name: aws.String("tag:" + tags.TagOwner), Values: []string{deps.owner}},
+			ec2types.Filter{Name: aws.String("tag:" + tags.TagComponent), Values: []string{tags.ComponentSecurityGroup}},
+		),
+		checkSecurityGroup(ctx, deps, fixMode, "admin security group", sg.AdminRules(),
+			fmt.Sprintf("not found — run %s", hint.Cmd("mint admin setup")),
+			mintFilter,
+			ec2types.Filter{Name: aws.String("tag:" + tags.TagComponent), Values: []string{"admin"}},
+		),
+	}
+}
+
+// checkSecurityGroup checks one managed security group found by filters.
+func checkSecurityGroup(ctx context.Context, deps *doctorDeps, fixMode bool, name string, rules []sg.Rule, notFound string, filters ...ec2types.Filter) checkResult {
+	out, err := deps.describeSGs.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{Filters: filters})
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not check rules: %v", err)}
+	}
+	if len(out.SecurityGroups) == 0 {
+		return checkResult{name: name, status: "FAIL", message: notFound}
+	}
+
+	group := out.SecurityGroups[0]
+	groupID := aws.ToString(group.GroupId)
+	diff := sg.Diff(rules, group)
+
+	extraNote := ""
+	if len(diff.Extra) > 0 {
+		extraNote = fmt.Sprintf("%d unrecognized rule(s) left in place: %s", len(diff.Extra), joinRules(diff.Extra))
+	}
+
+	if len(diff.Missing) > 0 {
+		missing := joinRules(diff.Missing)
+		if !fixMode || deps.authorizeIngress == nil || deps.authorizeEgress == nil {
+			return checkResult{
+				name:   name,
+				status: "FAIL",
+				message: fmt.Sprintf("%s is missing required rule(s): %s — run %s to add them",
+					groupID, missing, hint.Cmd("mint doctor --fix")),
+			}
+		}
+		if err := sg.Repair(ctx, deps.authorizeIngress, deps.authorizeEgress, groupID, diff.Missing); err != nil {
+			return checkResult{
+				name:              name,
+				status:            "FAIL",
+				message:           fmt.Sprintf("%s is missing required rule(s): %s — repair failed: %v", groupID, missing, err),
+				remediation:       remediationFailed,
+				remediationDetail: err.Error(),
+			}
+		}
+		msg := fmt.Sprintf("%s: added missing rule(s): %s", groupID, missing)
+		status := "PASS"
+		if extraNote != "" {
+			msg += "; " + extraNote
+			status = "WARN"
+		}
+		return checkResult{name: name, status: status, message: msg, remediation: remediationFixed, remediationDetail: msg}
+	}
+
+	if extraNote != "" {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("%s: %s", groupID, extraNote)}
+	}
+	return checkResult{name: name, status: "PASS", message: fmt.Sprintf("%s rules match", groupID)}
+}
+
+// joinRules renders rules as a semicolon-separated list.
+func joinRules(rules []sg.Rule) string {
+	parts := make([]string, len(rules))
+	for i, r := range rules {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, "; ")
 }
 
 // printResults writes the check results to the writer and returns true if
 // any check failed.
 func printResults(w io.Writer, results []checkResult) bool {
+	return printResultsIndented(w, results, "")
+}
+
+// printResultsIndented writes results with each level of children indented
+// further, and returns true if any check failed.
+func printResultsIndented(w io.Writer, results []checkResult, indent string) bool {
 	hasFail := false
 	for _, r := range results {
-		fmt.Fprintf(w, "[%s] %s: %s\n", r.status, r.name, r.message)
+		fmt.Fprintf(w, "%s[%s] %s: %s%s\n", indent, r.status, r.name, r.message, remediationNote(r))
 		if r.status == "FAIL" {
 			hasFail = true
 		}
+		if printResultsIndented(w, r.children, indent+"    ") {
+			hasFail = true
+		}
 	}
 	return hasFail
 }
 
 // printResultsJSON writes check results as a JSON array.
 func printResultsJSON(w io.Writer, results []checkResult) error {
-	jsonResults := make([]checkResultJSON, len(results))
-	for i, r := range results {
-		jsonResults[i] = checkResultJSON{
-			Name:   r.name,
-			Status: r.status,
-			Detail: r.message,
-		}
-	}
-
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(jsonResults); err != nil {
+	if err := enc.Encode(checkResultsToJSON(results)); err != nil {
 		return fmt.Errorf("encoding JSON: %w", err)
 	}
 
@@ -719,3 +1379,176 @@ func printResultsJSON(w io.Writer, results []checkResult) error {
 	}
 	return nil
 }
+
+// checkResultsToJSON converts results, including grouped children, to their
+// JSON representation.
+func checkResultsToJSON(results []checkResult) []checkResultJSON {
+	jsonResults := make([]checkResultJSON, len(results))
+	for i, r := range results {
+		jsonResults[i] = checkResultJSON{
+			Name:              r.name,
+			Status:            r.status,
+			Detail:            r.message,
+			Fixable:           r.fixable(),
+			Remediation:       r.remediation,
+			RemediationDetail: r.remediationDetail,
+		}
+		if len(r.children) > 0 {
+			jsonResults[i].Checks = checkResultsToJSON(r.children)
+		}
+	}
+	return jsonResults
+}
+
+// vmConfigSource returns " (from [vm.<name>])" when vmName's [vm.<name>]
+// table in cfg sets key, and "" when the value is the top-level one.
+func vmConfigSource(cfg *config.Config, vmName, key string) string {
+	for _, k := range cfg.OverriddenKeys(vmName) {
+		if k == key {
+			return fmt.Sprintf(" (from [vm.%s])", vmName)
+		}
+	}
+	return ""
+}
+
+// instanceTypeConfig loads the config whose instance_type the doctor checks
+// for vmName. ok is false when the config does not load or sets no region
+// or instance type.
+func instanceTypeConfig(deps *doctorDeps, vmName string) (cfg *config.Config, ok bool) {
+	cfg, err := config.Load(deps.configDir)
+	if err != nil {
+		return nil, false
+	}
+	// A bad [vm.<name>] table is reported by checkConfig; check the
+	// top-level type instead.
+	if vmCfg, vmErr := cfg.ForVM(vmName); vmErr == nil {
+		cfg = vmCfg
+	}
+	return cfg, cfg.Region != "" && cfg.InstanceType != ""
+}
+
+// checkInstanceType validates vmName's configured instance_type against the
+// region's instance type catalog: unknown types fail with a suggestion and
+// previous-generation types warn.
+func checkInstanceType(ctx context.Context, deps *doctorDeps, vmName string) checkResult {
+	cfg, ok := instanceTypeConfig(deps, vmName)
+	if !ok {
+		// The config and region checks already report these cases.
+		return checkResult{
+			name:    "instance_type",
+			status:  "WARN",
+			message: "not checked — region or instance type is not set",
+		}
+	}
+
+	validator := mintaws.NewInstanceTypeValidator(deps.describeTypes).WithCacheDir(deps.configDir)
+	warning, err := validator.Check(ctx, cfg.InstanceType, cfg.Region)
+	var unknown *mintaws.UnknownInstanceTypeError
+	switch {
+	case errors.As(err, &unknown):
+		return checkResult{
+			name:    "instance_type",
+			status:  "FAIL",
+			message: unknown.Error(),
+		}
+	case err != nil:
+		return checkResult{
+			name:    "instance_type",
+			status:  "WARN",
+			message: fmt.Sprintf("could not check %s: %v", cfg.InstanceType, err),
+		}
+	case warning != "":
+		return checkResult{
+			name:    "instance_type",
+			status:  "WARN",
+			message: warning,
+		}
+	}
+	return checkResult{
+		name:    "instance_type",
+		status:  "PASS",
+		message: cfg.InstanceType + vmConfigSource(cfg, vmName, "instance_type"),
+	}
+}
+
+// checkInstanceTypeOffering checks that the availability zones of the
+// default subnets, where mint up launches, offer vmName's configured
+// instance_type: it fails when none does and warns when some do not. ok is
+// false when there is nothing to check.
+func checkInstanceTypeOffering(ctx context.Context, deps *doctorDeps, vmName string) (result checkResult, ok bool) {
+	cfg, ok := instanceTypeConfig(deps, vmName)
+	if !ok {
+		return checkResult{}, false
+	}
+	couldNotCheck := func(err error) (checkResult, bool) {
+		return checkResult{
+			name:    "instance_type_offering",
+			status:  "WARN",
+			message: fmt.Sprintf("could not check where %s is offered: %v", cfg.InstanceType, err),
+		}, true
+	}
+
+	out, err := deps.describeSubnets.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("default-for-az"), Values: []string{"true"}},
+		},
+	})
+	if err != nil {
+		return couldNotCheck(err)
+	}
+	var azs []string
+	for _, subnet := range out.Subnets {
+		if az := aws.ToString(subnet.AvailabilityZone); az != "" && !slices.Contains(azs, az) {
+			azs = append(azs, az)
+		}
+	}
+	if len(azs) == 0 {
+		return checkResult{}, false
+	}
+	slices.Sort(azs)
+
+	offerings := mintaws.NewInstanceTypeOfferings(deps.describeOfferings)
+	var offered, missing []string
+	var first *mintaws.InstanceTypeNotOfferedError
+	for _, az := range azs {
+		err := offerings.CheckAZ(ctx, cfg.InstanceType, az)
+		var notOffered *mintaws.InstanceTypeNotOfferedError
+		switch {
+		case err == nil:
+			offered = append(offered, az)
+		case errors.As(err, &notOffered):
+			if first == nil {
+				first = notOffered
+			}
+			missing = append(missing, az)
+		default:
+			return couldNotCheck(err)
+		}
+	}
+
+	switch {
+	case len(offered) == 0:
+		notOffered := &mintaws.InstanceTypeNotOfferedError{
+			InstanceType: cfg.InstanceType,
+			Location:     strings.Join(missing, ", "),
+			Suggestions:  first.Suggestions,
+		}
+		return checkResult{
+			name:    "instance_type_offering",
+			status:  "FAIL",
+			message: notOffered.Error(),
+		}, true
+	case len(missing) > 0:
+		return checkResult{
+			name:   "instance_type_offering",
+			status: "WARN",
+			message: fmt.Sprintf("%s is not offered in %s — mint up launches only in %s",
+				cfg.InstanceType, strings.Join(missing, ", "), strings.Join(offered, ", ")),
+		}, true
+	}
+	return checkResult{
+		name:    "instance_type_offering",
+		status:  "PASS",
+		message: fmt.Sprintf("%s is offered in every default subnet's AZ (%s)", cfg.InstanceType, strings.Join(azs, ", ")),
+	}, true
+}
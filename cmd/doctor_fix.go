@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// Remediation outcomes of a check under --fix.
+const (
+	remediationFixed   = "fixed"
+	remediationSkipped = "skipped"
+	remediationFailed  = "failed"
+	remediationManual  = "manual"
+)
+
+// errFixDeclined is returned by a fix the user declined.
+var errFixDeclined = errors.New("declined")
+
+// fixPrompter is how a fix asks the user before it changes anything.
+type fixPrompter struct {
+	w   io.Writer
+	in  *bufio.Scanner
+	yes bool
+}
+
+// confirm asks question and reports whether the user answered yes. With
+// --yes it answers yes without asking.
+func (p *fixPrompter) confirm(question string) bool {
+	if p.yes {
+		return true
+	}
+	answer, ok := p.ask(question + " [y/N]: ")
+	answer = strings.ToLower(answer)
+	return ok && (answer == "y" || answer == "yes")
+}
+
+// ask prints prompt and returns the trimmed line the user typed. ok is
+// false at the end of input.
+func (p *fixPrompter) ask(prompt string) (answer string, ok bool) {
+	fmt.Fprint(p.w, prompt)
+	if !p.in.Scan() {
+		fmt.Fprintln(p.w)
+		return "", false
+	}
+	return strings.TrimSpace(p.in.Text()), true
+}
+
+// applyFixes runs the fix of each failing or warning result, and records
+// every such result's remediation outcome. A fixed result passes, with
+// what the fix did as its message. Results named <check>/fix report a
+// reinstall already made and are left alone.
+func applyFixes(ctx context.Context, p *fixPrompter, results []checkResult) {
+	for i := range results {
+		r := &results[i]
+		if r.remediation != "" || strings.HasSuffix(r.name, "/fix") ||
+			(r.status != "FAIL" && r.status != "WARN") {
+			continue
+		}
+		if !r.fixable() {
+			r.remediation = remediationManual
+			continue
+		}
+		fmt.Fprintf(p.w, "[%s] %s: %s\n", r.status, r.name, r.message)
+		detail, err := r.fix(ctx, p)
+		switch {
+		case errors.Is(err, errFixDeclined):
+			r.remediation = remediationSkipped
+		case err != nil:
+			r.remediation = remediationFailed
+			r.remediationDetail = err.Error()
+		default:
+			r.remediation = remediationFixed
+			r.remediationDetail = detail
+			r.status = "PASS"
+			r.message = detail
+		}
+	}
+}
+
+// printFixSummary writes the counts of fixed, skipped, and unfixed results.
+func printFixSummary(w io.Writer, results []checkResult) {
+	var fixed, skipped, manual int
+	for _, r := range results {
+		switch r.remediation {
+		case remediationFixed:
+			fixed++
+		case remediationSkipped:
+			skipped++
+		case remediationFailed, remediationManual:
+			manual++
+		}
+	}
+	fmt.Fprintf(w, "\nFix summary: %d fixed, %d skipped, %d needs manual action\n", fixed, skipped, manual)
+}
+
+// remediationNote returns the suffix printed after a result's message for
+// its remediation outcome.
+func remediationNote(r checkResult) string {
+	switch r.remediation {
+	case remediationFixed:
+		return " (fixed)"
+	case remediationSkipped:
+		return " (fix skipped)"
+	case remediationFailed:
+		return fmt.Sprintf(" (fix failed: %s)", r.remediationDetail)
+	}
+	return ""
+}
+
+// fixSSHConfigFor returns the fix that writes the managed SSH config block
+// for the default VM from its live instance data, or nil when the VM is
+// not running with a public IP to write it for.
+func fixSSHConfigFor(ctx context.Context, deps *doctorDeps, sshPath string) func(context.Context, *fixPrompter) (string, error) {
+	if deps.describe == nil {
+		return nil
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, "default")
+	if err != nil || found == nil || found.State != string(ec2types.InstanceStateNameRunning) || found.PublicIP == "" {
+		return nil
+	}
+	return func(_ context.Context, p *fixPrompter) (string, error) {
+		if !p.confirm(fmt.Sprintf("Write the managed block for VM %q (%s, %s) to %s?", found.Name, found.ID, found.PublicIP, sshPath)) {
+			return "", errFixDeclined
+		}
+		opts := deps.sshOptions
+		if deps.sshVersion != nil {
+			if client, ok := probeSSHClient(deps.sshVersion); ok {
+				opts.Client = client
+			}
+		}
+		block := sshconfig.GenerateBlockWithOptions(found.Name, found.PublicIP, opts.LoginUser(defaultSSHUser),
+			opts.LoginPort(defaultSSHPort), found.ID, found.AvailabilityZone, deps.profile, deps.region, opts)
+		if err := sshconfig.WriteManagedBlock(sshPath, found.Name, block); err != nil {
+			return "", fmt.Errorf("write ssh config: %w", err)
+		}
+		return fmt.Sprintf("wrote managed block for VM %q (%s)", found.Name, found.PublicIP), nil
+	}
+}
+
+// fixRegion asks for a region, offering the AWS SDK's when it has one, and
+// saves it to config.toml. With --yes the SDK's region is saved without
+// asking.
+func fixRegion(deps *doctorDeps) func(context.Context, *fixPrompter) (string, error) {
+	return func(_ context.Context, p *fixPrompter) (string, error) {
+		region := deps.region
+		if !p.yes || region == "" {
+			prompt := "Region to save in config.toml (e.g. us-west-2): "
+			if region != "" {
+				prompt = fmt.Sprintf("Region to save in config.toml [%s]: ", region)
+			}
+			answer, ok := p.ask(prompt)
+			if !ok {
+				return "", errFixDeclined
+			}
+			if answer != "" {
+				region = answer
+			}
+		}
+		if region == "" {
+			return "", errFixDeclined
+		}
+
+		cfg, err := config.Load(deps.configDir)
+		if err != nil {
+			return "", err
+		}
+		if err := cfg.Set("region", region); err != nil {
+			return "", err
+		}
+		if err := config.Save(cfg, deps.configDir); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("set to %s in config.toml", region), nil
+	}
+}
+
+// releasableAddresses returns the owner's mint-tagged Elastic IPs that are
+// not associated with anything. Addresses without mint tags, and those
+// tagged mint:retained=true (such as one kept by mint destroy --keep-eip),
+// are never included.
+func releasableAddresses(addresses []ec2types.Address, owner string) []ec2types.Address {
+	var releasable []ec2types.Address
+	for _, addr := range addresses {
+		t := tags.ToMap(addr.Tags)
+		if t[tags.TagMint] == "true" && t[tags.TagOwner] == owner && addr.AssociationId == nil && addr.AllocationId != nil &&
+			t[tags.TagRetained] != "true" {
+			releasable = append(releasable, addr)
+		}
+	}
+	return releasable
+}
+
+// fixReleaseAddresses returns the fix that releases addresses, out of the
+// allocated Elastic IPs in the account.
+func fixReleaseAddresses(deps *doctorDeps, addresses []ec2types.Address, allocated int) func(context.Context, *fixPrompter) (string, error) {
+	return func(ctx context.Context, p *fixPrompter) (string, error) {
+		ids := make([]string, len(addresses))
+		for i, addr := range addresses {
+			ids[i] = fmt.Sprintf("%s (%s)", aws.ToString(addr.AllocationId), aws.ToString(addr.PublicIp))
+		}
+		if !p.confirm(fmt.Sprintf("Release unassociated mint Elastic IP(s) %s?", strings.Join(ids, ", "))) {
+			return "", errFixDeclined
+		}
+		var released []string
+		for _, addr := range addresses {
+			id := aws.ToString(addr.AllocationId)
+			if _, err := deps.releaseAddress.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{AllocationId: addr.AllocationId}); err != nil {
+				if len(released) > 0 {
+					return "", fmt.Errorf("released %s, then releasing %s: %w", strings.Join(released, ", "), id, err)
+				}
+				return "", fmt.Errorf("releasing %s: %w", id, err)
+			}
+			released = append(released, id)
+		}
+		return fmt.Sprintf("released %s (%d of %d EIPs allocated)",
+			strings.Join(released, ", "), allocated-len(released), eipDefaultLimit), nil
+	}
+}
+
+// checkHostKeys reports stored host keys for VMs that no longer exist.
+// ok is false when there is no host key store or no way to list VMs.
+func checkHostKeys(ctx context.Context, deps *doctorDeps) (result checkResult, ok bool) {
+	const name = "host keys"
+	if deps.hostKeys == nil || deps.describe == nil {
+		return checkResult{}, false
+	}
+	keys, err := deps.hostKeys.ListKeys()
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not read host keys: %v", err)}, true
+	}
+	if len(keys) == 0 {
+		return checkResult{name: name, status: "PASS", message: "no host keys recorded"}, true
+	}
+	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		return checkResult{name: name, status: "WARN", message: fmt.Sprintf("could not list VMs: %v", err)}, true
+	}
+	exists := make(map[string]bool, len(vms))
+	for _, v := range vms {
+		exists[v.Name] = true
+	}
+	var stale []string
+	for _, k := range keys {
+		if !exists[k.VM] {
+			stale = append(stale, k.VM)
+		}
+	}
+	if len(stale) == 0 {
+		return checkResult{name: name, status: "PASS", message: fmt.Sprintf("%d host key(s), all for existing VMs", len(keys))}, true
+	}
+	sort.Strings(stale)
+	return checkResult{
+		name:   name,
+		status: "WARN",
+		message: fmt.Sprintf("host key(s) recorded for VMs that no longer exist: %s — run %s to remove them",
+			strings.Join(stale, ", "), hint.Cmd("mint doctor --fix")),
+		fix: func(_ context.Context, p *fixPrompter) (string, error) {
+			if !p.confirm(fmt.Sprintf("Remove the host keys of %s?", strings.Join(stale, ", "))) {
+				return "", errFixDeclined
+			}
+			for _, vmName := range stale {
+				if err := deps.hostKeys.RemoveKey(vmName); err != nil {
+					return "", err
+				}
+			}
+			return fmt.Sprintf("removed host key(s) of %s", strings.Join(stale, ", ")), nil
+		},
+	}, true
+}
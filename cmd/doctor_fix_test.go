@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// runDoctorFix runs doctor with args, answering prompts from stdin, and
+// returns its output.
+func runDoctorFix(t *testing.T, deps *doctorDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"doctor"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// withoutRegion rewrites the config in deps.configDir without a region.
+func withoutRegion(t *testing.T, deps *doctorDeps) {
+	t.Helper()
+	content := "instance_type = \"m6i.xlarge\"\nvolume_size_gb = 50\nidle_timeout = \"60m\"\n"
+	if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mintAddress returns an Elastic IP tagged for owner, associated when
+// associationID is non-empty.
+func mintAddress(allocationID, owner, associationID string) ec2types.Address {
+	addr := ec2types.Address{
+		AllocationId: aws.String(allocationID),
+		PublicIp:     aws.String("203.0.113.10"),
+		Tags: []ec2types.Tag{
+			{Key: aws.String("mint"), Value: aws.String("true")},
+			{Key: aws.String("mint:owner"), Value: aws.String(owner)},
+		},
+	}
+	if associationID != "" {
+		addr.AssociationId = aws.String(associationID)
+	}
+	return addr
+}
+
+func TestDoctorFixRegion(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		sdkRegion  string
+		wantRegion string
+		wantOutput string
+	}{
+		{name: "typed", args: []string{"--fix"}, stdin: "eu-west-1\n", sdkRegion: "us-east-1", wantRegion: "eu-west-1", wantOutput: "1 fixed, 0 skipped"},
+		{name: "default accepted", args: []string{"--fix"}, stdin: "\n", sdkRegion: "us-east-1", wantRegion: "us-east-1", wantOutput: "Region to save in config.toml [us-east-1]:"},
+		{name: "yes uses the SDK region", args: []string{"--fix", "--yes"}, sdkRegion: "us-east-1", wantRegion: "us-east-1", wantOutput: "[PASS] region: set to us-east-1 in config.toml (fixed)"},
+		{name: "no answer skips", args: []string{"--fix"}, stdin: "", sdkRegion: "us-east-1", wantRegion: "", wantOutput: "0 fixed, 1 skipped"},
+		{name: "without --fix nothing changes", args: nil, sdkRegion: "us-east-1", wantRegion: "", wantOutput: "[FAIL] region: region is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			withoutRegion(t, deps)
+			deps.region = tt.sdkRegion
+
+			output, _ := runDoctorFix(t, deps, tt.stdin, tt.args...)
+			if !strings.Contains(output, tt.wantOutput) {
+				t.Errorf("output missing %q:\n%s", tt.wantOutput, output)
+			}
+			cfg, err := config.Load(deps.configDir)
+			if err != nil {
+				t.Fatalf("loading config: %v", err)
+			}
+			if cfg.Region != tt.wantRegion {
+				t.Errorf("region = %q, want %q", cfg.Region, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestDoctorFixSSHConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		stdin     string
+		args      []string
+		wantBlock bool
+	}{
+		{name: "confirmed", stdin: "y\n", args: []string{"--fix"}, wantBlock: true},
+		{name: "yes", args: []string{"--fix", "--yes"}, wantBlock: true},
+		{name: "declined", stdin: "n\n", args: []string{"--fix"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.sshConfigPath = filepath.Join(t.TempDir(), ".ssh", "config")
+			deps.describe = &cmdtest.DescribeInstances{
+				Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+					ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
+				),
+			}
+			deps.region = "us-west-2"
+
+			output, err := runDoctorFix(t, deps, tt.stdin, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, output)
+			}
+			data, _ := os.ReadFile(deps.sshConfigPath)
+			block, found := sshconfig.ReadManagedBlock(string(data), "default")
+			if found != tt.wantBlock {
+				t.Fatalf("block written = %v, want %v\n%s", found, tt.wantBlock, output)
+			}
+			if !tt.wantBlock {
+				if !strings.Contains(output, "(fix skipped)") {
+					t.Errorf("output missing skipped note:\n%s", output)
+				}
+				return
+			}
+			for _, want := range []string{"HostName 1.2.3.4", "--instance-id i-vm1", "--availability-zone us-west-2a", "--region us-west-2"} {
+				if !strings.Contains(block, want) {
+					t.Errorf("block missing %q:\n%s", want, block)
+				}
+			}
+			if !strings.Contains(output, "1 fixed") {
+				t.Errorf("summary missing fixed count:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestDoctorSSHConfigNotFixableWithoutRunningVM(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	deps.sshConfigPath = filepath.Join(t.TempDir(), ".ssh", "config")
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "stopped", ""),
+	}
+
+	output, _ := runDoctorFix(t, deps, "", "--fix", "--yes")
+	if _, err := os.Stat(deps.sshConfigPath); !os.IsNotExist(err) {
+		t.Errorf("ssh config written for a stopped VM")
+	}
+	if !strings.Contains(output, "needs manual action") {
+		t.Errorf("output missing summary:\n%s", output)
+	}
+}
+
+func TestDoctorFixReleasesOnlyMintAddresses(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	deps.describeAddresses = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{
+			mintAddress("eipalloc-free", "alice", ""),
+			mintAddress("eipalloc-used", "alice", "eipassoc-1"),
+			mintAddress("eipalloc-bob", "bob", ""),
+			{AllocationId: aws.String("eipalloc-other"), PublicIp: aws.String("203.0.113.11")},
+		}},
+	}
+	release := &cmdtest.ReleaseAddress{Output: &ec2.ReleaseAddressOutput{}}
+	deps.releaseAddress = release
+
+	output, err := runDoctorFix(t, deps, "y\n", "--fix")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+	if len(release.Released) != 1 || release.Released[0] != "eipalloc-free" {
+		t.Errorf("released = %v, want [eipalloc-free]", release.Released)
+	}
+	if !strings.Contains(output, "[PASS] EIP quota: released eipalloc-free (3 of 5 EIPs allocated) (fixed)") {
+		t.Errorf("output missing fixed EIP quota:\n%s", output)
+	}
+}
+
+// taggedAddresses is an account's Elastic IPs: DescribeAddresses lists them
+// and CreateTags adds tags to them.
+type taggedAddresses struct {
+	addresses []ec2types.Address
+}
+
+func (m *taggedAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	return &ec2.DescribeAddressesOutput{Addresses: m.addresses}, nil
+}
+
+func (m *taggedAddresses) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	for i, addr := range m.addresses {
+		if slices.Contains(params.Resources, aws.ToString(addr.AllocationId)) {
+			m.addresses[i].Tags = append(m.addresses[i].Tags, params.Tags...)
+		}
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func TestDoctorFixKeepsAddressKeptByDestroy(t *testing.T) {
+	// The kept address and three others fill the quota to 4 of 5.
+	account := &taggedAddresses{addresses: []ec2types.Address{mintAddress("eipalloc-abc", "alice", "")}}
+	for i := range 3 {
+		account.addresses = append(account.addresses, ec2types.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))})
+	}
+
+	destroy := newHappyDestroyDeps("alice")
+	destroy.describeAddrs = account
+	destroy.createTags = account
+	root := cmdtest.NewRoot(newDestroyCommandWithDeps(destroy))
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"destroy", "--keep-eip", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("destroy: %v", err)
+	}
+	if got := tags.ToMap(account.addresses[0].Tags)[tags.TagRetained]; got != "true" {
+		t.Fatalf("kept address %s = %q, want true", tags.TagRetained, got)
+	}
+
+	deps := newHappyDoctorDeps(t)
+	deps.describeAddresses = account
+	release := &cmdtest.ReleaseAddress{Output: &ec2.ReleaseAddressOutput{}}
+	deps.releaseAddress = release
+
+	output, _ := runDoctorFix(t, deps, "", "--fix", "--yes")
+	if len(release.Released) != 0 {
+		t.Errorf("released %v, want the kept address left alone", release.Released)
+	}
+	if !strings.Contains(output, "0 fixed, 0 skipped, 1 needs manual action") {
+		t.Errorf("output missing summary:\n%s", output)
+	}
+}
+
+func TestDoctorEIPQuotaNotFixableWithoutMintAddresses(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	deps.describeAddresses = happyDescribeAddresses(4)
+	release := &cmdtest.ReleaseAddress{Output: &ec2.ReleaseAddressOutput{}}
+	deps.releaseAddress = release
+
+	output, _ := runDoctorFix(t, deps, "", "--fix", "--yes")
+	if len(release.Released) != 0 {
+		t.Errorf("released %v, want none", release.Released)
+	}
+	if !strings.Contains(output, "0 fixed, 0 skipped, 1 needs manual action") {
+		t.Errorf("output missing summary:\n%s", output)
+	}
+}
+
+func TestDoctorFixStaleHostKeys(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	deps.hostKeys = sshconfig.NewHostKeyStore(t.TempDir())
+	for _, name := range []string{"default", "old"} {
+		if err := deps.hostKeys.RecordKey(name, "SHA256:"+name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
+		),
+	}
+
+	output, _ := runDoctorFix(t, deps, "")
+	if !strings.Contains(output, "[WARN] host keys: host key(s) recorded for VMs that no longer exist: old") {
+		t.Errorf("output missing stale host key warning:\n%s", output)
+	}
+
+	output, err := runDoctorFix(t, deps, "", "--fix", "--yes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+	keys, err := deps.hostKeys.ListKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].VM != "default" {
+		t.Errorf("keys = %v, want only default", keys)
+	}
+	if !strings.Contains(output, "removed host key(s) of old (fixed)") {
+		t.Errorf("output missing fixed host keys:\n%s", output)
+	}
+}
+
+func TestDoctorFixJSON(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	withoutRegion(t, deps)
+	deps.region = "us-east-1"
+	// volume_size_gb below the minimum has no known remediation.
+	content := "volume_size_gb = 10\nidle_timeout = \"60m\"\n"
+	if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"doctor", "--fix", "--yes", "--json"})
+	_ = root.Execute()
+
+	var results []checkResultJSON
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	byName := make(map[string]checkResultJSON)
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+	if r := byName["region"]; r.Status != "PASS" || !r.Fixable || r.Remediation != "fixed" || r.RemediationDetail != "set to us-east-1 in config.toml" {
+		t.Errorf("region = %+v, want a fixed PASS", r)
+	}
+	if r := byName["volume_size_gb"]; r.Status != "FAIL" || r.Fixable || r.Remediation != "manual" {
+		t.Errorf("volume_size_gb = %+v, want FAIL needing manual action", r)
+	}
+	if r := byName["AWS credentials"]; r.Remediation != "" {
+		t.Errorf("passing check has remediation %q", r.Remediation)
+	}
+}
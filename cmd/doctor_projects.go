@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// projectExecTimeoutSeconds bounds the docker exec liveness probe. A
+// container that cannot run `true` in this time is treated as wedged.
+const projectExecTimeoutSeconds = 10
+
+// maxHealthyRestartCount is the highest container restart count doctor
+// --deep reports as PASS.
+const maxHealthyRestartCount = 3
+
+// containerInspect is the subset of `docker inspect` output doctor --deep
+// reads for a project container.
+type containerInspect struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status  string `json:"Status"`
+		Running bool   `json:"Running"`
+	} `json:"State"`
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+}
+
+// checkProjects runs the --deep container checks for every project on v.
+// Each project becomes one grouped result whose children are the individual
+// checks. Problems are WARNs; the groups are promoted to FAIL only when every
+// project's container is broken (missing, stopped, or unresponsive).
+func checkProjects(ctx context.Context, deps *doctorDeps, v *vm.VM, prefix string) []checkResult {
+	name := prefix + "/projects"
+	run := func(command ...string) ([]byte, error) {
+		return deps.remoteRun(ctx, deps.sendKey, v.ID, v.AvailabilityZone, v.PublicIP,
+			sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	}
+
+	projects, err := listProjectDirs(run)
+	if err != nil {
+		return []checkResult{{name: name, status: "WARN", message: fmt.Sprintf("could not list projects: %v", err)}}
+	}
+	if len(projects) == 0 {
+		return []checkResult{{name: name, status: "PASS", message: "no projects"}}
+	}
+
+	containers, err := inspectProjectContainers(run)
+	if err != nil {
+		return []checkResult{{name: name, status: "WARN", message: fmt.Sprintf("could not inspect containers: %v", err)}}
+	}
+
+	var results []checkResult
+	broken := 0
+	for _, project := range projects {
+		group, isBroken := checkProject(run, prefix+"/project/"+project, project, containers["/mint/projects/"+project])
+		if isBroken {
+			broken++
+		}
+		results = append(results, group)
+	}
+
+	if broken == len(results) {
+		for i := range results {
+			results[i].status = "FAIL"
+			results[i].message += " (no project container is healthy)"
+		}
+	}
+	return results
+}
+
+// inspectProjectContainers returns the devcontainers on the VM keyed by
+// the project directory their devcontainer.local_folder label belongs to.
+// When a project has several containers, a running one is preferred.
+func inspectProjectContainers(run func(command ...string) ([]byte, error)) (map[string]*containerInspect, error) {
+	psOutput, err := run("docker", "ps", "-aq", "--filter", "label=devcontainer.local_folder")
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(psOutput))
+	containers := make(map[string]*containerInspect)
+	if len(ids) == 0 {
+		return containers, nil
+	}
+
+	inspectOutput, err := run(append([]string{"docker", "inspect"}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	var inspected []containerInspect
+	if err := json.Unmarshal(inspectOutput, &inspected); err != nil {
+		return nil, fmt.Errorf("parsing docker inspect output: %w", err)
+	}
+	for i := range inspected {
+		c := &inspected[i]
+		folder := projectRootFolder(c.Config.Labels["devcontainer.local_folder"])
+		if existing, ok := containers[folder]; ok && existing.State.Running {
+			continue
+		}
+		containers[folder] = c
+	}
+	return containers, nil
+}
+
+// checkProject checks one project's container and returns the grouped
+// result and whether the container is broken.
+func checkProject(run func(command ...string) ([]byte, error), name, project string, c *containerInspect) (checkResult, bool) {
+	group := checkResult{name: name, status: "PASS"}
+
+	if c == nil {
+		group.status = "WARN"
+		group.message = "no container"
+		group.children = []checkResult{{
+			name:   name + "/container",
+			status: "WARN",
+			message: fmt.Sprintf("missing — no devcontainer for /mint/projects/%s; run %s",
+				project, hint.Cmd("mint project rebuild "+project)),
+		}}
+		return group, true
+	}
+
+	containerName := strings.TrimPrefix(c.Name, "/")
+	broken := false
+	if c.State.Running {
+		group.children = append(group.children, checkResult{
+			name: name + "/container", status: "PASS", message: fmt.Sprintf("running (%s)", containerName),
+		})
+		group.children = append(group.children, checkProjectExec(run, name, c.ID))
+		broken = group.children[len(group.children)-1].status != "PASS"
+	} else {
+		group.children = append(group.children, checkResult{
+			name: name + "/container", status: "WARN",
+			message: fmt.Sprintf("%s (%s) — start it with %s", c.State.Status, containerName, hint.Cmd("mint project rebuild "+project)),
+		})
+		broken = true
+	}
+	group.children = append(group.children,
+		checkProjectMount(name, project, c),
+		checkProjectRestarts(name, c),
+	)
+
+	var problems []string
+	for _, child := range group.children {
+		if child.status != "PASS" {
+			problems = append(problems, strings.TrimPrefix(child.name, name+"/"))
+		}
+	}
+	if len(problems) == 0 {
+		group.message = "healthy"
+	} else {
+		group.status = "WARN"
+		group.message = "problems: " + strings.Join(problems, ", ")
+	}
+	return group, broken
+}
+
+// checkProjectExec runs `true` inside the container to confirm it is not
+// wedged.
+func checkProjectExec(run func(command ...string) ([]byte, error), name, containerID string) checkResult {
+	_, err := run("timeout", fmt.Sprint(projectExecTimeoutSeconds), "docker", "exec", containerID, "true")
+	if err == nil {
+		return checkResult{name: name + "/exec", status: "PASS", message: "responsive"}
+	}
+	if isTimeoutExit(err) {
+		return checkResult{
+			name:    name + "/exec",
+			status:  "WARN",
+			message: fmt.Sprintf("docker exec did not finish within %ds — the container may be wedged", projectExecTimeoutSeconds),
+		}
+	}
+	return checkResult{name: name + "/exec", status: "WARN", message: fmt.Sprintf("docker exec failed: %v", err)}
+}
+
+// isTimeoutExit reports whether err is timeout(1)'s exit status 124.
+func isTimeoutExit(err error) bool {
+	return strings.Contains(err.Error(), "exit status 124")
+}
+
+// checkProjectMount checks that the project directory, or for a --subdir
+// project a directory below it, is mounted into the container.
+func checkProjectMount(name, project string, c *containerInspect) checkResult {
+	want := "/mint/projects/" + project
+	var sources []string
+	for _, m := range c.Mounts {
+		if source := strings.TrimSuffix(m.Source, "/"); source == want || strings.HasPrefix(source, want+"/") {
+			return checkResult{name: name + "/mount", status: "PASS", message: fmt.Sprintf("%s mounted at %s", source, m.Destination)}
+		}
+		sources = append(sources, m.Source)
+	}
+	found := "no mounts"
+	if len(sources) > 0 {
+		found = "mounts: " + strings.Join(sources, ", ")
+	}
+	return checkResult{
+		name:    name + "/mount",
+		status:  "WARN",
+		message: fmt.Sprintf("workspace is not mounted from %s (%s)", want, found),
+	}
+}
+
+// checkProjectRestarts reports the container's restart count, warning when
+// it suggests a crash loop.
+func checkProjectRestarts(name string, c *containerInspect) checkResult {
+	if c.RestartCount > maxHealthyRestartCount {
+		return checkResult{
+			name:    name + "/restarts",
+			status:  "WARN",
+			message: fmt.Sprintf("restarted %d times — check %s", c.RestartCount, hint.Cmd("docker logs "+strings.TrimPrefix(c.Name, "/"))),
+		}
+	}
+	return checkResult{name: name + "/restarts", status: "PASS", message: fmt.Sprintf("%d restarts", c.RestartCount)}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+// projectRemoteRunner answers the doctor --deep commands from fixtures and
+// falls back to the happy host-level responses for everything else.
+type projectRemoteRunner struct {
+	ls      string
+	ps      string
+	inspect string
+	// execErr maps a container ID to the docker exec error it returns.
+	execErr map[string]error
+	host    *mockDoctorRemoteRunner
+}
+
+func (r *projectRemoteRunner) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	joined := strings.Join(command, " ")
+	switch {
+	case joined == "ls -1 /mint/projects/":
+		return []byte(r.ls), nil
+	case strings.HasPrefix(joined, "docker ps -aq"):
+		return []byte(r.ps), nil
+	case strings.HasPrefix(joined, "docker inspect"):
+		return []byte(r.inspect), nil
+	case len(command) == 6 && command[0] == "timeout" && command[3] == "exec":
+		return nil, r.execErr[command[4]]
+	}
+	return r.host.run(ctx, sendKey, instanceID, az, host, port, user, command)
+}
+
+// inspectFixture renders one container in docker inspect JSON form.
+func inspectFixture(id, project string, running bool, restarts int, mountSource string) string {
+	status := "running"
+	if !running {
+		status = "exited"
+	}
+	return fmt.Sprintf(`{"Id": %q, "Name": "/%s-dev", "RestartCount": %d,
+  "State": {"Status": %q, "Running": %t},
+  "Mounts": [{"Type": "bind", "Source": %q, "Destination": "/workspaces/%s"}],
+  "Config": {"Labels": {"devcontainer.local_folder": "/mint/projects/%s"}}}`,
+		id, project, restarts, status, running, mountSource, project, project)
+}
+
+func runDeepDoctor(t *testing.T, runner *projectRemoteRunner, extraArgs ...string) (string, error) {
+	t.Helper()
+	deps, host := newHappyDoctorDepsWithVM(t)
+	runner.host = host
+	deps.remoteRun = runner.run
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"doctor", "--deep"}, extraArgs...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestDoctorDeepProjectChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		runner  *projectRemoteRunner
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "healthy",
+			runner: &projectRemoteRunner{
+				ls:      "api\nweb\n",
+				ps:      "c1\nc2\n",
+				inspect: "[" + inspectFixture("c1", "api", true, 0, "/mint/projects/api") + "," + inspectFixture("c2", "web", true, 1, "/mint/projects/web") + "]",
+			},
+			want: []string{
+				"[PASS] vm/default/project/api: healthy",
+				"    [PASS] vm/default/project/api/container: running (api-dev)",
+				"    [PASS] vm/default/project/api/exec: responsive",
+				"    [PASS] vm/default/project/api/mount: /mint/projects/api mounted at /workspaces/api",
+				"    [PASS] vm/default/project/web/restarts: 1 restarts",
+			},
+		},
+		{
+			name: "wedged exec timeout",
+			runner: &projectRemoteRunner{
+				ls:      "api\nweb\n",
+				ps:      "c1\nc2\n",
+				inspect: "[" + inspectFixture("c1", "api", true, 0, "/mint/projects/api") + "," + inspectFixture("c2", "web", true, 0, "/mint/projects/web") + "]",
+				execErr: map[string]error{"c1": errors.New("remote command failed: exit status 124 (stderr: )")},
+			},
+			want: []string{
+				"[WARN] vm/default/project/api: problems: exec",
+				"    [WARN] vm/default/project/api/exec: docker exec did not finish within 10s",
+				"[PASS] vm/default/project/web: healthy",
+			},
+		},
+		{
+			name: "wrong mount",
+			runner: &projectRemoteRunner{
+				ls:      "api\n",
+				ps:      "c1\n",
+				inspect: "[" + inspectFixture("c1", "api", true, 0, "/home/ubuntu/api") + "]",
+			},
+			want: []string{
+				"[WARN] vm/default/project/api: problems: mount",
+				"    [WARN] vm/default/project/api/mount: workspace is not mounted from /mint/projects/api (mounts: /home/ubuntu/api)",
+			},
+		},
+		{
+			name: "high restart count",
+			runner: &projectRemoteRunner{
+				ls:      "api\n",
+				ps:      "c1\n",
+				inspect: "[" + inspectFixture("c1", "api", true, 7, "/mint/projects/api") + "]",
+			},
+			want: []string{
+				"[WARN] vm/default/project/api: problems: restarts",
+				"    [WARN] vm/default/project/api/restarts: restarted 7 times",
+			},
+		},
+		{
+			name: "every project broken fails",
+			runner: &projectRemoteRunner{
+				ls:      "api\nweb\n",
+				ps:      "c1\n",
+				inspect: "[" + inspectFixture("c1", "api", false, 0, "/mint/projects/api") + "]",
+			},
+			want: []string{
+				"[FAIL] vm/default/project/api: problems: container (no project container is healthy)",
+				"    [WARN] vm/default/project/api/container: exited (api-dev)",
+				"[FAIL] vm/default/project/web: no container (no project container is healthy)",
+				"    [WARN] vm/default/project/web/container: missing",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runDeepDoctor(t, tt.runner)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v\n%s", err, tt.wantErr, output)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestDoctorDeepSkippedWithoutFlag(t *testing.T) {
+	deps, runner := newHappyDoctorDepsWithVM(t)
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	for _, call := range runner.calls {
+		if call.command[0] == "ls" {
+			t.Errorf("project checks ran without --deep: %v", call.command)
+		}
+	}
+}
+
+func TestDoctorDeepJSONNestsProjectChecks(t *testing.T) {
+	runner := &projectRemoteRunner{
+		ls:      "api\n",
+		ps:      "c1\n",
+		inspect: "[" + inspectFixture("c1", "api", true, 5, "/mint/projects/api") + "]",
+	}
+	output, err := runDeepDoctor(t, runner, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	var results []checkResultJSON
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, output)
+	}
+	var group *checkResultJSON
+	for i := range results {
+		if results[i].Name == "vm/default/project/api" {
+			group = &results[i]
+		}
+	}
+	if group == nil {
+		t.Fatalf("no project group in JSON:\n%s", output)
+	}
+	if group.Status != "WARN" || len(group.Checks) != 4 {
+		t.Fatalf("group = %+v, want WARN with 4 nested checks", group)
+	}
+	if got := group.Checks[3]; got.Name != "vm/default/project/api/restarts" || got.Status != "WARN" {
+		t.Errorf("restarts check = %+v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,16 +12,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/identity"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/identity"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
-	"github.com/spf13/cobra"
 )
 
 // ---------------------------------------------------------------------------
@@ -36,37 +38,6 @@ func (m *mockDoctorSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCa
 	return m.output, m.err
 }
 
-type mockDoctorDescribeAddresses struct {
-	output *ec2.DescribeAddressesOutput
-	err    error
-}
-
-func (m *mockDoctorDescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
-	return m.output, m.err
-}
-
-// mockDoctorDescribeInstances implements mintaws.DescribeInstancesAPI for
-// doctor VM checks.
-type mockDoctorDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDoctorDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-// mockDoctorSendSSHPublicKey implements mintaws.SendSSHPublicKeyAPI for
-// doctor VM checks.
-type mockDoctorSendSSHPublicKey struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-}
-
-func (m *mockDoctorSendSSHPublicKey) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	return m.output, m.err
-}
-
 // mockDoctorRemoteRunner records remote commands and returns configured
 // output per command. It matches on the first element of the command slice.
 type mockDoctorRemoteRunner struct {
@@ -113,26 +84,6 @@ func (m *mockDoctorRemoteRunner) run(
 // Helpers
 // ---------------------------------------------------------------------------
 
-func newDoctorTestRoot(sub *cobra.Command) *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	root.AddCommand(sub)
-	return root
-}
-
 func happySTS() *mockDoctorSTS {
 	return &mockDoctorSTS{
 		output: &sts.GetCallerIdentityOutput{
@@ -142,13 +93,13 @@ func happySTS() *mockDoctorSTS {
 	}
 }
 
-func happyDescribeAddresses(count int) *mockDoctorDescribeAddresses {
+func happyDescribeAddresses(count int) *cmdtest.DescribeAddresses {
 	addrs := make([]ec2types.Address, count)
 	for i := range addrs {
 		addrs[i] = ec2types.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))}
 	}
-	return &mockDoctorDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{Addresses: addrs},
+	return &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{Addresses: addrs},
 	}
 }
 
@@ -161,7 +112,7 @@ func writeValidConfig(t *testing.T, dir string) {
 	content := `region = "us-west-2"
 instance_type = "m6i.xlarge"
 volume_size_gb = 50
-idle_timeout_minutes = 60
+idle_timeout = "60m"
 `
 	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
 		t.Fatal(err)
@@ -233,6 +184,8 @@ func happyRemoteRunner() *mockDoctorRemoteRunner {
 			"devcontainer": {output: []byte("0.52.1\n")},
 			"tmux":         {output: []byte("tmux 3.3a\n")},
 			"mosh-server":  {output: []byte("mosh 1.4.0\n")},
+
+			agentVersionReadCommand()[0]: {output: []byte("3\n")},
 		},
 	}
 }
@@ -243,13 +196,13 @@ func newHappyDoctorDepsWithVM(t *testing.T) (*doctorDeps, *mockDoctorRemoteRunne
 	t.Helper()
 	deps := newHappyDoctorDeps(t)
 	runner := happyRemoteRunner()
-	deps.describe = &mockDoctorDescribeInstances{
-		output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
 			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
 		),
 	}
-	deps.sendKey = &mockDoctorSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{},
+	deps.sendKey = &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{},
 	}
 	deps.remoteRun = runner.run
 	return deps, runner
@@ -263,7 +216,7 @@ func TestDoctorAllPass(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -292,7 +245,7 @@ func TestDoctorCredentialCheckFail(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -312,7 +265,7 @@ func TestDoctorCredentialCheckPass(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -326,6 +279,9 @@ func TestDoctorCredentialCheckPass(t *testing.T) {
 	if !strings.Contains(output, "[PASS]") || !strings.Contains(output, "AWS credentials") {
 		t.Errorf("expected [PASS] AWS credentials, got: %s", output)
 	}
+	if !strings.Contains(output, "authenticated as alice (arn:aws:iam::123456789012:user/alice)") {
+		t.Errorf("expected owner and raw ARN, got: %s", output)
+	}
 }
 
 func TestDoctorRegionNotSet(t *testing.T) {
@@ -334,7 +290,7 @@ func TestDoctorRegionNotSet(t *testing.T) {
 	dir := deps.configDir
 	content := `instance_type = "m6i.xlarge"
 volume_size_gb = 50
-idle_timeout_minutes = 60
+idle_timeout = "60m"
 `
 	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
 		t.Fatalf("writing config: %v", err)
@@ -342,7 +298,7 @@ idle_timeout_minutes = 60
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -363,7 +319,7 @@ func TestDoctorRegionInvalidFormat(t *testing.T) {
 	dir := deps.configDir
 	content := `region = "invalid"
 volume_size_gb = 50
-idle_timeout_minutes = 60
+idle_timeout = "60m"
 `
 	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
 		t.Fatalf("writing config: %v", err)
@@ -371,7 +327,7 @@ idle_timeout_minutes = 60
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -392,7 +348,7 @@ func TestDoctorVolumeTooSmall(t *testing.T) {
 	dir := deps.configDir
 	content := `region = "us-west-2"
 volume_size_gb = 10
-idle_timeout_minutes = 60
+idle_timeout = "60m"
 `
 	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
 		t.Fatalf("writing config: %v", err)
@@ -400,7 +356,7 @@ idle_timeout_minutes = 60
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -421,7 +377,7 @@ func TestDoctorIdleTimeoutTooLow(t *testing.T) {
 	dir := deps.configDir
 	content := `region = "us-west-2"
 volume_size_gb = 50
-idle_timeout_minutes = 5
+idle_timeout = "5m"
 `
 	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
 		t.Fatalf("writing config: %v", err)
@@ -429,7 +385,7 @@ idle_timeout_minutes = 5
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -445,13 +401,75 @@ idle_timeout_minutes = 5
 	}
 }
 
+func TestDoctorLegacyIdleTimeoutMinutesWarns(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	content := `region = "us-west-2"
+volume_size_gb = 50
+idle_timeout_minutes = 90
+`
+	if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("deprecated key should warn, not fail: %v\n%s", err, buf.String())
+	}
+
+	output := buf.String()
+	for _, want := range []string{"[WARN] idle_timeout", "1h 30m", "idle_timeout_minutes is deprecated", "mint config set idle_timeout 90m"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDoctorExtraTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		wantErr bool
+		want    string
+	}{
+		{"valid", "CostCenter = \"1234\"\nTeam = \"platform\"\n", false, "[PASS] extra_tags: CostCenter, Team"},
+		{"mint key", "\"mint:owner\" = \"bob\"\n", true, `[FAIL] extra_tags: tag key "mint:owner" would override one of mint's own tags`},
+		{"bad characters", "Team = \"a&b\"\n", true, `value of tag "Team" may only contain`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			content := "region = \"us-west-2\"\n\n[extra_tags]\n" + tt.table
+			if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+				t.Fatalf("writing config: %v", err)
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+			if err := root.Execute(); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v\n%s", err, tt.wantErr, buf.String())
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
 func TestDoctorSSHConfigMissing(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
 	deps.sshConfigPath = "/nonexistent/path/.ssh/config"
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -473,7 +491,7 @@ func TestDoctorSSHConfigPresent(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -489,13 +507,29 @@ func TestDoctorSSHConfigPresent(t *testing.T) {
 	}
 }
 
+func TestCheckSSHConfigCRLF(t *testing.T) {
+	// A backslash is an ordinary file name character here and a separator
+	// on Windows; either way the path must be used as given.
+	path := filepath.Join(t.TempDir(), `C:\Users\alice\.ssh\config`)
+	block := sshconfig.GenerateBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "")
+	content := strings.ReplaceAll("Host example\n\n"+block, "\n", "\r\n")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	result := checkSSHConfig(context.Background(), &doctorDeps{sshConfigPath: path})
+	if result.status != "PASS" {
+		t.Errorf("status = %q (%s), want PASS", result.status, result.message)
+	}
+}
+
 func TestDoctorEIPQuotaOK(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
 	deps.describeAddresses = happyDescribeAddresses(2) // 2 of 5, plenty of headroom
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -517,7 +551,7 @@ func TestDoctorEIPQuotaWarn(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -540,7 +574,7 @@ func TestDoctorEIPQuotaFull(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -558,13 +592,13 @@ func TestDoctorEIPQuotaFull(t *testing.T) {
 
 func TestDoctorEIPDescribeError(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
-	deps.describeAddresses = &mockDoctorDescribeAddresses{
-		err: fmt.Errorf("access denied"),
+	deps.describeAddresses = &cmdtest.DescribeAddresses{
+		Err: fmt.Errorf("access denied"),
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -586,7 +620,7 @@ func TestDoctorOutputFormat(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -616,7 +650,7 @@ func TestDoctorReturnsErrorOnFail(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -635,7 +669,7 @@ func TestDoctorNoErrorOnWarnOnly(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -655,7 +689,7 @@ func TestDoctorVMHealthy(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -685,15 +719,15 @@ func TestDoctorVMHealthy(t *testing.T) {
 func TestDoctorVMDriftDetected(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// Set health tag to drift-detected.
-	deps.describe = &mockDoctorDescribeInstances{
-		output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
 			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("drift-detected")},
 		),
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -712,13 +746,13 @@ func TestDoctorVMDriftDetected(t *testing.T) {
 func TestDoctorVMHealthTagMissing(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// No health tag.
-	deps.describe = &mockDoctorDescribeInstances{
-		output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4"),
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4"),
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -734,18 +768,110 @@ func TestDoctorVMHealthTagMissing(t *testing.T) {
 	}
 }
 
+func TestDoctorVMOwnerCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		taggedARN string
+		wantWarn  bool
+	}{
+		{"different identity with the same owner", "arn:aws:sts::123456789012:assumed-role/Role/Alice@other.com", true},
+		{"same identity", "arn:aws:iam::123456789012:user/alice", false},
+		{"no owner ARN tag", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, _ := newHappyDoctorDepsWithVM(t)
+			deps.ownerARN = "arn:aws:iam::123456789012:user/alice"
+			extra := []ec2types.Tag{{Key: aws.String("mint:health"), Value: aws.String("healthy")}}
+			if tt.taggedARN != "" {
+				extra = append(extra, ec2types.Tag{Key: aws.String("mint:owner-arn"), Value: aws.String(tt.taggedARN)})
+			}
+			deps.describe = &cmdtest.DescribeInstances{
+				Output: makeDoctorInstance("i-vm1", "default", "alice", "stopped", "", extra...),
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			warned := strings.Contains(output, "vm/default/owner")
+			if warned != tt.wantWarn {
+				t.Fatalf("owner collision warning = %v, want %v:\n%s", warned, tt.wantWarn, output)
+			}
+			if tt.wantWarn && !strings.Contains(output, tt.taggedARN) {
+				t.Errorf("warning should name the other ARN, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestDoctorVMUserBootstrapFailed(t *testing.T) {
+	deps, _ := newHappyDoctorDepsWithVM(t)
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
+			ec2types.Tag{Key: aws.String("mint:user-bootstrap"), Value: aws.String("failed:3")},
+		),
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newDoctorCommandWithDeps(deps)
+	root := cmdtest.NewRoot(cmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+
+	// A failed user hook is a warning, not a failure.
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "vm/default/user-bootstrap") ||
+		!strings.Contains(output, "core bootstrap: complete, user hook: failed (exit 3)") {
+		t.Errorf("expected WARN for failed user hook, got: %s", output)
+	}
+	if !strings.Contains(output, "mint logs --vm default") {
+		t.Errorf("user hook warning should point at mint logs, got: %s", output)
+	}
+}
+
+func TestDoctorVMUserBootstrapAbsentSkipsCheck(t *testing.T) {
+	deps, _ := newHappyDoctorDepsWithVM(t)
+
+	buf := new(bytes.Buffer)
+	cmd := newDoctorCommandWithDeps(deps)
+	root := cmdtest.NewRoot(cmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "user-bootstrap") {
+		t.Errorf("user-bootstrap check should be skipped when no hook ran, got: %s", buf.String())
+	}
+}
+
 func TestDoctorVMNotRunning(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// VM is stopped.
-	deps.describe = &mockDoctorDescribeInstances{
-		output: makeDoctorInstance("i-vm1", "default", "alice", "stopped", "",
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-vm1", "default", "alice", "stopped", "",
 			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
 		),
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -766,16 +892,65 @@ func TestDoctorVMNotRunning(t *testing.T) {
 	}
 }
 
+func TestDoctorVMVolumeEncryption(t *testing.T) {
+	hint.IsTTY = false
+	for _, tc := range []struct {
+		name    string
+		volumes *cmdtest.DescribeVolumes
+		want    string
+	}{
+		{
+			name: "encrypted",
+			volumes: &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+				{VolumeId: aws.String("vol-proj"), Encrypted: aws.Bool(true)},
+			}}},
+			want: "[PASS] vm/default/encryption: project volume encrypted",
+		},
+		{
+			name: "not encrypted",
+			volumes: &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+				{VolumeId: aws.String("vol-proj"), Encrypted: aws.Bool(false)},
+			}}},
+			want: "[WARN] vm/default/encryption: project volume is not encrypted — `mint snapshot create` then `mint snapshot restore`",
+		},
+		{
+			name:    "describe error",
+			volumes: &cmdtest.DescribeVolumes{Err: fmt.Errorf("access denied")},
+			want:    "[WARN] vm/default/encryption: could not check volume encryption",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deps, _ := newHappyDoctorDepsWithVM(t)
+			// Encryption is checked even when the VM is stopped.
+			deps.describe = &cmdtest.DescribeInstances{
+				Output: makeDoctorInstance("i-vm1", "default", "alice", "stopped", ""),
+			}
+			deps.describeVolumes = tc.volumes
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+			_ = root.Execute()
+
+			if !strings.Contains(buf.String(), tc.want) {
+				t.Errorf("output missing %q:\n%s", tc.want, buf.String())
+			}
+		})
+	}
+}
+
 func TestDoctorVMNoVMs(t *testing.T) {
 	deps := newHappyDoctorDeps(t)
 	// Describe returns no VMs.
-	deps.describe = &mockDoctorDescribeInstances{
-		output: &ec2.DescribeInstancesOutput{},
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: &ec2.DescribeInstancesOutput{},
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -808,7 +983,7 @@ func TestDoctorVMComponentFail(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -846,7 +1021,7 @@ func TestDoctorFixMode(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--fix"})
@@ -887,7 +1062,7 @@ func TestDoctorFixModeSkipsPassingComponents(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--fix"})
@@ -909,7 +1084,7 @@ func TestDoctorJSONOutput(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--json"})
@@ -970,7 +1145,7 @@ func TestDoctorJSONOutputLocalOnly(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--json"})
@@ -994,15 +1169,15 @@ func TestDoctorJSONOutputLocalOnly(t *testing.T) {
 func TestDoctorVMSpecificVM(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// Override describe to return a VM named "dev-box".
-	deps.describe = &mockDoctorDescribeInstances{
-		output: makeDoctorInstance("i-dev", "dev-box", "alice", "running", "10.0.0.1",
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeDoctorInstance("i-dev", "dev-box", "alice", "running", "10.0.0.1",
 			ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
 		),
 	}
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--vm", "dev-box"})
@@ -1023,11 +1198,11 @@ func TestDoctorMultipleVMs(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// Return two running VMs.
 	inst1 := ec2types.Instance{
-		InstanceId:   aws.String("i-vm1"),
-		InstanceType: ec2types.InstanceTypeM6iXlarge,
-		LaunchTime:   aws.Time(time.Now()),
-		State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
-		Placement:    &ec2types.Placement{AvailabilityZone: aws.String("us-west-2a")},
+		InstanceId:      aws.String("i-vm1"),
+		InstanceType:    ec2types.InstanceTypeM6iXlarge,
+		LaunchTime:      aws.Time(time.Now()),
+		State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:       &ec2types.Placement{AvailabilityZone: aws.String("us-west-2a")},
 		PublicIpAddress: aws.String("1.2.3.4"),
 		Tags: []ec2types.Tag{
 			{Key: aws.String("mint"), Value: aws.String("true")},
@@ -1037,11 +1212,11 @@ func TestDoctorMultipleVMs(t *testing.T) {
 		},
 	}
 	inst2 := ec2types.Instance{
-		InstanceId:   aws.String("i-vm2"),
-		InstanceType: ec2types.InstanceTypeM6iXlarge,
-		LaunchTime:   aws.Time(time.Now()),
-		State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
-		Placement:    &ec2types.Placement{AvailabilityZone: aws.String("us-west-2b")},
+		InstanceId:      aws.String("i-vm2"),
+		InstanceType:    ec2types.InstanceTypeM6iXlarge,
+		LaunchTime:      aws.Time(time.Now()),
+		State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:       &ec2types.Placement{AvailabilityZone: aws.String("us-west-2b")},
 		PublicIpAddress: aws.String("5.6.7.8"),
 		Tags: []ec2types.Tag{
 			{Key: aws.String("mint"), Value: aws.String("true")},
@@ -1050,8 +1225,8 @@ func TestDoctorMultipleVMs(t *testing.T) {
 			{Key: aws.String("mint:health"), Value: aws.String("healthy")},
 		},
 	}
-	deps.describe = &mockDoctorDescribeInstances{
-		output: &ec2.DescribeInstancesOutput{
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: &ec2.DescribeInstancesOutput{
 			Reservations: []ec2types.Reservation{{
 				Instances: []ec2types.Instance{inst1, inst2},
 			}},
@@ -1060,7 +1235,7 @@ func TestDoctorMultipleVMs(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1096,7 +1271,7 @@ func TestDoctorDiskUsageHighWarn(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1128,7 +1303,7 @@ func TestDoctorDiskUsageCriticalFail(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1144,6 +1319,80 @@ func TestDoctorDiskUsageCriticalFail(t *testing.T) {
 	}
 }
 
+func TestDoctorVMGPU(t *testing.T) {
+	smi := "| NVIDIA-SMI 570.124.06   Driver Version: 570.124.06   CUDA Version: 12.8 |\n"
+	tests := []struct {
+		name         string
+		instanceType ec2types.InstanceType
+		smi          *mockRemoteResponse
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "driver loaded",
+			instanceType: "g5.xlarge",
+			smi:          &mockRemoteResponse{output: []byte(smi)},
+			want:         "driver 570.124.06, CUDA 12.8",
+		},
+		{
+			name:         "nvidia-smi fails",
+			instanceType: "g4dn.xlarge",
+			smi:          &mockRemoteResponse{err: errors.New("exit status 9")},
+			want:         "nvidia-smi failed: exit status 9",
+			wantErr:      true,
+		},
+		{
+			name:         "unparseable output",
+			instanceType: "p4d.24xlarge",
+			smi:          &mockRemoteResponse{output: []byte("No devices were found\n")},
+			want:         "could not parse driver version",
+		},
+		{
+			name:         "not a GPU type",
+			instanceType: ec2types.InstanceTypeM6iXlarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, runner := newHappyDoctorDepsWithVM(t)
+			out := makeDoctorInstance("i-vm1", "default", "alice", "running", "1.2.3.4",
+				ec2types.Tag{Key: aws.String("mint:health"), Value: aws.String("healthy")},
+			)
+			out.Reservations[0].Instances[0].InstanceType = tt.instanceType
+			deps.describe = &cmdtest.DescribeInstances{Output: out}
+			if tt.smi != nil {
+				runner.responses["nvidia-smi"] = *tt.smi
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+
+			err := root.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			output := buf.String()
+			if tt.want == "" {
+				if strings.Contains(output, "vm/default/gpu") {
+					t.Errorf("non-GPU VM should have no gpu check, got: %s", output)
+				}
+				for _, c := range runner.calls {
+					if c.command[0] == "nvidia-smi" {
+						t.Error("nvidia-smi should not run on a non-GPU VM")
+					}
+				}
+				return
+			}
+			if !strings.Contains(output, "vm/default/gpu") || !strings.Contains(output, tt.want) {
+				t.Errorf("expected vm/default/gpu with %q, got: %s", tt.want, output)
+			}
+		})
+	}
+}
+
 func TestDoctorSSHConnectionFail(t *testing.T) {
 	deps, _ := newHappyDoctorDepsWithVM(t)
 	// All SSH commands fail — should warn, not hard fail.
@@ -1154,7 +1403,7 @@ func TestDoctorSSHConnectionFail(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1188,7 +1437,7 @@ func TestDoctorJSONWithFailures(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor", "--json"})
@@ -1239,7 +1488,7 @@ func TestDoctorNilClientsSkipsAWSChecks(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1286,7 +1535,7 @@ func TestDoctorJSONFailureSilentOnStderr(t *testing.T) {
 	stdout := new(bytes.Buffer)
 	stderr := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(stdout)
 	root.SetErr(stderr)
 	root.SetArgs([]string{"doctor", "--json"})
@@ -1325,7 +1574,7 @@ func TestDoctorSSHConfigMissingHelpfulMessage(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1362,7 +1611,7 @@ func TestDoctorSSHConnectionErrorWrapped(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1399,7 +1648,7 @@ func TestDoctorSSHConnectionTimedOutWrapped(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1485,7 +1734,7 @@ func TestDoctorCheckCredentials_SSOExpiredE2E(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newDoctorCommandWithDeps(deps)
-	root := newDoctorTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"doctor"})
@@ -1503,3 +1752,562 @@ func TestDoctorCheckCredentials_SSOExpiredE2E(t *testing.T) {
 		t.Errorf("expected SSO login hint in output, got: %s", output)
 	}
 }
+
+func TestDoctorCheckAssumeRole(t *testing.T) {
+	hint.IsTTY = false
+	const roleARN = "arn:aws:iam::123456789012:role/ProjectDev"
+	tests := []struct {
+		name       string
+		resolver   identityResolverAPI
+		wantStatus string
+		wantMsg    string
+	}{
+		{
+			name:       "assumed",
+			resolver:   &cachedOwnerResolver{name: "ryan", arn: "arn:aws:sts::123456789012:assumed-role/ProjectDev/mint-ryan-1700000000"},
+			wantStatus: "PASS",
+			wantMsg:    "assumed " + roleARN + " as arn:aws:sts::123456789012:assumed-role/ProjectDev/mint-ryan-1700000000",
+		},
+		{
+			name:       "trust policy refuses the caller",
+			resolver:   &errorIdentityResolver{err: assumeRoleDeniedError()},
+			wantStatus: "FAIL",
+			wantMsg:    "not allowed to assume the role in role_arn",
+		},
+		{
+			name:       "base credentials unavailable",
+			resolver:   &errorIdentityResolver{err: fmt.Errorf("NoCredentialProviders: no valid providers in chain")},
+			wantStatus: "WARN",
+			wantMsg:    "could not check " + roleARN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.identityResolver = tt.resolver
+			deps.roleARN = roleARN
+
+			result := checkAssumeRole(context.Background(), deps)
+			if result.name != "role_arn" || result.status != tt.wantStatus || !strings.Contains(result.message, tt.wantMsg) {
+				t.Errorf("result = %+v, want %s containing %q", result, tt.wantStatus, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestDoctorInstanceTypeCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType string
+		types        *mockResizeDescribeInstanceTypes
+		wantLine     string
+		wantErr      bool
+	}{
+		{
+			name:         "known current type passes",
+			instanceType: "m6i.xlarge",
+			types:        &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()},
+			wantLine:     "[PASS] instance_type",
+		},
+		{
+			name:         "typo fails with suggestion",
+			instanceType: "m6i.xlrage",
+			types:        &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()},
+			wantLine:     "did you mean m6i.xlarge?",
+			wantErr:      true,
+		},
+		{
+			name:         "previous generation warns",
+			instanceType: "m4.xlarge",
+			types: &mockResizeDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: []ec2types.InstanceTypeInfo{
+					{InstanceType: ec2types.InstanceTypeM4Xlarge, CurrentGeneration: aws.Bool(false)},
+					{InstanceType: ec2types.InstanceTypeM6iXlarge, CurrentGeneration: aws.Bool(true)},
+				},
+			}},
+			wantLine: "consider m6i.xlarge",
+		},
+		{
+			name:         "API error warns",
+			instanceType: "m6i.xlarge",
+			types:        &mockResizeDescribeInstanceTypes{err: errors.New("UnauthorizedOperation")},
+			wantLine:     "[WARN] instance_type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.describeTypes = tt.types
+			content := "region = \"us-west-2\"\ninstance_type = \"" + tt.instanceType + "\"\nvolume_size_gb = 50\nidle_timeout = \"60m\"\n"
+			if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+
+			err := root.Execute()
+			if tt.wantErr && err == nil {
+				t.Error("expected doctor to fail")
+			}
+			if !strings.Contains(buf.String(), tt.wantLine) {
+				t.Errorf("output missing %q:\n%s", tt.wantLine, buf.String())
+			}
+		})
+	}
+}
+
+func TestDoctorInstanceTypeOfferingCheck(t *testing.T) {
+	subnets := &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+		{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-west-2b")},
+		{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-west-2a")},
+	}}}
+	tests := []struct {
+		name         string
+		instanceType string
+		offerings    *mockTypeOfferings
+		wantLine     string
+		wantErr      bool
+	}{
+		{
+			name:         "offered everywhere passes",
+			instanceType: "m6i.xlarge",
+			offerings: &mockTypeOfferings{byAZ: map[string][]string{
+				"us-west-2a": {"m6i.xlarge"}, "us-west-2b": {"m6i.xlarge"},
+			}},
+			wantLine: "[PASS] instance_type_offering",
+		},
+		{
+			name:         "missing from one AZ warns",
+			instanceType: "m6i.xlarge",
+			offerings: &mockTypeOfferings{byAZ: map[string][]string{
+				"us-west-2a": {"m6i.xlarge"}, "us-west-2b": {"m6i.large"},
+			}},
+			wantLine: "m6i.xlarge is not offered in us-west-2b — mint up launches only in us-west-2a",
+		},
+		{
+			name:         "offered nowhere fails with suggestions",
+			instanceType: "m6i.xlarge",
+			offerings: &mockTypeOfferings{byAZ: map[string][]string{
+				"us-west-2a": {"m6i.large"}, "us-west-2b": {"m6i.large"},
+			}},
+			wantLine: "instance type m6i.xlarge is not offered in us-west-2a, us-west-2b — did you mean m6i.large?",
+			wantErr:  true,
+		},
+		{
+			name:         "API error warns",
+			instanceType: "m6i.xlarge",
+			offerings:    &mockTypeOfferings{err: errors.New("UnauthorizedOperation")},
+			wantLine:     "could not check where m6i.xlarge is offered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.describeTypes = &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()}
+			deps.describeSubnets = subnets
+			deps.describeOfferings = tt.offerings
+			content := "region = \"us-west-2\"\ninstance_type = \"" + tt.instanceType + "\"\nvolume_size_gb = 50\nidle_timeout = \"60m\"\n"
+			if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+
+			err := root.Execute()
+			if tt.wantErr && err == nil {
+				t.Error("expected doctor to fail")
+			}
+			if !strings.Contains(buf.String(), tt.wantLine) {
+				t.Errorf("output missing %q:\n%s", tt.wantLine, buf.String())
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Security group rule checks
+// ---------------------------------------------------------------------------
+
+// mockDoctorSecurityGroups returns the user or admin group depending on the
+// component filter and records authorize calls. It has no revoke method, so
+// a repair can only ever add rules.
+type mockDoctorSecurityGroups struct {
+	user, admin *ec2types.SecurityGroup
+	ingress     []*ec2.AuthorizeSecurityGroupIngressInput
+	egress      []*ec2.AuthorizeSecurityGroupEgressInput
+}
+
+func (m *mockDoctorSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	group := m.admin
+	if filterValue(params.Filters, "tag:mint:component") == "security-group" {
+		group = m.user
+	}
+	out := &ec2.DescribeSecurityGroupsOutput{}
+	if group != nil {
+		out.SecurityGroups = []ec2types.SecurityGroup{*group}
+	}
+	return out, nil
+}
+
+func (m *mockDoctorSecurityGroups) AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	m.ingress = append(m.ingress, params)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, nil
+}
+
+func (m *mockDoctorSecurityGroups) AuthorizeSecurityGroupEgress(ctx context.Context, params *ec2.AuthorizeSecurityGroupEgressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupEgressOutput, error) {
+	m.egress = append(m.egress, params)
+	return &ec2.AuthorizeSecurityGroupEgressOutput{}, nil
+}
+
+func doctorUserSG(perms ...ec2types.IpPermission) *ec2types.SecurityGroup {
+	return &ec2types.SecurityGroup{
+		GroupId:       aws.String("sg-user"),
+		IpPermissions: perms,
+		IpPermissionsEgress: []ec2types.IpPermission{{
+			IpProtocol: aws.String("-1"),
+			IpRanges:   []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+		}},
+	}
+}
+
+func doctorAdminSG() *ec2types.SecurityGroup {
+	return &ec2types.SecurityGroup{
+		GroupId: aws.String("sg-admin"),
+		IpPermissions: []ec2types.IpPermission{{
+			IpProtocol:       aws.String("tcp"),
+			FromPort:         aws.Int32(2049),
+			ToPort:           aws.Int32(2049),
+			UserIdGroupPairs: []ec2types.UserIdGroupPair{{GroupId: aws.String("sg-admin")}},
+		}},
+	}
+}
+
+func tcpFrom(port int32, cidr string) ec2types.IpPermission {
+	return ec2types.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int32(port),
+		ToPort:     aws.Int32(port),
+		IpRanges:   []ec2types.IpRange{{CidrIp: aws.String(cidr)}},
+	}
+}
+
+var moshPermission = ec2types.IpPermission{
+	IpProtocol: aws.String("udp"),
+	FromPort:   aws.Int32(60000),
+	ToPort:     aws.Int32(61000),
+	IpRanges:   []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+}
+
+// ipv6Permissions are the user group's SSH and mosh rules for IPv6 clients.
+var ipv6Permissions = []ec2types.IpPermission{
+	{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int32(41122),
+		ToPort:     aws.Int32(41122),
+		Ipv6Ranges: []ec2types.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+	},
+	{
+		IpProtocol: aws.String("udp"),
+		FromPort:   aws.Int32(60000),
+		ToPort:     aws.Int32(61000),
+		Ipv6Ranges: []ec2types.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+	},
+}
+
+func runDoctorWithSGs(t *testing.T, sgs *mockDoctorSecurityGroups, args ...string) (string, error) {
+	t.Helper()
+	deps := newHappyDoctorDeps(t)
+	deps.describeSGs = sgs
+	deps.authorizeIngress = sgs
+	deps.authorizeEgress = sgs
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"doctor"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestDoctorSecurityGroupsMatchSpec(t *testing.T) {
+	sgs := &mockDoctorSecurityGroups{
+		user:  doctorUserSG(append([]ec2types.IpPermission{tcpFrom(41122, "0.0.0.0/0"), moshPermission}, ipv6Permissions...)...),
+		admin: doctorAdminSG(),
+	}
+
+	out, err := runDoctorWithSGs(t, sgs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	for _, want := range []string{"[PASS] user security group: sg-user rules match", "[PASS] admin security group: sg-admin rules match"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDoctorSecurityGroupMissingRuleFails(t *testing.T) {
+	sgs := &mockDoctorSecurityGroups{
+		user:  doctorUserSG(moshPermission),
+		admin: doctorAdminSG(),
+	}
+
+	out, err := runDoctorWithSGs(t, sgs)
+	if err == nil {
+		t.Fatal("expected doctor to fail on a missing required rule")
+	}
+	for _, want := range []string{"[FAIL] user security group", "missing required rule(s): ingress tcp 41122 from 0.0.0.0/0", "mint doctor --fix"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if len(sgs.ingress)+len(sgs.egress) != 0 {
+		t.Error("rules were authorized without --fix")
+	}
+}
+
+func TestDoctorSecurityGroupFixAddsMissingAndKeepsUnknown(t *testing.T) {
+	sgs := &mockDoctorSecurityGroups{
+		user:  doctorUserSG(append([]ec2types.IpPermission{moshPermission, tcpFrom(3389, "10.0.0.0/8")}, ipv6Permissions...)...),
+		admin: doctorAdminSG(),
+	}
+
+	out, err := runDoctorWithSGs(t, sgs, "--fix")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if len(sgs.ingress) != 1 || len(sgs.egress) != 0 {
+		t.Fatalf("got %d ingress and %d egress calls, want 1 ingress", len(sgs.ingress), len(sgs.egress))
+	}
+	perms := sgs.ingress[0].IpPermissions
+	if aws.ToString(sgs.ingress[0].GroupId) != "sg-user" || len(perms) != 1 || aws.ToInt32(perms[0].FromPort) != 41122 {
+		t.Errorf("authorize call = %+v, want only tcp 41122 on sg-user", sgs.ingress[0])
+	}
+	for _, want := range []string{
+		"[WARN] user security group",
+		"added missing rule(s): ingress tcp 41122 from 0.0.0.0/0",
+		"1 unrecognized rule(s) left in place: ingress tcp 3389 from 10.0.0.0/8",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDoctorSecurityGroupRequiresConfiguredSSHPort(t *testing.T) {
+	sgs := &mockDoctorSecurityGroups{
+		user:  doctorUserSG(append([]ec2types.IpPermission{tcpFrom(41122, "0.0.0.0/0"), moshPermission}, ipv6Permissions...)...),
+		admin: doctorAdminSG(),
+	}
+	deps := newHappyDoctorDeps(t)
+	deps.describeSGs = sgs
+	deps.authorizeIngress = sgs
+	deps.authorizeEgress = sgs
+	deps.sshPort = 2222
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+	if err := root.Execute(); err == nil {
+		t.Fatalf("expected doctor to fail when ssh_port is not open:\n%s", buf)
+	}
+	if want := "missing required rule(s): ingress tcp 2222 from 0.0.0.0/0"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf)
+	}
+}
+
+func TestDoctorSecurityGroupNotFound(t *testing.T) {
+	sgs := &mockDoctorSecurityGroups{admin: doctorAdminSG()}
+
+	out, err := runDoctorWithSGs(t, sgs)
+	if err == nil {
+		t.Fatal("expected doctor to fail when the user security group is missing")
+	}
+	if !strings.Contains(out, "[FAIL] user security group: not found") || !strings.Contains(out, "mint init") {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestDoctorTemplateStaleness(t *testing.T) {
+	const applied = "1111111111111111111111111111111111111111"
+	tests := []struct {
+		name   string
+		commit string
+		head   string
+		err    error
+		want   string
+	}{
+		{"current", applied, applied, nil, "[PASS] template"},
+		{"behind HEAD", applied, "2222222222222222222222222222222222222222", nil,
+			"[WARN] template: https://github.com/acme/mint-template has changed since it was applied (1111111 \u2192 2222222) \u2014 run `mint init --from-template https://github.com/acme/mint-template`"},
+		{"never applied", "", applied, nil, "has not been applied"},
+		{"HEAD unreachable", applied, "", fmt.Errorf("git ls-remote: exit status 128"), "[WARN] template: could not check https://github.com/acme/mint-template for updates: git ls-remote: exit status 128"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint.IsTTY = false
+			deps := newHappyDoctorDeps(t)
+			cfg, _ := config.Load(deps.configDir)
+			cfg.TemplateRepo = "https://github.com/acme/mint-template"
+			cfg.TemplateCommit = tt.commit
+			if err := config.Save(cfg, deps.configDir); err != nil {
+				t.Fatal(err)
+			}
+			deps.templateHead = func(context.Context, string) (string, error) { return tt.head, tt.err }
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("a stale template should warn, not fail: %v\n%s", err, buf.String())
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestDoctorTemplateCheckSkippedWithoutTemplate(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	called := false
+	deps.templateHead = func(context.Context, string) (string, error) {
+		called = true
+		return "", nil
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetArgs([]string{"doctor"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if called || strings.Contains(buf.String(), "template") {
+		t.Errorf("template check should not run without template_repo:\n%s", buf.String())
+	}
+}
+
+func TestDoctorAgentVersion(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "current", version: "3\n", want: "[PASS] vm/default/agent: v3 (CLI v2–v3)"},
+		{name: "older", version: "", want: "[WARN] vm/default/agent: v1 (CLI v2–v3) — older; run `mint recreate` to upgrade"},
+		{name: "newer", version: "4\n", want: "[WARN] vm/default/agent: v4 (CLI v2–v3) — newer; run `mint update`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, runner := newHappyDoctorDepsWithVM(t)
+			runner.responses[agentVersionReadCommand()[0]] = mockRemoteResponse{output: []byte(tt.version)}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"doctor"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q, got:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestDoctorFixSkippedForNewerAgent(t *testing.T) {
+	deps, runner := newHappyDoctorDepsWithVM(t)
+	runner.responses[agentVersionReadCommand()[0]] = mockRemoteResponse{output: []byte("4\n")}
+	runner.responses["docker"] = mockRemoteResponse{err: fmt.Errorf("docker: command not found")}
+	runner.responses["sudo"] = mockRemoteResponse{output: []byte("installed\n")}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor", "--fix"})
+	_ = root.Execute()
+
+	for _, call := range runner.calls {
+		if call.command[0] == "sudo" {
+			t.Errorf("--fix ran %v on a VM with a newer agent", call.command)
+		}
+	}
+	if !strings.Contains(buf.String(), "vm/default/fix: skipped: VM agent v4 is newer than this CLI supports") {
+		t.Errorf("expected a skipped fix, got:\n%s", buf.String())
+	}
+}
+
+func TestDoctorShowsVMConfigOverrides(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	content := `region = "us-west-2"
+volume_size_gb = 50
+idle_timeout = "60m"
+
+[vm.gpu]
+volume_size_gb = 200
+idle_timeout = "3h"
+`
+	if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor", "--vm", "gpu"})
+	_ = root.Execute()
+
+	output := buf.String()
+	for _, want := range []string{"200 GiB (from [vm.gpu])", "3h (from [vm.gpu])"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDoctorFailsOnUnknownVMConfigKey(t *testing.T) {
+	deps := newHappyDoctorDeps(t)
+	content := `region = "us-west-2"
+volume_size_gb = 50
+idle_timeout = "60m"
+
+[vm.gpu]
+instance_typ = "g5.2xlarge"
+`
+	if err := os.WriteFile(filepath.Join(deps.configDir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor", "--vm", "gpu"})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected doctor to fail on an unknown [vm.gpu] key")
+	}
+	if !strings.Contains(buf.String(), `unknown key "instance_typ"`) {
+		t.Errorf("output missing offending key:\n%s", buf.String())
+	}
+}
@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
@@ -12,14 +15,24 @@ import (
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
 
 // downDeps holds the injectable dependencies for the down command.
 type downDeps struct {
-	describe mintaws.DescribeInstancesAPI
-	stop     mintaws.StopInstancesAPI
-	owner    string
+	describe     mintaws.DescribeInstancesAPI
+	stop         mintaws.StopInstancesAPI
+	owner        string
+	selfDetector *selfcheck.Detector // nil skips the self-target guard
+	// sendKey and remote check the VM for active sessions and automation
+	// guards. A nil remote skips the check.
+	sendKey mintaws.SendSSHPublicKeyAPI
+	remote  RemoteCommandRunner
+	sshPort int               // ssh_port from config; zero means defaultSSHPort
+	locker  *provision.Locker // nil takes no operation lock
 }
 
 // newDownCommand creates the production down command. It will be wired with
@@ -32,11 +45,19 @@ func newDownCommand() *cobra.Command {
 // for testing. When deps is nil, the command will need real AWS clients
 // injected before execution (placeholder for future integration).
 func newDownCommandWithDeps(deps *downDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "down",
 		Short: "Stop the VM instance",
-		Long:  "Stop the VM instance. All volumes and Elastic IP persist for next mint up.",
-		Args:  cobra.NoArgs,
+		Long: "Stop the VM instance. All volumes and Elastic IP persist for next mint up.\n\n" +
+			"Like mint recreate, the stop is blocked unless --force is used while the VM has " +
+			"attached tmux clients, SSH/mosh connections, claude processes in containers, " +
+			"an active mint extend, or active automation guards (see mint guard).\n\n" +
+			"The stop is refused while another mint command holds the VM's operation lock; " +
+			"--steal-lock takes it anyway.\n\n" +
+			"--hibernate saves the VM's memory to its root volume, so tmux panes and REPLs " +
+			"inside containers are still there after the next mint up. The VM must have been " +
+			"launched with hibernate = true; any other VM is stopped normally, with a warning.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
 				return runDown(cmd, deps)
@@ -46,15 +67,43 @@ func newDownCommandWithDeps(deps *downDeps) *cobra.Command {
 				return fmt.Errorf("AWS clients not configured")
 			}
 			return runDown(cmd, &downDeps{
-				describe: clients.ec2Client,
-				stop:     clients.ec2Client,
-				owner:    clients.owner,
+				describe:     clients.ec2Client,
+				stop:         clients.ec2Client,
+				owner:        clients.owner,
+				selfDetector: selfcheck.Default(),
+				sendKey:      clients.sendKey,
+				remote:       clients.remoteRunner(),
+				sshPort:      clients.sshOptions.Port,
+				locker:       newOperationLocker(cmd, clients.ec2Client),
 			})
 		},
 	}
+
+	addSelfTargetFlag(cmd)
+	addStealLockFlag(cmd)
+	cmd.Flags().Bool("force", false, "Bypass active session guard")
+	cmd.Flags().Bool("hibernate", false, "Hibernate the VM, keeping its memory, when it was launched hibernation-enabled")
+
+	return cmd
+}
+
+// downJSON is the --json output of mint down.
+type downJSON struct {
+	VM         string `json:"vm"`
+	InstanceID string `json:"instance_id"`
+	State      string `json:"state"`
+	// Stopped reports whether this run stopped the VM: false when it was
+	// already stopped or active sessions blocked the stop.
+	Stopped bool `json:"stopped"`
+	// Hibernated reports whether the stop hibernated the VM.
+	Hibernated bool `json:"hibernated,omitempty"`
+	// Sessions is the active session report, omitted when the VM was not
+	// checked.
+	Sessions *session.ActiveSessions `json:"sessions,omitempty"`
 }
 
-// runDown executes the down command logic: discover VM, check state, stop.
+// runDown executes the down command logic: discover VM, check state and
+// active sessions, stop.
 func runDown(cmd *cobra.Command, deps *downDeps) error {
 	ctx := cmd.Context()
 	if ctx == nil {
@@ -64,12 +113,25 @@ func runDown(cmd *cobra.Command, deps *downDeps) error {
 	cliCtx := cli.FromCommand(cmd)
 	vmName := "default"
 	verbose := false
+	jsonOutput := false
 	if cliCtx != nil {
 		vmName = cliCtx.VM
 		verbose = cliCtx.Verbose
+		jsonOutput = cliCtx.JSON
 	}
 
 	w := cmd.OutOrStdout()
+	// Notes and warnings go to stderr in JSON mode so stdout stays one
+	// JSON document.
+	notes := w
+	if jsonOutput {
+		notes = cmd.ErrOrStderr()
+	}
+	writeJSON := func(result downJSON) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
 
 	// Discover VM
 	if verbose {
@@ -82,33 +144,118 @@ func runDown(cmd *cobra.Command, deps *downDeps) error {
 	}
 
 	if found == nil {
+		if err := notOwnedError(ctx, deps.describe, deps.owner, vmName, "down"); err != nil {
+			return err
+		}
 		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
 	}
 
 	// Handle already-stopped VM
 	if found.State == string(ec2types.InstanceStateNameStopped) ||
 		found.State == string(ec2types.InstanceStateNameStopping) {
+		if jsonOutput {
+			return writeJSON(downJSON{VM: vmName, InstanceID: found.ID, State: found.State})
+		}
 		fmt.Fprintf(w, "VM %q (%s) is already stopped.\n", vmName, found.ID)
 		return nil
 	}
 
+	if err := guardSelfTarget(ctx, cmd, deps.selfDetector, vmName, found.ID, "stop this VM"); err != nil {
+		return err
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	report := checkDownSessions(ctx, notes, deps, found, vmName, verbose)
+	if report != nil && report.HasActivity() {
+		if !force {
+			if jsonOutput {
+				if err := writeJSON(downJSON{VM: vmName, InstanceID: found.ID, State: found.State, Sessions: report}); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("active sessions detected on VM %q:\n\n%s\n\nUse %s to proceed anyway", vmName, report.Summary(), hint.Cmd("--force"))
+		}
+		fmt.Fprintf(notes, "Warning: proceeding despite active sessions on VM %q:\n%s\n\n", vmName, report.Summary())
+	}
+
+	// Only an instance launched hibernation-enabled can hibernate; any
+	// other is stopped normally rather than refused.
+	hibernate, _ := cmd.Flags().GetBool("hibernate")
+	if hibernate && !found.HibernationConfigured {
+		fmt.Fprintf(notes, "Warning: VM %q was not launched hibernation-enabled, so it is stopped without keeping its memory. "+
+			"Set hibernate = true and run %s to enable it.\n", vmName, hint.Cmd("mint recreate"))
+		hibernate = false
+	}
+
+	release, err := lockVM(ctx, cmd, deps.locker, found, "down")
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Spinner starts after VM discovery and state check.
-	sp := progress.NewCommandSpinner(w, false)
-	sp.Start("Stopping VM...")
+	sp := progress.NewCommandSpinner(w, jsonOutput)
+	if hibernate {
+		sp.Start("Hibernating VM...")
+	} else {
+		sp.Start("Stopping VM...")
+	}
 
-	_, err = deps.stop.StopInstances(ctx, &ec2.StopInstancesInput{
+	input := &ec2.StopInstancesInput{
 		InstanceIds: []string{found.ID},
-	})
+	}
+	if hibernate {
+		input.Hibernate = aws.Bool(true)
+	}
+	_, err = deps.stop.StopInstances(ctx, input)
 	if err != nil {
 		sp.Fail(err.Error())
 		return fmt.Errorf("stopping instance %s: %w", found.ID, err)
 	}
+	cliCtx.TouchResource(cli.ResourceInstance, found.ID)
 
 	// Announce the async stop phase; EC2 stop is fire-and-return, so we
 	// surface the "waiting" label after the API call returns.
 	sp.Update("Waiting for VM to stop...")
 	sp.Stop("")
 
+	if jsonOutput {
+		return writeJSON(downJSON{
+			VM:         vmName,
+			InstanceID: found.ID,
+			State:      string(ec2types.InstanceStateNameStopping),
+			Stopped:    true,
+			Hibernated: hibernate,
+			Sessions:   report,
+		})
+	}
+	if hibernate {
+		fmt.Fprintf(w, "VM %q (%s) hibernated. Memory, volumes, and Elastic IP persist.\n", vmName, found.ID)
+		return nil
+	}
 	fmt.Fprintf(w, "VM %q (%s) stopped. Volumes and Elastic IP persist.\n", vmName, found.ID)
 	return nil
 }
+
+// checkDownSessions checks a running VM for active sessions and automation
+// guards, printing notes on ignored guards to w. It returns nil when the
+// VM was not checked: it is not running, there is no remote runner, or
+// the check failed, which is a warning so a flaky connection does not
+// block the stop.
+func checkDownSessions(ctx context.Context, w io.Writer, deps *downDeps, found *vm.VM, vmName string, verbose bool) *session.ActiveSessions {
+	if deps.remote == nil || found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil
+	}
+	if verbose {
+		fmt.Fprintf(w, "Checking for active sessions on VM %q...\n", vmName)
+	}
+	report, err := detectVMSessions(ctx, deps.remote, deps.sendKey, found, sshPortOrDefault(deps.sshPort))
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not detect active sessions: %v\n", err)
+		return nil
+	}
+	for _, note := range report.GuardNotes {
+		fmt.Fprintf(w, "Note: %s\n", note)
+	}
+	return report
+}
@@ -2,43 +2,21 @@ package cmd
 
 import (
 	"bytes"
-	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/spf13/cobra"
 )
 
-// mockDescribeInstances implements mintaws.DescribeInstancesAPI for testing.
-type mockDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-// mockStopInstances implements mintaws.StopInstancesAPI for testing.
-type mockStopInstances struct {
-	output *ec2.StopInstancesOutput
-	err    error
-	called bool
-	input  *ec2.StopInstancesInput
-}
-
-func (m *mockStopInstances) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
-	m.called = true
-	m.input = params
-	return m.output, m.err
-}
-
 // makeRunningInstance returns a DescribeInstancesOutput containing one running instance.
 func makeRunningInstance(id, vmName, owner string) *ec2.DescribeInstancesOutput {
 	return &ec2.DescribeInstancesOutput{
@@ -77,36 +55,13 @@ func makeStoppedInstance(id, vmName, owner string) *ec2.DescribeInstancesOutput
 	}
 }
 
-// newTestRoot creates a minimal root command with global flags and
-// PersistentPreRunE for CLI context propagation, without registering
-// any default subcommands. This avoids collisions when injecting
-// test commands.
-func newTestRoot() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestDownCommand(t *testing.T) {
 	hint.IsTTY = false // Ensure non-TTY mode for consistent test assertions.
 
 	tests := []struct {
 		name           string
-		describe       *mockDescribeInstances
-		stop           *mockStopInstances
+		describe       *cmdtest.DescribeInstances
+		stop           *cmdtest.StopInstances
 		owner          string
 		vmName         string
 		verbose        bool
@@ -117,11 +72,11 @@ func TestDownCommand(t *testing.T) {
 	}{
 		{
 			name: "successful stop of running instance",
-			describe: &mockDescribeInstances{
-				output: makeRunningInstance("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstance("i-abc123", "default", "alice"),
 			},
-			stop: &mockStopInstances{
-				output: &ec2.StopInstancesOutput{},
+			stop: &cmdtest.StopInstances{
+				Output: &ec2.StopInstancesOutput{},
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -130,10 +85,10 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "vm not found suggests mint up",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			stop:           &mockStopInstances{},
+			stop:           &cmdtest.StopInstances{},
 			owner:          "alice",
 			vmName:         "default",
 			wantErr:        true,
@@ -142,10 +97,10 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "already stopped vm shows informational message",
-			describe: &mockDescribeInstances{
-				output: makeStoppedInstance("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstance("i-abc123", "default", "alice"),
 			},
-			stop:           &mockStopInstances{},
+			stop:           &cmdtest.StopInstances{},
 			owner:          "alice",
 			vmName:         "default",
 			wantStopCalled: false,
@@ -153,10 +108,10 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeInstances{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			stop:           &mockStopInstances{},
+			stop:           &cmdtest.StopInstances{},
 			owner:          "alice",
 			vmName:         "default",
 			wantErr:        true,
@@ -165,11 +120,11 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "stop API error propagates",
-			describe: &mockDescribeInstances{
-				output: makeRunningInstance("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstance("i-abc123", "default", "alice"),
 			},
-			stop: &mockStopInstances{
-				err: fmt.Errorf("insufficient permissions"),
+			stop: &cmdtest.StopInstances{
+				Err: fmt.Errorf("insufficient permissions"),
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -179,11 +134,11 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "verbose shows progress steps",
-			describe: &mockDescribeInstances{
-				output: makeRunningInstance("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstance("i-abc123", "default", "alice"),
 			},
-			stop: &mockStopInstances{
-				output: &ec2.StopInstancesOutput{},
+			stop: &cmdtest.StopInstances{
+				Output: &ec2.StopInstancesOutput{},
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -193,11 +148,11 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "spinner shows stopping and waiting phases",
-			describe: &mockDescribeInstances{
-				output: makeRunningInstance("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstance("i-abc123", "default", "alice"),
 			},
-			stop: &mockStopInstances{
-				output: &ec2.StopInstancesOutput{},
+			stop: &cmdtest.StopInstances{
+				Output: &ec2.StopInstancesOutput{},
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -207,11 +162,11 @@ func TestDownCommand(t *testing.T) {
 		},
 		{
 			name: "stop sends correct instance ID to non-default vm",
-			describe: &mockDescribeInstances{
-				output: makeRunningInstance("i-specific456", "dev", "bob"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstance("i-specific456", "dev", "bob"),
 			},
-			stop: &mockStopInstances{
-				output: &ec2.StopInstancesOutput{},
+			stop: &cmdtest.StopInstances{
+				Output: &ec2.StopInstancesOutput{},
 			},
 			owner:          "bob",
 			vmName:         "dev",
@@ -231,7 +186,7 @@ func TestDownCommand(t *testing.T) {
 			}
 
 			cmd := newDownCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -268,19 +223,227 @@ func TestDownCommand(t *testing.T) {
 				}
 			}
 
-			if tt.wantStopCalled != tt.stop.called {
-				t.Errorf("StopInstances called = %v, want %v", tt.stop.called, tt.wantStopCalled)
+			if tt.wantStopCalled != tt.stop.Called {
+				t.Errorf("StopInstances called = %v, want %v", tt.stop.Called, tt.wantStopCalled)
 			}
 
 			// Verify correct instance ID was sent to StopInstances
-			if tt.stop.called && tt.stop.input != nil {
-				if len(tt.stop.input.InstanceIds) != 1 {
-					t.Fatalf("expected 1 instance ID in stop call, got %d", len(tt.stop.input.InstanceIds))
+			if tt.stop.Called && tt.stop.Input != nil {
+				if len(tt.stop.Input.InstanceIds) != 1 {
+					t.Fatalf("expected 1 instance ID in stop call, got %d", len(tt.stop.Input.InstanceIds))
+				}
+				expectedID := aws.ToString(tt.describe.Output.Reservations[0].Instances[0].InstanceId)
+				if tt.stop.Input.InstanceIds[0] != expectedID {
+					t.Errorf("StopInstances called with %q, want %q", tt.stop.Input.InstanceIds[0], expectedID)
+				}
+			}
+		})
+	}
+}
+
+func TestDownHibernate(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name           string
+		configured     bool
+		args           []string
+		wantHibernate  bool
+		wantOutput     []string
+		wantJSONHibern bool
+	}{
+		{
+			name:          "hibernation-enabled VM hibernates",
+			configured:    true,
+			args:          []string{"--hibernate"},
+			wantHibernate: true,
+			wantOutput:    []string{"hibernated. Memory, volumes, and Elastic IP persist."},
+		},
+		{
+			name:       "VM launched without hibernation falls back to a stop",
+			args:       []string{"--hibernate"},
+			wantOutput: []string{"Warning: VM \"default\" was not launched hibernation-enabled", "stopped. Volumes and Elastic IP persist."},
+		},
+		{
+			name:       "no flag stops a hibernation-enabled VM normally",
+			configured: true,
+			wantOutput: []string{"stopped. Volumes and Elastic IP persist."},
+		},
+		{
+			name:           "json reports hibernated",
+			configured:     true,
+			args:           []string{"--hibernate", "--json"},
+			wantHibernate:  true,
+			wantJSONHibern: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeRunningInstance("i-hib", "default", "alice")
+			if tt.configured {
+				out.Reservations[0].Instances[0].HibernationOptions = &ec2types.HibernationOptions{Configured: aws.Bool(true)}
+			}
+			stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+			deps := &downDeps{
+				describe: &cmdtest.DescribeInstances{Output: out},
+				stop:     stop,
+				owner:    "alice",
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newDownCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"down"}, tt.args...))
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !stop.Called {
+				t.Fatal("StopInstances not called")
+			}
+			if got := aws.ToBool(stop.Input.Hibernate); got != tt.wantHibernate {
+				t.Errorf("StopInstancesInput.Hibernate = %v, want %v", got, tt.wantHibernate)
+			}
+			output := buf.String()
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q, got: %s", want, output)
 				}
-				expectedID := aws.ToString(tt.describe.output.Reservations[0].Instances[0].InstanceId)
-				if tt.stop.input.InstanceIds[0] != expectedID {
-					t.Errorf("StopInstances called with %q, want %q", tt.stop.input.InstanceIds[0], expectedID)
+			}
+			if tt.wantJSONHibern && !strings.Contains(output, `"hibernated": true`) {
+				t.Errorf("JSON missing hibernated, got: %s", output)
+			}
+		})
+	}
+}
+
+func TestDownJSONIncludesSessionReport(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name        string
+		runner      *guardRemoteRunner
+		args        []string
+		wantErr     bool
+		wantStopped bool
+		wantTmux    string
+	}{
+		{
+			name:     "blocked stop reports sessions",
+			runner:   &guardRemoteRunner{tmux: "/dev/pts/0 main"},
+			wantErr:  true,
+			wantTmux: "/dev/pts/0 main",
+		},
+		{
+			name:        "forced stop keeps the report",
+			runner:      &guardRemoteRunner{tmux: "/dev/pts/0 main"},
+			args:        []string{"--force"},
+			wantStopped: true,
+			wantTmux:    "/dev/pts/0 main",
+		},
+		{
+			name:        "idle VM stops",
+			runner:      &guardRemoteRunner{},
+			wantStopped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+			deps := &downDeps{
+				describe: &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")},
+				stop:     stop,
+				owner:    "alice",
+				sendKey:  &cmdtest.SendSSHPublicKey{},
+				remote:   tt.runner.run,
+			}
+			root := cmdtest.NewRoot(newDownCommandWithDeps(deps))
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			root.SetOut(stdout)
+			root.SetErr(stderr)
+			root.SetArgs(append([]string{"down", "--json"}, tt.args...))
+			err := root.Execute()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got downJSON
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("stdout is not one JSON document: %v\n%s", err, stdout.String())
+			}
+			if got.InstanceID != "i-abc123" || got.Stopped != tt.wantStopped || stop.Called != tt.wantStopped {
+				t.Errorf("result = %+v, stop called = %v", got, stop.Called)
+			}
+			if got.Sessions == nil {
+				t.Fatal("sessions missing from JSON output")
+			}
+			if got.Sessions.TmuxClients != tt.wantTmux {
+				t.Errorf("tmux_clients = %q, want %q", got.Sessions.TmuxClients, tt.wantTmux)
+			}
+		})
+	}
+}
+
+func TestDownOperationLock(t *testing.T) {
+	hint.IsTTY = false
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		lock      string
+		args      []string
+		wantErr   string
+		wantStop  bool
+		wantSteal bool
+	}{
+		{"unlocked", "", nil, "", true, false},
+		{"lock respected", "recreate:desktop:4242:2026-10-15T11:50:00Z", nil, "locked by mint recreate", false, false},
+		{"lock expired", "recreate:desktop:4242:2026-10-15T11:00:00Z", nil, "", true, false},
+		{"lock stolen", "recreate:desktop:4242:2026-10-15T11:50:00Z", []string{"--steal-lock"}, "", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeRunningInstance("i-abc123", "default", "alice")
+			if tt.lock != "" {
+				inst := &out.Reservations[0].Instances[0]
+				inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagOperation), Value: aws.String(tt.lock)})
+			}
+			describe := &cmdtest.DescribeInstances{Output: out}
+			createTags, deleteTags := &cmdtest.CreateTags{}, &cmdtest.DeleteTags{}
+			stderr := new(bytes.Buffer)
+			stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+			deps := &downDeps{
+				describe: describe,
+				stop:     stop,
+				owner:    "alice",
+				locker: provision.NewLocker(describe, createTags, deleteTags).
+					WithWarnings(stderr).
+					WithIdentity("laptop", 100, func() time.Time { return now }),
+			}
+			root := cmdtest.NewRoot(newDownCommandWithDeps(deps))
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(stderr)
+			root.SetArgs(append([]string{"down"}, tt.args...))
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
 				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stop.Called != tt.wantStop {
+				t.Errorf("StopInstances called = %v, want %v", stop.Called, tt.wantStop)
+			}
+			if stole := strings.Contains(stderr.String(), "WARNING: stealing the operation lock"); stole != tt.wantSteal {
+				t.Errorf("steal warning = %v, want %v:\n%s", stole, tt.wantSteal, stderr.String())
+			}
+			if tt.wantStop && (len(createTags.Inputs) != 1 || len(deleteTags.Inputs) != 1) {
+				t.Errorf("lock tagged %d times and released %d times, want once each", len(createTags.Inputs), len(deleteTags.Inputs))
 			}
 		})
 	}
@@ -1,5 +1,7 @@
 package cmd
 
+import "errors"
+
 // silentExitError is an error that carries no message text. It signals to
 // main.go that the command failed (so os.Exit(1) is appropriate) but that
 // the error has already been reported to the user (e.g., via structured JSON
@@ -7,3 +9,62 @@ package cmd
 type silentExitError struct{}
 
 func (silentExitError) Error() string { return "" }
+
+// Process exit codes returned by ExitCode. Anything not listed here exits 1.
+const (
+	// exitCodeFailure is the generic failure exit code.
+	exitCodeFailure = 1
+
+	// exitCodeOutOfDate means mint ssh-config sync found the SSH config
+	// out of date: it rewrote the file or, with --dry-run, would have.
+	exitCodeOutOfDate = 2
+
+	// exitCodeUserBootstrapFailed means the VM was provisioned and core
+	// bootstrap completed, but the user-bootstrap.sh hook exited non-zero.
+	// The VM is usable; scripts can treat this as a warning.
+	exitCodeUserBootstrapFailed = 3
+
+	// exitCodeNetwork means AWS could not be reached (or --offline was
+	// given) and the command has no offline fallback.
+	exitCodeNetwork = 4
+
+	// exitCodeInterrupted means the command stopped after Ctrl-C. It is
+	// the shell's 128+SIGINT convention.
+	exitCodeInterrupted = 130
+)
+
+// exitCodeError is a silent error (already reported to the user, like
+// silentExitError) that requests a specific process exit code.
+type exitCodeError struct {
+	code int
+}
+
+func (exitCodeError) Error() string { return "" }
+
+// interruptedError reports that a command stopped at a safe step after
+// Ctrl-C. recovery describes what was left behind and how to finish.
+type interruptedError struct {
+	recovery string
+}
+
+func (e *interruptedError) Error() string {
+	return "Interrupted. " + e.recovery
+}
+
+// ExitCode returns the process exit code main.go should use for an error
+// returned by Execute.
+func ExitCode(err error) int {
+	var ec exitCodeError
+	if errors.As(err, &ec) {
+		return ec.code
+	}
+	var ne *networkError
+	if errors.As(err, &ne) {
+		return exitCodeNetwork
+	}
+	var ie *interruptedError
+	if errors.As(err, &ie) {
+		return exitCodeInterrupted
+	}
+	return exitCodeFailure
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// eventsDeps holds the injectable dependencies for the events command.
+type eventsDeps struct {
+	describe       mintaws.DescribeInstancesAPI
+	describeStatus mintaws.DescribeInstanceStatusAPI
+	owner          string
+}
+
+// newEventsCommand creates the production events command.
+func newEventsCommand() *cobra.Command {
+	return newEventsCommandWithDeps(nil)
+}
+
+// newEventsCommandWithDeps creates the events command with explicit
+// dependencies for testing.
+func newEventsCommandWithDeps(deps *eventsDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "events",
+		Short: "List scheduled EC2 events for your VMs",
+		Long: "List pending EC2 scheduled events, such as instance retirement and system reboots, " +
+			"for all of your VMs. AWS announces these by email to the account root; mint also " +
+			"shows them in mint status, mint doctor, and mint up.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runEvents(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runEvents(cmd, &eventsDeps{
+				describe:       clients.ec2Client,
+				describeStatus: clients.ec2Client,
+				owner:          clients.owner,
+			})
+		},
+	}
+}
+
+// vmEvent is a scheduled event together with the name of its VM.
+type vmEvent struct {
+	VM string `json:"vm"`
+	vm.ScheduledEvent
+}
+
+// eventsJSON is the top-level JSON envelope for the events command output.
+type eventsJSON struct {
+	Events []vmEvent `json:"events"`
+}
+
+// runEvents executes the events command logic.
+func runEvents(cmd *cobra.Command, deps *eventsDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	jsonOutput := false
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil {
+		jsonOutput = cliCtx.JSON
+	}
+
+	w := cmd.OutOrStdout()
+	sp := progress.NewCommandSpinner(w, jsonOutput)
+	sp.Start("Checking scheduled events...")
+
+	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("listing VMs: %w", err)
+	}
+	events, err := vmScheduledEvents(ctx, deps.describeStatus, vms)
+	if err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("checking scheduled events: %w", err)
+	}
+
+	sp.Stop("")
+
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(eventsJSON{Events: events})
+	}
+	writeEventsTable(w, events)
+	return nil
+}
+
+// vmScheduledEvents returns the pending scheduled events for vms, ordered by
+// VM and then by date. It never returns nil so JSON output is an array.
+func vmScheduledEvents(ctx context.Context, client mintaws.DescribeInstanceStatusAPI, vms []*vm.VM) ([]vmEvent, error) {
+	ids := make([]string, 0, len(vms))
+	for _, v := range vms {
+		ids = append(ids, v.ID)
+	}
+	byInstance, err := vm.ScheduledEvents(ctx, client, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []vmEvent{}
+	for _, v := range vms {
+		for _, ev := range byInstance[v.ID] {
+			events = append(events, vmEvent{VM: v.Name, ScheduledEvent: ev})
+		}
+	}
+	return events, nil
+}
+
+// writeEventsTable outputs events in a human-readable table followed by
+// what to do about each one.
+func writeEventsTable(w io.Writer, events []vmEvent) {
+	if len(events) == 0 {
+		fmt.Fprintln(w, "No scheduled events for your VMs.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VM\tEVENT\tNOT BEFORE\tDESCRIPTION")
+	for _, ev := range events {
+		notBefore := "-"
+		if !ev.NotBefore.IsZero() {
+			notBefore = ev.NotBefore.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ev.VM, ev.Code, notBefore, ev.Description)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	for _, ev := range events {
+		fmt.Fprintf(w, "⚠  VM %q: %s\n", ev.VM, eventWarning(ev.ScheduledEvent))
+	}
+}
+
+// eventWarning renders an event and its guidance on one line, for example
+// "instance scheduled for retirement on 2025-02-01 — run `mint recreate`
+// before then; ...".
+func eventWarning(ev vm.ScheduledEvent) string {
+	return ev.Summary() + " — " + ev.Guidance()
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// mockDescribeInstanceStatus implements mintaws.DescribeInstanceStatusAPI.
+type mockDescribeInstanceStatus struct {
+	output *ec2.DescribeInstanceStatusOutput
+	err    error
+}
+
+func (m *mockDescribeInstanceStatus) DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.output, nil
+}
+
+var _ mintaws.DescribeInstanceStatusAPI = (*mockDescribeInstanceStatus)(nil)
+
+// retirementDate is the not-before date of the event from instanceEvents.
+var retirementDate = time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+// instanceEvents returns a status mock reporting the given events for
+// instanceID.
+func instanceEvents(instanceID string, events ...ec2types.InstanceStatusEvent) *mockDescribeInstanceStatus {
+	return &mockDescribeInstanceStatus{output: &ec2.DescribeInstanceStatusOutput{
+		InstanceStatuses: []ec2types.InstanceStatus{{InstanceId: aws.String(instanceID), Events: events}},
+	}}
+}
+
+// retirementEvent is a pending instance-retirement event on retirementDate.
+func retirementEvent() ec2types.InstanceStatusEvent {
+	return ec2types.InstanceStatusEvent{
+		Code:        ec2types.EventCodeInstanceRetirement,
+		Description: aws.String("The instance is running on degraded hardware"),
+		NotBefore:   aws.Time(retirementDate),
+	}
+}
+
+func runEventsCommand(t *testing.T, deps *eventsDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newEventsCommandWithDeps(deps))
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"events"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestEventsCommand(t *testing.T) {
+	hint.IsTTY = false
+	launched := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		status      *mockDescribeInstanceStatus
+		wantOutput  []string
+		wantMissing []string
+	}{
+		{
+			name:       "no events",
+			status:     instanceEvents("i-abc123"),
+			wantOutput: []string{"No scheduled events for your VMs."},
+		},
+		{
+			name:   "retirement event",
+			status: instanceEvents("i-abc123", retirementEvent()),
+			wantOutput: []string{
+				"instance-retirement",
+				"2025-02-01T00:00:00Z",
+				"The instance is running on degraded hardware",
+				`VM "default": instance scheduled for retirement on 2025-02-01 — run ` + "`mint recreate`" + ` before then; your project volume and EIP will be preserved`,
+			},
+		},
+		{
+			name: "completed event is filtered out",
+			status: instanceEvents("i-abc123", ec2types.InstanceStatusEvent{
+				Code:        ec2types.EventCodeSystemReboot,
+				Description: aws.String("[Completed] Scheduled reboot"),
+				NotBefore:   aws.Time(retirementDate),
+			}),
+			wantOutput:  []string{"No scheduled events for your VMs."},
+			wantMissing: []string{"system-reboot"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := &eventsDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeInstanceWithTime("i-abc123", "default", "alice", "stopped", "", "m6i.xlarge", "complete", launched),
+				},
+				describeStatus: tt.status,
+				owner:          "alice",
+			}
+			out, err := runEventsCommand(t, deps)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(out, missing) {
+					t.Errorf("output should not contain %q:\n%s", missing, out)
+				}
+			}
+		})
+	}
+}
+
+func TestEventsCommandJSON(t *testing.T) {
+	launched := time.Now().Add(-time.Hour)
+	for _, tt := range []struct {
+		name   string
+		status *mockDescribeInstanceStatus
+		want   int
+	}{
+		{name: "no events is an empty array", status: instanceEvents("i-abc123"), want: 0},
+		{name: "retirement event", status: instanceEvents("i-abc123", retirementEvent()), want: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := &eventsDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", launched),
+				},
+				describeStatus: tt.status,
+				owner:          "alice",
+			}
+			out, err := runEventsCommand(t, deps, "--json")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got struct {
+				Events []map[string]any `json:"events"`
+			}
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, out)
+			}
+			if !strings.Contains(out, `"events": [`) {
+				t.Errorf("events should be an array:\n%s", out)
+			}
+			if len(got.Events) != tt.want {
+				t.Fatalf("events = %d, want %d", len(got.Events), tt.want)
+			}
+			if tt.want == 1 {
+				ev := got.Events[0]
+				if ev["vm"] != "default" || ev["instance_id"] != "i-abc123" || ev["code"] != "instance-retirement" || ev["not_before"] != "2025-02-01T00:00:00Z" {
+					t.Errorf("event = %v", ev)
+				}
+			}
+		})
+	}
+}
+
+func TestEventsCommandStatusError(t *testing.T) {
+	deps := &eventsDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now()),
+		},
+		describeStatus: &mockDescribeInstanceStatus{err: fmt.Errorf("access denied")},
+		owner:          "alice",
+	}
+	if _, err := runEventsCommand(t, deps); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("error = %v, want access denied", err)
+	}
+}
@@ -12,14 +12,18 @@ import (
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
 
 
+// minExtendDuration matches the idle_timeout config minimum.
+const minExtendDuration = 15 * time.Minute
+
 // validateExtendArgs is a cobra Args function that validates the optional
-// [minutes] argument before AWS initialization runs in PersistentPreRunE.
+// [duration] argument before AWS initialization runs in PersistentPreRunE.
 // This ensures argument errors are reported immediately rather than after a
 // (potentially slow or failing) credential check.
 func validateExtendArgs(_ *cobra.Command, args []string) error {
@@ -27,17 +31,31 @@ func validateExtendArgs(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("accepts at most 1 arg(s), received %d", len(args))
 	}
 	if len(args) == 1 {
-		n, err := strconv.Atoi(args[0])
-		if err != nil {
-			return fmt.Errorf("invalid minutes %q: must be a number", args[0])
-		}
-		if n < 15 {
-			return fmt.Errorf("minutes must be >= 15 (got %d)", n)
+		if _, err := parseExtendDuration(args[0]); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// parseExtendDuration parses the extend argument: a duration such as "90m"
+// or "2h", or a bare number of minutes as accepted before durations were.
+func parseExtendDuration(arg string) (time.Duration, error) {
+	var d time.Duration
+	if n, err := strconv.Atoi(arg); err == nil {
+		d = time.Duration(n) * time.Minute
+	} else {
+		d, err = format.ParseDuration(arg)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: use a duration such as 90m or 2h, or a number of minutes", arg)
+		}
+	}
+	if d < minExtendDuration {
+		return 0, fmt.Errorf("duration must be at least %s (got %s)", format.FormatDuration(minExtendDuration), format.FormatDuration(d))
+	}
+	return d, nil
+}
+
 // extendDeps holds the injectable dependencies for the extend command.
 type extendDeps struct {
 	describe    mintaws.DescribeInstancesAPI
@@ -45,6 +63,7 @@ type extendDeps struct {
 	owner       string
 	remote      RemoteCommandRunner
 	idleTimeout int // default minutes from config
+	sshPort     int // ssh_port from config; zero means defaultSSHPort
 }
 
 // newExtendCommand creates the production extend command.
@@ -56,12 +75,13 @@ func newExtendCommand() *cobra.Command {
 // dependencies for testing.
 func newExtendCommandWithDeps(deps *extendDeps) *cobra.Command {
 	return &cobra.Command{
-		Use:   "extend [minutes]",
-		Short: "Extend the VM idle auto-stop timer",
+		Use:         "extend [duration]",
+		Short:       "Extend the VM idle auto-stop timer",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
 		Long: "Reset the idle auto-stop timer on the VM. " +
-			"Defaults to the configured idle_timeout_minutes (from config). " +
-			"Pass a number of minutes as an argument to override the default. " +
-			"Minimum value is 15 minutes.",
+			"Defaults to the configured idle_timeout (from config). " +
+			"Pass a duration such as 90m or 2h (or a number of minutes) to override the default. " +
+			"Minimum value is 15m.",
 		Args: validateExtendArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -82,36 +102,37 @@ func newExtendCommandWithDeps(deps *extendDeps) *cobra.Command {
 			}
 			return runExtend(cmd, &extendDeps{
 				describe:    clients.ec2Client,
-				sendKey:     clients.icClient,
+				sendKey:     clients.sendKey,
 				owner:       clients.owner,
-				remote:      defaultRemoteRunner,
+				remote:      clients.remoteRunner(),
 				idleTimeout: idleTimeout,
+				sshPort:     clients.sshOptions.Port,
 			}, args)
 		},
 	}
 }
 
-// runExtend executes the extend command logic: parse minutes, discover VM,
-// run remote command to write the extended-until timestamp.
+// runExtend executes the extend command logic: parse the duration, discover
+// VM, run remote command to write the extended-until timestamp.
 func runExtend(cmd *cobra.Command, deps *extendDeps, args []string) error {
 	ctx := cmd.Context()
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	// Determine minutes: positional arg or config default.
-	minutes := deps.idleTimeout
+	// Determine the duration: positional arg or config default.
+	extendBy := time.Duration(deps.idleTimeout) * time.Minute
 	if len(args) > 0 {
-		n, err := strconv.Atoi(args[0])
+		d, err := parseExtendDuration(args[0])
 		if err != nil {
-			return fmt.Errorf("invalid minutes %q: must be a number", args[0])
+			return err
 		}
-		minutes = n
+		extendBy = d
 	}
 
-	// Validate minimum (matches config validation: >= 15).
-	if minutes < 15 {
-		return fmt.Errorf("minutes must be >= 15 (got %d)", minutes)
+	// Validate minimum (matches config validation: >= 15m).
+	if extendBy < minExtendDuration {
+		return fmt.Errorf("duration must be at least %s (got %s)", format.FormatDuration(minExtendDuration), format.FormatDuration(extendBy))
 	}
 
 	cliCtx := cli.FromCommand(cmd)
@@ -145,7 +166,7 @@ func runExtend(cmd *cobra.Command, deps *extendDeps, args []string) error {
 	// Pass as a single string so SSH forwards it to the remote shell intact.
 	// Using ["bash", "-c", "..."] as separate args causes SSH to concatenate
 	// them, and bash -c only takes the first word after -c as the command.
-	seconds := minutes * 60
+	seconds := int64(extendBy / time.Second)
 	remoteCmd := []string{
 		fmt.Sprintf("echo $(($(date +%%s) + %d)) | sudo tee /var/lib/mint/idle-extended-until", seconds),
 	}
@@ -158,7 +179,7 @@ func runExtend(cmd *cobra.Command, deps *extendDeps, args []string) error {
 		found.ID,
 		found.AvailabilityZone,
 		found.PublicIP,
-		defaultSSHPort,
+		sshPortOrDefault(deps.sshPort),
 		defaultSSHUser,
 		remoteCmd,
 	)
@@ -166,9 +187,9 @@ func runExtend(cmd *cobra.Command, deps *extendDeps, args []string) error {
 		sp.Fail(err.Error())
 		if isSSHConnectionError(err) {
 			return fmt.Errorf(
-				"cannot connect to VM %q (port 41122 refused) — "+
+				"cannot connect to VM %q (port %d refused) — "+
 					"bootstrap may be incomplete\n%s",
-				vmName,
+				vmName, sshPortOrDefault(deps.sshPort),
 				hint.Suggest("Diagnose", "mint doctor"),
 			)
 		}
@@ -178,11 +199,11 @@ func runExtend(cmd *cobra.Command, deps *extendDeps, args []string) error {
 	sp.Stop("")
 
 	// Compute the approximate expiry time for the success message.
-	expiry := time.Now().Add(time.Duration(minutes) * time.Minute)
+	expiry := time.Now().Add(extendBy)
 
 	w := cmd.OutOrStdout()
-	fmt.Fprintf(w, "Extended idle timer by %d minutes (until %s)\n",
-		minutes, expiry.Format("15:04 local time"))
+	fmt.Fprintf(w, "Extended idle timer by %s (until %s)\n",
+		format.FormatDuration(extendBy), expiry.Format("15:04 local time"))
 
 	return nil
 }
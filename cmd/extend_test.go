@@ -2,71 +2,17 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/spf13/cobra"
-
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 )
 
-// mockDescribeForExtend implements mintaws.DescribeInstancesAPI for extend tests.
-type mockDescribeForExtend struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeForExtend) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-// mockSendKeyForExtend implements mintaws.SendSSHPublicKeyAPI for extend tests.
-type mockSendKeyForExtend struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-	called bool
-}
-
-func (m *mockSendKeyForExtend) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	m.called = true
-	return m.output, m.err
-}
-
-// capturedRemoteCommand records what the remote runner was called with.
-type capturedRemoteCommand struct {
-	called     bool
-	command    []string
-	instanceID string
-}
-
-// newMockRemoteRunner creates a RemoteCommandRunner that captures its arguments.
-func newMockRemoteRunner(output []byte, err error) (RemoteCommandRunner, *capturedRemoteCommand) {
-	captured := &capturedRemoteCommand{}
-	runner := func(
-		ctx context.Context,
-		sendKey mintaws.SendSSHPublicKeyAPI,
-		instanceID string,
-		az string,
-		host string,
-		port int,
-		user string,
-		command []string,
-	) ([]byte, error) {
-		captured.called = true
-		captured.command = command
-		captured.instanceID = instanceID
-		return output, err
-	}
-	return runner, captured
-}
-
 func makeRunningInstanceForExtend(id, vmName, owner, ip, az string) *ec2.DescribeInstancesOutput {
 	return &ec2.DescribeInstancesOutput{
 		Reservations: []ec2types.Reservation{{
@@ -107,30 +53,11 @@ func makeStoppedInstanceForExtend(id, vmName, owner string) *ec2.DescribeInstanc
 	}
 }
 
-func newTestRootForExtend() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestExtendCommand(t *testing.T) {
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForExtend
-		sendKey        *mockSendKeyForExtend
+		describe       *cmdtest.DescribeInstances
+		sendKey        *cmdtest.SendSSHPublicKey
 		remoteOutput   []byte
 		remoteErr      error
 		owner          string
@@ -145,17 +72,17 @@ func TestExtendCommand(t *testing.T) {
 	}{
 		{
 			name: "extend with default minutes from config",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteOutput: []byte("ok"),
 			owner:        "alice",
 			idleTimeout:  30,
 			wantRemote:   true,
-			wantOutput:   []string{"Extended idle timer by 30 minutes"},
+			wantOutput:   []string{"Extended idle timer by 30m"},
 			checkCommand: func(t *testing.T, command []string) {
 				t.Helper()
 				joined := strings.Join(command, " ")
@@ -173,18 +100,18 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "extend with explicit minutes overrides config default",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteOutput: []byte("ok"),
 			owner:        "alice",
 			idleTimeout:  30,
 			args:         []string{"45"},
 			wantRemote:   true,
-			wantOutput:   []string{"Extended idle timer by 45 minutes"},
+			wantOutput:   []string{"Extended idle timer by 45m"},
 			checkCommand: func(t *testing.T, command []string) {
 				t.Helper()
 				joined := strings.Join(command, " ")
@@ -196,11 +123,11 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "extend with minutes below 15 fails validation",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:          "alice",
 			idleTimeout:    30,
@@ -211,11 +138,11 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "extend with non-numeric arg fails",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:          "alice",
 			idleTimeout:    30,
@@ -226,10 +153,10 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForExtend{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendKeyForExtend{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			idleTimeout:    30,
 			wantErr:        true,
@@ -238,10 +165,10 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForExtend{
-				output: makeStoppedInstanceForExtend("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForExtend("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendKeyForExtend{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			idleTimeout:    30,
 			wantErr:        true,
@@ -250,10 +177,10 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForExtend{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendKeyForExtend{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			idleTimeout:    30,
 			wantErr:        true,
@@ -262,11 +189,11 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "remote command failure propagates",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteErr:      fmt.Errorf("connection refused"),
 			owner:          "alice",
@@ -277,11 +204,11 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "SSH connection refused wrapped with bootstrap-aware context",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteErr:      fmt.Errorf("remote command failed: exit status 255 (stderr: ssh: connect to host 1.2.3.4 port 41122: Connection refused)"),
 			owner:          "alice",
@@ -292,11 +219,11 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "SSH connection refused bootstrap context mentions mint doctor",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteErr:      fmt.Errorf("remote command failed: exit status 255 (stderr: ssh: connect to host 1.2.3.4 port 41122: Connection timed out)"),
 			owner:          "alice",
@@ -307,32 +234,32 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "non-default vm name",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-dev456", "dev", "bob", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-dev456", "dev", "bob", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteOutput: []byte("ok"),
 			owner:        "bob",
 			vmName:       "dev",
 			idleTimeout:  60,
 			wantRemote:   true,
-			wantOutput:   []string{"Extended idle timer by 60 minutes"},
+			wantOutput:   []string{"Extended idle timer by 1h"},
 		},
 		{
 			name: "uses config default of 60 when not overridden",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteOutput: []byte("ok"),
 			owner:        "alice",
 			idleTimeout:  60,
 			wantRemote:   true,
-			wantOutput:   []string{"Extended idle timer by 60 minutes"},
+			wantOutput:   []string{"Extended idle timer by 1h"},
 			checkCommand: func(t *testing.T, command []string) {
 				t.Helper()
 				joined := strings.Join(command, " ")
@@ -344,18 +271,61 @@ func TestExtendCommand(t *testing.T) {
 		},
 		{
 			name: "boundary value: exactly 15 minutes is accepted",
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			remoteOutput: []byte("ok"),
 			owner:        "alice",
 			idleTimeout:  30,
 			args:         []string{"15"},
 			wantRemote:   true,
-			wantOutput:   []string{"Extended idle timer by 15 minutes"},
+			wantOutput:   []string{"Extended idle timer by 15m"},
+		},
+		{
+			name: "extend with a duration argument",
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			},
+			remoteOutput: []byte("ok"),
+			owner:        "alice",
+			idleTimeout:  30,
+			args:         []string{"1h30m"},
+			wantRemote:   true,
+			wantOutput:   []string{"Extended idle timer by 1h 30m"},
+			checkCommand: func(t *testing.T, command []string) {
+				t.Helper()
+				if joined := strings.Join(command, " "); !strings.Contains(joined, "5400") {
+					t.Errorf("command should contain 5400 seconds (1h30m), got: %s", joined)
+				}
+			},
+		},
+		{
+			name: "extend with a duration below 15m fails validation",
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			},
+			owner:          "alice",
+			idleTimeout:    30,
+			args:           []string{"10m"},
+			wantErr:        true,
+			wantErrContain: "at least 15m (got 10m)",
+		},
+		{
+			name: "extend with mixed-unit garbage fails validation",
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			},
+			owner:          "alice",
+			idleTimeout:    30,
+			args:           []string{"30m1h"},
+			wantErr:        true,
+			wantErrContain: "invalid duration",
 		},
 	}
 
@@ -363,18 +333,18 @@ func TestExtendCommand(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := new(bytes.Buffer)
 
-			runner, captured := newMockRemoteRunner(tt.remoteOutput, tt.remoteErr)
+			runner := &cmdtest.RemoteRunner{Output: tt.remoteOutput, Err: tt.remoteErr}
 
 			deps := &extendDeps{
 				describe:    tt.describe,
 				sendKey:     tt.sendKey,
 				owner:       tt.owner,
-				remote:      runner,
+				remote:      runner.Run,
 				idleTimeout: tt.idleTimeout,
 			}
 
 			cmd := newExtendCommandWithDeps(deps)
-			root := newTestRootForExtend()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -410,12 +380,13 @@ func TestExtendCommand(t *testing.T) {
 				}
 			}
 
-			if tt.wantRemote != captured.called {
-				t.Errorf("remote runner called = %v, want %v", captured.called, tt.wantRemote)
+			called := len(runner.Calls()) > 0
+			if tt.wantRemote != called {
+				t.Errorf("remote runner called = %v, want %v", called, tt.wantRemote)
 			}
 
-			if tt.checkCommand != nil && captured.called {
-				tt.checkCommand(t, captured.command)
+			if tt.checkCommand != nil && called {
+				tt.checkCommand(t, runner.LastCall().Command)
 			}
 		})
 	}
@@ -423,8 +394,8 @@ func TestExtendCommand(t *testing.T) {
 
 func TestExtendCommandUseAndShort(t *testing.T) {
 	cmd := newExtendCommand()
-	if cmd.Use != "extend [minutes]" {
-		t.Errorf("Use = %q, want %q", cmd.Use, "extend [minutes]")
+	if cmd.Use != "extend [duration]" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "extend [duration]")
 	}
 	if cmd.Short == "" {
 		t.Error("Short description should not be empty")
@@ -439,21 +410,21 @@ func TestExtendSpinnerWiring(t *testing.T) {
 	t.Run("spinner emits Looking up VM during discovery", func(t *testing.T) {
 		buf := new(bytes.Buffer)
 
-		runner, _ := newMockRemoteRunner([]byte("ok"), nil)
+		runner := &cmdtest.RemoteRunner{Output: []byte("ok")}
 		deps := &extendDeps{
-			describe: &mockDescribeForExtend{
-				output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForExtend{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote:      runner,
+			remote:      runner.Run,
 			owner:       "alice",
 			idleTimeout: 30,
 		}
 
 		cmd := newExtendCommandWithDeps(deps)
-		root := newTestRootForExtend()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -475,19 +446,19 @@ func TestExtendSpinnerWiring(t *testing.T) {
 	t.Run("spinner emits Looking up VM even when VM not found", func(t *testing.T) {
 		buf := new(bytes.Buffer)
 
-		runner, _ := newMockRemoteRunner(nil, nil)
+		runner := &cmdtest.RemoteRunner{}
 		deps := &extendDeps{
-			describe: &mockDescribeForExtend{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:     &mockSendKeyForExtend{},
-			remote:      runner,
+			sendKey:     &cmdtest.SendSSHPublicKey{},
+			remote:      runner.Run,
 			owner:       "alice",
 			idleTimeout: 30,
 		}
 
 		cmd := newExtendCommandWithDeps(deps)
-		root := newTestRootForExtend()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
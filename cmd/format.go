@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+)
+
+// outputFormat selects how a command renders the data it has already
+// collected. Commands gather everything into a struct first and hand it to a
+// per-command render function together with the resolved format, so adding a
+// format never touches collection logic.
+type outputFormat int
+
+const (
+	// formatHuman is the default aligned, decorated output for terminals.
+	formatHuman outputFormat = iota
+	// formatJSON is the machine-readable JSON output selected by --json (ADR-0012).
+	formatJSON
+	// formatPlain is tab-separated columns with no headers and a stable column
+	// order, intended for shell pipelines (cut -f2, while read ...).
+	formatPlain
+	// formatMetrics is the Prometheus text exposition format selected by
+	// mint status --metrics.
+	formatMetrics
+)
+
+// plainEmpty is emitted in place of empty values in plain output so that
+// column positions never shift when a field has no value.
+const plainEmpty = "-"
+
+// addFormatFlag registers the --format flag on a command that supports plain
+// output. columns documents the stable column order and is appended to the
+// command's Long help text so scripts have a single authoritative reference.
+func addFormatFlag(cmd *cobra.Command, columns ...string) {
+	cmd.Flags().String("format", "", "Output format: human (default), json, or plain (tab-separated, no headers)")
+	cmd.Long += "\n\nWith --format plain, each record is printed as one tab-separated line " +
+		"with no header. Columns (in order): " + strings.Join(columns, ", ") + ". " +
+		"Empty values are printed as \"" + plainEmpty + "\"."
+}
+
+// resolveOutputFormat combines the global --json flag and the per-command
+// --format flag into a single outputFormat. --json always wins so existing
+// scripts keep working. Commands without a --format flag resolve to human or
+// JSON only.
+func resolveOutputFormat(cmd *cobra.Command) (outputFormat, error) {
+	cliCtx := cli.FromCommand(cmd)
+	if cliCtx != nil && cliCtx.JSON {
+		return formatJSON, nil
+	}
+
+	if cmd.Flags().Lookup("format") == nil {
+		return formatHuman, nil
+	}
+	value, _ := cmd.Flags().GetString("format")
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "human", "table":
+		return formatHuman, nil
+	case "json":
+		return formatJSON, nil
+	case "plain":
+		return formatPlain, nil
+	default:
+		return formatHuman, fmt.Errorf("invalid --format %q: must be one of human, json, plain", value)
+	}
+}
+
+// plainField sanitizes a single value for plain output. Tabs, carriage
+// returns, and newlines are replaced with spaces so a value can never split
+// a record or shift columns; empty values become plainEmpty.
+func plainField(s string) string {
+	s = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+	if strings.TrimSpace(s) == "" {
+		return plainEmpty
+	}
+	return s
+}
+
+// writePlainRow writes one tab-separated record terminated by a newline.
+// Every field is passed through plainField.
+func writePlainRow(w io.Writer, fields ...string) {
+	sanitized := make([]string, len(fields))
+	for i, f := range fields {
+		sanitized[i] = plainField(f)
+	}
+	fmt.Fprintln(w, strings.Join(sanitized, "\t"))
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/spf13/cobra"
+)
+
+// updateGolden rewrites testdata/*.golden files from the current output.
+// Run: go test ./cmd/ -run Plain -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// assertGolden compares got against testdata/<name>.golden, rewriting the
+// file instead when -update is set.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatalf("creating testdata: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file %s: %v (run with -update to create)", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestPlainField(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"running", "running"},
+		{"", "-"},
+		{"   ", "-"},
+		{"a\tb", "a b"},
+		{"line1\nline2", "line1 line2"},
+		{"crlf\r\n", "crlf  "},
+	}
+	for _, tt := range tests {
+		if got := plainField(tt.in); got != tt.want {
+			t.Errorf("plainField(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWritePlainRowKeepsColumnCount(t *testing.T) {
+	var buf bytes.Buffer
+	writePlainRow(&buf, "a", "", "b\tc", "d\ne")
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if strings.Contains(line, "\n") {
+		t.Fatalf("row must be a single line, got %q", buf.String())
+	}
+	if got := len(strings.Split(line, "\t")); got != 4 {
+		t.Errorf("column count = %d, want 4 (%q)", got, line)
+	}
+}
+
+func TestResolveOutputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    outputFormat
+		wantErr bool
+	}{
+		{name: "default is human", args: nil, want: formatHuman},
+		{name: "plain", args: []string{"--format", "plain"}, want: formatPlain},
+		{name: "json via format", args: []string{"--format", "json"}, want: formatJSON},
+		{name: "json flag wins", args: []string{"--json", "--format", "plain"}, want: formatJSON},
+		{name: "invalid value", args: []string{"--format", "yaml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got outputFormat
+			var gotErr error
+			sub := &cobra.Command{
+				Use: "probe",
+				RunE: func(cmd *cobra.Command, args []string) error {
+					got, gotErr = resolveOutputFormat(cmd)
+					return nil
+				},
+			}
+			addFormatFlag(sub, "a")
+			root := cmdtest.NewRoot()
+			root.AddCommand(sub)
+			root.SetArgs(append([]string{"probe"}, tt.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if tt.wantErr {
+				if gotErr == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("unexpected error: %v", gotErr)
+			}
+			if got != tt.want {
+				t.Errorf("format = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusPlainGolden(t *testing.T) {
+	launch := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	diskOut := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		return []byte("Mounted on     Use% Avail\n/               42%   38G\n"), nil
+	}
+
+	tests := []struct {
+		golden string
+		vmName string
+		deps   *statusDeps
+	}{
+		{
+			golden: "status_plain_running",
+			vmName: "default",
+			deps: &statusDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeInstanceWithVolumeTags("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", launch, "200", "50"),
+				},
+				sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				remoteRun: diskOut,
+				owner:     "alice",
+			},
+		},
+		{
+			golden: "status_plain_stopped",
+			vmName: "dev",
+			deps: &statusDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeInstanceWithTime("i-dev456", "dev", "alice", "stopped", "", "t3.medium", "", launch),
+				},
+				owner: "alice",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			tt.deps.versionChecker = func() (bool, *string) { return false, nil }
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(tt.deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status", "--vm", tt.vmName, "--format", "plain"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertGolden(t, tt.golden, buf.String())
+		})
+	}
+}
+
+func TestListPlainGolden(t *testing.T) {
+	launch := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	deps := &listDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeMultiInstanceOutput(
+				makeTestInstance("i-one", "default", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", launch),
+				makeTestInstance("i-two", "dev", "alice", "stopped", "", "t3.medium", "", launch.Add(time.Hour)),
+			),
+		},
+		owner:          "alice",
+		idleTimeout:    time.Hour,
+		versionChecker: func() (bool, *string) { return false, nil },
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newListCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"list", "--format", "plain"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertGolden(t, "list_plain", buf.String())
+}
+
+func TestProjectListPlainGolden(t *testing.T) {
+	hint.IsTTY = false
+	remote := &cmdtest.RemoteRunner{
+		Outputs: [][]byte{
+			[]byte("myproject\nsidecar\n"),
+			[]byte("myproject_devcontainer-app-1\tUp 2 hours\tmcr.microsoft.com/devcontainers/go:1.21\t/mint/projects/myproject\n"),
+		},
+	}
+	deps := &projectListDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:   "alice",
+		remote:  remote.Run,
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithListDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"project", "list", "--format", "plain"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertGolden(t, "project_list_plain", buf.String())
+}
@@ -0,0 +1,370 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// gc actions reported for each VM or Elastic IP.
+const (
+	gcActionRelease  = "release"
+	gcActionReleased = "released"
+	gcActionSkip     = "skip"
+	gcActionFailed   = "failed"
+)
+
+// gcDeps holds the injectable dependencies for the gc command.
+type gcDeps struct {
+	describe      mintaws.DescribeInstancesAPI
+	describeAddrs mintaws.DescribeAddressesAPI
+	disassociate  mintaws.DisassociateAddressAPI
+	release       mintaws.ReleaseAddressAPI
+	createTags    mintaws.CreateTagsAPI
+	owner         string
+	// releaseAfterDays is release_eip_after_stopped_days. Zero disables gc.
+	releaseAfterDays int
+	now              func() time.Time // nil uses time.Now
+}
+
+// clock returns the current time from deps.now, or time.Now.
+func (d *gcDeps) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// gcEntry is one line of the gc report: an Elastic IP to release, or a VM
+// that gc leaves alone and why.
+type gcEntry struct {
+	VM           string `json:"vm"`
+	InstanceID   string `json:"instance_id"`
+	State        string `json:"state"`
+	StoppedDays  *int   `json:"stopped_days"`
+	AllocationID string `json:"allocation_id,omitempty"`
+	PublicIP     string `json:"public_ip,omitempty"`
+	Action       string `json:"action"`
+	Reason       string `json:"reason,omitempty"`
+
+	// associationID is the Elastic IP's association to disassociate first.
+	associationID string
+}
+
+// gcReport is the outcome of a gc run, also used as the JSON output.
+type gcReport struct {
+	ThresholdDays int       `json:"threshold_days"`
+	Applied       bool      `json:"applied"`
+	Entries       []gcEntry `json:"entries"`
+}
+
+// newGCCommand creates the production gc command.
+func newGCCommand() *cobra.Command {
+	return newGCCommandWithDeps(nil)
+}
+
+// newGCCommandWithDeps creates the gc command with explicit dependencies for
+// testing.
+func newGCCommandWithDeps(deps *gcDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Release Elastic IPs of VMs stopped for a long time",
+		Long: "List the Elastic IPs of VMs that have been stopped longer than " +
+			"release_eip_after_stopped_days, which keep billing while the VM is stopped. " +
+			"Nothing is released unless --apply is given. Running VMs and Elastic IPs " +
+			"tagged mint:retained=true are never touched. A VM whose Elastic IP was " +
+			"released gets a fresh one on the next mint up. Use --vm to check a single VM.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runGC(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runGC(cmd, &gcDeps{
+				describe:         clients.ec2Client,
+				describeAddrs:    clients.ec2Client,
+				disassociate:     clients.ec2Client,
+				release:          clients.ec2Client,
+				createTags:       clients.ec2Client,
+				owner:            clients.owner,
+				releaseAfterDays: clients.releaseEIPAfterStoppedDays(),
+			})
+		},
+	}
+
+	cmd.Flags().Bool("apply", false, "Release the listed Elastic IPs (default is a dry run)")
+
+	return cmd
+}
+
+// runGC executes the gc command logic.
+func runGC(cmd *cobra.Command, deps *gcDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+	apply, _ := cmd.Flags().GetBool("apply")
+
+	if deps.releaseAfterDays <= 0 {
+		return fmt.Errorf("Elastic IP release is off — enable it with %s",
+			hint.Cmd("mint config set release_eip_after_stopped_days 14"))
+	}
+
+	var vms []*vm.VM
+	if cmd.Flags().Changed("vm") {
+		found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+		if err != nil {
+			return fmt.Errorf("discovering VM: %w", err)
+		}
+		if found == nil {
+			return fmt.Errorf("no VM %q found", vmName)
+		}
+		vms = []*vm.VM{found}
+	} else {
+		all, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+		if err != nil {
+			return fmt.Errorf("listing VMs: %w", err)
+		}
+		vms = all
+	}
+	sort.Slice(vms, func(i, j int) bool { return vms[i].Name < vms[j].Name })
+
+	addrs, err := ownerElasticIPs(ctx, deps.describeAddrs, deps.owner)
+	if err != nil {
+		return err
+	}
+
+	report := &gcReport{ThresholdDays: deps.releaseAfterDays, Applied: apply, Entries: []gcEntry{}}
+	now := deps.clock()
+	for _, v := range vms {
+		report.Entries = append(report.Entries, planGC(v, addrs[v.Name], deps.releaseAfterDays, now)...)
+	}
+
+	failed := 0
+	if apply {
+		for i := range report.Entries {
+			e := &report.Entries[i]
+			if e.Action != gcActionRelease {
+				continue
+			}
+			if err := releaseStoppedEIP(ctx, deps, e); err != nil {
+				e.Action = gcActionFailed
+				e.Reason = err.Error()
+				failed++
+				continue
+			}
+			e.Action = gcActionReleased
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return silentExitError{}
+		}
+		return nil
+	}
+	writeGCHuman(cmd.OutOrStdout(), report)
+	if failed > 0 {
+		return fmt.Errorf("%d Elastic IP(s) could not be released", failed)
+	}
+	return nil
+}
+
+// ownerElasticIPs returns the owner's mint Elastic IPs keyed by VM name.
+func ownerElasticIPs(ctx context.Context, client mintaws.DescribeAddressesAPI, owner string) (map[string][]ec2types.Address, error) {
+	filters := append(tags.FilterByOwner(owner), ec2types.Filter{
+		Name:   aws.String("tag:" + tags.TagComponent),
+		Values: []string{tags.ComponentElasticIP},
+	})
+	out, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("describing Elastic IPs: %w", err)
+	}
+	byVM := make(map[string][]ec2types.Address)
+	for _, addr := range out.Addresses {
+		name := tags.ToMap(addr.Tags)[tags.TagVM]
+		byVM[name] = append(byVM[name], addr)
+	}
+	return byVM, nil
+}
+
+// planGC decides what gc does for v and its Elastic IPs: release each one
+// when v has been stopped at least thresholdDays, otherwise skip with a
+// reason. A VM whose stop time cannot be read is always skipped.
+func planGC(v *vm.VM, addrs []ec2types.Address, thresholdDays int, now time.Time) []gcEntry {
+	base := gcEntry{VM: v.Name, InstanceID: v.ID, State: v.State, Action: gcActionSkip}
+	skip := func(reason string) []gcEntry {
+		base.Reason = reason
+		return []gcEntry{base}
+	}
+
+	if v.State != string(ec2types.InstanceStateNameStopped) {
+		return skip("not stopped")
+	}
+	days := stoppedDays(v, now)
+	base.StoppedDays = days
+	if days == nil {
+		return skip("unknown age — skipped")
+	}
+	if *days < thresholdDays {
+		return skip(fmt.Sprintf("stopped %s, below the %d-day threshold", formatDays(*days), thresholdDays))
+	}
+	if len(addrs) == 0 {
+		if v.Tags[tags.TagEIP] == tags.EIPReleasedByGC {
+			return skip("Elastic IP already released")
+		}
+		return skip("no Elastic IP")
+	}
+
+	entries := make([]gcEntry, 0, len(addrs))
+	for _, addr := range addrs {
+		e := base
+		e.AllocationID = aws.ToString(addr.AllocationId)
+		e.PublicIP = aws.ToString(addr.PublicIp)
+		e.associationID = aws.ToString(addr.AssociationId)
+		if tags.ToMap(addr.Tags)[tags.TagRetained] == "true" {
+			e.Reason = tags.TagRetained + "=true"
+		} else {
+			e.Action = gcActionRelease
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// stoppedDays returns how many whole days v has been stopped, or nil when v
+// is not stopped or its stop time is unknown.
+func stoppedDays(v *vm.VM, now time.Time) *int {
+	d, ok := v.StoppedFor(now)
+	if !ok {
+		return nil
+	}
+	days := int(d / (24 * time.Hour))
+	return &days
+}
+
+// eipReleaseDue reports whether gc would release v's Elastic IP under a
+// release_eip_after_stopped_days of thresholdDays. It does not look up the
+// address, so a VM without one can still report due.
+func eipReleaseDue(v *vm.VM, thresholdDays int, now time.Time) bool {
+	if thresholdDays <= 0 || v.Tags[tags.TagEIP] == tags.EIPReleasedByGC {
+		return false
+	}
+	days := stoppedDays(v, now)
+	return days != nil && *days >= thresholdDays
+}
+
+// releaseStoppedEIP disassociates and releases e's Elastic IP, then tags
+// the instance so the next mint up allocates a fresh one.
+func releaseStoppedEIP(ctx context.Context, deps *gcDeps, e *gcEntry) error {
+	if e.associationID != "" {
+		if _, err := deps.disassociate.DisassociateAddress(ctx, &ec2.DisassociateAddressInput{
+			AssociationId: aws.String(e.associationID),
+		}); err != nil {
+			return fmt.Errorf("disassociating %s: %w", e.AllocationID, err)
+		}
+	}
+	if _, err := deps.release.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{
+		AllocationId: aws.String(e.AllocationID),
+	}); err != nil {
+		return fmt.Errorf("releasing %s: %w", e.AllocationID, err)
+	}
+	if _, err := deps.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{e.InstanceID},
+		Tags: []ec2types.Tag{
+			{Key: aws.String(tags.TagEIP), Value: aws.String(tags.EIPReleasedByGC)},
+		},
+	}); err != nil {
+		return fmt.Errorf("released %s but could not tag %s with %s=%s — add the tag by hand so %s allocates a new Elastic IP: %w",
+			e.AllocationID, e.InstanceID, tags.TagEIP, tags.EIPReleasedByGC, hint.Cmd("mint up"), err)
+	}
+	return nil
+}
+
+// writeGCHuman outputs the gc report as a table with a summary.
+func writeGCHuman(w io.Writer, report *gcReport) {
+	if len(report.Entries) == 0 {
+		fmt.Fprintln(w, "No VMs found.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VM\tSTATE\tSTOPPED\tELASTIC IP\tACTION")
+	pending := 0
+	released := 0
+	for _, e := range report.Entries {
+		stopped := "-"
+		if e.StoppedDays != nil {
+			stopped = formatDays(*e.StoppedDays)
+		} else if e.State == string(ec2types.InstanceStateNameStopped) {
+			stopped = "unknown"
+		}
+		ip := e.PublicIP
+		if ip == "" {
+			ip = "-"
+		}
+		action := e.Action
+		if e.Reason != "" {
+			action += ": " + e.Reason
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.VM, e.State, stopped, ip, action)
+		switch e.Action {
+		case gcActionRelease:
+			pending++
+		case gcActionReleased:
+			released++
+		}
+	}
+	tw.Flush()
+
+	switch {
+	case report.Applied && released > 0:
+		fmt.Fprintf(w, "\nReleased %d Elastic IP(s). %s on those VMs allocates a new one.\n", released, hint.Cmd("mint up"))
+	case report.Applied:
+		fmt.Fprintln(w, "\nNothing released.")
+	case pending == 0:
+		fmt.Fprintf(w, "\nNothing to release (threshold: %d days).\n", report.ThresholdDays)
+	default:
+		fmt.Fprintf(w, "\nDry run — would release %d Elastic IP(s). Run %s to release them.\n", pending, hint.Cmd("mint gc --apply"))
+	}
+}
+
+// formatDays formats a whole number of days.
+func formatDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return strconv.Itoa(days) + " days"
+}
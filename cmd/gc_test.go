@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// gcNow is the fixed time the gc tests run at.
+var gcNow = time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+
+// gcInstance returns a mint instance in state with the given state
+// transition reason and extra tags.
+func gcInstance(id, vmName string, state ec2types.InstanceStateName, reason string, extra ...ec2types.Tag) ec2types.Instance {
+	return ec2types.Instance{
+		InstanceId:            aws.String(id),
+		State:                 &ec2types.InstanceState{Name: state},
+		StateTransitionReason: aws.String(reason),
+		Tags: append([]ec2types.Tag{
+			{Key: aws.String(tags.TagVM), Value: aws.String(vmName)},
+			{Key: aws.String(tags.TagOwner), Value: aws.String("alice")},
+		}, extra...),
+	}
+}
+
+// gcAddress returns an Elastic IP tagged for vmName and associated with its
+// instance.
+func gcAddress(allocID, ip, vmName string, extra ...ec2types.Tag) ec2types.Address {
+	return ec2types.Address{
+		AllocationId:  aws.String(allocID),
+		AssociationId: aws.String("eipassoc-" + vmName),
+		PublicIp:      aws.String(ip),
+		Tags: append([]ec2types.Tag{
+			{Key: aws.String(tags.TagVM), Value: aws.String(vmName)},
+			{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentElasticIP)},
+		}, extra...),
+	}
+}
+
+// newGCDeps returns gc deps over a fleet of VMs covering every gc decision:
+//   - old: stopped 17 days, released
+//   - recent: stopped 3 days, below the threshold
+//   - kept: stopped 30 days, EIP tagged mint:retained=true
+//   - mystery: stopped, no timestamp in the reason
+//   - busy: running
+func newGCDeps() *gcDeps {
+	instances := []ec2types.Instance{
+		gcInstance("i-old", "old", ec2types.InstanceStateNameStopped, "User initiated (2025-01-03 10:00:00 GMT)"),
+		gcInstance("i-recent", "recent", ec2types.InstanceStateNameStopped, "User initiated (2025-01-17 10:00:00 GMT)"),
+		gcInstance("i-kept", "kept", ec2types.InstanceStateNameStopped, "User initiated (2024-12-21 10:00:00 GMT)"),
+		gcInstance("i-mystery", "mystery", ec2types.InstanceStateNameStopped, "Server.ScheduledStop: Stopped due to scheduled retirement"),
+		gcInstance("i-busy", "busy", ec2types.InstanceStateNameRunning, ""),
+	}
+	retained := ec2types.Tag{Key: aws.String(tags.TagRetained), Value: aws.String("true")}
+	return &gcDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{Instances: instances}},
+		}},
+		describeAddrs: &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
+			Addresses: []ec2types.Address{
+				gcAddress("eipalloc-old", "54.0.0.1", "old"),
+				gcAddress("eipalloc-recent", "54.0.0.2", "recent"),
+				gcAddress("eipalloc-kept", "54.0.0.3", "kept", retained),
+				gcAddress("eipalloc-mystery", "54.0.0.4", "mystery"),
+				gcAddress("eipalloc-busy", "54.0.0.5", "busy"),
+			},
+		}},
+		disassociate:     &mockDisassociateAddress{},
+		release:          &cmdtest.ReleaseAddress{Output: &ec2.ReleaseAddressOutput{}},
+		createTags:       &cmdtest.CreateTags{},
+		owner:            "alice",
+		releaseAfterDays: 14,
+		now:              func() time.Time { return gcNow },
+	}
+}
+
+func runGCCommand(t *testing.T, deps *gcDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newGCCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"gc"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func gcActions(t *testing.T, out string) map[string]gcEntry {
+	t.Helper()
+	var report gcReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	byVM := make(map[string]gcEntry)
+	for _, e := range report.Entries {
+		byVM[e.VM] = e
+	}
+	return byVM
+}
+
+func TestGCDryRunListsWithoutReleasing(t *testing.T) {
+	deps := newGCDeps()
+	out, err := runGCCommand(t, deps, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	got := gcActions(t, out)
+	want := map[string]struct{ action, reason string }{
+		"old":     {gcActionRelease, ""},
+		"recent":  {gcActionSkip, "stopped 3 days, below the 14-day threshold"},
+		"kept":    {gcActionSkip, "mint:retained=true"},
+		"mystery": {gcActionSkip, "unknown age — skipped"},
+		"busy":    {gcActionSkip, "not stopped"},
+	}
+	for vmName, w := range want {
+		e := got[vmName]
+		if e.Action != w.action || e.Reason != w.reason {
+			t.Errorf("%s: action %q (%q), want %q (%q)", vmName, e.Action, e.Reason, w.action, w.reason)
+		}
+	}
+	if e := got["old"]; e.AllocationID != "eipalloc-old" || e.StoppedDays == nil || *e.StoppedDays != 17 {
+		t.Errorf("old entry = %+v, want eipalloc-old stopped 17 days", e)
+	}
+
+	if released := deps.release.(*cmdtest.ReleaseAddress).Released; len(released) != 0 {
+		t.Errorf("dry run released %v", released)
+	}
+	if deps.disassociate.(*mockDisassociateAddress).called || len(deps.createTags.(*cmdtest.CreateTags).Inputs) != 0 {
+		t.Error("dry run must not disassociate or tag")
+	}
+}
+
+func TestGCApplyReleasesAndTags(t *testing.T) {
+	hint.IsTTY = false
+	deps := newGCDeps()
+	out, err := runGCCommand(t, deps, "--apply")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	if got := aws.ToString(deps.disassociate.(*mockDisassociateAddress).captured.AssociationId); got != "eipassoc-old" {
+		t.Errorf("disassociated %q, want eipassoc-old", got)
+	}
+	if released := deps.release.(*cmdtest.ReleaseAddress).Released; !slices.Equal(released, []string{"eipalloc-old"}) {
+		t.Errorf("released %v, want only eipalloc-old", released)
+	}
+	calls := deps.createTags.(*cmdtest.CreateTags).Inputs
+	if len(calls) != 1 || calls[0].Resources[0] != "i-old" ||
+		aws.ToString(calls[0].Tags[0].Key) != tags.TagEIP || aws.ToString(calls[0].Tags[0].Value) != tags.EIPReleasedByGC {
+		t.Errorf("CreateTags calls = %+v, want i-old tagged %s=%s", calls, tags.TagEIP, tags.EIPReleasedByGC)
+	}
+	for _, want := range []string{"released", "Released 1 Elastic IP(s). `mint up` on those VMs allocates a new one."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGCScopedToVM(t *testing.T) {
+	deps := newGCDeps()
+	deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{
+			gcInstance("i-recent", "recent", ec2types.InstanceStateNameStopped, "User initiated (2025-01-17 10:00:00 GMT)"),
+		}}},
+	}}
+
+	out, err := runGCCommand(t, deps, "--vm", "recent", "--apply", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	got := gcActions(t, out)
+	if len(got) != 1 || got["recent"].Action != gcActionSkip {
+		t.Errorf("entries = %+v, want only recent, skipped", got)
+	}
+	if released := deps.release.(*cmdtest.ReleaseAddress).Released; len(released) != 0 {
+		t.Errorf("released %v outside the --vm scope", released)
+	}
+}
+
+func TestGCErrors(t *testing.T) {
+	hint.IsTTY = false
+
+	t.Run("policy disabled", func(t *testing.T) {
+		deps := newGCDeps()
+		deps.releaseAfterDays = 0
+		_, err := runGCCommand(t, deps)
+		if err == nil || !strings.Contains(err.Error(), "`mint config set release_eip_after_stopped_days 14`") {
+			t.Errorf("error = %v, want config hint", err)
+		}
+	})
+
+	t.Run("release failure", func(t *testing.T) {
+		deps := newGCDeps()
+		deps.release = &cmdtest.ReleaseAddress{Err: errors.New("AuthFailure")}
+		out, err := runGCCommand(t, deps, "--apply")
+		if err == nil || !strings.Contains(err.Error(), "1 Elastic IP(s) could not be released") {
+			t.Errorf("error = %v", err)
+		}
+		if !strings.Contains(out, "failed: releasing eipalloc-old: AuthFailure") {
+			t.Errorf("output missing failure:\n%s", out)
+		}
+		if len(deps.createTags.(*cmdtest.CreateTags).Inputs) != 0 {
+			t.Error("an EIP that was not released must not be tagged as released")
+		}
+	})
+}
+
+func TestPlanGCAlreadyReleased(t *testing.T) {
+	deps := newGCDeps()
+	deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{
+			gcInstance("i-old", "old", ec2types.InstanceStateNameStopped, "User initiated (2025-01-03 10:00:00 GMT)",
+				ec2types.Tag{Key: aws.String(tags.TagEIP), Value: aws.String(tags.EIPReleasedByGC)}),
+		}}},
+	}}
+	deps.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}}
+
+	out, err := runGCCommand(t, deps, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if e := gcActions(t, out)["old"]; e.Action != gcActionSkip || e.Reason != "Elastic IP already released" {
+		t.Errorf("entry = %+v", e)
+	}
+}
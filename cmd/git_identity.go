@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/gitidentity"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// gitIdentityDeps holds the injectable dependencies for the git-identity
+// subcommands.
+type gitIdentityDeps struct {
+	describe       mintaws.DescribeInstancesAPI
+	sendKey        mintaws.SendSSHPublicKeyAPI
+	owner          string
+	remote         RemoteCommandRunner
+	sshPort        int // ssh_port from config; zero means defaultSSHPort
+	hostKeyStore   *sshconfig.HostKeyStore
+	hostKeyScanner HostKeyScanner
+}
+
+// gitIdentityRunner runs a command on the VM a git-identity subcommand
+// targets.
+type gitIdentityRunner func(command []string) ([]byte, error)
+
+// newGitIdentityCommand creates the parent git-identity command with
+// subcommands.
+func newGitIdentityCommand() *cobra.Command {
+	return newGitIdentityCommandWithDeps(nil)
+}
+
+// newGitIdentityCommandWithDeps creates the git-identity command with
+// explicit dependencies for testing.
+func newGitIdentityCommandWithDeps(deps *gitIdentityDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git-identity",
+		Short: "Map repository URLs to git commit identities",
+		Long: "Manage the git commit identities on the VM. Each identity sets user.name and " +
+			"user.email for repositories whose remote URL matches one of its --match patterns, " +
+			"using includeIf \"hasconfig:remote.*.url:...\" sections in " + gitidentity.IncludePath +
+			", which the global git config includes. An identity without --match is the default " +
+			"for repositories no pattern matches.",
+	}
+
+	cmd.AddCommand(newGitIdentitySetCommand(deps))
+	cmd.AddCommand(newGitIdentityListCommand(deps))
+	cmd.AddCommand(newGitIdentityRemoveCommand(deps))
+
+	return cmd
+}
+
+// gitIdentityRunE wraps run so it receives production dependencies when
+// deps is nil.
+func gitIdentityRunE(deps *gitIdentityDeps, run func(cmd *cobra.Command, deps *gitIdentityDeps, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if deps != nil {
+			return run(cmd, deps, args)
+		}
+		clients := awsClientsFromContext(cmd.Context())
+		if clients == nil {
+			return fmt.Errorf("AWS clients not configured")
+		}
+		return run(cmd, &gitIdentityDeps{
+			describe:       clients.ec2Client,
+			sendKey:        clients.sendKey,
+			owner:          clients.owner,
+			remote:         clients.remoteRunner(),
+			sshPort:        clients.sshOptions.Port,
+			hostKeyStore:   sshconfig.NewHostKeyStore(config.DefaultConfigDir()),
+			hostKeyScanner: defaultHostKeyScanner,
+		}, args)
+	}
+}
+
+func newGitIdentitySetCommand(deps *gitIdentityDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "set <name>",
+		Short:       "Add or replace a git identity",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Long: "Add a git identity, or replace the one with the same name.\n\n" +
+			"A --match pattern is a repository URL glob such as github.com/acme/* or " +
+			"https://gitlab.example.com/**. A pattern without a scheme matches the https://, " +
+			"ssh://git@, and git@host: forms of the URL; a bare host matches every repository on it. " +
+			"Repeat --match for several patterns. Omit --match to set the default identity.",
+		Example: "  mint git-identity set work --user 'Jane Doe' --email jane@acme.com --match 'github.com/acme/*'\n" +
+			"  mint git-identity set personal --user 'Jane Doe' --email jane@example.com",
+		Args: cobra.ExactArgs(1),
+		RunE: gitIdentityRunE(deps, runGitIdentitySet),
+	}
+
+	cmd.Flags().String("user", "", "Commit author name (user.name)")
+	cmd.Flags().String("email", "", "Commit author email (user.email)")
+	cmd.Flags().StringArray("match", nil, "Repository URL pattern this identity applies to (repeatable)")
+	_ = cmd.MarkFlagRequired("user")
+	_ = cmd.MarkFlagRequired("email")
+
+	return cmd
+}
+
+func newGitIdentityListCommand(deps *gitIdentityDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List git identities on the VM",
+		Args:  cobra.NoArgs,
+		RunE:  gitIdentityRunE(deps, runGitIdentityList),
+	}
+}
+
+func newGitIdentityRemoveCommand(deps *gitIdentityDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:         "remove <name>",
+		Short:       "Remove a git identity",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Args:        cobra.ExactArgs(1),
+		RunE:        gitIdentityRunE(deps, runGitIdentityRemove),
+	}
+}
+
+// runGitIdentitySet validates the identity, merges it into the identities on
+// the VM, and writes the result.
+func runGitIdentitySet(cmd *cobra.Command, deps *gitIdentityDeps, args []string) error {
+	user, _ := cmd.Flags().GetString("user")
+	email, _ := cmd.Flags().GetString("email")
+	match, _ := cmd.Flags().GetStringArray("match")
+	id := gitidentity.Identity{Name: args[0], User: user, Email: email, Match: match}
+	if err := id.Validate(); err != nil {
+		return err
+	}
+
+	run, vmName, err := connectGitIdentityVM(cmd, deps)
+	if err != nil {
+		return err
+	}
+	identities, err := readGitIdentities(run)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+	replaced := false
+	for i, existing := range identities {
+		if existing.Name == id.Name {
+			identities[i] = id
+			replaced = true
+			if id.IsDefault() && !existing.IsDefault() {
+				stale = append(stale, existing.FileName())
+			}
+			continue
+		}
+		if id.IsDefault() && existing.IsDefault() {
+			return fmt.Errorf("identity %q is already the default; give %q a --match pattern or remove %q first",
+				existing.Name, id.Name, existing.Name)
+		}
+	}
+	if !replaced {
+		identities = append(identities, id)
+	}
+
+	if _, err := run(buildGitIdentityWriteCommand(identities, stale)); err != nil {
+		return fmt.Errorf("writing git identities: %w", err)
+	}
+
+	verb := "Added"
+	if replaced {
+		verb = "Updated"
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s git identity %q on VM %q\n", verb, id.Name, vmName)
+	return nil
+}
+
+// runGitIdentityList prints the identities on the VM.
+func runGitIdentityList(cmd *cobra.Command, deps *gitIdentityDeps, _ []string) error {
+	run, _, err := connectGitIdentityVM(cmd, deps)
+	if err != nil {
+		return err
+	}
+	identities, err := readGitIdentities(run)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		if identities == nil {
+			identities = []gitidentity.Identity{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(identities)
+	}
+	writeGitIdentityListHuman(w, identities)
+	return nil
+}
+
+// runGitIdentityRemove drops an identity and its file from the VM.
+func runGitIdentityRemove(cmd *cobra.Command, deps *gitIdentityDeps, args []string) error {
+	name := args[0]
+	run, vmName, err := connectGitIdentityVM(cmd, deps)
+	if err != nil {
+		return err
+	}
+	identities, err := readGitIdentities(run)
+	if err != nil {
+		return err
+	}
+
+	kept := identities[:0]
+	var removed *gitidentity.Identity
+	for _, id := range identities {
+		if id.Name == name {
+			removed = &id
+			continue
+		}
+		kept = append(kept, id)
+	}
+	if removed == nil {
+		return fmt.Errorf("no git identity %q on VM %q — run %s to see them", name, vmName, hint.Cmd("mint git-identity list"))
+	}
+
+	var stale []string
+	if !removed.IsDefault() {
+		stale = append(stale, removed.FileName())
+	}
+	if _, err := run(buildGitIdentityWriteCommand(kept, stale)); err != nil {
+		return fmt.Errorf("writing git identities: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed git identity %q from VM %q\n", name, vmName)
+	return nil
+}
+
+// connectGitIdentityVM finds the running, bootstrapped VM and returns a
+// TOFU-verified runner for it (ADR-0019).
+func connectGitIdentityVM(cmd *cobra.Command, deps *gitIdentityDeps) (gitIdentityRunner, string, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return nil, "", fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return nil, "", fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil, "", fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+	switch found.BootstrapStatus {
+	case tags.BootstrapPending:
+		return nil, "", fmt.Errorf(
+			"VM %q bootstrap is not complete (status: pending).\n"+
+				"Run %s for details or %s to rebuild.",
+			vmName, hint.Cmd("mint doctor"), hint.Cmd("mint recreate"),
+		)
+	case tags.BootstrapFailed:
+		return nil, "", fmt.Errorf(
+			"VM %q bootstrap failed.\nRun %s to rebuild.",
+			vmName, hint.Cmd("mint recreate"),
+		)
+	}
+
+	remote := deps.remote
+	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
+		tofu := NewTOFURemoteRunner(deps.remote, deps.hostKeyStore, deps.hostKeyScanner, vmName)
+		remote = tofu.Run
+	}
+	run := func(command []string) ([]byte, error) {
+		return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	}
+	return run, vmName, nil
+}
+
+// readGitIdentities reads the identities recorded in the index on the VM.
+// A missing index means none have been set.
+func readGitIdentities(run gitIdentityRunner) ([]gitidentity.Identity, error) {
+	out, err := run(buildGitIdentityReadCommand())
+	if err != nil {
+		return nil, fmt.Errorf("reading git identities: %w", err)
+	}
+	identities, err := gitidentity.ParseIndex(out)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", gitidentity.IncludePath, err)
+	}
+	return identities, nil
+}
+
+// buildGitIdentityReadCommand returns the remote command that prints the
+// index, or nothing when it does not exist.
+func buildGitIdentityReadCommand() []string {
+	return []string{fmt.Sprintf("cat ~/%s/%s 2>/dev/null || true", gitidentity.ConfigDir, gitidentity.IndexFile)}
+}
+
+// buildGitIdentityWriteCommand returns the remote command that writes the
+// index for identities and the file of every identity with match patterns,
+// deletes the stale files (paths relative to the git config directory), and
+// adds the index to the global config's include.path once.
+//
+// Each file is written to a temporary name and renamed into place, so git
+// never reads a half-written config. Identity files are written before the
+// index that references them and deleted after it stops referencing them.
+// Contents travel base64-encoded so names and emails are never interpreted
+// by the shell.
+func buildGitIdentityWriteCommand(identities []gitidentity.Identity, stale []string) []string {
+	dir := "~/" + gitidentity.ConfigDir
+	writeFile := func(b *strings.Builder, name string, data []byte) {
+		path := dir + "/" + name
+		fmt.Fprintf(b, "printf %%s %s | base64 -d > %s.tmp\nmv -f %s.tmp %s\n",
+			base64.StdEncoding.EncodeToString(data), path, path, path)
+	}
+
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	fmt.Fprintf(&b, "mkdir -p %s/%s\n", dir, gitidentity.IdentityDir)
+	for _, id := range identities {
+		if !id.IsDefault() {
+			writeFile(&b, id.FileName(), gitidentity.RenderIdentity(id))
+		}
+	}
+	writeFile(&b, gitidentity.IndexFile, gitidentity.RenderIndex(identities))
+	for _, name := range stale {
+		fmt.Fprintf(&b, "rm -f %s/%s\n", dir, name)
+	}
+	include := shellQuote(gitidentity.IncludePath)
+	fmt.Fprintf(&b, "git config --global --get-all include.path | grep -qxF %s || git config --global --add include.path %s\n",
+		include, include)
+	return []string{"sh", "-c", shellQuote(b.String())}
+}
+
+// writeGitIdentityListHuman prints identities as a table.
+func writeGitIdentityListHuman(w io.Writer, identities []gitidentity.Identity) {
+	if len(identities) == 0 {
+		fmt.Fprintf(w, "No git identities — run %s to add one.\n", hint.Cmd("mint git-identity set <name> --user <name> --email <email>"))
+		return
+	}
+
+	nameWidth, idWidth := len("NAME"), len("IDENTITY")
+	rows := make([][3]string, 0, len(identities))
+	for _, id := range identities {
+		who := fmt.Sprintf("%s <%s>", id.User, id.Email)
+		match := strings.Join(id.Match, ", ")
+		if id.IsDefault() {
+			match = "(default)"
+		}
+		rows = append(rows, [3]string{id.Name, who, match})
+		nameWidth = max(nameWidth, len(id.Name))
+		idWidth = max(idWidth, len(who))
+	}
+	fmt.Fprintf(w, "%-*s  %-*s  %s\n", nameWidth, "NAME", idWidth, "IDENTITY", "MATCH")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-*s  %-*s  %s\n", nameWidth, r[0], idWidth, r[1], r[2])
+	}
+}
+
+// buildGitIdentityCheckCommand returns the remote command that prints the
+// user.email git will use in projectPath and the file that sets it.
+func buildGitIdentityCheckCommand(projectPath string) []string {
+	return []string{"git", "-C", projectPath, "config", "--show-origin", "user.email"}
+}
+
+// reportGitIdentity tells the user which identity commits in the freshly
+// cloned projectPath will use, and warns when no identity matched its
+// remote. Failures to check are reported, never returned: the clone itself
+// succeeded.
+func reportGitIdentity(w io.Writer, run gitIdentityRunner, projectPath string) {
+	out, err := run(buildGitIdentityCheckCommand(projectPath))
+	origin, email, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	setHint := hint.Cmd("mint git-identity set <name> --user <name> --email <email> --match <pattern>")
+
+	identityDir := "/" + gitidentity.ConfigDir + "/" + gitidentity.IdentityDir + "/"
+	switch {
+	case err != nil || email == "":
+		fmt.Fprintf(w, "Warning: no git identity matches this repository and user.email is not set. Run %s before committing.\n", setHint)
+	case strings.Contains(origin, identityDir):
+		name := strings.TrimSuffix(origin[strings.LastIndex(origin, "/")+1:], ".conf")
+		fmt.Fprintf(w, "Git identity: %s <%s>\n", name, email)
+	case strings.HasSuffix(origin, "/"+gitidentity.ConfigDir+"/"+gitidentity.IndexFile):
+		fmt.Fprintf(w, "Warning: no git identity matches this repository; commits will use the default identity <%s>.\n", email)
+	default:
+		fmt.Fprintf(w, "Warning: no git identity matches this repository; commits will use the fallback user.email <%s> from %s. Run %s to map it.\n",
+			email, strings.TrimPrefix(origin, "file:"), setHint)
+	}
+}
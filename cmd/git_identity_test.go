@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/gitidentity"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// gitIdentityMockRemote serves index as the VM's identity index and records
+// every command it is asked to run.
+type gitIdentityMockRemote struct {
+	index    []byte
+	calls    [][]string
+	writeErr error
+}
+
+func (m *gitIdentityMockRemote) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	m.calls = append(m.calls, command)
+	if strings.HasPrefix(command[0], "cat ") {
+		return m.index, nil
+	}
+	return nil, m.writeErr
+}
+
+// writeScript returns the unquoted script of the last write command.
+func (m *gitIdentityMockRemote) writeScript(t *testing.T) string {
+	t.Helper()
+	last := m.calls[len(m.calls)-1]
+	if len(last) != 3 || last[0] != "sh" || last[1] != "-c" {
+		t.Fatalf("last command = %v, want sh -c <script>", last)
+	}
+	return unquoteShell(last[2])
+}
+
+// unquoteShell reverses shellQuote.
+func unquoteShell(s string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(s, "'"), "'"), `'\''`, "'")
+}
+
+// scriptFiles decodes the files a write script creates, keyed by path.
+func scriptFiles(t *testing.T, script string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	for _, line := range strings.Split(script, "\n") {
+		var b64, tmp string
+		if _, err := fmt.Sscanf(line, "printf %%s %s | base64 -d > %s", &b64, &tmp); err != nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			t.Fatalf("bad base64 in %q: %v", line, err)
+		}
+		files[strings.TrimSuffix(tmp, ".tmp")] = string(data)
+	}
+	return files
+}
+
+func runGitIdentity(t *testing.T, remote *gitIdentityMockRemote, args ...string) (string, error) {
+	t.Helper()
+	deps := &gitIdentityDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:    "alice",
+		remote:   remote.run,
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newGitIdentityCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"git-identity"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestBuildGitIdentityWriteCommand(t *testing.T) {
+	identities := []gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "personal", User: "Jane O'Doe", Email: "jane@example.com"},
+	}
+	command := buildGitIdentityWriteCommand(identities, []string{"mint-identities.d/old.conf"})
+	if len(command) != 3 || command[0] != "sh" || command[1] != "-c" {
+		t.Fatalf("command = %v, want sh -c <script>", command)
+	}
+	script := unquoteShell(command[2])
+
+	wantLines := []string{
+		"set -e",
+		"mkdir -p ~/.config/git/mint-identities.d",
+		"mv -f ~/.config/git/mint-identities.d/work.conf.tmp ~/.config/git/mint-identities.d/work.conf",
+		"mv -f ~/.config/git/mint-identities.conf.tmp ~/.config/git/mint-identities.conf",
+		"rm -f ~/.config/git/mint-identities.d/old.conf",
+		"git config --global --get-all include.path | grep -qxF '~/.config/git/mint-identities.conf' || " +
+			"git config --global --add include.path '~/.config/git/mint-identities.conf'",
+	}
+	lines := strings.Split(strings.TrimSpace(script), "\n")
+	pos := 0
+	for _, want := range wantLines {
+		for pos < len(lines) && lines[pos] != want {
+			pos++
+		}
+		if pos == len(lines) {
+			t.Fatalf("script missing %q in order:\n%s", want, script)
+		}
+	}
+
+	files := scriptFiles(t, script)
+	if got, want := files["~/.config/git/mint-identities.conf"], string(gitidentity.RenderIndex(identities)); got != want {
+		t.Errorf("index =\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := files["~/.config/git/mint-identities.d/work.conf"], string(gitidentity.RenderIdentity(identities[0])); got != want {
+		t.Errorf("work.conf =\n%s\nwant:\n%s", got, want)
+	}
+	if _, ok := files["~/.config/git/mint-identities.d/personal.conf"]; ok {
+		t.Error("the default identity should not get its own file")
+	}
+}
+
+// TestGitIdentityWriteScriptAppliesIdentity runs the write script against a
+// scratch home directory and checks the identity git picks for repositories
+// with matching and non-matching remotes.
+func TestGitIdentityWriteScriptAppliesIdentity(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	env := append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME=", "GIT_CONFIG_NOSYSTEM=1")
+	sh := func(dir, script string) string {
+		t.Helper()
+		c := exec.Command("sh", "-c", script)
+		c.Dir = dir
+		c.Env = env
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", script, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	identities := []gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "personal", User: "Jane Doe", Email: "jane@example.com"},
+	}
+	script := unquoteShell(buildGitIdentityWriteCommand(identities, nil)[2])
+	// Running twice must not add the include a second time.
+	sh(home, script)
+	sh(home, script)
+	if got := sh(home, "git config --global --get-all include.path"); got != gitidentity.IncludePath {
+		t.Errorf("include.path = %q, want a single %q", got, gitidentity.IncludePath)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(home, ".config/git/*.tmp")); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"git@github.com:acme/api.git", "jane@acme.com"},
+		{"https://github.com/acme/api.git", "jane@acme.com"},
+		{"ssh://git@github.com/acme/api.git", "jane@acme.com"},
+		{"https://github.com/other/api.git", "jane@example.com"},
+	}
+	for _, tt := range tests {
+		repo := t.TempDir()
+		sh(repo, "git init -q . && git remote add origin "+tt.remote)
+		if got := sh(repo, "git config user.email"); got != tt.want {
+			t.Errorf("remote %s: user.email = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestGitIdentitySet(t *testing.T) {
+	remote := &gitIdentityMockRemote{}
+	out, err := runGitIdentity(t, remote, "set", "work", "--user", "Jane Doe", "--email", "jane@acme.com",
+		"--match", "github.com/acme/*", "--match", "gitlab.com/acme/**")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Added git identity "work" on VM "default"`) {
+		t.Errorf("output = %q", out)
+	}
+	if len(remote.calls) != 2 {
+		t.Fatalf("expected read + write, got %d calls", len(remote.calls))
+	}
+	index := scriptFiles(t, remote.writeScript(t))["~/.config/git/mint-identities.conf"]
+	for _, want := range []string{
+		`[includeIf "hasconfig:remote.*.url:git@github.com:acme/*"]`,
+		`[includeIf "hasconfig:remote.*.url:https://gitlab.com/acme/**"]`,
+		"\tpath = mint-identities.d/work.conf\n",
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index missing %q:\n%s", want, index)
+		}
+	}
+}
+
+func TestGitIdentitySetReplacesExisting(t *testing.T) {
+	remote := &gitIdentityMockRemote{index: gitidentity.RenderIndex([]gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "old@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "oss", User: "Jane Doe", Email: "jane@example.org", Match: []string{"github.com/oss/*"}},
+	})}
+	// Dropping --match turns work into the default identity.
+	out, err := runGitIdentity(t, remote, "set", "work", "--user", "Jane Doe", "--email", "jane@acme.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Updated git identity "work"`) {
+		t.Errorf("output = %q", out)
+	}
+	script := remote.writeScript(t)
+	got, err := gitidentity.ParseIndex([]byte(scriptFiles(t, script)["~/.config/git/mint-identities.conf"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Name != "work" || got[1].Email != "jane@acme.com" || !got[1].IsDefault() {
+		t.Errorf("identities after set = %+v", got)
+	}
+	if !strings.Contains(script, "rm -f ~/.config/git/mint-identities.d/work.conf") {
+		t.Errorf("work's file should be removed now that it is the default:\n%s", script)
+	}
+}
+
+func TestGitIdentitySetRejectsSecondDefault(t *testing.T) {
+	remote := &gitIdentityMockRemote{index: gitidentity.RenderIndex([]gitidentity.Identity{
+		{Name: "personal", User: "Jane Doe", Email: "jane@example.com"},
+	})}
+	_, err := runGitIdentity(t, remote, "set", "work", "--user", "Jane Doe", "--email", "jane@acme.com")
+	if err == nil || !strings.Contains(err.Error(), `identity "personal" is already the default`) {
+		t.Fatalf("error = %v, want a second-default error", err)
+	}
+	if len(remote.calls) != 1 {
+		t.Errorf("nothing should be written, got %d calls", len(remote.calls))
+	}
+}
+
+func TestGitIdentitySetValidatesBeforeConnecting(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"bad pattern", []string{"--match", "github.com/acme/[x"}, "unmatched '['"},
+		{"pattern with space", []string{"--match", "github.com/a b"}, "only URL and glob characters"},
+		{"bad email", []string{"--email", "jane"}, "invalid email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &gitIdentityMockRemote{}
+			args := append([]string{"set", "work", "--user", "Jane Doe", "--email", "jane@acme.com"}, tt.args...)
+			_, err := runGitIdentity(t, remote, args...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if len(remote.calls) != 0 {
+				t.Errorf("remote called %d times for invalid input", len(remote.calls))
+			}
+		})
+	}
+}
+
+func TestGitIdentityList(t *testing.T) {
+	hint.IsTTY = false
+	identities := []gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "personal", User: "Jane Doe", Email: "jane@example.com"},
+	}
+
+	out, err := runGitIdentity(t, &gitIdentityMockRemote{index: gitidentity.RenderIndex(identities)}, "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "NAME      IDENTITY                     MATCH\n" +
+		"personal  Jane Doe <jane@example.com>  (default)\n" +
+		"work      Jane Doe <jane@acme.com>     github.com/acme/*\n"
+	if out != want {
+		t.Errorf("list output =\n%s\nwant:\n%s", out, want)
+	}
+
+	out, err = runGitIdentity(t, &gitIdentityMockRemote{}, "list")
+	if err != nil || !strings.Contains(out, "No git identities") {
+		t.Errorf("empty list = %q, %v", out, err)
+	}
+}
+
+func TestGitIdentityListJSON(t *testing.T) {
+	remote := &gitIdentityMockRemote{index: gitidentity.RenderIndex([]gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+	})}
+	out, err := runGitIdentity(t, remote, "list", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []gitidentity.Identity
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if len(got) != 1 || got[0].Name != "work" || got[0].Match[0] != "github.com/acme/*" {
+		t.Errorf("JSON = %+v", got)
+	}
+}
+
+func TestGitIdentityRemove(t *testing.T) {
+	remote := &gitIdentityMockRemote{index: gitidentity.RenderIndex([]gitidentity.Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "personal", User: "Jane Doe", Email: "jane@example.com"},
+	})}
+	out, err := runGitIdentity(t, remote, "remove", "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Removed git identity "work" from VM "default"`) {
+		t.Errorf("output = %q", out)
+	}
+	script := remote.writeScript(t)
+	index := scriptFiles(t, script)["~/.config/git/mint-identities.conf"]
+	if strings.Contains(index, "work") {
+		t.Errorf("index still mentions work:\n%s", index)
+	}
+	indexAt := strings.Index(script, "mv -f ~/.config/git/mint-identities.conf.tmp")
+	rmAt := strings.Index(script, "rm -f ~/.config/git/mint-identities.d/work.conf")
+	if indexAt < 0 || rmAt < indexAt {
+		t.Errorf("work.conf must be removed after the index stops including it:\n%s", script)
+	}
+}
+
+func TestGitIdentityRemoveUnknown(t *testing.T) {
+	hint.IsTTY = false
+	remote := &gitIdentityMockRemote{}
+	_, err := runGitIdentity(t, remote, "remove", "work")
+	if err == nil || !strings.Contains(err.Error(), `no git identity "work" on VM "default"`) {
+		t.Fatalf("error = %v", err)
+	}
+	if len(remote.calls) != 1 {
+		t.Errorf("nothing should be written, got %d calls", len(remote.calls))
+	}
+}
+
+func TestGitIdentityRequiresRunningVM(t *testing.T) {
+	hint.IsTTY = false
+	deps := &gitIdentityDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		owner:    "alice",
+		remote:   (&gitIdentityMockRemote{}).run,
+	}
+	root := cmdtest.NewRoot(newGitIdentityCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"git-identity", "list"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "no VM \"default\" found") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestProjectAddReportsGitIdentity(t *testing.T) {
+	hint.IsTTY = false
+	notFound := fmt.Errorf("exit status 1")
+	tests := []struct {
+		name     string
+		output   string
+		err      error
+		want     string
+		wantWarn bool
+	}{
+		{
+			name:   "matching identity",
+			output: "file:/home/ubuntu/.config/git/mint-identities.d/work.conf\tjane@acme.com\n",
+			want:   "Git identity: work <jane@acme.com>",
+		},
+		{
+			name:     "default identity",
+			output:   "file:/home/ubuntu/.config/git/mint-identities.conf\tjane@example.com\n",
+			want:     "no git identity matches this repository; commits will use the default identity <jane@example.com>",
+			wantWarn: true,
+		},
+		{
+			name:     "fallback from gitconfig",
+			output:   "file:/home/ubuntu/.gitconfig\tubuntu@example.com\n",
+			want:     "commits will use the fallback user.email <ubuntu@example.com> from /home/ubuntu/.gitconfig",
+			wantWarn: true,
+		},
+		{
+			name:     "no user.email",
+			err:      notFound,
+			want:     "no git identity matches this repository and user.email is not set",
+			wantWarn: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// test -d (missing), devcontainer check (none), record
+			// mint.devcontainer, tmux session, identity check
+			remote := &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, nil, nil, []byte(tt.output)},
+				Errs:    []error{notFound, notFound, nil, nil, tt.err},
+			}
+			out, err := runProjectAddSubdir(t, remote, &projectMockStreamingRemote{}, "https://github.com/acme/api.git")
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out)
+			}
+			last := remote.LastCall().Command
+			if got := strings.Join(last, " "); got != "git -C /mint/projects/api config --show-origin user.email" {
+				t.Errorf("identity check = %q", got)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+			if got := strings.Contains(out, "Warning:"); got != tt.wantWarn {
+				t.Errorf("warning printed = %v, want %v:\n%s", got, tt.wantWarn, out)
+			}
+		})
+	}
+}
+
+func TestProjectAddSkipsGitIdentityReportForExistingClone(t *testing.T) {
+	// test -d (exists), devcontainer check (none): already set up.
+	remote := &cmdtest.RemoteRunner{Errs: []error{nil, fmt.Errorf("exit status 1")}}
+	out, err := runProjectAddSubdir(t, remote, &projectMockStreamingRemote{}, "https://github.com/acme/api.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range remote.Calls() {
+		if strings.Contains(strings.Join(c.Command, " "), "user.email") {
+			t.Errorf("identity checked for an existing clone:\n%s", out)
+		}
+	}
+}
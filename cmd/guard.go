@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// guardDeps holds the injectable dependencies for the guard commands.
+type guardDeps struct {
+	describe mintaws.DescribeInstancesAPI
+	sendKey  mintaws.SendSSHPublicKeyAPI
+	owner    string
+	remote   RemoteCommandRunner
+	sshPort  int              // ssh_port from config; zero means defaultSSHPort
+	now      func() time.Time // nil uses time.Now
+}
+
+// guardInfo is a guard as shown by guard list.
+type guardInfo struct {
+	session.Guard
+	Expired bool `json:"expired"`
+}
+
+// newGuardCommand creates the production guard command tree.
+func newGuardCommand() *cobra.Command {
+	return newGuardCommandWithDeps(nil)
+}
+
+// newGuardCommandWithDeps creates the guard command tree with explicit
+// dependencies for testing.
+func newGuardCommandWithDeps(deps *guardDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guard",
+		Short: "Protect unattended jobs on the VM from recreate, down, and destroy",
+		Long: "Manage guard files that keep mint recreate, mint down, and mint destroy " +
+			"from running while an unattended job, such as a nightly build started by " +
+			"cron or systemd, is using the VM. Those jobs have no tmux client or SSH " +
+			"connection for session detection to see.\n\n" +
+			"A guard is a JSON file " + session.GuardDir + "/<name>.json with owner, reason, " +
+			"and expires_at (RFC 3339) fields. Jobs on the VM can write and remove these " +
+			"files themselves; these commands do the same over SSH. Expired guards are " +
+			"ignored, and --force on the guarded command overrides the rest.",
+	}
+
+	cmd.AddCommand(newGuardSetCommandWithDeps(deps))
+	cmd.AddCommand(newGuardListCommandWithDeps(deps))
+	cmd.AddCommand(newGuardClearCommandWithDeps(deps))
+
+	return cmd
+}
+
+// guardDepsFor returns deps, or the production dependencies when deps is nil.
+func guardDepsFor(cmd *cobra.Command, deps *guardDeps) (*guardDeps, error) {
+	if deps != nil {
+		return deps, nil
+	}
+	clients := awsClientsFromContext(cmd.Context())
+	if clients == nil {
+		return nil, fmt.Errorf("AWS clients not configured")
+	}
+	return &guardDeps{
+		describe: clients.ec2Client,
+		sendKey:  clients.sendKey,
+		owner:    clients.owner,
+		remote:   clients.remoteRunner(),
+		sshPort:  clients.sshOptions.Port,
+	}, nil
+}
+
+// newGuardSetCommandWithDeps creates the guard set subcommand.
+func newGuardSetCommandWithDeps(deps *guardDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "set <name>",
+		Short:       "Create or replace a guard",
+		Long:        "Write a guard file on the VM that blocks recreate, down, and destroy until it expires or is cleared.",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := guardDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runGuardSet(cmd, d, args[0])
+		},
+	}
+
+	cmd.Flags().String("reason", "", "Why the VM is guarded, shown to anyone who is blocked (required)")
+	cmd.Flags().String("expires", "", "How long the guard lasts, such as 6h or 1d (required)")
+
+	return cmd
+}
+
+// newGuardListCommandWithDeps creates the guard list subcommand.
+func newGuardListCommandWithDeps(deps *guardDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the guards on the VM",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := guardDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runGuardList(cmd, d)
+		},
+	}
+}
+
+// newGuardClearCommandWithDeps creates the guard clear subcommand.
+func newGuardClearCommandWithDeps(deps *guardDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:         "clear <name>",
+		Short:       "Remove a guard",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Args:        cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := guardDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runGuardClear(cmd, d, args[0])
+		},
+	}
+}
+
+// validateGuardName applies the project name rules, which keep names safe
+// to interpolate into remote commands.
+func validateGuardName(name string) error {
+	if !projectNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid guard name %q: must start with a letter or digit and contain only letters, digits, dots, hyphens, and underscores", name)
+	}
+	return nil
+}
+
+// parseGuardExpiry parses the --expires duration.
+func parseGuardExpiry(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("--expires is required, e.g. %s", hint.Cmd("--expires 6h"))
+	}
+	d, err := format.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --expires %q: use a duration such as 90m, 6h, or 1d", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid --expires %q: must be longer than zero", s)
+	}
+	return d, nil
+}
+
+// runningGuardVM discovers the VM the guard commands act on, which must be
+// running for SSH.
+func runningGuardVM(ctx context.Context, cmd *cobra.Command, deps *guardDeps) (*vm.VM, string, error) {
+	vmName := "default"
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return nil, "", fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return nil, "", fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil, "", fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+	return found, vmName, nil
+}
+
+func runGuardSet(cmd *cobra.Command, deps *guardDeps, name string) error {
+	if err := validateGuardName(name); err != nil {
+		return err
+	}
+	reason, _ := cmd.Flags().GetString("reason")
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("--reason is required, e.g. %s", hint.Cmd(`--reason "nightly build"`))
+	}
+	expiresFlag, _ := cmd.Flags().GetString("expires")
+	ttl, err := parseGuardExpiry(expiresFlag)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningGuardVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now
+	if deps.now != nil {
+		now = deps.now
+	}
+	g := session.Guard{Name: name, Owner: deps.owner, Reason: reason, ExpiresAt: now().Add(ttl).UTC().Truncate(time.Second)}
+	if _, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildGuardWriteCommand(g)); err != nil {
+		return fmt.Errorf("writing guard %q: %w", name, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Guard %q set on VM %q until %s. Clear it early with %s.\n",
+		name, vmName, g.ExpiresAt.Local().Format(time.RFC3339), hint.Cmd("mint guard clear "+name))
+	return nil
+}
+
+// buildGuardWriteCommand writes g to its file, creating the guard
+// directory on VMs bootstrapped before it existed. The file is written
+// under a temporary name and renamed so readers never see half of it.
+func buildGuardWriteCommand(g session.Guard) []string {
+	data, _ := json.Marshal(g)
+	path := session.GuardDir + "/" + g.Name + ".json"
+	script := fmt.Sprintf("[ -d %[1]s ] || sudo install -d -o %[2]s -g %[2]s %[1]s; printf '%%s\\n' %[3]s > %[4]s.tmp && mv %[4]s.tmp %[4]s",
+		session.GuardDir, defaultSSHUser, shellQuote(string(data)), path)
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+func runGuardList(cmd *cobra.Command, deps *guardDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningGuardVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+
+	out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, session.GuardReadCommand())
+	if err != nil {
+		return fmt.Errorf("reading guards: %w", err)
+	}
+	guards, unreadable := session.ParseGuards(out)
+	now := time.Now()
+	if deps.now != nil {
+		now = deps.now()
+	}
+	infos := make([]guardInfo, 0, len(guards))
+	for _, g := range guards {
+		infos = append(infos, guardInfo{Guard: g, Expired: g.Expired(now)})
+	}
+
+	w := cmd.OutOrStdout()
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+	writeGuardListHuman(w, vmName, infos, now)
+	for _, name := range unreadable {
+		fmt.Fprintf(w, "Note: guard %q could not be read and is ignored.\n", name)
+	}
+	return nil
+}
+
+// writeGuardListHuman outputs guards as a table.
+func writeGuardListHuman(w io.Writer, vmName string, guards []guardInfo, now time.Time) {
+	if len(guards) == 0 {
+		fmt.Fprintf(w, "No guards on VM %q.\n", vmName)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tOWNER\tEXPIRES\tREASON")
+	for _, g := range guards {
+		expires := "in " + format.FormatDuration(g.ExpiresAt.Sub(now))
+		if g.Expired {
+			expires = "expired (ignored)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", g.Name, g.Owner, expires, g.Reason)
+	}
+	tw.Flush()
+}
+
+func runGuardClear(cmd *cobra.Command, deps *guardDeps, name string) error {
+	if err := validateGuardName(name); err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningGuardVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+
+	out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildGuardClearCommand(name))
+	if err != nil {
+		return fmt.Errorf("clearing guard %q: %w", name, err)
+	}
+	if strings.TrimSpace(string(out)) == "missing" {
+		return fmt.Errorf("no guard %q on VM %q — see %s", name, vmName, hint.Cmd("mint guard list"))
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Guard %q cleared on VM %q.\n", name, vmName)
+	return nil
+}
+
+// buildGuardClearCommand removes a guard file, printing "missing" when
+// there is none.
+func buildGuardClearCommand(name string) []string {
+	path := session.GuardDir + "/" + name + ".json"
+	script := fmt.Sprintf("if [ -e %[1]s ]; then rm -f %[1]s; else echo missing; fi", path)
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// checkGuards refuses to continue while found has active automation
+// guards, unless force is set, in which case it warns. Expired and
+// unreadable guards are noted and ignored. A VM that is not running, or
+// has no remote runner, has no guards to check; a failure to read them is
+// a warning, so a flaky connection does not block the command.
+func checkGuards(ctx context.Context, w io.Writer, remote RemoteCommandRunner, sendKey mintaws.SendSSHPublicKeyAPI,
+	found *vm.VM, port int, vmName string, force bool) error {
+	if remote == nil || found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil
+	}
+	executor := func(ctx context.Context, command []string) ([]byte, error) {
+		return remote(ctx, sendKey, found.ID, found.AvailabilityZone, found.PublicIP, port, defaultSSHUser, command)
+	}
+	guards, notes, err := session.DetectGuards(ctx, executor)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not check automation guards: %v\n", err)
+		return nil
+	}
+	for _, note := range notes {
+		fmt.Fprintf(w, "Note: %s\n", note)
+	}
+	if len(guards) == 0 {
+		return nil
+	}
+	summary := session.GuardSummary(guards)
+	if !force {
+		return fmt.Errorf("active automation guards on VM %q:\n\n%s\n\nUse %s to proceed anyway", vmName, summary, hint.Cmd("--force"))
+	}
+	fmt.Fprintf(w, "Warning: proceeding despite active automation guards on VM %q:\n%s\n\n", vmName, summary)
+	return nil
+}
+
+// detectVMSessions runs the ADR-0018 session checks and reads the
+// automation guards on found, a running VM, over remote.
+func detectVMSessions(ctx context.Context, remote RemoteCommandRunner, sendKey mintaws.SendSSHPublicKeyAPI,
+	found *vm.VM, port int) (*session.ActiveSessions, error) {
+	executor := func(ctx context.Context, command []string) ([]byte, error) {
+		return remote(ctx, sendKey, found.ID, found.AvailabilityZone, found.PublicIP, port, defaultSSHUser, command)
+	}
+	return session.DetectActiveSessions(ctx, executor)
+}
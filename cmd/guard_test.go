@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
+)
+
+// guardRemoteRunner answers the guard and session commands: reads return
+// guards, and writes and removals are recorded.
+type guardRemoteRunner struct {
+	guards  string // GuardReadCommand output
+	readErr error
+	clear   string // output of the clear command
+	tmux    string // tmux list-clients output
+	connErr error  // fails the session checks, like an unreachable VM
+	calls   []string
+}
+
+func (r *guardRemoteRunner) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	joined := strings.Join(command, " ")
+	r.calls = append(r.calls, joined)
+	switch {
+	case joined == strings.Join(session.GuardReadCommand(), " "):
+		return []byte(r.guards), r.readErr
+	case strings.Contains(joined, "rm -f"):
+		return []byte(r.clear), nil
+	case strings.Contains(joined, ".tmp"):
+		return nil, nil
+	case command[0] == "tmux":
+		return []byte(r.tmux), r.connErr
+	case command[0] == "who":
+		return nil, r.connErr
+	case command[0] == "docker", command[0] == "cat":
+		return nil, errors.New("not found")
+	}
+	return nil, errors.New("unexpected command: " + joined)
+}
+
+// guardFile renders one guard in the GuardReadCommand output format.
+func guardFile(name, reason string, expires time.Time) string {
+	return fmt.Sprintf("== %s\n{\"owner\":\"ci\",\"reason\":%q,\"expires_at\":%q}\n", name, reason, expires.UTC().Format(time.RFC3339))
+}
+
+func runGuardCmd(t *testing.T, runner *guardRemoteRunner, args ...string) (string, error) {
+	t.Helper()
+	deps := &guardDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		remote:   runner.run,
+		now:      func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) },
+	}
+	root := cmdtest.NewRoot(newGuardCommandWithDeps(deps))
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"guard"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestGuardSetWritesGuardFile(t *testing.T) {
+	hint.IsTTY = false
+	runner := &guardRemoteRunner{}
+	out, err := runGuardCmd(t, runner, "set", "nightly", "--reason", "nightly build", "--expires", "6h")
+	if err != nil {
+		t.Fatalf("guard set: %v\n%s", err, out)
+	}
+	if len(runner.calls) != 1 {
+		t.Fatalf("calls = %q, want one write", runner.calls)
+	}
+	call := runner.calls[0]
+	for _, want := range []string{
+		session.GuardDir + "/nightly.json.tmp",
+		`"owner":"alice"`,
+		`"reason":"nightly build"`,
+		`"expires_at":"2026-10-15T18:00:00Z"`,
+	} {
+		if !strings.Contains(call, want) {
+			t.Errorf("write command missing %q:\n%s", want, call)
+		}
+	}
+	if !strings.Contains(out, `Guard "nightly" set on VM "default"`) || !strings.Contains(out, "`mint guard clear nightly`") {
+		t.Errorf("output = %s", out)
+	}
+}
+
+func TestGuardSetValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"bad name", []string{"set", "../x", "--reason", "r", "--expires", "1h"}, "invalid guard name"},
+		{"no reason", []string{"set", "nightly", "--expires", "1h"}, "--reason is required"},
+		{"no expiry", []string{"set", "nightly", "--reason", "r"}, "--expires is required"},
+		{"bad expiry", []string{"set", "nightly", "--reason", "r", "--expires", "soon"}, "invalid --expires"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &guardRemoteRunner{}
+			_, err := runGuardCmd(t, runner, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if len(runner.calls) != 0 {
+				t.Errorf("invalid set reached the VM: %q", runner.calls)
+			}
+		})
+	}
+}
+
+func TestGuardList(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	runner := &guardRemoteRunner{guards: guardFile("nightly", "nightly build", now.Add(6*time.Hour)) +
+		guardFile("old", "yesterday", now.Add(-time.Hour)) + "== broken\n"}
+
+	out, err := runGuardCmd(t, runner, "list")
+	if err != nil {
+		t.Fatalf("guard list: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"nightly  ci     in 6h              nightly build",
+		"old      ci     expired (ignored)  yesterday",
+		`Note: guard "broken" could not be read and is ignored.`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = runGuardCmd(t, runner, "list", "--json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var guards []guardInfo
+	if err := json.Unmarshal([]byte(out), &guards); err != nil {
+		t.Fatalf("decoding %s: %v", out, err)
+	}
+	if len(guards) != 2 || guards[0].Name != "nightly" || guards[0].Expired || !guards[1].Expired {
+		t.Errorf("guards = %+v", guards)
+	}
+}
+
+func TestGuardClear(t *testing.T) {
+	runner := &guardRemoteRunner{}
+	out, err := runGuardCmd(t, runner, "clear", "nightly")
+	if err != nil {
+		t.Fatalf("guard clear: %v\n%s", err, out)
+	}
+	if !strings.Contains(runner.calls[0], session.GuardDir+"/nightly.json") {
+		t.Errorf("clear command = %s", runner.calls[0])
+	}
+
+	_, err = runGuardCmd(t, &guardRemoteRunner{clear: "missing\n"}, "clear", "nightly")
+	if err == nil || !strings.Contains(err.Error(), `no guard "nightly"`) {
+		t.Errorf("error = %v, want no guard", err)
+	}
+}
+
+func TestDownBlockedByGuards(t *testing.T) {
+	hint.IsTTY = false
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		runner   *guardRemoteRunner
+		args     []string
+		wantErr  string
+		wantOut  []string
+		wantStop bool
+	}{
+		{
+			name:    "active guard blocks",
+			runner:  &guardRemoteRunner{guards: guardFile("nightly", "nightly build", future)},
+			wantErr: `active sessions detected on VM "default"`,
+		},
+		{
+			name:    "attached tmux client blocks",
+			runner:  &guardRemoteRunner{tmux: "/dev/pts/0 main"},
+			wantErr: "/dev/pts/0 main",
+		},
+		{
+			name:     "force overrides with warning",
+			runner:   &guardRemoteRunner{guards: guardFile("nightly", "nightly build", future)},
+			args:     []string{"--force"},
+			wantOut:  []string{`Warning: proceeding despite active sessions on VM "default"`, `nightly: "nightly build" (owner ci`},
+			wantStop: true,
+		},
+		{
+			name:     "expired guard ignored with note",
+			runner:   &guardRemoteRunner{guards: guardFile("old", "yesterday", past)},
+			wantOut:  []string{`Note: guard "old" expired at`, "stopped"},
+			wantStop: true,
+		},
+		{
+			name:     "unreadable guard tolerated",
+			runner:   &guardRemoteRunner{guards: "== broken\nnot json\n"},
+			wantOut:  []string{`Note: guard "broken" could not be read — ignored`, "stopped"},
+			wantStop: true,
+		},
+		{
+			name:     "connection failure warns",
+			runner:   &guardRemoteRunner{connErr: errors.New("connection refused")},
+			wantOut:  []string{"Warning: could not detect active sessions", "stopped"},
+			wantStop: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+			deps := &downDeps{
+				describe: &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")},
+				stop:     stop,
+				owner:    "alice",
+				sendKey:  &cmdtest.SendSSHPublicKey{},
+				remote:   tt.runner.run,
+			}
+			root := cmdtest.NewRoot(newDownCommandWithDeps(deps))
+			buf := new(bytes.Buffer)
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"down"}, tt.args...))
+			err := root.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "`--force`") {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("down: %v\n%s", err, buf.String())
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+			if stop.Called != tt.wantStop {
+				t.Errorf("stop called = %v, want %v", stop.Called, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestDestroyBlockedByGuard(t *testing.T) {
+	deps := newHappyDestroyDeps("alice")
+	terminate := &cmdtest.TerminateInstances{Output: &ec2.TerminateInstancesOutput{}}
+	deps.terminate = terminate
+	deps.sendKey = &cmdtest.SendSSHPublicKey{}
+	deps.remote = (&guardRemoteRunner{guards: guardFile("nightly", "nightly build", time.Now().Add(time.Hour))}).run
+
+	root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"destroy", "--yes"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "active automation guards") {
+		t.Fatalf("error = %v, want active automation guards", err)
+	}
+	if terminate.Called {
+		t.Error("guarded VM was terminated")
+	}
+}
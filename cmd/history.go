@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// historyDeps holds the injectable dependencies for the history command.
+type historyDeps struct {
+	path string
+	now  func() time.Time
+}
+
+// newHistoryCommand creates the production history command.
+func newHistoryCommand() *cobra.Command {
+	return newHistoryCommandWithDeps(nil)
+}
+
+// newHistoryCommandWithDeps creates the history command with explicit
+// dependencies for testing.
+func newHistoryCommandWithDeps(deps *historyDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recent mint commands",
+		Long: "Show the local audit log of mint commands: when each ran, which flags were set, " +
+			"the target VM, how long it took, how it exited, and the AWS resources it changed.\n\n" +
+			"The log is stored at ~/.local/state/mint/history.ndjson (or $XDG_STATE_HOME/mint). " +
+			"Disable recording with: mint config set history_enabled false",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps == nil {
+				deps = &historyDeps{path: history.DefaultPath(), now: time.Now}
+			}
+			return runHistory(cmd, deps)
+		},
+	}
+
+	cmd.Flags().String("since", "", "Only show commands newer than this age (e.g. 7d, 12h, 30m)")
+
+	return cmd
+}
+
+// runHistory executes the history command logic.
+func runHistory(cmd *cobra.Command, deps *historyDeps) error {
+	var since time.Time
+	if s, _ := cmd.Flags().GetString("since"); s != "" {
+		age, err := format.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = deps.now().Add(-age)
+	}
+
+	// --vm defaults to "default" globally; only filter when it was given.
+	vmName := ""
+	if cmd.Flags().Changed("vm") {
+		vmName, _ = cmd.Flags().GetString("vm")
+	}
+
+	records, err := history.Read(deps.path)
+	if err != nil {
+		return err
+	}
+	records = history.Filter(records, vmName, since)
+
+	cliCtx := cli.FromCommand(cmd)
+	if cliCtx != nil && cliCtx.JSON {
+		if records == nil {
+			records = []history.Record{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	writeHistoryTable(cmd.OutOrStdout(), records)
+	return nil
+}
+
+// writeHistoryTable prints records as an aligned table, oldest first.
+func writeHistoryTable(w io.Writer, records []history.Record) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No history recorded.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tCOMMAND\tVM\tDURATION\tEXIT\tRESOURCES")
+
+	for _, rec := range records {
+		command := rec.Command
+		if len(rec.Flags) > 0 {
+			command += " " + strings.Join(rec.Flags, " ")
+		}
+
+		vmName := rec.VM
+		if vmName == "" {
+			vmName = "-"
+		}
+
+		resources := "-"
+		if len(rec.Resources) > 0 {
+			ids := make([]string, len(rec.Resources))
+			for i, r := range rec.Resources {
+				ids[i] = r.ID
+			}
+			resources = strings.Join(ids, ",")
+		}
+
+		duration := (time.Duration(rec.DurationMS) * time.Millisecond).Round(100 * time.Millisecond)
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			rec.Time.Local().Format("2006-01-02 15:04:05"),
+			command, vmName, duration, rec.Exit, resources)
+	}
+
+	tw.Flush()
+}
+
+// recordHistory appends one history record for the command that just ran.
+// It is called once, after Execute returns, and is best-effort: any failure
+// is ignored so the audit log can never change a command's outcome or
+// output. Only flag names are recorded — never flag values, positional
+// arguments, or environment — so secrets passed on the command line stay
+// out of the log.
+func recordHistory(executed *cobra.Command, start time.Time, runErr error) {
+	recordHistoryTo(history.DefaultPath(), executed, start, time.Now(), runErr)
+}
+
+// recordHistoryTo is recordHistory with an explicit log path and end time.
+func recordHistoryTo(path string, executed *cobra.Command, start, end time.Time, runErr error) {
+	if executed == nil || !historyRecordable(executed) {
+		return
+	}
+	if cfg, err := config.Load(config.DefaultConfigDir()); err == nil && !cfg.HistoryEnabled {
+		return
+	}
+
+	rec := history.Record{
+		Time:       start.UTC(),
+		Command:    strings.TrimPrefix(executed.CommandPath(), executed.Root().Name()+" "),
+		DurationMS: end.Sub(start).Milliseconds(),
+		Exit:       historyExitCategory(runErr),
+	}
+
+	executed.Flags().Visit(func(f *pflag.Flag) {
+		rec.Flags = append(rec.Flags, "--"+f.Name)
+	})
+
+	cliCtx := cli.FromCommand(executed)
+	if cliCtx != nil {
+		rec.VM = cliCtx.VM
+		rec.Resources = cliCtx.TouchedResources()
+	} else if vmName, err := executed.Flags().GetString("vm"); err == nil {
+		rec.VM = vmName
+	}
+
+	_ = history.Append(path, rec, history.MaxBytes)
+}
+
+// historyRecordable reports whether invocations of cmd belong in the log.
+// The root command itself, help, shell completion, and history are skipped.
+func historyRecordable(cmd *cobra.Command) bool {
+	if !cmd.HasParent() {
+		return false
+	}
+	path := cmd.CommandPath()
+	if strings.Contains(path, " completion") {
+		return false
+	}
+	switch cmd.Name() {
+	case "history", "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	}
+	return true
+}
+
+// historyExitCategory maps a command error to the recorded exit category.
+func historyExitCategory(err error) string {
+	if err == nil {
+		return history.ExitOK
+	}
+	if ExitCode(err) == exitCodeUserBootstrapFailed {
+		return history.ExitUserBootstrapFailed
+	}
+	return history.ExitError
+}
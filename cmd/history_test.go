@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+)
+
+func writeHistoryFixture(t *testing.T, path string, records ...history.Record) {
+	t.Helper()
+	for _, rec := range records {
+		if err := history.Append(path, rec, history.MaxBytes); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+func runHistoryCmd(t *testing.T, deps *historyDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newHistoryCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"history"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestHistoryCommandFilters(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	writeHistoryFixture(t, path,
+		history.Record{Time: now.Add(-10 * 24 * time.Hour), Command: "up", VM: "default", Exit: history.ExitOK,
+			Resources: []cli.Resource{{Kind: cli.ResourceInstance, ID: "i-old"}}},
+		history.Record{Time: now.Add(-2 * time.Hour), Command: "destroy", Flags: []string{"--yes", "--vm"}, VM: "staging", DurationMS: 4200, Exit: history.ExitOK,
+			Resources: []cli.Resource{{Kind: cli.ResourceInstance, ID: "i-staging"}}},
+		history.Record{Time: now.Add(-1 * time.Hour), Command: "list", VM: "default", DurationMS: 300, Exit: history.ExitError},
+	)
+	deps := &historyDeps{path: path, now: func() time.Time { return now }}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{"all", nil, []string{"i-old", "destroy --yes --vm", "list"}, nil},
+		{"since", []string{"--since", "7d"}, []string{"destroy", "list"}, []string{"i-old"}},
+		{"vm", []string{"--vm", "staging"}, []string{"i-staging", "4.2s"}, []string{"list", "i-old"}},
+		{"since hours", []string{"--since", "90m"}, []string{"list"}, []string{"destroy"}},
+		{"since compound", []string{"--since", "1h30m"}, []string{"list"}, []string{"destroy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runHistoryCmd(t, deps, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output missing %q:\n%s", w, out)
+				}
+			}
+			for _, nw := range tt.notWant {
+				if strings.Contains(out, nw) {
+					t.Errorf("output should not contain %q:\n%s", nw, out)
+				}
+			}
+		})
+	}
+}
+
+func TestHistoryCommandJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	deps := &historyDeps{path: path, now: time.Now}
+
+	out, err := runHistoryCmd(t, deps, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(out) != "[]" {
+		t.Errorf("empty history JSON = %q, want []", out)
+	}
+
+	writeHistoryFixture(t, path, history.Record{Time: time.Now(), Command: "down", VM: "default", Exit: history.ExitOK})
+	out, err = runHistoryCmd(t, deps, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(out), &records); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(records) != 1 || records[0]["command"] != "down" {
+		t.Errorf("records = %v", records)
+	}
+}
+
+func TestHistoryCommandRejectsBadSince(t *testing.T) {
+	deps := &historyDeps{path: filepath.Join(t.TempDir(), "h"), now: time.Now}
+	if _, err := runHistoryCmd(t, deps, "--since", "last week"); err == nil || !strings.Contains(err.Error(), "invalid --since") {
+		t.Errorf("error = %v, want invalid --since", err)
+	}
+}
+
+// historyTestRoot builds a root with a mutating command that touches
+// resources and a read-only --json command, and runs args through it.
+func historyTestRoot(t *testing.T, args ...string) (*cobra.Command, string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(&cobra.Command{
+		Use: "destroy",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := cli.FromCommand(cmd)
+			cliCtx.TouchResource(cli.ResourceInstance, "i-abc")
+			cliCtx.TouchResource(cli.ResourceVolume, "vol-abc")
+			return nil
+		},
+	})
+	root.AddCommand(&cobra.Command{
+		Use: "list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.OutOrStdout().Write([]byte("{\"vms\":[]}\n"))
+			return nil
+		},
+	})
+	root.AddCommand(newHistoryCommandWithDeps(&historyDeps{path: filepath.Join(t.TempDir(), "unused"), now: time.Now}))
+
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(args)
+	executed, err := root.ExecuteC()
+	return executed, buf.String(), err
+}
+
+func TestRecordHistoryRecordShape(t *testing.T) {
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+
+	executed, _, err := historyTestRoot(t, "destroy", "--vm", "staging", "--yes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	recordHistoryTo(path, executed, start, start.Add(1500*time.Millisecond), nil)
+
+	records, err := history.Read(path)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Read = %v, %v; want one record", records, err)
+	}
+	rec := records[0]
+	if rec.Command != "destroy" || rec.VM != "staging" || rec.DurationMS != 1500 || rec.Exit != history.ExitOK {
+		t.Errorf("record = %+v", rec)
+	}
+	if strings.Join(rec.Flags, " ") != "--vm --yes" {
+		t.Errorf("flags = %v, want names only", rec.Flags)
+	}
+	if len(rec.Resources) != 2 || rec.Resources[0].ID != "i-abc" || rec.Resources[1].ID != "vol-abc" {
+		t.Errorf("resources = %v", rec.Resources)
+	}
+}
+
+func TestRecordHistoryExitCategories(t *testing.T) {
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	executed, _, _ := historyTestRoot(t, "destroy")
+
+	now := time.Now()
+	recordHistoryTo(path, executed, now, now, silentExitError{})
+	recordHistoryTo(path, executed, now, now, exitCodeError{code: exitCodeUserBootstrapFailed})
+
+	records, _ := history.Read(path)
+	if len(records) != 2 || records[0].Exit != history.ExitError || records[1].Exit != history.ExitUserBootstrapFailed {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestRecordHistorySkipsHistoryAndOptOut(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", configDir)
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+
+	executed, _, _ := historyTestRoot(t, "history")
+	recordHistoryTo(path, executed, time.Now(), time.Now(), nil)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("mint history should not record itself")
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("history_enabled = false\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	executed, _, _ = historyTestRoot(t, "destroy")
+	recordHistoryTo(path, executed, time.Now(), time.Now(), nil)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("history written with history_enabled = false")
+	}
+}
+
+func TestRecordHistoryDoesNotPolluteJSONStdout(t *testing.T) {
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+
+	executed, out, err := historyTestRoot(t, "list", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	recordHistoryTo(path, executed, time.Now(), time.Now(), nil)
+
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(out), &obj); err != nil {
+		t.Fatalf("stdout is not a single JSON document: %v\n%s", err, out)
+	}
+	records, _ := history.Read(path)
+	if len(records) != 1 || records[0].Command != "list" || len(records[0].Resources) != 0 {
+		t.Errorf("records = %+v, want one read-only list record", records)
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+)
+
+// hostKeyDeps holds the injectable dependencies for the hostkey commands.
+type hostKeyDeps struct {
+	store *sshconfig.HostKeyStore
+}
+
+// hostKeyExportEntry is one VM's key in the document mint hostkey export
+// prints and mint hostkey import reads, keyed by VM name.
+type hostKeyExportEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	RecordedAt  string `json:"recorded_at,omitempty"`
+}
+
+// newHostKeyCommand creates the parent hostkey command with subcommands.
+func newHostKeyCommand() *cobra.Command {
+	return newHostKeyCommandWithDeps(nil)
+}
+
+// newHostKeyCommandWithDeps creates the hostkey command tree with explicit
+// dependencies for testing.
+func newHostKeyCommandWithDeps(deps *hostKeyDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hostkey",
+		Short: "Manage the recorded SSH host keys of VMs",
+		Long: "Manage the SSH host key fingerprints mint records the first time it connects " +
+			"to each VM (~/.config/mint/known_hosts). Export them on one machine and import " +
+			"them on another so connecting from a second laptop does not report a changed key.",
+	}
+
+	resolve := func() *hostKeyDeps {
+		if deps != nil {
+			return deps
+		}
+		return &hostKeyDeps{store: sshconfig.NewHostKeyStore(config.DefaultConfigDir())}
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export",
+		Short: "Print the recorded host keys as JSON",
+		Long: "Print every recorded host key as a JSON object mapping VM name to its " +
+			"fingerprint and the time it was recorded, for mint hostkey import on another machine.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHostKeyExport(cmd, resolve())
+		},
+	})
+
+	importCmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Merge host keys exported by mint hostkey export",
+		Long: "Merge a document written by mint hostkey export, from a file or - for stdin. " +
+			"Keys for VMs with no recorded key are added. A key that differs from the one " +
+			"already recorded refuses the whole import unless --force is set, in which case " +
+			"the imported key replaces it.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHostKeyImport(cmd, resolve(), args[0])
+		},
+	}
+	importCmd.Flags().Bool("force", false, "Replace recorded keys that differ from the imported ones")
+	cmd.AddCommand(importCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "reset <vm>",
+		Short: "Forget the recorded host key of a VM",
+		Long: "Remove the recorded host key of a VM, as mint recreate and mint destroy do, " +
+			"so the next connection records the key the VM presents. Use it after a VM was " +
+			"rebuilt outside mint and every connection reports HOST KEY CHANGED.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHostKeyReset(cmd, resolve(), args[0])
+		},
+	})
+
+	return cmd
+}
+
+func runHostKeyExport(cmd *cobra.Command, deps *hostKeyDeps) error {
+	keys, err := deps.store.ListKeys()
+	if err != nil {
+		return err
+	}
+
+	doc := make(map[string]hostKeyExportEntry, len(keys))
+	for _, k := range keys {
+		entry := hostKeyExportEntry{Fingerprint: k.Fingerprint}
+		if !k.RecordedAt.IsZero() {
+			entry.RecordedAt = k.RecordedAt.UTC().Format(time.RFC3339)
+		}
+		doc[k.VM] = entry
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func runHostKeyImport(cmd *cobra.Command, deps *hostKeyDeps, arg string) error {
+	force, _ := cmd.Flags().GetBool("force")
+
+	var data []byte
+	var err error
+	if arg == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(arg)
+	}
+	if err != nil {
+		return fmt.Errorf("reading host keys: %w", err)
+	}
+	keys, err := parseHostKeyExport(data)
+	if err != nil {
+		return err
+	}
+
+	result, err := deps.store.ImportKeys(keys, force)
+	if err != nil {
+		return err
+	}
+	if len(result.Conflicts) > 0 && !force {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%d imported host keys differ from the recorded ones; nothing was imported:\n", len(result.Conflicts))
+		for _, c := range result.Conflicts {
+			fmt.Fprintf(&b, "  %s: recorded %s, imported %s\n", c.VM, c.Existing, c.Imported)
+		}
+		fmt.Fprintf(&b, "Check that the imported keys are the VMs' current ones, then rerun with %s to replace them.",
+			hint.Cmd("mint hostkey import --force"))
+		return fmt.Errorf("%s", b.String())
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Imported host keys: %d added, %d replaced, %d unchanged.\n",
+		len(result.Added), len(result.Replaced), len(result.Unchanged))
+	for _, vmName := range result.Replaced {
+		fmt.Fprintf(w, "  Replaced the key for VM %q.\n", vmName)
+	}
+	return nil
+}
+
+// parseHostKeyExport decodes a mint hostkey export document into keys
+// sorted by VM name.
+func parseHostKeyExport(data []byte) ([]sshconfig.HostKey, error) {
+	var doc map[string]hostKeyExportEntry
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing host keys: %w", err)
+	}
+
+	keys := make([]sshconfig.HostKey, 0, len(doc))
+	for vmName, entry := range doc {
+		if vmName == "" || strings.ContainsAny(vmName, "= \t\n") {
+			return nil, fmt.Errorf("invalid VM name %q in host keys", vmName)
+		}
+		if !strings.HasPrefix(entry.Fingerprint, "SHA256:") || strings.ContainsAny(entry.Fingerprint, " \t\n") {
+			return nil, fmt.Errorf("invalid fingerprint %q for VM %q — want SHA256:<base64>", entry.Fingerprint, vmName)
+		}
+		k := sshconfig.HostKey{VM: vmName, Fingerprint: entry.Fingerprint}
+		if entry.RecordedAt != "" {
+			t, err := time.Parse(time.RFC3339, entry.RecordedAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recorded_at %q for VM %q: %w", entry.RecordedAt, vmName, err)
+			}
+			k.RecordedAt = t
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].VM < keys[j].VM })
+	return keys, nil
+}
+
+func runHostKeyReset(cmd *cobra.Command, deps *hostKeyDeps, vmName string) error {
+	_, existing, err := deps.store.CheckKey(vmName, "")
+	if err != nil {
+		return err
+	}
+	if existing == "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "No host key recorded for VM %q.\n", vmName)
+		return nil
+	}
+	if err := deps.store.RemoveKey(vmName); err != nil {
+		return fmt.Errorf("removing host key: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed the host key for VM %q. The next connection records the key it presents.\n", vmName)
+	return nil
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+)
+
+func runHostKeyCommand(t *testing.T, deps *hostKeyDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot(newHostKeyCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"hostkey"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestHostKeyExportImportRoundTrip(t *testing.T) {
+	src := sshconfig.NewHostKeyStore(t.TempDir())
+	_ = src.RecordKey("default", "SHA256:aaa")
+	_ = src.RecordKey("dev", "SHA256:ddd")
+
+	exported, err := runHostKeyCommand(t, &hostKeyDeps{store: src}, "", "export")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var doc map[string]hostKeyExportEntry
+	if err := json.Unmarshal([]byte(exported), &doc); err != nil {
+		t.Fatalf("export is not JSON: %v\n%s", err, exported)
+	}
+	if doc["dev"].Fingerprint != "SHA256:ddd" || doc["dev"].RecordedAt == "" {
+		t.Errorf("dev entry = %+v", doc["dev"])
+	}
+
+	dst := sshconfig.NewHostKeyStore(t.TempDir())
+	_ = dst.RecordKey("default", "SHA256:aaa")
+	out, err := runHostKeyCommand(t, &hostKeyDeps{store: dst}, exported, "import", "-")
+	if err != nil {
+		t.Fatalf("import: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Imported host keys: 1 added, 0 replaced, 1 unchanged.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if matched, _, _ := dst.CheckKey("dev", "SHA256:ddd"); !matched {
+		t.Error("dev key not imported")
+	}
+}
+
+func TestHostKeyImportConflict(t *testing.T) {
+	hint.IsTTY = false
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"default":{"fingerprint":"SHA256:theirs"},"dev":{"fingerprint":"SHA256:ddd"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := sshconfig.NewHostKeyStore(t.TempDir())
+	_ = store.RecordKey("default", "SHA256:mine")
+	deps := &hostKeyDeps{store: store}
+
+	_, err := runHostKeyCommand(t, deps, "", "import", path)
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	for _, want := range []string{"nothing was imported", "default: recorded SHA256:mine, imported SHA256:theirs", "`mint hostkey import --force`"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+	if _, existing, _ := store.CheckKey("dev", ""); existing != "" {
+		t.Errorf("dev key imported despite the conflict")
+	}
+
+	out, err := runHostKeyCommand(t, deps, "", "import", "--force", path)
+	if err != nil {
+		t.Fatalf("import --force: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Replaced the key for VM "default".`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if matched, _, _ := store.CheckKey("default", "SHA256:theirs"); !matched {
+		t.Error("default key not replaced")
+	}
+}
+
+func TestHostKeyImportRejectsInvalidDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{"not JSON", "default=SHA256:aaa", "parsing host keys"},
+		{"bad fingerprint", `{"default":{"fingerprint":"abc"}}`, "invalid fingerprint"},
+		{"bad time", `{"default":{"fingerprint":"SHA256:aaa","recorded_at":"yesterday"}}`, "invalid recorded_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := &hostKeyDeps{store: sshconfig.NewHostKeyStore(t.TempDir())}
+			_, err := runHostKeyCommand(t, deps, tt.doc, "import", "-")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHostKeyReset(t *testing.T) {
+	store := sshconfig.NewHostKeyStore(t.TempDir())
+	_ = store.RecordKey("dev", "SHA256:ddd")
+	deps := &hostKeyDeps{store: store}
+
+	out, err := runHostKeyCommand(t, deps, "", "reset", "dev")
+	if err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if !strings.Contains(out, `Removed the host key for VM "dev".`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if _, existing, _ := store.CheckKey("dev", ""); existing != "" {
+		t.Errorf("key still recorded: %q", existing)
+	}
+
+	out, err = runHostKeyCommand(t, deps, "", "reset", "dev")
+	if err != nil {
+		t.Fatalf("second reset: %v", err)
+	}
+	if !strings.Contains(out, `No host key recorded for VM "dev".`) {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
@@ -0,0 +1,421 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// Files the VM's mint-idle-check timer reads and writes (ADR-0018).
+const (
+	idleTimeoutFile = "/etc/default/mint-idle"
+	idleSinceFile   = "/var/lib/mint/idle-since"
+)
+
+// maxIdleExtension caps mint idle extend, so a typo cannot keep a VM
+// running for days.
+const maxIdleExtension = 24 * time.Hour
+
+// defaultIdleTimeoutMinutes is what mint-idle-check uses when
+// /etc/default/mint-idle does not set MINT_IDLE_TIMEOUT.
+const defaultIdleTimeoutMinutes = 60
+
+// idleDeps holds the injectable dependencies for the idle commands.
+type idleDeps struct {
+	describe mintaws.DescribeInstancesAPI
+	sendKey  mintaws.SendSSHPublicKeyAPI
+	owner    string
+	remote   RemoteCommandRunner
+	sshPort  int // ssh_port from config; zero means defaultSSHPort
+}
+
+// idleState is the idle monitor state read from the VM.
+type idleState struct {
+	Now            time.Time
+	TimeoutMinutes int
+	IdleSince      time.Time // zero when activity was seen at the last check
+	ExtendedUntil  time.Time // zero when no extension is active
+}
+
+// idleStatusJSON is the JSON output of idle status.
+type idleStatusJSON struct {
+	VM             string `json:"vm"`
+	TimeoutMinutes int    `json:"timeout_minutes"`
+	IdleSince      string `json:"idle_since,omitempty"`
+	IdleMinutes    int    `json:"idle_minutes"`
+	ExtendedUntil  string `json:"extended_until,omitempty"`
+}
+
+// idleExtendJSON is the JSON output of idle extend.
+type idleExtendJSON struct {
+	VM            string `json:"vm"`
+	Duration      string `json:"duration"`
+	ExtendedUntil string `json:"extended_until"`
+}
+
+// idleCancelJSON is the JSON output of idle cancel.
+type idleCancelJSON struct {
+	VM            string `json:"vm"`
+	Cancelled     bool   `json:"cancelled"`
+	ExtendedUntil string `json:"extended_until,omitempty"`
+}
+
+// newIdleCommand creates the production idle command tree.
+func newIdleCommand() *cobra.Command {
+	return newIdleCommandWithDeps(nil)
+}
+
+// newIdleCommandWithDeps creates the idle command tree with explicit
+// dependencies for testing.
+func newIdleCommandWithDeps(deps *idleDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "idle",
+		Short: "Inspect and adjust the VM idle auto-stop timer",
+		Long: "Inspect and adjust the idle monitor that stops the VM after idle_timeout " +
+			"minutes without SSH, mosh, tmux, or Claude activity (ADR-0018). The monitor " +
+			"checks every 5 minutes; a manual extension counts as activity until it expires.",
+	}
+
+	cmd.AddCommand(newIdleStatusCommandWithDeps(deps))
+	cmd.AddCommand(newIdleExtendCommandWithDeps(deps))
+	cmd.AddCommand(newIdleCancelCommandWithDeps(deps))
+
+	return cmd
+}
+
+// idleDepsFor returns deps, or the production dependencies when deps is nil.
+func idleDepsFor(cmd *cobra.Command, deps *idleDeps) (*idleDeps, error) {
+	if deps != nil {
+		return deps, nil
+	}
+	clients := awsClientsFromContext(cmd.Context())
+	if clients == nil {
+		return nil, fmt.Errorf("AWS clients not configured")
+	}
+	return &idleDeps{
+		describe: clients.ec2Client,
+		sendKey:  clients.sendKey,
+		owner:    clients.owner,
+		remote:   clients.remoteRunner(),
+		sshPort:  clients.sshOptions.Port,
+	}, nil
+}
+
+// newIdleStatusCommandWithDeps creates the idle status subcommand.
+func newIdleStatusCommandWithDeps(deps *idleDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show time since last activity, the timeout, and any extension",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := idleDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runIdleStatus(cmd, d)
+		},
+	}
+}
+
+// newIdleExtendCommandWithDeps creates the idle extend subcommand.
+func newIdleExtendCommandWithDeps(deps *idleDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "extend <duration>",
+		Short: "Keep the VM from auto-stopping for a while",
+		Long: "Keep the VM running for duration, such as 90m or 2h, whether or not it is idle. " +
+			"The extension replaces any earlier one. Minimum 15m, maximum 24h.",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Args:        validateIdleExtendArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := idleDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runIdleExtend(cmd, d, args[0])
+		},
+	}
+}
+
+// newIdleCancelCommandWithDeps creates the idle cancel subcommand.
+func newIdleCancelCommandWithDeps(deps *idleDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:         "cancel",
+		Short:       "Remove a manual idle extension",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := idleDepsFor(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runIdleCancel(cmd, d)
+		},
+	}
+}
+
+// validateIdleExtendArgs checks the duration argument before AWS
+// initialization runs in PersistentPreRunE.
+func validateIdleExtendArgs(_ *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("accepts 1 arg(s), received %d — pass a duration such as %s", len(args), hint.Cmd("mint idle extend 2h"))
+	}
+	_, err := parseIdleExtension(args[0])
+	return err
+}
+
+// parseIdleExtension parses the idle extend duration, which follows the
+// mint extend rules and may not exceed maxIdleExtension.
+func parseIdleExtension(arg string) (time.Duration, error) {
+	d, err := parseExtendDuration(arg)
+	if err != nil {
+		return 0, err
+	}
+	if d > maxIdleExtension {
+		return 0, fmt.Errorf("duration must be at most %s (got %s)", format.FormatDuration(maxIdleExtension), format.FormatDuration(d))
+	}
+	return d, nil
+}
+
+// runningIdleVM discovers the VM the idle commands act on. The idle
+// monitor only runs while the VM does, so a stopped VM is an error.
+func runningIdleVM(ctx context.Context, cmd *cobra.Command, deps *idleDeps) (*vm.VM, string, error) {
+	vmName := "default"
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return nil, "", fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return nil, "", fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return nil, "", fmt.Errorf("VM %q (%s) is not running (state: %s) — the idle monitor only runs on a running VM; run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+	return found, vmName, nil
+}
+
+// runIdleRemote runs script on found, turning a refused connection into
+// the bootstrap hint mint extend gives.
+func runIdleRemote(ctx context.Context, deps *idleDeps, found *vm.VM, vmName, script, action string) ([]byte, error) {
+	out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, []string{"sh", "-c", shellQuote(script)})
+	if err != nil {
+		if isSSHConnectionError(err) {
+			return nil, fmt.Errorf(
+				"cannot connect to VM %q (port %d refused) — "+
+					"bootstrap may be incomplete\n%s",
+				vmName, sshPortOrDefault(deps.sshPort),
+				hint.Suggest("Diagnose", "mint doctor"),
+			)
+		}
+		return nil, fmt.Errorf("%s: %w", action, err)
+	}
+	return out, nil
+}
+
+// idleStatusScript prints the VM clock and the idle monitor files as
+// key=value lines for parseIdleState.
+func idleStatusScript() string {
+	return fmt.Sprintf("echo now=$(date +%%s); "+
+		"echo timeout=$(sed -n 's/^MINT_IDLE_TIMEOUT=//p' %s 2>/dev/null); "+
+		"echo idle_since=$(cat %s 2>/dev/null); "+
+		"echo extended_until=$(cat %s 2>/dev/null)",
+		idleTimeoutFile, idleSinceFile, session.ExtendTimestampPath)
+}
+
+// parseIdleState parses idleStatusScript output. Missing or unreadable
+// values fall back to what mint-idle-check assumes: the default timeout,
+// no idle period, and no extension. An extension in the past is no
+// extension.
+func parseIdleState(out []byte) (idleState, error) {
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			values[key] = strings.TrimSpace(value)
+		}
+	}
+	now, err := strconv.ParseInt(values["now"], 10, 64)
+	if err != nil {
+		return idleState{}, fmt.Errorf("unexpected idle status output %q", strings.TrimSpace(string(out)))
+	}
+	state := idleState{Now: time.Unix(now, 0).UTC(), TimeoutMinutes: defaultIdleTimeoutMinutes}
+	if minutes, err := strconv.Atoi(values["timeout"]); err == nil && minutes > 0 {
+		state.TimeoutMinutes = minutes
+	}
+	if since, err := strconv.ParseInt(values["idle_since"], 10, 64); err == nil {
+		state.IdleSince = time.Unix(since, 0).UTC()
+	}
+	if until, err := strconv.ParseInt(values["extended_until"], 10, 64); err == nil && until > now {
+		state.ExtendedUntil = time.Unix(until, 0).UTC()
+	}
+	return state, nil
+}
+
+// formatUTC formats t as an absolute UTC time, so the VM's and the
+// laptop's time zones cannot be confused.
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+func runIdleStatus(cmd *cobra.Command, deps *idleDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningIdleVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+	out, err := runIdleRemote(ctx, deps, found, vmName, idleStatusScript(), "reading idle state")
+	if err != nil {
+		return err
+	}
+	state, err := parseIdleState(out)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		result := idleStatusJSON{VM: vmName, TimeoutMinutes: state.TimeoutMinutes}
+		if !state.IdleSince.IsZero() {
+			result.IdleSince = state.IdleSince.Format(time.RFC3339)
+			result.IdleMinutes = int(state.Now.Sub(state.IdleSince) / time.Minute)
+		}
+		if !state.ExtendedUntil.IsZero() {
+			result.ExtendedUntil = state.ExtendedUntil.Format(time.RFC3339)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	writeIdleStatusHuman(w, vmName, state)
+	return nil
+}
+
+// writeIdleStatusHuman outputs the idle state as aligned lines.
+func writeIdleStatusHuman(w io.Writer, vmName string, s idleState) {
+	timeout := time.Duration(s.TimeoutMinutes) * time.Minute
+	fmt.Fprintf(w, "VM:             %s\n", vmName)
+	fmt.Fprintf(w, "Idle timeout:   %s\n", format.FormatDuration(timeout))
+
+	if s.IdleSince.IsZero() {
+		fmt.Fprintln(w, "Last activity:  active at the last check")
+	} else {
+		idle := s.Now.Sub(s.IdleSince)
+		fmt.Fprintf(w, "Last activity:  idle for %s (since %s)\n", format.FormatDuration(idle), formatUTC(s.IdleSince))
+		if s.ExtendedUntil.IsZero() {
+			fmt.Fprintf(w, "Auto-stop:      about %s\n", formatUTC(s.IdleSince.Add(timeout)))
+		}
+	}
+
+	if s.ExtendedUntil.IsZero() {
+		fmt.Fprintln(w, "Extension:      none")
+		return
+	}
+	fmt.Fprintf(w, "Extension:      until %s (%s left)\n", formatUTC(s.ExtendedUntil), format.FormatDuration(s.ExtendedUntil.Sub(s.Now)))
+}
+
+// idleExtendScript writes the extension to a temporary file and renames
+// it over the timestamp file, so mint-idle-check never reads half of it,
+// then prints the timestamp it wrote.
+func idleExtendScript(d time.Duration) string {
+	return fmt.Sprintf("set -e; until=$(($(date +%%s) + %d)); "+
+		"echo $until | sudo tee %[2]s.tmp >/dev/null; sudo mv -f %[2]s.tmp %[2]s; echo $until",
+		int64(d/time.Second), session.ExtendTimestampPath)
+}
+
+func runIdleExtend(cmd *cobra.Command, deps *idleDeps, arg string) error {
+	d, err := parseIdleExtension(arg)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningIdleVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+	out, err := runIdleRemote(ctx, deps, found, vmName, idleExtendScript(d), "extending idle timer")
+	if err != nil {
+		return err
+	}
+	until, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected idle extend output %q", strings.TrimSpace(string(out)))
+	}
+	expiry := time.Unix(until, 0).UTC()
+
+	w := cmd.OutOrStdout()
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(idleExtendJSON{VM: vmName, Duration: format.FormatDuration(d), ExtendedUntil: expiry.Format(time.RFC3339)})
+	}
+	fmt.Fprintf(w, "Idle auto-stop on VM %q extended by %s, until %s. Undo with %s.\n",
+		vmName, format.FormatDuration(d), formatUTC(expiry), hint.Cmd("mint idle cancel"))
+	return nil
+}
+
+// idleCancelScript prints the clock and any extension, then removes it.
+func idleCancelScript() string {
+	return fmt.Sprintf("echo now=$(date +%%s); echo extended_until=$(cat %[1]s 2>/dev/null); sudo rm -f %[1]s",
+		session.ExtendTimestampPath)
+}
+
+func runIdleCancel(cmd *cobra.Command, deps *idleDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	found, vmName, err := runningIdleVM(ctx, cmd, deps)
+	if err != nil {
+		return err
+	}
+	out, err := runIdleRemote(ctx, deps, found, vmName, idleCancelScript(), "cancelling idle extension")
+	if err != nil {
+		return err
+	}
+	state, err := parseIdleState(out)
+	if err != nil {
+		return err
+	}
+	cancelled := !state.ExtendedUntil.IsZero()
+
+	w := cmd.OutOrStdout()
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		result := idleCancelJSON{VM: vmName, Cancelled: cancelled}
+		if cancelled {
+			result.ExtendedUntil = state.ExtendedUntil.Format(time.RFC3339)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	if !cancelled {
+		fmt.Fprintf(w, "No idle extension was active on VM %q.\n", vmName)
+		return nil
+	}
+	fmt.Fprintf(w, "Cancelled the idle extension on VM %q (was until %s). The idle timeout applies again.\n",
+		vmName, formatUTC(state.ExtendedUntil))
+	return nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// idleNow is the VM clock in the idle tests: 2026-10-15 12:00:00 UTC.
+const idleNow = "1792065600"
+
+func runIdleCmd(t *testing.T, describe *ec2.DescribeInstancesOutput, runner *cmdtest.RemoteRunner, args ...string) (string, error) {
+	t.Helper()
+	hint.IsTTY = false
+	deps := &idleDeps{
+		describe: &cmdtest.DescribeInstances{Output: describe},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		remote:   runner.Run,
+	}
+	root := cmdtest.NewRoot(newIdleCommandWithDeps(deps))
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"idle"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func runningIdleInstance() *ec2.DescribeInstancesOutput {
+	return makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")
+}
+
+func TestParseIdleState(t *testing.T) {
+	state, err := parseIdleState([]byte("now=" + idleNow + "\ntimeout=90\nidle_since=1792063800\nextended_until=1792072800\n"))
+	if err != nil {
+		t.Fatalf("parseIdleState: %v", err)
+	}
+	if state.TimeoutMinutes != 90 || state.IdleSince.Unix() != 1792063800 || state.ExtendedUntil.Unix() != 1792072800 {
+		t.Errorf("state = %+v", state)
+	}
+
+	// Unset files fall back to mint-idle-check's defaults, and an expired
+	// extension is no extension.
+	state, err = parseIdleState([]byte("now=" + idleNow + "\ntimeout=\nidle_since=\nextended_until=1791000000\n"))
+	if err != nil {
+		t.Fatalf("parseIdleState: %v", err)
+	}
+	if state.TimeoutMinutes != defaultIdleTimeoutMinutes || !state.IdleSince.IsZero() || !state.ExtendedUntil.IsZero() {
+		t.Errorf("defaults = %+v", state)
+	}
+
+	if _, err := parseIdleState([]byte("garbage")); err == nil {
+		t.Error("expected an error for output without now=")
+	}
+}
+
+func TestIdleStatus(t *testing.T) {
+	runner := &cmdtest.RemoteRunner{Output: []byte("now=" + idleNow + "\ntimeout=60\nidle_since=1792063800\nextended_until=\n")}
+	out, err := runIdleCmd(t, runningIdleInstance(), runner, "status")
+	if err != nil {
+		t.Fatalf("idle status: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"Idle timeout:   1h",
+		"Last activity:  idle for 30m (since 2026-10-15 11:30 UTC)",
+		"Auto-stop:      about 2026-10-15 12:30 UTC",
+		"Extension:      none",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestIdleStatusJSON(t *testing.T) {
+	runner := &cmdtest.RemoteRunner{Output: []byte("now=" + idleNow + "\ntimeout=45\nidle_since=\nextended_until=1792072800\n")}
+	out, err := runIdleCmd(t, runningIdleInstance(), runner, "status", "--json")
+	if err != nil {
+		t.Fatalf("idle status --json: %v\n%s", err, out)
+	}
+	var got idleStatusJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	want := idleStatusJSON{VM: "default", TimeoutMinutes: 45, ExtendedUntil: "2026-10-15T14:00:00Z"}
+	if got != want {
+		t.Errorf("JSON = %+v, want %+v", got, want)
+	}
+}
+
+func TestIdleExtendWritesAtomicallyAndEchoesUTC(t *testing.T) {
+	runner := &cmdtest.RemoteRunner{Output: []byte("1792072800\n")}
+	out, err := runIdleCmd(t, runningIdleInstance(), runner, "extend", "2h")
+	if err != nil {
+		t.Fatalf("idle extend: %v\n%s", err, out)
+	}
+	if len(runner.Commands()) != 1 {
+		t.Fatalf("remote calls = %v, want 1", runner.Commands())
+	}
+	for _, want := range []string{"+ 7200", "idle-extended-until.tmp", "mv -f"} {
+		if !strings.Contains(runner.Commands()[0], want) {
+			t.Errorf("extend script missing %q: %s", want, runner.Commands()[0])
+		}
+	}
+	if !strings.Contains(out, "extended by 2h, until 2026-10-15 14:00 UTC") {
+		t.Errorf("output = %q, want the UTC expiry", out)
+	}
+}
+
+func TestIdleExtendJSON(t *testing.T) {
+	runner := &cmdtest.RemoteRunner{Output: []byte("1792072800\n")}
+	out, err := runIdleCmd(t, runningIdleInstance(), runner, "extend", "120", "--json")
+	if err != nil {
+		t.Fatalf("idle extend --json: %v\n%s", err, out)
+	}
+	var got idleExtendJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	want := idleExtendJSON{VM: "default", Duration: "2h", ExtendedUntil: "2026-10-15T14:00:00Z"}
+	if got != want {
+		t.Errorf("JSON = %+v, want %+v", got, want)
+	}
+}
+
+func TestIdleExtendRejectsBadDurations(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"25h"}, "at most 1d"},
+		{[]string{"10m"}, "at least 15m"},
+		{[]string{"soon"}, "invalid duration"},
+		{nil, "accepts 1 arg(s)"},
+	}
+	for _, tt := range tests {
+		runner := &cmdtest.RemoteRunner{}
+		_, err := runIdleCmd(t, runningIdleInstance(), runner, append([]string{"extend"}, tt.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("extend %v: error = %v, want %q", tt.args, err, tt.want)
+		}
+		if len(runner.Commands()) != 0 {
+			t.Errorf("extend %v ran remote commands %v", tt.args, runner.Commands())
+		}
+	}
+}
+
+func TestIdleCancel(t *testing.T) {
+	runner := &cmdtest.RemoteRunner{Output: []byte("now=" + idleNow + "\nextended_until=1792072800\n")}
+	out, err := runIdleCmd(t, runningIdleInstance(), runner, "cancel")
+	if err != nil {
+		t.Fatalf("idle cancel: %v\n%s", err, out)
+	}
+	if len(runner.Commands()) != 1 || !strings.Contains(runner.Commands()[0], "sudo rm -f /var/lib/mint/idle-extended-until") {
+		t.Errorf("remote calls = %v, want the extension removed", runner.Commands())
+	}
+	if !strings.Contains(out, "was until 2026-10-15 14:00 UTC") {
+		t.Errorf("output = %q", out)
+	}
+
+	runner = &cmdtest.RemoteRunner{Output: []byte("now=" + idleNow + "\nextended_until=\n")}
+	out, err = runIdleCmd(t, runningIdleInstance(), runner, "cancel", "--json")
+	if err != nil {
+		t.Fatalf("idle cancel --json: %v\n%s", err, out)
+	}
+	var got idleCancelJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if got.Cancelled || got.ExtendedUntil != "" {
+		t.Errorf("JSON = %+v, want nothing cancelled", got)
+	}
+}
+
+func TestIdleCommandsRequireRunningVM(t *testing.T) {
+	for _, args := range [][]string{{"status"}, {"extend", "1h"}, {"cancel"}} {
+		runner := &cmdtest.RemoteRunner{}
+		_, err := runIdleCmd(t, makeStoppedInstanceForProject("i-abc123", "default", "alice"), runner, args...)
+		if err == nil || !strings.Contains(err.Error(), "is not running (state: stopped)") {
+			t.Errorf("%v: error = %v, want the stopped-VM error", args, err)
+		}
+		if len(runner.Commands()) != 0 {
+			t.Errorf("%v ran remote commands on a stopped VM", args)
+		}
+	}
+}
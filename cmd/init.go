@@ -11,19 +11,30 @@ import (
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
 	"github.com/spf13/cobra"
 )
 
 func newInitCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize mint for the current user",
 		Long: "Validate prerequisites (default VPC, admin EFS) and create per-user " +
 			"resources (security group, EFS access point). Safe to run multiple times — " +
-			"existing resources are detected and skipped.",
+			"existing resources are detected and skipped.\n\n" +
+			"With --instance-connect-endpoint, also ensure an EC2 Instance Connect " +
+			"Endpoint exists in the VPC so VMs without a public IP stay reachable.\n\n" +
+			"With --from-template (or template_repo in config), first apply a team " +
+			"template repository: its config defaults, user-bootstrap.sh hook, and " +
+			"devcontainer overrides. Values and files you already have are kept.",
 		Args: cobra.NoArgs,
 		RunE: runInit,
 	}
+	cmd.Flags().Bool("instance-connect-endpoint", false,
+		"Create an EC2 Instance Connect Endpoint in the VPC if none exists")
+	cmd.Flags().String("from-template", "",
+		"Apply a team template repository (git URL or local path) before initializing")
+	return cmd
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -38,6 +49,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("AWS clients not configured")
 	}
 
+	tmpl, err := initTemplate(cmd, teamtemplate.GitFetch, config.DefaultConfigDir(), clients.mintConfig)
+	if err != nil {
+		return err
+	}
+
 	vmName := "default"
 	if cliCtx != nil && cliCtx.VM != "" {
 		vmName = cliCtx.VM
@@ -72,23 +88,33 @@ func runInit(cmd *cobra.Command, args []string) error {
 		clients.efsClient, // DescribeAccessPointsAPI
 		clients.efsClient, // CreateAccessPointAPI
 	)
+	if withEndpoint, _ := cmd.Flags().GetBool("instance-connect-endpoint"); withEndpoint {
+		initializer.WithInstanceConnectEndpoint(clients.ec2Client, clients.ec2Client)
+	}
+	if clients.mintConfig != nil {
+		initializer.WithInstanceProfileName(clients.mintConfig.InstanceProfile).
+			WithExtraTags(clients.mintConfig.ExtraTags).
+			WithSSHPort(clients.mintConfig.SSHPort)
+	}
 
 	result, err := initializer.Run(ctx, clients.owner, clients.ownerARN, vmName)
 	if err != nil {
 		return err
 	}
 
-	return printInitResult(cmd, cliCtx, result)
+	return printInitResult(cmd, cliCtx, result, tmpl)
 }
 
-func printInitResult(cmd *cobra.Command, cliCtx *cli.CLIContext, result *provision.InitResult) error {
+// printInitResult writes the init result. tmpl is the applied team
+// template, nil when init ran without one.
+func printInitResult(cmd *cobra.Command, cliCtx *cli.CLIContext, result *provision.InitResult, tmpl *templateReport) error {
 	if cliCtx != nil && cliCtx.JSON {
-		return printInitJSON(cmd, result)
+		return printInitJSON(cmd, result, tmpl)
 	}
-	return printInitHuman(cmd, result)
+	return printInitHuman(cmd, result, tmpl)
 }
 
-func printInitJSON(cmd *cobra.Command, result *provision.InitResult) error {
+func printInitJSON(cmd *cobra.Command, result *provision.InitResult, tmpl *templateReport) error {
 	data := map[string]any{
 		"vpc_id":          result.VPCID,
 		"efs_id":          result.EFSID,
@@ -97,15 +123,26 @@ func printInitJSON(cmd *cobra.Command, result *provision.InitResult) error {
 		"access_point_id": result.AccessPointID,
 		"ap_created":      result.APCreated,
 	}
+	if result.EndpointID != "" {
+		data["instance_connect_endpoint_id"] = result.EndpointID
+		data["endpoint_created"] = result.EndpointCreated
+	}
+	if tmpl != nil {
+		data["template"] = tmpl
+	}
 
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	enc.SetIndent("", "  ")
 	return enc.Encode(data)
 }
 
-func printInitHuman(cmd *cobra.Command, result *provision.InitResult) error {
+func printInitHuman(cmd *cobra.Command, result *provision.InitResult, tmpl *templateReport) error {
 	w := cmd.OutOrStdout()
 
+	if tmpl != nil {
+		printTemplateReport(w, tmpl)
+	}
+
 	fmt.Fprintf(w, "VPC           %s\n", result.VPCID)
 	fmt.Fprintf(w, "EFS           %s\n", result.EFSID)
 
@@ -121,6 +158,14 @@ func printInitHuman(cmd *cobra.Command, result *provision.InitResult) error {
 		fmt.Fprintf(w, "Access point  %s (exists)\n", result.AccessPointID)
 	}
 
+	if result.EndpointID != "" {
+		if result.EndpointCreated {
+			fmt.Fprintf(w, "Endpoint      %s (created)\n", result.EndpointID)
+		} else {
+			fmt.Fprintf(w, "Endpoint      %s (exists)\n", result.EndpointID)
+		}
+	}
+
 	fmt.Fprintln(w, "\nInitialization complete.")
 	return nil
 }
@@ -145,5 +190,5 @@ func initWithInitializer(ctx context.Context, cmd *cobra.Command, cliCtx *cli.CL
 	if err != nil {
 		return err
 	}
-	return printInitResult(cmd, cliCtx, result)
+	return printInitResult(cmd, cliCtx, result, nil)
 }
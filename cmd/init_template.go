@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
+)
+
+// templateReport records what mint init took from a team template.
+type templateReport struct {
+	URL    string `json:"url"`
+	Commit string `json:"commit"`
+	// Taken are the config keys set from the template, as key = value.
+	Taken []string `json:"config_taken"`
+	// Kept are the config keys where the user's value won.
+	Kept []string `json:"config_kept"`
+	// Invalid are template values config validation rejected.
+	Invalid []string `json:"config_invalid,omitempty"`
+	// Ignored are template keys that are not settable mint config keys.
+	Ignored []string `json:"config_ignored,omitempty"`
+	// Hook is "installed", "kept" (the user already has one), or "" when
+	// the template has none.
+	Hook string `json:"hook,omitempty"`
+	// OverridesInstalled and OverridesKept are devcontainer override file
+	// names.
+	OverridesInstalled []string                      `json:"overrides_installed,omitempty"`
+	OverridesKept      []string                      `json:"overrides_kept,omitempty"`
+	Matrix             []teamtemplate.Recommendation `json:"recommended,omitempty"`
+}
+
+// initTemplate applies the team template for mint init: --from-template,
+// else template_repo from config. It returns nil when there is no template
+// or it could not be fetched; that is a warning on stderr, not an error, so
+// init still completes.
+func initTemplate(cmd *cobra.Command, fetch teamtemplate.Fetcher, configDir string, cfg *config.Config) (*templateReport, error) {
+	url, _ := cmd.Flags().GetString("from-template")
+	if url == "" && cfg != nil {
+		url = cfg.TemplateRepo
+	}
+	if url == "" {
+		return nil, nil
+	}
+	if err := config.ValidateTemplateRepo(url); err != nil {
+		return nil, fmt.Errorf("invalid --from-template %q: %w", url, err)
+	}
+	return fetchAndApplyTemplate(cmd.Context(), cmd.ErrOrStderr(), fetch, configDir, url)
+}
+
+// fetchAndApplyTemplate checks out the template at url with fetch and
+// applies it to configDir. A template that cannot be fetched or read is a
+// warning: init carries on without it and the returned report is nil.
+func fetchAndApplyTemplate(ctx context.Context, w io.Writer, fetch teamtemplate.Fetcher, configDir, url string) (*templateReport, error) {
+	tmp, err := os.MkdirTemp("", "mint-template-*")
+	if err != nil {
+		return nil, fmt.Errorf("create template checkout dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dest := filepath.Join(tmp, "template")
+	commit, err := fetch(ctx, url, dest)
+	if err == nil {
+		var tmpl *teamtemplate.Template
+		if tmpl, err = teamtemplate.Load(dest); err == nil {
+			return applyTemplate(configDir, tmpl, url, commit)
+		}
+	}
+	fmt.Fprintf(w, "Warning: could not use template %s: %v — continuing without it\n", url, err)
+	return nil, nil
+}
+
+// applyTemplate merges tmpl into the config in configDir and installs its
+// hook and overrides. The user's existing values and files always win.
+func applyTemplate(configDir string, tmpl *teamtemplate.Template, url, commit string) (*templateReport, error) {
+	report := &templateReport{URL: url, Commit: commit, Ignored: tmpl.Ignored, Matrix: tmpl.Matrix}
+
+	cfg, err := config.Load(configDir)
+	if err != nil {
+		return nil, err
+	}
+	explicit, err := config.ExplicitKeys(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tmpl.Config))
+	for k := range tmpl.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := tmpl.Config[key]
+		canonical := key
+		if key == "idle_timeout_minutes" {
+			canonical = "idle_timeout"
+		}
+		if explicit[canonical] {
+			report.Kept = append(report.Kept, key)
+			continue
+		}
+		if err := cfg.Set(key, value); err != nil {
+			report.Invalid = append(report.Invalid, err.Error())
+			continue
+		}
+		report.Taken = append(report.Taken, key+" = "+value)
+	}
+	cfg.TemplateRepo = url
+	cfg.TemplateCommit = commit
+	if err := config.Save(cfg, configDir); err != nil {
+		return nil, fmt.Errorf("save config: %w", err)
+	}
+
+	if tmpl.Hook != nil {
+		installed, err := installIfAbsent(filepath.Join(configDir, teamtemplate.HookFile), tmpl.Hook)
+		if err != nil {
+			return nil, err
+		}
+		report.Hook = "kept"
+		if installed {
+			report.Hook = "installed"
+		}
+	}
+
+	names := make([]string, 0, len(tmpl.Overrides))
+	for name := range tmpl.Overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		installed, err := installIfAbsent(filepath.Join(configDir, devcontainerOverridesDirName, name), tmpl.Overrides[name])
+		if err != nil {
+			return nil, err
+		}
+		if installed {
+			report.OverridesInstalled = append(report.OverridesInstalled, name)
+		} else {
+			report.OverridesKept = append(report.OverridesKept, name)
+		}
+	}
+	return report, nil
+}
+
+// installIfAbsent writes data to path unless a file is already there. It
+// reports whether it wrote.
+func installIfAbsent(path string, data []byte) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("install %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return false, fmt.Errorf("install %s: %w", path, err)
+	}
+	return true, f.Close()
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// printTemplateReport writes what init took from the template.
+func printTemplateReport(w io.Writer, r *templateReport) {
+	fmt.Fprintf(w, "Template      %s (%s)\n", r.URL, shortCommit(r.Commit))
+	for _, kv := range r.Taken {
+		fmt.Fprintf(w, "  %s (from template)\n", kv)
+	}
+	for _, key := range r.Kept {
+		fmt.Fprintf(w, "  %s: kept your value\n", key)
+	}
+	for _, msg := range r.Invalid {
+		fmt.Fprintf(w, "  skipped: %s\n", msg)
+	}
+	if len(r.Ignored) > 0 {
+		fmt.Fprintf(w, "  ignored unknown keys: %s\n", strings.Join(r.Ignored, ", "))
+	}
+	switch r.Hook {
+	case "installed":
+		fmt.Fprintf(w, "  %s installed\n", teamtemplate.HookFile)
+	case "kept":
+		fmt.Fprintf(w, "  %s: kept yours\n", teamtemplate.HookFile)
+	}
+	for _, name := range r.OverridesInstalled {
+		fmt.Fprintf(w, "  devcontainer override %s installed\n", name)
+	}
+	for _, name := range r.OverridesKept {
+		fmt.Fprintf(w, "  devcontainer override %s: kept yours\n", name)
+	}
+	if len(r.Matrix) > 0 {
+		fmt.Fprintln(w, "Recommended instance types:")
+		for _, rec := range r.Matrix {
+			fmt.Fprintf(w, "  %-12s %-14s %s\n", rec.Purpose, rec.InstanceType, strings.Join(rec.Regions, ", "))
+		}
+		fmt.Fprintf(w, "  Choose one with %s\n", hint.Cmd("mint config set instance_type <type>"))
+	}
+	fmt.Fprintln(w)
+}
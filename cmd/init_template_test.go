@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
+)
+
+const fixtureCommit = "1111111111111111111111111111111111111111"
+
+// fixtureFetcher "clones" the testdata/team-template fixture at
+// fixtureCommit.
+func fixtureFetcher(_ context.Context, _, dest string) (string, error) {
+	if err := os.CopyFS(dest, os.DirFS(filepath.Join("testdata", "team-template"))); err != nil {
+		return "", err
+	}
+	return fixtureCommit, nil
+}
+
+// runInitTemplate runs initTemplate as mint init would with the given
+// --from-template value, returning the report and stderr.
+func runInitTemplate(t *testing.T, configDir, fromTemplate string, fetch teamtemplate.Fetcher) (*templateReport, string) {
+	t.Helper()
+	cmd := newInitCommand()
+	stderr := new(bytes.Buffer)
+	cmd.SetErr(stderr)
+	cmd.SetContext(context.Background())
+	if fromTemplate != "" {
+		if err := cmd.Flags().Set("from-template", fromTemplate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := config.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := initTemplate(cmd, fetch, configDir, cfg)
+	if err != nil {
+		t.Fatalf("initTemplate: %v", err)
+	}
+	return report, stderr.String()
+}
+
+func TestInitTemplateUserValuesWin(t *testing.T) {
+	configDir := t.TempDir()
+	cfg, _ := config.Load(configDir)
+	cfg.Region = "eu-west-1"
+	if err := config.Save(cfg, configDir); err != nil {
+		t.Fatal(err)
+	}
+
+	report, _ := runInitTemplate(t, configDir, "https://github.com/acme/mint-template", fixtureFetcher)
+	if report == nil {
+		t.Fatal("expected a template report")
+	}
+
+	got, err := config.Load(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Region != "eu-west-1" {
+		t.Errorf("region = %q, want the user's eu-west-1", got.Region)
+	}
+	if got.InstanceType != "m6i.2xlarge" || got.VolumeSizeGB != 100 || got.IdleTimeoutMinutes != 120 {
+		t.Errorf("template defaults not applied: instance_type=%q volume_size_gb=%d idle_timeout=%dm",
+			got.InstanceType, got.VolumeSizeGB, got.IdleTimeoutMinutes)
+	}
+	if got.SSHConfigApproved {
+		t.Error("a template must not approve SSH config edits")
+	}
+	if got.TemplateRepo != "https://github.com/acme/mint-template" || got.TemplateCommit != fixtureCommit {
+		t.Errorf("recorded template = %q@%q", got.TemplateRepo, got.TemplateCommit)
+	}
+
+	if !slices.Equal(report.Kept, []string{"region"}) {
+		t.Errorf("Kept = %v, want [region]", report.Kept)
+	}
+	if !slices.Contains(report.Taken, "instance_type = m6i.2xlarge") {
+		t.Errorf("Taken = %v, want instance_type", report.Taken)
+	}
+	if !slices.Equal(report.Ignored, []string{"not_a_mint_key", "ssh_config_approved"}) {
+		t.Errorf("Ignored = %v", report.Ignored)
+	}
+}
+
+func TestInitTemplateInstallsHookAndOverrides(t *testing.T) {
+	configDir := t.TempDir()
+
+	report, _ := runInitTemplate(t, configDir, "/srv/mint-template", fixtureFetcher)
+	if report == nil || report.Hook != "installed" {
+		t.Fatalf("report = %+v, want hook installed", report)
+	}
+	hook, err := os.ReadFile(filepath.Join(configDir, "user-bootstrap.sh"))
+	if err != nil || !strings.Contains(string(hook), "Acme user bootstrap") {
+		t.Errorf("user-bootstrap.sh = %q, %v", hook, err)
+	}
+	override, err := os.ReadFile(filepath.Join(configDir, devcontainerOverridesDirName, "api.json"))
+	if err != nil || !strings.Contains(string(override), "forwardPorts") {
+		t.Errorf("api.json override = %q, %v", override, err)
+	}
+	if !slices.Equal(report.OverridesInstalled, []string{"api.json"}) {
+		t.Errorf("OverridesInstalled = %v", report.OverridesInstalled)
+	}
+}
+
+func TestInitTemplateKeepsExistingHook(t *testing.T) {
+	configDir := t.TempDir()
+	hookPath := filepath.Join(configDir, "user-bootstrap.sh")
+	if err := os.WriteFile(hookPath, []byte("#!/bin/bash\necho mine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report, _ := runInitTemplate(t, configDir, "/srv/mint-template", fixtureFetcher)
+	if report == nil || report.Hook != "kept" {
+		t.Fatalf("report = %+v, want hook kept", report)
+	}
+	hook, _ := os.ReadFile(hookPath)
+	if string(hook) != "#!/bin/bash\necho mine\n" {
+		t.Errorf("user hook was overwritten: %q", hook)
+	}
+}
+
+func TestInitTemplateFetchFailureDegrades(t *testing.T) {
+	configDir := t.TempDir()
+	failing := func(context.Context, string, string) (string, error) {
+		return "", errors.New("git clone: exit status 128 (repository not found)")
+	}
+
+	report, stderr := runInitTemplate(t, configDir, "https://github.com/acme/missing", failing)
+	if report != nil {
+		t.Errorf("report = %+v, want nil", report)
+	}
+	if !strings.Contains(stderr, "Warning: could not use template https://github.com/acme/missing: git clone: exit status 128 (repository not found) — continuing without it") {
+		t.Errorf("stderr = %q, want a fetch warning", stderr)
+	}
+	cfg, _ := config.Load(configDir)
+	if cfg.TemplateRepo != "" {
+		t.Errorf("TemplateRepo = %q after a failed fetch", cfg.TemplateRepo)
+	}
+}
+
+func TestInitTemplateSource(t *testing.T) {
+	configDir := t.TempDir()
+	var fetched []string
+	fetch := func(ctx context.Context, url, dest string) (string, error) {
+		fetched = append(fetched, url)
+		return fixtureFetcher(ctx, url, dest)
+	}
+
+	if report, _ := runInitTemplate(t, configDir, "", fetch); report != nil || len(fetched) != 0 {
+		t.Fatalf("plain init fetched %v", fetched)
+	}
+
+	cfg, _ := config.Load(configDir)
+	cfg.TemplateRepo = "https://github.com/acme/mint-template"
+	if err := config.Save(cfg, configDir); err != nil {
+		t.Fatal(err)
+	}
+	runInitTemplate(t, configDir, "", fetch)
+	if !slices.Equal(fetched, []string{"https://github.com/acme/mint-template"}) {
+		t.Errorf("fetched %v, want template_repo from config", fetched)
+	}
+
+	cmd := newInitCommand()
+	_ = cmd.Flags().Set("from-template", "acme/mint-template")
+	if _, err := initTemplate(cmd, fetch, configDir, cfg); err == nil {
+		t.Error("expected an error for a relative template path")
+	}
+}
+
+func TestInitOutputWithTemplate(t *testing.T) {
+	hint.IsTTY = false
+	configDir := t.TempDir()
+	report, _ := runInitTemplate(t, configDir, "https://github.com/acme/mint-template", fixtureFetcher)
+	result := &provision.InitResult{VPCID: "vpc-test", EFSID: "fs-test", SecurityGroup: "sg-test", AccessPointID: "fsap-test"}
+
+	buf := new(bytes.Buffer)
+	cmd := newInitCommand()
+	cmd.SetOut(buf)
+	if err := printInitResult(cmd, &cli.CLIContext{}, result, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Template      https://github.com/acme/mint-template (1111111)",
+		"  instance_type = m6i.2xlarge (from template)",
+		"  user-bootstrap.sh installed",
+		"  ml           g5.2xlarge     us-west-2",
+		"`mint config set instance_type <type>`",
+		"Initialization complete.",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := printInitResult(cmd, &cli.CLIContext{JSON: true}, result, report); err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Template templateReport `json:"template"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data.Template.Commit != fixtureCommit || len(data.Template.Matrix) != 2 {
+		t.Errorf("JSON template = %+v", data.Template)
+	}
+}
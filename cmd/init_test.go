@@ -15,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/provision"
@@ -25,42 +26,6 @@ import (
 // Inline mocks for cmd-level init tests
 // ---------------------------------------------------------------------------
 
-type stubDescribeVpcs struct {
-	output *ec2.DescribeVpcsOutput
-	err    error
-}
-
-func (s *stubDescribeVpcs) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
-	return s.output, s.err
-}
-
-type stubDescribeSubnets struct {
-	output *ec2.DescribeSubnetsOutput
-	err    error
-}
-
-func (s *stubDescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
-	return s.output, s.err
-}
-
-type stubDescribeFileSystems struct {
-	output *efs.DescribeFileSystemsOutput
-	err    error
-}
-
-func (s *stubDescribeFileSystems) DescribeFileSystems(ctx context.Context, params *efs.DescribeFileSystemsInput, optFns ...func(*efs.Options)) (*efs.DescribeFileSystemsOutput, error) {
-	return s.output, s.err
-}
-
-type stubDescribeSecurityGroups struct {
-	output *ec2.DescribeSecurityGroupsOutput
-	err    error
-}
-
-func (s *stubDescribeSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
-	return s.output, s.err
-}
-
 type stubCreateSecurityGroup struct {
 	output *ec2.CreateSecurityGroupOutput
 	err    error
@@ -79,15 +44,6 @@ func (s *stubAuthorizeIngress) AuthorizeSecurityGroupIngress(ctx context.Context
 	return s.output, s.err
 }
 
-type stubCreateTags struct {
-	output *ec2.CreateTagsOutput
-	err    error
-}
-
-func (s *stubCreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
-	return s.output, s.err
-}
-
 type stubDescribeAccessPoints struct {
 	output *efs.DescribeAccessPointsOutput
 	err    error
@@ -118,13 +74,13 @@ func (s *stubGetInstanceProfile) GetInstanceProfile(ctx context.Context, params
 // newTestInitializer builds an Initializer with happy-path stubs.
 func newTestInitializer() *provision.Initializer {
 	return provision.NewInitializer(
-		&stubDescribeVpcs{output: &ec2.DescribeVpcsOutput{
+		&cmdtest.DescribeVpcs{Output: &ec2.DescribeVpcsOutput{
 			Vpcs: []ec2types.Vpc{{VpcId: aws.String("vpc-test"), IsDefault: aws.Bool(true)}},
 		}},
-		&stubDescribeSubnets{output: &ec2.DescribeSubnetsOutput{
+		&cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{SubnetId: aws.String("subnet-1"), MapPublicIpOnLaunch: aws.Bool(true)}},
 		}},
-		&stubDescribeFileSystems{output: &efs.DescribeFileSystemsOutput{
+		&cmdtest.DescribeFileSystems{Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{{
 				FileSystemId: aws.String("fs-test"),
 				Tags: []efstypes.Tag{
@@ -138,14 +94,14 @@ func newTestInitializer() *provision.Initializer {
 				InstanceProfileName: aws.String("mint-vm"),
 			},
 		}},
-		&stubDescribeSecurityGroups{output: &ec2.DescribeSecurityGroupsOutput{
+		&cmdtest.DescribeSecurityGroups{Output: &ec2.DescribeSecurityGroupsOutput{
 			SecurityGroups: []ec2types.SecurityGroup{},
 		}},
 		&stubCreateSecurityGroup{output: &ec2.CreateSecurityGroupOutput{
 			GroupId: aws.String("sg-test"),
 		}},
 		&stubAuthorizeIngress{output: &ec2.AuthorizeSecurityGroupIngressOutput{}},
-		&stubCreateTags{output: &ec2.CreateTagsOutput{}},
+		&cmdtest.CreateTags{},
 		&stubDescribeAccessPoints{output: &efs.DescribeAccessPointsOutput{
 			AccessPoints: []efstypes.AccessPointDescription{},
 		}},
@@ -238,18 +194,18 @@ func TestInitCommandError(t *testing.T) {
 
 	// Build an initializer that will fail on VPC validation.
 	initializer := provision.NewInitializer(
-		&stubDescribeVpcs{output: &ec2.DescribeVpcsOutput{Vpcs: []ec2types.Vpc{}}},
-		&stubDescribeSubnets{output: &ec2.DescribeSubnetsOutput{}},
-		&stubDescribeFileSystems{output: &efs.DescribeFileSystemsOutput{}},
+		&cmdtest.DescribeVpcs{Output: &ec2.DescribeVpcsOutput{Vpcs: []ec2types.Vpc{}}},
+		&cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{}},
+		&cmdtest.DescribeFileSystems{Output: &efs.DescribeFileSystemsOutput{}},
 		&stubGetInstanceProfile{output: &iam.GetInstanceProfileOutput{
 			InstanceProfile: &iamtypes.InstanceProfile{
 				InstanceProfileName: aws.String("mint-vm"),
 			},
 		}},
-		&stubDescribeSecurityGroups{output: &ec2.DescribeSecurityGroupsOutput{}},
+		&cmdtest.DescribeSecurityGroups{Output: &ec2.DescribeSecurityGroupsOutput{}},
 		&stubCreateSecurityGroup{output: &ec2.CreateSecurityGroupOutput{}},
 		&stubAuthorizeIngress{output: &ec2.AuthorizeSecurityGroupIngressOutput{}},
-		&stubCreateTags{output: &ec2.CreateTagsOutput{}},
+		&cmdtest.CreateTags{},
 		&stubDescribeAccessPoints{output: &efs.DescribeAccessPointsOutput{}},
 		&stubCreateAccessPoint{output: &efs.CreateAccessPointOutput{}},
 	)
@@ -274,13 +230,13 @@ func TestInitCommandIdempotentOutput(t *testing.T) {
 
 	// Build an initializer where SG and AP already exist.
 	initializer := provision.NewInitializer(
-		&stubDescribeVpcs{output: &ec2.DescribeVpcsOutput{
+		&cmdtest.DescribeVpcs{Output: &ec2.DescribeVpcsOutput{
 			Vpcs: []ec2types.Vpc{{VpcId: aws.String("vpc-test"), IsDefault: aws.Bool(true)}},
 		}},
-		&stubDescribeSubnets{output: &ec2.DescribeSubnetsOutput{
+		&cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{SubnetId: aws.String("subnet-1"), MapPublicIpOnLaunch: aws.Bool(true)}},
 		}},
-		&stubDescribeFileSystems{output: &efs.DescribeFileSystemsOutput{
+		&cmdtest.DescribeFileSystems{Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{{
 				FileSystemId: aws.String("fs-test"),
 				Tags: []efstypes.Tag{
@@ -294,14 +250,14 @@ func TestInitCommandIdempotentOutput(t *testing.T) {
 				InstanceProfileName: aws.String("mint-vm"),
 			},
 		}},
-		&stubDescribeSecurityGroups{output: &ec2.DescribeSecurityGroupsOutput{
+		&cmdtest.DescribeSecurityGroups{Output: &ec2.DescribeSecurityGroupsOutput{
 			SecurityGroups: []ec2types.SecurityGroup{
 				{GroupId: aws.String("sg-existing")},
 			},
 		}},
 		&stubCreateSecurityGroup{output: &ec2.CreateSecurityGroupOutput{}},
 		&stubAuthorizeIngress{output: &ec2.AuthorizeSecurityGroupIngressOutput{}},
-		&stubCreateTags{output: &ec2.CreateTagsOutput{}},
+		&cmdtest.CreateTags{},
 		&stubDescribeAccessPoints{output: &efs.DescribeAccessPointsOutput{
 			AccessPoints: []efstypes.AccessPointDescription{{
 				AccessPointId: aws.String("fsap-existing"),
@@ -346,6 +302,46 @@ func TestInitCommandRegistered(t *testing.T) {
 	}
 }
 
+func TestInitCommandInstanceConnectEndpointOutput(t *testing.T) {
+	result := &provision.InitResult{
+		VPCID:           "vpc-test",
+		EFSID:           "fs-test",
+		SecurityGroup:   "sg-test",
+		AccessPointID:   "fsap-test",
+		EndpointID:      "eice-test",
+		EndpointCreated: true,
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := printInitResult(cmd, &cli.CLIContext{}, result, nil); err != nil {
+		t.Fatalf("printInitResult: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Endpoint      eice-test (created)") {
+		t.Errorf("human output missing endpoint line, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printInitResult(cmd, &cli.CLIContext{JSON: true}, result, nil); err != nil {
+		t.Fatalf("printInitResult JSON: %v", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data["instance_connect_endpoint_id"] != "eice-test" || data["endpoint_created"] != true {
+		t.Errorf("JSON endpoint fields = %v, %v", data["instance_connect_endpoint_id"], data["endpoint_created"])
+	}
+}
+
+func TestInitCommandInstanceConnectEndpointFlag(t *testing.T) {
+	cmd := newInitCommand()
+	if cmd.Flags().Lookup("instance-connect-endpoint") == nil {
+		t.Fatal("init command missing --instance-connect-endpoint flag")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests for effectiveAWSProfile profile-selection logic (Bug #156)
 // ---------------------------------------------------------------------------
@@ -0,0 +1,45 @@
+// Package cmdtest holds the test doubles shared by the cmd package tests:
+// a root command builder, mocks for the AWS API interfaces that many
+// commands depend on, and a canned remote command runner. Mocks that script
+// answers for one command's remote commands stay in its own test files.
+//
+// The mocks record their calls under a lock, so they can be shared by code
+// that calls them concurrently; read what they recorded once the calls have
+// returned.
+package cmdtest
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+)
+
+// NewRoot returns a minimal mint root command with the persistent flags the
+// subcommands read through cli.FromCommand, and adds subs to it. Unlike the
+// production root it never touches AWS.
+func NewRoot(subs ...*cobra.Command) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "mint",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := cli.NewCLIContext(cmd)
+			// Tests cancel through root.ExecuteContext like Ctrl-C does.
+			parent := cmd.Context()
+			if parent == nil {
+				parent = context.Background()
+			}
+			cmd.SetContext(cli.WithContext(parent, cliCtx))
+			return nil
+		},
+	}
+	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
+	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
+	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
+	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
+	root.PersistentFlags().String("vm", "default", "Target VM name")
+	root.AddCommand(subs...)
+	return root
+}
@@ -0,0 +1,624 @@
+package cmdtest
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// DescribeInstances is a mintaws.DescribeInstancesAPI that returns Output
+// and Err and records the last input.
+type DescribeInstances struct {
+	Output *ec2.DescribeInstancesOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.DescribeInstancesInput
+}
+
+var _ mintaws.DescribeInstancesAPI = (*DescribeInstances)(nil)
+
+func (m *DescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// DescribeInstancesSequence is a mintaws.DescribeInstancesAPI that answers
+// each call with the next of Outputs, repeating the last one, and counts
+// the calls.
+type DescribeInstancesSequence struct {
+	Outputs []*ec2.DescribeInstancesOutput
+	mu      sync.Mutex
+	Calls   int
+}
+
+var _ mintaws.DescribeInstancesAPI = (*DescribeInstancesSequence)(nil)
+
+func (m *DescribeInstancesSequence) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := min(m.Calls, len(m.Outputs)-1)
+	m.Calls++
+	return m.Outputs[i], nil
+}
+
+// TaggedDescribeInstances is a mintaws.DescribeInstancesAPI that applies the
+// tag and instance-id filters of each call to Instances, as EC2 does. Other
+// filters are ignored. A call fails with Err, or with FailVMs[name] when it
+// filters on that mint:vm name.
+type TaggedDescribeInstances struct {
+	Instances []ec2types.Instance
+	Err       error
+	FailVMs   map[string]error
+}
+
+var _ mintaws.DescribeInstancesAPI = (*TaggedDescribeInstances)(nil)
+
+func (m *TaggedDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	for _, f := range params.Filters {
+		if aws.ToString(f.Name) == "tag:"+tags.TagVM && len(f.Values) == 1 {
+			if err := m.FailVMs[f.Values[0]]; err != nil {
+				return nil, err
+			}
+		}
+	}
+	out := &ec2.DescribeInstancesOutput{}
+	for _, inst := range m.Instances {
+		if matchesFilters(inst, params.Filters) {
+			out.Reservations = append(out.Reservations, ec2types.Reservation{Instances: []ec2types.Instance{inst}})
+		}
+	}
+	return out, nil
+}
+
+// matchesFilters reports whether inst passes the tag and instance-id
+// filters.
+func matchesFilters(inst ec2types.Instance, filters []ec2types.Filter) bool {
+	instTags := make(map[string]string, len(inst.Tags))
+	for _, t := range inst.Tags {
+		instTags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	for _, f := range filters {
+		name := aws.ToString(f.Name)
+		if key, ok := strings.CutPrefix(name, "tag:"); ok && !slices.Contains(f.Values, instTags[key]) {
+			return false
+		}
+		if name == "instance-id" && !slices.Contains(f.Values, aws.ToString(inst.InstanceId)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Instances flattens the reservations of outputs, for building a
+// TaggedDescribeInstances from single-VM fixtures. Each instance gets the
+// mint=true tag every Mint instance carries, when the fixture left it out.
+func Instances(outputs ...*ec2.DescribeInstancesOutput) []ec2types.Instance {
+	var instances []ec2types.Instance
+	for _, out := range outputs {
+		for _, r := range out.Reservations {
+			for _, inst := range r.Instances {
+				if !slices.ContainsFunc(inst.Tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == tags.TagMint }) {
+					inst.Tags = append(slices.Clip(inst.Tags), ec2types.Tag{Key: aws.String(tags.TagMint), Value: aws.String("true")})
+				}
+				instances = append(instances, inst)
+			}
+		}
+	}
+	return instances
+}
+
+// SendSSHPublicKey is a mintaws.SendSSHPublicKeyAPI that returns Output and
+// Err and records the last call.
+type SendSSHPublicKey struct {
+	Output *ec2instanceconnect.SendSSHPublicKeyOutput
+	Err    error
+	mu     sync.Mutex
+	Called bool
+	Input  *ec2instanceconnect.SendSSHPublicKeyInput
+}
+
+var _ mintaws.SendSSHPublicKeyAPI = (*SendSSHPublicKey)(nil)
+
+func (m *SendSSHPublicKey) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
+	m.mu.Lock()
+	m.Called = true
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// StopInstances is a mintaws.StopInstancesAPI that returns Output and Err
+// and records the last call.
+type StopInstances struct {
+	Output *ec2.StopInstancesOutput
+	Err    error
+	mu     sync.Mutex
+	Called bool
+	Input  *ec2.StopInstancesInput
+}
+
+var _ mintaws.StopInstancesAPI = (*StopInstances)(nil)
+
+func (m *StopInstances) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	m.mu.Lock()
+	m.Called = true
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// TerminateInstances is a mintaws.TerminateInstancesAPI that returns Output
+// and Err, or FailIDs[id] for an instance listed there, and records the
+// instances it terminated.
+type TerminateInstances struct {
+	Output     *ec2.TerminateInstancesOutput
+	Err        error
+	FailIDs    map[string]error
+	mu         sync.Mutex
+	Called     bool
+	Terminated []string
+}
+
+var _ mintaws.TerminateInstancesAPI = (*TerminateInstances)(nil)
+
+func (m *TerminateInstances) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Called = true
+	for _, id := range params.InstanceIds {
+		if err := m.FailIDs[id]; err != nil {
+			return nil, err
+		}
+	}
+	if m.Err == nil {
+		m.Terminated = append(m.Terminated, params.InstanceIds...)
+	}
+	return m.Output, m.Err
+}
+
+// DescribeVolumes is a mintaws.DescribeVolumesAPI that returns Output and
+// Err.
+type DescribeVolumes struct {
+	Output *ec2.DescribeVolumesOutput
+	Err    error
+}
+
+var _ mintaws.DescribeVolumesAPI = (*DescribeVolumes)(nil)
+
+func (m *DescribeVolumes) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return m.Output, m.Err
+}
+
+// AttachVolume is a mintaws.AttachVolumeAPI that returns Output and Err
+// and records the last input.
+type AttachVolume struct {
+	Output *ec2.AttachVolumeOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.AttachVolumeInput
+}
+
+var _ mintaws.AttachVolumeAPI = (*AttachVolume)(nil)
+
+func (m *AttachVolume) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// DetachVolume is a mintaws.DetachVolumeAPI that returns Output and Err.
+type DetachVolume struct {
+	Output *ec2.DetachVolumeOutput
+	Err    error
+}
+
+var _ mintaws.DetachVolumeAPI = (*DetachVolume)(nil)
+
+func (m *DetachVolume) DetachVolume(ctx context.Context, params *ec2.DetachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DetachVolumeOutput, error) {
+	return m.Output, m.Err
+}
+
+// DescribeAddresses is a mintaws.DescribeAddressesAPI that returns Output
+// and Err and records every call in order.
+type DescribeAddresses struct {
+	Output *ec2.DescribeAddressesOutput
+	Err    error
+	mu     sync.Mutex
+	Inputs []*ec2.DescribeAddressesInput
+}
+
+var _ mintaws.DescribeAddressesAPI = (*DescribeAddresses)(nil)
+
+func (m *DescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	m.mu.Lock()
+	m.Inputs = append(m.Inputs, params)
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// DescribeAccountAttributes is a mintaws.DescribeAccountAttributesAPI that
+// returns Output and Err.
+type DescribeAccountAttributes struct {
+	Output *ec2.DescribeAccountAttributesOutput
+	Err    error
+}
+
+var _ mintaws.DescribeAccountAttributesAPI = (*DescribeAccountAttributes)(nil)
+
+func (m *DescribeAccountAttributes) DescribeAccountAttributes(ctx context.Context, params *ec2.DescribeAccountAttributesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAccountAttributesOutput, error) {
+	return m.Output, m.Err
+}
+
+// EIPLimit returns a DescribeAccountAttributes reporting an Elastic IP
+// quota of limit.
+func EIPLimit(limit int) *DescribeAccountAttributes {
+	return &DescribeAccountAttributes{Output: &ec2.DescribeAccountAttributesOutput{
+		AccountAttributes: []ec2types.AccountAttribute{{
+			AttributeName:   aws.String("vpc-max-elastic-ips"),
+			AttributeValues: []ec2types.AccountAttributeValue{{AttributeValue: aws.String(strconv.Itoa(limit))}},
+		}},
+	}}
+}
+
+// GetMetricData is a mintaws.GetMetricDataAPI that returns Output and Err
+// and records the last input.
+type GetMetricData struct {
+	Output *cloudwatch.GetMetricDataOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *cloudwatch.GetMetricDataInput
+}
+
+var _ mintaws.GetMetricDataAPI = (*GetMetricData)(nil)
+
+func (m *GetMetricData) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// CreateSnapshot is a mintaws.CreateSnapshotAPI that returns Output and Err
+// and records the last input.
+type CreateSnapshot struct {
+	Output *ec2.CreateSnapshotOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.CreateSnapshotInput
+}
+
+var _ mintaws.CreateSnapshotAPI = (*CreateSnapshot)(nil)
+
+func (m *CreateSnapshot) CreateSnapshot(ctx context.Context, params *ec2.CreateSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.CreateSnapshotOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// DescribeSnapshots is a mintaws.DescribeSnapshotsAPI that returns Output
+// and Err and records the last input.
+type DescribeSnapshots struct {
+	Output *ec2.DescribeSnapshotsOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.DescribeSnapshotsInput
+}
+
+var _ mintaws.DescribeSnapshotsAPI = (*DescribeSnapshots)(nil)
+
+func (m *DescribeSnapshots) DescribeSnapshots(ctx context.Context, params *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// StartInstances is a mintaws.StartInstancesAPI that returns Output and Err
+// and records the last call.
+type StartInstances struct {
+	Output *ec2.StartInstancesOutput
+	Err    error
+	mu     sync.Mutex
+	Called bool
+	Input  *ec2.StartInstancesInput
+}
+
+var _ mintaws.StartInstancesAPI = (*StartInstances)(nil)
+
+func (m *StartInstances) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	m.mu.Lock()
+	m.Called = true
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// RunInstances is a mintaws.RunInstancesAPI that returns Output with the
+// next of Errs, then Err once they run out, and records a copy of every
+// input, since callers retrying on-demand after spot reuse theirs.
+type RunInstances struct {
+	Output *ec2.RunInstancesOutput
+	Err    error
+	Errs   []error
+	mu     sync.Mutex
+	Inputs []*ec2.RunInstancesInput
+}
+
+var _ mintaws.RunInstancesAPI = (*RunInstances)(nil)
+
+func (m *RunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	input := *params
+	m.Inputs = append(m.Inputs, &input)
+	if len(m.Errs) > 0 {
+		err := m.Errs[0]
+		m.Errs = m.Errs[1:]
+		return m.Output, err
+	}
+	return m.Output, m.Err
+}
+
+// Input returns the last recorded input, or nil before the first call.
+func (m *RunInstances) Input() *ec2.RunInstancesInput {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.Inputs) == 0 {
+		return nil
+	}
+	return m.Inputs[len(m.Inputs)-1]
+}
+
+// CreateVolume is a mintaws.CreateVolumeAPI that returns Output and Err and
+// records the last input.
+type CreateVolume struct {
+	Output *ec2.CreateVolumeOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.CreateVolumeInput
+}
+
+var _ mintaws.CreateVolumeAPI = (*CreateVolume)(nil)
+
+func (m *CreateVolume) CreateVolume(ctx context.Context, params *ec2.CreateVolumeInput, optFns ...func(*ec2.Options)) (*ec2.CreateVolumeOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// DeleteVolume is a mintaws.DeleteVolumeAPI that returns Output and Err and
+// records the volumes it was asked to delete.
+type DeleteVolume struct {
+	Output  *ec2.DeleteVolumeOutput
+	Err     error
+	mu      sync.Mutex
+	Deleted []string
+}
+
+var _ mintaws.DeleteVolumeAPI = (*DeleteVolume)(nil)
+
+func (m *DeleteVolume) DeleteVolume(ctx context.Context, params *ec2.DeleteVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVolumeOutput, error) {
+	m.mu.Lock()
+	m.Deleted = append(m.Deleted, aws.ToString(params.VolumeId))
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// AllocateAddress is a mintaws.AllocateAddressAPI that returns Output and
+// Err.
+type AllocateAddress struct {
+	Output *ec2.AllocateAddressOutput
+	Err    error
+}
+
+var _ mintaws.AllocateAddressAPI = (*AllocateAddress)(nil)
+
+func (m *AllocateAddress) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
+	return m.Output, m.Err
+}
+
+// AssociateAddress is a mintaws.AssociateAddressAPI that returns Output and
+// Err and records the last input.
+type AssociateAddress struct {
+	Output *ec2.AssociateAddressOutput
+	Err    error
+	mu     sync.Mutex
+	Input  *ec2.AssociateAddressInput
+}
+
+var _ mintaws.AssociateAddressAPI = (*AssociateAddress)(nil)
+
+func (m *AssociateAddress) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	m.mu.Lock()
+	m.Input = params
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// ReleaseAddress is a mintaws.ReleaseAddressAPI that returns Output and Err
+// and records the allocations it was asked to release.
+type ReleaseAddress struct {
+	Output   *ec2.ReleaseAddressOutput
+	Err      error
+	mu       sync.Mutex
+	Released []string
+}
+
+var _ mintaws.ReleaseAddressAPI = (*ReleaseAddress)(nil)
+
+func (m *ReleaseAddress) ReleaseAddress(ctx context.Context, params *ec2.ReleaseAddressInput, optFns ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error) {
+	m.mu.Lock()
+	m.Released = append(m.Released, aws.ToString(params.AllocationId))
+	m.mu.Unlock()
+	return m.Output, m.Err
+}
+
+// CreateTags is a mintaws.CreateTagsAPI that records every input. It fails
+// with Err on every call, or only on call FailOnCall (1-indexed) when that
+// is set.
+type CreateTags struct {
+	Err        error
+	FailOnCall int
+	mu         sync.Mutex
+	Inputs     []*ec2.CreateTagsInput
+}
+
+var _ mintaws.CreateTagsAPI = (*CreateTags)(nil)
+
+func (m *CreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Inputs = append(m.Inputs, params)
+	if m.Err != nil && (m.FailOnCall == 0 || len(m.Inputs) == m.FailOnCall) {
+		return nil, m.Err
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+// DeleteTags is a provision.DeleteTagsAPI that returns Err and records every
+// input.
+type DeleteTags struct {
+	Err    error
+	mu     sync.Mutex
+	Inputs []*ec2.DeleteTagsInput
+}
+
+var _ provision.DeleteTagsAPI = (*DeleteTags)(nil)
+
+func (m *DeleteTags) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	m.mu.Lock()
+	m.Inputs = append(m.Inputs, params)
+	m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+// DescribeVpcs is a mintaws.DescribeVpcsAPI that returns Output and Err.
+type DescribeVpcs struct {
+	Output *ec2.DescribeVpcsOutput
+	Err    error
+}
+
+var _ mintaws.DescribeVpcsAPI = (*DescribeVpcs)(nil)
+
+func (m *DescribeVpcs) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return m.Output, m.Err
+}
+
+// DescribeSubnets is a mintaws.DescribeSubnetsAPI that returns Output and
+// Err.
+type DescribeSubnets struct {
+	Output *ec2.DescribeSubnetsOutput
+	Err    error
+}
+
+var _ mintaws.DescribeSubnetsAPI = (*DescribeSubnets)(nil)
+
+func (m *DescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return m.Output, m.Err
+}
+
+// DescribeSecurityGroups is a mintaws.DescribeSecurityGroupsAPI. A lookup
+// by group ID returns the ByID groups it names, a lookup filtered on a
+// mint:component tag returns ByComponent for that value, and anything else
+// returns Output, or no groups when Output is nil. Err fails every call.
+type DescribeSecurityGroups struct {
+	Output      *ec2.DescribeSecurityGroupsOutput
+	ByComponent map[string]*ec2.DescribeSecurityGroupsOutput
+	ByID        map[string]ec2types.SecurityGroup
+	Err         error
+}
+
+var _ mintaws.DescribeSecurityGroupsAPI = (*DescribeSecurityGroups)(nil)
+
+func (m *DescribeSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if len(params.GroupIds) > 0 {
+		out := &ec2.DescribeSecurityGroupsOutput{}
+		for _, id := range params.GroupIds {
+			if group, ok := m.ByID[id]; ok {
+				out.SecurityGroups = append(out.SecurityGroups, group)
+			}
+		}
+		return out, nil
+	}
+	for _, f := range params.Filters {
+		if aws.ToString(f.Name) == "tag:"+tags.TagComponent && len(f.Values) > 0 {
+			if out, ok := m.ByComponent[f.Values[0]]; ok {
+				return out, nil
+			}
+		}
+	}
+	if m.Output != nil {
+		return m.Output, nil
+	}
+	return &ec2.DescribeSecurityGroupsOutput{}, nil
+}
+
+// DescribeFileSystems is a mintaws.DescribeFileSystemsAPI that returns
+// Output and Err.
+type DescribeFileSystems struct {
+	Output *efs.DescribeFileSystemsOutput
+	Err    error
+}
+
+var _ mintaws.DescribeFileSystemsAPI = (*DescribeFileSystems)(nil)
+
+func (m *DescribeFileSystems) DescribeFileSystems(ctx context.Context, params *efs.DescribeFileSystemsInput, optFns ...func(*efs.Options)) (*efs.DescribeFileSystemsOutput, error) {
+	return m.Output, m.Err
+}
+
+// DescribeImages is a mintaws.DescribeImagesAPI that returns Output and Err.
+type DescribeImages struct {
+	Output *ec2.DescribeImagesOutput
+	Err    error
+}
+
+var _ mintaws.DescribeImagesAPI = (*DescribeImages)(nil)
+
+func (m *DescribeImages) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return m.Output, m.Err
+}
+
+// DescribeVolumesByTag is a mintaws.DescribeVolumesAPI that answers each
+// call with the ByValue volumes for the value of its tag:Key filter.
+type DescribeVolumesByTag struct {
+	Key     string
+	ByValue map[string][]ec2types.Volume
+}
+
+var _ mintaws.DescribeVolumesAPI = (*DescribeVolumesByTag)(nil)
+
+func (m *DescribeVolumesByTag) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	for _, f := range params.Filters {
+		if aws.ToString(f.Name) == "tag:"+m.Key && len(f.Values) > 0 {
+			return &ec2.DescribeVolumesOutput{Volumes: m.ByValue[f.Values[0]]}, nil
+		}
+	}
+	return &ec2.DescribeVolumesOutput{}, nil
+}
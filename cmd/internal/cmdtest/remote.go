@@ -0,0 +1,79 @@
+package cmdtest
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+// RemoteCall is one command run through a RemoteRunner.
+type RemoteCall struct {
+	InstanceID string
+	AZ         string
+	Host       string
+	Port       int
+	User       string
+	Command    []string
+}
+
+// RemoteRunner fakes the cmd package's remote command runner: pass its Run
+// method where a RemoteCommandRunner is expected. The i-th call returns
+// Outputs[i], or fails with Errs[i] when that is set; a call past the end
+// of both returns Output and Err. Every call is recorded.
+type RemoteRunner struct {
+	Outputs [][]byte
+	Errs    []error
+	Output  []byte
+	Err     error
+	mu      sync.Mutex
+	calls   []RemoteCall
+}
+
+// Run records the call and returns its canned result.
+func (r *RemoteRunner) Run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := len(r.calls)
+	r.calls = append(r.calls, RemoteCall{InstanceID: instanceID, AZ: az, Host: host, Port: port, User: user, Command: command})
+	if i < len(r.Errs) && r.Errs[i] != nil {
+		return nil, r.Errs[i]
+	}
+	if i < len(r.Outputs) {
+		return r.Outputs[i], nil
+	}
+	if i < len(r.Errs) {
+		return nil, nil
+	}
+	return r.Output, r.Err
+}
+
+// Calls returns the recorded calls in order.
+func (r *RemoteRunner) Calls() []RemoteCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RemoteCall(nil), r.calls...)
+}
+
+// Commands returns the recorded commands, each joined with spaces.
+func (r *RemoteRunner) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	commands := make([]string, len(r.calls))
+	for i, c := range r.calls {
+		commands[i] = strings.Join(c.Command, " ")
+	}
+	return commands
+}
+
+// LastCall returns the most recent call, or the zero RemoteCall before the
+// first one.
+func (r *RemoteRunner) LastCall() RemoteCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) == 0 {
+		return RemoteCall{}
+	}
+	return r.calls[len(r.calls)-1]
+}
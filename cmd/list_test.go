@@ -11,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 )
 
 // makeInstanceWithTime creates a DescribeInstancesOutput with a launch time.
@@ -79,7 +81,7 @@ func TestListCommand(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		describe       *mockDescribeInstances
+		describe       *cmdtest.DescribeInstances
 		owner          string
 		idleTimeout    int
 		jsonOutput     bool
@@ -90,8 +92,8 @@ func TestListCommand(t *testing.T) {
 	}{
 		{
 			name: "single running VM table output",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -99,8 +101,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "empty list shows no VMs message",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -108,8 +110,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "multiple VMs listed",
-			describe: &mockDescribeInstances{
-				output: makeMultiInstanceOutput(
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiInstanceOutput(
 					makeTestInstance("i-one", "default", "alice", "running", "1.1.1.1", "t3.medium", "complete", recentLaunch),
 					makeTestInstance("i-two", "dev", "alice", "stopped", "", "m6i.xlarge", "complete", oldLaunch),
 				),
@@ -120,8 +122,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "idle timeout warning",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-idle", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", oldLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-idle", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", oldLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60, // 60 min timeout, VM running 3 hours
@@ -129,8 +131,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "no idle warning when under threshold",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-recent", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-recent", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60, // 60 min timeout, VM running 30 min
@@ -138,8 +140,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "no idle warning for stopped VMs",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-stopped", "default", "alice", "stopped", "", "m6i.xlarge", "complete", oldLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-stopped", "default", "alice", "stopped", "", "m6i.xlarge", "complete", oldLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -147,8 +149,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "bootstrap failed indicator",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-fail", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "failed", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-fail", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "failed", recentLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -156,8 +158,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "json output format",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -166,8 +168,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "json output is valid JSON object",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -175,8 +177,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "json empty list",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:       "alice",
 			idleTimeout: 60,
@@ -185,8 +187,8 @@ func TestListCommand(t *testing.T) {
 		},
 		{
 			name: "API error propagates",
-			describe: &mockDescribeInstances{
-				err: errThrottled,
+			describe: &cmdtest.DescribeInstances{
+				Err: errThrottled,
 			},
 			owner:          "alice",
 			idleTimeout:    60,
@@ -206,7 +208,7 @@ func TestListCommand(t *testing.T) {
 			}
 
 			cmd := newListCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -272,8 +274,8 @@ func TestListJSONIncludesVersionFields(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &listDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		idleTimeout:    60 * time.Minute,
@@ -281,7 +283,7 @@ func TestListJSONIncludesVersionFields(t *testing.T) {
 	}
 
 	cmd := newListCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -325,8 +327,8 @@ func TestListJSONVersionFieldsFailOpen(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &listDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-abc456", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc456", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		idleTimeout:    60 * time.Minute,
@@ -334,7 +336,7 @@ func TestListJSONVersionFieldsFailOpen(t *testing.T) {
 	}
 
 	cmd := newListCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -367,8 +369,8 @@ func TestListJSONStructureHasVmsArray(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &listDeps{
-		describe: &mockDescribeInstances{
-			output: &ec2.DescribeInstancesOutput{},
+		describe: &cmdtest.DescribeInstances{
+			Output: &ec2.DescribeInstancesOutput{},
 		},
 		owner:          "alice",
 		idleTimeout:    60 * time.Minute,
@@ -376,7 +378,7 @@ func TestListJSONStructureHasVmsArray(t *testing.T) {
 	}
 
 	cmd := newListCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -405,6 +407,62 @@ func TestListJSONStructureHasVmsArray(t *testing.T) {
 	}
 }
 
+func TestListUptimeHumanAndJSON(t *testing.T) {
+	launched := time.Now().Add(-(51*time.Hour + 20*time.Minute))
+	deps := &listDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", launched),
+		},
+		owner:          "alice",
+		versionChecker: stubVersionChecker(false, nil),
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		buf := new(bytes.Buffer)
+		root := cmdtest.NewRoot()
+		root.AddCommand(newListCommandWithDeps(deps))
+		root.SetOut(buf)
+		root.SetErr(buf)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+
+	if out := run("list"); !strings.Contains(out, "2d 3h") {
+		t.Errorf("human uptime should be humanized as 2d 3h, got:\n%s", out)
+	}
+
+	var result listJSON
+	if err := json.Unmarshal([]byte(run("list", "--json")), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got := result.VMs[0].UptimeSeconds; got < 184800 || got > 184860 {
+		t.Errorf("uptime_seconds = %d, want about 184800", got)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{47*time.Minute + 30*time.Second, "47m"},
+		{2*time.Hour + 5*time.Minute, "2h 5m"},
+		{-time.Hour, "0s"},
+	}
+	for _, tt := range tests {
+		if got := formatUptime(time.Now().Add(-tt.ago)); got != tt.want {
+			t.Errorf("formatUptime(%v ago) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+	if got := formatUptime(time.Time{}); got != "-" {
+		t.Errorf("formatUptime(zero) = %q, want -", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: Spinner wiring
 // ---------------------------------------------------------------------------
@@ -419,8 +477,8 @@ func TestListSpinnerShownInHumanMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &listDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-spin1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-spin1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		idleTimeout:    60 * time.Minute,
@@ -428,7 +486,7 @@ func TestListSpinnerShownInHumanMode(t *testing.T) {
 	}
 
 	cmd := newListCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -457,8 +515,8 @@ func TestListSpinnerSuppressedInJSONMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &listDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-spin2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-spin2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		idleTimeout:    60 * time.Minute,
@@ -466,7 +524,7 @@ func TestListSpinnerSuppressedInJSONMode(t *testing.T) {
 	}
 
 	cmd := newListCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -524,23 +582,23 @@ func TestListMultiVMWarning(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		describe    *mockDescribeInstances
+		describe    *cmdtest.DescribeInstances
 		wantWarning bool
 		wantAbsent  []string
 		wantOutput  []string
 	}{
 		{
 			name: "0 running VMs — no warning",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			wantWarning: false,
 			wantAbsent:  []string{"running VMs", "unnecessary costs"},
 		},
 		{
 			name: "2 running VMs — no warning",
-			describe: &mockDescribeInstances{
-				output: makeMultiInstanceOutput(
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiInstanceOutput(
 					makeTestInstance("i-001", "vm1", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", recentLaunch),
 					makeTestInstance("i-002", "vm2", "alice", "running", "1.1.1.2", "m6i.xlarge", "complete", recentLaunch),
 				),
@@ -550,24 +608,24 @@ func TestListMultiVMWarning(t *testing.T) {
 		},
 		{
 			name: "3 running VMs — warning shown",
-			describe: &mockDescribeInstances{
-				output: makeMultiRunningInstances(3),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiRunningInstances(3),
 			},
 			wantWarning: true,
 			wantOutput:  []string{"3", "running VMs", "unnecessary costs"},
 		},
 		{
 			name: "4 running VMs — warning shown with count",
-			describe: &mockDescribeInstances{
-				output: makeMultiRunningInstances(4),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiRunningInstances(4),
 			},
 			wantWarning: true,
 			wantOutput:  []string{"4", "running VMs", "unnecessary costs"},
 		},
 		{
 			name: "stopped VMs do not count toward warning",
-			describe: &mockDescribeInstances{
-				output: makeMultiInstanceOutput(
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiInstanceOutput(
 					makeTestInstance("i-001", "vm1", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", recentLaunch),
 					makeTestInstance("i-002", "vm2", "alice", "running", "1.1.1.2", "m6i.xlarge", "complete", recentLaunch),
 					makeTestInstance("i-003", "vm3", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
@@ -587,7 +645,7 @@ func TestListMultiVMWarning(t *testing.T) {
 				idleTimeout: 60 * time.Minute,
 			}
 			cmd := newListCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -616,21 +674,21 @@ func TestListJSONRunningVMCount(t *testing.T) {
 	recentLaunch := time.Now().Add(-30 * time.Minute)
 
 	tests := []struct {
-		name              string
-		describe          *mockDescribeInstances
+		name               string
+		describe           *cmdtest.DescribeInstances
 		wantRunningVMCount float64
 	}{
 		{
 			name: "0 running VMs",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			wantRunningVMCount: 0,
 		},
 		{
 			name: "2 running VMs",
-			describe: &mockDescribeInstances{
-				output: makeMultiInstanceOutput(
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiInstanceOutput(
 					makeTestInstance("i-001", "vm1", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", recentLaunch),
 					makeTestInstance("i-002", "vm2", "alice", "running", "1.1.1.2", "m6i.xlarge", "complete", recentLaunch),
 				),
@@ -639,15 +697,15 @@ func TestListJSONRunningVMCount(t *testing.T) {
 		},
 		{
 			name: "3 running VMs",
-			describe: &mockDescribeInstances{
-				output: makeMultiRunningInstances(3),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiRunningInstances(3),
 			},
 			wantRunningVMCount: 3,
 		},
 		{
 			name: "stopped VMs excluded from count",
-			describe: &mockDescribeInstances{
-				output: makeMultiInstanceOutput(
+			describe: &cmdtest.DescribeInstances{
+				Output: makeMultiInstanceOutput(
 					makeTestInstance("i-001", "vm1", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", recentLaunch),
 					makeTestInstance("i-002", "vm2", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
 				),
@@ -666,7 +724,7 @@ func TestListJSONRunningVMCount(t *testing.T) {
 				versionChecker: stubVersionChecker(false, nil),
 			}
 			cmd := newListCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
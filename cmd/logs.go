@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// Log files read by mint logs. Bootstrap output lands in cloud-init's
+// output log unless the VM's bootstrap writes a dedicated log.
+const (
+	bootstrapLogPath       = "/var/log/mint-bootstrap.log"
+	cloudInitOutputLogPath = "/var/log/cloud-init-output.log"
+	cloudInitLogPath       = "/var/log/cloud-init.log"
+)
+
+// logsDeps holds the injectable dependencies for the logs command.
+type logsDeps struct {
+	describe  mintaws.DescribeInstancesAPI
+	sendKey   mintaws.SendSSHPublicKeyAPI
+	owner     string
+	remote    RemoteCommandRunner
+	sshPort   int // ssh_port from config; zero means defaultSSHPort
+	streaming StreamingRemoteRunner
+	// consoleOutput is the fallback when the VM cannot be reached over
+	// SSH. Nil disables the fallback.
+	consoleOutput mintaws.GetConsoleOutputAPI
+	chunkSize     int64            // bytes per remote read for --output; zero means defaultRemoteChunkSize
+	now           func() time.Time // nil uses time.Now
+}
+
+// clock returns the current time from deps.now, or time.Now.
+func (d *logsDeps) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+// newLogsCommand creates the production logs command.
+func newLogsCommand() *cobra.Command {
+	return newLogsCommandWithDeps(nil)
+}
+
+// newLogsCommandWithDeps creates the logs command with explicit
+// dependencies for testing.
+func newLogsCommandWithDeps(deps *logsDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show bootstrap, cloud-init, or systemd unit logs from the VM",
+		Long: "Show logs from the VM over SSH. By default this is the bootstrap output; " +
+			"use --cloud-init for cloud-init's own log or --journal for a systemd unit. " +
+			"--follow keeps printing new lines until interrupted.\n\n" +
+			"--since limits the output to lines from the last duration. Log files are " +
+			"cut at the first line stamped at or after the cutoff (in UTC), and only the " +
+			"rest of the file is read from the VM.\n\n" +
+			"--output saves the log to a file instead of printing it. The file is read " +
+			"in chunks into <file>.partial; when the connection drops, running the same " +
+			"command again resumes where it stopped, provided the log on the VM still has " +
+			"the same bytes. The finished file is checked against a checksum taken on " +
+			"the VM.\n\n" +
+			"When the VM is running but cannot be reached over SSH (for example while it " +
+			"is still booting), mint prints the EC2 console output instead. The console " +
+			"output may lag several minutes behind the VM.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runLogs(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runLogs(cmd, &logsDeps{
+				describe:      clients.ec2Client,
+				sendKey:       clients.sendKey,
+				owner:         clients.owner,
+				remote:        clients.remoteRunner(),
+				sshPort:       clients.sshOptions.Port,
+				streaming:     clients.streamingRemoteRunner(),
+				consoleOutput: clients.ec2Client,
+			})
+		},
+	}
+
+	cmd.Flags().BoolP("follow", "f", false, "Keep printing new log lines until interrupted")
+	cmd.Flags().Bool("bootstrap", false, "Show the bootstrap output (default)")
+	cmd.Flags().Bool("cloud-init", false, "Show cloud-init's log")
+	cmd.Flags().String("journal", "", "Show the journal of a systemd `unit`")
+	cmd.Flags().String("since", "", "Only show lines newer than this age (e.g. 30m, 12h, 7d)")
+	cmd.Flags().String("output", "", "Save the log to `file`, resuming an interrupted download")
+	cmd.MarkFlagsMutuallyExclusive("bootstrap", "cloud-init", "journal")
+	cmd.MarkFlagsMutuallyExclusive("output", "follow")
+	cmd.MarkFlagsMutuallyExclusive("output", "journal")
+	addOwnerFlag(cmd)
+
+	return cmd
+}
+
+// logsScript returns the shell script that prints the selected log on the
+// VM, following it when follow is set and starting at since unless it is
+// zero.
+func logsScript(cmd *cobra.Command, follow bool, since time.Time) string {
+	if unit, _ := cmd.Flags().GetString("journal"); unit != "" {
+		script := "sudo journalctl --no-pager -u " + shellQuote(unit)
+		if !since.IsZero() {
+			script += fmt.Sprintf(" --since @%d", since.Unix())
+		}
+		if follow {
+			script += " -f"
+		}
+		return script
+	}
+
+	show := "sudo cat \"$f\""
+	if follow {
+		show = "sudo tail -n +1 -F \"$f\""
+	}
+	if !since.IsZero() {
+		show = "off=$(" + logOffsetScript(since) + "); sudo tail -c +$((off + 1))"
+		if follow {
+			show += " -F"
+		}
+		show += " -- \"$f\""
+	}
+	return logFileScript(cmd) + "; " + show
+}
+
+// logFileScript returns the shell script that sets $f to the path of the
+// selected log file on the VM.
+func logFileScript(cmd *cobra.Command) string {
+	if cloudInit, _ := cmd.Flags().GetBool("cloud-init"); cloudInit {
+		return "f=" + cloudInitLogPath
+	}
+	return fmt.Sprintf("f=%s; sudo test -f \"$f\" || f=%s", bootstrapLogPath, cloudInitOutputLogPath)
+}
+
+// logOffsetScript returns the shell command that prints the byte offset in
+// $f of the first line stamped at or after since, or the file's length
+// when there is none. The stamp is the line's first date and time in
+// ISO 8601 form, as the bootstrap log and cloud-init.log write them; lines
+// without one go with the line before.
+func logOffsetScript(since time.Time) string {
+	const program = `match($0, /[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9][ T][0-9][0-9]:[0-9][0-9]:[0-9][0-9]/) {` +
+		` ts = substr($0, RSTART, RLENGTH); sub(/T/, " ", ts); if (ts >= cutoff) exit }` +
+		` { off += length($0) + 1 } END { print off + 0 }`
+	return "sudo env LC_ALL=C awk -v cutoff=" + shellQuote(since.UTC().Format(time.DateTime)) +
+		" " + shellQuote(program) + " \"$f\""
+}
+
+// runLogs executes the logs command logic: discover the VM, print the
+// selected log over SSH, and fall back to the EC2 console output when SSH
+// fails.
+func runLogs(cmd *cobra.Command, deps *logsDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var since time.Time
+	if s, _ := cmd.Flags().GetString("since"); s != "" {
+		age, err := format.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = deps.clock().Add(-age)
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+
+	owner := deps.owner
+	if o := ownerOverride(cmd, owner); o != "" {
+		owner = o
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		if other := otherOwnerHint(ctx, deps.describe, owner, vmName); other != "" {
+			return fmt.Errorf("%s", other)
+		}
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		return downloadLogs(cmd, deps, found, since, output)
+	}
+
+	follow, _ := cmd.Flags().GetBool("follow")
+	script := logsScript(cmd, follow, since)
+	w := cmd.OutOrStdout()
+
+	if follow {
+		// The streaming runner captures stdout, so the log goes to stderr
+		// on the VM and is streamed to w as it arrives.
+		remoteCmd := []string{"sh", "-c", shellQuote(script + " >&2")}
+		_, err = deps.streaming(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, remoteCmd, w)
+		if err == nil || ctx.Err() != nil {
+			// Interrupting a follow is how it normally ends.
+			return nil
+		}
+	} else {
+		var out []byte
+		out, err = deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, []string{"sh", "-c", shellQuote(script)})
+		if err == nil {
+			_, err = w.Write(out)
+			return err
+		}
+	}
+
+	sshErr := err
+	if deps.consoleOutput == nil {
+		return fmt.Errorf("reading logs over SSH: %w", sshErr)
+	}
+	console, err := vmConsoleOutput(ctx, deps.consoleOutput, found.ID)
+	if err != nil {
+		return fmt.Errorf("reading logs over SSH: %w; console output is not available either: %v", sshErr, err)
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(),
+		"Note: could not read logs over SSH (%v) — showing the EC2 console output instead, which may lag several minutes behind the VM.\n",
+		sshErr)
+	fmt.Fprint(w, console)
+	if !strings.HasSuffix(console, "\n") {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// downloadLogs saves the selected log file, from since unless it is zero,
+// to output with downloadRemoteFile. There is no console fallback: the
+// console output is not the file that was asked for.
+func downloadLogs(cmd *cobra.Command, deps *logsDeps, found *vm.VM, since time.Time, output string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	port := sshPortOrDefault(deps.sshPort)
+
+	locate := "off=0"
+	if !since.IsZero() {
+		locate = "off=$(" + logOffsetScript(since) + ")"
+	}
+	script := logFileScript(cmd) + "; " + locate + "; printf '%s %s\\n' \"$off\" \"$f\""
+	out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, port, defaultSSHUser, []string{"sh", "-c", shellQuote(script)})
+	if err != nil {
+		return fmt.Errorf("reading logs over SSH: %w", err)
+	}
+	field, path, ok := strings.Cut(strings.TrimSpace(string(out)), " ")
+	offset, err := strconv.ParseInt(field, 10, 64)
+	if !ok || err != nil || path == "" {
+		return fmt.Errorf("locating the log on the VM: unexpected output %q", strings.TrimSpace(string(out)))
+	}
+
+	file := &remoteFile{run: deps.remote, sendKey: deps.sendKey, target: found, port: port, path: path}
+	result, err := downloadRemoteFile(ctx, file, offset, output, deps.chunkSize, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Saved %d bytes of %s to %s.\n", result.Bytes, path, output)
+	return nil
+}
+
+// vmConsoleOutput returns the decoded EC2 console output of instance id.
+func vmConsoleOutput(ctx context.Context, client mintaws.GetConsoleOutputAPI, id string) (string, error) {
+	out, err := client.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(id),
+		Latest:     aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := aws.ToString(out.Output)
+	if encoded == "" {
+		return "", errors.New("no console output yet")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decoding console output: %w", err)
+	}
+	return string(decoded), nil
+}
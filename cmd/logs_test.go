@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+type mockGetConsoleOutput struct {
+	output *ec2.GetConsoleOutputOutput
+	err    error
+	called bool
+}
+
+func (m *mockGetConsoleOutput) GetConsoleOutput(ctx context.Context, params *ec2.GetConsoleOutputInput, optFns ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error) {
+	m.called = true
+	return m.output, m.err
+}
+
+func consoleOutputOf(text string) *ec2.GetConsoleOutputOutput {
+	return &ec2.GetConsoleOutputOutput{Output: aws.String(base64.StdEncoding.EncodeToString([]byte(text)))}
+}
+
+var errSSHRefused = errors.New("remote command failed: exit status 255 (stderr: ssh: connect to host 1.2.3.4 port 41122: Connection refused)")
+
+func runLogsForTest(t *testing.T, deps *logsDeps, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	root := cmdtest.NewRoot(newLogsCommandWithDeps(deps))
+	root.SetOut(&out)
+	root.SetErr(&errOut)
+	root.SetArgs(append([]string{"logs"}, args...))
+	err = root.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestLogsPrintsBootstrapLog(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{Output: []byte("bootstrap: step 1\n")}
+	console := &mockGetConsoleOutput{}
+	stdout, _, err := runLogsForTest(t, &logsDeps{
+		describe:      &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		owner:         "alice",
+		remote:        remote.Run,
+		consoleOutput: console,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "bootstrap: step 1\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	joined := strings.Join(remote.LastCall().Command, " ")
+	for _, want := range []string{bootstrapLogPath, cloudInitOutputLogPath, "sudo cat"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("command %q missing %q", joined, want)
+		}
+	}
+	if console.called {
+		t.Error("console output read although SSH succeeded")
+	}
+}
+
+func TestLogsUsesConfiguredSSHPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		sshPort int
+		want    int
+	}{
+		{"no ssh_port", 0, defaultSSHPort},
+		{"ssh_port set", 2222, 2222},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{Output: []byte("bootstrap: step 1\n")}
+			_, _, err := runLogsForTest(t, &logsDeps{
+				describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+				owner:    "alice",
+				remote:   remote.Run,
+				sshPort:  tt.sshPort,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if remote.LastCall().Port != tt.want {
+				t.Errorf("port = %d, want %d", remote.LastCall().Port, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogsSourceFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant string
+	}{
+		{"cloud-init", []string{"--cloud-init"}, []string{cloudInitLogPath}, bootstrapLogPath},
+		{"journal", []string{"--journal", "mint-reconcile"}, []string{"journalctl", "--no-pager", "mint-reconcile"}, "-f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{Output: []byte("ok\n")}
+			_, _, err := runLogsForTest(t, &logsDeps{
+				describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+				owner:    "alice",
+				remote:   remote.Run,
+			}, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			joined := strings.Join(remote.LastCall().Command, " ")
+			for _, want := range tt.want {
+				if !strings.Contains(joined, want) {
+					t.Errorf("command %q missing %q", joined, want)
+				}
+			}
+			if strings.Contains(joined, tt.notWant) {
+				t.Errorf("command %q should not contain %q", joined, tt.notWant)
+			}
+		})
+	}
+}
+
+func TestLogsSourceFlagsAreExclusive(t *testing.T) {
+	_, _, err := runLogsForTest(t, &logsDeps{}, "--cloud-init", "--journal", "docker")
+	if err == nil {
+		t.Fatal("expected an error for --cloud-init with --journal")
+	}
+}
+
+func TestLogsFollowStreams(t *testing.T) {
+	var gotCmd []string
+	streaming := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string, stderr io.Writer) ([]byte, error) {
+		gotCmd = command
+		fmt.Fprint(stderr, "line 1\nline 2\n")
+		return nil, nil
+	}
+	stdout, _, err := runLogsForTest(t, &logsDeps{
+		describe:  &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		owner:     "alice",
+		streaming: streaming,
+	}, "--follow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "line 1\nline 2\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	joined := strings.Join(gotCmd, " ")
+	if !strings.Contains(joined, "tail -n +1 -F") || !strings.Contains(joined, ">&2") {
+		t.Errorf("follow command = %q, want tail -F redirected to stderr", joined)
+	}
+}
+
+func TestLogsFallsBackToConsoleOutput(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{Err: errSSHRefused}
+	console := &mockGetConsoleOutput{output: consoleOutputOf("[  OK  ] Reached target Cloud-init target.")}
+	stdout, stderr, err := runLogsForTest(t, &logsDeps{
+		describe:      &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		owner:         "alice",
+		remote:        remote.Run,
+		consoleOutput: console,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "[  OK  ] Reached target Cloud-init target.\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(stderr, "console output") || !strings.Contains(stderr, "lag") {
+		t.Errorf("stderr = %q, want a note that the console output may lag", stderr)
+	}
+}
+
+func TestLogsFailsWhenNoSourceAvailable(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{Err: errSSHRefused}
+	tests := []struct {
+		name    string
+		console *mockGetConsoleOutput
+	}{
+		{"console error", &mockGetConsoleOutput{err: errors.New("UnauthorizedOperation")}},
+		{"console empty", &mockGetConsoleOutput{output: &ec2.GetConsoleOutputOutput{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, err := runLogsForTest(t, &logsDeps{
+				describe:      &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+				owner:         "alice",
+				remote:        remote.Run,
+				consoleOutput: tt.console,
+			})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), "Connection refused") || !strings.Contains(err.Error(), "console output") {
+				t.Errorf("error = %v, want both the SSH and console failures", err)
+			}
+			if stdout != "" {
+				t.Errorf("stdout = %q, want empty", stdout)
+			}
+		})
+	}
+}
+
+func TestLogsStoppedVM(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{}
+	console := &mockGetConsoleOutput{}
+	_, _, err := runLogsForTest(t, &logsDeps{
+		describe:      &cmdtest.DescribeInstances{Output: makeStoppedInstanceForExtend("i-abc123", "default", "alice")},
+		owner:         "alice",
+		remote:        remote.Run,
+		consoleOutput: console,
+	})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("error = %v, want not running", err)
+	}
+	if len(remote.Calls()) > 0 || console.called {
+		t.Error("stopped VM should not be read")
+	}
+}
+
+func TestLogsSinceStartsAtCutoff(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"bootstrap", []string{"--since", "90m"}, []string{"cutoff='\\''2026-10-16 10:30:00'\\''", "tail -c +$((off + 1)) --", bootstrapLogPath}},
+		{"journal", []string{"--since", "1d", "--journal", "docker"}, []string{"journalctl", fmt.Sprintf("--since @%d", now.Add(-24*time.Hour).Unix())}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{Output: []byte("ok\n")}
+			_, _, err := runLogsForTest(t, &logsDeps{
+				describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+				owner:    "alice",
+				remote:   remote.Run,
+				now:      func() time.Time { return now },
+			}, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			joined := strings.Join(remote.LastCall().Command, " ")
+			for _, want := range tt.want {
+				if !strings.Contains(joined, want) {
+					t.Errorf("command %q missing %q", joined, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogsSinceRejectsBadDuration(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{}
+	_, _, err := runLogsForTest(t, &logsDeps{remote: remote.Run}, "--since", "yesterday")
+	if err == nil || !strings.Contains(err.Error(), "invalid --since") {
+		t.Fatalf("error = %v, want invalid --since", err)
+	}
+	if len(remote.Calls()) > 0 {
+		t.Error("VM read despite an invalid --since")
+	}
+}
+
+func TestLogsOutputDownloadsFromSinceOffset(t *testing.T) {
+	content := testLogContent()
+	remote := &fakeRemoteFile{content: content, other: []byte("23 " + bootstrapLogPath + "\n")}
+	dest := filepath.Join(t.TempDir(), "bootstrap.log")
+	stdout, _, err := runLogsForTest(t, &logsDeps{
+		describe:  &cmdtest.DescribeInstances{Output: makeRunningInstanceForExtend("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		owner:     "alice",
+		remote:    remote.run,
+		chunkSize: 64,
+	}, "--since", "1h", "--output", dest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("reading download: %v", err)
+	}
+	if !bytes.Equal(got, content[23:]) {
+		t.Errorf("download = %q, want %q", got, content[23:])
+	}
+	if len(remote.reads) == 0 || remote.reads[0] != 23 {
+		t.Errorf("reads at %v, want the first at the --since offset 23", remote.reads)
+	}
+	if !strings.Contains(stdout, "Saved") || !strings.Contains(stdout, dest) {
+		t.Errorf("stdout = %q, want a saved summary", stdout)
+	}
+}
+
+func TestLogsOutputExclusiveWithFollowAndJournal(t *testing.T) {
+	for _, args := range [][]string{{"--follow"}, {"--journal", "docker"}} {
+		_, _, err := runLogsForTest(t, &logsDeps{}, append([]string{"--output", "out.log"}, args...)...)
+		if err == nil {
+			t.Errorf("expected an error for --output with %v", args)
+		}
+	}
+}
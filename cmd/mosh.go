@@ -29,6 +29,7 @@ type moshDeps struct {
 	lookupPath     func(string) (string, error)
 	hostKeyStore   *sshconfig.HostKeyStore
 	hostKeyScanner HostKeyScanner
+	sshOptions     sshconfig.Options
 	// isTerminal reports whether stdin is an interactive terminal.
 	// Defaults to a real os.Stdin TTY check; override in tests.
 	isTerminal func() bool
@@ -60,11 +61,12 @@ func newMoshCommandWithDeps(deps *moshDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runMosh(cmd, &moshDeps{
 				describe:       clients.ec2Client,
-				sendKey:        clients.icClient,
+				sendKey:        clients.sendKey,
 				owner:          clients.owner,
 				lookupPath:     exec.LookPath,
 				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner: defaultHostKeyScanner,
+				sshOptions:     clients.sshOptions,
 				isTerminal:     func() bool { return term.IsTerminal(int(os.Stdin.Fd())) },
 			})
 		},
@@ -124,10 +126,12 @@ func runMosh(cmd *cobra.Command, deps *moshDeps) error {
 			vmName, found.ID, found.State, hint.Cmd("mint up"))
 	}
 
+	port := deps.sshOptions.LoginPort(defaultSSHPort)
+
 	// TOFU host key verification (ADR-0019).
 	var knownHostsPath string
 	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
-		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, defaultSSHPort)
+		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, port)
 		if scanErr != nil {
 			return fmt.Errorf("scanning host key: %w", scanErr)
 		}
@@ -163,7 +167,7 @@ func runMosh(cmd *cobra.Command, deps *moshDeps) error {
 		knownHostsPath = tmpKH.Name()
 		defer os.Remove(knownHostsPath)
 
-		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, defaultSSHPort, hostKeyLine)
+		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, port, hostKeyLine)
 		if _, err := tmpKH.WriteString(hostEntry); err != nil {
 			tmpKH.Close()
 			return fmt.Errorf("writing temp known_hosts: %w", err)
@@ -186,7 +190,7 @@ func runMosh(cmd *cobra.Command, deps *moshDeps) error {
 	// Push public key via Instance Connect.
 	_, err = deps.sendKey.SendSSHPublicKey(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
 		InstanceId:       aws.String(found.ID),
-		InstanceOSUser:   aws.String(defaultSSHUser),
+		InstanceOSUser:   aws.String(deps.sshOptions.LoginUser(defaultSSHUser)),
 		SSHPublicKey:     aws.String(pubKey),
 		AvailabilityZone: aws.String(found.AvailabilityZone),
 	})
@@ -195,17 +199,18 @@ func runMosh(cmd *cobra.Command, deps *moshDeps) error {
 	}
 
 	// Build the ssh sub-command string for mosh --ssh="...".
-	sshCmd := fmt.Sprintf("ssh -p %d -i %s", defaultSSHPort, privKeyPath)
+	sshCmd := fmt.Sprintf("ssh -p %d -i %s", port, privKeyPath)
 	if knownHostsPath != "" {
 		sshCmd += fmt.Sprintf(" -o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s", knownHostsPath)
 	} else {
 		sshCmd += " -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
 	}
+	sshCmd += shellQuoteArgs(deps.sshOptions.Args())
 
 	// Build mosh command arguments.
 	moshArgs := []string{
 		fmt.Sprintf("--ssh=%s", sshCmd),
-		fmt.Sprintf("%s@%s", defaultSSHUser, found.PublicIP),
+		fmt.Sprintf("%s@%s", deps.sshOptions.LoginUser(defaultSSHUser), found.PublicIP),
 	}
 
 	runner := deps.runner
@@ -6,18 +6,19 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 )
 
 func TestMoshCommand(t *testing.T) {
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForSSH
-		sendKey        *mockSendSSHPublicKey
+		describe       *cmdtest.DescribeInstances
+		sendKey        *cmdtest.SendSSHPublicKey
 		owner          string
 		vmName         string
 		lookupMosh     func(string) (string, error) // mock exec.LookPath
@@ -29,11 +30,11 @@ func TestMoshCommand(t *testing.T) {
 	}{
 		{
 			name: "successful mosh to running instance",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			lookupMosh:  func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -69,23 +70,23 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "mosh binary not found returns error",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey:    &mockSendSSHPublicKey{},
-			owner:      "alice",
-			lookupMosh: func(string) (string, error) { return "", fmt.Errorf("not found") },
-			wantErr:    true,
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			owner:          "alice",
+			lookupMosh:     func(string) (string, error) { return "", fmt.Errorf("not found") },
+			wantErr:        true,
 			wantErrContain: "mosh",
 			wantSendKey:    false,
 			wantExec:       false,
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
 			wantErr:        true,
@@ -95,10 +96,10 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
 			wantErr:        true,
@@ -108,10 +109,10 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForSSH{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
 			wantErr:        true,
@@ -121,11 +122,11 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "send public key error propagates",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				err: fmt.Errorf("access denied"),
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Err: fmt.Errorf("access denied"),
 			},
 			owner:          "alice",
 			lookupMosh:     func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -136,11 +137,11 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "non-default vm name",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			vmName:      "dev",
@@ -157,11 +158,11 @@ func TestMoshCommand(t *testing.T) {
 		},
 		{
 			name: "send key called with correct params",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-xyz789", "default", "bob", "5.6.7.8", "eu-west-1b"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-xyz789", "default", "bob", "5.6.7.8", "eu-west-1b"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "bob",
 			lookupMosh:  func(string) (string, error) { return "/usr/bin/mosh", nil },
@@ -191,7 +192,7 @@ func TestMoshCommand(t *testing.T) {
 			}
 
 			cmd := newMoshCommandWithDeps(deps)
-			root := newTestRootForSSH()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -218,8 +219,8 @@ func TestMoshCommand(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if tt.wantSendKey != tt.sendKey.called {
-				t.Errorf("SendSSHPublicKey called = %v, want %v", tt.sendKey.called, tt.wantSendKey)
+			if tt.wantSendKey != tt.sendKey.Called {
+				t.Errorf("SendSSHPublicKey called = %v, want %v", tt.sendKey.Called, tt.wantSendKey)
 			}
 
 			if tt.wantExec {
@@ -234,18 +235,18 @@ func TestMoshCommand(t *testing.T) {
 			}
 
 			// Verify SendSSHPublicKey input when called.
-			if tt.sendKey.called && tt.sendKey.input != nil {
-				if aws.ToString(tt.sendKey.input.InstanceId) == "" {
+			if tt.sendKey.Called && tt.sendKey.Input != nil {
+				if aws.ToString(tt.sendKey.Input.InstanceId) == "" {
 					t.Error("SendSSHPublicKey missing instance ID")
 				}
-				if aws.ToString(tt.sendKey.input.InstanceOSUser) != "ubuntu" {
+				if aws.ToString(tt.sendKey.Input.InstanceOSUser) != "ubuntu" {
 					t.Errorf("SendSSHPublicKey OS user = %q, want ubuntu",
-						aws.ToString(tt.sendKey.input.InstanceOSUser))
+						aws.ToString(tt.sendKey.Input.InstanceOSUser))
 				}
-				if aws.ToString(tt.sendKey.input.AvailabilityZone) == "" {
+				if aws.ToString(tt.sendKey.Input.AvailabilityZone) == "" {
 					t.Error("SendSSHPublicKey missing availability zone")
 				}
-				if aws.ToString(tt.sendKey.input.SSHPublicKey) == "" {
+				if aws.ToString(tt.sendKey.Input.SSHPublicKey) == "" {
 					t.Error("SendSSHPublicKey missing SSH public key")
 				}
 			}
@@ -254,16 +255,16 @@ func TestMoshCommand(t *testing.T) {
 }
 
 func TestMoshCommandEmptyAvailabilityZone(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	var captured capturedCommand
 	deps := &moshDeps{
-		describe:   describe,
-		sendKey:    sendKey,
-		owner:      "alice",
+		describe: describe,
+		sendKey:  sendKey,
+		owner:    "alice",
 		runner: func(name string, args ...string) error {
 			captured.name = name
 			captured.args = args
@@ -285,7 +286,7 @@ func TestMoshCommandEmptyAvailabilityZone(t *testing.T) {
 		t.Errorf("mosh should not have been executed, got: %s %v", captured.name, captured.args)
 	}
 	// SendSSHPublicKey should NOT have been called.
-	if sendKey.called {
+	if sendKey.Called {
 		t.Error("SendSSHPublicKey should not have been called when AZ is empty")
 	}
 }
@@ -293,10 +294,10 @@ func TestMoshCommandEmptyAvailabilityZone(t *testing.T) {
 func TestMoshRequiresInteractiveTerminal(t *testing.T) {
 	hint.IsTTY = false
 
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	deps := &moshDeps{
-		describe:   &mockDescribeForSSH{output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		describe:   &cmdtest.DescribeInstances{Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
 		sendKey:    sendKey,
 		owner:      "alice",
 		runner:     func(name string, args ...string) error { return nil },
@@ -318,17 +319,17 @@ func TestMoshRequiresInteractiveTerminal(t *testing.T) {
 
 	// SendSSHPublicKey (and by extension, the mosh-server start) must NOT be
 	// called when stdin is not a terminal — that is the core of the bug fix.
-	if sendKey.called {
+	if sendKey.Called {
 		t.Error("SendSSHPublicKey should NOT be called when stdin is not a terminal")
 	}
 }
 
 func TestMoshCommandTOFUFirstConnection(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:testfp123", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil)
 
@@ -357,11 +358,11 @@ func TestMoshCommandTOFUFirstConnection(t *testing.T) {
 func TestMoshCommandTOFUKeyMismatch(t *testing.T) {
 	hint.IsTTY = false
 
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil)
 
@@ -395,11 +396,11 @@ func TestMoshCommandTOFUKeyMismatch(t *testing.T) {
 }
 
 func TestMoshCommandTOFUScannerError(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("", "", fmt.Errorf("connection refused"))
 
@@ -420,11 +421,11 @@ func TestMoshCommandTOFUScannerError(t *testing.T) {
 }
 
 func TestMoshCommandUsesStrictHostKeyChecking(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:strictfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIStrict", nil)
 
@@ -452,7 +453,7 @@ func TestMoshCommandUsesStrictHostKeyChecking(t *testing.T) {
 }
 
 // newTOFUMoshDeps creates moshDeps with TOFU support for testing.
-func newTOFUMoshDeps(t *testing.T, describe *mockDescribeForSSH, sendKey *mockSendSSHPublicKey, owner string, scanner HostKeyScanner) (*moshDeps, *capturedCommand) {
+func newTOFUMoshDeps(t *testing.T, describe *cmdtest.DescribeInstances, sendKey *cmdtest.SendSSHPublicKey, owner string, scanner HostKeyScanner) (*moshDeps, *capturedCommand) {
 	t.Helper()
 	dir := t.TempDir()
 	store := sshconfig.NewHostKeyStore(dir)
@@ -480,7 +481,7 @@ func newTOFUMoshDeps(t *testing.T, describe *mockDescribeForSSH, sendKey *mockSe
 func runMoshWithDeps(t *testing.T, deps *moshDeps, vmName string) error {
 	t.Helper()
 	cmd := newMoshCommandWithDeps(deps)
-	root := newTestRootForSSH()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
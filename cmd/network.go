@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+)
+
+// awsProbeTimeout bounds the reachability probe that runs before a
+// command's first AWS call. Without it, an offline laptop waits out the
+// SDK's retries and connect timeouts on every command.
+const awsProbeTimeout = 2 * time.Second
+
+// networkError reports that a command needs AWS but AWS cannot be reached,
+// either because the probe failed or because --offline was given. It exits
+// with exitCodeNetwork.
+type networkError struct {
+	region string
+	// offline is true when --offline was given rather than a probe failing.
+	offline bool
+}
+
+func (e *networkError) Error() string {
+	if e.offline {
+		return "this command needs AWS and cannot run with --offline"
+	}
+	return fmt.Sprintf("AWS unreachable (region %s): check your network or VPN", e.region)
+}
+
+// endpointProber checks that url answers over HTTP with client. Any
+// response, whatever its status, means the endpoint is reachable.
+type endpointProber func(ctx context.Context, client aws.HTTPClient, url string) error
+
+// probeEndpoint is the prober used by probeAWS. Tests replace it to
+// simulate an unreachable endpoint.
+var probeEndpoint endpointProber = headEndpoint
+
+// awsReachability caches probe results for the life of the process, keyed
+// by endpoint, so commands that initialize AWS more than once probe once.
+var awsReachability = struct {
+	sync.Mutex
+	results map[string]error
+}{results: make(map[string]error)}
+
+// probeAWS checks that the regional EC2 endpoint of cfg is reachable and
+// returns a *networkError when it is not. A config without a region is not
+// probed; the SDK reports the missing region itself.
+func probeAWS(ctx context.Context, cfg aws.Config) error {
+	endpoint := ec2Endpoint(cfg)
+	if endpoint == "" {
+		return nil
+	}
+
+	awsReachability.Lock()
+	defer awsReachability.Unlock()
+	err, ok := awsReachability.results[endpoint]
+	if !ok {
+		probeCtx, cancel := context.WithTimeout(ctx, awsProbeTimeout)
+		err = probeEndpoint(probeCtx, cfg.HTTPClient, endpoint)
+		cancel()
+		awsReachability.results[endpoint] = err
+	}
+	if err != nil {
+		return &networkError{region: cfg.Region}
+	}
+	return nil
+}
+
+// ec2Endpoint returns the EC2 endpoint URL for cfg: the configured base
+// endpoint when one is set, otherwise the regional endpoint.
+func ec2Endpoint(cfg aws.Config) string {
+	if cfg.BaseEndpoint != nil && *cfg.BaseEndpoint != "" {
+		return *cfg.BaseEndpoint
+	}
+	if cfg.Region == "" {
+		return ""
+	}
+	suffix := "amazonaws.com"
+	if strings.HasPrefix(cfg.Region, "cn-") {
+		suffix = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://ec2.%s.%s/", cfg.Region, suffix)
+}
+
+// headEndpoint sends a HEAD request to url with client, falling back to a
+// default client when the config has none.
+func headEndpoint(ctx context.Context, client aws.HTTPClient, url string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// offlineCommands are the commands that normally use AWS but can answer
+// from local data when it is unreachable or --offline is given. Keys are
+// command paths without the root command name.
+var offlineCommands = map[string]bool{
+	"project list": true,
+}
+
+// commandWorksOffline reports whether cmd has an offline fallback.
+func commandWorksOffline(cmd *cobra.Command) bool {
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	return offlineCommands[path]
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// useProber installs prober for the test and clears the per-process probe
+// cache before and after.
+func useProber(t *testing.T, prober endpointProber) {
+	t.Helper()
+	saved := probeEndpoint
+	probeEndpoint = prober
+	awsReachability.results = make(map[string]error)
+	t.Cleanup(func() {
+		probeEndpoint = saved
+		awsReachability.results = make(map[string]error)
+	})
+}
+
+// unreachable is a prober for an endpoint that cannot be reached.
+func unreachable(ctx context.Context, client aws.HTTPClient, url string) error {
+	return errors.New("dial tcp: lookup ec2.us-west-2.amazonaws.com: no such host")
+}
+
+// offlineEnv isolates the mint and AWS config so the root command loads a
+// region without reading the developer's files.
+func offlineEnv(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", dir)
+	t.Setenv("AWS_CONFIG_FILE", dir+"/aws-config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", dir+"/aws-credentials")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "us-west-2")
+	return dir
+}
+
+func TestNetworkErrorMessage(t *testing.T) {
+	err := error(&networkError{region: "us-west-2"})
+	if got, want := err.Error(), "AWS unreachable (region us-west-2): check your network or VPN"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := ExitCode(err); got != exitCodeNetwork {
+		t.Errorf("ExitCode = %d, want %d", got, exitCodeNetwork)
+	}
+	if got := ExitCode(errors.New("other")); got != exitCodeFailure {
+		t.Errorf("ExitCode(other) = %d, want %d", got, exitCodeFailure)
+	}
+}
+
+func TestProbeAWSCachesResult(t *testing.T) {
+	calls := 0
+	useProber(t, func(ctx context.Context, client aws.HTTPClient, url string) error {
+		calls++
+		if url != "https://ec2.us-west-2.amazonaws.com/" {
+			t.Errorf("probed %q", url)
+		}
+		if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > awsProbeTimeout {
+			t.Errorf("probe deadline = %v, %v; want within %s", deadline, ok, awsProbeTimeout)
+		}
+		return errors.New("unreachable")
+	})
+
+	cfg := aws.Config{Region: "us-west-2"}
+	for i := 0; i < 3; i++ {
+		var netErr *networkError
+		if err := probeAWS(context.Background(), cfg); !errors.As(err, &netErr) || netErr.region != "us-west-2" {
+			t.Fatalf("probeAWS = %v, want networkError for us-west-2", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("prober called %d times, want 1", calls)
+	}
+}
+
+func TestEC2Endpoint(t *testing.T) {
+	custom := "http://localhost:4566"
+	tests := []struct {
+		cfg  aws.Config
+		want string
+	}{
+		{aws.Config{Region: "us-west-2"}, "https://ec2.us-west-2.amazonaws.com/"},
+		{aws.Config{Region: "cn-north-1"}, "https://ec2.cn-north-1.amazonaws.com.cn/"},
+		{aws.Config{Region: "us-east-1", BaseEndpoint: &custom}, custom},
+		{aws.Config{}, ""},
+	}
+	for _, tt := range tests {
+		if got := ec2Endpoint(tt.cfg); got != tt.want {
+			t.Errorf("ec2Endpoint(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestUnreachableAWSFailsFast(t *testing.T) {
+	offlineEnv(t)
+	useProber(t, unreachable)
+
+	var out bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"list"})
+
+	start := time.Now()
+	err := root.Execute()
+	if elapsed := time.Since(start); elapsed > awsProbeTimeout {
+		t.Errorf("command took %s, want a fast failure", elapsed)
+	}
+	if err == nil || err.Error() != "AWS unreachable (region us-west-2): check your network or VPN" {
+		t.Fatalf("error = %v, want the AWS unreachable message", err)
+	}
+	if ExitCode(err) != exitCodeNetwork {
+		t.Errorf("ExitCode = %d, want %d", ExitCode(err), exitCodeNetwork)
+	}
+}
+
+func TestUnreachableAWSJSONError(t *testing.T) {
+	offlineEnv(t)
+	useProber(t, unreachable)
+
+	var out bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs([]string{"--json", "list"})
+
+	err := root.Execute()
+	if ExitCode(err) != exitCodeNetwork || err.Error() != "" {
+		t.Fatalf("err = %q (exit %d), want silent network exit", err, ExitCode(err))
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"error":"AWS unreachable (region us-west-2): check your network or VPN"}` {
+		t.Errorf("output = %s", got)
+	}
+}
+
+func TestOfflineCapableCommands(t *testing.T) {
+	dir := offlineEnv(t)
+	useProber(t, unreachable)
+
+	fetched := time.Now().Add(-2 * time.Hour)
+	if err := writeProjectListCache(dir, "dev", []projectInfo{{Name: "api", ContainerStatus: "Up 1 hour"}}, fetched); err != nil {
+		t.Fatalf("writeProjectListCache: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantErr     string
+		wantContain []string
+	}{
+		{
+			name:        "project list falls back to the cache when unreachable",
+			args:        []string{"project", "list", "--vm", "dev"},
+			wantContain: []string{"Offline — showing the cached project list", "api", projectStatusOffline},
+		},
+		{
+			name:        "--offline uses the cache",
+			args:        []string{"--offline", "project", "list", "--vm", "dev"},
+			wantContain: []string{"Offline — showing the cached project list"},
+		},
+		{
+			name:    "project list without a cache",
+			args:    []string{"project", "list", "--vm", "other"},
+			wantErr: "no cached project list",
+		},
+		{
+			name:    "--offline fails commands that need AWS",
+			args:    []string{"--offline", "status"},
+			wantErr: "cannot run with --offline",
+		},
+		{
+			name:        "config get never needs AWS",
+			args:        []string{"config", "get", "instance_type"},
+			wantContain: []string{"m6i.xlarge"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			root := NewRootCommand()
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out.String())
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/notify"
+)
+
+// annotationNotify marks commands long enough to notify the user when they
+// finish. addNotifyFlags sets it.
+const annotationNotify = "mint:notify"
+
+// notifyThreshold is how long a command must run before it notifies. A
+// command that finishes sooner has most likely been watched throughout.
+const notifyThreshold = 60 * time.Second
+
+// addNotifyFlags registers --notify and --no-notify on cmd and marks it as
+// a command that notifies when it finishes.
+func addNotifyFlags(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[annotationNotify] = "true"
+	cmd.Flags().Bool("notify", false, "Notify when the command finishes, even if the notify config key is off")
+	cmd.Flags().Bool("no-notify", false, "Do not notify when the command finishes")
+	cmd.MarkFlagsMutuallyExclusive("notify", "no-notify")
+}
+
+// notifyFinished notifies the user that executed has finished, when the
+// notify config key or its flags ask for it. The bell goes to stderr.
+func notifyFinished(executed *cobra.Command, elapsed time.Duration, runErr error) {
+	configured := notify.ModeOff
+	if cfg, err := config.Load(config.DefaultConfigDir()); err == nil {
+		configured = cfg.Notify
+	}
+	notifyFinishedWith(executed, configured, elapsed, runErr, func(mode string) notify.Notifier {
+		return notify.ForMode(mode, os.Stderr)
+	})
+}
+
+// notifyFinishedWith is notifyFinished with the configured mode and the
+// Notifier lookup injected.
+func notifyFinishedWith(
+	executed *cobra.Command,
+	configured string,
+	elapsed time.Duration,
+	runErr error,
+	notifierFor func(mode string) notify.Notifier,
+) {
+	if executed == nil {
+		return
+	}
+	mode := notifyMode(executed, configured, elapsed, runErr)
+	if mode == notify.ModeOff {
+		return
+	}
+	notifierFor(mode).Notify(executed.CommandPath(), notifyMessage(executed, elapsed, runErr))
+}
+
+// notifyMode returns the notify mode for a run of cmd, or notify.ModeOff
+// when the run should not notify: the command is not annotated, it finished
+// within notifyThreshold, its output is JSON, --no-notify was given, or the
+// user interrupted it (and so is at the terminal). --notify turns
+// notifications on when the config key is off.
+func notifyMode(cmd *cobra.Command, configured string, elapsed time.Duration, runErr error) string {
+	if cmd.Annotations[annotationNotify] != "true" || elapsed < notifyThreshold {
+		return notify.ModeOff
+	}
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.JSON {
+		return notify.ModeOff
+	}
+	if errors.Is(runErr, context.Canceled) {
+		return notify.ModeOff
+	}
+	if off, _ := cmd.Flags().GetBool("no-notify"); off {
+		return notify.ModeOff
+	}
+	if on, _ := cmd.Flags().GetBool("notify"); on && (configured == "" || configured == notify.ModeOff) {
+		return notify.ModeAuto
+	}
+	if configured == "" {
+		return notify.ModeOff
+	}
+	return configured
+}
+
+// notifyMessage describes the outcome of a run: the VM it targeted, how
+// long it took, and the first line of its error when it failed.
+func notifyMessage(cmd *cobra.Command, elapsed time.Duration, runErr error) string {
+	var b strings.Builder
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.VM != "" {
+		fmt.Fprintf(&b, "VM %q: ", cliCtx.VM)
+	}
+	took := format.FormatDuration(elapsed.Round(time.Second))
+	if runErr == nil {
+		fmt.Fprintf(&b, "finished in %s", took)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "failed after %s", took)
+	if msg, _, _ := strings.Cut(runErr.Error(), "\n"); msg != "" {
+		fmt.Fprintf(&b, ": %s", msg)
+	}
+	return b.String()
+}
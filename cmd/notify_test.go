@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/notify"
+)
+
+// fakeNotifier records the notifications it is asked to show.
+type fakeNotifier struct {
+	mode  string
+	title string
+	msg   string
+	calls int
+}
+
+func (f *fakeNotifier) Notify(title, message string) {
+	f.calls++
+	f.title, f.msg = title, message
+}
+
+// notifierFor returns a notifier lookup that records the mode into f.
+func (f *fakeNotifier) notifierFor(mode string) notify.Notifier {
+	f.mode = mode
+	return f
+}
+
+// notifyTestRoot runs a mint up stand-in with args and returns the executed
+// command.
+func notifyTestRoot(t *testing.T, annotated bool, args ...string) *cobra.Command {
+	t.Helper()
+	up := &cobra.Command{
+		Use:  "up",
+		RunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	if annotated {
+		addNotifyFlags(up)
+	}
+	root := cmdtest.NewRoot(up)
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs(append([]string{"up"}, args...))
+	executed, err := root.ExecuteC()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return executed
+}
+
+func TestNotifyFinishedTriggers(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		runErr  error
+		wantMsg string
+	}{
+		{name: "success", elapsed: 4*time.Minute + 12*time.Second, wantMsg: `VM "default": finished in 4m 12s`},
+		{name: "failure", elapsed: 90 * time.Second, runErr: fmt.Errorf("bootstrap failed\nsee logs"), wantMsg: `VM "default": failed after 1m 30s: bootstrap failed`},
+		{name: "silent failure", elapsed: 2 * time.Minute, runErr: silentExitError{}, wantMsg: `VM "default": failed after 2m`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeNotifier{}
+			notifyFinishedWith(notifyTestRoot(t, true), notify.ModeAuto, tt.elapsed, tt.runErr, f.notifierFor)
+			if f.calls != 1 || f.mode != notify.ModeAuto || f.title != "mint up" || f.msg != tt.wantMsg {
+				t.Errorf("notified %d times with mode %q: %q %q; want %q", f.calls, f.mode, f.title, f.msg, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestNotifyFinishedSuppression(t *testing.T) {
+	long := 5 * time.Minute
+	tests := []struct {
+		name       string
+		annotated  bool
+		args       []string
+		configured string
+		elapsed    time.Duration
+		runErr     error
+		wantMode   string
+	}{
+		{name: "under threshold", annotated: true, configured: notify.ModeAuto, elapsed: 59 * time.Second},
+		{name: "config off", annotated: true, configured: notify.ModeOff, elapsed: long},
+		{name: "config unset", annotated: true, elapsed: long},
+		{name: "json", annotated: true, args: []string{"--json"}, configured: notify.ModeAuto, elapsed: long},
+		{name: "no-notify", annotated: true, args: []string{"--no-notify"}, configured: notify.ModeBell, elapsed: long},
+		{name: "interrupted", annotated: true, configured: notify.ModeAuto, elapsed: long, runErr: context.Canceled},
+		{name: "not annotated", configured: notify.ModeAuto, elapsed: long},
+		{name: "notify with config off", annotated: true, args: []string{"--notify"}, configured: notify.ModeOff, elapsed: long, wantMode: notify.ModeAuto},
+		{name: "notify keeps configured mode", annotated: true, args: []string{"--notify"}, configured: notify.ModeBell, elapsed: long, wantMode: notify.ModeBell},
+		{name: "notify still under threshold", annotated: true, args: []string{"--notify"}, elapsed: time.Second},
+		{name: "configured desktop", annotated: true, configured: notify.ModeDesktop, elapsed: long, wantMode: notify.ModeDesktop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeNotifier{}
+			notifyFinishedWith(notifyTestRoot(t, tt.annotated, tt.args...), tt.configured, tt.elapsed, tt.runErr, f.notifierFor)
+			if tt.wantMode == "" {
+				if f.calls != 0 {
+					t.Errorf("notified with mode %q, want no notification", f.mode)
+				}
+				return
+			}
+			if f.calls != 1 || f.mode != tt.wantMode {
+				t.Errorf("notified %d times with mode %q, want once with %q", f.calls, f.mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestNotifyFlagsMutuallyExclusive(t *testing.T) {
+	cmd := &cobra.Command{Use: "up", RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	addNotifyFlags(cmd)
+	root := cmdtest.NewRoot(cmd)
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"up", "--notify", "--no-notify"})
+	if err := root.Execute(); err == nil {
+		t.Error("expected an error for --notify with --no-notify")
+	}
+}
+
+func TestLongRunningCommandsNotify(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		newUpCommand(),
+		newRecreateCommand(),
+		newDestroyCommand(),
+		newProjectAddCommand(),
+		newProjectRebuildCommand(),
+	} {
+		if cmd.Annotations[annotationNotify] != "true" || cmd.Flags().Lookup("no-notify") == nil {
+			t.Errorf("mint %s is long-running but does not notify", cmd.Name())
+		}
+	}
+}
+
+func TestNotifyMessageWithoutError(t *testing.T) {
+	executed := notifyTestRoot(t, true, "--vm", "staging")
+	if got := notifyMessage(executed, 61*time.Second, errors.New("")); got != `VM "staging": failed after 1m 1s` {
+		t.Errorf("notifyMessage = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// stealLockFlag takes a VM's operation lock from the mint command holding
+// it.
+const stealLockFlag = "steal-lock"
+
+// addStealLockFlag registers --steal-lock on a mutating lifecycle command.
+func addStealLockFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(stealLockFlag, false, "Take the VM's operation lock even though another mint command holds it")
+}
+
+// operationLockAPI is what a production Locker needs; *ec2.Client
+// implements it.
+type operationLockAPI interface {
+	mintaws.DescribeInstancesAPI
+	mintaws.CreateTagsAPI
+	provision.DeleteTagsAPI
+}
+
+// newOperationLocker returns the Locker a command takes operation locks
+// with. Warnings about a stolen lock go to stderr.
+func newOperationLocker(cmd *cobra.Command, client operationLockAPI) *provision.Locker {
+	return provision.NewLocker(client, client, client).WithWarnings(cmd.ErrOrStderr())
+}
+
+// lockVM takes the operation lock on found for operation, honouring
+// --steal-lock, and returns the function that releases it. A nil locker
+// takes no lock.
+func lockVM(ctx context.Context, cmd *cobra.Command, locker *provision.Locker, found *vm.VM, operation string) (release func(), err error) {
+	if locker == nil {
+		return func() {}, nil
+	}
+	steal, _ := cmd.Flags().GetBool(stealLockFlag)
+	lock, err := locker.Acquire(ctx, found, operation, steal)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		// Best effort: a lock left behind expires on its own after
+		// provision.OperationLockExpiry.
+		_ = lock.Release(context.WithoutCancel(ctx))
+	}, nil
+}
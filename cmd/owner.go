@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// addOwnerFlag adds --owner to a read-only command, letting it inspect a VM
+// that another owner created. Commands that change a VM never take it: mint
+// only modifies the caller's own VMs.
+func addOwnerFlag(cmd *cobra.Command) {
+	cmd.Flags().String("owner", "", "Inspect a VM of this owner instead of your own (read-only)")
+}
+
+// ownerOverride returns the owner named by --owner, or "" when the flag is
+// unset or names the caller.
+func ownerOverride(cmd *cobra.Command, self string) string {
+	owner, _ := cmd.Flags().GetString("owner")
+	owner = strings.TrimSpace(owner)
+	if owner == self {
+		return ""
+	}
+	return owner
+}
+
+// otherVMOwners returns the owners other than owner that have a VM named
+// vmName. The lookup is best effort: its caller is already reporting that
+// owner has no such VM, so a failure returns nil.
+func otherVMOwners(ctx context.Context, describe mintaws.DescribeInstancesAPI, owner, vmName string) []string {
+	if describe == nil {
+		return nil
+	}
+	owners, err := vm.FindVMOwners(ctx, describe, vmName)
+	if err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(owners, func(o string) bool { return o == owner })
+}
+
+// otherOwnerHint returns the message a read-only command reports when owner
+// has no VM named vmName but someone else does, or "" when nobody does.
+func otherOwnerHint(ctx context.Context, describe mintaws.DescribeInstancesAPI, owner, vmName string) string {
+	others := otherVMOwners(ctx, describe, owner, vmName)
+	switch len(others) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("VM %q exists but is owned by %s — use --owner %s to inspect it (read-only)",
+			vmName, others[0], others[0])
+	default:
+		return fmt.Sprintf("VM %q exists but is owned by %s — use --owner with one of them to inspect it (read-only)",
+			vmName, strings.Join(others, ", "))
+	}
+}
+
+// notOwnedError returns the error a command that changes a VM reports when
+// owner has no VM named vmName but someone else does, or nil when nobody
+// does. action is the command, such as "destroy".
+func notOwnedError(ctx context.Context, describe mintaws.DescribeInstancesAPI, owner, vmName, action string) error {
+	others := otherVMOwners(ctx, describe, owner, vmName)
+	if len(others) == 0 {
+		return nil
+	}
+	return fmt.Errorf("VM %q is owned by %s, not you (%s) — mint %s only changes your own VMs",
+		vmName, strings.Join(others, ", "), owner, action)
+}
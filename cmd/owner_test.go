@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+)
+
+// alicesDefaultVM returns a describe client that knows only alice's running
+// VM "default".
+func alicesDefaultVM() *cmdtest.TaggedDescribeInstances {
+	return &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(
+		makeInstanceWithTime("i-alice", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now()),
+	)}
+}
+
+func runOwnerTestCommand(t *testing.T, sub string, args ...string) (string, error) {
+	t.Helper()
+	describe := alicesDefaultVM()
+	cmd := map[string]func() *cobra.Command{
+		"status": func() *cobra.Command {
+			return newStatusCommandWithDeps(&statusDeps{describe: describe, owner: "bob"})
+		},
+		"logs": func() *cobra.Command {
+			remote := &cmdtest.RemoteRunner{Output: []byte("alice's bootstrap log\n")}
+			return newLogsCommandWithDeps(&logsDeps{describe: describe, owner: "bob", remote: remote.Run})
+		},
+	}[sub]()
+	root := cmdtest.NewRoot(cmd)
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{sub}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestReadOnlyCommandsReportOtherOwner(t *testing.T) {
+	const want = `VM "default" exists but is owned by alice — use --owner alice to inspect it (read-only)`
+	for _, sub := range []string{"status", "logs"} {
+		t.Run(sub, func(t *testing.T) {
+			_, err := runOwnerTestCommand(t, sub)
+			if err == nil || err.Error() != want {
+				t.Errorf("error = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestReadOnlyCommandsOwnerFlag(t *testing.T) {
+	tests := []struct {
+		sub  string
+		want string
+	}{
+		{"status", "i-alice"},
+		{"logs", "alice's bootstrap log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sub, func(t *testing.T) {
+			out, err := runOwnerTestCommand(t, tt.sub, "--owner", "alice")
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestMutatingCommandsRefuseOtherOwner(t *testing.T) {
+	t.Run("down", func(t *testing.T) {
+		stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+		root := cmdtest.NewRoot(newDownCommandWithDeps(&downDeps{describe: alicesDefaultVM(), stop: stop, owner: "bob"}))
+		root.SetOut(new(bytes.Buffer))
+		root.SetArgs([]string{"down"})
+		err := root.Execute()
+		if err == nil || !strings.Contains(err.Error(), `VM "default" is owned by alice, not you (bob) — mint down only changes your own VMs`) {
+			t.Errorf("error = %v", err)
+		}
+		if stop.Called {
+			t.Error("stopped another owner's VM")
+		}
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		deps := newHappyDestroyDeps("bob")
+		deps.describe = alicesDefaultVM()
+		terminate := deps.terminate.(*cmdtest.TerminateInstances)
+		root := cmdtest.NewRoot(newDestroyCommandWithDeps(deps))
+		root.SetOut(new(bytes.Buffer))
+		root.SetArgs([]string{"destroy", "--yes"})
+		err := root.Execute()
+		if err == nil || !strings.Contains(err.Error(), "mint destroy only changes your own VMs") {
+			t.Errorf("error = %v", err)
+		}
+		if terminate.Called {
+			t.Error("terminated another owner's VM")
+		}
+	})
+
+	t.Run("owner flag not accepted", func(t *testing.T) {
+		root := cmdtest.NewRoot(newDownCommandWithDeps(&downDeps{describe: alicesDefaultVM(), owner: "bob"}))
+		root.SetOut(new(bytes.Buffer))
+		root.SetErr(new(bytes.Buffer))
+		root.SetArgs([]string{"down", "--owner", "alice"})
+		if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "unknown flag: --owner") {
+			t.Errorf("error = %v, want unknown flag", err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+)
+
+// maxPrefetchImages caps how many images recreate carries over to the new
+// instance. User-data size may cut the list further.
+const maxPrefetchImages = 20
+
+// prefetchCaptureScript lists the registry images on a VM that has
+// devcontainers, one "created<TAB>repo:tag<TAB>digest" line per tag. The
+// devcontainers' own images are built locally and have no digest; the
+// registry images beside them are the bases they were built from, which
+// are what a new VM spends its first project build pulling. Nothing is
+// printed when the VM has no devcontainers.
+const prefetchCaptureScript = `docker ps -aq --filter label=devcontainer.local_folder | grep -q . || exit 0
+docker image ls --digests --format '{{.CreatedAt}}	{{.Repository}}:{{.Tag}}	{{.Digest}}'`
+
+// prefetchCreatedLayout is the layout of docker's CreatedAt column.
+const prefetchCreatedLayout = "2006-01-02 15:04:05 -0700 MST"
+
+// prefetchImagePattern matches image references that are safe to render
+// into the double-quoted MINT_PREFETCH_IMAGES assignment in user-data.
+var prefetchImagePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/:@-]*$`)
+
+// buildPrefetchCaptureCommand returns the remote command that lists the
+// images to prefetch; parsePrefetchImages reads its output.
+func buildPrefetchCaptureCommand() []string {
+	return []string{"sh", "-c", shellQuote(prefetchCaptureScript)}
+}
+
+// parsePrefetchImages returns the registry images in the output of
+// buildPrefetchCaptureCommand, most recently built first and capped at
+// maxPrefetchImages. Locally built images (no digest), untagged images,
+// and references unsafe to put in user-data are skipped.
+func parsePrefetchImages(output []byte) []string {
+	type image struct {
+		ref     string
+		created time.Time
+	}
+	var images []image
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		ref, digest := fields[1], fields[2]
+		if digest == "<none>" || strings.Contains(ref, "<none>") || !prefetchImagePattern.MatchString(ref) || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		// An unparseable date sorts last rather than dropping the image.
+		created, _ := time.Parse(prefetchCreatedLayout, fields[0])
+		images = append(images, image{ref: ref, created: created})
+	}
+	sort.SliceStable(images, func(i, j int) bool { return images[i].created.After(images[j].created) })
+
+	if len(images) > maxPrefetchImages {
+		images = images[:maxPrefetchImages]
+	}
+	refs := make([]string, len(images))
+	for i, img := range images {
+		refs[i] = img.ref
+	}
+	return refs
+}
+
+// printPrefetchSummary reports how many images a new instance will pull in
+// the background and how many were left out to fit in user-data.
+func printPrefetchSummary(w io.Writer, queued, dropped int) {
+	if queued == 0 && dropped == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Prefetch      %d container image(s) queued", queued)
+	if dropped > 0 {
+		fmt.Fprintf(w, " (%d more omitted to fit the %d-byte user-data limit)", dropped, bootstrap.MaxUserDataBytes)
+	}
+	fmt.Fprintln(w)
+}
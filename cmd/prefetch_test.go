@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+)
+
+// prefetchImagesOutput is sample output of the prefetch capture command.
+const prefetchImagesOutput = "2024-03-01 10:00:00 +0000 UTC\tnode:22\tsha256:aaa\n" +
+	"2024-05-01 10:00:00 +0000 UTC\tpostgres:16\tsha256:bbb\n" +
+	"2024-06-01 10:00:00 +0000 UTC\tvsc-myproject-123:latest\t<none>\n"
+
+func TestBuildPrefetchCaptureCommand(t *testing.T) {
+	command := buildPrefetchCaptureCommand()
+	if len(command) != 3 || command[0] != "sh" || command[1] != "-c" {
+		t.Fatalf("command = %v, want sh -c <script>", command)
+	}
+	script := command[2]
+	if !strings.HasPrefix(script, "'") || !strings.HasSuffix(script, "'") {
+		t.Errorf("script should be a single quoted ssh argument: %s", script)
+	}
+	for _, want := range []string{
+		"docker ps -aq --filter label=devcontainer.local_folder",
+		"docker image ls --digests",
+		"{{.CreatedAt}}",
+		"{{.Repository}}:{{.Tag}}",
+		"{{.Digest}}",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestParsePrefetchImages(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{name: "empty", output: "", want: nil},
+		{name: "newest first, local builds skipped", output: prefetchImagesOutput, want: []string{"postgres:16", "node:22"}},
+		{
+			name: "untagged and unsafe refs skipped",
+			output: "2024-01-01 00:00:00 +0000 UTC\t<none>:<none>\tsha256:ccc\n" +
+				"2024-01-01 00:00:00 +0000 UTC\tbad\"ref:1\tsha256:ddd\n" +
+				"2024-01-01 00:00:00 +0000 UTC\tghcr.io/org/img:1.2\tsha256:eee\n",
+			want: []string{"ghcr.io/org/img:1.2"},
+		},
+		{
+			name: "duplicates and malformed lines",
+			output: "garbage\n" +
+				"2024-01-01 00:00:00 +0000 UTC\tnode:22\tsha256:aaa\n" +
+				"2024-01-01 00:00:00 +0000 UTC\tnode:22\tsha256:aaa\n",
+			want: []string{"node:22"},
+		},
+		{
+			name: "unparseable date sorts last",
+			output: "yesterday\tredis:7\tsha256:fff\n" +
+				"2024-01-01 00:00:00 +0000 UTC\tnode:22\tsha256:aaa\n",
+			want: []string{"node:22", "redis:7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePrefetchImages([]byte(tt.output))
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("parsePrefetchImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrefetchImagesCapsList(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < maxPrefetchImages+5; i++ {
+		fmt.Fprintf(&b, "2024-01-%02d 00:00:00 +0000 UTC\timg%02d:latest\tsha256:%d\n", i+1, i, i)
+	}
+	got := parsePrefetchImages([]byte(b.String()))
+	if len(got) != maxPrefetchImages {
+		t.Fatalf("got %d images, want %d", len(got), maxPrefetchImages)
+	}
+	// The newest images are kept.
+	if got[0] != fmt.Sprintf("img%02d:latest", maxPrefetchImages+4) {
+		t.Errorf("first image = %s, want the most recently built", got[0])
+	}
+}
+
+func TestPrintPrefetchSummary(t *testing.T) {
+	tests := []struct {
+		queued, dropped int
+		want            string
+	}{
+		{0, 0, ""},
+		{3, 0, "Prefetch      3 container image(s) queued\n"},
+		{2, 4, "Prefetch      2 container image(s) queued (4 more omitted to fit the 16384-byte user-data limit)\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		printPrefetchSummary(buf, tt.queued, tt.dropped)
+		if buf.String() != tt.want {
+			t.Errorf("printPrefetchSummary(%d, %d) = %q, want %q", tt.queued, tt.dropped, buf.String(), tt.want)
+		}
+	}
+}
+
+func TestRecreateThreadsPrefetchImagesToUserData(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	runner := noSessionsRunner()
+	runner.imagesOut = []byte(prefetchImagesOutput)
+	deps.remoteRun = runner.run
+	deps.journal = provision.NewJournalStore(t.TempDir())
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--verbose"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	if lm.run.Input() == nil {
+		t.Fatal("RunInstances was not called")
+	}
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(lm.run.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if !strings.Contains(string(ud), `MINT_PREFETCH_IMAGES="postgres:16 node:22"`) {
+		t.Errorf("UserData missing the captured images:\n%s", ud)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Captured 2 container image(s) to prefetch on the new instance",
+		"Prefetch      2 container image(s) queued",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// The new instance has the list, so mint up must not reuse it.
+	if images, _ := deps.journal.LoadPrefetch("alice", "default"); images != nil {
+		t.Errorf("prefetch list left behind after launch: %v", images)
+	}
+}
+
+func TestRecreateInterruptedBeforeLaunchKeepsPrefetchList(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	runner := noSessionsRunner()
+	runner.imagesOut = []byte(prefetchImagesOutput)
+	deps.remoteRun = runner.run
+	deps.journal = provision.NewJournalStore(t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deps.terminate = &cancelOnTerminate{next: deps.terminate, cancel: cancel}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+
+	err := root.ExecuteContext(ctx)
+	if ExitCode(err) != exitCodeInterrupted {
+		t.Fatalf("error = %v, want an interrupt", err)
+	}
+	images, loadErr := deps.journal.LoadPrefetch("alice", "default")
+	if loadErr != nil || strings.Join(images, " ") != "postgres:16 node:22" {
+		t.Errorf("LoadPrefetch() = %v, %v; want the captured list for mint up", images, loadErr)
+	}
+}
+
+// cancelOnTerminate cancels the command's context when the instance is
+// terminated, the way Ctrl-C during step 5 would.
+type cancelOnTerminate struct {
+	next   mintaws.TerminateInstancesAPI
+	cancel context.CancelFunc
+}
+
+func (m *cancelOnTerminate) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	m.cancel()
+	return m.next.TerminateInstances(ctx, params, optFns...)
+}
+
+func TestUpUsesAndClearsRecreatePrefetchList(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetContext(cli.WithContext(context.Background(), &cli.CLIContext{VM: "default", Verbose: true}))
+
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+		Instances: []ec2types.Instance{{
+			InstanceId: aws.String("i-test123"),
+			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-test")},
+			}},
+		}},
+	}}
+	journal := provision.NewJournalStore(t.TempDir())
+	if err := journal.SavePrefetch("testuser", "default", []string{"node:22"}); err != nil {
+		t.Fatal(err)
+	}
+	deps := &upDeps{
+		provisioner:         newTestProvisionerCapturingRun(ri),
+		owner:               "testuser",
+		ownerARN:            "arn:aws:iam::123:user/testuser",
+		bootstrapScript:     []byte("#!/bin/bash"),
+		instanceType:        "m6i.xlarge",
+		volumeSize:          50,
+		describeFileSystems: defaultEFSStub(),
+		journal:             journal,
+	}
+
+	if err := runUp(cmd, deps); err != nil {
+		t.Fatalf("runUp error: %v", err)
+	}
+
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(ri.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if !strings.Contains(string(ud), `MINT_PREFETCH_IMAGES="node:22"`) {
+		t.Errorf("UserData missing the saved prefetch list:\n%s", ud)
+	}
+	if !strings.Contains(buf.String(), "Prefetch      1 container image(s) queued") {
+		t.Errorf("verbose output should report the prefetch, got:\n%s", buf.String())
+	}
+	if images, _ := journal.LoadPrefetch("testuser", "default"); images != nil {
+		t.Errorf("prefetch list not cleared after up: %v", images)
+	}
+}
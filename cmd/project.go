@@ -9,8 +9,11 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
 
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
@@ -18,6 +21,7 @@ import (
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/dirarchive"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
@@ -29,9 +33,27 @@ type projectAddDeps struct {
 	sendKey         mintaws.SendSSHPublicKeyAPI
 	owner           string
 	remote          RemoteCommandRunner
+	sshPort         int // ssh_port from config; zero means defaultSSHPort
 	streamingRunner StreamingRemoteRunner
 	hostKeyStore    *sshconfig.HostKeyStore
 	hostKeyScanner  HostKeyScanner
+	// cacheDir holds the per-VM project cache that records last-known
+	// container states. Empty disables recording.
+	cacheDir string
+	// overrideDir holds per-project devcontainer override files. Empty
+	// disables the default lookup; --override still applies.
+	overrideDir string
+	// projectHosts rewrites the VM's project Host blocks in ~/.ssh/config
+	// once the container is built. nil disables it.
+	projectHosts *projectHosts
+	// sleep waits between devcontainer build retries. nil uses a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+	// checkSSHAgent verifies a local SSH agent has keys for --auth agent.
+	// nil uses ssh-add.
+	checkSSHAgent func(ctx context.Context) error
+	// gitToken returns the token for --auth https-token. nil reads
+	// MINT_GIT_TOKEN or the keychain.
+	gitToken func(ctx context.Context) (string, error)
 }
 
 // projectListDeps holds the injectable dependencies for the project list command.
@@ -40,6 +62,9 @@ type projectListDeps struct {
 	sendKey  mintaws.SendSSHPublicKeyAPI
 	owner    string
 	remote   RemoteCommandRunner
+	sshPort  int // ssh_port from config; zero means defaultSSHPort
+	// cacheDir holds the per-VM project list cache. Empty disables caching.
+	cacheDir string
 }
 
 // projectRebuildDeps holds the injectable dependencies for the project rebuild command.
@@ -48,10 +73,40 @@ type projectRebuildDeps struct {
 	sendKey         mintaws.SendSSHPublicKeyAPI
 	owner           string
 	remote          RemoteCommandRunner
+	sshPort         int // ssh_port from config; zero means defaultSSHPort
 	streamingRunner StreamingRemoteRunner
 	stdin           io.Reader
 	hostKeyStore    *sshconfig.HostKeyStore
 	hostKeyScanner  HostKeyScanner
+	// cacheDir holds the per-VM project cache that records last-known
+	// container states. Empty disables recording.
+	cacheDir string
+	// overrideDir holds per-project devcontainer override files. Empty
+	// disables the default lookup; --override still applies.
+	overrideDir string
+	// projectHosts rewrites the VM's project Host blocks in ~/.ssh/config
+	// once the container is built. nil disables it.
+	projectHosts *projectHosts
+	// sleep waits between devcontainer build retries. nil uses a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// projectRemoveDeps holds the injectable dependencies for the project remove command.
+type projectRemoveDeps struct {
+	describe       mintaws.DescribeInstancesAPI
+	sendKey        mintaws.SendSSHPublicKeyAPI
+	owner          string
+	remote         RemoteCommandRunner
+	sshPort        int // ssh_port from config; zero means defaultSSHPort
+	stdin          io.Reader
+	hostKeyStore   *sshconfig.HostKeyStore
+	hostKeyScanner HostKeyScanner
+	// cacheDir holds the per-VM project cache, from which the removed
+	// project is dropped. Empty disables it.
+	cacheDir string
+	// projectHosts rewrites the VM's project Host blocks in ~/.ssh/config
+	// once the container is gone. nil disables it.
+	projectHosts *projectHosts
 }
 
 // projectInfo represents a project on the VM with its container status.
@@ -59,6 +114,12 @@ type projectInfo struct {
 	Name            string `json:"name"`
 	ContainerStatus string `json:"container_status"`
 	Image           string `json:"image"`
+	// Subdir is the checked-out subdirectory of a project added with
+	// --subdir.
+	Subdir string `json:"subdir,omitempty"`
+	// CachedAt is set when the row comes from the local cache because the
+	// VM is stopped.
+	CachedAt *time.Time `json:"cached_at,omitempty"`
 }
 
 // newProjectCommand creates the parent "project" command with subcommands attached.
@@ -78,6 +139,9 @@ func newProjectCommandWithDeps(deps *projectAddDeps) *cobra.Command {
 	cmd.AddCommand(newProjectAddCommandWithDeps(deps))
 	cmd.AddCommand(newProjectListCommand())
 	cmd.AddCommand(newProjectRebuildCommand())
+	cmd.AddCommand(newProjectRemoveCommand())
+	cmd.AddCommand(newProjectStartCommand())
+	cmd.AddCommand(newProjectStatsCommand())
 
 	return cmd
 }
@@ -113,6 +177,51 @@ func newProjectCommandWithRebuildDeps(rebuildDeps *projectRebuildDeps) *cobra.Co
 	return cmd
 }
 
+// newProjectCommandWithRemoveDeps creates the project command tree with explicit
+// remove dependencies for testing.
+func newProjectCommandWithRemoveDeps(removeDeps *projectRemoveDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage projects on the VM",
+		Long:  "Clone repositories, build devcontainers, and manage projects on the VM.",
+	}
+
+	cmd.AddCommand(newProjectListCommand())
+	cmd.AddCommand(newProjectRemoveCommandWithDeps(removeDeps))
+
+	return cmd
+}
+
+// newProjectCommandWithStartDeps creates the project command tree with explicit
+// start dependencies for testing.
+func newProjectCommandWithStartDeps(startDeps *projectStartDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage projects on the VM",
+		Long:  "Clone repositories, build devcontainers, and manage projects on the VM.",
+	}
+
+	cmd.AddCommand(newProjectListCommand())
+	cmd.AddCommand(newProjectStartCommandWithDeps(startDeps))
+
+	return cmd
+}
+
+// newProjectCommandWithStatsDeps creates the project command tree with explicit
+// stats dependencies for testing.
+func newProjectCommandWithStatsDeps(statsDeps *projectStatsDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage projects on the VM",
+		Long:  "Clone repositories, build devcontainers, and manage projects on the VM.",
+	}
+
+	cmd.AddCommand(newProjectListCommand())
+	cmd.AddCommand(newProjectStatsCommandWithDeps(statsDeps))
+
+	return cmd
+}
+
 // newProjectAddCommand creates the production project add subcommand.
 func newProjectAddCommand() *cobra.Command {
 	return newProjectAddCommandWithDeps(nil)
@@ -122,12 +231,28 @@ func newProjectAddCommand() *cobra.Command {
 // dependencies for testing.
 func newProjectAddCommandWithDeps(deps *projectAddDeps) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add <git-url>",
-		Short: "Clone a repo and optionally build its devcontainer",
+		Use:         "add <git-url | local-dir>",
+		Short:       "Clone a repo or push a local directory and optionally build its devcontainer",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
 		Long: "Clone a git repository to /mint/projects/<name> on the VM. " +
-			"If the repo contains a .devcontainer/ directory or .devcontainer.json file, " +
+			"If the repo contains .devcontainer/devcontainer.json or .devcontainer.json, " +
 			"runs devcontainer up to build the development container. " +
-			"Projects without devcontainer config are cloned only.",
+			"Projects without devcontainer config, or added with --no-devcontainer, get a " +
+			"plain tmux session on the VM instead.\n\n" +
+			"With --subdir, only that subdirectory of a monorepo is checked out (a sparse, " +
+			"partial clone), the project is named after the subdirectory, and the devcontainer " +
+			"in the subdirectory is used when there is one, otherwise the repository root's.\n\n" +
+			"An argument that is an existing local directory is pushed to the VM instead of cloned " +
+			"(the project is named after the directory). Files matched by its .gitignore files are " +
+			"left out, as are .git objects over --max-git-object-size. Use --local to require a " +
+			"directory, or --local=false to treat the argument as a git URL even when a directory " +
+			"of that name exists.\n\n" +
+			"The clone is anonymous by default. For a private repo, --auth agent forwards your " +
+			"local SSH agent for the clone only (SSH URLs), and --auth https-token answers git's " +
+			"password prompt with a token from " + gitTokenEnv + " or the keychain entry " +
+			gitTokenKeychainService + " (https:// URLs). The token is never written to disk or " +
+			"shown.\n\n" +
+			devcontainerOverrideHelp,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -140,24 +265,36 @@ func newProjectAddCommandWithDeps(deps *projectAddDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runProjectAdd(cmd, &projectAddDeps{
 				describe:        clients.ec2Client,
-				sendKey:         clients.icClient,
+				sendKey:         clients.sendKey,
 				owner:           clients.owner,
-				remote:          defaultRemoteRunner,
-				streamingRunner: defaultStreamingRemoteRunner,
+				remote:          clients.remoteRunner(),
+				sshPort:         clients.sshOptions.Port,
+				streamingRunner: clients.streamingRemoteRunner(),
 				hostKeyStore:    sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner:  defaultHostKeyScanner,
+				cacheDir:        configDir,
+				overrideDir:     filepath.Join(configDir, devcontainerOverridesDirName),
+				projectHosts:    newProjectHosts(cmd, clients),
 			}, args[0])
 		},
 	}
 
-	cmd.Flags().String("name", "", "Override the project name (default: derived from git URL)")
+	cmd.Flags().String("name", "", "Override the project name (default: derived from git URL or directory name)")
 	cmd.Flags().String("branch", "", "Branch to clone")
+	cmd.Flags().String("subdir", "", "Check out only this subdirectory of the repo (sparse clone)")
+	cmd.Flags().Bool("no-devcontainer", false, "Skip the devcontainer build even when the repo has config")
+	addCloneAuthFlag(cmd)
+	addLocalPushFlags(cmd)
+	addDevcontainerOverrideFlags(cmd)
+	addNotifyFlags(cmd)
+	cmd.MarkFlagsMutuallyExclusive("no-devcontainer", "override")
 
 	return cmd
 }
 
-// runProjectAdd executes the project add logic: discover VM, clone repo,
-// detect devcontainer config, and optionally build the devcontainer.
+// runProjectAdd executes the project add logic: discover VM, clone repo or
+// push the local directory, detect devcontainer config, and optionally build
+// the devcontainer.
 func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) error {
 	ctx := cmd.Context()
 	if ctx == nil {
@@ -170,13 +307,32 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 		vmName = cliCtx.VM
 	}
 
-	// Expand GitHub shorthand "owner/repo" → full HTTPS URL.
-	gitURL = expandGitHubShorthand(gitURL)
-
-	// Derive project name from URL or --name flag.
-	projectName, err := extractProjectName(gitURL)
+	localDir, err := resolveLocalProjectDir(cmd, gitURL)
 	if err != nil {
-		return fmt.Errorf("invalid git URL %q: %w", gitURL, err)
+		return err
+	}
+
+	// Derive project name from the directory, URL, or --name flag.
+	var projectName string
+	if localDir != "" {
+		projectName = filepath.Base(localDir)
+	} else {
+		// Expand GitHub shorthand "owner/repo" → full HTTPS URL.
+		gitURL = expandGitHubShorthand(gitURL)
+
+		projectName, err = extractProjectName(gitURL)
+		if err != nil {
+			return fmt.Errorf("invalid git URL %q: %w", gitURL, err)
+		}
+	}
+
+	// --subdir names the project after the subdirectory unless --name is set.
+	subdir, _ := cmd.Flags().GetString("subdir")
+	if subdir != "" {
+		if err := validateSubdir(subdir); err != nil {
+			return err
+		}
+		projectName = path.Base(subdir)
 	}
 
 	nameOverride, _ := cmd.Flags().GetString("name")
@@ -185,10 +341,39 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 	}
 
 	if err := validateProjectName(projectName); err != nil {
+		if localDir != "" && nameOverride == "" {
+			return fmt.Errorf("%w — pass --name to choose one", err)
+		}
 		return err
 	}
 
 	branch, _ := cmd.Flags().GetString("branch")
+	noDevcontainer, _ := cmd.Flags().GetBool("no-devcontainer")
+
+	// A pushed directory is read before the VM is contacted, so a bad
+	// path or unreadable file fails fast.
+	var localTree *dirarchive.Tree
+	if localDir != "" {
+		if localTree, err = scanLocalProject(cmd, localDir); err != nil {
+			return err
+		}
+	}
+	// Only a git repository has the git config project list reads and a
+	// git identity to report.
+	isGit := localTree == nil || localTree.Git
+
+	override, err := resolveDevcontainerOverride(cmd, deps.overrideDir, projectName)
+	if err != nil {
+		return err
+	}
+
+	// The clone's credentials are checked before the VM is contacted.
+	var auth cloneAuth
+	if localTree == nil {
+		if auth, err = resolveCloneAuth(ctx, cmd, deps, gitURL); err != nil {
+			return err
+		}
+	}
 
 	// Discover VM by owner + VM name.
 	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
@@ -221,11 +406,37 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 	dirExists := false
 	containerID := ""
 	hasDevcontainer := false
+	workspace := projectPath
+
+	// detectDevcontainer sets workspace and hasDevcontainer from the
+	// devcontainer config present in the clone. With --no-devcontainer the
+	// config is ignored.
+	detectDevcontainer := func() {
+		if noDevcontainer {
+			return
+		}
+		if subdir == "" {
+			_, devcontainerErr := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildDevcontainerCheckCommand(projectPath))
+			hasDevcontainer = devcontainerErr == nil
+			return
+		}
+		hasConfig := func(dir string) bool {
+			_, err := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildDevcontainerTestCommand(dir))
+			return err == nil
+		}
+		var usedRoot bool
+		workspace, hasDevcontainer, usedRoot = selectSubdirWorkspace(hasConfig, projectPath, subdir)
+		if usedRoot {
+			fmt.Fprintf(w, "No devcontainer config in %s, using the repository root's devcontainer.\n", subdir)
+		}
+	}
 
 	// Check 1: Does the project directory exist?
 	dirCheckCmd := []string{"test", "-d", projectPath}
 	_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, dirCheckCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, dirCheckCmd)
 	if err != nil {
 		if isTOFUError(err) {
 			return err
@@ -235,9 +446,7 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 		dirExists = true
 
 		// Check 2: Does the project have devcontainer config?
-		_, devcontainerErr := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-			found.PublicIP, defaultSSHPort, defaultSSHUser, buildDevcontainerCheckCommand(projectPath))
-		hasDevcontainer = devcontainerErr == nil
+		detectDevcontainer()
 
 		if hasDevcontainer {
 			// Check 3: Is a container running for this project?
@@ -245,8 +454,11 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 				"docker", "ps", "-q",
 				"--filter", fmt.Sprintf("label=devcontainer.local_folder=%s", projectPath),
 			}
+			if workspace != projectPath {
+				containerCheckCmd[len(containerCheckCmd)-1] = shellQuote("label=devcontainer.local_folder=" + workspace)
+			}
 			containerOutput, containerErr := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-				found.PublicIP, defaultSSHPort, defaultSSHUser, containerCheckCmd)
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, containerCheckCmd)
 			if containerErr == nil {
 				containerID = strings.TrimSpace(string(containerOutput))
 			}
@@ -270,55 +482,178 @@ func runProjectAdd(cmd *cobra.Command, deps *projectAddDeps, gitURL string) erro
 		streaming = defaultStreamingRemoteRunner
 	}
 
-	// Clone step: skip if directory already exists.
-	if !dirExists {
+	// Clone or push step: skip if directory already exists.
+	if !dirExists && localTree != nil {
+		fmt.Fprintf(w, "Pushing %s (%s)...\n", localDir, describeLocalPush(localTree))
+		limit, _ := cmd.Flags().GetString("max-git-object-size")
+		if warning := skippedGitObjectsWarning(localTree, limit); warning != "" {
+			fmt.Fprintln(w, warning)
+		}
+		if err := pushLocalProject(ctx, streaming, deps, found, localTree, projectPath, os.Stderr); err != nil {
+			return fmt.Errorf("pushing %s: %w", localDir, err)
+		}
+
+		detectDevcontainer()
+	} else if !dirExists {
 		fmt.Fprintf(w, "Cloning %s...\n", gitURL)
 		cloneCmd := buildCloneCommand(gitURL, projectPath, branch)
+		if subdir != "" {
+			cloneCmd = buildSparseCloneCommand(gitURL, projectPath, branch)
+		}
 		var cloneStderr bytes.Buffer
-		_, err = streaming(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-			found.PublicIP, defaultSSHPort, defaultSSHUser, cloneCmd,
+		_, err = streaming(auth.context(ctx), deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, auth.command(cloneCmd),
 			io.MultiWriter(os.Stderr, &cloneStderr))
 		if err != nil {
-			return classifyCloneError(gitURL, err, cloneStderr.String())
+			return classifyCloneError(gitURL, auth.mode, err, cloneStderr.String())
+		}
+
+		if subdir != "" {
+			// The checkout fetches file contents, so it authenticates
+			// like the clone.
+			fmt.Fprintf(w, "Checking out %s...\n", subdir)
+			_, err = streaming(auth.context(ctx), deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, auth.command(buildSparseCheckoutCommand(projectPath, subdir)), os.Stderr)
+			if err != nil {
+				return fmt.Errorf("checking out %s: %w", subdir, err)
+			}
+			// Record the subdirectory in the clone's git config so project
+			// list can show it.
+			_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildRecordSubdirCommand(projectPath, subdir))
+			if err != nil {
+				return fmt.Errorf("recording subdirectory: %w", err)
+			}
 		}
 
 		// After cloning, check if devcontainer config exists.
-		_, devcontainerErr := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-			found.PublicIP, defaultSSHPort, defaultSSHUser, buildDevcontainerCheckCommand(projectPath))
-		hasDevcontainer = devcontainerErr == nil
+		detectDevcontainer()
 	} else if hasDevcontainer && containerID == "" {
 		fmt.Fprintf(w, "Found existing clone for %q, resuming from devcontainer build.\n", projectName)
 	}
 
-	// If no devcontainer config, we are done after cloning.
+	// reportIdentity tells the user which git identity the new clone
+	// commits with. It runs last so a build failure is not buried under it.
+	reportIdentity := func() {
+		if dirExists || !isGit {
+			return
+		}
+		reportGitIdentity(w, func(command []string) ([]byte, error) {
+			return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+		}, projectPath)
+	}
+
+	// Without devcontainer config the project gets a plain tmux session
+	// on the VM instead of a container.
 	if !hasDevcontainer {
-		fmt.Fprintf(w, "No devcontainer config detected, skipping container build.\n")
+		sessionDir := projectPath
+		if subdir != "" {
+			sessionDir = projectPath + "/" + subdir
+		}
+		if isGit {
+			if _, err := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildRecordNoDevcontainerCommand(projectPath)); err != nil {
+				fmt.Fprintf(w, "Warning: could not record that %q has no devcontainer: %v\n", projectName, err)
+			}
+		}
+		_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildPlainSessionCommand(projectName, sessionDir))
+		if err != nil {
+			return fmt.Errorf("creating tmux session: %w", err)
+		}
+		if noDevcontainer {
+			fmt.Fprintf(w, "Skipping devcontainer (--no-devcontainer) — created plain tmux session\n")
+		} else {
+			fmt.Fprintf(w, "No devcontainer config found — created plain tmux session (add one and run %s later)\n",
+				hint.Cmd("mint project rebuild"))
+		}
+		reportIdentity()
 		fmt.Fprintf(w, "\nProject %q ready at %s\n", projectName, projectPath)
 		return nil
 	}
 
 	// Build step: run devcontainer up.
-	fmt.Fprintf(w, "Building devcontainer...\n")
 	buildCmd := []string{"devcontainer", "up", "--workspace-folder", projectPath}
-	_, err = streaming(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, buildCmd, os.Stderr)
+	if workspace != projectPath {
+		buildCmd = []string{"devcontainer", "up", "--workspace-folder", shellQuote(workspace)}
+	}
+	buildCmd = append(buildCmd, devcontainerGPUArgs(found)...)
+	if override != nil {
+		fmt.Fprintf(w, "Applying devcontainer override from %s\n", override.path)
+		mergedPath, err := applyDevcontainerOverride(func(command []string) ([]byte, error) {
+			return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+		}, projectPath, workspace, override)
+		if err != nil {
+			return err
+		}
+		buildCmd = append(buildCmd, "--config", mergedPath)
+	}
+	fmt.Fprintf(w, "Building devcontainer...\n")
+	err = runDevcontainerBuild(ctx, w, streaming, deps.sendKey, found, sshPortOrDefault(deps.sshPort), buildCmd, deps.sleep)
 	if err != nil {
 		return fmt.Errorf("building devcontainer: %w", err)
 	}
+	recordStartedProject(deps.cacheDir, vmName, projectName)
+	refreshProjectHosts(ctx, w, deps.projectHosts, remote, deps.sendKey, vmName, found, projectName)
+	reportIdentity()
 
 	fmt.Fprintf(w, "\nProject %q ready at %s\n", projectName, projectPath)
 	return nil
 }
 
 // buildDevcontainerCheckCommand constructs the remote command that tests for
-// the presence of a .devcontainer/ directory or .devcontainer.json file.
+// a .devcontainer/devcontainer.json or .devcontainer.json file. A
+// .devcontainer/ directory without devcontainer.json does not count:
+// devcontainer up cannot build from it.
 func buildDevcontainerCheckCommand(projectPath string) []string {
 	return []string{
 		"sh", "-c",
-		fmt.Sprintf("test -d %s/.devcontainer -o -f %s/.devcontainer.json", projectPath, projectPath),
+		fmt.Sprintf("test -f %s/.devcontainer/devcontainer.json -o -f %s/.devcontainer.json", projectPath, projectPath),
 	}
 }
 
+// buildDevcontainerTestCommand is buildDevcontainerCheckCommand for a
+// directory that may need quoting, such as a --subdir workspace.
+func buildDevcontainerTestCommand(dir string) []string {
+	return []string{
+		"test", "-f", shellQuote(dir + "/.devcontainer/devcontainer.json"),
+		"-o", "-f", shellQuote(dir + "/.devcontainer.json"),
+	}
+}
+
+// buildRecordNoDevcontainerCommand stores mint.devcontainer=false in the
+// clone's git config, where project list reads it to tell projects without
+// a devcontainer from those whose container is missing.
+func buildRecordNoDevcontainerCommand(projectPath string) []string {
+	return []string{"git", "-C", projectPath, "config", "mint.devcontainer", "false"}
+}
+
+// buildPlainSessionCommand constructs the remote command that creates a
+// detached tmux session named after the project with its shell in dir, unless
+// the session already exists.
+func buildPlainSessionCommand(projectName, dir string) []string {
+	script := fmt.Sprintf("tmux has-session -t %s 2>/dev/null || tmux new-session -d -s %s -c %s",
+		projectName, projectName, shellQuote(dir))
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// selectSubdirWorkspace picks the devcontainer workspace for a project added
+// with --subdir: the subdirectory when it has devcontainer config, otherwise
+// the repository root when that does. hasDevcontainer is false when neither
+// has config; usedRoot reports the fallback to the root.
+func selectSubdirWorkspace(hasConfig func(dir string) bool, projectPath, subdir string) (workspace string, hasDevcontainer, usedRoot bool) {
+	subdirPath := projectPath + "/" + subdir
+	if hasConfig(subdirPath) {
+		return subdirPath, true, false
+	}
+	if hasConfig(projectPath) {
+		return projectPath, true, true
+	}
+	return subdirPath, false, false
+}
+
 // buildCloneCommand constructs the git clone command arguments.
 //
 // Three env vars ensure the clone is fully anonymous — no credential helpers,
@@ -339,14 +674,7 @@ func buildDevcontainerCheckCommand(projectPath string) []string {
 // and an SSH-key clone for git@ URLs, with no dependence on any credential
 // helper that may be installed on the VM.
 func buildCloneCommand(gitURL, projectPath, branch string) []string {
-	cmd := []string{
-		"env",
-		"GIT_TERMINAL_PROMPT=0",
-		"GIT_CONFIG_NOSYSTEM=1",
-		"GIT_CONFIG_GLOBAL=/dev/null",
-		"git",
-		"clone",
-	}
+	cmd := append(gitEnv(), "git", "clone")
 	if branch != "" {
 		cmd = append(cmd, "--branch", branch)
 	}
@@ -354,8 +682,64 @@ func buildCloneCommand(gitURL, projectPath, branch string) []string {
 	return cmd
 }
 
+// buildSparseCloneCommand constructs the git clone command for a --subdir
+// project: a partial clone that fetches file contents on demand and checks
+// out only the repository root until buildSparseCheckoutCommand runs.
+func buildSparseCloneCommand(gitURL, projectPath, branch string) []string {
+	cmd := append(gitEnv(), "git", "clone", "--filter=blob:none", "--sparse")
+	if branch != "" {
+		cmd = append(cmd, "--branch", branch)
+	}
+	return append(cmd, gitURL, projectPath)
+}
+
+// buildSparseCheckoutCommand limits the checkout to subdir. The root
+// .devcontainer directory is included so the repository's devcontainer can
+// be used when subdir has none. Missing blobs are fetched from the remote,
+// so the same credential suppression as the clone applies.
+func buildSparseCheckoutCommand(projectPath, subdir string) []string {
+	return append(gitEnv(), "git", "-C", projectPath, "sparse-checkout", "set", shellQuote(subdir), ".devcontainer")
+}
+
+// buildRecordSubdirCommand stores subdir in the clone's git config as
+// mint.subdir, where project list reads it.
+func buildRecordSubdirCommand(projectPath, subdir string) []string {
+	return []string{"git", "-C", projectPath, "config", "mint.subdir", shellQuote(subdir)}
+}
+
+// gitEnv returns the env prefix that keeps remote git commands anonymous and
+// non-interactive. See buildCloneCommand.
+func gitEnv() []string {
+	return []string{"env", "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_GLOBAL=/dev/null"}
+}
+
+// validateSubdir checks that a --subdir value is a clean path relative to
+// the repository root. It is shell-quoted in remote commands, so the checks
+// only keep it inside the clone and unambiguous to git.
+func validateSubdir(subdir string) error {
+	switch {
+	case subdir == "":
+		return fmt.Errorf("invalid --subdir: must not be empty")
+	case strings.HasPrefix(subdir, "/"):
+		return fmt.Errorf("invalid --subdir %q: must be relative to the repository root", subdir)
+	case strings.IndexFunc(subdir, unicode.IsControl) >= 0:
+		return fmt.Errorf("invalid --subdir %q: must not contain control characters", subdir)
+	case strings.HasPrefix(subdir, "-"):
+		return fmt.Errorf("invalid --subdir %q: must not start with '-'", subdir)
+	}
+	for _, segment := range strings.Split(subdir, "/") {
+		if segment == ".." {
+			return fmt.Errorf("invalid --subdir %q: must not contain '..'", subdir)
+		}
+	}
+	if clean := path.Clean(subdir); clean != subdir || clean == "." {
+		return fmt.Errorf("invalid --subdir %q: must be a clean path such as services/payments", subdir)
+	}
+	return nil
+}
+
 // expandGitHubShorthand converts "owner/repo" shorthand to a full GitHub SSH
-// URL (git@github.com:owner/repo.git). SSH URLs work with agent forwarding so
+// URL (git@github.com:owner/repo.git). SSH URLs work with --auth agent so
 // that private repositories are accessible without any credential configuration
 // on the VM. Inputs that already look like a URL (contain "://" or "@") are
 // returned unchanged.
@@ -375,43 +759,89 @@ func expandGitHubShorthand(gitURL string) string {
 	return gitURL
 }
 
+// cloneNetworkErrors are git clone stderr fragments that mean the VM could
+// not reach the git server at all, as opposed to being refused by it.
+var cloneNetworkErrors = []string{
+	"Could not resolve host",
+	"Could not resolve hostname",
+	"Connection timed out",
+	"Connection refused",
+	"Network is unreachable",
+	"No route to host",
+	"Failed to connect to",
+	"Operation timed out",
+}
+
 // classifyCloneError inspects git clone stderr and returns an actionable error.
 // Since git's raw output is already streamed to the user's terminal, this
 // function focuses on providing a concise follow-up hint rather than repeating
-// the raw error.
-func classifyCloneError(gitURL string, err error, stderr string) error {
-	isSSHURL := strings.Contains(gitURL, "git@") || strings.HasPrefix(gitURL, "ssh://")
+// the raw error. auth is the --auth mode the clone ran with, empty when it
+// was anonymous: an anonymous clone the server refused suggests --auth, one
+// with --auth suggests checking the credentials it sent.
+func classifyCloneError(gitURL, auth string, err error, stderr string) error {
+	isSSHURL := isSSHGitURL(gitURL)
+
+	// Network failures: the server was never reached, so credentials are
+	// not the problem.
+	for _, fragment := range cloneNetworkErrors {
+		if strings.Contains(stderr, fragment) {
+			return fmt.Errorf("cloning repository: could not reach the git server\n\n"+
+				"  The VM could not connect to the server for %s; this is a network\n"+
+				"  problem, not an authentication one.\n"+
+				"  • Check that the VM can reach the internet: %s\n"+
+				"  • For a self-hosted server, check that its address resolves and is reachable from the VM's VPC",
+				gitURL, hint.Cmd("mint doctor"))
+		}
+	}
 
 	// Auth failures: SSH key rejected, HTTPS credentials not available.
 	if strings.Contains(stderr, "Permission denied") ||
 		strings.Contains(stderr, "Authentication failed") ||
 		strings.Contains(stderr, "could not read Username") ||
+		strings.Contains(stderr, "could not read Password") ||
 		strings.Contains(stderr, "Invalid username or password") {
-		if isSSHURL {
+		switch {
+		case auth == cloneAuthAgent:
 			return fmt.Errorf("cloning repository: authentication failed\n\n"+
-				"  The VM could not authenticate with the git server.\n"+
-				"  • Ensure your local SSH agent has the right key loaded: %s\n"+
-				"  • SSH agent forwarding is enabled automatically when SSH_AUTH_SOCK is set\n"+
+				"  The git server rejected the keys in your forwarded SSH agent.\n"+
+				"  • Check that the agent holds a key with access to the repository: %s\n"+
 				"  • Or add a deploy key to the repository: %s",
 				hint.Cmd("ssh-add -l"), hint.Cmd("mint key add <public-key-path>"))
+		case auth == cloneAuthHTTPSToken:
+			return fmt.Errorf("cloning repository: authentication failed\n\n"+
+				"  The git server rejected the token from %s or the keychain.\n"+
+				"  • Check that the token has not expired and can read the repository",
+				gitTokenEnv)
+		case isSSHURL:
+			return fmt.Errorf("cloning repository: authentication failed\n\n"+
+				"  The clone is anonymous and the git server requires authentication.\n"+
+				"  • Forward your local SSH agent for the clone: %s\n"+
+				"  • Or add a deploy key to the repository: %s",
+				hint.Cmd("mint project add --auth agent "+gitURL), hint.Cmd("mint key add <public-key-path>"))
 		}
 		return fmt.Errorf("cloning repository: authentication failed\n\n"+
-			"  The VM could not authenticate with the git server.\n"+
-			"  • Use an SSH URL to leverage agent forwarding: %s\n"+
-			"  • Or include a token in the HTTPS URL: %s\n"+
-			"  • Or add a deploy key to the repository: %s",
-			hint.Cmd("git@github.com:org/repo.git"),
-			hint.Cmd("https://<token>@github.com/org/repo"),
-			hint.Cmd("mint key add <public-key-path>"))
+			"  The clone is anonymous and the git server requires authentication.\n"+
+			"  • Send a token from %s or the keychain: %s\n"+
+			"  • Or use an SSH URL with your SSH agent: %s",
+			gitTokenEnv, hint.Cmd("mint project add --auth https-token "+gitURL),
+			hint.Cmd("mint project add --auth agent git@github.com:org/repo.git"))
 	}
 
 	// Repository not found — GitHub returns this for both missing and inaccessible repos.
 	if strings.Contains(stderr, "Repository not found") ||
 		strings.Contains(stderr, "repository not found") ||
 		strings.Contains(stderr, "does not exist") {
-		return fmt.Errorf("cloning repository: repository not found\n\n" +
-			"  Check that %q is correct and that you have access.\n" +
-			"  For private repos, authentication is required — see above.", gitURL)
+		if auth != "" {
+			return fmt.Errorf("cloning repository: repository not found\n\n"+
+				"  Check that %q is correct and that your credentials can access it.", gitURL)
+		}
+		authFlag := "--auth https-token"
+		if isSSHURL {
+			authFlag = "--auth agent"
+		}
+		return fmt.Errorf("cloning repository: repository not found\n\n"+
+			"  Check that %q is correct and that you have access.\n"+
+			"  Servers report private repos as not found to anonymous clones; retry with %s.", gitURL, hint.Cmd(authFlag))
 	}
 
 	return fmt.Errorf("cloning repository: %w", err)
@@ -492,27 +922,40 @@ func newProjectListCommand() *cobra.Command {
 // newProjectListCommandWithDeps creates the project list subcommand with explicit
 // dependencies for testing.
 func newProjectListCommandWithDeps(deps *projectListDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List projects on the VM",
-		Long:  "List project directories under /mint/projects/ and their devcontainer status.",
-		Args:  cobra.NoArgs,
+		Long: "List project directories under /mint/projects/ and their devcontainer status.\n\n" +
+			"When the VM is stopped, the list from the last successful run is shown instead, " +
+			"labelled with its age and with container status unknown. Use --require-live to fail instead.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
 				return runProjectList(cmd, deps)
 			}
 			clients := awsClientsFromContext(cmd.Context())
 			if clients == nil {
+				if cliCtx := cli.FromCommand(cmd); cliCtx != nil && cliCtx.Offline {
+					return runProjectList(cmd, &projectListDeps{cacheDir: config.DefaultConfigDir()})
+				}
 				return fmt.Errorf("AWS clients not configured")
 			}
 			return runProjectList(cmd, &projectListDeps{
 				describe: clients.ec2Client,
-				sendKey:  clients.icClient,
+				sendKey:  clients.sendKey,
 				owner:    clients.owner,
-				remote:   defaultRemoteRunner,
+				remote:   clients.remoteRunner(),
+				sshPort:  clients.sshOptions.Port,
+				cacheDir: config.DefaultConfigDir(),
 			})
 		},
 	}
+
+	cmd.Flags().Bool("require-live", false, "Fail when the VM is stopped instead of showing the cached list")
+	addOwnerFlag(cmd)
+	addFormatFlag(cmd, "name", "container_status", "image")
+
+	return cmd
 }
 
 // runProjectList executes the project list logic: discover VM, list project
@@ -525,23 +968,63 @@ func runProjectList(cmd *cobra.Command, deps *projectListDeps) error {
 
 	cliCtx := cli.FromCommand(cmd)
 	vmName := "default"
-	jsonOutput := false
+	offline := false
 	if cliCtx != nil {
 		vmName = cliCtx.VM
-		jsonOutput = cliCtx.JSON
+		offline = cliCtx.Offline
+	}
+
+	format, err := resolveOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	// --owner lists another owner's VM. The cache is keyed by VM name
+	// only, so it is neither read nor written for it.
+	owner := deps.owner
+	cacheDir := deps.cacheDir
+	otherOwner := ownerOverride(cmd, owner)
+	if otherOwner != "" {
+		owner, cacheDir = otherOwner, ""
+	}
+
+	// Offline, the cache is the only source; there is no live list to fall
+	// back to.
+	if offline {
+		if otherOwner != "" {
+			return fmt.Errorf("--owner needs AWS: the cached project lists are of your own VMs")
+		}
+		cache, err := readProjectListCache(deps.cacheDir, vmName)
+		if err != nil {
+			return fmt.Errorf("offline and no cached project list for VM %q — run %s once while online", vmName, hint.Cmd("mint project list"))
+		}
+		header := fmt.Sprintf("Offline — showing the cached project list for VM %q from %s.", vmName, formatCacheAge(time.Since(cache.FetchedAt)))
+		return renderCachedProjectList(cmd.OutOrStdout(), format, cache, projectStatusOffline, header)
 	}
 
 	// Discover VM by owner + VM name.
-	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	found, err := vm.FindVM(ctx, deps.describe, owner, vmName)
 	if err != nil {
 		return fmt.Errorf("discovering VM: %w", err)
 	}
 	if found == nil {
+		if other := otherOwnerHint(ctx, deps.describe, owner, vmName); other != "" {
+			return fmt.Errorf("%s", other)
+		}
 		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
 	}
 
-	// Verify VM is running.
+	// A stopped VM can still answer from the cache; any other state, or a
+	// missing or unreadable cache, keeps the plain "not running" error.
 	if found.State != string(ec2types.InstanceStateNameRunning) {
+		requireLive, _ := cmd.Flags().GetBool("require-live")
+		if !requireLive && cacheDir != "" && found.State == string(ec2types.InstanceStateNameStopped) {
+			if cache, err := readProjectListCache(cacheDir, vmName); err == nil {
+				header := fmt.Sprintf("VM %q is stopped — showing the cached project list from %s. Start it with %s for live data.",
+					vmName, formatCacheAge(time.Since(cache.FetchedAt)), hint.Cmd("mint up"))
+				return renderCachedProjectList(cmd.OutOrStdout(), format, cache, projectStatusVMStopped, header)
+			}
+		}
 		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
 			vmName, found.ID, found.State, hint.Cmd("mint up"))
 	}
@@ -549,7 +1032,7 @@ func runProjectList(cmd *cobra.Command, deps *projectListDeps) error {
 	// List project directories.
 	lsCmd := []string{"ls", "-1", "/mint/projects/"}
 	lsOutput, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, lsCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, lsCmd)
 	if err != nil {
 		return fmt.Errorf("listing projects: %w", err)
 	}
@@ -561,7 +1044,7 @@ func runProjectList(cmd *cobra.Command, deps *projectListDeps) error {
 		"--filter", "label=devcontainer.local_folder",
 	}
 	dockerOutput, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, dockerCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, dockerCmd)
 	if err != nil {
 		// Docker errors are non-fatal; just show projects without container info.
 		dockerOutput = nil
@@ -569,12 +1052,69 @@ func runProjectList(cmd *cobra.Command, deps *projectListDeps) error {
 
 	projects := parseProjectsAndContainers(string(lsOutput), string(dockerOutput))
 
-	w := cmd.OutOrStdout()
-	if jsonOutput {
+	// Read the subdirectory of --subdir projects and which projects have no
+	// devcontainer. Like docker errors, a failure here only loses detail.
+	settingsOutput, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildProjectSettingsCommand())
+	if err == nil {
+		settings := parseProjectSettings(string(settingsOutput))
+		for i := range projects {
+			ps := settings[projects[i].Name]
+			projects[i].Subdir = ps.subdir
+			if ps.noDevcontainer && projects[i].ContainerStatus == "none" {
+				projects[i].ContainerStatus = projectStatusNoDevcontainer
+			}
+		}
+	}
+
+	// The cache only serves stopped-VM lookups; failing to write it must not
+	// fail a live list.
+	if cacheDir != "" {
+		_ = writeProjectListCache(cacheDir, vmName, projects, time.Now())
+	}
+
+	return renderProjectList(cmd.OutOrStdout(), format, projects)
+}
+
+// renderCachedProjectList writes a cached project list. Every row gets the
+// given container status, since container state cannot be known without
+// SSH, and human output starts with header, which labels the data as cached.
+func renderCachedProjectList(w io.Writer, format outputFormat, cache *projectListCache, status, header string) error {
+	fetchedAt := cache.FetchedAt
+	projects := make([]projectInfo, 0, len(cache.Projects))
+	for _, p := range cache.Projects {
+		p.ContainerStatus = status
+		p.Image = ""
+		p.CachedAt = &fetchedAt
+		projects = append(projects, p)
+	}
+
+	if format == formatHuman {
+		fmt.Fprintf(w, "%s\n\n", header)
+	}
+	return renderProjectList(w, format, projects)
+}
+
+// renderProjectList writes the collected projects in the requested format.
+func renderProjectList(w io.Writer, format outputFormat, projects []projectInfo) error {
+	switch format {
+	case formatJSON:
 		return writeProjectListJSON(w, projects)
+	case formatPlain:
+		writeProjectListPlain(w, projects)
+		return nil
+	default:
+		writeProjectListHuman(w, projects)
+		return nil
+	}
+}
+
+// writeProjectListPlain outputs one tab-separated line per project with no
+// header. An empty project list produces no output.
+func writeProjectListPlain(w io.Writer, projects []projectInfo) {
+	for _, p := range projects {
+		writePlainRow(w, p.Name, p.ContainerStatus, p.Image)
 	}
-	writeProjectListHuman(w, projects)
-	return nil
 }
 
 // parseProjectsAndContainers parses the output of ls and docker ps to build
@@ -610,7 +1150,7 @@ func parseProjectsAndContainers(lsOutput, dockerOutput string) []projectInfo {
 		if len(parts) < 4 {
 			continue
 		}
-		folder := strings.TrimSpace(parts[3])
+		folder := projectRootFolder(strings.TrimSpace(parts[3]))
 		status := normalizeContainerStatus(parts[1])
 		image := strings.TrimSpace(parts[2])
 		containers[folder] = containerInfo{status: status, image: image}
@@ -634,6 +1174,60 @@ func parseProjectsAndContainers(lsOutput, dockerOutput string) []projectInfo {
 	return projects
 }
 
+// projectStatusNoDevcontainer is the container status of a project added
+// without a devcontainer, which has a plain tmux session instead.
+const projectStatusNoDevcontainer = "n/a"
+
+// projectSettings holds the mint.* git config values of a project clone.
+type projectSettings struct {
+	subdir         string // mint.subdir, see buildRecordSubdirCommand
+	noDevcontainer bool   // mint.devcontainer=false, see buildRecordNoDevcontainerCommand
+}
+
+// buildProjectSettingsCommand constructs the remote command that prints
+// "<project>\t<key>\t<value>" for every mint.* key in each project's git
+// config.
+func buildProjectSettingsCommand() []string {
+	script := `for d in /mint/projects/*/; do n=$(basename "$d"); ` +
+		`git -C "$d" config --get-regexp '^mint\.' 2>/dev/null | ` +
+		`while read -r k v; do printf '%s\t%s\t%s\n' "$n" "$k" "$v"; done; ` +
+		`done; true`
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// parseProjectSettings parses buildProjectSettingsCommand output into a map
+// of project name to settings.
+func parseProjectSettings(output string) map[string]projectSettings {
+	settings := make(map[string]projectSettings)
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		ps := settings[parts[0]]
+		switch parts[1] {
+		case "mint.subdir":
+			ps.subdir = parts[2]
+		case "mint.devcontainer":
+			ps.noDevcontainer = parts[2] == "false"
+		}
+		settings[parts[0]] = ps
+	}
+	return settings
+}
+
+// projectRootFolder returns the /mint/projects/<name> directory that a
+// devcontainer.local_folder label belongs to. A --subdir project's
+// devcontainer may be built from a folder below its project directory.
+func projectRootFolder(folder string) string {
+	rest, ok := strings.CutPrefix(folder, "/mint/projects/")
+	if !ok {
+		return folder
+	}
+	name, _, _ := strings.Cut(rest, "/")
+	return "/mint/projects/" + name
+}
+
 // normalizeContainerStatus converts a docker status string to a simplified
 // status label: "running", "exited", "created", "paused", or the raw status.
 func normalizeContainerStatus(rawStatus string) string {
@@ -669,13 +1263,35 @@ func writeProjectListHuman(w io.Writer, projects []projectInfo) {
 		return
 	}
 
-	fmt.Fprintf(w, "%-20s  %-10s  %s\n", "PROJECT", "STATUS", "IMAGE")
+	statusWidth := 10
+	for _, p := range projects {
+		statusWidth = max(statusWidth, len(p.ContainerStatus))
+	}
+
+	// The SUBDIR column is shown only when a project was added with --subdir.
+	subdirWidth := 0
+	for _, p := range projects {
+		if p.Subdir != "" {
+			subdirWidth = max(subdirWidth, len("SUBDIR"), len(p.Subdir))
+		}
+	}
+	subdirCell := func(s string) string {
+		if subdirWidth == 0 {
+			return ""
+		}
+		if s == "" {
+			s = "\u2014"
+		}
+		return fmt.Sprintf("%-*s  ", subdirWidth, s)
+	}
+
+	fmt.Fprintf(w, "%-20s  %s%-*s  %s\n", "PROJECT", subdirCell("SUBDIR"), statusWidth, "STATUS", "IMAGE")
 	for _, p := range projects {
 		image := p.Image
 		if image == "" {
 			image = "\u2014"
 		}
-		fmt.Fprintf(w, "%-20s  %-10s  %s\n", p.Name, p.ContainerStatus, image)
+		fmt.Fprintf(w, "%-20s  %s%-*s  %s\n", p.Name, subdirCell(p.Subdir), statusWidth, p.ContainerStatus, image)
 	}
 }
 
@@ -687,16 +1303,24 @@ func newProjectRebuildCommand() *cobra.Command {
 // newProjectRebuildCommandWithDeps creates the project rebuild subcommand with
 // explicit dependencies for testing.
 func newProjectRebuildCommandWithDeps(deps *projectRebuildDeps) *cobra.Command {
-	return &cobra.Command{
-		Use:   "rebuild <project-name>",
-		Short: "Tear down and rebuild a project's devcontainer",
+	cmd := &cobra.Command{
+		Use:         "rebuild [project-name]",
+		Short:       "Tear down and rebuild a project's devcontainer",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
 		Long: "Stop and remove the existing devcontainer for a project, " +
 			"then rebuild it with devcontainer up. Requires confirmation " +
-			"unless --yes is set.",
-		Args: cobra.ExactArgs(1),
+			"unless --yes is set. With --all, every project is rebuilt in " +
+			"turn, and a failure does not stop the rest. --pull rebuilds " +
+			"without the image cache, so updated base images are fetched.\n\n" +
+			devcontainerOverrideHelp,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			if all == (len(args) == 1) {
+				return fmt.Errorf("specify a project name or --all")
+			}
 			if deps != nil {
-				return runProjectRebuild(cmd, deps, args[0])
+				return runProjectRebuild(cmd, deps, args)
 			}
 			clients := awsClientsFromContext(cmd.Context())
 			if clients == nil {
@@ -705,23 +1329,48 @@ func newProjectRebuildCommandWithDeps(deps *projectRebuildDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runProjectRebuild(cmd, &projectRebuildDeps{
 				describe:        clients.ec2Client,
-				sendKey:         clients.icClient,
+				sendKey:         clients.sendKey,
 				owner:           clients.owner,
-				remote:          defaultRemoteRunner,
-				streamingRunner: defaultStreamingRemoteRunner,
+				remote:          clients.remoteRunner(),
+				sshPort:         clients.sshOptions.Port,
+				streamingRunner: clients.streamingRemoteRunner(),
 				stdin:           cmd.InOrStdin(),
 				hostKeyStore:    sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner:  defaultHostKeyScanner,
-			}, args[0])
+				cacheDir:        configDir,
+				overrideDir:     filepath.Join(configDir, devcontainerOverridesDirName),
+				projectHosts:    newProjectHosts(cmd, clients),
+			}, args)
 		},
 	}
+
+	cmd.Flags().Bool("all", false, "Rebuild the devcontainers of all projects")
+	cmd.Flags().Bool("pull", false, "Rebuild without the image cache, fetching updated base images")
+	addDevcontainerOverrideFlags(cmd)
+	addNotifyFlags(cmd)
+
+	return cmd
 }
 
 // runProjectRebuild executes the project rebuild logic: discover VM, verify
-// project exists, confirm, stop container, remove container, rebuild.
-func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName string) error {
-	if err := validateProjectName(projectName); err != nil {
-		return err
+// project exists, confirm, stop container, remove container, rebuild. With
+// no args every project is rebuilt; see rebuildAllProjects.
+func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, args []string) error {
+	pull, _ := cmd.Flags().GetBool("pull")
+	var projectName string
+	var override *devcontainerOverride
+	if len(args) == 1 {
+		projectName = args[0]
+		if err := validateProjectName(projectName); err != nil {
+			return err
+		}
+		var err error
+		if override, err = resolveDevcontainerOverride(cmd, deps.overrideDir, projectName); err != nil {
+			return err
+		}
+	} else if cmd.Flags().Changed("override") {
+		return fmt.Errorf("--override cannot be combined with --all — save per-project overrides as %s instead",
+			filepath.Join(deps.overrideDir, "<project>.json"))
 	}
 
 	ctx := cmd.Context()
@@ -732,9 +1381,11 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 	cliCtx := cli.FromCommand(cmd)
 	vmName := "default"
 	yes := false
+	jsonOutput := false
 	if cliCtx != nil {
 		vmName = cliCtx.VM
 		yes = cliCtx.Yes
+		jsonOutput = cliCtx.JSON
 	}
 
 	// Discover VM by owner + VM name.
@@ -760,13 +1411,20 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 	}
 
 	w := cmd.OutOrStdout()
+	if projectName == "" {
+		stdin := deps.stdin
+		if stdin == nil {
+			stdin = cmd.InOrStdin()
+		}
+		return rebuildAllProjects(ctx, cmd, deps, remote, found, vmName, stdin, yes, pull, jsonOutput)
+	}
 	projectPath := fmt.Sprintf("/mint/projects/%s", projectName)
 
 	// Step 1: Verify project exists.
 	fmt.Fprintf(w, "Verifying project %q exists...\n", projectName)
 	testCmd := []string{"test", "-d", projectPath}
 	_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, testCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, testCmd)
 	if err != nil {
 		// Propagate TOFU host key errors directly instead of masking them
 		// as "project not found".
@@ -796,14 +1454,28 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 		}
 	}
 
+	if err := rebuildProject(ctx, w, deps, remote, found, vmName, projectName, override, pull); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Rebuilt devcontainer for %q\n", projectName)
+	return nil
+}
+
+// rebuildProject stops and removes projectName's container, builds it again
+// with devcontainer up (without the image cache when pull is set), and
+// points the project's tmux session at the new container.
+func rebuildProject(ctx context.Context, w io.Writer, deps *projectRebuildDeps, remote RemoteCommandRunner,
+	found *vm.VM, vmName, projectName string, override *devcontainerOverride, pull bool) error {
+	projectPath := fmt.Sprintf("/mint/projects/%s", projectName)
+
 	// Step 3: Stop container (graceful if none found).
 	fmt.Fprintf(w, "Stopping container...\n")
 	stopCmd := []string{
 		"sh", "-c",
 		fmt.Sprintf("docker stop $(docker ps -q --filter label=devcontainer.local_folder=%s) 2>/dev/null || true", projectPath),
 	}
-	_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, stopCmd)
+	_, err := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, stopCmd)
 	if err != nil {
 		return fmt.Errorf("stopping container: %w", err)
 	}
@@ -815,7 +1487,7 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 		fmt.Sprintf("docker rm $(docker ps -aq --filter label=devcontainer.local_folder=%s) 2>/dev/null || true", projectPath),
 	}
 	_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, rmCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, rmCmd)
 	if err != nil {
 		return fmt.Errorf("removing container: %w", err)
 	}
@@ -827,10 +1499,24 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 	if streaming == nil {
 		streaming = defaultStreamingRemoteRunner
 	}
-	fmt.Fprintf(w, "Rebuilding devcontainer...\n")
 	buildCmd := []string{"devcontainer", "up", "--workspace-folder", projectPath}
-	_, err = streaming(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, buildCmd, os.Stderr)
+	if pull {
+		buildCmd = append(buildCmd, "--build-no-cache")
+	}
+	buildCmd = append(buildCmd, devcontainerGPUArgs(found)...)
+	if override != nil {
+		fmt.Fprintf(w, "Devcontainer override in effect: %s (use --no-override to build without it)\n", override.path)
+		mergedPath, err := applyDevcontainerOverride(func(command []string) ([]byte, error) {
+			return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+				found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+		}, projectPath, projectPath, override)
+		if err != nil {
+			return err
+		}
+		buildCmd = append(buildCmd, "--config", mergedPath)
+	}
+	fmt.Fprintf(w, "Rebuilding devcontainer...\n")
+	err = runDevcontainerBuild(ctx, w, streaming, deps.sendKey, found, sshPortOrDefault(deps.sshPort), buildCmd, deps.sleep)
 	if err != nil {
 		return fmt.Errorf("rebuilding devcontainer: %w", err)
 	}
@@ -842,7 +1528,7 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 		"--filter", fmt.Sprintf("label=devcontainer.local_folder=%s", projectPath),
 	}
 	containerOutput, err := remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, dockerPsCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, dockerPsCmd)
 	if err != nil {
 		containerOutput = nil
 	}
@@ -851,7 +1537,7 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 	// Step 7: Kill existing tmux session (graceful — ignore errors).
 	killCmd := []string{"tmux", "kill-session", "-t", projectName}
 	_, _ = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, killCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, killCmd)
 
 	// Step 8: Create new tmux session with docker exec into container.
 	var tmuxCmd []string
@@ -863,11 +1549,192 @@ func runProjectRebuild(cmd *cobra.Command, deps *projectRebuildDeps, projectName
 			"docker", "exec", "-it", containerID, "/bin/bash"}
 	}
 	_, err = remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
-		found.PublicIP, defaultSSHPort, defaultSSHUser, tmuxCmd)
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, tmuxCmd)
 	if err != nil {
 		return fmt.Errorf("creating tmux session: %w", err)
 	}
 
-	fmt.Fprintf(w, "Rebuilt devcontainer for %q\n", projectName)
+	if containerID != "" {
+		recordStartedProject(deps.cacheDir, vmName, projectName)
+		refreshProjectHosts(ctx, w, deps.projectHosts, remote, deps.sendKey, vmName, found, projectName)
+	}
+	return nil
+}
+
+// newProjectRemoveCommand creates the production project remove subcommand.
+func newProjectRemoveCommand() *cobra.Command {
+	return newProjectRemoveCommandWithDeps(nil)
+}
+
+// newProjectRemoveCommandWithDeps creates the project remove subcommand with
+// explicit dependencies for testing.
+func newProjectRemoveCommandWithDeps(deps *projectRemoveDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "remove <project-name>",
+		Short:       "Remove a project's devcontainer, tmux session, and files",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Long: "Stop and remove the devcontainer for a project, kill its tmux " +
+			"session, and delete its directory under /mint/projects. With " +
+			"--keep-files the clone is kept. Requires confirmation unless " +
+			"--yes is set.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runProjectRemove(cmd, deps, args[0])
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			configDir := config.DefaultConfigDir()
+			return runProjectRemove(cmd, &projectRemoveDeps{
+				describe:       clients.ec2Client,
+				sendKey:        clients.sendKey,
+				owner:          clients.owner,
+				remote:         clients.remoteRunner(),
+				sshPort:        clients.sshOptions.Port,
+				stdin:          cmd.InOrStdin(),
+				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
+				hostKeyScanner: defaultHostKeyScanner,
+				cacheDir:       configDir,
+				projectHosts:   newProjectHosts(cmd, clients),
+			}, args[0])
+		},
+	}
+
+	cmd.Flags().Bool("keep-files", false, "Remove only the devcontainer and tmux session, keeping the project files")
+
+	return cmd
+}
+
+// runProjectRemove executes the project remove logic: discover VM, verify
+// project exists, confirm, stop and remove the container, kill the tmux
+// session, and delete the project directory unless --keep-files is set.
+func runProjectRemove(cmd *cobra.Command, deps *projectRemoveDeps, projectName string) error {
+	if err := validateProjectName(projectName); err != nil {
+		return err
+	}
+	keepFiles, _ := cmd.Flags().GetBool("keep-files")
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	yes := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		yes = cliCtx.Yes
+	}
+
+	// Discover VM by owner + VM name.
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+
+	// Verify VM is running.
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	// Build a TOFU-verified remote runner for write commands (ADR-0019).
+	remote := deps.remote
+	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
+		tofu := NewTOFURemoteRunner(deps.remote, deps.hostKeyStore, deps.hostKeyScanner, vmName)
+		remote = tofu.Run
+	}
+	run := func(command []string) ([]byte, error) {
+		return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	}
+
+	w := cmd.OutOrStdout()
+	projectPath := fmt.Sprintf("/mint/projects/%s", projectName)
+
+	// Step 1: Verify project exists.
+	fmt.Fprintf(w, "Verifying project %q exists...\n", projectName)
+	if _, err := run([]string{"test", "-d", projectPath}); err != nil {
+		// Propagate TOFU host key errors directly instead of masking them
+		// as "project not found".
+		if isTOFUError(err) {
+			return err
+		}
+		return fmt.Errorf("project %q not found — run %s to see available projects", projectName, hint.Cmd("mint project list"))
+	}
+
+	// Step 2: Confirmation prompt (unless --yes).
+	if !yes {
+		if keepFiles {
+			fmt.Fprintf(w, "This will remove the devcontainer and tmux session for %q. The files in %s are kept.\n", projectName, projectPath)
+		} else {
+			fmt.Fprintf(w, "This will remove the devcontainer, tmux session, and all files in %s for %q.\n", projectPath, projectName)
+		}
+		fmt.Fprintf(w, "Type the project name to confirm: ")
+
+		stdin := deps.stdin
+		if stdin == nil {
+			stdin = cmd.InOrStdin()
+		}
+		scanner := bufio.NewScanner(stdin)
+		if scanner.Scan() {
+			input := strings.TrimSpace(scanner.Text())
+			if input != projectName {
+				return fmt.Errorf("confirmation %q does not match project name %q — remove aborted", input, projectName)
+			}
+		} else {
+			return fmt.Errorf("no confirmation input received — remove aborted")
+		}
+	}
+
+	// Step 3: Stop container (graceful if none found).
+	fmt.Fprintf(w, "Stopping container...\n")
+	stopCmd := []string{
+		"sh", "-c",
+		fmt.Sprintf("docker stop $(docker ps -q --filter label=devcontainer.local_folder=%s) 2>/dev/null || true", projectPath),
+	}
+	if _, err := run(stopCmd); err != nil {
+		return fmt.Errorf("stopping container: %w", err)
+	}
+
+	// Step 4: Remove container (graceful if none found).
+	fmt.Fprintf(w, "Removing container...\n")
+	rmCmd := []string{
+		"sh", "-c",
+		fmt.Sprintf("docker rm $(docker ps -aq --filter label=devcontainer.local_folder=%s) 2>/dev/null || true", projectPath),
+	}
+	if _, err := run(rmCmd); err != nil {
+		return fmt.Errorf("removing container: %w", err)
+	}
+
+	// Step 5: Kill tmux session (graceful — ignore errors).
+	_, _ = run([]string{"tmux", "kill-session", "-t", projectName})
+
+	// Step 6: Delete the project directory.
+	if !keepFiles {
+		fmt.Fprintf(w, "Deleting %s...\n", projectPath)
+		if _, err := run([]string{"rm", "-rf", projectPath}); err != nil {
+			return fmt.Errorf("deleting project files: %w", err)
+		}
+	}
+
+	// The cache only drives project list and mint up's reconcile, so a
+	// failed write is ignored.
+	if deps.cacheDir != "" {
+		_ = forgetProject(deps.cacheDir, vmName, projectName, keepFiles)
+	}
+	refreshProjectHosts(ctx, w, deps.projectHosts, remote, deps.sendKey, vmName, found, projectName)
+
+	if keepFiles {
+		fmt.Fprintf(w, "Removed devcontainer for %q; files kept in %s\n", projectName, projectPath)
+	} else {
+		fmt.Fprintf(w, "Removed project %q\n", projectName)
+	}
 	return nil
 }
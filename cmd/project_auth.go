@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// Clone authentication modes for project add --auth. The default clones
+// anonymously.
+const (
+	cloneAuthAgent      = "agent"
+	cloneAuthHTTPSToken = "https-token"
+)
+
+// gitTokenEnv is the environment variable --auth https-token reads the
+// token from before trying the keychain.
+const gitTokenEnv = "MINT_GIT_TOKEN"
+
+// gitTokenKeychainService is the keychain entry --auth https-token reads
+// when gitTokenEnv is unset: a macOS generic password, or a Secret Service
+// item with a "service" attribute, under this name.
+const gitTokenKeychainService = "mint-git-token"
+
+// addCloneAuthFlag registers project add's --auth flag.
+func addCloneAuthFlag(cmd *cobra.Command) {
+	cmd.Flags().String("auth", "", `Authenticate the clone: "agent" forwards your SSH agent, "https-token" sends a token from `+
+		gitTokenEnv+` or the keychain (default: anonymous)`)
+}
+
+// cloneAuth is how project add authenticates a clone. The zero value
+// clones anonymously.
+type cloneAuth struct {
+	mode  string
+	token string
+}
+
+// resolveCloneAuth reads --auth and gets what it needs before the VM is
+// contacted: a local SSH agent with keys loaded, or a token.
+func resolveCloneAuth(ctx context.Context, cmd *cobra.Command, deps *projectAddDeps, gitURL string) (cloneAuth, error) {
+	mode, _ := cmd.Flags().GetString("auth")
+	switch mode {
+	case "":
+		return cloneAuth{}, nil
+	case cloneAuthAgent:
+		if !isSSHGitURL(gitURL) {
+			return cloneAuth{}, fmt.Errorf("--auth agent needs an SSH git URL such as git@github.com:org/repo.git, not %s", gitURL)
+		}
+		check := deps.checkSSHAgent
+		if check == nil {
+			check = checkSSHAgent
+		}
+		if err := check(ctx); err != nil {
+			return cloneAuth{}, err
+		}
+		return cloneAuth{mode: mode}, nil
+	case cloneAuthHTTPSToken:
+		if !strings.HasPrefix(gitURL, "https://") {
+			return cloneAuth{}, fmt.Errorf("--auth https-token needs an https:// git URL, not %s", gitURL)
+		}
+		lookup := deps.gitToken
+		if lookup == nil {
+			lookup = lookupGitToken
+		}
+		token, err := lookup(ctx)
+		if err != nil {
+			return cloneAuth{}, err
+		}
+		return cloneAuth{mode: mode, token: token}, nil
+	default:
+		return cloneAuth{}, fmt.Errorf("invalid --auth %q: must be %s or %s", mode, cloneAuthAgent, cloneAuthHTTPSToken)
+	}
+}
+
+// context returns ctx set up for a remote git command authenticated with
+// a: the SSH agent forwarded for just that command, or the token on its
+// stdin.
+func (a cloneAuth) context(ctx context.Context) context.Context {
+	switch a.mode {
+	case cloneAuthAgent:
+		return withAgentForwarding(ctx)
+	case cloneAuthHTTPSToken:
+		return withRemoteStdin(ctx, func() io.ReadCloser {
+			return io.NopCloser(strings.NewReader(a.token + "\n"))
+		})
+	}
+	return ctx
+}
+
+// command returns gitCmd as run under a. With a token, gitCmd runs with a
+// one-shot GIT_ASKPASS helper; otherwise it is returned unchanged.
+func (a cloneAuth) command(gitCmd []string) []string {
+	if a.mode != cloneAuthHTTPSToken {
+		return gitCmd
+	}
+	return append([]string{"sh", "-c", shellQuote(askpassScript), "mint-askpass"}, gitCmd...)
+}
+
+// askpassScript runs its arguments with GIT_ASKPASS pointing at a helper in
+// a temp file, deleted as soon as they exit. The token is read from stdin
+// into the helper's environment, so it never appears on a command line or
+// on disk, and git prints neither the helper's answers nor the token.
+// GitHub and GitLab accept any user name with a token.
+const askpassScript = `set -e
+IFS= read -r MINT_GIT_TOKEN
+export MINT_GIT_TOKEN
+askpass=$(mktemp)
+trap 'rm -f "$askpass"' EXIT
+printf '%s\n' '#!/bin/sh' 'case "$1" in Username*) echo x-access-token ;; *) printf "%s\n" "$MINT_GIT_TOKEN" ;; esac' >"$askpass"
+chmod 700 "$askpass"
+GIT_ASKPASS="$askpass" "$@"`
+
+// isSSHGitURL reports whether gitURL is cloned over SSH: scp-like
+// user@host:path or ssh://.
+func isSSHGitURL(gitURL string) bool {
+	return strings.HasPrefix(gitURL, "ssh://") || (strings.Contains(gitURL, "@") && !strings.Contains(gitURL, "://"))
+}
+
+// checkSSHAgent returns an error unless a local SSH agent is running with
+// at least one key loaded, so --auth agent fails before the clone starts
+// rather than with the git server's permission denied.
+func checkSSHAgent(ctx context.Context) error {
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return fmt.Errorf("--auth agent needs a local SSH agent, but SSH_AUTH_SOCK is not set — start one and load your key with %s",
+			hint.Cmd("ssh-add"))
+	}
+	err := exec.CommandContext(ctx, "ssh-add", "-l").Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return fmt.Errorf("--auth agent: your SSH agent has no keys loaded — add one with %s", hint.Cmd("ssh-add"))
+	default:
+		return fmt.Errorf("--auth agent: cannot query the SSH agent at SSH_AUTH_SOCK: %w", err)
+	}
+}
+
+// lookupGitToken returns the token for --auth https-token: gitTokenEnv,
+// else the keychain entry named gitTokenKeychainService.
+func lookupGitToken(ctx context.Context) (string, error) {
+	if token := strings.TrimSpace(os.Getenv(gitTokenEnv)); token != "" {
+		return token, nil
+	}
+	return keychainGitToken(ctx, runtime.GOOS, exec.LookPath, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, name, args...).Output()
+	})
+}
+
+// keychainGitToken reads the token from the system keychain: the macOS
+// keychain with security, or the Secret Service with secret-tool.
+func keychainGitToken(ctx context.Context, goos string, lookPath func(string) (string, error),
+	output func(ctx context.Context, name string, args ...string) ([]byte, error)) (string, error) {
+	var name string
+	var args []string
+	switch goos {
+	case "darwin":
+		name, args = "security", []string{"find-generic-password", "-s", gitTokenKeychainService, "-w"}
+	case "linux":
+		name, args = "secret-tool", []string{"lookup", "service", gitTokenKeychainService}
+	}
+	if name != "" {
+		if _, err := lookPath(name); err == nil {
+			out, err := output(ctx, name, args...)
+			if token := strings.TrimSpace(string(out)); err == nil && token != "" {
+				return token, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("--auth https-token needs a token: set %s, or store one in the keychain as %q", gitTokenEnv, gitTokenKeychainService)
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+func TestProjectAddAuth(t *testing.T) {
+	hint.IsTTY = false
+	const token = "ghp_secret123"
+	gitEnvPrefix := "env GIT_TERMINAL_PROMPT=0 GIT_CONFIG_NOSYSTEM=1 GIT_CONFIG_GLOBAL=/dev/null "
+
+	tests := []struct {
+		name      string
+		args      []string
+		agentErr  error
+		tokenErr  error
+		wantErr   string
+		wantClone string
+		wantStdin string
+		wantAgent bool
+	}{
+		{
+			name:      "anonymous by default",
+			args:      []string{"git@github.com:org/private.git"},
+			wantClone: gitEnvPrefix + "git clone git@github.com:org/private.git /mint/projects/private",
+		},
+		{
+			name:      "agent forwards the agent for the clone",
+			args:      []string{"git@github.com:org/private.git", "--auth", "agent"},
+			wantClone: gitEnvPrefix + "git clone git@github.com:org/private.git /mint/projects/private",
+			wantAgent: true,
+		},
+		{
+			name:      "https-token sends the token on stdin",
+			args:      []string{"https://github.com/org/private.git", "--auth", "https-token"},
+			wantClone: "sh -c " + shellQuote(askpassScript) + " mint-askpass " + gitEnvPrefix + "git clone https://github.com/org/private.git /mint/projects/private",
+			wantStdin: token + "\n",
+		},
+		{
+			name:     "agent without keys fails before the VM is contacted",
+			args:     []string{"git@github.com:org/private.git", "--auth", "agent"},
+			agentErr: errors.New("--auth agent: your SSH agent has no keys loaded"),
+			wantErr:  "no keys loaded",
+		},
+		{
+			name:     "missing token fails before the VM is contacted",
+			args:     []string{"https://github.com/org/private.git", "--auth", "https-token"},
+			tokenErr: errors.New("--auth https-token needs a token"),
+			wantErr:  "needs a token",
+		},
+		{
+			name:    "agent needs an SSH URL",
+			args:    []string{"https://github.com/org/private.git", "--auth", "agent"},
+			wantErr: "--auth agent needs an SSH git URL",
+		},
+		{
+			name:    "https-token needs an https URL",
+			args:    []string{"org/private", "--auth", "https-token"},
+			wantErr: "--auth https-token needs an https:// git URL",
+		},
+		{
+			name:    "unknown mode",
+			args:    []string{"org/private", "--auth", "password"},
+			wantErr: `invalid --auth "password"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")}}
+			streaming := &projectMockStreamingRemote{}
+			agentChecked := false
+			buf := new(bytes.Buffer)
+			deps := &projectAddDeps{
+				describe:        &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+				sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				owner:           "alice",
+				remote:          remote.Run,
+				streamingRunner: streaming.run,
+				checkSSHAgent: func(context.Context) error {
+					agentChecked = true
+					return tt.agentErr
+				},
+				gitToken: func(context.Context) (string, error) {
+					return token, tt.tokenErr
+				},
+			}
+			root := cmdtest.NewRoot()
+			root.AddCommand(newProjectCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"project", "add"}, tt.args...))
+			err := root.Execute()
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if len(remote.Calls()) != 0 || len(streaming.calls) != 0 {
+					t.Errorf("VM contacted after a failed --auth check: %d remote, %d streaming calls", len(remote.Calls()), len(streaming.calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+			}
+			if tt.wantAgent != agentChecked {
+				t.Errorf("agent checked = %v, want %v", agentChecked, tt.wantAgent)
+			}
+			if len(streaming.calls) == 0 {
+				t.Fatal("no streaming calls")
+			}
+			clone := streaming.calls[0]
+			if got := strings.Join(clone.command, " "); got != tt.wantClone {
+				t.Errorf("clone command:\n%s\nwant:\n%s", got, tt.wantClone)
+			}
+			if string(clone.stdin) != tt.wantStdin {
+				t.Errorf("clone stdin = %q, want %q", clone.stdin, tt.wantStdin)
+			}
+			if clone.forwardAgent != tt.wantAgent {
+				t.Errorf("clone forwardAgent = %v, want %v", clone.forwardAgent, tt.wantAgent)
+			}
+			for _, c := range streaming.calls[1:] {
+				if c.forwardAgent || c.stdin != nil {
+					t.Errorf("%q ran with the clone's credentials", strings.Join(c.command, " "))
+				}
+			}
+			if strings.Contains(buf.String(), token) {
+				t.Errorf("output shows the token:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestProjectAddAuthSubdirCheckout(t *testing.T) {
+	hint.IsTTY = false
+	remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1"), nil, fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")}}
+	streaming := &projectMockStreamingRemote{}
+	deps := &projectAddDeps{
+		describe:        &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: streaming.run,
+		gitToken:        func(context.Context) (string, error) { return "tok", nil },
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"project", "add", "https://github.com/org/platform.git", "--subdir", "tools/cli", "--auth", "https-token"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(streaming.calls) != 2 {
+		t.Fatalf("streaming calls = %d, want clone and checkout", len(streaming.calls))
+	}
+	checkout := streaming.calls[1]
+	if !strings.HasPrefix(strings.Join(checkout.command, " "), "sh -c ") || string(checkout.stdin) != "tok\n" {
+		t.Errorf("sparse checkout ran without the token: %q (stdin %q)", strings.Join(checkout.command, " "), checkout.stdin)
+	}
+}
+
+// TestAskpassScript runs the helper script with a stand-in for git that
+// asks the helper for a user name and password, as git does.
+func TestAskpassScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	fakeGit := filepath.Join(dir, "git")
+	script := "#!/bin/sh\n" +
+		`"$GIT_ASKPASS" "Username for 'https://github.com': "` + "\n" +
+		`"$GIT_ASKPASS" "Password for 'https://x-access-token@github.com': "` + "\n" +
+		`echo "$GIT_ASKPASS" >"$1"` + "\n"
+	if err := os.WriteFile(fakeGit, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	helperPath := filepath.Join(dir, "helper-path")
+
+	cmd := exec.Command("sh", "-c", askpassScript, "mint-askpass", fakeGit, helperPath)
+	cmd.Stdin = strings.NewReader("s3cret\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("askpass script: %v", err)
+	}
+	if got, want := string(out), "x-access-token\ns3cret\n"; got != want {
+		t.Errorf("helper answers = %q, want %q", got, want)
+	}
+	helper, err := os.ReadFile(helperPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(strings.TrimSpace(string(helper))); !os.IsNotExist(err) {
+		t.Errorf("helper %s still exists after the command (stat error %v)", strings.TrimSpace(string(helper)), err)
+	}
+
+	// The command's exit status is kept.
+	cmd = exec.Command("sh", "-c", askpassScript, "mint-askpass", "false")
+	cmd.Stdin = strings.NewReader("s3cret\n")
+	if err := cmd.Run(); err == nil {
+		t.Error("failing command: expected an error")
+	}
+}
+
+func TestKeychainGitToken(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/tool", nil }
+	missing := func(string) (string, error) { return "", errors.New("not found") }
+
+	tests := []struct {
+		name     string
+		goos     string
+		lookPath func(string) (string, error)
+		out      string
+		outErr   error
+		wantCmd  string
+		want     string
+	}{
+		{
+			name:     "macOS keychain",
+			goos:     "darwin",
+			lookPath: found,
+			out:      "tok-mac\n",
+			wantCmd:  "security find-generic-password -s mint-git-token -w",
+			want:     "tok-mac",
+		},
+		{
+			name:     "Secret Service",
+			goos:     "linux",
+			lookPath: found,
+			out:      "tok-linux",
+			wantCmd:  "secret-tool lookup service mint-git-token",
+			want:     "tok-linux",
+		},
+		{
+			name:     "no entry",
+			goos:     "darwin",
+			lookPath: found,
+			outErr:   errors.New("exit status 44"),
+			wantCmd:  "security find-generic-password -s mint-git-token -w",
+		},
+		{
+			name:     "tool not installed",
+			goos:     "linux",
+			lookPath: missing,
+		},
+		{
+			name: "unsupported OS",
+			goos: "windows",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			output := func(_ context.Context, name string, args ...string) ([]byte, error) {
+				ran = strings.Join(append([]string{name}, args...), " ")
+				return []byte(tt.out), tt.outErr
+			}
+			got, err := keychainGitToken(context.Background(), tt.goos, tt.lookPath, output)
+			if ran != tt.wantCmd {
+				t.Errorf("ran %q, want %q", ran, tt.wantCmd)
+			}
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), "set MINT_GIT_TOKEN") {
+					t.Fatalf("error = %v, want a hint to set MINT_GIT_TOKEN", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("token = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupGitTokenPrefersEnv(t *testing.T) {
+	t.Setenv(gitTokenEnv, "  tok-env \n")
+	got, err := lookupGitToken(context.Background())
+	if err != nil || got != "tok-env" {
+		t.Errorf("lookupGitToken = %q, %v, want tok-env", got, err)
+	}
+}
+
+func TestCheckSSHAgentNeedsSocket(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	err := checkSSHAgent(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "SSH_AUTH_SOCK is not set") {
+		t.Errorf("error = %v, want SSH_AUTH_SOCK is not set", err)
+	}
+}
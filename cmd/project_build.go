@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// devcontainerBuildBackoff is the wait before each retry of a devcontainer
+// build that failed on a transient registry error. Its length is the number
+// of retries.
+var devcontainerBuildBackoff = []time.Duration{30 * time.Second, 60 * time.Second}
+
+// buildOutputTail is how much of a failed build's output is classified.
+const buildOutputTail = 8 << 10
+
+// transientBuildPatterns are lowercase substrings of devcontainer build
+// output that mark a failure as a transient registry or network error worth
+// retrying: timeouts, TLS handshake timeouts, rate limiting (429),
+// connection resets, and temporary DNS failures.
+var transientBuildPatterns = []string{
+	"tls handshake timeout",
+	"i/o timeout",
+	"context deadline exceeded",
+	"timeout exceeded while awaiting headers",
+	"429 too many requests",
+	"toomanyrequests",
+	"connection reset by peer",
+	"temporary failure in name resolution",
+	"server misbehaving",
+}
+
+// isTransientBuildFailure reports whether the output of a failed
+// devcontainer build matches a transient error pattern.
+func isTransientBuildFailure(output string) bool {
+	output = strings.ToLower(output)
+	for _, p := range transientBuildPatterns {
+		if strings.Contains(output, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// tailWriter keeps the last max bytes written to it.
+type tailWriter struct {
+	buf []byte
+	max int
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > 2*t.max {
+		t.buf = append([]byte(nil), t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the kept tail.
+func (t *tailWriter) String() string {
+	if len(t.buf) > t.max {
+		return string(t.buf[len(t.buf)-t.max:])
+	}
+	return string(t.buf)
+}
+
+// devcontainerGPUArgs returns the devcontainer up options that pass the
+// VM's GPUs through, as docker run --gpus all would, or nil on a VM without
+// them. devcontainer up then honours a hostRequirements.gpu without probing
+// the host for a GPU.
+func devcontainerGPUArgs(found *vm.VM) []string {
+	if !mintaws.IsGPUInstanceType(found.InstanceType) {
+		return nil
+	}
+	return []string{"--gpu-availability", "all"}
+}
+
+// runDevcontainerBuild runs buildCmd on the VM, streaming its progress to
+// stderr. A failure whose output tail matches a transient error pattern is
+// retried after each devcontainerBuildBackoff wait; any other failure is
+// returned at once. Only the build is retried — earlier steps such as the
+// clone are not repeated.
+func runDevcontainerBuild(ctx context.Context, w io.Writer, streaming StreamingRemoteRunner, sendKey mintaws.SendSSHPublicKeyAPI,
+	found *vm.VM, port int, buildCmd []string, sleep func(context.Context, time.Duration) error) error {
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	attempts := len(devcontainerBuildBackoff) + 1
+	for attempt := 1; ; attempt++ {
+		tail := &tailWriter{max: buildOutputTail}
+		stdout, err := streaming(ctx, sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, port, defaultSSHUser, buildCmd, io.MultiWriter(os.Stderr, tail))
+		if err == nil {
+			return nil
+		}
+		tail.Write(stdout)
+		if attempt == attempts || !isTransientBuildFailure(tail.String()) {
+			return err
+		}
+		fmt.Fprintf(w, "transient registry error — retrying (%d/%d)\n", attempt+1, attempts)
+		if err := sleep(ctx, devcontainerBuildBackoff[attempt-1]); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+)
+
+func TestIsTransientBuildFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"Error response from daemon: Get \"https://mcr.microsoft.com/v2/\": net/http: TLS handshake timeout", true},
+		{"toomanyrequests: You have reached your pull rate limit.", true},
+		{"unexpected status: 429 Too Many Requests", true},
+		{"read tcp 10.0.0.5:51234->104.18.1.1:443: read: connection reset by peer", true},
+		{"dial tcp: lookup registry-1.docker.io: Temporary failure in name resolution", true},
+		{"dial tcp: lookup ghcr.io on 127.0.0.53:53: server misbehaving", true},
+		{"dial tcp 20.1.1.1:443: i/o timeout", true},
+		{"failed to solve: process \"/bin/sh -c npm ci\" did not complete successfully: exit code: 1", false},
+		{"Error: Dev container config (/mint/projects/api/.devcontainer/devcontainer.json) not found.", false},
+		{"manifest for node:99 not found: manifest unknown", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isTransientBuildFailure(tt.output); got != tt.want {
+			t.Errorf("isTransientBuildFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestTailWriterKeepsTail(t *testing.T) {
+	tw := &tailWriter{max: 8}
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(tw, "line%d\n", i)
+	}
+	if got := tw.String(); got != "8\nline9\n" {
+		t.Errorf("tail = %q", got)
+	}
+}
+
+// recordSleeps returns a sleep func that records its waits without waiting.
+func recordSleeps(waits *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(_ context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+}
+
+const transientPullError = "Error response from daemon: Get \"https://mcr.microsoft.com/v2/\": net/http: TLS handshake timeout\n"
+
+func TestProjectAddRetriesTransientBuildFailure(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name           string
+		streaming      *projectMockStreamingRemote
+		wantErrContain string
+		wantBuilds     int
+		wantWaits      []time.Duration
+		wantOutput     []string
+	}{
+		{
+			name: "fail, fail, succeed",
+			// streaming: clone, then three builds
+			streaming: &projectMockStreamingRemote{
+				stderr: []string{"", transientPullError, "toomanyrequests: rate limit\n", ""},
+				errors: []error{nil, fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1"), nil},
+			},
+			wantBuilds: 3,
+			wantWaits:  []time.Duration{30 * time.Second, 60 * time.Second},
+			wantOutput: []string{
+				"transient registry error — retrying (2/3)",
+				"transient registry error — retrying (3/3)",
+				"ready at /mint/projects/repo",
+			},
+		},
+		{
+			name: "transient failures exhaust the retries",
+			streaming: &projectMockStreamingRemote{
+				stderr: []string{"", transientPullError, transientPullError, transientPullError},
+				errors: []error{nil, fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")},
+			},
+			wantErrContain: "building devcontainer",
+			wantBuilds:     3,
+			wantWaits:      []time.Duration{30 * time.Second, 60 * time.Second},
+		},
+		{
+			name: "permanent failure is not retried",
+			streaming: &projectMockStreamingRemote{
+				stderr: []string{"", "failed to solve: process \"/bin/sh -c npm ci\" did not complete successfully\n"},
+				errors: []error{nil, fmt.Errorf("exit status 1")},
+			},
+			wantErrContain: "building devcontainer",
+			wantBuilds:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var waits []time.Duration
+			buf := new(bytes.Buffer)
+			deps := &projectAddDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+				},
+				sendKey: &cmdtest.SendSSHPublicKey{
+					Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+				},
+				owner: "alice",
+				// remote: test -d (dir doesn't exist), devcontainer config check (has config)
+				remote:          (&cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1"), nil}}).Run,
+				streamingRunner: tt.streaming.run,
+				sleep:           recordSleeps(&waits),
+			}
+
+			root := cmdtest.NewRoot(newProjectCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"project", "add", "https://github.com/org/repo.git"})
+			err := root.Execute()
+
+			if tt.wantErrContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErrContain)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := len(tt.streaming.calls) - 1; got != tt.wantBuilds {
+				t.Errorf("devcontainer up ran %d times, want %d", got, tt.wantBuilds)
+			}
+			for i, call := range tt.streaming.calls {
+				isClone := strings.Contains(strings.Join(call.command, " "), "clone")
+				if isClone != (i == 0) {
+					t.Errorf("streaming call %d: clone must run once, first; got %v", i, call.command)
+				}
+			}
+			if fmt.Sprint(waits) != fmt.Sprint(tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestProjectRebuildRetriesTransientBuildFailure(t *testing.T) {
+	hint.IsTTY = false
+
+	var waits []time.Duration
+	buf := new(bytes.Buffer)
+	streaming := &projectMockStreamingRemote{
+		stderr: []string{"read: connection reset by peer\n", ""},
+		errors: []error{fmt.Errorf("exit status 1"), nil},
+	}
+	// remote: test -d, stop, rm, docker ps, tmux kill, tmux new
+	remote := &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil}}
+	deps := &projectRebuildDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: streaming.run,
+		stdin:           strings.NewReader(""),
+		sleep:           recordSleeps(&waits),
+	}
+
+	root := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"--yes", "project", "rebuild", "myproject"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(streaming.calls) != 2 {
+		t.Errorf("devcontainer up ran %d times, want 2", len(streaming.calls))
+	}
+	if len(remote.Calls()) != 6 {
+		t.Errorf("expected the 6 remote calls of one rebuild, got %d", len(remote.Calls()))
+	}
+	if fmt.Sprint(waits) != fmt.Sprint([]time.Duration{30 * time.Second}) {
+		t.Errorf("waits = %v, want [30s]", waits)
+	}
+	if !strings.Contains(buf.String(), "transient registry error — retrying (2/3)") {
+		t.Errorf("output missing retry message:\n%s", buf.String())
+	}
+}
+
+func TestProjectBuildPassesGPUs(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType ec2types.InstanceType
+		want         bool
+	}{
+		{"GPU instance", "g5.xlarge", true},
+		{"non-GPU instance", ec2types.InstanceTypeT3Medium, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			describe := func() *cmdtest.DescribeInstances {
+				out := makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")
+				out.Reservations[0].Instances[0].InstanceType = tt.instanceType
+				return &cmdtest.DescribeInstances{Output: out}
+			}
+			sendKey := &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}}
+
+			// add: test -d (dir doesn't exist), devcontainer config check (has config)
+			streaming := &projectMockStreamingRemote{}
+			add := cmdtest.NewRoot(newProjectCommandWithDeps(&projectAddDeps{
+				describe:        describe(),
+				sendKey:         sendKey,
+				owner:           "alice",
+				remote:          (&cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1"), nil}}).Run,
+				streamingRunner: streaming.run,
+			}))
+			add.SetOut(new(bytes.Buffer))
+			add.SetErr(new(bytes.Buffer))
+			add.SetArgs([]string{"project", "add", "https://github.com/org/repo.git"})
+			if err := add.Execute(); err != nil {
+				t.Fatalf("project add: %v", err)
+			}
+
+			m := &rebuildAllMocks{projects: []string{"api"}}
+			rebuild := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(&projectRebuildDeps{
+				describe:        describe(),
+				sendKey:         sendKey,
+				owner:           "alice",
+				remote:          m.remote,
+				streamingRunner: m.streaming,
+			}))
+			rebuild.SetOut(new(bytes.Buffer))
+			rebuild.SetErr(new(bytes.Buffer))
+			rebuild.SetArgs([]string{"project", "rebuild", "api", "--yes"})
+			if err := rebuild.Execute(); err != nil {
+				t.Fatalf("project rebuild: %v", err)
+			}
+
+			builds := map[string][]string{"add": streaming.calls[len(streaming.calls)-1].command, "rebuild": m.builds[0]}
+			for name, build := range builds {
+				if got := strings.Contains(strings.Join(build, " "), "--gpu-availability all"); got != tt.want {
+					t.Errorf("%s: devcontainer up = %v, want --gpu-availability all: %v", name, build, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// projectStatusVMStopped is the container status shown for every cached
+// project row while the VM is stopped. Container state cannot be known
+// without SSH.
+const projectStatusVMStopped = "unknown (VM stopped)"
+
+// projectStatusOffline is the container status shown for cached project
+// rows when AWS is unreachable or --offline is given.
+const projectStatusOffline = "unknown (offline)"
+
+// Last-known container states recorded in the project cache. mint up uses
+// them to restart the containers that were running before the VM stopped.
+const (
+	projectStateRunning = "running"
+	projectStateStopped = "stopped"
+)
+
+// projectListCache is the locally cached result of the last successful
+// project list for a VM. It lets project list answer while the VM is stopped.
+type projectListCache struct {
+	VM        string        `json:"vm"`
+	FetchedAt time.Time     `json:"fetched_at"`
+	Projects  []projectInfo `json:"projects"`
+	// LastKnownState maps project names to the state their container was
+	// last seen in, from project list or from a project add, rebuild, or
+	// start that mint ran. Projects without a container have no entry.
+	LastKnownState map[string]string `json:"last_known_state,omitempty"`
+}
+
+// projectListCachePath returns the cache file for vmName under dir.
+func projectListCachePath(dir, vmName string) string {
+	return filepath.Join(dir, fmt.Sprintf("projects-%s.json", vmName))
+}
+
+// writeProjectListCache stores the projects of a successful live list for
+// vmName. An empty list is cached too, so a stopped VM with no projects
+// reports none instead of falling back to the plain "not running" error.
+// The state of each listed container is recorded as its last-known state.
+func writeProjectListCache(dir, vmName string, projects []projectInfo, now time.Time) error {
+	if projects == nil {
+		projects = []projectInfo{}
+	}
+	cache := &projectListCache{VM: vmName, FetchedAt: now, Projects: projects}
+	// Carry over the states of listed projects whose container could not be
+	// seen this time, e.g. because docker ps failed.
+	var previous map[string]string
+	if old, err := loadProjectListCache(dir, vmName); err == nil {
+		previous = old.LastKnownState
+	}
+	for _, p := range projects {
+		switch p.ContainerStatus {
+		case "", "none", projectStatusNoDevcontainer:
+			if state, ok := previous[p.Name]; ok {
+				cache.setState(p.Name, state)
+			}
+		case projectStateRunning:
+			cache.setState(p.Name, projectStateRunning)
+		default:
+			cache.setState(p.Name, projectStateStopped)
+		}
+	}
+	return saveProjectListCache(dir, cache)
+}
+
+// recordProjectState sets the last-known container state of project in
+// vmName's cache without touching the cached list. When no list has been
+// cached yet the file holds only states, which project list ignores.
+func recordProjectState(dir, vmName, project, state string) error {
+	cache, err := loadProjectListCache(dir, vmName)
+	if err != nil {
+		cache = &projectListCache{VM: vmName, Projects: []projectInfo{}}
+	}
+	cache.setState(project, state)
+	return saveProjectListCache(dir, cache)
+}
+
+// forgetProject drops project's last-known state from vmName's cache, so
+// mint up no longer tries to restart its container, and unless keepListed
+// also its cached list row. A missing cache has nothing to forget.
+func forgetProject(dir, vmName, project string, keepListed bool) error {
+	cache, err := loadProjectListCache(dir, vmName)
+	if err != nil {
+		return nil
+	}
+	delete(cache.LastKnownState, project)
+	if !keepListed {
+		projects := cache.Projects[:0]
+		for _, p := range cache.Projects {
+			if p.Name != project {
+				projects = append(projects, p)
+			}
+		}
+		cache.Projects = projects
+	}
+	return saveProjectListCache(dir, cache)
+}
+
+// lastRunningProjects returns the projects whose containers were last known
+// to be running on vmName, sorted by name. A missing cache has none.
+func lastRunningProjects(dir, vmName string) []string {
+	cache, err := loadProjectListCache(dir, vmName)
+	if err != nil {
+		return nil
+	}
+	var projects []string
+	for name, state := range cache.LastKnownState {
+		if state == projectStateRunning {
+			projects = append(projects, name)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+func (c *projectListCache) setState(project, state string) {
+	if c.LastKnownState == nil {
+		c.LastKnownState = make(map[string]string)
+	}
+	c.LastKnownState[project] = state
+}
+
+// saveProjectListCache writes cache to its file under dir.
+func saveProjectListCache(dir string, cache *projectListCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding project cache: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	if err := os.WriteFile(projectListCachePath(dir, cache.VM), data, 0o600); err != nil {
+		return fmt.Errorf("writing project cache: %w", err)
+	}
+	return nil
+}
+
+// readProjectListCache returns the cached project list for vmName. A missing,
+// corrupt, or mismatched cache file is an error.
+func readProjectListCache(dir, vmName string) (*projectListCache, error) {
+	cache, err := loadProjectListCache(dir, vmName)
+	if err != nil {
+		return nil, err
+	}
+	if cache.FetchedAt.IsZero() {
+		return nil, fmt.Errorf("project cache for VM %q has no project list", vmName)
+	}
+	return cache, nil
+}
+
+// loadProjectListCache reads vmName's cache file whether or not it holds a
+// project list.
+func loadProjectListCache(dir, vmName string) (*projectListCache, error) {
+	data, err := os.ReadFile(projectListCachePath(dir, vmName))
+	if err != nil {
+		return nil, err
+	}
+	var cache projectListCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("parsing project cache: %w", err)
+	}
+	if cache.VM != vmName {
+		return nil, fmt.Errorf("project cache for VM %q is invalid", vmName)
+	}
+	return &cache, nil
+}
+
+// formatCacheAge renders how long ago a cache was written, e.g. "3h ago".
+func formatCacheAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
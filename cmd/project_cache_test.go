@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestProjectListCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	projects := []projectInfo{
+		{Name: "api", ContainerStatus: "running", Image: "go:1.22"},
+		{Name: "web", ContainerStatus: "none"},
+	}
+
+	if err := writeProjectListCache(dir, "dev", projects, fetched); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	cache, err := readProjectListCache(dir, "dev")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !cache.FetchedAt.Equal(fetched) {
+		t.Errorf("FetchedAt = %v, want %v", cache.FetchedAt, fetched)
+	}
+	if len(cache.Projects) != 2 || cache.Projects[0].Name != "api" || cache.Projects[1].Name != "web" {
+		t.Errorf("Projects = %+v", cache.Projects)
+	}
+
+	if _, err := readProjectListCache(dir, "default"); err == nil {
+		t.Error("cache for another VM should not be found")
+	}
+}
+
+func TestProjectListCacheEmptyList(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeProjectListCache(dir, "default", nil, time.Now()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	cache, err := readProjectListCache(dir, "default")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if cache.Projects == nil || len(cache.Projects) != 0 {
+		t.Errorf("Projects = %#v, want empty non-nil", cache.Projects)
+	}
+}
+
+func TestReadProjectListCacheInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "corrupt JSON", content: "{not json"},
+		{name: "missing timestamp", content: `{"vm":"default","projects":[]}`},
+		{name: "different VM", content: `{"vm":"dev","fetched_at":"2026-03-01T12:00:00Z","projects":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "projects-default.json"), []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readProjectListCache(dir, "default"); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestFormatCacheAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{30 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{47 * time.Hour, "47h ago"},
+		{72 * time.Hour, "3d ago"},
+	}
+	for _, tt := range tests {
+		if got := formatCacheAge(tt.age); got != tt.want {
+			t.Errorf("formatCacheAge(%v) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestProjectLastKnownState(t *testing.T) {
+	dir := t.TempDir()
+
+	// A state recorded before any list is cached does not make a list.
+	if err := recordProjectState(dir, "dev", "api", projectStateRunning); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if _, err := readProjectListCache(dir, "dev"); err == nil {
+		t.Error("a states-only cache should not be read as a project list")
+	}
+
+	projects := []projectInfo{
+		{Name: "api", ContainerStatus: "none"}, // docker ps failed: keeps the recorded state
+		{Name: "web", ContainerStatus: "running"},
+		{Name: "db", ContainerStatus: "exited"},
+		{Name: "docs", ContainerStatus: "none"},
+	}
+	if err := writeProjectListCache(dir, "dev", projects, time.Now()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := recordProjectState(dir, "dev", "db", projectStateRunning); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	cache, err := readProjectListCache(dir, "dev")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(cache.Projects) != 4 {
+		t.Errorf("recording a state changed the list: %+v", cache.Projects)
+	}
+	if _, ok := cache.LastKnownState["docs"]; ok {
+		t.Error("a project without a container should have no state")
+	}
+	if got, want := lastRunningProjects(dir, "dev"), []string{"api", "db", "web"}; !slices.Equal(got, want) {
+		t.Errorf("lastRunningProjects = %v, want %v", got, want)
+	}
+	if got := lastRunningProjects(dir, "other"); got != nil {
+		t.Errorf("lastRunningProjects without a cache = %v, want none", got)
+	}
+}
+
+func TestForgetProject(t *testing.T) {
+	dir := t.TempDir()
+	projects := []projectInfo{
+		{Name: "api", ContainerStatus: "running"},
+		{Name: "web", ContainerStatus: "running"},
+	}
+	if err := writeProjectListCache(dir, "dev", projects, time.Now()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// Keeping the files keeps the row but forgets the container.
+	if err := forgetProject(dir, "dev", "api", true); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	cache, err := readProjectListCache(dir, "dev")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(cache.Projects) != 2 {
+		t.Errorf("projects = %+v, want both rows kept", cache.Projects)
+	}
+	if got, want := lastRunningProjects(dir, "dev"), []string{"web"}; !slices.Equal(got, want) {
+		t.Errorf("lastRunningProjects = %v, want %v", got, want)
+	}
+
+	if err := forgetProject(dir, "dev", "web", false); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	cache, _ = readProjectListCache(dir, "dev")
+	if len(cache.Projects) != 1 || cache.Projects[0].Name != "api" {
+		t.Errorf("projects = %+v, want only api", cache.Projects)
+	}
+	if got := lastRunningProjects(dir, "dev"); got != nil {
+		t.Errorf("lastRunningProjects = %v, want none", got)
+	}
+
+	if err := forgetProject(dir, "other", "api", false); err != nil {
+		t.Errorf("forget without a cache: %v", err)
+	}
+}
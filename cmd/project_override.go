@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/devcontainer"
+)
+
+// devcontainerOverridesDirName is the directory under the config dir that
+// holds per-project override files named <project>.json.
+const devcontainerOverridesDirName = "devcontainer-overrides"
+
+// mergedDevcontainerDir is where, relative to the project directory, the
+// merged devcontainer.json is uploaded. It is excluded from git.
+const mergedDevcontainerDir = ".mint"
+
+// devcontainerOverrideHelp describes overrides in the project add and
+// project rebuild help text.
+const devcontainerOverrideHelp = "A partial devcontainer.json at ~/.config/mint/devcontainer-overrides/<project>.json " +
+	"(or the file given with --override) is deep-merged into the repository's config before " +
+	"building: objects merge, arrays are unioned, and other values are replaced. The merged " +
+	"file is written to /mint/projects/<project>/.mint/devcontainer.merged.json. " +
+	"Use --no-override to build with the repository's config as-is."
+
+// devcontainerOverride is a laptop-side partial devcontainer.json that is
+// merged into the repository's config at build time.
+type devcontainerOverride struct {
+	path string
+	cfg  map[string]any
+}
+
+// addDevcontainerOverrideFlags registers the --override and --no-override
+// flags shared by project add and project rebuild.
+func addDevcontainerOverrideFlags(cmd *cobra.Command) {
+	cmd.Flags().String("override", "", "Partial devcontainer.json to merge into the repo's config (default: ~/.config/mint/devcontainer-overrides/<project>.json)")
+	cmd.Flags().Bool("no-override", false, "Build with the repo's devcontainer.json as-is, ignoring any override")
+}
+
+// resolveDevcontainerOverride loads the override for projectName. An
+// explicit --override file must exist; the default file in dir is used only
+// when present. It returns nil when no override applies.
+func resolveDevcontainerOverride(cmd *cobra.Command, dir, projectName string) (*devcontainerOverride, error) {
+	overridePath, _ := cmd.Flags().GetString("override")
+	noOverride, _ := cmd.Flags().GetBool("no-override")
+	if overridePath != "" && noOverride {
+		return nil, fmt.Errorf("--override and --no-override cannot be used together")
+	}
+	if noOverride {
+		return nil, nil
+	}
+
+	explicit := overridePath != ""
+	if !explicit {
+		if dir == "" {
+			return nil, nil
+		}
+		overridePath = filepath.Join(dir, projectName+".json")
+	}
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading devcontainer override: %w", err)
+	}
+	cfg, err := devcontainer.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing devcontainer override %s: %w", overridePath, err)
+	}
+	return &devcontainerOverride{path: overridePath, cfg: cfg}, nil
+}
+
+// applyDevcontainerOverride reads the devcontainer.json for workspace on
+// the VM, merges the override into it, and uploads the result under the
+// project directory. It returns the remote path of the merged file for
+// devcontainer up --config.
+func applyDevcontainerOverride(run func(command []string) ([]byte, error), projectPath, workspace string, ov *devcontainerOverride) (string, error) {
+	// The devcontainer CLI prefers .devcontainer/devcontainer.json over
+	// .devcontainer.json; read them in the same order.
+	var base []byte
+	var configDir string
+	for _, candidate := range []string{workspace + "/.devcontainer/devcontainer.json", workspace + "/.devcontainer.json"} {
+		out, err := run([]string{"cat", shellQuote(candidate)})
+		if err == nil {
+			base, configDir = out, candidate[:strings.LastIndex(candidate, "/")]
+			break
+		}
+		if isTOFUError(err) {
+			return "", err
+		}
+	}
+	if configDir == "" {
+		return "", fmt.Errorf("no devcontainer.json found in %s to apply the override to", workspace)
+	}
+
+	cfg, err := devcontainer.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s/devcontainer.json: %w", configDir, err)
+	}
+	// The merged file lives in <project>/.mint, so paths relative to the
+	// original config must be rewritten to still point at the same files.
+	devcontainer.RebasePaths(cfg, ".."+strings.TrimPrefix(configDir, projectPath))
+
+	merged, err := json.MarshalIndent(devcontainer.Merge(cfg, ov.cfg), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding merged devcontainer.json: %w", err)
+	}
+
+	mergedPath := mergedDevcontainerPath(projectPath)
+	if _, err := run(buildUploadMergedDevcontainerCommand(projectPath, merged)); err != nil {
+		return "", fmt.Errorf("uploading merged devcontainer.json: %w", err)
+	}
+	return mergedPath, nil
+}
+
+// mergedDevcontainerPath returns the remote path of the merged config.
+func mergedDevcontainerPath(projectPath string) string {
+	return projectPath + "/" + mergedDevcontainerDir + "/devcontainer.merged.json"
+}
+
+// buildUploadMergedDevcontainerCommand writes contents to the merged config
+// path and adds the .mint directory to the clone's git exclude file so it
+// never shows up in git status. The contents travel base64-encoded so no
+// quoting is needed. Updating the exclude file is best effort.
+func buildUploadMergedDevcontainerCommand(projectPath string, contents []byte) []string {
+	dir := projectPath + "/" + mergedDevcontainerDir
+	exclude := projectPath + "/.git/info/exclude"
+	script := fmt.Sprintf(
+		"mkdir -p %s && printf %%s %s | base64 -d > %s || exit 1; "+
+			"{ grep -qsxF %s %s || echo %s >> %s; } 2>/dev/null; exit 0",
+		shellQuote(dir), base64.StdEncoding.EncodeToString(contents), shellQuote(mergedDevcontainerPath(projectPath)),
+		mergedDevcontainerDir+"/", shellQuote(exclude), mergedDevcontainerDir+"/", shellQuote(exclude),
+	)
+	return []string{"sh", "-c", shellQuote(script)}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/devcontainer"
+)
+
+// writeOverride writes an override file for project into a temp override
+// dir and returns the dir.
+func writeOverride(t *testing.T, project, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, project+".json"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// uploadedContents decodes the base64 payload of a merged devcontainer
+// upload command.
+func uploadedContents(t *testing.T, command []string) map[string]any {
+	t.Helper()
+	script := strings.Join(command, " ")
+	_, rest, ok := strings.Cut(script, "printf %s ")
+	if !ok {
+		t.Fatalf("not an upload command: %s", script)
+	}
+	encoded, _, _ := strings.Cut(rest, " ")
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("decoding upload: %v", err)
+	}
+	cfg, err := devcontainer.Parse(data)
+	if err != nil {
+		t.Fatalf("uploaded contents are not JSON: %v\n%s", err, data)
+	}
+	return cfg
+}
+
+// canonicalJSON re-encodes v with sorted keys for comparison.
+func canonicalJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func runRebuildWithOverride(t *testing.T, overrideDir string, remote *cmdtest.RemoteRunner, streaming *projectMockStreamingRemote, args ...string) (string, error) {
+	t.Helper()
+	deps := &projectRebuildDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: streaming.run,
+		overrideDir:     overrideDir,
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"--yes", "project", "rebuild", "myproject"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestProjectRebuildAppliesOverride(t *testing.T) {
+	overrideDir := writeOverride(t, "myproject", `{
+		// laptop-only tweaks
+		"forwardPorts": [5432],
+		"containerEnv": {"DEBUG": "1"},
+	}`)
+	repoConfig := `{
+		"build": {"dockerfile": "Dockerfile"},
+		"forwardPorts": [3000], // app
+		"containerEnv": {"TZ": "UTC"}
+	}`
+	// remote: test -d, stop, rm, cat devcontainer.json, upload, docker ps, tmux kill, tmux new
+	remote := &cmdtest.RemoteRunner{
+		Outputs: [][]byte{nil, nil, nil, []byte(repoConfig), nil, []byte("newctr789\n"), nil, nil},
+	}
+	streaming := &projectMockStreamingRemote{}
+
+	out, err := runRebuildWithOverride(t, overrideDir, remote, streaming)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	wantNote := "Devcontainer override in effect: " + filepath.Join(overrideDir, "myproject.json")
+	if !strings.Contains(out, wantNote) {
+		t.Errorf("output missing %q:\n%s", wantNote, out)
+	}
+	if len(remote.Calls()) != 8 {
+		t.Fatalf("expected 8 remote calls, got %d", len(remote.Calls()))
+	}
+	if got := strings.Join(remote.Calls()[3].Command, " "); got != "cat '/mint/projects/myproject/.devcontainer/devcontainer.json'" {
+		t.Errorf("read call = %s", got)
+	}
+
+	merged := uploadedContents(t, remote.Calls()[4].Command)
+	if got := canonicalJSON(t, merged); got !=
+		`{"build":{"dockerfile":"../.devcontainer/Dockerfile"},"containerEnv":{"DEBUG":"1","TZ":"UTC"},"forwardPorts":[3000,5432]}` {
+		t.Errorf("uploaded config = %s", got)
+	}
+	upload := strings.Join(remote.Calls()[4].Command, " ")
+	if !strings.Contains(upload, "/mint/projects/myproject/.mint/devcontainer.merged.json") {
+		t.Errorf("upload should write the merged path, got: %s", upload)
+	}
+
+	build := strings.Join(streaming.calls[0].command, " ")
+	want := "devcontainer up --workspace-folder /mint/projects/myproject --config /mint/projects/myproject/.mint/devcontainer.merged.json"
+	if build != want {
+		t.Errorf("build command = %q, want %q", build, want)
+	}
+}
+
+func TestProjectRebuildNoOverrideBypasses(t *testing.T) {
+	overrideDir := writeOverride(t, "myproject", `{"forwardPorts": [5432]}`)
+	// remote: test -d, stop, rm, docker ps, tmux kill, tmux new
+	remote := &cmdtest.RemoteRunner{
+		Outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil},
+	}
+	streaming := &projectMockStreamingRemote{}
+
+	out, err := runRebuildWithOverride(t, overrideDir, remote, streaming, "--no-override")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "override") {
+		t.Errorf("output should not mention an override:\n%s", out)
+	}
+	if len(remote.Calls()) != 6 {
+		t.Errorf("expected 6 remote calls, got %d", len(remote.Calls()))
+	}
+	if build := strings.Join(streaming.calls[0].command, " "); strings.Contains(build, "--config") {
+		t.Errorf("--no-override build should not pass --config: %s", build)
+	}
+}
+
+func TestResolveDevcontainerOverride(t *testing.T) {
+	overrideDir := writeOverride(t, "myproject", `{"image": "node:22"}`)
+	explicit := filepath.Join(t.TempDir(), "custom.jsonc")
+	if err := os.WriteFile(explicit, []byte(`{"image": "node:20", /* pinned */}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`["not an object"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		project     string
+		args        []string
+		wantPath    string
+		wantErr     string
+		wantNoMatch bool
+	}{
+		{name: "default file", project: "myproject", wantPath: filepath.Join(overrideDir, "myproject.json")},
+		{name: "missing default ignored", project: "other", wantNoMatch: true},
+		{name: "explicit file", project: "myproject", args: []string{"--override", explicit}, wantPath: explicit},
+		{name: "explicit missing", project: "myproject", args: []string{"--override", explicit + ".missing"}, wantErr: "reading devcontainer override"},
+		{name: "explicit invalid", project: "myproject", args: []string{"--override", invalid}, wantErr: "top-level value must be an object"},
+		{name: "no-override", project: "myproject", args: []string{"--no-override"}, wantNoMatch: true},
+		{name: "both flags", project: "myproject", args: []string{"--override", explicit, "--no-override"}, wantErr: "cannot be used together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newProjectRebuildCommand()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			ov, err := resolveDevcontainerOverride(cmd, overrideDir, tt.project)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantNoMatch {
+				if ov != nil {
+					t.Errorf("override = %+v, want none", ov)
+				}
+				return
+			}
+			if ov == nil || ov.path != tt.wantPath {
+				t.Errorf("override = %+v, want path %s", ov, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestApplyDevcontainerOverrideRootConfig(t *testing.T) {
+	var commands [][]string
+	run := func(command []string) ([]byte, error) {
+		commands = append(commands, command)
+		switch {
+		case strings.Contains(command[1], "/.devcontainer/devcontainer.json"):
+			return nil, errors.New("exit status 1")
+		case command[0] == "cat":
+			return []byte(`{"dockerFile": "Dockerfile", "runArgs": ["--init"]}`), nil
+		}
+		return nil, nil
+	}
+	ov := &devcontainerOverride{path: "o.json", cfg: map[string]any{"runArgs": []any{"--privileged"}}}
+
+	// A --subdir project with the config at the subdirectory's root.
+	mergedPath, err := applyDevcontainerOverride(run, "/mint/projects/api", "/mint/projects/api/services/api", ov)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mergedPath != "/mint/projects/api/.mint/devcontainer.merged.json" {
+		t.Errorf("merged path = %s", mergedPath)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("expected read, fallback read, upload; got %v", commands)
+	}
+	got := canonicalJSON(t, uploadedContents(t, commands[2]))
+	if want := `{"dockerFile":"../services/api/Dockerfile","runArgs":["--init","--privileged"]}`; got != want {
+		t.Errorf("uploaded config = %s, want %s", got, want)
+	}
+}
+
+func TestApplyDevcontainerOverrideNoConfig(t *testing.T) {
+	run := func([]string) ([]byte, error) { return nil, errors.New("exit status 1") }
+	ov := &devcontainerOverride{cfg: map[string]any{}}
+	_, err := applyDevcontainerOverride(run, "/mint/projects/p", "/mint/projects/p", ov)
+	if err == nil || !strings.Contains(err.Error(), "no devcontainer.json found") {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/dirarchive"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// defaultMaxGitObjectSize is the --max-git-object-size default: git objects
+// larger than this stay on the laptop when a local directory is pushed.
+const defaultMaxGitObjectSize = "50MiB"
+
+// uploadProgressInterval is how often the bytes sent so far are reported
+// while a local directory is pushed.
+const uploadProgressInterval = 250 * time.Millisecond
+
+// addLocalPushFlags registers the project add flags for pushing a local
+// directory.
+func addLocalPushFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("local", false, "Treat the argument as a local directory to push (--local=false: always a git URL)")
+	cmd.Flags().String("max-git-object-size", defaultMaxGitObjectSize, "Leave out .git objects larger than this when pushing a local directory")
+}
+
+// resolveLocalProjectDir decides whether the project add argument is a
+// local directory to push rather than a git URL, and returns the directory
+// when it is. An existing directory is pushed unless --local=false is given;
+// with --local the argument must be one.
+func resolveLocalProjectDir(cmd *cobra.Command, arg string) (string, error) {
+	info, statErr := os.Stat(arg)
+	isDir := statErr == nil && info.IsDir()
+
+	if cmd.Flags().Changed("local") {
+		local, _ := cmd.Flags().GetBool("local")
+		if !local {
+			return "", nil
+		}
+		if !isDir {
+			return "", fmt.Errorf("--local: %s is not a directory", arg)
+		}
+	} else if !isDir {
+		return "", nil
+	}
+
+	for _, flag := range []string{"branch", "subdir", "auth"} {
+		if cmd.Flags().Changed(flag) {
+			return "", fmt.Errorf("--%s applies only to git URLs, not the local directory %s", flag, arg)
+		}
+	}
+	return filepath.Abs(arg)
+}
+
+// scanLocalProject lists what pushing dir uploads, applying
+// --max-git-object-size.
+func scanLocalProject(cmd *cobra.Command, dir string) (*dirarchive.Tree, error) {
+	limit, _ := cmd.Flags().GetString("max-git-object-size")
+	maxSize, err := format.ParseSize(limit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --max-git-object-size: %w", err)
+	}
+	tree, err := dirarchive.Scan(dir, dirarchive.Options{MaxGitObjectSize: maxSize})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+	return tree, nil
+}
+
+// buildUnpackCommand constructs the remote command that unpacks a pushed
+// directory, read from stdin, into projectPath. It unpacks into a staging
+// directory first so that an interrupted push leaves no project directory
+// behind for the next project add to mistake for a finished one. The staging
+// directory is hidden so project list does not show it.
+func buildUnpackCommand(projectPath string) []string {
+	staging := shellQuote(path.Dir(projectPath) + "/." + path.Base(projectPath) + ".push")
+	script := fmt.Sprintf("rm -rf %s && mkdir -p %s && tar -xzf - -C %s && mv %s %s",
+		staging, staging, staging, staging, shellQuote(projectPath))
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// pushLocalProject uploads tree to projectPath on the VM as a tar stream
+// unpacked by the streaming runner, reporting the bytes sent on progress.
+func pushLocalProject(ctx context.Context, streaming StreamingRemoteRunner, deps *projectAddDeps, found *vm.VM, tree *dirarchive.Tree, projectPath string, progress io.Writer) error {
+	var last *archiveReader
+	open := func() io.ReadCloser {
+		last = newArchiveReader(tree, progress)
+		return last
+	}
+	_, err := streaming(withRemoteStdin(ctx, open), deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildUnpackCommand(projectPath), progress)
+	if last != nil {
+		last.finish()
+		// A local read error also breaks the remote tar; it is the one
+		// worth reporting.
+		if last.err != nil {
+			return last.err
+		}
+	}
+	return err
+}
+
+// archiveReader streams a tree's archive as it is written, counting the
+// bytes read for the progress line.
+type archiveReader struct {
+	pr       *io.PipeReader
+	done     chan struct{}
+	err      error
+	progress io.Writer
+	sent     int64
+	reported time.Time
+}
+
+func newArchiveReader(tree *dirarchive.Tree, progress io.Writer) *archiveReader {
+	pr, pw := io.Pipe()
+	r := &archiveReader{pr: pr, done: make(chan struct{}), progress: progress}
+	go func() {
+		defer close(r.done)
+		err := tree.Write(pw)
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			r.err = err
+		}
+		pw.CloseWithError(err)
+	}()
+	return r
+}
+
+func (r *archiveReader) Read(p []byte) (int, error) {
+	n, err := r.pr.Read(p)
+	r.sent += int64(n)
+	if now := time.Now(); now.Sub(r.reported) >= uploadProgressInterval {
+		r.reported = now
+		fmt.Fprintf(r.progress, "\rUploading: %s", format.FormatSize(r.sent))
+	}
+	return n, err
+}
+
+// Close stops the archive writer and waits for it, after which err holds
+// any error reading the local files.
+func (r *archiveReader) Close() error {
+	r.pr.Close()
+	<-r.done
+	return nil
+}
+
+// finish ends the progress line with the total sent.
+func (r *archiveReader) finish() {
+	if r.sent > 0 {
+		fmt.Fprintf(r.progress, "\rUploading: %s, done.\n", format.FormatSize(r.sent))
+	}
+}
+
+// describeLocalPush summarises what a push uploads, e.g.
+// "120 files, 4.2 MiB".
+func describeLocalPush(tree *dirarchive.Tree) string {
+	files := "files"
+	if tree.Files() == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%d %s, %s", tree.Files(), files, format.FormatSize(tree.Bytes))
+}
+
+// skippedGitObjectsWarning explains the git objects a push leaves out, or
+// returns "" when there are none.
+func skippedGitObjectsWarning(tree *dirarchive.Tree, limit string) string {
+	if len(tree.Skipped) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tree.Skipped))
+	for _, s := range tree.Skipped {
+		names = append(names, fmt.Sprintf("%s (%s)", s.Path, format.FormatSize(s.Size)))
+	}
+	return fmt.Sprintf("Warning: left out %d git object(s) over %s: %s. History stored in them is missing on the VM; "+
+		"raise --max-git-object-size to include them.", len(tree.Skipped), limit, strings.Join(names, ", "))
+}
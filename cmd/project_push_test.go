@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// makeLocalProject creates a directory named name holding files, a map of
+// slash paths to contents, and returns its path.
+func makeLocalProject(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	for file, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// archiveNames lists the regular files in a gzipped tar.
+func archiveNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("stdin is not gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			names = append(names, hdr.Name)
+		}
+	}
+}
+
+func TestProjectAddLocalDirectory(t *testing.T) {
+	hint.IsTTY = false
+	dir := makeLocalProject(t, "scratch", map[string]string{
+		".gitignore": "*.log\n",
+		"main.go":    "package main",
+		"debug.log":  "noise",
+	})
+
+	// test -d (missing), devcontainer check (none), tmux session. A directory
+	// that is not a git repository gets no git config or identity check.
+	remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")}}
+	streaming := &projectMockStreamingRemote{}
+	output, err := runProjectAddSubdir(t, remote, streaming, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	var got []string
+	for _, c := range remote.Calls() {
+		got = append(got, strings.Join(c.Command, " "))
+	}
+	want := []string{
+		"test -d /mint/projects/scratch",
+		"sh -c test -f /mint/projects/scratch/.devcontainer/devcontainer.json -o -f /mint/projects/scratch/.devcontainer.json",
+		`sh -c 'tmux has-session -t scratch 2>/dev/null || tmux new-session -d -s scratch -c '\''/mint/projects/scratch'\'''`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("remote commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if len(streaming.calls) != 1 {
+		t.Fatalf("streaming calls = %d, want only the push", len(streaming.calls))
+	}
+	push := streaming.calls[0]
+	wantCmd := strings.Join(buildUnpackCommand("/mint/projects/scratch"), " ")
+	if gotCmd := strings.Join(push.command, " "); gotCmd != wantCmd {
+		t.Errorf("push command = %s, want %s", gotCmd, wantCmd)
+	}
+	if names := archiveNames(t, push.stdin); !slices.Equal(names, []string{".gitignore", "main.go"}) {
+		t.Errorf("pushed files = %v, want .gitignore and main.go", names)
+	}
+	for _, want := range []string{"Pushing " + dir + " (2 files", `Project "scratch" ready at /mint/projects/scratch`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestProjectAddLocalGitRepository(t *testing.T) {
+	hint.IsTTY = false
+	dir := makeLocalProject(t, "repo", map[string]string{
+		".git/HEAD":                  "ref: refs/heads/main\n",
+		".git/objects/pack/big.pack": strings.Repeat("x", 2048),
+		"README":                     "hello",
+	})
+
+	remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1")}}
+	streaming := &projectMockStreamingRemote{}
+	output, err := runProjectAddSubdir(t, remote, streaming, dir, "--name", "app", "--no-devcontainer", "--max-git-object-size", "1KiB")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	var got []string
+	for _, c := range remote.Calls() {
+		got = append(got, strings.Join(c.Command, " "))
+	}
+	// A pushed git repository records its settings and reports its
+	// identity like a clone.
+	for _, want := range []string{"git -C /mint/projects/app config mint.devcontainer false", "git -C /mint/projects/app config --show-origin user.email"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("remote commands missing %q:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+	if names := archiveNames(t, streaming.calls[0].stdin); !slices.Equal(names, []string{".git/HEAD", "README"}) {
+		t.Errorf("pushed files = %v, want the big pack left out", names)
+	}
+	if !strings.Contains(output, "left out 1 git object(s) over 1KiB: .git/objects/pack/big.pack (2 KiB)") {
+		t.Errorf("output missing the skipped object warning:\n%s", output)
+	}
+}
+
+func TestProjectAddLocalFlag(t *testing.T) {
+	hint.IsTTY = false
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join("org", "repo"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("--local=false clones a shorthand that is also a directory", func(t *testing.T) {
+		remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1")}}
+		streaming := &projectMockStreamingRemote{}
+		if output, err := runProjectAddSubdir(t, remote, streaming, "org/repo", "--local=false"); err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, output)
+		}
+		if len(streaming.calls) == 0 || !strings.Contains(strings.Join(streaming.calls[0].command, " "), "git clone git@github.com:org/repo.git") {
+			t.Errorf("streaming calls = %+v, want the clone", streaming.calls)
+		}
+	})
+
+	for _, tt := range []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"--local needs a directory", []string{"https://github.com/org/app.git", "--local"}, "--local: https://github.com/org/app.git is not a directory"},
+		{"--branch needs a git URL", []string{"org/repo", "--branch", "main"}, "--branch applies only to git URLs"},
+		{"--subdir needs a git URL", []string{"org/repo", "--subdir", "x"}, "--subdir applies only to git URLs"},
+		{"bad size", []string{"org/repo", "--max-git-object-size", "lots"}, "invalid --max-git-object-size"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{}
+			_, err := runProjectAddSubdir(t, remote, &projectMockStreamingRemote{}, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if len(remote.Calls()) != 0 {
+				t.Errorf("expected no remote calls, got %d", len(remote.Calls()))
+			}
+		})
+	}
+}
+
+func TestProjectAddLocalUnsafeDirectoryName(t *testing.T) {
+	dir := makeLocalProject(t, "my project", map[string]string{"a": "b"})
+	_, err := runProjectAddSubdir(t, &cmdtest.RemoteRunner{}, &projectMockStreamingRemote{}, dir)
+	if err == nil || !strings.Contains(err.Error(), "pass --name to choose one") {
+		t.Fatalf("error = %v, want a hint to pass --name", err)
+	}
+}
+
+func TestPushLocalProjectReportsLocalReadError(t *testing.T) {
+	dir := makeLocalProject(t, "app", map[string]string{"a": "b"})
+	tree, err := scanLocalProject(newProjectAddCommand(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file vanishes between the scan and the upload.
+	if err := os.Remove(filepath.Join(dir, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	streaming := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string, stderr io.Writer) ([]byte, error) {
+		stdin := remoteStdinFromContext(ctx)()
+		defer stdin.Close()
+		if _, err := io.ReadAll(stdin); err != nil {
+			return nil, fmt.Errorf("remote command failed: exit status 2")
+		}
+		return nil, nil
+	}
+	found := &vm.VM{ID: "i-abc123", AvailabilityZone: "us-east-1a", PublicIP: "1.2.3.4"}
+	err = pushLocalProject(context.Background(), streaming, &projectAddDeps{}, found, tree, "/mint/projects/app", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "no such file") {
+		t.Errorf("error = %v, want the local read error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// Per-project outcomes of mint project rebuild --all.
+const (
+	rebuildStatusRebuilt = "rebuilt"
+	rebuildStatusFailed  = "failed"
+)
+
+// projectRebuildResult is one project's outcome of mint project rebuild
+// --all, and an element of its JSON output.
+type projectRebuildResult struct {
+	Project string `json:"project"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// rebuildAllProjects rebuilds every project under /mint/projects in turn,
+// after the user types "all" to confirm (unless yes). A project that fails
+// does not stop the others; the summary, or with jsonOutput the JSON array
+// of results, lists each one, and the command exits 1 when any failed.
+// Progress goes to stderr under jsonOutput so stdout is only the JSON.
+func rebuildAllProjects(ctx context.Context, cmd *cobra.Command, deps *projectRebuildDeps, remote RemoteCommandRunner,
+	found *vm.VM, vmName string, stdin io.Reader, yes, pull, jsonOutput bool) error {
+	w := cmd.OutOrStdout()
+	progress := w
+	if jsonOutput {
+		progress = cmd.ErrOrStderr()
+	}
+
+	dirs, err := listProjectDirs(func(command ...string) ([]byte, error) {
+		return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	})
+	if err != nil {
+		if isTOFUError(err) {
+			return err
+		}
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	var projects []string
+	for _, dir := range dirs {
+		if validateProjectName(dir) == nil {
+			projects = append(projects, dir)
+		}
+	}
+	if len(projects) == 0 {
+		if jsonOutput {
+			return writeProjectRebuildJSON(w, nil)
+		}
+		fmt.Fprintln(w, "No projects to rebuild.")
+		return nil
+	}
+
+	if !yes {
+		fmt.Fprintf(progress, "This will destroy and rebuild the devcontainers of %d project(s): %s.\n",
+			len(projects), strings.Join(projects, ", "))
+		fmt.Fprintf(progress, "Type \"all\" to confirm: ")
+		scanner := bufio.NewScanner(stdin)
+		if !scanner.Scan() {
+			return fmt.Errorf("no confirmation input received — rebuild aborted")
+		}
+		if input := strings.TrimSpace(scanner.Text()); input != "all" {
+			return fmt.Errorf("confirmation %q does not match \"all\" — rebuild aborted", input)
+		}
+	}
+
+	results := make([]projectRebuildResult, 0, len(projects))
+	for i, project := range projects {
+		fmt.Fprintf(progress, "\n[%d/%d] Rebuilding %q...\n", i+1, len(projects), project)
+		err := ctx.Err()
+		if err == nil {
+			var override *devcontainerOverride
+			override, err = resolveDevcontainerOverride(cmd, deps.overrideDir, project)
+			if err == nil {
+				err = rebuildProject(ctx, progress, deps, remote, found, vmName, project, override, pull)
+			}
+		}
+		result := projectRebuildResult{Project: project, Status: rebuildStatusRebuilt}
+		if err != nil {
+			result.Status = rebuildStatusFailed
+			result.Error = err.Error()
+			fmt.Fprintf(progress, "[%d/%d] %s failed: %v\n", i+1, len(projects), project, err)
+		}
+		results = append(results, result)
+	}
+
+	if jsonOutput {
+		if err := writeProjectRebuildJSON(w, results); err != nil {
+			return err
+		}
+	} else {
+		writeProjectRebuildSummary(w, results)
+	}
+	for _, r := range results {
+		if r.Status == rebuildStatusFailed {
+			return silentExitError{}
+		}
+	}
+	return nil
+}
+
+// writeProjectRebuildSummary prints the project → status table and lists
+// the failures.
+func writeProjectRebuildSummary(w io.Writer, results []projectRebuildResult) {
+	fmt.Fprintf(w, "\n%-24s  %s\n", "PROJECT", "STATUS")
+	var failed []projectRebuildResult
+	for _, r := range results {
+		fmt.Fprintf(w, "%-24s  %s\n", r.Project, r.Status)
+		if r.Status == rebuildStatusFailed {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		fmt.Fprintf(w, "\nAll %d projects rebuilt.\n", len(results))
+		return
+	}
+	fmt.Fprintf(w, "\n%d of %d projects failed:\n", len(failed), len(results))
+	for _, r := range failed {
+		fmt.Fprintf(w, "  %s: %s\n", r.Project, r.Error)
+	}
+}
+
+// writeProjectRebuildJSON prints the results as a JSON array.
+func writeProjectRebuildJSON(w io.Writer, results []projectRebuildResult) error {
+	if results == nil {
+		results = []projectRebuildResult{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+// rebuildAllMocks answers the remote commands of mint project rebuild
+// --all for the projects in /mint/projects, failing the devcontainer build
+// of the projects in failBuild.
+type rebuildAllMocks struct {
+	projects  []string
+	failBuild map[string]bool
+	commands  []string
+	builds    [][]string
+}
+
+func (m *rebuildAllMocks) remote(_ context.Context, _ mintaws.SendSSHPublicKeyAPI, _, _, _ string, _ int, _ string, command []string) ([]byte, error) {
+	joined := strings.Join(command, " ")
+	m.commands = append(m.commands, joined)
+	switch {
+	case joined == "ls -1 /mint/projects/":
+		return []byte(strings.Join(m.projects, "\n") + "\nlost+found\n"), nil
+	case strings.HasPrefix(joined, "docker ps -q"):
+		return []byte("ctr-1\n"), nil
+	}
+	return nil, nil
+}
+
+func (m *rebuildAllMocks) streaming(_ context.Context, _ mintaws.SendSSHPublicKeyAPI, _, _, _ string, _ int, _ string, command []string, _ io.Writer) ([]byte, error) {
+	m.builds = append(m.builds, command)
+	if m.failBuild[strings.TrimPrefix(command[3], "/mint/projects/")] {
+		return nil, fmt.Errorf("exit status 1")
+	}
+	return nil, nil
+}
+
+// runRebuildAll runs mint project rebuild with args against m, returning
+// stdout and stderr separately.
+func runRebuildAll(t *testing.T, m *rebuildAllMocks, stdin string, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+	deps := &projectRebuildDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:           "alice",
+		remote:          m.remote,
+		streamingRunner: m.streaming,
+		stdin:           strings.NewReader(stdin),
+	}
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	root := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(deps))
+	root.SetOut(out)
+	root.SetErr(errOut)
+	root.SetArgs(append([]string{"project", "rebuild"}, args...))
+	err = root.Execute()
+	return out.String(), errOut.String(), err
+}
+
+func TestProjectRebuildAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		failBuild   map[string]bool
+		stdin       string
+		args        []string
+		wantErr     string
+		wantBuilds  []string
+		wantOutput  []string
+		wantSilent  bool
+		wantNoBuild bool
+	}{
+		{
+			name:       "all succeed",
+			args:       []string{"--all", "--yes"},
+			wantBuilds: []string{"/mint/projects/api", "/mint/projects/web"},
+			wantOutput: []string{"[1/2] Rebuilding \"api\"", "[2/2] Rebuilding \"web\"", "api                       rebuilt", "All 2 projects rebuilt."},
+		},
+		{
+			name:       "failure does not stop the rest",
+			failBuild:  map[string]bool{"api": true},
+			args:       []string{"--all", "--yes"},
+			wantBuilds: []string{"/mint/projects/api", "/mint/projects/web"},
+			wantOutput: []string{"api                       failed", "web                       rebuilt", "1 of 2 projects failed:\n  api: rebuilding devcontainer: exit status 1"},
+			wantSilent: true,
+		},
+		{
+			name:       "typed all confirms",
+			stdin:      "all\n",
+			args:       []string{"--all"},
+			wantBuilds: []string{"/mint/projects/api", "/mint/projects/web"},
+			wantOutput: []string{"devcontainers of 2 project(s): api, web.", `Type "all" to confirm`},
+		},
+		{
+			name:        "project name does not confirm",
+			stdin:       "api\n",
+			args:        []string{"--all"},
+			wantErr:     `confirmation "api" does not match "all"`,
+			wantNoBuild: true,
+		},
+		{
+			name:        "name and --all",
+			args:        []string{"api", "--all"},
+			wantErr:     "specify a project name or --all",
+			wantNoBuild: true,
+		},
+		{
+			name:        "--override with --all",
+			args:        []string{"--all", "--yes", "--override", "x.json"},
+			wantErr:     "--override cannot be combined with --all",
+			wantNoBuild: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &rebuildAllMocks{projects: []string{"api", "web"}, failBuild: tt.failBuild}
+			stdout, stderr, err := runRebuildAll(t, m, tt.stdin, tt.args...)
+			switch {
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+			case tt.wantSilent:
+				if !errors.As(err, new(silentExitError)) {
+					t.Fatalf("error = %v, want a silent exit", err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v\n%s%s", err, stdout, stderr)
+			}
+
+			var workspaces []string
+			for _, b := range m.builds {
+				workspaces = append(workspaces, b[3])
+			}
+			if tt.wantNoBuild && len(workspaces) > 0 {
+				t.Errorf("built %v, want nothing", workspaces)
+			}
+			if tt.wantBuilds != nil && !slices.Equal(workspaces, tt.wantBuilds) {
+				t.Errorf("built %v, want %v", workspaces, tt.wantBuilds)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("output missing %q:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectRebuildAllJSON(t *testing.T) {
+	m := &rebuildAllMocks{projects: []string{"api", "web"}, failBuild: map[string]bool{"web": true}}
+	stdout, stderr, err := runRebuildAll(t, m, "", "--all", "--yes", "--json")
+	if !errors.As(err, new(silentExitError)) {
+		t.Fatalf("error = %v, want a silent exit", err)
+	}
+
+	var results []projectRebuildResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	want := []projectRebuildResult{
+		{Project: "api", Status: "rebuilt"},
+		{Project: "web", Status: "failed", Error: "rebuilding devcontainer: exit status 1"},
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+	if !strings.Contains(stderr, `[1/2] Rebuilding "api"`) {
+		t.Errorf("progress should go to stderr, got:\n%s", stderr)
+	}
+}
+
+func TestProjectRebuildPull(t *testing.T) {
+	for _, args := range [][]string{{"api", "--yes", "--pull"}, {"--all", "--yes", "--pull"}} {
+		m := &rebuildAllMocks{projects: []string{"api"}}
+		if _, _, err := runRebuildAll(t, m, "", args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if len(m.builds) != 1 || !slices.Contains(m.builds[0], "--build-no-cache") {
+			t.Errorf("%v: builds = %v, want devcontainer up --build-no-cache", args, m.builds)
+		}
+	}
+
+	m := &rebuildAllMocks{projects: []string{"api"}}
+	if _, _, err := runRebuildAll(t, m, "", "api", "--yes"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Contains(m.builds[0], "--build-no-cache") {
+		t.Errorf("build without --pull = %v, want the cache used", m.builds[0])
+	}
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// projectStartDeps holds the injectable dependencies for the project start command.
+type projectStartDeps struct {
+	describe       mintaws.DescribeInstancesAPI
+	sendKey        mintaws.SendSSHPublicKeyAPI
+	owner          string
+	remote         RemoteCommandRunner
+	sshPort        int // ssh_port from config; zero means defaultSSHPort
+	hostKeyStore   *sshconfig.HostKeyStore
+	hostKeyScanner HostKeyScanner
+	// cacheDir holds the per-VM project cache that records last-known
+	// container states. Empty disables recording.
+	cacheDir string
+}
+
+// newProjectStartCommand creates the production project start subcommand.
+func newProjectStartCommand() *cobra.Command {
+	return newProjectStartCommandWithDeps(nil)
+}
+
+// newProjectStartCommandWithDeps creates the project start subcommand with
+// explicit dependencies for testing.
+func newProjectStartCommandWithDeps(deps *projectStartDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "start [project-name]",
+		Short: "Start a project's existing devcontainer",
+		Long: "Start the existing devcontainer of a project without rebuilding it, " +
+			"for example after the VM was stopped and started. The project's tmux " +
+			"session is recreated when it is gone, and its pane is respawned when " +
+			"the docker exec process in it has died. With --all, every project " +
+			"that has a container is started.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			if all == (len(args) == 1) {
+				return fmt.Errorf("specify a project name or --all")
+			}
+			if deps != nil {
+				return runProjectStart(cmd, deps, args)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			configDir := config.DefaultConfigDir()
+			return runProjectStart(cmd, &projectStartDeps{
+				describe:       clients.ec2Client,
+				sendKey:        clients.sendKey,
+				owner:          clients.owner,
+				remote:         clients.remoteRunner(),
+				sshPort:        clients.sshOptions.Port,
+				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
+				hostKeyScanner: defaultHostKeyScanner,
+				cacheDir:       configDir,
+			}, args)
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Start the containers of all projects")
+
+	return cmd
+}
+
+// runProjectStart executes the project start logic: discover VM, find the
+// project containers, and start each one. With no args every project is
+// started and projects without a container are skipped.
+func runProjectStart(cmd *cobra.Command, deps *projectStartDeps, args []string) error {
+	for _, name := range args {
+		if err := validateProjectName(name); err != nil {
+			return err
+		}
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	// Starting containers changes state on the VM, so verify the host key
+	// first like the other write commands (ADR-0019).
+	remote := deps.remote
+	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
+		tofu := NewTOFURemoteRunner(deps.remote, deps.hostKeyStore, deps.hostKeyScanner, vmName)
+		remote = tofu.Run
+	}
+	run := func(command ...string) ([]byte, error) {
+		return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone, found.PublicIP,
+			sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	}
+
+	w := cmd.OutOrStdout()
+	if len(args) == 1 {
+		containers, err := inspectProjectContainers(run)
+		if err != nil {
+			return fmt.Errorf("inspecting containers: %w", err)
+		}
+		if err := startProject(w, run, args[0], containers); err != nil {
+			return err
+		}
+		recordStartedProject(deps.cacheDir, vmName, args[0])
+		return nil
+	}
+
+	projects, err := listProjectDirs(run)
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	containers, err := inspectProjectContainers(run)
+	if err != nil {
+		return fmt.Errorf("inspecting containers: %w", err)
+	}
+	failed := 0
+	for _, project := range projects {
+		if validateProjectName(project) != nil {
+			continue
+		}
+		if containers["/mint/projects/"+project] == nil {
+			fmt.Fprintf(w, "Skipping %q: no container.\n", project)
+			continue
+		}
+		if err := startProject(w, run, project, containers); err != nil {
+			fmt.Fprintf(w, "Warning: %v\n", err)
+			failed++
+			continue
+		}
+		recordStartedProject(deps.cacheDir, vmName, project)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) could not be started", failed)
+	}
+	return nil
+}
+
+// startProject starts one project's container and reports what it did.
+func startProject(w io.Writer, run func(command ...string) ([]byte, error), project string, containers map[string]*containerInspect) error {
+	result, err := startProjectContainer(run, project, containers["/mint/projects/"+project])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, result.summary())
+	if result.mountWarning != "" {
+		fmt.Fprintf(w, "Warning: %s: %s\n", project, result.mountWarning)
+	}
+	return nil
+}
+
+// recordStartedProject records project as running in the project cache.
+// The cache only drives mint up's reconcile, so a failed write is ignored.
+func recordStartedProject(cacheDir, vmName, project string) {
+	if cacheDir != "" {
+		_ = recordProjectState(cacheDir, vmName, project, projectStateRunning)
+	}
+}
+
+// projectStartResult describes what startProjectContainer did.
+type projectStartResult struct {
+	project string
+	// started is true when the container was stopped and has been started.
+	started bool
+	// session is "created" or "respawned" when the tmux session was
+	// repaired, and empty when it was already healthy.
+	session string
+	// mountWarning is set when the workspace is not mounted as expected.
+	mountWarning string
+}
+
+func (r projectStartResult) summary() string {
+	msg := fmt.Sprintf("Started %q.", r.project)
+	if !r.started {
+		msg = fmt.Sprintf("Container for %q is already running.", r.project)
+	}
+	switch r.session {
+	case "created":
+		msg += " Created its tmux session."
+	case "respawned":
+		msg += " Respawned its dead tmux pane."
+	}
+	return msg
+}
+
+// startProjectContainer starts the existing container c of project without
+// rebuilding it, checks the workspace mount, and makes sure the project's
+// tmux session has a live docker exec pane.
+func startProjectContainer(run func(command ...string) ([]byte, error), project string, c *containerInspect) (projectStartResult, error) {
+	result := projectStartResult{project: project}
+	if c == nil {
+		return result, fmt.Errorf("no container for project %q — run %s to build one",
+			project, hint.Cmd("mint project rebuild "+project))
+	}
+
+	if !c.State.Running {
+		if _, err := run("docker", "start", c.ID); err != nil {
+			return result, fmt.Errorf("starting container for %q: %w", project, err)
+		}
+		result.started = true
+	}
+
+	if mount := checkProjectMount(project, project, c); mount.status != "PASS" {
+		result.mountWarning = mount.message
+	}
+
+	execCmd := []string{"docker", "exec", "-it", c.ID, "/bin/bash"}
+	if _, err := run("tmux", "has-session", "-t", project); err != nil {
+		if _, err := run(append([]string{"tmux", "new-session", "-d", "-s", project}, execCmd...)...); err != nil {
+			return result, fmt.Errorf("creating tmux session for %q: %w", project, err)
+		}
+		result.session = "created"
+		return result, nil
+	}
+
+	// The format is quoted: a word starting with # is a comment to the
+	// remote shell.
+	panes, err := run("tmux", "list-panes", "-t", project, "-F", shellQuote("#{pane_dead}"))
+	if err != nil {
+		return result, fmt.Errorf("checking tmux session for %q: %w", project, err)
+	}
+	for _, dead := range strings.Fields(string(panes)) {
+		if dead != "1" {
+			continue
+		}
+		if _, err := run(append([]string{"tmux", "respawn-pane", "-k", "-t", project}, execCmd...)...); err != nil {
+			return result, fmt.Errorf("respawning tmux pane for %q: %w", project, err)
+		}
+		result.session = "respawned"
+		break
+	}
+	return result, nil
+}
+
+// listProjectDirs returns the project directories under /mint/projects.
+func listProjectDirs(run func(command ...string) ([]byte, error)) ([]string, error) {
+	output, err := run("ls", "-1", "/mint/projects/")
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if p := strings.TrimSpace(line); p != "" && p != "lost+found" {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
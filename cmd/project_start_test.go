@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// startRemoteRunner answers the project start commands from fixtures and
+// records each command it runs.
+type startRemoteRunner struct {
+	ls      string
+	ps      string
+	inspect string
+	// sessions are the tmux sessions that exist, mapped to their
+	// list-panes #{pane_dead} output.
+	sessions map[string]string
+	// sshDown fails this many calls before SSH answers.
+	sshDown int
+	calls   []string
+}
+
+func (r *startRemoteRunner) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	joined := strings.Join(command, " ")
+	r.calls = append(r.calls, joined)
+	if r.sshDown > 0 {
+		r.sshDown--
+		return nil, errors.New("ssh: connect to host 1.2.3.4 port 22: Connection refused")
+	}
+	switch {
+	case joined == "true":
+		return nil, nil
+	case joined == "ls -1 /mint/projects/":
+		return []byte(r.ls), nil
+	case strings.HasPrefix(joined, "docker ps -aq"):
+		return []byte(r.ps), nil
+	case strings.HasPrefix(joined, "docker inspect"):
+		return []byte(r.inspect), nil
+	case strings.HasPrefix(joined, "docker start "):
+		return nil, nil
+	case strings.HasPrefix(joined, "tmux has-session -t "):
+		if _, ok := r.sessions[command[3]]; ok {
+			return nil, nil
+		}
+		return nil, errors.New("remote command failed: exit status 1")
+	case strings.HasPrefix(joined, "tmux list-panes -t "):
+		return []byte(r.sessions[command[3]]), nil
+	case strings.HasPrefix(joined, "tmux new-session "), strings.HasPrefix(joined, "tmux respawn-pane "):
+		return nil, nil
+	}
+	return nil, errors.New("unexpected command: " + joined)
+}
+
+func runProjectStartCommand(t *testing.T, runner *startRemoteRunner, cacheDir string, args ...string) (string, error) {
+	t.Helper()
+	deps := &projectStartDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		remote:   runner.run,
+		cacheDir: cacheDir,
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithStartDeps(deps))
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"project", "start"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestProjectStartStartsStoppedContainer(t *testing.T) {
+	runner := &startRemoteRunner{
+		ps:      "c1\n",
+		inspect: "[" + inspectFixture("c1", "api", false, 0, "/mint/projects/api") + "]",
+	}
+	cacheDir := t.TempDir()
+
+	out, err := runProjectStartCommand(t, runner, cacheDir, "api")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	want := []string{
+		"docker ps -aq --filter label=devcontainer.local_folder",
+		"docker inspect c1",
+		"docker start c1",
+		"tmux has-session -t api",
+		"tmux new-session -d -s api docker exec -it c1 /bin/bash",
+	}
+	if !slices.Equal(runner.calls, want) {
+		t.Errorf("commands = %q, want %q", runner.calls, want)
+	}
+	if !strings.Contains(out, `Started "api". Created its tmux session.`) {
+		t.Errorf("output = %q", out)
+	}
+	if got := lastRunningProjects(cacheDir, "default"); !slices.Equal(got, []string{"api"}) {
+		t.Errorf("recorded running projects = %v, want [api]", got)
+	}
+}
+
+func TestProjectStartRespawnsDeadPane(t *testing.T) {
+	runner := &startRemoteRunner{
+		ps:       "c1\n",
+		inspect:  "[" + inspectFixture("c1", "api", true, 0, "/mint/projects/api") + "]",
+		sessions: map[string]string{"api": "1\n"},
+	}
+
+	out, err := runProjectStartCommand(t, runner, "", "api")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	want := []string{
+		"docker ps -aq --filter label=devcontainer.local_folder",
+		"docker inspect c1",
+		"tmux has-session -t api",
+		"tmux list-panes -t api -F '#{pane_dead}'",
+		"tmux respawn-pane -k -t api docker exec -it c1 /bin/bash",
+	}
+	if !slices.Equal(runner.calls, want) {
+		t.Errorf("commands = %q, want %q", runner.calls, want)
+	}
+	if !strings.Contains(out, `Container for "api" is already running. Respawned its dead tmux pane.`) {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestProjectStartCommand(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name        string
+		args        []string
+		runner      *startRemoteRunner
+		wantErr     string
+		wantContain []string
+		wantNoCall  string
+	}{
+		{
+			name:    "name or --all is required",
+			args:    nil,
+			runner:  &startRemoteRunner{},
+			wantErr: "specify a project name or --all",
+		},
+		{
+			name:    "name and --all conflict",
+			args:    []string{"api", "--all"},
+			runner:  &startRemoteRunner{},
+			wantErr: "specify a project name or --all",
+		},
+		{
+			name:    "no container points at rebuild",
+			args:    []string{"api"},
+			runner:  &startRemoteRunner{},
+			wantErr: "no container for project \"api\" — run `mint project rebuild api`",
+		},
+		{
+			name: "healthy session is left alone",
+			args: []string{"api"},
+			runner: &startRemoteRunner{
+				ps:       "c1\n",
+				inspect:  "[" + inspectFixture("c1", "api", true, 0, "/mint/projects/api") + "]",
+				sessions: map[string]string{"api": "0\n"},
+			},
+			wantContain: []string{`Container for "api" is already running.`},
+			wantNoCall:  "tmux respawn-pane",
+		},
+		{
+			name: "wrong mount is a warning",
+			args: []string{"api"},
+			runner: &startRemoteRunner{
+				ps:      "c1\n",
+				inspect: "[" + inspectFixture("c1", "api", false, 0, "/home/ubuntu/api") + "]",
+			},
+			wantContain: []string{`Started "api".`, "Warning: api: workspace is not mounted from /mint/projects/api"},
+		},
+		{
+			name: "--all skips projects without a container",
+			args: []string{"--all"},
+			runner: &startRemoteRunner{
+				ls:      "api\ndocs\n",
+				ps:      "c1\n",
+				inspect: "[" + inspectFixture("c1", "api", false, 0, "/mint/projects/api") + "]",
+			},
+			wantContain: []string{`Started "api".`, `Skipping "docs": no container.`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runProjectStartCommand(t, tt.runner, "", tt.args...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out)
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			for _, call := range tt.runner.calls {
+				if tt.wantNoCall != "" && strings.HasPrefix(call, tt.wantNoCall) {
+					t.Errorf("unexpected command %q", call)
+				}
+				if strings.HasPrefix(call, "devcontainer") {
+					t.Errorf("project start must not rebuild: %q", call)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectStartVMNotRunning(t *testing.T) {
+	deps := &projectStartDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeStoppedInstanceForProject("i-abc123", "default", "alice")},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		remote:   (&startRemoteRunner{}).run,
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithStartDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"project", "start", "api"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "is not running") {
+		t.Errorf("error = %v, want VM not running", err)
+	}
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// otherProject is the row that aggregates containers no project owns.
+const otherProject = "(other)"
+
+// projectStatsDeps holds the injectable dependencies for the project stats command.
+type projectStatsDeps struct {
+	describe mintaws.DescribeInstancesAPI
+	sendKey  mintaws.SendSSHPublicKeyAPI
+	owner    string
+	remote   RemoteCommandRunner
+	sshPort  int // ssh_port from config; zero means defaultSSHPort
+	// watch runs the --watch refresh loop.
+	watch watchLoop
+}
+
+// projectStats is the resource usage of one project's containers.
+type projectStats struct {
+	Project         string  `json:"project"`
+	Containers      int     `json:"containers"`
+	CPUPercent      float64 `json:"cpu_percent"`
+	MemUsedBytes    int64   `json:"mem_used_bytes"`
+	MemLimitBytes   int64   `json:"mem_limit_bytes"`
+	BlockReadBytes  int64   `json:"block_read_bytes"`
+	BlockWriteBytes int64   `json:"block_write_bytes"`
+	PIDs            int     `json:"pids"`
+}
+
+// hostStats summarizes the VM itself.
+type hostStats struct {
+	Load1         float64 `json:"load1"`
+	Load5         float64 `json:"load5"`
+	Load15        float64 `json:"load15"`
+	MemTotalBytes int64   `json:"mem_total_bytes"`
+	MemUsedBytes  int64   `json:"mem_used_bytes"`
+}
+
+// projectStatsReport is the JSON output of project stats.
+type projectStatsReport struct {
+	Projects []projectStats `json:"projects"`
+	Host     hostStats      `json:"host"`
+}
+
+// dockerStatsLine is one line of docker stats --format json.
+type dockerStatsLine struct {
+	ID       string `json:"ID"`
+	CPUPerc  string `json:"CPUPerc"`
+	MemUsage string `json:"MemUsage"`
+	BlockIO  string `json:"BlockIO"`
+	PIDs     string `json:"PIDs"`
+}
+
+// newProjectStatsCommand creates the production project stats subcommand.
+func newProjectStatsCommand() *cobra.Command {
+	return newProjectStatsCommandWithDeps(nil)
+}
+
+// newProjectStatsCommandWithDeps creates the project stats subcommand with
+// explicit dependencies for testing.
+func newProjectStatsCommandWithDeps(deps *projectStatsDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show resource usage of project containers",
+		Long: "Show the CPU, memory, block I/O, and process count of each project's " +
+			"containers, from one docker stats sample on the VM, with the VM's load " +
+			"average and memory. Containers that belong to no project are summed " +
+			"under " + otherProject + ".\n\n" +
+			"With --watch the table is refreshed every few seconds until Ctrl-C.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runProjectStats(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runProjectStats(cmd, &projectStatsDeps{
+				describe: clients.ec2Client,
+				sendKey:  clients.sendKey,
+				owner:    clients.owner,
+				remote:   clients.remoteRunner(),
+				sshPort:  clients.sshOptions.Port,
+			})
+		},
+	}
+
+	cmd.Flags().Bool("watch", false, "Refresh the stats every few seconds until Ctrl-C")
+
+	return cmd
+}
+
+// runProjectStats discovers the VM, samples its containers, and renders the
+// per-project usage once or, with --watch, repeatedly.
+func runProjectStats(cmd *cobra.Command, deps *projectStatsDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	watch, _ := cmd.Flags().GetBool("watch")
+	w := cmd.OutOrStdout()
+	render := func(ctx context.Context) error {
+		out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildProjectStatsCommand())
+		if err != nil {
+			return fmt.Errorf("reading container stats: %w", err)
+		}
+		report, err := parseProjectStats(string(out))
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		writeProjectStatsHuman(w, vmName, report)
+		if watch {
+			fmt.Fprintln(w, "Refreshing every few seconds — press Ctrl-C to stop.")
+		}
+		return nil
+	}
+
+	if watch {
+		return deps.watch.run(ctx, w, render)
+	}
+	return render(ctx)
+}
+
+// Section markers of the project stats script output.
+const (
+	statsSectionStats      = "== stats"
+	statsSectionContainers = "== containers"
+	statsSectionLoad       = "== loadavg"
+	statsSectionMem        = "== meminfo"
+)
+
+// buildProjectStatsCommand returns one remote script that samples docker
+// stats, maps container IDs to their devcontainer folders, and reads the
+// load average and memory totals, each output under a section marker.
+func buildProjectStatsCommand() []string {
+	script := strings.Join([]string{
+		"echo '" + statsSectionStats + "'",
+		"docker stats --no-stream --format json",
+		"echo '" + statsSectionContainers + "'",
+		"docker ps --format '{{.ID}}\t{{.Label \"devcontainer.local_folder\"}}'",
+		"echo '" + statsSectionLoad + "'",
+		"cat /proc/loadavg",
+		"echo '" + statsSectionMem + "'",
+		"grep -E '^(MemTotal|MemAvailable):' /proc/meminfo",
+	}, "; ")
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// parseProjectStats parses the output of buildProjectStatsCommand. Each
+// container is joined to its project by devcontainer.local_folder, the
+// same label project rebuild resolves, and containers with no project are
+// summed under otherProject, listed last.
+func parseProjectStats(output string) (*projectStatsReport, error) {
+	sections := map[string][]string{}
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch line {
+		case statsSectionStats, statsSectionContainers, statsSectionLoad, statsSectionMem:
+			section = line
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			sections[section] = append(sections[section], line)
+		}
+	}
+
+	projectOf := map[string]string{}
+	for _, line := range sections[statsSectionContainers] {
+		id, folder, _ := strings.Cut(line, "\t")
+		root := projectRootFolder(strings.TrimSpace(folder))
+		if name, ok := strings.CutPrefix(root, "/mint/projects/"); ok && name != "" {
+			projectOf[shortContainerID(id)] = name
+		}
+	}
+
+	byProject := map[string]*projectStats{}
+	for _, line := range sections[statsSectionStats] {
+		var s dockerStatsLine
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			return nil, fmt.Errorf("parsing docker stats line %q: %w", line, err)
+		}
+		name, ok := projectOf[shortContainerID(s.ID)]
+		if !ok {
+			name = otherProject
+		}
+		p := byProject[name]
+		if p == nil {
+			p = &projectStats{Project: name}
+			byProject[name] = p
+		}
+		used, limit := parseStatSizePair(s.MemUsage)
+		read, written := parseStatSizePair(s.BlockIO)
+		pids, _ := strconv.Atoi(strings.TrimSpace(s.PIDs))
+		p.Containers++
+		p.CPUPercent += parseStatPercent(s.CPUPerc)
+		p.MemUsedBytes += used
+		// Containers without their own limit all report the VM's memory,
+		// so summing limits would overstate it.
+		p.MemLimitBytes = max(p.MemLimitBytes, limit)
+		p.BlockReadBytes += read
+		p.BlockWriteBytes += written
+		p.PIDs += pids
+	}
+
+	report := &projectStatsReport{Projects: []projectStats{}}
+	for _, p := range byProject {
+		report.Projects = append(report.Projects, *p)
+	}
+	sort.Slice(report.Projects, func(i, j int) bool {
+		a, b := report.Projects[i].Project, report.Projects[j].Project
+		if (a == otherProject) != (b == otherProject) {
+			return b == otherProject
+		}
+		return a < b
+	})
+
+	report.Host = parseHostStats(sections[statsSectionLoad], sections[statsSectionMem])
+	return report, nil
+}
+
+// shortContainerID returns the 12-character form docker ps and docker
+// stats print, so full and short IDs join.
+func shortContainerID(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// parseHostStats reads the load averages from /proc/loadavg and memory
+// totals from the MemTotal and MemAvailable lines of /proc/meminfo.
+// Missing or malformed values are left zero.
+func parseHostStats(loadLines, memLines []string) hostStats {
+	var h hostStats
+	if len(loadLines) > 0 {
+		fields := strings.Fields(loadLines[0])
+		loads := []*float64{&h.Load1, &h.Load5, &h.Load15}
+		for i := 0; i < len(loads) && i < len(fields); i++ {
+			*loads[i], _ = strconv.ParseFloat(fields[i], 64)
+		}
+	}
+	var available int64
+	for _, line := range memLines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "MemTotal":
+			h.MemTotalBytes = kb * format.KiB
+		case "MemAvailable":
+			available = kb * format.KiB
+		}
+	}
+	if h.MemTotalBytes > 0 {
+		h.MemUsedBytes = max(h.MemTotalBytes-available, 0)
+	}
+	return h
+}
+
+// parseStatSize parses a docker stats size such as "1.5GiB", "12.3MB", or
+// "4.1kB". Docker mixes binary units for memory with decimal units for I/O.
+// Values docker cannot measure, such as "--", and anything else that does
+// not parse count as zero, so one odd container does not hide the rest.
+func parseStatSize(s string) int64 {
+	n, err := format.ParseSize(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseStatSizePair parses a docker stats "used / limit" or "read / write"
+// pair such as "1.5GiB / 15.6GiB".
+func parseStatSizePair(s string) (int64, int64) {
+	a, b, _ := strings.Cut(s, "/")
+	return parseStatSize(a), parseStatSize(b)
+}
+
+// parseStatPercent parses a docker stats percentage such as "12.34%",
+// counting anything unparsable as zero.
+func parseStatPercent(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// writeProjectStatsHuman outputs the per-project usage table and a host
+// summary line.
+func writeProjectStatsHuman(w io.Writer, vmName string, report *projectStatsReport) {
+	if len(report.Projects) == 0 {
+		fmt.Fprintf(w, "No running containers on VM %q.\n", vmName)
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROJECT\tCPU %\tMEM USED / LIMIT\tBLOCK I/O (R / W)\tPIDS")
+		for _, p := range report.Projects {
+			fmt.Fprintf(tw, "%s\t%.1f%%\t%s / %s\t%s / %s\t%d\n", p.Project, p.CPUPercent,
+				format.FormatSize(p.MemUsedBytes), format.FormatSize(p.MemLimitBytes),
+				format.FormatSize(p.BlockReadBytes), format.FormatSize(p.BlockWriteBytes), p.PIDs)
+		}
+		tw.Flush()
+	}
+	h := report.Host
+	fmt.Fprintf(w, "\nHost: load %.2f %.2f %.2f · memory %s / %s used\n", h.Load1, h.Load5, h.Load15,
+		format.FormatSize(h.MemUsedBytes), format.FormatSize(h.MemTotalBytes))
+}
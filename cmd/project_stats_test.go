@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+)
+
+// projectStatsFixture is the stats script output for a VM running a
+// two-container project (one built from a --subdir folder), a
+// single-container project, and two containers no project owns.
+const projectStatsFixture = `== stats
+{"BlockIO":"12.3MB / 4.1kB","CPUPerc":"10.50%","ID":"aaaaaaaaaaaa","MemUsage":"1.5GiB / 15.6GiB","Name":"api-app","PIDs":"20"}
+{"BlockIO":"1MB / 0B","CPUPerc":"2.00%","ID":"bbbbbbbbbbbb","MemUsage":"512MiB / 2GiB","Name":"api-db","PIDs":"5"}
+{"BlockIO":"0B / 0B","CPUPerc":"0.25%","ID":"cccccccccccc","MemUsage":"100MiB / 15.6GiB","Name":"web","PIDs":"3"}
+{"BlockIO":"2kB / 1kB","CPUPerc":"1.00%","ID":"dddddddddddd","MemUsage":"10MiB / 15.6GiB","Name":"registry","PIDs":"2"}
+{"BlockIO":"--","CPUPerc":"--","ID":"eeeeeeeeeeee","MemUsage":"-- / --","Name":"odd","PIDs":"--"}
+== containers
+aaaaaaaaaaaa	/mint/projects/api
+bbbbbbbbbbbb	/mint/projects/api/services/db
+cccccccccccc	/mint/projects/web
+dddddddddddd
+eeeeeeeeeeee	/home/ubuntu/scratch
+== loadavg
+0.52 0.40 0.31 2/345 6789
+== meminfo
+MemTotal:       16384000 kB
+MemAvailable:   12288000 kB
+`
+
+type statsRemoteRunner struct {
+	output string
+	calls  int
+}
+
+func (r *statsRemoteRunner) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	r.calls++
+	if len(command) != 3 || command[0] != "sh" || !strings.Contains(command[2], "docker stats --no-stream --format json") {
+		return nil, errors.New("unexpected command: " + strings.Join(command, " "))
+	}
+	return []byte(r.output), nil
+}
+
+func runProjectStatsCommand(t *testing.T, deps *projectStatsDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithStatsDeps(deps))
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"project", "stats"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func newProjectStatsTestDeps(runner *statsRemoteRunner) *projectStatsDeps {
+	return &projectStatsDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		remote:   runner.run,
+	}
+}
+
+func TestParseStatSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1.5GiB", 3 * format.GiB / 2},
+		{" 512MiB ", 512 * format.MiB},
+		{"12.3MB", 12_300_000},
+		{"4.1kB", 4100},
+		{"0B", 0},
+		{"--", 0},
+		{"", 0},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := parseStatSize(tt.in); got != tt.want {
+			t.Errorf("parseStatSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	used, limit := parseStatSizePair("12.3MB / 4.1kB")
+	if used != 12_300_000 || limit != 4100 {
+		t.Errorf("parseStatSizePair = %d, %d", used, limit)
+	}
+	if got := parseStatPercent("10.50%"); got != 10.5 {
+		t.Errorf("parseStatPercent = %v, want 10.5", got)
+	}
+}
+
+func TestProjectStatsJoinsAndAggregates(t *testing.T) {
+	out, err := runProjectStatsCommand(t, newProjectStatsTestDeps(&statsRemoteRunner{output: projectStatsFixture}), "--json")
+	if err != nil {
+		t.Fatalf("project stats: %v\n%s", err, out)
+	}
+	var report projectStatsReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("decoding %s: %v", out, err)
+	}
+
+	want := []projectStats{
+		{
+			Project: "api", Containers: 2, CPUPercent: 12.5,
+			MemUsedBytes: 3*format.GiB/2 + 512*format.MiB, MemLimitBytes: 16750372454,
+			BlockReadBytes: 13_300_000, BlockWriteBytes: 4100, PIDs: 25,
+		},
+		{
+			Project: "web", Containers: 1, CPUPercent: 0.25,
+			MemUsedBytes: 100 * format.MiB, MemLimitBytes: 16750372454, PIDs: 3,
+		},
+		{
+			Project: otherProject, Containers: 2, CPUPercent: 1,
+			MemUsedBytes: 10 * format.MiB, MemLimitBytes: 16750372454,
+			BlockReadBytes: 2000, BlockWriteBytes: 1000, PIDs: 2,
+		},
+	}
+	if len(report.Projects) != len(want) {
+		t.Fatalf("projects = %+v, want %d rows", report.Projects, len(want))
+	}
+	for i := range want {
+		if report.Projects[i] != want[i] {
+			t.Errorf("projects[%d] = %+v, want %+v", i, report.Projects[i], want[i])
+		}
+	}
+
+	wantHost := hostStats{Load1: 0.52, Load5: 0.40, Load15: 0.31, MemTotalBytes: 16384000 * 1024, MemUsedBytes: 4096000 * 1024}
+	if report.Host != wantHost {
+		t.Errorf("host = %+v, want %+v", report.Host, wantHost)
+	}
+}
+
+func TestProjectStatsHumanOutput(t *testing.T) {
+	out, err := runProjectStatsCommand(t, newProjectStatsTestDeps(&statsRemoteRunner{output: projectStatsFixture}))
+	if err != nil {
+		t.Fatalf("project stats: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"PROJECT  ",
+		"api      12.5%  2 GiB / 15.6 GiB",
+		"(other)  1.0%",
+		"Host: load 0.52 0.40 0.31 · memory 3.9 GiB / 15.6 GiB used",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "web") > strings.Index(out, otherProject) {
+		t.Errorf("(other) should be listed last:\n%s", out)
+	}
+}
+
+func TestProjectStatsNoContainers(t *testing.T) {
+	runner := &statsRemoteRunner{output: "== stats\n== containers\n== loadavg\n0.00 0.00 0.00 1/1 1\n== meminfo\n"}
+	out, err := runProjectStatsCommand(t, newProjectStatsTestDeps(runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `No running containers on VM "default".`) {
+		t.Errorf("output = %s", out)
+	}
+}
+
+func TestProjectStatsWatchRefreshes(t *testing.T) {
+	runner := &statsRemoteRunner{output: projectStatsFixture}
+	deps := newProjectStatsTestDeps(runner)
+	sleeps := 0
+	deps.watch = watchLoop{
+		sleep: func(ctx context.Context, d time.Duration) error {
+			if sleeps++; sleeps == 3 {
+				return context.Canceled
+			}
+			return nil
+		},
+		clear: func(io.Writer) bool { return false },
+	}
+
+	out, err := runProjectStatsCommand(t, deps, "--watch")
+	if err != nil {
+		t.Fatalf("project stats --watch: %v\n%s", err, out)
+	}
+	if runner.calls != 3 {
+		t.Errorf("stats sampled %d times, want 3", runner.calls)
+	}
+	if n := strings.Count(out, "Refreshing every few seconds"); n != 3 {
+		t.Errorf("rendered %d frames, want 3:\n%s", n, out)
+	}
+}
+
+func TestProjectStatsVMNotRunning(t *testing.T) {
+	deps := newProjectStatsTestDeps(&statsRemoteRunner{})
+	deps.describe = &cmdtest.DescribeInstances{Output: makeStoppedInstanceForProject("i-abc123", "default", "alice")}
+	_, err := runProjectStatsCommand(t, deps)
+	if err == nil || !strings.Contains(err.Error(), "is not running") {
+		t.Errorf("error = %v, want VM not running", err)
+	}
+}
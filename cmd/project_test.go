@@ -6,18 +6,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
-	"github.com/spf13/cobra"
 )
 
 // --- Project name validation tests ---
@@ -139,12 +140,20 @@ func TestClassifyCloneError(t *testing.T) {
 	tests := []struct {
 		name       string
 		gitURL     string
+		auth       string
 		stderr     string
 		wantSubstr string
 	}{
 		{
-			name:       "SSH auth failure hints at agent",
+			name:       "SSH auth failure suggests --auth agent",
+			gitURL:     "git@github.com:org/private.git",
+			stderr:     "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.",
+			wantSubstr: "mint project add --auth agent git@github.com:org/private.git",
+		},
+		{
+			name:       "SSH auth failure with --auth agent checks the agent",
 			gitURL:     "git@github.com:org/private.git",
+			auth:       cloneAuthAgent,
 			stderr:     "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.",
 			wantSubstr: "ssh-add -l",
 		},
@@ -155,16 +164,35 @@ func TestClassifyCloneError(t *testing.T) {
 			wantSubstr: "git@github.com:org/repo.git",
 		},
 		{
-			name:       "HTTPS auth failure hints at token",
+			name:       "HTTPS auth failure suggests --auth https-token",
 			gitURL:     "https://github.com/org/private",
 			stderr:     "fatal: Authentication failed for 'https://github.com/org/private'",
-			wantSubstr: "<token>@github.com",
+			wantSubstr: "--auth https-token https://github.com/org/private",
+		},
+		{
+			name:       "HTTPS auth failure with a token blames the token",
+			gitURL:     "https://github.com/org/private",
+			auth:       cloneAuthHTTPSToken,
+			stderr:     "fatal: Authentication failed for 'https://github.com/org/private'",
+			wantSubstr: "rejected the token from MINT_GIT_TOKEN",
 		},
 		{
 			name:       "repository not found",
 			gitURL:     "git@github.com:org/typo.git",
 			stderr:     "ERROR: Repository not found.\nfatal: Could not read from remote repository.",
-			wantSubstr: "repository not found",
+			wantSubstr: "retry with `--auth agent`",
+		},
+		{
+			name:       "DNS failure is a network error",
+			gitURL:     "https://git.corp.example/org/repo",
+			stderr:     "fatal: unable to access 'https://git.corp.example/org/repo/': Could not resolve host: git.corp.example",
+			wantSubstr: "not an authentication one",
+		},
+		{
+			name:       "SSH connection timeout is a network error",
+			gitURL:     "git@github.com:org/repo.git",
+			stderr:     "ssh: connect to host github.com port 22: Connection timed out\nfatal: Could not read from remote repository.",
+			wantSubstr: "could not reach the git server",
 		},
 		{
 			name:       "unrecognized error falls back to wrapped error",
@@ -175,7 +203,7 @@ func TestClassifyCloneError(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := classifyCloneError(tt.gitURL, sentinel, tt.stderr)
+			err := classifyCloneError(tt.gitURL, tt.auth, sentinel, tt.stderr)
 			if err == nil {
 				t.Fatal("expected error, got nil")
 			}
@@ -188,63 +216,6 @@ func TestClassifyCloneError(t *testing.T) {
 
 // --- Mock infrastructure for project tests ---
 
-// mockDescribeForProject implements mintaws.DescribeInstancesAPI for project tests.
-type mockDescribeForProject struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeForProject) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-// mockSendKeyForProject implements mintaws.SendSSHPublicKeyAPI for project tests.
-type mockSendKeyForProject struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-}
-
-func (m *mockSendKeyForProject) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	return m.output, m.err
-}
-
-// projectRemoteCall records a single remote command invocation.
-type projectRemoteCall struct {
-	instanceID string
-	az         string
-	host       string
-	port       int
-	user       string
-	command    []string
-}
-
-// projectMockRemote records calls and returns configurable results per call index.
-type projectMockRemote struct {
-	calls   []projectRemoteCall
-	outputs [][]byte
-	errors  []error
-}
-
-func (m *projectMockRemote) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
-	idx := len(m.calls)
-	m.calls = append(m.calls, projectRemoteCall{
-		instanceID: instanceID,
-		az:         az,
-		host:       host,
-		port:       port,
-		user:       user,
-		command:    command,
-	})
-
-	if idx < len(m.errors) && m.errors[idx] != nil {
-		return nil, m.errors[idx]
-	}
-	if idx < len(m.outputs) {
-		return m.outputs[idx], nil
-	}
-	return nil, nil
-}
-
 // projectStreamingCall records a single streaming remote command invocation.
 type projectStreamingCall struct {
 	instanceID string
@@ -253,6 +224,12 @@ type projectStreamingCall struct {
 	port       int
 	user       string
 	command    []string
+	// stdin is what the call read from the remote stdin set with
+	// withRemoteStdin, if any.
+	stdin []byte
+	// forwardAgent is whether the call asked for agent forwarding with
+	// withAgentForwarding.
+	forwardAgent bool
 }
 
 // projectMockStreamingRemote records streaming calls and returns configurable results.
@@ -260,19 +237,31 @@ type projectMockStreamingRemote struct {
 	calls   []projectStreamingCall
 	outputs [][]byte
 	errors  []error
+	// stderr is written to the call's stderr writer, by call index.
+	stderr []string
 }
 
 func (m *projectMockStreamingRemote) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string, stderr io.Writer) ([]byte, error) {
 	idx := len(m.calls)
-	m.calls = append(m.calls, projectStreamingCall{
+	call := projectStreamingCall{
 		instanceID: instanceID,
 		az:         az,
 		host:       host,
 		port:       port,
 		user:       user,
 		command:    command,
-	})
+	}
+	if open := remoteStdinFromContext(ctx); open != nil {
+		stdin := open()
+		call.stdin, _ = io.ReadAll(stdin)
+		stdin.Close()
+	}
+	call.forwardAgent, _ = ctx.Value(agentForwardingKey{}).(bool)
+	m.calls = append(m.calls, call)
 
+	if idx < len(m.stderr) && stderr != nil {
+		io.WriteString(stderr, m.stderr[idx])
+	}
 	if idx < len(m.errors) && m.errors[idx] != nil {
 		return nil, m.errors[idx]
 	}
@@ -322,25 +311,6 @@ func makeStoppedInstanceForProject(id, vmName, owner string) *ec2.DescribeInstan
 	}
 }
 
-func newTestRootForProject() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 // --- Command tests ---
 
 func TestProjectAddCommand(t *testing.T) {
@@ -348,9 +318,9 @@ func TestProjectAddCommand(t *testing.T) {
 
 	tests := []struct {
 		name                string
-		describe            *mockDescribeForProject
-		sendKey             *mockSendKeyForProject
-		remote              *projectMockRemote
+		describe            *cmdtest.DescribeInstances
+		sendKey             *cmdtest.SendSSHPublicKey
+		remote              *cmdtest.RemoteRunner
 		streaming           *projectMockStreamingRemote
 		owner               string
 		args                []string
@@ -358,28 +328,28 @@ func TestProjectAddCommand(t *testing.T) {
 		wantErrContain      string
 		wantCalls           int
 		wantStreamingCalls  int
-		checkCalls          func(t *testing.T, calls []projectRemoteCall)
+		checkCalls          func(t *testing.T, calls []cmdtest.RemoteCall)
 		checkStreamingCalls func(t *testing.T, calls []projectStreamingCall)
 		checkOutput         func(t *testing.T, output string)
 	}{
 		{
 			name: "successful project add with devcontainer",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{fmt.Errorf("exit status 1"), nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{fmt.Errorf("exit status 1"), nil},
 			},
 			// streaming: clone, devcontainer up
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
 			checkStreamingCalls: func(t *testing.T, calls []projectStreamingCall) {
 				t.Helper()
@@ -406,15 +376,15 @@ func TestProjectAddCommand(t *testing.T) {
 					t.Errorf("devcontainer up should target workspace folder, got: %s", buildCmd)
 				}
 			},
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				// Remote call 0: state check (test -d, fails because dir doesn't exist)
-				preCheck := strings.Join(calls[0].command, " ")
+				preCheck := strings.Join(calls[0].Command, " ")
 				if !strings.Contains(preCheck, "test -d") {
 					t.Errorf("first remote call should be dir check, got: %s", preCheck)
 				}
 				// Remote call 1: devcontainer config check
-				dcCheck := strings.Join(calls[1].command, " ")
+				dcCheck := strings.Join(calls[1].Command, " ")
 				if !strings.Contains(dcCheck, ".devcontainer") {
 					t.Errorf("second remote call should check devcontainer config, got: %s", dcCheck)
 				}
@@ -434,23 +404,33 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "successful project add without devcontainer",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			// remote: test -d (dir doesn't exist), devcontainer config check (no config)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")},
+			// remote: test -d (dir doesn't exist), devcontainer config check (no config),
+			// record mint.devcontainer, tmux session, identity check
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")},
 			},
 			// streaming: clone only (no devcontainer up)
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "https://github.com/org/plain-repo.git"},
-			wantCalls:          2,
+			wantCalls:          5,
 			wantStreamingCalls: 1,
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
+				t.Helper()
+				if got := strings.Join(calls[2].Command, " "); got != "git -C /mint/projects/plain-repo config mint.devcontainer false" {
+					t.Errorf("third call should record mint.devcontainer, got: %s", got)
+				}
+				if got := strings.Join(calls[3].Command, " "); !strings.Contains(got, "tmux new-session -d -s plain-repo -c") {
+					t.Errorf("fourth call should create the tmux session, got: %s", got)
+				}
+			},
 			checkStreamingCalls: func(t *testing.T, calls []projectStreamingCall) {
 				t.Helper()
 				cloneCmd := strings.Join(calls[0].command, " ")
@@ -463,7 +443,7 @@ func TestProjectAddCommand(t *testing.T) {
 				if !strings.Contains(output, "Cloning") {
 					t.Errorf("output should show cloning progress, got: %s", output)
 				}
-				if !strings.Contains(output, "No devcontainer config detected") {
+				if !strings.Contains(output, "No devcontainer config found — created plain tmux session") {
 					t.Errorf("output should indicate no devcontainer config, got: %s", output)
 				}
 				if strings.Contains(output, "Building devcontainer") {
@@ -476,18 +456,18 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "successful project add with git ssh url",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote:             &projectMockRemote{outputs: [][]byte{nil, nil}, errors: []error{fmt.Errorf("exit status 1"), nil}},
+			remote:             &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil}, Errs: []error{fmt.Errorf("exit status 1"), nil}},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "git@github.com:org/my-app.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
 			checkStreamingCalls: func(t *testing.T, calls []projectStreamingCall) {
 				t.Helper()
@@ -499,23 +479,23 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "name flag overrides extracted name",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote:             &projectMockRemote{outputs: [][]byte{nil, nil}, errors: []error{fmt.Errorf("exit status 1"), nil}},
+			remote:             &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil}, Errs: []error{fmt.Errorf("exit status 1"), nil}},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "--name", "custom-name", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				// Devcontainer check references custom-name path
-				dcCheck := strings.Join(calls[1].command, " ")
+				dcCheck := strings.Join(calls[1].Command, " ")
 				if !strings.Contains(dcCheck, "/mint/projects/custom-name/.devcontainer") {
 					t.Errorf("devcontainer check should use custom name, got: %s", dcCheck)
 				}
@@ -530,18 +510,18 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "branch flag passed to git clone",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote:             &projectMockRemote{outputs: [][]byte{nil, nil}, errors: []error{fmt.Errorf("exit status 1"), nil}},
+			remote:             &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil}, Errs: []error{fmt.Errorf("exit status 1"), nil}},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "--branch", "develop", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
 			checkStreamingCalls: func(t *testing.T, calls []projectStreamingCall) {
 				t.Helper()
@@ -553,11 +533,11 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForProject{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"project", "add", "https://github.com/org/repo.git"},
@@ -566,11 +546,11 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForProject{
-				output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"project", "add", "https://github.com/org/repo.git"},
@@ -579,17 +559,17 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "resume from build when dir exists with devcontainer but no container",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (exists), devcontainer config check (has config),
 			//         docker ps check (empty)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, []byte("")},
-				errors:  []error{nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, []byte("")},
+				Errs:    []error{nil, nil, nil},
 			},
 			// streaming: devcontainer up only (clone skipped)
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}},
@@ -617,17 +597,17 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "already set up when dir exists with devcontainer and container running",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (exists), devcontainer config check (has config),
 			//         docker ps check (container running)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, []byte("abc123\n")},
-				errors:  []error{nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, []byte("abc123\n")},
+				Errs:    []error{nil, nil, nil},
 			},
 			// streaming: nothing
 			streaming:          &projectMockStreamingRemote{},
@@ -650,16 +630,16 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "already set up when dir exists without devcontainer",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (exists), devcontainer config check (no config)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{nil, fmt.Errorf("exit status 1")},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{nil, fmt.Errorf("exit status 1")},
 			},
 			// streaming: nothing
 			streaming:          &projectMockStreamingRemote{},
@@ -682,16 +662,16 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "devcontainer build failure returns clear error",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{fmt.Errorf("exit status 1"), nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{fmt.Errorf("exit status 1"), nil},
 			},
 			// streaming: clone succeeds, devcontainer up fails
 			streaming: &projectMockStreamingRemote{
@@ -707,11 +687,11 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "missing git url argument",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey:   &mockSendKeyForProject{},
-			remote:    &projectMockRemote{},
+			sendKey:   &cmdtest.SendSSHPublicKey{},
+			remote:    &cmdtest.RemoteRunner{},
 			streaming: &projectMockStreamingRemote{},
 			owner:     "alice",
 			args:      []string{"project", "add"},
@@ -719,38 +699,38 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "non-default vm name",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote:             &projectMockRemote{outputs: [][]byte{nil, nil}, errors: []error{fmt.Errorf("exit status 1"), nil}},
+			remote:             &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil}, Errs: []error{fmt.Errorf("exit status 1"), nil}},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"--vm", "dev", "project", "add", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
-				if calls[0].host != "10.0.0.1" {
-					t.Errorf("expected host 10.0.0.1, got %s", calls[0].host)
+				if calls[0].Host != "10.0.0.1" {
+					t.Errorf("expected host 10.0.0.1, got %s", calls[0].Host)
 				}
-				if calls[0].az != "us-west-2a" {
-					t.Errorf("expected az us-west-2a, got %s", calls[0].az)
+				if calls[0].AZ != "us-west-2a" {
+					t.Errorf("expected az us-west-2a, got %s", calls[0].AZ)
 				}
 			},
 		},
 		{
 			name: "name flag with shell metacharacters rejected",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote:         &projectMockRemote{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"project", "add", "--name", "foo;rm -rf /", "https://github.com/org/repo.git"},
@@ -760,13 +740,13 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "name flag with backtick injection rejected",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote:         &projectMockRemote{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"project", "add", "--name", "foo`whoami`", "https://github.com/org/repo.git"},
@@ -776,11 +756,11 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForProject{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"project", "add", "https://github.com/org/repo.git"},
@@ -789,62 +769,62 @@ func TestProjectAddCommand(t *testing.T) {
 		},
 		{
 			name: "remote commands use correct SSH params",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote:             &projectMockRemote{outputs: [][]byte{nil, nil}, errors: []error{fmt.Errorf("exit status 1"), nil}},
+			remote:             &cmdtest.RemoteRunner{Outputs: [][]byte{nil, nil}, Errs: []error{fmt.Errorf("exit status 1"), nil}},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				for i, call := range calls {
-					if call.instanceID != "i-abc123" {
-						t.Errorf("call %d: instanceID = %q, want i-abc123", i, call.instanceID)
+					if call.InstanceID != "i-abc123" {
+						t.Errorf("call %d: instanceID = %q, want i-abc123", i, call.InstanceID)
 					}
-					if call.host != "1.2.3.4" {
-						t.Errorf("call %d: host = %q, want 1.2.3.4", i, call.host)
+					if call.Host != "1.2.3.4" {
+						t.Errorf("call %d: host = %q, want 1.2.3.4", i, call.Host)
 					}
-					if call.port != 41122 {
-						t.Errorf("call %d: port = %d, want 41122", i, call.port)
+					if call.Port != 41122 {
+						t.Errorf("call %d: port = %d, want 41122", i, call.Port)
 					}
-					if call.user != "ubuntu" {
-						t.Errorf("call %d: user = %q, want ubuntu", i, call.user)
+					if call.User != "ubuntu" {
+						t.Errorf("call %d: user = %q, want ubuntu", i, call.User)
 					}
-					if call.az != "us-east-1a" {
-						t.Errorf("call %d: az = %q, want us-east-1a", i, call.az)
+					if call.AZ != "us-east-1a" {
+						t.Errorf("call %d: az = %q, want us-east-1a", i, call.AZ)
 					}
 				}
 			},
 		},
 		{
 			name: "no tmux commands issued during project add",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{fmt.Errorf("exit status 1"), nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{fmt.Errorf("exit status 1"), nil},
 			},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil, nil}, errors: []error{nil, nil}},
 			owner:              "alice",
 			args:               []string{"project", "add", "https://github.com/org/repo.git"},
-			wantCalls:          2,
+			wantCalls:          3,
 			wantStreamingCalls: 2,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				for i, call := range calls {
-					cmdStr := strings.Join(call.command, " ")
+					cmdStr := strings.Join(call.Command, " ")
 					if strings.Contains(cmdStr, "tmux") {
 						t.Errorf("call %d: should not contain tmux commands, got: %s", i, cmdStr)
 					}
@@ -870,12 +850,12 @@ func TestProjectAddCommand(t *testing.T) {
 				describe:        tt.describe,
 				sendKey:         tt.sendKey,
 				owner:           tt.owner,
-				remote:          tt.remote.run,
+				remote:          tt.remote.Run,
 				streamingRunner: tt.streaming.run,
 			}
 
 			projectCmd := newProjectCommandWithDeps(deps)
-			root := newTestRootForProject()
+			root := cmdtest.NewRoot()
 			root.AddCommand(projectCmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -890,8 +870,8 @@ func TestProjectAddCommand(t *testing.T) {
 				if tt.wantErrContain != "" && !strings.Contains(err.Error(), tt.wantErrContain) {
 					t.Errorf("error %q does not contain %q", err.Error(), tt.wantErrContain)
 				}
-				if tt.wantCalls > 0 && len(tt.remote.calls) != tt.wantCalls {
-					t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.calls))
+				if tt.wantCalls > 0 && len(tt.remote.Calls()) != tt.wantCalls {
+					t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.Calls()))
 				}
 				if tt.wantStreamingCalls > 0 && len(tt.streaming.calls) != tt.wantStreamingCalls {
 					t.Errorf("expected %d streaming calls, got %d", tt.wantStreamingCalls, len(tt.streaming.calls))
@@ -903,8 +883,8 @@ func TestProjectAddCommand(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if tt.wantCalls > 0 && len(tt.remote.calls) != tt.wantCalls {
-				t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.calls))
+			if tt.wantCalls > 0 && len(tt.remote.Calls()) != tt.wantCalls {
+				t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.Calls()))
 			}
 
 			if tt.wantStreamingCalls > 0 && len(tt.streaming.calls) != tt.wantStreamingCalls {
@@ -912,7 +892,7 @@ func TestProjectAddCommand(t *testing.T) {
 			}
 
 			if tt.checkCalls != nil {
-				tt.checkCalls(t, tt.remote.calls)
+				tt.checkCalls(t, tt.remote.Calls())
 			}
 
 			if tt.checkStreamingCalls != nil {
@@ -1037,9 +1017,9 @@ func TestProjectListCommand(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForProject
-		sendKey        *mockSendKeyForProject
-		remote         *projectMockRemote
+		describe       *cmdtest.DescribeInstances
+		sendKey        *cmdtest.SendSSHPublicKey
+		remote         *cmdtest.RemoteRunner
 		owner          string
 		vmName         string
 		jsonOutput     bool
@@ -1050,36 +1030,36 @@ func TestProjectListCommand(t *testing.T) {
 	}{
 		{
 			name: "lists projects with running containers",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte("myproject\nsidecar\n"),
 					[]byte("myproject_devcontainer-app-1\tUp 2 hours\tmcr.microsoft.com/devcontainers/go:1.21\t/mint/projects/myproject\n"),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			wantOutput: []string{"myproject", "running", "mcr.microsoft.com/devcontainers/go:1.21", "sidecar", "none"},
 		},
 		{
 			name: "json output returns array",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte("myproject\n"),
 					[]byte("myproject_devcontainer-app-1\tUp 2 hours\tmcr.microsoft.com/devcontainers/go:1.21\t/mint/projects/myproject\n"),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			jsonOutput: true,
@@ -1087,36 +1067,36 @@ func TestProjectListCommand(t *testing.T) {
 		},
 		{
 			name: "no projects directory shows message",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte(""),
 					[]byte(""),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			wantOutput: []string{"No projects yet — run `mint project add <git-url>` to clone one."},
 		},
 		{
 			name: "no projects json returns empty array",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte(""),
 					[]byte(""),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			jsonOutput: true,
@@ -1124,65 +1104,65 @@ func TestProjectListCommand(t *testing.T) {
 		},
 		{
 			name: "projects with no containers show none status",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte("experiments\n"),
 					[]byte(""),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			wantOutput: []string{"experiments", "none"},
 		},
 		{
 			name: "VM not found returns error",
-			describe: &mockDescribeForProject{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "mint up",
 		},
 		{
 			name: "stopped VM returns error",
-			describe: &mockDescribeForProject{
-				output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "not running",
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForProject{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "throttled",
 		},
 		{
 			name: "ls command error propagates",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				errors: []error{fmt.Errorf("connection refused")},
+			remote: &cmdtest.RemoteRunner{
+				Errs: []error{fmt.Errorf("connection refused")},
 			},
 			owner:          "alice",
 			wantErr:        true,
@@ -1190,18 +1170,18 @@ func TestProjectListCommand(t *testing.T) {
 		},
 		{
 			name: "non-default VM name",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte("myapp\n"),
 					[]byte(""),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			vmName:     "dev",
@@ -1209,18 +1189,18 @@ func TestProjectListCommand(t *testing.T) {
 		},
 		{
 			name: "multiple projects mixed container states",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{
 					[]byte("myproject\nsidecar\nexperiments\n"),
 					[]byte("myproject_devcontainer-app-1\tUp 2 hours\tmcr.microsoft.com/devcontainers/go:1.21\t/mint/projects/myproject\nsidecar_devcontainer-app-1\tExited (0) 5 minutes ago\tnode:18\t/mint/projects/sidecar\n"),
 				},
-				errors: []error{nil, nil},
+				Errs: []error{nil, nil},
 			},
 			owner:      "alice",
 			wantOutput: []string{"myproject", "running", "sidecar", "exited", "experiments", "none"},
@@ -1235,11 +1215,11 @@ func TestProjectListCommand(t *testing.T) {
 				describe: tt.describe,
 				sendKey:  tt.sendKey,
 				owner:    tt.owner,
-				remote:   tt.remote.run,
+				remote:   tt.remote.Run,
 			}
 
 			projectCmd := newProjectCommandWithListDeps(listDeps)
-			root := newTestRootForProject()
+			root := cmdtest.NewRoot()
 			root.AddCommand(projectCmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -1293,13 +1273,145 @@ func TestProjectListCommand(t *testing.T) {
 	}
 }
 
+// runProjectListWithCache runs project list against a cache directory and
+// returns the combined output.
+func runProjectListWithCache(t *testing.T, describe *cmdtest.DescribeInstances, remote *cmdtest.RemoteRunner, cacheDir string, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	listDeps := &projectListDeps{
+		describe: describe,
+		sendKey:  &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:    "alice",
+		remote:   remote.Run,
+		cacheDir: cacheDir,
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithListDeps(listDeps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"project", "list"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestProjectListWritesCacheOnLiveList(t *testing.T) {
+	dir := t.TempDir()
+	_, err := runProjectListWithCache(t,
+		&cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		&cmdtest.RemoteRunner{
+			Outputs: [][]byte{
+				[]byte("myproject\n"),
+				[]byte("myproject_devcontainer-app-1\tUp 2 hours\tgo:1.21\t/mint/projects/myproject\n"),
+			},
+			Errs: []error{nil, nil},
+		}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache, err := readProjectListCache(dir, "default")
+	if err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	if len(cache.Projects) != 1 || cache.Projects[0].Name != "myproject" || cache.Projects[0].ContainerStatus != "running" {
+		t.Errorf("cached projects = %+v", cache.Projects)
+	}
+}
+
+func TestProjectListStoppedVM(t *testing.T) {
+	hint.IsTTY = false
+
+	stopped := func() *cmdtest.DescribeInstances {
+		return &cmdtest.DescribeInstances{Output: makeStoppedInstanceForProject("i-abc123", "default", "alice")}
+	}
+	writeCache := func(t *testing.T, dir string) {
+		t.Helper()
+		projects := []projectInfo{{Name: "myproject", ContainerStatus: "running", Image: "go:1.21"}}
+		if err := writeProjectListCache(dir, "default", projects, time.Now().Add(-3*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("human output is labelled as cached", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCache(t, dir)
+		remote := &cmdtest.RemoteRunner{}
+
+		out, err := runProjectListWithCache(t, stopped(), remote, dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(remote.Calls()) != 0 {
+			t.Errorf("remote called %d times for a stopped VM", len(remote.Calls()))
+		}
+		for _, want := range []string{
+			"showing the cached project list from 3h ago",
+			"`mint up`",
+			"myproject",
+			"unknown (VM stopped)",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "go:1.21") || strings.Contains(out, "running") {
+			t.Errorf("cached output should not show container details:\n%s", out)
+		}
+	})
+
+	t.Run("JSON rows carry cached_at", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCache(t, dir)
+
+		out, err := runProjectListWithCache(t, stopped(), &cmdtest.RemoteRunner{}, dir, "--json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var rows []map[string]any
+		if err := json.Unmarshal([]byte(out), &rows); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, out)
+		}
+		if len(rows) != 1 || rows[0]["container_status"] != "unknown (VM stopped)" || rows[0]["cached_at"] == nil {
+			t.Errorf("rows = %v", rows)
+		}
+	})
+
+	t.Run("require-live keeps the error", func(t *testing.T) {
+		dir := t.TempDir()
+		writeCache(t, dir)
+
+		_, err := runProjectListWithCache(t, stopped(), &cmdtest.RemoteRunner{}, dir, "--require-live")
+		if err == nil || !strings.Contains(err.Error(), "not running") {
+			t.Errorf("error = %v, want not running", err)
+		}
+	})
+
+	t.Run("no cache falls back to the error", func(t *testing.T) {
+		_, err := runProjectListWithCache(t, stopped(), &cmdtest.RemoteRunner{}, t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), "not running") {
+			t.Errorf("error = %v, want not running", err)
+		}
+	})
+
+	t.Run("corrupt cache falls back to the error", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(projectListCachePath(dir, "default"), []byte("{garbage"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := runProjectListWithCache(t, stopped(), &cmdtest.RemoteRunner{}, dir)
+		if err == nil || !strings.Contains(err.Error(), "not running") {
+			t.Errorf("error = %v, want not running", err)
+		}
+	})
+}
+
 func TestParseProjectsAndContainers(t *testing.T) {
 	tests := []struct {
-		name            string
-		lsOutput        string
-		dockerOutput    string
-		expectedCount   int
-		check           func(t *testing.T, projects []projectInfo)
+		name          string
+		lsOutput      string
+		dockerOutput  string
+		expectedCount int
+		check         func(t *testing.T, projects []projectInfo)
 	}{
 		{
 			name:          "projects with matching containers",
@@ -1385,8 +1497,8 @@ func TestParseProjectsAndContainers(t *testing.T) {
 func TestProjectAddRequiresGitURL(t *testing.T) {
 	// Verify the command requires exactly 1 argument.
 	deps := &projectAddDeps{
-		describe: &mockDescribeForProject{output: &ec2.DescribeInstancesOutput{}},
-		sendKey:  &mockSendKeyForProject{},
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
 		owner:    "alice",
 		remote: func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
 			return nil, nil
@@ -1394,7 +1506,7 @@ func TestProjectAddRequiresGitURL(t *testing.T) {
 	}
 
 	projectCmd := newProjectCommandWithDeps(deps)
-	root := newTestRootForProject()
+	root := cmdtest.NewRoot()
 	root.AddCommand(projectCmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -1417,27 +1529,27 @@ func TestProjectAddTOFUKeyscanTriggered(t *testing.T) {
 		return "SHA256:projectfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil
 	}
 
-	remote := &projectMockRemote{
+	remote := &cmdtest.RemoteRunner{
 		// remote: test -d (dir doesn't exist), devcontainer config check (has config)
-		outputs: [][]byte{nil, nil},
-		errors:  []error{fmt.Errorf("exit status 1"), nil},
+		Outputs: [][]byte{nil, nil},
+		Errs:    []error{fmt.Errorf("exit status 1"), nil},
 	}
 	streaming := &projectMockStreamingRemote{
 		outputs: [][]byte{nil, nil},
 		errors:  []error{nil, nil},
 	}
 	deps := &projectAddDeps{
-		describe:        &mockDescribeForProject{output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:         &mockSendKeyForProject{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:        &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:           "alice",
-		remote:          remote.run,
+		remote:          remote.Run,
 		streamingRunner: streaming.run,
 		hostKeyStore:    sshconfig.NewHostKeyStore(t.TempDir()),
 		hostKeyScanner:  scanner,
 	}
 
 	projectCmd := newProjectCommandWithDeps(deps)
-	root := newTestRootForProject()
+	root := cmdtest.NewRoot()
 	root.AddCommand(projectCmd)
 
 	buf := new(bytes.Buffer)
@@ -1450,9 +1562,9 @@ func TestProjectAddTOFUKeyscanTriggered(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// 2 remote calls (test -d, devcontainer config check) + 2 streaming (clone, devcontainer up), keyscan once.
-	if len(remote.calls) != 2 {
-		t.Fatalf("expected 2 remote calls, got %d", len(remote.calls))
+	// 3 remote calls (test -d, devcontainer config check, identity check) + 2 streaming (clone, devcontainer up), keyscan once.
+	if len(remote.Calls()) != 3 {
+		t.Fatalf("expected 3 remote calls, got %d", len(remote.Calls()))
 	}
 	if len(streaming.calls) != 2 {
 		t.Fatalf("expected 2 streaming calls, got %d", len(streaming.calls))
@@ -1473,18 +1585,18 @@ func TestProjectAddTOFUHostKeyMismatch(t *testing.T) {
 		return "SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil
 	}
 
-	remote := &projectMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &projectAddDeps{
-		describe:       &mockDescribeForProject{output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendKeyForProject{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remote:         remote.run,
+		remote:         remote.Run,
 		hostKeyStore:   store,
 		hostKeyScanner: scanner,
 	}
 
 	projectCmd := newProjectCommandWithDeps(deps)
-	root := newTestRootForProject()
+	root := cmdtest.NewRoot()
 	root.AddCommand(projectCmd)
 
 	buf := new(bytes.Buffer)
@@ -1499,8 +1611,8 @@ func TestProjectAddTOFUHostKeyMismatch(t *testing.T) {
 	if !strings.Contains(err.Error(), "HOST KEY CHANGED") {
 		t.Errorf("error should mention HOST KEY CHANGED, got: %s", err.Error())
 	}
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.Calls()))
 	}
 }
 
@@ -1513,20 +1625,20 @@ func TestProjectRebuildTOFUKeyscanTriggered(t *testing.T) {
 		return "SHA256:rebuildfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil
 	}
 
-	remote := &projectMockRemote{
+	remote := &cmdtest.RemoteRunner{
 		// remote: test -d, stop, rm, docker ps, tmux kill, tmux new
-		outputs: [][]byte{nil, nil, nil, []byte("newctr\n"), nil, nil},
-		errors:  []error{nil, nil, nil, nil, nil, nil},
+		Outputs: [][]byte{nil, nil, nil, []byte("newctr\n"), nil, nil},
+		Errs:    []error{nil, nil, nil, nil, nil, nil},
 	}
 	streaming := &projectMockStreamingRemote{
 		outputs: [][]byte{nil},
 		errors:  []error{nil},
 	}
 	deps := &projectRebuildDeps{
-		describe:        &mockDescribeForProject{output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:         &mockSendKeyForProject{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:        &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:           "alice",
-		remote:          remote.run,
+		remote:          remote.Run,
 		streamingRunner: streaming.run,
 		stdin:           strings.NewReader(""),
 		hostKeyStore:    sshconfig.NewHostKeyStore(t.TempDir()),
@@ -1534,7 +1646,7 @@ func TestProjectRebuildTOFUKeyscanTriggered(t *testing.T) {
 	}
 
 	projectCmd := newProjectCommandWithRebuildDeps(deps)
-	root := newTestRootForProject()
+	root := cmdtest.NewRoot()
 	root.AddCommand(projectCmd)
 
 	buf := new(bytes.Buffer)
@@ -1548,8 +1660,8 @@ func TestProjectRebuildTOFUKeyscanTriggered(t *testing.T) {
 	}
 
 	// 6 remote calls (test -d, stop, rm, docker ps, tmux kill, tmux new) + 1 streaming (devcontainer up), keyscan once.
-	if len(remote.calls) != 6 {
-		t.Fatalf("expected 6 remote calls, got %d", len(remote.calls))
+	if len(remote.Calls()) != 6 {
+		t.Fatalf("expected 6 remote calls, got %d", len(remote.Calls()))
 	}
 	if len(streaming.calls) != 1 {
 		t.Fatalf("expected 1 streaming call, got %d", len(streaming.calls))
@@ -1569,19 +1681,19 @@ func TestProjectRebuildTOFUHostKeyMismatch(t *testing.T) {
 		return "SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil
 	}
 
-	remote := &projectMockRemote{}
+	remote := &cmdtest.RemoteRunner{}
 	deps := &projectRebuildDeps{
-		describe:       &mockDescribeForProject{output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
-		sendKey:        &mockSendKeyForProject{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:          "alice",
-		remote:         remote.run,
+		remote:         remote.Run,
 		stdin:          strings.NewReader(""),
 		hostKeyStore:   store,
 		hostKeyScanner: scanner,
 	}
 
 	projectCmd := newProjectCommandWithRebuildDeps(deps)
-	root := newTestRootForProject()
+	root := cmdtest.NewRoot()
 	root.AddCommand(projectCmd)
 
 	buf := new(bytes.Buffer)
@@ -1596,8 +1708,8 @@ func TestProjectRebuildTOFUHostKeyMismatch(t *testing.T) {
 	if !strings.Contains(err.Error(), "HOST KEY CHANGED") {
 		t.Errorf("error should mention HOST KEY CHANGED, got: %s", err.Error())
 	}
-	if len(remote.calls) != 0 {
-		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.calls))
+	if len(remote.Calls()) != 0 {
+		t.Errorf("remote runner should not be called on host key mismatch, got %d calls", len(remote.Calls()))
 	}
 }
 
@@ -1606,9 +1718,9 @@ func TestProjectRebuildCommand(t *testing.T) {
 
 	tests := []struct {
 		name               string
-		describe           *mockDescribeForProject
-		sendKey            *mockSendKeyForProject
-		remote             *projectMockRemote
+		describe           *cmdtest.DescribeInstances
+		sendKey            *cmdtest.SendSSHPublicKey
+		remote             *cmdtest.RemoteRunner
 		streaming          *projectMockStreamingRemote
 		owner              string
 		args               []string
@@ -1617,21 +1729,21 @@ func TestProjectRebuildCommand(t *testing.T) {
 		wantErrContain     string
 		wantCalls          int
 		wantStreamingCalls int
-		checkCalls         func(t *testing.T, calls []projectRemoteCall)
+		checkCalls         func(t *testing.T, calls []cmdtest.RemoteCall)
 		checkOutput        func(t *testing.T, output string)
 	}{
 		{
 			name: "successful rebuild with yes flag",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			// remote: test -d, stop, rm, docker ps, tmux kill, tmux new
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil},
-				errors:  []error{nil, nil, nil, nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil},
+				Errs:    []error{nil, nil, nil, nil, nil, nil},
 			},
 			// streaming: devcontainer up
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}},
@@ -1639,15 +1751,15 @@ func TestProjectRebuildCommand(t *testing.T) {
 			args:               []string{"--yes", "project", "rebuild", "myproject"},
 			wantCalls:          6,
 			wantStreamingCalls: 1,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				// Call 0: test -d /mint/projects/myproject
-				testCmd := strings.Join(calls[0].command, " ")
+				testCmd := strings.Join(calls[0].Command, " ")
 				if !strings.Contains(testCmd, "test -d /mint/projects/myproject") {
 					t.Errorf("first call should verify project exists, got: %s", testCmd)
 				}
 				// Call 1: docker stop
-				stopCmd := strings.Join(calls[1].command, " ")
+				stopCmd := strings.Join(calls[1].Command, " ")
 				if !strings.Contains(stopCmd, "docker stop") {
 					t.Errorf("second call should stop container, got: %s", stopCmd)
 				}
@@ -1655,12 +1767,12 @@ func TestProjectRebuildCommand(t *testing.T) {
 					t.Errorf("stop should filter by project path, got: %s", stopCmd)
 				}
 				// Call 2: docker rm
-				rmCmd := strings.Join(calls[2].command, " ")
+				rmCmd := strings.Join(calls[2].Command, " ")
 				if !strings.Contains(rmCmd, "docker rm") {
 					t.Errorf("third call should remove container, got: %s", rmCmd)
 				}
 				// Call 3: docker ps to discover new container
-				dockerCmd := strings.Join(calls[3].command, " ")
+				dockerCmd := strings.Join(calls[3].Command, " ")
 				if !strings.Contains(dockerCmd, "docker ps -q") {
 					t.Errorf("fourth call should be docker ps, got: %s", dockerCmd)
 				}
@@ -1668,7 +1780,7 @@ func TestProjectRebuildCommand(t *testing.T) {
 					t.Errorf("docker ps should filter by project path, got: %s", dockerCmd)
 				}
 				// Call 4: tmux kill-session
-				killCmd := strings.Join(calls[4].command, " ")
+				killCmd := strings.Join(calls[4].Command, " ")
 				if !strings.Contains(killCmd, "tmux kill-session") {
 					t.Errorf("fifth call should kill tmux session, got: %s", killCmd)
 				}
@@ -1676,7 +1788,7 @@ func TestProjectRebuildCommand(t *testing.T) {
 					t.Errorf("kill-session should target project name, got: %s", killCmd)
 				}
 				// Call 5: tmux new-session with docker exec
-				tmuxCmd := strings.Join(calls[5].command, " ")
+				tmuxCmd := strings.Join(calls[5].Command, " ")
 				if !strings.Contains(tmuxCmd, "tmux new-session") {
 					t.Errorf("sixth call should be tmux new-session, got: %s", tmuxCmd)
 				}
@@ -1708,15 +1820,15 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "successful rebuild with confirmation prompt",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, nil, []byte("ctr123\n"), nil, nil},
-				errors:  []error{nil, nil, nil, nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, nil, []byte("ctr123\n"), nil, nil},
+				Errs:    []error{nil, nil, nil, nil, nil, nil},
 			},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}},
 			owner:              "alice",
@@ -1736,15 +1848,15 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "confirmation mismatch aborts rebuild",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil},
-				errors:  []error{nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil},
+				Errs:    []error{nil},
 			},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
@@ -1756,15 +1868,15 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "empty confirmation aborts rebuild",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil},
-				errors:  []error{nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil},
+				Errs:    []error{nil},
 			},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
@@ -1776,14 +1888,14 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "project not found returns error",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				errors: []error{fmt.Errorf("exit status 1")},
+			remote: &cmdtest.RemoteRunner{
+				Errs: []error{fmt.Errorf("exit status 1")},
 			},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
@@ -1794,13 +1906,13 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "rebuild with shell metacharacters rejected",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote:         &projectMockRemote{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"--yes", "project", "rebuild", "foo$(whoami)"},
@@ -1810,13 +1922,13 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "rebuild with pipe injection rejected",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote:         &projectMockRemote{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"--yes", "project", "rebuild", "foo|cat /etc/passwd"},
@@ -1826,11 +1938,11 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "VM not found returns error",
-			describe: &mockDescribeForProject{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"--yes", "project", "rebuild", "myproject"},
@@ -1839,11 +1951,11 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "stopped VM returns error",
-			describe: &mockDescribeForProject{
-				output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForProject("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendKeyForProject{},
-			remote:         &projectMockRemote{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
+			remote:         &cmdtest.RemoteRunner{},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"--yes", "project", "rebuild", "myproject"},
@@ -1852,15 +1964,15 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "devcontainer rebuild failure returns error",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, nil},
-				errors:  []error{nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, nil},
+				Errs:    []error{nil, nil, nil},
 			},
 			streaming: &projectMockStreamingRemote{
 				errors: []error{fmt.Errorf("Dockerfile syntax error")},
@@ -1874,11 +1986,11 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "missing project name argument",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey:   &mockSendKeyForProject{},
-			remote:    &projectMockRemote{},
+			sendKey:   &cmdtest.SendSSHPublicKey{},
+			remote:    &cmdtest.RemoteRunner{},
 			streaming: &projectMockStreamingRemote{},
 			owner:     "alice",
 			args:      []string{"project", "rebuild"},
@@ -1886,53 +1998,53 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "remote commands use correct SSH params",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil, nil, []byte("ctr1\n"), nil, nil},
-				errors:  []error{nil, nil, nil, nil, nil, nil},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil, nil, []byte("ctr1\n"), nil, nil},
+				Errs:    []error{nil, nil, nil, nil, nil, nil},
 			},
 			streaming:          &projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}},
 			owner:              "alice",
 			args:               []string{"--yes", "project", "rebuild", "myproject"},
 			wantCalls:          6,
 			wantStreamingCalls: 1,
-			checkCalls: func(t *testing.T, calls []projectRemoteCall) {
+			checkCalls: func(t *testing.T, calls []cmdtest.RemoteCall) {
 				t.Helper()
 				for i, call := range calls {
-					if call.instanceID != "i-abc123" {
-						t.Errorf("call %d: instanceID = %q, want i-abc123", i, call.instanceID)
+					if call.InstanceID != "i-abc123" {
+						t.Errorf("call %d: instanceID = %q, want i-abc123", i, call.InstanceID)
 					}
-					if call.host != "1.2.3.4" {
-						t.Errorf("call %d: host = %q, want 1.2.3.4", i, call.host)
+					if call.Host != "1.2.3.4" {
+						t.Errorf("call %d: host = %q, want 1.2.3.4", i, call.Host)
 					}
-					if call.port != 41122 {
-						t.Errorf("call %d: port = %d, want 41122", i, call.port)
+					if call.Port != 41122 {
+						t.Errorf("call %d: port = %d, want 41122", i, call.Port)
 					}
-					if call.user != "ubuntu" {
-						t.Errorf("call %d: user = %q, want ubuntu", i, call.user)
+					if call.User != "ubuntu" {
+						t.Errorf("call %d: user = %q, want ubuntu", i, call.User)
 					}
-					if call.az != "us-east-1a" {
-						t.Errorf("call %d: az = %q, want us-east-1a", i, call.az)
+					if call.AZ != "us-east-1a" {
+						t.Errorf("call %d: az = %q, want us-east-1a", i, call.AZ)
 					}
 				}
 			},
 		},
 		{
 			name: "stop container failure propagates",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil},
-				errors:  []error{nil, fmt.Errorf("connection reset")},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil},
+				Errs:    []error{nil, fmt.Errorf("connection reset")},
 			},
 			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
@@ -1943,17 +2055,17 @@ func TestProjectRebuildCommand(t *testing.T) {
 		},
 		{
 			name: "remove container failure propagates",
-			describe: &mockDescribeForProject{
-				output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendKeyForProject{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
-			remote: &projectMockRemote{
-				outputs: [][]byte{nil, nil},
-				errors:  []error{nil, nil, fmt.Errorf("permission denied")},
+			remote: &cmdtest.RemoteRunner{
+				Outputs: [][]byte{nil, nil},
+				Errs:    []error{nil, nil, fmt.Errorf("permission denied")},
 			},
-			streaming: &projectMockStreamingRemote{},
+			streaming:      &projectMockStreamingRemote{},
 			owner:          "alice",
 			args:           []string{"--yes", "project", "rebuild", "myproject"},
 			wantErr:        true,
@@ -1970,13 +2082,13 @@ func TestProjectRebuildCommand(t *testing.T) {
 				describe:        tt.describe,
 				sendKey:         tt.sendKey,
 				owner:           tt.owner,
-				remote:          tt.remote.run,
+				remote:          tt.remote.Run,
 				streamingRunner: tt.streaming.run,
 				stdin:           strings.NewReader(tt.stdinInput),
 			}
 
 			projectCmd := newProjectCommandWithRebuildDeps(deps)
-			root := newTestRootForProject()
+			root := cmdtest.NewRoot()
 			root.AddCommand(projectCmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -1991,8 +2103,8 @@ func TestProjectRebuildCommand(t *testing.T) {
 				if tt.wantErrContain != "" && !strings.Contains(err.Error(), tt.wantErrContain) {
 					t.Errorf("error %q does not contain %q", err.Error(), tt.wantErrContain)
 				}
-				if tt.wantCalls > 0 && len(tt.remote.calls) != tt.wantCalls {
-					t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.calls))
+				if tt.wantCalls > 0 && len(tt.remote.Calls()) != tt.wantCalls {
+					t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.Calls()))
 				}
 				if tt.wantStreamingCalls > 0 && len(tt.streaming.calls) != tt.wantStreamingCalls {
 					t.Errorf("expected %d streaming calls, got %d", tt.wantStreamingCalls, len(tt.streaming.calls))
@@ -2004,8 +2116,8 @@ func TestProjectRebuildCommand(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if tt.wantCalls > 0 && len(tt.remote.calls) != tt.wantCalls {
-				t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.calls))
+			if tt.wantCalls > 0 && len(tt.remote.Calls()) != tt.wantCalls {
+				t.Errorf("expected %d remote calls, got %d", tt.wantCalls, len(tt.remote.Calls()))
 			}
 
 			if tt.wantStreamingCalls > 0 && len(tt.streaming.calls) != tt.wantStreamingCalls {
@@ -2013,7 +2125,7 @@ func TestProjectRebuildCommand(t *testing.T) {
 			}
 
 			if tt.checkCalls != nil {
-				tt.checkCalls(t, tt.remote.calls)
+				tt.checkCalls(t, tt.remote.Calls())
 			}
 
 			if tt.checkOutput != nil {
@@ -2022,3 +2134,453 @@ func TestProjectRebuildCommand(t *testing.T) {
 		})
 	}
 }
+
+// runProjectAddSubdir runs project add with the given args against mocks and
+// returns the combined output.
+func runProjectAddSubdir(t *testing.T, remote *cmdtest.RemoteRunner, streaming *projectMockStreamingRemote, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	deps := &projectAddDeps{
+		describe:        &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:         &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: streaming.run,
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"project", "add"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestProjectAddSubdir(t *testing.T) {
+	hint.IsTTY = false
+	gitEnvPrefix := "env GIT_TERMINAL_PROMPT=0 GIT_CONFIG_NOSYSTEM=1 GIT_CONFIG_GLOBAL=/dev/null "
+
+	tests := []struct {
+		name          string
+		args          []string
+		remoteErrs    []error
+		wantRemote    []string
+		wantStreaming []string
+		wantOutput    []string
+	}{
+		{
+			name: "devcontainer in subdirectory",
+			args: []string{"https://github.com/org/platform.git", "--subdir", "services/payments"},
+			// test -d (missing), record subdir, subdir devcontainer check (found)
+			remoteErrs: []error{fmt.Errorf("exit status 1"), nil, nil},
+			wantRemote: []string{
+				"test -d /mint/projects/payments",
+				"git -C /mint/projects/payments config mint.subdir 'services/payments'",
+				"test -f '/mint/projects/payments/services/payments/.devcontainer/devcontainer.json' -o -f '/mint/projects/payments/services/payments/.devcontainer.json'",
+				"git -C /mint/projects/payments config --show-origin user.email",
+			},
+			wantStreaming: []string{
+				gitEnvPrefix + "git clone --filter=blob:none --sparse https://github.com/org/platform.git /mint/projects/payments",
+				gitEnvPrefix + "git -C /mint/projects/payments sparse-checkout set 'services/payments' .devcontainer",
+				"devcontainer up --workspace-folder '/mint/projects/payments/services/payments'",
+			},
+			wantOutput: []string{"Checking out services/payments", `Project "payments" ready`},
+		},
+		{
+			name: "falls back to the root devcontainer",
+			args: []string{"https://github.com/org/platform.git", "--subdir", "services/payments", "--name", "pay", "--branch", "main"},
+			// test -d (missing), record subdir, subdir check (none), root check (found)
+			remoteErrs: []error{fmt.Errorf("exit status 1"), nil, fmt.Errorf("exit status 1"), nil},
+			wantRemote: []string{
+				"test -d /mint/projects/pay",
+				"git -C /mint/projects/pay config mint.subdir 'services/payments'",
+				"test -f '/mint/projects/pay/services/payments/.devcontainer/devcontainer.json' -o -f '/mint/projects/pay/services/payments/.devcontainer.json'",
+				"test -f '/mint/projects/pay/.devcontainer/devcontainer.json' -o -f '/mint/projects/pay/.devcontainer.json'",
+				"git -C /mint/projects/pay config --show-origin user.email",
+			},
+			wantStreaming: []string{
+				gitEnvPrefix + "git clone --filter=blob:none --sparse --branch main https://github.com/org/platform.git /mint/projects/pay",
+				gitEnvPrefix + "git -C /mint/projects/pay sparse-checkout set 'services/payments' .devcontainer",
+				"devcontainer up --workspace-folder /mint/projects/pay",
+			},
+			wantOutput: []string{"No devcontainer config in services/payments, using the repository root's devcontainer."},
+		},
+		{
+			name:       "no devcontainer anywhere",
+			args:       []string{"https://github.com/org/platform.git", "--subdir", "tools/cli"},
+			remoteErrs: []error{fmt.Errorf("exit status 1"), nil, fmt.Errorf("exit status 1"), fmt.Errorf("exit status 1")},
+			wantStreaming: []string{
+				gitEnvPrefix + "git clone --filter=blob:none --sparse https://github.com/org/platform.git /mint/projects/cli",
+				gitEnvPrefix + "git -C /mint/projects/cli sparse-checkout set 'tools/cli' .devcontainer",
+			},
+			wantOutput: []string{"No devcontainer config found", `Project "cli" ready at /mint/projects/cli`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{Errs: tt.remoteErrs}
+			streaming := &projectMockStreamingRemote{}
+			output, err := runProjectAddSubdir(t, remote, streaming, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, output)
+			}
+
+			if tt.wantRemote != nil {
+				var got []string
+				for _, c := range remote.Calls() {
+					got = append(got, strings.Join(c.Command, " "))
+				}
+				if strings.Join(got, "\n") != strings.Join(tt.wantRemote, "\n") {
+					t.Errorf("remote commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantRemote, "\n"))
+				}
+			}
+			var got []string
+			for _, c := range streaming.calls {
+				got = append(got, strings.Join(c.command, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantStreaming, "\n") {
+				t.Errorf("streaming commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantStreaming, "\n"))
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q, got:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectAddSubdirRejectsInvalidPaths(t *testing.T) {
+	for _, subdir := range []string{"/services/payments", "../payments", "services/../../etc", "services//payments", "services/payments/", "./services", ".", "-x", "services/pay\nments"} {
+		t.Run(subdir, func(t *testing.T) {
+			remote := &cmdtest.RemoteRunner{}
+			_, err := runProjectAddSubdir(t, remote, &projectMockStreamingRemote{}, "https://github.com/org/platform.git", "--subdir", subdir)
+			if err == nil || !strings.Contains(err.Error(), "invalid --subdir") {
+				t.Fatalf("error = %v, want invalid --subdir", err)
+			}
+			if len(remote.Calls()) != 0 {
+				t.Errorf("expected no remote calls, got %d", len(remote.Calls()))
+			}
+		})
+	}
+}
+
+func TestValidateSubdirAccepts(t *testing.T) {
+	for _, subdir := range []string{"services/payments", "payments", "apps/web app", "a.b/c-d_e"} {
+		if err := validateSubdir(subdir); err != nil {
+			t.Errorf("validateSubdir(%q): %v", subdir, err)
+		}
+	}
+}
+
+func TestSelectSubdirWorkspace(t *testing.T) {
+	tests := []struct {
+		name          string
+		configIn      map[string]bool
+		wantWorkspace string
+		wantHas       bool
+		wantRoot      bool
+	}{
+		{
+			name:          "subdir config wins",
+			configIn:      map[string]bool{"/mint/projects/p/svc": true, "/mint/projects/p": true},
+			wantWorkspace: "/mint/projects/p/svc",
+			wantHas:       true,
+		},
+		{
+			name:          "root fallback",
+			configIn:      map[string]bool{"/mint/projects/p": true},
+			wantWorkspace: "/mint/projects/p",
+			wantHas:       true,
+			wantRoot:      true,
+		},
+		{
+			name:          "no config",
+			configIn:      map[string]bool{},
+			wantWorkspace: "/mint/projects/p/svc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, has, root := selectSubdirWorkspace(func(dir string) bool { return tt.configIn[dir] }, "/mint/projects/p", "svc")
+			if ws != tt.wantWorkspace || has != tt.wantHas || root != tt.wantRoot {
+				t.Errorf("selectSubdirWorkspace = (%q, %v, %v), want (%q, %v, %v)", ws, has, root, tt.wantWorkspace, tt.wantHas, tt.wantRoot)
+			}
+		})
+	}
+}
+
+func TestProjectListShowsSubdir(t *testing.T) {
+	hint.IsTTY = false
+	remote := func() *cmdtest.RemoteRunner {
+		return &cmdtest.RemoteRunner{Outputs: [][]byte{
+			[]byte("payments\nsidecar\n"),
+			[]byte("payments-app-1\tUp 1 hour\tgo:1.22\t/mint/projects/payments/services/payments\n"),
+			[]byte("payments\tmint.subdir\tservices/payments\n"),
+		}}
+	}
+	describe := &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")}
+
+	output, err := runProjectListWithCache(t, describe, remote(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"SUBDIR", "services/payments", "running", "go:1.22"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got:\n%s", want, output)
+		}
+	}
+
+	output, err = runProjectListWithCache(t, describe, remote(), "", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var projects []projectInfo
+	if err := json.Unmarshal([]byte(output), &projects); err != nil {
+		t.Fatalf("parsing JSON: %v\n%s", err, output)
+	}
+	if len(projects) != 2 || projects[0].Subdir != "services/payments" || projects[1].Subdir != "" {
+		t.Errorf("projects = %+v", projects)
+	}
+}
+
+func TestProjectAddNoDevcontainerFlag(t *testing.T) {
+	hint.IsTTY = false
+	// test -d (missing), record mint.devcontainer, tmux session, identity check.
+	// The devcontainer config check is skipped entirely.
+	remote := &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1")}}
+	streaming := &projectMockStreamingRemote{}
+	output, err := runProjectAddSubdir(t, remote, streaming, "https://github.com/org/app.git", "--no-devcontainer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	var got []string
+	for _, c := range remote.Calls() {
+		got = append(got, strings.Join(c.Command, " "))
+	}
+	want := []string{
+		"test -d /mint/projects/app",
+		"git -C /mint/projects/app config mint.devcontainer false",
+		`sh -c 'tmux has-session -t app 2>/dev/null || tmux new-session -d -s app -c '\''/mint/projects/app'\'''`,
+		"git -C /mint/projects/app config --show-origin user.email",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("remote commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(streaming.calls) != 1 {
+		t.Errorf("streaming calls = %d, want only the clone", len(streaming.calls))
+	}
+	if !strings.Contains(output, "--no-devcontainer") || !strings.Contains(output, "created plain tmux session") {
+		t.Errorf("output = %q, want the plain session message", output)
+	}
+}
+
+func TestProjectAddNoDevcontainerConflictsWithOverride(t *testing.T) {
+	remote := &cmdtest.RemoteRunner{}
+	_, err := runProjectAddSubdir(t, remote, &projectMockStreamingRemote{},
+		"https://github.com/org/app.git", "--no-devcontainer", "--override", "{}")
+	if err == nil {
+		t.Fatal("expected an error for --no-devcontainer with --override")
+	}
+	if len(remote.Calls()) != 0 {
+		t.Errorf("expected no remote calls, got %d", len(remote.Calls()))
+	}
+}
+
+func TestProjectListShowsNoDevcontainer(t *testing.T) {
+	hint.IsTTY = false
+	remote := &cmdtest.RemoteRunner{Outputs: [][]byte{
+		[]byte("notes\nplain\n"),
+		[]byte("notes-app-1\tUp 1 hour\tgo:1.22\t/mint/projects/notes\n"),
+		[]byte("notes\tmint.devcontainer\tfalse\nplain\tmint.devcontainer\tfalse\n"),
+	}}
+	describe := &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")}
+
+	output, err := runProjectListWithCache(t, describe, remote, "", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var projects []projectInfo
+	if err := json.Unmarshal([]byte(output), &projects); err != nil {
+		t.Fatalf("parsing JSON: %v\n%s", err, output)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("projects = %+v", projects)
+	}
+	// A container added later (after a rebuild) reports its real status.
+	if projects[0].ContainerStatus != "running" {
+		t.Errorf("notes status = %q, want running", projects[0].ContainerStatus)
+	}
+	if projects[1].ContainerStatus != "n/a" {
+		t.Errorf("plain status = %q, want n/a", projects[1].ContainerStatus)
+	}
+}
+
+func TestParseProjectSettings(t *testing.T) {
+	got := parseProjectSettings("a\tmint.subdir\tsvc/a\na\tmint.devcontainer\tfalse\nb\tmint.devcontainer\ttrue\nmalformed\n\n")
+	want := map[string]projectSettings{
+		"a": {subdir: "svc/a", noDevcontainer: true},
+		"b": {},
+	}
+	if len(got) != len(want) || got["a"] != want["a"] || got["b"] != want["b"] {
+		t.Errorf("parseProjectSettings = %+v, want %+v", got, want)
+	}
+}
+
+func TestProjectRemoveCommand(t *testing.T) {
+	hint.IsTTY = false // Ensure non-TTY mode for consistent test assertions.
+
+	tests := []struct {
+		name           string
+		describe       *cmdtest.DescribeInstances
+		remote         *cmdtest.RemoteRunner
+		args           []string
+		stdinInput     string
+		wantErrContain string
+		wantCommands   []string // joined commands, in order
+		wantOutput     string
+	}{
+		{
+			name:     "removes container, session, and files",
+			describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:   &cmdtest.RemoteRunner{},
+			args:     []string{"--yes", "project", "remove", "myproject"},
+			wantCommands: []string{
+				"test -d /mint/projects/myproject",
+				"sh -c docker stop $(docker ps -q --filter label=devcontainer.local_folder=/mint/projects/myproject) 2>/dev/null || true",
+				"sh -c docker rm $(docker ps -aq --filter label=devcontainer.local_folder=/mint/projects/myproject) 2>/dev/null || true",
+				"tmux kill-session -t myproject",
+				"rm -rf /mint/projects/myproject",
+			},
+			wantOutput: `Removed project "myproject"`,
+		},
+		{
+			name:     "keep-files leaves the clone",
+			describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:   &cmdtest.RemoteRunner{},
+			args:     []string{"--yes", "project", "remove", "myproject", "--keep-files"},
+			wantCommands: []string{
+				"test -d /mint/projects/myproject",
+				"sh -c docker stop $(docker ps -q --filter label=devcontainer.local_folder=/mint/projects/myproject) 2>/dev/null || true",
+				"sh -c docker rm $(docker ps -aq --filter label=devcontainer.local_folder=/mint/projects/myproject) 2>/dev/null || true",
+				"tmux kill-session -t myproject",
+			},
+			wantOutput: "files kept in /mint/projects/myproject",
+		},
+		{
+			name:       "confirmation prompt accepts the project name",
+			describe:   &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:     &cmdtest.RemoteRunner{},
+			args:       []string{"project", "remove", "myproject"},
+			stdinInput: "myproject\n",
+			wantOutput: "Type the project name to confirm",
+		},
+		{
+			name:           "confirmation mismatch aborts",
+			describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:         &cmdtest.RemoteRunner{},
+			args:           []string{"project", "remove", "myproject"},
+			stdinInput:     "other\n",
+			wantErrContain: "remove aborted",
+			wantCommands:   []string{"test -d /mint/projects/myproject"},
+		},
+		{
+			name:           "missing project directory",
+			describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:         &cmdtest.RemoteRunner{Errs: []error{fmt.Errorf("exit status 1")}},
+			args:           []string{"--yes", "project", "remove", "nonexistent"},
+			wantErrContain: `project "nonexistent" not found`,
+			wantCommands:   []string{"test -d /mint/projects/nonexistent"},
+		},
+		{
+			name:           "shell metacharacters rejected",
+			describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:         &cmdtest.RemoteRunner{},
+			args:           []string{"--yes", "project", "remove", "foo;rm -rf /"},
+			wantErrContain: "invalid project name",
+			wantCommands:   []string{},
+		},
+		{
+			name:           "stopped VM",
+			describe:       &cmdtest.DescribeInstances{Output: makeStoppedInstanceForProject("i-abc123", "default", "alice")},
+			remote:         &cmdtest.RemoteRunner{},
+			args:           []string{"--yes", "project", "remove", "myproject"},
+			wantErrContain: "not running",
+		},
+		{
+			name:           "delete failure propagates",
+			describe:       &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+			remote:         &cmdtest.RemoteRunner{Errs: []error{nil, nil, nil, nil, fmt.Errorf("permission denied")}},
+			args:           []string{"--yes", "project", "remove", "myproject"},
+			wantErrContain: "deleting project files: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			deps := &projectRemoveDeps{
+				describe: tt.describe,
+				sendKey:  &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				owner:    "alice",
+				remote:   tt.remote.Run,
+				stdin:    strings.NewReader(tt.stdinInput),
+			}
+			root := cmdtest.NewRoot()
+			root.AddCommand(newProjectCommandWithRemoveDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(tt.args)
+
+			err := root.Execute()
+			if tt.wantErrContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErrContain)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantCommands != nil {
+				var got []string
+				for _, c := range tt.remote.Calls() {
+					got = append(got, strings.Join(c.Command, " "))
+				}
+				if strings.Join(got, "\n") != strings.Join(tt.wantCommands, "\n") {
+					t.Errorf("commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantCommands, "\n"))
+				}
+			}
+			if tt.wantOutput != "" && !strings.Contains(buf.String(), tt.wantOutput) {
+				t.Errorf("output missing %q:\n%s", tt.wantOutput, buf.String())
+			}
+		})
+	}
+}
+
+func TestProjectRemoveForgetsCachedProject(t *testing.T) {
+	dir := t.TempDir()
+	projects := []projectInfo{{Name: "myproject", ContainerStatus: "running"}, {Name: "other", ContainerStatus: "running"}}
+	if err := writeProjectListCache(dir, "default", projects, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	deps := &projectRemoveDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:    "alice",
+		remote:   (&cmdtest.RemoteRunner{}).Run,
+		cacheDir: dir,
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newProjectCommandWithRemoveDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"--yes", "project", "remove", "myproject"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lastRunningProjects(dir, "default"); len(got) != 1 || got[0] != "other" {
+		t.Errorf("lastRunningProjects = %v, want [other]", got)
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// provisionHistoryPath is the provisioning history file on a VM, one JSON
+// entry per line. It lives on the root volume, so mint recreate reads it
+// from the old instance and writes it to the new one.
+const provisionHistoryPath = "/mint/.mint-history.jsonl"
+
+// provisionHistoryMax is how many entries mint recreate carries over to the
+// new instance, the newest kept.
+const provisionHistoryMax = 100
+
+// Provisioning history actions.
+const (
+	provisionActionUp       = "up"
+	provisionActionRecreate = "recreate"
+)
+
+// provisionHistoryEntry is one line of the provisioning history file: an
+// instance a fresh mint up or mint recreate provisioned.
+type provisionHistoryEntry struct {
+	InstanceID      string    `json:"instance_id"`
+	Action          string    `json:"action"`
+	Timestamp       time.Time `json:"timestamp"`
+	CLIVersion      string    `json:"cli_version"`
+	DurationSeconds int       `json:"duration_seconds"`
+}
+
+// newProvisionHistoryEntry returns the entry for instanceID, provisioned by
+// action between started and now with this CLI.
+func newProvisionHistoryEntry(instanceID, action string, started, now time.Time) provisionHistoryEntry {
+	return provisionHistoryEntry{
+		InstanceID:      instanceID,
+		Action:          action,
+		Timestamp:       now.UTC().Truncate(time.Second),
+		CLIVersion:      version,
+		DurationSeconds: int(now.Sub(started).Round(time.Second) / time.Second),
+	}
+}
+
+// recordProvisioning records that target was provisioned: it tags the
+// instance with when, by whom, and with which CLI version, and writes
+// entries to its history file, the new entry last. It is best effort: the
+// VM is usable either way, so the caller only warns about the returned
+// error.
+func recordProvisioning(
+	ctx context.Context,
+	createTags mintaws.CreateTagsAPI,
+	run RemoteCommandRunner,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	target *vm.VM,
+	port int,
+	owner string,
+	entries []provisionHistoryEntry,
+) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	latest := entries[len(entries)-1]
+
+	var errs []error
+	if createTags != nil {
+		if _, err := createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{target.ID},
+			Tags: []ec2types.Tag{
+				{Key: aws.String(tags.TagProvisionedAt), Value: aws.String(latest.Timestamp.Format(time.RFC3339))},
+				{Key: aws.String(tags.TagProvisionedBy), Value: aws.String(owner)},
+				{Key: aws.String(tags.TagCLIVersion), Value: aws.String(latest.CLIVersion)},
+			},
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("tagging instance: %w", err))
+		}
+	}
+	if run != nil && target.PublicIP != "" {
+		command, err := provisionHistoryWriteCommand(entries)
+		if err == nil {
+			_, err = run(ctx, sendKey, target.ID, target.AvailabilityZone, target.PublicIP, port, defaultSSHUser, command)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("writing %s: %w", provisionHistoryPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// provisionHistoryWriteCommand returns the remote command that appends
+// entries to the history file. /mint is owned by root.
+func provisionHistoryWriteCommand(entries []provisionHistoryEntry) ([]string, error) {
+	var b strings.Builder
+	b.WriteString("printf '%s\\n'")
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(" ")
+		b.WriteString(shellQuote(string(line)))
+	}
+	b.WriteString(" | sudo tee -a " + provisionHistoryPath + " >/dev/null")
+	return []string{b.String()}, nil
+}
+
+// provisionHistoryReadCommand returns the remote command that prints the
+// history file, or nothing when the VM has none.
+func provisionHistoryReadCommand() []string {
+	return []string{"cat " + provisionHistoryPath + " 2>/dev/null || true"}
+}
+
+// readProvisionHistory reads the history file of target, oldest entry
+// first.
+func readProvisionHistory(ctx context.Context, run RemoteCommandRunner, sendKey mintaws.SendSSHPublicKeyAPI, target *vm.VM, port int) ([]provisionHistoryEntry, error) {
+	out, err := run(ctx, sendKey, target.ID, target.AvailabilityZone, target.PublicIP, port, defaultSSHUser, provisionHistoryReadCommand())
+	if err != nil {
+		return nil, err
+	}
+	return parseProvisionHistory(out), nil
+}
+
+// parseProvisionHistory parses the history file. Lines that are not an
+// entry, such as one cut short by a full disk, are skipped.
+func parseProvisionHistory(out []byte) []provisionHistoryEntry {
+	var entries []provisionHistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e provisionHistoryEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil || e.InstanceID == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// lastProvisionHistory returns the last n entries, all of them when n is 0
+// or there are fewer.
+func lastProvisionHistory(entries []provisionHistoryEntry, n int) []provisionHistoryEntry {
+	if n > 0 && len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// provisionedSummary describes when, by whom, and with which CLI a VM was
+// last provisioned, e.g. "provisioned 3d ago by alice (mint v0.9.2)", from
+// its tags. It is empty for a VM provisioned before the tags existed.
+func provisionedSummary(instanceTags map[string]string, now time.Time) string {
+	at, err := time.Parse(time.RFC3339, instanceTags[tags.TagProvisionedAt])
+	if err != nil {
+		return ""
+	}
+	summary := "provisioned " + formatCacheAge(now.Sub(at))
+	if by := instanceTags[tags.TagProvisionedBy]; by != "" {
+		summary += " by " + by
+	}
+	if v := instanceTags[tags.TagCLIVersion]; v != "" {
+		summary += " (mint " + displayVersion(v) + ")"
+	}
+	return summary
+}
+
+// displayVersion renders a CLI version for people: release versions,
+// recorded without their "v", get it back; "dev" stays as it is.
+func displayVersion(v string) string {
+	if v != "" && v[0] >= '0' && v[0] <= '9' {
+		return "v" + v
+	}
+	return v
+}
+
+// warnProvisionHistory warns that recording the provisioning history failed.
+func warnProvisionHistory(w io.Writer, err error) {
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not record provisioning history: %v\n", err)
+	}
+}
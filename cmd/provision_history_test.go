@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+func TestNewProvisionHistoryEntry(t *testing.T) {
+	started := time.Date(2026, 10, 12, 9, 55, 0, 0, time.UTC)
+	now := started.Add(4*time.Minute + 12*time.Second + 400*time.Millisecond)
+	got := newProvisionHistoryEntry("i-abc", provisionActionRecreate, started, now)
+	want := provisionHistoryEntry{
+		InstanceID:      "i-abc",
+		Action:          "recreate",
+		Timestamp:       time.Date(2026, 10, 12, 9, 59, 12, 0, time.UTC),
+		CLIVersion:      version,
+		DurationSeconds: 252,
+	}
+	if got != want {
+		t.Errorf("entry = %+v, want %+v", got, want)
+	}
+}
+
+// TestProvisionHistoryWriteCommand runs the write command's printf with sh
+// and parses what it would append, so the quoting is checked end to end.
+func TestProvisionHistoryWriteCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	entries := []provisionHistoryEntry{
+		{InstanceID: "i-old", Action: "up", Timestamp: time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC), CLIVersion: "0.9.1", DurationSeconds: 301},
+		{InstanceID: "i-new", Action: "recreate", Timestamp: time.Date(2026, 10, 12, 9, 59, 12, 0, time.UTC), CLIVersion: "0.9.2-it's", DurationSeconds: 252},
+	}
+	command, err := provisionHistoryWriteCommand(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(command) != 1 {
+		t.Fatalf("command = %q, want one shell string", command)
+	}
+	printf, ok := strings.CutSuffix(command[0], " | sudo tee -a "+provisionHistoryPath+" >/dev/null")
+	if !ok {
+		t.Fatalf("command %q does not append to %s", command[0], provisionHistoryPath)
+	}
+	out, err := exec.Command("sh", "-c", printf).Output()
+	if err != nil {
+		t.Fatalf("sh: %v", err)
+	}
+	if got := parseProvisionHistory(out); !reflect.DeepEqual(got, entries) {
+		t.Errorf("appended =\n%+v\nwant\n%+v", got, entries)
+	}
+}
+
+func TestParseProvisionHistory(t *testing.T) {
+	out := []byte(`{"instance_id":"i-1","action":"up","timestamp":"2026-09-01T08:00:00Z","cli_version":"0.9.1","duration_seconds":301}
+
+not json
+{"action":"up"}
+{"instance_id":"i-2","action":"recreate","timestamp":"2026-10-12T09:59:12Z","cli_version":"0.9.2","duration_seconds":252}
+{"instance_id":"i-3","action":"up","timest`)
+	got := parseProvisionHistory(out)
+	if len(got) != 2 || got[0].InstanceID != "i-1" || got[1].InstanceID != "i-2" {
+		t.Errorf("parsed %+v, want entries i-1 and i-2", got)
+	}
+	if parseProvisionHistory(nil) != nil {
+		t.Error("empty file: want no entries")
+	}
+}
+
+func TestLastProvisionHistory(t *testing.T) {
+	entries := []provisionHistoryEntry{{InstanceID: "i-1"}, {InstanceID: "i-2"}, {InstanceID: "i-3"}}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 0, want: []string{"i-1", "i-2", "i-3"}},
+		{n: 2, want: []string{"i-2", "i-3"}},
+		{n: 5, want: []string{"i-1", "i-2", "i-3"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range lastProvisionHistory(entries, tt.n) {
+			got = append(got, e.InstanceID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("last %d = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRecordProvisioning(t *testing.T) {
+	target := &vm.VM{ID: "i-new", AvailabilityZone: "us-east-1a", PublicIP: "1.2.3.4"}
+	entries := []provisionHistoryEntry{
+		{InstanceID: "i-old", Action: "up", Timestamp: time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC), CLIVersion: "0.9.1"},
+		{InstanceID: "i-new", Action: "recreate", Timestamp: time.Date(2026, 10, 12, 9, 59, 12, 0, time.UTC), CLIVersion: "0.9.2"},
+	}
+
+	tests := []struct {
+		name     string
+		tagErr   error
+		runErr   error
+		wantErrs []string
+	}{
+		{name: "success"},
+		{name: "tagging fails", tagErr: errors.New("UnauthorizedOperation"), wantErrs: []string{"tagging instance: UnauthorizedOperation"}},
+		{name: "ssh fails", runErr: errors.New("connection refused"), wantErrs: []string{"writing " + provisionHistoryPath + ": connection refused"}},
+		{
+			name:     "both fail",
+			tagErr:   errors.New("UnauthorizedOperation"),
+			runErr:   errors.New("connection refused"),
+			wantErrs: []string{"tagging instance", "connection refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagger := &cmdtest.CreateTags{Err: tt.tagErr}
+			var ran []string
+			run := func(_ context.Context, _ mintaws.SendSSHPublicKeyAPI, instanceID, _, host string, _ int, _ string, command []string) ([]byte, error) {
+				ran = append(ran, instanceID+"@"+host+": "+strings.Join(command, " "))
+				return nil, tt.runErr
+			}
+
+			err := recordProvisioning(context.Background(), tagger, run, nil, target, defaultSSHPort, "alice", entries)
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %v, want it to contain %q", err, want)
+				}
+			}
+
+			// Both steps are attempted whatever the other's outcome.
+			if len(tagger.Inputs) != 1 {
+				t.Fatalf("CreateTags calls = %d, want 1", len(tagger.Inputs))
+			}
+			got := map[string]string{}
+			for _, tag := range tagger.Inputs[0].Tags {
+				got[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			want := map[string]string{
+				tags.TagProvisionedAt: "2026-10-12T09:59:12Z",
+				tags.TagProvisionedBy: "alice",
+				tags.TagCLIVersion:    "0.9.2",
+			}
+			if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(tagger.Inputs[0].Resources, []string{"i-new"}) {
+				t.Errorf("tagged %v with %v, want i-new with %v", tagger.Inputs[0].Resources, got, want)
+			}
+			if len(ran) != 1 || !strings.HasPrefix(ran[0], "i-new@1.2.3.4: printf") || !strings.Contains(ran[0], `"i-old"`) {
+				t.Errorf("remote commands = %q, want one write of both entries to i-new", ran)
+			}
+		})
+	}
+}
+
+func TestProvisionedSummary(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{
+			name: "all tags",
+			tags: map[string]string{tags.TagProvisionedAt: "2026-10-12T09:00:00Z", tags.TagProvisionedBy: "alice", tags.TagCLIVersion: "0.9.2"},
+			want: "provisioned 3d ago by alice (mint v0.9.2)",
+		},
+		{
+			name: "version already has its v",
+			tags: map[string]string{tags.TagProvisionedAt: "2026-10-15T11:30:00Z", tags.TagCLIVersion: "v1.0.0"},
+			want: "provisioned 30m ago (mint v1.0.0)",
+		},
+		{
+			name: "no tags",
+			want: "",
+		},
+		{
+			name: "unparseable time",
+			tags: map[string]string{tags.TagProvisionedAt: "yesterday", tags.TagProvisionedBy: "alice"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := provisionedSummary(tt.tags, now); got != tt.want {
+				t.Errorf("summary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,512 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// dockerDataRoot is Docker's default data root on the VM. Free space is
+// measured on the filesystem that holds it.
+const dockerDataRoot = "/var/lib/docker"
+
+// Prune category names, in the order --min-free prunes them. Unused networks
+// hold no disk space, so --min-free never prunes them.
+const (
+	pruneBuildCache        = "build-cache"
+	pruneDanglingImages    = "dangling-images"
+	pruneStoppedContainers = "stopped-containers"
+	pruneUnusedNetworks    = "unused-networks"
+)
+
+// pruneDeps holds the injectable dependencies for the prune command.
+type pruneDeps struct {
+	describe mintaws.DescribeInstancesAPI
+	sendKey  mintaws.SendSSHPublicKeyAPI
+	owner    string
+	remote   RemoteCommandRunner
+	sshPort  int // ssh_port from config; zero means defaultSSHPort
+}
+
+// pruneCategory is one group of reclaimable Docker resources.
+type pruneCategory struct {
+	Name             string `json:"name"`
+	Count            int    `json:"count"`
+	ReclaimableBytes int64  `json:"reclaimable_bytes"`
+
+	// command is the prune command that reclaims this category.
+	command []string
+}
+
+// pruneReport is the outcome of a prune run, also used as the JSON output.
+type pruneReport struct {
+	VM                    string          `json:"vm"`
+	FreeBytes             int64           `json:"free_bytes"`
+	Categories            []pruneCategory `json:"categories"`
+	TotalReclaimableBytes int64           `json:"total_reclaimable_bytes"`
+	Applied               bool            `json:"applied"`
+	MinFreeBytes          int64           `json:"min_free_bytes,omitempty"`
+	Planned               []string        `json:"planned,omitempty"`
+	Pruned                []string        `json:"pruned"`
+	FreeBytesAfter        *int64          `json:"free_bytes_after,omitempty"`
+}
+
+// newPruneCommand creates the production prune command.
+func newPruneCommand() *cobra.Command {
+	return newPruneCommandWithDeps(nil)
+}
+
+// newPruneCommandWithDeps creates the prune command with explicit dependencies
+// for testing.
+func newPruneCommandWithDeps(deps *pruneDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "prune",
+		Short:       "Reclaim disk space used by Docker on the VM",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Long: "Report reclaimable Docker disk space on the VM: builder cache, dangling " +
+			"images, stopped containers that are not project devcontainers, and unused " +
+			"networks. Nothing is removed unless --apply is given. With --min-free, " +
+			"categories are pruned in that order only until the target free space is reached.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runPrune(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runPrune(cmd, &pruneDeps{
+				describe: clients.ec2Client,
+				sendKey:  clients.sendKey,
+				owner:    clients.owner,
+				remote:   clients.remoteRunner(),
+				sshPort:  clients.sshOptions.Port,
+			})
+		},
+	}
+
+	cmd.Flags().Bool("apply", false, "Run the prune commands (default is a dry run)")
+	cmd.Flags().Int("min-free", 0, "Prune progressively until at least this many GB are free (0 prunes everything reported)")
+
+	return cmd
+}
+
+// runPrune executes the prune command logic.
+func runPrune(cmd *cobra.Command, deps *pruneDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+
+	apply, _ := cmd.Flags().GetBool("apply")
+	minFreeGB, _ := cmd.Flags().GetInt("min-free")
+	if minFreeGB < 0 {
+		return fmt.Errorf("--min-free must be zero or a positive number of GB")
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+	}
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+			vmName, found.ID, found.State, hint.Cmd("mint up"))
+	}
+
+	run := func(command []string) ([]byte, error) {
+		return deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	}
+
+	categories, err := gatherPruneCategories(run)
+	if err != nil {
+		return err
+	}
+	free, err := fetchFreeBytes(run)
+	if err != nil {
+		return err
+	}
+
+	report := &pruneReport{
+		VM:           vmName,
+		FreeBytes:    free,
+		Categories:   categories,
+		Applied:      apply,
+		MinFreeBytes: int64(minFreeGB) * bytesPerGB,
+		Pruned:       []string{},
+	}
+	for _, c := range categories {
+		report.TotalReclaimableBytes += c.ReclaimableBytes
+	}
+
+	if apply {
+		if err := applyPrune(run, report); err != nil {
+			return err
+		}
+	} else {
+		report.Planned = planPrune(report)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writePruneHuman(cmd.OutOrStdout(), report)
+	return nil
+}
+
+// bytesPerGB is the decimal gigabyte used for --min-free and size output,
+// matching Docker's own size units.
+const bytesPerGB = 1000 * 1000 * 1000
+
+// gatherPruneCategories queries Docker on the VM and returns the reclaimable
+// resources grouped by category, in --min-free prune order.
+func gatherPruneCategories(run func([]string) ([]byte, error)) ([]pruneCategory, error) {
+	// Project folders whose devcontainers must never be pruned. A missing
+	// projects directory just means there is nothing to exclude.
+	lsOut, _ := run([]string{"ls", "-1", "/mint/projects/"})
+	projectFolders := projectFoldersFromLs(string(lsOut))
+
+	dfOut, err := run([]string{"docker", "system", "df", "--format", "json"})
+	if err != nil {
+		return nil, fmt.Errorf("reading docker disk usage: %w", err)
+	}
+	cache, err := parseDockerSystemDF(string(dfOut))
+	if err != nil {
+		return nil, err
+	}
+
+	imagesOut, err := run([]string{"docker", "images", "--filter", "dangling=true", "--format", "json"})
+	if err != nil {
+		return nil, fmt.Errorf("listing dangling images: %w", err)
+	}
+	images, err := parseDanglingImages(string(imagesOut))
+	if err != nil {
+		return nil, err
+	}
+
+	psOut, err := run([]string{"docker", "ps", "-a", "--size",
+		"--filter", "status=exited", "--filter", "status=created", "--format", "json"})
+	if err != nil {
+		return nil, fmt.Errorf("listing stopped containers: %w", err)
+	}
+	containers, err := parseStoppedContainers(string(psOut), projectFolders)
+	if err != nil {
+		return nil, err
+	}
+
+	netOut, err := run([]string{"docker", "network", "ls", "--filter", "dangling=true", "--format", "{{.Name}}"})
+	if err != nil {
+		return nil, fmt.Errorf("listing unused networks: %w", err)
+	}
+	networks := pruneCategory{
+		Name:    pruneUnusedNetworks,
+		Count:   len(nonEmptyLines(string(netOut))),
+		command: []string{"docker", "network", "prune", "-f"},
+	}
+
+	containers.command = containerPruneCommand(projectFolders)
+	return []pruneCategory{cache, images, containers, networks}, nil
+}
+
+// containerPruneCommand returns the stopped-container prune command. Each
+// project folder adds a label!= filter so its devcontainer is never removed.
+func containerPruneCommand(projectFolders []string) []string {
+	command := []string{"docker", "container", "prune", "-f"}
+	for _, folder := range projectFolders {
+		command = append(command, "--filter", "label!=devcontainer.local_folder="+folder)
+	}
+	return command
+}
+
+// projectFoldersFromLs converts `ls -1 /mint/projects/` output into project
+// folder paths. Names that are not valid project names are skipped because
+// they cannot be interpolated into a remote command safely.
+func projectFoldersFromLs(lsOutput string) []string {
+	var folders []string
+	for _, name := range nonEmptyLines(lsOutput) {
+		if validateProjectName(name) != nil {
+			continue
+		}
+		folders = append(folders, "/mint/projects/"+name)
+	}
+	return folders
+}
+
+// dockerDFEntry is one line of `docker system df --format json`.
+type dockerDFEntry struct {
+	Type        string `json:"Type"`
+	TotalCount  string `json:"TotalCount"`
+	Reclaimable string `json:"Reclaimable"`
+}
+
+// parseDockerSystemDF extracts the builder cache category from
+// `docker system df --format json` output (one JSON object per line).
+func parseDockerSystemDF(output string) (pruneCategory, error) {
+	cache := pruneCategory{
+		Name:    pruneBuildCache,
+		command: []string{"docker", "builder", "prune", "-f"},
+	}
+	for _, line := range nonEmptyLines(output) {
+		var entry dockerDFEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return cache, fmt.Errorf("parsing docker system df output: %w", err)
+		}
+		if entry.Type != "Build Cache" {
+			continue
+		}
+		size, err := parseDockerSize(entry.Reclaimable)
+		if err != nil {
+			return cache, err
+		}
+		cache.ReclaimableBytes = size
+		cache.Count, _ = strconv.Atoi(entry.TotalCount)
+	}
+	return cache, nil
+}
+
+// parseDanglingImages sums `docker images --filter dangling=true --format json`.
+func parseDanglingImages(output string) (pruneCategory, error) {
+	images := pruneCategory{
+		Name:    pruneDanglingImages,
+		command: []string{"docker", "image", "prune", "-f"},
+	}
+	for _, line := range nonEmptyLines(output) {
+		var entry struct {
+			Size string `json:"Size"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return images, fmt.Errorf("parsing docker images output: %w", err)
+		}
+		size, err := parseDockerSize(entry.Size)
+		if err != nil {
+			return images, err
+		}
+		images.Count++
+		images.ReclaimableBytes += size
+	}
+	return images, nil
+}
+
+// parseStoppedContainers sums `docker ps -a --size --format json` output for
+// stopped containers, skipping devcontainers of the given project folders.
+// The writable layer (the first Size value) is what pruning reclaims.
+func parseStoppedContainers(output string, projectFolders []string) (pruneCategory, error) {
+	containers := pruneCategory{Name: pruneStoppedContainers}
+	for _, line := range nonEmptyLines(output) {
+		var entry struct {
+			Labels string `json:"Labels"`
+			Size   string `json:"Size"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return containers, fmt.Errorf("parsing docker ps output: %w", err)
+		}
+		if isProjectDevcontainer(entry.Labels, projectFolders) {
+			continue
+		}
+		size, err := parseDockerSize(entry.Size)
+		if err != nil {
+			return containers, err
+		}
+		containers.Count++
+		containers.ReclaimableBytes += size
+	}
+	return containers, nil
+}
+
+// isProjectDevcontainer reports whether a docker ps Labels string carries a
+// devcontainer.local_folder label for one of the project folders.
+func isProjectDevcontainer(labels string, projectFolders []string) bool {
+	for _, label := range strings.Split(labels, ",") {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key != "devcontainer.local_folder" {
+			continue
+		}
+		for _, folder := range projectFolders {
+			if value == folder {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseDockerSize parses a Docker human-readable size such as "1.2GB",
+// "512kB", "0B", or "1.035GB (52%)". Docker uses decimal units.
+func parseDockerSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"TB", 1e12}, {"GB", 1e9}, {"MB", 1e6}, {"kB", 1e3}, {"KB", 1e3}, {"B", 1},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("parsing docker size %q: %w", s, err)
+			}
+			return int64(math.Round(n * u.mult)), nil
+		}
+	}
+	return 0, fmt.Errorf("parsing docker size %q: unknown unit", s)
+}
+
+// fetchFreeBytes returns the free space on the filesystem holding Docker data.
+func fetchFreeBytes(run func([]string) ([]byte, error)) (int64, error) {
+	out, err := run([]string{"df", "--output=avail", "-B1", dockerDataRoot})
+	if err != nil {
+		return 0, fmt.Errorf("reading free disk space: %w", err)
+	}
+	lines := nonEmptyLines(string(out))
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %q", strings.TrimSpace(string(out)))
+	}
+	free, err := strconv.ParseInt(strings.TrimSpace(lines[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing df output: %w", err)
+	}
+	return free, nil
+}
+
+// planPrune returns the categories a dry run would prune. Without --min-free
+// that is every non-empty category; with it, categories are added in order
+// until the estimated free space reaches the target.
+func planPrune(report *pruneReport) []string {
+	planned := []string{}
+	free := report.FreeBytes
+	for _, c := range report.Categories {
+		if report.MinFreeBytes > 0 {
+			if free >= report.MinFreeBytes || c.Name == pruneUnusedNetworks {
+				break
+			}
+		}
+		if c.Count == 0 && c.ReclaimableBytes == 0 {
+			continue
+		}
+		planned = append(planned, c.Name)
+		free += c.ReclaimableBytes
+	}
+	return planned
+}
+
+// applyPrune runs the prune commands. With --min-free it re-measures free
+// space after each category and stops as soon as the target is reached.
+func applyPrune(run func([]string) ([]byte, error), report *pruneReport) error {
+	free := report.FreeBytes
+	for _, c := range report.Categories {
+		if report.MinFreeBytes > 0 {
+			if free >= report.MinFreeBytes || c.Name == pruneUnusedNetworks {
+				break
+			}
+		}
+		if c.Count == 0 && c.ReclaimableBytes == 0 {
+			continue
+		}
+		if _, err := run(c.command); err != nil {
+			return fmt.Errorf("pruning %s: %w", c.Name, err)
+		}
+		report.Pruned = append(report.Pruned, c.Name)
+
+		measured, err := fetchFreeBytes(run)
+		if err != nil {
+			return err
+		}
+		free = measured
+	}
+	report.FreeBytesAfter = &free
+	return nil
+}
+
+// writePruneHuman outputs the prune report as a table with a summary.
+func writePruneHuman(w io.Writer, report *pruneReport) {
+	fmt.Fprintf(w, "Docker disk usage on VM %q (free: %s)\n\n", report.VM, formatGB(report.FreeBytes))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tCOUNT\tRECLAIMABLE")
+	for _, c := range report.Categories {
+		size := formatGB(c.ReclaimableBytes)
+		if c.Name == pruneUnusedNetworks {
+			size = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", c.Name, c.Count, size)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nTotal reclaimable: %s\n", formatGB(report.TotalReclaimableBytes))
+
+	if report.Applied {
+		if len(report.Pruned) == 0 {
+			fmt.Fprintln(w, "Nothing pruned.")
+		} else {
+			fmt.Fprintf(w, "Pruned: %s\n", strings.Join(report.Pruned, ", "))
+		}
+		if report.FreeBytesAfter != nil {
+			fmt.Fprintf(w, "Free:   %s\n", formatGB(*report.FreeBytesAfter))
+		}
+		if report.MinFreeBytes > 0 && report.FreeBytesAfter != nil && *report.FreeBytesAfter < report.MinFreeBytes {
+			fmt.Fprintf(w, "Warning: free space is still below the %s target.\n", formatGB(report.MinFreeBytes))
+		}
+		return
+	}
+
+	if len(report.Planned) == 0 {
+		fmt.Fprintln(w, "\nNothing to prune.")
+		return
+	}
+	fmt.Fprintf(w, "\nDry run — would prune: %s\n", strings.Join(report.Planned, ", "))
+	applyCmd := "mint prune --apply"
+	if report.MinFreeBytes > 0 {
+		applyCmd += fmt.Sprintf(" --min-free %d", report.MinFreeBytes/bytesPerGB)
+	}
+	fmt.Fprintf(w, "Run %s to reclaim this space.\n", hint.Cmd(applyCmd))
+}
+
+// formatGB formats a byte count in decimal gigabytes.
+func formatGB(b int64) string {
+	return fmt.Sprintf("%.1f GB", float64(b)/bytesPerGB)
+}
+
+// nonEmptyLines splits output into trimmed, non-empty lines.
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
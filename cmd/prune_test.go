@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return string(data)
+}
+
+// pruneMockRemote answers remote commands by prefix and records every call.
+// Each df call consumes the next free-space value; the last one repeats.
+type pruneMockRemote struct {
+	outputs map[string]string
+	free    []int64
+	calls   [][]string
+}
+
+func (m *pruneMockRemote) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	m.calls = append(m.calls, command)
+	joined := strings.Join(command, " ")
+	if strings.HasPrefix(joined, "df ") {
+		free := m.free[0]
+		if len(m.free) > 1 {
+			m.free = m.free[1:]
+		}
+		return []byte(fmt.Sprintf("       Avail\n%d\n", free)), nil
+	}
+	for prefix, out := range m.outputs {
+		if strings.HasPrefix(joined, prefix) {
+			return []byte(out), nil
+		}
+	}
+	return nil, nil
+}
+
+// pruneCommandsIssued returns the prune commands from the recorded calls.
+func (m *pruneMockRemote) pruneCommandsIssued() []string {
+	var issued []string
+	for _, c := range m.calls {
+		if joined := strings.Join(c, " "); strings.Contains(joined, " prune ") {
+			issued = append(issued, joined)
+		}
+	}
+	return issued
+}
+
+func newPruneMockRemote(t *testing.T, free ...int64) *pruneMockRemote {
+	return &pruneMockRemote{
+		outputs: map[string]string{
+			"ls -1 /mint/projects/": "myproject\nsidecar\n",
+			"docker system df":      readFixture(t, "docker_system_df.json"),
+			"docker images":         readFixture(t, "docker_images_dangling.json"),
+			"docker ps":             readFixture(t, "docker_ps_stopped.json"),
+			"docker network ls":     "mint_old_default\n",
+		},
+		free: free,
+	}
+}
+
+func runPruneCommand(t *testing.T, remote *pruneMockRemote, args ...string) (string, error) {
+	t.Helper()
+	deps := &pruneDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:   "alice",
+		remote:  remote.run,
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newPruneCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"prune"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestParseDockerSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0B", 0},
+		{"512B", 512},
+		{"1.5kB", 1500},
+		{"12.3MB (4%)", 12300000},
+		{"1.035GB (52%)", 1035000000},
+		{"48.2MB (virtual 1.2GB)", 48200000},
+		{"2TB", 2000000000000},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseDockerSize(tt.in)
+		if err != nil {
+			t.Errorf("parseDockerSize(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDockerSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	if _, err := parseDockerSize("12 parsecs"); err == nil {
+		t.Error("expected error for unknown unit")
+	}
+}
+
+func TestParseDockerSystemDFFixture(t *testing.T) {
+	cache, err := parseDockerSystemDF(readFixture(t, "docker_system_df.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cache.ReclaimableBytes != 4500000000 {
+		t.Errorf("build cache reclaimable = %d, want 4500000000", cache.ReclaimableBytes)
+	}
+	if cache.Count != 42 {
+		t.Errorf("build cache count = %d, want 42", cache.Count)
+	}
+}
+
+func TestParseStoppedContainersExcludesProjectDevcontainers(t *testing.T) {
+	folders := []string{"/mint/projects/myproject", "/mint/projects/sidecar"}
+	containers, err := parseStoppedContainers(readFixture(t, "docker_ps_stopped.json"), folders)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// myproject's devcontainer is excluded; the unlabeled container and the
+	// devcontainer for a folder outside /mint/projects are reclaimable.
+	if containers.Count != 2 {
+		t.Errorf("count = %d, want 2", containers.Count)
+	}
+	if containers.ReclaimableBytes != 12000000 {
+		t.Errorf("reclaimable = %d, want 12000000", containers.ReclaimableBytes)
+	}
+}
+
+func TestPruneDryRunIssuesNoPruneCommands(t *testing.T) {
+	remote := newPruneMockRemote(t, 2*bytesPerGB)
+
+	out, err := runPruneCommand(t, remote)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if issued := remote.pruneCommandsIssued(); len(issued) != 0 {
+		t.Errorf("dry run issued prune commands: %v", issued)
+	}
+	for _, want := range []string{"build-cache", "4.5 GB", "dangling-images", "1.0 GB", "stopped-containers", "unused-networks", "Dry run", "mint prune --apply"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPruneApplyExcludesProjectDevcontainers(t *testing.T) {
+	remote := newPruneMockRemote(t, 2*bytesPerGB)
+
+	if _, err := runPruneCommand(t, remote, "--apply"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	issued := remote.pruneCommandsIssued()
+	want := []string{
+		"docker builder prune -f",
+		"docker image prune -f",
+		"docker container prune -f --filter label!=devcontainer.local_folder=/mint/projects/myproject --filter label!=devcontainer.local_folder=/mint/projects/sidecar",
+		"docker network prune -f",
+	}
+	if len(issued) != len(want) {
+		t.Fatalf("issued %d prune commands, want %d: %v", len(issued), len(want), issued)
+	}
+	for i := range want {
+		if issued[i] != want[i] {
+			t.Errorf("prune command %d = %q, want %q", i, issued[i], want[i])
+		}
+	}
+}
+
+func TestPruneMinFreeStopsWhenTargetReached(t *testing.T) {
+	tests := []struct {
+		name       string
+		free       []int64
+		wantPruned []string
+	}{
+		{
+			name:       "already above target",
+			free:       []int64{8 * bytesPerGB},
+			wantPruned: []string{},
+		},
+		{
+			name:       "cache is enough",
+			free:       []int64{2 * bytesPerGB, 6 * bytesPerGB},
+			wantPruned: []string{pruneBuildCache},
+		},
+		{
+			name:       "cache then images",
+			free:       []int64{1 * bytesPerGB, 3 * bytesPerGB, 5 * bytesPerGB},
+			wantPruned: []string{pruneBuildCache, pruneDanglingImages},
+		},
+		{
+			name:       "never reached skips networks",
+			free:       []int64{1 * bytesPerGB, 2 * bytesPerGB, 3 * bytesPerGB, 4 * bytesPerGB},
+			wantPruned: []string{pruneBuildCache, pruneDanglingImages, pruneStoppedContainers},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newPruneMockRemote(t, tt.free...)
+
+			out, err := runPruneCommand(t, remote, "--apply", "--min-free", "5", "--json")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var report pruneReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, out)
+			}
+			if strings.Join(report.Pruned, ",") != strings.Join(tt.wantPruned, ",") {
+				t.Errorf("pruned = %v, want %v", report.Pruned, tt.wantPruned)
+			}
+			if got := len(remote.pruneCommandsIssued()); got != len(tt.wantPruned) {
+				t.Errorf("issued %d prune commands, want %d", got, len(tt.wantPruned))
+			}
+		})
+	}
+}
+
+func TestPruneMinFreeDryRunPlansFromEstimates(t *testing.T) {
+	remote := newPruneMockRemote(t, 2*bytesPerGB)
+
+	out, err := runPruneCommand(t, remote, "--min-free", "5", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report pruneReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	// 2 GB free + 4.5 GB build cache reaches the 5 GB target.
+	if strings.Join(report.Planned, ",") != pruneBuildCache {
+		t.Errorf("planned = %v, want [%s]", report.Planned, pruneBuildCache)
+	}
+	if report.Applied || len(report.Pruned) != 0 {
+		t.Errorf("dry run must not prune, got applied=%v pruned=%v", report.Applied, report.Pruned)
+	}
+}
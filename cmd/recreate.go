@@ -22,9 +22,10 @@ import (
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/provision"
-	"github.com/SpiceLabsHQ/Mint/internal/session"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
@@ -35,6 +36,7 @@ type recreateDeps struct {
 	describe            mintaws.DescribeInstancesAPI
 	sendKey             mintaws.SendSSHPublicKeyAPI
 	remoteRun           RemoteCommandRunner
+	sshPort             int // ssh_port from config; zero means defaultSSHPort
 	owner               string
 	ownerARN            string
 	stop                mintaws.StopInstancesAPI
@@ -54,14 +56,36 @@ type recreateDeps struct {
 	describeAddrs       mintaws.DescribeAddressesAPI
 	associateAddr       mintaws.AssociateAddressAPI
 	disassociateAddr    mintaws.DisassociateAddressAPI
+	waitEIP             mintaws.WaitEIPAssociatedAPI // nil skips waiting for the association to propagate
 	bootstrapScript      []byte
 	bootstrapURL         string // GitHub raw URL for bootstrap.sh delivery
+	resolveBootstrap     bootstrapResolveFunc // signed manifest lookup; nil uses the embedded hash
+	bootstrapSource      bootstrap.Source     // set by runRecreate before the lifecycle starts
 	userBootstrapScript  []byte // Optional user-bootstrap.sh content read from config dir
 	mintConfig           *config.Config
 	pollBootstrap       provision.BootstrapPollFunc
 	resolveAMI          provision.AMIResolver
 	verifyBootstrap     provision.BootstrapVerifier
 	removeHostKey       func(vmName string) error
+	sshConfigPath       string // checked for a stale Host block once the recreate completes; empty skips the check
+	selfDetector        *selfcheck.Detector // nil skips the self-target guard
+	journal             *provision.JournalStore // hands the prefetch list to mint up; nil skips it
+	prefetchImages      []string                // set by runRecreate from the old instance's images
+	provisionHistory    []provisionHistoryEntry // set by runRecreate from the old instance's history file
+	sshUser             string                  // recorded in the new instance's mint:ssh-user tag; empty leaves it off
+	spot                bool                    // set by runRecreate: launch on the spot market (--spot, or the old instance was spot)
+	spotFallback        bool                    // set by runRecreate from --spot-fallback
+	spotWarning         string                  // set by launchRecreateInstance when a spot launch fell back to on-demand
+	ipMode              string                  // set by runRecreate from the old instance: a VM keeps its IP mode
+	kmsKeyID            string                  // set by runRecreate from --kms-key-id or kms_key_id; encrypts the new root volume
+	ipv6Address         string                  // set by launchRecreateInstance when RunInstances reports the new IPv6 address
+	checkOffering       provision.InstanceTypeOfferingFunc // nil skips checking that the VM's AZ offers the new instance's type
+	checkType           provision.InstanceTypeCheckFunc    // nil skips validating a changed instance type
+	instanceType        string                             // set by runRecreate from --instance-type; overrides instance_type
+	locker              *provision.Locker                  // nil takes no operation lock and skips the launch race check
+	network             networkConfig                      // set by runRecreate from the network flags or config
+	describeTypes       mintaws.DescribeInstanceTypesAPI   // sizes a hibernation-enabled root volume
+	rootVolumeGB        int32                              // set by runRecreate: 200, plus the new type's memory with hibernate
 }
 
 // WithWaitVolumeAvailable sets the waiter used to poll until the EBS volume
@@ -83,8 +107,11 @@ func newRecreateCommandWithDeps(deps *recreateDeps) *cobra.Command {
 		Use:   "recreate",
 		Short: "Destroy and re-provision the VM with the same configuration",
 		Long: "Destroy the current VM and create a fresh one with the same instance type, " +
-			"storage, and project configuration. Active sessions are detected and the " +
-			"operation is blocked unless --force is used.",
+			"storage, and project configuration. --instance-type launches the new instance " +
+			"as a different type, keeping the project volume. Active sessions and automation guards " +
+			"(see mint guard) are detected and the operation is blocked unless --force is used. " +
+			"It is also refused while another mint command holds the VM's operation lock, " +
+			"unless --steal-lock is used.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -121,8 +148,9 @@ func newRecreateCommandWithDeps(deps *recreateDeps) *cobra.Command {
 			}
 			return runRecreate(cmd, &recreateDeps{
 				describe:             clients.ec2Client,
-				sendKey:              clients.icClient,
-				remoteRun:            defaultRemoteRunner,
+				sendKey:              clients.sendKey,
+				remoteRun:            clients.uncheckedRemoteRunner(),
+				sshPort:              clients.sshOptions.Port,
 				owner:                clients.owner,
 				ownerARN:             clients.ownerARN,
 				stop:                 clients.ec2Client,
@@ -142,18 +170,37 @@ func newRecreateCommandWithDeps(deps *recreateDeps) *cobra.Command {
 				describeAddrs:        clients.ec2Client,
 				associateAddr:        clients.ec2Client,
 				disassociateAddr:     clients.ec2Client,
+				waitEIP:              mintaws.NewEIPAssociatedWaiter(clients.ec2Client, clients.ec2Client),
 				bootstrapScript:      GetBootstrapScript(),
 				bootstrapURL:         bootstrap.ScriptURL(version),
+				resolveBootstrap:     defaultBootstrapResolver(clients.mintConfig.BootstrapURL),
 				userBootstrapScript:  userBootstrapScript,
 				verifyBootstrap:      bootstrap.Verify,
 				mintConfig:           clients.mintConfig,
 				removeHostKey:        hostKeyStore.RemoveKey,
+				sshConfigPath:        paths.SSHConfigPath(),
 				pollBootstrap:        poller.Poll,
+				selfDetector:         selfcheck.Default(),
+				journal:              provision.NewJournalStore(configDir),
+				sshUser:              clients.sshOptions.LoginUser(defaultSSHUser),
+				checkOffering:        instanceTypeOffering(cmd, clients.ec2Client),
+				checkType:            instanceTypeCheck(cmd, clients.ec2Client, clients.region, configDir),
+				describeTypes:        clients.ec2Client,
+				locker:               newOperationLocker(cmd, clients.ec2Client),
 			})
 		},
 	}
 
 	cmd.Flags().Bool("force", false, "Bypass active session guard")
+	cmd.Flags().String("instance-type", "", "Instance type for the new instance (default: instance_type from config)")
+	addSelfTargetFlag(cmd)
+	addStealLockFlag(cmd)
+	addSpotFlags(cmd)
+	addKMSKeyFlag(cmd)
+	addNetworkFlags(cmd)
+	addSkipTypeValidationFlag(cmd)
+	addBootstrapFileFlag(cmd)
+	addNotifyFlags(cmd)
 
 	return cmd
 }
@@ -183,6 +230,9 @@ func runRecreate(cmd *cobra.Command, deps *recreateDeps) error {
 	}
 
 	force, _ := cmd.Flags().GetBool("force")
+	spot, _ := cmd.Flags().GetBool("spot")
+	spotFallback, _ := cmd.Flags().GetBool("spot-fallback")
+	deps.instanceType, _ = cmd.Flags().GetString("instance-type")
 	w := cmd.OutOrStdout()
 
 	// Discover VM — plain text, no spinner (follows destroy.go pattern).
@@ -195,25 +245,116 @@ func runRecreate(cmd *cobra.Command, deps *recreateDeps) error {
 		return fmt.Errorf("discovering VM: %w", err)
 	}
 	if found == nil {
+		if err := notOwnedError(ctx, deps.describe, deps.owner, vmName, "recreate"); err != nil {
+			return err
+		}
 		return fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
 	}
 
+	// Resolve the VM's [vm.<name>] overrides now: a bad table must fail
+	// while the old instance still exists.
+	if deps.mintConfig != nil {
+		vmCfg, err := deps.mintConfig.ForVM(vmName)
+		if err != nil {
+			return fmt.Errorf("config.toml: %w", err)
+		}
+		deps.mintConfig = vmCfg
+	}
+	// extra_tags that clash with mint's own tags would fail the launch
+	// after the old instance is gone.
+	if _, err := tags.MergeExtra(nil, recreateExtraTags(deps)); err != nil {
+		return err
+	}
+	// So would a bootstrap stub this build cannot render.
+	if err := bootstrap.CheckStub(); err != nil {
+		return provision.BootstrapRenderError(err)
+	}
+
+	// A spot VM stays spot; --spot moves an on-demand VM to the spot market.
+	deps.spot = spot || found.Spot
+	deps.spotFallback = spotFallback
+	if spotFallback && !deps.spot {
+		return fmt.Errorf("--spot-fallback requires --spot or a spot VM")
+	}
+	deps.ipMode = found.IPMode
+	configuredKey := ""
+	if deps.mintConfig != nil {
+		configuredKey = deps.mintConfig.KMSKeyID
+	}
+	if deps.kmsKeyID, err = kmsKeyFlag(cmd, configuredKey); err != nil {
+		return err
+	}
+	if deps.network, err = networkFlags(cmd, configuredNetwork(deps.mintConfig)); err != nil {
+		return err
+	}
+
 	// Verify VM is running (session detection requires SSH access).
 	state := ec2types.InstanceStateName(found.State)
 	if state != ec2types.InstanceStateNameRunning {
 		return fmt.Errorf("VM %q is %s — must be running to recreate (need SSH access for session detection)", vmName, found.State)
 	}
 
+	if err := guardSelfTarget(ctx, cmd, deps.selfDetector, vmName, found.ID, "terminate this VM and replace it with a new instance"); err != nil {
+		return err
+	}
+
+	// A new instance type must exist in the region, and the new instance
+	// launches in the old one's AZ, next to the project volume: a type that
+	// AZ does not offer must fail before anything stops.
+	newType := recreateInstanceType(deps, found)
+	if deps.checkType != nil && newType != found.InstanceType {
+		warning, err := deps.checkType(ctx, newType)
+		if err != nil {
+			return fmt.Errorf("invalid instance type: %w", err)
+		}
+		if warning != "" {
+			fmt.Fprintf(w, "Warning: %s\n", warning)
+		}
+	}
+	if deps.checkOffering != nil {
+		if err := deps.checkOffering(ctx, newType, found.AvailabilityZone); err != nil {
+			return fmt.Errorf("checking instance type: %w", err)
+		}
+	}
+	// A GPU type needs an image bootstrap can install the NVIDIA driver on.
+	if mintaws.IsGPUInstanceType(newType) {
+		amiID, err := resolveRecreateAMI(ctx, deps)
+		if err != nil {
+			return err
+		}
+		if err := mintaws.CheckGPUImage(ctx, deps.describeImages, amiID, newType); err != nil {
+			return err
+		}
+	}
+	// hibernate = true needs a type that can hibernate, and a root volume
+	// sized to its memory.
+	if deps.rootVolumeGB, err = provision.RootVolumeSize(ctx, deps.describeTypes, newType, recreateHibernate(deps)); err != nil {
+		return err
+	}
+	// Likewise a configured subnet must be in that AZ, and configured
+	// security groups in the subnet's VPC.
+	if !deps.network.isZero() {
+		if _, _, err := recreateNetwork(ctx, deps, found.AvailabilityZone); err != nil {
+			return err
+		}
+	}
+
 	// Active session detection — plain text, no spinner.
 	if verbose {
 		fmt.Fprintf(w, "Checking for active sessions on VM %q...\n", vmName)
 	}
 
-	activeSessions, err := detectActiveSessions(ctx, deps, found)
+	var activeSessions string
+	report, err := detectVMSessions(ctx, deps.remoteRun, deps.sendKey, found, sshPortOrDefault(deps.sshPort))
 	if err != nil {
 		// Non-fatal: if we can't detect sessions, warn but continue with
 		// confirmation. This avoids blocking recreate when SSH is flaky.
 		fmt.Fprintf(w, "Warning: could not detect active sessions: %v\n", err)
+	} else {
+		for _, note := range report.GuardNotes {
+			fmt.Fprintf(w, "Note: %s\n", note)
+		}
+		activeSessions = report.Summary()
 	}
 
 	if activeSessions != "" && !force {
@@ -224,10 +365,62 @@ func runRecreate(cmd *cobra.Command, deps *recreateDeps) error {
 		fmt.Fprintf(w, "Warning: proceeding despite active sessions on VM %q:\n%s\n\n", vmName, activeSessions)
 	}
 
+	// Capture the images the devcontainers were built from so the new
+	// instance can pull them in the background. Best effort: without the
+	// list the first project build just pulls them itself.
+	images, err := capturePrefetchImages(ctx, deps, found)
+	if err != nil && verbose {
+		fmt.Fprintf(w, "Could not list container images to prefetch: %v\n", err)
+	}
+	deps.prefetchImages = images
+	if verbose && len(images) > 0 {
+		fmt.Fprintf(w, "Captured %d container image(s) to prefetch on the new instance\n", len(images))
+	}
+
+	// The provisioning history file is on the root volume too; carry it
+	// over so the new instance's history goes back past this recreate.
+	history, err := readProvisionHistory(ctx, deps.remoteRun, deps.sendKey, found, sshPortOrDefault(deps.sshPort))
+	if err != nil && verbose {
+		fmt.Fprintf(w, "Could not read the provisioning history: %v\n", err)
+	}
+	deps.provisionHistory = lastProvisionHistory(history, provisionHistoryMax-1)
+
+	// Resolve the bootstrap source before confirming: a manifest that needs
+	// a newer CLI must fail while the old instance still exists.
+	src, err := resolveBootstrapSource(ctx, deps.resolveBootstrap, deps.bootstrapURL)
+	if err != nil {
+		return err
+	}
+	if src, err = bootstrapFileSource(cmd, src); err != nil {
+		return err
+	}
+	deps.bootstrapSource = src
+	if verbose {
+		fmt.Fprintf(w, "Bootstrap source: %s\n", src.Label)
+	}
+
+	// Take the lock before prompting, so a command already changing the VM
+	// refuses the recreate up front. The lock goes with the old instance
+	// once it is terminated.
+	release, err := lockVM(ctx, cmd, deps.locker, found, "recreate")
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Show what will happen.
 	fmt.Fprintf(w, "This will destroy and re-provision VM %q (%s).\n", vmName, found.ID)
 	fmt.Fprintf(w, "  - Instance %s will be terminated\n", found.ID)
 	fmt.Fprintf(w, "  - A new VM will be provisioned with the same configuration\n")
+	if newType != found.InstanceType {
+		fmt.Fprintf(w, "  - The instance type will change: %s → %s\n", found.InstanceType, newType)
+	}
+	if deps.spot {
+		fmt.Fprintf(w, "  - The new instance will be launched on the spot market\n")
+	}
+	if deps.ipMode == tags.IPModeIPv6Only {
+		fmt.Fprintf(w, "  - The VM's IPv6 address will change (ipv6-only VMs have no Elastic IP)\n")
+	}
 	fmt.Fprintf(w, "  - Project EBS volumes will be preserved if possible\n")
 
 	// Confirmation: require user to type VM name unless --yes is set.
@@ -244,6 +437,14 @@ func runRecreate(cmd *cobra.Command, deps *recreateDeps) error {
 		}
 	}
 
+	// If the recreate stops between terminating and launching, the mint up
+	// that finishes it launches with the captured images.
+	if deps.journal != nil && len(deps.prefetchImages) > 0 {
+		if err := deps.journal.SavePrefetch(deps.owner, vmName, deps.prefetchImages); err != nil && verbose {
+			fmt.Fprintf(w, "Could not save the prefetch list: %v\n", err)
+		}
+	}
+
 	// Spinner starts AFTER confirmation is obtained (follows destroy.go pattern).
 	sp := progress.NewCommandSpinner(w, false)
 	sp.Start("Starting recreate lifecycle...")
@@ -262,6 +463,13 @@ func runRecreate(cmd *cobra.Command, deps *recreateDeps) error {
 //  7. Attach project EBS + remove pending-attach tag
 //  8. Reassociate Elastic IP
 //  9. Poll for bootstrap complete
+//
+// Ctrl-C cancels ctx, and the lifecycle stops at the next safe boundary:
+// before step 2, after step 5, after step 6, or after step 8. Steps 2-5 run
+// as one unit so the volume is never detached without the pending-attach
+// tag, and every mutating step runs on a context the interrupt does not
+// cancel so no call is abandoned halfway. On an interrupt the returned
+// error describes the state left behind and how to finish.
 func executeRecreateLifecycle(
 	ctx context.Context,
 	deps *recreateDeps,
@@ -270,37 +478,75 @@ func executeRecreateLifecycle(
 	sp *progress.Spinner,
 	w io.Writer,
 ) error {
+	stepCtx := context.WithoutCancel(ctx)
+	started := time.Now()
+	interrupted := func(recovery string, args ...any) error {
+		sp.Stop("")
+		return &interruptedError{recovery: fmt.Sprintf(recovery, args...)}
+	}
+
 	volumeID, volumeAZ, err := stepQueryProjectVolume(ctx, deps, vmName, sp, w)
+	if ctx.Err() != nil {
+		return interrupted("Nothing was changed; VM %q (%s) is untouched.", vmName, found.ID)
+	}
 	if err != nil {
 		return fmt.Errorf("querying project volume: %w", err)
 	}
 
-	if err := stepTagPendingAttach(ctx, deps, volumeID, sp); err != nil {
+	if err := stepTagPendingAttach(stepCtx, deps, volumeID, sp); err != nil {
 		return fmt.Errorf("tagging project volume with pending-attach: %w", err)
 	}
 
-	if err := stepStopInstance(ctx, deps, found.ID, sp); err != nil {
+	if err := stepStopInstance(stepCtx, deps, found.ID, sp); err != nil {
 		return fmt.Errorf("stopping instance %s: %w", found.ID, err)
 	}
 
-	if err := stepDetachVolume(ctx, deps, volumeID, found.ID, sp); err != nil {
+	if err := stepDetachVolume(stepCtx, deps, volumeID, found.ID, sp); err != nil {
 		return fmt.Errorf("detaching project volume %s: %w", volumeID, err)
 	}
 
-	if err := stepTerminateInstance(ctx, deps, found.ID, sp); err != nil {
+	if err := stepTerminateInstance(stepCtx, deps, found.ID, sp); err != nil {
 		return fmt.Errorf("terminating instance %s: %w", found.ID, err)
 	}
+	cliCtx := cli.FromContext(ctx)
+	cliCtx.TouchResource(cli.ResourceInstance, found.ID)
+	cliCtx.TouchResource(cli.ResourceVolume, volumeID)
 
-	newInstanceID, err := stepLaunchInstance(ctx, deps, found, vmName, volumeAZ, sp)
+	if ctx.Err() != nil {
+		return interrupted("Instance %s was terminated. Project volume %s is tagged pending-attach — run %s to finish.",
+			found.ID, volumeID, hint.Cmd("mint up"))
+	}
+
+	newInstanceID, prefetchQueued, prefetchDropped, err := stepLaunchInstance(stepCtx, deps, found, vmName, volumeAZ, sp)
 	if err != nil {
 		return fmt.Errorf("launching new instance: %w", err)
 	}
+	cliCtx.TouchResource(cli.ResourceInstance, newInstanceID)
+
+	// With the old instance gone, a mint up that found no VM may have
+	// launched one too; the run with the younger instance gives way.
+	if deps.locker != nil {
+		if err := provision.ResolveLaunchRace(stepCtx, deps.describe, deps.terminate, deps.owner, vmName, newInstanceID); err != nil {
+			return err
+		}
+	}
+
+	launchedRecovery := func() error {
+		return interrupted("New instance %s was launched. Project volume %s is tagged pending-attach and not yet attached — run %s to finish.",
+			newInstanceID, volumeID, hint.Cmd("mint up"))
+	}
+	if ctx.Err() != nil {
+		return launchedRecovery()
+	}
 
 	if deps.waitRunning != nil {
 		sp.Update(fmt.Sprintf("  Waiting for instance %s to be running...", newInstanceID))
 		if err := deps.waitRunning.Wait(ctx, &ec2.DescribeInstancesInput{
 			InstanceIds: []string{newInstanceID},
 		}, 5*time.Minute); err != nil {
+			if ctx.Err() != nil {
+				return launchedRecovery()
+			}
 			return fmt.Errorf("waiting for instance %s to be running: %w", newInstanceID, err)
 		}
 	}
@@ -310,22 +556,86 @@ func executeRecreateLifecycle(
 		if err := deps.waitVolumeAvailable.Wait(ctx, &ec2.DescribeVolumesInput{
 			VolumeIds: []string{volumeID},
 		}, 5*time.Minute); err != nil {
+			if ctx.Err() != nil {
+				return launchedRecovery()
+			}
 			return fmt.Errorf("waiting for volume to become available: %w", err)
 		}
 	}
 
-	if err := stepAttachVolume(ctx, deps, volumeID, newInstanceID, sp, w); err != nil {
+	if ctx.Err() != nil {
+		return launchedRecovery()
+	}
+
+	// A volume prepared by mint snapshot restore --force replaces the
+	// project volume, which stays detached and is kept.
+	restoredID, err := adoptRestoredVolume(stepCtx, deps, vmName, volumeID, volumeAZ, w)
+	if err != nil {
+		return fmt.Errorf("swapping in restored volume: %w", err)
+	}
+	if restoredID != "" {
+		cliCtx.TouchResource(cli.ResourceVolume, restoredID)
+		volumeID = restoredID
+	}
+
+	if err := stepAttachVolume(stepCtx, deps, volumeID, newInstanceID, sp, w); err != nil {
 		return fmt.Errorf("attaching project volume %s to %s: %w", volumeID, newInstanceID, err)
 	}
 
-	newInstancePublicIP, err := stepReassociateEIP(ctx, deps, vmName, newInstanceID, sp, w)
+	newInstancePublicIP, allocID, err := stepReassociateEIP(stepCtx, deps, vmName, newInstanceID, sp, w)
 	if err != nil {
 		return fmt.Errorf("reassociating Elastic IP: %w", err)
 	}
 
-	if bootstrapErr := stepBootstrapPoll(ctx, deps, vmName, newInstanceID, sp); bootstrapErr != nil {
+	// From here on the VM is complete apart from bootstrap, which runs on
+	// the instance whether or not mint keeps polling.
+	bootstrappingRecovery := func() error {
+		if deps.removeHostKey != nil {
+			_ = deps.removeHostKey(vmName)
+		}
+		return interrupted("Instance %s has the project volume and Elastic IP; bootstrap is still running — check on it with %s.",
+			newInstanceID, hint.Cmd("mint status"))
+	}
+	if ctx.Err() != nil {
+		return bootstrappingRecovery()
+	}
+
+	// Until the association propagates, SSH to the address can still reach
+	// the terminated instance or nothing at all.
+	var eipWait time.Duration
+	if allocID != "" && deps.waitEIP != nil {
+		sp.Update(fmt.Sprintf("  Waiting for Elastic IP %s to reach %s...", newInstancePublicIP, newInstanceID))
+		eipWait, err = deps.waitEIP.Wait(ctx, allocID, newInstanceID, 2*time.Minute)
+		if err != nil {
+			if ctx.Err() != nil {
+				return bootstrappingRecovery()
+			}
+			return fmt.Errorf("reassociating Elastic IP: %w", err)
+		}
+	}
+
+	// A failed user hook leaves a usable VM: finish the recreate, then warn
+	// and exit with a dedicated code instead of reporting a bootstrap failure.
+	bootstrapErr := stepBootstrapPoll(ctx, deps, vmName, newInstanceID, sp)
+	if bootstrapErr != nil && ctx.Err() != nil {
+		return bootstrappingRecovery()
+	}
+	userHookExitCode, userHookFailed := userBootstrapExitCode(bootstrapErr)
+
+	// With --verbose, show where bootstrap spent its time, including the
+	// phase it failed in.
+	verbose := cliCtx != nil && cliCtx.Verbose
+	var timings []bootstrap.PhaseTiming
+	if verbose {
+		sp.Update("Reading bootstrap timings...")
+		timings = fetchBootstrapTimings(ctx, retryFirstConnection(deps.remoteRun), deps.sendKey,
+			newInstanceID, volumeAZ, newInstancePublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser)
+	}
+
+	if bootstrapErr != nil && !userHookFailed {
 		sp.Stop("")
-		printBootstrapFailureHint(w, bootstrapErr, newInstancePublicIP)
+		printBootstrapTimings(w, timings, recreatePhaseThreshold(deps))
+		printBootstrapFailureHint(w, bootstrapErr, newInstancePublicIP, sshPortOrDefault(deps.sshPort))
 		return silentExitError{}
 	}
 
@@ -337,16 +647,47 @@ func executeRecreateLifecycle(
 		}
 	}
 
+	sp.Update("Recording provisioning history...")
+	entry := newProvisionHistoryEntry(newInstanceID, provisionActionRecreate, started, time.Now())
+	historyErr := recordProvisioning(ctx, deps.createTags, retryFirstConnection(deps.remoteRun), deps.sendKey,
+		&vm.VM{ID: newInstanceID, AvailabilityZone: volumeAZ, PublicIP: newInstancePublicIP},
+		sshPortOrDefault(deps.sshPort), deps.owner, append(deps.provisionHistory, entry))
+
 	// Print the final success message to the command output unconditionally.
 	// sp.Stop clears the spinner line in interactive mode before we print.
 	sp.Stop("")
 	fmt.Fprintf(w, "Recreate complete. New instance: %s\n", newInstanceID)
+	warnProvisionHistory(w, historyErr)
+	if deps.sshConfigPath != "" {
+		warnStaleSSHConfig(w, deps.sshConfigPath, vmName, newInstancePublicIP, newInstanceID)
+	}
+	if verbose {
+		printEIPPropagation(w, eipWait)
+		printPrefetchSummary(w, prefetchQueued, prefetchDropped)
+		printBootstrapTimings(w, timings, recreatePhaseThreshold(deps))
+	}
+	if deps.spotWarning != "" {
+		fmt.Fprintf(w, "Warning: %s\n", deps.spotWarning)
+	}
+	if userHookFailed {
+		printUserBootstrapWarning(w, userHookExitCode, newInstancePublicIP, sshPortOrDefault(deps.sshPort))
+		return exitCodeError{code: exitCodeUserBootstrapFailed}
+	}
 	if deps.pollBootstrap != nil {
 		fmt.Fprintln(w, "\nBootstrap complete. VM is ready.")
 	}
 	return nil
 }
 
+// recreatePhaseThreshold returns the configured bootstrap_phase_threshold,
+// or the default when the config is not loaded (tests).
+func recreatePhaseThreshold(deps *recreateDeps) time.Duration {
+	if deps.mintConfig == nil {
+		return config.DefaultBootstrapPhaseThreshold
+	}
+	return deps.mintConfig.BootstrapPhaseThreshold
+}
+
 // stepQueryProjectVolume discovers the project EBS volume for the VM (Step 1/9).
 func stepQueryProjectVolume(
 	ctx context.Context,
@@ -434,24 +775,34 @@ func stepTerminateInstance(
 }
 
 // stepLaunchInstance launches a new EC2 instance in the same AZ as the project
-// volume (Step 6/9).
+// volume (Step 6/9). It also returns how many prefetch images were queued on
+// the new instance and how many did not fit in user-data.
 func stepLaunchInstance(
 	ctx context.Context,
 	deps *recreateDeps,
 	original *vm.VM,
 	vmName, volumeAZ string,
 	sp *progress.Spinner,
-) (string, error) {
-	sp.Update(fmt.Sprintf("Step 6/9: Launching new instance in %s...", volumeAZ))
+) (instanceID string, prefetchQueued, prefetchDropped int, err error) {
+	if newType := recreateInstanceType(deps, original); newType != original.InstanceType {
+		sp.Update(fmt.Sprintf("Step 6/9: Launching new instance (%s → %s) in %s...", original.InstanceType, newType, volumeAZ))
+	} else {
+		sp.Update(fmt.Sprintf("Step 6/9: Launching new instance in %s...", volumeAZ))
+	}
 
-	newInstanceID, err := launchRecreateInstance(ctx, deps, original, vmName, volumeAZ)
+	instanceID, prefetchQueued, prefetchDropped, err = launchRecreateInstance(ctx, deps, original, vmName, volumeAZ)
 	if err != nil {
-		return "", err
+		return "", 0, 0, err
+	}
+
+	// The new instance has the list; mint up no longer needs it.
+	if deps.journal != nil {
+		_ = deps.journal.RemovePrefetch(deps.owner, vmName)
 	}
 
-	sp.Update(fmt.Sprintf("  Launched new instance %s", newInstanceID))
+	sp.Update(fmt.Sprintf("  Launched new instance %s", instanceID))
 
-	return newInstanceID, nil
+	return instanceID, prefetchQueued, prefetchDropped, nil
 }
 
 // stepAttachVolume attaches the project EBS volume to the new instance and
@@ -492,20 +843,77 @@ func stepAttachVolume(
 	return nil
 }
 
+// adoptRestoredVolume looks for a volume mint snapshot restore --force
+// prepared for vmName. When there is one in the project volume's
+// availability zone, the detached project volume is retagged as replaced
+// and the restored volume becomes the project volume, still tagged
+// pending-attach until stepAttachVolume attaches it. It returns the restored
+// volume ID, or "" when there is nothing to swap in.
+func adoptRestoredVolume(
+	ctx context.Context,
+	deps *recreateDeps,
+	vmName, volumeID, volumeAZ string,
+	w io.Writer,
+) (string, error) {
+	restored, err := findRestoredVolume(ctx, deps.describeVolumes, deps.owner, vmName)
+	if err != nil || restored == nil {
+		return "", err
+	}
+	restoredID := aws.ToString(restored.VolumeId)
+	if az := aws.ToString(restored.AvailabilityZone); az != volumeAZ {
+		fmt.Fprintf(w, "Warning: restored volume %s is in %s, not %s — keeping project volume %s\n",
+			restoredID, az, volumeAZ, volumeID)
+		return "", nil
+	}
+
+	if err := retireProjectVolume(ctx, deps.createTags, deps.deleteTags, volumeID); err != nil {
+		return "", err
+	}
+	if err := adoptRestoredVolumeTags(ctx, deps.createTags, restoredID); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(w, "Swapping in restored volume %s; previous project volume %s is kept, detached.\n", restoredID, volumeID)
+	return restoredID, nil
+}
+
 // stepReassociateEIP reassociates the Elastic IP with the new instance (Step 8/9).
-// Returns the public IP address of the Elastic IP (empty string if none found).
+// Returns the public IP address and allocation ID of the Elastic IP (empty
+// strings if none found). An ipv6-only VM has no Elastic IP: its public
+// address is the new instance's IPv6 address.
 func stepReassociateEIP(
 	ctx context.Context,
 	deps *recreateDeps,
 	vmName, newInstanceID string,
 	sp *progress.Spinner,
 	w io.Writer,
-) (publicIP string, err error) {
+) (publicIP, allocID string, err error) {
+	if deps.ipMode == tags.IPModeIPv6Only {
+		sp.Update("Step 8/9: Reading IPv6 address...")
+		publicIP, err = newInstanceIPv6Address(ctx, deps, newInstanceID)
+		return publicIP, "", err
+	}
+
 	sp.Update("Step 8/9: Reassociating Elastic IP...")
 
 	return reassociateElasticIP(ctx, deps, vmName, newInstanceID, sp, w)
 }
 
+// newInstanceIPv6Address returns the IPv6 address of the new instance,
+// describing it when the RunInstances response did not report one.
+func newInstanceIPv6Address(ctx context.Context, deps *recreateDeps, newInstanceID string) (string, error) {
+	if deps.ipv6Address != "" {
+		return deps.ipv6Address, nil
+	}
+	found, err := vm.FindVMByID(ctx, deps.describe, newInstanceID)
+	if err != nil {
+		return "", fmt.Errorf("reading IPv6 address of %s: %w", newInstanceID, err)
+	}
+	if found == nil || found.IPv6Address == "" {
+		return "", fmt.Errorf("instance %s has no IPv6 address", newInstanceID)
+	}
+	return found.IPv6Address, nil
+}
+
 // stepBootstrapPoll waits for the bootstrap process to complete on the new
 // instance (Step 9/9).
 func stepBootstrapPoll(
@@ -553,8 +961,10 @@ func findProjectVolume(ctx context.Context, deps *recreateDeps, vmName string) (
 // fail (the VM still has an auto-assigned public IP). If association fails,
 // it returns an error.
 //
-// Returns the public IP of the Elastic IP (empty string when no EIP is found
-// or when describeAddrs is nil). This is used for the bootstrap failure hint.
+// Returns the public IP and allocation ID of the Elastic IP (empty strings
+// when no EIP is found or when describeAddrs is nil). The public IP is used
+// for the bootstrap failure hint, the allocation ID to wait for the
+// association to propagate.
 //
 // If the EIP has a stale AssociationId from the terminated instance's ENI,
 // DisassociateAddress is called explicitly before AssociateAddress. This
@@ -566,10 +976,10 @@ func reassociateElasticIP(
 	vmName, newInstanceID string,
 	sp *progress.Spinner,
 	w io.Writer,
-) (publicIP string, err error) {
+) (publicIP, allocID string, err error) {
 	if deps.describeAddrs == nil {
 		sp.Update("  Warning: no Elastic IP client configured, skipping EIP reassociation")
-		return "", nil
+		return "", "", nil
 	}
 
 	filters := append(
@@ -584,23 +994,23 @@ func reassociateElasticIP(
 		Filters: filters,
 	})
 	if err != nil {
-		return "", fmt.Errorf("discovering Elastic IP: %w", err)
+		return "", "", fmt.Errorf("discovering Elastic IP: %w", err)
 	}
 
 	if len(out.Addresses) == 0 {
 		sp.Update(fmt.Sprintf("  Warning: no Elastic IP found for VM %q — using auto-assigned public IP", vmName))
-		return "", nil
+		return "", "", nil
 	}
 
 	addr := out.Addresses[0]
-	allocID := aws.ToString(addr.AllocationId)
+	allocID = aws.ToString(addr.AllocationId)
 	eipPublicIP := aws.ToString(addr.PublicIp)
 
 	sp.Update(fmt.Sprintf("  Found Elastic IP %s (%s), reassociating with %s",
 		eipPublicIP, allocID, newInstanceID))
 
 	if deps.associateAddr == nil {
-		return "", fmt.Errorf("no AssociateAddress client configured")
+		return "", "", fmt.Errorf("no AssociateAddress client configured")
 	}
 
 	// If the EIP still carries a stale AssociationId from the terminated
@@ -610,13 +1020,13 @@ func reassociateElasticIP(
 	if aws.ToString(addr.AssociationId) != "" {
 		sp.Update(fmt.Sprintf("  Disassociating stale EIP association %s...", aws.ToString(addr.AssociationId)))
 		if deps.disassociateAddr == nil {
-			return "", fmt.Errorf("no DisassociateAddress client configured")
+			return "", "", fmt.Errorf("no DisassociateAddress client configured")
 		}
 		_, disassocErr := deps.disassociateAddr.DisassociateAddress(ctx, &ec2.DisassociateAddressInput{
 			AssociationId: addr.AssociationId,
 		})
 		if disassocErr != nil {
-			return "", fmt.Errorf("disassociating EIP: %w", disassocErr)
+			return "", "", fmt.Errorf("disassociating EIP: %w", disassocErr)
 		}
 	}
 
@@ -625,23 +1035,16 @@ func reassociateElasticIP(
 		InstanceId:   aws.String(newInstanceID),
 	})
 	if err != nil {
-		return "", fmt.Errorf("associating EIP %s with instance %s: %w", allocID, newInstanceID, err)
+		return "", "", fmt.Errorf("associating EIP %s with instance %s: %w", allocID, newInstanceID, err)
 	}
 
 	sp.Update("  Elastic IP reassociated successfully")
 
-	return eipPublicIP, nil
+	return eipPublicIP, allocID, nil
 }
 
-// launchRecreateInstance launches a new EC2 instance in the specified AZ,
-// reusing the same configuration as the original instance.
-func launchRecreateInstance(
-	ctx context.Context,
-	deps *recreateDeps,
-	original *vm.VM,
-	vmName, targetAZ string,
-) (string, error) {
-	// Resolve AMI.
+// resolveRecreateAMI resolves the AMI the new instance launches from.
+func resolveRecreateAMI(ctx context.Context, deps *recreateDeps) (string, error) {
 	resolveAMI := deps.resolveAMI
 	if resolveAMI == nil {
 		resolveAMI = mintaws.ResolveAMI
@@ -650,48 +1053,66 @@ func launchRecreateInstance(
 	if err != nil {
 		return "", fmt.Errorf("resolving AMI: %w", err)
 	}
+	return amiID, nil
+}
 
-	// Find user's security group.
-	userSGID, err := findRecreateSG(ctx, deps, deps.owner, tags.ComponentSecurityGroup)
-	if err != nil {
-		return "", fmt.Errorf("finding user security group: %w", err)
-	}
-
-	// Find admin EFS security group.
-	adminSGID, err := findRecreateAdminSG(ctx, deps)
+// launchRecreateInstance launches a new EC2 instance in the specified AZ,
+// reusing the same configuration as the original instance. The captured
+// prefetch images go into user-data as far as its size limit allows; the
+// number queued and dropped are returned with the instance ID.
+func launchRecreateInstance(
+	ctx context.Context,
+	deps *recreateDeps,
+	original *vm.VM,
+	vmName, targetAZ string,
+) (instanceID string, prefetchQueued, prefetchDropped int, err error) {
+	amiID, err := resolveRecreateAMI(ctx, deps)
 	if err != nil {
-		return "", fmt.Errorf("finding admin security group: %w", err)
+		return "", 0, 0, err
 	}
 
-	// Find a subnet in the target AZ.
-	subnetID, err := findSubnetInAZ(ctx, deps, targetAZ)
+	// Find the subnet in the target AZ and the security groups.
+	subnetID, sgIDs, err := recreateNetwork(ctx, deps, targetAZ)
 	if err != nil {
-		return "", fmt.Errorf("finding subnet in %s: %w", targetAZ, err)
+		return "", 0, 0, err
 	}
 
-	// Prepare bootstrap script.
+	// Prepare bootstrap script and verify it against the source the stub
+	// will pin.
 	bootstrapScript := deps.bootstrapScript
 	if deps.verifyBootstrap != nil {
 		if verifyErr := deps.verifyBootstrap(bootstrapScript); verifyErr != nil {
-			return "", fmt.Errorf("bootstrap verification failed: %w", verifyErr)
+			return "", 0, 0, fmt.Errorf("bootstrap verification failed: %w", verifyErr)
 		}
 	}
+	src := deps.bootstrapSource
+	if src.Label == "" {
+		src = bootstrap.EmbeddedSource(deps.bootstrapURL)
+	}
+	if err := bootstrap.VerifySource(src); err != nil {
+		return "", 0, 0, fmt.Errorf("bootstrap verification failed: %w", err)
+	}
 
 	// Determine instance type and volume config from original or config.
-	instanceType := ec2types.InstanceType(original.InstanceType)
+	instanceType := ec2types.InstanceType(recreateInstanceType(deps, original))
 	idleTimeout := 60
 	volumeSize := int32(50)
+	instanceProfile := config.DefaultInstanceProfile
+	sshPort := ""
 
 	if deps.mintConfig != nil {
-		if deps.mintConfig.InstanceType != "" {
-			instanceType = ec2types.InstanceType(deps.mintConfig.InstanceType)
-		}
 		if deps.mintConfig.IdleTimeoutMinutes > 0 {
 			idleTimeout = deps.mintConfig.IdleTimeoutMinutes
 		}
 		if deps.mintConfig.VolumeSizeGB > 0 {
 			volumeSize = int32(deps.mintConfig.VolumeSizeGB)
 		}
+		if deps.mintConfig.InstanceProfile != "" {
+			instanceProfile = deps.mintConfig.InstanceProfile
+		}
+		if deps.mintConfig.SSHPort > 0 {
+			sshPort = strconv.Itoa(deps.mintConfig.SSHPort)
+		}
 	}
 
 	// Discover admin EFS filesystem.
@@ -700,7 +1121,7 @@ func launchRecreateInstance(
 		var efsErr error
 		efsID, efsErr = discoverEFS(ctx, deps.describeFS)
 		if efsErr != nil {
-			return "", fmt.Errorf("discovering EFS: %w", efsErr)
+			return "", 0, 0, fmt.Errorf("discovering EFS: %w", efsErr)
 		}
 	}
 
@@ -711,27 +1132,45 @@ func launchRecreateInstance(
 	}
 
 	// Render the bootstrap stub with runtime values.
-	stub, renderErr := bootstrap.RenderStub(
-		bootstrap.ScriptSHA256,
-		deps.bootstrapURL,
-		efsID,
-		"/dev/xvdf",
-		vmName,
-		strconv.Itoa(idleTimeout),
-		userBootstrapB64,
-	)
+	render := func(images []string) ([]byte, error) {
+		return bootstrap.RenderStub(
+			src.SHA256,
+			src.URL,
+			src.Inline,
+			efsID,
+			"/dev/xvdf",
+			vmName,
+			strconv.Itoa(idleTimeout),
+			sshPort,
+			userBootstrapB64,
+			mintaws.IsGPUInstanceType(string(instanceType)),
+			images,
+		)
+	}
+	stub, renderErr := render(nil)
 	if renderErr != nil {
-		return "", fmt.Errorf("rendering bootstrap stub: %w", renderErr)
+		return "", 0, 0, provision.BootstrapRenderError(renderErr)
 	}
 
-	const maxUserDataBytes = 16384
-	if len(stub) > maxUserDataBytes {
-		return "", fmt.Errorf("user-bootstrap.sh too large: rendered user-data is %d bytes, max is %d (%d bytes over limit)",
-			len(stub), maxUserDataBytes, len(stub)-maxUserDataBytes)
+	if err := bootstrap.CheckUserDataSize(stub, src.Inline); err != nil {
+		return "", 0, 0, err
+	}
+
+	// Prefetch images only use the space the rest of user-data leaves.
+	prefetch, dropped := bootstrap.FitPrefetchImages(deps.prefetchImages, len(stub))
+	if len(prefetch) > 0 {
+		if stub, renderErr = render(prefetch); renderErr != nil {
+			return "", 0, 0, provision.BootstrapRenderError(renderErr)
+		}
 	}
 
 	userData := base64.StdEncoding.EncodeToString(stub)
 
+	rootVolumeGB := deps.rootVolumeGB
+	if rootVolumeGB == 0 {
+		rootVolumeGB = 200
+	}
+
 	// Build instance tags.
 	instanceTags := tags.NewTagBuilder(deps.owner, deps.ownerARN, vmName).
 		WithComponent(tags.ComponentInstance).
@@ -739,23 +1178,35 @@ func launchRecreateInstance(
 		Build()
 
 	instanceTags = append(instanceTags,
-		ec2types.Tag{Key: aws.String(tags.TagRootVolumeGB), Value: aws.String("200")},
+		ec2types.Tag{Key: aws.String(tags.TagRootVolumeGB), Value: aws.String(strconv.Itoa(int(rootVolumeGB)))},
 		ec2types.Tag{Key: aws.String(tags.TagProjectVolumeGB), Value: aws.String(strconv.Itoa(int(volumeSize)))},
+		ec2types.Tag{Key: aws.String(tags.TagBootstrapSource), Value: aws.String(src.Label)},
 	)
+	// The project volume is reattached as it is, so it keeps the throughput
+	// the original instance recorded.
+	if throughput := original.Tags[tags.TagProjectVolumeThroughput]; throughput != "" {
+		instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(tags.TagProjectVolumeThroughput), Value: aws.String(throughput)})
+	}
+	if deps.sshUser != "" {
+		instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(tags.TagSSHUser), Value: aws.String(deps.sshUser)})
+	}
+	if deps.ipMode != "" && deps.ipMode != tags.IPModeEIP {
+		instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(deps.ipMode)})
+	}
+	if instanceTags, err = tags.MergeExtra(instanceTags, recreateExtraTags(deps)); err != nil {
+		return "", 0, 0, err
+	}
 
 	input := &ec2.RunInstancesInput{
 		ImageId:      aws.String(amiID),
 		InstanceType: instanceType,
 		MinCount:     aws.Int32(1),
 		MaxCount:     aws.Int32(1),
-		SubnetId:     aws.String(subnetID),
-		SecurityGroupIds: []string{
-			userSGID,
-			adminSGID,
-		},
-		UserData: aws.String(userData),
+		SubnetId:         aws.String(subnetID),
+		SecurityGroupIds: sgIDs,
+		UserData:         aws.String(userData),
 		IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{
-			Name: aws.String("mint-instance-profile"),
+			Name: aws.String(instanceProfile),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
 			{
@@ -765,16 +1216,118 @@ func launchRecreateInstance(
 		},
 	}
 
+	if recreateHibernate(deps) {
+		input.HibernationOptions = &ec2types.HibernationOptionsRequest{Configured: aws.Bool(true)}
+	}
+
+	// The root volume matches mint up's: 200GB gp3, more with hibernation,
+	// encrypted with the configured KMS key or else the account's default
+	// EBS key. The project volume is reattached as it is.
+	rootEBS := &ec2types.EbsBlockDevice{
+		VolumeSize:          aws.Int32(rootVolumeGB),
+		VolumeType:          ec2types.VolumeTypeGp3,
+		DeleteOnTermination: aws.Bool(true),
+		Encrypted:           aws.Bool(true),
+	}
+	if deps.kmsKeyID != "" {
+		rootEBS.KmsKeyId = aws.String(deps.kmsKeyID)
+	}
+	input.BlockDeviceMappings = []ec2types.BlockDeviceMapping{
+		{DeviceName: aws.String(rootDeviceName), Ebs: rootEBS},
+	}
+
+	// An IPv6 address is requested on the primary network interface,
+	// which then carries the subnet and security groups.
+	if deps.ipMode != "" && deps.ipMode != tags.IPModeEIP {
+		input.NetworkInterfaces = []ec2types.InstanceNetworkInterfaceSpecification{
+			mintaws.IPv6NetworkInterface(subnetID, input.SecurityGroupIds, deps.ipMode != tags.IPModeIPv6Only),
+		}
+		input.SubnetId, input.SecurityGroupIds = nil, nil
+	}
+
+	if deps.spot {
+		input.InstanceMarketOptions = mintaws.SpotMarketOptions()
+		input.TagSpecifications[0].Tags = append(instanceTags[:len(instanceTags):len(instanceTags)],
+			ec2types.Tag{Key: aws.String(tags.TagSpot), Value: aws.String("true")})
+	}
+
 	out, err := deps.run.RunInstances(ctx, input)
+	if code := mintaws.SpotCapacityErrorCode(err); deps.spot && deps.spotFallback && code != "" {
+		deps.spotWarning = mintaws.SpotFallbackWarning(string(instanceType), code)
+		input.InstanceMarketOptions = nil
+		input.TagSpecifications[0].Tags = instanceTags
+		out, err = deps.run.RunInstances(ctx, input)
+	}
 	if err != nil {
-		return "", fmt.Errorf("run instances: %w", err)
+		return "", 0, 0, fmt.Errorf("run instances: %w", err)
 	}
 
 	if len(out.Instances) == 0 {
-		return "", fmt.Errorf("run instances returned no instances")
+		return "", 0, 0, fmt.Errorf("run instances returned no instances")
+	}
+	if input.NetworkInterfaces != nil {
+		deps.ipv6Address = mintaws.InstanceIPv6Address(out.Instances[0])
 	}
 
-	return aws.ToString(out.Instances[0].InstanceId), nil
+	return aws.ToString(out.Instances[0].InstanceId), len(prefetch), dropped, nil
+}
+
+// recreateInstanceType returns the instance type the new instance launches
+// as: --instance-type, else the configured instance_type, else the old
+// instance's.
+func recreateInstanceType(deps *recreateDeps, original *vm.VM) string {
+	if deps.instanceType != "" {
+		return deps.instanceType
+	}
+	if deps.mintConfig != nil && deps.mintConfig.InstanceType != "" {
+		return deps.mintConfig.InstanceType
+	}
+	return original.InstanceType
+}
+
+// recreateExtraTags returns the extra_tags the new instance is tagged with.
+func recreateExtraTags(deps *recreateDeps) map[string]string {
+	if deps.mintConfig == nil {
+		return nil
+	}
+	return deps.mintConfig.ExtraTags
+}
+
+// recreateHibernate reports whether the new instance is launched
+// hibernation-enabled: the VM's hibernate setting in config.toml.
+func recreateHibernate(deps *recreateDeps) bool {
+	return deps.mintConfig != nil && deps.mintConfig.Hibernate
+}
+
+// recreateNetwork returns the subnet in az the new instance launches in and
+// its security groups: the configured security_group_ids, checked against
+// the subnet's VPC, or else the user's and the admin EFS groups.
+func recreateNetwork(ctx context.Context, deps *recreateDeps, az string) (subnetID string, sgIDs []string, err error) {
+	sgIDs = deps.network.securityGroupIDs
+	if len(sgIDs) == 0 {
+		userSGID, err := findRecreateSG(ctx, deps, deps.owner, tags.ComponentSecurityGroup)
+		if err != nil {
+			return "", nil, fmt.Errorf("finding user security group: %w", err)
+		}
+		adminSGID, err := findRecreateAdminSG(ctx, deps)
+		if err != nil {
+			return "", nil, fmt.Errorf("finding admin security group: %w", err)
+		}
+		sgIDs = []string{userSGID, adminSGID}
+	}
+
+	subnet, err := findSubnetInAZ(ctx, deps, az)
+	if err != nil {
+		return "", nil, fmt.Errorf("finding subnet in %s: %w", az, err)
+	}
+	subnetID = aws.ToString(subnet.SubnetId)
+
+	if len(deps.network.securityGroupIDs) > 0 {
+		if err := mintaws.CheckSecurityGroups(ctx, deps.describeSGs, sgIDs, subnetID, aws.ToString(subnet.VpcId)); err != nil {
+			return "", nil, fmt.Errorf("security_group_ids: %w", err)
+		}
+	}
+	return subnetID, sgIDs, nil
 }
 
 // findRecreateSG discovers a security group by owner and component tags.
@@ -812,47 +1365,70 @@ func findRecreateAdminSG(ctx context.Context, deps *recreateDeps) (string, error
 	return aws.ToString(out.SecurityGroups[0].GroupId), nil
 }
 
-// findSubnetInAZ finds a default subnet in the specified AZ.
-func findSubnetInAZ(ctx context.Context, deps *recreateDeps, az string) (string, error) {
+// findSubnetInAZ returns the configured subnet_id, which must be in the
+// specified AZ, or else a default subnet there (a vpc_id subnet when that
+// is set). A VM with an IPv6 address needs one with an IPv6 CIDR block.
+func findSubnetInAZ(ctx context.Context, deps *recreateDeps, az string) (ec2types.Subnet, error) {
+	needsIPv6 := deps.ipMode == tags.IPModeDualStack || deps.ipMode == tags.IPModeIPv6Only
+	if id := deps.network.subnetID; id != "" {
+		subnet, err := mintaws.DescribeSubnet(ctx, deps.describeSubnets, id, deps.network.vpcID)
+		if err != nil {
+			return ec2types.Subnet{}, err
+		}
+		if got := aws.ToString(subnet.AvailabilityZone); got != az {
+			return ec2types.Subnet{}, fmt.Errorf("subnet_id %s is in %s, but the project volume is in %s — set subnet_id to a subnet in %s", id, got, az, az)
+		}
+		if needsIPv6 && !mintaws.SubnetHasIPv6(subnet) {
+			return ec2types.Subnet{}, fmt.Errorf("ip_mode %s needs a subnet with an IPv6 CIDR block, and subnet_id %s has none", deps.ipMode, id)
+		}
+		return subnet, nil
+	}
+
+	filter := ec2types.Filter{Name: aws.String("default-for-az"), Values: []string{"true"}}
+	if deps.network.vpcID != "" {
+		filter = ec2types.Filter{Name: aws.String("vpc-id"), Values: []string{deps.network.vpcID}}
+	}
 	out, err := deps.describeSubnets.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
 		Filters: []ec2types.Filter{
-			{Name: aws.String("default-for-az"), Values: []string{"true"}},
+			filter,
 			{Name: aws.String("availability-zone"), Values: []string{az}},
 		},
 	})
 	if err != nil {
-		return "", fmt.Errorf("describe subnets: %w", err)
+		return ec2types.Subnet{}, fmt.Errorf("describe subnets: %w", err)
+	}
+	if len(out.Subnets) == 0 && deps.network.vpcID != "" {
+		return ec2types.Subnet{}, fmt.Errorf("no subnet of vpc_id %s found in %s", deps.network.vpcID, az)
 	}
 	if len(out.Subnets) == 0 {
-		return "", fmt.Errorf("no default subnet found in %s", az)
+		return ec2types.Subnet{}, fmt.Errorf("no default subnet found in %s — set subnet_id to a subnet there", az)
 	}
-	return aws.ToString(out.Subnets[0].SubnetId), nil
-}
-
-// detectActiveSessions SSHs into the VM and checks all four ADR-0018 idle
-// detection criteria: tmux clients, SSH/mosh connections, claude processes
-// in containers, and manual extend timestamps. Returns a human-readable
-// summary of active sessions, or empty string if no active sessions found.
-func detectActiveSessions(ctx context.Context, deps *recreateDeps, found *vm.VM) (string, error) {
-	// Create a RemoteExecutor closure that adapts the recreateDeps' remoteRun
-	// to the simpler session.RemoteExecutor interface.
-	executor := func(ctx context.Context, command []string) ([]byte, error) {
-		return deps.remoteRun(
-			ctx,
-			deps.sendKey,
-			found.ID,
-			found.AvailabilityZone,
-			found.PublicIP,
-			defaultSSHPort,
-			defaultSSHUser,
-			command,
-		)
+	if needsIPv6 {
+		for _, subnet := range out.Subnets {
+			if mintaws.SubnetHasIPv6(subnet) {
+				return subnet, nil
+			}
+		}
+		return ec2types.Subnet{}, fmt.Errorf("ip_mode %s needs a subnet with an IPv6 CIDR block, and the default subnet in %s has none", deps.ipMode, az)
 	}
+	return out.Subnets[0], nil
+}
 
-	result, err := session.DetectActiveSessions(ctx, executor)
+// capturePrefetchImages lists the container images on the VM for the new
+// instance to prefetch, most recently built first.
+func capturePrefetchImages(ctx context.Context, deps *recreateDeps, found *vm.VM) ([]string, error) {
+	output, err := deps.remoteRun(
+		ctx,
+		deps.sendKey,
+		found.ID,
+		found.AvailabilityZone,
+		found.PublicIP,
+		sshPortOrDefault(deps.sshPort),
+		defaultSSHUser,
+		buildPrefetchCaptureCommand(),
+	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	return result.Summary(), nil
+	return parsePrefetchImages(output), nil
 }
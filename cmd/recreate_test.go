@@ -3,44 +3,36 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/provision"
-	"github.com/spf13/cobra"
+	"github.com/aws/smithy-go"
 )
 
 // ---------------------------------------------------------------------------
 // Inline mocks for recreate command tests
 // ---------------------------------------------------------------------------
 
-type mockRecreateDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockRecreateDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
-type mockRecreateSendSSHPublicKey struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-}
-
-func (m *mockRecreateSendSSHPublicKey) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	return m.output, m.err
-}
-
 // mockRecreateRemoteRunner returns a RemoteCommandRunner that yields different
 // output based on the command being run (tmux, who, docker, cat).
 type mockRecreateRemoteRunner struct {
@@ -54,6 +46,10 @@ type mockRecreateRemoteRunner struct {
 	dockerTopErr map[string]error
 	catExtendOut []byte
 	catExtendErr error
+	imagesOut    []byte // output of the prefetch capture command
+	guardsOut    []byte // output of the guard read command
+	historyOut   []byte   // the old instance's provisioning history file
+	historyWrite []string // provisioning history write commands run
 }
 
 func (m *mockRecreateRemoteRunner) run(
@@ -90,40 +86,24 @@ func (m *mockRecreateRemoteRunner) run(
 	if len(command) >= 2 && command[0] == "cat" && strings.Contains(command[1], "idle-extended-until") {
 		return m.catExtendOut, m.catExtendErr
 	}
+	if len(command) == 3 && command[0] == "sh" && strings.Contains(command[2], "docker image ls") {
+		return m.imagesOut, nil
+	}
+	if len(command) == 3 && command[0] == "sh" && strings.Contains(command[2], session.GuardDir) {
+		return m.guardsOut, nil
+	}
+	if len(command) == 1 && strings.HasPrefix(command[0], "cat "+provisionHistoryPath) {
+		return m.historyOut, nil
+	}
+	if len(command) == 1 && strings.HasPrefix(command[0], "printf") && strings.Contains(command[0], provisionHistoryPath) {
+		m.historyWrite = append(m.historyWrite, command[0])
+		return nil, nil
+	}
 	return nil, fmt.Errorf("unexpected command: %v", command)
 }
 
 // EIP mocks for Elastic IP reassociation.
 
-type mockDescribeAddresses struct {
-	output   *ec2.DescribeAddressesOutput
-	err      error
-	captured *ec2.DescribeAddressesInput
-	// capturedAll stores all calls in order.
-	capturedAll []*ec2.DescribeAddressesInput
-}
-
-func (m *mockDescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
-	m.capturedAll = append(m.capturedAll, params)
-	// captured tracks the first tag-filter call (no AllocationIds), which is what
-	// tests inspect to verify correct tag filters were passed.
-	if len(params.AllocationIds) == 0 {
-		m.captured = params
-	}
-	return m.output, m.err
-}
-
-type mockAssociateAddress struct {
-	output   *ec2.AssociateAddressOutput
-	err      error
-	captured *ec2.AssociateAddressInput
-}
-
-func (m *mockAssociateAddress) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
-	m.captured = params
-	return m.output, m.err
-}
-
 type mockDisassociateAddress struct {
 	output   *ec2.DisassociateAddressOutput
 	err      error
@@ -142,127 +122,6 @@ func (m *mockDisassociateAddress) DisassociateAddress(ctx context.Context, param
 
 // Lifecycle mocks for the 8-step recreate sequence.
 
-type mockDescribeVolumes struct {
-	output *ec2.DescribeVolumesOutput
-	err    error
-}
-
-func (m *mockDescribeVolumes) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
-	return m.output, m.err
-}
-
-type mockRecreateStopInstances struct {
-	output *ec2.StopInstancesOutput
-	err    error
-}
-
-func (m *mockRecreateStopInstances) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
-	return m.output, m.err
-}
-
-type mockTerminateInstances struct {
-	output *ec2.TerminateInstancesOutput
-	err    error
-}
-
-func (m *mockTerminateInstances) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
-	return m.output, m.err
-}
-
-type mockDetachVolume struct {
-	output *ec2.DetachVolumeOutput
-	err    error
-}
-
-func (m *mockDetachVolume) DetachVolume(ctx context.Context, params *ec2.DetachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DetachVolumeOutput, error) {
-	return m.output, m.err
-}
-
-type mockAttachVolume struct {
-	output *ec2.AttachVolumeOutput
-	err    error
-}
-
-func (m *mockAttachVolume) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
-	return m.output, m.err
-}
-
-type mockRunInstances struct {
-	output *ec2.RunInstancesOutput
-	err    error
-	// captured stores the last RunInstancesInput for assertions.
-	captured *ec2.RunInstancesInput
-}
-
-func (m *mockRunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
-	m.captured = params
-	return m.output, m.err
-}
-
-type mockCreateTags struct {
-	calls []*ec2.CreateTagsInput
-	err   error
-	// failOnCall makes the Nth call (1-indexed) return an error.
-	failOnCall int
-	callCount  int
-}
-
-func (m *mockCreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
-	m.callCount++
-	m.calls = append(m.calls, params)
-	if m.failOnCall > 0 && m.callCount == m.failOnCall {
-		return nil, m.err
-	}
-	if m.failOnCall == 0 && m.err != nil {
-		return nil, m.err
-	}
-	return &ec2.CreateTagsOutput{}, nil
-}
-
-type mockDeleteTags struct {
-	calls []*ec2.DeleteTagsInput
-	err   error
-}
-
-func (m *mockDeleteTags) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
-	m.calls = append(m.calls, params)
-	if m.err != nil {
-		return nil, m.err
-	}
-	return &ec2.DeleteTagsOutput{}, nil
-}
-
-type mockDescribeSubnets struct {
-	output *ec2.DescribeSubnetsOutput
-	err    error
-}
-
-func (m *mockDescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
-	return m.output, m.err
-}
-
-type mockDescribeSecurityGroups struct {
-	// outputs maps component tag values to their responses.
-	outputs map[string]*ec2.DescribeSecurityGroupsOutput
-	err     error
-}
-
-func (m *mockDescribeSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
-	if m.err != nil {
-		return nil, m.err
-	}
-	// Find which component is being queried.
-	for _, f := range params.Filters {
-		if aws.ToString(f.Name) == "tag:mint:component" && len(f.Values) > 0 {
-			if out, ok := m.outputs[f.Values[0]]; ok {
-				return out, nil
-			}
-		}
-	}
-	// Fallback: return empty.
-	return &ec2.DescribeSecurityGroupsOutput{}, nil
-}
-
 // ---------------------------------------------------------------------------
 // Helpers
 // ---------------------------------------------------------------------------
@@ -307,26 +166,6 @@ func makeInstanceWithState(id, vmName, owner string, state ec2types.InstanceStat
 	}
 }
 
-func newRecreateTestRoot(sub *cobra.Command) *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	root.AddCommand(sub)
-	return root
-}
-
 func noSessionsRunner() *mockRecreateRemoteRunner {
 	return &mockRecreateRemoteRunner{
 		tmuxOutput:   nil,
@@ -355,37 +194,37 @@ func activeSessionsRunner() *mockRecreateRemoteRunner {
 
 func defaultLifecycleMocks() lifecycleMocks {
 	return lifecycleMocks{
-		describeVolumes: &mockDescribeVolumes{
-			output: &ec2.DescribeVolumesOutput{
+		describeVolumes: &cmdtest.DescribeVolumes{
+			Output: &ec2.DescribeVolumesOutput{
 				Volumes: []ec2types.Volume{{
 					VolumeId:         aws.String("vol-proj123"),
 					AvailabilityZone: aws.String("us-east-1a"),
 				}},
 			},
 		},
-		stop:      &mockRecreateStopInstances{output: &ec2.StopInstancesOutput{}},
-		terminate: &mockTerminateInstances{output: &ec2.TerminateInstancesOutput{}},
-		detach:    &mockDetachVolume{output: &ec2.DetachVolumeOutput{}},
-		attach:    &mockAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		run: &mockRunInstances{
-			output: &ec2.RunInstancesOutput{
+		stop:      &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}},
+		terminate: &cmdtest.TerminateInstances{Output: &ec2.TerminateInstancesOutput{}},
+		detach:    &cmdtest.DetachVolume{Output: &ec2.DetachVolumeOutput{}},
+		attach:    &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		run: &cmdtest.RunInstances{
+			Output: &ec2.RunInstancesOutput{
 				Instances: []ec2types.Instance{{
 					InstanceId: aws.String("i-new789"),
 				}},
 			},
 		},
-		createTags: &mockCreateTags{},
-		deleteTags: &mockDeleteTags{},
-		subnets: &mockDescribeSubnets{
-			output: &ec2.DescribeSubnetsOutput{
+		createTags: &cmdtest.CreateTags{},
+		deleteTags: &cmdtest.DeleteTags{},
+		subnets: &cmdtest.DescribeSubnets{
+			Output: &ec2.DescribeSubnetsOutput{
 				Subnets: []ec2types.Subnet{{
 					SubnetId:         aws.String("subnet-abc"),
 					AvailabilityZone: aws.String("us-east-1a"),
 				}},
 			},
 		},
-		sgs: &mockDescribeSecurityGroups{
-			outputs: map[string]*ec2.DescribeSecurityGroupsOutput{
+		sgs: &cmdtest.DescribeSecurityGroups{
+			ByComponent: map[string]*ec2.DescribeSecurityGroupsOutput{
 				"security-group": {
 					SecurityGroups: []ec2types.SecurityGroup{{
 						GroupId: aws.String("sg-user123"),
@@ -398,29 +237,29 @@ func defaultLifecycleMocks() lifecycleMocks {
 				},
 			},
 		},
-		describeAddrs: &mockDescribeAddresses{
-			output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{}},
+		describeAddrs: &cmdtest.DescribeAddresses{
+			Output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{}},
 		},
-		associateAddr: &mockAssociateAddress{
-			output: &ec2.AssociateAddressOutput{},
+		associateAddr: &cmdtest.AssociateAddress{
+			Output: &ec2.AssociateAddressOutput{},
 		},
 		disassociateAddr: &mockDisassociateAddress{},
 	}
 }
 
 type lifecycleMocks struct {
-	describeVolumes  *mockDescribeVolumes
-	stop             *mockRecreateStopInstances
-	terminate        *mockTerminateInstances
-	detach           *mockDetachVolume
-	attach           *mockAttachVolume
-	run              *mockRunInstances
-	createTags       *mockCreateTags
-	deleteTags       *mockDeleteTags
-	subnets          *mockDescribeSubnets
-	sgs              *mockDescribeSecurityGroups
+	describeVolumes  *cmdtest.DescribeVolumes
+	stop             *cmdtest.StopInstances
+	terminate        *cmdtest.TerminateInstances
+	detach           *cmdtest.DetachVolume
+	attach           *cmdtest.AttachVolume
+	run              *cmdtest.RunInstances
+	createTags       *cmdtest.CreateTags
+	deleteTags       *cmdtest.DeleteTags
+	subnets          *cmdtest.DescribeSubnets
+	sgs              *cmdtest.DescribeSecurityGroups
 	describeAddrs    mintaws.DescribeAddressesAPI
-	associateAddr    *mockAssociateAddress
+	associateAddr    *cmdtest.AssociateAddress
 	disassociateAddr *mockDisassociateAddress
 }
 
@@ -428,25 +267,25 @@ func newHappyRecreateDeps(owner string) *recreateDeps {
 	runner := noSessionsRunner()
 	lm := defaultLifecycleMocks()
 	return &recreateDeps{
-		describe:        &mockRecreateDescribeInstances{output: makeRunningInstanceForRecreate("i-abc123", "default", owner, "1.2.3.4", "us-east-1a")},
-		sendKey:         &mockRecreateSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
-		remoteRun:       runner.run,
-		owner:           owner,
-		ownerARN:        "arn:aws:iam::123456789012:user/" + owner,
-		describeVolumes: lm.describeVolumes,
-		stop:            lm.stop,
-		terminate:       lm.terminate,
-		detachVolume:    lm.detach,
-		attachVolume:    lm.attach,
-		run:             lm.run,
-		createTags:      lm.createTags,
-		deleteTags:      lm.deleteTags,
-		describeSubnets: lm.subnets,
-		describeSGs:     lm.sgs,
+		describe:         &cmdtest.DescribeInstances{Output: makeRunningInstanceForRecreate("i-abc123", "default", owner, "1.2.3.4", "us-east-1a")},
+		sendKey:          &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
+		remoteRun:        runner.run,
+		owner:            owner,
+		ownerARN:         "arn:aws:iam::123456789012:user/" + owner,
+		describeVolumes:  lm.describeVolumes,
+		stop:             lm.stop,
+		terminate:        lm.terminate,
+		detachVolume:     lm.detach,
+		attachVolume:     lm.attach,
+		run:              lm.run,
+		createTags:       lm.createTags,
+		deleteTags:       lm.deleteTags,
+		describeSubnets:  lm.subnets,
+		describeSGs:      lm.sgs,
 		describeAddrs:    lm.describeAddrs,
 		associateAddr:    lm.associateAddr,
 		disassociateAddr: lm.disassociateAddr,
-		bootstrapScript: []byte("#!/bin/bash\necho hello"),
+		bootstrapScript:  []byte("#!/bin/bash\necho hello"),
 		resolveAMI: func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
 			return "ami-test123", nil
 		},
@@ -456,25 +295,25 @@ func newHappyRecreateDeps(owner string) *recreateDeps {
 func newHappyRecreateDepsWithMocks(owner string, lm lifecycleMocks) *recreateDeps {
 	runner := noSessionsRunner()
 	return &recreateDeps{
-		describe:        &mockRecreateDescribeInstances{output: makeRunningInstanceForRecreate("i-abc123", "default", owner, "1.2.3.4", "us-east-1a")},
-		sendKey:         &mockRecreateSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
-		remoteRun:       runner.run,
-		owner:           owner,
-		ownerARN:        "arn:aws:iam::123456789012:user/" + owner,
-		describeVolumes: lm.describeVolumes,
-		stop:            lm.stop,
-		terminate:       lm.terminate,
-		detachVolume:    lm.detach,
-		attachVolume:    lm.attach,
-		run:             lm.run,
-		createTags:      lm.createTags,
-		deleteTags:      lm.deleteTags,
-		describeSubnets: lm.subnets,
-		describeSGs:     lm.sgs,
+		describe:         &cmdtest.DescribeInstances{Output: makeRunningInstanceForRecreate("i-abc123", "default", owner, "1.2.3.4", "us-east-1a")},
+		sendKey:          &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
+		remoteRun:        runner.run,
+		owner:            owner,
+		ownerARN:         "arn:aws:iam::123456789012:user/" + owner,
+		describeVolumes:  lm.describeVolumes,
+		stop:             lm.stop,
+		terminate:        lm.terminate,
+		detachVolume:     lm.detach,
+		attachVolume:     lm.attach,
+		run:              lm.run,
+		createTags:       lm.createTags,
+		deleteTags:       lm.deleteTags,
+		describeSubnets:  lm.subnets,
+		describeSGs:      lm.sgs,
 		describeAddrs:    lm.describeAddrs,
 		associateAddr:    lm.associateAddr,
 		disassociateAddr: lm.disassociateAddr,
-		bootstrapScript: []byte("#!/bin/bash\necho hello"),
+		bootstrapScript:  []byte("#!/bin/bash\necho hello"),
 		resolveAMI: func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
 			return "ami-test123", nil
 		},
@@ -530,8 +369,8 @@ func TestRecreateCommand(t *testing.T) {
 			name: "VM not found returns error",
 			deps: func() *recreateDeps {
 				d := newHappyRecreateDeps("alice")
-				d.describe = &mockRecreateDescribeInstances{
-					output: &ec2.DescribeInstancesOutput{},
+				d.describe = &cmdtest.DescribeInstances{
+					Output: &ec2.DescribeInstancesOutput{},
 				}
 				return d
 			}(),
@@ -543,8 +382,8 @@ func TestRecreateCommand(t *testing.T) {
 			name: "VM in stopped state returns error",
 			deps: func() *recreateDeps {
 				d := newHappyRecreateDeps("alice")
-				d.describe = &mockRecreateDescribeInstances{
-					output: makeInstanceWithState("i-abc123", "default", "alice", ec2types.InstanceStateNameStopped),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeInstanceWithState("i-abc123", "default", "alice", ec2types.InstanceStateNameStopped),
 				}
 				return d
 			}(),
@@ -556,8 +395,8 @@ func TestRecreateCommand(t *testing.T) {
 			name: "VM in pending state returns error",
 			deps: func() *recreateDeps {
 				d := newHappyRecreateDeps("alice")
-				d.describe = &mockRecreateDescribeInstances{
-					output: makeInstanceWithState("i-abc123", "default", "alice", ec2types.InstanceStateNamePending),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeInstanceWithState("i-abc123", "default", "alice", ec2types.InstanceStateNamePending),
 				}
 				return d
 			}(),
@@ -588,12 +427,37 @@ func TestRecreateCommand(t *testing.T) {
 			args:       []string{"recreate", "--yes", "--force"},
 			wantOutput: []string{"Warning: proceeding despite active sessions", "Recreate complete"},
 		},
+		{
+			name: "active automation guard blocks without --force",
+			deps: func() *recreateDeps {
+				runner := noSessionsRunner()
+				runner.guardsOut = []byte(guardFile("nightly", "nightly build", time.Now().Add(time.Hour)))
+				d := newHappyRecreateDeps("alice")
+				d.remoteRun = runner.run
+				return d
+			}(),
+			args:           []string{"recreate", "--yes"},
+			wantErr:        true,
+			wantErrContain: `nightly: "nightly build" (owner ci`,
+		},
+		{
+			name: "expired automation guard is noted and ignored",
+			deps: func() *recreateDeps {
+				runner := noSessionsRunner()
+				runner.guardsOut = []byte(guardFile("old", "yesterday", time.Now().Add(-time.Hour)))
+				d := newHappyRecreateDeps("alice")
+				d.remoteRun = runner.run
+				return d
+			}(),
+			args:       []string{"recreate", "--yes"},
+			wantOutput: []string{`Note: guard "old" expired at`, "Recreate complete"},
+		},
 		{
 			name: "describe API error propagates",
 			deps: func() *recreateDeps {
 				d := newHappyRecreateDeps("alice")
-				d.describe = &mockRecreateDescribeInstances{
-					err: fmt.Errorf("API throttled"),
+				d.describe = &cmdtest.DescribeInstances{
+					Err: fmt.Errorf("API throttled"),
 				}
 				return d
 			}(),
@@ -625,25 +489,25 @@ func TestRecreateCommand(t *testing.T) {
 				runner := noSessionsRunner()
 				lm := defaultLifecycleMocks()
 				return &recreateDeps{
-					describe:        &mockRecreateDescribeInstances{output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
-					sendKey:         &mockRecreateSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
-					remoteRun:       runner.run,
-					owner:           "bob",
-					ownerARN:        "arn:aws:iam::123456789012:user/bob",
-					describeVolumes: lm.describeVolumes,
-					stop:            lm.stop,
-					terminate:       lm.terminate,
-					detachVolume:    lm.detach,
-					attachVolume:    lm.attach,
-					run:             lm.run,
-					createTags:      lm.createTags,
-					deleteTags:      lm.deleteTags,
-					describeSubnets: lm.subnets,
-					describeSGs:     lm.sgs,
+					describe:         &cmdtest.DescribeInstances{Output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
+					sendKey:          &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
+					remoteRun:        runner.run,
+					owner:            "bob",
+					ownerARN:         "arn:aws:iam::123456789012:user/bob",
+					describeVolumes:  lm.describeVolumes,
+					stop:             lm.stop,
+					terminate:        lm.terminate,
+					detachVolume:     lm.detach,
+					attachVolume:     lm.attach,
+					run:              lm.run,
+					createTags:       lm.createTags,
+					deleteTags:       lm.deleteTags,
+					describeSubnets:  lm.subnets,
+					describeSGs:      lm.sgs,
 					describeAddrs:    lm.describeAddrs,
 					associateAddr:    lm.associateAddr,
 					disassociateAddr: lm.disassociateAddr,
-					bootstrapScript: []byte("#!/bin/bash\necho hello"),
+					bootstrapScript:  []byte("#!/bin/bash\necho hello"),
 					resolveAMI: func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
 						return "ami-test123", nil
 					},
@@ -658,25 +522,25 @@ func TestRecreateCommand(t *testing.T) {
 				runner := noSessionsRunner()
 				lm := defaultLifecycleMocks()
 				return &recreateDeps{
-					describe:        &mockRecreateDescribeInstances{output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
-					sendKey:         &mockRecreateSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
-					remoteRun:       runner.run,
-					owner:           "bob",
-					ownerARN:        "arn:aws:iam::123456789012:user/bob",
-					describeVolumes: lm.describeVolumes,
-					stop:            lm.stop,
-					terminate:       lm.terminate,
-					detachVolume:    lm.detach,
-					attachVolume:    lm.attach,
-					run:             lm.run,
-					createTags:      lm.createTags,
-					deleteTags:      lm.deleteTags,
-					describeSubnets: lm.subnets,
-					describeSGs:     lm.sgs,
+					describe:         &cmdtest.DescribeInstances{Output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
+					sendKey:          &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
+					remoteRun:        runner.run,
+					owner:            "bob",
+					ownerARN:         "arn:aws:iam::123456789012:user/bob",
+					describeVolumes:  lm.describeVolumes,
+					stop:             lm.stop,
+					terminate:        lm.terminate,
+					detachVolume:     lm.detach,
+					attachVolume:     lm.attach,
+					run:              lm.run,
+					createTags:       lm.createTags,
+					deleteTags:       lm.deleteTags,
+					describeSubnets:  lm.subnets,
+					describeSGs:      lm.sgs,
 					describeAddrs:    lm.describeAddrs,
 					associateAddr:    lm.associateAddr,
 					disassociateAddr: lm.disassociateAddr,
-					bootstrapScript: []byte("#!/bin/bash\necho hello"),
+					bootstrapScript:  []byte("#!/bin/bash\necho hello"),
 					resolveAMI: func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
 						return "ami-test123", nil
 					},
@@ -723,7 +587,7 @@ func TestRecreateCommand(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := new(bytes.Buffer)
 			cmd := newRecreateCommandWithDeps(tt.deps)
-			root := newRecreateTestRoot(cmd)
+			root := cmdtest.NewRoot(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
 
@@ -770,6 +634,182 @@ func TestRecreateForceFlag(t *testing.T) {
 	}
 }
 
+func TestRecreateTypeNotOfferedFailsBeforeStop(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = &config.Config{InstanceType: "m6i.xlarge"}
+	offerings := &mockTypeOfferings{byAZ: map[string][]string{"us-east-1a": {"m6i.2xlarge", "t3.medium"}}}
+	deps.checkOffering = mintaws.NewInstanceTypeOfferings(offerings).CheckAZ
+
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes"})
+	err := root.Execute()
+	want := "checking instance type: instance type m6i.xlarge is not offered in us-east-1a — did you mean m6i.2xlarge?"
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	if lm.stop.Called || lm.run.Input() != nil {
+		t.Error("recreate changed the VM despite the unoffered type")
+	}
+
+	// Without a configured type, the old instance's type is checked.
+	deps = newHappyRecreateDepsWithMocks("alice", defaultLifecycleMocks())
+	deps.checkOffering = mintaws.NewInstanceTypeOfferings(offerings).CheckAZ
+	root = cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("t3.medium is offered: %v", err)
+	}
+}
+
+func TestRecreateGPUInstanceType(t *testing.T) {
+	image := func(arch ec2types.ArchitectureValues) *cmdtest.DescribeImages {
+		return &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+			ImageId:      aws.String("ami-test123"),
+			Architecture: arch,
+			Name:         aws.String("ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-" + string(arch) + "-server-20260218"),
+		}}}}
+	}
+
+	// An image the NVIDIA driver cannot be installed on fails before the
+	// old instance stops.
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.describeImages = image(ec2types.ArchitectureValuesArm64)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--instance-type", "g5.xlarge"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "GPU instance type g5.xlarge needs an x86_64 Ubuntu AMI") {
+		t.Fatalf("error = %v, want the GPU image error", err)
+	}
+	if lm.stop.Called || lm.run.Input() != nil {
+		t.Error("recreate changed the VM despite the unsuitable image")
+	}
+
+	lm = defaultLifecycleMocks()
+	deps = newHappyRecreateDepsWithMocks("alice", lm)
+	deps.describeImages = image(ec2types.ArchitectureValuesX8664)
+	root = cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--instance-type", "g5.xlarge"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(lm.run.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if !strings.Contains(string(ud), `MINT_GPU="true"`) {
+		t.Errorf("UserData missing MINT_GPU=\"true\":\n%s", ud)
+	}
+}
+
+func TestRecreateHibernate(t *testing.T) {
+	types := &mockResizeDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: []ec2types.InstanceTypeInfo{
+			{
+				InstanceType:         ec2types.InstanceTypeM6i2xlarge,
+				HibernationSupported: aws.Bool(true),
+				MemoryInfo:           &ec2types.MemoryInfo{SizeInMiB: aws.Int64(32768)},
+			},
+		},
+	}}
+
+	// A type that cannot hibernate fails before the old instance stops.
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = &config.Config{Hibernate: true}
+	deps.describeTypes = types
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--instance-type", "x2iedn.32xlarge"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "hibernation is not supported by instance type(s) x2iedn.32xlarge") {
+		t.Fatalf("error = %v, want the unsupported hibernation error", err)
+	}
+	if lm.stop.Called || lm.run.Input() != nil {
+		t.Error("recreate changed the VM despite the unsupported type")
+	}
+
+	lm = defaultLifecycleMocks()
+	deps = newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = &config.Config{Hibernate: true}
+	deps.describeTypes = types
+	root = cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--instance-type", "m6i.2xlarge"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	input := lm.run.Input()
+	if input.HibernationOptions == nil || !aws.ToBool(input.HibernationOptions.Configured) {
+		t.Errorf("HibernationOptions = %+v, want Configured true", input.HibernationOptions)
+	}
+	rootEBS := input.BlockDeviceMappings[0].Ebs
+	if got := aws.ToInt32(rootEBS.VolumeSize); got != 232 {
+		t.Errorf("root VolumeSize = %d, want 232 (200 + 32 GiB of RAM)", got)
+	}
+	if !aws.ToBool(rootEBS.Encrypted) {
+		t.Error("root volume not encrypted")
+	}
+}
+
+func TestRecreateInstanceTypeFlag(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = &config.Config{InstanceType: "m6i.xlarge"}
+	var checked []string
+	deps.checkType = func(ctx context.Context, instanceType string) (string, error) {
+		checked = append(checked, instanceType)
+		return "", nil
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--verbose", "--instance-type", "m6i.2xlarge"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if got := lm.run.Input().InstanceType; got != "m6i.2xlarge" {
+		t.Errorf("InstanceType = %s, want m6i.2xlarge", got)
+	}
+	if !slices.Equal(checked, []string{"m6i.2xlarge"}) {
+		t.Errorf("validated %v, want [m6i.2xlarge]", checked)
+	}
+	for _, want := range []string{
+		"The instance type will change: t3.medium → m6i.2xlarge",
+		"Step 6/9: Launching new instance (t3.medium → m6i.2xlarge) in us-east-1a",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRecreateInvalidInstanceTypeFailsBeforeStop(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.checkType = func(ctx context.Context, instanceType string) (string, error) {
+		return "", fmt.Errorf("instance type %q is not available in us-east-1", instanceType)
+	}
+
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--instance-type", "m6i.huge"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid instance type") {
+		t.Fatalf("error = %v, want invalid instance type", err)
+	}
+	if lm.stop.Called || lm.run.Input() != nil {
+		t.Error("recreate changed the VM despite the invalid type")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests — Lifecycle (8-step recreate sequence)
 // ---------------------------------------------------------------------------
@@ -780,7 +820,7 @@ func TestRecreateLifecycleHappyPath(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -801,12 +841,12 @@ func TestRecreateLifecycleHappyPath(t *testing.T) {
 	}
 
 	// Verify pending-attach tag was set via CreateTags then cleared via DeleteTags.
-	if len(lm.createTags.calls) < 1 {
-		t.Fatalf("expected at least 1 CreateTags call, got %d", len(lm.createTags.calls))
+	if len(lm.createTags.Inputs) < 1 {
+		t.Fatalf("expected at least 1 CreateTags call, got %d", len(lm.createTags.Inputs))
 	}
 
 	// First CreateTags call: set pending-attach=true on the volume.
-	setCall := lm.createTags.calls[0]
+	setCall := lm.createTags.Inputs[0]
 	if len(setCall.Resources) != 1 || setCall.Resources[0] != "vol-proj123" {
 		t.Errorf("pending-attach set call resource = %v, want [vol-proj123]", setCall.Resources)
 	}
@@ -821,10 +861,10 @@ func TestRecreateLifecycleHappyPath(t *testing.T) {
 	}
 
 	// DeleteTags call: remove pending-attach tag key entirely.
-	if len(lm.deleteTags.calls) < 1 {
-		t.Fatalf("expected at least 1 DeleteTags call, got %d", len(lm.deleteTags.calls))
+	if len(lm.deleteTags.Inputs) < 1 {
+		t.Fatalf("expected at least 1 DeleteTags call, got %d", len(lm.deleteTags.Inputs))
 	}
-	delCall := lm.deleteTags.calls[0]
+	delCall := lm.deleteTags.Inputs[0]
 	if len(delCall.Resources) != 1 || delCall.Resources[0] != "vol-proj123" {
 		t.Errorf("pending-attach delete call resource = %v, want [vol-proj123]", delCall.Resources)
 	}
@@ -839,24 +879,24 @@ func TestRecreateLifecycleHappyPath(t *testing.T) {
 	}
 
 	// Verify the RunInstances input has correct AZ (via subnet in same AZ).
-	if lm.run.captured == nil {
+	if lm.run.Input() == nil {
 		t.Fatal("RunInstances was not called")
 	}
-	if aws.ToString(lm.run.captured.SubnetId) != "subnet-abc" {
-		t.Errorf("RunInstances subnet = %q, want %q", aws.ToString(lm.run.captured.SubnetId), "subnet-abc")
+	if aws.ToString(lm.run.Input().SubnetId) != "subnet-abc" {
+		t.Errorf("RunInstances subnet = %q, want %q", aws.ToString(lm.run.Input().SubnetId), "subnet-abc")
 	}
 }
 
 func TestRecreateLifecycleVolumeNotFound(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.describeVolumes = &mockDescribeVolumes{
-		output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{}},
+	lm.describeVolumes = &cmdtest.DescribeVolumes{
+		Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{}},
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -872,14 +912,14 @@ func TestRecreateLifecycleVolumeNotFound(t *testing.T) {
 
 func TestRecreateLifecycleDescribeVolumesFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.describeVolumes = &mockDescribeVolumes{
-		err: fmt.Errorf("describe volumes throttled"),
+	lm.describeVolumes = &cmdtest.DescribeVolumes{
+		Err: fmt.Errorf("describe volumes throttled"),
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -895,12 +935,12 @@ func TestRecreateLifecycleDescribeVolumesFails(t *testing.T) {
 
 func TestRecreateLifecycleStopFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.stop = &mockRecreateStopInstances{err: fmt.Errorf("stop instance timeout")}
+	lm.stop = &cmdtest.StopInstances{Err: fmt.Errorf("stop instance timeout")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -913,19 +953,19 @@ func TestRecreateLifecycleStopFails(t *testing.T) {
 		t.Errorf("error %q does not contain 'stopping instance'", err.Error())
 	}
 	// Verify pending-attach was already set before the failure.
-	if len(lm.createTags.calls) < 1 {
+	if len(lm.createTags.Inputs) < 1 {
 		t.Error("pending-attach tag should have been set before stop failed")
 	}
 }
 
 func TestRecreateLifecycleDetachFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.detach = &mockDetachVolume{err: fmt.Errorf("volume still in-use")}
+	lm.detach = &cmdtest.DetachVolume{Err: fmt.Errorf("volume still in-use")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -941,12 +981,12 @@ func TestRecreateLifecycleDetachFails(t *testing.T) {
 
 func TestRecreateLifecycleTerminateFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.terminate = &mockTerminateInstances{err: fmt.Errorf("terminate denied")}
+	lm.terminate = &cmdtest.TerminateInstances{Err: fmt.Errorf("terminate denied")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -962,12 +1002,12 @@ func TestRecreateLifecycleTerminateFails(t *testing.T) {
 
 func TestRecreateLifecycleLaunchFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.run = &mockRunInstances{err: fmt.Errorf("insufficient capacity")}
+	lm.run = &cmdtest.RunInstances{Err: fmt.Errorf("insufficient capacity")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -983,12 +1023,12 @@ func TestRecreateLifecycleLaunchFails(t *testing.T) {
 
 func TestRecreateLifecycleAttachFails(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.attach = &mockAttachVolume{err: fmt.Errorf("attach volume conflict")}
+	lm.attach = &cmdtest.AttachVolume{Err: fmt.Errorf("attach volume conflict")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1006,12 +1046,12 @@ func TestRecreateLifecyclePendingAttachTagSetBeforeStop(t *testing.T) {
 	// Verify that the pending-attach tag is set BEFORE the stop call.
 	// If stop fails, the pending-attach tag should already be in place.
 	lm := defaultLifecycleMocks()
-	lm.stop = &mockRecreateStopInstances{err: fmt.Errorf("stop failed")}
+	lm.stop = &cmdtest.StopInstances{Err: fmt.Errorf("stop failed")}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1019,10 +1059,10 @@ func TestRecreateLifecyclePendingAttachTagSetBeforeStop(t *testing.T) {
 	_ = root.Execute() // Will fail at stop step.
 
 	// The CreateTags call for pending-attach should have happened.
-	if len(lm.createTags.calls) < 1 {
+	if len(lm.createTags.Inputs) < 1 {
 		t.Fatal("expected CreateTags call for pending-attach before stop")
 	}
-	pendingCall := lm.createTags.calls[0]
+	pendingCall := lm.createTags.Inputs[0]
 	foundPending := false
 	for _, tag := range pendingCall.Tags {
 		if aws.ToString(tag.Key) == "mint:pending-attach" {
@@ -1040,7 +1080,7 @@ func TestRecreateLifecyclePendingAttachTagRemovedAfterAttach(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1051,11 +1091,11 @@ func TestRecreateLifecyclePendingAttachTagRemovedAfterAttach(t *testing.T) {
 	}
 
 	// Verify the DeleteTags call removes the pending-attach tag.
-	if len(lm.deleteTags.calls) < 1 {
-		t.Fatalf("expected at least 1 DeleteTags call, got %d", len(lm.deleteTags.calls))
+	if len(lm.deleteTags.Inputs) < 1 {
+		t.Fatalf("expected at least 1 DeleteTags call, got %d", len(lm.deleteTags.Inputs))
 	}
 
-	delCall := lm.deleteTags.calls[0]
+	delCall := lm.deleteTags.Inputs[0]
 	foundClear := false
 	for _, tag := range delCall.Tags {
 		if aws.ToString(tag.Key) == "mint:pending-attach" {
@@ -1070,16 +1110,16 @@ func TestRecreateLifecyclePendingAttachTagRemovedAfterAttach(t *testing.T) {
 func TestRecreateLifecycleSameAZ(t *testing.T) {
 	// Verify the new instance is launched in the same AZ as the project volume.
 	lm := defaultLifecycleMocks()
-	lm.describeVolumes = &mockDescribeVolumes{
-		output: &ec2.DescribeVolumesOutput{
+	lm.describeVolumes = &cmdtest.DescribeVolumes{
+		Output: &ec2.DescribeVolumesOutput{
 			Volumes: []ec2types.Volume{{
 				VolumeId:         aws.String("vol-proj123"),
 				AvailabilityZone: aws.String("us-west-2b"),
 			}},
 		},
 	}
-	lm.subnets = &mockDescribeSubnets{
-		output: &ec2.DescribeSubnetsOutput{
+	lm.subnets = &cmdtest.DescribeSubnets{
+		Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{
 				SubnetId:         aws.String("subnet-west2b"),
 				AvailabilityZone: aws.String("us-west-2b"),
@@ -1090,7 +1130,7 @@ func TestRecreateLifecycleSameAZ(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1100,11 +1140,125 @@ func TestRecreateLifecycleSameAZ(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if lm.run.captured == nil {
+	if lm.run.Input() == nil {
 		t.Fatal("RunInstances was not called")
 	}
-	if aws.ToString(lm.run.captured.SubnetId) != "subnet-west2b" {
-		t.Errorf("RunInstances subnet = %q, want %q (same AZ as volume)", aws.ToString(lm.run.captured.SubnetId), "subnet-west2b")
+	if aws.ToString(lm.run.Input().SubnetId) != "subnet-west2b" {
+		t.Errorf("RunInstances subnet = %q, want %q (same AZ as volume)", aws.ToString(lm.run.Input().SubnetId), "subnet-west2b")
+	}
+}
+
+func TestRecreateLifecycleEncryptsRootVolume(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		wantKey string
+	}{
+		{name: "default key", args: nil, wantKey: ""},
+		{name: "customer key", args: []string{"--kms-key-id", "alias/mint"}, wantKey: "alias/mint"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lm := defaultLifecycleMocks()
+			deps := newHappyRecreateDepsWithMocks("alice", lm)
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"recreate", "--yes"}, tc.args...))
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+			}
+			if lm.run.Input() == nil {
+				t.Fatal("RunInstances was not called")
+			}
+			bdms := lm.run.Input().BlockDeviceMappings
+			if len(bdms) != 1 || aws.ToString(bdms[0].DeviceName) != "/dev/sda1" || bdms[0].Ebs == nil {
+				t.Fatalf("BlockDeviceMappings = %+v, want one root mapping", bdms)
+			}
+			ebs := bdms[0].Ebs
+			if !aws.ToBool(ebs.Encrypted) {
+				t.Error("root volume should be encrypted")
+			}
+			if got := aws.ToString(ebs.KmsKeyId); got != tc.wantKey {
+				t.Errorf("KmsKeyId = %q, want %q", got, tc.wantKey)
+			}
+			if aws.ToInt32(ebs.VolumeSize) != 200 {
+				t.Errorf("VolumeSize = %d, want 200", aws.ToInt32(ebs.VolumeSize))
+			}
+		})
+	}
+}
+
+func TestRecreateLifecycleUsesConfiguredProfileAndPort(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = &config.Config{InstanceProfile: "team-mint-profile", SSHPort: 2222}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if lm.run.Input() == nil {
+		t.Fatal("RunInstances was not called")
+	}
+	if got := aws.ToString(lm.run.Input().IamInstanceProfile.Name); got != "team-mint-profile" {
+		t.Errorf("IamInstanceProfile.Name = %q, want team-mint-profile", got)
+	}
+	userData, err := base64.StdEncoding.DecodeString(aws.ToString(lm.run.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if !strings.Contains(string(userData), `MINT_SSH_PORT="2222"`) {
+		t.Errorf("UserData should render the configured port:\n%s", userData)
+	}
+}
+
+func TestRecreateBootstrapFileEmbedsScript(t *testing.T) {
+	path, src := writeBootstrapFile(t, "#!/bin/bash\necho bootstrap\n")
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.resolveBootstrap = func(ctx context.Context) (bootstrap.Source, error) { return src, nil }
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--bootstrap-file", path})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if lm.run.Input() == nil {
+		t.Fatal("RunInstances was not called")
+	}
+	userData, err := base64.StdEncoding.DecodeString(aws.ToString(lm.run.Input().UserData))
+	if err != nil {
+		t.Fatalf("UserData is not valid base64: %v", err)
+	}
+	if strings.Contains(string(userData), `_STUB_INLINE=""`) || !strings.Contains(string(userData), src.SHA256) {
+		t.Errorf("UserData should embed the script and pin its hash:\n%s", userData)
+	}
+}
+
+func TestRecreateRejectsInvalidKMSKeyID(t *testing.T) {
+	deps := newHappyRecreateDepsWithMocks("alice", defaultLifecycleMocks())
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--kms-key-id", "not a key"})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--kms-key-id") {
+		t.Fatalf("expected a --kms-key-id error, got %v", err)
 	}
 }
 
@@ -1113,7 +1267,7 @@ func TestRecreateLifecycleVerboseOutput(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes", "--verbose"})
@@ -1154,7 +1308,7 @@ func TestRecreateLifecycleBootstrapPollError(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1186,6 +1340,72 @@ func TestRecreateLifecycleBootstrapPollError(t *testing.T) {
 	}
 }
 
+func TestRecreateLifecycleUserBootstrapFailed(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.pollBootstrap = func(ctx context.Context, owner, vmName, instanceID string) error {
+		return &provision.UserBootstrapError{InstanceID: instanceID, ExitCode: 2}
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newRecreateCommandWithDeps(deps)
+	root := cmdtest.NewRoot(cmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+
+	err := root.Execute()
+	if err == nil {
+		t.Fatal("expected an exit-code error when the user hook failed, got nil")
+	}
+	if code := ExitCode(err); code != exitCodeUserBootstrapFailed {
+		t.Errorf("ExitCode = %d, want %d", code, exitCodeUserBootstrapFailed)
+	}
+
+	output := buf.String()
+	// The recreate itself succeeded; the hook failure is a warning.
+	if !strings.Contains(output, "Recreate complete") {
+		t.Errorf("output must report recreate completion, got:\n%s", output)
+	}
+	if !strings.Contains(output, "user hook: failed (exit 2)") {
+		t.Errorf("output must report the user hook failure, got:\n%s", output)
+	}
+	if strings.Contains(output, "Bootstrap failed") {
+		t.Errorf("user hook failure must not be reported as a bootstrap failure, got:\n%s", output)
+	}
+}
+
+func TestRecreateLifecycleBootstrapTimeout(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.pollBootstrap = func(ctx context.Context, owner, vmName, instanceID string) error {
+		return &provision.BootstrapTimeoutError{InstanceID: instanceID, Elapsed: 15 * time.Minute, LastStatus: "pending"}
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := newRecreateCommandWithDeps(deps)
+	root := cmdtest.NewRoot(cmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected an error when bootstrap timed out, got nil")
+	}
+
+	output := buf.String()
+	// A timeout may still finish, so it points at status --watch instead of
+	// suggesting another rebuild.
+	for _, want := range []string{"may still be bootstrapping", "mint status --watch"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "mint recreate") {
+		t.Errorf("timeout must not suggest mint recreate, got:\n%s", output)
+	}
+}
+
 func TestRecreateLifecycleBootstrapPollSuccess(t *testing.T) {
 	lm := defaultLifecycleMocks()
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
@@ -1200,7 +1420,7 @@ func TestRecreateLifecycleBootstrapPollSuccess(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1221,18 +1441,57 @@ func TestRecreateLifecycleBootstrapPollSuccess(t *testing.T) {
 	}
 }
 
+func TestRecreateHintsAtStaleSSHConfig(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name     string
+		existing string
+		wantHint bool
+	}{
+		{name: "block for the old instance", existing: sshconfig.GenerateBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", ""), wantHint: true},
+		{name: "no block", existing: "Host github.com\n    User git\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			deps := newHappyRecreateDepsWithMocks("alice", defaultLifecycleMocks())
+			deps.sshConfigPath = path
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"recreate", "--yes"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gotHint := strings.Contains(buf.String(), "run `mint ssh-config sync` to update it")
+			if gotHint != tt.wantHint {
+				t.Errorf("hint shown = %v, want %v; output:\n%s", gotHint, tt.wantHint, buf.String())
+			}
+			if got, _ := os.ReadFile(path); string(got) != tt.existing {
+				t.Errorf("recreate edited the SSH config:\n%s", got)
+			}
+		})
+	}
+}
+
 func TestRecreateLifecyclePendingAttachClearFailureIsNonFatal(t *testing.T) {
 	// If removing the pending-attach tag fails, the recreate should still succeed
 	// (the volume is attached; the tag is a safety net for crash recovery).
 	lm := defaultLifecycleMocks()
-	lm.deleteTags = &mockDeleteTags{
-		err: fmt.Errorf("tag cleanup failed"),
+	lm.deleteTags = &cmdtest.DeleteTags{
+		Err: fmt.Errorf("tag cleanup failed"),
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1255,15 +1514,15 @@ func TestRecreateLifecyclePendingAttachSetFailure(t *testing.T) {
 	// If setting the pending-attach tag fails, the recreate should abort
 	// (the safety net must be in place before destructive actions).
 	lm := defaultLifecycleMocks()
-	lm.createTags = &mockCreateTags{
-		failOnCall: 1, // First call (the set) fails.
-		err:        fmt.Errorf("cannot tag volume"),
+	lm.createTags = &cmdtest.CreateTags{
+		FailOnCall: 1, // First call (the set) fails.
+		Err:        fmt.Errorf("cannot tag volume"),
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1286,7 +1545,7 @@ func TestRecreateLifecycleAMIResolutionFails(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1302,8 +1561,8 @@ func TestRecreateLifecycleAMIResolutionFails(t *testing.T) {
 
 func TestRecreateLifecycleSecurityGroupNotFound(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.sgs = &mockDescribeSecurityGroups{
-		outputs: map[string]*ec2.DescribeSecurityGroupsOutput{
+	lm.sgs = &cmdtest.DescribeSecurityGroups{
+		ByComponent: map[string]*ec2.DescribeSecurityGroupsOutput{
 			"security-group": {SecurityGroups: []ec2types.SecurityGroup{}}, // empty
 			"admin":          {SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-admin456")}}},
 		},
@@ -1312,7 +1571,7 @@ func TestRecreateLifecycleSecurityGroupNotFound(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1326,16 +1585,87 @@ func TestRecreateLifecycleSecurityGroupNotFound(t *testing.T) {
 	}
 }
 
+// customNetworkLifecycleMocks returns lifecycle mocks whose subnet
+// subnet-0c0ffee1 is in vpc-0c0ffee1 in az, with group sg-0c0ffee1 in vpc.
+func customNetworkLifecycleMocks(az, vpc string) lifecycleMocks {
+	lm := defaultLifecycleMocks()
+	lm.subnets = &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{{
+		SubnetId:         aws.String("subnet-0c0ffee1"),
+		VpcId:            aws.String("vpc-0c0ffee1"),
+		AvailabilityZone: aws.String(az),
+	}}}}
+	lm.sgs.ByID = map[string]ec2types.SecurityGroup{
+		"sg-0c0ffee1": {GroupId: aws.String("sg-0c0ffee1"), VpcId: aws.String(vpc)},
+	}
+	return lm
+}
+
+func TestRecreateNetworkFlags(t *testing.T) {
+	lm := customNetworkLifecycleMocks("us-east-1a", "vpc-0c0ffee1")
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--subnet-id", "subnet-0c0ffee1", "--security-group-ids", "sg-0c0ffee1"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	input := lm.run.Input()
+	if aws.ToString(input.SubnetId) != "subnet-0c0ffee1" {
+		t.Errorf("SubnetId = %q, want subnet-0c0ffee1", aws.ToString(input.SubnetId))
+	}
+	if len(input.SecurityGroupIds) != 1 || input.SecurityGroupIds[0] != "sg-0c0ffee1" {
+		t.Errorf("SecurityGroupIds = %v, want [sg-0c0ffee1]", input.SecurityGroupIds)
+	}
+}
+
+func TestRecreateNetworkMismatchFailsBeforeStopping(t *testing.T) {
+	tests := []struct {
+		name string
+		az   string
+		vpc  string
+		args []string
+		want string
+	}{
+		{"subnet in another AZ", "us-east-1b", "vpc-0c0ffee1", []string{"--subnet-id", "subnet-0c0ffee1"},
+			"subnet_id subnet-0c0ffee1 is in us-east-1b, but the project volume is in us-east-1a"},
+		{"group in another VPC", "us-east-1a", "vpc-0bad0001", []string{"--subnet-id", "subnet-0c0ffee1", "--security-group-ids", "sg-0c0ffee1"},
+			"security groups sg-0c0ffee1 (vpc-0bad0001) are not in vpc-0c0ffee1"},
+		{"malformed flag", "us-east-1a", "vpc-0c0ffee1", []string{"--vpc-id", "default"}, "--vpc-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := customNetworkLifecycleMocks(tt.az, tt.vpc)
+			deps := newHappyRecreateDepsWithMocks("alice", lm)
+
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"recreate", "--yes"}, tt.args...))
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if lm.stop.Called || lm.terminate.Called {
+				t.Error("the old instance was stopped or terminated")
+			}
+		})
+	}
+}
+
 func TestRecreateLifecycleSubnetNotFound(t *testing.T) {
 	lm := defaultLifecycleMocks()
-	lm.subnets = &mockDescribeSubnets{
-		output: &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{}},
+	lm.subnets = &cmdtest.DescribeSubnets{
+		Output: &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{}},
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1363,7 +1693,7 @@ func TestRecreateLifecycleBootstrapVerifyCalled(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1388,7 +1718,7 @@ func TestRecreateLifecycleBootstrapVerifyRejectsScript(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1405,7 +1735,7 @@ func TestRecreateLifecycleBootstrapVerifyRejectsScript(t *testing.T) {
 	}
 
 	// RunInstances should NOT have been called.
-	if lm.run.captured != nil {
+	if lm.run.Input() != nil {
 		t.Error("RunInstances was called despite bootstrap verification failure")
 	}
 }
@@ -1417,8 +1747,8 @@ func TestRecreateLifecycleBootstrapVerifyRejectsScript(t *testing.T) {
 func TestRecreateReassociatesEIP(t *testing.T) {
 	// Happy path: EIP found by tags and reassociated with the new instance.
 	lm := defaultLifecycleMocks()
-	describeAddrsMock := &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	describeAddrsMock := &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{{
 				AllocationId: aws.String("eipalloc-abc123"),
 				PublicIp:     aws.String("54.1.2.3"),
@@ -1426,14 +1756,14 @@ func TestRecreateReassociatesEIP(t *testing.T) {
 		},
 	}
 	lm.describeAddrs = describeAddrsMock
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1444,10 +1774,10 @@ func TestRecreateReassociatesEIP(t *testing.T) {
 	}
 
 	// Verify DescribeAddresses was called with correct tag filters.
-	if describeAddrsMock.captured == nil {
+	if len(describeAddrsMock.Inputs) == 0 {
 		t.Fatal("DescribeAddresses was not called")
 	}
-	filters := describeAddrsMock.captured.Filters
+	filters := describeAddrsMock.Inputs[0].Filters
 	foundMint := false
 	foundOwner := false
 	foundVM := false
@@ -1482,31 +1812,31 @@ func TestRecreateReassociatesEIP(t *testing.T) {
 	}
 
 	// Verify AssociateAddress was called with correct AllocationId and new instance ID.
-	if lm.associateAddr.captured == nil {
+	if lm.associateAddr.Input == nil {
 		t.Fatal("AssociateAddress was not called")
 	}
-	if aws.ToString(lm.associateAddr.captured.AllocationId) != "eipalloc-abc123" {
-		t.Errorf("AssociateAddress AllocationId = %q, want %q", aws.ToString(lm.associateAddr.captured.AllocationId), "eipalloc-abc123")
+	if aws.ToString(lm.associateAddr.Input.AllocationId) != "eipalloc-abc123" {
+		t.Errorf("AssociateAddress AllocationId = %q, want %q", aws.ToString(lm.associateAddr.Input.AllocationId), "eipalloc-abc123")
 	}
-	if aws.ToString(lm.associateAddr.captured.InstanceId) != "i-new789" {
-		t.Errorf("AssociateAddress InstanceId = %q, want %q", aws.ToString(lm.associateAddr.captured.InstanceId), "i-new789")
+	if aws.ToString(lm.associateAddr.Input.InstanceId) != "i-new789" {
+		t.Errorf("AssociateAddress InstanceId = %q, want %q", aws.ToString(lm.associateAddr.Input.InstanceId), "i-new789")
 	}
 }
 
 func TestRecreateNoEIPWarning(t *testing.T) {
 	// No EIP found (empty DescribeAddresses result). Recreate still succeeds.
 	lm := defaultLifecycleMocks()
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{}},
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{}},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes", "--verbose"})
@@ -1523,7 +1853,7 @@ func TestRecreateNoEIPWarning(t *testing.T) {
 	}
 
 	// AssociateAddress should NOT have been called.
-	if lm.associateAddr.captured != nil {
+	if lm.associateAddr.Input != nil {
 		t.Error("AssociateAddress should not be called when no EIP is found")
 	}
 
@@ -1536,22 +1866,22 @@ func TestRecreateNoEIPWarning(t *testing.T) {
 func TestRecreateEIPAssociateFails(t *testing.T) {
 	// EIP found but AssociateAddress fails. Recreate should return error.
 	lm := defaultLifecycleMocks()
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{{
 				AllocationId: aws.String("eipalloc-abc123"),
 				PublicIp:     aws.String("54.1.2.3"),
 			}},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		err: fmt.Errorf("association limit exceeded"),
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Err: fmt.Errorf("association limit exceeded"),
 	}
 	deps := newHappyRecreateDepsWithMocks("alice", lm)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1565,6 +1895,98 @@ func TestRecreateEIPAssociateFails(t *testing.T) {
 	}
 }
 
+// fakeEIPWaiter is a WaitEIPAssociatedAPI that records its call and
+// reports waited and err.
+type fakeEIPWaiter struct {
+	waited time.Duration
+	err    error
+
+	allocationID, instanceID string
+}
+
+func (f *fakeEIPWaiter) Wait(ctx context.Context, allocationID, instanceID string, maxWaitDur time.Duration) (time.Duration, error) {
+	f.allocationID, f.instanceID = allocationID, instanceID
+	return f.waited, f.err
+}
+
+func eipLifecycleMocks() lifecycleMocks {
+	lm := defaultLifecycleMocks()
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
+			Addresses: []ec2types.Address{{
+				AllocationId: aws.String("eipalloc-abc123"),
+				PublicIp:     aws.String("54.1.2.3"),
+			}},
+		},
+	}
+	lm.associateAddr = &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}}
+	return lm
+}
+
+func TestRecreateWaitsForEIPPropagation(t *testing.T) {
+	tests := []struct {
+		name     string
+		waited   time.Duration
+		wantNote bool
+	}{
+		{name: "immediate", waited: 300 * time.Millisecond},
+		{name: "slow", waited: 9 * time.Second, wantNote: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyRecreateDepsWithMocks("alice", eipLifecycleMocks())
+			waiter := &fakeEIPWaiter{waited: tt.waited}
+			deps.waitEIP = waiter
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"recreate", "--yes", "--verbose"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if waiter.allocationID != "eipalloc-abc123" || waiter.instanceID != "i-new789" {
+				t.Errorf("waited for %s on %s, want eipalloc-abc123 on i-new789", waiter.allocationID, waiter.instanceID)
+			}
+			note := strings.Contains(buf.String(), "Elastic IP took 9s to reach the instance")
+			if note != tt.wantNote {
+				t.Errorf("propagation note shown = %v, want %v:\n%s", note, tt.wantNote, buf.String())
+			}
+		})
+	}
+}
+
+func TestRecreateEIPPropagationTimeout(t *testing.T) {
+	deps := newHappyRecreateDepsWithMocks("alice", eipLifecycleMocks())
+	deps.waitEIP = &fakeEIPWaiter{err: &mintaws.EIPPropagationError{
+		AllocationID: "eipalloc-abc123", InstanceID: "i-new789", Waited: 2 * time.Minute,
+		Reason: "DescribeAddresses shows it associated with no instance",
+	}}
+	polled := false
+	deps.pollBootstrap = func(ctx context.Context, owner, vmName, instanceID string) error {
+		polled = true
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	err := root.Execute()
+	if !mintaws.IsEIPPropagationError(err) {
+		t.Fatalf("err = %v, want the propagation error", err)
+	}
+	if !strings.Contains(err.Error(), "not an SSH problem") {
+		t.Errorf("error %q does not rule out SSH", err)
+	}
+	if polled {
+		t.Error("bootstrap polled after the Elastic IP failed to propagate")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests — TOFU host key reset
 // ---------------------------------------------------------------------------
@@ -1582,7 +2004,7 @@ func TestRecreateLifecycleRemovesHostKey(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1608,7 +2030,7 @@ func TestRecreateLifecycleHostKeyRemovalError(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1642,7 +2064,7 @@ func TestRecreateLifecycleHostKeyNotRemovedOnBootstrapFailure(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1659,24 +2081,24 @@ func TestRecreateLifecycleHostKeyRemovedWithNonDefaultVM(t *testing.T) {
 	runner := noSessionsRunner()
 	lm := defaultLifecycleMocks()
 	deps := &recreateDeps{
-		describe:        &mockRecreateDescribeInstances{output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
-		sendKey:         &mockRecreateSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
-		remoteRun:       runner.run,
-		owner:           "bob",
-		ownerARN:        "arn:aws:iam::123456789012:user/bob",
-		describeVolumes: lm.describeVolumes,
-		stop:            lm.stop,
-		terminate:       lm.terminate,
-		detachVolume:    lm.detach,
-		attachVolume:    lm.attach,
-		run:             lm.run,
-		createTags:      lm.createTags,
-		describeSubnets: lm.subnets,
-		describeSGs:     lm.sgs,
+		describe:         &cmdtest.DescribeInstances{Output: makeRunningInstanceForRecreate("i-dev456", "dev", "bob", "5.6.7.8", "us-west-2a")},
+		sendKey:          &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{}},
+		remoteRun:        runner.run,
+		owner:            "bob",
+		ownerARN:         "arn:aws:iam::123456789012:user/bob",
+		describeVolumes:  lm.describeVolumes,
+		stop:             lm.stop,
+		terminate:        lm.terminate,
+		detachVolume:     lm.detach,
+		attachVolume:     lm.attach,
+		run:              lm.run,
+		createTags:       lm.createTags,
+		describeSubnets:  lm.subnets,
+		describeSGs:      lm.sgs,
 		describeAddrs:    lm.describeAddrs,
 		associateAddr:    lm.associateAddr,
 		disassociateAddr: lm.disassociateAddr,
-		bootstrapScript: []byte("#!/bin/bash\necho hello"),
+		bootstrapScript:  []byte("#!/bin/bash\necho hello"),
 		resolveAMI: func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
 			return "ami-test123", nil
 		},
@@ -1690,7 +2112,7 @@ func TestRecreateLifecycleHostKeyRemovedWithNonDefaultVM(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--vm", "dev", "--yes"})
@@ -1705,6 +2127,55 @@ func TestRecreateLifecycleHostKeyRemovedWithNonDefaultVM(t *testing.T) {
 	}
 }
 
+func TestRecreateRecordsProvisioningHistory(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	lm.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
+		Addresses: []ec2types.Address{eipWithoutAssociation("eipalloc-abc123", "54.1.2.3")},
+	}}
+	lm.associateAddr = &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}}
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	runner := noSessionsRunner()
+	runner.historyOut = []byte(`{"instance_id":"i-first","action":"up","timestamp":"2026-09-01T08:00:00Z","cli_version":"0.9.1","duration_seconds":301}` + "\n")
+	deps.remoteRun = runner.run
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	var provisioned *ec2.CreateTagsInput
+	for _, call := range lm.createTags.Inputs {
+		for _, tag := range call.Tags {
+			if aws.ToString(tag.Key) == tags.TagProvisionedBy {
+				provisioned = call
+			}
+		}
+	}
+	if provisioned == nil {
+		t.Fatalf("new instance not tagged with %s", tags.TagProvisionedBy)
+	}
+	if len(provisioned.Resources) != 1 || provisioned.Resources[0] == "i-abc123" {
+		t.Errorf("provisioning tags on %v, want the new instance", provisioned.Resources)
+	}
+
+	// The old instance's entries are carried over, the new entry last.
+	if len(runner.historyWrite) != 1 {
+		t.Fatalf("history writes = %q, want one\n%s", runner.historyWrite, buf.String())
+	}
+	write := runner.historyWrite[0]
+	first, recreate := strings.Index(write, `"i-first"`), strings.Index(write, `"action":"recreate"`)
+	if first < 0 || recreate < first {
+		t.Errorf("history write %q does not append the recreate after the old entries", write)
+	}
+	if strings.Contains(buf.String(), "could not record provisioning history") {
+		t.Errorf("unexpected warning:\n%s", buf.String())
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests — Claude-in-containers detection via recreate
 // ---------------------------------------------------------------------------
@@ -1713,10 +2184,10 @@ func TestRecreateDetectsClaudeInContainers(t *testing.T) {
 	// When claude processes are running in containers but no tmux/SSH
 	// sessions exist, recreate should still block.
 	runner := &mockRecreateRemoteRunner{
-		tmuxOutput: nil,
-		tmuxErr:    fmt.Errorf("no server running on /tmp/tmux-1000/default"),
-		whoOutput:  []byte(""),
-		whoErr:     nil,
+		tmuxOutput:  nil,
+		tmuxErr:     fmt.Errorf("no server running on /tmp/tmux-1000/default"),
+		whoOutput:   []byte(""),
+		whoErr:      nil,
 		dockerPsOut: []byte("abc123\n"),
 		dockerPsErr: nil,
 		dockerTopOut: map[string][]byte{
@@ -1730,7 +2201,7 @@ func TestRecreateDetectsClaudeInContainers(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(d)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1765,7 +2236,7 @@ func TestRecreateDetectsManualExtend(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(d)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1789,8 +2260,8 @@ func TestRecreateDetectsManualExtend(t *testing.T) {
 // mockWaitVolumeAvailable is a WaitVolumeAvailableAPI mock that records the
 // order in which it was called relative to detach and attach operations.
 type mockWaitVolumeAvailable struct {
-	err      error
-	called   bool
+	err    error
+	called bool
 	// callOrder records the name of each operation in the order it occurred.
 	// Populated by the ordering wrappers on detach/attach.
 	callOrder *[]string
@@ -1809,9 +2280,9 @@ func (m *mockWaitVolumeAvailable) Wait(
 	return m.err
 }
 
-// mockDetachVolumeOrdered wraps mockDetachVolume and appends to callOrder.
+// mockDetachVolumeOrdered wraps a detach mock and appends to callOrder.
 type mockDetachVolumeOrdered struct {
-	mockDetachVolume
+	next      cmdtest.DetachVolume
 	callOrder *[]string
 }
 
@@ -1819,12 +2290,12 @@ func (m *mockDetachVolumeOrdered) DetachVolume(ctx context.Context, params *ec2.
 	if m.callOrder != nil {
 		*m.callOrder = append(*m.callOrder, "detach")
 	}
-	return m.mockDetachVolume.DetachVolume(ctx, params, optFns...)
+	return m.next.DetachVolume(ctx, params, optFns...)
 }
 
-// mockAttachVolumeOrdered wraps mockAttachVolume and appends to callOrder.
+// mockAttachVolumeOrdered wraps a attach mock and appends to callOrder.
 type mockAttachVolumeOrdered struct {
-	mockAttachVolume
+	next      cmdtest.AttachVolume
 	callOrder *[]string
 }
 
@@ -1832,7 +2303,7 @@ func (m *mockAttachVolumeOrdered) AttachVolume(ctx context.Context, params *ec2.
 	if m.callOrder != nil {
 		*m.callOrder = append(*m.callOrder, "attach")
 	}
-	return m.mockAttachVolume.AttachVolume(ctx, params, optFns...)
+	return m.next.AttachVolume(ctx, params, optFns...)
 }
 
 func TestRecreateWaitVolumeAvailableCalledAfterDetachBeforeAttach(t *testing.T) {
@@ -1845,12 +2316,12 @@ func TestRecreateWaitVolumeAvailableCalledAfterDetachBeforeAttach(t *testing.T)
 
 	// Replace detach/attach with order-tracking variants.
 	orderedDetach := &mockDetachVolumeOrdered{
-		mockDetachVolume: mockDetachVolume{output: &ec2.DetachVolumeOutput{}},
-		callOrder:        &callOrder,
+		next:      cmdtest.DetachVolume{Output: &ec2.DetachVolumeOutput{}},
+		callOrder: &callOrder,
 	}
 	orderedAttach := &mockAttachVolumeOrdered{
-		mockAttachVolume: mockAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		callOrder:        &callOrder,
+		next:      cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		callOrder: &callOrder,
 	}
 	waiter := &mockWaitVolumeAvailable{callOrder: &callOrder}
 
@@ -1860,7 +2331,7 @@ func TestRecreateWaitVolumeAvailableCalledAfterDetachBeforeAttach(t *testing.T)
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1917,7 +2388,7 @@ func TestRecreateWaitVolumeAvailableTimeoutProducesFriendlyError(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1940,7 +2411,7 @@ func TestRecreateWaitVolumeAvailableNilWaiterSkipped(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -1968,7 +2439,7 @@ func TestRecreateConfirmationMessageAppearsExactlyOnce(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2027,15 +2498,15 @@ func TestEIPDisassociateCalledWhenStaleAssociationExists(t *testing.T) {
 	lm := defaultLifecycleMocks()
 
 	// DescribeAddresses returns an EIP with a stale AssociationId.
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{
 				eipWithAssociation("eipalloc-stale123", "54.2.3.4", "eipassoc-old111"),
 			},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	lm.disassociateAddr = &mockDisassociateAddress{}
 
@@ -2043,7 +2514,7 @@ func TestEIPDisassociateCalledWhenStaleAssociationExists(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2066,16 +2537,16 @@ func TestEIPDisassociateCalledWhenStaleAssociationExists(t *testing.T) {
 	}
 
 	// AssociateAddress must have been called after disassociation.
-	if lm.associateAddr.captured == nil {
+	if lm.associateAddr.Input == nil {
 		t.Fatal("AssociateAddress was not called after DisassociateAddress")
 	}
-	if aws.ToString(lm.associateAddr.captured.AllocationId) != "eipalloc-stale123" {
+	if aws.ToString(lm.associateAddr.Input.AllocationId) != "eipalloc-stale123" {
 		t.Errorf("AssociateAddress AllocationId = %q, want %q",
-			aws.ToString(lm.associateAddr.captured.AllocationId), "eipalloc-stale123")
+			aws.ToString(lm.associateAddr.Input.AllocationId), "eipalloc-stale123")
 	}
-	if aws.ToString(lm.associateAddr.captured.InstanceId) != "i-new789" {
+	if aws.ToString(lm.associateAddr.Input.InstanceId) != "i-new789" {
 		t.Errorf("AssociateAddress InstanceId = %q, want %q",
-			aws.ToString(lm.associateAddr.captured.InstanceId), "i-new789")
+			aws.ToString(lm.associateAddr.Input.InstanceId), "i-new789")
 	}
 
 	if !strings.Contains(buf.String(), "Recreate complete") {
@@ -2090,15 +2561,15 @@ func TestEIPDisassociateSkippedWhenNoAssociationExists(t *testing.T) {
 	lm := defaultLifecycleMocks()
 
 	// DescribeAddresses returns an EIP with no AssociationId.
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{
 				eipWithoutAssociation("eipalloc-clean777", "54.8.9.0"),
 			},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	lm.disassociateAddr = &mockDisassociateAddress{}
 
@@ -2106,7 +2577,7 @@ func TestEIPDisassociateSkippedWhenNoAssociationExists(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2122,12 +2593,12 @@ func TestEIPDisassociateSkippedWhenNoAssociationExists(t *testing.T) {
 	}
 
 	// AssociateAddress must still have been called.
-	if lm.associateAddr.captured == nil {
+	if lm.associateAddr.Input == nil {
 		t.Fatal("AssociateAddress was not called")
 	}
-	if aws.ToString(lm.associateAddr.captured.AllocationId) != "eipalloc-clean777" {
+	if aws.ToString(lm.associateAddr.Input.AllocationId) != "eipalloc-clean777" {
 		t.Errorf("AssociateAddress AllocationId = %q, want %q",
-			aws.ToString(lm.associateAddr.captured.AllocationId), "eipalloc-clean777")
+			aws.ToString(lm.associateAddr.Input.AllocationId), "eipalloc-clean777")
 	}
 
 	if !strings.Contains(buf.String(), "Recreate complete") {
@@ -2141,15 +2612,15 @@ func TestEIPDisassociateSkippedWhenNoAssociationExists(t *testing.T) {
 func TestEIPReassociatePollsUntilDisassociated(t *testing.T) {
 	lm := defaultLifecycleMocks()
 
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{
 				eipWithAssociation("eipalloc-poll123", "54.2.3.4", "eipassoc-old111"),
 			},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	lm.disassociateAddr = &mockDisassociateAddress{}
 
@@ -2157,7 +2628,7 @@ func TestEIPReassociatePollsUntilDisassociated(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2173,12 +2644,12 @@ func TestEIPReassociatePollsUntilDisassociated(t *testing.T) {
 	}
 
 	// AssociateAddress must have been called with the correct allocation ID.
-	if lm.associateAddr.captured == nil {
+	if lm.associateAddr.Input == nil {
 		t.Fatal("AssociateAddress was not called after disassociation")
 	}
-	if aws.ToString(lm.associateAddr.captured.AllocationId) != "eipalloc-poll123" {
+	if aws.ToString(lm.associateAddr.Input.AllocationId) != "eipalloc-poll123" {
 		t.Errorf("AssociateAddress AllocationId = %q, want %q",
-			aws.ToString(lm.associateAddr.captured.AllocationId), "eipalloc-poll123")
+			aws.ToString(lm.associateAddr.Input.AllocationId), "eipalloc-poll123")
 	}
 
 	if !strings.Contains(buf.String(), "Recreate complete") {
@@ -2191,15 +2662,15 @@ func TestEIPReassociatePollsUntilDisassociated(t *testing.T) {
 func TestEIPDisassociateErrorPropagates(t *testing.T) {
 	lm := defaultLifecycleMocks()
 
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{
 				eipWithAssociation("eipalloc-err999", "54.5.6.7", "eipassoc-stale222"),
 			},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	lm.disassociateAddr = &mockDisassociateAddress{
 		err: fmt.Errorf("disassociate: request throttled"),
@@ -2209,7 +2680,7 @@ func TestEIPDisassociateErrorPropagates(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2226,7 +2697,7 @@ func TestEIPDisassociateErrorPropagates(t *testing.T) {
 	}
 
 	// AssociateAddress must NOT have been called.
-	if lm.associateAddr.captured != nil {
+	if lm.associateAddr.Input != nil {
 		t.Error("AssociateAddress should NOT be called when DisassociateAddress fails")
 	}
 }
@@ -2237,15 +2708,15 @@ func TestEIPDisassociateErrorPropagates(t *testing.T) {
 func TestEIPReassociateImmediateSuccessWhenAlreadyDisassociated(t *testing.T) {
 	lm := defaultLifecycleMocks()
 
-	lm.describeAddrs = &mockDescribeAddresses{
-		output: &ec2.DescribeAddressesOutput{
+	lm.describeAddrs = &cmdtest.DescribeAddresses{
+		Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{
 				eipWithoutAssociation("eipalloc-clean777", "54.8.9.0"),
 			},
 		},
 	}
-	lm.associateAddr = &mockAssociateAddress{
-		output: &ec2.AssociateAddressOutput{},
+	lm.associateAddr = &cmdtest.AssociateAddress{
+		Output: &ec2.AssociateAddressOutput{},
 	}
 	lm.disassociateAddr = &mockDisassociateAddress{}
 
@@ -2253,7 +2724,7 @@ func TestEIPReassociateImmediateSuccessWhenAlreadyDisassociated(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2264,12 +2735,12 @@ func TestEIPReassociateImmediateSuccessWhenAlreadyDisassociated(t *testing.T) {
 	}
 
 	// AssociateAddress must have been called.
-	if lm.associateAddr.captured == nil {
+	if lm.associateAddr.Input == nil {
 		t.Fatal("AssociateAddress was not called")
 	}
-	if aws.ToString(lm.associateAddr.captured.AllocationId) != "eipalloc-clean777" {
+	if aws.ToString(lm.associateAddr.Input.AllocationId) != "eipalloc-clean777" {
 		t.Errorf("AssociateAddress AllocationId = %q, want %q",
-			aws.ToString(lm.associateAddr.captured.AllocationId), "eipalloc-clean777")
+			aws.ToString(lm.associateAddr.Input.AllocationId), "eipalloc-clean777")
 	}
 
 	// DisassociateAddress must NOT have been called.
@@ -2297,7 +2768,7 @@ func TestRecreateUserBootstrapScriptAbsent(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2320,7 +2791,7 @@ func TestRecreateUserBootstrapScriptPresent(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes"})
@@ -2333,6 +2804,32 @@ func TestRecreateUserBootstrapScriptPresent(t *testing.T) {
 		t.Errorf("expected 'Recreate complete' in output, got:\n%s", buf.String())
 	}
 }
+
+// TestRecreateStubPlaceholderDrift verifies that recreate reports a stub
+// the CLI cannot fully render as an out-of-sync build instead of launching.
+func TestRecreateStubPlaceholderDrift(t *testing.T) {
+	bootstrap.SetStub([]byte(stubTemplateForTests + "export MINT_REGION=\"__MINT_REGION__\"\n"))
+	defer bootstrap.SetStub([]byte(stubTemplateForTests))
+
+	deps := newHappyRecreateDeps("alice")
+	stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+	deps.stop = stop
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+
+	err := root.Execute()
+	if err == nil || !strings.HasPrefix(err.Error(), "bootstrap rendering failed: ") ||
+		!strings.Contains(err.Error(), "out of sync") || !strings.Contains(err.Error(), "__MINT_REGION__ (line ") {
+		t.Fatalf("error = %v, want a bootstrap rendering error naming __MINT_REGION__ and its line", err)
+	}
+	if stop.Called {
+		t.Error("the old instance was stopped before the stub was checked")
+	}
+}
+
 var _ provision.AMIResolver = func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) { return "", nil }
 
 // ---------------------------------------------------------------------------
@@ -2352,7 +2849,7 @@ func TestRecreateSpinnerNotActiveBeforeConfirmation(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetIn(strings.NewReader("default\n"))
@@ -2427,7 +2924,7 @@ func TestRecreateSessionWarningAppearsAsPlainText(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes", "--verbose"})
@@ -2468,7 +2965,7 @@ func TestRecreateSpinnerOnlyDuringLifecycle(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newRecreateCommandWithDeps(deps)
-	root := newRecreateTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"recreate", "--yes", "--verbose"})
@@ -2517,3 +3014,430 @@ func TestRecreateSpinnerOnlyDuringLifecycle(t *testing.T) {
 		t.Errorf("Step 1/9 not found after pre-confirmation text in output:\n%s", output)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Tests — Ctrl-C during the lifecycle
+// ---------------------------------------------------------------------------
+
+// interruptingLifecycle wraps the lifecycle mocks and cancels the command
+// context when the call named at is made, the way Ctrl-C would mid-step.
+type interruptingLifecycle struct {
+	lm     lifecycleMocks
+	at     string
+	cancel context.CancelFunc
+	// called records the mutating calls made.
+	called map[string]bool
+	// cancelledCalls lists mutating calls made with a cancelled context.
+	cancelledCalls []string
+}
+
+func (m *interruptingLifecycle) hit(ctx context.Context, name string) {
+	m.called[name] = true
+	if name == m.at {
+		m.cancel()
+		return
+	}
+	if ctx.Err() != nil {
+		m.cancelledCalls = append(m.cancelledCalls, name)
+	}
+}
+
+func (m *interruptingLifecycle) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	if m.at == "DescribeVolumes" {
+		m.cancel()
+	}
+	return m.lm.describeVolumes.DescribeVolumes(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	m.hit(ctx, "CreateTags")
+	return m.lm.createTags.CreateTags(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	m.hit(ctx, "StopInstances")
+	return m.lm.stop.StopInstances(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) DetachVolume(ctx context.Context, params *ec2.DetachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DetachVolumeOutput, error) {
+	m.hit(ctx, "DetachVolume")
+	return m.lm.detach.DetachVolume(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	m.hit(ctx, "TerminateInstances")
+	return m.lm.terminate.TerminateInstances(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	m.hit(ctx, "RunInstances")
+	return m.lm.run.RunInstances(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) Wait(ctx context.Context, params *ec2.DescribeVolumesInput, maxWaitDur time.Duration, optFns ...func(*ec2.VolumeAvailableWaiterOptions)) error {
+	if m.at == "WaitVolumeAvailable" {
+		m.cancel()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (m *interruptingLifecycle) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
+	m.hit(ctx, "AttachVolume")
+	return m.lm.attach.AttachVolume(ctx, params, optFns...)
+}
+
+func (m *interruptingLifecycle) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	m.hit(ctx, "DeleteTags")
+	return m.lm.deleteTags.DeleteTags(ctx, params, optFns...)
+}
+
+func TestRecreateLifecycleInterrupted(t *testing.T) {
+	hint.IsTTY = false
+
+	const (
+		untouched  = "Nothing was changed; VM \"default\" (i-abc123) is untouched."
+		terminated = "Instance i-abc123 was terminated. Project volume vol-proj123 is tagged pending-attach — run `mint up` to finish."
+		launched   = "New instance i-new789 was launched. Project volume vol-proj123 is tagged pending-attach and not yet attached — run `mint up` to finish."
+		booting    = "Instance i-new789 has the project volume and Elastic IP; bootstrap is still running — check on it with `mint status`."
+	)
+
+	tests := []struct {
+		at           string
+		wantRecovery string
+		// wantCalled and wantNotCalled name the mutating calls that must
+		// and must not happen.
+		wantCalled    []string
+		wantNotCalled []string
+	}{
+		{at: "DescribeVolumes", wantRecovery: untouched, wantNotCalled: []string{"CreateTags", "StopInstances"}},
+		// Steps 2-5 finish as a unit once the volume is tagged.
+		{at: "CreateTags", wantRecovery: terminated, wantCalled: []string{"StopInstances", "DetachVolume", "TerminateInstances"}, wantNotCalled: []string{"RunInstances"}},
+		{at: "StopInstances", wantRecovery: terminated, wantCalled: []string{"DetachVolume", "TerminateInstances"}, wantNotCalled: []string{"RunInstances"}},
+		{at: "DetachVolume", wantRecovery: terminated, wantCalled: []string{"TerminateInstances"}, wantNotCalled: []string{"RunInstances"}},
+		{at: "TerminateInstances", wantRecovery: terminated, wantNotCalled: []string{"RunInstances"}},
+		{at: "RunInstances", wantRecovery: launched, wantNotCalled: []string{"AttachVolume"}},
+		{at: "WaitVolumeAvailable", wantRecovery: launched, wantNotCalled: []string{"AttachVolume"}},
+		// Attaching and removing the pending-attach tag happen together.
+		{at: "AttachVolume", wantRecovery: booting, wantCalled: []string{"DeleteTags"}, wantNotCalled: []string{"pollBootstrap"}},
+		{at: "pollBootstrap", wantRecovery: booting},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			lm := defaultLifecycleMocks()
+			m := &interruptingLifecycle{lm: lm, at: tt.at, cancel: cancel, called: map[string]bool{}}
+			deps := newHappyRecreateDepsWithMocks("alice", lm)
+			deps.describeVolumes = m
+			deps.createTags = m
+			deps.stop = m
+			deps.detachVolume = m
+			deps.terminate = m
+			deps.run = m
+			deps.waitVolumeAvailable = m
+			deps.attachVolume = m
+			deps.deleteTags = m
+			deps.pollBootstrap = func(ctx context.Context, owner, vmName, instanceID string) error {
+				m.called["pollBootstrap"] = true
+				if tt.at == "pollBootstrap" {
+					cancel()
+					return fmt.Errorf("polling bootstrap: %w", ctx.Err())
+				}
+				return nil
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"recreate", "--yes"})
+			err := root.ExecuteContext(ctx)
+
+			var ierr *interruptedError
+			if !errors.As(err, &ierr) {
+				t.Fatalf("error = %v, want an interruptedError\n%s", err, buf.String())
+			}
+			if ierr.recovery != tt.wantRecovery {
+				t.Errorf("recovery = %q\nwant %q", ierr.recovery, tt.wantRecovery)
+			}
+			if ExitCode(err) != exitCodeInterrupted {
+				t.Errorf("ExitCode = %d, want %d", ExitCode(err), exitCodeInterrupted)
+			}
+			if strings.Contains(buf.String(), "Recreate complete") {
+				t.Error("an interrupted recreate must not report completion")
+			}
+			if len(m.cancelledCalls) != 0 {
+				t.Errorf("mutating calls made with a cancelled context: %v", m.cancelledCalls)
+			}
+
+			for _, name := range tt.wantCalled {
+				if !m.called[name] {
+					t.Errorf("%s was not called; it must finish with the step in flight", name)
+				}
+			}
+			for _, name := range tt.wantNotCalled {
+				if m.called[name] {
+					t.Errorf("%s was called after the interrupt", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRecreateSpotMarket(t *testing.T) {
+	tests := []struct {
+		name         string
+		originalSpot bool
+		args         []string
+		wantSpot     bool
+	}{
+		{name: "on-demand stays on-demand"},
+		{name: "spot stays spot", originalSpot: true, wantSpot: true},
+		{name: "--spot moves to spot", args: []string{"--spot"}, wantSpot: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := defaultLifecycleMocks()
+			deps := newHappyRecreateDepsWithMocks("alice", lm)
+			if tt.originalSpot {
+				out := makeRunningInstanceForRecreate("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")
+				inst := &out.Reservations[0].Instances[0]
+				inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagSpot), Value: aws.String("true")})
+				deps.describe = &cmdtest.DescribeInstances{Output: out}
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"recreate", "--yes"}, tt.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+			}
+
+			if gotSpot := lm.run.Input().InstanceMarketOptions != nil; gotSpot != tt.wantSpot {
+				t.Errorf("spot launch = %v, want %v", gotSpot, tt.wantSpot)
+			}
+			tagged := false
+			for _, tag := range lm.run.Input().TagSpecifications[0].Tags {
+				if aws.ToString(tag.Key) == tags.TagSpot {
+					tagged = true
+				}
+			}
+			if tagged != tt.wantSpot {
+				t.Errorf("%s tag present = %v, want %v", tags.TagSpot, tagged, tt.wantSpot)
+			}
+		})
+	}
+}
+
+func TestRecreateSpotFallback(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	lm.run.Errs = []error{&smithy.GenericAPIError{Code: "SpotMaxPriceTooLow", Message: "price too low"}}
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes", "--spot", "--spot-fallback"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	if len(lm.run.Inputs) != 2 || lm.run.Inputs[0].InstanceMarketOptions == nil || lm.run.Inputs[1].InstanceMarketOptions != nil {
+		t.Fatalf("RunInstances calls = %d, want spot then on-demand", len(lm.run.Inputs))
+	}
+	if !strings.Contains(buf.String(), "Warning: no spot capacity for t3.medium (SpotMaxPriceTooLow)") {
+		t.Errorf("output missing spot fallback warning:\n%s", buf.String())
+	}
+}
+
+func TestRecreateSpotFallbackRequiresSpot(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes", "--spot-fallback"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--spot-fallback requires --spot or a spot VM") {
+		t.Fatalf("err = %v, want --spot-fallback requires --spot or a spot VM", err)
+	}
+	if lm.run.Input() != nil {
+		t.Error("RunInstances called despite the invalid flags")
+	}
+}
+
+func TestRecreateKeepsIPv6OnlyMode(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	lm.subnets.Output.Subnets = append(lm.subnets.Output.Subnets, ec2types.Subnet{
+		SubnetId:         aws.String("subnet-v6"),
+		AvailabilityZone: aws.String("us-east-1a"),
+		Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{{
+			Ipv6CidrBlock:      aws.String("2600:1f14::/64"),
+			Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeAssociated},
+		}},
+	})
+	lm.run.Output.Instances[0].Ipv6Address = aws.String("2600:1f14::20")
+	// An Elastic IP tagged for the VM must be left alone.
+	lm.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{{
+		AllocationId: aws.String("eipalloc-stray"),
+		PublicIp:     aws.String("54.0.0.9"),
+	}}}}
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	out := makeRunningInstanceForRecreate("i-abc123", "default", "alice", "", "us-east-1a")
+	inst := &out.Reservations[0].Instances[0]
+	inst.Ipv6Address = aws.String("2600:1f14::10")
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(tags.IPModeIPv6Only)})
+	deps.describe = &cmdtest.DescribeInstances{Output: out}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	input := lm.run.Input()
+	if input.SubnetId != nil || len(input.NetworkInterfaces) != 1 {
+		t.Fatalf("SubnetId = %v, NetworkInterfaces = %+v, want one network interface", input.SubnetId, input.NetworkInterfaces)
+	}
+	nic := input.NetworkInterfaces[0]
+	if aws.ToString(nic.SubnetId) != "subnet-v6" || aws.ToInt32(nic.Ipv6AddressCount) != 1 {
+		t.Errorf("network interface = %+v, want one IPv6 address in subnet-v6", nic)
+	}
+	tagged := ""
+	for _, tag := range input.TagSpecifications[0].Tags {
+		if aws.ToString(tag.Key) == tags.TagIPMode {
+			tagged = aws.ToString(tag.Value)
+		}
+	}
+	if tagged != tags.IPModeIPv6Only {
+		t.Errorf("%s tag = %q, want %q", tags.TagIPMode, tagged, tags.IPModeIPv6Only)
+	}
+	if lm.associateAddr.Input != nil {
+		t.Error("ipv6-only recreate associated an Elastic IP")
+	}
+	if !strings.Contains(buf.String(), "IPv6 address will change") {
+		t.Errorf("plan does not mention the IPv6 address change:\n%s", buf.String())
+	}
+}
+
+func TestRecreateKeepsProjectVolumeThroughputTag(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	out := makeRunningInstanceForRecreate("i-abc123", "default", "alice", "", "us-east-1a")
+	inst := &out.Reservations[0].Instances[0]
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagProjectVolumeThroughput), Value: aws.String("500")})
+	deps.describe = &cmdtest.DescribeInstances{Output: out}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	tagged := ""
+	for _, tag := range lm.run.Input().TagSpecifications[0].Tags {
+		if aws.ToString(tag.Key) == tags.TagProjectVolumeThroughput {
+			tagged = aws.ToString(tag.Value)
+		}
+	}
+	if tagged != "500" {
+		t.Errorf("%s tag = %q, want the original instance's 500", tags.TagProjectVolumeThroughput, tagged)
+	}
+}
+
+func TestRecreateAppliesVMConfigOverrides(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"default": {"instance_type": "g5.2xlarge"},
+	}
+	// A GPU type has its AMI checked before anything stops.
+	deps.describeImages = &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+		ImageId:      aws.String("ami-test123"),
+		Architecture: ec2types.ArchitectureValuesX8664,
+		Name:         aws.String("ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-20260218"),
+	}}}}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	if got := lm.run.Input().InstanceType; got != "g5.2xlarge" {
+		t.Errorf("InstanceType = %s, want g5.2xlarge", got)
+	}
+}
+
+func TestRecreateAddsExtraTags(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.ExtraTags = map[string]string{"CostCenter": "1234"}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+	got := tags.ToMap(lm.run.Input().TagSpecifications[0].Tags)
+	if got["CostCenter"] != "1234" || got[tags.TagOwner] != "alice" {
+		t.Errorf("instance tags = %v, want CostCenter=1234 alongside mint's", got)
+	}
+
+	// A clash with mint's tags fails while the old instance still exists.
+	lm = defaultLifecycleMocks()
+	deps = newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.ExtraTags = map[string]string{"mint:vm": "other"}
+	root = cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "mint:vm") {
+		t.Fatalf("error = %v, want a mint:vm conflict", err)
+	}
+	if lm.stop.Called {
+		t.Error("recreate stopped the VM despite the conflict")
+	}
+}
+
+func TestRecreateRejectsBadVMConfigBeforeTerminating(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"default": {"volume_size_gb": "10"},
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "[vm.default]: invalid value for volume_size_gb") {
+		t.Fatalf("err = %v, want invalid [vm.default] value", err)
+	}
+	if lm.run.Input() != nil {
+		t.Error("launched an instance despite a bad [vm.default] table")
+	}
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// defaultRemoteChunkSize is how many bytes one remote command reads during
+// a download. Each command returns its whole stdout, so the chunk bounds
+// both memory use and how much a dropped connection costs.
+const defaultRemoteChunkSize = 8 << 20
+
+// remoteFile reads a file on a VM, one remote command per call.
+type remoteFile struct {
+	run     RemoteCommandRunner
+	sendKey mintaws.SendSSHPublicKeyAPI
+	target  *vm.VM
+	port    int
+	path    string
+}
+
+// command runs script on the VM and returns its stdout.
+func (f *remoteFile) command(ctx context.Context, script string) ([]byte, error) {
+	return f.run(ctx, f.sendKey, f.target.ID, f.target.AvailabilityZone, f.target.PublicIP,
+		f.port, defaultSSHUser, []string{"sh", "-c", shellQuote(script)})
+}
+
+// stat returns the file's size in bytes and its modification time in Unix
+// seconds.
+func (f *remoteFile) stat(ctx context.Context) (size, modTime int64, err error) {
+	out, err := f.command(ctx, "sudo stat -c '%s %Y' -- "+shellQuote(f.path))
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading the size of %s: %w", f.path, err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 2 {
+		size, err = strconv.ParseInt(fields[0], 10, 64)
+		if err == nil {
+			modTime, err = strconv.ParseInt(fields[1], 10, 64)
+		}
+		if err == nil {
+			return size, modTime, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("reading the size of %s: unexpected stat output %q", f.path, strings.TrimSpace(string(out)))
+}
+
+// rangeScript returns the shell pipeline that prints n bytes of the file
+// starting at offset.
+func (f *remoteFile) rangeScript(offset, n int64) string {
+	return fmt.Sprintf("sudo tail -c +%d -- %s | head -c %d", offset+1, shellQuote(f.path), n)
+}
+
+// readRange returns up to n bytes of the file starting at offset.
+func (f *remoteFile) readRange(ctx context.Context, offset, n int64) ([]byte, error) {
+	return f.command(ctx, f.rangeScript(offset, n))
+}
+
+// rangeSHA256 returns the hex SHA-256 of n bytes of the file starting at
+// offset, hashed on the VM.
+func (f *remoteFile) rangeSHA256(ctx context.Context, offset, n int64) (string, error) {
+	out, err := f.command(ctx, f.rangeScript(offset, n)+" | sha256sum")
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", f.path, err)
+	}
+	sum, _, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	if len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("hashing %s: unexpected sha256sum output %q", f.path, strings.TrimSpace(string(out)))
+	}
+	return sum, nil
+}
+
+// partialDownload is the sidecar of a partial download, saved as
+// <dest>.partial.json next to <dest>.partial.
+type partialDownload struct {
+	Path    string `json:"path"`
+	Start   int64  `json:"start"` // offset in the remote file of the partial's first byte
+	Size    int64  `json:"size"`  // remote size when the partial was last written
+	ModTime int64  `json:"mtime"` // remote modification time, Unix seconds
+	Written int64  `json:"written"`
+	// PrefixSHA256 is the hex SHA-256 of the Written bytes in the partial.
+	PrefixSHA256 string `json:"prefix_sha256"`
+}
+
+// downloadResult describes a finished download.
+type downloadResult struct {
+	Bytes       int64 // length of the downloaded file
+	ResumedFrom int64 // bytes reused from a partial download, zero when it started over
+}
+
+// downloadRemoteFile copies the remote file from offset start to its end
+// into dest, chunk bytes per remote command. Bytes land in dest.partial,
+// and after every chunk the sidecar records the remote file and a hash of
+// what the partial holds. When a partial from an earlier run exists, it is
+// resumed, from its own start offset, if the remote file still has the
+// same bytes there; otherwise it is discarded with a note on w. The
+// finished file is checked against a hash of the same range on the VM
+// before it is renamed to dest.
+func downloadRemoteFile(ctx context.Context, f *remoteFile, start int64, dest string, chunk int64, w io.Writer) (downloadResult, error) {
+	if chunk <= 0 {
+		chunk = defaultRemoteChunkSize
+	}
+	partialPath := dest + ".partial"
+	sidecarPath := partialPath + ".json"
+
+	size, modTime, err := f.stat(ctx)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	start = min(start, size)
+
+	state, digest, err := resumePartial(ctx, f, partialPath, sidecarPath, size, w)
+	if err != nil {
+		return downloadResult{}, err
+	}
+	if state == nil {
+		state = &partialDownload{Path: f.path, Start: start}
+		digest = sha256.New()
+	} else {
+		fmt.Fprintf(w, "Resuming the download of %s at byte %d of %d.\n", dest, state.Written, size-state.Start)
+	}
+	resumedFrom := state.Written
+
+	file, err := os.OpenFile(partialPath, os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return downloadResult{}, fmt.Errorf("opening %s: %w", partialPath, err)
+	}
+	defer file.Close()
+	if err := file.Truncate(state.Written); err != nil {
+		return downloadResult{}, fmt.Errorf("truncating %s: %w", partialPath, err)
+	}
+	if _, err := file.Seek(state.Written, io.SeekStart); err != nil {
+		return downloadResult{}, fmt.Errorf("seeking %s: %w", partialPath, err)
+	}
+
+	state.Size, state.ModTime = size, modTime
+	total := size - state.Start
+	for state.Written < total {
+		data, err := f.readRange(ctx, state.Start+state.Written, min(chunk, total-state.Written))
+		if err == nil && len(data) == 0 {
+			err = errors.New("the file ended early")
+		}
+		if err != nil {
+			return downloadResult{}, fmt.Errorf("reading %s at byte %d: %w (kept %s; rerun the command to resume)",
+				f.path, state.Start+state.Written, err, partialPath)
+		}
+		if _, err := file.Write(data); err != nil {
+			return downloadResult{}, fmt.Errorf("writing %s: %w", partialPath, err)
+		}
+		digest.Write(data)
+		state.Written += int64(len(data))
+		state.PrefixSHA256 = hex.EncodeToString(digest.Sum(nil))
+		if err := savePartialDownload(sidecarPath, state); err != nil {
+			return downloadResult{}, err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return downloadResult{}, fmt.Errorf("writing %s: %w", partialPath, err)
+	}
+
+	remoteSum, err := f.rangeSHA256(ctx, state.Start, state.Written)
+	if err != nil {
+		return downloadResult{}, fmt.Errorf("verifying %s: %w (kept %s; rerun the command to retry)", dest, err, partialPath)
+	}
+	if localSum := hex.EncodeToString(digest.Sum(nil)); localSum != remoteSum {
+		os.Remove(partialPath)
+		os.Remove(sidecarPath)
+		return downloadResult{}, fmt.Errorf("checksum mismatch for %s: downloaded sha256 %s, the VM has %s — discarded the download; rerun the command to fetch it again",
+			dest, localSum, remoteSum)
+	}
+	if err := os.Rename(partialPath, dest); err != nil {
+		return downloadResult{}, fmt.Errorf("saving %s: %w", dest, err)
+	}
+	os.Remove(sidecarPath)
+	return downloadResult{Bytes: state.Written, ResumedFrom: resumedFrom}, nil
+}
+
+// resumePartial loads the sidecar of an earlier download and checks that
+// its partial can be resumed: the partial still holds the bytes the
+// sidecar hashed, and the remote file has the same bytes at the same
+// offset. It returns the sidecar and a hash primed with the partial's
+// bytes, or nil when there is nothing to resume. A partial that fails a
+// check is noted on w and started over.
+func resumePartial(ctx context.Context, f *remoteFile, partialPath, sidecarPath string, size int64, w io.Writer) (*partialDownload, hash.Hash, error) {
+	data, err := os.ReadFile(sidecarPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", sidecarPath, err)
+	}
+
+	reason := ""
+	var state partialDownload
+	digest := sha256.New()
+	switch {
+	case json.Unmarshal(data, &state) != nil:
+		reason = "its sidecar is not valid JSON"
+	case state.Path != f.path:
+		reason = fmt.Sprintf("it is a download of %s", state.Path)
+	case state.Start+state.Written > size:
+		reason = fmt.Sprintf("the remote file shrank from %d to %d bytes", state.Size, size)
+	default:
+		reason, err = checkLocalPrefix(partialPath, &state, digest)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if reason == "" && state.Written > 0 {
+		remoteSum, err := f.rangeSHA256(ctx, state.Start, state.Written)
+		if err != nil {
+			return nil, nil, err
+		}
+		if remoteSum != state.PrefixSHA256 {
+			reason = "the remote file changed"
+		}
+	}
+	if reason != "" {
+		fmt.Fprintf(w, "Note: starting the download over: the partial download %s cannot be resumed (%s).\n", partialPath, reason)
+		return nil, nil, nil
+	}
+	return &state, digest, nil
+}
+
+// checkLocalPrefix hashes the first state.Written bytes of the partial into
+// digest and returns why the partial cannot be resumed, or "" when they
+// match the sidecar.
+func checkLocalPrefix(partialPath string, state *partialDownload, digest hash.Hash) (string, error) {
+	file, err := os.Open(partialPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "the partial file is missing", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", partialPath, err)
+	}
+	defer file.Close()
+	n, err := io.Copy(digest, io.LimitReader(file, state.Written))
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", partialPath, err)
+	}
+	if n < state.Written {
+		return fmt.Sprintf("the partial file is %d bytes, the sidecar expects %d", n, state.Written), nil
+	}
+	if hex.EncodeToString(digest.Sum(nil)) != state.PrefixSHA256 {
+		return "the partial file does not match its sidecar", nil
+	}
+	return "", nil
+}
+
+// savePartialDownload writes the sidecar, replacing it atomically so a
+// crash leaves either the old or the new record.
+func savePartialDownload(path string, state *partialDownload) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// fakeRemoteFile serves content for the stat, ranged read, and sha256sum
+// commands remoteFile runs. The failRead-th range read (1-based) fails, and
+// corrupt flips the first byte of every range read; hashes are always taken
+// over content. Any other command returns other.
+type fakeRemoteFile struct {
+	content  []byte
+	failRead int
+	corrupt  bool
+	other    []byte
+	reads    []int64 // offset of every range read
+}
+
+var fakeRangeRE = regexp.MustCompile(`tail -c \+(\d+) -- .* \| head -c (\d+)`)
+
+func (f *fakeRemoteFile) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	script := strings.Join(command, " ")
+	if strings.Contains(script, "stat -c") {
+		return []byte(strconv.Itoa(len(f.content)) + " 1760529600\n"), nil
+	}
+	m := fakeRangeRE.FindStringSubmatch(script)
+	if m == nil {
+		return f.other, nil
+	}
+	from, _ := strconv.Atoi(m[1])
+	n, _ := strconv.Atoi(m[2])
+	from = min(from-1, len(f.content))
+	data := append([]byte(nil), f.content[from:min(from+n, len(f.content))]...)
+	if strings.HasSuffix(script, "| sha256sum'") {
+		sum := sha256.Sum256(data)
+		return []byte(hex.EncodeToString(sum[:]) + "  -\n"), nil
+	}
+	f.reads = append(f.reads, int64(from))
+	if len(f.reads) == f.failRead {
+		return nil, errSSHRefused
+	}
+	if f.corrupt && len(data) > 0 {
+		data[0] ^= 0xff
+	}
+	return data, nil
+}
+
+func (f *fakeRemoteFile) file() *remoteFile {
+	return &remoteFile{
+		run:    f.run,
+		target: &vm.VM{ID: "i-abc123", PublicIP: "1.2.3.4", AvailabilityZone: "us-east-1a"},
+		port:   defaultSSHPort,
+		path:   bootstrapLogPath,
+	}
+}
+
+func testLogContent() []byte {
+	var b bytes.Buffer
+	for i := range 10 {
+		b.WriteString("[mint-bootstrap] step " + strconv.Itoa(i) + "\n")
+	}
+	return b.Bytes()
+}
+
+func TestDownloadRemoteFileResumesInterruptedTransfer(t *testing.T) {
+	content := testLogContent()
+	dest := filepath.Join(t.TempDir(), "bootstrap.log")
+	remote := &fakeRemoteFile{content: content, failRead: 3}
+
+	_, err := downloadRemoteFile(context.Background(), remote.file(), 10, dest, 16, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "rerun the command to resume") {
+		t.Fatalf("interrupted download error = %v", err)
+	}
+	partial, err := os.ReadFile(dest + ".partial")
+	if err != nil {
+		t.Fatalf("reading partial: %v", err)
+	}
+	if !bytes.Equal(partial, content[10:42]) {
+		t.Errorf("partial = %q, want %q", partial, content[10:42])
+	}
+	var state partialDownload
+	data, err := os.ReadFile(dest + ".partial.json")
+	if err != nil {
+		t.Fatalf("reading sidecar: %v", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("parsing sidecar: %v", err)
+	}
+	prefix := sha256.Sum256(content[10:42])
+	want := partialDownload{Path: bootstrapLogPath, Start: 10, Size: int64(len(content)), ModTime: 1760529600,
+		Written: 32, PrefixSHA256: hex.EncodeToString(prefix[:])}
+	if state != want {
+		t.Errorf("sidecar = %+v, want %+v", state, want)
+	}
+
+	// The rerun asks for a different start, as --since does when time has
+	// passed; the partial keeps its own.
+	remote.failRead, remote.reads = 0, nil
+	var notes bytes.Buffer
+	result, err := downloadRemoteFile(context.Background(), remote.file(), 20, dest, 16, &notes)
+	if err != nil {
+		t.Fatalf("resumed download: %v", err)
+	}
+	if result.ResumedFrom != 32 || result.Bytes != int64(len(content)-10) {
+		t.Errorf("result = %+v, want 32 bytes resumed of %d", result, len(content)-10)
+	}
+	if len(remote.reads) == 0 || remote.reads[0] != 42 {
+		t.Errorf("resumed reads at %v, want the first at 42", remote.reads)
+	}
+	if !strings.Contains(notes.String(), "Resuming the download") {
+		t.Errorf("notes = %q, want a resume note", notes.String())
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("reading download: %v", err)
+	}
+	if !bytes.Equal(got, content[10:]) {
+		t.Errorf("download = %q, want %q", got, content[10:])
+	}
+	for _, leftover := range []string{dest + ".partial", dest + ".partial.json"} {
+		if _, err := os.Stat(leftover); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s left behind (stat err %v)", leftover, err)
+		}
+	}
+}
+
+func TestDownloadRemoteFileRestartsWhenPrefixChanged(t *testing.T) {
+	content := testLogContent()
+	dest := filepath.Join(t.TempDir(), "bootstrap.log")
+	remote := &fakeRemoteFile{content: content, failRead: 2}
+	if _, err := downloadRemoteFile(context.Background(), remote.file(), 0, dest, 16, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+
+	// The log was rotated: the bytes the partial holds are gone.
+	changed := append([]byte("-- rotated --\n"), content...)
+	remote.content, remote.failRead, remote.reads = changed, 0, nil
+	var notes bytes.Buffer
+	result, err := downloadRemoteFile(context.Background(), remote.file(), 0, dest, 16, &notes)
+	if err != nil {
+		t.Fatalf("restarted download: %v", err)
+	}
+	if result.ResumedFrom != 0 {
+		t.Errorf("ResumedFrom = %d, want a restart", result.ResumedFrom)
+	}
+	if len(remote.reads) == 0 || remote.reads[0] != 0 {
+		t.Errorf("restarted reads at %v, want the first at 0", remote.reads)
+	}
+	if !strings.Contains(notes.String(), "the remote file changed") {
+		t.Errorf("notes = %q, want the prefix mismatch", notes.String())
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, changed) {
+		t.Errorf("download = %q, want %q", got, changed)
+	}
+}
+
+func TestDownloadRemoteFileRestartsWhenPartialEdited(t *testing.T) {
+	content := testLogContent()
+	dest := filepath.Join(t.TempDir(), "bootstrap.log")
+	remote := &fakeRemoteFile{content: content, failRead: 2}
+	if _, err := downloadRemoteFile(context.Background(), remote.file(), 0, dest, 16, &bytes.Buffer{}); err == nil {
+		t.Fatal("expected the interrupted download to fail")
+	}
+	if err := os.WriteFile(dest+".partial", []byte("edited locally..."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote.failRead = 0
+	var notes bytes.Buffer
+	result, err := downloadRemoteFile(context.Background(), remote.file(), 0, dest, 16, &notes)
+	if err != nil {
+		t.Fatalf("restarted download: %v", err)
+	}
+	if result.ResumedFrom != 0 || !strings.Contains(notes.String(), "does not match its sidecar") {
+		t.Errorf("result = %+v, notes = %q, want a restart", result, notes.String())
+	}
+	got, _ := os.ReadFile(dest)
+	if !bytes.Equal(got, content) {
+		t.Errorf("download = %q, want %q", got, content)
+	}
+}
+
+func TestDownloadRemoteFileChecksumMismatchDiscardsDownload(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "bootstrap.log")
+	remote := &fakeRemoteFile{content: testLogContent(), corrupt: true}
+
+	_, err := downloadRemoteFile(context.Background(), remote.file(), 0, dest, 16, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("error = %v, want a checksum mismatch", err)
+	}
+	for _, path := range []string{dest, dest + ".partial", dest + ".partial.json"} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s exists after a checksum mismatch (stat err %v)", path, err)
+		}
+	}
+}
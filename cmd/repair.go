@@ -0,0 +1,504 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// repairTagsDeps holds the injectable dependencies for the repair tags command.
+type repairTagsDeps struct {
+	describe          mintaws.DescribeInstancesAPI
+	describeVolumes   mintaws.DescribeVolumesAPI
+	describeAddresses mintaws.DescribeAddressesAPI
+	createTags        mintaws.CreateTagsAPI
+	owner             string
+	ownerARN          string
+}
+
+// newRepairCommand creates the repair parent command.
+func newRepairCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Repair mint-managed resources",
+		Long:  "Repair mint-managed AWS resources that have drifted from what mint expects. Use subcommands to choose what to repair.",
+	}
+
+	cmd.AddCommand(newRepairTagsCommand())
+
+	return cmd
+}
+
+// newRepairTagsCommand creates the production repair tags command.
+func newRepairTagsCommand() *cobra.Command {
+	return newRepairTagsCommandWithDeps(nil)
+}
+
+// newRepairTagsCommandWithDeps creates the repair tags command with explicit
+// dependencies for testing.
+func newRepairTagsCommandWithDeps(deps *repairTagsDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "Restore missing mint tags on a VM and its volumes and Elastic IP",
+		Long: "Restore the mint tags that discovery relies on when they have been removed from a VM's " +
+			"instance, project volumes, or Elastic IP. Without --instance-id, mint looks for likely " +
+			"candidates: instances named mint/<owner>/<vm> and instances whose attached volume or " +
+			"Elastic IP still carries your mint tags. Only missing tags are added; existing values, " +
+			"such as the bootstrap status, are never changed.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runRepairTags(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runRepairTags(cmd, &repairTagsDeps{
+				describe:          clients.ec2Client,
+				describeVolumes:   clients.ec2Client,
+				describeAddresses: clients.ec2Client,
+				createTags:        clients.ec2Client,
+				owner:             clients.owner,
+				ownerARN:          clients.ownerARN,
+			})
+		},
+	}
+
+	cmd.Flags().String("instance-id", "", "Instance to repair (default: discover candidates)")
+
+	return cmd
+}
+
+// tagRepairResource is one resource in a repair plan and the tags it lacks.
+type tagRepairResource struct {
+	id   string
+	kind string
+	diff tags.TagDiff
+}
+
+// tagRepairPlan is the set of tag changes for one instance and the volumes
+// and Elastic IP attached to it.
+type tagRepairPlan struct {
+	instanceID string
+	vmName     string
+	resources  []tagRepairResource
+}
+
+// missingCount returns the number of tags the plan would add.
+func (p *tagRepairPlan) missingCount() int {
+	n := 0
+	for _, r := range p.resources {
+		n += len(r.diff.Missing)
+	}
+	return n
+}
+
+// runRepairTags executes the repair tags command logic: pick the instance,
+// plan the missing tags, confirm, and apply.
+func runRepairTags(cmd *cobra.Command, deps *repairTagsDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	fallbackVM := "default"
+	yes := false
+	if cliCtx != nil {
+		fallbackVM = cliCtx.VM
+		yes = cliCtx.Yes
+	}
+	w := cmd.OutOrStdout()
+
+	instanceID, _ := cmd.Flags().GetString("instance-id")
+	var inst *ec2types.Instance
+	var refTags map[string]string
+	if instanceID != "" {
+		out, err := deps.describe.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceID}})
+		if err != nil {
+			return fmt.Errorf("describe instance %s: %w", instanceID, err)
+		}
+		instances := liveInstances(out)
+		if len(instances) == 0 {
+			return fmt.Errorf("instance %s not found or terminated", instanceID)
+		}
+		inst = &instances[0]
+	} else {
+		candidates, err := findRepairCandidates(ctx, deps, fallbackVM)
+		if err != nil {
+			return err
+		}
+		switch len(candidates) {
+		case 0:
+			fmt.Fprintln(w, "No instances with missing mint tags found.")
+			return nil
+		case 1:
+			inst = &candidates[0].instance
+			refTags = candidates[0].refTags
+			fmt.Fprintf(w, "Found candidate %s (%s).\n", aws.ToString(inst.InstanceId), candidates[0].reason)
+		default:
+			fmt.Fprintln(w, "Several instances have missing mint tags:")
+			for _, c := range candidates {
+				fmt.Fprintf(w, "  %s  VM %q (%s)\n", aws.ToString(c.instance.InstanceId), c.vmName, c.reason)
+			}
+			return fmt.Errorf("choose one with %s", hint.Cmd("mint repair tags --instance-id <id>"))
+		}
+	}
+
+	plan, err := planTagRepair(ctx, deps, *inst, refTags, fallbackVM)
+	if err != nil {
+		return err
+	}
+	writeTagRepairPlan(w, plan)
+	if plan.missingCount() == 0 {
+		fmt.Fprintln(w, "\nNo mint tags are missing — nothing to repair.")
+		return nil
+	}
+
+	if !yes {
+		fmt.Fprintf(w, "\nType the instance ID %q to confirm: ", plan.instanceID)
+		scanner := bufio.NewScanner(cmd.InOrStdin())
+		if !scanner.Scan() {
+			return fmt.Errorf("no confirmation input received — repair aborted")
+		}
+		if input := strings.TrimSpace(scanner.Text()); input != plan.instanceID {
+			return fmt.Errorf("confirmation %q does not match instance ID %q — repair aborted", input, plan.instanceID)
+		}
+	}
+
+	if err := applyTagRepair(ctx, deps.createTags, plan); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Restored %d tags. VM %q is discoverable again — check it with %s.\n",
+		plan.missingCount(), plan.vmName, hint.Cmd("mint status --vm "+plan.vmName))
+	return nil
+}
+
+// repairCandidate is an instance that looks like one of the caller's VMs
+// but is missing mint tags.
+type repairCandidate struct {
+	instance ec2types.Instance
+	vmName   string
+	reason   string
+	// refTags are the mint tags of the volume or Elastic IP that led to the
+	// instance, or nil when it was found by its Name tag.
+	refTags map[string]string
+}
+
+// findRepairCandidates returns the instances that are probably the caller's
+// VMs but lack instance mint tags: those whose Name tag follows the
+// mint/<owner>/<vm> convention, and those with an attached volume or
+// associated Elastic IP still tagged for the owner.
+func findRepairCandidates(ctx context.Context, deps *repairTagsDeps, fallbackVM string) ([]repairCandidate, error) {
+	var candidates []repairCandidate
+	seen := make(map[string]bool)
+	consider := func(inst ec2types.Instance, refTags map[string]string, reason string) {
+		id := aws.ToString(inst.InstanceId)
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		current := tags.ToMap(inst.Tags)
+		if checkRepairOwner(id, current, deps.owner) != nil {
+			return
+		}
+		vmName := inferVMName(deps.owner, current, refTags, fallbackVM)
+		canonical := instanceCanonicalTags(deps.owner, deps.ownerARN, vmName)
+		if tags.Diff(current, canonical).Empty() {
+			return
+		}
+		candidates = append(candidates, repairCandidate{instance: inst, vmName: vmName, reason: reason, refTags: refTags})
+	}
+
+	out, err := deps.describe.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag:" + tags.TagName), Values: []string{"mint/" + deps.owner + "/*"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe instances: %w", err)
+	}
+	for _, inst := range liveInstances(out) {
+		consider(inst, nil, "Name tag "+tags.ToMap(inst.Tags)[tags.TagName])
+	}
+
+	refs, order, err := referencedInstances(ctx, deps.describeVolumes, deps.describeAddresses, deps.owner)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, id := range order {
+		if !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return candidates, nil
+	}
+	out, err = deps.describe.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: ids})
+	if err != nil {
+		return nil, fmt.Errorf("describe instances: %w", err)
+	}
+	for _, inst := range liveInstances(out) {
+		ref := refs[aws.ToString(inst.InstanceId)]
+		consider(inst, ref.tags, ref.reason)
+	}
+	return candidates, nil
+}
+
+// instanceReference is the owner-tagged volume or Elastic IP attached to an
+// instance.
+type instanceReference struct {
+	tags   map[string]string
+	reason string
+}
+
+// referencedInstances returns the instances that the owner's tagged volumes
+// and Elastic IPs are attached to, keyed by instance ID, along with the IDs
+// in discovery order.
+func referencedInstances(ctx context.Context, describeVolumes mintaws.DescribeVolumesAPI, describeAddresses mintaws.DescribeAddressesAPI, owner string) (map[string]instanceReference, []string, error) {
+	refs := make(map[string]instanceReference)
+	var order []string
+	add := func(instanceID string, ref instanceReference) {
+		if instanceID == "" {
+			return
+		}
+		if _, ok := refs[instanceID]; ok {
+			return
+		}
+		refs[instanceID] = ref
+		order = append(order, instanceID)
+	}
+
+	volOut, err := describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{Filters: tags.FilterByOwner(owner)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("describe volumes: %w", err)
+	}
+	for _, vol := range volOut.Volumes {
+		for _, att := range vol.Attachments {
+			add(aws.ToString(att.InstanceId), instanceReference{
+				tags:   tags.ToMap(vol.Tags),
+				reason: "attached volume " + aws.ToString(vol.VolumeId),
+			})
+		}
+	}
+
+	addrOut, err := describeAddresses.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{Filters: tags.FilterByOwner(owner)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("describe addresses: %w", err)
+	}
+	for _, addr := range addrOut.Addresses {
+		add(aws.ToString(addr.InstanceId), instanceReference{
+			tags:   tags.ToMap(addr.Tags),
+			reason: "Elastic IP " + aws.ToString(addr.PublicIp),
+		})
+	}
+	return refs, order, nil
+}
+
+// planTagRepair builds the repair plan for inst. The VM name is taken from
+// the surviving tags where possible; refTags are the tags of the volume or
+// Elastic IP the instance was discovered through, if any.
+func planTagRepair(ctx context.Context, deps *repairTagsDeps, inst ec2types.Instance, refTags map[string]string, fallbackVM string) (*tagRepairPlan, error) {
+	instanceID := aws.ToString(inst.InstanceId)
+	current := tags.ToMap(inst.Tags)
+	if err := checkRepairOwner(instanceID, current, deps.owner); err != nil {
+		return nil, err
+	}
+
+	volOut, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("attachment.instance-id"), Values: []string{instanceID}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe volumes for %s: %w", instanceID, err)
+	}
+	var volumes []ec2types.Volume
+	for _, vol := range volOut.Volumes {
+		// The root volume is deleted with the instance and never carries
+		// mint tags, so it is not part of the repair.
+		if isRootAttachment(vol, inst) {
+			continue
+		}
+		volumes = append(volumes, vol)
+	}
+
+	addrOut, err := deps.describeAddresses.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("instance-id"), Values: []string{instanceID}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe addresses for %s: %w", instanceID, err)
+	}
+
+	if refTags == nil {
+		for _, vol := range volumes {
+			if m := tags.ToMap(vol.Tags); m[tags.TagVM] != "" {
+				refTags = m
+				break
+			}
+		}
+	}
+	if refTags == nil {
+		for _, addr := range addrOut.Addresses {
+			if m := tags.ToMap(addr.Tags); m[tags.TagVM] != "" {
+				refTags = m
+				break
+			}
+		}
+	}
+	vmName := inferVMName(deps.owner, current, refTags, fallbackVM)
+
+	plan := &tagRepairPlan{instanceID: instanceID, vmName: vmName}
+	plan.resources = append(plan.resources, tagRepairResource{
+		id:   instanceID,
+		kind: "instance",
+		diff: tags.Diff(current, instanceCanonicalTags(deps.owner, deps.ownerARN, vmName)),
+	})
+	for _, vol := range volumes {
+		id := aws.ToString(vol.VolumeId)
+		volTags := tags.ToMap(vol.Tags)
+		if err := checkRepairOwner(id, volTags, deps.owner); err != nil {
+			return nil, err
+		}
+		canonical := tags.NewTagBuilder(deps.owner, deps.ownerARN, vmName).WithComponent(tags.ComponentProjectVolume).Build()
+		plan.resources = append(plan.resources, tagRepairResource{id: id, kind: "volume", diff: tags.Diff(volTags, canonical)})
+	}
+	for _, addr := range addrOut.Addresses {
+		id := aws.ToString(addr.AllocationId)
+		addrTags := tags.ToMap(addr.Tags)
+		if err := checkRepairOwner(id, addrTags, deps.owner); err != nil {
+			return nil, err
+		}
+		canonical := tags.NewTagBuilder(deps.owner, deps.ownerARN, vmName).WithComponent(tags.ComponentElasticIP).Build()
+		plan.resources = append(plan.resources, tagRepairResource{id: id, kind: "elastic IP", diff: tags.Diff(addrTags, canonical)})
+	}
+	return plan, nil
+}
+
+// instanceCanonicalTags returns the tags every mint instance carries. The
+// bootstrap status is deliberately absent: only the VM knows it.
+func instanceCanonicalTags(owner, ownerARN, vmName string) []ec2types.Tag {
+	return tags.NewTagBuilder(owner, ownerARN, vmName).WithComponent(tags.ComponentInstance).Build()
+}
+
+// checkRepairOwner refuses to retag a resource that belongs to another owner.
+func checkRepairOwner(id string, current map[string]string, owner string) error {
+	if existing := current[tags.TagOwner]; existing != "" && existing != owner {
+		return fmt.Errorf("%s is tagged for owner %q, not %q — refusing to retag another user's resources", id, existing, owner)
+	}
+	return nil
+}
+
+// inferVMName picks the VM name for a repair, in order of trust: the
+// instance's own mint:vm tag, its mint/<owner>/<vm> Name tag, the mint:vm
+// tag of an attached volume or Elastic IP, and finally the --vm flag.
+func inferVMName(owner string, current, refTags map[string]string, fallback string) string {
+	if v := current[tags.TagVM]; v != "" {
+		return v
+	}
+	if v, ok := strings.CutPrefix(current[tags.TagName], "mint/"+owner+"/"); ok && v != "" && !strings.Contains(v, "/") {
+		return v
+	}
+	if v := refTags[tags.TagVM]; v != "" {
+		return v
+	}
+	return fallback
+}
+
+// isRootAttachment reports whether vol is attached to inst as its root device.
+func isRootAttachment(vol ec2types.Volume, inst ec2types.Instance) bool {
+	root := aws.ToString(inst.RootDeviceName)
+	for _, att := range vol.Attachments {
+		if aws.ToString(att.InstanceId) == aws.ToString(inst.InstanceId) && aws.ToString(att.Device) == root {
+			return true
+		}
+	}
+	return false
+}
+
+// liveInstances returns the instances in out that are not terminated or
+// shutting down.
+func liveInstances(out *ec2.DescribeInstancesOutput) []ec2types.Instance {
+	var instances []ec2types.Instance
+	for _, res := range out.Reservations {
+		for _, inst := range res.Instances {
+			if inst.State != nil && (inst.State.Name == ec2types.InstanceStateNameTerminated ||
+				inst.State.Name == ec2types.InstanceStateNameShuttingDown) {
+				continue
+			}
+			instances = append(instances, inst)
+		}
+	}
+	return instances
+}
+
+// writeTagRepairPlan prints the tags the plan adds and the conflicting
+// values it keeps.
+func writeTagRepairPlan(w io.Writer, plan *tagRepairPlan) {
+	fmt.Fprintf(w, "Tag repair for VM %q (%s):\n", plan.vmName, plan.instanceID)
+	for _, r := range plan.resources {
+		fmt.Fprintf(w, "  %s %s", r.kind, r.id)
+		if r.diff.Empty() && len(r.diff.Conflicts) == 0 {
+			fmt.Fprintln(w, ": tags complete")
+			continue
+		}
+		fmt.Fprintln(w)
+		for _, tag := range r.diff.Missing {
+			fmt.Fprintf(w, "    + %s=%s\n", aws.ToString(tag.Key), aws.ToString(tag.Value))
+		}
+		for _, c := range r.diff.Conflicts {
+			fmt.Fprintf(w, "    = %s=%s (kept; expected %s)\n", c.Key, c.Current, c.Canonical)
+		}
+	}
+}
+
+// applyTagRepair writes the plan's missing tags. Resources that lack the
+// same set of tags share one CreateTags call.
+func applyTagRepair(ctx context.Context, client mintaws.CreateTagsAPI, plan *tagRepairPlan) error {
+	type batch struct {
+		ids  []string
+		tags []ec2types.Tag
+	}
+	var batches []*batch
+	byKey := make(map[string]*batch)
+	for _, r := range plan.resources {
+		if r.diff.Empty() {
+			continue
+		}
+		var parts []string
+		for _, tag := range r.diff.Missing {
+			parts = append(parts, aws.ToString(tag.Key)+"="+aws.ToString(tag.Value))
+		}
+		key := strings.Join(parts, "\x00")
+		b, ok := byKey[key]
+		if !ok {
+			b = &batch{tags: r.diff.Missing}
+			byKey[key] = b
+			batches = append(batches, b)
+		}
+		b.ids = append(b.ids, r.id)
+	}
+
+	for _, b := range batches {
+		if _, err := client.CreateTags(ctx, &ec2.CreateTagsInput{Resources: b.ids, Tags: b.tags}); err != nil {
+			return fmt.Errorf("tagging %s: %w", strings.Join(b.ids, ", "), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+const repairOwnerARN = "arn:aws:iam::123456789012:user/alice"
+
+// mockRepairEC2 serves DescribeInstances, DescribeVolumes, and
+// DescribeAddresses from fixed resources, applying the filters repair uses.
+type mockRepairEC2 struct {
+	instances []ec2types.Instance
+	volumes   []ec2types.Volume
+	addresses []ec2types.Address
+}
+
+func (m *mockRepairEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	var matched []ec2types.Instance
+	for _, inst := range m.instances {
+		if len(params.InstanceIds) > 0 && !slices.Contains(params.InstanceIds, aws.ToString(inst.InstanceId)) {
+			continue
+		}
+		if !matchesRepairFilters(tags.ToMap(inst.Tags), params.Filters) {
+			continue
+		}
+		matched = append(matched, inst)
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: matched}}}, nil
+}
+
+func (m *mockRepairEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	var matched []ec2types.Volume
+	for _, vol := range m.volumes {
+		attachedTo := ""
+		if len(vol.Attachments) > 0 {
+			attachedTo = aws.ToString(vol.Attachments[0].InstanceId)
+		}
+		if !matchesRepairFilters(tags.ToMap(vol.Tags), params.Filters, "attachment.instance-id", attachedTo) {
+			continue
+		}
+		matched = append(matched, vol)
+	}
+	return &ec2.DescribeVolumesOutput{Volumes: matched}, nil
+}
+
+func (m *mockRepairEC2) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	var matched []ec2types.Address
+	for _, addr := range m.addresses {
+		if !matchesRepairFilters(tags.ToMap(addr.Tags), params.Filters, "instance-id", aws.ToString(addr.InstanceId)) {
+			continue
+		}
+		matched = append(matched, addr)
+	}
+	return &ec2.DescribeAddressesOutput{Addresses: matched}, nil
+}
+
+// matchesRepairFilters applies tag: filters (with a trailing * wildcard)
+// and the one attribute filter named by attr.
+func matchesRepairFilters(resourceTags map[string]string, filters []ec2types.Filter, attr ...string) bool {
+	for _, f := range filters {
+		name := aws.ToString(f.Name)
+		var have string
+		switch {
+		case strings.HasPrefix(name, "tag:"):
+			have = resourceTags[strings.TrimPrefix(name, "tag:")]
+		case len(attr) == 2 && name == attr[0]:
+			have = attr[1]
+		default:
+			continue
+		}
+		ok := false
+		for _, want := range f.Values {
+			if prefix, wild := strings.CutSuffix(want, "*"); (wild && strings.HasPrefix(have, prefix)) || have == want {
+				ok = true
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func repairTags(kv ...string) []ec2types.Tag {
+	var out []ec2types.Tag
+	for i := 0; i < len(kv); i += 2 {
+		out = append(out, ec2types.Tag{Key: aws.String(kv[i]), Value: aws.String(kv[i+1])})
+	}
+	return out
+}
+
+func repairInstance(id string, tagList []ec2types.Tag) ec2types.Instance {
+	return ec2types.Instance{
+		InstanceId:     aws.String(id),
+		RootDeviceName: aws.String("/dev/xvda"),
+		State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Tags:           tagList,
+	}
+}
+
+func repairVolume(id, instanceID, device string, tagList []ec2types.Tag) ec2types.Volume {
+	return ec2types.Volume{
+		VolumeId:    aws.String(id),
+		Attachments: []ec2types.VolumeAttachment{{InstanceId: aws.String(instanceID), Device: aws.String(device)}},
+		Tags:        tagList,
+	}
+}
+
+// strippedFleet is alice's VM "dev" after a tag-hygiene script removed every
+// mint tag from the instance except Name and the bootstrap status, and
+// mint:owner-arn from the project volume.
+func strippedFleet() *mockRepairEC2 {
+	projectVolume := tags.NewTagBuilder("alice", repairOwnerARN, "dev").WithComponent(tags.ComponentProjectVolume).Build()
+	var withoutARN []ec2types.Tag
+	for _, tag := range projectVolume {
+		if aws.ToString(tag.Key) != tags.TagOwnerARN {
+			withoutARN = append(withoutARN, tag)
+		}
+	}
+	return &mockRepairEC2{
+		instances: []ec2types.Instance{
+			repairInstance("i-dev", repairTags(tags.TagName, "mint/alice/dev", tags.TagBootstrap, tags.BootstrapComplete)),
+			repairInstance("i-healthy", tags.NewTagBuilder("alice", repairOwnerARN, "other").WithComponent(tags.ComponentInstance).Build()),
+			repairInstance("i-bob", repairTags(tags.TagName, "mint/alice/x", tags.TagOwner, "bob")),
+		},
+		volumes: []ec2types.Volume{
+			repairVolume("vol-root", "i-dev", "/dev/xvda", nil),
+			repairVolume("vol-proj", "i-dev", "/dev/xvdf", withoutARN),
+		},
+		addresses: []ec2types.Address{{
+			AllocationId: aws.String("eipalloc-dev"),
+			InstanceId:   aws.String("i-dev"),
+			PublicIp:     aws.String("1.2.3.4"),
+			Tags:         tags.NewTagBuilder("alice", repairOwnerARN, "dev").WithComponent(tags.ComponentElasticIP).Build(),
+		}},
+	}
+}
+
+func newRepairDeps(fake *mockRepairEC2, createTags *cmdtest.CreateTags) *repairTagsDeps {
+	return &repairTagsDeps{
+		describe:          fake,
+		describeVolumes:   fake,
+		describeAddresses: fake,
+		createTags:        createTags,
+		owner:             "alice",
+		ownerARN:          repairOwnerARN,
+	}
+}
+
+func TestFindRepairCandidatesByNameTag(t *testing.T) {
+	deps := newRepairDeps(strippedFleet(), &cmdtest.CreateTags{})
+
+	candidates, err := findRepairCandidates(context.Background(), deps, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// i-healthy is complete and i-bob belongs to another owner.
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1: %+v", len(candidates), candidates)
+	}
+	c := candidates[0]
+	if aws.ToString(c.instance.InstanceId) != "i-dev" || c.vmName != "dev" {
+		t.Errorf("candidate = %s vm %q, want i-dev vm \"dev\"", aws.ToString(c.instance.InstanceId), c.vmName)
+	}
+	if !strings.Contains(c.reason, "Name tag mint/alice/dev") {
+		t.Errorf("reason = %q, want Name tag", c.reason)
+	}
+}
+
+func TestFindRepairCandidatesByVolumeTags(t *testing.T) {
+	fake := strippedFleet()
+	// Name was removed too; only the volume and EIP still point at i-dev.
+	fake.instances[0].Tags = repairTags(tags.TagBootstrap, tags.BootstrapComplete)
+	deps := newRepairDeps(fake, &cmdtest.CreateTags{})
+
+	candidates, err := findRepairCandidates(context.Background(), deps, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || aws.ToString(candidates[0].instance.InstanceId) != "i-dev" {
+		t.Fatalf("candidates = %+v, want i-dev", candidates)
+	}
+	if candidates[0].vmName != "dev" {
+		t.Errorf("vmName = %q, want VM name from the volume's mint:vm tag", candidates[0].vmName)
+	}
+}
+
+func TestPlanTagRepairKeepsExistingValues(t *testing.T) {
+	fake := strippedFleet()
+	deps := newRepairDeps(fake, &cmdtest.CreateTags{})
+
+	plan, err := planTagRepair(context.Background(), deps, fake.instances[0], nil, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.vmName != "dev" {
+		t.Errorf("vmName = %q, want dev", plan.vmName)
+	}
+
+	byID := make(map[string]tagRepairResource)
+	for _, r := range plan.resources {
+		byID[r.id] = r
+	}
+	if _, ok := byID["vol-root"]; ok {
+		t.Error("root volume should not be part of the plan")
+	}
+
+	inst := byID["i-dev"]
+	var keys []string
+	for _, tag := range inst.diff.Missing {
+		keys = append(keys, aws.ToString(tag.Key))
+		if aws.ToString(tag.Key) == tags.TagBootstrap || aws.ToString(tag.Key) == tags.TagName {
+			t.Errorf("plan overwrites existing tag %s", aws.ToString(tag.Key))
+		}
+	}
+	want := []string{tags.TagMint, tags.TagOwner, tags.TagOwnerARN, tags.TagVM, tags.TagComponent}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("instance missing = %v, want %v", keys, want)
+	}
+
+	vol := byID["vol-proj"]
+	if len(vol.diff.Missing) != 1 || aws.ToString(vol.diff.Missing[0].Key) != tags.TagOwnerARN {
+		t.Errorf("volume missing = %+v, want only %s", vol.diff.Missing, tags.TagOwnerARN)
+	}
+	if eip := byID["eipalloc-dev"]; !eip.diff.Empty() {
+		t.Errorf("EIP should be complete, missing %+v", eip.diff.Missing)
+	}
+}
+
+func TestPlanTagRepairRefusesOtherOwner(t *testing.T) {
+	fake := strippedFleet()
+	deps := newRepairDeps(fake, &cmdtest.CreateTags{})
+
+	_, err := planTagRepair(context.Background(), deps, fake.instances[2], nil, "default")
+	if err == nil || !strings.Contains(err.Error(), `owner "bob"`) {
+		t.Fatalf("error = %v, want refusal naming bob", err)
+	}
+}
+
+func TestApplyTagRepairBatchesIdenticalTagSets(t *testing.T) {
+	missing := tags.NewTagBuilder("alice", repairOwnerARN, "dev").WithComponent(tags.ComponentProjectVolume).Build()
+	plan := &tagRepairPlan{
+		instanceID: "i-dev",
+		vmName:     "dev",
+		resources: []tagRepairResource{
+			{id: "i-dev", kind: "instance", diff: tags.TagDiff{Missing: repairTags(tags.TagMint, "true", tags.TagComponent, tags.ComponentInstance)}},
+			{id: "vol-a", kind: "volume", diff: tags.TagDiff{Missing: missing}},
+			{id: "vol-b", kind: "volume", diff: tags.TagDiff{Missing: missing}},
+			{id: "vol-c", kind: "volume", diff: tags.TagDiff{Missing: repairTags(tags.TagMint, "true", tags.TagComponent, tags.ComponentInstance)}},
+			{id: "eipalloc-dev", kind: "elastic IP"},
+		},
+	}
+	createTags := &cmdtest.CreateTags{}
+
+	if err := applyTagRepair(context.Background(), createTags, plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(createTags.Inputs) != 2 {
+		t.Fatalf("CreateTags called %d times, want 2", len(createTags.Inputs))
+	}
+	if got := strings.Join(createTags.Inputs[0].Resources, ","); got != "i-dev,vol-c" {
+		t.Errorf("first batch resources = %s, want i-dev,vol-c", got)
+	}
+	if got := strings.Join(createTags.Inputs[1].Resources, ","); got != "vol-a,vol-b" {
+		t.Errorf("second batch resources = %s, want vol-a,vol-b", got)
+	}
+	if len(createTags.Inputs[1].Tags) != len(missing) {
+		t.Errorf("second batch has %d tags, want %d", len(createTags.Inputs[1].Tags), len(missing))
+	}
+}
+
+func TestRepairTagsCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		wantErrContain string
+		wantOutput     []string
+		wantCalls      int
+	}{
+		{
+			name:       "discovers and repairs with --yes",
+			args:       []string{"repair", "tags", "--yes"},
+			wantOutput: []string{"Found candidate i-dev", "+ mint:owner=alice", "elastic IP eipalloc-dev: tags complete", "Restored 6 tags"},
+			wantCalls:  2,
+		},
+		{
+			name:       "explicit instance confirmed",
+			args:       []string{"repair", "tags", "--instance-id", "i-dev"},
+			stdin:      "i-dev\n",
+			wantOutput: []string{"Type the instance ID", "Restored 6 tags"},
+			wantCalls:  2,
+		},
+		{
+			name:           "confirmation mismatch aborts",
+			args:           []string{"repair", "tags", "--instance-id", "i-dev"},
+			stdin:          "no\n",
+			wantErrContain: "repair aborted",
+		},
+		{
+			name:       "complete instance needs nothing",
+			args:       []string{"repair", "tags", "--instance-id", "i-healthy"},
+			wantOutput: []string{"nothing to repair"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			createTags := &cmdtest.CreateTags{}
+			root := cmdtest.NewRoot()
+			repair := newRepairCommand()
+			repair.RemoveCommand(repair.Commands()...)
+			repair.AddCommand(newRepairTagsCommandWithDeps(newRepairDeps(strippedFleet(), createTags)))
+			root.AddCommand(repair)
+
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetIn(strings.NewReader(tt.stdin))
+			root.SetArgs(tt.args)
+			err := root.Execute()
+
+			if tt.wantErrContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErrContain)
+				}
+				if len(createTags.Inputs) != 0 {
+					t.Errorf("CreateTags called %d times after abort", len(createTags.Inputs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out.String())
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output missing %q:\n%s", want, out.String())
+				}
+			}
+			if len(createTags.Inputs) != tt.wantCalls {
+				t.Errorf("CreateTags called %d times, want %d", len(createTags.Inputs), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoctorResourceTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		fake        *mockRepairEC2
+		wantStatus  string
+		wantContain []string
+	}{
+		{
+			name:        "stripped instance points to repair",
+			fake:        strippedFleet(),
+			wantStatus:  "WARN",
+			wantContain: []string{"instance i-dev", `VM "dev"`, "mint:owner", "mint repair tags --instance-id i-dev"},
+		},
+		{
+			name: "consistent",
+			fake: func() *mockRepairEC2 {
+				f := strippedFleet()
+				f.instances[0].Tags = tags.NewTagBuilder("alice", repairOwnerARN, "dev").
+					WithComponent(tags.ComponentInstance).WithBootstrap(tags.BootstrapComplete).Build()
+				return f
+			}(),
+			wantStatus:  "PASS",
+			wantContain: []string{"1 instance(s) consistent"},
+		},
+		{
+			name:        "nothing attached",
+			fake:        &mockRepairEC2{},
+			wantStatus:  "PASS",
+			wantContain: []string{"no attached volumes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkResourceTags(context.Background(), &doctorDeps{
+				describe:          tt.fake,
+				describeVolumes:   tt.fake,
+				describeAddresses: tt.fake,
+				owner:             "alice",
+			})
+			if got.status != tt.wantStatus {
+				t.Errorf("status = %s, want %s (%s)", got.status, tt.wantStatus, got.message)
+			}
+			for _, want := range tt.wantContain {
+				if !strings.Contains(got.message, want) {
+					t.Errorf("message %q missing %q", got.message, want)
+				}
+			}
+		})
+	}
+}
@@ -12,8 +12,11 @@ import (
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
 
@@ -27,6 +30,9 @@ type resizeDeps struct {
 	start         mintaws.StartInstancesAPI
 	owner         string
 	region        string
+	selfDetector  *selfcheck.Detector // nil skips the self-target guard
+	typeCacheDir  string              // instance type catalog cache; "" caches in memory only
+	locker        *provision.Locker   // nil takes no operation lock
 }
 
 // WithWaitStopped sets the waiter used to poll until the instance reaches the
@@ -44,12 +50,14 @@ func newResizeCommand() *cobra.Command {
 // newResizeCommandWithDeps creates the resize command with explicit dependencies
 // for testing. When deps is nil, the command wires real AWS clients.
 func newResizeCommandWithDeps(deps *resizeDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "resize <instance-type>",
 		Short: "Change the VM instance type",
 		Long: "Stop the VM, change its instance type, and restart it. " +
 			"If the VM is already stopped, only the instance type is changed " +
-			"(the VM remains stopped).",
+			"(the VM remains stopped).\n\n" +
+			"The resize is refused while another mint command holds the VM's operation lock; " +
+			"--steal-lock takes it anyway.",
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -68,9 +76,18 @@ func newResizeCommandWithDeps(deps *resizeDeps) *cobra.Command {
 				start:         clients.ec2Client,
 				owner:         clients.owner,
 				region:        clients.mintConfig.Region,
+				selfDetector:  selfcheck.Default(),
+				typeCacheDir:  config.DefaultConfigDir(),
+				locker:        newOperationLocker(cmd, clients.ec2Client),
 			}, args[0])
 		},
 	}
+
+	addSelfTargetFlag(cmd)
+	addSkipTypeValidationFlag(cmd)
+	addStealLockFlag(cmd)
+
+	return cmd
 }
 
 // runResize executes the resize command logic: discover VM, validate type,
@@ -114,16 +131,37 @@ func runResize(cmd *cobra.Command, deps *resizeDeps, newType string) error {
 	}
 
 	// Validate instance type against AWS API.
-	sp.Update(fmt.Sprintf("Validating instance type %q...", newType))
+	var typeWarning string
+	if skip, _ := cmd.Flags().GetBool("skip-type-validation"); !skip {
+		sp.Update(fmt.Sprintf("Validating instance type %q...", newType))
+
+		validator := mintaws.NewInstanceTypeValidator(deps.describeTypes).WithCacheDir(deps.typeCacheDir)
+		typeWarning, err = validator.Check(ctx, newType, deps.region)
+		if err != nil {
+			sp.Fail(err.Error())
+			return fmt.Errorf("invalid instance type: %w", err)
+		}
+	}
 
-	validator := mintaws.NewInstanceTypeValidator(deps.describeTypes)
-	if err := validator.Validate(ctx, newType, deps.region); err != nil {
+	release, err := lockVM(ctx, cmd, deps.locker, found, "resize")
+	if err != nil {
 		sp.Fail(err.Error())
-		return fmt.Errorf("invalid instance type: %w", err)
+		return err
 	}
+	defer release()
 
 	wasRunning := state == ec2types.InstanceStateNameRunning
 
+	// Resizing a running VM stops it. Pause the spinner so the self-target
+	// warning is not overwritten.
+	if wasRunning && deps.selfDetector != nil && deps.selfDetector.IsLocalInstance(ctx, found.ID) {
+		sp.Stop("")
+		if err := guardSelfTarget(ctx, cmd, deps.selfDetector, vmName, found.ID, "stop and restart this VM"); err != nil {
+			return err
+		}
+		sp.Start(fmt.Sprintf("Resizing VM %q...", vmName))
+	}
+
 	// Stop instance if running.
 	if wasRunning {
 		sp.Update(fmt.Sprintf("Stopping instance %s...", found.ID))
@@ -162,6 +200,7 @@ func runResize(cmd *cobra.Command, deps *resizeDeps, newType string) error {
 		sp.Fail(err.Error())
 		return fmt.Errorf("modifying instance type: %w", err)
 	}
+	cliCtx.TouchResource(cli.ResourceInstance, found.ID)
 
 	// Restart instance if it was running before.
 	if wasRunning {
@@ -179,5 +218,8 @@ func runResize(cmd *cobra.Command, deps *resizeDeps, newType string) error {
 	// sp.Stop clears the spinner line in interactive mode before we print.
 	sp.Stop("")
 	fmt.Fprintf(w, "VM %q (%s) resized to %s.\n", vmName, found.ID, newType)
+	if typeWarning != "" {
+		fmt.Fprintf(w, "Warning: %s\n", typeWarning)
+	}
 	return nil
 }
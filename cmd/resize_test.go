@@ -8,27 +8,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/spf13/cobra"
 )
 
 // ---------------------------------------------------------------------------
 // Inline mocks for resize command tests
 // ---------------------------------------------------------------------------
 
-type mockResizeDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockResizeDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
 type mockResizeDescribeInstanceTypes struct {
 	output *ec2.DescribeInstanceTypesOutput
 	err    error
@@ -38,19 +30,6 @@ func (m *mockResizeDescribeInstanceTypes) DescribeInstanceTypes(ctx context.Cont
 	return m.output, m.err
 }
 
-type mockResizeStopInstances struct {
-	output *ec2.StopInstancesOutput
-	err    error
-	called bool
-	input  *ec2.StopInstancesInput
-}
-
-func (m *mockResizeStopInstances) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
-	m.called = true
-	m.input = params
-	return m.output, m.err
-}
-
 type mockResizeModifyInstanceAttribute struct {
 	output *ec2.ModifyInstanceAttributeOutput
 	err    error
@@ -64,19 +43,6 @@ func (m *mockResizeModifyInstanceAttribute) ModifyInstanceAttribute(ctx context.
 	return m.output, m.err
 }
 
-type mockResizeStartInstances struct {
-	output *ec2.StartInstancesOutput
-	err    error
-	called bool
-	input  *ec2.StartInstancesInput
-}
-
-func (m *mockResizeStartInstances) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
-	m.called = true
-	m.input = params
-	return m.output, m.err
-}
-
 type mockResizeWaitStopped struct {
 	err    error
 	called bool
@@ -115,38 +81,19 @@ func validInstanceTypeOutput() *ec2.DescribeInstanceTypesOutput {
 	return &ec2.DescribeInstanceTypesOutput{
 		InstanceTypes: []ec2types.InstanceTypeInfo{
 			{InstanceType: ec2types.InstanceTypeM6iXlarge},
+			{InstanceType: ec2types.InstanceTypeC52xlarge},
 		},
 	}
 }
 
-func newResizeTestRoot(sub *cobra.Command) *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	root.AddCommand(sub)
-	return root
-}
-
 func newHappyResizeDeps(owner string) *resizeDeps {
 	return &resizeDeps{
-		describe:      &mockResizeDescribeInstances{output: makeResizeInstance("i-abc123", "default", owner, "t3.medium", ec2types.InstanceStateNameRunning)},
+		describe:      &cmdtest.DescribeInstances{Output: makeResizeInstance("i-abc123", "default", owner, "t3.medium", ec2types.InstanceStateNameRunning)},
 		describeTypes: &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()},
-		stop:          &mockResizeStopInstances{output: &ec2.StopInstancesOutput{}},
+		stop:          &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}},
 		waitStopped:   &mockResizeWaitStopped{},
 		modify:        &mockResizeModifyInstanceAttribute{output: &ec2.ModifyInstanceAttributeOutput{}},
-		start:         &mockResizeStartInstances{output: &ec2.StartInstancesOutput{}},
+		start:         &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
 		owner:         owner,
 		region:        "us-west-2",
 	}
@@ -183,8 +130,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "resize stopped instance skips stop and start",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.describe = &mockResizeDescribeInstances{
-					output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopped),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopped),
 				}
 				return d
 			}(),
@@ -202,9 +149,9 @@ func TestResizeCommand(t *testing.T) {
 			wantErrContain: "accepts 1 arg",
 		},
 		{
-			name: "rejects resize to same instance type",
-			deps: newHappyResizeDeps("alice"),
-			args: []string{"resize", "t3.medium"},
+			name:           "rejects resize to same instance type",
+			deps:           newHappyResizeDeps("alice"),
+			args:           []string{"resize", "t3.medium"},
 			wantErr:        true,
 			wantErrContain: "already running",
 		},
@@ -212,8 +159,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "rejects resize when VM not found",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.describe = &mockResizeDescribeInstances{
-					output: &ec2.DescribeInstancesOutput{},
+				d.describe = &cmdtest.DescribeInstances{
+					Output: &ec2.DescribeInstancesOutput{},
 				}
 				return d
 			}(),
@@ -225,8 +172,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "rejects resize when VM in pending state",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.describe = &mockResizeDescribeInstances{
-					output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNamePending),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNamePending),
 				}
 				return d
 			}(),
@@ -238,8 +185,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "rejects resize when VM is stopping",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.describe = &mockResizeDescribeInstances{
-					output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopping),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopping),
 				}
 				return d
 			}(),
@@ -266,8 +213,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "describe API error propagates",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.describe = &mockResizeDescribeInstances{
-					err: fmt.Errorf("API throttled"),
+				d.describe = &cmdtest.DescribeInstances{
+					Err: fmt.Errorf("API throttled"),
 				}
 				return d
 			}(),
@@ -279,8 +226,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "stop API error propagates",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.stop = &mockResizeStopInstances{
-					err: fmt.Errorf("stop failed"),
+				d.stop = &cmdtest.StopInstances{
+					Err: fmt.Errorf("stop failed"),
 				}
 				return d
 			}(),
@@ -305,8 +252,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "start API error propagates",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("alice")
-				d.start = &mockResizeStartInstances{
-					err: fmt.Errorf("start failed"),
+				d.start = &cmdtest.StartInstances{
+					Err: fmt.Errorf("start failed"),
 				}
 				return d
 			}(),
@@ -333,8 +280,8 @@ func TestResizeCommand(t *testing.T) {
 			name: "non-default VM name",
 			deps: func() *resizeDeps {
 				d := newHappyResizeDeps("bob")
-				d.describe = &mockResizeDescribeInstances{
-					output: makeResizeInstance("i-dev456", "dev", "bob", "t3.medium", ec2types.InstanceStateNameRunning),
+				d.describe = &cmdtest.DescribeInstances{
+					Output: makeResizeInstance("i-dev456", "dev", "bob", "t3.medium", ec2types.InstanceStateNameRunning),
 				}
 				return d
 			}(),
@@ -350,7 +297,7 @@ func TestResizeCommand(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := new(bytes.Buffer)
 			cmd := newResizeCommandWithDeps(tt.deps)
-			root := newResizeTestRoot(cmd)
+			root := cmdtest.NewRoot(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
 			root.SetArgs(tt.args)
@@ -379,9 +326,9 @@ func TestResizeCommand(t *testing.T) {
 			}
 
 			// Verify mock call expectations
-			if stop, ok := tt.deps.stop.(*mockResizeStopInstances); ok {
-				if stop.called != tt.wantStopCalled {
-					t.Errorf("StopInstances called = %v, want %v", stop.called, tt.wantStopCalled)
+			if stop, ok := tt.deps.stop.(*cmdtest.StopInstances); ok {
+				if stop.Called != tt.wantStopCalled {
+					t.Errorf("StopInstances called = %v, want %v", stop.Called, tt.wantStopCalled)
 				}
 			}
 			if modify, ok := tt.deps.modify.(*mockResizeModifyInstanceAttribute); ok {
@@ -389,9 +336,9 @@ func TestResizeCommand(t *testing.T) {
 					t.Errorf("ModifyInstanceAttribute called = %v, want %v", modify.called, tt.wantModify)
 				}
 			}
-			if start, ok := tt.deps.start.(*mockResizeStartInstances); ok {
-				if start.called != tt.wantStart {
-					t.Errorf("StartInstances called = %v, want %v", start.called, tt.wantStart)
+			if start, ok := tt.deps.start.(*cmdtest.StartInstances); ok {
+				if start.Called != tt.wantStart {
+					t.Errorf("StartInstances called = %v, want %v", start.Called, tt.wantStart)
 				}
 			}
 		})
@@ -408,7 +355,7 @@ func TestResizeWaiterCalledAfterStop(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newResizeCommandWithDeps(deps)
-	root := newResizeTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"resize", "m6i.xlarge"})
@@ -429,14 +376,14 @@ func TestResizeWaiterCalledAfterStop(t *testing.T) {
 // NOT invoked when the instance is already stopped (no stop call is made).
 func TestResizeWaiterNotCalledForAlreadyStopped(t *testing.T) {
 	deps := newHappyResizeDeps("alice")
-	deps.describe = &mockResizeDescribeInstances{
-		output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopped),
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopped),
 	}
 	waiter := deps.waitStopped.(*mockResizeWaitStopped)
 
 	buf := new(bytes.Buffer)
 	cmd := newResizeCommandWithDeps(deps)
-	root := newResizeTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"resize", "m6i.xlarge"})
@@ -459,7 +406,7 @@ func TestResizeWaiterErrorPropagates(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newResizeCommandWithDeps(deps)
-	root := newResizeTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"resize", "m6i.xlarge"})
@@ -482,7 +429,7 @@ func TestResizeModifySendsCorrectInstanceType(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	cmd := newResizeCommandWithDeps(deps)
-	root := newResizeTestRoot(cmd)
+	root := cmdtest.NewRoot(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
 	root.SetArgs([]string{"resize", "c5.2xlarge"})
@@ -503,3 +450,54 @@ func TestResizeModifySendsCorrectInstanceType(t *testing.T) {
 		t.Error("modify did not set correct instance type to c5.2xlarge")
 	}
 }
+
+func TestResizeOperationLock(t *testing.T) {
+	hint.IsTTY = false
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantModify bool
+	}{
+		{"lock respected", nil, "locked by mint recreate", false},
+		{"lock stolen", []string{"--steal-lock"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeResizeInstance("i-abc123", "default", "alice", "t3.medium", ec2types.InstanceStateNameStopped)
+			inst := &out.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagOperation), Value: aws.String("recreate:desktop:4242:2026-10-15T11:50:00Z")})
+			describe := &cmdtest.DescribeInstances{Output: out}
+			deleteTags := &cmdtest.DeleteTags{}
+			stderr := new(bytes.Buffer)
+			deps := newHappyResizeDeps("alice")
+			deps.describe = describe
+			deps.locker = provision.NewLocker(describe, &cmdtest.CreateTags{}, deleteTags).
+				WithWarnings(stderr).
+				WithIdentity("laptop", 100, func() time.Time { return now })
+			modify := deps.modify.(*mockResizeModifyInstanceAttribute)
+
+			root := cmdtest.NewRoot(newResizeCommandWithDeps(deps))
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(stderr)
+			root.SetArgs(append([]string{"resize", "m6i.xlarge"}, tt.args...))
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if modify.called != tt.wantModify {
+				t.Errorf("ModifyInstanceAttribute called = %v, want %v", modify.called, tt.wantModify)
+			}
+			if tt.wantModify && len(deleteTags.Inputs) != 1 {
+				t.Errorf("lock released %d times, want once", len(deleteTags.Inputs))
+			}
+		})
+	}
+}
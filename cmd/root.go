@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"time"
 
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
@@ -24,13 +28,43 @@ func NewRootCommand() *cobra.Command {
 		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			cliCtx := cli.NewCLIContext(cmd)
-			ctx := cli.WithContext(context.Background(), cliCtx)
+			// The parent context carries Ctrl-C cancellation from executeRoot.
+			parent := cmd.Context()
+			if parent == nil {
+				parent = context.Background()
+			}
+			ctx := cli.WithContext(parent, cliCtx)
+
+			// A corrupted config.toml fails every command that loads it.
+			// Read-only commands run on the defaults instead, after a
+			// warning that says how to recover.
+			var parseErr *config.ParseError
+			if _, err := config.Load(config.DefaultConfigDir()); errors.As(err, &parseErr) && commandRunsOnDefaultConfig(cmd) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\nWarning: using the default settings for this command.\n", parseErr)
+				cliCtx.DefaultConfig = true
+			}
 
 			// Initialize AWS clients for commands that need them.
 			// Local-only commands (version, config, ssh-config, completion,
 			// help) skip AWS initialization entirely.
 			if commandNeedsAWS(cmd) {
 				clients, err := initAWSClients(ctx)
+				var netErr *networkError
+				if errors.As(err, &netErr) && commandWorksOffline(cmd) {
+					// Fall back to local data; the command reads
+					// cliCtx.Offline and never touches AWS.
+					cliCtx.Offline = true
+					cmd.SetContext(ctx)
+					return nil
+				}
+				if netErr != nil {
+					if cliCtx.JSON {
+						cmd.SetContext(ctx)
+						fmt.Fprintf(cmd.OutOrStdout(), "{\"error\":%q}\n", netErr.Error())
+						return exitCodeError{code: exitCodeNetwork}
+					}
+					return netErr
+				}
 				if err != nil {
 					friendlyMsg := fmt.Sprintf("initialize AWS: %v", err)
 					if isCredentialError(err) || isSSOReAuthError(err) {
@@ -57,6 +91,10 @@ func NewRootCommand() *cobra.Command {
 					}
 					return fmt.Errorf("%s", friendlyMsg)
 				}
+				clients.agentNotes = cmd.ErrOrStderr()
+				if cliCtx.Verbose {
+					clients.apiRetry.SetProgress(cmd.ErrOrStderr())
+				}
 				ctx = contextWithAWSClients(ctx, clients)
 			}
 
@@ -76,6 +114,10 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
 	rootCmd.PersistentFlags().String("vm", "default", "Target VM name")
 	rootCmd.PersistentFlags().String("profile", "", "AWS profile name (overrides AWS_PROFILE)")
+	rootCmd.PersistentFlags().String("role-arn", "", "IAM role ARN to assume for every AWS call (overrides role_arn)")
+	rootCmd.PersistentFlags().Bool("offline", false, "Skip AWS: use cached data where available and fail fast otherwise")
+	rootCmd.PersistentFlags().StringArray("ssh-arg", nil, "Extra argument for every ssh mint runs (repeatable)")
+	rootCmd.PersistentFlags().Bool("no-ssh-mux", false, "Open a new SSH connection for every remote command instead of sharing one per VM")
 
 	// Register subcommands
 	rootCmd.AddCommand(newVersionCommand())
@@ -87,7 +129,10 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.AddCommand(newSSHConfigCommand())
 	rootCmd.AddCommand(newListCommand())
 	rootCmd.AddCommand(newStatusCommand())
+	rootCmd.AddCommand(newEventsCommand())
 	rootCmd.AddCommand(newSSHCommand())
+	rootCmd.AddCommand(newTestSSHCommand())
+	rootCmd.AddCommand(newConsoleCommand())
 	rootCmd.AddCommand(newCodeCommand())
 
 	// Phase 2: Connectivity & session commands
@@ -95,14 +140,30 @@ func NewRootCommand() *cobra.Command {
 	rootCmd.AddCommand(newConnectCommand())
 	rootCmd.AddCommand(newSessionsCommand())
 	rootCmd.AddCommand(newKeyCommand())
+	rootCmd.AddCommand(newHostKeyCommand())
+	rootCmd.AddCommand(newGitIdentityCommand())
 	rootCmd.AddCommand(newProjectCommand())
 	rootCmd.AddCommand(newExtendCommand())
+	rootCmd.AddCommand(newIdleCommand())
+	rootCmd.AddCommand(newGuardCommand())
 
 	// Phase 3: Lifecycle & health commands
 	rootCmd.AddCommand(newResizeCommand())
 	rootCmd.AddCommand(newRecreateCommand())
+	rootCmd.AddCommand(newCloneVMCommand())
+	rootCmd.AddCommand(newSnapshotCommand())
+	rootCmd.AddCommand(newVolumeCommand())
+	rootCmd.AddCommand(newVMCommand())
+	rootCmd.AddCommand(newPruneCommand())
+	rootCmd.AddCommand(newGCCommand())
 	rootCmd.AddCommand(newDoctorCommand())
+	rootCmd.AddCommand(newLogsCommand())
 	rootCmd.AddCommand(newUpdateCommand())
+	rootCmd.AddCommand(newHistoryCommand())
+	rootCmd.AddCommand(newAuditCommand())
+	rootCmd.AddCommand(newExportStateCommand())
+	rootCmd.AddCommand(newImportStateCommand())
+	rootCmd.AddCommand(newRepairCommand())
 
 	// Admin commands for infrastructure setup
 	rootCmd.AddCommand(newAdminCommand())
@@ -130,7 +191,7 @@ func GetBootstrapScript() []byte {
 // Execute creates the root command and runs it. Called from main.
 // Deprecated: Use ExecuteWithBootstrapScript to pass the embedded bootstrap script.
 func Execute() error {
-	return NewRootCommand().Execute()
+	return executeRoot(NewRootCommand())
 }
 
 // ExecuteWithBootstrapScript stores the embedded bootstrap script and
@@ -138,5 +199,26 @@ func Execute() error {
 // (e.g., up) that need it for EC2 provisioning.
 func ExecuteWithBootstrapScript(script []byte) error {
 	SetBootstrapScript(script)
-	return NewRootCommand().Execute()
+	return executeRoot(NewRootCommand())
+}
+
+// executeRoot runs root and records the invocation in the local history
+// log. History is written here rather than in a post-run hook because
+// cobra skips post-run hooks when the command fails.
+//
+// The first Ctrl-C cancels the command's context so it can stop at a safe
+// step; a second one exits immediately.
+func executeRoot(root *cobra.Command) error {
+	start := time.Now()
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	ctx, stop := watchInterrupts(context.Background(), sigs, os.Stderr, os.Exit)
+	defer stop()
+
+	executed, err := root.ExecuteContextC(ctx)
+	closeSSHMux(executed)
+	recordHistory(executed, start, err)
+	notifyFinished(executed, time.Since(start), err)
+	return err
 }
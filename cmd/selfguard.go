@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
+)
+
+// selfTargetFlag acknowledges that a mutating command will act on the VM
+// mint itself is running on.
+const selfTargetFlag = "i-know-this-is-the-vm"
+
+// addSelfTargetFlag registers --i-know-this-is-the-vm on a mutating command.
+func addSelfTargetFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(selfTargetFlag, false, "Allow acting on the VM this command is running on")
+}
+
+// guardSelfTarget refuses to act on the VM this process runs on unless
+// --i-know-this-is-the-vm is set. consequence completes the sentence "This
+// will ..." and describes what happens to the current session. A nil detector
+// or an unreachable IMDS never blocks.
+func guardSelfTarget(ctx context.Context, cmd *cobra.Command, detector *selfcheck.Detector, vmName, instanceID, consequence string) error {
+	if detector == nil || !detector.IsLocalInstance(ctx, instanceID) {
+		return nil
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Warning: you are running mint on VM %q (%s) itself.\n", vmName, instanceID)
+	fmt.Fprintf(w, "This will %s, ending this shell session.\n", consequence)
+
+	if ack, _ := cmd.Flags().GetBool(selfTargetFlag); ack {
+		return nil
+	}
+	return fmt.Errorf("refusing to act on the VM this command is running on — run it from another machine or pass %s",
+		hint.Cmd("--"+selfTargetFlag))
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/selfcheck"
+)
+
+// fakeIMDS returns a fixed instance ID, or err to simulate running off EC2.
+type fakeIMDS struct {
+	instanceID string
+	err        error
+}
+
+func (f *fakeIMDS) GetMetadata(ctx context.Context, params *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &imds.GetMetadataOutput{Content: io.NopCloser(strings.NewReader(f.instanceID))}, nil
+}
+
+func TestSelfTargetGuardDown(t *testing.T) {
+	tests := []struct {
+		name        string
+		imds        *fakeIMDS
+		args        []string
+		wantBlocked bool
+		wantWarning bool
+	}{
+		{
+			name:        "on the target VM",
+			imds:        &fakeIMDS{instanceID: "i-abc123"},
+			wantBlocked: true,
+			wantWarning: true,
+		},
+		{
+			name:        "on the target VM with acknowledgement",
+			imds:        &fakeIMDS{instanceID: "i-abc123"},
+			args:        []string{"--i-know-this-is-the-vm"},
+			wantWarning: true,
+		},
+		{
+			name: "on a different VM",
+			imds: &fakeIMDS{instanceID: "i-other999"},
+		},
+		{
+			name: "off EC2",
+			imds: &fakeIMDS{err: errors.New("dial tcp 169.254.169.254:80: i/o timeout")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := &cmdtest.StopInstances{Output: &ec2.StopInstancesOutput{}}
+			deps := &downDeps{
+				describe:     &cmdtest.DescribeInstances{Output: makeRunningInstance("i-abc123", "default", "alice")},
+				stop:         stop,
+				owner:        "alice",
+				selfDetector: selfcheck.NewDetector(tt.imds),
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newDownCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs(append([]string{"down"}, tt.args...))
+			err := root.Execute()
+
+			if tt.wantBlocked {
+				if err == nil || !strings.Contains(err.Error(), "--i-know-this-is-the-vm") {
+					t.Fatalf("error = %v, want refusal mentioning --i-know-this-is-the-vm", err)
+				}
+				if stop.Called {
+					t.Error("StopInstances must not be called when the guard refuses")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !stop.Called {
+					t.Error("StopInstances should be called")
+				}
+			}
+
+			gotWarning := strings.Contains(buf.String(), "ending this shell session")
+			if gotWarning != tt.wantWarning {
+				t.Errorf("warning shown = %v, want %v\noutput: %s", gotWarning, tt.wantWarning, buf.String())
+			}
+		})
+	}
+}
+
+func TestSelfTargetGuardDestroy(t *testing.T) {
+	deps := newHappyDestroyDeps("alice")
+	deps.selfDetector = selfcheck.NewDetector(&fakeIMDS{instanceID: "i-abc123"})
+	terminate := deps.terminate.(*cmdtest.TerminateInstances)
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newDestroyCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"destroy", "--yes"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "refusing to act on the VM") {
+		t.Fatalf("error = %v, want self-target refusal", err)
+	}
+	if terminate.Called {
+		t.Error("TerminateInstances must not be called when the guard refuses")
+	}
+	if !strings.Contains(buf.String(), "terminate this VM") {
+		t.Errorf("warning should describe the termination, got:\n%s", buf.String())
+	}
+}
+
+func TestGuardSelfTargetNilDetector(t *testing.T) {
+	sub := newDownCommandWithDeps(nil)
+	if err := guardSelfTarget(context.Background(), sub, nil, "default", "i-abc123", "stop this VM"); err != nil {
+		t.Errorf("nil detector should never block, got %v", err)
+	}
+}
@@ -25,6 +25,7 @@ type sessionsDeps struct {
 	sendKey   mintaws.SendSSHPublicKeyAPI
 	owner     string
 	remoteRun RemoteCommandRunner
+	sshPort   int // ssh_port from config; zero means defaultSSHPort
 }
 
 // tmuxSession represents a parsed tmux session from the VM.
@@ -59,9 +60,10 @@ func newSessionsCommandWithDeps(deps *sessionsDeps) *cobra.Command {
 			}
 			return runSessions(cmd, &sessionsDeps{
 				describe:  clients.ec2Client,
-				sendKey:   clients.icClient,
+				sendKey:   clients.sendKey,
 				owner:     clients.owner,
-				remoteRun: defaultRemoteRunner,
+				remoteRun: clients.remoteRunner(),
+				sshPort:   clients.sshOptions.Port,
 			})
 		},
 	}
@@ -117,7 +119,7 @@ func runSessions(cmd *cobra.Command, deps *sessionsDeps) error {
 		found.ID,
 		found.AvailabilityZone,
 		found.PublicIP,
-		defaultSSHPort,
+		sshPortOrDefault(deps.sshPort),
 		defaultSSHUser,
 		tmuxCmd,
 	)
@@ -129,9 +131,9 @@ func runSessions(cmd *cobra.Command, deps *sessionsDeps) error {
 		sp.Fail(err.Error())
 		if isSSHConnectionError(err) {
 			return fmt.Errorf(
-				"cannot connect to VM %q (port 41122 refused) — "+
+				"cannot connect to VM %q (port %d refused) — "+
 					"bootstrap may be incomplete\n%s",
-				vmName,
+				vmName, sshPortOrDefault(deps.sshPort),
 				hint.Suggest("Diagnose", "mint doctor"),
 			)
 		}
@@ -2,42 +2,20 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/spf13/cobra"
 )
 
 // mockRemoteCommandRunner returns a RemoteCommandRunner that yields fixed output.
 func mockRemoteCommandRunner(stdout []byte, err error) RemoteCommandRunner {
-	return func(
-		ctx context.Context,
-		sendKey mintaws.SendSSHPublicKeyAPI,
-		instanceID, az, host string,
-		port int,
-		user string,
-		command []string,
-	) ([]byte, error) {
-		return stdout, err
-	}
-}
-
-// mockDescribeForSessions implements mintaws.DescribeInstancesAPI for sessions tests.
-type mockDescribeForSessions struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeForSessions) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
+	return (&cmdtest.RemoteRunner{Output: stdout, Err: err}).Run
 }
 
 // makeRunningInstanceForSessions creates a running instance for sessions tests.
@@ -63,30 +41,10 @@ func makeRunningInstanceForSessions(id, vmName, owner, ip, az string) *ec2.Descr
 	}
 }
 
-// newTestRootForSessions creates a minimal root command for sessions tests.
-func newTestRootForSessions() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 func TestSessionsCommand(t *testing.T) {
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForSessions
+		describe       *cmdtest.DescribeInstances
 		remoteOutput   []byte
 		remoteErr      error
 		owner          string
@@ -99,8 +57,8 @@ func TestSessionsCommand(t *testing.T) {
 	}{
 		{
 			name: "parses tmux sessions and displays table",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: []byte("main 3 1 1700000000\ndev 2 0 1700001000\n"),
 			owner:        "alice",
@@ -108,8 +66,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "json output returns array of session objects",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: []byte("main 3 1 1700000000\n"),
 			owner:        "alice",
@@ -118,8 +76,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "empty output shows no sessions message",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: []byte(""),
 			owner:        "alice",
@@ -127,8 +85,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "tmux no server running is not an error",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: nil,
 			remoteErr:    fmt.Errorf("remote command failed: exit status 1 (stderr: no server running on /tmp/tmux-1000/default)"),
@@ -137,8 +95,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "tmux no sessions is not an error",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: nil,
 			remoteErr:    fmt.Errorf("remote command failed: exit status 1 (stderr: no sessions)"),
@@ -150,8 +108,8 @@ func TestSessionsCommand(t *testing.T) {
 			// instead of "no server running" when the server socket doesn't exist.
 			// This must be treated as "no sessions", not an error.
 			name: "ubuntu 24.04 tmux socket not found is not an error",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: nil,
 			remoteErr:    fmt.Errorf("remote command failed: exit status 1 (stderr: error connecting to /tmp/tmux-1000/default (No such file or directory))"),
@@ -160,8 +118,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "VM not found returns error",
-			describe: &mockDescribeForSessions{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:          "alice",
 			wantErr:        true,
@@ -169,8 +127,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "stopped VM returns error",
-			describe: &mockDescribeForSessions{
-				output: &ec2.DescribeInstancesOutput{
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{
 					Reservations: []ec2types.Reservation{{
 						Instances: []ec2types.Instance{{
 							InstanceId:   aws.String("i-stopped"),
@@ -192,8 +150,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForSessions{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
 			owner:          "alice",
 			wantErr:        true,
@@ -201,8 +159,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "real remote command error propagates",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteErr:      fmt.Errorf("remote command failed: connection refused"),
 			owner:          "alice",
@@ -211,8 +169,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "SSH connection refused wrapped with bootstrap-aware context",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteErr:      fmt.Errorf("remote command failed: exit status 255 (stderr: ssh: connect to host 1.2.3.4 port 41122: Connection refused)"),
 			owner:          "alice",
@@ -221,8 +179,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "SSH connection refused bootstrap context mentions mint doctor",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteErr:      fmt.Errorf("remote command failed: exit status 255 (stderr: ssh: connect to host 1.2.3.4 port 41122: Connection refused)"),
 			owner:          "alice",
@@ -231,8 +189,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "non-default VM name",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
 			remoteOutput: []byte("work 1 0 1700000000\n"),
 			owner:        "alice",
@@ -241,8 +199,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "json output for no sessions returns empty array",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteOutput: []byte(""),
 			owner:        "alice",
@@ -251,8 +209,8 @@ func TestSessionsCommand(t *testing.T) {
 		},
 		{
 			name: "json output for no server running returns empty array",
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			remoteErr:  fmt.Errorf("remote command failed: exit status 1 (stderr: no server running on /tmp/tmux-1000/default)"),
 			owner:      "alice",
@@ -272,7 +230,7 @@ func TestSessionsCommand(t *testing.T) {
 			}
 
 			cmd := newSessionsCommandWithDeps(deps)
-			root := newTestRootForSessions()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -473,15 +431,15 @@ func TestSessionsSpinnerWiring(t *testing.T) {
 		buf := new(bytes.Buffer)
 
 		deps := &sessionsDeps{
-			describe: &mockDescribeForSessions{
-				output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceForSessions("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
 			owner:     "alice",
 			remoteRun: mockRemoteCommandRunner([]byte("main 1 1 1700000000\n"), nil),
 		}
 
 		cmd := newSessionsCommandWithDeps(deps)
-		root := newTestRootForSessions()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -501,15 +459,15 @@ func TestSessionsSpinnerWiring(t *testing.T) {
 		buf := new(bytes.Buffer)
 
 		deps := &sessionsDeps{
-			describe: &mockDescribeForSessions{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:     "alice",
 			remoteRun: mockRemoteCommandRunner(nil, nil),
 		}
 
 		cmd := newSessionsCommandWithDeps(deps)
-		root := newTestRootForSessions()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -0,0 +1,677 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// snapshotDeps holds the injectable dependencies for the snapshot commands.
+type snapshotDeps struct {
+	describe            mintaws.DescribeInstancesAPI
+	describeVolumes     mintaws.DescribeVolumesAPI
+	describeSnapshots   mintaws.DescribeSnapshotsAPI
+	createSnapshot      mintaws.CreateSnapshotAPI
+	waitSnapshot        mintaws.WaitSnapshotCompletedAPI // nil skips --wait polling
+	createVolume        mintaws.CreateVolumeAPI
+	waitVolumeAvailable mintaws.WaitVolumeAvailableAPI // nil skips waiting for volumes
+	detachVolume        mintaws.DetachVolumeAPI
+	attachVolume        mintaws.AttachVolumeAPI
+	createTags          mintaws.CreateTagsAPI
+	deleteTags          provision.DeleteTagsAPI
+	owner               string
+	ownerARN            string
+	kmsKeyID            string            // kms_key_id; encrypts restored volumes
+	extraTags           map[string]string // extra_tags; added to snapshots and restored volumes
+	locker              *provision.Locker // nil takes no operation lock on restore
+	now                 func() time.Time  // nil uses time.Now
+}
+
+// newSnapshotCommand creates the production snapshot command group.
+func newSnapshotCommand() *cobra.Command {
+	return newSnapshotCommandWithDeps(nil)
+}
+
+// newSnapshotCommandWithDeps creates the snapshot command group with
+// explicit dependencies for testing. When deps is nil, each subcommand
+// wires real AWS clients.
+func newSnapshotCommandWithDeps(deps *snapshotDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Back up and restore the project volume",
+		Long: "Take point-in-time EBS snapshots of the VM's project volume (/mint/projects), " +
+			"list them, and restore one in place of the current volume.",
+	}
+
+	cmd.AddCommand(newSnapshotCreateCommand(deps))
+	cmd.AddCommand(newSnapshotListCommand(deps))
+	cmd.AddCommand(newSnapshotRestoreCommand(deps))
+
+	return cmd
+}
+
+// resolveSnapshotDeps returns deps, or the production dependencies when
+// deps is nil.
+func resolveSnapshotDeps(cmd *cobra.Command, deps *snapshotDeps) (*snapshotDeps, error) {
+	if deps != nil {
+		return deps, nil
+	}
+	clients := awsClientsFromContext(cmd.Context())
+	if clients == nil {
+		return nil, fmt.Errorf("AWS clients not configured")
+	}
+	var kmsKeyID string
+	var extraTags map[string]string
+	if clients.mintConfig != nil {
+		kmsKeyID = clients.mintConfig.KMSKeyID
+		extraTags = clients.mintConfig.ExtraTags
+	}
+	return &snapshotDeps{
+		describe:            clients.ec2Client,
+		describeVolumes:     clients.ec2Client,
+		describeSnapshots:   clients.ec2Client,
+		createSnapshot:      clients.ec2Client,
+		waitSnapshot:        ec2.NewSnapshotCompletedWaiter(clients.ec2Client),
+		createVolume:        clients.ec2Client,
+		waitVolumeAvailable: ec2.NewVolumeAvailableWaiter(clients.ec2Client),
+		detachVolume:        clients.ec2Client,
+		attachVolume:        clients.ec2Client,
+		createTags:          clients.ec2Client,
+		deleteTags:          clients.ec2Client,
+		owner:               clients.owner,
+		ownerARN:            clients.ownerARN,
+		kmsKeyID:            kmsKeyID,
+		extraTags:           extraTags,
+		locker:              newOperationLocker(cmd, clients.ec2Client),
+	}, nil
+}
+
+func (d *snapshotDeps) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+func newSnapshotCreateCommand(deps *snapshotDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Snapshot the project volume",
+		Long: "Start an EBS snapshot of the VM's project volume, tagged with the VM, " +
+			"the owner, a name, and the time it was taken. The VM may stay running; " +
+			"the snapshot is then crash-consistent.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveSnapshotDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runSnapshotCreate(cmd, d)
+		},
+	}
+
+	cmd.Flags().String("name", "", "Name for the snapshot (default: the UTC time it is taken)")
+	cmd.Flags().Bool("wait", false, "Wait for the snapshot to complete")
+
+	return cmd
+}
+
+// snapshotCreateJSON is the --json output of mint snapshot create.
+type snapshotCreateJSON struct {
+	SnapshotID string `json:"snapshot_id"`
+	Name       string `json:"name"`
+	VolumeID   string `json:"volume_id"`
+	State      string `json:"state"`
+}
+
+// runSnapshotCreate executes the snapshot create command logic.
+func runSnapshotCreate(cmd *cobra.Command, deps *snapshotDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+	w := cmd.OutOrStdout()
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", vmName, hint.Cmd("mint list"))
+	}
+
+	volume, err := findSnapshotProjectVolume(ctx, deps, vmName)
+	if err != nil {
+		return err
+	}
+	volumeID := aws.ToString(volume.VolumeId)
+
+	now := deps.clock().UTC()
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		name = now.Format("20060102-150405")
+	}
+	wait, _ := cmd.Flags().GetBool("wait")
+
+	// Skip in JSON mode to avoid corrupting machine-readable output.
+	if found.State == string(ec2types.InstanceStateNameRunning) && !jsonOutput {
+		fmt.Fprintf(w, "Note: VM %q is running — the snapshot is crash-consistent. Stop it first with %s for a clean copy.\n",
+			vmName, hint.Cmd("mint down"))
+	}
+
+	sp := progress.NewCommandSpinner(w, jsonOutput)
+	sp.Start(fmt.Sprintf("Snapshotting project volume %s of VM %q...", volumeID, vmName))
+
+	snapTags, err := tags.MergeExtra(append(
+		tags.NewTagBuilder(deps.owner, deps.ownerARN, vmName).
+			WithComponent(tags.ComponentProjectSnapshot).
+			Build(),
+		ec2types.Tag{Key: aws.String(tags.TagSnapshotName), Value: aws.String(name)},
+		ec2types.Tag{Key: aws.String(tags.TagSnapshotCreated), Value: aws.String(now.Format(time.RFC3339))},
+	), deps.extraTags)
+	if err != nil {
+		sp.Fail(err.Error())
+		return err
+	}
+	out, err := deps.createSnapshot.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:    aws.String(volumeID),
+		Description: aws.String(fmt.Sprintf("mint snapshot of VM %s: %s", vmName, name)),
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeSnapshot,
+			Tags:         snapTags,
+		}},
+	})
+	if err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("creating snapshot of %s: %w", volumeID, err)
+	}
+	snapshotID := aws.ToString(out.SnapshotId)
+	cliCtx.TouchResource(cli.ResourceSnapshot, snapshotID)
+
+	state := string(out.State)
+	if wait && deps.waitSnapshot != nil {
+		sp.Update(fmt.Sprintf("Waiting for snapshot %s to complete...", snapshotID))
+		if err := deps.waitSnapshot.Wait(ctx, &ec2.DescribeSnapshotsInput{
+			SnapshotIds: []string{snapshotID},
+		}, 60*time.Minute); err != nil {
+			sp.Fail(err.Error())
+			return fmt.Errorf("waiting for snapshot %s to complete: %w", snapshotID, err)
+		}
+		state = string(ec2types.SnapshotStateCompleted)
+	}
+	sp.Stop("")
+
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshotCreateJSON{
+			SnapshotID: snapshotID,
+			Name:       name,
+			VolumeID:   volumeID,
+			State:      state,
+		})
+	}
+
+	if state == string(ec2types.SnapshotStateCompleted) {
+		fmt.Fprintf(w, "Snapshot %s (%s) of project volume %s is complete.\n", snapshotID, name, volumeID)
+		return nil
+	}
+	fmt.Fprintf(w, "Started snapshot %s (%s) of project volume %s.\n", snapshotID, name, volumeID)
+	fmt.Fprintf(w, "It completes in the background — check on it with %s.\n", hint.Cmd("mint snapshot list"))
+	return nil
+}
+
+func newSnapshotListCommand(deps *snapshotDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots of the project volume",
+		Long: "List the VM's project volume snapshots, newest first, with their state, " +
+			"size, and age. Snapshots mint clone-vm took for the VM are included.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveSnapshotDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runSnapshotList(cmd, d)
+		},
+	}
+}
+
+// snapshotJSON is one snapshot in the --json output of mint snapshot list.
+type snapshotJSON struct {
+	SnapshotID string    `json:"snapshot_id"`
+	Name       string    `json:"name,omitempty"`
+	State      string    `json:"state"`
+	Progress   string    `json:"progress,omitempty"`
+	VolumeID   string    `json:"volume_id"`
+	SizeGiB    int32     `json:"size_gib"`
+	StartTime  time.Time `json:"start_time"`
+	Age        string    `json:"age"`
+}
+
+// runSnapshotList executes the snapshot list command logic.
+func runSnapshotList(cmd *cobra.Command, deps *snapshotDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+	w := cmd.OutOrStdout()
+
+	snapshots, err := listProjectSnapshots(ctx, deps, vmName)
+	if err != nil {
+		return err
+	}
+	now := deps.clock()
+
+	if jsonOutput {
+		items := make([]snapshotJSON, 0, len(snapshots))
+		for _, s := range snapshots {
+			items = append(items, snapshotJSON{
+				SnapshotID: aws.ToString(s.SnapshotId),
+				Name:       snapshotName(s),
+				State:      string(s.State),
+				Progress:   aws.ToString(s.Progress),
+				VolumeID:   aws.ToString(s.VolumeId),
+				SizeGiB:    aws.ToInt32(s.VolumeSize),
+				StartTime:  aws.ToTime(s.StartTime).UTC(),
+				Age:        snapshotAge(s, now),
+			})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+
+	writeSnapshotTable(w, vmName, snapshots, now)
+	return nil
+}
+
+// writeSnapshotTable outputs snapshots in a human-readable table.
+func writeSnapshotTable(w io.Writer, vmName string, snapshots []ec2types.Snapshot, now time.Time) {
+	if len(snapshots) == 0 {
+		fmt.Fprintf(w, "No snapshots of VM %q. Take one with %s.\n", vmName, hint.Cmd("mint snapshot create"))
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATE\tSIZE\tAGE")
+	for _, s := range snapshots {
+		name := snapshotName(s)
+		if name == "" {
+			name = "-"
+		}
+		state := string(s.State)
+		if s.State == ec2types.SnapshotStatePending && aws.ToString(s.Progress) != "" {
+			state += " (" + aws.ToString(s.Progress) + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(s.SnapshotId), name, state, format.FormatGiB(int(aws.ToInt32(s.VolumeSize))), snapshotAge(s, now))
+	}
+	tw.Flush()
+}
+
+// listProjectSnapshots returns vmName's project volume snapshots, newest
+// first.
+func listProjectSnapshots(ctx context.Context, deps *snapshotDeps, vmName string) ([]ec2types.Snapshot, error) {
+	out, err := deps.describeSnapshots.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+		OwnerIds: []string{"self"},
+		Filters:  componentFilters(deps.owner, vmName, tags.ComponentProjectSnapshot),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe snapshots: %w", err)
+	}
+	snapshots := out.Snapshots
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return aws.ToTime(snapshots[i].StartTime).After(aws.ToTime(snapshots[j].StartTime))
+	})
+	return snapshots, nil
+}
+
+// snapshotName returns the mint:snapshot-name tag of s, or "" when it has
+// none (clone-vm snapshots).
+func snapshotName(s ec2types.Snapshot) string {
+	for _, t := range s.Tags {
+		if aws.ToString(t.Key) == tags.TagSnapshotName {
+			return aws.ToString(t.Value)
+		}
+	}
+	return ""
+}
+
+// snapshotAge returns how long ago s was started, to the minute.
+func snapshotAge(s ec2types.Snapshot, now time.Time) string {
+	start := aws.ToTime(s.StartTime)
+	if start.IsZero() {
+		return "-"
+	}
+	d := now.Sub(start)
+	if d < 0 {
+		d = 0
+	}
+	if d >= time.Minute {
+		d = d.Truncate(time.Minute)
+	}
+	return format.FormatDuration(d)
+}
+
+func newSnapshotRestoreCommand(deps *snapshotDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <snapshot-id>",
+		Short: "Restore a snapshot in place of the project volume",
+		Long: "Create a volume from the snapshot in the VM's availability zone. When the VM is " +
+			"stopped, the new volume replaces the project volume straight away. A running VM is " +
+			"refused unless --force is set; the volume is then prepared and the next mint " +
+			"recreate swaps it in. The replaced volume is kept until you delete it.\n\n" +
+			"The restore is refused while another mint command holds the VM's operation lock; " +
+			"--steal-lock takes it anyway.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveSnapshotDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runSnapshotRestore(cmd, d, args[0])
+		},
+	}
+
+	cmd.Flags().Bool("force", false, "Prepare the restored volume while the VM is running; mint recreate swaps it in")
+	addStealLockFlag(cmd)
+
+	return cmd
+}
+
+// runSnapshotRestore executes the snapshot restore command logic.
+func runSnapshotRestore(cmd *cobra.Command, deps *snapshotDeps, snapshotID string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	w := cmd.OutOrStdout()
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", vmName, hint.Cmd("mint list"))
+	}
+
+	snapshot, err := findRestoreSnapshot(ctx, deps, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	current, err := findSnapshotProjectVolume(ctx, deps, vmName)
+	if err != nil {
+		return err
+	}
+	currentID := aws.ToString(current.VolumeId)
+
+	state := ec2types.InstanceStateName(found.State)
+	switch {
+	case state == ec2types.InstanceStateNameRunning && !force:
+		return fmt.Errorf("VM %q is running — stop it with %s and run the restore again, or use %s to prepare the restored volume for %s",
+			vmName, hint.Cmd("mint down"), hint.Cmd("--force"), hint.Cmd("mint recreate"))
+	case state != ec2types.InstanceStateNameRunning && state != ec2types.InstanceStateNameStopped:
+		return fmt.Errorf("VM %q is %s — wait until it is running or stopped", vmName, found.State)
+	}
+
+	if existing, err := findRestoredVolume(ctx, deps.describeVolumes, deps.owner, vmName); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("restored volume %s is already waiting to replace the project volume of VM %q — run %s to swap it in, or delete it first",
+			aws.ToString(existing.VolumeId), vmName, hint.Cmd("mint recreate"))
+	}
+
+	release, err := lockVM(ctx, cmd, deps.locker, found, "snapshot restore")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	sp := progress.NewCommandSpinner(w, false)
+	sp.Start(fmt.Sprintf("Creating volume from snapshot %s in %s...", snapshotID, found.AvailabilityZone))
+
+	restoredID, err := createRestoredVolume(ctx, deps, snapshot, current, found.AvailabilityZone, vmName)
+	if err != nil {
+		sp.Fail(err.Error())
+		return err
+	}
+	cliCtx.TouchResource(cli.ResourceVolume, restoredID)
+
+	if state == ec2types.InstanceStateNameRunning {
+		sp.Stop("")
+		fmt.Fprintf(w, "Created volume %s from snapshot %s.\n", restoredID, snapshotID)
+		fmt.Fprintf(w, "Run %s to swap it in for project volume %s, which is kept.\n",
+			hint.Cmd("mint recreate"), currentID)
+		return nil
+	}
+
+	sp.Update(fmt.Sprintf("Swapping project volume %s for %s...", currentID, restoredID))
+	if err := swapProjectVolume(ctx, deps, found.ID, currentID, restoredID); err != nil {
+		sp.Fail(err.Error())
+		return fmt.Errorf("%w (restored volume %s is tagged %s=%s; %s swaps it in)", err,
+			restoredID, tags.TagComponent, tags.ComponentRestoredVolume, hint.Cmd("mint recreate"))
+	}
+	sp.Stop("")
+
+	fmt.Fprintf(w, "Restored snapshot %s: volume %s is now the project volume of VM %q.\n", snapshotID, restoredID, vmName)
+	fmt.Fprintf(w, "The previous project volume %s is kept, detached — delete it once the restore checks out.\n", currentID)
+	fmt.Fprintf(w, "Start the VM with %s.\n", hint.Cmd("mint up"))
+	return nil
+}
+
+// findSnapshotProjectVolume returns the project EBS volume of vmName.
+func findSnapshotProjectVolume(ctx context.Context, deps *snapshotDeps, vmName string) (ec2types.Volume, error) {
+	return lookupProjectVolume(ctx, deps.describeVolumes, deps.owner, vmName)
+}
+
+// findRestoreSnapshot returns snapshotID when it is a completed snapshot
+// of one of the owner's project volumes.
+func findRestoreSnapshot(ctx context.Context, deps *snapshotDeps, snapshotID string) (ec2types.Snapshot, error) {
+	out, err := deps.describeSnapshots.DescribeSnapshots(ctx, &ec2.DescribeSnapshotsInput{
+		OwnerIds:    []string{"self"},
+		SnapshotIds: []string{snapshotID},
+	})
+	if err != nil {
+		return ec2types.Snapshot{}, fmt.Errorf("describe snapshot %s: %w", snapshotID, err)
+	}
+	if len(out.Snapshots) == 0 {
+		return ec2types.Snapshot{}, fmt.Errorf("snapshot %s not found — run %s to list snapshots", snapshotID, hint.Cmd("mint snapshot list"))
+	}
+	snapshot := out.Snapshots[0]
+
+	tagMap := make(map[string]string, len(snapshot.Tags))
+	for _, t := range snapshot.Tags {
+		tagMap[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	if tagMap[tags.TagOwner] != deps.owner || tagMap[tags.TagComponent] != tags.ComponentProjectSnapshot {
+		return ec2types.Snapshot{}, fmt.Errorf("snapshot %s is not a project volume snapshot of owner %q", snapshotID, deps.owner)
+	}
+	if snapshot.State != ec2types.SnapshotStateCompleted {
+		return ec2types.Snapshot{}, fmt.Errorf("snapshot %s is %s — wait for it to complete (%s)",
+			snapshotID, snapshot.State, hint.Cmd("mint snapshot list"))
+	}
+	return snapshot, nil
+}
+
+// findRestoredVolume returns the volume mint snapshot restore prepared for
+// vmName, or nil when there is none.
+func findRestoredVolume(ctx context.Context, describe mintaws.DescribeVolumesAPI, owner, vmName string) (*ec2types.Volume, error) {
+	out, err := describe.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: componentFilters(owner, vmName, tags.ComponentRestoredVolume),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe restored volumes: %w", err)
+	}
+	for i, v := range out.Volumes {
+		for _, t := range v.Tags {
+			if aws.ToString(t.Key) == tags.TagComponent && aws.ToString(t.Value) == tags.ComponentRestoredVolume {
+				return &out.Volumes[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// createRestoredVolume creates a gp3 volume from snapshot in az, at least
+// as large as the current project volume and with its IOPS. It is tagged
+// as vmName's restored volume and pending-attach until it is swapped in.
+func createRestoredVolume(ctx context.Context, deps *snapshotDeps, snapshot ec2types.Snapshot, current ec2types.Volume, az, vmName string) (string, error) {
+	size := aws.ToInt32(snapshot.VolumeSize)
+	if s := aws.ToInt32(current.Size); s > size {
+		size = s
+	}
+	volTags, err := tags.MergeExtra(append(
+		tags.NewTagBuilder(deps.owner, deps.ownerARN, vmName).
+			WithComponent(tags.ComponentRestoredVolume).
+			Build(),
+		ec2types.Tag{Key: aws.String(tags.TagPendingAttach), Value: aws.String("true")},
+	), deps.extraTags)
+	if err != nil {
+		return "", err
+	}
+
+	// The restored volume is encrypted even when the snapshot is not, so a
+	// restore moves an unencrypted project volume onto an encrypted one.
+	snapshotID := aws.ToString(snapshot.SnapshotId)
+	input := &ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String(az),
+		SnapshotId:       aws.String(snapshotID),
+		VolumeType:       ec2types.VolumeTypeGp3,
+		Size:             aws.Int32(size),
+		Iops:             cloneVolumeIOPS(current),
+		Encrypted:        aws.Bool(true),
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeVolume,
+			Tags:         volTags,
+		}},
+	}
+	if deps.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(deps.kmsKeyID)
+	}
+	out, err := deps.createVolume.CreateVolume(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("creating volume from snapshot %s: %w", snapshotID, err)
+	}
+	volumeID := aws.ToString(out.VolumeId)
+
+	if deps.waitVolumeAvailable != nil {
+		if err := deps.waitVolumeAvailable.Wait(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{volumeID},
+		}, 5*time.Minute); err != nil {
+			return "", fmt.Errorf("waiting for volume %s to become available: %w", volumeID, err)
+		}
+	}
+	return volumeID, nil
+}
+
+// swapProjectVolume detaches currentID from the stopped instance, attaches
+// restoredID in its place, and retags both.
+func swapProjectVolume(ctx context.Context, deps *snapshotDeps, instanceID, currentID, restoredID string) error {
+	if _, err := deps.detachVolume.DetachVolume(ctx, &ec2.DetachVolumeInput{
+		VolumeId:   aws.String(currentID),
+		InstanceId: aws.String(instanceID),
+	}); err != nil {
+		return fmt.Errorf("detaching project volume %s: %w", currentID, err)
+	}
+	if deps.waitVolumeAvailable != nil {
+		if err := deps.waitVolumeAvailable.Wait(ctx, &ec2.DescribeVolumesInput{
+			VolumeIds: []string{currentID},
+		}, 5*time.Minute); err != nil {
+			return fmt.Errorf("waiting for volume %s to detach: %w", currentID, err)
+		}
+	}
+	if err := retireProjectVolume(ctx, deps.createTags, deps.deleteTags, currentID); err != nil {
+		return err
+	}
+
+	if _, err := deps.attachVolume.AttachVolume(ctx, &ec2.AttachVolumeInput{
+		VolumeId:   aws.String(restoredID),
+		InstanceId: aws.String(instanceID),
+		Device:     aws.String("/dev/xvdf"),
+	}); err != nil {
+		return fmt.Errorf("attaching restored volume %s: %w", restoredID, err)
+	}
+	if err := adoptRestoredVolumeTags(ctx, deps.createTags, restoredID); err != nil {
+		return err
+	}
+	if _, err := deps.deleteTags.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{restoredID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagPendingAttach)}},
+	}); err != nil {
+		return fmt.Errorf("removing pending-attach tag from %s: %w", restoredID, err)
+	}
+	return nil
+}
+
+// retireProjectVolume retags a detached project volume as replaced, so no
+// later command finds it as the project volume or attaches it.
+func retireProjectVolume(ctx context.Context, createTags mintaws.CreateTagsAPI, deleteTags provision.DeleteTagsAPI, volumeID string) error {
+	if _, err := createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{volumeID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentReplacedVolume)}},
+	}); err != nil {
+		return fmt.Errorf("tagging replaced volume %s: %w", volumeID, err)
+	}
+	if _, err := deleteTags.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{volumeID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagPendingAttach)}},
+	}); err != nil {
+		return fmt.Errorf("removing pending-attach tag from %s: %w", volumeID, err)
+	}
+	return nil
+}
+
+// adoptRestoredVolumeTags retags a restored volume as the project volume.
+func adoptRestoredVolumeTags(ctx context.Context, createTags mintaws.CreateTagsAPI, volumeID string) error {
+	if _, err := createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{volumeID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentProjectVolume)}},
+	}); err != nil {
+		return fmt.Errorf("tagging restored volume %s as the project volume: %w", volumeID, err)
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// filterValue returns the first value of the named filter, or "".
+func filterValue(filters []ec2types.Filter, name string) string {
+	for _, f := range filters {
+		if aws.ToString(f.Name) == name && len(f.Values) > 0 {
+			return f.Values[0]
+		}
+	}
+	return ""
+}
+
+var snapshotTestNow = time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+
+// snapshotFixture bundles snapshot deps with the mocks tests assert on.
+type snapshotFixture struct {
+	deps         *snapshotDeps
+	volumes      *cmdtest.DescribeVolumesByTag
+	snapshots    *cmdtest.DescribeSnapshots
+	create       *cmdtest.CreateSnapshot
+	wait         *mockWaitSnapshotCompleted
+	createVolume *cmdtest.CreateVolume
+	attach       *cmdtest.AttachVolume
+	createTags   *cmdtest.CreateTags
+	deleteTags   *cmdtest.DeleteTags
+}
+
+// newSnapshotFixture wires VM "default" in state with a 100 GB project
+// volume vol-proj in us-east-1a and one completed snapshot snap-good.
+func newSnapshotFixture(state string) *snapshotFixture {
+	f := &snapshotFixture{
+		volumes: &cmdtest.DescribeVolumesByTag{Key: tags.TagComponent, ByValue: map[string][]ec2types.Volume{
+			tags.ComponentProjectVolume: {{
+				VolumeId:         aws.String("vol-proj"),
+				AvailabilityZone: aws.String("us-east-1a"),
+				Size:             aws.Int32(100),
+				Iops:             aws.Int32(4000),
+				VolumeType:       ec2types.VolumeTypeGp3,
+			}},
+		}},
+		snapshots: &cmdtest.DescribeSnapshots{Output: &ec2.DescribeSnapshotsOutput{
+			Snapshots: []ec2types.Snapshot{{
+				SnapshotId: aws.String("snap-good"),
+				State:      ec2types.SnapshotStateCompleted,
+				VolumeId:   aws.String("vol-proj"),
+				VolumeSize: aws.Int32(80),
+				StartTime:  aws.Time(snapshotTestNow.Add(-3 * time.Hour)),
+				Tags: []ec2types.Tag{
+					{Key: aws.String(tags.TagOwner), Value: aws.String("alice")},
+					{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentProjectSnapshot)},
+					{Key: aws.String(tags.TagSnapshotName), Value: aws.String("before-upgrade")},
+				},
+			}},
+		}},
+		create: &cmdtest.CreateSnapshot{Output: &ec2.CreateSnapshotOutput{
+			SnapshotId: aws.String("snap-new"),
+			State:      ec2types.SnapshotStatePending,
+		}},
+		wait:         &mockWaitSnapshotCompleted{},
+		createVolume: &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-restored")}},
+		attach:       &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		createTags:   &cmdtest.CreateTags{},
+		deleteTags:   &cmdtest.DeleteTags{},
+	}
+	instance := makeInstanceWithTimeAndAZ("i-abc", "default", "alice", state, "1.2.3.4", "m6i.xlarge", "complete", snapshotTestNow, "us-east-1a")
+	f.deps = &snapshotDeps{
+		describe:            &cmdtest.DescribeInstances{Output: instance},
+		describeVolumes:     f.volumes,
+		describeSnapshots:   f.snapshots,
+		createSnapshot:      f.create,
+		waitSnapshot:        f.wait,
+		createVolume:        f.createVolume,
+		waitVolumeAvailable: &mockWaitVolumeAvailable{},
+		detachVolume:        &cmdtest.DetachVolume{Output: &ec2.DetachVolumeOutput{}},
+		attachVolume:        f.attach,
+		createTags:          f.createTags,
+		deleteTags:          f.deleteTags,
+		owner:               "alice",
+		ownerARN:            "arn:aws:iam::123:user/alice",
+		now:                 func() time.Time { return snapshotTestNow },
+	}
+	return f
+}
+
+func runSnapshotCommand(t *testing.T, deps *snapshotDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newSnapshotCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"snapshot"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// componentTagged reports whether calls retag volumeID with component.
+func componentTagged(calls []*ec2.CreateTagsInput, volumeID, component string) bool {
+	for _, c := range calls {
+		v, ok := tagValue(c.Tags, tags.TagComponent)
+		if ok && v == component && len(c.Resources) == 1 && c.Resources[0] == volumeID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSnapshotCreateTagsSnapshot(t *testing.T) {
+	f := newSnapshotFixture("running")
+
+	out, err := runSnapshotCommand(t, f.deps, "create", "--name", "pre-migration")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := f.create.Input
+	if aws.ToString(in.VolumeId) != "vol-proj" {
+		t.Errorf("VolumeId = %q, want vol-proj", aws.ToString(in.VolumeId))
+	}
+	snapTags := in.TagSpecifications[0].Tags
+	for key, want := range map[string]string{
+		tags.TagOwner:           "alice",
+		tags.TagVM:              "default",
+		tags.TagComponent:       tags.ComponentProjectSnapshot,
+		tags.TagSnapshotName:    "pre-migration",
+		tags.TagSnapshotCreated: "2026-10-15T09:30:00Z",
+	} {
+		if got, _ := tagValue(snapTags, key); got != want {
+			t.Errorf("tag %s = %q, want %q", key, got, want)
+		}
+	}
+	if f.wait.called {
+		t.Error("waited for the snapshot without --wait")
+	}
+	if !strings.Contains(out, "crash-consistent") {
+		t.Errorf("output missing running-VM note:\n%s", out)
+	}
+	if !strings.Contains(out, "Started snapshot snap-new (pre-migration)") {
+		t.Errorf("output missing started line:\n%s", out)
+	}
+}
+
+func TestSnapshotCreateDefaultNameAndWait(t *testing.T) {
+	f := newSnapshotFixture("stopped")
+
+	out, err := runSnapshotCommand(t, f.deps, "create", "--wait")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := tagValue(f.create.Input.TagSpecifications[0].Tags, tags.TagSnapshotName); got != "20261015-093000" {
+		t.Errorf("default name = %q, want 20261015-093000", got)
+	}
+	if !f.wait.called {
+		t.Error("--wait did not wait for the snapshot")
+	}
+	if strings.Contains(out, "crash-consistent") {
+		t.Errorf("stopped VM should not get the crash-consistent note:\n%s", out)
+	}
+	if !strings.Contains(out, "is complete") {
+		t.Errorf("output missing completion line:\n%s", out)
+	}
+}
+
+func TestSnapshotCreateJSON(t *testing.T) {
+	f := newSnapshotFixture("running")
+
+	out, err := runSnapshotCommand(t, f.deps, "create", "--name", "n1", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got snapshotCreateJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	want := snapshotCreateJSON{SnapshotID: "snap-new", Name: "n1", VolumeID: "vol-proj", State: "pending"}
+	if got != want {
+		t.Errorf("JSON = %+v, want %+v", got, want)
+	}
+}
+
+func TestSnapshotCreateNoVM(t *testing.T) {
+	f := newSnapshotFixture("running")
+	f.deps.describe = &cmdtest.DescribeInstances{Output: makeEmptyDescribeOutput()}
+
+	_, err := runSnapshotCommand(t, f.deps, "create")
+	if err == nil || !strings.Contains(err.Error(), "no VM") {
+		t.Fatalf("expected no VM error, got %v", err)
+	}
+}
+
+func TestSnapshotListNewestFirst(t *testing.T) {
+	f := newSnapshotFixture("running")
+	f.snapshots.Output.Snapshots = append(f.snapshots.Output.Snapshots, ec2types.Snapshot{
+		SnapshotId: aws.String("snap-clone"),
+		State:      ec2types.SnapshotStatePending,
+		Progress:   aws.String("42%"),
+		VolumeId:   aws.String("vol-proj"),
+		VolumeSize: aws.Int32(100),
+		StartTime:  aws.Time(snapshotTestNow.Add(-5 * time.Minute)),
+	})
+
+	out, err := runSnapshotCommand(t, f.deps, "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.snapshots.Input.OwnerIds[0] != "self" {
+		t.Errorf("OwnerIds = %v, want [self]", f.snapshots.Input.OwnerIds)
+	}
+	if got := filterValue(f.snapshots.Input.Filters, "tag:"+tags.TagComponent); got != tags.ComponentProjectSnapshot {
+		t.Errorf("component filter = %q, want %q", got, tags.ComponentProjectSnapshot)
+	}
+	clone := strings.Index(out, "snap-clone")
+	good := strings.Index(out, "snap-good")
+	if clone < 0 || good < 0 || clone > good {
+		t.Errorf("want snap-clone listed before snap-good:\n%s", out)
+	}
+	if !strings.Contains(out, "pending (42%)") {
+		t.Errorf("output missing pending progress:\n%s", out)
+	}
+	if !strings.Contains(out, "before-upgrade") {
+		t.Errorf("output missing snapshot name:\n%s", out)
+	}
+}
+
+func TestSnapshotListJSON(t *testing.T) {
+	f := newSnapshotFixture("running")
+
+	out, err := runSnapshotCommand(t, f.deps, "list", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []snapshotJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(got) != 1 || got[0].SnapshotID != "snap-good" || got[0].Name != "before-upgrade" || got[0].SizeGiB != 80 {
+		t.Errorf("JSON = %+v", got)
+	}
+}
+
+func TestSnapshotListEmpty(t *testing.T) {
+	f := newSnapshotFixture("running")
+	f.snapshots.Output.Snapshots = nil
+
+	out, err := runSnapshotCommand(t, f.deps, "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `No snapshots of VM "default"`) {
+		t.Errorf("output missing empty message:\n%s", out)
+	}
+}
+
+func TestSnapshotRestoreRefusesRunningVM(t *testing.T) {
+	f := newSnapshotFixture("running")
+
+	_, err := runSnapshotCommand(t, f.deps, "restore", "snap-good")
+	if err == nil || !strings.Contains(err.Error(), "is running") {
+		t.Fatalf("expected running VM error, got %v", err)
+	}
+	if f.createVolume.Input != nil {
+		t.Error("created a volume for a refused restore")
+	}
+}
+
+func TestSnapshotRestoreRejectsForeignSnapshot(t *testing.T) {
+	f := newSnapshotFixture("stopped")
+	f.snapshots.Output.Snapshots[0].Tags[0].Value = aws.String("bob")
+
+	_, err := runSnapshotCommand(t, f.deps, "restore", "snap-good")
+	if err == nil || !strings.Contains(err.Error(), "not a project volume snapshot") {
+		t.Fatalf("expected foreign snapshot error, got %v", err)
+	}
+}
+
+func TestSnapshotRestoreRejectsPendingSnapshot(t *testing.T) {
+	f := newSnapshotFixture("stopped")
+	f.snapshots.Output.Snapshots[0].State = ec2types.SnapshotStatePending
+
+	_, err := runSnapshotCommand(t, f.deps, "restore", "snap-good")
+	if err == nil || !strings.Contains(err.Error(), "wait for it to complete") {
+		t.Fatalf("expected pending snapshot error, got %v", err)
+	}
+}
+
+func TestSnapshotRestoreForcePreparesVolume(t *testing.T) {
+	f := newSnapshotFixture("running")
+
+	out, err := runSnapshotCommand(t, f.deps, "restore", "snap-good", "--force")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := f.createVolume.Input
+	if aws.ToString(in.SnapshotId) != "snap-good" || aws.ToString(in.AvailabilityZone) != "us-east-1a" {
+		t.Errorf("CreateVolume snapshot/AZ = %s/%s", aws.ToString(in.SnapshotId), aws.ToString(in.AvailabilityZone))
+	}
+	if aws.ToInt32(in.Size) != 100 {
+		t.Errorf("Size = %d, want the larger current volume size 100", aws.ToInt32(in.Size))
+	}
+	if aws.ToInt32(in.Iops) != 4000 {
+		t.Errorf("Iops = %d, want 4000", aws.ToInt32(in.Iops))
+	}
+	volTags := in.TagSpecifications[0].Tags
+	if got, _ := tagValue(volTags, tags.TagComponent); got != tags.ComponentRestoredVolume {
+		t.Errorf("component = %q, want %q", got, tags.ComponentRestoredVolume)
+	}
+	if got, _ := tagValue(volTags, tags.TagPendingAttach); got != "true" {
+		t.Errorf("pending-attach = %q, want true", got)
+	}
+	if f.attach.Input != nil {
+		t.Error("attached the restored volume to a running VM")
+	}
+	if !strings.Contains(out, "mint recreate") {
+		t.Errorf("output missing recreate hint:\n%s", out)
+	}
+}
+
+func TestSnapshotRestoreEncryptsVolume(t *testing.T) {
+	f := newSnapshotFixture("running")
+	f.deps.kmsKeyID = "alias/mint"
+
+	if _, err := runSnapshotCommand(t, f.deps, "restore", "snap-good", "--force"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := f.createVolume.Input
+	if !aws.ToBool(in.Encrypted) {
+		t.Error("restored volume should be encrypted")
+	}
+	if got := aws.ToString(in.KmsKeyId); got != "alias/mint" {
+		t.Errorf("KmsKeyId = %q, want alias/mint", got)
+	}
+}
+
+func TestSnapshotRestoreRefusesSecondRestoredVolume(t *testing.T) {
+	f := newSnapshotFixture("running")
+	f.volumes.ByValue[tags.ComponentRestoredVolume] = []ec2types.Volume{{
+		VolumeId: aws.String("vol-waiting"),
+		Tags:     []ec2types.Tag{{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentRestoredVolume)}},
+	}}
+
+	_, err := runSnapshotCommand(t, f.deps, "restore", "snap-good", "--force")
+	if err == nil || !strings.Contains(err.Error(), "vol-waiting") {
+		t.Fatalf("expected already-restored error, got %v", err)
+	}
+}
+
+func TestSnapshotRestoreSwapsVolumeOnStoppedVM(t *testing.T) {
+	f := newSnapshotFixture("stopped")
+
+	out, err := runSnapshotCommand(t, f.deps, "restore", "snap-good")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if aws.ToString(f.attach.Input.VolumeId) != "vol-restored" || aws.ToString(f.attach.Input.Device) != "/dev/xvdf" {
+		t.Errorf("attached %s at %s, want vol-restored at /dev/xvdf",
+			aws.ToString(f.attach.Input.VolumeId), aws.ToString(f.attach.Input.Device))
+	}
+	if !componentTagged(f.createTags.Inputs, "vol-restored", tags.ComponentProjectVolume) {
+		t.Error("restored volume not retagged as the project volume")
+	}
+	if !componentTagged(f.createTags.Inputs, "vol-proj", tags.ComponentReplacedVolume) {
+		t.Error("old project volume not retagged as replaced")
+	}
+	var cleared []string
+	for _, c := range f.deleteTags.Inputs {
+		cleared = append(cleared, c.Resources...)
+	}
+	if strings.Join(cleared, ",") != "vol-proj,vol-restored" {
+		t.Errorf("pending-attach cleared on %v, want [vol-proj vol-restored]", cleared)
+	}
+	if !strings.Contains(out, "vol-proj is kept") {
+		t.Errorf("output missing kept-volume note:\n%s", out)
+	}
+}
+
+func TestRecreateSwapsInRestoredVolume(t *testing.T) {
+	lm := defaultLifecycleMocks()
+	deps := newHappyRecreateDepsWithMocks("alice", lm)
+	deps.describeVolumes = &cmdtest.DescribeVolumesByTag{Key: tags.TagComponent, ByValue: map[string][]ec2types.Volume{
+		tags.ComponentProjectVolume: {{VolumeId: aws.String("vol-proj123"), AvailabilityZone: aws.String("us-east-1a")}},
+		tags.ComponentRestoredVolume: {{
+			VolumeId:         aws.String("vol-restored"),
+			AvailabilityZone: aws.String("us-east-1a"),
+			Tags:             []ec2types.Tag{{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentRestoredVolume)}},
+		}},
+	}}
+	attach := &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}}
+	deps.attachVolume = attach
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newRecreateCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"recreate", "--yes"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	if aws.ToString(attach.Input.VolumeId) != "vol-restored" {
+		t.Errorf("attached %s, want vol-restored", aws.ToString(attach.Input.VolumeId))
+	}
+	createTags := lm.createTags
+	if !componentTagged(createTags.Inputs, "vol-proj123", tags.ComponentReplacedVolume) {
+		t.Error("old project volume not retagged as replaced")
+	}
+	if !componentTagged(createTags.Inputs, "vol-restored", tags.ComponentProjectVolume) {
+		t.Error("restored volume not retagged as the project volume")
+	}
+	if !strings.Contains(buf.String(), "Swapping in restored volume vol-restored") {
+		t.Errorf("output missing swap note:\n%s", buf.String())
+	}
+}
+
+func TestSnapshotRestoreOperationLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantCreate bool
+	}{
+		{"lock respected", nil, "locked by mint recreate", false},
+		{"lock stolen", []string{"--steal-lock"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSnapshotFixture("stopped")
+			describe := f.deps.describe.(*cmdtest.DescribeInstances)
+			inst := &describe.Output.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagOperation), Value: aws.String("recreate:desktop:4242:2026-10-15T09:20:00Z")})
+			f.deps.locker = provision.NewLocker(describe, &cmdtest.CreateTags{}, &cmdtest.DeleteTags{}).
+				WithWarnings(new(bytes.Buffer)).
+				WithIdentity("laptop", 100, f.deps.now)
+
+			_, err := runSnapshotCommand(t, f.deps, append([]string{"restore", "snap-good"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created := f.createVolume.Input != nil; created != tt.wantCreate {
+				t.Errorf("CreateVolume called = %v, want %v", created, tt.wantCreate)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -31,6 +33,7 @@ type sshDeps struct {
 	runner         CommandRunner
 	hostKeyStore   *sshconfig.HostKeyStore
 	hostKeyScanner HostKeyScanner
+	sshOptions     sshconfig.Options
 }
 
 // newSSHCommand creates the production ssh command.
@@ -58,10 +61,11 @@ func newSSHCommandWithDeps(deps *sshDeps) *cobra.Command {
 			configDir := config.DefaultConfigDir()
 			return runSSH(cmd, &sshDeps{
 				describe:       clients.ec2Client,
-				sendKey:        clients.icClient,
+				sendKey:        clients.sendKey,
 				owner:          clients.owner,
 				hostKeyStore:   sshconfig.NewHostKeyStore(configDir),
 				hostKeyScanner: defaultHostKeyScanner,
+				sshOptions:     clients.sshOptions,
 			}, args)
 		},
 	}
@@ -143,7 +147,7 @@ func runSSH(cmd *cobra.Command, deps *sshDeps, extraArgs []string) error {
 	// Push public key via Instance Connect.
 	_, err = deps.sendKey.SendSSHPublicKey(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
 		InstanceId:       aws.String(found.ID),
-		InstanceOSUser:   aws.String(defaultSSHUser),
+		InstanceOSUser:   aws.String(deps.sshOptions.LoginUser(defaultSSHUser)),
 		SSHPublicKey:     aws.String(pubKey),
 		AvailabilityZone: aws.String(found.AvailabilityZone),
 	})
@@ -151,10 +155,12 @@ func runSSH(cmd *cobra.Command, deps *sshDeps, extraArgs []string) error {
 		return fmt.Errorf("pushing SSH key via Instance Connect: %w", err)
 	}
 
+	port := deps.sshOptions.LoginPort(defaultSSHPort)
+
 	// TOFU host key verification (ADR-0019).
 	var knownHostsPath string
 	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
-		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, defaultSSHPort)
+		fingerprint, hostKeyLine, scanErr := deps.hostKeyScanner(found.PublicIP, port)
 		if scanErr != nil {
 			return fmt.Errorf("scanning host key: %w", scanErr)
 		}
@@ -191,7 +197,7 @@ func runSSH(cmd *cobra.Command, deps *sshDeps, extraArgs []string) error {
 		defer os.Remove(knownHostsPath)
 
 		// Write the host key line in OpenSSH known_hosts format.
-		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, defaultSSHPort, hostKeyLine)
+		hostEntry := fmt.Sprintf("[%s]:%d %s\n", found.PublicIP, port, hostKeyLine)
 		if _, err := tmpKH.WriteString(hostEntry); err != nil {
 			tmpKH.Close()
 			return fmt.Errorf("writing temp known_hosts: %w", err)
@@ -202,7 +208,7 @@ func runSSH(cmd *cobra.Command, deps *sshDeps, extraArgs []string) error {
 	// Build ssh command arguments.
 	sshArgs := []string{
 		"-i", privKeyPath,
-		"-p", fmt.Sprintf("%d", defaultSSHPort),
+		"-p", fmt.Sprintf("%d", port),
 	}
 	if knownHostsPath != "" {
 		sshArgs = append(sshArgs,
@@ -216,7 +222,8 @@ func runSSH(cmd *cobra.Command, deps *sshDeps, extraArgs []string) error {
 			"-o", "UserKnownHostsFile=/dev/null",
 		)
 	}
-	sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", defaultSSHUser, found.PublicIP))
+	sshArgs = append(sshArgs, deps.sshOptions.Args()...)
+	sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", deps.sshOptions.LoginUser(defaultSSHUser), found.PublicIP))
 	sshArgs = append(sshArgs, extraArgs...)
 
 	runner := deps.runner
@@ -241,7 +248,7 @@ func defaultHostKeyScanner(host string, port int) (fingerprint string, hostKeyLi
 
 	output := stdout.String()
 	if output == "" {
-		return "", "", fmt.Errorf("ssh-keyscan returned no keys for %s:%d", host, port)
+		return "", "", fmt.Errorf("ssh-keyscan returned no keys for %s", net.JoinHostPort(host, strconv.Itoa(port)))
 	}
 
 	// Parse the first valid key line. Format: "host key-type base64-data"
@@ -268,5 +275,5 @@ func defaultHostKeyScanner(host string, port int) (fingerprint string, hostKeyLi
 		return fp, keyLine, nil
 	}
 
-	return "", "", fmt.Errorf("ssh-keyscan returned no parseable keys for %s:%d", host, port)
+	return "", "", fmt.Errorf("ssh-keyscan returned no parseable keys for %s", net.JoinHostPort(host, strconv.Itoa(port)))
 }
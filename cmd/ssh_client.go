@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+)
+
+// LocalProbe runs a command on the laptop and returns its output. Tests
+// inject fixtures.
+type LocalProbe func() (string, error)
+
+// sshVersionProbe runs `ssh -V`, which prints the client version on stderr.
+func sshVersionProbe() (string, error) {
+	out, err := exec.Command("ssh", "-V").CombinedOutput()
+	return string(out), err
+}
+
+// codeExtensionsProbe lists VS Code's extensions with their versions.
+func codeExtensionsProbe() (string, error) {
+	out, err := exec.Command("code", "--list-extensions", "--show-versions").Output()
+	return string(out), err
+}
+
+// localSSHVersion is the probe the SSH config writers use to fit Host
+// blocks to the laptop's ssh client.
+var localSSHVersion LocalProbe = sshVersionProbe
+
+// probeSSHClient returns the version of the ssh client probe reports. ok
+// is false when ssh could not be run or is not OpenSSH.
+func probeSSHClient(probe LocalProbe) (sshconfig.Version, bool) {
+	out, err := probe()
+	if err != nil {
+		return sshconfig.Version{}, false
+	}
+	return sshconfig.ParseClientVersion(out)
+}
+
+// fitSSHClient returns opts fitted to the laptop's ssh client, so the
+// Host blocks written with them hold only options the client accepts. Each
+// directive that changes is reported on w: a note for one left out or
+// renamed, a warning with upgrade guidance for one the block needs. With
+// project, the directives of project Host blocks are reported instead of
+// the VM's.
+func fitSSHClient(w io.Writer, opts sshconfig.Options, project bool) sshconfig.Options {
+	client, ok := probeSSHClient(localSSHVersion)
+	if !ok {
+		return opts
+	}
+	opts.Client = client
+	issues := opts.ClientIssues()
+	if project {
+		issues = opts.ProjectClientIssues()
+	}
+	for _, issue := range issues {
+		if issue.Required {
+			fmt.Fprintf(w, "Warning: your ssh client (OpenSSH %s) rejects the SSH config: %s — %s.\n",
+				client, issue, sshUpgradeHint(issue.Min))
+			continue
+		}
+		fmt.Fprintf(w, "Note: your ssh client is OpenSSH %s: %s.\n", client, issue)
+	}
+	return opts
+}
+
+// sshUpgradeHint tells the user how to get OpenSSH min or later.
+func sshUpgradeHint(min sshconfig.Version) string {
+	return fmt.Sprintf("upgrade to OpenSSH %s or later (on macOS, %s; on Windows, update the OpenSSH Client optional feature)",
+		min, hint.Cmd("brew install openssh"))
+}
+
+// checkSSHClient reports whether the laptop's ssh client supports every
+// directive in the Host blocks mint writes for the configured SSH options.
+func checkSSHClient(deps *doctorDeps) checkResult {
+	const name = "SSH client"
+	out, err := deps.sshVersion()
+	if err != nil {
+		return checkResult{
+			name:    name,
+			status:  "WARN",
+			message: fmt.Sprintf("could not run ssh -V: %v — install OpenSSH to use mint ssh, mint code, and the SSH config", err),
+		}
+	}
+	client, ok := sshconfig.ParseClientVersion(out)
+	if !ok {
+		return checkResult{
+			name:    name,
+			status:  "WARN",
+			message: fmt.Sprintf("unrecognized ssh client %q — the SSH config mint writes is for OpenSSH", strings.TrimSpace(out)),
+		}
+	}
+
+	var opts sshconfig.Options
+	if cfg, err := config.Load(deps.configDir); err == nil {
+		if resolved, err := resolveSSHOptions(cfg, nil); err == nil {
+			opts = resolved
+		}
+	}
+	opts.Client = client
+	issues := append(opts.ClientIssues(), opts.ProjectClientIssues()...)
+	if len(issues) == 0 {
+		return checkResult{
+			name:    name,
+			status:  "PASS",
+			message: fmt.Sprintf("OpenSSH %s supports every option mint writes", client),
+		}
+	}
+
+	details := make([]string, 0, len(issues))
+	var upgradeTo sshconfig.Version
+	for _, issue := range issues {
+		details = append(details, issue.String())
+		if upgradeTo.Before(issue.Min) {
+			upgradeTo = issue.Min
+		}
+	}
+	return checkResult{
+		name:    name,
+		status:  "WARN",
+		message: fmt.Sprintf("OpenSSH %s: %s — %s", client, strings.Join(details, "; "), sshUpgradeHint(upgradeTo)),
+	}
+}
+
+// checkRemoteSSH reports whether VS Code's Remote-SSH extension is new
+// enough for mint's project Host blocks. ok is false when VS Code or the
+// extension is not installed, or the check is disabled.
+func checkRemoteSSH(deps *doctorDeps) (result checkResult, ok bool) {
+	if deps.codeExtensions == nil {
+		return checkResult{}, false
+	}
+	out, err := deps.codeExtensions()
+	if err != nil {
+		return checkResult{}, false
+	}
+	version, found := sshconfig.ParseRemoteSSHVersion(out)
+	if !found {
+		return checkResult{}, false
+	}
+	const name = "VS Code Remote-SSH"
+	if version.Before(sshconfig.MinRemoteSSHVersion) {
+		return checkResult{
+			name:   name,
+			status: "WARN",
+			message: fmt.Sprintf("Remote-SSH %s is older than %s, which mint code needs for project hosts — update the extension in VS Code",
+				version, sshconfig.MinRemoteSSHVersion),
+		}, true
+	}
+	return checkResult{
+		name:    name,
+		status:  "PASS",
+		message: fmt.Sprintf("Remote-SSH %s", version),
+	}, true
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+)
+
+// sshVersionFixture returns a LocalProbe that reports output.
+func sshVersionFixture(output string) LocalProbe {
+	return func() (string, error) { return output, nil }
+}
+
+func runDoctorWithProbes(t *testing.T, deps *doctorDeps) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newDoctorCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"doctor"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("doctor: %v\n%s", err, buf.String())
+	}
+	return buf.String()
+}
+
+func TestDoctorSSHClient(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name      string
+		probe     LocalProbe
+		extraArgs string
+		want      string
+	}{
+		{
+			name:  "modern ssh",
+			probe: sshVersionFixture("OpenSSH_9.6p1, LibreSSL 3.3.6\n"),
+			want:  "[PASS] SSH client: OpenSSH 9.6 supports every option mint writes",
+		},
+		{
+			name:  "old ssh",
+			probe: sshVersionFixture("OpenSSH_7.4p1, OpenSSL 1.0.2k-fips  26 Jan 2017\n"),
+			want: "[WARN] SSH client: OpenSSH 7.4: StrictHostKeyChecking accept-new needs OpenSSH 7.6 or later — left out, " +
+				"so ssh asks to confirm the VM's host key on first connection; RemoteCommand needs OpenSSH 7.6 or later — " +
+				"upgrade to OpenSSH 7.6 or later (on macOS, `brew install openssh`; on Windows, update the OpenSSH Client optional feature)",
+		},
+		{
+			name:      "configured options checked",
+			probe:     sshVersionFixture("OpenSSH_for_Windows_8.1p1, LibreSSL 3.0.2\r\n"),
+			extraArgs: `ssh_extra_args = ["-o", "PubkeyAcceptedAlgorithms=+ssh-rsa"]`,
+			want:      "[WARN] SSH client: OpenSSH 8.1: PubkeyAcceptedAlgorithms needs OpenSSH 8.5 or later — written as PubkeyAcceptedKeyTypes",
+		},
+		{
+			name:  "not OpenSSH",
+			probe: sshVersionFixture("Dropbear v2022.83\n"),
+			want:  `[WARN] SSH client: unrecognized ssh client "Dropbear v2022.83" — the SSH config mint writes is for OpenSSH`,
+		},
+		{
+			name:  "ssh missing",
+			probe: func() (string, error) { return "", errors.New(`exec: "ssh": executable file not found in $PATH`) },
+			want:  "[WARN] SSH client: could not run ssh -V:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.sshVersion = tt.probe
+			if tt.extraArgs != "" {
+				f, err := os.OpenFile(filepath.Join(deps.configDir, "config.toml"), os.O_APPEND|os.O_WRONLY, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.WriteString(tt.extraArgs + "\n")
+				f.Close()
+			}
+
+			out := runDoctorWithProbes(t, deps)
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestDoctorRemoteSSH(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe LocalProbe
+		want  string
+	}{
+		{"current", sshVersionFixture("ms-vscode-remote.remote-ssh@0.113.1\n"), "[PASS] VS Code Remote-SSH: Remote-SSH 0.113"},
+		{"old", sshVersionFixture("ms-vscode-remote.remote-ssh@0.51.0\n"),
+			"[WARN] VS Code Remote-SSH: Remote-SSH 0.51 is older than 0.65, which mint code needs for project hosts — update the extension in VS Code"},
+		{"not installed", sshVersionFixture("golang.go@0.41.4\n"), ""},
+		{"no code", func() (string, error) { return "", errors.New("not found") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyDoctorDeps(t)
+			deps.codeExtensions = tt.probe
+
+			out := runDoctorWithProbes(t, deps)
+			if tt.want == "" {
+				if strings.Contains(out, "Remote-SSH") {
+					t.Errorf("Remote-SSH reported without the extension:\n%s", out)
+				}
+				return
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestSSHConfigCommandFitsOldClient(t *testing.T) {
+	orig := localSSHVersion
+	localSSHVersion = sshVersionFixture("OpenSSH_7.4p1, OpenSSL 1.0.2k-fips  26 Jan 2017\n")
+	defer func() { localSSHVersion = orig }()
+
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	sshConfigPath := filepath.Join(t.TempDir(), "config")
+
+	buf := new(bytes.Buffer)
+	root := NewRootCommand()
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{
+		"ssh-config", "--yes",
+		"--ssh-config-path", sshConfigPath,
+		"--hostname", "1.2.3.4",
+		"--instance-id", "i-abc123",
+		"--az", "us-east-1a",
+	})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("ssh-config: %v\n%s", err, buf.String())
+	}
+
+	data, err := os.ReadFile(sshConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "StrictHostKeyChecking") {
+		t.Errorf("accept-new written for OpenSSH 7.4:\n%s", data)
+	}
+	want := "Note: your ssh client is OpenSSH 7.4: StrictHostKeyChecking accept-new needs OpenSSH 7.6 or later — left out"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestFitSSHClientWarnsForRequiredDirective(t *testing.T) {
+	hint.IsTTY = false
+	orig := localSSHVersion
+	localSSHVersion = sshVersionFixture("OpenSSH_7.4p1\n")
+	defer func() { localSSHVersion = orig }()
+
+	buf := new(bytes.Buffer)
+	opts := fitSSHClient(buf, sshconfig.Options{}, true)
+	if opts.Client.String() != "7.4" {
+		t.Errorf("client = %v, want 7.4", opts.Client)
+	}
+	want := "Warning: your ssh client (OpenSSH 7.4) rejects the SSH config: RemoteCommand needs OpenSSH 7.6 or later — " +
+		"upgrade to OpenSSH 7.6 or later (on macOS, `brew install openssh`;"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
@@ -1,16 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"strings"
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +32,16 @@ type sshConfigDeps struct {
 	owner    string
 }
 
+// sshConfigSyncDeps holds the injectable dependencies for the ssh-config
+// sync command.
+type sshConfigSyncDeps struct {
+	describe mintaws.DescribeInstancesAPI
+	sendKey  mintaws.SendSSHPublicKeyAPI
+	remote   RemoteCommandRunner
+	sshPort  int // ssh_port from config; zero means defaultSSHPort
+	owner    string
+}
+
 // newSSHConfigCommand creates the production ssh-config command.
 func newSSHConfigCommand() *cobra.Command {
 	return newSSHConfigCommandWithDeps(nil)
@@ -58,16 +72,88 @@ func newSSHConfigCommandWithDeps(deps *sshConfigDeps) *cobra.Command {
 	cmd.Flags().String("ssh-config-path", "", "Path to SSH config file (default: ~/.ssh/config)")
 	cmd.Flags().Bool("remove", false, "Remove the managed block for the VM")
 
+	cmd.AddCommand(newSSHConfigSyncCommand())
+
 	return cmd
 }
 
-// defaultSSHConfigPath returns ~/.ssh/config.
-func defaultSSHConfigPath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(".", ".ssh", "config")
+// newSSHConfigSyncCommand creates the production ssh-config sync command.
+func newSSHConfigSyncCommand() *cobra.Command {
+	return newSSHConfigSyncCommandWithDeps(nil)
+}
+
+// newSSHConfigSyncCommandWithDeps creates the ssh-config sync command with
+// explicit dependencies for testing.
+func newSSHConfigSyncCommandWithDeps(deps *sshConfigSyncDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Regenerate the SSH config entries for a VM and its projects",
+		Long: "Regenerate the managed Host block for the VM and one Host block per " +
+			"project devcontainer on it from the VM's live address, instance ID, " +
+			"and availability zone. Each project block is marked with " +
+			"# mint:project-begin/end markers and named mint-<vm>-<project>; " +
+			"connecting to it opens a shell in the project's container. Blocks " +
+			"for projects without a container are removed.\n\n" +
+			"Only the mint-managed blocks are rewritten; the rest of the file and " +
+			"its permissions are kept, and a missing file is created. A diff of " +
+			"the changes is printed unless --quiet is given. The command exits 2 " +
+			"when the file was out of date (with --dry-run, when it would have " +
+			"changed), so shell hooks can chain it.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all, _ := cmd.Flags().GetBool("all"); all && cmd.Flags().Changed("vm") {
+				return fmt.Errorf("--vm cannot be combined with --all")
+			}
+			if deps != nil {
+				return runSSHConfigSync(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runSSHConfigSync(cmd, &sshConfigSyncDeps{
+				describe: clients.ec2Client,
+				sendKey:  clients.sendKey,
+				remote:   clients.remoteRunner(),
+				sshPort:  clients.sshOptions.Port,
+				owner:    clients.owner,
+			})
+		},
 	}
-	return filepath.Join(home, ".ssh", "config")
+
+	cmd.Flags().String("ssh-config-path", "", "Path to SSH config file (default: ~/.ssh/config)")
+	cmd.Flags().Bool("all", false, "Sync the Host blocks of every running VM")
+	cmd.Flags().Bool("dry-run", false, "Show what would change without writing the file")
+	cmd.Flags().BoolP("quiet", "q", false, "Do not print the diff or summary")
+
+	return cmd
+}
+
+// sshConfigSyncCommand returns the ssh-config sync command line for the VM
+// vmName, for hints.
+func sshConfigSyncCommand(vmName string) string {
+	if vmName == "default" {
+		return "mint ssh-config sync"
+	}
+	return "mint ssh-config sync --vm " + vmName
+}
+
+// printStaleSSHConfigHint tells the user on w that the Host block for the
+// VM vmName is out of date and how to repair it.
+func printStaleSSHConfigHint(w io.Writer, vmName string) {
+	fmt.Fprintf(w, "SSH config block for VM %q is out of date — run %s to update it.\n",
+		vmName, hint.Cmd(sshConfigSyncCommand(vmName)))
+}
+
+// warnStaleSSHConfig prints the stale-block hint when the SSH config file at
+// path has a Host block for the VM vmName that no longer connects to
+// hostname and instanceID. It never edits the file.
+func warnStaleSSHConfig(w io.Writer, path, vmName, hostname, instanceID string) {
+	content, err := sshconfig.ReadConfig(path)
+	if err != nil || !sshconfig.IsStale(content, vmName, hostname, instanceID) {
+		return
+	}
+	printStaleSSHConfigHint(w, vmName)
 }
 
 func runSSHConfig(cmd *cobra.Command, deps *sshConfigDeps) error {
@@ -83,7 +169,7 @@ func runSSHConfig(cmd *cobra.Command, deps *sshConfigDeps) error {
 
 	sshConfigPath, _ := cmd.Flags().GetString("ssh-config-path")
 	if sshConfigPath == "" {
-		sshConfigPath = defaultSSHConfigPath()
+		sshConfigPath = paths.SSHConfigPath()
 	}
 
 	remove, _ := cmd.Flags().GetBool("remove")
@@ -154,64 +240,250 @@ func runSSHConfig(cmd *cobra.Command, deps *sshConfigDeps) error {
 		az = found.AvailabilityZone
 	}
 
-	// ADR-0015: Check permission before writing to ~/.ssh/config.
+	hosts, err := approvedProjectHosts(cmd, sshConfigPath, yes)
+	if err != nil {
+		return err
+	}
+	if err := writeVMHost(w, hosts, vmName, hostname, instanceID, az); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "SSH config updated for VM %q (Host mint-%s).\n", vmName, vmName)
+	return nil
+}
+
+// approvedProjectHosts loads the mint config, checks permission to write
+// sshConfigPath (ADR-0015), storing it when yes is set, and returns the
+// settings for the Host blocks written there.
+func approvedProjectHosts(cmd *cobra.Command, sshConfigPath string, yes bool) (*projectHosts, error) {
 	configDir := config.DefaultConfigDir()
 	cfg, err := config.Load(configDir)
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return nil, fmt.Errorf("load config: %w", err)
 	}
 
 	if !cfg.SSHConfigApproved {
 		if !yes {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"mint needs permission to write to %s (ADR-0015) — "+
 					"run with --yes to approve, or set ssh_config_approved=true in config\n%s",
 				sshConfigPath,
-				hint.Suggest("Approve", "mint ssh-config --yes"),
+				hint.Suggest("Approve", cmd.CommandPath()+" --yes"),
 			)
 		}
 
 		// Store approval so we never prompt again.
 		cfg.SSHConfigApproved = true
 		if err := config.Save(cfg, configDir); err != nil {
-			return fmt.Errorf("save config: %w", err)
+			return nil, fmt.Errorf("save config: %w", err)
 		}
-		fmt.Fprintf(w, "SSH config write approval stored.\n")
+		fmt.Fprintf(cmd.OutOrStdout(), "SSH config write approval stored.\n")
 	}
 
-	// Check for hand edits on existing block.
-	if data, err := os.ReadFile(sshConfigPath); err == nil {
-		if sshconfig.HasHandEdits(string(data), vmName) {
-			fmt.Fprintf(w, "Warning: hand-edits detected in managed block for %q. Overwriting.\n", vmName)
-		}
-	}
+	return configProjectHosts(cmd, cfg, sshConfigPath)
+}
 
+// configProjectHosts returns the settings for the Host blocks written to
+// sshConfigPath from cfg and the global flags.
+func configProjectHosts(cmd *cobra.Command, cfg *config.Config, sshConfigPath string) (*projectHosts, error) {
 	// Determine effective profile and region for the aws CLI in ProxyCommand.
+	cliCtx := cli.FromCommand(cmd)
 	profile := ""
-	region := ""
 	if cliCtx != nil {
 		profile = cliCtx.Profile
 	}
 	if profile == "" {
 		profile = cfg.AWSProfile
 	}
-	region = cfg.Region
 
-	// Generate and write the managed block.
-	block := sshconfig.GenerateBlock(vmName, hostname, defaultSSHUser, defaultSSHPort, instanceID, az, profile, region)
-	if err := sshconfig.WriteManagedBlock(sshConfigPath, vmName, block); err != nil {
-		return fmt.Errorf("write ssh config: %w", err)
+	sshOptions, err := resolveSSHOptions(cfg, cliCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Fprintf(w, "SSH config updated for VM %q (Host mint-%s).\n", vmName, vmName)
+	return &projectHosts{path: sshConfigPath, profile: profile, region: cfg.Region, sshOptions: sshOptions}, nil
+}
+
+// writeVMHost writes the managed Host block for the VM vmName, warning on w
+// when it overwrites hand edits or the block does not suit the laptop's ssh
+// client.
+func writeVMHost(w io.Writer, hosts *projectHosts, vmName, hostname, instanceID, az string) error {
+	if data, err := os.ReadFile(hosts.path); err == nil {
+		if sshconfig.HasHandEdits(string(data), vmName) {
+			fmt.Fprintf(w, "Warning: hand-edits detected in managed block for %q. Overwriting.\n", vmName)
+		}
+	}
+
+	block := vmHostBlock(w, hosts, vmName, hostname, instanceID, az)
+	if err := sshconfig.WriteManagedBlock(hosts.path, vmName, block); err != nil {
+		return fmt.Errorf("write ssh config: %w", err)
+	}
 	return nil
 }
 
+// vmHostBlock returns the managed Host block for the VM vmName, warning on
+// w when it does not suit the laptop's ssh client.
+func vmHostBlock(w io.Writer, hosts *projectHosts, vmName, hostname, instanceID, az string) string {
+	opts := fitSSHClient(w, hosts.sshOptions, false)
+	return sshconfig.GenerateBlockWithOptions(vmName, hostname, opts.LoginUser(defaultSSHUser), opts.LoginPort(defaultSSHPort), instanceID, az, hosts.profile, hosts.region, opts)
+}
+
+// runSSHConfigSync regenerates the Host blocks of the VM, or of every
+// running VM with --all, and their project Host blocks from the
+// devcontainers on them. The new content is built in memory and written
+// only when it differs; an out-of-date file exits with exitCodeOutOfDate.
+func runSSHConfigSync(cmd *cobra.Command, deps *sshConfigSyncDeps) error {
+	ctx := cmd.Context()
+	cliCtx := cli.FromCommand(cmd)
+	w := cmd.OutOrStdout()
+
+	vmName := "default"
+	yes := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		yes = cliCtx.Yes
+	}
+	all, _ := cmd.Flags().GetBool("all")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	out := w
+	if quiet {
+		out = io.Discard
+	}
+
+	sshConfigPath, _ := cmd.Flags().GetString("ssh-config-path")
+	if sshConfigPath == "" {
+		sshConfigPath = paths.SSHConfigPath()
+	}
+
+	targets, err := sshConfigSyncTargets(ctx, out, deps, vmName, all)
+	if err != nil {
+		return err
+	}
+
+	// A dry run writes nothing, so it needs no permission to write (ADR-0015).
+	var hosts *projectHosts
+	if dryRun {
+		cfg, err := config.Load(config.DefaultConfigDir())
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		hosts, err = configProjectHosts(cmd, cfg, sshConfigPath)
+		if err != nil {
+			return err
+		}
+	} else {
+		hosts, err = approvedProjectHosts(cmd, sshConfigPath, yes)
+		if err != nil {
+			return err
+		}
+	}
+
+	before, err := sshconfig.ReadConfig(sshConfigPath)
+	if err != nil {
+		return err
+	}
+	after := before
+	var summary strings.Builder
+	for _, found := range targets {
+		containers, err := listProjectContainers(ctx, deps.remote, deps.sendKey, found, sshPortOrDefault(deps.sshPort))
+		if err != nil {
+			return err
+		}
+		if sshconfig.HasHandEdits(after, found.Name) {
+			if dryRun {
+				fmt.Fprintf(w, "Warning: hand-edits detected in managed block for %q. Sync would overwrite them.\n", found.Name)
+			} else {
+				fmt.Fprintf(w, "Warning: hand-edits detected in managed block for %q. Overwriting.\n", found.Name)
+			}
+		}
+		vmBefore := after
+		after = sshconfig.ReplaceManagedBlock(after, found.Name,
+			vmHostBlock(w, hosts, found.Name, found.PublicIP, found.ID, found.AvailabilityZone))
+		var pruned []string
+		after, pruned = sshconfig.ReplaceProjectBlocks(after, found.Name,
+			projectHostBlocks(w, hosts, found.Name, found, containers))
+
+		switch {
+		case after == vmBefore:
+			fmt.Fprintf(&summary, "SSH config for VM %q is up to date.\n", found.Name)
+		case dryRun:
+			fmt.Fprintf(&summary, "SSH config for VM %q is out of date (dry run; nothing written).\n", found.Name)
+		default:
+			fmt.Fprintf(&summary, "SSH config updated for VM %q (Host mint-%s).\n", found.Name, found.Name)
+			for _, c := range containers {
+				fmt.Fprintf(&summary, "  Host %s  project %s\n", sshconfig.ProjectHost(found.Name, c.Project), c.Project)
+			}
+			for _, project := range pruned {
+				fmt.Fprintf(&summary, "  Removed Host %s (no container)\n", sshconfig.ProjectHost(found.Name, project))
+			}
+		}
+	}
+
+	if after == before {
+		fmt.Fprint(out, summary.String())
+		return nil
+	}
+	fmt.Fprint(out, sshconfig.Diff(sshConfigPath, before, after))
+	if !dryRun {
+		if err := sshconfig.WriteConfig(sshConfigPath, after); err != nil {
+			return fmt.Errorf("write ssh config: %w", err)
+		}
+	}
+	fmt.Fprint(out, summary.String())
+	return exitCodeError{code: exitCodeOutOfDate}
+}
+
+// sshConfigSyncTargets returns the VMs whose Host blocks ssh-config sync
+// regenerates: the running VM vmName, or with all every running VM, noting
+// the others on w.
+func sshConfigSyncTargets(ctx context.Context, w io.Writer, deps *sshConfigSyncDeps, vmName string, all bool) ([]*vm.VM, error) {
+	running := string(ec2types.InstanceStateNameRunning)
+	if !all {
+		found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+		if err != nil {
+			return nil, fmt.Errorf("discovering VM: %w", err)
+		}
+		if found == nil {
+			return nil, fmt.Errorf("no VM %q found — run %s first to create one", vmName, hint.Cmd("mint up"))
+		}
+		if found.State != running {
+			return nil, fmt.Errorf("VM %q (%s) is not running (state: %s) — run %s to start it",
+				vmName, found.ID, found.State, hint.Cmd("mint up"))
+		}
+		return []*vm.VM{found}, nil
+	}
+
+	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		return nil, fmt.Errorf("listing VMs: %w", err)
+	}
+	var targets []*vm.VM
+	for _, v := range vms {
+		if v.State != running {
+			fmt.Fprintf(w, "Skipping VM %q (%s): not running.\n", v.Name, v.State)
+			continue
+		}
+		targets = append(targets, v)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no running VMs found — run %s to start one", hint.Cmd("mint up"))
+	}
+	return targets, nil
+}
+
 func runSSHConfigRemove(cmd *cobra.Command, sshConfigPath, vmName string) error {
 	found, err := sshconfig.RemoveManagedBlock(sshConfigPath, vmName)
 	if err != nil {
 		return fmt.Errorf("remove ssh config block: %w", err)
 	}
+	projects, err := sshconfig.RemoveProjectBlocks(sshConfigPath, vmName)
+	if err != nil {
+		return fmt.Errorf("remove ssh config block: %w", err)
+	}
+	for _, project := range projects {
+		fmt.Fprintf(cmd.OutOrStdout(), "SSH config block removed for Host %s.\n", sshconfig.ProjectHost(vmName, project))
+	}
 
 	if found {
 		fmt.Fprintf(cmd.OutOrStdout(), "SSH config block removed for VM %q.\n", vmName)
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// projectHosts holds what writing a VM's project Host blocks needs beyond
+// the VM itself (see sshconfig.GenerateProjectBlock). Commands hold a nil
+// *projectHosts when the user has not approved writes to ~/.ssh/config.
+type projectHosts struct {
+	path       string // SSH config path; empty uses ~/.ssh/config
+	profile    string // AWS profile for the ProxyCommand aws CLI
+	region     string // AWS region for the ProxyCommand aws CLI
+	sshOptions sshconfig.Options
+}
+
+// newProjectHosts returns the projectHosts for cmd, or nil when writing
+// ~/.ssh/config is not approved. The --profile flag takes precedence over
+// the aws_profile config key.
+func newProjectHosts(cmd *cobra.Command, clients *awsClients) *projectHosts {
+	if clients.mintConfig == nil || !clients.mintConfig.SSHConfigApproved {
+		return nil
+	}
+	profile := ""
+	if cliCtx := cli.FromCommand(cmd); cliCtx != nil {
+		profile = cliCtx.Profile
+	}
+	if profile == "" {
+		profile = clients.mintConfig.AWSProfile
+	}
+	return &projectHosts{
+		profile:    profile,
+		region:     clients.region,
+		sshOptions: clients.sshOptions,
+	}
+}
+
+// projectContainersCommand lists every devcontainer on the VM as its
+// devcontainer.local_folder and devcontainer.metadata labels.
+func projectContainersCommand() []string {
+	return []string{
+		"docker", "ps", "-a",
+		"--format", shellQuote(`{{.Label "devcontainer.local_folder"}}` + "\t" + `{{.Label "devcontainer.metadata"}}`),
+		"--filter", "label=devcontainer.local_folder",
+	}
+}
+
+// parseProjectContainers parses projectContainersCommand output into one
+// container per project, the first listed for each. Containers built
+// outside /mint/projects are skipped.
+func parseProjectContainers(out string) []sshconfig.Container {
+	var containers []sshconfig.Container
+	seen := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		folder, metadata, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		name, ok := strings.CutPrefix(projectRootFolder(folder), "/mint/projects/")
+		if !ok || seen[name] || validateProjectName(name) != nil {
+			continue
+		}
+		seen[name] = true
+		containers = append(containers, sshconfig.Container{
+			Project:     name,
+			LocalFolder: folder,
+			Workspace:   devcontainerWorkspace(metadata, folder),
+		})
+	}
+	return containers
+}
+
+// devcontainerWorkspace returns the workspace folder inside a container
+// from its devcontainer.metadata label: the last workspaceFolder among its
+// entries. Without one, it is the devcontainer default,
+// /workspaces/<folder name>.
+func devcontainerWorkspace(metadata, localFolder string) string {
+	type entry struct {
+		WorkspaceFolder string `json:"workspaceFolder"`
+	}
+	var entries []entry
+	if err := json.Unmarshal([]byte(metadata), &entries); err != nil {
+		var single entry
+		if json.Unmarshal([]byte(metadata), &single) == nil {
+			entries = []entry{single}
+		}
+	}
+	workspace := ""
+	for _, e := range entries {
+		if e.WorkspaceFolder != "" {
+			workspace = e.WorkspaceFolder
+		}
+	}
+	if workspace == "" {
+		workspace = "/workspaces/" + path.Base(localFolder)
+	}
+	return workspace
+}
+
+// listProjectContainers returns the devcontainers on the VM found.
+func listProjectContainers(ctx context.Context, remote RemoteCommandRunner, sendKey mintaws.SendSSHPublicKeyAPI, found *vm.VM, port int) ([]sshconfig.Container, error) {
+	out, err := remote(ctx, sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, port, defaultSSHUser, projectContainersCommand())
+	if err != nil {
+		return nil, fmt.Errorf("listing devcontainers: %w", err)
+	}
+	return parseProjectContainers(string(out)), nil
+}
+
+// syncProjectHosts rewrites the project Host blocks of the VM vmName to
+// match containers, removing the blocks of projects without one, and
+// returns the removed projects. Directives the laptop's ssh client does not
+// support are reported on w.
+func syncProjectHosts(w io.Writer, h *projectHosts, vmName string, found *vm.VM, containers []sshconfig.Container) ([]string, error) {
+	configPath := h.path
+	if configPath == "" {
+		configPath = paths.SSHConfigPath()
+	}
+	pruned, err := sshconfig.SyncProjectBlocks(configPath, vmName, projectHostBlocks(w, h, vmName, found, containers))
+	if err != nil {
+		return nil, fmt.Errorf("write ssh config: %w", err)
+	}
+	return pruned, nil
+}
+
+// projectHostBlocks returns the project Host blocks of the VM vmName for
+// containers, keyed by project. Directives the laptop's ssh client does not
+// support are reported on w.
+func projectHostBlocks(w io.Writer, h *projectHosts, vmName string, found *vm.VM, containers []sshconfig.Container) map[string]string {
+	opts := h.sshOptions
+	if len(containers) > 0 {
+		opts = fitSSHClient(w, opts, true)
+	}
+	blocks := make(map[string]string, len(containers))
+	for _, c := range containers {
+		blocks[c.Project] = sshconfig.GenerateProjectBlock(vmName, found.PublicIP, opts.LoginUser(defaultSSHUser),
+			opts.LoginPort(defaultSSHPort), found.ID, found.AvailabilityZone, h.profile, h.region, opts, c)
+	}
+	return blocks
+}
+
+// refreshProjectHosts re-syncs the project Host blocks after a project's
+// container was built or rebuilt, reporting the project's Host on w. A
+// failure is a warning: the project itself is ready.
+func refreshProjectHosts(ctx context.Context, w io.Writer, h *projectHosts, remote RemoteCommandRunner, sendKey mintaws.SendSSHPublicKeyAPI, vmName string, found *vm.VM, project string) {
+	if h == nil {
+		return
+	}
+	containers, err := listProjectContainers(ctx, remote, sendKey, found, h.sshOptions.LoginPort(defaultSSHPort))
+	if err == nil {
+		_, err = syncProjectHosts(w, h, vmName, found, containers)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not update the SSH config for project %q: %v\n", project, err)
+		return
+	}
+	for _, c := range containers {
+		if c.Project == project {
+			fmt.Fprintf(w, "SSH config updated: Host %s opens the container.\n", sshconfig.ProjectHost(vmName, project))
+		}
+	}
+}
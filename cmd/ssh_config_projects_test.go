@@ -0,0 +1,491 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+)
+
+// projectContainersOutput is projectContainersCommand output for the
+// devcontainers of api (custom workspace) and web (default workspace).
+const projectContainersOutput = "/mint/projects/api\t[{\"id\":\"base\"},{\"workspaceFolder\":\"/workspace\"}]\n" +
+	"/mint/projects/web\t[{\"id\":\"base\"}]\n"
+
+func TestParseProjectContainers(t *testing.T) {
+	out := projectContainersOutput +
+		"/mint/projects/api/.devcontainer/app\t[]\n" + // second container of api
+		"/home/ubuntu/scratch\t[]\n" + // not a mint project
+		"\n"
+
+	got := parseProjectContainers(out)
+	want := []sshconfig.Container{
+		{Project: "api", LocalFolder: "/mint/projects/api", Workspace: "/workspace"},
+		{Project: "web", LocalFolder: "/mint/projects/web", Workspace: "/workspaces/web"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProjectContainers =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDevcontainerWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     string
+	}{
+		{"last entry wins", `[{"workspaceFolder":"/a"},{"workspaceFolder":"/b"},{"id":"x"}]`, "/b"},
+		{"single object", `{"workspaceFolder":"/src"}`, "/src"},
+		{"no workspace folder", `[{"id":"x"}]`, "/workspaces/api"},
+		{"missing label", ``, "/workspaces/api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := devcontainerWorkspace(tt.metadata, "/mint/projects/api"); got != tt.want {
+				t.Errorf("devcontainerWorkspace = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// staleProjectConfig writes an SSH config holding a project Host block for
+// "gone", a project no longer on the VM, and returns its path.
+func staleProjectConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	gone := sshconfig.Container{Project: "gone", LocalFolder: "/mint/projects/gone", Workspace: "/workspaces/gone"}
+	block := sshconfig.GenerateProjectBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "", sshconfig.Options{}, gone)
+	if err := os.WriteFile(path, []byte(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readSSHConfig(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestProjectRebuildRefreshesProjectHosts(t *testing.T) {
+	hint.IsTTY = false
+
+	sshConfigPath := staleProjectConfig(t)
+	buf := new(bytes.Buffer)
+	// remote: test -d, stop, rm, docker ps, tmux kill, tmux new, list containers
+	remote := &cmdtest.RemoteRunner{
+		Outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil, []byte(projectContainersOutput)},
+	}
+	deps := &projectRebuildDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: (&projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}}).run,
+		stdin:           strings.NewReader(""),
+		projectHosts:    &projectHosts{path: sshConfigPath},
+	}
+
+	root := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"--yes", "project", "rebuild", "api"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+	}
+
+	if len(remote.Calls()) != 7 {
+		t.Fatalf("expected 7 remote calls, got %d", len(remote.Calls()))
+	}
+	if got := strings.Join(remote.Calls()[6].Command, " "); !strings.Contains(got, "docker ps -a") {
+		t.Errorf("last call should list devcontainers, got: %s", got)
+	}
+	if !strings.Contains(buf.String(), "Host mint-default-api opens the container") {
+		t.Errorf("output should name the project Host, got: %s", buf.String())
+	}
+
+	content := readSSHConfig(t, sshConfigPath)
+	if got := sshconfig.ProjectBlocks(content, "default"); !reflect.DeepEqual(got, []string{"api", "web"}) {
+		t.Errorf("project blocks = %v, want [api web]", got)
+	}
+	if !strings.Contains(content, "-w '/workspace' ") {
+		t.Errorf("api block should use the rebuilt workspace:\n%s", content)
+	}
+}
+
+func TestProjectRebuildProjectHostsFailureIsWarning(t *testing.T) {
+	hint.IsTTY = false
+
+	buf := new(bytes.Buffer)
+	remote := &cmdtest.RemoteRunner{
+		Outputs: [][]byte{nil, nil, nil, []byte("newctr789\n"), nil, nil},
+		Errs:    []error{nil, nil, nil, nil, nil, nil, os.ErrDeadlineExceeded},
+	}
+	deps := &projectRebuildDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		},
+		owner:           "alice",
+		remote:          remote.Run,
+		streamingRunner: (&projectMockStreamingRemote{outputs: [][]byte{nil}, errors: []error{nil}}).run,
+		stdin:           strings.NewReader(""),
+		projectHosts:    &projectHosts{path: filepath.Join(t.TempDir(), "config")},
+	}
+
+	root := cmdtest.NewRoot(newProjectCommandWithRebuildDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"--yes", "project", "rebuild", "api"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("a failed SSH config refresh should not fail the rebuild: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Warning: could not update the SSH config") {
+		t.Errorf("output should warn, got: %s", buf.String())
+	}
+}
+
+func TestSSHConfigSyncCommand(t *testing.T) {
+	hint.IsTTY = false
+	configDir := t.TempDir()
+	t.Setenv("MINT_CONFIG_DIR", configDir)
+
+	sshConfigPath := staleProjectConfig(t)
+	buf := new(bytes.Buffer)
+	remote := &cmdtest.RemoteRunner{Outputs: [][]byte{[]byte(projectContainersOutput)}}
+	deps := &sshConfigSyncDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{},
+		remote:  remote.Run,
+		owner:   "alice",
+	}
+
+	sshConfigCmd := newSSHConfigCommandWithDeps(nil)
+	sshConfigCmd.RemoveCommand(sshConfigCmd.Commands()...)
+	sshConfigCmd.AddCommand(newSSHConfigSyncCommandWithDeps(deps))
+	root := cmdtest.NewRoot(sshConfigCmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"--yes", "ssh-config", "sync", "--ssh-config-path", sshConfigPath})
+	if err := root.Execute(); ExitCode(err) != exitCodeOutOfDate {
+		t.Fatalf("error = %v, want exit code %d for an out-of-date file", err, exitCodeOutOfDate)
+	}
+
+	content := readSSHConfig(t, sshConfigPath)
+	if _, ok := sshconfig.ReadManagedBlock(content, "default"); !ok {
+		t.Errorf("VM block not written:\n%s", content)
+	}
+	if got := sshconfig.ProjectBlocks(content, "default"); !reflect.DeepEqual(got, []string{"api", "web"}) {
+		t.Errorf("project blocks = %v, want [api web]", got)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"Host mint-default-api  project api",
+		"Host mint-default-web  project web",
+		"Removed Host mint-default-gone (no container)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q, got: %s", want, output)
+		}
+	}
+}
+
+// runSSHConfigSync runs ssh-config sync with args against the running VM
+// "default" at 1.2.3.4 with no devcontainers, and returns its output.
+func runSSHConfigSyncCommand(t *testing.T, describe *cmdtest.DescribeInstances, args ...string) (string, error) {
+	t.Helper()
+	if describe == nil {
+		describe = &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		}
+	}
+	deps := &sshConfigSyncDeps{
+		describe: describe,
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		remote:   (&cmdtest.RemoteRunner{}).Run,
+		owner:    "alice",
+	}
+	sshConfigCmd := newSSHConfigCommandWithDeps(nil)
+	sshConfigCmd.RemoveCommand(sshConfigCmd.Commands()...)
+	sshConfigCmd.AddCommand(newSSHConfigSyncCommandWithDeps(deps))
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(sshConfigCmd)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"ssh-config", "sync"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestSSHConfigSyncOutOfDate(t *testing.T) {
+	hint.IsTTY = false
+	userContent := "Host github.com\n    User git\n\n"
+	staleBlock := sshconfig.GenerateBlock("default", "9.9.9.9", "ubuntu", 41122, "i-old", "us-east-1a", "", "")
+
+	tests := []struct {
+		name      string
+		args      []string
+		missing   bool
+		wantCode  int
+		wantOut   []string
+		wantEmpty bool // no diff or summary
+		wantFile  bool // the file was rewritten
+	}{
+		{
+			name:     "stale block rewritten",
+			args:     []string{"--yes"},
+			wantCode: exitCodeOutOfDate,
+			wantOut:  []string{"-    HostName 9.9.9.9\n", "+    HostName 1.2.3.4\n", "SSH config updated for VM \"default\""},
+			wantFile: true,
+		},
+		{
+			name:     "dry run writes nothing and needs no approval",
+			args:     []string{"--dry-run"},
+			wantCode: exitCodeOutOfDate,
+			wantOut:  []string{"+    HostName 1.2.3.4\n", "dry run; nothing written"},
+		},
+		{
+			name:      "quiet",
+			args:      []string{"--yes", "--quiet"},
+			wantCode:  exitCodeOutOfDate,
+			wantEmpty: true,
+			wantFile:  true,
+		},
+		{
+			name:     "missing file created",
+			args:     []string{"--yes"},
+			missing:  true,
+			wantCode: exitCodeOutOfDate,
+			wantOut:  []string{"@@ -0,0 +1,"},
+			wantFile: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+			path := filepath.Join(t.TempDir(), ".ssh", "config")
+			before := ""
+			if !tt.missing {
+				before = userContent + staleBlock
+				if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(before), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			output, err := runSSHConfigSyncCommand(t, nil, append(tt.args, "--ssh-config-path", path)...)
+			if got := ExitCode(err); err == nil || got != tt.wantCode {
+				t.Fatalf("error = %v (exit %d), want exit %d", err, got, tt.wantCode)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+			if tt.wantEmpty && (strings.Contains(output, "@@") || strings.Contains(output, "SSH config updated")) {
+				t.Errorf("output = %q, want no diff or summary", output)
+			}
+
+			data, _ := os.ReadFile(path)
+			content := string(data)
+			if !tt.wantFile {
+				if content != before {
+					t.Errorf("file changed on a dry run:\n%s", content)
+				}
+				return
+			}
+			if sshconfig.IsStale(content, "default", "1.2.3.4", "i-abc123") {
+				t.Errorf("block still stale:\n%s", content)
+			}
+			if !tt.missing && !strings.HasPrefix(content, userContent) {
+				t.Errorf("user content not kept:\n%s", content)
+			}
+			wantPerm := os.FileMode(0o644)
+			if tt.missing {
+				wantPerm = 0o600
+			}
+			if info, _ := os.Stat(path); info.Mode().Perm() != wantPerm {
+				t.Errorf("permissions = %o, want %o", info.Mode().Perm(), wantPerm)
+			}
+
+			// A second sync finds nothing to change.
+			output, err = runSSHConfigSyncCommand(t, nil, "--ssh-config-path", path)
+			if err != nil {
+				t.Fatalf("second sync: error = %v (exit %d), want success", err, ExitCode(err))
+			}
+			if !strings.Contains(output, "is up to date") || strings.Contains(output, "---") {
+				t.Errorf("second sync output:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestSSHConfigSyncAll(t *testing.T) {
+	hint.IsTTY = false
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "config")
+
+	out := makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")
+	dev := makeRunningInstanceForProject("i-def456", "dev", "alice", "5.6.7.8", "us-east-1b")
+	stopped := makeRunningInstanceForProject("i-stopped", "old", "alice", "", "us-east-1a")
+	stopped.Reservations[0].Instances[0].State.Name = ec2types.InstanceStateNameStopped
+	out.Reservations = append(out.Reservations, dev.Reservations[0], stopped.Reservations[0])
+	describe := &cmdtest.DescribeInstances{Output: out}
+
+	output, err := runSSHConfigSyncCommand(t, describe, "--yes", "--all", "--ssh-config-path", path)
+	if ExitCode(err) != exitCodeOutOfDate {
+		t.Fatalf("error = %v, want exit code %d", err, exitCodeOutOfDate)
+	}
+	content := readSSHConfig(t, path)
+	for _, name := range []string{"default", "dev"} {
+		if _, ok := sshconfig.ReadManagedBlock(content, name); !ok {
+			t.Errorf("no block for VM %q:\n%s", name, content)
+		}
+	}
+	if _, ok := sshconfig.ReadManagedBlock(content, "old"); ok {
+		t.Error("stopped VM got a block")
+	}
+	if !strings.Contains(output, `Skipping VM "old" (stopped): not running.`) {
+		t.Errorf("output should note the stopped VM:\n%s", output)
+	}
+
+	if _, err := runSSHConfigSyncCommand(t, describe, "--all", "--vm", "dev"); err == nil ||
+		!strings.Contains(err.Error(), "--vm cannot be combined with --all") {
+		t.Errorf("error = %v, want --vm/--all conflict", err)
+	}
+}
+
+func TestSSHConfigRemoveAlsoRemovesProjectBlocks(t *testing.T) {
+	t.Setenv("MINT_CONFIG_DIR", t.TempDir())
+	sshConfigPath := staleProjectConfig(t)
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot(newSSHConfigCommandWithDeps(nil))
+	root.SetOut(buf)
+	root.SetArgs([]string{"ssh-config", "--remove", "--ssh-config-path", sshConfigPath})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := sshconfig.ProjectBlocks(readSSHConfig(t, sshConfigPath), "default"); len(got) != 0 {
+		t.Errorf("project blocks = %v, want none", got)
+	}
+	if !strings.Contains(buf.String(), "removed for Host mint-default-gone") {
+		t.Errorf("output should name the removed Host, got: %s", buf.String())
+	}
+}
+
+func TestCodeCommandContainer(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name           string
+		args           []string
+		remoteOut      string
+		wantErrContain string
+		wantRemote     string
+		wantPath       string
+	}{
+		{
+			name:       "opens project host at workspace",
+			args:       []string{"code", "--container", "api"},
+			remoteOut:  projectContainersOutput,
+			wantRemote: "ssh-remote+mint-default-api",
+			wantPath:   "/workspace",
+		},
+		{
+			name:       "default workspace",
+			args:       []string{"code", "--container", "web"},
+			remoteOut:  projectContainersOutput,
+			wantRemote: "ssh-remote+mint-default-web",
+			wantPath:   "/workspaces/web",
+		},
+		{
+			name:           "project without container",
+			args:           []string{"code", "--container", "docs"},
+			remoteOut:      projectContainersOutput,
+			wantErrContain: `project "docs" has no devcontainer`,
+		},
+		{
+			name:           "conflicts with project argument",
+			args:           []string{"code", "api", "--container", "api"},
+			wantErrContain: "--container cannot be combined",
+		},
+		{
+			name:           "invalid project name",
+			args:           []string{"code", "--container", "../etc"},
+			wantErrContain: "invalid project name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sshConfigPath := filepath.Join(t.TempDir(), "config")
+			var captured *capturedCommand
+			remoteRunner := mockRemoteCommandRunner([]byte(tt.remoteOut), nil)
+			deps := &codeDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+				},
+				owner: "alice",
+				runner: func(name string, args ...string) error {
+					captured = &capturedCommand{name: name, args: args}
+					return nil
+				},
+				sendKey:           &cmdtest.SendSSHPublicKey{},
+				runRemoteCommand:  remoteRunner,
+				sshConfigPath:     sshConfigPath,
+				sshConfigApproved: true,
+			}
+
+			root := cmdtest.NewRoot(newCodeCommandWithDeps(deps))
+			root.SetArgs(tt.args)
+			err := root.Execute()
+
+			if tt.wantErrContain != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrContain) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErrContain)
+				}
+				if captured != nil {
+					t.Error("VS Code should not be launched")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if captured == nil {
+				t.Fatal("expected VS Code to be launched")
+			}
+			want := []string{"--remote", tt.wantRemote, tt.wantPath}
+			if !reflect.DeepEqual(captured.args, want) {
+				t.Errorf("code args = %v, want %v", captured.args, want)
+			}
+			content := readSSHConfig(t, sshConfigPath)
+			if !strings.Contains(content, "Host "+strings.TrimPrefix(tt.wantRemote, "ssh-remote+")+"\n") {
+				t.Errorf("SSH config missing the project Host:\n%s", content)
+			}
+		})
+	}
+}
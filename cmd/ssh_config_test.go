@@ -11,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 )
 
 func TestSSHConfigCommand_WritesBlock(t *testing.T) {
@@ -120,12 +122,12 @@ func TestSSHConfigCommand_PartialFlags_MissingHostname(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	deps := &sshConfigDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner: "alice",
 	}
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
@@ -153,12 +155,12 @@ func TestSSHConfigCommand_PartialFlags_MissingInstanceID(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	deps := &sshConfigDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner: "alice",
 	}
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
@@ -186,12 +188,12 @@ func TestSSHConfigCommand_PartialFlags_MissingAZ(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	deps := &sshConfigDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
 		owner: "alice",
 	}
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
@@ -410,14 +412,14 @@ func TestSSHConfigCommand_AutoDiscover_Success(t *testing.T) {
 
 	launchTime := time.Now().Add(-10 * time.Minute)
 	deps := &sshConfigDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTimeAndAZ("i-disco123", "default", "alice", "running", "5.6.7.8", "m6i.xlarge", "complete", launchTime, "us-west-2a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTimeAndAZ("i-disco123", "default", "alice", "running", "5.6.7.8", "m6i.xlarge", "complete", launchTime, "us-west-2a"),
 		},
 		owner: "alice",
 	}
 
 	buf := new(bytes.Buffer)
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
@@ -460,15 +462,15 @@ func TestSSHConfigCommand_AutoDiscover_NoVM(t *testing.T) {
 	t.Setenv("MINT_CONFIG_DIR", configDir)
 
 	deps := &sshConfigDeps{
-		describe: &mockDescribeInstances{
+		describe: &cmdtest.DescribeInstances{
 			// Empty output — no VMs found.
-			output: makeEmptyDescribeOutput(),
+			Output: makeEmptyDescribeOutput(),
 		},
 		owner: "alice",
 	}
 
 	buf := new(bytes.Buffer)
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
@@ -503,14 +505,14 @@ func TestSSHConfigCommand_AutoDiscover_ExplicitFlagsStillWork(t *testing.T) {
 
 	deps := &sshConfigDeps{
 		// describe would return a different IP — explicit flags must win.
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-other", "default", "alice", "9.9.9.9", "eu-west-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-other", "default", "alice", "9.9.9.9", "eu-west-1a"),
 		},
 		owner: "alice",
 	}
 
 	buf := new(bytes.Buffer)
-	rootCmd := newTestRoot()
+	rootCmd := cmdtest.NewRoot()
 	rootCmd.SetOut(buf)
 	rootCmd.SetErr(buf)
 	rootCmd.AddCommand(newSSHConfigCommandWithDeps(deps))
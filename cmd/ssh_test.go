@@ -2,46 +2,20 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
-	"github.com/spf13/cobra"
 )
 
-// mockSendSSHPublicKey implements mintaws.SendSSHPublicKeyAPI for testing.
-type mockSendSSHPublicKey struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-	called bool
-	input  *ec2instanceconnect.SendSSHPublicKeyInput
-}
-
-func (m *mockSendSSHPublicKey) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	m.called = true
-	m.input = params
-	return m.output, m.err
-}
-
-// mockDescribeForSSH implements mintaws.DescribeInstancesAPI with AZ support.
-type mockDescribeForSSH struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (m *mockDescribeForSSH) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return m.output, m.err
-}
-
 // makeRunningInstanceWithAZ returns a DescribeInstancesOutput with placement info.
 func makeRunningInstanceWithAZ(id, vmName, owner, ip, az string) *ec2.DescribeInstancesOutput {
 	return &ec2.DescribeInstancesOutput{
@@ -136,26 +110,6 @@ type capturedCommand struct {
 	args []string
 }
 
-// newTestRootForSSH creates a minimal root command for ssh/code tests.
-func newTestRootForSSH() *cobra.Command {
-	root := &cobra.Command{
-		Use:           "mint",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			cliCtx := cli.NewCLIContext(cmd)
-			cmd.SetContext(cli.WithContext(context.Background(), cliCtx))
-			return nil
-		},
-	}
-	root.PersistentFlags().Bool("verbose", false, "Show progress steps")
-	root.PersistentFlags().Bool("debug", false, "Show AWS SDK details")
-	root.PersistentFlags().Bool("json", false, "Machine-readable JSON output")
-	root.PersistentFlags().Bool("yes", false, "Skip confirmation on destructive operations")
-	root.PersistentFlags().String("vm", "default", "Target VM name")
-	return root
-}
-
 // TestSSHBootstrapPreCheck verifies that runSSH checks the bootstrap tag
 // before attempting ssh-keyscan or any SSH operation.
 func TestSSHBootstrapPreCheck(t *testing.T) {
@@ -213,11 +167,11 @@ func TestSSHBootstrapPreCheck(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sendKey := &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey := &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			}
-			describe := &mockDescribeForSSH{
-				output: makeRunningInstanceWithBootstrap(
+			describe := &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithBootstrap(
 					"i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", tt.bootstrapStatus,
 				),
 			}
@@ -244,7 +198,7 @@ func TestSSHBootstrapPreCheck(t *testing.T) {
 					t.Errorf("error %q does not contain %q", err.Error(), tt.wantErrContain)
 				}
 				// Key must NOT have been pushed.
-				if sendKey.called {
+				if sendKey.Called {
 					t.Error("SendSSHPublicKey should not be called when bootstrap is incomplete")
 				}
 				// SSH must NOT have been executed.
@@ -257,7 +211,7 @@ func TestSSHBootstrapPreCheck(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if tt.wantSendKey && !sendKey.called {
+			if tt.wantSendKey && !sendKey.Called {
 				t.Error("SendSSHPublicKey should have been called for complete bootstrap")
 			}
 		})
@@ -267,8 +221,8 @@ func TestSSHBootstrapPreCheck(t *testing.T) {
 func TestSSHCommand(t *testing.T) {
 	tests := []struct {
 		name           string
-		describe       *mockDescribeForSSH
-		sendKey        *mockSendSSHPublicKey
+		describe       *cmdtest.DescribeInstances
+		sendKey        *cmdtest.SendSSHPublicKey
 		owner          string
 		vmName         string
 		extraArgs      []string
@@ -280,11 +234,11 @@ func TestSSHCommand(t *testing.T) {
 	}{
 		{
 			name: "successful ssh to running instance",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			wantSendKey: true,
@@ -319,10 +273,10 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "vm not found returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "mint up",
@@ -331,10 +285,10 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "stopped vm returns actionable error",
-			describe: &mockDescribeForSSH{
-				output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeStoppedInstanceForSSH("i-abc123", "default", "alice"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "not running",
@@ -343,10 +297,10 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "describe API error propagates",
-			describe: &mockDescribeForSSH{
-				err: fmt.Errorf("throttled"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("throttled"),
 			},
-			sendKey:        &mockSendSSHPublicKey{},
+			sendKey:        &cmdtest.SendSSHPublicKey{},
 			owner:          "alice",
 			wantErr:        true,
 			wantErrContain: "throttled",
@@ -355,11 +309,11 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "send public key error propagates",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				err: fmt.Errorf("access denied"),
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Err: fmt.Errorf("access denied"),
 			},
 			owner:          "alice",
 			wantErr:        true,
@@ -369,11 +323,11 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "extra args after -- are passed through",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			extraArgs:   []string{"-L", "8080:localhost:8080"},
@@ -389,11 +343,11 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "send key called with correct params",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-xyz789", "default", "bob", "5.6.7.8", "eu-west-1b"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-xyz789", "default", "bob", "5.6.7.8", "eu-west-1b"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "bob",
 			wantSendKey: true,
@@ -404,11 +358,11 @@ func TestSSHCommand(t *testing.T) {
 		},
 		{
 			name: "non-default vm name",
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithAZ("i-dev456", "dev", "alice", "10.0.0.1", "us-west-2a"),
 			},
-			sendKey: &mockSendSSHPublicKey{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			sendKey: &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			},
 			owner:       "alice",
 			vmName:      "dev",
@@ -442,7 +396,7 @@ func TestSSHCommand(t *testing.T) {
 			}
 
 			cmd := newSSHCommandWithDeps(deps)
-			root := newTestRootForSSH()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -473,8 +427,8 @@ func TestSSHCommand(t *testing.T) {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if tt.wantSendKey != tt.sendKey.called {
-				t.Errorf("SendSSHPublicKey called = %v, want %v", tt.sendKey.called, tt.wantSendKey)
+			if tt.wantSendKey != tt.sendKey.Called {
+				t.Errorf("SendSSHPublicKey called = %v, want %v", tt.sendKey.Called, tt.wantSendKey)
 			}
 
 			if tt.wantExec {
@@ -489,18 +443,18 @@ func TestSSHCommand(t *testing.T) {
 			}
 
 			// Verify SendSSHPublicKey input when called
-			if tt.sendKey.called && tt.sendKey.input != nil {
-				if aws.ToString(tt.sendKey.input.InstanceId) == "" {
+			if tt.sendKey.Called && tt.sendKey.Input != nil {
+				if aws.ToString(tt.sendKey.Input.InstanceId) == "" {
 					t.Error("SendSSHPublicKey missing instance ID")
 				}
-				if aws.ToString(tt.sendKey.input.InstanceOSUser) != "ubuntu" {
+				if aws.ToString(tt.sendKey.Input.InstanceOSUser) != "ubuntu" {
 					t.Errorf("SendSSHPublicKey OS user = %q, want ubuntu",
-						aws.ToString(tt.sendKey.input.InstanceOSUser))
+						aws.ToString(tt.sendKey.Input.InstanceOSUser))
 				}
-				if aws.ToString(tt.sendKey.input.AvailabilityZone) == "" {
+				if aws.ToString(tt.sendKey.Input.AvailabilityZone) == "" {
 					t.Error("SendSSHPublicKey missing availability zone")
 				}
-				if aws.ToString(tt.sendKey.input.SSHPublicKey) == "" {
+				if aws.ToString(tt.sendKey.Input.SSHPublicKey) == "" {
 					t.Error("SendSSHPublicKey missing SSH public key")
 				}
 			}
@@ -509,10 +463,10 @@ func TestSSHCommand(t *testing.T) {
 }
 
 func TestSSHCommandEmptyAvailabilityZone(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceNoAZ("i-abc123", "default", "alice", "1.2.3.4"),
 	}
-	sendKey := &mockSendSSHPublicKey{}
+	sendKey := &cmdtest.SendSSHPublicKey{}
 
 	var captured capturedCommand
 	deps := &sshDeps{
@@ -538,7 +492,7 @@ func TestSSHCommandEmptyAvailabilityZone(t *testing.T) {
 		t.Errorf("ssh should not have been executed, got: %s %v", captured.name, captured.args)
 	}
 	// SendSSHPublicKey should NOT have been called.
-	if sendKey.called {
+	if sendKey.Called {
 		t.Error("SendSSHPublicKey should not have been called when AZ is empty")
 	}
 }
@@ -583,7 +537,7 @@ func mockHostKeyScanner(fingerprint, hostKeyLine string, err error) HostKeyScann
 
 // newTOFUDeps creates sshDeps with TOFU support for testing. The store
 // is backed by a temp directory that is cleaned up by the test.
-func newTOFUDeps(t *testing.T, describe *mockDescribeForSSH, sendKey *mockSendSSHPublicKey, owner string, scanner HostKeyScanner) (*sshDeps, *capturedCommand) {
+func newTOFUDeps(t *testing.T, describe *cmdtest.DescribeInstances, sendKey *cmdtest.SendSSHPublicKey, owner string, scanner HostKeyScanner) (*sshDeps, *capturedCommand) {
 	t.Helper()
 	dir := t.TempDir()
 	store := sshconfig.NewHostKeyStore(dir)
@@ -609,7 +563,7 @@ func newTOFUDeps(t *testing.T, describe *mockDescribeForSSH, sendKey *mockSendSS
 func runSSHWithDeps(t *testing.T, deps *sshDeps, vmName string) error {
 	t.Helper()
 	cmd := newSSHCommandWithDeps(deps)
-	root := newTestRootForSSH()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(new(bytes.Buffer))
 	root.SetErr(new(bytes.Buffer))
@@ -623,11 +577,11 @@ func runSSHWithDeps(t *testing.T, deps *sshDeps, vmName string) error {
 }
 
 func TestSSHCommandTOFUFirstConnection(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:testfp123", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil)
 
@@ -654,11 +608,11 @@ func TestSSHCommandTOFUFirstConnection(t *testing.T) {
 }
 
 func TestSSHCommandTOFUKeyMatch(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:matchfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAITest", nil)
 
@@ -683,11 +637,11 @@ func TestSSHCommandTOFUKeyMatch(t *testing.T) {
 func TestSSHCommandTOFUKeyMismatch(t *testing.T) {
 	hint.IsTTY = false
 
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	// Scanner returns a different fingerprint than what's stored.
 	scanner := mockHostKeyScanner("SHA256:newfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew", nil)
@@ -729,11 +683,11 @@ func TestSSHCommandTOFUKeyMismatch(t *testing.T) {
 }
 
 func TestSSHCommandTOFUSkippedWhenNoStore(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	var captured capturedCommand
@@ -768,11 +722,11 @@ func TestSSHCommandTOFUSkippedWhenNoStore(t *testing.T) {
 }
 
 func TestSSHCommandTOFUScannerError(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	// Scanner returns an error.
 	scanner := mockHostKeyScanner("", "", fmt.Errorf("connection refused"))
@@ -838,11 +792,11 @@ echo "1.2.3.4 ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9
 }
 
 func TestSSHCommandUsesStrictHostKeyChecking(t *testing.T) {
-	describe := &mockDescribeForSSH{
-		output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 	}
-	sendKey := &mockSendSSHPublicKey{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 	scanner := mockHostKeyScanner("SHA256:strictfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIStrict", nil)
 
@@ -889,11 +843,11 @@ func TestSSHSpinnerWiring(t *testing.T) {
 	t.Run("spinner emits Looking up VM and Checking bootstrap status on success path", func(t *testing.T) {
 		buf := new(bytes.Buffer)
 
-		sendKey := &mockSendSSHPublicKey{
-			output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		sendKey := &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 		}
-		describe := &mockDescribeForSSH{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe := &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		}
 
 		var execCalled bool
@@ -908,7 +862,7 @@ func TestSSHSpinnerWiring(t *testing.T) {
 		}
 
 		cmd := newSSHCommandWithDeps(deps)
-		root := newTestRootForSSH()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -934,11 +888,11 @@ func TestSSHSpinnerWiring(t *testing.T) {
 		// The spinner must be fully stopped (no goroutine running) when exec
 		// is called. We verify this indirectly: if Stop was called the test
 		// completes without deadlock. Direct goroutine inspection is not needed.
-		sendKey := &mockSendSSHPublicKey{
-			output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+		sendKey := &cmdtest.SendSSHPublicKey{
+			Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 		}
-		describe := &mockDescribeForSSH{
-			output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe := &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
 		}
 
 		deps := &sshDeps{
@@ -960,16 +914,16 @@ func TestSSHSpinnerWiring(t *testing.T) {
 		buf := new(bytes.Buffer)
 
 		deps := &sshDeps{
-			describe: &mockDescribeForSSH{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
-			sendKey: &mockSendSSHPublicKey{},
+			sendKey: &cmdtest.SendSSHPublicKey{},
 			owner:   "alice",
 			runner:  func(name string, args ...string) error { return nil },
 		}
 
 		cmd := newSSHCommandWithDeps(deps)
-		root := newTestRootForSSH()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -990,16 +944,16 @@ func TestSSHSpinnerWiring(t *testing.T) {
 		buf := new(bytes.Buffer)
 
 		deps := &sshDeps{
-			describe: &mockDescribeForSSH{
-				output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "pending"),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeRunningInstanceWithBootstrap("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a", "pending"),
 			},
-			sendKey: &mockSendSSHPublicKey{},
+			sendKey: &cmdtest.SendSSHPublicKey{},
 			owner:   "alice",
 			runner:  func(name string, args ...string) error { return nil },
 		}
 
 		cmd := newSSHCommandWithDeps(deps)
-		root := newTestRootForSSH()
+		root := cmdtest.NewRoot()
 		root.AddCommand(cmd)
 		root.SetOut(buf)
 		root.SetErr(buf)
@@ -1019,3 +973,86 @@ func TestSSHSpinnerWiring(t *testing.T) {
 		}
 	})
 }
+
+func TestSSHCommandAppliesSSHOptions(t *testing.T) {
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	}
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	}
+	scanner := mockHostKeyScanner("SHA256:optsfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOpts", nil)
+
+	deps, captured := newTOFUDeps(t, describe, sendKey, "alice", scanner)
+	deps.sshOptions = sshconfig.Options{
+		ExtraArgs:       []string{"-o", "ProxyJump=bastion.corp"},
+		IdentityFile:    "/home/alice/.ssh/corp",
+		CertificateFile: "/home/alice/.ssh/corp-cert.pub",
+	}
+
+	if err := runSSHWithDeps(t, deps, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	index := func(arg string) int {
+		for i, a := range captured.args {
+			if a == arg {
+				return i
+			}
+		}
+		t.Fatalf("missing %q in args: %v", arg, captured.args)
+		return -1
+	}
+
+	// The ephemeral Instance Connect key is the first identity offered;
+	// the user's identity follows it.
+	if captured.args[0] != "-i" || !strings.Contains(captured.args[1], "mint-ssh-key-") {
+		t.Errorf("first identity should be the ephemeral key, args: %v", captured.args)
+	}
+	userIdentity := index("/home/alice/.ssh/corp")
+	if captured.args[userIdentity-1] != "-i" || userIdentity < 2 {
+		t.Errorf("user identity should follow the ephemeral key, args: %v", captured.args)
+	}
+
+	// User options come after mint's host key options and before the
+	// destination.
+	dest := index("ubuntu@1.2.3.4")
+	for _, arg := range []string{"CertificateFile=/home/alice/.ssh/corp-cert.pub", "ProxyJump=bastion.corp"} {
+		i := index(arg)
+		if i > dest {
+			t.Errorf("%q should precede the destination, args: %v", arg, captured.args)
+		}
+		if i < index("StrictHostKeyChecking=yes") {
+			t.Errorf("%q should follow mint's options, args: %v", arg, captured.args)
+		}
+	}
+}
+
+func TestSSHCommandUsesConfiguredPort(t *testing.T) {
+	describe := &cmdtest.DescribeInstances{
+		Output: makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a"),
+	}
+	sendKey := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	}
+	var scannedPort int
+	scanner := func(host string, port int) (string, string, error) {
+		scannedPort = port
+		return "SHA256:portfp", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIPort", nil
+	}
+
+	deps, captured := newTOFUDeps(t, describe, sendKey, "alice", scanner)
+	deps.sshOptions = sshconfig.Options{Port: 2222}
+
+	if err := runSSHWithDeps(t, deps, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if scannedPort != 2222 {
+		t.Errorf("host key scanned on port %d, want 2222", scannedPort)
+	}
+	args := strings.Join(captured.args, " ")
+	if !strings.Contains(args, "-p 2222") {
+		t.Errorf("ssh args should use the configured port, got: %v", captured.args)
+	}
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// sshAuthProbeTimeout bounds the second connection that diagnoses a
+// refused login.
+const sshAuthProbeTimeout = 5 * time.Second
+
+// sshStderrPrefixBytes is how much of a streaming command's stderr is kept
+// to recognize a refused login, which ssh reports before any remote output.
+const sshStderrPrefixBytes = 4096
+
+// sshAuthRejectedError is returned by the remote runners when sshd refuses
+// the login after EC2 Instance Connect accepted the key push. The push does
+// not check that the OS user exists, so a wrong login user surfaces here
+// rather than at the push.
+type sshAuthRejectedError struct {
+	user string
+	err  error
+}
+
+func (e *sshAuthRejectedError) Error() string { return e.err.Error() }
+func (e *sshAuthRejectedError) Unwrap() error { return e.err }
+
+// isSSHPermissionDenied reports whether ssh's stderr shows that the server
+// refused every offered credential.
+func isSSHPermissionDenied(stderr string) bool {
+	return strings.Contains(stderr, "Permission denied (")
+}
+
+// prefixWriter keeps the first max bytes written to it.
+type prefixWriter struct {
+	buf []byte
+	max int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.max - len(w.buf); room > 0 {
+		w.buf = append(w.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+func (w *prefixWriter) String() string { return string(w.buf) }
+
+// sshAuthProbe is what a second, unauthenticated connection learns about
+// how sshd treats a login user.
+type sshAuthProbe struct {
+	// Banner is the pre-authentication banner, if sshd sends one.
+	Banner string
+	// Methods are the authentication methods sshd offers the user.
+	Methods []string
+}
+
+// sshAuthProber connects to address as user without credentials and
+// reports the banner and offered methods. Tests inject fakes.
+type sshAuthProber func(ctx context.Context, address, user string) (sshAuthProbe, error)
+
+// errSSHProbeDeclined is returned by the probe's auth callbacks so it never
+// sends a credential.
+var errSSHProbeDeclined = errors.New("probe offers no credentials")
+
+// newSSHAuthProber returns the production sshAuthProber, connecting with
+// dial.
+func newSSHAuthProber(dial func(ctx context.Context, network, address string) (net.Conn, error)) sshAuthProber {
+	return func(ctx context.Context, address, user string) (sshAuthProbe, error) {
+		ctx, cancel := context.WithTimeout(ctx, sshAuthProbeTimeout)
+		defer cancel()
+		conn, err := dial(ctx, "tcp", address)
+		if err != nil {
+			return sshAuthProbe{}, err
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(sshAuthProbeTimeout))
+
+		// The client only calls a method's callback when the server offers
+		// that method, so each callback records the offer and declines.
+		var probe sshAuthProbe
+		offered := func(method string) {
+			if !slices.Contains(probe.Methods, method) {
+				probe.Methods = append(probe.Methods, method)
+			}
+		}
+		config := &ssh.ClientConfig{
+			User: user,
+			Auth: []ssh.AuthMethod{
+				ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+					offered("publickey")
+					return nil, errSSHProbeDeclined
+				}),
+				ssh.PasswordCallback(func() (string, error) {
+					offered("password")
+					return "", errSSHProbeDeclined
+				}),
+				ssh.KeyboardInteractive(func(string, string, []string, []bool) ([]string, error) {
+					offered("keyboard-interactive")
+					return nil, errSSHProbeDeclined
+				}),
+			},
+			BannerCallback: func(message string) error {
+				probe.Banner += message
+				return nil
+			},
+			// Nothing secret is sent, so the host key does not matter.
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         sshAuthProbeTimeout,
+		}
+		c, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+		switch {
+		case err == nil:
+			ssh.NewClient(c, chans, reqs).Close()
+			probe.Methods = append(probe.Methods, "none")
+		case errors.Is(err, errSSHProbeDeclined), strings.Contains(err.Error(), "unable to authenticate"):
+		default:
+			return sshAuthProbe{}, err
+		}
+		return probe, nil
+	}
+}
+
+// sshUserRejected reports whether a refused login points at the login user
+// rather than the key: the VM was provisioned for a different user, sshd
+// does not offer the user public key authentication, or its banner calls
+// the user invalid. OpenSSH otherwise answers an unknown user exactly as it
+// answers a known one, so anything else is treated as a key problem.
+func sshUserRejected(user string, probe sshAuthProbe, provisionedUser string) bool {
+	if provisionedUser != "" && provisionedUser != user {
+		return true
+	}
+	if !slices.Contains(probe.Methods, "publickey") {
+		return true
+	}
+	banner := strings.ToLower(probe.Banner)
+	return strings.Contains(banner, "invalid user") || strings.Contains(banner, "unknown user")
+}
+
+// sshLoginError explains a refused login. It unwraps to the runner's
+// original error.
+type sshLoginError struct {
+	msg string
+	err error
+}
+
+func (e *sshLoginError) Error() string { return e.msg }
+func (e *sshLoginError) Unwrap() error { return e.err }
+
+// explainSSHAuthRejection probes address to tell a wrong login user from a
+// rejected key. The original error is returned when the probe cannot
+// connect.
+func explainSSHAuthRejection(ctx context.Context, rejected *sshAuthRejectedError, describe mintaws.DescribeInstancesAPI, probe sshAuthProber, instanceID, address string) error {
+	result, err := probe(ctx, address, rejected.user)
+	if err != nil {
+		return rejected
+	}
+
+	var provisioned, ami string
+	if describe != nil {
+		if found, err := vm.FindVMByID(ctx, describe, instanceID); err == nil && found != nil {
+			provisioned, ami = found.SSHUser, found.ImageID
+		}
+	}
+	if ami == "" {
+		ami = "unknown"
+	}
+
+	if sshUserRejected(rejected.user, result, provisioned) {
+		detail := "current AMI: " + ami
+		if provisioned != "" && provisioned != rejected.user {
+			detail += fmt.Sprintf(", provisioned for user '%s'", provisioned)
+		}
+		return &sshLoginError{
+			msg: fmt.Sprintf("SSH user '%s' was rejected — this VM may use a different login user; set ssh_user in config.toml (%s)",
+				rejected.user, detail),
+			err: rejected,
+		}
+	}
+	return &sshLoginError{
+		msg: fmt.Sprintf("SSH login as '%s' was refused although EC2 Instance Connect accepted the key — "+
+			"the VM's Instance Connect agent did not pick it up. Check %s, or rebuild the VM with %s",
+			rejected.user, hint.Cmd("mint doctor"), hint.Cmd("mint recreate")),
+		err: rejected,
+	}
+}
+
+// diagnoseSSHAuth wraps inner so a refused login is reported as a wrong
+// login user or a rejected key instead of ssh's bare "Permission denied".
+func diagnoseSSHAuth(inner RemoteCommandRunner, describe mintaws.DescribeInstancesAPI, probe sshAuthProber) RemoteCommandRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		out, err := inner(ctx, sendKey, instanceID, az, host, port, user, command)
+		var rejected *sshAuthRejectedError
+		if errors.As(err, &rejected) {
+			return out, explainSSHAuthRejection(ctx, rejected, describe, probe, instanceID, net.JoinHostPort(host, strconv.Itoa(port)))
+		}
+		return out, err
+	}
+}
+
+// diagnoseStreamingSSHAuth is the StreamingRemoteRunner counterpart of
+// diagnoseSSHAuth.
+func diagnoseStreamingSSHAuth(inner StreamingRemoteRunner, describe mintaws.DescribeInstancesAPI, probe sshAuthProber) StreamingRemoteRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		out, err := inner(ctx, sendKey, instanceID, az, host, port, user, command, stderr)
+		var rejected *sshAuthRejectedError
+		if errors.As(err, &rejected) {
+			return out, explainSSHAuthRejection(ctx, rejected, describe, probe, instanceID, net.JoinHostPort(host, strconv.Itoa(port)))
+		}
+		return out, err
+	}
+}
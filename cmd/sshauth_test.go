@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// fakeSSHServerDial returns a dialer that ignores the address and connects
+// to an in-process sshd built from config. Every login is refused.
+func fakeSSHServerDial(t *testing.T, config *ssh.ServerConfig) func(ctx context.Context, network, address string) (net.Conn, error) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			server, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer server.Close()
+				if conn, _, _, err := ssh.NewServerConn(server, config); err == nil {
+					conn.Close()
+				}
+			}()
+		}
+	}()
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, ln.Addr().String())
+	}
+}
+
+// keyRejectingServer offers public key authentication and refuses every key,
+// as sshd does for an existing user whose pushed key never arrived.
+func keyRejectingServer() *ssh.ServerConfig {
+	return &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, errors.New("key not authorized")
+		},
+	}
+}
+
+// rejectedRunner is a RemoteCommandRunner whose login is always refused.
+func rejectedRunner(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	return nil, &sshAuthRejectedError{
+		user: user,
+		err:  errors.New("remote command failed: exit status 255 (stderr: ubuntu@1.2.3.4: Permission denied (publickey).)"),
+	}
+}
+
+// describeForSSHAuth returns the instance the diagnosis looks up, tagged
+// with provisionedUser when it is non-empty.
+func describeForSSHAuth(provisionedUser string) *cmdtest.DescribeInstances {
+	out := makeRunningInstanceWithAZ("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")
+	inst := &out.Reservations[0].Instances[0]
+	inst.ImageId = aws.String("ami-0123456789")
+	if provisionedUser != "" {
+		inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String("mint:ssh-user"), Value: aws.String(provisionedUser)})
+	}
+	return &cmdtest.DescribeInstances{Output: out}
+}
+
+func TestSSHAuthProberReportsBannerAndMethods(t *testing.T) {
+	config := keyRejectingServer()
+	config.PasswordCallback = func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+		return nil, errors.New("no passwords")
+	}
+	config.BannerCallback = func(conn ssh.ConnMetadata) string { return "Authorized use only\n" }
+
+	probe, err := newSSHAuthProber(fakeSSHServerDial(t, config))(context.Background(), "1.2.3.4:22", "ubuntu")
+	if err != nil {
+		t.Fatalf("probe error: %v", err)
+	}
+	if probe.Banner != "Authorized use only\n" {
+		t.Errorf("Banner = %q", probe.Banner)
+	}
+	if strings.Join(probe.Methods, ",") != "publickey,password" {
+		t.Errorf("Methods = %v, want [publickey password]", probe.Methods)
+	}
+}
+
+func TestDiagnoseSSHAuth(t *testing.T) {
+	hint.IsTTY = false
+
+	wrongUser := "SSH user 'ubuntu' was rejected — this VM may use a different login user; set ssh_user in config.toml"
+	keyProblem := "SSH login as 'ubuntu' was refused although EC2 Instance Connect accepted the key"
+
+	tests := []struct {
+		name        string
+		server      *ssh.ServerConfig
+		provisioned string
+		wantErr     []string
+	}{
+		{
+			name:        "genuine key problem",
+			server:      keyRejectingServer(),
+			provisioned: "ubuntu",
+			wantErr:     []string{keyProblem, "mint recreate"},
+		},
+		{
+			name:    "untagged VM with key problem",
+			server:  keyRejectingServer(),
+			wantErr: []string{keyProblem},
+		},
+		{
+			name:        "VM provisioned for another user",
+			server:      keyRejectingServer(),
+			provisioned: "ec2-user",
+			wantErr:     []string{wrongUser, "(current AMI: ami-0123456789, provisioned for user 'ec2-user')"},
+		},
+		{
+			name: "sshd offers the user no public key auth",
+			server: &ssh.ServerConfig{
+				PasswordCallback: func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+					return nil, errors.New("denied")
+				},
+			},
+			wantErr: []string{wrongUser, "(current AMI: ami-0123456789)"},
+		},
+		{
+			name: "banner names an invalid user",
+			server: func() *ssh.ServerConfig {
+				c := keyRejectingServer()
+				c.BannerCallback = func(conn ssh.ConnMetadata) string { return "Invalid user " + conn.User() + "\n" }
+				return c
+			}(),
+			provisioned: "ubuntu",
+			wantErr:     []string{wrongUser},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := diagnoseSSHAuth(rejectedRunner, describeForSSHAuth(tt.provisioned), newSSHAuthProber(fakeSSHServerDial(t, tt.server)))
+			_, err := runner(context.Background(), &cmdtest.SendSSHPublicKey{}, "i-abc123", "us-east-1a", "1.2.3.4", 22, "ubuntu", []string{"true"})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+			var rejected *sshAuthRejectedError
+			if !errors.As(err, &rejected) {
+				t.Error("diagnosed error should unwrap to the runner's error")
+			}
+		})
+	}
+}
+
+func TestDiagnoseSSHAuthProbeFailureKeepsOriginalError(t *testing.T) {
+	probe := newSSHAuthProber(failDial(errors.New("connection refused")))
+	runner := diagnoseSSHAuth(rejectedRunner, describeForSSHAuth("ubuntu"), probe)
+	_, err := runner(context.Background(), &cmdtest.SendSSHPublicKey{}, "i-abc123", "us-east-1a", "1.2.3.4", 22, "ubuntu", nil)
+	if err == nil || !strings.Contains(err.Error(), "Permission denied (publickey)") {
+		t.Errorf("error = %v, want the original ssh error", err)
+	}
+}
+
+func TestDiagnoseSSHAuthPassesOtherErrors(t *testing.T) {
+	called := false
+	probe := func(context.Context, string, string) (sshAuthProbe, error) {
+		called = true
+		return sshAuthProbe{}, nil
+	}
+	inner := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		return []byte("partial"), errors.New("remote command failed: exit status 1")
+	}
+	out, err := diagnoseSSHAuth(inner, describeForSSHAuth(""), probe)(context.Background(), nil, "i-abc123", "", "1.2.3.4", 22, "ubuntu", nil)
+	if err == nil || err.Error() != "remote command failed: exit status 1" || string(out) != "partial" {
+		t.Errorf("got (%q, %v), want the inner result unchanged", out, err)
+	}
+	if called {
+		t.Error("probe should only run for a refused login")
+	}
+}
+
+func TestIsSSHPermissionDenied(t *testing.T) {
+	if !isSSHPermissionDenied("ubuntu@1.2.3.4: Permission denied (publickey).\r\n") {
+		t.Error("expected a refused login to be recognized")
+	}
+	if isSSHPermissionDenied("bash: /root/x: Permission denied") {
+		t.Error("a remote command's permission error is not a refused login")
+	}
+}
+
+func TestPrefixWriterKeepsStart(t *testing.T) {
+	w := &prefixWriter{max: 5}
+	for _, s := range []string{"abc", "defg", "hij"} {
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if w.String() != "abcde" {
+		t.Errorf("String() = %q, want %q", w.String(), "abcde")
+	}
+}
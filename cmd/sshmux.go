@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/sshmux"
+)
+
+// newSSHMuxPool returns the pool that multiplexes a command's remote
+// commands over one SSH connection per VM, or nil when --no-ssh-mux is set.
+// Windows' OpenSSH has no ControlMaster, so commands there always connect
+// per call.
+func newSSHMuxPool(cliCtx *cli.CLIContext) *sshmux.Pool {
+	if runtime.GOOS == "windows" || (cliCtx != nil && cliCtx.NoSSHMux) {
+		return nil
+	}
+	return sshmux.NewPool()
+}
+
+// closeSSHMux ends the multiplexed SSH connections of the command that ran,
+// whether or not it succeeded.
+func closeSSHMux(executed *cobra.Command) {
+	if executed == nil || executed.Context() == nil {
+		return
+	}
+	if clients := awsClientsFromContext(executed.Context()); clients != nil && clients.sshMux != nil {
+		_ = clients.sshMux.Close()
+	}
+}
+
+// pooledRemoteRunner is the counterpart of remoteRunnerWithOptions that
+// runs each command over pool's master connection to the VM. The ephemeral
+// key is generated and pushed only when the connection is made, or made
+// again after it died.
+func pooledRemoteRunner(pool *sshmux.Pool, opts sshconfig.Options) RemoteCommandRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		user = opts.LoginUser(user)
+		path, err := connectPooled(ctx, pool, sendKey, instanceID, az, host, port, user, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd := exec.CommandContext(ctx, "ssh", pooledSSHArgs(path, host, port, user, command, false)...)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("remote command failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	}
+}
+
+// pooledStreamingRemoteRunner is the StreamingRemoteRunner counterpart of
+// pooledRemoteRunner.
+func pooledStreamingRemoteRunner(pool *sshmux.Pool, opts sshconfig.Options) StreamingRemoteRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		user = opts.LoginUser(user)
+		path, err := connectPooled(ctx, pool, sendKey, instanceID, az, host, port, user, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd := exec.CommandContext(ctx, "ssh", pooledSSHArgs(path, host, port, user, command, agentForwardingFromContext(ctx))...)
+		cmd.Stderr = stderr
+		if open := remoteStdinFromContext(ctx); open != nil {
+			stdin := open()
+			defer stdin.Close()
+			cmd.Stdin = stdin
+		}
+		stdout, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("remote command failed: %w", err)
+		}
+		return stdout, nil
+	}
+}
+
+// connectPooled returns the control socket of the master connection to
+// user@host on instanceID, pushing a fresh ephemeral key to make one when
+// needed. The master forwards the SSH agent when there is one, so the
+// streaming commands that ask for it get it. A master that fails to start
+// is reported like a failed runRemoteCommand, so the SSH auth diagnosis and
+// the Instance Connect Endpoint fallback see the same errors.
+func connectPooled(
+	ctx context.Context,
+	pool *sshmux.Pool,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID string,
+	az string,
+	host string,
+	port int,
+	user string,
+	opts sshconfig.Options,
+) (string, error) {
+	dest := sshmux.Dest{InstanceID: instanceID, Host: host, Port: port, User: user}
+	path, err := pool.Connect(ctx, dest, func(ctx context.Context) ([]string, func(), error) {
+		pubKey, privKeyPath, cleanup, err := generateEphemeralKeyPair()
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating ephemeral SSH key: %w", err)
+		}
+		_, err = sendKey.SendSSHPublicKey(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
+			InstanceId:       aws.String(instanceID),
+			InstanceOSUser:   aws.String(user),
+			SSHPublicKey:     aws.String(pubKey),
+			AvailabilityZone: aws.String(az),
+		})
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("pushing SSH key via Instance Connect: %w", err)
+		}
+		forwardAgent := os.Getenv("SSH_AUTH_SOCK") != ""
+		return remoteSSHArgs(privKeyPath, host, port, user, nil, forwardAgent, opts), cleanup, nil
+	})
+	var masterErr *sshmux.MasterError
+	if errors.As(err, &masterErr) {
+		err = fmt.Errorf("remote command failed: %w (stderr: %s)", masterErr.Err, masterErr.Stderr)
+		if isSSHPermissionDenied(masterErr.Stderr) {
+			return "", &sshAuthRejectedError{user: user, err: err}
+		}
+	}
+	return path, err
+}
+
+// pooledSSHArgs builds the argv for running command over the master
+// connection at path. The master already authenticated, so only the
+// destination and the agent forwarding request are needed.
+func pooledSSHArgs(path, host string, port int, user string, command []string, forwardAgent bool) []string {
+	sshArgs := append(sshmux.Args(path),
+		"-o", "BatchMode=yes",
+		"-p", fmt.Sprintf("%d", port),
+	)
+	if forwardAgent {
+		sshArgs = append(sshArgs, "-o", "ForwardAgent=yes")
+	}
+	sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host))
+	return append(sshArgs, command...)
+}
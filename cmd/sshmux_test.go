@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/sshmux"
+)
+
+// countingSendKey counts Instance Connect key pushes.
+type countingSendKey struct {
+	pushes int
+	users  []string
+}
+
+func (m *countingSendKey) SendSSHPublicKey(_ context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, _ ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
+	m.pushes++
+	m.users = append(m.users, aws.ToString(params.InstanceOSUser))
+	return &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}, nil
+}
+
+func TestConnectPooled(t *testing.T) {
+	var masters [][]string
+	alive := map[string]bool{}
+	startErr := ""
+	pool := sshmux.NewPool().WithExec(func(_ context.Context, stderr io.Writer, args ...string) error {
+		path := args[slices.Index(args, "-S")+1]
+		switch {
+		case slices.Contains(args, "-M"):
+			masters = append(masters, args)
+			if startErr != "" {
+				fmt.Fprint(stderr, startErr)
+				return fmt.Errorf("exit status 255")
+			}
+			alive[path] = true
+		case slices.Contains(args, "check") && !alive[path]:
+			return fmt.Errorf("exit status 255")
+		}
+		return nil
+	})
+	defer pool.Close()
+
+	sendKey := &countingSendKey{}
+	opts := sshconfig.Options{User: "dev", ExtraArgs: []string{"-o", "Compression=yes"}}
+	ctx := context.Background()
+	for range 3 {
+		if _, err := connectPooled(ctx, pool, sendKey, "i-abc", "us-east-1a", "1.2.3.4", 22, opts.LoginUser("ubuntu"), opts); err != nil {
+			t.Fatalf("connectPooled: %v", err)
+		}
+	}
+	if sendKey.pushes != 1 || len(masters) != 1 {
+		t.Fatalf("pushed %d keys for %d masters, want one of each for three commands", sendKey.pushes, len(masters))
+	}
+	if sendKey.users[0] != "dev" {
+		t.Errorf("key pushed for %q, want the configured ssh_user", sendKey.users[0])
+	}
+	master := strings.Join(masters[0], " ")
+	for _, want := range []string{"-M -N -f", "StrictHostKeyChecking=no", "-o Compression=yes", "dev@1.2.3.4"} {
+		if !strings.Contains(master, want) {
+			t.Errorf("master args %q missing %q", master, want)
+		}
+	}
+
+	// A refused login is reported as one, for the SSH auth diagnosis.
+	startErr = "dev@1.2.3.4: Permission denied (publickey)."
+	_, err := connectPooled(ctx, pool, sendKey, "i-new", "us-east-1a", "1.2.3.4", 22, "dev", opts)
+	var rejected *sshAuthRejectedError
+	if !errors.As(err, &rejected) || !strings.Contains(err.Error(), "Permission denied (publickey)") {
+		t.Errorf("error = %v, want an sshAuthRejectedError", err)
+	}
+}
+
+func TestPooledSSHArgs(t *testing.T) {
+	got := pooledSSHArgs("/tmp/mint-ssh-1/0", "1.2.3.4", 2222, "ubuntu", []string{"ls", "/mint/projects"}, true)
+	want := []string{"-S", "/tmp/mint-ssh-1/0", "-o", "ControlMaster=no", "-o", "BatchMode=yes", "-p", "2222",
+		"-o", "ForwardAgent=yes", "ubuntu@1.2.3.4", "ls", "/mint/projects"}
+	if !slices.Equal(got, want) {
+		t.Errorf("pooledSSHArgs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/aws/tunnel"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// tunnelLoopbackHost is the address ssh connects to when a session is
+// carried over an Instance Connect Endpoint tunnel.
+const tunnelLoopbackHost = "127.0.0.1"
+
+// connectivityEICE is the human label for VMs reached through an EC2
+// Instance Connect Endpoint rather than a public IP.
+const connectivityEICE = "via Instance Connect Endpoint"
+
+// sshRouteProbeTimeout bounds the TCP probe that decides whether a public IP
+// is reachable before a command is run against it.
+const sshRouteProbeTimeout = 5 * time.Second
+
+// sshRouter decides how a remote command reaches a VM. A VM with a public IP
+// whose SSH port accepts a connection is dialled directly; a VM without one,
+// or whose port cannot be reached, is reached through an EC2 Instance Connect
+// Endpoint tunnel in its VPC. Consumers never see the difference: the wrapped
+// runners keep the RemoteCommandRunner and StreamingRemoteRunner signatures.
+type sshRouter struct {
+	describe  mintaws.DescribeInstancesAPI
+	endpoints mintaws.DescribeInstanceConnectEndpointsAPI
+	dialer    tunnel.Dialer
+	dial      func(ctx context.Context, network, address string) (net.Conn, error) // nil uses net.Dialer
+
+	mu        sync.Mutex
+	targets   map[string]tunnel.Target // keyed by instanceID + port
+	reachable map[string]error         // probe result keyed by host:port
+}
+
+// newSSHRouter creates an sshRouter with the given dependencies.
+func newSSHRouter(describe mintaws.DescribeInstancesAPI, endpoints mintaws.DescribeInstanceConnectEndpointsAPI, dialer tunnel.Dialer) *sshRouter {
+	return &sshRouter{
+		describe:  describe,
+		endpoints: endpoints,
+		dialer:    dialer,
+		targets:   make(map[string]tunnel.Target),
+		reachable: make(map[string]error),
+	}
+}
+
+// remoteRunner wraps inner so each call is routed directly or through the
+// Instance Connect Endpoint tunnel.
+func (r *sshRouter) remoteRunner(inner RemoteCommandRunner) RemoteCommandRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		var out []byte
+		err := r.route(ctx, instanceID, host, port, func(h string, p int) error {
+			var runErr error
+			out, runErr = inner(ctx, sendKey, instanceID, az, h, p, user, command)
+			return runErr
+		})
+		return out, err
+	}
+}
+
+// streamingRemoteRunner is the StreamingRemoteRunner counterpart of
+// remoteRunner.
+func (r *sshRouter) streamingRemoteRunner(inner StreamingRemoteRunner) StreamingRemoteRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		var out []byte
+		err := r.route(ctx, instanceID, host, port, func(h string, p int) error {
+			var runErr error
+			out, runErr = inner(ctx, sendKey, instanceID, az, h, p, user, command, stderr)
+			return runErr
+		})
+		return out, err
+	}
+}
+
+// route runs attempt against the public IP when its SSH port accepts a
+// connection, and through the tunnel when there is no public IP or the port
+// is unreachable. The choice is made before attempt runs, so a command is
+// never run twice: its errors, including ssh's exit status 255, are returned
+// unchanged. When both paths fail to connect, the error explains each.
+func (r *sshRouter) route(ctx context.Context, instanceID, host string, port int, attempt func(host string, port int) error) error {
+	var directErr error
+	if host != "" {
+		directErr = r.probe(ctx, host, port)
+		if directErr == nil {
+			return attempt(host, port)
+		}
+	}
+
+	tunnelErr := r.viaTunnel(ctx, instanceID, port, attempt)
+	if tunnelErr == nil {
+		return nil
+	}
+
+	var sessionErr *tunnelSessionError
+	if errors.As(tunnelErr, &sessionErr) && !isSSHUnreachable(sessionErr.err) {
+		// The tunnel connected and the remote command itself failed.
+		return sessionErr.err
+	}
+
+	if directErr != nil {
+		return fmt.Errorf("cannot reach VM %s: direct SSH to %s failed (%v); Instance Connect Endpoint fallback failed (%w)",
+			instanceID, host, directErr, tunnelErr)
+	}
+	return fmt.Errorf("cannot reach VM %s: it has no public IP and the Instance Connect Endpoint tunnel failed: %w",
+		instanceID, tunnelErr)
+}
+
+// probe reports whether host:port accepts a TCP connection. Results are
+// cached for the lifetime of the router, like tunnel targets.
+func (r *sshRouter) probe(ctx context.Context, host string, port int) error {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	r.mu.Lock()
+	cached, ok := r.reachable[address]
+	r.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	dial := r.dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, sshRouteProbeTimeout)
+	defer cancel()
+	conn, err := dial(dialCtx, "tcp", address)
+	if err == nil {
+		conn.Close()
+	}
+	r.mu.Lock()
+	r.reachable[address] = err
+	r.mu.Unlock()
+	return err
+}
+
+// tunnelSessionError marks an error returned by the SSH session run over an
+// established tunnel, as opposed to a failure setting the tunnel up.
+type tunnelSessionError struct {
+	err error
+}
+
+func (e *tunnelSessionError) Error() string { return e.err.Error() }
+func (e *tunnelSessionError) Unwrap() error { return e.err }
+
+// viaTunnel opens a loopback forwarder to the instance and runs attempt
+// against it.
+func (r *sshRouter) viaTunnel(ctx context.Context, instanceID string, port int, attempt func(host string, port int) error) error {
+	target, err := r.tunnelTarget(ctx, instanceID, port)
+	if err != nil {
+		return err
+	}
+
+	fwd, err := tunnel.Forward(ctx, r.dialer, target)
+	if err != nil {
+		return err
+	}
+	defer fwd.Close()
+
+	if err := attempt(tunnelLoopbackHost, fwd.Port()); err != nil {
+		// A failed dial surfaces to ssh as a dropped connection; report the
+		// tunnel's own error instead.
+		if dialErr := fwd.Err(); dialErr != nil {
+			return dialErr
+		}
+		return &tunnelSessionError{err: err}
+	}
+	return nil
+}
+
+// tunnelTarget resolves the private IP and Instance Connect Endpoint for an
+// instance. Results are cached for the lifetime of the router so repeated
+// remote commands in one invocation make the lookups once.
+func (r *sshRouter) tunnelTarget(ctx context.Context, instanceID string, port int) (tunnel.Target, error) {
+	key := fmt.Sprintf("%s:%d", instanceID, port)
+	r.mu.Lock()
+	cached, ok := r.targets[key]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	out, err := r.describe.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return tunnel.Target{}, fmt.Errorf("describing instance %s: %w", instanceID, err)
+	}
+	var privateIP, vpcID string
+	for _, res := range out.Reservations {
+		for _, inst := range res.Instances {
+			privateIP = aws.ToString(inst.PrivateIpAddress)
+			vpcID = aws.ToString(inst.VpcId)
+		}
+	}
+	if privateIP == "" || vpcID == "" {
+		return tunnel.Target{}, fmt.Errorf("instance %s has no private IP in a VPC", instanceID)
+	}
+
+	endpoint, err := findInstanceConnectEndpoint(ctx, r.endpoints, vpcID)
+	if err != nil {
+		return tunnel.Target{}, err
+	}
+
+	target := tunnel.Target{
+		EndpointID:  aws.ToString(endpoint.InstanceConnectEndpointId),
+		EndpointDNS: aws.ToString(endpoint.DnsName),
+		PrivateIP:   privateIP,
+		Port:        port,
+	}
+	r.mu.Lock()
+	r.targets[key] = target
+	r.mu.Unlock()
+	return target, nil
+}
+
+// findInstanceConnectEndpoint returns a ready Instance Connect Endpoint in
+// vpcID, or an actionable error when none exists.
+func findInstanceConnectEndpoint(ctx context.Context, client mintaws.DescribeInstanceConnectEndpointsAPI, vpcID string) (*ec2types.Ec2InstanceConnectEndpoint, error) {
+	out, err := client.DescribeInstanceConnectEndpoints(ctx, &ec2.DescribeInstanceConnectEndpointsInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			{Name: aws.String("state"), Values: []string{string(ec2types.Ec2InstanceConnectEndpointStateCreateComplete)}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing Instance Connect Endpoints in %s: %w", vpcID, err)
+	}
+	for i := range out.InstanceConnectEndpoints {
+		if aws.ToString(out.InstanceConnectEndpoints[i].DnsName) != "" {
+			return &out.InstanceConnectEndpoints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no EC2 Instance Connect Endpoint in VPC %s — run %s to create one",
+		vpcID, hint.Cmd("mint init --instance-connect-endpoint"))
+}
+
+// isSSHUnreachable reports whether err means ssh never established a
+// session: a known connection failure message, or ssh's own exit status 255.
+func isSSHUnreachable(err error) bool {
+	if isSSHConnectionError(err) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 255
+}
+
+// vmConnectivity labels how remote commands reach v: "direct" for a public
+// IP, or the Instance Connect Endpoint label otherwise.
+func vmConnectivity(v *vm.VM) string {
+	if v.PublicIP != "" {
+		return "direct"
+	}
+	return connectivityEICE
+}
@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/aws/tunnel"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// fakeTunnelDialer stands in for the OpenTunnel websocket. Each dial returns
+// an in-memory stream whose far end echoes bytes back, and records the
+// bytes it carried so tests can assert traffic flowed through the tunnel.
+type fakeTunnelDialer struct {
+	mu      sync.Mutex
+	targets []tunnel.Target
+	carried bytes.Buffer
+	err     error
+}
+
+func (d *fakeTunnelDialer) Dial(_ context.Context, target tunnel.Target) (io.ReadWriteCloser, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.mu.Lock()
+	d.targets = append(d.targets, target)
+	d.mu.Unlock()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		buf := make([]byte, 1024)
+		for {
+			n, err := server.Read(buf)
+			if n > 0 {
+				d.mu.Lock()
+				d.carried.Write(buf[:n])
+				d.mu.Unlock()
+				if _, werr := server.Write(buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return client, nil
+}
+
+// mockDescribeEICE implements mintaws.DescribeInstanceConnectEndpointsAPI.
+type mockDescribeEICE struct {
+	endpoints []ec2types.Ec2InstanceConnectEndpoint
+	err       error
+}
+
+func (m *mockDescribeEICE) DescribeInstanceConnectEndpoints(ctx context.Context, params *ec2.DescribeInstanceConnectEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceConnectEndpointsOutput, error) {
+	return &ec2.DescribeInstanceConnectEndpointsOutput{InstanceConnectEndpoints: m.endpoints}, m.err
+}
+
+// sshSessionFake is an inner RemoteCommandRunner. Connections to the public
+// IP return directErr; connections to the loopback forwarder speak a fake SSH
+// banner through the tunnel and return what came back.
+type sshSessionFake struct {
+	directErr error
+	tunnelErr error
+	hosts     []string
+}
+
+func (f *sshSessionFake) run(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+	f.hosts = append(f.hosts, host)
+	if host != tunnelLoopbackHost {
+		if f.directErr != nil {
+			return nil, f.directErr
+		}
+		return []byte("direct"), nil
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	banner := "SSH-2.0-mint-test\r\n"
+	if _, err := conn.Write([]byte(banner)); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, len(banner))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	if f.tunnelErr != nil {
+		return nil, f.tunnelErr
+	}
+	return reply, nil
+}
+
+// fakeProbe stands in for the TCP probe of the public IP: it fails with err,
+// or accepts the connection when err is nil.
+type fakeProbe struct {
+	err       error
+	addresses []string
+}
+
+func (p *fakeProbe) dial(_ context.Context, network, address string) (net.Conn, error) {
+	p.addresses = append(p.addresses, address)
+	if p.err != nil {
+		return nil, p.err
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func newTestSSHRouter(dialer tunnel.Dialer, endpoints []ec2types.Ec2InstanceConnectEndpoint) *sshRouter {
+	return newTestSSHRouterProbe(dialer, endpoints, &fakeProbe{})
+}
+
+func newTestSSHRouterProbe(dialer tunnel.Dialer, endpoints []ec2types.Ec2InstanceConnectEndpoint, probe *fakeProbe) *sshRouter {
+	out := makeRunningInstance("i-private", "default", "alice")
+	inst := &out.Reservations[0].Instances[0]
+	inst.PrivateIpAddress = aws.String("172.31.8.15")
+	inst.VpcId = aws.String("vpc-private")
+	r := newSSHRouter(&cmdtest.DescribeInstances{Output: out}, &mockDescribeEICE{endpoints: endpoints}, dialer)
+	r.dial = probe.dial
+	return r
+}
+
+func readyEndpoint() []ec2types.Ec2InstanceConnectEndpoint {
+	return []ec2types.Ec2InstanceConnectEndpoint{{
+		InstanceConnectEndpointId: aws.String("eice-0abc"),
+		DnsName:                   aws.String("eice-0abc.ec2-instance-connect-endpoint.us-east-1.amazonaws.com"),
+		State:                     ec2types.Ec2InstanceConnectEndpointStateCreateComplete,
+	}}
+}
+
+func runRouted(r *sshRouter, inner *sshSessionFake, host string) ([]byte, error) {
+	run := r.remoteRunner(inner.run)
+	return run(context.Background(), nil, "i-private", "us-east-1a", host, defaultSSHPort, defaultSSHUser, []string{"true"})
+}
+
+func TestSSHRouterPublicIPConnectsDirectly(t *testing.T) {
+	dialer := &fakeTunnelDialer{}
+	inner := &sshSessionFake{}
+
+	out, err := runRouted(newTestSSHRouter(dialer, readyEndpoint()), inner, "54.1.2.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "direct" {
+		t.Errorf("output = %q, want direct", out)
+	}
+	if len(inner.hosts) != 1 || inner.hosts[0] != "54.1.2.3" {
+		t.Errorf("hosts = %v, want only the public IP", inner.hosts)
+	}
+	if len(dialer.targets) != 0 {
+		t.Errorf("tunnel dialled %d times, want 0", len(dialer.targets))
+	}
+}
+
+func TestSSHRouterNoPublicIPUsesTunnel(t *testing.T) {
+	dialer := &fakeTunnelDialer{}
+	inner := &sshSessionFake{}
+
+	out, err := runRouted(newTestSSHRouter(dialer, readyEndpoint()), inner, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(out) != "SSH-2.0-mint-test\r\n" {
+		t.Errorf("output = %q, want echoed banner", out)
+	}
+	if len(inner.hosts) != 1 || inner.hosts[0] != tunnelLoopbackHost {
+		t.Errorf("hosts = %v, want only the loopback forwarder", inner.hosts)
+	}
+
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+	if len(dialer.targets) != 1 {
+		t.Fatalf("tunnel dialled %d times, want 1", len(dialer.targets))
+	}
+	want := tunnel.Target{
+		EndpointID:  "eice-0abc",
+		EndpointDNS: "eice-0abc.ec2-instance-connect-endpoint.us-east-1.amazonaws.com",
+		PrivateIP:   "172.31.8.15",
+		Port:        defaultSSHPort,
+	}
+	if dialer.targets[0] != want {
+		t.Errorf("tunnel target = %+v, want %+v", dialer.targets[0], want)
+	}
+	if !strings.Contains(dialer.carried.String(), "SSH-2.0-mint-test") {
+		t.Errorf("SSH traffic did not flow through the tunnel; carried %q", dialer.carried.String())
+	}
+}
+
+func TestSSHRouterFallsBackWhenDirectUnreachable(t *testing.T) {
+	dialer := &fakeTunnelDialer{}
+	inner := &sshSessionFake{}
+	probe := &fakeProbe{err: errors.New("dial tcp 54.1.2.3:41122: i/o timeout")}
+
+	_, err := runRouted(newTestSSHRouterProbe(dialer, readyEndpoint(), probe), inner, "54.1.2.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(probe.addresses) != 1 || probe.addresses[0] != "54.1.2.3:41122" {
+		t.Errorf("probed %v, want the public IP's SSH port", probe.addresses)
+	}
+	if len(inner.hosts) != 1 || inner.hosts[0] != tunnelLoopbackHost {
+		t.Errorf("hosts = %v, want only the loopback forwarder", inner.hosts)
+	}
+}
+
+func TestSSHRouterCachesProbe(t *testing.T) {
+	probe := &fakeProbe{}
+	r := newTestSSHRouterProbe(&fakeTunnelDialer{}, readyEndpoint(), probe)
+	for range 3 {
+		if _, err := runRouted(r, &sshSessionFake{}, "54.1.2.3"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(probe.addresses) != 1 {
+		t.Errorf("probed %d times, want 1", len(probe.addresses))
+	}
+}
+
+func TestSSHRouterDirectCommandFailureIsNotRetried(t *testing.T) {
+	dialer := &fakeTunnelDialer{}
+	inner := &sshSessionFake{directErr: errors.New("remote command failed: exit status 1")}
+
+	_, err := runRouted(newTestSSHRouter(dialer, readyEndpoint()), inner, "54.1.2.3")
+	if err == nil || err.Error() != "remote command failed: exit status 1" {
+		t.Fatalf("error = %v, want the command failure unchanged", err)
+	}
+	if len(dialer.targets) != 0 {
+		t.Error("tunnel used after a session that did connect")
+	}
+}
+
+func TestSSHRouterDirectExit255IsNotRerun(t *testing.T) {
+	exit255 := exec.Command("sh", "-c", "exit 255").Run()
+	dialer := &fakeTunnelDialer{}
+	var calls int
+	var stdout bytes.Buffer
+	streaming := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string, stderr io.Writer) ([]byte, error) {
+		calls++
+		stdout.WriteString("migrating...\n")
+		return nil, exit255
+	}
+
+	run := newTestSSHRouter(dialer, readyEndpoint()).streamingRemoteRunner(streaming)
+	_, err := run(context.Background(), nil, "i-private", "us-east-1a", "54.1.2.3", defaultSSHPort, defaultSSHUser, []string{"./migrate"}, io.Discard)
+	if !errors.Is(err, exit255) {
+		t.Fatalf("error = %v, want the command's exit status unchanged", err)
+	}
+	if calls != 1 || stdout.String() != "migrating...\n" {
+		t.Errorf("command ran %d times with output %q, want once", calls, stdout.String())
+	}
+	if len(dialer.targets) != 0 {
+		t.Error("tunnel used after the command ran directly")
+	}
+}
+
+func TestSSHRouterTunnelCommandFailureIsUnchanged(t *testing.T) {
+	inner := &sshSessionFake{tunnelErr: errors.New("remote command failed: exit status 2")}
+
+	_, err := runRouted(newTestSSHRouter(&fakeTunnelDialer{}, readyEndpoint()), inner, "")
+	if err == nil || err.Error() != "remote command failed: exit status 2" {
+		t.Fatalf("error = %v, want the command failure unchanged", err)
+	}
+}
+
+func TestSSHRouterBothPathsFailExplainsEach(t *testing.T) {
+	inner := &sshSessionFake{}
+	probe := &fakeProbe{err: errors.New("dial tcp 54.1.2.3:41122: connect: Connection refused")}
+
+	_, err := runRouted(newTestSSHRouterProbe(&fakeTunnelDialer{}, nil, probe), inner, "54.1.2.3")
+	if err == nil {
+		t.Fatal("expected error when both paths fail")
+	}
+	for _, want := range []string{"direct SSH to 54.1.2.3 failed", "Connection refused", "Instance Connect Endpoint fallback failed", "no EC2 Instance Connect Endpoint in VPC vpc-private"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q: %v", want, err)
+		}
+	}
+}
+
+func TestSSHRouterNoPublicIPTunnelFailure(t *testing.T) {
+	dialer := &fakeTunnelDialer{err: errors.New("endpoint rejected tunnel: 403 Forbidden")}
+	inner := &sshSessionFake{}
+
+	_, err := runRouted(newTestSSHRouter(dialer, readyEndpoint()), inner, "")
+	if err == nil {
+		t.Fatal("expected error when the tunnel cannot be opened")
+	}
+	if !strings.Contains(err.Error(), "no public IP") {
+		t.Errorf("error should explain the VM has no public IP: %v", err)
+	}
+}
+
+func TestSSHRouterStreamingRunnerUsesTunnel(t *testing.T) {
+	dialer := &fakeTunnelDialer{}
+	inner := &sshSessionFake{}
+	streaming := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string, stderr io.Writer) ([]byte, error) {
+		return inner.run(ctx, sendKey, instanceID, az, host, port, user, command)
+	}
+
+	run := newTestSSHRouter(dialer, readyEndpoint()).streamingRemoteRunner(streaming)
+	if _, err := run(context.Background(), nil, "i-private", "us-east-1a", "", defaultSSHPort, defaultSSHUser, []string{"true"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dialer.targets) != 1 {
+		t.Errorf("tunnel dialled %d times, want 1", len(dialer.targets))
+	}
+}
+
+func TestStatusLabelsInstanceConnectEndpoint(t *testing.T) {
+	v := &vm.VM{Name: "default", ID: "i-private", State: "running", InstanceType: "t3.medium"}
+
+	var human bytes.Buffer
+	writeStatusHuman(&human, v, nil, nil, nil, nil, nil)
+	if !strings.Contains(human.String(), "IP:        - (via Instance Connect Endpoint)") {
+		t.Errorf("human output missing connectivity label:\n%s", human.String())
+	}
+
+	var out bytes.Buffer
+	if err := writeStatusJSON(&out, &statusReport{VM: v}, nil); err != nil {
+		t.Fatalf("writeStatusJSON: %v", err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(out.Bytes(), &obj); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if obj["connectivity"] != "instance-connect-endpoint" {
+		t.Errorf("connectivity = %v, want instance-connect-endpoint", obj["connectivity"])
+	}
+}
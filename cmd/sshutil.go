@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
@@ -18,6 +19,7 @@ import (
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 )
 
@@ -40,6 +42,25 @@ func defaultRunner(name string, args ...string) error {
 	return cmd.Run()
 }
 
+// BackgroundRunner starts an external command without waiting for it and
+// returns a function that stops it.
+type BackgroundRunner func(name string, args ...string) (stop func(), err error)
+
+// defaultBackgroundRunner starts the command using os/exec with stderr
+// connected to the parent process, so its errors reach the user. stop
+// kills the command and waits for it to exit.
+func defaultBackgroundRunner(name string, args ...string) (func(), error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}, nil
+}
+
 // RemoteCommandRunner executes a command on a remote VM via SSH using
 // Instance Connect ephemeral keys. It pushes a temporary public key,
 // runs the command, and returns the captured stdout. Unlike CommandRunner
@@ -68,6 +89,40 @@ func defaultRemoteRunner(
 	port int,
 	user string,
 	command []string,
+) ([]byte, error) {
+	return remoteRunnerWithOptions(sshconfig.Options{})(ctx, sendKey, instanceID, az, host, port, user, command)
+}
+
+// remoteRunnerWithOptions returns a defaultRemoteRunner that also applies
+// the user's SSH options (ssh_extra_args, ssh_identity_file,
+// ssh_certificate_file, --ssh-arg). A configured ssh_user replaces the
+// caller's user.
+func remoteRunnerWithOptions(opts sshconfig.Options) RemoteCommandRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+	) ([]byte, error) {
+		return runRemoteCommand(ctx, sendKey, instanceID, az, host, port, opts.LoginUser(user), command, opts)
+	}
+}
+
+// runRemoteCommand implements defaultRemoteRunner.
+func runRemoteCommand(
+	ctx context.Context,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID string,
+	az string,
+	host string,
+	port int,
+	user string,
+	command []string,
+	opts sshconfig.Options,
 ) ([]byte, error) {
 	// Generate ephemeral key pair.
 	pubKey, privKeyPath, cleanup, err := generateEphemeralKeyPair()
@@ -87,25 +142,18 @@ func defaultRemoteRunner(
 		return nil, fmt.Errorf("pushing SSH key via Instance Connect: %w", err)
 	}
 
-	// Build ssh command for non-interactive execution.
-	sshArgs := []string{
-		"-i", privKeyPath,
-		"-p", fmt.Sprintf("%d", port),
-		"-o", "StrictHostKeyChecking=no",
-		"-o", "UserKnownHostsFile=/dev/null",
-		"-o", "BatchMode=yes",
-		"-o", "ConnectTimeout=10",
-		fmt.Sprintf("%s@%s", user, host),
-	}
-	sshArgs = append(sshArgs, command...)
-
+	sshArgs := remoteSSHArgs(privKeyPath, host, port, user, command, false, opts)
 	cmd := exec.CommandContext(ctx, "ssh", sshArgs...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("remote command failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+		err = fmt.Errorf("remote command failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+		if isSSHPermissionDenied(stderr.String()) {
+			return nil, &sshAuthRejectedError{user: user, err: err}
+		}
+		return nil, err
 	}
 
 	return stdout.Bytes(), nil
@@ -140,6 +188,49 @@ func defaultStreamingRemoteRunner(
 	user string,
 	command []string,
 	stderr io.Writer,
+) ([]byte, error) {
+	return streamingRemoteRunnerWithOptions(sshconfig.Options{})(ctx, sendKey, instanceID, az, host, port, user, command, stderr)
+}
+
+// streamingRemoteRunnerWithOptions is the StreamingRemoteRunner counterpart
+// of remoteRunnerWithOptions.
+func streamingRemoteRunnerWithOptions(opts sshconfig.Options) StreamingRemoteRunner {
+	return func(
+		ctx context.Context,
+		sendKey mintaws.SendSSHPublicKeyAPI,
+		instanceID string,
+		az string,
+		host string,
+		port int,
+		user string,
+		command []string,
+		stderr io.Writer,
+	) ([]byte, error) {
+		return runStreamingRemoteCommand(ctx, sendKey, instanceID, az, host, port, opts.LoginUser(user), command, stderr, opts)
+	}
+}
+
+// sshPortOrDefault returns the configured ssh_port, or defaultSSHPort when
+// none is set.
+func sshPortOrDefault(port int) int {
+	if port == 0 {
+		return defaultSSHPort
+	}
+	return port
+}
+
+// runStreamingRemoteCommand implements defaultStreamingRemoteRunner.
+func runStreamingRemoteCommand(
+	ctx context.Context,
+	sendKey mintaws.SendSSHPublicKeyAPI,
+	instanceID string,
+	az string,
+	host string,
+	port int,
+	user string,
+	command []string,
+	stderr io.Writer,
+	opts sshconfig.Options,
 ) ([]byte, error) {
 	// Generate ephemeral key pair.
 	pubKey, privKeyPath, cleanup, err := generateEphemeralKeyPair()
@@ -159,7 +250,86 @@ func defaultStreamingRemoteRunner(
 		return nil, fmt.Errorf("pushing SSH key via Instance Connect: %w", err)
 	}
 
-	// Build ssh command for non-interactive execution.
+	sshArgs := remoteSSHArgs(privKeyPath, host, port, user, command, agentForwardingFromContext(ctx), opts)
+
+	// The start of stderr is also kept to tell a refused login from a failed
+	// command.
+	captured := &prefixWriter{max: sshStderrPrefixBytes}
+	cmd := exec.CommandContext(ctx, "ssh", sshArgs...)
+	cmd.Stderr = io.MultiWriter(stderr, captured)
+	if open := remoteStdinFromContext(ctx); open != nil {
+		stdin := open()
+		defer stdin.Close()
+		cmd.Stdin = stdin
+	}
+
+	stdout, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("remote command failed: %w", err)
+		if isSSHPermissionDenied(captured.String()) {
+			return nil, &sshAuthRejectedError{user: user, err: err}
+		}
+		return nil, err
+	}
+
+	return stdout, nil
+}
+
+// remoteStdinKey is the context key for the stdin of a streaming remote
+// command. See withRemoteStdin.
+type remoteStdinKey struct{}
+
+// withRemoteStdin returns ctx with open as the source of stdin for the
+// streaming remote commands run with it, such as the tar that unpacks a
+// local project push. The input travels in the context so the runner
+// wrappers (agent check, SSH routing, auth diagnosis) pass it through
+// unchanged. open is called once per ssh attempt, so an attempt the router
+// retries through the tunnel reads the input from the start; the reader is
+// closed when the attempt ends.
+func withRemoteStdin(ctx context.Context, open func() io.ReadCloser) context.Context {
+	return context.WithValue(ctx, remoteStdinKey{}, open)
+}
+
+// withoutRemoteStdin returns ctx with no remote stdin, for commands a
+// wrapper runs on its own behalf before the caller's.
+func withoutRemoteStdin(ctx context.Context) context.Context {
+	if remoteStdinFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteStdinKey{}, (func() io.ReadCloser)(nil))
+}
+
+// remoteStdinFromContext returns the stdin opener set by withRemoteStdin,
+// or nil.
+func remoteStdinFromContext(ctx context.Context) func() io.ReadCloser {
+	open, _ := ctx.Value(remoteStdinKey{}).(func() io.ReadCloser)
+	return open
+}
+
+// agentForwardingKey is the context key that asks for the local SSH agent
+// to be forwarded. See withAgentForwarding.
+type agentForwardingKey struct{}
+
+// withAgentForwarding returns ctx asking the streaming remote commands run
+// with it to forward the local SSH agent, so a git command on the VM can
+// authenticate with the caller's keys (project add --auth agent). Other
+// commands run without the agent.
+func withAgentForwarding(ctx context.Context) context.Context {
+	return context.WithValue(ctx, agentForwardingKey{}, true)
+}
+
+// agentForwardingFromContext reports whether ctx asks for agent forwarding
+// and there is a local agent to forward.
+func agentForwardingFromContext(ctx context.Context) bool {
+	forward, _ := ctx.Value(agentForwardingKey{}).(bool)
+	return forward && os.Getenv("SSH_AUTH_SOCK") != ""
+}
+
+// remoteSSHArgs builds the argv for a non-interactive ssh to user@host
+// running command. The ephemeral key and mint's own options come first so
+// the user's options cannot displace them: ssh offers identities in order
+// and keeps the first value given for an option.
+func remoteSSHArgs(privKeyPath, host string, port int, user string, command []string, forwardAgent bool, opts sshconfig.Options) []string {
 	sshArgs := []string{
 		"-i", privKeyPath,
 		"-p", fmt.Sprintf("%d", port),
@@ -168,24 +338,31 @@ func defaultStreamingRemoteRunner(
 		"-o", "BatchMode=yes",
 		"-o", "ConnectTimeout=10",
 	}
-	// Forward the local SSH agent when available so that git operations on the
-	// VM can authenticate using the caller's keys (e.g. for private repos via
-	// SSH git URLs). Agent forwarding is a no-op when SSH_AUTH_SOCK is unset.
-	if os.Getenv("SSH_AUTH_SOCK") != "" {
+	if forwardAgent {
 		sshArgs = append(sshArgs, "-o", "ForwardAgent=yes")
 	}
+	sshArgs = append(sshArgs, opts.Args()...)
 	sshArgs = append(sshArgs, fmt.Sprintf("%s@%s", user, host))
-	sshArgs = append(sshArgs, command...)
-
-	cmd := exec.CommandContext(ctx, "ssh", sshArgs...)
-	cmd.Stderr = stderr
+	return append(sshArgs, command...)
+}
 
-	stdout, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("remote command failed: %w", err)
+// shellQuoteArgs renders args for appending to the ssh command string mosh
+// takes in --ssh, which it splits with shell quoting rules. Each argument is
+// single-quoted and preceded by a space; an empty slice yields "".
+func shellQuoteArgs(args []string) string {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(" ")
+		b.WriteString(shellQuote(arg))
 	}
+	return b.String()
+}
 
-	return stdout, nil
+// shellQuote single-quotes s for the remote shell. ssh joins a remote
+// command's arguments with spaces, so any argument that is not known to be
+// shell-safe must be quoted before it is passed to a RemoteCommandRunner.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // TOFURemoteRunner wraps a RemoteCommandRunner with TOFU host key
@@ -243,6 +420,11 @@ func (t *TOFURemoteRunner) Run(
 // verifyHostKey implements the TOFU logic: scan the host key, check
 // against the store, record on first use, reject on mismatch.
 func (t *TOFURemoteRunner) verifyHostKey(host string, port int) error {
+	if host == "" {
+		// Instance Connect Endpoint tunnels are opened per session inside
+		// the routed runner, so there is no address to scan up front.
+		return fmt.Errorf("scanning host key: VM %q has no public IP; host key verification requires a direct connection", t.vmName)
+	}
 	fingerprint, _, scanErr := t.hostKeyScanner(host, port)
 	if scanErr != nil {
 		return fmt.Errorf("scanning host key: %w", scanErr)
@@ -271,7 +453,7 @@ func (t *TOFURemoteRunner) verifyHostKey(host string, port int) error {
 				"%s",
 			t.vmName, existing, fingerprint,
 			hint.Suggest("Rebuild", "mint recreate"),
-			hint.Suggest("Accept new key", fmt.Sprintf("delete the %q entry from ~/.config/mint/known_hosts", t.vmName)),
+			hint.Suggest("Accept new key", "mint hostkey reset "+t.vmName),
 		)
 	}
 
@@ -292,6 +474,41 @@ func isSSHConnectionError(err error) bool {
 		strings.Contains(msg, "Connection timed out")
 }
 
+// firstConnectionAttempts and firstConnectionRetry bound the retries of the
+// first SSH connection to a newly associated Elastic IP: three attempts over
+// 30 seconds. A package var so tests can retry without waiting.
+const firstConnectionAttempts = 3
+
+var firstConnectionRetry = 15 * time.Second
+
+// retryFirstConnection wraps run so that its first call is retried while the
+// connection is refused or times out, as it can for a few seconds after an
+// Elastic IP moves to a new instance. Authentication and command failures
+// are returned at once, and later calls are not retried.
+func retryFirstConnection(run RemoteCommandRunner) RemoteCommandRunner {
+	if run == nil {
+		return nil
+	}
+	first := true
+	return func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		if !first {
+			return run(ctx, sendKey, instanceID, az, host, port, user, command)
+		}
+		first = false
+		for attempt := 1; ; attempt++ {
+			out, err := run(ctx, sendKey, instanceID, az, host, port, user, command)
+			if !isSSHConnectionError(err) || attempt == firstConnectionAttempts {
+				return out, err
+			}
+			select {
+			case <-ctx.Done():
+				return out, err
+			case <-time.After(firstConnectionRetry):
+			}
+		}
+	}
+}
+
 // isTOFUError returns true if the error is a TOFU host key verification
 // error that should be propagated directly rather than masked by
 // command-specific error wrapping.
@@ -330,7 +547,7 @@ func generateEphemeralKeyPair() (pubKeyStr string, privKeyPath string, cleanup f
 		return "", "", nil, fmt.Errorf("create temp key file: %w", err)
 	}
 
-	if err := os.Chmod(tmpFile.Name(), 0o600); err != nil {
+	if err := paths.Chmod(tmpFile.Name(), paths.PrivateFileMode); err != nil {
 		os.Remove(tmpFile.Name())
 		return "", "", nil, fmt.Errorf("chmod temp key file: %w", err)
 	}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -11,27 +12,14 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
 )
 
-// mockSendKeyForRemote implements mintaws.SendSSHPublicKeyAPI for remote runner tests.
-type mockSendKeyForRemote struct {
-	output *ec2instanceconnect.SendSSHPublicKeyOutput
-	err    error
-	called bool
-	input  *ec2instanceconnect.SendSSHPublicKeyInput
-}
-
-func (m *mockSendKeyForRemote) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
-	m.called = true
-	m.input = params
-	return m.output, m.err
-}
-
 // Verify interface compliance at compile time.
-var _ mintaws.SendSSHPublicKeyAPI = (*mockSendKeyForRemote)(nil)
+var _ mintaws.SendSSHPublicKeyAPI = (*cmdtest.SendSSHPublicKey)(nil)
 
 // --- isSSHConnectionError tests ---
 
@@ -101,6 +89,62 @@ func TestIsSSHConnectionError(t *testing.T) {
 	}
 }
 
+func TestRetryFirstConnection(t *testing.T) {
+	orig := firstConnectionRetry
+	firstConnectionRetry = 0
+	t.Cleanup(func() { firstConnectionRetry = orig })
+
+	refused := errors.New("ssh: connect to host 54.1.2.3 port 41122: Connection refused")
+	denied := errors.New("ubuntu@54.1.2.3: Permission denied (publickey)")
+	tests := []struct {
+		name      string
+		errs      []error // one per call; nil succeeds
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "connects first time", errs: []error{nil}, wantCalls: 1},
+		{name: "refused then connects", errs: []error{refused, refused, nil}, wantCalls: 3},
+		{name: "gives up after three attempts", errs: []error{refused, refused, refused, nil}, wantCalls: 3, wantErr: refused},
+		{name: "auth failure not retried", errs: []error{denied, nil}, wantCalls: 1, wantErr: denied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			run := retryFirstConnection(func(ctx context.Context, _ mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+				err := tt.errs[calls]
+				calls++
+				return nil, err
+			})
+			_, err := run(context.Background(), nil, "i-1", "us-east-1a", "54.1.2.3", defaultSSHPort, defaultSSHUser, []string{"true"})
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryFirstConnectionOnlyFirstCall(t *testing.T) {
+	orig := firstConnectionRetry
+	firstConnectionRetry = 0
+	t.Cleanup(func() { firstConnectionRetry = orig })
+
+	calls := 0
+	run := retryFirstConnection(func(ctx context.Context, _ mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return nil, nil
+		}
+		return nil, errors.New("Connection timed out")
+	})
+	run(context.Background(), nil, "i-1", "az", "54.1.2.3", defaultSSHPort, defaultSSHUser, nil)
+	if _, err := run(context.Background(), nil, "i-1", "az", "54.1.2.3", defaultSSHPort, defaultSSHUser, nil); err == nil || calls != 2 {
+		t.Errorf("second call: err = %v after %d calls, want one unretried failure", err, calls)
+	}
+}
+
 func TestRemoteCommandRunnerType(t *testing.T) {
 	// Verify that defaultRemoteRunner satisfies the RemoteCommandRunner type.
 	var runner RemoteCommandRunner = defaultRemoteRunner
@@ -162,8 +206,8 @@ func TestRemoteCommandRunnerMockInjection(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mock := &mockSendKeyForRemote{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			mock := &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			}
 
 			out, err := tt.mockRunner(ctx, mock, "i-test123", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"tmux", "list-sessions"})
@@ -192,8 +236,8 @@ func TestDefaultRemoteRunnerSendKeyError(t *testing.T) {
 	// When Instance Connect rejects the key, defaultRemoteRunner should
 	// propagate the error without attempting to run ssh.
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		err: fmt.Errorf("access denied"),
+	mock := &cmdtest.SendSSHPublicKey{
+		Err: fmt.Errorf("access denied"),
 	}
 
 	_, err := defaultRemoteRunner(ctx, mock, "i-test123", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"whoami"})
@@ -203,21 +247,21 @@ func TestDefaultRemoteRunnerSendKeyError(t *testing.T) {
 	if !strings.Contains(err.Error(), "access denied") {
 		t.Errorf("error %q does not contain 'access denied'", err.Error())
 	}
-	if !mock.called {
+	if !mock.Called {
 		t.Error("SendSSHPublicKey should have been called")
 	}
 
 	// Verify the input was populated correctly.
-	if aws.ToString(mock.input.InstanceId) != "i-test123" {
-		t.Errorf("InstanceId = %q, want %q", aws.ToString(mock.input.InstanceId), "i-test123")
+	if aws.ToString(mock.Input.InstanceId) != "i-test123" {
+		t.Errorf("InstanceId = %q, want %q", aws.ToString(mock.Input.InstanceId), "i-test123")
 	}
-	if aws.ToString(mock.input.AvailabilityZone) != "us-east-1a" {
-		t.Errorf("AvailabilityZone = %q, want %q", aws.ToString(mock.input.AvailabilityZone), "us-east-1a")
+	if aws.ToString(mock.Input.AvailabilityZone) != "us-east-1a" {
+		t.Errorf("AvailabilityZone = %q, want %q", aws.ToString(mock.Input.AvailabilityZone), "us-east-1a")
 	}
-	if aws.ToString(mock.input.InstanceOSUser) != "ubuntu" {
-		t.Errorf("InstanceOSUser = %q, want %q", aws.ToString(mock.input.InstanceOSUser), "ubuntu")
+	if aws.ToString(mock.Input.InstanceOSUser) != "ubuntu" {
+		t.Errorf("InstanceOSUser = %q, want %q", aws.ToString(mock.Input.InstanceOSUser), "ubuntu")
 	}
-	if aws.ToString(mock.input.SSHPublicKey) == "" {
+	if aws.ToString(mock.Input.SSHPublicKey) == "" {
 		t.Error("SSHPublicKey should not be empty (key was generated before send)")
 	}
 }
@@ -290,8 +334,8 @@ func TestTOFURemoteRunnerFirstCallTriggersKeyscan(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, "default")
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	// First call should trigger keyscan.
@@ -331,8 +375,8 @@ func TestTOFURemoteRunnerSecondCallSkipsKeyscan(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, "default")
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	// First call.
@@ -370,8 +414,8 @@ func TestTOFURemoteRunnerKeyMismatchRejects(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, "default")
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	_, err := runner.Run(ctx, mock, "i-test", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"whoami"})
@@ -396,8 +440,8 @@ func TestTOFURemoteRunnerKeyscanError(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, "default")
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	_, err := runner.Run(ctx, mock, "i-test", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"whoami"})
@@ -427,8 +471,8 @@ func TestTOFURemoteRunnerMatchingKeyProceeds(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, "default")
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	out, err := runner.Run(ctx, mock, "i-test", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"whoami"})
@@ -453,7 +497,7 @@ func TestTOFURemoteRunnerMatchingKeyProceeds(t *testing.T) {
 //   - the current (new) fingerprint labeled "Current fingerprint:"
 //   - the "HOST KEY CHANGED" sentinel
 //   - hint.Suggest-formatted remediation for "mint recreate"
-//   - hint.Suggest-formatted instruction to accept the new key via known_hosts
+//   - hint.Suggest-formatted instruction to accept the new key via mint hostkey reset
 func TestVerifyHostKeyMismatchErrorFormat(t *testing.T) {
 	hint.IsTTY = false
 
@@ -512,8 +556,8 @@ func TestVerifyHostKeyMismatchErrorFormat(t *testing.T) {
 	if !strings.Contains(msg, "Accept new key:") {
 		t.Errorf("error missing 'Accept new key:' label, got:\n%s", msg)
 	}
-	if !strings.Contains(msg, "known_hosts") {
-		t.Errorf("error missing known_hosts reference, got:\n%s", msg)
+	if !strings.Contains(msg, "`mint hostkey reset my-dev-vm`") {
+		t.Errorf("error missing hint-formatted 'mint hostkey reset my-dev-vm', got:\n%s", msg)
 	}
 
 	// The inner runner must not have been called.
@@ -544,8 +588,8 @@ func TestVerifyHostKeyMismatchErrorFormatViaRun(t *testing.T) {
 	runner := NewTOFURemoteRunner(inner.run, store, scanner, vmName)
 
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+	mock := &cmdtest.SendSSHPublicKey{
+		Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 	}
 
 	_, err := runner.Run(ctx, mock, "i-staging", "us-west-2a", "10.0.0.1", 41122, "ubuntu", []string{"ls"})
@@ -573,8 +617,8 @@ func TestVerifyHostKeyMismatchErrorFormatViaRun(t *testing.T) {
 	if !strings.Contains(msg, "`mint recreate`") {
 		t.Errorf("error missing hint-formatted 'mint recreate', got:\n%s", msg)
 	}
-	if !strings.Contains(msg, "known_hosts") {
-		t.Errorf("error missing known_hosts reference, got:\n%s", msg)
+	if !strings.Contains(msg, "`mint hostkey reset staging-vm`") {
+		t.Errorf("error missing hint-formatted 'mint hostkey reset staging-vm', got:\n%s", msg)
 	}
 }
 
@@ -691,8 +735,8 @@ func TestStreamingRemoteRunnerMockInjection(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mock := &mockSendKeyForRemote{
-				output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
+			mock := &cmdtest.SendSSHPublicKey{
+				Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true},
 			}
 
 			out, err := tt.mockRunner(ctx, mock, "i-test123", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"git", "clone", "https://github.com/org/repo.git"}, io.Discard)
@@ -721,8 +765,8 @@ func TestDefaultStreamingRemoteRunnerSendKeyError(t *testing.T) {
 	// When Instance Connect rejects the key, defaultStreamingRemoteRunner should
 	// propagate the error without attempting to run ssh.
 	ctx := context.Background()
-	mock := &mockSendKeyForRemote{
-		err: fmt.Errorf("access denied"),
+	mock := &cmdtest.SendSSHPublicKey{
+		Err: fmt.Errorf("access denied"),
 	}
 
 	_, err := defaultStreamingRemoteRunner(ctx, mock, "i-test123", "us-east-1a", "1.2.3.4", 41122, "ubuntu", []string{"whoami"}, io.Discard)
@@ -732,7 +776,44 @@ func TestDefaultStreamingRemoteRunnerSendKeyError(t *testing.T) {
 	if !strings.Contains(err.Error(), "access denied") {
 		t.Errorf("error %q does not contain 'access denied'", err.Error())
 	}
-	if !mock.called {
+	if !mock.Called {
 		t.Error("SendSSHPublicKey should have been called")
 	}
 }
+
+// --- remoteSSHArgs tests ---
+
+func TestRemoteSSHArgs(t *testing.T) {
+	opts := sshconfig.Options{
+		ExtraArgs:    []string{"-J", "bastion.corp"},
+		IdentityFile: "/home/alice/.ssh/corp",
+	}
+	got := remoteSSHArgs("/tmp/mint-ssh-key-1", "1.2.3.4", 41122, "ubuntu", []string{"uptime"}, true, opts)
+	want := []string{
+		"-i", "/tmp/mint-ssh-key-1",
+		"-p", "41122",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-o", "ForwardAgent=yes",
+		"-i", "/home/alice/.ssh/corp",
+		"-J", "bastion.corp",
+		"ubuntu@1.2.3.4",
+		"uptime",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("remoteSSHArgs() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestShellQuoteArgs(t *testing.T) {
+	if got := shellQuoteArgs(nil); got != "" {
+		t.Errorf("shellQuoteArgs(nil) = %q, want empty", got)
+	}
+	got := shellQuoteArgs([]string{"-o", "ProxyCommand=nc %h 22", "it's"})
+	want := ` '-o' 'ProxyCommand=nc %h 22' 'it'\''s'`
+	if got != want {
+		t.Errorf("shellQuoteArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+	"github.com/SpiceLabsHQ/Mint/internal/statearchive"
+)
+
+// stateDeps holds the injectable dependencies for the export-state and
+// import-state commands.
+type stateDeps struct {
+	layout statearchive.Layout
+	now    func() time.Time
+}
+
+// defaultStateDeps returns the production state locations.
+func defaultStateDeps() *stateDeps {
+	return &stateDeps{
+		layout: statearchive.Layout{ConfigDir: config.DefaultConfigDir(), HistoryPath: history.DefaultPath()},
+		now:    time.Now,
+	}
+}
+
+// newExportStateCommand creates the production export-state command.
+func newExportStateCommand() *cobra.Command {
+	return newExportStateCommandWithDeps(nil)
+}
+
+// newExportStateCommandWithDeps creates the export-state command with
+// explicit dependencies for testing.
+func newExportStateCommandWithDeps(deps *stateDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export-state <file.tar.gz>",
+		Short: "Bundle local mint state for another machine",
+		Long: "Write mint's portable local state to a tar.gz archive for mint import-state " +
+			"on another machine: config.toml, the VM host keys, the user bootstrap hook, " +
+			"devcontainer overrides, and command history.\n\n" +
+			"Caches and provisioning journals are left out. Config values whose keys " +
+			"name a secret, token, or password are replaced with a placeholder and must " +
+			"be re-entered after import.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps == nil {
+				return runExportState(cmd, defaultStateDeps(), args[0])
+			}
+			return runExportState(cmd, deps, args[0])
+		},
+	}
+}
+
+func runExportState(cmd *cobra.Command, deps *stateDeps, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists — choose another file or remove it first", path)
+	}
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	m, err := statearchive.Export(f, deps.layout, version, deps.now())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("export state: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Exported %d files to %s:\n", len(m.Files), path)
+	for _, name := range m.Files {
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+	if len(m.Redacted) > 0 {
+		fmt.Fprintf(w, "Redacted secrets (re-enter after import): %s\n", strings.Join(m.Redacted, ", "))
+	}
+	return nil
+}
+
+// newImportStateCommand creates the production import-state command.
+func newImportStateCommand() *cobra.Command {
+	return newImportStateCommandWithDeps(nil)
+}
+
+// newImportStateCommandWithDeps creates the import-state command with
+// explicit dependencies for testing.
+func newImportStateCommandWithDeps(deps *stateDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-state <file.tar.gz>",
+		Short: "Merge state exported by mint export-state",
+		Long: "Merge an archive written by mint export-state into the local mint state.\n\n" +
+			"Entries only the archive has, such as host keys for VMs this machine has not " +
+			"connected to, are added. History is merged. For each config key, host key, " +
+			"or file that differs, you are asked whether to keep yours or take the " +
+			"archive's; --mine or --theirs answers every conflict at once.\n\n" +
+			"Archives from a newer, incompatible mint are refused.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps == nil {
+				return runImportState(cmd, defaultStateDeps(), args[0])
+			}
+			return runImportState(cmd, deps, args[0])
+		},
+	}
+
+	cmd.Flags().Bool("mine", false, "Keep the local value of every conflicting entry")
+	cmd.Flags().Bool("theirs", false, "Take the archived value of every conflicting entry")
+	cmd.MarkFlagsMutuallyExclusive("mine", "theirs")
+
+	return cmd
+}
+
+func runImportState(cmd *cobra.Command, deps *stateDeps, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	archive, err := statearchive.Read(f)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+	plan, err := archive.Plan(deps.layout)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Importing state exported by mint %s on %s.\n",
+		archive.Manifest.MintVersion, archive.Manifest.CreatedAt.Local().Format("2006-01-02 15:04"))
+	writeImportPlan(w, plan)
+
+	if len(plan.Added) == 0 && len(plan.Conflicts) == 0 && plan.NewHistory == 0 {
+		fmt.Fprintln(w, "Nothing to import: local state already has everything in the archive.")
+		return nil
+	}
+
+	theirs, err := resolveImportConflicts(cmd, plan.Conflicts)
+	if err != nil {
+		return err
+	}
+	if err := archive.Apply(deps.layout, func(c statearchive.Change) bool { return theirs[c.String()] }); err != nil {
+		return fmt.Errorf("import %s: %w", path, err)
+	}
+
+	fmt.Fprintln(w, "State imported.")
+	for _, key := range plan.Redacted {
+		fmt.Fprintf(w, "Re-enter the redacted secret %s: %s\n", key, hint.Cmd("mint config set "+key+" <value>"))
+	}
+	return nil
+}
+
+// writeImportPlan prints what an import adds and may overwrite.
+func writeImportPlan(w io.Writer, plan *statearchive.Plan) {
+	if len(plan.Added) > 0 {
+		fmt.Fprintln(w, "Will add:")
+		for _, c := range plan.Added {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		fmt.Fprintln(w, "Conflicts (local entry would be overwritten):")
+		for _, c := range plan.Conflicts {
+			fmt.Fprintf(w, "  %s\n", describeImportConflict(c))
+		}
+	}
+	if plan.NewHistory > 0 {
+		fmt.Fprintf(w, "Will merge %d history records.\n", plan.NewHistory)
+	}
+}
+
+func describeImportConflict(c statearchive.Change) string {
+	if c.Key == "" {
+		return c.String() + " (contents differ)"
+	}
+	return fmt.Sprintf("%s (mine: %s, theirs: %s)", c, c.Mine, c.Theirs)
+}
+
+// resolveImportConflicts returns, keyed by Change.String, whether to take
+// the archived value of each conflict: from --mine or --theirs, or else by
+// asking on stdin.
+func resolveImportConflicts(cmd *cobra.Command, conflicts []statearchive.Change) (map[string]bool, error) {
+	theirs := make(map[string]bool, len(conflicts))
+	if len(conflicts) == 0 {
+		return theirs, nil
+	}
+	mine, _ := cmd.Flags().GetBool("mine")
+	takeTheirs, _ := cmd.Flags().GetBool("theirs")
+	if mine || takeTheirs {
+		for _, c := range conflicts {
+			theirs[c.String()] = takeTheirs
+		}
+		return theirs, nil
+	}
+
+	w := cmd.OutOrStdout()
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	for _, c := range conflicts {
+		for {
+			fmt.Fprintf(w, "%s — keep [m]ine or take [t]heirs? ", describeImportConflict(c))
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("no answer for %s — import aborted; use %s or %s to resolve every conflict",
+					c, hint.Cmd("--mine"), hint.Cmd("--theirs"))
+			}
+			answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if answer == "m" || answer == "mine" || answer == "t" || answer == "theirs" {
+				theirs[c.String()] = answer == "t" || answer == "theirs"
+				break
+			}
+		}
+	}
+	return theirs, nil
+}
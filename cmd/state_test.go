@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/statearchive"
+)
+
+func newStateTestDeps(t *testing.T, files map[string]string) *stateDeps {
+	t.Helper()
+	dir := t.TempDir()
+	deps := &stateDeps{
+		layout: statearchive.Layout{
+			ConfigDir:   filepath.Join(dir, "config"),
+			HistoryPath: filepath.Join(dir, "state", "history.ndjson"),
+		},
+		now: func() time.Time { return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	for name, content := range files {
+		p := filepath.Join(deps.layout.ConfigDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return deps
+}
+
+func runStateCmd(t *testing.T, cmd string, deps *stateDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot(newExportStateCommandWithDeps(deps), newImportStateCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{cmd}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// exportTestState exports a state with two host keys and a secret.
+func exportTestState(t *testing.T) string {
+	t.Helper()
+	src := newStateTestDeps(t, map[string]string{
+		"config.toml": "region = 'us-west-2'\napi_token = 'tok'\n",
+		"known_hosts": "default=SHA256:new\ndev=SHA256:dev\n",
+	})
+	archive := filepath.Join(t.TempDir(), "state.tar.gz")
+	out, err := runStateCmd(t, "export-state", src, "", archive)
+	if err != nil {
+		t.Fatalf("export-state: %v\n%s", err, out)
+	}
+	for _, want := range []string{"Exported 2 files", "config.toml", "known_hosts", "Redacted secrets (re-enter after import): api_token"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export output missing %q:\n%s", want, out)
+		}
+	}
+	return archive
+}
+
+func TestExportStateRefusesExistingFile(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "state.tar.gz")
+	if err := os.WriteFile(archive, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runStateCmd(t, "export-state", newStateTestDeps(t, nil), "", archive)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("error = %v, want already exists", err)
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "keep" {
+		t.Error("existing file was overwritten")
+	}
+}
+
+func TestImportStateResolvesConflicts(t *testing.T) {
+	hint.IsTTY = false
+	archive := exportTestState(t)
+
+	tests := []struct {
+		name      string
+		args      []string
+		stdin     string
+		wantHosts string
+		wantOut   []string
+	}{
+		{
+			name:      "mine flag",
+			args:      []string{"--mine"},
+			wantHosts: "default=SHA256:old\ndev=SHA256:dev\n",
+		},
+		{
+			name:      "theirs flag",
+			args:      []string{"--theirs"},
+			wantHosts: "default=SHA256:new\ndev=SHA256:dev\n",
+		},
+		{
+			name:      "prompt",
+			stdin:     "x\nt\n",
+			wantHosts: "default=SHA256:new\ndev=SHA256:dev\n",
+			wantOut:   []string{"known_hosts: default (mine: SHA256:old, theirs: SHA256:new) — keep [m]ine or take [t]heirs?"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := newStateTestDeps(t, map[string]string{"known_hosts": "default=SHA256:old\n"})
+			out, err := runStateCmd(t, "import-state", dst, tt.stdin, append([]string{archive}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("import-state: %v\n%s", err, out)
+			}
+			for _, want := range append([]string{
+				"Will add:\n  config.toml: region\n  known_hosts: dev\n",
+				"Conflicts (local entry would be overwritten):\n  known_hosts: default",
+				"State imported.",
+				"Re-enter the redacted secret api_token: `mint config set api_token <value>`",
+			}, tt.wantOut...) {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			data, err := os.ReadFile(filepath.Join(dst.layout.ConfigDir, "known_hosts"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.wantHosts {
+				t.Errorf("known_hosts = %q, want %q", data, tt.wantHosts)
+			}
+		})
+	}
+}
+
+func TestImportStateNoAnswerAborts(t *testing.T) {
+	archive := exportTestState(t)
+	dst := newStateTestDeps(t, map[string]string{"known_hosts": "default=SHA256:old\n"})
+
+	_, err := runStateCmd(t, "import-state", dst, "", archive)
+	if err == nil || !strings.Contains(err.Error(), "import aborted") {
+		t.Fatalf("error = %v, want import aborted", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dst.layout.ConfigDir, "known_hosts"))
+	if string(data) != "default=SHA256:old\n" {
+		t.Errorf("known_hosts changed on abort: %q", data)
+	}
+}
+
+func TestImportStateMineAndTheirsConflict(t *testing.T) {
+	archive := exportTestState(t)
+	_, err := runStateCmd(t, "import-state", newStateTestDeps(t, nil), "", archive, "--mine", "--theirs")
+	if err == nil {
+		t.Fatal("expected an error for --mine with --theirs")
+	}
+}
+
+func TestImportStateNothingToImport(t *testing.T) {
+	archive := exportTestState(t)
+	dst := newStateTestDeps(t, map[string]string{
+		"config.toml": "region = 'us-west-2'\napi_token = 'mine'\n",
+		"known_hosts": "default=SHA256:new\ndev=SHA256:dev\n",
+	})
+	out, err := runStateCmd(t, "import-state", dst, "", archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Nothing to import") {
+		t.Errorf("output = %s", out)
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,7 +15,11 @@ import (
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/identity"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
@@ -24,8 +29,24 @@ type statusDeps struct {
 	describe       mintaws.DescribeInstancesAPI
 	sendKey        mintaws.SendSSHPublicKeyAPI
 	owner          string
+	ownerARN       string
 	remoteRun      RemoteCommandRunner
+	sshPort        int // ssh_port from config; zero means defaultSSHPort
 	versionChecker VersionCheckerFunc
+	// describeStatus reads scheduled events. nil skips the check.
+	describeStatus mintaws.DescribeInstanceStatusAPI
+	// releaseEIPAfterDays is release_eip_after_stopped_days. Zero disables
+	// the Elastic IP release warning.
+	releaseEIPAfterDays int
+	// describeVolumes and metrics feed the --deep volume performance
+	// section. A nil metrics client reports the volumes without data.
+	describeVolumes mintaws.DescribeVolumesAPI
+	metrics         mintaws.GetMetricDataAPI
+	// watch runs the --watch polling loop; its sleep is replaced in tests.
+	watch watchLoop
+	// probeTimeout bounds each VM's SSH probes with --all. Zero uses
+	// statusAllProbeTimeout.
+	probeTimeout time.Duration
 }
 
 // newStatusCommand creates the production status command.
@@ -36,11 +57,20 @@ func newStatusCommand() *cobra.Command {
 // newStatusCommandWithDeps creates the status command with explicit dependencies
 // for testing.
 func newStatusCommandWithDeps(deps *statusDeps) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show VM details",
-		Long:  "Show detailed status of a single VM including state, IP, instance type, and tags.",
-		Args:  cobra.NoArgs,
+		Long: "Show detailed status of a single VM including state, IP, instance type, and tags.\n\n" +
+			"With --watch the status is polled and redrawn until bootstrap completes (exit 0), " +
+			"bootstrap fails (exit 1), or the VM changes state. With --json each poll is written " +
+			"as one line of NDJSON.\n\n" +
+			"With --all every VM you own is shown, one row per VM. The VMs are checked in " +
+			"parallel, and a VM that does not answer over SSH in time is shown without its " +
+			"disk usage. With --json the output is an array of the single-VM objects.\n\n" +
+			"With --metrics the status is printed in the Prometheus text exposition format, " +
+			"one set of samples per VM, labeled with vm and owner. A running VM that does not " +
+			"answer over SSH is reported with mint_vm_reachable 0.",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
 				if deps.versionChecker == nil {
@@ -54,31 +84,115 @@ func newStatusCommandWithDeps(deps *statusDeps) *cobra.Command {
 			}
 			return runStatus(cmd, &statusDeps{
 				describe:       clients.ec2Client,
-				sendKey:        clients.icClient,
+				sendKey:        clients.sendKey,
 				owner:          clients.owner,
-				remoteRun:      defaultRemoteRunner,
+				ownerARN:       clients.ownerARN,
+				remoteRun:      clients.uncheckedRemoteRunner(),
+				sshPort:        clients.sshOptions.Port,
 				versionChecker: defaultVersionChecker(),
+				describeStatus: clients.ec2Client,
+
+				releaseEIPAfterDays: clients.releaseEIPAfterStoppedDays(),
+				describeVolumes:     clients.ec2Client,
+				metrics:             clients.cwClient,
 			})
 		},
 	}
+
+	cmd.Flags().Bool("watch", false, "Poll and redraw until bootstrap completes or fails, or the VM changes state")
+	cmd.Flags().Duration("interval", watchInterval, "Time between polls with --watch")
+	cmd.Flags().Bool("deep", false, "Also report EBS volume performance over the last 15 minutes: IOPS and throughput saturation, and gp2 burst balance")
+	cmd.Flags().Bool("all", false, "Show every VM you own, one row per VM")
+	cmd.Flags().Bool("metrics", false, "Print the status as Prometheus metrics (text exposition format)")
+	cmd.MarkFlagsMutuallyExclusive("all", "watch")
+	cmd.MarkFlagsMutuallyExclusive("metrics", "watch")
+	addOwnerFlag(cmd)
+	addFormatFlag(cmd, "name", "id", "state", "public_ip", "instance_type",
+		"root_volume_gb", "project_volume_gb", "disk_usage_pct", "launch_time", "bootstrap_status")
+
+	return cmd
 }
 
 // statusJSON is the JSON representation of a VM for --json output.
 type statusJSON struct {
-	ID              string            `json:"id"`
-	Name            string            `json:"name"`
-	State           string            `json:"state"`
-	PublicIP        string            `json:"public_ip,omitempty"`
-	InstanceType    string            `json:"instance_type"`
-	RootVolumeGB    int               `json:"root_volume_gb,omitempty"`
-	ProjectVolumeGB int               `json:"project_volume_gb,omitempty"`
-	DiskUsagePct    *int              `json:"disk_usage_pct,omitempty"`
-	LaunchTime      time.Time         `json:"launch_time"`
-	BootstrapStatus string            `json:"bootstrap_status"`
-	Tags            map[string]string `json:"tags,omitempty"`
-	MintVersion     string            `json:"mint_version"`
-	UpdateAvailable bool              `json:"update_available"`
-	LatestVersion   *string           `json:"latest_version"`
+	ID                     string              `json:"id"`
+	Name                   string              `json:"name"`
+	State                  string              `json:"state"`
+	PublicIP               string              `json:"public_ip,omitempty"`
+	IPv6Address            string              `json:"ipv6_address,omitempty"`
+	IPMode                 string              `json:"ip_mode"`
+	Connectivity           string              `json:"connectivity,omitempty"`
+	InstanceType           string              `json:"instance_type"`
+	Spot                   bool                `json:"spot,omitempty"`
+	Hibernated             bool                `json:"hibernated,omitempty"`
+	RootVolumeGB           int                 `json:"root_volume_gb,omitempty"`
+	ProjectVolumeGB        int                 `json:"project_volume_gb,omitempty"`
+	ProjectVolumeEncrypted *bool               `json:"project_volume_encrypted,omitempty"`
+	DiskUsagePct           *int                `json:"disk_usage_pct,omitempty"`
+	Disks                  []diskUsage         `json:"disks,omitempty"`
+	AgentVersion           *int                `json:"agent_version,omitempty"`
+	CLIAgentMin            int                 `json:"cli_agent_version_min"`
+	CLIAgentMax            int                 `json:"cli_agent_version_max"`
+	LaunchTime             time.Time           `json:"launch_time"`
+	BootstrapStatus        string              `json:"bootstrap_status"`
+	UserBootstrap          string              `json:"user_bootstrap_status,omitempty"`
+	Events                 []vm.ScheduledEvent `json:"events"`
+	Tags                   map[string]string   `json:"tags,omitempty"`
+	Owner                  string              `json:"owner"`
+	OwnerARN               string              `json:"owner_arn,omitempty"`
+	OwnerWarning           string              `json:"owner_warning,omitempty"`
+	StoppedDays            *int                `json:"stopped_days,omitempty"`
+	EIPReleaseDue          bool                `json:"eip_release_due,omitempty"`
+	ExpiresAt              string              `json:"expires_at,omitempty"`
+	Expired                bool                `json:"expired,omitempty"`
+	Volumes                []statusVolumeJSON  `json:"volumes,omitempty"`
+	VolumesError           string              `json:"volumes_error,omitempty"`
+	MintVersion            string              `json:"mint_version"`
+	UpdateAvailable        bool                `json:"update_available"`
+	LatestVersion          *string             `json:"latest_version"`
+	Guards                 []session.Guard     `json:"guards,omitempty"`
+}
+
+// statusReport is the data collected by runStatus before rendering. The
+// human, JSON, and plain renderers all consume the same report so collection
+// and presentation stay separate.
+type statusReport struct {
+	VM *vm.VM
+	// DiskUsagePct is the root filesystem's usage, from Disks.
+	DiskUsagePct *int
+	// Disks are the VM's filesystems as the disk probe read them. DisksErr
+	// is set when the probe could not run.
+	Disks    []diskUsage
+	DisksErr error
+	// ProjectVolumeEncrypted is whether the project volume is encrypted;
+	// nil when it could not be read.
+	ProjectVolumeEncrypted *bool
+	// AgentVersion is the VM agent version, read with the disk usage.
+	AgentVersion *int
+	// Guards are the VM's active automation guards.
+	Guards []session.Guard
+	// Events are the VM's pending EC2 scheduled events.
+	Events []vm.ScheduledEvent
+	Owner  statusOwner
+	// StoppedDays is how long a stopped VM has been stopped, when known.
+	StoppedDays *int
+	// EIPReleaseDue is true when mint gc would release the VM's Elastic IP.
+	EIPReleaseDue bool
+	// EIPThresholdDays is release_eip_after_stopped_days.
+	EIPThresholdDays int
+	// Deep is set by --deep. Volumes and VolumesErr are only collected then.
+	Deep       bool
+	Volumes    []volumePerf
+	VolumesErr error
+}
+
+// statusOwner is the caller's identity as status reports it: the raw ARN,
+// the owner it normalizes to, and a warning when the VM was created by a
+// different identity with the same owner.
+type statusOwner struct {
+	Name    string
+	ARN     string
+	Warning string
 }
 
 // runStatus executes the status command logic.
@@ -90,17 +204,42 @@ func runStatus(cmd *cobra.Command, deps *statusDeps) error {
 
 	cliCtx := cli.FromCommand(cmd)
 	vmName := "default"
-	jsonOutput := false
 	if cliCtx != nil {
 		vmName = cliCtx.VM
-		jsonOutput = cliCtx.JSON
+	}
+
+	format, err := resolveOutputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if metricsOutput, _ := cmd.Flags().GetBool("metrics"); metricsOutput {
+		if format != formatHuman {
+			return fmt.Errorf("--metrics cannot be combined with --json or --format")
+		}
+		format = formatMetrics
+	}
+	jsonOutput := format == formatJSON
+
+	// --owner inspects another owner's VM. The caller's ARN says nothing
+	// about that VM, so the owner collision warning is skipped.
+	if owner := ownerOverride(cmd, deps.owner); owner != "" {
+		d := *deps
+		d.owner, d.ownerARN = owner, ""
+		deps = &d
 	}
 
 	w := cmd.OutOrStdout()
 
-	// Show a spinner during the AWS VM lookup. Suppress in JSON mode so
-	// spinner lines do not corrupt machine-readable output.
-	sp := progress.NewCommandSpinner(w, jsonOutput)
+	if watch, _ := cmd.Flags().GetBool("watch"); watch {
+		return runStatusWatch(ctx, cmd, deps, vmName, format)
+	}
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		return runStatusAll(ctx, cmd, deps, format)
+	}
+
+	// Show a spinner during the AWS VM lookup. Suppress in JSON, plain, and metrics
+	// modes so spinner lines do not corrupt machine-readable output.
+	sp := progress.NewCommandSpinner(w, format != formatHuman)
 	sp.Start("Checking VM status...")
 
 	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
@@ -117,6 +256,9 @@ func runStatus(cmd *cobra.Command, deps *statusDeps) error {
 	if found == nil {
 		sp.Fail(fmt.Sprintf("VM %q not found", vmName))
 		msg := fmt.Sprintf("VM %q not found for owner %q", vmName, deps.owner)
+		if other := otherOwnerHint(ctx, deps.describe, deps.owner, vmName); other != "" {
+			msg = other
+		}
 		if jsonOutput {
 			fmt.Fprintf(w, "{\"error\":%q}\n", msg)
 			return silentExitError{}
@@ -127,44 +269,310 @@ func runStatus(cmd *cobra.Command, deps *statusDeps) error {
 	// Stop the spinner before printing any output to prevent interleaving.
 	sp.Stop("")
 
+	return renderStatus(w, format, collectStatusReport(ctx, cmd, deps, found), deps.versionChecker)
+}
+
+// collectStatusReport gathers everything status reports about found beyond
+// the instance itself. Each lookup is best effort.
+func collectStatusReport(ctx context.Context, cmd *cobra.Command, deps *statusDeps, found *vm.VM) *statusReport {
+	report := &statusReport{
+		VM: found,
+		Owner: statusOwner{
+			Name:    deps.owner,
+			ARN:     deps.ownerARN,
+			Warning: ownerCollisionWarning(found, deps.ownerARN),
+		},
+		EIPThresholdDays: deps.releaseEIPAfterDays,
+	}
+	now := time.Now()
+	report.StoppedDays = stoppedDays(found, now)
+	report.EIPReleaseDue = eipReleaseDue(found, deps.releaseEIPAfterDays, now)
+
 	// Fetch disk usage when VM is running and SSH deps are available.
-	var diskUsagePct *int
 	if found.State == string(ec2types.InstanceStateNameRunning) && deps.remoteRun != nil && deps.sendKey != nil {
-		diskUsagePct = fetchDiskUsage(ctx, deps, found)
+		report.Disks, report.DisksErr = fetchDisks(ctx, deps, found)
+		report.DiskUsagePct = rootDiskUsagePct(report.Disks)
+		if v, err := agentVersionOf(ctx, deps.remoteRun, deps.sendKey, found.ID, found.AvailabilityZone,
+			found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser); err == nil {
+			report.AgentVersion = &v
+		}
+		report.Guards = fetchGuards(ctx, deps, found)
+	}
+
+	// Scheduled events are best effort: a failed lookup must not hide the
+	// rest of the status.
+	if deps.describeStatus != nil {
+		if events, err := vm.ScheduledEvents(ctx, deps.describeStatus, []string{found.ID}); err == nil {
+			report.Events = events[found.ID]
+		}
 	}
 
-	if jsonOutput {
-		return writeStatusJSON(w, found, diskUsagePct, deps.versionChecker)
+	if deps.describeVolumes != nil {
+		if encrypted, err := projectVolumeEncrypted(ctx, deps.describeVolumes, deps.owner, found.Name); err == nil {
+			report.ProjectVolumeEncrypted = encrypted
+		}
 	}
 
-	writeStatusHuman(w, found, diskUsagePct)
-	appendVersionNotice(w)
-	return nil
+	if deep, _ := cmd.Flags().GetBool("deep"); deep && deps.describeVolumes != nil {
+		report.Deep = true
+		report.Volumes, report.VolumesErr = fetchVolumePerf(ctx, deps.describeVolumes, deps.metrics, found.ID, now)
+	}
+
+	return report
 }
 
-// fetchDiskUsage retrieves the root volume disk usage percentage via SSH.
-// Returns nil if the SSH command fails (graceful degradation).
-func fetchDiskUsage(ctx context.Context, deps *statusDeps, v *vm.VM) *int {
-	dfCmd := []string{"df", "--output=pcent", "/"}
-	output, err := deps.remoteRun(
-		ctx,
-		deps.sendKey,
-		v.ID,
-		v.AvailabilityZone,
-		v.PublicIP,
-		defaultSSHPort,
-		defaultSSHUser,
-		dfCmd,
-	)
-	if err != nil {
+// runStatusWatch polls the VM every --interval and renders each poll until
+// bootstrap completes or fails, the VM changes state, or Ctrl-C. Human
+// output is redrawn in place on a terminal; JSON output is one NDJSON line
+// per poll.
+func runStatusWatch(ctx context.Context, cmd *cobra.Command, deps *statusDeps, vmName string, format outputFormat) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive, got %s", interval)
+	}
+	loop := deps.watch
+	loop.interval = interval
+	if format != formatHuman {
+		loop.clear = func(io.Writer) bool { return false }
+	}
+
+	w := cmd.OutOrStdout()
+	lastState := ""
+	render := func(ctx context.Context) error {
+		found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+		if err == nil && found == nil {
+			err = fmt.Errorf("VM %q not found for owner %q", vmName, deps.owner)
+		} else if err != nil {
+			err = fmt.Errorf("finding VM: %w", err)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if format == formatJSON {
+				fmt.Fprintf(w, "{\"error\":%q}\n", err.Error())
+				return silentExitError{}
+			}
+			return err
+		}
+
+		report := collectStatusReport(ctx, cmd, deps, found)
+		if format == formatJSON {
+			if err := writeStatusNDJSON(w, report, deps.versionChecker); err != nil {
+				return err
+			}
+		} else if err := renderStatus(w, format, report, deps.versionChecker); err != nil {
+			return err
+		}
+
+		prevState := lastState
+		lastState = found.State
+		switch found.BootstrapStatus {
+		case tags.BootstrapComplete:
+			statusWatchNote(w, format, fmt.Sprintf("Bootstrap complete on VM %q.", vmName))
+			return errWatchDone
+		case tags.BootstrapFailed:
+			if format == formatJSON {
+				return silentExitError{}
+			}
+			return fmt.Errorf("bootstrap failed on VM %q — run %s for details", vmName, hint.Cmd("mint doctor"))
+		}
+		if stateChangeEndsWatch(prevState, found.State) {
+			if !isActiveInstanceState(found.State) {
+				if format == formatJSON {
+					return silentExitError{}
+				}
+				return fmt.Errorf("VM %q is now %s — bootstrap did not complete", vmName, found.State)
+			}
+			statusWatchNote(w, format, fmt.Sprintf("VM %q is now %s.", vmName, found.State))
+			return errWatchDone
+		}
+		statusWatchNote(w, format, fmt.Sprintf("Refreshing every %s — press Ctrl-C to stop.", interval))
 		return nil
 	}
 
-	pct, err := parseDiskUsagePct(string(output))
+	return loop.run(ctx, w, render)
+}
+
+// stateChangeEndsWatch reports whether the VM moving from prev to cur ends
+// a status watch. The first poll has no prev, and pending to running is the
+// normal start of a new VM.
+func stateChangeEndsWatch(prev, cur string) bool {
+	if prev == "" || prev == cur {
+		return false
+	}
+	return !(prev == string(ec2types.InstanceStateNamePending) && cur == string(ec2types.InstanceStateNameRunning))
+}
+
+// isActiveInstanceState reports whether an instance in state is starting
+// or running, so bootstrap may still complete.
+func isActiveInstanceState(state string) bool {
+	return state == string(ec2types.InstanceStateNamePending) || state == string(ec2types.InstanceStateNameRunning)
+}
+
+// statusWatchNote prints a line under a human status frame. Machine-readable
+// formats get nothing so each poll stays parseable.
+func statusWatchNote(w io.Writer, format outputFormat, note string) {
+	if format == formatHuman {
+		fmt.Fprintf(w, "\n%s\n", note)
+	}
+}
+
+// writeStatusNDJSON writes the status JSON object on a single line.
+func writeStatusNDJSON(w io.Writer, report *statusReport, checker VersionCheckerFunc) error {
+	var buf bytes.Buffer
+	if err := writeStatusJSON(&buf, report, checker); err != nil {
+		return err
+	}
+	var line bytes.Buffer
+	if err := json.Compact(&line, buf.Bytes()); err != nil {
+		return err
+	}
+	line.WriteByte('\n')
+	_, err := w.Write(line.Bytes())
+	return err
+}
+
+// renderStatus writes a collected statusReport in the requested format.
+func renderStatus(w io.Writer, format outputFormat, report *statusReport, checker VersionCheckerFunc) error {
+	switch format {
+	case formatJSON:
+		return writeStatusJSON(w, report, checker)
+	case formatPlain:
+		writeStatusPlain(w, report)
+		return nil
+	case formatMetrics:
+		return writeStatusMetrics(w, []*statusReport{report}, time.Now())
+	default:
+		writeStatusHuman(w, report.VM, report.ProjectVolumeEncrypted, report.Disks, report.DisksErr, report.AgentVersion, report.Events)
+		if report.Deep {
+			writeVolumePerfHuman(w, report.Volumes, report.VolumesErr)
+		}
+		if len(report.Guards) > 0 {
+			fmt.Fprintln(w, "\nAutomation guards (block recreate, down, and destroy):")
+			for _, g := range report.Guards {
+				fmt.Fprintf(w, "  %s\n", g)
+			}
+		}
+		if report.Owner.Warning != "" {
+			fmt.Fprintf(w, "\nWarning: %s\n", report.Owner.Warning)
+		}
+		if report.EIPReleaseDue {
+			fmt.Fprintf(w, "\nWarning: VM has been stopped %s, past release_eip_after_stopped_days (%d); its Elastic IP is still billed — run %s to release it\n",
+				formatDays(*report.StoppedDays), report.EIPThresholdDays, hint.Cmd("mint gc --apply"))
+		}
+		appendVersionNotice(w)
+		return nil
+	}
+}
+
+// ownerCollisionWarning returns a warning when v carries the caller's
+// mint:owner but its mint:owner-arn is a different principal: two identities
+// normalize to the same owner and see each other's VMs (ADR-0013). It
+// returns "" when the VM has no owner ARN tag or the caller ARN is unknown.
+func ownerCollisionWarning(v *vm.VM, ownerARN string) string {
+	taggedARN := v.Tags[tags.TagOwnerARN]
+	if taggedARN == "" || ownerARN == "" || identity.SamePrincipal(taggedARN, ownerARN) {
+		return ""
+	}
+	return fmt.Sprintf("VM %q was created by %s, not by you (%s); both identities normalize to owner %q, so mint shows each of you the other's VMs",
+		v.Name, taggedARN, ownerARN, v.Tags[tags.TagOwner])
+}
+
+// diskFullPct is the usage over which status flags a filesystem as near
+// full.
+const diskFullPct = 85
+
+// diskProbeScript reports the usage of the root filesystem, the project
+// volume, and any extra volumes under /mint/volumes. Only paths that are
+// mount points are passed to df, so an unmounted project volume is not
+// reported as the root filesystem twice.
+const diskProbeScript = `set -- /
+for d in /mint/projects /mint/volumes/*; do
+  mountpoint -q "$d" && set -- "$@" "$d"
+done
+df --output=target,pcent,avail -B G "$@"`
+
+// diskUsage is one mounted filesystem on the VM.
+type diskUsage struct {
+	Mount       string `json:"mount"`
+	PercentUsed int    `json:"percent_used"`
+	AvailableGB int    `json:"available_gb"`
+}
+
+// fetchDisks reads the usage of the VM's filesystems via SSH. The error is
+// set when the probe could not run; unparseable output returns no disks
+// and no error.
+func fetchDisks(ctx context.Context, deps *statusDeps, v *vm.VM) ([]diskUsage, error) {
+	output, err := deps.remoteRun(ctx, deps.sendKey, v.ID, v.AvailabilityZone, v.PublicIP,
+		sshPortOrDefault(deps.sshPort), defaultSSHUser, []string{"sh", "-c", shellQuote(diskProbeScript)})
+	if err != nil {
+		return nil, err
+	}
+	disks, err := parseDiskUsages(string(output))
+	if err != nil {
+		return nil, nil
+	}
+	return disks, nil
+}
+
+// parseDiskUsages parses df --output=target,pcent,avail -B G output.
+// Expected format:
+//
+//	Mounted on     Use% Avail
+//	/               42%   38G
+//	/mint/projects  91%    4G
+func parseDiskUsages(output string) ([]diskUsage, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("unexpected df output: %q", output)
+	}
+	disks := make([]diskUsage, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("unexpected df line: %q", line)
+		}
+		n := len(fields)
+		pct, err := strconv.Atoi(strings.TrimSuffix(fields[n-2], "%"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing disk usage percentage: %w", err)
+		}
+		avail, err := strconv.Atoi(strings.TrimSuffix(fields[n-1], "G"))
+		if err != nil {
+			return nil, fmt.Errorf("parsing available space: %w", err)
+		}
+		disks = append(disks, diskUsage{
+			Mount:       strings.Join(fields[:n-2], " "),
+			PercentUsed: pct,
+			AvailableGB: avail,
+		})
+	}
+	return disks, nil
+}
+
+// rootDiskUsagePct returns the usage of the root filesystem in disks, or
+// nil when it is not there.
+func rootDiskUsagePct(disks []diskUsage) *int {
+	for _, d := range disks {
+		if d.Mount == "/" {
+			pct := d.PercentUsed
+			return &pct
+		}
+	}
+	return nil
+}
+
+// fetchGuards reads the VM's active automation guards via SSH. Returns nil
+// if the guards cannot be read (graceful degradation).
+func fetchGuards(ctx context.Context, deps *statusDeps, v *vm.VM) []session.Guard {
+	guards, _, err := session.DetectGuards(ctx, func(ctx context.Context, command []string) ([]byte, error) {
+		return deps.remoteRun(ctx, deps.sendKey, v.ID, v.AvailabilityZone, v.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, command)
+	})
 	if err != nil {
 		return nil
 	}
-	return &pct
+	return guards
 }
 
 // parseDiskUsagePct extracts the percentage value from df --output=pcent output.
@@ -188,37 +596,161 @@ func parseDiskUsagePct(output string) (int, error) {
 }
 
 // writeStatusJSON outputs a single VM as a JSON object.
-func writeStatusJSON(w io.Writer, v *vm.VM, diskUsagePct *int, checker VersionCheckerFunc) error {
+func writeStatusJSON(w io.Writer, report *statusReport, checker VersionCheckerFunc) error {
 	updateAvailable := false
 	var latestVersion *string
 	if checker != nil {
 		updateAvailable, latestVersion = checker()
 	}
 
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(statusJSONOf(report, updateAvailable, latestVersion))
+}
+
+// statusJSONOf builds the JSON object for a collected statusReport.
+func statusJSONOf(report *statusReport, updateAvailable bool, latestVersion *string) statusJSON {
+	v, events, owner := report.VM, report.Events, report.Owner
+
+	connectivity := ""
+	if v.State == string(ec2types.InstanceStateNameRunning) {
+		connectivity = "direct"
+		if v.PublicIP == "" {
+			connectivity = "instance-connect-endpoint"
+		}
+	}
+
+	if events == nil {
+		events = []vm.ScheduledEvent{}
+	}
+
 	obj := statusJSON{
-		ID:              v.ID,
-		Name:            v.Name,
-		State:           v.State,
-		PublicIP:        v.PublicIP,
-		InstanceType:    v.InstanceType,
-		RootVolumeGB:    v.RootVolumeGB,
-		ProjectVolumeGB: v.ProjectVolumeGB,
-		DiskUsagePct:    diskUsagePct,
-		LaunchTime:      v.LaunchTime,
-		BootstrapStatus: v.BootstrapStatus,
-		Tags:            v.Tags,
-		MintVersion:     version,
-		UpdateAvailable: updateAvailable,
-		LatestVersion:   latestVersion,
+		ID:                     v.ID,
+		Name:                   v.Name,
+		State:                  v.State,
+		PublicIP:               v.PublicIP,
+		IPv6Address:            v.IPv6Address,
+		IPMode:                 v.IPMode,
+		Connectivity:           connectivity,
+		InstanceType:           v.InstanceType,
+		Spot:                   v.Spot,
+		Hibernated:             v.Hibernated,
+		RootVolumeGB:           v.RootVolumeGB,
+		ProjectVolumeGB:        v.ProjectVolumeGB,
+		ProjectVolumeEncrypted: report.ProjectVolumeEncrypted,
+		DiskUsagePct:           report.DiskUsagePct,
+		Disks:                  report.Disks,
+		AgentVersion:           report.AgentVersion,
+		CLIAgentMin:            agentVersionMin,
+		CLIAgentMax:            agentVersionMax,
+		LaunchTime:             v.LaunchTime,
+		BootstrapStatus:        v.BootstrapStatus,
+		UserBootstrap:          v.UserBootstrapStatus,
+		Events:                 events,
+		Tags:                   v.Tags,
+		Owner:                  owner.Name,
+		OwnerARN:               owner.ARN,
+		OwnerWarning:           owner.Warning,
+		StoppedDays:            report.StoppedDays,
+		EIPReleaseDue:          report.EIPReleaseDue,
+		MintVersion:            version,
+		UpdateAvailable:        updateAvailable,
+		LatestVersion:          latestVersion,
+		Guards:                 report.Guards,
 	}
 
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	return enc.Encode(obj)
+	if value := v.Tags[tags.TagExpires]; value != "" {
+		obj.ExpiresAt = value
+		if expires, err := tags.ParseExpires(value); err == nil {
+			obj.Expired = tags.Expired(expires, time.Now())
+		}
+	}
+
+	if report.Deep {
+		if report.VolumesErr != nil {
+			obj.VolumesError = report.VolumesErr.Error()
+		} else {
+			obj.Volumes = volumePerfJSON(report.Volumes)
+		}
+	}
+	return obj
+}
+
+// writeStatusPlain outputs a single VM as one tab-separated line. The column
+// order is documented in the command help and must not change.
+func writeStatusPlain(w io.Writer, report *statusReport) {
+	v := report.VM
+	disk := ""
+	if report.DiskUsagePct != nil {
+		disk = strconv.Itoa(*report.DiskUsagePct)
+	}
+	launched := ""
+	if !v.LaunchTime.IsZero() {
+		launched = v.LaunchTime.UTC().Format(time.RFC3339)
+	}
+	writePlainRow(w,
+		v.Name,
+		v.ID,
+		v.State,
+		v.PublicIP,
+		v.InstanceType,
+		plainInt(v.RootVolumeGB),
+		plainInt(v.ProjectVolumeGB),
+		disk,
+		launched,
+		v.BootstrapStatus,
+	)
+}
+
+// formatUserBootstrapStatus renders a raw mint:user-bootstrap tag value for
+// human output, falling back to the raw value when it cannot be parsed.
+func formatUserBootstrapStatus(value string) string {
+	status, err := tags.ParseUserBootstrapStatus(value)
+	if err != nil {
+		return value
+	}
+	if status.Failed {
+		return fmt.Sprintf("FAILED (exit %d)", status.ExitCode)
+	}
+	return status.String()
+}
+
+// plainInt renders a positive integer for plain output; zero (unknown)
+// renders as an empty field.
+func plainInt(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// writeDisksHuman writes the Disk lines, one per filesystem. The root
+// filesystem holds Docker's data and is flagged from 80% with a hint to
+// prune; any other volume over diskFullPct is flagged with a hint to grow
+// it.
+func writeDisksHuman(w io.Writer, disks []diskUsage) {
+	width := 0
+	for _, d := range disks {
+		width = max(width, len(d.Mount))
+	}
+	for i, d := range disks {
+		label := "Disk:"
+		if i > 0 {
+			label = ""
+		}
+		line := fmt.Sprintf("%-10s %-*s %3d%%  %d GB available", label, width, d.Mount, d.PercentUsed, d.AvailableGB)
+		switch {
+		case d.Mount == "/" && d.PercentUsed >= 80:
+			line += fmt.Sprintf(" [WARN] — run %s to reclaim Docker space", hint.Cmd("mint prune"))
+		case d.Mount != "/" && d.PercentUsed > diskFullPct:
+			line += fmt.Sprintf(" [WARN] — run %s to add space", hint.Cmd("mint volume grow --size <GB>"))
+		}
+		fmt.Fprintln(w, line)
+	}
 }
 
 // writeStatusHuman outputs a single VM in human-readable format.
-func writeStatusHuman(w io.Writer, v *vm.VM, diskUsagePct *int) {
+func writeStatusHuman(w io.Writer, v *vm.VM, projectEncrypted *bool, disks []diskUsage, disksErr error, agentVersion *int, events []vm.ScheduledEvent) {
 	bootstrap := v.BootstrapStatus
 	if bootstrap == tags.BootstrapFailed {
 		bootstrap = "FAILED"
@@ -227,30 +759,82 @@ func writeStatusHuman(w io.Writer, v *vm.VM, diskUsagePct *int) {
 	ip := v.PublicIP
 	if ip == "" {
 		ip = "-"
+		if v.State == string(ec2types.InstanceStateNameRunning) {
+			ip = "- (" + vmConnectivity(v) + ")"
+		} else if v.Tags[tags.TagEIP] == tags.EIPReleasedByGC {
+			ip = "- (Elastic IP released by mint gc; " + hint.Cmd("mint up") + " allocates a new one)"
+		}
+	} else if v.IPMode == tags.IPModeIPv6Only {
+		ip += " (ipv6-only)"
 	}
 
-	fmt.Fprintf(w, "VM:        %s\n", v.Name)
+	if summary := provisionedSummary(v.Tags, time.Now()); summary != "" {
+		fmt.Fprintf(w, "VM:        %s — %s\n", v.Name, summary)
+	} else {
+		fmt.Fprintf(w, "VM:        %s\n", v.Name)
+	}
 	fmt.Fprintf(w, "ID:        %s\n", v.ID)
-	fmt.Fprintf(w, "State:     %s\n", v.State)
+	fmt.Fprintf(w, "State:     %s\n", v.DisplayState())
 	fmt.Fprintf(w, "IP:        %s\n", ip)
-	fmt.Fprintf(w, "Type:      %s\n", v.InstanceType)
+	if v.IPv6Address != "" && v.IPv6Address != v.PublicIP {
+		fmt.Fprintf(w, "IPv6:      %s\n", v.IPv6Address)
+	}
+	if v.Spot {
+		fmt.Fprintf(w, "Type:      %s (spot)\n", v.InstanceType)
+	} else {
+		fmt.Fprintf(w, "Type:      %s\n", v.InstanceType)
+	}
 	if v.RootVolumeGB > 0 {
-		fmt.Fprintf(w, "Root Vol:  %d GB\n", v.RootVolumeGB)
+		fmt.Fprintf(w, "Root Vol:  %s\n", format.FormatGiB(v.RootVolumeGB))
 	}
 	if v.ProjectVolumeGB > 0 {
-		fmt.Fprintf(w, "Proj Vol:  %d GB\n", v.ProjectVolumeGB)
+		switch {
+		case projectEncrypted == nil:
+			fmt.Fprintf(w, "Proj Vol:  %s\n", format.FormatGiB(v.ProjectVolumeGB))
+		case *projectEncrypted:
+			fmt.Fprintf(w, "Proj Vol:  %s (encrypted)\n", format.FormatGiB(v.ProjectVolumeGB))
+		default:
+			fmt.Fprintf(w, "Proj Vol:  %s (not encrypted)\n", format.FormatGiB(v.ProjectVolumeGB))
+		}
 	}
-	if diskUsagePct != nil {
-		if *diskUsagePct >= 80 {
-			fmt.Fprintf(w, "Disk:      %d%% [WARN]\n", *diskUsagePct)
+	if len(disks) > 0 {
+		writeDisksHuman(w, disks)
+	} else if v.State == string(ec2types.InstanceStateNameRunning) {
+		if disksErr != nil {
+			fmt.Fprintf(w, "Disk:      unavailable (VM not reachable over SSH)\n")
 		} else {
-			fmt.Fprintf(w, "Disk:      %d%%\n", *diskUsagePct)
+			fmt.Fprintf(w, "Disk:      unknown\n")
 		}
-	} else if v.State == string(ec2types.InstanceStateNameRunning) {
-		fmt.Fprintf(w, "Disk:      unknown\n")
+	} else if v.State == string(ec2types.InstanceStateNameStopped) {
+		// Disk usage comes over SSH; say why it is missing rather than
+		// leaving a blank.
+		fmt.Fprintf(w, "Disk:      (VM stopped — start with %s for live data)\n", hint.Cmd("mint up"))
 	}
 	fmt.Fprintf(w, "Launched:  %s\n", v.LaunchTime.Format(time.RFC3339))
+	if value := v.Tags[tags.TagExpires]; value != "" {
+		now := time.Now()
+		line := formatExpiry(value, now)
+		if expires, err := tags.ParseExpires(value); err == nil && tags.Expired(expires, now) {
+			line += " — run " + hint.Cmd(destroyCommand(v.Name)) + " to remove it"
+		}
+		fmt.Fprintf(w, "Expires:   %s\n", line)
+	}
 	fmt.Fprintf(w, "Bootstrap: %s\n", bootstrap)
+	if v.UserBootstrapStatus != "" {
+		fmt.Fprintf(w, "User hook: %s\n", formatUserBootstrapStatus(v.UserBootstrapStatus))
+	}
+	if agentVersion != nil {
+		fmt.Fprintf(w, "Agent:     %s\n", agentVersionSummary(*agentVersion))
+	}
+	if len(events) > 0 {
+		fmt.Fprintln(w, "\nScheduled events:")
+		for _, ev := range events {
+			fmt.Fprintf(w, "  ⚠  %s\n", eventWarning(ev))
+			if ev.Description != "" {
+				fmt.Fprintf(w, "     %s\n", ev.Description)
+			}
+		}
+	}
 
 	if len(v.Tags) > 0 {
 		fmt.Fprintln(w, "\nTags:")
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/session"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// statusAllConcurrency is how many VMs status --all probes over SSH at once.
+const statusAllConcurrency = 4
+
+// statusAllProbeTimeout bounds the SSH probes of one VM with --all, so a VM
+// that accepts connections but never answers does not stall the others.
+const statusAllProbeTimeout = 20 * time.Second
+
+// statusAllRow is one VM of status --all: its report and, for running VMs,
+// the ADR-0018 session checks read alongside it. sessions is nil when the
+// VM is not running or the checks failed, so its idle state is unknown.
+type statusAllRow struct {
+	report   *statusReport
+	sessions *session.ActiveSessions
+}
+
+// runStatusAll reports every VM of the owner, one row per VM. Each VM's
+// probes run in parallel under their own timeout.
+func runStatusAll(ctx context.Context, cmd *cobra.Command, deps *statusDeps, format outputFormat) error {
+	w := cmd.OutOrStdout()
+	jsonOutput := format == formatJSON
+
+	sp := progress.NewCommandSpinner(w, format != formatHuman)
+	sp.Start("Checking VMs...")
+
+	vms, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+	if err != nil {
+		sp.Fail(err.Error())
+		msg := fmt.Sprintf("listing VMs: %v", err)
+		if jsonOutput {
+			fmt.Fprintf(w, "{\"error\":%q}\n", msg)
+			return silentExitError{}
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	sortVMsByName(vms)
+
+	timeout := deps.probeTimeout
+	if timeout <= 0 {
+		timeout = statusAllProbeTimeout
+	}
+	rows := make([]statusAllRow, len(vms))
+	provision.RunBounded(len(vms), statusAllConcurrency, func(i int) {
+		probeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		rows[i] = collectStatusAllRow(probeCtx, cmd, deps, vms[i])
+	})
+
+	sp.Stop("")
+
+	switch format {
+	case formatJSON:
+		return writeStatusAllJSON(w, rows, deps.versionChecker)
+	case formatPlain:
+		for _, row := range rows {
+			writeStatusPlain(w, row.report)
+		}
+		return nil
+	case formatMetrics:
+		reports := make([]*statusReport, 0, len(rows))
+		for _, row := range rows {
+			reports = append(reports, row.report)
+		}
+		return writeStatusMetrics(w, reports, time.Now())
+	default:
+		writeStatusAllTable(w, rows)
+		appendVersionNotice(w)
+		return nil
+	}
+}
+
+// sortVMsByName orders vms by their mint:vm name, then instance ID, so rows
+// of the same VM sit together.
+func sortVMsByName(vms []*vm.VM) {
+	sort.SliceStable(vms, func(i, j int) bool {
+		if vms[i].Name != vms[j].Name {
+			return vms[i].Name < vms[j].Name
+		}
+		return vms[i].ID < vms[j].ID
+	})
+}
+
+// collectStatusAllRow collects the status report of v and, when it is
+// running, the session checks mint down and the idle monitor rely on. A
+// failed check leaves sessions nil rather than guessing.
+func collectStatusAllRow(ctx context.Context, cmd *cobra.Command, deps *statusDeps, v *vm.VM) statusAllRow {
+	row := statusAllRow{report: collectStatusReport(ctx, cmd, deps, v)}
+	if v.State == string(ec2types.InstanceStateNameRunning) && deps.remoteRun != nil && deps.sendKey != nil && ctx.Err() == nil {
+		if sessions, err := detectVMSessions(ctx, deps.remoteRun, deps.sendKey, v, sshPortOrDefault(deps.sshPort)); err == nil {
+			row.sessions = sessions
+		}
+	}
+	return row
+}
+
+// writeStatusAllJSON outputs the VMs as a JSON array of the single-VM
+// status objects.
+func writeStatusAllJSON(w io.Writer, rows []statusAllRow, checker VersionCheckerFunc) error {
+	updateAvailable := false
+	var latestVersion *string
+	if checker != nil {
+		updateAvailable, latestVersion = checker()
+	}
+
+	items := make([]statusJSON, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, statusJSONOf(row.report, updateAvailable, latestVersion))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// writeStatusAllTable outputs the VMs as a table with one row per VM.
+func writeStatusAllTable(w io.Writer, rows []statusAllRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No VMs found.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tTYPE\tIP\tBOOTSTRAP\tUPTIME\tDISK\tIDLE")
+
+	for _, row := range rows {
+		v := row.report.VM
+		running := v.State == string(ec2types.InstanceStateNameRunning)
+
+		bootstrap := v.BootstrapStatus
+		if bootstrap == tags.BootstrapFailed {
+			bootstrap = "FAILED"
+		}
+
+		ip := v.PublicIP
+		if ip == "" {
+			ip = "-"
+		}
+
+		uptime := "-"
+		if running {
+			uptime = formatUptime(v.LaunchTime)
+		}
+
+		disk := "-"
+		if row.report.DiskUsagePct != nil {
+			disk = fmt.Sprintf("%d%%", *row.report.DiskUsagePct)
+			if *row.report.DiskUsagePct >= 80 {
+				disk += " [WARN]"
+			}
+		} else if running {
+			disk = "unknown"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.Name, v.DisplayState(), v.InstanceType, ip, bootstrap, uptime, disk, idleColumn(row.sessions))
+	}
+
+	tw.Flush()
+
+	for _, row := range rows {
+		if row.report.Owner.Warning != "" {
+			fmt.Fprintf(w, "\nWarning: %s\n", row.report.Owner.Warning)
+		}
+	}
+}
+
+// idleColumn returns the IDLE cell for a VM's session checks: its manual
+// extension, "active" when any other criterion saw activity, "idle" when
+// none did, and "-" when the checks did not run.
+func idleColumn(sessions *session.ActiveSessions) string {
+	switch {
+	case sessions == nil:
+		return "-"
+	case sessions.ExtendedUntil != nil:
+		return "extended until " + sessions.ExtendedUntil.Local().Format("15:04")
+	case sessions.HasActivity():
+		return "active"
+	default:
+		return "idle"
+	}
+}
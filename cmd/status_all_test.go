@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// threeVMs returns a running default, a stopped gpu, and a running scratch
+// VM, deliberately out of name order.
+func threeVMs() *ec2.DescribeInstancesOutput {
+	launched := time.Now().Add(-3 * time.Hour)
+	var instances []ec2types.Instance
+	for _, out := range []*ec2.DescribeInstancesOutput{
+		makeInstanceWithTime("i-scratch", "scratch", "alice", "running", "3.3.3.3", "t3.large", "complete", launched),
+		makeInstanceWithTime("i-gpu", "gpu", "alice", "stopped", "", "g5.xlarge", "complete", launched),
+		makeInstanceWithTime("i-default", "default", "alice", "running", "1.1.1.1", "m6i.xlarge", "complete", launched),
+	} {
+		instances = append(instances, out.Reservations[0].Instances...)
+	}
+	return makeMultiInstanceOutput(instances...)
+}
+
+// statusAllRemote answers df with per-host disk usage, the session checks
+// with no tmux server and nobody logged in, and the extend file with
+// extendedUntil on 1.1.1.1. Hosts in hang block until their context is
+// done.
+func statusAllRemote(extendedUntil time.Time, hang map[string]bool) RemoteCommandRunner {
+	disk := map[string]string{"1.1.1.1": "42", "3.3.3.3": "91"}
+	return func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		if hang[host] {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		switch {
+		case command[0] == "sh" && strings.Contains(command[2], "df --output"):
+			return []byte("Mounted on Use% Avail\n/ " + disk[host] + "% 10G\n"), nil
+		case command[0] == "tmux":
+			return nil, fmt.Errorf("no server running on /tmp/tmux-1000/default")
+		case command[0] == "who":
+			return nil, nil
+		case command[0] == "cat" && host == "1.1.1.1":
+			return []byte(fmt.Sprintf("%d\n", extendedUntil.Unix())), nil
+		}
+		return nil, fmt.Errorf("exit status 1")
+	}
+}
+
+func runStatusAllForTest(t *testing.T, deps *statusDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if deps.versionChecker == nil {
+		deps.versionChecker = func() (bool, *string) { return false, nil }
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"status", "--all"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestStatusAllTable(t *testing.T) {
+	hint.IsTTY = false
+	extendedUntil := time.Now().Add(90 * time.Minute)
+	describe := &cmdtest.DescribeInstances{Output: threeVMs()}
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe:  describe,
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:     "alice",
+		remoteRun: statusAllRemote(extendedUntil, nil),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, f := range describe.Input.Filters {
+		if aws.ToString(f.Name) == "tag:mint:vm" {
+			t.Errorf("--all should not filter by mint:vm, filters = %+v", describe.Input.Filters)
+		}
+	}
+
+	// Skip the spinner's progress line.
+	table := output[strings.Index(output, "NAME"):]
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("want header and 3 rows, got:\n%s", output)
+	}
+	for i, want := range [][]string{
+		{"NAME", "STATE", "TYPE", "IP", "BOOTSTRAP", "UPTIME", "DISK", "IDLE"},
+		{"default", "running", "m6i.xlarge", "1.1.1.1", "complete", "3h", "42%", "extended until " + extendedUntil.Format("15:04")},
+		{"gpu", "stopped", "g5.xlarge"},
+		{"scratch", "running", "3.3.3.3", "91% [WARN]", "idle"},
+	} {
+		for _, field := range want {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("line %d missing %q: %q", i, field, lines[i])
+			}
+		}
+	}
+}
+
+func TestStatusAllIdleColumnShowsSessionActivity(t *testing.T) {
+	hint.IsTTY = false
+	base := statusAllRemote(time.Now().Add(time.Hour), nil)
+	remote := func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		if command[0] == "who" && host == "3.3.3.3" {
+			return []byte("ubuntu   pts/0        2026-10-15 09:12 (203.0.113.7)\n"), nil
+		}
+		return base(ctx, sendKey, instanceID, az, host, port, user, command)
+	}
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe:  &cmdtest.DescribeInstances{Output: threeVMs()},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:     "alice",
+		remoteRun: remote,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"gpu": " -", "scratch": " active"}
+	for _, line := range strings.Split(output, "\n") {
+		name, _, _ := strings.Cut(line, " ")
+		if suffix, ok := want[name]; ok {
+			if !strings.HasSuffix(strings.TrimSpace(line), suffix) {
+				t.Errorf("%s IDLE should end with %q: %q", name, suffix, line)
+			}
+			delete(want, name)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("rows missing from output:\n%s", output)
+	}
+}
+
+func TestStatusAllJSONIsArrayOfStatusObjects(t *testing.T) {
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe:  &cmdtest.DescribeInstances{Output: threeVMs()},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:     "alice",
+		remoteRun: statusAllRemote(time.Now().Add(time.Hour), nil),
+	}, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []statusJSON
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, output)
+	}
+	if len(got) != 3 || got[0].Name != "default" || got[1].Name != "gpu" || got[2].Name != "scratch" {
+		t.Fatalf("got %+v", got)
+	}
+	if got[0].DiskUsagePct == nil || *got[0].DiskUsagePct != 42 {
+		t.Errorf("default disk_usage_pct = %v, want 42", got[0].DiskUsagePct)
+	}
+	if got[1].DiskUsagePct != nil {
+		t.Errorf("stopped VM should have no disk_usage_pct, got %d", *got[1].DiskUsagePct)
+	}
+	if got[0].Owner != "alice" || got[0].Events == nil {
+		t.Errorf("entries should match the single-VM object, got %+v", got[0])
+	}
+}
+
+func TestStatusAllJSONEmpty(t *testing.T) {
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		owner:    "alice",
+	}, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("output = %q, want []", output)
+	}
+}
+
+func TestStatusAllSlowVMDoesNotStallOthers(t *testing.T) {
+	hint.IsTTY = false
+	start := time.Now()
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe:     &cmdtest.DescribeInstances{Output: threeVMs()},
+		sendKey:      &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:        "alice",
+		remoteRun:    statusAllRemote(time.Now(), map[string]bool{"3.3.3.3": true}),
+		probeTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("status --all took %s with one hung VM", elapsed)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "default") && !strings.Contains(line, "42%") {
+			t.Errorf("default row should keep its disk usage: %q", line)
+		}
+		if strings.HasPrefix(line, "scratch") && !strings.Contains(line, "unknown") {
+			t.Errorf("hung VM should show unknown disk usage: %q", line)
+		}
+		if strings.HasPrefix(line, "scratch") && !strings.HasSuffix(strings.TrimSpace(line), " -") {
+			t.Errorf("hung VM should show an unknown idle state: %q", line)
+		}
+	}
+}
+
+func TestStatusAllRejectsWatch(t *testing.T) {
+	_, err := runStatusAllForTest(t, &statusDeps{
+		describe: &cmdtest.DescribeInstances{Output: threeVMs()},
+		owner:    "alice",
+	}, "--watch")
+	if err == nil {
+		t.Fatal("expected an error for --all with --watch")
+	}
+}
+
+func TestStatusAllMetrics(t *testing.T) {
+	output, err := runStatusAllForTest(t, &statusDeps{
+		describe:     &cmdtest.DescribeInstances{Output: threeVMs()},
+		sendKey:      &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:        "alice",
+		remoteRun:    statusAllRemote(time.Now(), map[string]bool{"3.3.3.3": true}),
+		probeTimeout: 50 * time.Millisecond,
+	}, "--metrics")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := strings.Count(output, "# TYPE mint_vm_state gauge"); n != 1 {
+		t.Errorf("mint_vm_state has %d TYPE lines, want one for all VMs:\n%s", n, output)
+	}
+	for _, want := range []string{
+		`mint_vm_state{vm="gpu",owner="alice"} 3`,
+		`mint_vm_reachable{vm="default",owner="alice"} 1`,
+		`mint_vm_reachable{vm="scratch",owner="alice"} 0`,
+		`mint_disk_used_percent{vm="default",owner="alice",mount="/"} 42`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
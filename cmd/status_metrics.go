@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/metrics"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// vmStateValues encodes EC2 instance states as mint_vm_state values. States
+// not listed are reported as -1.
+var vmStateValues = map[string]float64{
+	string(ec2types.InstanceStateNamePending):      0,
+	string(ec2types.InstanceStateNameRunning):      1,
+	string(ec2types.InstanceStateNameStopping):     2,
+	string(ec2types.InstanceStateNameStopped):      3,
+	string(ec2types.InstanceStateNameShuttingDown): 4,
+	string(ec2types.InstanceStateNameTerminated):   5,
+}
+
+// bootstrapStatusValues encodes mint:bootstrap values as
+// mint_bootstrap_status values. A missing or unknown value is -1.
+var bootstrapStatusValues = map[string]float64{
+	tags.BootstrapPending:  0,
+	tags.BootstrapComplete: 1,
+	tags.BootstrapFailed:   2,
+}
+
+// writeStatusMetrics writes the reports in the Prometheus text exposition
+// format, for status --metrics.
+func writeStatusMetrics(w io.Writer, reports []*statusReport, now time.Time) error {
+	var ms []metrics.Metric
+	for _, report := range reports {
+		ms = append(ms, statusMetrics(report, now)...)
+	}
+	return metrics.Render(w, ms)
+}
+
+// statusMetrics returns the samples of one VM, each labeled with the VM name
+// and owner. A running VM whose disk probe failed over SSH is reported with
+// mint_vm_reachable 0 and no disk samples.
+func statusMetrics(report *statusReport, now time.Time) []metrics.Metric {
+	v := report.VM
+	labels := []metrics.Label{{Name: "vm", Value: v.Name}, {Name: "owner", Value: report.Owner.Name}}
+	gauge := func(name, help string, value float64, extra ...metrics.Label) metrics.Metric {
+		return metrics.Metric{
+			Name:   name,
+			Help:   help,
+			Type:   metrics.Gauge,
+			Labels: append(append([]metrics.Label(nil), labels...), extra...),
+			Value:  value,
+		}
+	}
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	running := v.State == string(ec2types.InstanceStateNameRunning)
+
+	state, ok := vmStateValues[v.State]
+	if !ok {
+		state = -1
+	}
+	bootstrap, ok := bootstrapStatusValues[v.BootstrapStatus]
+	if !ok {
+		bootstrap = -1
+	}
+	var uptime float64
+	if running && !v.LaunchTime.IsZero() {
+		uptime = now.Sub(v.LaunchTime).Seconds()
+	}
+	eipAllocated := v.IPMode != tags.IPModeIPv6Only && v.Tags[tags.TagEIP] != tags.EIPReleasedByGC
+	reachable := running && report.Disks != nil && report.DisksErr == nil
+
+	ms := []metrics.Metric{
+		gauge("mint_vm_state",
+			"EC2 instance state: 0 pending, 1 running, 2 stopping, 3 stopped, 4 shutting-down, 5 terminated, -1 unknown.",
+			state),
+		gauge("mint_vm_reachable", "Whether the VM answered over SSH: 1 yes, 0 no or not running.", boolValue(reachable)),
+		gauge("mint_vm_uptime_seconds", "Seconds since the VM was launched; 0 when it is not running.", uptime),
+		gauge("mint_bootstrap_status", "Bootstrap status: 0 pending, 1 complete, 2 failed, -1 unknown.", bootstrap),
+	}
+	for _, d := range report.Disks {
+		ms = append(ms, gauge("mint_disk_used_percent", "Filesystem space used, in percent.",
+			float64(d.PercentUsed), metrics.Label{Name: "mount", Value: d.Mount}))
+	}
+	ms = append(ms, gauge("mint_eip_allocated", "Whether the VM has an Elastic IP allocated: 1 yes, 0 no.", boolValue(eipAllocated)))
+	return ms
+}
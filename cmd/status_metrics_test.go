@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+func TestStatusMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteErr error
+		args      []string
+		wantErr   string
+		want      []string
+		notWant   []string
+	}{
+		{
+			name: "reachable VM",
+			want: []string{
+				"# TYPE mint_vm_state gauge\n",
+				`mint_vm_state{vm="default",owner="alice"} 1` + "\n",
+				`mint_vm_reachable{vm="default",owner="alice"} 1` + "\n",
+				`mint_disk_used_percent{vm="default",owner="alice",mount="/"} 42` + "\n",
+				`mint_disk_used_percent{vm="default",owner="alice",mount="/mint/projects"} 7` + "\n",
+				`mint_bootstrap_status{vm="default",owner="alice"} -1` + "\n",
+				`mint_eip_allocated{vm="default",owner="alice"} 1` + "\n",
+			},
+		},
+		{
+			name:      "SSH failure is not fatal",
+			remoteErr: fmt.Errorf("connection refused"),
+			want: []string{
+				`mint_vm_state{vm="default",owner="alice"} 1` + "\n",
+				`mint_vm_reachable{vm="default",owner="alice"} 0` + "\n",
+			},
+			notWant: []string{"mint_disk_used_percent"},
+		},
+		{
+			name:    "json conflicts",
+			args:    []string{"--json"},
+			wantErr: "--metrics cannot be combined with --json or --format",
+		},
+		{
+			name:    "format conflicts",
+			args:    []string{"--format", "plain"},
+			wantErr: "--metrics cannot be combined with --json or --format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := "Mounted on     Use% Avail\n/               42%   38G\n/mint/projects   7%  180G\n"
+			deps := &statusDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeRunningInstanceWithAZ("i-metrics", "default", "alice", "1.2.3.4", "us-east-1a"),
+				},
+				sendKey:        &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				owner:          "alice",
+				remoteRun:      mockRemoteCommandRunner([]byte(output), tt.remoteErr),
+				versionChecker: func() (bool, *string) { return false, nil },
+			}
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"status", "--metrics"}, tt.args...))
+
+			err := root.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output missing %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("output should not contain %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusMetricsValues(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		vm   vm.VM
+		want map[string]float64
+	}{
+		{
+			name: "running",
+			vm: vm.VM{Name: "default", State: "running", BootstrapStatus: tags.BootstrapComplete,
+				LaunchTime: now.Add(-90 * time.Minute)},
+			want: map[string]float64{"mint_vm_state": 1, "mint_vm_uptime_seconds": 5400,
+				"mint_bootstrap_status": 1, "mint_eip_allocated": 1},
+		},
+		{
+			name: "stopped after gc released the EIP",
+			vm: vm.VM{Name: "default", State: "stopped", BootstrapStatus: tags.BootstrapFailed,
+				LaunchTime: now.Add(-time.Hour), Tags: map[string]string{tags.TagEIP: tags.EIPReleasedByGC}},
+			want: map[string]float64{"mint_vm_state": 3, "mint_vm_uptime_seconds": 0,
+				"mint_vm_reachable": 0, "mint_bootstrap_status": 2, "mint_eip_allocated": 0},
+		},
+		{
+			name: "ipv6-only",
+			vm:   vm.VM{Name: "default", State: "pending", BootstrapStatus: tags.BootstrapPending, IPMode: tags.IPModeIPv6Only},
+			want: map[string]float64{"mint_vm_state": 0, "mint_bootstrap_status": 0, "mint_eip_allocated": 0},
+		},
+		{
+			name: "unknown state",
+			vm:   vm.VM{Name: "default", State: "rebooting"},
+			want: map[string]float64{"mint_vm_state": -1, "mint_bootstrap_status": -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := tt.vm
+			got := map[string]float64{}
+			for _, m := range statusMetrics(&statusReport{VM: &v, Owner: statusOwner{Name: "alice"}}, now) {
+				got[m.Name] = m.Value
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s = %v, want %v", name, got[name], want)
+				}
+			}
+		})
+	}
+}
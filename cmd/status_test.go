@@ -5,16 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 )
 
 func TestStatusCommand(t *testing.T) {
@@ -22,7 +27,7 @@ func TestStatusCommand(t *testing.T) {
 
 	tests := []struct {
 		name           string
-		describe       *mockDescribeInstances
+		describe       *cmdtest.DescribeInstances
 		owner          string
 		vmName         string
 		jsonOutput     bool
@@ -32,8 +37,8 @@ func TestStatusCommand(t *testing.T) {
 	}{
 		{
 			name: "happy path shows VM details",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:      "alice",
 			vmName:     "default",
@@ -41,8 +46,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "VM not found returns error",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -51,8 +56,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "json output format",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:      "alice",
 			vmName:     "default",
@@ -61,8 +66,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "json output is valid JSON object",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:      "alice",
 			vmName:     "default",
@@ -70,8 +75,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "API error propagates",
-			describe: &mockDescribeInstances{
-				err: fmt.Errorf("access denied"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("access denied"),
 			},
 			owner:          "alice",
 			vmName:         "default",
@@ -80,8 +85,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "non-default VM name",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-dev456", "dev-box", "bob", "stopped", "", "t3.medium", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-dev456", "dev-box", "bob", "stopped", "", "t3.medium", "complete", recentLaunch),
 			},
 			owner:      "bob",
 			vmName:     "dev-box",
@@ -89,8 +94,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "stopped VM shows no IP",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-stopped", "default", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-stopped", "default", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
 			},
 			owner:      "alice",
 			vmName:     "default",
@@ -98,8 +103,8 @@ func TestStatusCommand(t *testing.T) {
 		},
 		{
 			name: "bootstrap failed shown",
-			describe: &mockDescribeInstances{
-				output: makeInstanceWithTime("i-fail", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "failed", recentLaunch),
+			describe: &cmdtest.DescribeInstances{
+				Output: makeInstanceWithTime("i-fail", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "failed", recentLaunch),
 			},
 			owner:      "alice",
 			vmName:     "default",
@@ -117,7 +122,7 @@ func TestStatusCommand(t *testing.T) {
 			}
 
 			cmd := newStatusCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			root.SetOut(buf)
 			root.SetErr(buf)
@@ -188,14 +193,14 @@ func TestStatusShowsVolumeInfo(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithVolumeTags("i-vol1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch, "200", "50"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithVolumeTags("i-vol1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch, "200", "50"),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -206,27 +211,74 @@ func TestStatusShowsVolumeInfo(t *testing.T) {
 	}
 
 	output := buf.String()
-	if !strings.Contains(output, "Root Vol:  200 GB") {
+	if !strings.Contains(output, "Root Vol:  200 GiB") {
 		t.Errorf("output missing root volume info, got:\n%s", output)
 	}
-	if !strings.Contains(output, "Proj Vol:  50 GB") {
+	if !strings.Contains(output, "Proj Vol:  50 GiB") {
 		t.Errorf("output missing project volume info, got:\n%s", output)
 	}
 }
 
+func TestStatusShowsUserBootstrapStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "ok", value: "ok", want: "User hook: ok"},
+		{name: "failed", value: "failed:3", want: "User hook: FAILED (exit 3)"},
+		{name: "absent", value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeInstanceWithTime("i-hook1", "default", "alice", "stopped", "", "m6i.xlarge", "complete", time.Now())
+			if tt.value != "" {
+				inst := &out.Reservations[0].Instances[0]
+				inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String("mint:user-bootstrap"), Value: aws.String(tt.value)})
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(&statusDeps{
+				describe: &cmdtest.DescribeInstances{Output: out},
+				owner:    "alice",
+			}))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status"})
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			output := buf.String()
+			if tt.want == "" {
+				if strings.Contains(output, "User hook:") {
+					t.Errorf("output should omit user hook line when tag absent, got:\n%s", output)
+				}
+				return
+			}
+			if !strings.Contains(output, tt.want) {
+				t.Errorf("output missing %q, got:\n%s", tt.want, output)
+			}
+		})
+	}
+}
+
 func TestStatusHidesVolumesWhenZero(t *testing.T) {
 	recentLaunch := time.Now().Add(-30 * time.Minute)
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-novol", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-novol", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -250,14 +302,14 @@ func TestStatusJSONIncludesVolumes(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithVolumeTags("i-vol2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch, "200", "50"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithVolumeTags("i-vol2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch, "200", "50"),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -285,19 +337,246 @@ func TestStatusJSONIncludesVolumes(t *testing.T) {
 	}
 }
 
+func TestStatusShowsSpotMarket(t *testing.T) {
+	out := makeInstanceWithVolumeTags("i-spot1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now(), "200", "50")
+	inst := &out.Reservations[0].Instances[0]
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagSpot), Value: aws.String("true")})
+
+	for _, args := range [][]string{{"status"}, {"status", "--json"}} {
+		buf := new(bytes.Buffer)
+		root := cmdtest.NewRoot()
+		root.AddCommand(newStatusCommandWithDeps(&statusDeps{
+			describe: &cmdtest.DescribeInstances{Output: out},
+			owner:    "alice",
+		}))
+		root.SetOut(buf)
+		root.SetErr(buf)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+
+		if len(args) == 1 {
+			if !strings.Contains(buf.String(), "Type:      m6i.xlarge (spot)") {
+				t.Errorf("output missing spot type, got:\n%s", buf.String())
+			}
+			continue
+		}
+		var result map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if result["spot"] != true {
+			t.Errorf("spot = %v, want true", result["spot"])
+		}
+	}
+}
+
+func TestStatusShowsProvisioned(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{
+			name: "all tags",
+			tags: map[string]string{
+				tags.TagProvisionedAt: time.Now().Add(-75 * time.Hour).UTC().Format(time.RFC3339),
+				tags.TagProvisionedBy: "alice",
+				tags.TagCLIVersion:    "0.9.2",
+			},
+			want: "VM:        default — provisioned 3d ago by alice (mint v0.9.2)\n",
+		},
+		{
+			name: "dev build",
+			tags: map[string]string{
+				tags.TagProvisionedAt: time.Now().Add(-5 * time.Hour).UTC().Format(time.RFC3339),
+				tags.TagProvisionedBy: "bob",
+				tags.TagCLIVersion:    "dev",
+			},
+			want: "VM:        default — provisioned 5h ago by bob (mint dev)\n",
+		},
+		{
+			name: "provisioned before the tags existed",
+			want: "VM:        default\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeInstanceWithVolumeTags("i-prov1", "default", "alice", "stopped", "", "m6i.xlarge", "complete", time.Now(), "200", "50")
+			inst := &out.Reservations[0].Instances[0]
+			for k, v := range tt.tags {
+				inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(k), Value: aws.String(v)})
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(&statusDeps{
+				describe: &cmdtest.DescribeInstances{Output: out},
+				owner:    "alice",
+			}))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q, got:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestStatusShowsHibernated(t *testing.T) {
+	out := makeInstanceWithVolumeTags("i-hib", "default", "alice", "stopped", "1.2.3.4", "m6i.xlarge", "complete", time.Now(), "216", "50")
+	inst := &out.Reservations[0].Instances[0]
+	inst.HibernationOptions = &ec2types.HibernationOptions{Configured: aws.Bool(true)}
+	inst.StateReason = &ec2types.StateReason{
+		Code:    aws.String("Client.UserInitiatedHibernate"),
+		Message: aws.String("Client.UserInitiatedHibernate: User initiated hibernate"),
+	}
+
+	for _, args := range [][]string{{"status"}, {"status", "--json"}} {
+		buf := new(bytes.Buffer)
+		root := cmdtest.NewRoot()
+		root.AddCommand(newStatusCommandWithDeps(&statusDeps{
+			describe: &cmdtest.DescribeInstances{Output: out},
+			owner:    "alice",
+		}))
+		root.SetOut(buf)
+		root.SetErr(buf)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+
+		if len(args) == 1 {
+			if !strings.Contains(buf.String(), "State:     stopped (hibernated)") {
+				t.Errorf("output missing hibernated state, got:\n%s", buf.String())
+			}
+			continue
+		}
+		var result map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if result["state"] != "stopped" || result["hibernated"] != true {
+			t.Errorf("state = %v, hibernated = %v, want stopped, true", result["state"], result["hibernated"])
+		}
+	}
+}
+
+func TestStatusShowsIPv6OnlyAddress(t *testing.T) {
+	out := makeInstanceWithVolumeTags("i-v6", "default", "alice", "running", "", "m6i.xlarge", "complete", time.Now(), "200", "50")
+	inst := &out.Reservations[0].Instances[0]
+	inst.PublicIpAddress = nil
+	inst.Ipv6Address = aws.String("2600:1f14::10")
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(tags.IPModeIPv6Only)})
+
+	for _, args := range [][]string{{"status"}, {"status", "--json"}} {
+		buf := new(bytes.Buffer)
+		root := cmdtest.NewRoot()
+		root.AddCommand(newStatusCommandWithDeps(&statusDeps{
+			describe: &cmdtest.DescribeInstances{Output: out},
+			owner:    "alice",
+		}))
+		root.SetOut(buf)
+		root.SetErr(buf)
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+
+		if len(args) == 1 {
+			if !strings.Contains(buf.String(), "IP:        2600:1f14::10 (ipv6-only)") {
+				t.Errorf("output missing IPv6 address, got:\n%s", buf.String())
+			}
+			continue
+		}
+		var result map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if result["ip_mode"] != tags.IPModeIPv6Only || result["ipv6_address"] != "2600:1f14::10" || result["public_ip"] != "2600:1f14::10" {
+			t.Errorf("ip_mode = %v, ipv6_address = %v, public_ip = %v", result["ip_mode"], result["ipv6_address"], result["public_ip"])
+		}
+	}
+}
+
+func TestStatusOwnerIdentity(t *testing.T) {
+	myARN := "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Dev_abc/José García"
+	tests := []struct {
+		name        string
+		taggedARN   string
+		wantWarning bool
+	}{
+		{"own VM", myARN, false},
+		{"own VM under another permission set", "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_def/José García", false},
+		{"another identity with the same owner", "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Dev_abc/Jose Garcia", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeInstanceWithTime("i-own", "default", "jose-garcia", "stopped", "", "m6i.xlarge", "complete", time.Now())
+			inst := &out.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String("mint:owner-arn"), Value: aws.String(tt.taggedARN)})
+			deps := &statusDeps{
+				describe: &cmdtest.DescribeInstances{Output: out},
+				owner:    "jose-garcia",
+				ownerARN: myARN,
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status", "--json"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var result statusJSON
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.Owner != "jose-garcia" || result.OwnerARN != myARN {
+				t.Errorf("owner = %q, owner_arn = %q", result.Owner, result.OwnerARN)
+			}
+			if got := result.OwnerWarning != ""; got != tt.wantWarning {
+				t.Errorf("owner_warning = %q, want warning %v", result.OwnerWarning, tt.wantWarning)
+			}
+
+			// Human output carries the same warning.
+			buf.Reset()
+			root = cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Contains(buf.String(), "Warning: VM \"default\" was created by"); got != tt.wantWarning {
+				t.Errorf("human warning = %v, want %v:\n%s", got, tt.wantWarning, buf.String())
+			}
+		})
+	}
+}
+
 func TestStatusShowsVersionNotice(t *testing.T) {
 	recentLaunch := time.Now().Add(-30 * time.Minute)
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-ver1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-ver1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -342,14 +621,14 @@ func TestStatusAppendsVersionNoticeInHumanMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-vn1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-vn1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -385,14 +664,14 @@ func TestStatusDoesNotAppendVersionBannerInJSONMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-vn2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-vn2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -419,14 +698,14 @@ func TestStatusJSONIncludesVersion(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-ver2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-ver2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -452,16 +731,16 @@ func TestStatusShowsDiskUsage(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk1", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk1", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
-		remoteRun: mockRemoteCommandRunner([]byte("Use%\n 42%\n"), nil),
+		remoteRun: mockRemoteCommandRunner([]byte("Mounted on     Use% Avail\n/               42%   38G\n"), nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -472,7 +751,7 @@ func TestStatusShowsDiskUsage(t *testing.T) {
 	}
 
 	output := buf.String()
-	if !strings.Contains(output, "Disk:      42%") {
+	if !strings.Contains(output, "Disk:      /  42%  38 GB available") {
 		t.Errorf("output missing disk usage, got:\n%s", output)
 	}
 	if strings.Contains(output, "[WARN]") {
@@ -484,16 +763,16 @@ func TestStatusDiskUsageWarning(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk2", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk2", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
-		remoteRun: mockRemoteCommandRunner([]byte("Use%\n 85%\n"), nil),
+		remoteRun: mockRemoteCommandRunner([]byte("Mounted on     Use% Avail\n/               85%   38G\n"), nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -504,25 +783,28 @@ func TestStatusDiskUsageWarning(t *testing.T) {
 	}
 
 	output := buf.String()
-	if !strings.Contains(output, "Disk:      85% [WARN]") {
+	if !strings.Contains(output, "Disk:      /  85%  38 GB available [WARN]") {
 		t.Errorf("output missing disk usage warning, got:\n%s", output)
 	}
+	if !strings.Contains(output, "mint prune") {
+		t.Errorf("disk warning should suggest mint prune, got:\n%s", output)
+	}
 }
 
 func TestStatusDiskUsageWarningAt80(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk80", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk80", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
-		remoteRun: mockRemoteCommandRunner([]byte("Use%\n 80%\n"), nil),
+		remoteRun: mockRemoteCommandRunner([]byte("Mounted on     Use% Avail\n/               80%   38G\n"), nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -533,7 +815,7 @@ func TestStatusDiskUsageWarningAt80(t *testing.T) {
 	}
 
 	output := buf.String()
-	if !strings.Contains(output, "80% [WARN]") {
+	if !strings.Contains(output, "80%  38 GB available [WARN]") {
 		t.Errorf("expected [WARN] at exactly 80%%, got:\n%s", output)
 	}
 }
@@ -542,16 +824,16 @@ func TestStatusDiskUsageSSHFailure(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk3", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk3", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
 		remoteRun: mockRemoteCommandRunner(nil, fmt.Errorf("connection refused")),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -563,8 +845,8 @@ func TestStatusDiskUsageSSHFailure(t *testing.T) {
 	}
 
 	output := buf.String()
-	if !strings.Contains(output, "Disk:      unknown") {
-		t.Errorf("expected 'unknown' disk usage on SSH failure, got:\n%s", output)
+	if !strings.Contains(output, "Disk:      unavailable (VM not reachable over SSH)") {
+		t.Errorf("expected unavailable disk usage on SSH failure, got:\n%s", output)
 	}
 }
 
@@ -572,16 +854,16 @@ func TestStatusDiskUsageJSON(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk4", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk4", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
-		remoteRun: mockRemoteCommandRunner([]byte("Use%\n 42%\n"), nil),
+		remoteRun: mockRemoteCommandRunner([]byte("Mounted on     Use% Avail\n/               42%   38G\n"), nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -609,16 +891,16 @@ func TestStatusDiskUsageJSONSSHFailure(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeRunningInstanceWithAZ("i-disk5", "default", "alice", "1.2.3.4", "us-east-1a"),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk5", "default", "alice", "1.2.3.4", "us-east-1a"),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
 		remoteRun: mockRemoteCommandRunner(nil, fmt.Errorf("connection refused")),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -653,20 +935,20 @@ func TestStatusNoDiskCheckWhenStopped(t *testing.T) {
 		command []string,
 	) ([]byte, error) {
 		remoteCallCount++
-		return []byte("Use%\n 50%\n"), nil
+		return []byte("Mounted on     Use% Avail\n/               50%   38G\n"), nil
 	}
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-stopped2", "default", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-stopped2", "default", "alice", "stopped", "", "m6i.xlarge", "complete", recentLaunch),
 		},
-		sendKey:   &mockSendSSHPublicKey{output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
 		owner:     "alice",
 		remoteRun: trackingRunner,
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -681,8 +963,11 @@ func TestStatusNoDiskCheckWhenStopped(t *testing.T) {
 	}
 
 	output := buf.String()
-	if strings.Contains(output, "Disk:") {
-		t.Errorf("stopped VM should NOT show Disk line, got:\n%s", output)
+	if strings.Contains(output, "50%") {
+		t.Errorf("stopped VM should NOT show disk usage, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Disk:      (VM stopped — start with `mint up` for live data)") {
+		t.Errorf("stopped VM should explain the missing disk usage, got:\n%s", output)
 	}
 }
 
@@ -691,15 +976,15 @@ func TestStatusJSONIncludesVersionCheckFields(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-vc1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-vc1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		versionChecker: stubVersionChecker(true, strPtr("v2.0.0")),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -736,15 +1021,15 @@ func TestStatusJSONVersionFieldsFailOpen(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-vc2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-vc2", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		versionChecker: stubVersionChecker(false, nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -779,15 +1064,15 @@ func TestStatusJSONVersionFieldsOnLatestVersion(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-vc3", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-vc3", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		versionChecker: stubVersionChecker(false, strPtr("v1.0.0")),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -821,23 +1106,23 @@ func TestStatusJSONVersionFieldsOnLatestVersion(t *testing.T) {
 // machine-readable consumers.
 func TestStatusJSONErrorRouting(t *testing.T) {
 	tests := []struct {
-		name           string
-		describe       *mockDescribeInstances
-		owner          string
-		wantErrKey     string // substring expected inside the JSON "error" value
+		name       string
+		describe   *cmdtest.DescribeInstances
+		owner      string
+		wantErrKey string // substring expected inside the JSON "error" value
 	}{
 		{
 			name: "VM not found writes JSON error to stdout",
-			describe: &mockDescribeInstances{
-				output: &ec2.DescribeInstancesOutput{},
+			describe: &cmdtest.DescribeInstances{
+				Output: &ec2.DescribeInstancesOutput{},
 			},
 			owner:      "alice",
 			wantErrKey: "not found",
 		},
 		{
 			name: "AWS error writes JSON error to stdout",
-			describe: &mockDescribeInstances{
-				err: fmt.Errorf("access denied"),
+			describe: &cmdtest.DescribeInstances{
+				Err: fmt.Errorf("access denied"),
 			},
 			owner:      "alice",
 			wantErrKey: "access denied",
@@ -855,7 +1140,7 @@ func TestStatusJSONErrorRouting(t *testing.T) {
 			}
 
 			cmd := newStatusCommandWithDeps(deps)
-			root := newTestRoot()
+			root := cmdtest.NewRoot()
 			root.AddCommand(cmd)
 			// Separate stdout from stderr so we can verify routing.
 			root.SetOut(stdout)
@@ -911,14 +1196,14 @@ func TestStatusJSONErrorRoutingHappyPath(t *testing.T) {
 	stderr := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-rte1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-rte1", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner: "alice",
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(stdout)
 	root.SetErr(stderr)
@@ -957,15 +1242,15 @@ func TestStatusSpinnerShownInHumanMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-spin3", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-spin3", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		versionChecker: stubVersionChecker(false, nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -993,15 +1278,15 @@ func TestStatusSpinnerSuppressedInJSONMode(t *testing.T) {
 	buf := new(bytes.Buffer)
 
 	deps := &statusDeps{
-		describe: &mockDescribeInstances{
-			output: makeInstanceWithTime("i-spin4", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-spin4", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
 		},
 		owner:          "alice",
 		versionChecker: stubVersionChecker(false, nil),
 	}
 
 	cmd := newStatusCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -1085,3 +1370,466 @@ func TestParseDiskUsagePct(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDiskUsages(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []diskUsage
+		wantErr bool
+	}{
+		{
+			name:  "root and project volume",
+			input: "Mounted on     Use% Avail\n/               42%   38G\n/mint/projects  91%    4G\n",
+			want:  []diskUsage{{"/", 42, 38}, {"/mint/projects", 91, 4}},
+		},
+		{
+			name:  "extra volume",
+			input: "Mounted on Use% Avail\n/ 10% 70G\n/mint/volumes/data 0% 100G\n",
+			want:  []diskUsage{{"/", 10, 70}, {"/mint/volumes/data", 0, 100}},
+		},
+		{name: "empty output", input: "", wantErr: true},
+		{name: "header only", input: "Mounted on Use% Avail", wantErr: true},
+		{name: "bad percentage", input: "Mounted on Use% Avail\n/ abc 38G\n", wantErr: true},
+		{name: "bad available", input: "Mounted on Use% Avail\n/ 42% -\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDiskUsages(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDiskUsages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusDiskSection(t *testing.T) {
+	hint.IsTTY = false
+	dfOut := "Mounted on         Use% Avail\n/                   42%   38G\n/mint/projects      91%    4G\n/mint/volumes/data  20%   80G\n"
+	var probed []string
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-disk6", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey: &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:   "alice",
+		remoteRun: func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+			if command[0] == "sh" && strings.Contains(command[2], "df --output=target,pcent,avail -B G") {
+				probed = command
+				return []byte(dfOut), nil
+			}
+			return nil, fmt.Errorf("exit status 1")
+		},
+	}
+
+	for _, jsonOutput := range []bool{false, true} {
+		t.Run(fmt.Sprintf("json=%v", jsonOutput), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			args := []string{"status"}
+			if jsonOutput {
+				args = append(args, "--json")
+			}
+			root.SetArgs(args)
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if probed == nil {
+				t.Fatal("disk probe was not run")
+			}
+
+			if jsonOutput {
+				var result statusJSON
+				if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+					t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+				}
+				want := []diskUsage{{"/", 42, 38}, {"/mint/projects", 91, 4}, {"/mint/volumes/data", 20, 80}}
+				if !reflect.DeepEqual(result.Disks, want) {
+					t.Errorf("disks = %v, want %v", result.Disks, want)
+				}
+				if result.DiskUsagePct == nil || *result.DiskUsagePct != 42 {
+					t.Errorf("disk_usage_pct = %v, want the root filesystem's 42", result.DiskUsagePct)
+				}
+				if !strings.Contains(buf.String(), `"available_gb": 4`) {
+					t.Errorf("JSON missing available_gb:\n%s", buf.String())
+				}
+				return
+			}
+
+			output := buf.String()
+			for _, want := range []string{
+				"Disk:      /                   42%  38 GB available\n",
+				"           /mint/projects      91%  4 GB available [WARN] — run `mint volume grow --size <GB>` to add space\n",
+				"           /mint/volumes/data  20%  80 GB available\n",
+			} {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+			if strings.Contains(output, "mint prune") {
+				t.Errorf("root filesystem under 80%% should not suggest mint prune:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestStatusShowsScheduledEvents(t *testing.T) {
+	hint.IsTTY = false
+	recentLaunch := time.Now().Add(-30 * time.Minute)
+
+	for _, jsonOutput := range []bool{false, true} {
+		t.Run(fmt.Sprintf("json=%v", jsonOutput), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			deps := &statusDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", recentLaunch),
+				},
+				describeStatus: instanceEvents("i-abc123", retirementEvent()),
+				owner:          "alice",
+			}
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			args := []string{"status"}
+			if jsonOutput {
+				args = append(args, "--json")
+			}
+			root.SetArgs(args)
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !jsonOutput {
+				for _, want := range []string{"Scheduled events:", "instance scheduled for retirement on 2025-02-01", "`mint recreate`", "degraded hardware"} {
+					if !strings.Contains(buf.String(), want) {
+						t.Errorf("output missing %q:\n%s", want, buf.String())
+					}
+				}
+				return
+			}
+			var result struct {
+				Events []map[string]any `json:"events"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(result.Events) != 1 || result.Events[0]["code"] != "instance-retirement" {
+				t.Errorf("events = %v", result.Events)
+			}
+		})
+	}
+}
+
+func TestStatusJSONEventsEmptyArray(t *testing.T) {
+	buf := new(bytes.Buffer)
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now()),
+		},
+		describeStatus: &mockDescribeInstanceStatus{err: fmt.Errorf("throttled")},
+		owner:          "alice",
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"status", "--json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("event lookup failure should not fail status: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"events": []`) {
+		t.Errorf("expected empty events array, got:\n%s", buf.String())
+	}
+}
+
+func TestStatusEIPReleaseWarning(t *testing.T) {
+	hint.IsTTY = false
+
+	stoppedAt := func(ago time.Duration) string {
+		return "User initiated (" + time.Now().Add(-ago).UTC().Format("2006-01-02 15:04:05") + " GMT)"
+	}
+	tests := []struct {
+		name        string
+		reason      string
+		threshold   int
+		wantDays    int
+		wantWarning bool
+	}{
+		{"past the threshold", stoppedAt(20 * 24 * time.Hour), 14, 20, true},
+		{"below the threshold", stoppedAt(3 * 24 * time.Hour), 14, 3, false},
+		{"policy disabled", stoppedAt(20 * 24 * time.Hour), 0, 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := makeInstanceWithTime("i-stopped", "default", "alice", "stopped", "", "m6i.xlarge", "complete", time.Now())
+			out.Reservations[0].Instances[0].StateTransitionReason = aws.String(tt.reason)
+			deps := &statusDeps{
+				describe:            &cmdtest.DescribeInstances{Output: out},
+				owner:               "alice",
+				versionChecker:      stubVersionChecker(false, nil),
+				releaseEIPAfterDays: tt.threshold,
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetArgs([]string{"status", "--json"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var result statusJSON
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if result.StoppedDays == nil || *result.StoppedDays != tt.wantDays {
+				t.Errorf("stopped_days = %v, want %d", result.StoppedDays, tt.wantDays)
+			}
+			if result.EIPReleaseDue != tt.wantWarning {
+				t.Errorf("eip_release_due = %v, want %v", result.EIPReleaseDue, tt.wantWarning)
+			}
+
+			buf.Reset()
+			root = cmdtest.NewRoot(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetArgs([]string{"status"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Contains(buf.String(), "run `mint gc --apply` to release it"); got != tt.wantWarning {
+				t.Errorf("human warning present = %v, want %v:\n%s", got, tt.wantWarning, buf.String())
+			}
+		})
+	}
+}
+
+func TestStatusShowsAgentVersion(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "current", version: "3\n", want: "Agent:     v3 (CLI v2–v3)\n"},
+		{name: "absent file", version: "", want: "Agent:     v1 (CLI v2–v3) — older; run `mint recreate` to upgrade"},
+		{name: "newer", version: "4\n", want: "Agent:     v4 (CLI v2–v3) — newer; run `mint update`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, _ := agentRunner(tt.version, nil)
+			deps := &statusDeps{
+				describe: &cmdtest.DescribeInstances{
+					Output: makeRunningInstanceWithAZ("i-agent", "default", "alice", "1.2.3.4", "us-east-1a"),
+				},
+				sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+				owner:     "alice",
+				remoteRun: run,
+			}
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot()
+			root.AddCommand(newStatusCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"status"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q, got:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestStatusJSONIncludesAgentVersion(t *testing.T) {
+	run, _ := agentRunner("", nil)
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeRunningInstanceWithAZ("i-agent", "default", "alice", "1.2.3.4", "us-east-1a"),
+		},
+		sendKey:   &cmdtest.SendSSHPublicKey{Output: &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}},
+		owner:     "alice",
+		remoteRun: run,
+	}
+
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetArgs([]string{"status", "--json"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var data struct {
+		AgentVersion *int `json:"agent_version"`
+		Min          int  `json:"cli_agent_version_min"`
+		Max          int  `json:"cli_agent_version_max"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if data.AgentVersion == nil || *data.AgentVersion != 1 || data.Min != agentVersionMin || data.Max != agentVersionMax {
+		t.Errorf("agent fields = %v %d %d", data.AgentVersion, data.Min, data.Max)
+	}
+}
+
+// runStatusWatchCommand runs status --watch against VM polls, recording the
+// sleep intervals instead of sleeping.
+func runStatusWatchCommand(t *testing.T, describe *cmdtest.DescribeInstancesSequence, args ...string) (string, []time.Duration, error) {
+	t.Helper()
+	var sleeps []time.Duration
+	deps := &statusDeps{
+		describe: describe,
+		owner:    "alice",
+		watch: watchLoop{
+			sleep: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			},
+			clear: func(io.Writer) bool { return false },
+		},
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"status", "--watch"}, args...))
+	err := root.Execute()
+	return buf.String(), sleeps, err
+}
+
+func watchPoll(state, bootstrap string) *ec2.DescribeInstancesOutput {
+	return makeInstanceWithTime("i-watch", "default", "alice", state, "1.2.3.4", "m6i.xlarge", bootstrap, time.Now())
+}
+
+func TestStatusWatchUntilBootstrapComplete(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+		watchPoll("pending", "pending"),
+		watchPoll("running", "pending"),
+		watchPoll("running", "complete"),
+	}}
+
+	out, sleeps, err := runStatusWatchCommand(t, describe, "--interval", "2s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if describe.Calls != 3 {
+		t.Errorf("polled %d times, want 3", describe.Calls)
+	}
+	if len(sleeps) != 2 || sleeps[0] != 2*time.Second {
+		t.Errorf("sleeps = %v, want two of 2s", sleeps)
+	}
+	if n := strings.Count(out, "Refreshing every 2s"); n != 2 {
+		t.Errorf("rendered %d refreshing frames, want 2:\n%s", n, out)
+	}
+	if !strings.Contains(out, `Bootstrap complete on VM "default".`) {
+		t.Errorf("output missing completion line:\n%s", out)
+	}
+}
+
+func TestStatusWatchBootstrapFailedExitsNonZero(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+		watchPoll("running", "pending"),
+		watchPoll("running", "failed"),
+	}}
+
+	_, _, err := runStatusWatchCommand(t, describe)
+	if err == nil || !strings.Contains(err.Error(), "bootstrap failed") {
+		t.Fatalf("expected bootstrap failed error, got %v", err)
+	}
+}
+
+func TestStatusWatchStopsWhenVMStops(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+		watchPoll("running", "pending"),
+		watchPoll("stopping", "pending"),
+	}}
+
+	_, _, err := runStatusWatchCommand(t, describe)
+	if err == nil || !strings.Contains(err.Error(), "is now stopping") {
+		t.Fatalf("expected state change error, got %v", err)
+	}
+}
+
+func TestStatusWatchStoppedVMStarting(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+		watchPoll("stopped", "pending"),
+		watchPoll("pending", "pending"),
+	}}
+
+	out, _, err := runStatusWatchCommand(t, describe)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `VM "default" is now pending.`) {
+		t.Errorf("output missing state change line:\n%s", out)
+	}
+}
+
+func TestStatusWatchJSONIsNDJSON(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{
+		watchPoll("running", "pending"),
+		watchPoll("running", "complete"),
+	}}
+
+	out, _, err := runStatusWatchCommand(t, describe, "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), out)
+	}
+	for i, want := range []string{"pending", "complete"} {
+		var obj statusJSON
+		if err := json.Unmarshal([]byte(lines[i]), &obj); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", i, err, lines[i])
+		}
+		if obj.BootstrapStatus != want {
+			t.Errorf("line %d bootstrap_status = %q, want %q", i, obj.BootstrapStatus, want)
+		}
+	}
+}
+
+func TestStatusWatchCtrlCExitsCleanly(t *testing.T) {
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{watchPoll("running", "pending")}},
+		owner:    "alice",
+		watch: watchLoop{
+			sleep: func(ctx context.Context, d time.Duration) error { return context.Canceled },
+			clear: func(io.Writer) bool { return false },
+		},
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(io.Discard)
+	root.SetArgs([]string{"status", "--watch"})
+	if err := root.Execute(); err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+}
+
+func TestStatusWatchRejectsNonPositiveInterval(t *testing.T) {
+	describe := &cmdtest.DescribeInstancesSequence{Outputs: []*ec2.DescribeInstancesOutput{watchPoll("running", "pending")}}
+
+	_, _, err := runStatusWatchCommand(t, describe, "--interval", "0s")
+	if err == nil || !strings.Contains(err.Error(), "--interval must be positive") {
+		t.Fatalf("expected interval error, got %v", err)
+	}
+}
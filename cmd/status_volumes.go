@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/volumeperf"
+)
+
+const (
+	// volumePerfWindow is how far back status --deep reads volume metrics.
+	volumePerfWindow = 15 * time.Minute
+	// volumePerfPeriod is the CloudWatch period; EBS publishes one-minute
+	// metrics for volumes on Nitro instances.
+	volumePerfPeriod = time.Minute
+	// volumeSaturatedPct is the average IOPS or throughput usage status
+	// warns at.
+	volumeSaturatedPct = 80
+	// volumeBurstLowPct is the gp2 burst balance status warns below.
+	volumeBurstLowPct = 20
+	// rootDeviceName is the root device mint launches instances with.
+	rootDeviceName = "/dev/sda1"
+)
+
+// volumePerf is the recent performance of one EBS volume attached to a VM.
+// Nil usage and burst fields mean CloudWatch returned no data for them.
+type volumePerf struct {
+	ID   string
+	Role string
+	Type string
+	// IOPS and ThroughputMiBs are what the volume is provisioned for.
+	IOPS            int32
+	ThroughputMiBs  int32
+	IOPSUsage       *volumeperf.Usage
+	ThroughputUsage *volumeperf.Usage
+	// BurstBalancePct is a gp2 volume's remaining burst credits.
+	BurstBalancePct *float64
+}
+
+// hasData reports whether any metric was available for the volume.
+func (p volumePerf) hasData() bool {
+	return p.IOPSUsage != nil || p.ThroughputUsage != nil || p.BurstBalancePct != nil
+}
+
+// volumeRole names a volume for display: "project" for the mint project
+// volume, "root" for the boot volume, and the volume ID otherwise.
+func volumeRole(vol ec2types.Volume) string {
+	if tags.ToMap(vol.Tags)[tags.TagComponent] == tags.ComponentProjectVolume {
+		return "project"
+	}
+	for _, att := range vol.Attachments {
+		if aws.ToString(att.Device) == rootDeviceName {
+			return "root"
+		}
+	}
+	return aws.ToString(vol.VolumeId)
+}
+
+// projectVolumeEncrypted reports whether the project volume of vmName is
+// encrypted. It returns nil when the VM has no project volume.
+func projectVolumeEncrypted(ctx context.Context, describe mintaws.DescribeVolumesAPI, owner, vmName string) (*bool, error) {
+	out, err := describe.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: componentFilters(owner, vmName, tags.ComponentProjectVolume),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe volumes: %w", err)
+	}
+	if len(out.Volumes) == 0 {
+		return nil, nil
+	}
+	encrypted := aws.ToBool(out.Volumes[0].Encrypted)
+	return &encrypted, nil
+}
+
+// fetchVolumePerf returns the recent performance of the volumes attached to
+// instanceID, project volume first. A failed metrics read leaves the usage
+// fields nil rather than failing status.
+func fetchVolumePerf(ctx context.Context, describe mintaws.DescribeVolumesAPI, metrics mintaws.GetMetricDataAPI, instanceID string, now time.Time) ([]volumePerf, error) {
+	out, err := describe.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("attachment.instance-id"), Values: []string{instanceID}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe volumes: %w", err)
+	}
+
+	perfs := make([]volumePerf, len(out.Volumes))
+	var queries []cwtypes.MetricDataQuery
+	for i, vol := range out.Volumes {
+		perfs[i] = volumePerf{
+			ID:             aws.ToString(vol.VolumeId),
+			Role:           volumeRole(vol),
+			Type:           string(vol.VolumeType),
+			IOPS:           aws.ToInt32(vol.Iops),
+			ThroughputMiBs: aws.ToInt32(vol.Throughput),
+		}
+		queries = append(queries, volumeMetricQueries(i, perfs[i].ID)...)
+	}
+	sort.SliceStable(perfs, func(i, j int) bool { return volumeRoleRank(perfs[i].Role) < volumeRoleRank(perfs[j].Role) })
+	if len(queries) == 0 || metrics == nil {
+		return perfs, nil
+	}
+
+	series, err := getMetricSeries(ctx, metrics, queries, now)
+	if err != nil {
+		// Missing CloudWatch permissions degrade to "no data".
+		return perfs, nil
+	}
+	byID := make(map[string]int, len(out.Volumes))
+	for i, vol := range out.Volumes {
+		byID[aws.ToString(vol.VolumeId)] = i
+	}
+	for k := range perfs {
+		i := byID[perfs[k].ID]
+		ops := volumeperf.Combine(series[fmt.Sprintf("r%d", i)], series[fmt.Sprintf("w%d", i)])
+		if u, ok := volumeperf.Saturation(ops, volumePerfPeriod, volumePerfWindow, float64(perfs[k].IOPS)); ok {
+			perfs[k].IOPSUsage = &u
+		}
+		bytes := volumeperf.Combine(series[fmt.Sprintf("rb%d", i)], series[fmt.Sprintf("wb%d", i)])
+		if u, ok := volumeperf.Saturation(bytes, volumePerfPeriod, volumePerfWindow, float64(perfs[k].ThroughputMiBs)*1024*1024); ok {
+			perfs[k].ThroughputUsage = &u
+		}
+		if v, ok := volumeperf.Latest(series[fmt.Sprintf("bb%d", i)]); ok {
+			perfs[k].BurstBalancePct = &v
+		}
+	}
+	return perfs, nil
+}
+
+func volumeRoleRank(role string) int {
+	switch role {
+	case "project":
+		return 0
+	case "root":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// volumeMetricQueries returns the CloudWatch queries for volume i. Query IDs
+// must start with a lowercase letter, so they are the metric's short name
+// followed by i.
+func volumeMetricQueries(i int, volumeID string) []cwtypes.MetricDataQuery {
+	query := func(id, metric string, stat string) cwtypes.MetricDataQuery {
+		return cwtypes.MetricDataQuery{
+			Id: aws.String(fmt.Sprintf("%s%d", id, i)),
+			MetricStat: &cwtypes.MetricStat{
+				Metric: &cwtypes.Metric{
+					Namespace:  aws.String("AWS/EBS"),
+					MetricName: aws.String(metric),
+					Dimensions: []cwtypes.Dimension{{Name: aws.String("VolumeId"), Value: aws.String(volumeID)}},
+				},
+				Period: aws.Int32(int32(volumePerfPeriod.Seconds())),
+				Stat:   aws.String(stat),
+			},
+		}
+	}
+	return []cwtypes.MetricDataQuery{
+		query("r", "VolumeReadOps", "Sum"),
+		query("w", "VolumeWriteOps", "Sum"),
+		query("rb", "VolumeReadBytes", "Sum"),
+		query("wb", "VolumeWriteBytes", "Sum"),
+		query("bb", "BurstBalance", "Average"),
+	}
+}
+
+// getMetricSeries runs queries over the window ending at now and returns
+// the datapoints by query ID, following pagination.
+func getMetricSeries(ctx context.Context, metrics mintaws.GetMetricDataAPI, queries []cwtypes.MetricDataQuery, now time.Time) (map[string][]volumeperf.Point, error) {
+	series := make(map[string][]volumeperf.Point)
+	input := &cloudwatch.GetMetricDataInput{
+		MetricDataQueries: queries,
+		StartTime:         aws.Time(now.Add(-volumePerfWindow)),
+		EndTime:           aws.Time(now),
+	}
+	for {
+		out, err := metrics.GetMetricData(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("get metric data: %w", err)
+		}
+		for _, r := range out.MetricDataResults {
+			id := aws.ToString(r.Id)
+			for j := range min(len(r.Timestamps), len(r.Values)) {
+				series[id] = append(series[id], volumeperf.Point{Time: r.Timestamps[j], Value: r.Values[j]})
+			}
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return series, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// writeVolumePerfHuman writes the status --deep volume section.
+func writeVolumePerfHuman(w io.Writer, perfs []volumePerf, fetchErr error) {
+	fmt.Fprintf(w, "\nVolumes (last %s):\n", formatMinutes(volumePerfWindow))
+	if fetchErr != nil {
+		fmt.Fprintf(w, "  no data (%v)\n", fetchErr)
+		return
+	}
+	if len(perfs) == 0 {
+		fmt.Fprintln(w, "  no attached volumes")
+		return
+	}
+	for _, p := range perfs {
+		fmt.Fprintf(w, "  %s\n", volumePerfLine(p))
+	}
+}
+
+// volumePerfLine renders one volume, e.g. "project volume: ~85% of
+// provisioned IOPS (3000), ~12% of throughput (125 MiB/s) [WARN] — ...".
+func volumePerfLine(p volumePerf) string {
+	name := p.Role + " volume"
+	if !p.hasData() {
+		return name + ": no data"
+	}
+
+	line := name + ":"
+	sep := " "
+	if p.IOPSUsage != nil {
+		line += fmt.Sprintf("%s~%.0f%% of provisioned IOPS (%d)", sep, p.IOPSUsage.AvgPct, p.IOPS)
+		sep = ", "
+	}
+	if p.ThroughputUsage != nil {
+		line += fmt.Sprintf("%s~%.0f%% of throughput (%d MiB/s)", sep, p.ThroughputUsage.AvgPct, p.ThroughputMiBs)
+		sep = ", "
+	}
+	if p.BurstBalancePct != nil {
+		line += fmt.Sprintf("%sburst balance %.0f%% (%s)", sep, *p.BurstBalancePct, p.Type)
+	}
+
+	switch {
+	case p.BurstBalancePct != nil && *p.BurstBalancePct < volumeBurstLowPct:
+		line += fmt.Sprintf(" [WARN] — burst credits are nearly spent; move to gp3 with %s",
+			hint.Cmd(fmt.Sprintf("aws ec2 modify-volume --volume-id %s --volume-type gp3", p.ID)))
+	case p.IOPSUsage != nil && p.IOPSUsage.AvgPct >= volumeSaturatedPct:
+		line += fmt.Sprintf(" [WARN] — raise its IOPS with %s",
+			hint.Cmd(fmt.Sprintf("aws ec2 modify-volume --volume-id %s --iops <n>", p.ID)))
+	case p.ThroughputUsage != nil && p.ThroughputUsage.AvgPct >= volumeSaturatedPct:
+		line += fmt.Sprintf(" [WARN] — raise its throughput with %s",
+			hint.Cmd(fmt.Sprintf("aws ec2 modify-volume --volume-id %s --throughput <MiB/s>", p.ID)))
+	}
+	return line
+}
+
+// formatMinutes renders d as "15m".
+func formatMinutes(d time.Duration) string {
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
+
+// statusVolumeJSON is one volume in status --deep --json output.
+type statusVolumeJSON struct {
+	ID                string   `json:"volume_id"`
+	Role              string   `json:"role"`
+	Type              string   `json:"type"`
+	IOPS              int32    `json:"iops,omitempty"`
+	ThroughputMiBs    int32    `json:"throughput_mibs,omitempty"`
+	IOPSPct           *float64 `json:"iops_pct"`
+	IOPSPeakPct       *float64 `json:"iops_peak_pct"`
+	ThroughputPct     *float64 `json:"throughput_pct"`
+	ThroughputPeakPct *float64 `json:"throughput_peak_pct"`
+	BurstBalancePct   *float64 `json:"burst_balance_pct"`
+}
+
+// volumePerfJSON converts perfs for JSON output. Metrics without data are
+// null.
+func volumePerfJSON(perfs []volumePerf) []statusVolumeJSON {
+	out := make([]statusVolumeJSON, 0, len(perfs))
+	for _, p := range perfs {
+		v := statusVolumeJSON{
+			ID:              p.ID,
+			Role:            p.Role,
+			Type:            p.Type,
+			IOPS:            p.IOPS,
+			ThroughputMiBs:  p.ThroughputMiBs,
+			BurstBalancePct: p.BurstBalancePct,
+		}
+		if p.IOPSUsage != nil {
+			v.IOPSPct, v.IOPSPeakPct = &p.IOPSUsage.AvgPct, &p.IOPSUsage.PeakPct
+		}
+		if p.ThroughputUsage != nil {
+			v.ThroughputPct, v.ThroughputPeakPct = &p.ThroughputUsage.AvgPct, &p.ThroughputUsage.PeakPct
+		}
+		out = append(out, v)
+	}
+	return out
+}
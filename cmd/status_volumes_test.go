@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// statusVolumes returns a gp3 project volume and a gp2 root volume attached
+// to i-abc123, in that order, so their metric query IDs end in 0 and 1.
+func statusVolumes() *cmdtest.DescribeVolumes {
+	attach := func(device string) []ec2types.VolumeAttachment {
+		return []ec2types.VolumeAttachment{{InstanceId: aws.String("i-abc123"), Device: aws.String(device)}}
+	}
+	return &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+		{
+			VolumeId:    aws.String("vol-proj"),
+			VolumeType:  ec2types.VolumeTypeGp3,
+			Iops:        aws.Int32(3000),
+			Throughput:  aws.Int32(125),
+			Attachments: attach("/dev/xvdf"),
+			Tags:        []ec2types.Tag{{Key: aws.String("mint:component"), Value: aws.String("project-volume")}},
+		},
+		{
+			VolumeId:    aws.String("vol-root"),
+			VolumeType:  ec2types.VolumeTypeGp2,
+			Iops:        aws.Int32(600),
+			Attachments: attach("/dev/sda1"),
+		},
+	}}}
+}
+
+// metricResult returns 15 one-minute datapoints of value for query id.
+func metricResult(id string, value float64) cwtypes.MetricDataResult {
+	r := cwtypes.MetricDataResult{Id: aws.String(id)}
+	start := time.Now().Add(-15 * time.Minute).Truncate(time.Minute)
+	for i := range 15 {
+		r.Timestamps = append(r.Timestamps, start.Add(time.Duration(i)*time.Minute))
+		r.Values = append(r.Values, value)
+	}
+	return r
+}
+
+func runStatusDeep(t *testing.T, volumes *cmdtest.DescribeVolumes, metrics *cmdtest.GetMetricData, args ...string) string {
+	t.Helper()
+	hint.IsTTY = false
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now()),
+		},
+		owner:           "alice",
+		describeVolumes: volumes,
+		metrics:         metrics,
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"status", "--deep"}, args...))
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestStatusDeepVolumePerformance(t *testing.T) {
+	tests := []struct {
+		name      string
+		volumes   *cmdtest.DescribeVolumes
+		metrics   *cmdtest.GetMetricData
+		want      []string
+		notWanted []string
+	}{
+		{
+			name:    "saturated project volume",
+			volumes: statusVolumes(),
+			metrics: &cmdtest.GetMetricData{Output: &cloudwatch.GetMetricDataOutput{MetricDataResults: []cwtypes.MetricDataResult{
+				// 1500 + 1200 IOPS against 3000 provisioned.
+				metricResult("r0", 1500*60),
+				metricResult("w0", 1200*60),
+				// 25 MiB/s against 125 MiB/s.
+				metricResult("wb0", 25*1024*1024*60),
+				metricResult("r1", 60*60),
+				metricResult("bb1", 85),
+			}}},
+			want: []string{
+				"Volumes (last 15m):",
+				"project volume: ~90% of provisioned IOPS (3000), ~20% of throughput (125 MiB/s) [WARN] — raise its IOPS with `aws ec2 modify-volume --volume-id vol-proj --iops <n>`",
+				"root volume: ~10% of provisioned IOPS (600), burst balance 85% (gp2)\n",
+			},
+		},
+		{
+			name:    "idle volumes",
+			volumes: statusVolumes(),
+			metrics: &cmdtest.GetMetricData{Output: &cloudwatch.GetMetricDataOutput{MetricDataResults: []cwtypes.MetricDataResult{
+				metricResult("r0", 0),
+				metricResult("w0", 0),
+				metricResult("bb1", 12),
+			}}},
+			want: []string{
+				"project volume: ~0% of provisioned IOPS (3000)\n",
+				"root volume: burst balance 12% (gp2) [WARN] — burst credits are nearly spent; move to gp3 with `aws ec2 modify-volume --volume-id vol-root --volume-type gp3`",
+			},
+			notWanted: []string{"raise its IOPS"},
+		},
+		{
+			name:    "missing metrics",
+			volumes: statusVolumes(),
+			metrics: &cmdtest.GetMetricData{Output: &cloudwatch.GetMetricDataOutput{}},
+			want:    []string{"project volume: no data\n", "root volume: no data\n"},
+		},
+		{
+			name:    "CloudWatch access denied",
+			volumes: statusVolumes(),
+			metrics: &cmdtest.GetMetricData{Err: &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized"}},
+			want:    []string{"project volume: no data\n", "root volume: no data\n"},
+		},
+		{
+			name:    "volumes cannot be listed",
+			volumes: &cmdtest.DescribeVolumes{Err: errors.New("UnauthorizedOperation")},
+			metrics: &cmdtest.GetMetricData{},
+			want:    []string{"Volumes (last 15m):\n  no data (describe volumes: UnauthorizedOperation)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := runStatusDeep(t, tt.volumes, tt.metrics)
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q, got:\n%s", want, out)
+				}
+			}
+			for _, bad := range tt.notWanted {
+				if strings.Contains(out, bad) {
+					t.Errorf("output should not contain %q, got:\n%s", bad, out)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusDeepQueriesLast15Minutes(t *testing.T) {
+	metrics := &cmdtest.GetMetricData{Output: &cloudwatch.GetMetricDataOutput{}}
+	runStatusDeep(t, statusVolumes(), metrics)
+
+	in := metrics.Input
+	if in == nil {
+		t.Fatal("GetMetricData not called")
+	}
+	if got := aws.ToTime(in.EndTime).Sub(aws.ToTime(in.StartTime)); got != 15*time.Minute {
+		t.Errorf("window = %v, want 15m", got)
+	}
+	if len(in.MetricDataQueries) != 10 {
+		t.Fatalf("queries = %d, want 5 per volume", len(in.MetricDataQueries))
+	}
+	q := in.MetricDataQueries[0]
+	if aws.ToString(q.Id) != "r0" || aws.ToString(q.MetricStat.Metric.MetricName) != "VolumeReadOps" ||
+		aws.ToString(q.MetricStat.Metric.Dimensions[0].Value) != "vol-proj" || aws.ToString(q.MetricStat.Stat) != "Sum" {
+		t.Errorf("first query = %+v", q)
+	}
+}
+
+func TestStatusDeepJSON(t *testing.T) {
+	metrics := &cmdtest.GetMetricData{Output: &cloudwatch.GetMetricDataOutput{MetricDataResults: []cwtypes.MetricDataResult{
+		metricResult("r0", 1500*60),
+		metricResult("w0", 1200*60),
+	}}}
+	out := runStatusDeep(t, statusVolumes(), metrics, "--json")
+
+	var got struct {
+		Volumes []statusVolumeJSON `json:"volumes"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(got.Volumes) != 2 {
+		t.Fatalf("volumes = %d, want 2", len(got.Volumes))
+	}
+	proj, root := got.Volumes[0], got.Volumes[1]
+	if proj.Role != "project" || proj.IOPSPct == nil || *proj.IOPSPct < 89.9 || *proj.IOPSPct > 90.1 {
+		t.Errorf("project volume = %+v, want ~90%% IOPS", proj)
+	}
+	if root.Role != "root" || root.IOPSPct != nil || root.BurstBalancePct != nil {
+		t.Errorf("root volume = %+v, want no data", root)
+	}
+}
+
+func TestStatusWithoutDeepSkipsVolumes(t *testing.T) {
+	metrics := &cmdtest.GetMetricData{}
+	deps := &statusDeps{
+		describe: &cmdtest.DescribeInstances{
+			Output: makeInstanceWithTime("i-abc123", "default", "alice", "running", "1.2.3.4", "m6i.xlarge", "complete", time.Now()),
+		},
+		owner:           "alice",
+		describeVolumes: statusVolumes(),
+		metrics:         metrics,
+	}
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newStatusCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetArgs([]string{"status"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics.Input != nil || strings.Contains(buf.String(), "Volumes (last") {
+		t.Errorf("status without --deep should not read volume metrics, got:\n%s", buf.String())
+	}
+}
+
+func TestStatusProjectVolumeEncryption(t *testing.T) {
+	hint.IsTTY = false
+	for _, tc := range []struct {
+		name      string
+		encrypted *bool
+		wantLine  string
+	}{
+		{name: "encrypted", encrypted: aws.Bool(true), wantLine: "Proj Vol:  50 GiB (encrypted)"},
+		{name: "not encrypted", encrypted: aws.Bool(false), wantLine: "Proj Vol:  50 GiB (not encrypted)"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := makeInstanceWithTime("i-abc123", "default", "alice", "stopped", "", "m6i.xlarge", "complete", time.Now())
+			inst := &instance.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String("mint:project-volume-gb"), Value: aws.String("50")})
+			deps := &statusDeps{
+				describe: &cmdtest.DescribeInstances{Output: instance},
+				owner:    "alice",
+				describeVolumes: &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+					{VolumeId: aws.String("vol-proj"), Encrypted: tc.encrypted},
+				}}},
+			}
+
+			for _, args := range [][]string{nil, {"--json"}} {
+				buf := new(bytes.Buffer)
+				root := cmdtest.NewRoot()
+				root.AddCommand(newStatusCommandWithDeps(deps))
+				root.SetOut(buf)
+				root.SetErr(buf)
+				root.SetArgs(append([]string{"status"}, args...))
+				if err := root.Execute(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if args == nil {
+					if !strings.Contains(buf.String(), tc.wantLine) {
+						t.Errorf("output missing %q:\n%s", tc.wantLine, buf.String())
+					}
+					continue
+				}
+				var got statusJSON
+				if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				if got.ProjectVolumeEncrypted == nil || *got.ProjectVolumeEncrypted != *tc.encrypted {
+					t.Errorf("project_volume_encrypted = %v, want %v", got.ProjectVolumeEncrypted, *tc.encrypted)
+				}
+			}
+		})
+	}
+}
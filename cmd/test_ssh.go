@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// testSSHDialTimeout bounds the TCP probe of the SSH port.
+const testSSHDialTimeout = 3 * time.Second
+
+// testSSHHandshakeTimeout bounds the SSH handshake and authentication probe.
+const testSSHHandshakeTimeout = 10 * time.Second
+
+// testSSHEcho is the string the round-trip probe echoes on the VM.
+const testSSHEcho = "mint-test-ssh"
+
+// The layers test-ssh probes, in order.
+const (
+	sshLayerAWS             = "aws"
+	sshLayerInstance        = "instance"
+	sshLayerTCP             = "tcp"
+	sshLayerInstanceConnect = "instance-connect"
+	sshLayerSSH             = "ssh"
+	sshLayerEcho            = "echo"
+)
+
+// sshLayers lists the layers in probe order.
+var sshLayers = []string{
+	sshLayerAWS, sshLayerInstance, sshLayerTCP,
+	sshLayerInstanceConnect, sshLayerSSH, sshLayerEcho,
+}
+
+// sshProbeSession is an authenticated SSH connection that can run a command.
+type sshProbeSession interface {
+	Output(command string) ([]byte, error)
+	Close() error
+}
+
+// sshProbeConnector opens an SSH connection to address with config. The
+// production implementation uses golang.org/x/crypto/ssh; tests inject fakes.
+type sshProbeConnector func(ctx context.Context, address string, config *ssh.ClientConfig) (sshProbeSession, error)
+
+// testSSHDeps holds the injectable dependencies for the test-ssh command.
+type testSSHDeps struct {
+	describe     mintaws.DescribeInstancesAPI
+	sendKey      mintaws.SendSSHPublicKeyAPI
+	owner        string
+	hostKeyStore *sshconfig.HostKeyStore
+	dial         func(ctx context.Context, network, address string) (net.Conn, error)
+	connect      sshProbeConnector
+	sshPort      int // ssh_port from config; zero means defaultSSHPort
+}
+
+// newTestSSHCommand creates the production test-ssh command.
+func newTestSSHCommand() *cobra.Command {
+	return newTestSSHCommandWithDeps(nil)
+}
+
+// newTestSSHCommandWithDeps creates the test-ssh command with explicit
+// dependencies for testing.
+func newTestSSHCommandWithDeps(deps *testSSHDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test-ssh",
+		Short: "Diagnose SSH connectivity to the VM layer by layer",
+		Long: "Probe the path to the VM's SSH server one layer at a time: the VM " +
+			"lookup, the instance state and public IP, a TCP connection to the " +
+			"SSH port (41122 unless ssh_port is set), the EC2 Instance Connect key push, the SSH handshake and " +
+			"authentication, and a command round-trip. Each layer reports PASS " +
+			"or FAIL with a hint for fixing it; probing stops at the first " +
+			"failure. The probes connect directly and do not apply ssh_extra_args.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps != nil {
+				return runTestSSH(cmd, deps)
+			}
+			clients := awsClientsFromContext(cmd.Context())
+			if clients == nil {
+				return fmt.Errorf("AWS clients not configured")
+			}
+			return runTestSSH(cmd, &testSSHDeps{
+				describe:     clients.ec2Client,
+				sendKey:      clients.sendKey,
+				owner:        clients.owner,
+				hostKeyStore: sshconfig.NewHostKeyStore(config.DefaultConfigDir()),
+				sshPort:      clients.sshOptions.Port,
+			})
+		},
+	}
+
+	return cmd
+}
+
+// sshProbe is the outcome of one layer of test-ssh.
+type sshProbe struct {
+	Layer  string `json:"layer"`
+	Status string `json:"status"` // "PASS", "FAIL", or "SKIP"
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// testSSHJSON is the --json output of test-ssh.
+type testSSHJSON struct {
+	VM     string     `json:"vm"`
+	OK     bool       `json:"ok"`
+	Probes []sshProbe `json:"probes"`
+}
+
+// runTestSSH runs the probes and reports them.
+func runTestSSH(cmd *cobra.Command, deps *testSSHDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+
+	probes := probeSSH(ctx, deps, vmName)
+	failed := ""
+	for _, p := range probes {
+		if p.Status == "FAIL" {
+			failed = p.Layer
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(testSSHJSON{VM: vmName, OK: failed == "", Probes: probes}); err != nil {
+			return fmt.Errorf("encoding JSON: %w", err)
+		}
+		if failed != "" {
+			return silentExitError{}
+		}
+		return nil
+	}
+
+	writeSSHProbes(w, probes)
+	if failed != "" {
+		return fmt.Errorf("SSH to VM %q fails at the %s layer", vmName, failed)
+	}
+	fmt.Fprintf(w, "\nSSH to VM %q works.\n", vmName)
+	return nil
+}
+
+// writeSSHProbes prints one line per probe, with the hint of a failed probe
+// indented beneath it.
+func writeSSHProbes(w io.Writer, probes []sshProbe) {
+	for _, p := range probes {
+		fmt.Fprintf(w, "[%s] %s: %s\n", p.Status, p.Layer, p.Detail)
+		if p.Hint != "" {
+			fmt.Fprintf(w, "       %s\n", p.Hint)
+		}
+	}
+}
+
+// probeSSH runs each layer in order and stops at the first failure. The
+// layers after it are reported as SKIP so the output always covers the
+// whole path.
+func probeSSH(ctx context.Context, deps *testSSHDeps, vmName string) []sshProbe {
+	var probes []sshProbe
+	pass := func(layer, detail string) {
+		probes = append(probes, sshProbe{Layer: layer, Status: "PASS", Detail: detail})
+	}
+	fail := func(layer, detail, fix string) []sshProbe {
+		probes = append(probes, sshProbe{Layer: layer, Status: "FAIL", Detail: detail, Hint: fix})
+		for _, l := range sshLayers[len(probes):] {
+			probes = append(probes, sshProbe{Layer: l, Status: "SKIP", Detail: "not attempted"})
+		}
+		return probes
+	}
+
+	// 1. The VM can be looked up.
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fail(sshLayerAWS, fmt.Sprintf("could not describe instances: %v", err),
+			fmt.Sprintf("Check your network, VPN, and AWS credentials with %s.", hint.Cmd("mint doctor")))
+	}
+	if found == nil {
+		return fail(sshLayerAWS, fmt.Sprintf("no VM %q found for owner %q", vmName, deps.owner),
+			fmt.Sprintf("Run %s to see your VMs, or %s to create this one.", hint.Cmd("mint list"), hint.Cmd("mint up")))
+	}
+	pass(sshLayerAWS, fmt.Sprintf("found VM %q (%s)", vmName, found.ID))
+
+	// 2. The instance is running and has an address to connect to.
+	if found.State != string(ec2types.InstanceStateNameRunning) {
+		return fail(sshLayerInstance, fmt.Sprintf("instance is %s", found.State),
+			fmt.Sprintf("Run %s to start it.", hint.Cmd("mint up")))
+	}
+	if found.PublicIP == "" {
+		return fail(sshLayerInstance, "instance is running but has no public IP",
+			fmt.Sprintf("test-ssh probes direct connections only. A private VM is reached through an Instance Connect Endpoint; try %s.", hint.Cmd("mint ssh")))
+	}
+	instanceDetail := fmt.Sprintf("running at %s", found.PublicIP)
+	if found.BootstrapStatus != "" {
+		instanceDetail += fmt.Sprintf(", bootstrap %s", found.BootstrapStatus)
+	}
+	pass(sshLayerInstance, instanceDetail)
+
+	// 3. The SSH port accepts a TCP connection.
+	port := sshPortOrDefault(deps.sshPort)
+	address := net.JoinHostPort(found.PublicIP, strconv.Itoa(port))
+	dial := deps.dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, testSSHDialTimeout)
+	start := time.Now()
+	conn, err := dial(dialCtx, "tcp", address)
+	cancel()
+	if err != nil {
+		detail, fix := tcpProbeFailure(address, port, err, found.BootstrapStatus)
+		return fail(sshLayerTCP, detail, fix)
+	}
+	conn.Close()
+	pass(sshLayerTCP, fmt.Sprintf("%s accepted a connection in %s", address, time.Since(start).Round(time.Millisecond)))
+
+	// 4. EC2 Instance Connect accepts an ephemeral key.
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fail(sshLayerInstanceConnect, fmt.Sprintf("generating ephemeral key: %v", err), "")
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return fail(sshLayerInstanceConnect, fmt.Sprintf("generating ephemeral key: %v", err), "")
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return fail(sshLayerInstanceConnect, fmt.Sprintf("generating ephemeral key: %v", err), "")
+	}
+	if _, err := deps.sendKey.SendSSHPublicKey(ctx, &ec2instanceconnect.SendSSHPublicKeyInput{
+		InstanceId:       aws.String(found.ID),
+		InstanceOSUser:   aws.String(defaultSSHUser),
+		SSHPublicKey:     aws.String(strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))),
+		AvailabilityZone: aws.String(found.AvailabilityZone),
+	}); err != nil {
+		detail, fix := instanceConnectProbeFailure(err)
+		return fail(sshLayerInstanceConnect, detail, fix)
+	}
+	pass(sshLayerInstanceConnect, fmt.Sprintf("ephemeral key pushed for %s", defaultSSHUser))
+
+	// 5. The SSH handshake and authentication succeed.
+	var hostKeyMismatch string
+	clientConfig := &ssh.ClientConfig{
+		User:    defaultSSHUser,
+		Auth:    []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		Timeout: testSSHHandshakeTimeout,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			// Only a recorded key that no longer matches fails; test-ssh
+			// never records keys itself.
+			if deps.hostKeyStore == nil {
+				return nil
+			}
+			fingerprint := ssh.FingerprintSHA256(key)
+			matched, existing, err := deps.hostKeyStore.CheckKey(vmName, fingerprint)
+			if err != nil || existing == "" || matched {
+				return nil
+			}
+			hostKeyMismatch = fmt.Sprintf("host key changed: stored %s, VM presented %s", existing, fingerprint)
+			return errors.New(hostKeyMismatch)
+		},
+	}
+	connect := deps.connect
+	if connect == nil {
+		connect = connectSSHProbe
+	}
+	session, err := connect(ctx, address, clientConfig)
+	if err != nil {
+		detail, fix := sshProbeFailure(err, hostKeyMismatch, vmName)
+		return fail(sshLayerSSH, detail, fix)
+	}
+	defer session.Close()
+	pass(sshLayerSSH, fmt.Sprintf("authenticated as %s", defaultSSHUser))
+
+	// 6. A command runs and its output comes back.
+	start = time.Now()
+	out, err := session.Output("echo " + testSSHEcho)
+	if err != nil {
+		return fail(sshLayerEcho, fmt.Sprintf("running a command failed: %v", err),
+			fmt.Sprintf("The login works but the shell does not; the VM's disk may be full or its bootstrap broken. Check %s.", hint.Cmd("mint doctor")))
+	}
+	if got := strings.TrimSpace(string(out)); got != testSSHEcho {
+		return fail(sshLayerEcho, fmt.Sprintf("echo returned %q, want %q", got, testSSHEcho),
+			"A login script on the VM is printing extra output or replacing the command.")
+	}
+	pass(sshLayerEcho, fmt.Sprintf("round-trip in %s", time.Since(start).Round(time.Millisecond)))
+
+	return probes
+}
+
+// tcpProbeFailure explains a failed TCP connection to the SSH port.
+func tcpProbeFailure(address string, port int, err error, bootstrap string) (detail, fix string) {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return fmt.Sprintf("no answer from %s within %s", address, testSSHDialTimeout),
+			fmt.Sprintf("Packets are being dropped. The security group rule for port %d may be missing (%s restores it), "+
+				"your IP may have changed onto a network that blocks the port, or a network ACL blocks it.",
+				port, hint.Cmd("mint doctor --fix"))
+	case errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(err.Error(), "connection refused"):
+		fix := "The VM answered but sshd is not listening on the port."
+		if bootstrap == "pending" {
+			fix += " Bootstrap is still running; try again in a minute."
+		} else {
+			fix += fmt.Sprintf(" Check %s, or rebuild the VM with %s.", hint.Cmd("mint doctor"), hint.Cmd("mint recreate"))
+		}
+		return fmt.Sprintf("%s refused the connection", address), fix
+	}
+	return fmt.Sprintf("connecting to %s: %v", address, err),
+		"Check your local network and VPN."
+}
+
+// instanceConnectProbeFailure explains a rejected SendSSHPublicKey call.
+func instanceConnectProbeFailure(err error) (detail, fix string) {
+	detail = fmt.Sprintf("EC2 Instance Connect rejected the key: %v", err)
+	var ae smithy.APIError
+	if errors.As(err, &ae) && (ae.ErrorCode() == "AccessDenied" || ae.ErrorCode() == "AccessDeniedException") {
+		return detail, fmt.Sprintf("Your IAM identity may not call ec2-instance-connect:SendSSHPublicKey; an admin can grant it with %s.",
+			hint.Cmd("mint admin attach-policy"))
+	}
+	return detail, fmt.Sprintf("Check that the instance is running and reachable with %s.", hint.Cmd("mint doctor"))
+}
+
+// sshProbeFailure explains a failed SSH handshake or authentication.
+func sshProbeFailure(err error, hostKeyMismatch, vmName string) (detail, fix string) {
+	switch {
+	case hostKeyMismatch != "":
+		return hostKeyMismatch,
+			fmt.Sprintf("The VM was rebuilt, or this is a man-in-the-middle. If it was rebuilt, run %s.", hint.Cmd("mint hostkey reset "+vmName))
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		return fmt.Sprintf("the VM rejected the pushed key: %v", err),
+			fmt.Sprintf("The EC2 Instance Connect agent on the VM did not pick up the key, which happens when its instance profile "+
+				"or ec2-instance-connect package is broken. %s rebuilds the VM.", hint.Cmd("mint recreate"))
+	}
+	return fmt.Sprintf("SSH handshake failed: %v", err),
+		fmt.Sprintf("sshd accepted the connection but closed it. Check %s.", hint.Cmd("mint doctor"))
+}
+
+// sshProbeClient adapts an *ssh.Client to sshProbeSession.
+type sshProbeClient struct {
+	client *ssh.Client
+}
+
+func (c *sshProbeClient) Output(command string) ([]byte, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	return session.Output(command)
+}
+
+func (c *sshProbeClient) Close() error {
+	return c.client.Close()
+}
+
+// connectSSHProbe is the production sshProbeConnector.
+func connectSSHProbe(ctx context.Context, address string, config *ssh.ClientConfig) (sshProbeSession, error) {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(config.Timeout))
+	c, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &sshProbeClient{client: ssh.NewClient(c, chans, reqs)}, nil
+}
@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+)
+
+// fakeProbeSession is an sshProbeSession with a canned echo result.
+type fakeProbeSession struct {
+	output string
+	err    error
+}
+
+func (s *fakeProbeSession) Output(command string) ([]byte, error) {
+	return []byte(s.output), s.err
+}
+
+func (s *fakeProbeSession) Close() error { return nil }
+
+// fakeConnector presents hostKey to the client config's host key callback,
+// then returns session or err.
+func fakeConnector(hostKey ssh.PublicKey, session *fakeProbeSession, err error) sshProbeConnector {
+	return func(ctx context.Context, address string, config *ssh.ClientConfig) (sshProbeSession, error) {
+		if hostKey != nil {
+			if cbErr := config.HostKeyCallback(address, &net.TCPAddr{}, hostKey); cbErr != nil {
+				return nil, fmt.Errorf("ssh: handshake failed: %w", cbErr)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return session, nil
+	}
+}
+
+// okDial is a TCP dialer whose connections always succeed.
+func okDial(ctx context.Context, network, address string) (net.Conn, error) {
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func failDial(err error) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, err
+	}
+}
+
+// newHappyTestSSHDeps returns deps under which every layer passes.
+func newHappyTestSSHDeps() *testSSHDeps {
+	return &testSSHDeps{
+		describe: &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "1.2.3.4", "us-east-1a")},
+		sendKey:  &cmdtest.SendSSHPublicKey{},
+		owner:    "alice",
+		dial:     okDial,
+		connect:  fakeConnector(nil, &fakeProbeSession{output: testSSHEcho + "\n"}, nil),
+	}
+}
+
+func runTestSSHCommand(t *testing.T, deps *testSSHDeps, args ...string) (string, error) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	root := cmdtest.NewRoot()
+	root.AddCommand(newTestSSHCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs(append([]string{"test-ssh"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func testHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestTestSSHAllLayersPass(t *testing.T) {
+	out, err := runTestSSHCommand(t, newHappyTestSSHDeps())
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	for _, layer := range sshLayers {
+		if !strings.Contains(out, "[PASS] "+layer+":") {
+			t.Errorf("output missing PASS for %s:\n%s", layer, out)
+		}
+	}
+	if !strings.Contains(out, `SSH to VM "default" works.`) {
+		t.Errorf("output missing verdict:\n%s", out)
+	}
+}
+
+func TestTestSSHFailureAtEachLayer(t *testing.T) {
+	hint.IsTTY = false
+
+	hostKey := testHostKey(t)
+	store := sshconfig.NewHostKeyStore(t.TempDir())
+	if err := store.RecordKey("default", "SHA256:previous"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(*testSSHDeps)
+		layer    string
+		wantHint string
+	}{
+		{
+			name:     "describe fails",
+			change:   func(d *testSSHDeps) { d.describe = &cmdtest.DescribeInstances{Err: errors.New("RequestExpired")} },
+			layer:    sshLayerAWS,
+			wantHint: "`mint doctor`",
+		},
+		{
+			name:     "VM not found",
+			change:   func(d *testSSHDeps) { d.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}} },
+			layer:    sshLayerAWS,
+			wantHint: "`mint list`",
+		},
+		{
+			name: "instance stopped",
+			change: func(d *testSSHDeps) {
+				d.describe = &cmdtest.DescribeInstances{Output: makeStoppedInstanceForProject("i-abc123", "default", "alice")}
+			},
+			layer:    sshLayerInstance,
+			wantHint: "`mint up` to start it",
+		},
+		{
+			name: "no public IP",
+			change: func(d *testSSHDeps) {
+				d.describe = &cmdtest.DescribeInstances{Output: makeRunningInstanceForProject("i-abc123", "default", "alice", "", "us-east-1a")}
+			},
+			layer:    sshLayerInstance,
+			wantHint: "Instance Connect Endpoint",
+		},
+		{
+			name:     "TCP timeout",
+			change:   func(d *testSSHDeps) { d.dial = failDial(context.DeadlineExceeded) },
+			layer:    sshLayerTCP,
+			wantHint: "security group rule for port 41122 may be missing (`mint doctor --fix` restores it), your IP may have changed",
+		},
+		{
+			name: "TCP refused",
+			change: func(d *testSSHDeps) {
+				d.dial = failDial(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+			},
+			layer:    sshLayerTCP,
+			wantHint: "sshd is not listening",
+		},
+		{
+			name: "Instance Connect access denied",
+			change: func(d *testSSHDeps) {
+				d.sendKey = &cmdtest.SendSSHPublicKey{Err: &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}}
+			},
+			layer:    sshLayerInstanceConnect,
+			wantHint: "`mint admin attach-policy`",
+		},
+		{
+			name: "key rejected at authentication",
+			change: func(d *testSSHDeps) {
+				d.connect = fakeConnector(nil, nil, errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]"))
+			},
+			layer:    sshLayerSSH,
+			wantHint: "instance profile",
+		},
+		{
+			name: "host key changed",
+			change: func(d *testSSHDeps) {
+				d.hostKeyStore = store
+				d.connect = fakeConnector(hostKey, &fakeProbeSession{output: testSSHEcho}, nil)
+			},
+			layer:    sshLayerSSH,
+			wantHint: "mint hostkey reset default",
+		},
+		{
+			name: "remote command fails",
+			change: func(d *testSSHDeps) {
+				d.connect = fakeConnector(nil, &fakeProbeSession{err: errors.New("Process exited with status 1")}, nil)
+			},
+			layer:    sshLayerEcho,
+			wantHint: "the shell does not",
+		},
+		{
+			name: "login script pollutes output",
+			change: func(d *testSSHDeps) {
+				d.connect = fakeConnector(nil, &fakeProbeSession{output: "Welcome!\n" + testSSHEcho}, nil)
+			},
+			layer:    sshLayerEcho,
+			wantHint: "login script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := newHappyTestSSHDeps()
+			tt.change(deps)
+
+			out, err := runTestSSHCommand(t, deps, "--json")
+			if !errors.As(err, &silentExitError{}) {
+				t.Fatalf("error = %v, want silent exit\n%s", err, out)
+			}
+			var result testSSHJSON
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, out)
+			}
+			if result.OK || result.VM != "default" || len(result.Probes) != len(sshLayers) {
+				t.Fatalf("result = %+v", result)
+			}
+
+			failedAt := -1
+			for i, p := range result.Probes {
+				if p.Layer != sshLayers[i] {
+					t.Errorf("probe %d layer = %q, want %q", i, p.Layer, sshLayers[i])
+				}
+				switch {
+				case failedAt < 0 && p.Status == "FAIL":
+					failedAt = i
+					if p.Layer != tt.layer {
+						t.Errorf("failed at %s, want %s", p.Layer, tt.layer)
+					}
+					if !strings.Contains(p.Hint, tt.wantHint) {
+						t.Errorf("hint = %q, want containing %q", p.Hint, tt.wantHint)
+					}
+				case failedAt < 0 && p.Status != "PASS":
+					t.Errorf("layer %s before the failure is %s", p.Layer, p.Status)
+				case failedAt >= 0 && p.Status != "SKIP":
+					t.Errorf("layer %s after the failure is %s, want SKIP", p.Layer, p.Status)
+				}
+			}
+			if failedAt < 0 {
+				t.Fatalf("no layer failed: %+v", result.Probes)
+			}
+		})
+	}
+}
+
+func TestTestSSHHumanFailure(t *testing.T) {
+	deps := newHappyTestSSHDeps()
+	deps.dial = failDial(context.DeadlineExceeded)
+
+	out, err := runTestSSHCommand(t, deps)
+	if err == nil || err.Error() != `SSH to VM "default" fails at the tcp layer` {
+		t.Fatalf("error = %v", err)
+	}
+	for _, want := range []string{
+		"[PASS] instance: running at 1.2.3.4",
+		"[FAIL] tcp: no answer from 1.2.3.4:41122 within 3s",
+		"[SKIP] echo: not attempted",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTestSSHMatchingHostKeyPasses(t *testing.T) {
+	hostKey := testHostKey(t)
+	store := sshconfig.NewHostKeyStore(t.TempDir())
+	if err := store.RecordKey("default", ssh.FingerprintSHA256(hostKey)); err != nil {
+		t.Fatal(err)
+	}
+	deps := newHappyTestSSHDeps()
+	deps.hostKeyStore = store
+	deps.connect = fakeConnector(hostKey, &fakeProbeSession{output: testSSHEcho}, nil)
+
+	if out, err := runTestSSHCommand(t, deps); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,4 @@
+#!/bin/bash
+# Acme user bootstrap: install the team CLI.
+set -euo pipefail
+curl -fsSL https://tools.acme.example/install.sh | bash
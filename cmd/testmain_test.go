@@ -14,16 +14,22 @@ export MINT_EFS_ID="__MINT_EFS_ID__"
 export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
 export MINT_VM_NAME="__MINT_VM_NAME__"
 export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
 export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
 _STUB_URL="__MINT_BOOTSTRAP_URL__"
 _STUB_SHA256="__MINT_BOOTSTRAP_SHA256__"
+_STUB_INLINE="__MINT_BOOTSTRAP_INLINE__"
 exec /tmp/bootstrap.sh
 `
 
 // TestMain loads the test stub template once for the entire cmd test package.
 // This ensures that bootstrap.RenderStub does not fail with "stub template not
-// loaded" during any test that exercises the provision launch path.
+// loaded" during any test that exercises the provision launch path. The SSH
+// config writers see a modern ssh client, whatever the machine has.
 func TestMain(m *testing.M) {
 	bootstrap.SetStub([]byte(stubTemplateForTests))
+	localSSHVersion = func() (string, error) { return "OpenSSH_9.6p1, LibreSSL 3.3.6", nil }
 	m.Run()
 }
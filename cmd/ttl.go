@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// addTTLFlags registers --ttl and --extend-ttl on mint up.
+func addTTLFlags(cmd *cobra.Command) {
+	cmd.Flags().String("ttl", "", "Tag a new VM, its project volume, and its Elastic IP to expire after this long, e.g. 72h or 3d (nothing is deleted automatically)")
+	cmd.Flags().String("extend-ttl", "", "Push back the expiry of an existing VM by this long, e.g. 24h, instead of provisioning")
+	cmd.MarkFlagsMutuallyExclusive("ttl", "extend-ttl")
+}
+
+// ttlFlag parses the duration flag name. An unset flag is zero.
+func ttlFlag(cmd *cobra.Command, name string) (time.Duration, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := format.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("--%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("--%s must be longer than 0", name)
+	}
+	return d, nil
+}
+
+// formatExpiry describes a mint:expires value relative to now: "in 1d 17h",
+// or "EXPIRED 3h ago" (in red on a TTY) once it is past the grace window.
+// An unparseable value is shown as it is.
+func formatExpiry(value string, now time.Time) string {
+	expires, err := tags.ParseExpires(value)
+	if err != nil {
+		return value
+	}
+	if tags.Expired(expires, now) {
+		return hint.Alert("EXPIRED " + format.FormatDuration(now.Sub(expires).Truncate(time.Minute)) + " ago")
+	}
+	if left := expires.Sub(now); left > 0 {
+		return "in " + format.FormatDuration(left.Truncate(time.Minute))
+	}
+	return "now"
+}
+
+// destroyCommand returns the mint destroy command for vmName.
+func destroyCommand(vmName string) string {
+	if vmName == "default" {
+		return "mint destroy"
+	}
+	return "mint destroy --vm " + vmName
+}
+
+// extendTTLResult is what mint up --extend-ttl --json prints.
+type extendTTLResult struct {
+	VM        string    `json:"vm"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Resources []string  `json:"resources"`
+}
+
+// runExtendTTL pushes back the mint:expires tag of vmName's instance,
+// project volume, and Elastic IP by d. An expiry still ahead is extended
+// from when it falls; one already past, or a VM without one, from now.
+func runExtendTTL(ctx context.Context, cmd *cobra.Command, deps *upDeps, vmName string, d time.Duration, jsonOutput bool) error {
+	if deps.describe == nil || deps.createTags == nil {
+		return fmt.Errorf("--extend-ttl is not available: AWS clients not configured")
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("finding VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("VM %q not found — --extend-ttl changes an existing VM; create one with %s",
+			vmName, hint.Cmd("mint up --ttl <duration>"))
+	}
+
+	now := time.Now()
+	base := now
+	if current, err := tags.ParseExpires(found.Tags[tags.TagExpires]); err == nil && current.After(now) {
+		base = current
+	}
+	expires := base.Add(d).UTC().Truncate(time.Second)
+
+	resources := []string{found.ID}
+	if deps.describeVolumes != nil {
+		out, err := deps.describeVolumes.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+			Filters: componentFilters(deps.owner, vmName, tags.ComponentProjectVolume),
+		})
+		if err != nil {
+			return fmt.Errorf("discovering project volume: %w", err)
+		}
+		for _, v := range out.Volumes {
+			resources = append(resources, aws.ToString(v.VolumeId))
+		}
+	}
+	if deps.describeAddrs != nil {
+		out, err := deps.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+			Filters: componentFilters(deps.owner, vmName, tags.ComponentElasticIP),
+		})
+		if err != nil {
+			return fmt.Errorf("discovering Elastic IP: %w", err)
+		}
+		for _, a := range out.Addresses {
+			resources = append(resources, aws.ToString(a.AllocationId))
+		}
+	}
+
+	if _, err := deps.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: resources,
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagExpires), Value: aws.String(tags.FormatExpires(expires))}},
+	}); err != nil {
+		return fmt.Errorf("tagging %s: %w", tags.TagExpires, err)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(extendTTLResult{VM: vmName, ExpiresAt: expires, Resources: resources})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "VM %q now expires at %s (%s).\n",
+		vmName, expires.Format("2006-01-02 15:04 UTC"), formatExpiry(tags.FormatExpires(expires), now))
+	return nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// expiresTag returns a mint:expires tag at t.
+func expiresTag(t time.Time) ec2types.Tag {
+	return ec2types.Tag{Key: aws.String(tags.TagExpires), Value: aws.String(tags.FormatExpires(t))}
+}
+
+func TestFormatExpiry(t *testing.T) {
+	hint.IsTTY = false
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "ahead", value: "2026-10-17T05:00:30Z", want: "in 1d 17h"},
+		{name: "past, within the grace window", value: "2026-10-15T11:58:00Z", want: "now"},
+		{name: "past the grace window", value: "2026-10-15T09:00:00Z", want: "EXPIRED 3h ago"},
+		{name: "unparseable", value: "soon", want: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatExpiry(tt.value, now); got != tt.want {
+				t.Errorf("formatExpiry(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpTTLFlagValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "bad duration", args: []string{"--ttl", "forever"}, wantErr: "--ttl: invalid duration"},
+		{name: "zero", args: []string{"--extend-ttl", "0h"}, wantErr: "--extend-ttl must be longer than 0"},
+		{name: "both", args: []string{"--ttl", "72h", "--extend-ttl", "24h"}, wantErr: "none of the others can be"},
+		{name: "extend with batch", args: []string{"--extend-ttl", "24h", "--name-prefix", "exp"}, wantErr: "--extend-ttl cannot be combined with --name-prefix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := cmdtest.NewRoot(newUpCommandWithDeps(newTestUpDeps()))
+			root.SetOut(new(bytes.Buffer))
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"up"}, tt.args...))
+			if err := root.Execute(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpExtendTTL(t *testing.T) {
+	hint.IsTTY = false
+	future := time.Now().Add(10 * time.Hour).UTC().Truncate(time.Second)
+	tests := []struct {
+		name    string
+		current []ec2types.Tag
+		// wantFrom is the time the extension is added to; zero is now.
+		wantFrom time.Time
+	}{
+		{name: "extends a future expiry", current: []ec2types.Tag{expiresTag(future)}, wantFrom: future},
+		{name: "restarts a past expiry from now", current: []ec2types.Tag{expiresTag(time.Now().Add(-48 * time.Hour))}},
+		{name: "adds an expiry to a VM without one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			describe := makeRunningInstanceWithAZ("i-exp", "default", "testuser", "1.2.3.4", "us-east-1a")
+			inst := &describe.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, tt.current...)
+			createTags := &cmdtest.CreateTags{}
+			deps := newTestUpDeps()
+			deps.describe = &cmdtest.DescribeInstances{Output: describe}
+			deps.describeVolumes = &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{
+				Volumes: []ec2types.Volume{{VolumeId: aws.String("vol-exp")}},
+			}}
+			deps.describeAddrs = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
+				Addresses: []ec2types.Address{{AllocationId: aws.String("eipalloc-exp")}},
+			}}
+			deps.createTags = createTags
+
+			buf := new(bytes.Buffer)
+			root := cmdtest.NewRoot(newUpCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"up", "--extend-ttl", "24h", "--json"})
+			start := time.Now()
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+			}
+
+			if len(createTags.Inputs) != 1 {
+				t.Fatalf("CreateTags called %d times, want 1", len(createTags.Inputs))
+			}
+			call := createTags.Inputs[0]
+			if want := []string{"i-exp", "vol-exp", "eipalloc-exp"}; !slices.Equal(call.Resources, want) {
+				t.Errorf("tagged %v, want %v", call.Resources, want)
+			}
+			got, err := tags.ParseExpires(tags.ToMap(call.Tags)[tags.TagExpires])
+			if err != nil {
+				t.Fatal(err)
+			}
+			from := tt.wantFrom
+			if from.IsZero() {
+				from = start
+			}
+			if want := from.Add(24 * time.Hour); got.Sub(want).Abs() > 2*time.Second {
+				t.Errorf("new expiry = %s, want about %s", got, want)
+			}
+
+			var result extendTTLResult
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+			}
+			if !result.ExpiresAt.Equal(got) || result.VM != "default" {
+				t.Errorf("JSON = %+v, want expires_at %s", result, got)
+			}
+		})
+	}
+}
+
+func TestUpExtendTTLWithoutVM(t *testing.T) {
+	deps := newTestUpDeps()
+	deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}}
+	deps.createTags = &cmdtest.CreateTags{}
+
+	root := cmdtest.NewRoot(newUpCommandWithDeps(deps))
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	root.SetArgs([]string{"up", "--extend-ttl", "24h"})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "mint up --ttl <duration>") {
+		t.Fatalf("error = %v, want a hint to create the VM with --ttl", err)
+	}
+}
+
+func TestStatusShowsExpiry(t *testing.T) {
+	hint.IsTTY = false
+	tests := []struct {
+		name        string
+		expires     time.Time
+		wantHuman   string
+		wantExpired bool
+	}{
+		{name: "ahead", expires: time.Now().Add(41*time.Hour + 30*time.Second), wantHuman: "Expires:   in 1d 17h\n"},
+		{name: "past", expires: time.Now().Add(-3*time.Hour - 30*time.Second), wantHuman: "Expires:   EXPIRED 3h ago — run `mint destroy` to remove it\n", wantExpired: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			describe := makeRunningInstanceWithAZ("i-exp", "default", "alice", "1.2.3.4", "us-east-1a")
+			inst := &describe.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, expiresTag(tt.expires))
+			deps := &statusDeps{describe: &cmdtest.DescribeInstances{Output: describe}, owner: "alice"}
+
+			for _, jsonOutput := range []bool{false, true} {
+				buf := new(bytes.Buffer)
+				root := cmdtest.NewRoot(newStatusCommandWithDeps(deps))
+				root.SetOut(buf)
+				root.SetErr(buf)
+				args := []string{"status"}
+				if jsonOutput {
+					args = append(args, "--json")
+				}
+				root.SetArgs(args)
+				if err := root.Execute(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !jsonOutput {
+					if !strings.Contains(buf.String(), tt.wantHuman) {
+						t.Errorf("output missing %q:\n%s", tt.wantHuman, buf.String())
+					}
+					continue
+				}
+				var result statusJSON
+				if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+					t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+				}
+				if result.ExpiresAt != tags.FormatExpires(tt.expires) || result.Expired != tt.wantExpired {
+					t.Errorf("expires_at = %q, expired = %v, want %q, %v",
+						result.ExpiresAt, result.Expired, tags.FormatExpires(tt.expires), tt.wantExpired)
+				}
+			}
+		})
+	}
+}
+
+func TestDoctorExpiredResources(t *testing.T) {
+	hint.IsTTY = false
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	resourceTags := func(vmName string, expires time.Time) []ec2types.Tag {
+		return append(tags.NewTagBuilder("alice", "", vmName).Build(), expiresTag(expires))
+	}
+	instances := makeMultiInstanceOutput(
+		makeDoctorInstance("i-old", "old", "alice", "stopped", "", expiresTag(now.Add(-3*time.Hour))).Reservations[0].Instances[0],
+		// Past its expiry, but within the grace window for clock skew.
+		makeDoctorInstance("i-skew", "skew", "alice", "running", "", expiresTag(now.Add(-2*time.Minute))).Reservations[0].Instances[0],
+		makeDoctorInstance("i-new", "new", "alice", "running", "", expiresTag(now.Add(time.Hour))).Reservations[0].Instances[0],
+		makeDoctorInstance("i-plain", "default", "alice", "running", "").Reservations[0].Instances[0],
+	)
+	deps := &doctorDeps{
+		describe: &cmdtest.DescribeInstances{Output: instances},
+		describeVolumes: &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+			{VolumeId: aws.String("vol-old"), Tags: resourceTags("old", now.Add(-3*time.Hour))},
+			// A volume left behind by a VM that is gone.
+			{VolumeId: aws.String("vol-orphan"), Tags: resourceTags("gone", now.Add(-25*time.Hour))},
+		}}},
+		describeAddresses: &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{
+			{AllocationId: aws.String("eipalloc-old"), Tags: resourceTags("old", now.Add(-3*time.Hour))},
+			{AllocationId: aws.String("eipalloc-new"), Tags: resourceTags("new", now.Add(time.Hour))},
+		}}},
+		owner: "alice",
+	}
+
+	got := checkExpiredResources(context.Background(), deps, now)
+	want := `VM "gone" expired 1d 1h ago (vol-orphan) — run ` + "`mint destroy --vm gone`" +
+		`; VM "old" expired 3h ago (i-old, vol-old, eipalloc-old) — run ` + "`mint destroy --vm old`"
+	if got.status != "WARN" || got.message != want {
+		t.Errorf("result = %s %q, want WARN %q", got.status, got.message, want)
+	}
+
+	deps.describe = &cmdtest.DescribeInstances{Output: makeDoctorInstance("i-plain", "default", "alice", "running", "")}
+	deps.describeVolumes = &cmdtest.DescribeVolumes{Output: &ec2.DescribeVolumesOutput{}}
+	deps.describeAddresses = &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}}
+	if got := checkExpiredResources(context.Background(), deps, now); got.status != "PASS" {
+		t.Errorf("result = %s %q, want PASS", got.status, got.message)
+	}
+}
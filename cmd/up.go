@@ -3,11 +3,13 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -18,7 +20,9 @@ import (
 	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
 	"github.com/SpiceLabsHQ/Mint/internal/cli"
 	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 	"github.com/SpiceLabsHQ/Mint/internal/progress"
 	"github.com/SpiceLabsHQ/Mint/internal/provision"
 	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
@@ -48,16 +52,47 @@ type upDeps struct {
 	ownerARN             string
 	bootstrapScript      []byte
 	bootstrapURL         string // GitHub raw URL for bootstrap.sh delivery
+	resolveBootstrap     bootstrapResolveFunc // signed manifest lookup; nil uses the embedded hash
 	userBootstrapScript  []byte // Optional user-bootstrap.sh content read from config dir
 	instanceType         string
 	volumeSize           int32
 	volumeIOPS           int32
+	volumeThroughput     int32          // MB/s; 0 uses the provisioner default
+	idleTimeout          int            // minutes; 0 uses the provisioner default
+	ipMode               string         // ip_mode config value; --ip-mode overrides it
+	hibernate            bool           // hibernate config value
+	kmsKeyID             string         // kms_key_id config value; --kms-key-id overrides it
+	network              networkConfig  // subnet_id, security_group_ids, and vpc_id config values; the network flags override them
+	instanceProfile      string         // instance_profile config value; empty uses the default
+	mintConfig           *config.Config // [vm.<name>] overrides of the values above; nil applies none
 	sshConfigApproved    bool
 	sshConfigPath        string
 	profile              string // AWS profile for SSH config ProxyCommand
 	region               string // AWS region for SSH config ProxyCommand
+	sshOptions           sshconfig.Options
 	describe             mintaws.DescribeInstancesAPI
+	describeStatus       mintaws.DescribeInstanceStatusAPI // scheduled events of an existing VM
 	describeFileSystems  mintaws.DescribeFileSystemsAPI
+	describeAddrs        mintaws.DescribeAddressesAPI // batch EIP quota pre-check
+	accountAttrs         mintaws.DescribeAccountAttributesAPI
+	describeVolumes      mintaws.DescribeVolumesAPI  // project volume retagged by --extend-ttl
+	createTags           mintaws.CreateTagsAPI       // --extend-ttl
+	journal              *provision.JournalStore      // provisioning journals; nil disables --abandon-journal
+	// newProvisioner builds a fresh Provisioner for each VM in batch mode
+	// (--name-prefix). nil disables batch mode.
+	newProvisioner func() (*provision.Provisioner, error)
+	// Project container reconcile after a restart. A nil remote or an
+	// empty projectCacheDir disables it.
+	sendKey         mintaws.SendSSHPublicKeyAPI
+	remote          RemoteCommandRunner
+	hostKeyStore    *sshconfig.HostKeyStore
+	hostKeyScanner  HostKeyScanner
+	projectCacheDir string
+	sshWait         time.Duration // how long to wait for SSH; 0 uses defaultReconcileSSHWait
+	sshRetry        time.Duration // delay between SSH attempts; 0 uses reconcileSSHRetryInterval
+	// bootstrapPhaseThreshold flags slow bootstrap phases in the timings
+	// shown after a fresh provision; 0 flags none.
+	bootstrapPhaseThreshold time.Duration
 }
 
 // newUpCommand creates the production up command.
@@ -72,7 +107,14 @@ func newUpCommandWithDeps(deps *upDeps) *cobra.Command {
 		Short: "Provision or start the VM",
 		Long: "Provision a new VM or start a stopped one. Creates EC2 instance, " +
 			"project EBS volume, and Elastic IP. If a VM already exists and is " +
-			"stopped, it will be started.",
+			"stopped, it will be started.\n\n" +
+			"Each step of a fresh provision is recorded in a local journal. If " +
+			"mint up is interrupted, the next run checks what was created and " +
+			"picks up where it left off instead of starting over. Use " +
+			"--abandon-journal to discard the journal and provision normally.\n\n" +
+			"Starting an existing VM is refused while another mint command holds its " +
+			"operation lock; --steal-lock takes it anyway. When two runs provision the " +
+			"same VM at once, the one that launched later terminates its instance.",
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if deps != nil {
@@ -94,24 +136,31 @@ func newUpCommandWithDeps(deps *upDeps) *cobra.Command {
 			} else {
 				pollerWriter = sp.Writer
 			}
-			poller := provision.NewBootstrapPoller(
-				clients.ec2Client, // DescribeInstancesAPI
-				clients.ec2Client, // StopInstancesAPI
-				clients.ec2Client, // TerminateInstancesAPI
-				clients.ec2Client, // CreateTagsAPI
-				pollerWriter,
-				cmd.InOrStdin(),
-			)
+			newPoller := func(w io.Writer) *provision.BootstrapPoller {
+				return provision.NewBootstrapPoller(
+					clients.ec2Client, // DescribeInstancesAPI
+					clients.ec2Client, // StopInstancesAPI
+					clients.ec2Client, // TerminateInstancesAPI
+					clients.ec2Client, // CreateTagsAPI
+					w,
+					cmd.InOrStdin(),
+				)
+			}
 			sshApproved := false
 			volumeIOPS := int32(0)
+			volumeThroughput := int32(0)
 			if clients.mintConfig != nil {
 				sshApproved = clients.mintConfig.SSHConfigApproved
 				volumeIOPS = int32(clients.mintConfig.VolumeIOPS)
+				volumeThroughput = int32(clients.mintConfig.VolumeThroughputMB)
 			}
 			// --volume-iops flag overrides config value when provided (> 0).
 			if flagIOPS, _ := cmd.Flags().GetInt32("volume-iops"); flagIOPS > 0 {
 				volumeIOPS = flagIOPS
 			}
+			if flagThroughput, _ := cmd.Flags().GetInt32("volume-throughput"); flagThroughput > 0 {
+				volumeThroughput = flagThroughput
+			}
 			// Read user-bootstrap.sh from the config directory if it exists.
 			configDir := config.DefaultConfigDir()
 			var userBootstrapScript []byte
@@ -127,43 +176,97 @@ func newUpCommandWithDeps(deps *upDeps) *cobra.Command {
 			if effectiveProfile == "" {
 				effectiveProfile = clients.mintConfig.AWSProfile
 			}
+			typeCheck := instanceTypeCheck(cmd, clients.ec2Client, clients.region, configDir)
+			offeringCheck := instanceTypeOffering(cmd, clients.ec2Client)
+			journal := provision.NewJournalStore(configDir)
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			stealLock, _ := cmd.Flags().GetBool(stealLockFlag)
+			newProvisioner := func(poller *provision.BootstrapPoller) (*provision.Provisioner, error) {
+				return provision.NewProvisioner(provision.EC2Clients(clients.ec2Client),
+					provision.WithWaitRunning(awsec2.NewInstanceRunningWaiter(clients.ec2Client)),
+					provision.WithWaitVolumeAvailable(awsec2.NewVolumeAvailableWaiter(clients.ec2Client)),
+					provision.WithWaitEIPAssociated(mintaws.NewEIPAssociatedWaiter(clients.ec2Client, clients.ec2Client)),
+					provision.WithTerminateInstances(clients.ec2Client),
+					provision.WithReleaseAddress(clients.ec2Client),
+					provision.WithBootstrapPoller(poller),
+					provision.WithInstanceTypeCheck(typeCheck),
+					provision.WithInstanceTypeOffering(offeringCheck),
+					provision.WithDescribeInstanceTypes(clients.ec2Client),
+					provision.WithExtraTags(clients.mintConfig.ExtraTags),
+					provision.WithJournal(journal),
+					provision.WithDryRun(dryRun),
+					provision.WithOperationLock(newOperationLocker(cmd, clients.ec2Client), stealLock),
+				)
+			}
+			provisioner, err := newProvisioner(newPoller(pollerWriter))
+			if err != nil {
+				return err
+			}
 			return runUp(cmd, &upDeps{
-				provisioner: provision.NewProvisioner(
-					clients.ec2Client, // DescribeInstancesAPI
-					clients.ec2Client, // StartInstancesAPI
-					clients.ec2Client, // RunInstancesAPI
-					clients.ec2Client, // DescribeSecurityGroupsAPI
-					clients.ec2Client, // DescribeSubnetsAPI
-					clients.ec2Client, // CreateVolumeAPI
-					clients.ec2Client, // AttachVolumeAPI
-					clients.ec2Client, // AllocateAddressAPI
-					clients.ec2Client, // AssociateAddressAPI
-					clients.ec2Client, // DescribeAddressesAPI
-					clients.ec2Client, // CreateTagsAPI
-					clients.ec2Client, // DescribeImagesAPI
-				).WithWaitRunning(awsec2.NewInstanceRunningWaiter(clients.ec2Client)).
-				WithWaitVolumeAvailable(awsec2.NewVolumeAvailableWaiter(clients.ec2Client)).
-				WithBootstrapPoller(poller),
+				provisioner: provisioner,
+				// Batch VMs poll silently and never prompt: concurrent VMs
+				// cannot share the spinner or stdin.
+				newProvisioner: func() (*provision.Provisioner, error) {
+					return newProvisioner(newPoller(io.Discard).WithNonInteractive())
+				},
 				owner:                clients.owner,
 				ownerARN:             clients.ownerARN,
 				bootstrapScript:      GetBootstrapScript(),
 				bootstrapURL:         bootstrap.ScriptURL(version),
+				resolveBootstrap:     defaultBootstrapResolver(clients.mintConfig.BootstrapURL),
 				userBootstrapScript:  userBootstrapScript,
 				instanceType:         clients.mintConfig.InstanceType,
 				volumeSize:           int32(clients.mintConfig.VolumeSizeGB),
 				volumeIOPS:           volumeIOPS,
+				volumeThroughput:     volumeThroughput,
+				idleTimeout:          clients.mintConfig.IdleTimeoutMinutes,
+				ipMode:               clients.mintConfig.IPMode,
+				hibernate:            clients.mintConfig.Hibernate,
+				kmsKeyID:             clients.mintConfig.KMSKeyID,
+				network:              configuredNetwork(clients.mintConfig),
+				instanceProfile:      clients.mintConfig.InstanceProfile,
+				mintConfig:           clients.mintConfig,
 				sshConfigApproved:    sshApproved,
 				sshConfigPath:        "",
 				profile:              effectiveProfile,
 				region:               clients.region,
+				sshOptions:           clients.sshOptions,
 				describe:             clients.ec2Client,
+				describeStatus:       clients.ec2Client,
 				describeFileSystems:  clients.efsClient,
+				describeAddrs:        clients.ec2Client,
+				accountAttrs:         clients.ec2Client,
+				describeVolumes:      clients.ec2Client,
+				createTags:           clients.ec2Client,
+				journal:              journal,
+				sendKey:              clients.sendKey,
+				remote:               clients.remoteRunner(),
+				hostKeyStore:         sshconfig.NewHostKeyStore(configDir),
+				hostKeyScanner:       defaultHostKeyScanner,
+				projectCacheDir:      configDir,
+
+				bootstrapPhaseThreshold: clients.mintConfig.BootstrapPhaseThreshold,
 			})
 		},
 	}
 
 	// --volume-iops overrides the config value. 0 means "use config value".
 	cmd.Flags().Int32("volume-iops", 0, "IOPS for the project EBS volume (gp3, range 3000-16000; 0 uses config value)")
+	// --volume-throughput overrides the config value the same way.
+	cmd.Flags().Int32("volume-throughput", 0, "Throughput in MB/s for the project EBS volume (gp3, range 125-1000 and at most IOPS/4; 0 uses config value)")
+	cmd.Flags().Bool("abandon-journal", false, "Discard the journal of an interrupted mint up instead of resuming it")
+	cmd.Flags().Bool("no-reconcile", false, "Do not restart the project containers that were running before the VM stopped")
+	cmd.Flags().Bool("dry-run", false, "Show what mint up would create or start, making only read-only AWS calls")
+	addSkipTypeValidationFlag(cmd)
+	addStealLockFlag(cmd)
+	addSpotFlags(cmd)
+	addIPModeFlag(cmd)
+	addKMSKeyFlag(cmd)
+	addNetworkFlags(cmd)
+	addBootstrapFileFlag(cmd)
+	addTTLFlags(cmd)
+	addBatchFlags(cmd)
+	addNotifyFlags(cmd)
 
 	return cmd
 }
@@ -175,6 +278,53 @@ func runUp(cmd *cobra.Command, deps *upDeps) error {
 		ctx = context.Background()
 	}
 
+	abandonJournal, _ := cmd.Flags().GetBool("abandon-journal")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun && abandonJournal {
+		return fmt.Errorf("--dry-run cannot be combined with --abandon-journal")
+	}
+	spot, spotFallback, err := spotFlags(cmd)
+	if err != nil {
+		return err
+	}
+	ipMode, err := ipModeFlag(cmd, deps.ipMode)
+	if err != nil {
+		return err
+	}
+	kmsKeyID, err := kmsKeyFlag(cmd, deps.kmsKeyID)
+	if err != nil {
+		return err
+	}
+	network, err := networkFlags(cmd, deps.network)
+	if err != nil {
+		return err
+	}
+	ttl, err := ttlFlag(cmd, "ttl")
+	if err != nil {
+		return err
+	}
+	extendTTL, err := ttlFlag(cmd, "extend-ttl")
+	if err != nil {
+		return err
+	}
+	if extendTTL > 0 && (dryRun || abandonJournal) {
+		return fmt.Errorf("--extend-ttl cannot be combined with --dry-run or --abandon-journal")
+	}
+	if batch, err := batchRequested(cmd); err != nil {
+		return err
+	} else if batch {
+		if extendTTL > 0 {
+			return fmt.Errorf("--extend-ttl cannot be combined with --name-prefix")
+		}
+		if abandonJournal {
+			return fmt.Errorf("--abandon-journal cannot be combined with --name-prefix")
+		}
+		if dryRun {
+			return fmt.Errorf("--dry-run cannot be combined with --name-prefix")
+		}
+		return runUpBatch(cmd, deps)
+	}
+
 	cliCtx := cli.FromCommand(cmd)
 	vmName := "default"
 	verbose := false
@@ -185,6 +335,10 @@ func runUp(cmd *cobra.Command, deps *upDeps) error {
 		jsonOutput = cliCtx.JSON
 	}
 
+	if extendTTL > 0 {
+		return runExtendTTL(ctx, cmd, deps, vmName, extendTTL, jsonOutput)
+	}
+
 	// Pre-flight: warn when provisioning would result in 3+ running VMs (SPEC).
 	// Warning is informational only — never blocks the operation.
 	// Skip in JSON mode to avoid corrupting machine-readable output.
@@ -199,6 +353,19 @@ func runUp(cmd *cobra.Command, deps *upDeps) error {
 		}
 	}
 
+	if !jsonOutput {
+		warnScheduledEvents(ctx, cmd.OutOrStdout(), deps, vmName)
+	}
+
+	if abandonJournal && deps.journal != nil {
+		if err := deps.journal.Remove(deps.owner, vmName); err != nil {
+			return err
+		}
+		if !jsonOutput {
+			fmt.Fprintf(cmd.OutOrStdout(), "Discarded the provisioning journal for VM %q.\n", vmName)
+		}
+	}
+
 	sp := progress.NewCommandSpinner(cmd.OutOrStdout(), jsonOutput)
 	sp.Start(fmt.Sprintf("Provisioning VM %q for owner %q...", vmName, deps.owner))
 
@@ -209,43 +376,361 @@ func runUp(cmd *cobra.Command, deps *upDeps) error {
 		return fmt.Errorf("discovering EFS: %w", err)
 	}
 
+	src, err := resolveBootstrapSource(ctx, deps.resolveBootstrap, deps.bootstrapURL)
+	if err == nil {
+		src, err = bootstrapFileSource(cmd, src)
+	}
+	if err != nil {
+		sp.Fail(err.Error())
+		return err
+	}
+
 	cfg := provision.ProvisionConfig{
 		InstanceType:        deps.instanceType,
 		VolumeSize:          deps.volumeSize,
 		VolumeIOPS:          deps.volumeIOPS,
+		VolumeThroughput:    deps.volumeThroughput,
+		IdleTimeout:         deps.idleTimeout,
 		BootstrapScript:     deps.bootstrapScript,
 		BootstrapURL:        deps.bootstrapURL,
+		Bootstrap:           src,
 		EFSID:               efsID,
 		UserBootstrapScript: deps.userBootstrapScript,
+		PrefetchImages:      loadPrefetchImages(deps, vmName),
+		SSHUser:             deps.sshOptions.LoginUser(defaultSSHUser),
+		Spot:                spot,
+		SpotFallback:        spotFallback,
+		IPMode:              ipMode,
+		Hibernate:           deps.hibernate,
+		KMSKeyID:            kmsKeyID,
+		InstanceProfile:     deps.instanceProfile,
+		SSHPort:             deps.sshOptions.Port,
+	}
+	if ttl > 0 {
+		cfg.ExpiresAt = time.Now().Add(ttl)
+	}
+	network.apply(&cfg)
+	if err := applyVMConfig(cmd, deps, vmName, &cfg); err != nil {
+		sp.Fail(err.Error())
+		return err
 	}
 
 	sp.Update(fmt.Sprintf("Provisioning VM %q...", vmName))
 
+	started := time.Now()
 	result, err := deps.provisioner.Run(ctx, deps.owner, deps.ownerARN, vmName, cfg)
 	if err != nil {
+		err = upInterrupted(err)
 		sp.Fail(err.Error())
 		return err
 	}
+	if result.Plan != nil {
+		sp.Stop("")
+		return printUpPlan(cmd, vmName, result, jsonOutput)
+	}
+	clearPrefetchImages(deps, vmName)
+	touchProvisionedResources(cliCtx, result)
+	if ttl > 0 && (result.Restarted || result.AlreadyRunning) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: --ttl only applies to a new VM; VM %q already exists and keeps its expiry — use %s to change it\n",
+			vmName, hint.Cmd("mint up --extend-ttl <duration>"))
+	}
+	if verbose || jsonOutput {
+		sp.Update("Reading bootstrap timings...")
+		attachBootstrapTimings(ctx, deps, vmName, result)
+	}
+	historyErr := recordUpProvisioning(ctx, deps, result, started)
 
 	// Stop the spinner (clears line in interactive mode) before printing results.
 	sp.Stop("")
+	warnProvisionHistory(cmd.ErrOrStderr(), historyErr)
 
 	// Auto-generate SSH config entry if approved (ADR-0015).
 	if deps.sshConfigApproved && result.PublicIP != "" {
 		writeSSHConfigAfterUp(ctx, cmd, deps, vmName, result)
 	}
 
-	return printUpResult(cmd, cliCtx, result, jsonOutput, verbose)
+	if err := printUpResult(cmd, cliCtx, result, jsonOutput, verbose, deps.sshOptions.LoginPort(defaultSSHPort)); err != nil {
+		return err
+	}
+	reconcileIfRestarted(ctx, cmd, deps, vmName, result, jsonOutput)
+	return nil
+}
+
+// attachBootstrapTimings fetches the phase timings of a freshly
+// bootstrapped VM into result. A restarted or already running VM did not
+// bootstrap in this run, so it has none.
+func attachBootstrapTimings(ctx context.Context, deps *upDeps, vmName string, result *provision.ProvisionResult) {
+	if result.Restarted || result.AlreadyRunning || deps.describe == nil {
+		return
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil || found == nil {
+		return
+	}
+	result.BootstrapTimings = fetchBootstrapTimings(ctx, retryFirstConnection(deps.remote), deps.sendKey,
+		result.InstanceID, found.AvailabilityZone, result.PublicIP, deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser)
+	result.BootstrapPhaseThreshold = deps.bootstrapPhaseThreshold
+}
+
+// recordUpProvisioning records a fresh provision in the instance's tags and
+// history file. A restarted or already running VM was not provisioned in
+// this run, and one whose bootstrap failed is not usable, so neither is
+// recorded.
+func recordUpProvisioning(ctx context.Context, deps *upDeps, result *provision.ProvisionResult, started time.Time) error {
+	if result.Restarted || result.AlreadyRunning || result.BootstrapError != nil || deps.describe == nil {
+		return nil
+	}
+	found, err := vm.FindVMByID(ctx, deps.describe, result.InstanceID)
+	if err != nil {
+		return fmt.Errorf("describing %s: %w", result.InstanceID, err)
+	}
+	if found == nil {
+		return nil
+	}
+	target := &vm.VM{ID: result.InstanceID, AvailabilityZone: found.AvailabilityZone, PublicIP: result.PublicIP}
+	entry := newProvisionHistoryEntry(result.InstanceID, provisionActionUp, started, time.Now())
+	return recordProvisioning(ctx, deps.createTags, retryFirstConnection(deps.remote), deps.sendKey,
+		target, deps.sshOptions.LoginPort(defaultSSHPort), deps.owner, []provisionHistoryEntry{entry})
+}
+
+// warnScheduledEvents prints the pending EC2 scheduled events of an existing
+// VM before mint up starts it, so a retirement is noticed while there is
+// still time to recreate. Lookup failures are ignored: the warning is
+// informational and must never block mint up.
+func warnScheduledEvents(ctx context.Context, w io.Writer, deps *upDeps, vmName string) {
+	if deps.describe == nil || deps.describeStatus == nil {
+		return
+	}
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil || found == nil {
+		return
+	}
+	events, err := vm.ScheduledEvents(ctx, deps.describeStatus, []string{found.ID})
+	if err != nil {
+		return
+	}
+	for _, ev := range events[found.ID] {
+		fmt.Fprintf(w, "⚠  VM %q: %s\n", vmName, eventWarning(ev))
+	}
+}
+
+// bootstrapResolveFunc picks the bootstrap.sh hash and URL a new instance is
+// provisioned with.
+type bootstrapResolveFunc func(ctx context.Context) (bootstrap.Source, error)
+
+// defaultBootstrapResolver returns the production resolver: the signed
+// published manifest, falling back to the embedded hash when it cannot be
+// fetched. A non-empty mirrorURL (bootstrap_url) replaces the script URL.
+func defaultBootstrapResolver(mirrorURL string) bootstrapResolveFunc {
+	r := &bootstrap.Resolver{
+		ManifestURL: bootstrap.ManifestURL(version),
+		ScriptURL:   bootstrap.ScriptURL(version),
+		MirrorURL:   mirrorURL,
+		CLIVersion:  version,
+	}
+	return r.Resolve
+}
+
+// resolveBootstrapSource runs resolve, or returns the embedded source for
+// fallbackURL when resolve is nil (tests).
+func resolveBootstrapSource(ctx context.Context, resolve bootstrapResolveFunc, fallbackURL string) (bootstrap.Source, error) {
+	if resolve == nil {
+		return bootstrap.EmbeddedSource(fallbackURL), nil
+	}
+	return resolve(ctx)
+}
+
+// addBootstrapFileFlag registers --bootstrap-file on a command that
+// launches an instance.
+func addBootstrapFileFlag(cmd *cobra.Command) {
+	cmd.Flags().String("bootstrap-file", "", "Embed this copy of bootstrap.sh in user-data instead of downloading it, for networks that cannot reach GitHub")
+}
+
+// bootstrapFileSource returns src with the script named by --bootstrap-file
+// embedded in it, or src unchanged when the flag is not set. The script must
+// be the one src pins.
+func bootstrapFileSource(cmd *cobra.Command, src bootstrap.Source) (bootstrap.Source, error) {
+	path, _ := cmd.Flags().GetString("bootstrap-file")
+	if path == "" {
+		return src, nil
+	}
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return bootstrap.Source{}, fmt.Errorf("reading --bootstrap-file: %w", err)
+	}
+	inline, err := bootstrap.InlineSource(src, script)
+	if err != nil {
+		return bootstrap.Source{}, fmt.Errorf("--bootstrap-file %s: %w", path, err)
+	}
+	return inline, nil
+}
+
+// addSkipTypeValidationFlag registers --skip-type-validation on a command
+// that checks an instance type against the region's catalog.
+func addSkipTypeValidationFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("skip-type-validation", false, "Skip checking the instance type against the region's catalog (for types newer than the catalog)")
+}
+
+// addSpotFlags registers --spot and --spot-fallback on a command that
+// launches an instance.
+func addSpotFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("spot", false, "Launch the instance on the spot market (stopped, not terminated, when EC2 reclaims it)")
+	cmd.Flags().Bool("spot-fallback", false, "Launch on demand when no spot capacity is available (requires --spot)")
+}
+
+// addIPModeFlag registers --ip-mode on a command that launches instances.
+func addIPModeFlag(cmd *cobra.Command) {
+	cmd.Flags().String("ip-mode", "", "How a new VM is addressed: eip, dualstack, or ipv6-only (default: the ip_mode config key)")
+}
+
+// ipModeFlag returns --ip-mode when it is set, else configured.
+func ipModeFlag(cmd *cobra.Command, configured string) (string, error) {
+	mode, _ := cmd.Flags().GetString("ip-mode")
+	if mode == "" {
+		return configured, nil
+	}
+	if err := config.ValidateIPMode(mode); err != nil {
+		return "", fmt.Errorf("--ip-mode: %w", err)
+	}
+	return mode, nil
+}
+
+// addKMSKeyFlag registers --kms-key-id on a command that creates volumes.
+func addKMSKeyFlag(cmd *cobra.Command) {
+	cmd.Flags().String("kms-key-id", "", "KMS key to encrypt new volumes with: key ID, key ARN, alias/<name>, or alias ARN (default: the kms_key_id config key, else the account's default EBS key)")
+}
+
+// kmsKeyFlag returns --kms-key-id when it is set, else configured.
+func kmsKeyFlag(cmd *cobra.Command, configured string) (string, error) {
+	keyID, _ := cmd.Flags().GetString("kms-key-id")
+	if keyID == "" {
+		return configured, nil
+	}
+	if err := config.ValidateKMSKeyID(keyID); err != nil {
+		return "", fmt.Errorf("--kms-key-id: %w", err)
+	}
+	return keyID, nil
 }
 
-func printUpResult(cmd *cobra.Command, cliCtx *cli.CLIContext, result *provision.ProvisionResult, jsonOutput, verbose bool) error {
+// networkConfig is the network new instances launch in: the subnet_id,
+// security_group_ids, and vpc_id config keys, or the flags that override
+// them. The zero value is the default VPC.
+type networkConfig struct {
+	subnetID         string
+	securityGroupIDs []string
+	vpcID            string
+}
+
+// configuredNetwork returns cfg's network keys; a nil cfg is the default
+// VPC.
+func configuredNetwork(cfg *config.Config) networkConfig {
+	if cfg == nil {
+		return networkConfig{}
+	}
+	return networkConfig{subnetID: cfg.SubnetID, securityGroupIDs: cfg.SecurityGroupIDs, vpcID: cfg.VPCID}
+}
+
+// isZero reports whether n is the default VPC.
+func (n networkConfig) isZero() bool {
+	return n.subnetID == "" && len(n.securityGroupIDs) == 0 && n.vpcID == ""
+}
+
+// apply sets cfg's network fields from n.
+func (n networkConfig) apply(cfg *provision.ProvisionConfig) {
+	cfg.SubnetID = n.subnetID
+	cfg.SecurityGroupIDs = n.securityGroupIDs
+	cfg.VPCID = n.vpcID
+}
+
+// addNetworkFlags registers --subnet-id, --security-group-ids, and
+// --vpc-id on a command that launches instances.
+func addNetworkFlags(cmd *cobra.Command) {
+	cmd.Flags().String("subnet-id", "", "Subnet to launch in (default: the subnet_id config key, else a default subnet)")
+	cmd.Flags().StringSlice("security-group-ids", nil, "Security groups for the instance, comma-separated (default: the security_group_ids config key, else the groups mint init created)")
+	cmd.Flags().String("vpc-id", "", "VPC whose subnets to launch in (default: the vpc_id config key, else the default VPC)")
+}
+
+// networkFlags returns configured with each network flag that is set in
+// place of its config key.
+func networkFlags(cmd *cobra.Command, configured networkConfig) (networkConfig, error) {
+	n := configured
+	if subnetID, _ := cmd.Flags().GetString("subnet-id"); subnetID != "" {
+		if err := config.ValidateSubnetID(subnetID); err != nil {
+			return networkConfig{}, fmt.Errorf("--subnet-id: %w", err)
+		}
+		n.subnetID = subnetID
+	}
+	if groups, _ := cmd.Flags().GetStringSlice("security-group-ids"); len(groups) > 0 {
+		if err := config.ValidateSecurityGroupIDs(strings.Join(groups, " ")); err != nil {
+			return networkConfig{}, fmt.Errorf("--security-group-ids: %w", err)
+		}
+		n.securityGroupIDs = groups
+	}
+	if vpcID, _ := cmd.Flags().GetString("vpc-id"); vpcID != "" {
+		if err := config.ValidateVPCID(vpcID); err != nil {
+			return networkConfig{}, fmt.Errorf("--vpc-id: %w", err)
+		}
+		n.vpcID = vpcID
+	}
+	return n, nil
+}
+
+// spotFlags reads --spot and --spot-fallback.
+func spotFlags(cmd *cobra.Command) (spot, fallback bool, err error) {
+	spot, _ = cmd.Flags().GetBool("spot")
+	fallback, _ = cmd.Flags().GetBool("spot-fallback")
+	if fallback && !spot {
+		return false, false, fmt.Errorf("--spot-fallback requires --spot")
+	}
+	return spot, fallback, nil
+}
+
+// instanceTypeCheck returns the provisioner's instance type check, or nil
+// when --skip-type-validation is set. The region's catalog is cached in
+// configDir so repeated commands do not re-list it.
+func instanceTypeCheck(cmd *cobra.Command, client mintaws.DescribeInstanceTypesAPI, region, configDir string) provision.InstanceTypeCheckFunc {
+	if skip, _ := cmd.Flags().GetBool("skip-type-validation"); skip {
+		return nil
+	}
+	validator := mintaws.NewInstanceTypeValidator(client).WithCacheDir(configDir)
+	return func(ctx context.Context, instanceType string) (string, error) {
+		return validator.Check(ctx, instanceType, region)
+	}
+}
+
+// instanceTypeOffering returns the provisioner's check that the instance
+// type is offered in a subnet's availability zone, or nil when
+// --skip-type-validation is set. Each AZ's offerings are listed once per
+// command, however many VMs it provisions.
+func instanceTypeOffering(cmd *cobra.Command, client mintaws.DescribeInstanceTypeOfferingsAPI) provision.InstanceTypeOfferingFunc {
+	if skip, _ := cmd.Flags().GetBool("skip-type-validation"); skip {
+		return nil
+	}
+	return mintaws.NewInstanceTypeOfferings(client).CheckAZ
+}
+
+// touchProvisionedResources records the resources a provisioner run acted
+// on for the history log.
+func touchProvisionedResources(cliCtx *cli.CLIContext, result *provision.ProvisionResult) {
+	cliCtx.TouchResource(cli.ResourceInstance, result.InstanceID)
+	cliCtx.TouchResource(cli.ResourceVolume, result.VolumeID)
+	cliCtx.TouchResource(cli.ResourceEIP, result.AllocationID)
+}
+
+func printUpResult(cmd *cobra.Command, cliCtx *cli.CLIContext, result *provision.ProvisionResult, jsonOutput, verbose bool, sshPort int) error {
 	if jsonOutput {
 		return printUpJSON(cmd, result)
 	}
-	return printUpHuman(cmd, result, verbose)
+	return printUpHuman(cmd, result, verbose, sshPort)
 }
 
 func printUpJSON(cmd *cobra.Command, result *provision.ProvisionResult) error {
+	return writeUpJSON(cmd, result, nil)
+}
+
+// writeUpJSON encodes the up JSON object for result, merged with any extra
+// fields the calling command adds (e.g. clone-vm's source details).
+func writeUpJSON(cmd *cobra.Command, result *provision.ProvisionResult, extra map[string]any) error {
 	data := map[string]any{
 		"instance_id":      result.InstanceID,
 		"public_ip":        result.PublicIP,
@@ -254,18 +739,82 @@ func printUpJSON(cmd *cobra.Command, result *provision.ProvisionResult) error {
 		"restarted":        result.Restarted,
 		"already_running":  result.AlreadyRunning,
 		"bootstrap_status": result.BootstrapStatus,
+		"resumed":          result.Resumed,
 	}
 
 	if result.BootstrapError != nil {
 		data["bootstrap_error"] = result.BootstrapError.Error()
+		if reason, step := bootstrapFailureReason(result.BootstrapError); reason != "" {
+			data["failure_reason"] = reason
+			if step != "" {
+				data["failed_step"] = step
+			}
+		}
+	}
+	if result.UserBootstrapStatus != "" {
+		data["user_bootstrap_status"] = result.UserBootstrapStatus
+	}
+	if result.InstanceTypeWarning != "" {
+		data["instance_type_warning"] = result.InstanceTypeWarning
+	}
+	if result.IPv6Address != "" {
+		data["ipv6_address"] = result.IPv6Address
+	}
+	if result.Spot {
+		data["spot"] = true
+	}
+	if result.SpotWarning != "" {
+		data["spot_warning"] = result.SpotWarning
+	}
+	if result.BootstrapSource != "" {
+		data["bootstrap_source"] = result.BootstrapSource
+	}
+	if result.Expires != "" {
+		data["expires_at"] = result.Expires
+	}
+	if result.EIPReallocated {
+		data["eip_reallocated"] = true
+	}
+	if result.EIPReused {
+		data["eip_reused"] = true
+	}
+	if result.PrefetchQueued > 0 || result.PrefetchDropped > 0 {
+		data["prefetch_images_queued"] = result.PrefetchQueued
+		data["prefetch_images_dropped"] = result.PrefetchDropped
+	}
+	if len(result.BootstrapTimings) > 0 {
+		data["bootstrap_timings"] = bootstrapTimingsJSON(result.BootstrapTimings, result.BootstrapPhaseThreshold)
+	}
+	for k, v := range extra {
+		data[k] = v
 	}
 
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	enc.SetIndent("", "  ")
-	return enc.Encode(data)
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+
+	// A failed user hook leaves a usable VM; signal it via a dedicated exit code.
+	if result.UserBootstrapError != nil {
+		return exitCodeError{code: exitCodeUserBootstrapFailed}
+	}
+	return nil
 }
 
-func printUpHuman(cmd *cobra.Command, result *provision.ProvisionResult, verbose bool) error {
+// eipPropagationNotice is how long an Elastic IP may take to reach its
+// instance before verbose output mentions it.
+const eipPropagationNotice = 2 * time.Second
+
+// printEIPPropagation notes a slow Elastic IP association, which explains
+// a pause before the first SSH connection.
+func printEIPPropagation(w io.Writer, waited time.Duration) {
+	if waited > eipPropagationNotice {
+		fmt.Fprintf(w, "Elastic IP took %s to reach the instance\n", format.FormatDuration(waited))
+	}
+}
+
+func printUpHuman(cmd *cobra.Command, result *provision.ProvisionResult, verbose bool, sshPort int) error {
 	w := cmd.OutOrStdout()
 
 	if result.Restarted {
@@ -273,8 +822,14 @@ func printUpHuman(cmd *cobra.Command, result *provision.ProvisionResult, verbose
 		if result.PublicIP != "" {
 			fmt.Fprintf(w, "IP            %s\n", result.PublicIP)
 		}
+		if result.EIPReallocated {
+			fmt.Fprintln(w, "Allocated a new Elastic IP; mint gc released the previous one.")
+		}
+		if verbose {
+			printEIPPropagation(w, result.EIPPropagation)
+		}
 		if result.BootstrapError != nil {
-			printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP)
+			printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP, sshPort)
 			return silentExitError{}
 		}
 		return nil
@@ -287,7 +842,7 @@ func printUpHuman(cmd *cobra.Command, result *provision.ProvisionResult, verbose
 			fmt.Fprintf(w, "IP            %s\n", result.PublicIP)
 		}
 		if result.BootstrapError != nil {
-			printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP)
+			printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP, sshPort)
 			return silentExitError{}
 		} else if result.BootstrapStatus == tags.BootstrapComplete {
 			fmt.Fprintln(w, "\nBootstrap complete. VM is ready.")
@@ -299,27 +854,67 @@ func printUpHuman(cmd *cobra.Command, result *provision.ProvisionResult, verbose
 	}
 
 	// Fresh provision.
+	if result.Resumed {
+		fmt.Fprintln(w, "Resumed an interrupted mint up.")
+	}
+	if result.EIPReused {
+		fmt.Fprintf(w, "Reusing existing Elastic IP %s\n", result.PublicIP)
+	}
 	fmt.Fprintf(w, "Instance      %s\n", result.InstanceID)
 	if result.PublicIP != "" {
 		fmt.Fprintf(w, "IP            %s\n", result.PublicIP)
 	}
+	if result.IPv6Address != "" && result.IPv6Address != result.PublicIP {
+		fmt.Fprintf(w, "IPv6          %s\n", result.IPv6Address)
+	}
 	if result.VolumeID != "" {
-		fmt.Fprintf(w, "Volume        %s\n", result.VolumeID)
+		if result.VolumeSizeGB > 0 {
+			fmt.Fprintf(w, "Volume        %s (%s)\n", result.VolumeID, format.FormatGiB(int(result.VolumeSizeGB)))
+		} else {
+			fmt.Fprintf(w, "Volume        %s\n", result.VolumeID)
+		}
 	}
 	if result.AllocationID != "" {
 		fmt.Fprintf(w, "EIP           %s\n", result.AllocationID)
 	}
+	if result.Spot {
+		fmt.Fprintln(w, "Market        spot")
+	}
+	if result.Expires != "" {
+		fmt.Fprintf(w, "Expires       %s (%s)\n", result.Expires, formatExpiry(result.Expires, time.Now()))
+	}
+	if verbose && result.BootstrapSource != "" {
+		fmt.Fprintf(w, "Bootstrap     %s\n", result.BootstrapSource)
+	}
+	if verbose {
+		printEIPPropagation(w, result.EIPPropagation)
+		printPrefetchSummary(w, result.PrefetchQueued, result.PrefetchDropped)
+		printBootstrapTimings(w, result.BootstrapTimings, result.BootstrapPhaseThreshold)
+	}
+	if result.InstanceTypeWarning != "" {
+		fmt.Fprintf(w, "Warning: %s\n", result.InstanceTypeWarning)
+	}
+	if result.SpotWarning != "" {
+		fmt.Fprintf(w, "Warning: %s\n", result.SpotWarning)
+	}
 
 	if result.BootstrapError != nil {
-		printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP)
+		printBootstrapFailureHint(w, result.BootstrapError, result.PublicIP, sshPort)
 		return silentExitError{}
 	}
+	if exitCode, ok := userBootstrapExitCode(result.UserBootstrapError); ok {
+		printUserBootstrapWarning(w, exitCode, result.PublicIP, sshPort)
+		return exitCodeError{code: exitCodeUserBootstrapFailed}
+	}
 	fmt.Fprintln(w, "\nBootstrap complete. VM is ready.")
 	return nil
 }
 
-// writeSSHConfigAfterUp generates and writes the SSH config block for the VM.
-// Failures are non-fatal: a warning is printed but the command still succeeds.
+// writeSSHConfigAfterUp generates and writes the SSH config block for the VM
+// when the file has none. An existing block that differs from the generated
+// one is left alone, with a hint to run mint ssh-config sync, so the file is
+// never edited silently. Failures are non-fatal: a warning is printed but
+// the command still succeeds.
 func writeSSHConfigAfterUp(ctx context.Context, cmd *cobra.Command, deps *upDeps, vmName string, result *provision.ProvisionResult) {
 	w := cmd.OutOrStdout()
 
@@ -338,10 +933,22 @@ func writeSSHConfigAfterUp(ctx context.Context, cmd *cobra.Command, deps *upDeps
 
 	configPath := deps.sshConfigPath
 	if configPath == "" {
-		configPath = defaultSSHConfigPath()
+		configPath = paths.SSHConfigPath()
 	}
 
-	block := sshconfig.GenerateBlock(vmName, result.PublicIP, defaultSSHUser, defaultSSHPort, result.InstanceID, az, deps.profile, deps.region)
+	opts := fitSSHClient(w, deps.sshOptions, false)
+	block := sshconfig.GenerateBlockWithOptions(vmName, result.PublicIP, opts.LoginUser(defaultSSHUser), opts.LoginPort(defaultSSHPort), result.InstanceID, az, deps.profile, deps.region, opts)
+	content, err := sshconfig.ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not update ssh config: %v\n", err)
+		return
+	}
+	if existing, ok := sshconfig.ReadManagedBlock(content, vmName); ok {
+		if strings.TrimSuffix(existing, "\n") != strings.TrimSuffix(block, "\n") {
+			printStaleSSHConfigHint(w, vmName)
+		}
+		return
+	}
 	if err := sshconfig.WriteManagedBlock(configPath, vmName, block); err != nil {
 		fmt.Fprintf(w, "Warning: could not update ssh config: %v\n", err)
 	}
@@ -375,15 +982,110 @@ func efsTagsToMap(efsTags []efstypes.Tag) map[string]string {
 	return m
 }
 
+// upInterrupted turns a provision stopped by Ctrl-C into recovery
+// guidance. Other errors are returned unchanged.
+func upInterrupted(err error) error {
+	var ierr *provision.InterruptedError
+	if !errors.As(err, &ierr) {
+		return err
+	}
+	if ierr.Step == "" {
+		return &interruptedError{recovery: "No resources were created."}
+	}
+	return &interruptedError{recovery: fmt.Sprintf(
+		"Instance %s was launched and its progress is saved — run %s to resume.",
+		ierr.InstanceID, hint.Cmd("mint up"))}
+}
+
+// applyVMConfig applies the values vmName's [vm.<name>] table in
+// config.toml sets over cfg, which was built from the top-level config. A
+// --volume-iops or --volume-throughput flag still takes precedence.
+func applyVMConfig(cmd *cobra.Command, deps *upDeps, vmName string, cfg *provision.ProvisionConfig) error {
+	if deps.mintConfig == nil {
+		return nil
+	}
+	vmCfg, err := deps.mintConfig.ForVM(vmName)
+	if err != nil {
+		return fmt.Errorf("config.toml: %w", err)
+	}
+	for _, key := range deps.mintConfig.OverriddenKeys(vmName) {
+		switch key {
+		case "instance_type":
+			cfg.InstanceType = vmCfg.InstanceType
+		case "volume_size_gb":
+			cfg.VolumeSize = int32(vmCfg.VolumeSizeGB)
+		case "volume_iops":
+			if flagIOPS, _ := cmd.Flags().GetInt32("volume-iops"); flagIOPS == 0 {
+				cfg.VolumeIOPS = int32(vmCfg.VolumeIOPS)
+			}
+		case "volume_throughput_mb":
+			if flagThroughput, _ := cmd.Flags().GetInt32("volume-throughput"); flagThroughput == 0 {
+				cfg.VolumeThroughput = int32(vmCfg.VolumeThroughputMB)
+			}
+		case "idle_timeout":
+			cfg.IdleTimeout = vmCfg.IdleTimeoutMinutes
+		case "ip_mode":
+			if flagMode, _ := cmd.Flags().GetString("ip-mode"); flagMode == "" {
+				cfg.IPMode = vmCfg.IPMode
+			}
+		case "hibernate":
+			cfg.Hibernate = vmCfg.Hibernate
+		case "kms_key_id":
+			if flagKey, _ := cmd.Flags().GetString("kms-key-id"); flagKey == "" {
+				cfg.KMSKeyID = vmCfg.KMSKeyID
+			}
+		case "instance_profile":
+			cfg.InstanceProfile = vmCfg.InstanceProfile
+		case "subnet_id":
+			if flagSubnet, _ := cmd.Flags().GetString("subnet-id"); flagSubnet == "" {
+				cfg.SubnetID = vmCfg.SubnetID
+			}
+		case "security_group_ids":
+			if flagGroups, _ := cmd.Flags().GetStringSlice("security-group-ids"); len(flagGroups) == 0 {
+				cfg.SecurityGroupIDs = vmCfg.SecurityGroupIDs
+			}
+		case "vpc_id":
+			if flagVPC, _ := cmd.Flags().GetString("vpc-id"); flagVPC == "" {
+				cfg.VPCID = vmCfg.VPCID
+			}
+		}
+	}
+	return nil
+}
+
+// loadPrefetchImages returns the images an interrupted mint recreate
+// captured for vmName, so finishing the recreate keeps the prefetch. A list
+// that cannot be read is ignored: prefetching only saves time.
+func loadPrefetchImages(deps *upDeps, vmName string) []string {
+	if deps.journal == nil {
+		return nil
+	}
+	images, _ := deps.journal.LoadPrefetch(deps.owner, vmName)
+	return images
+}
+
+// clearPrefetchImages removes vmName's prefetch list once a run has used it
+// or found the VM already provisioned.
+func clearPrefetchImages(deps *upDeps, vmName string) {
+	if deps.journal != nil {
+		_ = deps.journal.RemovePrefetch(deps.owner, vmName)
+	}
+}
+
 // upWithProvisioner runs up with a pre-built Provisioner (for testing).
 func upWithProvisioner(ctx context.Context, cmd *cobra.Command, cliCtx *cli.CLIContext, deps *upDeps, vmName string) error {
 	cfg := provision.ProvisionConfig{
 		InstanceType:        deps.instanceType,
 		VolumeSize:          deps.volumeSize,
 		VolumeIOPS:          deps.volumeIOPS,
+		VolumeThroughput:    deps.volumeThroughput,
 		BootstrapScript:     deps.bootstrapScript,
 		BootstrapURL:        deps.bootstrapURL,
 		UserBootstrapScript: deps.userBootstrapScript,
+		PrefetchImages:      loadPrefetchImages(deps, vmName),
+		SSHUser:             deps.sshOptions.LoginUser(defaultSSHUser),
+		InstanceProfile:     deps.instanceProfile,
+		SSHPort:             deps.sshOptions.Port,
 	}
 
 	verbose := false
@@ -393,11 +1095,19 @@ func upWithProvisioner(ctx context.Context, cmd *cobra.Command, cliCtx *cli.CLIC
 		jsonOutput = cliCtx.JSON
 	}
 
+	started := time.Now()
 	result, err := deps.provisioner.Run(ctx, deps.owner, deps.ownerARN, vmName, cfg)
 	if err != nil {
-		return err
+		return upInterrupted(err)
 	}
+	clearPrefetchImages(deps, vmName)
+	touchProvisionedResources(cliCtx, result)
+	warnProvisionHistory(cmd.ErrOrStderr(), recordUpProvisioning(ctx, deps, result, started))
 
-	return printUpResult(cmd, cliCtx, result, jsonOutput, verbose)
+	if err := printUpResult(cmd, cliCtx, result, jsonOutput, verbose, deps.sshOptions.LoginPort(defaultSSHPort)); err != nil {
+		return err
+	}
+	reconcileIfRestarted(ctx, cmd, deps, vmName, result, jsonOutput)
+	return nil
 }
 
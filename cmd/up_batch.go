@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// addBatchFlags registers the flags that switch up into batch mode.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("count", 1, "Number of VMs to provision (requires --name-prefix)")
+	cmd.Flags().String("name-prefix", "", "Provision VMs named <prefix>01, <prefix>02, … instead of --vm")
+	cmd.Flags().Int("concurrency", provision.DefaultBatchConcurrency, "Maximum number of VMs provisioned at once in batch mode")
+}
+
+// batchRequested reports whether up was invoked in batch mode, validating
+// the batch flags.
+func batchRequested(cmd *cobra.Command) (bool, error) {
+	count, _ := cmd.Flags().GetInt("count")
+	prefix, _ := cmd.Flags().GetString("name-prefix")
+	if prefix == "" {
+		if cmd.Flags().Changed("count") && count != 1 {
+			return false, fmt.Errorf("--count requires --name-prefix")
+		}
+		return false, nil
+	}
+	if count < 1 {
+		return false, fmt.Errorf("--count must be at least 1, got %d", count)
+	}
+	if cmd.Flags().Changed("vm") {
+		return false, fmt.Errorf("--vm cannot be combined with --name-prefix")
+	}
+	return true, nil
+}
+
+// upBatchResult is the JSON representation of one VM in a batch.
+type upBatchResult struct {
+	VM              string `json:"vm"`
+	InstanceID      string `json:"instance_id,omitempty"`
+	PublicIP        string `json:"public_ip,omitempty"`
+	VolumeID        string `json:"volume_id,omitempty"`
+	AllocationID    string `json:"allocation_id,omitempty"`
+	BootstrapStatus string `json:"bootstrap_status"`
+	Error           string `json:"error,omitempty"`
+	FailureReason   string `json:"failure_reason,omitempty"`
+	FailedStep      string `json:"failed_step,omitempty"`
+}
+
+// runUpBatch provisions the VMs named by --name-prefix and --count
+// concurrently, each through its own Provisioner. A failed VM does not stop
+// the others; the command exits 1 when any VM failed.
+func runUpBatch(cmd *cobra.Command, deps *upDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	jsonOutput := cliCtx != nil && cliCtx.JSON
+	w := cmd.OutOrStdout()
+
+	if deps.newProvisioner == nil {
+		return fmt.Errorf("batch provisioning is not available")
+	}
+
+	count, _ := cmd.Flags().GetInt("count")
+	prefix, _ := cmd.Flags().GetString("name-prefix")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+	}
+	names := provision.BatchVMNames(prefix, count)
+	spot, spotFallback, err := spotFlags(cmd)
+	if err != nil {
+		return err
+	}
+	ipMode, err := ipModeFlag(cmd, deps.ipMode)
+	if err != nil {
+		return err
+	}
+	kmsKeyID, err := kmsKeyFlag(cmd, deps.kmsKeyID)
+	if err != nil {
+		return err
+	}
+	network, err := networkFlags(cmd, deps.network)
+	if err != nil {
+		return err
+	}
+
+	// Existing VMs are started rather than created and keep their Elastic
+	// IPs, so only the missing ones count against the quota.
+	missing := names
+	if deps.describe != nil {
+		existing, err := vm.ListVMs(ctx, deps.describe, deps.owner)
+		if err != nil {
+			return fmt.Errorf("listing VMs: %w", err)
+		}
+		missing = missingVMs(names, existing)
+	}
+	// ipv6-only VMs have no Elastic IP. Each run still checks the quota
+	// itself when a [vm.<name>] table gives it an Elastic IP.
+	if deps.describeAddrs != nil && deps.accountAttrs != nil && ipMode != tags.IPModeIPv6Only {
+		if err := provision.CheckBatchEIPQuota(ctx, deps.describeAddrs, deps.accountAttrs, deps.owner, missing); err != nil {
+			return err
+		}
+	}
+
+	efsID, err := discoverEFS(ctx, deps.describeFileSystems)
+	if err != nil {
+		return fmt.Errorf("discovering EFS: %w", err)
+	}
+	src, err := resolveBootstrapSource(ctx, deps.resolveBootstrap, deps.bootstrapURL)
+	if err != nil {
+		return err
+	}
+	if src, err = bootstrapFileSource(cmd, src); err != nil {
+		return err
+	}
+	cfg := provision.ProvisionConfig{
+		InstanceType:        deps.instanceType,
+		VolumeSize:          deps.volumeSize,
+		VolumeIOPS:          deps.volumeIOPS,
+		VolumeThroughput:    deps.volumeThroughput,
+		IdleTimeout:         deps.idleTimeout,
+		BootstrapScript:     deps.bootstrapScript,
+		BootstrapURL:        deps.bootstrapURL,
+		Bootstrap:           src,
+		EFSID:               efsID,
+		UserBootstrapScript: deps.userBootstrapScript,
+		Spot:                spot,
+		SpotFallback:        spotFallback,
+		IPMode:              ipMode,
+		Hibernate:           deps.hibernate,
+		KMSKeyID:            kmsKeyID,
+		InstanceProfile:     deps.instanceProfile,
+		SSHPort:             deps.sshOptions.Port,
+	}
+	if ttl, err := ttlFlag(cmd, "ttl"); err != nil {
+		return err
+	} else if ttl > 0 {
+		cfg.ExpiresAt = time.Now().Add(ttl)
+	}
+	network.apply(&cfg)
+
+	// Resolve every VM's [vm.<name>] overrides before launching any, so a
+	// bad table fails the batch up front.
+	vmCfgs := make(map[string]provision.ProvisionConfig, len(names))
+	for _, name := range names {
+		vmCfg := cfg
+		if err := applyVMConfig(cmd, deps, name, &vmCfg); err != nil {
+			return err
+		}
+		vmCfgs[name] = vmCfg
+	}
+
+	if !jsonOutput {
+		fmt.Fprintf(w, "Provisioning %d VMs (%s … %s), %d at a time...\n",
+			len(names), names[0], names[len(names)-1], concurrency)
+	}
+
+	var mu sync.Mutex
+	done := 0
+	results := provision.RunBatch(ctx, names, concurrency, func(ctx context.Context, vmName string) (*provision.ProvisionResult, error) {
+		p, err := deps.newProvisioner()
+		var result *provision.ProvisionResult
+		if err == nil {
+			result, err = p.Run(ctx, deps.owner, deps.ownerARN, vmName, vmCfgs[vmName])
+		}
+		if !jsonOutput {
+			mu.Lock()
+			done++
+			if err != nil {
+				fmt.Fprintf(w, "[%d/%d] %s failed: %v\n", done, len(names), vmName, err)
+			} else {
+				fmt.Fprintf(w, "[%d/%d] %s provisioned: %s (bootstrap %s)\n", done, len(names), vmName, result.InstanceID, batchBootstrapLabel(result, nil))
+			}
+			mu.Unlock()
+		}
+		return result, err
+	})
+
+	// Record resources and write SSH config entries serially: neither the
+	// history context nor the SSH config file is safe for concurrent writers.
+	for _, r := range results {
+		if r.Result == nil {
+			continue
+		}
+		touchProvisionedResources(cliCtx, r.Result)
+		if deps.sshConfigApproved && r.Result.PublicIP != "" {
+			writeSSHConfigAfterUp(ctx, cmd, deps, r.VMName, r.Result)
+		}
+	}
+
+	if jsonOutput {
+		if err := writeUpBatchJSON(w, results); err != nil {
+			return err
+		}
+	} else {
+		writeUpBatchSummary(w, results)
+	}
+
+	if countBatchFailures(results) > 0 {
+		return silentExitError{}
+	}
+	return nil
+}
+
+// missingVMs returns the names that have no existing VM.
+func missingVMs(names []string, existing []*vm.VM) []string {
+	have := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		have[v.Name] = true
+	}
+	var missing []string
+	for _, name := range names {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// batchFailed reports whether a batch VM counts as failed: provisioning
+// errored or core bootstrap did not complete.
+func batchFailed(r provision.BatchResult) bool {
+	return r.Err != nil || r.Result.BootstrapError != nil
+}
+
+// countBatchFailures returns the number of failed VMs in a batch.
+func countBatchFailures(results []provision.BatchResult) int {
+	n := 0
+	for _, r := range results {
+		if batchFailed(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// batchBootstrapLabel summarises a batch VM's bootstrap outcome. A fresh
+// provision without a bootstrap error completed, matching single-VM up.
+func batchBootstrapLabel(result *provision.ProvisionResult, err error) string {
+	switch {
+	case err != nil || result.BootstrapError != nil:
+		return "FAILED"
+	case result.UserBootstrapError != nil:
+		code, _ := userBootstrapExitCode(result.UserBootstrapError)
+		return fmt.Sprintf("complete, user hook failed (exit %d)", code)
+	case result.BootstrapStatus == "":
+		return tags.BootstrapComplete
+	default:
+		return result.BootstrapStatus
+	}
+}
+
+// writeUpBatchSummary prints the name → instance → IP → bootstrap table and
+// lists the failures.
+func writeUpBatchSummary(w io.Writer, results []provision.BatchResult) {
+	fmt.Fprintf(w, "\n%-20s  %-20s  %-15s  %s\n", "NAME", "INSTANCE", "IP", "BOOTSTRAP")
+	for _, r := range results {
+		id, ip := "-", "-"
+		if r.Result != nil {
+			id = r.Result.InstanceID
+			if r.Result.PublicIP != "" {
+				ip = r.Result.PublicIP
+			}
+		}
+		fmt.Fprintf(w, "%-20s  %-20s  %-15s  %s\n", r.VMName, id, ip, batchBootstrapLabel(r.Result, r.Err))
+	}
+
+	failed := countBatchFailures(results)
+	if failed == 0 {
+		fmt.Fprintf(w, "\nAll %d VMs ready.\n", len(results))
+		return
+	}
+	fmt.Fprintf(w, "\n%d of %d VMs failed:\n", failed, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "  %s: %v\n", r.VMName, r.Err)
+		} else if r.Result.BootstrapError != nil {
+			fmt.Fprintf(w, "  %s: %v\n", r.VMName, r.Result.BootstrapError)
+		}
+	}
+}
+
+// writeUpBatchJSON prints the batch as a JSON array of per-VM results.
+func writeUpBatchJSON(w io.Writer, results []provision.BatchResult) error {
+	out := make([]upBatchResult, 0, len(results))
+	for _, r := range results {
+		item := upBatchResult{VM: r.VMName, BootstrapStatus: batchBootstrapLabel(r.Result, r.Err)}
+		if r.Result != nil {
+			item.InstanceID = r.Result.InstanceID
+			item.PublicIP = r.Result.PublicIP
+			item.VolumeID = r.Result.VolumeID
+			item.AllocationID = r.Result.AllocationID
+			if r.Result.BootstrapError != nil {
+				item.Error = r.Result.BootstrapError.Error()
+				item.FailureReason, item.FailedStep = bootstrapFailureReason(r.Result.BootstrapError)
+			}
+		}
+		if r.Err != nil {
+			item.Error = r.Err.Error()
+		}
+		out = append(out, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+)
+
+// eipAddresses returns a DescribeAddresses stub reporting n allocated EIPs.
+func eipAddresses(n int) *cmdtest.DescribeAddresses {
+	out := &ec2.DescribeAddressesOutput{}
+	for i := 0; i < n; i++ {
+		out.Addresses = append(out.Addresses, ec2types.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))})
+	}
+	return &cmdtest.DescribeAddresses{Output: out}
+}
+
+// newBatchTestUpDeps returns up deps whose batch provisioners fail for the
+// VM names in fail. built counts the provisioners created.
+func newBatchTestUpDeps(fail map[string]bool, built *atomic.Int32) *upDeps {
+	deps := newTestUpDeps()
+	deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}}
+	deps.describeAddrs = eipAddresses(0)
+	deps.accountAttrs = cmdtest.EIPLimit(5)
+	failVMs := make(map[string]error, len(fail))
+	for name := range fail {
+		failVMs[name] = fmt.Errorf("describe failed for %s", name)
+	}
+	deps.newProvisioner = func() (*provision.Provisioner, error) {
+		built.Add(1)
+		return newTestProvisionerWithDescribe(&cmdtest.TaggedDescribeInstances{FailVMs: failVMs}), nil
+	}
+	return deps
+}
+
+func runUpBatchCommand(t *testing.T, deps *upDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newUpCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"up"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestUpBatchSummarizesFailures(t *testing.T) {
+	var built atomic.Int32
+	deps := newBatchTestUpDeps(map[string]bool{"ws-02": true, "ws-04": true}, &built)
+
+	out, err := runUpBatchCommand(t, deps, "--count", "4", "--name-prefix", "ws-")
+	if !errors.As(err, &silentExitError{}) {
+		t.Fatalf("error = %v, want silentExitError", err)
+	}
+	if ExitCode(err) != 1 {
+		t.Errorf("exit code = %d, want 1", ExitCode(err))
+	}
+	if built.Load() != 4 {
+		t.Errorf("built %d provisioners, want one per VM (4)", built.Load())
+	}
+
+	for _, want := range []string{
+		"Provisioning 4 VMs (ws-01 … ws-04), 3 at a time",
+		"NAME", "INSTANCE", "IP", "BOOTSTRAP",
+		"2 of 4 VMs failed:",
+		"ws-02: discovering VM: describe instances: describe failed for ws-02",
+		"ws-04: discovering VM: describe instances: describe failed for ws-04",
+		"ws-01 provisioned: i-test123 (bootstrap complete)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	table := out[strings.Index(out, "NAME"):]
+	for _, row := range []struct{ name, want string }{
+		{"ws-01", "i-test123"},
+		{"ws-02", "FAILED"},
+		{"ws-03", "54.10.20.30"},
+		{"ws-04", "FAILED"},
+	} {
+		line := ""
+		for _, l := range strings.Split(table, "\n") {
+			if strings.HasPrefix(l, row.name+" ") {
+				line = l
+			}
+		}
+		if !strings.Contains(line, row.want) {
+			t.Errorf("table row for %s = %q, want it to contain %q", row.name, line, row.want)
+		}
+	}
+}
+
+func TestUpBatchAllSucceed(t *testing.T) {
+	var built atomic.Int32
+	deps := newBatchTestUpDeps(nil, &built)
+
+	out, err := runUpBatchCommand(t, deps, "--count", "2", "--name-prefix", "ws-", "--concurrency", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "All 2 VMs ready.") || !strings.Contains(out, "1 at a time") {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestUpBatchJSON(t *testing.T) {
+	var built atomic.Int32
+	deps := newBatchTestUpDeps(map[string]bool{"ws-01": true}, &built)
+
+	out, err := runUpBatchCommand(t, deps, "--count", "2", "--name-prefix", "ws-", "--json")
+	if !errors.As(err, &silentExitError{}) {
+		t.Fatalf("error = %v, want silentExitError", err)
+	}
+
+	var results []map[string]any
+	if err := json.Unmarshal([]byte(out), &results); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0]["vm"] != "ws-01" || results[0]["bootstrap_status"] != "FAILED" ||
+		!strings.Contains(fmt.Sprint(results[0]["error"]), "describe failed") {
+		t.Errorf("results[0] = %v", results[0])
+	}
+	if results[1]["vm"] != "ws-02" || results[1]["instance_id"] != "i-test123" || results[1]["error"] != nil {
+		t.Errorf("results[1] = %v", results[1])
+	}
+}
+
+func TestUpBatchEIPQuotaPrecheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		used     int
+		existing *ec2.DescribeInstancesOutput
+		count    string
+		wantErr  string
+	}{
+		{
+			name:    "not enough free EIPs",
+			used:    3,
+			count:   "3",
+			wantErr: "needs 3 new Elastic IPs but only 2 of the region's 5 are available (3 allocated in the account)",
+		},
+		{
+			name:     "existing VMs need no new EIP",
+			used:     3,
+			existing: makeRunningInstance("i-existing", "ws-02", "testuser"),
+			count:    "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var built atomic.Int32
+			deps := newBatchTestUpDeps(nil, &built)
+			deps.describeAddrs = eipAddresses(tt.used)
+			if tt.existing != nil {
+				deps.describe = &cmdtest.DescribeInstances{Output: tt.existing}
+			}
+
+			_, err := runUpBatchCommand(t, deps, "--count", tt.count, "--name-prefix", "ws-")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if built.Load() != 0 {
+				t.Errorf("provisioned %d VMs despite failed quota check", built.Load())
+			}
+		})
+	}
+}
+
+func TestUpBatchFlagValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "count without prefix", args: []string{"--count", "3"}, wantErr: "--count requires --name-prefix"},
+		{name: "zero count", args: []string{"--count", "0", "--name-prefix", "ws-"}, wantErr: "--count must be at least 1"},
+		{name: "vm with prefix", args: []string{"--vm", "dev", "--name-prefix", "ws-"}, wantErr: "--vm cannot be combined"},
+		{name: "zero concurrency", args: []string{"--name-prefix", "ws-", "--concurrency", "0"}, wantErr: "--concurrency must be at least 1"},
+		{name: "abandon journal with prefix", args: []string{"--name-prefix", "ws-", "--abandon-journal"}, wantErr: "--abandon-journal cannot be combined"},
+		{name: "dry run with prefix", args: []string{"--name-prefix", "ws-", "--dry-run"}, wantErr: "--dry-run cannot be combined with --name-prefix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var built atomic.Int32
+			_, err := runUpBatchCommand(t, newBatchTestUpDeps(nil, &built), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+			if built.Load() != 0 {
+				t.Error("provisioned despite invalid flags")
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+)
+
+// upPlanJSON is the JSON object mint up --dry-run prints.
+type upPlanJSON struct {
+	DryRun                  bool     `json:"dry_run"`
+	VM                      string   `json:"vm"`
+	Action                  string   `json:"action"`
+	InstanceID              string   `json:"instance_id,omitempty"`
+	ResumeStep              string   `json:"resume_step,omitempty"`
+	InstanceType            string   `json:"instance_type,omitempty"`
+	InstanceTypeWarning     string   `json:"instance_type_warning,omitempty"`
+	AMI                     string   `json:"ami,omitempty"`
+	IPMode                  string   `json:"ip_mode,omitempty"`
+	Spot                    bool     `json:"spot,omitempty"`
+	Hibernate               bool     `json:"hibernate,omitempty"`
+	SubnetID                string   `json:"subnet_id,omitempty"`
+	AvailabilityZone        string   `json:"availability_zone,omitempty"`
+	SecurityGroupIDs        []string `json:"security_group_ids,omitempty"`
+	RootVolumeGB            int32    `json:"root_volume_gb,omitempty"`
+	ProjectVolumeGB         int32    `json:"project_volume_gb,omitempty"`
+	ProjectVolumeIOPS       int32    `json:"project_volume_iops,omitempty"`
+	ProjectVolumeThroughput int32    `json:"project_volume_throughput_mb,omitempty"`
+	AdoptVolumeID           string   `json:"adopt_volume_id,omitempty"`
+	EIP                     string   `json:"eip,omitempty"`
+	AllocationID            string   `json:"allocation_id,omitempty"`
+	UserDataBytes           int      `json:"user_data_bytes,omitempty"`
+	UserDataMaxBytes        int      `json:"user_data_max_bytes,omitempty"`
+	PrefetchImages          int      `json:"prefetch_images,omitempty"`
+}
+
+// printUpPlan prints the plan of a mint up --dry-run for vmName.
+func printUpPlan(cmd *cobra.Command, vmName string, result *provision.ProvisionResult, jsonOutput bool) error {
+	plan := result.Plan
+	w := cmd.OutOrStdout()
+
+	if jsonOutput {
+		out := upPlanJSON{
+			DryRun:                  true,
+			VM:                      vmName,
+			Action:                  string(plan.Action),
+			InstanceID:              plan.InstanceID,
+			ResumeStep:              plan.ResumeStep,
+			InstanceType:            plan.InstanceType,
+			InstanceTypeWarning:     result.InstanceTypeWarning,
+			AMI:                     plan.AMI,
+			IPMode:                  plan.IPMode,
+			Spot:                    plan.Spot,
+			Hibernate:               plan.Hibernate,
+			SubnetID:                plan.SubnetID,
+			AvailabilityZone:        plan.AvailabilityZone,
+			SecurityGroupIDs:        plan.SecurityGroupIDs,
+			RootVolumeGB:            plan.RootVolumeGB,
+			ProjectVolumeGB:         plan.ProjectVolumeGB,
+			ProjectVolumeIOPS:       plan.ProjectVolumeIOPS,
+			ProjectVolumeThroughput: plan.ProjectVolumeThroughput,
+			AdoptVolumeID:           plan.AdoptVolumeID,
+			EIP:                     plan.EIP,
+			AllocationID:            plan.AllocationID,
+			UserDataBytes:           plan.UserDataBytes,
+			PrefetchImages:          plan.PrefetchImages,
+		}
+		if plan.Action == provision.PlanLaunch {
+			out.UserDataMaxBytes = bootstrap.MaxUserDataBytes
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	switch plan.Action {
+	case provision.PlanLaunch:
+		fmt.Fprintf(w, "Dry run: mint up would launch a new instance for VM %q.\n", vmName)
+		writeUpLaunchPlan(w, result)
+	case provision.PlanStart:
+		fmt.Fprintf(w, "Dry run: mint up would start VM %q (%s).\n", vmName, plan.InstanceID)
+		if plan.EIP == provision.PlanEIPAllocate {
+			fmt.Fprintln(w, "EIP           allocate a new Elastic IP (mint gc released the previous one)")
+		}
+	case provision.PlanResume:
+		fmt.Fprintf(w, "Dry run: mint up would resume the interrupted run for VM %q at instance %s (next step: %s).\n",
+			vmName, plan.InstanceID, plan.ResumeStep)
+	default:
+		fmt.Fprintf(w, "Dry run: VM %q (%s) is already running.\n", vmName, plan.InstanceID)
+		if plan.AdoptVolumeID != "" {
+			fmt.Fprintf(w, "Volume        attach %s, left pending by an interrupted mint recreate\n", plan.AdoptVolumeID)
+		}
+	}
+
+	fmt.Fprintf(w, "\nNothing was created or changed. Run %s to apply it.\n", hint.Cmd("mint up"))
+	return nil
+}
+
+// writeUpLaunchPlan prints the resources of a launch plan, one per line.
+func writeUpLaunchPlan(w io.Writer, result *provision.ProvisionResult) {
+	plan := result.Plan
+
+	instanceType := plan.InstanceType
+	if plan.Spot {
+		instanceType += " (spot)"
+	}
+	fmt.Fprintf(w, "Type          %s\n", instanceType)
+	if result.InstanceTypeWarning != "" {
+		fmt.Fprintf(w, "              %s\n", result.InstanceTypeWarning)
+	}
+	fmt.Fprintf(w, "AMI           %s\n", plan.AMI)
+	fmt.Fprintf(w, "Subnet        %s (%s)\n", plan.SubnetID, plan.AvailabilityZone)
+	fmt.Fprintf(w, "Groups        %s\n", strings.Join(plan.SecurityGroupIDs, ", "))
+	if plan.Hibernate {
+		fmt.Fprintf(w, "Root volume   %s gp3, encrypted, sized for hibernation\n", format.FormatGiB(int(plan.RootVolumeGB)))
+	} else {
+		fmt.Fprintf(w, "Root volume   %s gp3\n", format.FormatGiB(int(plan.RootVolumeGB)))
+	}
+	if plan.AdoptVolumeID != "" {
+		fmt.Fprintf(w, "Volume        attach %s, left pending by an interrupted mint recreate\n", plan.AdoptVolumeID)
+	} else {
+		fmt.Fprintf(w, "Volume        %s gp3, %d IOPS, %d MB/s\n", format.FormatGiB(int(plan.ProjectVolumeGB)), plan.ProjectVolumeIOPS, plan.ProjectVolumeThroughput)
+	}
+	switch plan.EIP {
+	case provision.PlanEIPNone:
+		fmt.Fprintf(w, "EIP           none (ip_mode %s)\n", plan.IPMode)
+	case provision.PlanEIPReuse:
+		fmt.Fprintf(w, "EIP           reuse %s\n", plan.AllocationID)
+	default:
+		fmt.Fprintln(w, "EIP           allocate a new Elastic IP")
+	}
+	userData := fmt.Sprintf("%d of %d bytes", plan.UserDataBytes, bootstrap.MaxUserDataBytes)
+	if plan.PrefetchImages > 0 {
+		userData += fmt.Sprintf(", prefetching %d images", plan.PrefetchImages)
+	}
+	fmt.Fprintf(w, "User data     %s\n", userData)
+}
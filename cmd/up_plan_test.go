@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+)
+
+// newDryRunUpDeps returns up deps whose provisioner plans a fresh launch
+// instead of running it.
+func newDryRunUpDeps() *upDeps {
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerWithDescribe(
+		&cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		provision.WithDryRun(true),
+	)
+	return deps
+}
+
+func TestUpCommandDryRunHumanOutput(t *testing.T) {
+	hint.IsTTY = false
+
+	out, err := runUpBatchCommand(t, newDryRunUpDeps(), "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	for _, want := range []string{
+		`Dry run: mint up would launch a new instance for VM "default".`,
+		"Type          m6i.xlarge",
+		"AMI           ami-test",
+		"Subnet        subnet-test (us-east-1a)",
+		"Groups        sg-user, sg-admin",
+		"Root volume   200 GiB gp3",
+		"Volume        50 GiB gp3",
+		"EIP           allocate a new Elastic IP",
+		"Nothing was created or changed. Run `mint up` to apply it.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"i-test123", "eipalloc-test", "Bootstrap complete"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("dry run reported a provisioned resource %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestUpCommandDryRunJSONOutput(t *testing.T) {
+	out, err := runUpBatchCommand(t, newDryRunUpDeps(), "--dry-run", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	var plan upPlanJSON
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if !plan.DryRun || plan.VM != "default" || plan.Action != "launch" {
+		t.Errorf("dry_run/vm/action = %v/%q/%q, want true/default/launch", plan.DryRun, plan.VM, plan.Action)
+	}
+	if plan.InstanceID != "" {
+		t.Errorf("instance_id = %q, want none for a launch plan", plan.InstanceID)
+	}
+	if plan.AMI != "ami-test" || plan.SubnetID != "subnet-test" || plan.EIP != provision.PlanEIPAllocate {
+		t.Errorf("ami/subnet/eip = %q/%q/%q", plan.AMI, plan.SubnetID, plan.EIP)
+	}
+	if plan.RootVolumeGB != 200 || plan.ProjectVolumeGB != 50 {
+		t.Errorf("root/project volume = %d/%d GiB, want 200/50", plan.RootVolumeGB, plan.ProjectVolumeGB)
+	}
+	if plan.UserDataBytes == 0 || plan.UserDataBytes > plan.UserDataMaxBytes {
+		t.Errorf("user_data_bytes = %d, max %d", plan.UserDataBytes, plan.UserDataMaxBytes)
+	}
+}
+
+func TestUpCommandDryRunRejectsAbandonJournal(t *testing.T) {
+	out, err := runUpBatchCommand(t, newDryRunUpDeps(), "--dry-run", "--abandon-journal")
+	if err == nil || !strings.Contains(err.Error(), "--dry-run cannot be combined with --abandon-journal") {
+		t.Fatalf("error = %v, want --abandon-journal conflict\n%s", err, out)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+	"github.com/spf13/cobra"
+)
+
+// defaultReconcileSSHWait bounds how long mint up waits for SSH on a
+// restarted VM before giving up on restarting its project containers.
+const defaultReconcileSSHWait = 3 * time.Minute
+
+// reconcileSSHRetryInterval is the delay between SSH attempts while waiting
+// for a restarted VM.
+const reconcileSSHRetryInterval = 5 * time.Second
+
+// reconcileIfRestarted reconciles project containers when mint up started a
+// stopped VM, which comes back with its containers stopped, unless
+// --no-reconcile is set. JSON output is left untouched.
+func reconcileIfRestarted(ctx context.Context, cmd *cobra.Command, deps *upDeps, vmName string, result *provision.ProvisionResult, jsonOutput bool) {
+	if noReconcile, _ := cmd.Flags().GetBool("no-reconcile"); !result.Restarted || noReconcile {
+		return
+	}
+	w := cmd.OutOrStdout()
+	if jsonOutput {
+		w = io.Discard
+	}
+	reconcileProjectsAfterUp(ctx, w, deps, vmName, result)
+}
+
+// reconcileProjectsAfterUp restarts the project containers of a restarted
+// VM that the project cache last saw running. Every failure is a warning:
+// the VM itself is up, and mint project start can finish the job.
+func reconcileProjectsAfterUp(ctx context.Context, w io.Writer, deps *upDeps, vmName string, result *provision.ProvisionResult) {
+	if deps.remote == nil || deps.projectCacheDir == "" || deps.describe == nil {
+		return
+	}
+	projects := lastRunningProjects(deps.projectCacheDir, vmName)
+	if len(projects) == 0 {
+		return
+	}
+	retryHint := hint.Cmd("mint project start --all")
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil || found == nil {
+		fmt.Fprintf(w, "Warning: could not look up VM to restart project containers (%v) — run %s\n", err, retryHint)
+		return
+	}
+	host := found.PublicIP
+	if host == "" {
+		host = result.PublicIP
+	}
+	runner := func(remote RemoteCommandRunner) func(command ...string) ([]byte, error) {
+		return func(command ...string) ([]byte, error) {
+			return remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone, host,
+				deps.sshOptions.LoginPort(defaultSSHPort), defaultSSHUser, command)
+		}
+	}
+
+	fmt.Fprintf(w, "Waiting for SSH to restart %d project container(s)...\n", len(projects))
+	wait, retry := deps.sshWait, deps.sshRetry
+	if wait == 0 {
+		wait = defaultReconcileSSHWait
+	}
+	if retry == 0 {
+		retry = reconcileSSHRetryInterval
+	}
+	if err := waitForSSH(ctx, runner(deps.remote), wait, retry); err != nil {
+		fmt.Fprintf(w, "Warning: SSH is not reachable yet (%v) — run %s once it is\n", err, retryHint)
+		return
+	}
+
+	// Host key verification waits until sshd answers; scanning a booting
+	// VM would fail.
+	remote := deps.remote
+	if deps.hostKeyStore != nil && deps.hostKeyScanner != nil {
+		remote = NewTOFURemoteRunner(deps.remote, deps.hostKeyStore, deps.hostKeyScanner, vmName).Run
+	}
+	run := runner(remote)
+
+	containers, err := inspectProjectContainers(run)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: could not inspect project containers (%v) — run %s\n", err, retryHint)
+		return
+	}
+	for _, project := range projects {
+		if err := startProject(w, run, project, containers); err != nil {
+			fmt.Fprintf(w, "Warning: %v\n", err)
+		}
+	}
+}
+
+// waitForSSH runs `true` on the VM until it succeeds or wait elapses,
+// returning the last error on timeout.
+func waitForSSH(ctx context.Context, run func(command ...string) ([]byte, error), wait, retry time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		_, err := run("true")
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(retry).After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
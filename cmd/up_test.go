@@ -3,211 +3,112 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/efs"
 	efstypes "github.com/aws/aws-sdk-go-v2/service/efs/types"
-	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
-	"github.com/SpiceLabsHQ/Mint/internal/cli"
-	"github.com/SpiceLabsHQ/Mint/internal/hint"
-	"github.com/SpiceLabsHQ/Mint/internal/provision"
 	"github.com/spf13/cobra"
 )
 
 // ---------------------------------------------------------------------------
-// Inline mocks for cmd-level up tests
+// Helper: build a test Provisioner with happy-path stubs
 // ---------------------------------------------------------------------------
 
-type stubUpDescribeInstances struct {
-	output *ec2.DescribeInstancesOutput
-	err    error
-}
-
-func (s *stubUpDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpStartInstances struct {
-	output *ec2.StartInstancesOutput
-	err    error
-}
-
-func (s *stubUpStartInstances) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpRunInstances struct {
-	output *ec2.RunInstancesOutput
-	err    error
-}
-
-func (s *stubUpRunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpDescribeSGs struct {
-	outputs []*ec2.DescribeSecurityGroupsOutput
-	errs    []error
-	calls   int
+// userAndAdminSecurityGroups answers the provisioner's security group
+// lookups with sg-user and sg-admin.
+func userAndAdminSecurityGroups() *cmdtest.DescribeSecurityGroups {
+	return &cmdtest.DescribeSecurityGroups{ByComponent: map[string]*ec2.DescribeSecurityGroupsOutput{
+		tags.ComponentSecurityGroup: {SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-user")}}},
+		"admin":                     {SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-admin")}}},
+	}}
 }
 
-func (s *stubUpDescribeSGs) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
-	idx := s.calls
-	s.calls++
-	if idx < len(s.outputs) {
-		var err error
-		if idx < len(s.errs) {
-			err = s.errs[idx]
-		}
-		return s.outputs[idx], err
+// testProvisioner returns provision.NewProvisioner(c, opts...), panicking
+// when a client is missing.
+func testProvisioner(c provision.Clients, opts ...provision.Option) *provision.Provisioner {
+	p, err := provision.NewProvisioner(c, opts...)
+	if err != nil {
+		panic(err)
 	}
-	return nil, fmt.Errorf("unexpected call %d", idx)
-}
-
-type stubUpDescribeSubnets struct {
-	output *ec2.DescribeSubnetsOutput
-	err    error
-}
-
-func (s *stubUpDescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpCreateVolume struct {
-	output *ec2.CreateVolumeOutput
-	err    error
-}
-
-func (s *stubUpCreateVolume) CreateVolume(ctx context.Context, params *ec2.CreateVolumeInput, optFns ...func(*ec2.Options)) (*ec2.CreateVolumeOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpAttachVolume struct {
-	output *ec2.AttachVolumeOutput
-	err    error
-}
-
-func (s *stubUpAttachVolume) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpAllocateAddress struct {
-	output *ec2.AllocateAddressOutput
-	err    error
-}
-
-func (s *stubUpAllocateAddress) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpAssociateAddress struct {
-	output *ec2.AssociateAddressOutput
-	err    error
-}
-
-func (s *stubUpAssociateAddress) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpDescribeAddresses struct {
-	output *ec2.DescribeAddressesOutput
-	err    error
-}
-
-func (s *stubUpDescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpCreateTags struct {
-	output *ec2.CreateTagsOutput
-	err    error
-}
-
-func (s *stubUpCreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpDescribeImages struct {
-	output *ec2.DescribeImagesOutput
-	err    error
-}
-
-func (s *stubUpDescribeImages) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
-	return s.output, s.err
-}
-
-type stubUpDescribeFileSystems struct {
-	output *efs.DescribeFileSystemsOutput
-	err    error
+	return p
 }
 
-func (s *stubUpDescribeFileSystems) DescribeFileSystems(ctx context.Context, params *efs.DescribeFileSystemsInput, optFns ...func(*efs.Options)) (*efs.DescribeFileSystemsOutput, error) {
-	return s.output, s.err
+func newTestProvisioner() *provision.Provisioner {
+	return newTestProvisionerWithDescribe(&cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}})
 }
 
-// ---------------------------------------------------------------------------
-// Helper: build a test Provisioner with happy-path stubs
-// ---------------------------------------------------------------------------
-
-func newTestProvisioner() *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubUpStartInstances{output: &ec2.StartInstancesOutput{}},
-		&stubUpRunInstances{output: &ec2.RunInstancesOutput{
+// newTestProvisionerWithDescribe builds a happy-path test Provisioner around
+// the given DescribeInstances client, with opts applied after the defaults.
+func newTestProvisionerWithDescribe(describe mintaws.DescribeInstancesAPI, opts ...provision.Option) *provision.Provisioner {
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: describe,
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances: &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
 			Instances: []ec2types.Instance{{
 				InstanceId: aws.String("i-test123"),
 				BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
 					DeviceName: aws.String("/dev/xvdf"),
-					Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-test")},
+					Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-test")},
 				}},
 			}},
 		}},
-		&stubUpDescribeSGs{
-			outputs: []*ec2.DescribeSecurityGroupsOutput{
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-user")}}},
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-admin")}}},
-			},
-			errs: []error{nil, nil},
-		},
-		&stubUpDescribeSubnets{output: &ec2.DescribeSubnetsOutput{
+		DescribeSGs: userAndAdminSecurityGroups(),
+		DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{
 				SubnetId:         aws.String("subnet-test"),
 				AvailabilityZone: aws.String("us-east-1a"),
 			}},
 		}},
-		&stubUpCreateVolume{output: &ec2.CreateVolumeOutput{
+		CreateVolume: &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{
 			VolumeId: aws.String("vol-test"),
 		}},
-		&stubUpAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		&stubUpAllocateAddress{output: &ec2.AllocateAddressOutput{
+		AttachVolume: &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr: &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{
 			AllocationId: aws.String("eipalloc-test"),
 			PublicIp:     aws.String("54.10.20.30"),
 		}},
-		&stubUpAssociateAddress{output: &ec2.AssociateAddressOutput{}},
-		&stubUpDescribeAddresses{output: &ec2.DescribeAddressesOutput{
+		AssociateAddr: &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs: &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{},
 		}},
-		&stubUpCreateTags{output: &ec2.CreateTagsOutput{}},
-		&stubUpDescribeImages{output: &ec2.DescribeImagesOutput{}},
+		CreateTags:     &cmdtest.CreateTags{},
+		DescribeImages: &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	},
+		append([]provision.Option{
+			provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+			provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+				return "ami-test", nil
+			}),
+		}, opts...)...,
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-test", nil
-	})
 	return p
 }
 
-func defaultEFSStub() *stubUpDescribeFileSystems {
-	return &stubUpDescribeFileSystems{
-		output: &efs.DescribeFileSystemsOutput{
+func defaultEFSStub() *cmdtest.DescribeFileSystems {
+	return &cmdtest.DescribeFileSystems{
+		Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{
 				{
 					FileSystemId: aws.String("fs-test123"),
@@ -257,7 +158,7 @@ func TestUpCommandHumanOutput(t *testing.T) {
 	expectations := []string{
 		"i-test123",
 		"54.10.20.30",
-		"vol-test",
+		"vol-test (50 GiB)",
 		"eipalloc-test",
 		"Bootstrap complete",
 	}
@@ -314,8 +215,8 @@ func TestUpCommandRestartedOutput(t *testing.T) {
 	cmd.SetContext(ctx)
 
 	// Build a provisioner that finds a stopped VM.
-	p := provision.NewProvisioner(
-		&stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
 			Reservations: []ec2types.Reservation{{
 				Instances: []ec2types.Instance{{
 					InstanceId:      aws.String("i-stopped1"),
@@ -331,22 +232,23 @@ func TestUpCommandRestartedOutput(t *testing.T) {
 				}},
 			}},
 		}},
-		&stubUpStartInstances{output: &ec2.StartInstancesOutput{}},
-		&stubUpRunInstances{output: &ec2.RunInstancesOutput{}},
-		&stubUpDescribeSGs{outputs: []*ec2.DescribeSecurityGroupsOutput{}, errs: []error{}},
-		&stubUpDescribeSubnets{output: &ec2.DescribeSubnetsOutput{}},
-		&stubUpCreateVolume{output: &ec2.CreateVolumeOutput{}},
-		&stubUpAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		&stubUpAllocateAddress{output: &ec2.AllocateAddressOutput{}},
-		&stubUpAssociateAddress{output: &ec2.AssociateAddressOutput{}},
-		&stubUpDescribeAddresses{output: &ec2.DescribeAddressesOutput{}},
-		&stubUpCreateTags{output: &ec2.CreateTagsOutput{}},
-		&stubUpDescribeImages{output: &ec2.DescribeImagesOutput{}},
+		StartInstances:  &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances:    &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}},
+		DescribeSGs:     &cmdtest.DescribeSecurityGroups{},
+		DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{}},
+		CreateVolume:    &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{}},
+		AttachVolume:    &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr:    &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{}},
+		AssociateAddr:   &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs:   &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}},
+		CreateTags:      &cmdtest.CreateTags{},
+		DescribeImages:  &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-test", nil
-	})
 
 	deps := &upDeps{
 		provisioner:     p,
@@ -381,26 +283,27 @@ func TestUpCommandError(t *testing.T) {
 	cmd.SetContext(ctx)
 
 	// Build a provisioner that fails on bootstrap verification.
-	p := provision.NewProvisioner(
-		&stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubUpStartInstances{output: &ec2.StartInstancesOutput{}},
-		&stubUpRunInstances{output: &ec2.RunInstancesOutput{}},
-		&stubUpDescribeSGs{outputs: []*ec2.DescribeSecurityGroupsOutput{}, errs: []error{}},
-		&stubUpDescribeSubnets{output: &ec2.DescribeSubnetsOutput{}},
-		&stubUpCreateVolume{output: &ec2.CreateVolumeOutput{}},
-		&stubUpAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		&stubUpAllocateAddress{output: &ec2.AllocateAddressOutput{}},
-		&stubUpAssociateAddress{output: &ec2.AssociateAddressOutput{}},
-		&stubUpDescribeAddresses{output: &ec2.DescribeAddressesOutput{}},
-		&stubUpCreateTags{output: &ec2.CreateTagsOutput{}},
-		&stubUpDescribeImages{output: &ec2.DescribeImagesOutput{}},
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances:      &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}},
+		DescribeSGs:       &cmdtest.DescribeSecurityGroups{},
+		DescribeSubnets:   &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{}},
+		CreateVolume:      &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{}},
+		AttachVolume:      &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr:      &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{}},
+		AssociateAddr:     &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs:     &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}},
+		CreateTags:        &cmdtest.CreateTags{},
+		DescribeImages:    &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error {
+			return fmt.Errorf("hash mismatch")
+		}),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error {
-		return fmt.Errorf("hash mismatch")
-	})
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-test", nil
-	})
 
 	deps := &upDeps{
 		provisioner:     p,
@@ -425,7 +328,7 @@ func TestUpCommandVerboseOutput(t *testing.T) {
 
 	deps := newTestUpDeps()
 	cmd := newUpCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -442,9 +345,101 @@ func TestUpCommandVerboseOutput(t *testing.T) {
 	}
 }
 
+func TestUpCommandVerboseShowsBootstrapSource(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	deps := newTestUpDeps()
+	deps.resolveBootstrap = func(ctx context.Context) (bootstrap.Source, error) {
+		return bootstrap.Source{SHA256: strings.Repeat("cd", 32), URL: "https://example.com/bootstrap.sh", Label: "manifest v12"}, nil
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newUpCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"up", "--verbose"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Bootstrap     manifest v12") {
+		t.Errorf("verbose output should name the bootstrap source, got:\n%s", buf.String())
+	}
+}
+
+func TestUpCommandBootstrapManifestRequiresNewerCLI(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	deps := newTestUpDeps()
+	deps.resolveBootstrap = func(ctx context.Context) (bootstrap.Source, error) {
+		return bootstrap.Source{}, &bootstrap.CLITooOldError{ManifestVersion: 12, Required: "1.4.0", Current: "1.2.0"}
+	}
+	root := cmdtest.NewRoot()
+	root.AddCommand(newUpCommandWithDeps(deps))
+	root.SetOut(buf)
+	root.SetErr(buf)
+	root.SetArgs([]string{"up"})
+
+	err := root.Execute()
+	if err == nil {
+		t.Fatal("expected error when the manifest requires a newer CLI")
+	}
+	if !strings.Contains(err.Error(), "requires mint v1.4.0 or newer") {
+		t.Errorf("error should explain the upgrade, got: %v", err)
+	}
+}
+
+// writeBootstrapFile writes script to a temporary file for --bootstrap-file
+// and returns its path and a source that pins it.
+func writeBootstrapFile(t *testing.T, script string) (string, bootstrap.Source) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bootstrap.sh")
+	if err := os.WriteFile(path, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(script))
+	return path, bootstrap.Source{SHA256: hex.EncodeToString(sum[:]), URL: "https://example.com/bootstrap.sh", Label: "manifest v12"}
+}
+
+func TestUpCommandBootstrapFile(t *testing.T) {
+	path, src := writeBootstrapFile(t, "#!/bin/bash\necho bootstrap\n")
+	otherPath, _ := writeBootstrapFile(t, "#!/bin/bash\necho patched\n")
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{name: "published script", file: path},
+		{name: "modified script", file: otherPath, wantErr: "want the published " + src.SHA256},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing.sh"), wantErr: "reading --bootstrap-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			deps := newTestUpDeps()
+			deps.resolveBootstrap = func(ctx context.Context) (bootstrap.Source, error) { return src, nil }
+			root := cmdtest.NewRoot(newUpCommandWithDeps(deps))
+			root.SetOut(buf)
+			root.SetErr(buf)
+			root.SetArgs([]string{"up", "--bootstrap-file", tt.file})
+
+			err := root.Execute()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestUpCommandNilDeps(t *testing.T) {
 	cmd := newUpCommandWithDeps(nil)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 
 	buf := new(bytes.Buffer)
@@ -659,7 +654,7 @@ func TestUpCommandWritesSSHConfigOnSuccess(t *testing.T) {
 	}
 
 	// Stub DescribeInstances to return the VM with AZ info for SSH config generation.
-	describeStub := &stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{
+	describeStub := &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
 		Reservations: []ec2types.Reservation{{
 			Instances: []ec2types.Instance{{
 				InstanceId:      aws.String("i-test123"),
@@ -705,6 +700,46 @@ func TestUpCommandWritesSSHConfigOnSuccess(t *testing.T) {
 	}
 }
 
+func TestWriteSSHConfigAfterUpLeavesExistingBlock(t *testing.T) {
+	hint.IsTTY = false
+	current := sshconfig.GenerateBlock("default", "5.6.7.8", "ubuntu", 41122, "i-new", "", "", "")
+	stale := sshconfig.GenerateBlock("default", "1.2.3.4", "ubuntu", 41122, "i-old", "", "", "")
+	tests := []struct {
+		name     string
+		existing string
+		want     string // file content afterwards
+		wantHint bool
+	}{
+		{name: "no block is written", existing: "", want: current},
+		{name: "current block", existing: current, want: current},
+		{name: "stale block is left for sync", existing: stale, want: stale, wantHint: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config")
+			if err := os.WriteFile(path, []byte(tt.existing), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			deps := newTestUpDeps()
+			deps.sshConfigPath = path
+
+			writeSSHConfigAfterUp(context.Background(), cmd, deps, "default",
+				&provision.ProvisionResult{InstanceID: "i-new", PublicIP: "5.6.7.8"})
+
+			if got, _ := os.ReadFile(path); string(got) != tt.want {
+				t.Errorf("ssh config =\n%s\nwant:\n%s", got, tt.want)
+			}
+			gotHint := strings.Contains(buf.String(), "run `mint ssh-config sync` to update it")
+			if gotHint != tt.wantHint {
+				t.Errorf("hint shown = %v, want %v; output: %s", gotHint, tt.wantHint, buf.String())
+			}
+		})
+	}
+}
+
 func TestUpCommandSkipsSSHConfigWhenNotApproved(t *testing.T) {
 	buf := new(bytes.Buffer)
 	cmd := &cobra.Command{}
@@ -751,7 +786,7 @@ func TestUpCommandSSHConfigWriteFailureIsWarning(t *testing.T) {
 	// Use a non-existent directory to force write failure.
 	sshConfigPath := "/nonexistent/dir/config"
 
-	describeStub := &stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{
+	describeStub := &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
 		Reservations: []ec2types.Reservation{{
 			Instances: []ec2types.Instance{{
 				InstanceId:      aws.String("i-test123"),
@@ -795,8 +830,8 @@ func TestUpCommandSSHConfigWriteFailureIsWarning(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestDiscoverEFS_Found(t *testing.T) {
-	stub := &stubUpDescribeFileSystems{
-		output: &efs.DescribeFileSystemsOutput{
+	stub := &cmdtest.DescribeFileSystems{
+		Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{
 				{
 					FileSystemId: aws.String("fs-abc123"),
@@ -821,8 +856,8 @@ func TestDiscoverEFS_Found(t *testing.T) {
 func TestDiscoverEFS_NotFound(t *testing.T) {
 	hint.IsTTY = false // Ensure non-TTY mode for consistent test assertions.
 
-	stub := &stubUpDescribeFileSystems{
-		output: &efs.DescribeFileSystemsOutput{
+	stub := &cmdtest.DescribeFileSystems{
+		Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{},
 		},
 	}
@@ -841,8 +876,8 @@ func TestDiscoverEFS_NotFound(t *testing.T) {
 }
 
 func TestDiscoverEFS_APIError(t *testing.T) {
-	stub := &stubUpDescribeFileSystems{
-		err: fmt.Errorf("access denied"),
+	stub := &cmdtest.DescribeFileSystems{
+		Err: fmt.Errorf("access denied"),
 	}
 
 	_, err := discoverEFS(context.Background(), stub)
@@ -855,8 +890,8 @@ func TestDiscoverEFS_APIError(t *testing.T) {
 }
 
 func TestDiscoverEFS_SkipsNonAdminEFS(t *testing.T) {
-	stub := &stubUpDescribeFileSystems{
-		output: &efs.DescribeFileSystemsOutput{
+	stub := &cmdtest.DescribeFileSystems{
+		Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{
 				{
 					FileSystemId: aws.String("fs-user1"),
@@ -894,8 +929,8 @@ func TestUpCommandPopulatesEFSID(t *testing.T) {
 	ctx := cli.WithContext(context.Background(), cliCtx)
 	cmd.SetContext(ctx)
 
-	efsStub := &stubUpDescribeFileSystems{
-		output: &efs.DescribeFileSystemsOutput{
+	efsStub := &cmdtest.DescribeFileSystems{
+		Output: &efs.DescribeFileSystemsOutput{
 			FileSystems: []efstypes.FileSystemDescription{
 				{
 					FileSystemId: aws.String("fs-wire123"),
@@ -928,110 +963,82 @@ func TestUpCommandPopulatesEFSID(t *testing.T) {
 // Tests: --volume-iops flag
 // ---------------------------------------------------------------------------
 
-type captureCreateVolume struct {
-	output *ec2.CreateVolumeOutput
-	err    error
-	input  *ec2.CreateVolumeInput
-}
-
-func (c *captureCreateVolume) CreateVolume(ctx context.Context, params *ec2.CreateVolumeInput, optFns ...func(*ec2.Options)) (*ec2.CreateVolumeOutput, error) {
-	c.input = params
-	return c.output, c.err
-}
-
-func newTestProvisionerWithCreateVolume(cv *captureCreateVolume) *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubUpStartInstances{output: &ec2.StartInstancesOutput{}},
-		&stubUpRunInstances{output: &ec2.RunInstancesOutput{
+func newTestProvisionerWithCreateVolume(cv *cmdtest.CreateVolume) *provision.Provisioner {
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances: &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
 			Instances: []ec2types.Instance{{
 				InstanceId: aws.String("i-test123"),
 				BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
 					DeviceName: aws.String("/dev/xvdf"),
-					Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
+					Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
 				}},
 			}},
 		}},
-		&stubUpDescribeSGs{
-			outputs: []*ec2.DescribeSecurityGroupsOutput{
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-user")}}},
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-admin")}}},
-			},
-			errs: []error{nil, nil},
-		},
-		&stubUpDescribeSubnets{output: &ec2.DescribeSubnetsOutput{
+		DescribeSGs: userAndAdminSecurityGroups(),
+		DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{
 				SubnetId:         aws.String("subnet-test"),
 				AvailabilityZone: aws.String("us-east-1a"),
 			}},
 		}},
-		cv,
-		&stubUpAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		&stubUpAllocateAddress{output: &ec2.AllocateAddressOutput{
+		CreateVolume: cv,
+		AttachVolume: &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr: &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{
 			AllocationId: aws.String("eipalloc-test"),
 			PublicIp:     aws.String("54.10.20.30"),
 		}},
-		&stubUpAssociateAddress{output: &ec2.AssociateAddressOutput{}},
-		&stubUpDescribeAddresses{output: &ec2.DescribeAddressesOutput{
+		AssociateAddr: &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs: &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{},
 		}},
-		&stubUpCreateTags{output: &ec2.CreateTagsOutput{}},
-		&stubUpDescribeImages{output: &ec2.DescribeImagesOutput{}},
+		CreateTags:     &cmdtest.CreateTags{},
+		DescribeImages: &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-test", nil
-	})
 	return p
 }
 
-// captureRunInstances records RunInstances input for IOPS assertion.
-type captureRunInstances struct {
-	output *ec2.RunInstancesOutput
-	err    error
-	input  *ec2.RunInstancesInput
-}
-
-func (c *captureRunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
-	c.input = params
-	return c.output, c.err
-}
-
-func newTestProvisionerCapturingRun(ri *captureRunInstances) *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubUpDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubUpStartInstances{output: &ec2.StartInstancesOutput{}},
-		ri,
-		&stubUpDescribeSGs{
-			outputs: []*ec2.DescribeSecurityGroupsOutput{
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-user")}}},
-				{SecurityGroups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-admin")}}},
-			},
-			errs: []error{nil, nil},
-		},
-		&stubUpDescribeSubnets{output: &ec2.DescribeSubnetsOutput{
+func newTestProvisionerCapturingRun(ri *cmdtest.RunInstances) *provision.Provisioner {
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances:      ri,
+		DescribeSGs:       userAndAdminSecurityGroups(),
+		DescribeSubnets: &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{
 			Subnets: []ec2types.Subnet{{
 				SubnetId:         aws.String("subnet-test"),
 				AvailabilityZone: aws.String("us-east-1a"),
 			}},
 		}},
-		&stubUpCreateVolume{output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-iops")}},
-		&stubUpAttachVolume{output: &ec2.AttachVolumeOutput{}},
-		&stubUpAllocateAddress{output: &ec2.AllocateAddressOutput{
+		CreateVolume: &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-iops")}},
+		AttachVolume: &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr: &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{
 			AllocationId: aws.String("eipalloc-test"),
 			PublicIp:     aws.String("54.10.20.30"),
 		}},
-		&stubUpAssociateAddress{output: &ec2.AssociateAddressOutput{}},
-		&stubUpDescribeAddresses{output: &ec2.DescribeAddressesOutput{
+		AssociateAddr: &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs: &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{
 			Addresses: []ec2types.Address{},
 		}},
-		&stubUpCreateTags{output: &ec2.CreateTagsOutput{}},
-		&stubUpDescribeImages{output: &ec2.DescribeImagesOutput{}},
+		CreateTags: &cmdtest.CreateTags{},
+		// The resolved AMI, described for the GPU image check.
+		DescribeImages: &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{Images: []ec2types.Image{{
+			ImageId:      aws.String("ami-test"),
+			Architecture: ec2types.ArchitectureValuesX8664,
+			Name:         aws.String("ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-20260218"),
+		}}}},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-test", nil
-	})
 	return p
 }
 
@@ -1039,7 +1046,7 @@ func TestUpCommandVolumeIOPSFlagOverridesConfig(t *testing.T) {
 	// When --volume-iops is set on the flag, it should override the deps.volumeIOPS value.
 	buf := new(bytes.Buffer)
 
-	cv := &captureCreateVolume{output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-iops")}}
+	cv := &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{VolumeId: aws.String("vol-iops")}}
 
 	deps := &upDeps{
 		provisioner:         newTestProvisionerWithCreateVolume(cv),
@@ -1053,7 +1060,7 @@ func TestUpCommandVolumeIOPSFlagOverridesConfig(t *testing.T) {
 	}
 
 	cmd := newUpCommandWithDeps(deps)
-	root := newTestRoot()
+	root := cmdtest.NewRoot()
 	root.AddCommand(cmd)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -1078,12 +1085,12 @@ func TestUpCommandVolumeIOPSDefault(t *testing.T) {
 	ctx := cli.WithContext(context.Background(), cliCtx)
 	cmd.SetContext(ctx)
 
-	ri := &captureRunInstances{output: &ec2.RunInstancesOutput{
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
 		Instances: []ec2types.Instance{{
 			InstanceId: aws.String("i-test123"),
 			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
 				DeviceName: aws.String("/dev/xvdf"),
-				Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
 			}},
 		}},
 	}}
@@ -1104,13 +1111,13 @@ func TestUpCommandVolumeIOPSDefault(t *testing.T) {
 		t.Fatalf("runUp error: %v", err)
 	}
 
-	if ri.input == nil {
+	if ri.Input() == nil {
 		t.Fatal("RunInstances was not called")
 	}
-	if len(ri.input.BlockDeviceMappings) < 2 {
+	if len(ri.Input().BlockDeviceMappings) < 2 {
 		t.Fatal("RunInstances: expected at least 2 BlockDeviceMappings (root + project EBS)")
 	}
-	if got := aws.ToInt32(ri.input.BlockDeviceMappings[1].Ebs.Iops); got != 3000 {
+	if got := aws.ToInt32(ri.Input().BlockDeviceMappings[1].Ebs.Iops); got != 3000 {
 		t.Errorf("BDM Iops = %d, want 3000 (default when volumeIOPS is 0)", got)
 	}
 }
@@ -1125,12 +1132,12 @@ func TestUpCommandVolumeIOPSFromDeps(t *testing.T) {
 	ctx := cli.WithContext(context.Background(), cliCtx)
 	cmd.SetContext(ctx)
 
-	ri := &captureRunInstances{output: &ec2.RunInstancesOutput{
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
 		Instances: []ec2types.Instance{{
 			InstanceId: aws.String("i-test123"),
 			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
 				DeviceName: aws.String("/dev/xvdf"),
-				Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
 			}},
 		}},
 	}}
@@ -1151,13 +1158,13 @@ func TestUpCommandVolumeIOPSFromDeps(t *testing.T) {
 		t.Fatalf("runUp error: %v", err)
 	}
 
-	if ri.input == nil {
+	if ri.Input() == nil {
 		t.Fatal("RunInstances was not called")
 	}
-	if len(ri.input.BlockDeviceMappings) < 2 {
+	if len(ri.Input().BlockDeviceMappings) < 2 {
 		t.Fatal("RunInstances: expected at least 2 BlockDeviceMappings (root + project EBS)")
 	}
-	if got := aws.ToInt32(ri.input.BlockDeviceMappings[1].Ebs.Iops); got != 6000 {
+	if got := aws.ToInt32(ri.Input().BlockDeviceMappings[1].Ebs.Iops); got != 6000 {
 		t.Errorf("BDM Iops = %d, want 6000", got)
 	}
 }
@@ -1182,8 +1189,8 @@ func TestUpCommandEFSDiscoveryFailure(t *testing.T) {
 	ctx := cli.WithContext(context.Background(), cliCtx)
 	cmd.SetContext(ctx)
 
-	efsStub := &stubUpDescribeFileSystems{
-		err: fmt.Errorf("EFS service unavailable"),
+	efsStub := &cmdtest.DescribeFileSystems{
+		Err: fmt.Errorf("EFS service unavailable"),
 	}
 
 	deps := newTestUpDeps()
@@ -1270,8 +1277,8 @@ func TestUpMultiVMWarning(t *testing.T) {
 
 			deps := newTestUpDeps()
 			// Wire a describe stub that returns tt.numRunning running instances.
-			deps.describe = &stubUpDescribeInstances{
-				output: makeRunningInstancesOutput(tt.numRunning),
+			deps.describe = &cmdtest.DescribeInstances{
+				Output: makeRunningInstancesOutput(tt.numRunning),
 			}
 
 			err := runUp(cmd, deps)
@@ -1307,8 +1314,8 @@ func TestUpMultiVMWarningJSONMode(t *testing.T) {
 	cmd.SetContext(ctx)
 
 	deps := newTestUpDeps()
-	deps.describe = &stubUpDescribeInstances{
-		output: makeRunningInstancesOutput(2),
+	deps.describe = &cmdtest.DescribeInstances{
+		Output: makeRunningInstancesOutput(2),
 	}
 
 	err := runUp(cmd, deps)
@@ -1328,6 +1335,40 @@ func TestUpMultiVMWarningJSONMode(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Tests: printUpHuman Elastic IP propagation note
+// ---------------------------------------------------------------------------
+
+func TestPrintUpHumanEIPPropagation(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   provision.ProvisionResult
+		verbose  bool
+		wantNote bool
+	}{
+		{name: "slow fresh provision", result: provision.ProvisionResult{EIPPropagation: 7 * time.Second}, verbose: true, wantNote: true},
+		{name: "slow reallocated EIP", result: provision.ProvisionResult{Restarted: true, EIPReallocated: true, EIPPropagation: 7 * time.Second}, verbose: true, wantNote: true},
+		{name: "immediate", result: provision.ProvisionResult{EIPPropagation: time.Second}, verbose: true},
+		{name: "not verbose", result: provision.ProvisionResult{EIPPropagation: 7 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+			result := tt.result
+			result.InstanceID = "i-new1"
+			if err := printUpHuman(cmd, &result, tt.verbose, defaultSSHPort); err != nil {
+				t.Fatal(err)
+			}
+			note := strings.Contains(buf.String(), "Elastic IP took 7s to reach the instance")
+			if note != tt.wantNote {
+				t.Errorf("propagation note shown = %v, want %v:\n%s", note, tt.wantNote, buf.String())
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: printUpHuman for AlreadyRunning VM with bootstrap status (fix #97)
 // ---------------------------------------------------------------------------
@@ -1344,7 +1385,7 @@ func TestPrintUpHumanAlreadyRunningBootstrapComplete(t *testing.T) {
 		BootstrapStatus: "complete",
 	}
 
-	err := printUpHuman(cmd, result, false)
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
 	if err != nil {
 		t.Fatalf("printUpHuman error: %v", err)
 	}
@@ -1374,7 +1415,7 @@ func TestPrintUpHumanAlreadyRunningBootstrapPending(t *testing.T) {
 		BootstrapStatus: "pending",
 	}
 
-	err := printUpHuman(cmd, result, false)
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
 	if err != nil {
 		t.Fatalf("printUpHuman error: %v", err)
 	}
@@ -1406,7 +1447,7 @@ func TestPrintUpHumanAlreadyRunningBootstrapFailed(t *testing.T) {
 		BootstrapError: fmt.Errorf("VM 'default' bootstrap failed. Run 'mint recreate' to rebuild."),
 	}
 
-	err := printUpHuman(cmd, result, false)
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
 	// AlreadyRunning + BootstrapError returns silentExitError (non-nil, empty message)
 	// so the command exits non-zero without double-printing.
 	if err == nil {
@@ -1447,7 +1488,7 @@ func TestPrintUpHumanRestartedBootstrapFailed(t *testing.T) {
 		BootstrapError:  fmt.Errorf("VM \"default\" has a previously failed bootstrap — run 'mint recreate' to recover"),
 	}
 
-	err := printUpHuman(cmd, result, false)
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
 	// printUpHuman returns silentExitError for non-zero exit on bootstrap failure.
 	// The recovery hints are printed to the output, not embedded in the error.
 	if err == nil {
@@ -1485,7 +1526,7 @@ func TestPrintUpHumanRestartedBootstrapFailedExitCode(t *testing.T) {
 		BootstrapError:  fmt.Errorf("VM \"default\" has a previously failed bootstrap — run 'mint recreate' to recover"),
 	}
 
-	err := printUpHuman(cmd, result, false)
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
 	if err == nil {
 		t.Fatal("printUpHuman should return an error (non-zero exit) when bootstrap failed on restart")
 	}
@@ -1532,6 +1573,87 @@ func TestPrintUpHumanRestartedBootstrapFailedJSON(t *testing.T) {
 	}
 }
 
+func TestPrintUpHumanBootstrapTimeoutAndFailureGuidance(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    []string
+		notWant string
+	}{
+		{
+			name:    "failed with step",
+			err:     &provision.BootstrapFailedError{InstanceID: "i-new123", Phase: "packages", Step: "claude"},
+			want:    []string{"Bootstrap failed", "Failed step:  claude", "mint recreate", "ssh -p 2222 ubuntu@54.0.0.1"},
+			notWant: "mint status --watch",
+		},
+		{
+			name:    "timeout",
+			err:     &provision.BootstrapTimeoutError{InstanceID: "i-new123", Elapsed: 15 * time.Minute, LastStatus: "pending"},
+			want:    []string{"may still be bootstrapping", "mint status --watch", "last status: pending", "ssh -p 2222 ubuntu@54.0.0.1", "mint logs --follow"},
+			notWant: "mint recreate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+
+			err := printUpHuman(cmd, &provision.ProvisionResult{
+				InstanceID:     "i-new123",
+				PublicIP:       "54.0.0.1",
+				BootstrapError: tt.err,
+			}, false, 2222)
+			if err == nil {
+				t.Fatal("printUpHuman should return a non-nil error when bootstrap did not complete")
+			}
+			output := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+			if strings.Contains(output, tt.notWant) {
+				t.Errorf("output should not contain %q:\n%s", tt.notWant, output)
+			}
+		})
+	}
+}
+
+func TestPrintUpJSONFailureReason(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason any
+		wantStep   any
+	}{
+		{"failed", &provision.BootstrapFailedError{InstanceID: "i-new123", Step: "docker"}, "bootstrap_failed", "docker"},
+		{"timeout", &provision.BootstrapTimeoutError{InstanceID: "i-new123", Elapsed: time.Minute}, "bootstrap_timeout", nil},
+		{"untyped", fmt.Errorf("VM \"default\" bootstrap failed"), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			cmd := &cobra.Command{}
+			cmd.SetOut(buf)
+
+			if err := printUpJSON(cmd, &provision.ProvisionResult{InstanceID: "i-new123", BootstrapError: tt.err}); err != nil {
+				t.Fatalf("printUpJSON error: %v", err)
+			}
+			var data map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+				t.Fatalf("output is not valid JSON: %v\nOutput: %s", err, buf.String())
+			}
+			if data["failure_reason"] != tt.wantReason {
+				t.Errorf("failure_reason = %v, want %v", data["failure_reason"], tt.wantReason)
+			}
+			if data["failed_step"] != tt.wantStep {
+				t.Errorf("failed_step = %v, want %v", data["failed_step"], tt.wantStep)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: user-bootstrap.sh detection and injection via MINT_CONFIG_DIR
 // ---------------------------------------------------------------------------
@@ -1705,8 +1827,8 @@ func TestUpBootstrapFailureShowsSSHHint(t *testing.T) {
 	}
 }
 
-// TestUpBootstrapFailureShowsLogPath asserts that the recovery block shows
-// the journalctl log path.
+// TestUpBootstrapFailureShowsLogPath asserts that the recovery block points
+// at mint logs.
 func TestUpBootstrapFailureShowsLogPath(t *testing.T) {
 	buf := new(bytes.Buffer)
 	cmd := &cobra.Command{}
@@ -1724,11 +1846,11 @@ func TestUpBootstrapFailureShowsLogPath(t *testing.T) {
 	upWithProvisioner(ctx, cmd, cliCtx, deps, "default") //nolint:errcheck
 
 	output := buf.String()
-	if !strings.Contains(output, "journalctl") {
-		t.Errorf("recovery block must show journalctl log path, got:\n%s", output)
+	if !strings.Contains(output, "Logs:  `mint logs`") {
+		t.Errorf("recovery block must point at mint logs, got:\n%s", output)
 	}
-	if !strings.Contains(output, "mint-bootstrap") {
-		t.Errorf("recovery block must mention mint-bootstrap service, got:\n%s", output)
+	if strings.Contains(output, "journalctl") {
+		t.Errorf("recovery block must not send the user to journalctl on the VM, got:\n%s", output)
 	}
 }
 
@@ -1790,3 +1912,761 @@ func TestUpBootstrapFailureJSONUnchanged(t *testing.T) {
 		t.Errorf("bootstrap_error = %v, want %q", result["bootstrap_error"], "bootstrap failed on instance i-test123")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Tests: user bootstrap hook failure (VM usable, dedicated exit code)
+// ---------------------------------------------------------------------------
+
+func TestPrintUpHumanUserBootstrapFailed(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+
+	result := &provision.ProvisionResult{
+		InstanceID:          "i-new123",
+		PublicIP:            "54.1.2.3",
+		BootstrapStatus:     "complete",
+		UserBootstrapStatus: "failed:3",
+		UserBootstrapError:  &provision.UserBootstrapError{InstanceID: "i-new123", ExitCode: 3},
+	}
+
+	err := printUpHuman(cmd, result, false, defaultSSHPort)
+	if err == nil {
+		t.Fatal("printUpHuman should return an error when the user hook failed")
+	}
+	if msg := err.Error(); msg != "" {
+		t.Errorf("error message must be empty to prevent double-print, got: %q", msg)
+	}
+	if code := ExitCode(err); code != exitCodeUserBootstrapFailed {
+		t.Errorf("ExitCode = %d, want %d", code, exitCodeUserBootstrapFailed)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "core bootstrap: complete, user hook: failed (exit 3)") {
+		t.Errorf("output should report core and hook status distinctly, got:\n%s", output)
+	}
+	// The VM is usable: no bootstrap failure block or recovery commands.
+	if strings.Contains(output, "Bootstrap failed") || strings.Contains(output, "mint destroy") {
+		t.Errorf("user hook failure must not be reported as a bootstrap failure, got:\n%s", output)
+	}
+}
+
+func TestPrintUpJSONUserBootstrapFailed(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+
+	result := &provision.ProvisionResult{
+		InstanceID:          "i-new123",
+		BootstrapStatus:     "complete",
+		UserBootstrapStatus: "failed:3",
+		UserBootstrapError:  &provision.UserBootstrapError{InstanceID: "i-new123", ExitCode: 3},
+	}
+
+	err := printUpJSON(cmd, result)
+	if code := ExitCode(err); err == nil || code != exitCodeUserBootstrapFailed {
+		t.Fatalf("printUpJSON error = %v (exit code %d), want exit code %d", err, code, exitCodeUserBootstrapFailed)
+	}
+
+	var data map[string]any
+	if jsonErr := json.Unmarshal(buf.Bytes(), &data); jsonErr != nil {
+		t.Fatalf("output is not valid JSON: %v\nOutput: %s", jsonErr, buf.String())
+	}
+	if data["user_bootstrap_status"] != "failed:3" {
+		t.Errorf("user_bootstrap_status = %v, want %q", data["user_bootstrap_status"], "failed:3")
+	}
+	if _, ok := data["bootstrap_error"]; ok {
+		t.Error("bootstrap_error must be absent when only the user hook failed")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: instance type validation
+// ---------------------------------------------------------------------------
+
+func TestUpInstanceTypeCheckSkipFlag(t *testing.T) {
+	types := &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()}
+
+	cmd := &cobra.Command{}
+	addSkipTypeValidationFlag(cmd)
+	check := instanceTypeCheck(cmd, types, "us-east-1", t.TempDir())
+	if check == nil {
+		t.Fatal("check should be wired without --skip-type-validation")
+	}
+	if _, err := check(context.Background(), "m6i.xlrage"); err == nil || !strings.Contains(err.Error(), "did you mean m6i.xlarge?") {
+		t.Errorf("error = %v, want the typo suggestion", err)
+	}
+
+	// A type newer than the catalog can be forced through.
+	forced := &cobra.Command{}
+	addSkipTypeValidationFlag(forced)
+	if err := forced.Flags().Set("skip-type-validation", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if instanceTypeCheck(forced, types, "us-east-1", t.TempDir()) != nil {
+		t.Error("--skip-type-validation should disable the check")
+	}
+}
+
+// mockTypeOfferings implements DescribeInstanceTypeOfferingsAPI with the
+// types each AZ offers, and counts calls.
+type mockTypeOfferings struct {
+	byAZ  map[string][]string
+	err   error
+	calls int
+}
+
+func (m *mockTypeOfferings) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	out := &ec2.DescribeInstanceTypeOfferingsOutput{}
+	for _, t := range m.byAZ[params.Filters[0].Values[0]] {
+		out.InstanceTypeOfferings = append(out.InstanceTypeOfferings, ec2types.InstanceTypeOffering{InstanceType: ec2types.InstanceType(t)})
+	}
+	return out, nil
+}
+
+func TestUpInstanceTypeOfferingSkipFlag(t *testing.T) {
+	offerings := &mockTypeOfferings{byAZ: map[string][]string{"us-east-1a": {"m6i.large", "m6i.2xlarge"}}}
+
+	cmd := &cobra.Command{}
+	addSkipTypeValidationFlag(cmd)
+	check := instanceTypeOffering(cmd, offerings)
+	if check == nil {
+		t.Fatal("check should be wired without --skip-type-validation")
+	}
+	for range 2 {
+		err := check(context.Background(), "m6i.xlarge", "us-east-1a")
+		if err == nil || !strings.Contains(err.Error(), "not offered in us-east-1a — did you mean m6i.2xlarge, m6i.large?") {
+			t.Errorf("error = %v, want the offered suggestions", err)
+		}
+	}
+	if offerings.calls != 1 {
+		t.Errorf("DescribeInstanceTypeOfferings called %d times, want once per AZ", offerings.calls)
+	}
+
+	forced := &cobra.Command{}
+	addSkipTypeValidationFlag(forced)
+	if err := forced.Flags().Set("skip-type-validation", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if instanceTypeOffering(forced, offerings) != nil {
+		t.Error("--skip-type-validation should disable the check")
+	}
+}
+
+func TestUpCommandForcedUnknownTypeProvisions(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	addSkipTypeValidationFlag(cmd)
+	if err := cmd.Flags().Set("skip-type-validation", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	deps := newTestUpDeps()
+	deps.instanceType = "m9x.brandnew"
+	types := &mockResizeDescribeInstanceTypes{output: validInstanceTypeOutput()}
+	deps.provisioner.WithInstanceTypeCheck(instanceTypeCheck(cmd, types, "us-east-1", t.TempDir()))
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("forced unknown type should provision: %v", err)
+	}
+}
+
+func TestUpRecordsProvisioningHistory(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	// A second instance of the same VM name must not stop the recording.
+	deps := newTestUpDeps()
+	stale := makeRunningInstanceForRecreate("i-stale", "default", "testuser", "54.0.0.9", "us-east-1b")
+	fresh := makeRunningInstanceForRecreate("i-test123", "default", "testuser", "54.10.20.30", "us-east-1a")
+	deps.describe = &cmdtest.TaggedDescribeInstances{Instances: cmdtest.Instances(stale, fresh)}
+	tagger := &cmdtest.CreateTags{}
+	deps.createTags = tagger
+	var written []string
+	deps.remote = func(_ context.Context, _ mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, _ int, _ string, command []string) ([]byte, error) {
+		written = append(written, instanceID+" "+az+" "+host+" "+strings.Join(command, " "))
+		return nil, nil
+	}
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("upWithProvisioner error: %v", err)
+	}
+	if len(tagger.Inputs) != 1 || tagger.Inputs[0].Resources[0] != "i-test123" {
+		t.Fatalf("CreateTags calls = %+v, want the provisioning tags on i-test123", tagger.Inputs)
+	}
+	if len(written) != 1 || !strings.HasPrefix(written[0], "i-test123 us-east-1a 54.10.20.30 printf") ||
+		!strings.Contains(written[0], `"action":"up"`) {
+		t.Errorf("remote commands = %q, want one history write to the new instance", written)
+	}
+	if strings.Contains(buf.String(), "Warning") {
+		t.Errorf("unexpected warning:\n%s", buf.String())
+	}
+}
+
+func TestUpCommandPrintsInstanceTypeWarning(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	deps := newTestUpDeps()
+	deps.provisioner.WithInstanceTypeCheck(func(ctx context.Context, instanceType string) (string, error) {
+		return "instance type m4.xlarge is previous-generation — consider m6i.xlarge", nil
+	})
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("upWithProvisioner error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Warning: instance type m4.xlarge is previous-generation — consider m6i.xlarge") {
+		t.Errorf("output missing previous-generation warning:\n%s", buf.String())
+	}
+}
+
+func TestUpCommandSpotFlag(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+		Instances: []ec2types.Instance{{
+			InstanceId: aws.String("i-test123"),
+			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-iops")},
+			}},
+		}},
+	}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+
+	out, err := runUpBatchCommand(t, deps, "--spot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if ri.Input() == nil || ri.Input().InstanceMarketOptions == nil ||
+		ri.Input().InstanceMarketOptions.MarketType != ec2types.MarketTypeSpot {
+		t.Fatalf("RunInstances was not asked for a spot instance: %+v", ri.Input())
+	}
+	if !strings.Contains(out, "Market        spot") {
+		t.Errorf("output missing spot market line:\n%s", out)
+	}
+}
+
+func TestUpCommandSpotFallbackRequiresSpot(t *testing.T) {
+	out, err := runUpBatchCommand(t, newTestUpDeps(), "--spot-fallback")
+	if err == nil || !strings.Contains(err.Error(), "--spot-fallback requires --spot") {
+		t.Fatalf("err = %v, want --spot-fallback requires --spot\n%s", err, out)
+	}
+}
+
+func TestUpCommandPrintsSpotFallbackWarning(t *testing.T) {
+	result := &provision.ProvisionResult{
+		InstanceID:      "i-test123",
+		BootstrapStatus: tags.BootstrapComplete,
+		SpotWarning:     "no spot capacity for m6i.xlarge (InsufficientInstanceCapacity) — launched an on-demand instance instead",
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := printUpHuman(cmd, result, false, defaultSSHPort); err != nil {
+		t.Fatalf("printUpHuman: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Warning: no spot capacity for m6i.xlarge") {
+		t.Errorf("output missing spot fallback warning:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "Market") {
+		t.Errorf("on-demand fallback reported as spot:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeUpJSON(cmd, result, nil); err != nil {
+		t.Fatalf("writeUpJSON: %v", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data["spot_warning"] != result.SpotWarning {
+		t.Errorf("spot_warning = %v, want %q", data["spot_warning"], result.SpotWarning)
+	}
+	if _, ok := data["spot"]; ok {
+		t.Errorf("spot present for an on-demand instance: %v", data)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: provisioning journal
+// ---------------------------------------------------------------------------
+
+func TestUpCommandAbandonJournal(t *testing.T) {
+	store := provision.NewJournalStore(t.TempDir())
+	if err := store.Save(&provision.Journal{Owner: "testuser", VM: "default", Step: provision.StepLaunched, InstanceID: "i-gone"}); err != nil {
+		t.Fatal(err)
+	}
+	deps := newTestUpDeps()
+	deps.journal = store
+	deps.provisioner.WithJournal(store)
+
+	out, err := runUpBatchCommand(t, deps, "--abandon-journal")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Discarded the provisioning journal for VM "default".`) {
+		t.Errorf("output missing discard note:\n%s", out)
+	}
+	if strings.Contains(out, "Resumed") {
+		t.Errorf("abandoned journal was resumed:\n%s", out)
+	}
+	if j, _ := store.Load("testuser", "default"); j != nil {
+		t.Errorf("journal still present: %+v", j)
+	}
+}
+
+func TestUpCommandResumedOutput(t *testing.T) {
+	result := &provision.ProvisionResult{
+		InstanceID:   "i-new123",
+		PublicIP:     "54.1.2.3",
+		VolumeID:     "vol-proj1",
+		AllocationID: "eipalloc-new1",
+		Resumed:      true,
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := printUpHuman(cmd, result, false, defaultSSHPort); err != nil {
+		t.Fatalf("printUpHuman error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "Resumed an interrupted mint up.\n") {
+		t.Errorf("human output should open with the resume note, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printUpJSON(cmd, result); err != nil {
+		t.Fatalf("printUpJSON error: %v", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data["resumed"] != true {
+		t.Errorf("resumed = %v, want true", data["resumed"])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: project container reconcile after a restart
+// ---------------------------------------------------------------------------
+
+// newRestartUpDeps returns up deps whose provisioner restarts a stopped VM
+// and whose remote runner is runner. The project cache in cacheDir records
+// api as running and web as stopped.
+func newRestartUpDeps(t *testing.T, runner *startRemoteRunner) *upDeps {
+	t.Helper()
+	stopped := &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:      aws.String("i-stopped1"),
+				InstanceType:    ec2types.InstanceTypeM6iXlarge,
+				PublicIpAddress: aws.String("54.0.0.1"),
+				State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+				Placement:       &ec2types.Placement{AvailabilityZone: aws.String("us-east-1a")},
+				Tags: []ec2types.Tag{
+					{Key: aws.String("mint:vm"), Value: aws.String("default")},
+					{Key: aws.String("mint:owner"), Value: aws.String("testuser")},
+				},
+			}},
+		}},
+	}
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &cmdtest.DescribeInstances{Output: stopped},
+		StartInstances:    &cmdtest.StartInstances{Output: &ec2.StartInstancesOutput{}},
+		RunInstances:      &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}},
+		DescribeSGs:       &cmdtest.DescribeSecurityGroups{},
+		DescribeSubnets:   &cmdtest.DescribeSubnets{Output: &ec2.DescribeSubnetsOutput{}},
+		CreateVolume:      &cmdtest.CreateVolume{Output: &ec2.CreateVolumeOutput{}},
+		AttachVolume:      &cmdtest.AttachVolume{Output: &ec2.AttachVolumeOutput{}},
+		AllocateAddr:      &cmdtest.AllocateAddress{Output: &ec2.AllocateAddressOutput{}},
+		AssociateAddr:     &cmdtest.AssociateAddress{Output: &ec2.AssociateAddressOutput{}},
+		DescribeAddrs:     &cmdtest.DescribeAddresses{Output: &ec2.DescribeAddressesOutput{}},
+		CreateTags:        &cmdtest.CreateTags{},
+		DescribeImages:    &cmdtest.DescribeImages{Output: &ec2.DescribeImagesOutput{}},
+	})
+
+	cacheDir := t.TempDir()
+	projects := []projectInfo{
+		{Name: "api", ContainerStatus: "running"},
+		{Name: "web", ContainerStatus: "exited"},
+	}
+	if err := writeProjectListCache(cacheDir, "default", projects, time.Now()); err != nil {
+		t.Fatalf("writeProjectListCache: %v", err)
+	}
+
+	return &upDeps{
+		provisioner:     p,
+		owner:           "testuser",
+		ownerARN:        "arn:aws:iam::123:user/testuser",
+		bootstrapScript: []byte("#!/bin/bash"),
+		instanceType:    "m6i.xlarge",
+		volumeSize:      50,
+		describe:        &cmdtest.DescribeInstances{Output: stopped},
+		sendKey:         &cmdtest.SendSSHPublicKey{},
+		remote:          runner.run,
+		projectCacheDir: cacheDir,
+		sshWait:         time.Second,
+		sshRetry:        time.Millisecond,
+	}
+}
+
+func restartFleetRunner() *startRemoteRunner {
+	return &startRemoteRunner{
+		sshDown: 2,
+		ps:      "c1\nc2\n",
+		inspect: "[" + inspectFixture("c1", "api", false, 0, "/mint/projects/api") + "," +
+			inspectFixture("c2", "web", false, 0, "/mint/projects/web") + "]",
+	}
+}
+
+func TestUpReconcilesProjectsAfterRestart(t *testing.T) {
+	runner := restartFleetRunner()
+	deps := newRestartUpDeps(t, runner)
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("upWithProvisioner error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "restarted") || !strings.Contains(out, `Started "api". Created its tmux session.`) {
+		t.Errorf("output should report the restart and the started project, got:\n%s", out)
+	}
+	// SSH is retried until it answers; only api was running before the stop.
+	if n := strings.Count(strings.Join(runner.calls, "\n"), "true"); n != 3 {
+		t.Errorf("SSH attempts = %d, want 3", n)
+	}
+	if !slices.Contains(runner.calls, "docker start c1") {
+		t.Errorf("api container was not started: %q", runner.calls)
+	}
+	if slices.Contains(runner.calls, "docker start c2") {
+		t.Errorf("web was stopped before the VM stopped and must stay stopped: %q", runner.calls)
+	}
+}
+
+func TestUpNoReconcile(t *testing.T) {
+	runner := restartFleetRunner()
+	deps := newRestartUpDeps(t, runner)
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.Flags().Bool("no-reconcile", false, "")
+	_ = cmd.Flags().Set("no-reconcile", "true")
+	cmd.SetOut(buf)
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("upWithProvisioner error: %v", err)
+	}
+	if len(runner.calls) != 0 {
+		t.Errorf("--no-reconcile ran remote commands: %q", runner.calls)
+	}
+}
+
+func TestUpReconcileSSHTimeoutIsWarning(t *testing.T) {
+	hint.IsTTY = false
+	runner := restartFleetRunner()
+	runner.sshDown = 1000
+	deps := newRestartUpDeps(t, runner)
+	deps.sshWait = 5 * time.Millisecond
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cliCtx := &cli.CLIContext{VM: "default"}
+	ctx := cli.WithContext(context.Background(), cliCtx)
+	cmd.SetContext(ctx)
+
+	if err := upWithProvisioner(ctx, cmd, cliCtx, deps, "default"); err != nil {
+		t.Fatalf("an unreachable VM must not fail mint up: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Warning: SSH is not reachable yet") || !strings.Contains(buf.String(), "mint project start --all") {
+		t.Errorf("output should warn and point at project start, got:\n%s", buf.String())
+	}
+}
+
+func TestUpInterruptedRecovery(t *testing.T) {
+	hint.IsTTY = false
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nothing created", &provision.InterruptedError{}, "Interrupted. No resources were created."},
+		{"launched", fmt.Errorf("wrapped: %w", &provision.InterruptedError{Step: provision.StepLaunched, InstanceID: "i-new123"}),
+			"Interrupted. Instance i-new123 was launched and its progress is saved — run `mint up` to resume."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := upInterrupted(tt.err)
+			if err.Error() != tt.want {
+				t.Errorf("error = %q, want %q", err.Error(), tt.want)
+			}
+			if ExitCode(err) != exitCodeInterrupted {
+				t.Errorf("ExitCode = %d, want %d", ExitCode(err), exitCodeInterrupted)
+			}
+		})
+	}
+
+	other := errors.New("launching instance: boom")
+	if got := upInterrupted(other); got != other {
+		t.Errorf("non-interrupt error changed: %v", got)
+	}
+}
+
+func TestUpCommandAppliesVMConfigOverrides(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+		Instances: []ec2types.Instance{{
+			InstanceId: aws.String("i-test123"),
+			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-gpu")},
+			}},
+		}},
+	}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"gpu": {"instance_type": "g5.2xlarge", "volume_size_gb": "200"},
+	}
+
+	out, err := runUpBatchCommand(t, deps, "--vm", "gpu")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if ri.Input().InstanceType != "g5.2xlarge" {
+		t.Errorf("InstanceType = %s, want g5.2xlarge", ri.Input().InstanceType)
+	}
+	var projectSize int32
+	for _, bdm := range ri.Input().BlockDeviceMappings {
+		if aws.ToString(bdm.DeviceName) == "/dev/xvdf" {
+			projectSize = aws.ToInt32(bdm.Ebs.VolumeSize)
+		}
+	}
+	if projectSize != 200 {
+		t.Errorf("project volume size = %d, want 200", projectSize)
+	}
+}
+
+func TestUpCommandAppliesVMKMSKeyAndInstanceProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantKMS string
+	}{
+		{"from table", nil, "alias/secure"},
+		{"flag wins", []string{"--kms-key-id", "alias/flag"}, "alias/flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+				Instances: []ec2types.Instance{{
+					InstanceId: aws.String("i-test123"),
+					BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+						DeviceName: aws.String("/dev/xvdf"),
+						Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-secure")},
+					}},
+				}},
+			}}
+			deps := newTestUpDeps()
+			deps.provisioner = newTestProvisionerCapturingRun(ri)
+			deps.mintConfig = config.Defaults()
+			deps.mintConfig.VMOverrides = map[string]map[string]string{
+				"secure": {"kms_key_id": "alias/secure", "instance_profile": "mint-secure"},
+			}
+
+			out, err := runUpBatchCommand(t, deps, append([]string{"--vm", "secure"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out)
+			}
+			for _, bdm := range ri.Input().BlockDeviceMappings {
+				if got := aws.ToString(bdm.Ebs.KmsKeyId); got != tt.wantKMS {
+					t.Errorf("%s KmsKeyId = %q, want %q", aws.ToString(bdm.DeviceName), got, tt.wantKMS)
+				}
+			}
+			if got := aws.ToString(ri.Input().IamInstanceProfile.Name); got != "mint-secure" {
+				t.Errorf("IamInstanceProfile = %q, want mint-secure", got)
+			}
+		})
+	}
+}
+
+func TestUpCommandIPModeFromVMConfig(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"v6": {"ip_mode": "ipv6-only"},
+	}
+
+	// The test subnets have no IPv6 CIDR block, so reaching the subnet
+	// check shows the provisioner got the VM's IP mode.
+	_, err := runUpBatchCommand(t, deps, "--vm", "v6")
+	if err == nil || !strings.Contains(err.Error(), "ip_mode ipv6-only needs a subnet with an IPv6 CIDR block") {
+		t.Fatalf("err = %v, want missing IPv6 subnet error", err)
+	}
+	if ri.Input() != nil {
+		t.Error("RunInstances called without an IPv6 subnet")
+	}
+}
+
+func TestUpCommandIPModeFlagOverridesConfig(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+		Instances: []ec2types.Instance{{
+			InstanceId: aws.String("i-test123"),
+			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-v6")},
+			}},
+		}},
+	}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+	deps.ipMode = "dualstack"
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"v6": {"ip_mode": "ipv6-only"},
+	}
+
+	out, err := runUpBatchCommand(t, deps, "--vm", "v6", "--ip-mode", "eip")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if ri.Input().NetworkInterfaces != nil {
+		t.Errorf("NetworkInterfaces = %+v, want none in eip mode", ri.Input().NetworkInterfaces)
+	}
+}
+
+func TestUpCommandRejectsInvalidIPMode(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+
+	_, err := runUpBatchCommand(t, deps, "--ip-mode", "ipv4")
+	if err == nil || !strings.Contains(err.Error(), `--ip-mode: "ipv4" is not an IP mode`) {
+		t.Fatalf("err = %v, want invalid IP mode error", err)
+	}
+	if ri.Input() != nil {
+		t.Error("RunInstances called with an invalid --ip-mode")
+	}
+}
+
+func TestUpCommandSubnetFlagOverridesConfig(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{
+		Instances: []ec2types.Instance{{
+			InstanceId: aws.String("i-test123"),
+			BlockDeviceMappings: []ec2types.InstanceBlockDeviceMapping{{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-net")},
+			}},
+		}},
+	}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+	deps.network = networkConfig{subnetID: "subnet-0a0a0a0a"}
+
+	out, err := runUpBatchCommand(t, deps, "--subnet-id", "subnet-0b0b0b0b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if got := aws.ToString(ri.Input().SubnetId); got != "subnet-0b0b0b0b" {
+		t.Errorf("SubnetId = %q, want the --subnet-id subnet", got)
+	}
+}
+
+func TestUpCommandRejectsInvalidSecurityGroupIDs(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+
+	_, err := runUpBatchCommand(t, deps, "--security-group-ids", "sg-0a0a0a0a,web")
+	if err == nil || !strings.Contains(err.Error(), `--security-group-ids: "web" is not a security group ID`) {
+		t.Fatalf("err = %v, want invalid security group error", err)
+	}
+	if ri.Input() != nil {
+		t.Error("RunInstances called with an invalid --security-group-ids")
+	}
+}
+
+func TestUpCommandPrintsIPv6Address(t *testing.T) {
+	result := &provision.ProvisionResult{
+		InstanceID:      "i-test123",
+		PublicIP:        "54.1.2.3",
+		IPv6Address:     "2600:1f14::11",
+		BootstrapStatus: tags.BootstrapComplete,
+	}
+
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := printUpHuman(cmd, result, false, defaultSSHPort); err != nil {
+		t.Fatalf("printUpHuman: %v", err)
+	}
+	if !strings.Contains(buf.String(), "IPv6          2600:1f14::11") {
+		t.Errorf("output missing IPv6 line:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeUpJSON(cmd, result, nil); err != nil {
+		t.Fatalf("writeUpJSON: %v", err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data["ipv6_address"] != result.IPv6Address {
+		t.Errorf("ipv6_address = %v, want %q", data["ipv6_address"], result.IPv6Address)
+	}
+}
+
+func TestUpCommandRejectsUnknownVMConfigKey(t *testing.T) {
+	ri := &cmdtest.RunInstances{Output: &ec2.RunInstancesOutput{}}
+	deps := newTestUpDeps()
+	deps.provisioner = newTestProvisionerCapturingRun(ri)
+	deps.mintConfig = config.Defaults()
+	deps.mintConfig.VMOverrides = map[string]map[string]string{
+		"gpu": {"region": "us-west-2"},
+	}
+
+	_, err := runUpBatchCommand(t, deps, "--vm", "gpu")
+	if err == nil || !strings.Contains(err.Error(), `[vm.gpu]: unknown key "region"`) {
+		t.Fatalf("err = %v, want unknown key error", err)
+	}
+	if ri.Input() != nil {
+		t.Error("RunInstances called despite a bad [vm.gpu] table")
+	}
+}
@@ -0,0 +1,840 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// vmSpecVersion is the version of the document mint vm export writes.
+// mint vm apply refuses documents from a newer version.
+const vmSpecVersion = 1
+
+// vmSpec is the shape of a VM as mint vm export writes it and mint vm
+// apply reads it. Fields left out of a document are not managed by apply.
+type vmSpec struct {
+	Version            int               `json:"version" yaml:"version"`
+	VM                 string            `json:"vm" yaml:"vm"`
+	InstanceType       string            `json:"instance_type,omitempty" yaml:"instance_type,omitempty"`
+	VolumeSizeGB       int               `json:"volume_size_gb,omitempty" yaml:"volume_size_gb,omitempty"`
+	VolumeIOPS         int               `json:"volume_iops,omitempty" yaml:"volume_iops,omitempty"`
+	VolumeThroughputMB int               `json:"volume_throughput_mb,omitempty" yaml:"volume_throughput_mb,omitempty"`
+	IdleTimeout        string            `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+	Forwards           []string          `json:"forwards,omitempty" yaml:"forwards,omitempty"`
+	ExtraTags          map[string]string `json:"extra_tags,omitempty" yaml:"extra_tags,omitempty"`
+	Projects           []vmSpecProject   `json:"projects,omitempty" yaml:"projects,omitempty"`
+}
+
+// vmSpecProject is one project of a vmSpec, with what mint project add
+// needs to clone it again.
+type vmSpecProject struct {
+	Name           string `json:"name" yaml:"name"`
+	Repo           string `json:"repo,omitempty" yaml:"repo,omitempty"`
+	Branch         string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Subdir         string `json:"subdir,omitempty" yaml:"subdir,omitempty"`
+	NoDevcontainer bool   `json:"no_devcontainer,omitempty" yaml:"no_devcontainer,omitempty"`
+}
+
+// vmDeps holds the injectable dependencies for the vm export, vm apply,
+// and vm history commands.
+type vmDeps struct {
+	describe        mintaws.DescribeInstancesAPI
+	describeVolumes mintaws.DescribeVolumesAPI
+	sendKey         mintaws.SendSSHPublicKeyAPI
+	owner           string
+	remote          RemoteCommandRunner
+	sshPort         int // ssh_port from config; zero means defaultSSHPort
+	// mintConfig is the loaded config, which apply updates alongside
+	// config.toml so the commands it runs see the new [vm.<name>] table.
+	// nil is the default config.
+	mintConfig *config.Config
+	// configDir holds config.toml and the project list cache.
+	configDir string
+	// run runs a mint command, given without "mint", for apply.
+	run func(cmd *cobra.Command, args []string) error
+}
+
+// newVMCommand creates the production vm command group.
+func newVMCommand() *cobra.Command {
+	return newVMCommandWithDeps(nil)
+}
+
+// newVMCommandWithDeps creates the vm command group with explicit
+// dependencies for testing. When deps is nil, each subcommand wires real
+// AWS clients.
+func newVMCommandWithDeps(deps *vmDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vm",
+		Short: "Export and apply the shape of a VM, and show its provisioning history",
+		Long: "Capture a VM's shape -- instance type, project volume, idle timeout, forwards, " +
+			"extra tags, and projects -- as a YAML file, and make a VM match such a file. " +
+			"Show when the VM was provisioned, by whom, and with which version of mint.",
+	}
+
+	cmd.AddCommand(newVMExportCommand(deps))
+	cmd.AddCommand(newVMApplyCommand(deps))
+	cmd.AddCommand(newVMHistoryCommand(deps))
+
+	return cmd
+}
+
+// resolveVMDeps returns deps, or the production dependencies when deps is
+// nil.
+func resolveVMDeps(cmd *cobra.Command, deps *vmDeps) (*vmDeps, error) {
+	if deps != nil {
+		return deps, nil
+	}
+	clients := awsClientsFromContext(cmd.Context())
+	if clients == nil {
+		return nil, fmt.Errorf("AWS clients not configured")
+	}
+	return &vmDeps{
+		describe:        clients.ec2Client,
+		describeVolumes: clients.ec2Client,
+		sendKey:         clients.sendKey,
+		owner:           clients.owner,
+		remote:          clients.remoteRunner(),
+		sshPort:         clients.sshOptions.Port,
+		mintConfig:      clients.mintConfig,
+		configDir:       config.DefaultConfigDir(),
+		run:             runMintCommand,
+	}, nil
+}
+
+func newVMExportCommand(deps *vmDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "Print the shape of a VM as YAML",
+		Long: "Print the VM's shape as a YAML document (JSON with --json) for mint vm apply: " +
+			"its instance type and project volume size, IOPS, and throughput from AWS, its " +
+			"idle timeout and forwards from config.toml, its extra tags, and its projects " +
+			"with their repository URL and branch, read over SSH.\n\n" +
+			"Projects can only be read from a running VM; exporting a stopped VM leaves them out.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveVMDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runVMExport(cmd, d)
+		},
+	}
+}
+
+func runVMExport(cmd *cobra.Command, deps *vmDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", vmName, hint.Cmd("mint list"))
+	}
+	volume, err := lookupProjectVolume(ctx, deps.describeVolumes, deps.owner, vmName)
+	if err != nil {
+		return err
+	}
+	vmCfg, err := vmConfigFor(deps.mintConfig, vmName)
+	if err != nil {
+		return err
+	}
+
+	spec := vmSpec{
+		Version:            vmSpecVersion,
+		VM:                 vmName,
+		InstanceType:       found.InstanceType,
+		VolumeSizeGB:       int(aws.ToInt32(volume.Size)),
+		VolumeIOPS:         int(aws.ToInt32(volume.Iops)),
+		VolumeThroughputMB: int(aws.ToInt32(volume.Throughput)),
+		IdleTimeout:        vmCfg.Value("idle_timeout"),
+		Forwards:           vmCfg.Forwards,
+	}
+	if extra := tags.Extra(found.Tags); len(extra) > 0 {
+		spec.ExtraTags = extra
+	}
+
+	if found.State == string(ec2types.InstanceStateNameRunning) {
+		spec.Projects, err = listVMProjects(ctx, deps, found)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(cmd.ErrOrStderr(), "VM %q is %s, so its projects were left out. Start it with %s and export again to include them.\n",
+			vmName, found.State, hint.Cmd("mint up"))
+	}
+
+	w := cmd.OutOrStdout()
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(spec)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(spec); err != nil {
+		return fmt.Errorf("encode YAML: %w", err)
+	}
+	return enc.Close()
+}
+
+// vmConfigFor returns the config vmName is provisioned with, from cfg or
+// the defaults when cfg is nil.
+func vmConfigFor(cfg *config.Config, vmName string) (*config.Config, error) {
+	if cfg == nil {
+		return config.Defaults(), nil
+	}
+	vmCfg, err := cfg.ForVM(vmName)
+	if err != nil {
+		return nil, fmt.Errorf("config.toml: %w", err)
+	}
+	return vmCfg, nil
+}
+
+// listVMProjects reads the projects on the running VM found: their origin
+// URL and branch, and the subdirectory and devcontainer settings mint
+// project add recorded.
+func listVMProjects(ctx context.Context, deps *vmDeps, found *vm.VM) ([]vmSpecProject, error) {
+	out, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildProjectSourcesCommand())
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	projects := parseProjectSources(string(out))
+	if len(projects) == 0 {
+		return nil, nil
+	}
+
+	settingsOut, err := deps.remote(ctx, deps.sendKey, found.ID, found.AvailabilityZone,
+		found.PublicIP, sshPortOrDefault(deps.sshPort), defaultSSHUser, buildProjectSettingsCommand())
+	if err != nil {
+		return nil, fmt.Errorf("reading project settings: %w", err)
+	}
+	settings := parseProjectSettings(string(settingsOut))
+	for i := range projects {
+		ps := settings[projects[i].Name]
+		projects[i].Subdir = ps.subdir
+		projects[i].NoDevcontainer = ps.noDevcontainer
+	}
+	return projects, nil
+}
+
+// buildProjectSourcesCommand constructs the remote command that prints
+// "<project>\t<origin URL>\t<branch>" for each project directory. A
+// project with no origin, such as one pushed from a local directory, has
+// an empty URL.
+func buildProjectSourcesCommand() []string {
+	script := `for d in /mint/projects/*/; do [ -d "$d" ] || continue; n=$(basename "$d"); ` +
+		`[ "$n" = lost+found ] && continue; ` +
+		`u=$(git -C "$d" remote get-url origin 2>/dev/null); ` +
+		`b=$(git -C "$d" symbolic-ref --short -q HEAD 2>/dev/null); ` +
+		`printf '%s\t%s\t%s\n' "$n" "$u" "$b"; ` +
+		`done; true`
+	return []string{"sh", "-c", shellQuote(script)}
+}
+
+// parseProjectSources parses buildProjectSourcesCommand output, sorted by
+// project name.
+func parseProjectSources(output string) []vmSpecProject {
+	var projects []vmSpecProject
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimRight(line, "\r"), "\t", 3)
+		if strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		p := vmSpecProject{Name: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			p.Repo = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			p.Branch = strings.TrimSpace(parts[2])
+		}
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects
+}
+
+func newVMApplyCommand(deps *vmDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "apply <file>",
+		Short:       "Make a VM match a file written by mint vm export",
+		Annotations: map[string]string{cli.AnnotationRemoteWrites: "true"},
+		Long: "Compare a VM with a YAML or JSON file written by mint vm export, print a plan, " +
+			"and after confirmation carry it out. The file's vm is used unless --vm is given.\n\n" +
+			"The file's idle timeout and forwards are written to the VM's [vm.<name>] table in " +
+			"config.toml, as are its instance type and volume settings when the VM is " +
+			"provisioned or recreated. A missing VM is provisioned with mint up, a VM of another " +
+			"instance type is recreated with mint recreate (which asks for its own confirmation), " +
+			"a smaller project volume is grown with mint volume grow, and missing projects are " +
+			"added with mint project add.\n\n" +
+			"Changes apply cannot make -- shrinking the volume, changing its IOPS or throughput, " +
+			"extra tags, and projects on the VM that the file does not list -- are reported as " +
+			"manual actions. Applying the same file again changes nothing.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveVMDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runVMApply(cmd, d, args[0])
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Print the plan without changing anything")
+
+	return cmd
+}
+
+// vmConfigChange is one key of the VM's [vm.<name>] table that apply sets.
+type vmConfigChange struct {
+	key, from, to string
+}
+
+// vmApplyStep is one mint command apply runs.
+type vmApplyStep struct {
+	summary string
+	args    []string
+	// project is the project a mint project add step adds. The step is
+	// skipped when the project turns out to be on the VM already.
+	project string
+}
+
+// vmApplyPlan is what apply does to make a VM match a vmSpec.
+type vmApplyPlan struct {
+	configChanges []vmConfigChange
+	steps         []vmApplyStep
+	// manual lists the differences apply reports instead of changing.
+	manual []string
+	// projectsKnown is true when the VM's projects were listed live, so
+	// the project steps need no second look.
+	projectsKnown bool
+}
+
+// empty reports whether the plan changes nothing.
+func (p *vmApplyPlan) empty() bool {
+	return len(p.configChanges) == 0 && len(p.steps) == 0
+}
+
+// vmLiveState is what apply knows about a VM before planning.
+type vmLiveState struct {
+	// found is the VM, nil when it does not exist.
+	found *vm.VM
+	// volumeID, volumeGB, volumeIOPS, and volumeThroughput describe the
+	// project volume of an existing VM.
+	volumeID         string
+	volumeGB         int
+	volumeIOPS       int
+	volumeThroughput int
+	// config is the config the VM is provisioned with.
+	config *config.Config
+	// projects are the names of the projects on the VM: live when
+	// projectsLive, otherwise from the project list cache.
+	projects     []string
+	projectsLive bool
+}
+
+func runVMApply(cmd *cobra.Command, deps *vmDeps, path string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	spec, err := readVMSpec(path)
+	if err != nil {
+		return err
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := spec.VM
+	yes := false
+	if cliCtx != nil {
+		if cmd.Flags().Changed("vm") || vmName == "" {
+			vmName = cliCtx.VM
+		}
+		// The commands apply runs act on the VM in the CLI context.
+		cliCtx.VM = vmName
+		yes = cliCtx.Yes
+	}
+	if vmName == "" {
+		vmName = "default"
+	}
+
+	live, err := readVMLiveState(ctx, deps, vmName)
+	if err != nil {
+		return err
+	}
+	plan, err := planVMApply(spec, vmName, live)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	w := cmd.OutOrStdout()
+	writeVMApplyPlan(w, vmName, path, plan)
+	if plan.empty() {
+		return nil
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+	if !yes {
+		fmt.Fprint(w, "\nApply these changes? [y/N]: ")
+		scanner := bufio.NewScanner(cmd.InOrStdin())
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return fmt.Errorf("no confirmation input received — apply aborted")
+		}
+		if answer := strings.ToLower(strings.TrimSpace(scanner.Text())); answer != "y" && answer != "yes" {
+			return fmt.Errorf("apply aborted")
+		}
+	}
+
+	if len(plan.configChanges) > 0 {
+		if err := saveVMConfig(deps, vmName, plan.configChanges); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nUpdated [vm.%s] in config.toml.\n", vmName)
+	}
+
+	// Projects could not be listed before a VM was provisioned or
+	// started, so they are listed once it runs.
+	var present map[string]bool
+	for _, step := range plan.steps {
+		if step.project != "" && !plan.projectsKnown {
+			if present == nil {
+				present = liveProjectNames(ctx, deps, vmName)
+			}
+			if present[step.project] {
+				fmt.Fprintf(w, "\nProject %q is already on the VM.\n", step.project)
+				continue
+			}
+		}
+		fmt.Fprintf(w, "\n==> %s\n", step.summary)
+		if err := deps.run(cmd, step.args); err != nil {
+			return fmt.Errorf("%s failed: %w\n%s", hint.Cmd(mintCommandLine(step.args)), err,
+				hint.Suggest("Resume with", "mint vm apply "+path))
+		}
+	}
+
+	fmt.Fprintf(w, "\nVM %q now matches %s.\n", vmName, path)
+	if len(plan.manual) > 0 {
+		fmt.Fprintln(w, "Manual actions remain; see the plan above.")
+	}
+	return nil
+}
+
+// readVMSpec reads and checks the document at path. JSON is read as
+// YAML, of which it is a subset; unknown fields are refused so a typo is
+// not silently ignored.
+func readVMSpec(path string) (*vmSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var spec vmSpec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if spec.Version > vmSpecVersion {
+		return nil, fmt.Errorf("%s is version %d, written by a newer mint — update mint with %s",
+			path, spec.Version, hint.Cmd("mint update"))
+	}
+	seen := make(map[string]bool, len(spec.Projects))
+	for _, p := range spec.Projects {
+		if err := validateProjectName(p.Name); err != nil {
+			return nil, fmt.Errorf("%s: project %q: %w", path, p.Name, err)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s: project %q is listed twice", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return &spec, nil
+}
+
+// readVMLiveState gathers what apply compares the document with.
+func readVMLiveState(ctx context.Context, deps *vmDeps, vmName string) (*vmLiveState, error) {
+	vmCfg, err := vmConfigFor(deps.mintConfig, vmName)
+	if err != nil {
+		return nil, err
+	}
+	live := &vmLiveState{config: vmCfg}
+
+	live.found, err = vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("discovering VM: %w", err)
+	}
+	if live.found == nil {
+		return live, nil
+	}
+
+	volume, err := lookupProjectVolume(ctx, deps.describeVolumes, deps.owner, vmName)
+	if err != nil {
+		return nil, err
+	}
+	live.volumeID = aws.ToString(volume.VolumeId)
+	live.volumeGB = int(aws.ToInt32(volume.Size))
+	live.volumeIOPS = int(aws.ToInt32(volume.Iops))
+	live.volumeThroughput = int(aws.ToInt32(volume.Throughput))
+
+	if live.found.State == string(ec2types.InstanceStateNameRunning) {
+		projects, err := listVMProjects(ctx, deps, live.found)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			live.projects = append(live.projects, p.Name)
+		}
+		live.projectsLive = true
+	} else if cache, err := readProjectListCache(deps.configDir, vmName); err == nil {
+		for _, p := range cache.Projects {
+			live.projects = append(live.projects, p.Name)
+		}
+	}
+	return live, nil
+}
+
+// liveProjectNames returns the projects on the running VM vmName. When
+// they cannot be listed it returns an empty set, leaving mint project add
+// to refuse any that exist.
+func liveProjectNames(ctx context.Context, deps *vmDeps, vmName string) map[string]bool {
+	present := make(map[string]bool)
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil || found == nil || found.State != string(ec2types.InstanceStateNameRunning) {
+		return present
+	}
+	projects, err := listVMProjects(ctx, deps, found)
+	if err != nil {
+		return present
+	}
+	for _, p := range projects {
+		present[p.Name] = true
+	}
+	return present
+}
+
+// vmSpecConfigKeys are the [vm.<name>] keys a vmSpec sets, in the order
+// the plan lists them.
+var vmSpecConfigKeys = []string{"instance_type", "volume_size_gb", "volume_iops", "volume_throughput_mb", "idle_timeout", "forwards"}
+
+// configValues returns the [vm.<name>] values spec sets, keyed by config
+// key, in the form config.Value prints them so they compare with the
+// current config. A value config.toml would not accept is an error.
+func (s *vmSpec) configValues() (map[string]string, error) {
+	raw := map[string]string{}
+	if s.InstanceType != "" {
+		raw["instance_type"] = s.InstanceType
+	}
+	if s.VolumeSizeGB != 0 {
+		raw["volume_size_gb"] = strconv.Itoa(s.VolumeSizeGB)
+	}
+	if s.VolumeIOPS != 0 {
+		raw["volume_iops"] = strconv.Itoa(s.VolumeIOPS)
+	}
+	if s.VolumeThroughputMB != 0 {
+		raw["volume_throughput_mb"] = strconv.Itoa(s.VolumeThroughputMB)
+	}
+	if s.IdleTimeout != "" {
+		raw["idle_timeout"] = s.IdleTimeout
+	}
+	if s.Forwards != nil {
+		raw["forwards"] = strings.Join(s.Forwards, " ")
+	}
+
+	probe := config.Defaults()
+	values := make(map[string]string, len(raw))
+	for _, key := range vmSpecConfigKeys {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		if err := probe.Set(key, value); err != nil {
+			return nil, err
+		}
+		values[key] = probe.Value(key)
+	}
+	return values, nil
+}
+
+// planVMApply works out what makes the VM vmName, as live describes it,
+// match spec.
+func planVMApply(spec *vmSpec, vmName string, live *vmLiveState) (*vmApplyPlan, error) {
+	want, err := spec.configValues()
+	if err != nil {
+		return nil, err
+	}
+	plan := &vmApplyPlan{projectsKnown: live.projectsLive}
+	found := live.found
+	recreate := found != nil && spec.InstanceType != "" && spec.InstanceType != found.InstanceType
+
+	// A new VM is launched from config.toml. An existing VM's instance
+	// type and volume are compared with AWS instead, and only a recreate
+	// needs its new instance type kept there.
+	keys := vmSpecConfigKeys
+	if found != nil {
+		keys = []string{"idle_timeout", "forwards"}
+		if recreate {
+			keys = append([]string{"instance_type"}, keys...)
+		}
+	}
+	for _, key := range keys {
+		if to, ok := want[key]; ok {
+			if from := live.config.Value(key); from != to {
+				plan.configChanges = append(plan.configChanges, vmConfigChange{key: key, from: from, to: to})
+			}
+		}
+	}
+
+	if found == nil {
+		plan.steps = append(plan.steps, vmApplyStep{
+			summary: fmt.Sprintf("Provision VM %q", vmName),
+			args:    []string{"up"},
+		})
+		steps, manual := projectAddSteps(spec.Projects, nil)
+		plan.steps = append(plan.steps, steps...)
+		plan.manual = append(plan.manual, manual...)
+		return plan, nil
+	}
+
+	if recreate {
+		plan.steps = append(plan.steps, vmApplyStep{
+			summary: fmt.Sprintf("Recreate VM %q as %s (now %s)", vmName, spec.InstanceType, found.InstanceType),
+			args:    []string{"recreate", "--instance-type", spec.InstanceType},
+		})
+	} else if plan.changes("idle_timeout") {
+		plan.manual = append(plan.manual, fmt.Sprintf("The idle timeout of a running instance is set at launch; run %s to apply idle_timeout %s.",
+			hint.Cmd("mint recreate"), want["idle_timeout"]))
+	}
+
+	var grow *vmApplyStep
+	switch {
+	case spec.VolumeSizeGB > live.volumeGB:
+		grow = &vmApplyStep{
+			summary: fmt.Sprintf("Grow project volume %s from %d to %d GiB", live.volumeID, live.volumeGB, spec.VolumeSizeGB),
+			args:    []string{"volume", "grow", "--size", strconv.Itoa(spec.VolumeSizeGB)},
+		}
+	case spec.VolumeSizeGB != 0 && spec.VolumeSizeGB < live.volumeGB:
+		plan.manual = append(plan.manual, fmt.Sprintf("Project volume %s is %d GiB, larger than %d GiB: EBS volumes cannot shrink. Copy the projects to a new VM to make it smaller.",
+			live.volumeID, live.volumeGB, spec.VolumeSizeGB))
+	}
+	if spec.VolumeIOPS != 0 && spec.VolumeIOPS != live.volumeIOPS {
+		plan.manual = append(plan.manual, fmt.Sprintf("Project volume %s has %d IOPS, not %d. Change it with %s.",
+			live.volumeID, live.volumeIOPS, spec.VolumeIOPS, hint.Cmd(fmt.Sprintf("aws ec2 modify-volume --volume-id %s --iops %d", live.volumeID, spec.VolumeIOPS))))
+	}
+	if spec.VolumeThroughputMB != 0 && spec.VolumeThroughputMB != live.volumeThroughput {
+		plan.manual = append(plan.manual, fmt.Sprintf("Project volume %s has a throughput of %d MB/s, not %d. Change it with %s.",
+			live.volumeID, live.volumeThroughput, spec.VolumeThroughputMB, hint.Cmd(fmt.Sprintf("aws ec2 modify-volume --volume-id %s --throughput %d", live.volumeID, spec.VolumeThroughputMB))))
+	}
+	if spec.ExtraTags != nil {
+		if have := tags.Extra(found.Tags); !maps.Equal(have, spec.ExtraTags) {
+			plan.manual = append(plan.manual, fmt.Sprintf("Instance %s has extra tags %s, not %s. Set them in the [extra_tags] table of config.toml and run %s.",
+				found.ID, formatTagSet(have), formatTagSet(spec.ExtraTags), hint.Cmd("mint recreate")))
+		}
+	}
+
+	projectSteps, manual := projectAddSteps(spec.Projects, live.projects)
+	plan.manual = append(plan.manual, manual...)
+	if spec.Projects != nil {
+		wanted := make(map[string]bool, len(spec.Projects))
+		for _, p := range spec.Projects {
+			wanted[p.Name] = true
+		}
+		for _, name := range live.projects {
+			if !wanted[name] {
+				plan.manual = append(plan.manual, fmt.Sprintf("Project %q is on the VM but not in the file. Remove it with %s, or export the VM again to keep it.",
+					name, hint.Cmd("mint project remove "+name)))
+			}
+		}
+	}
+
+	// Growing the filesystem and adding projects need the VM running. A
+	// recreated VM is.
+	needsRunning := grow != nil || len(projectSteps) > 0
+	if needsRunning && !recreate && found.State != string(ec2types.InstanceStateNameRunning) {
+		plan.steps = append(plan.steps, vmApplyStep{
+			summary: fmt.Sprintf("Start VM %q (now %s)", vmName, found.DisplayState()),
+			args:    []string{"up"},
+		})
+	}
+	if grow != nil {
+		plan.steps = append(plan.steps, *grow)
+	}
+	plan.steps = append(plan.steps, projectSteps...)
+	return plan, nil
+}
+
+// changes reports whether the plan sets key in the [vm.<name>] table.
+func (p *vmApplyPlan) changes(key string) bool {
+	for _, c := range p.configChanges {
+		if c.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// projectAddSteps returns a mint project add step for each of projects not
+// in present. A project without a repository URL cannot be cloned again,
+// so it is a manual action instead.
+func projectAddSteps(projects []vmSpecProject, present []string) (steps []vmApplyStep, manual []string) {
+	for _, proj := range projects {
+		if slices.Contains(present, proj.Name) {
+			continue
+		}
+		if proj.Repo == "" {
+			manual = append(manual, fmt.Sprintf("Project %q has no repository URL. Push it from a local copy with %s.",
+				proj.Name, hint.Cmd(fmt.Sprintf("mint project add <dir> --name %s", proj.Name))))
+			continue
+		}
+		args := []string{"project", "add", proj.Repo, "--name", proj.Name}
+		if proj.Branch != "" {
+			args = append(args, "--branch", proj.Branch)
+		}
+		if proj.Subdir != "" {
+			args = append(args, "--subdir", proj.Subdir)
+		}
+		if proj.NoDevcontainer {
+			args = append(args, "--no-devcontainer")
+		}
+		steps = append(steps, vmApplyStep{
+			summary: fmt.Sprintf("Add project %q from %s", proj.Name, proj.Repo),
+			args:    args,
+			project: proj.Name,
+		})
+	}
+	return steps, manual
+}
+
+// formatTagSet formats tags as {k=v, ...}, sorted by key.
+func formatTagSet(set map[string]string) string {
+	pairs := make([]string, 0, len(set))
+	for _, key := range slices.Sorted(maps.Keys(set)) {
+		pairs = append(pairs, key+"="+set[key])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// mintCommandLine returns args as the mint command line that runs them.
+func mintCommandLine(args []string) string {
+	return "mint " + strings.Join(args, " ")
+}
+
+// writeVMApplyPlan prints plan for vmName from the document at path.
+func writeVMApplyPlan(w io.Writer, vmName, path string, plan *vmApplyPlan) {
+	if plan.empty() {
+		fmt.Fprintf(w, "VM %q already matches %s.\n", vmName, path)
+	} else {
+		fmt.Fprintf(w, "Plan to make VM %q match %s:\n", vmName, path)
+	}
+	if len(plan.configChanges) > 0 {
+		fmt.Fprintf(w, "\n  Set in [vm.%s] of config.toml:\n", vmName)
+		for _, c := range plan.configChanges {
+			from := c.from
+			if from == "" {
+				from = `""`
+			}
+			fmt.Fprintf(w, "    %s: %s → %s\n", c.key, from, c.to)
+		}
+	}
+	if len(plan.steps) > 0 {
+		fmt.Fprintf(w, "\n  Run:\n")
+		for i, step := range plan.steps {
+			fmt.Fprintf(w, "    %d. %s\n       %s\n", i+1, step.summary, hint.Cmd(mintCommandLine(step.args)))
+		}
+	}
+	if len(plan.manual) > 0 {
+		fmt.Fprintf(w, "\n  Manual actions (not attempted):\n")
+		for _, m := range plan.manual {
+			fmt.Fprintf(w, "    - %s\n", m)
+		}
+	}
+}
+
+// saveVMConfig sets changes in vmName's [vm.<name>] table, both in
+// config.toml and in the loaded config the commands apply runs read.
+func saveVMConfig(deps *vmDeps, vmName string, changes []vmConfigChange) error {
+	cfg, err := config.Load(deps.configDir)
+	if err != nil {
+		return err
+	}
+	for _, target := range []*config.Config{cfg, deps.mintConfig} {
+		if target == nil {
+			continue
+		}
+		if target.VMOverrides == nil {
+			target.VMOverrides = make(map[string]map[string]string)
+		}
+		name := strings.ToLower(vmName)
+		if target.VMOverrides[name] == nil {
+			target.VMOverrides[name] = make(map[string]string)
+		}
+		for _, c := range changes {
+			target.VMOverrides[name][c.key] = c.to
+		}
+	}
+	if _, err := cfg.ForVM(vmName); err != nil {
+		return fmt.Errorf("config.toml: %w", err)
+	}
+	return config.Save(cfg, deps.configDir)
+}
+
+// runMintCommand runs the mint command args, e.g. ["volume", "grow",
+// "--size", "200"], in this process. It runs with cmd's context, so it
+// shares the CLI context and AWS clients.
+func runMintCommand(cmd *cobra.Command, args []string) error {
+	var sub *cobra.Command
+	switch args[0] {
+	case "up":
+		sub = newUpCommand()
+	case "recreate":
+		sub = newRecreateCommand()
+	case "volume":
+		sub = newVolumeCommand()
+	case "project":
+		sub = newProjectCommand()
+	default:
+		return fmt.Errorf("mint %s cannot be run by apply", args[0])
+	}
+	sub.SetArgs(args[1:])
+	sub.SetOut(cmd.OutOrStdout())
+	sub.SetErr(cmd.ErrOrStderr())
+	sub.SetIn(cmd.InOrStdin())
+	sub.SilenceUsage = true
+	sub.SilenceErrors = true
+	return sub.ExecuteContext(cmd.Context())
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// defaultVMHistoryLimit is how many history entries mint vm history shows
+// without --limit.
+const defaultVMHistoryLimit = 10
+
+// vmHistoryJSON is what mint vm history --json prints.
+type vmHistoryJSON struct {
+	VM            string                  `json:"vm"`
+	InstanceID    string                  `json:"instance_id"`
+	ProvisionedAt string                  `json:"provisioned_at,omitempty"`
+	ProvisionedBy string                  `json:"provisioned_by,omitempty"`
+	CLIVersion    string                  `json:"cli_version,omitempty"`
+	Entries       []provisionHistoryEntry `json:"entries"`
+}
+
+func newVMHistoryCommand(deps *vmDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show when the VM was provisioned and by which mint version",
+		Long: "Show when the VM's instance was last provisioned, by whom, and with which " +
+			"version of mint, from its tags, followed by the last entries of the " +
+			"provisioning history file on the VM (" + provisionHistoryPath + "). Every " +
+			"fresh mint up and mint recreate adds an entry; mint recreate carries the " +
+			"old instance's entries over to the new one.\n\n" +
+			"The history file is read over SSH, so it is only shown for a running VM. " +
+			"For the commands run from this machine, see mint history.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveVMDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runVMHistory(cmd, d)
+		},
+	}
+	cmd.Flags().Int("limit", defaultVMHistoryLimit, "Number of history entries to show (0 shows all)")
+	return cmd
+}
+
+func runVMHistory(cmd *cobra.Command, deps *vmDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit < 0 {
+		return fmt.Errorf("invalid --limit %d: must be 0 or more", limit)
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", vmName, hint.Cmd("mint list"))
+	}
+
+	var entries []provisionHistoryEntry
+	if found.State == string(ec2types.InstanceStateNameRunning) {
+		entries, err = readProvisionHistory(ctx, deps.remote, deps.sendKey, found, sshPortOrDefault(deps.sshPort))
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", provisionHistoryPath, err)
+		}
+		entries = lastProvisionHistory(entries, limit)
+	} else {
+		fmt.Fprintf(cmd.ErrOrStderr(), "VM %q is %s, so its history file was not read. Start it with %s to see it.\n",
+			vmName, found.State, hint.Cmd("mint up"))
+	}
+
+	w := cmd.OutOrStdout()
+	if jsonOutput {
+		if entries == nil {
+			entries = []provisionHistoryEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(vmHistoryJSON{
+			VM:            vmName,
+			InstanceID:    found.ID,
+			ProvisionedAt: found.Tags[tags.TagProvisionedAt],
+			ProvisionedBy: found.Tags[tags.TagProvisionedBy],
+			CLIVersion:    found.Tags[tags.TagCLIVersion],
+			Entries:       entries,
+		})
+	}
+
+	writeVMHistoryHuman(w, found, entries, time.Now())
+	return nil
+}
+
+// writeVMHistoryHuman prints the provisioning tags of v, then entries as an
+// aligned table, oldest first.
+func writeVMHistoryHuman(w io.Writer, v *vm.VM, entries []provisionHistoryEntry, now time.Time) {
+	fmt.Fprintf(w, "VM:              %s (%s)\n", v.Name, v.ID)
+	if at, err := time.Parse(time.RFC3339, v.Tags[tags.TagProvisionedAt]); err == nil {
+		fmt.Fprintf(w, "Provisioned at:  %s (%s)\n", at.Local().Format("2006-01-02 15:04:05"), formatCacheAge(now.Sub(at)))
+	} else {
+		fmt.Fprintf(w, "Provisioned at:  unknown (provisioned before mint recorded it)\n")
+	}
+	if by := v.Tags[tags.TagProvisionedBy]; by != "" {
+		fmt.Fprintf(w, "Provisioned by:  %s\n", by)
+	}
+	if cliVersion := v.Tags[tags.TagCLIVersion]; cliVersion != "" {
+		fmt.Fprintf(w, "CLI version:     %s\n", cliVersion)
+	}
+
+	if len(entries) == 0 {
+		if v.State == string(ec2types.InstanceStateNameRunning) {
+			fmt.Fprintln(w, "\nNo provisioning history recorded on the VM.")
+		}
+		return
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tACTION\tINSTANCE\tCLI VERSION\tDURATION")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			e.Action, e.InstanceID, e.CLIVersion,
+			format.FormatDuration(time.Duration(e.DurationSeconds)*time.Second))
+	}
+	tw.Flush()
+}
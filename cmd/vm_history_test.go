@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// newVMHistoryFixture wires VM "dev" in state with provisioning tags and a
+// history file of n entries, i-1 oldest.
+func newVMHistoryFixture(t *testing.T, state ec2types.InstanceStateName, n int) *vmFixture {
+	t.Helper()
+	f := newVMFixture(t, state, "m6i.xlarge")
+	out := f.deps.describe.(*cmdtest.DescribeInstances).Output
+	inst := &out.Reservations[0].Instances[0]
+	inst.Tags = append(inst.Tags,
+		ec2types.Tag{Key: aws.String(tags.TagProvisionedAt), Value: aws.String(time.Now().Add(-50 * time.Hour).UTC().Format(time.RFC3339))},
+		ec2types.Tag{Key: aws.String(tags.TagProvisionedBy), Value: aws.String("alice")},
+		ec2types.Tag{Key: aws.String(tags.TagCLIVersion), Value: aws.String("0.9.2")},
+	)
+	var file strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&file, `{"instance_id":"i-%d","action":"recreate","timestamp":"2026-10-%02dT09:00:00Z","cli_version":"0.9.%d","duration_seconds":%d}`+"\n",
+			i, i, i, 200+i)
+	}
+	f.deps.remote = func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		if strings.Join(command, " ") != strings.Join(provisionHistoryReadCommand(), " ") {
+			return nil, fmt.Errorf("unexpected command %q", command)
+		}
+		return []byte(file.String()), nil
+	}
+	return f
+}
+
+func TestVMHistory(t *testing.T) {
+	f := newVMHistoryFixture(t, ec2types.InstanceStateNameRunning, 12)
+	out, err := runVMCmd(t, f.deps, "", "history", "--vm", "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"VM:              dev (i-dev)",
+		"(2d ago)",
+		"Provisioned by:  alice",
+		"CLI version:     0.9.2",
+		"TIME", "ACTION", "INSTANCE", "CLI VERSION", "DURATION",
+		"i-12", "0.9.12", "3m 32s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// The last ten entries by default.
+	if strings.Contains(out, "i-2 ") || !strings.Contains(out, "i-3 ") {
+		t.Errorf("want entries i-3 to i-12:\n%s", out)
+	}
+}
+
+func TestVMHistoryJSON(t *testing.T) {
+	f := newVMHistoryFixture(t, ec2types.InstanceStateNameRunning, 3)
+	out, err := runVMCmd(t, f.deps, "", "history", "--vm", "dev", "--json", "--limit", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	var got vmHistoryJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if got.VM != "dev" || got.InstanceID != "i-dev" || got.ProvisionedBy != "alice" || got.CLIVersion != "0.9.2" || got.ProvisionedAt == "" {
+		t.Errorf("header = %+v", got)
+	}
+	if len(got.Entries) != 2 || got.Entries[0].InstanceID != "i-2" || got.Entries[1].DurationSeconds != 203 {
+		t.Errorf("entries = %+v, want i-2 and i-3", got.Entries)
+	}
+}
+
+func TestVMHistoryStoppedShowsTagsOnly(t *testing.T) {
+	f := newVMHistoryFixture(t, ec2types.InstanceStateNameStopped, 3)
+	f.deps.remote = nil // a stopped VM is not contacted
+	out, err := runVMCmd(t, f.deps, "", "history", "--vm", "dev", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "history file was not read") || !strings.Contains(out, `"entries": []`) {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestVMHistoryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		setup   func(f *vmFixture)
+		wantErr string
+	}{
+		{
+			name:    "negative limit",
+			args:    []string{"--limit", "-1"},
+			wantErr: "invalid --limit -1",
+		},
+		{
+			name: "no such VM",
+			setup: func(f *vmFixture) {
+				f.deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}}
+			},
+			wantErr: `no VM "dev" found`,
+		},
+		{
+			name: "unreachable VM",
+			setup: func(f *vmFixture) {
+				f.deps.remote = func(context.Context, mintaws.SendSSHPublicKeyAPI, string, string, string, int, string, []string) ([]byte, error) {
+					return nil, errors.New("connection refused")
+				}
+			},
+			wantErr: "reading " + provisionHistoryPath + ": connection refused",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newVMHistoryFixture(t, ec2types.InstanceStateNameRunning, 1)
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			out, err := runVMCmd(t, f.deps, "", append([]string{"history", "--vm", "dev"}, tt.args...)...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q\n%s", err, tt.wantErr, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// vmFixture bundles vm deps with what tests assert on.
+type vmFixture struct {
+	deps *vmDeps
+	// ran records the mint commands apply ran.
+	ran [][]string
+}
+
+// newVMFixture wires VM "dev" of type instanceType in state, with a 100
+// GiB gp3 project volume and the projects "api" and "web" when running.
+func newVMFixture(t *testing.T, state ec2types.InstanceStateName, instanceType string) *vmFixture {
+	t.Helper()
+	f := &vmFixture{}
+	f.deps = &vmDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{
+				InstanceId:      aws.String("i-dev"),
+				InstanceType:    ec2types.InstanceType(instanceType),
+				PublicIpAddress: aws.String("1.2.3.4"),
+				State:           &ec2types.InstanceState{Name: state},
+				Placement:       &ec2types.Placement{AvailabilityZone: aws.String("us-east-1a")},
+				Tags: []ec2types.Tag{
+					{Key: aws.String(tags.TagVM), Value: aws.String("dev")},
+					{Key: aws.String(tags.TagOwner), Value: aws.String("alice")},
+					{Key: aws.String("Team"), Value: aws.String("infra")},
+				},
+			}}}},
+		}},
+		describeVolumes: &cmdtest.DescribeVolumesByTag{Key: tags.TagComponent, ByValue: map[string][]ec2types.Volume{
+			tags.ComponentProjectVolume: {{
+				VolumeId:   aws.String("vol-proj"),
+				Size:       aws.Int32(100),
+				Iops:       aws.Int32(3000),
+				Throughput: aws.Int32(125),
+			}},
+		}},
+		owner:      "alice",
+		mintConfig: config.Defaults(),
+		configDir:  t.TempDir(),
+	}
+	f.deps.remote = func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		script := strings.Join(command, " ")
+		switch {
+		case strings.Contains(script, "remote get-url"):
+			return []byte("web\thttps://github.com/acme/web.git\tmain\napi\tgit@github.com:acme/mono.git\tdev\n"), nil
+		case strings.Contains(script, "--get-regexp"):
+			return []byte("api\tmint.subdir\tservices/api\napi\tmint.devcontainer\tfalse\n"), nil
+		}
+		return nil, nil
+	}
+	f.deps.run = func(cmd *cobra.Command, args []string) error {
+		f.ran = append(f.ran, args)
+		return nil
+	}
+	return f
+}
+
+func runVMCmd(t *testing.T, deps *vmDeps, stdin string, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newVMCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetIn(strings.NewReader(stdin))
+	root.SetArgs(append([]string{"vm"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// writeVMSpec writes content to a file in a temporary directory and
+// returns its path.
+func writeVMSpec(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dev.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVMExport(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	out, err := runVMCmd(t, f.deps, "", "export", "--vm", "dev", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	var got vmSpec
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	want := vmSpec{
+		Version:            vmSpecVersion,
+		VM:                 "dev",
+		InstanceType:       "m6i.xlarge",
+		VolumeSizeGB:       100,
+		VolumeIOPS:         3000,
+		VolumeThroughputMB: 125,
+		IdleTimeout:        "60m",
+		ExtraTags:          map[string]string{"Team": "infra"},
+		Projects: []vmSpecProject{
+			{Name: "api", Repo: "git@github.com:acme/mono.git", Branch: "dev", Subdir: "services/api", NoDevcontainer: true},
+			{Name: "web", Repo: "https://github.com/acme/web.git", Branch: "main"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("export =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestVMExportYAMLRoundTrips(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	out, err := runVMCmd(t, f.deps, "", "export", "--vm", "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "instance_type: m6i.xlarge\n") {
+		t.Errorf("output is not YAML:\n%s", out)
+	}
+
+	// Applying the export to the VM it came from changes nothing.
+	out, err = runVMCmd(t, f.deps, "", "apply", writeVMSpec(t, out))
+	if err != nil {
+		t.Fatalf("apply: unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `VM "dev" already matches`) || len(f.ran) != 0 {
+		t.Errorf("apply of an export ran %v:\n%s", f.ran, out)
+	}
+}
+
+func TestVMExportStoppedLeavesOutProjects(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameStopped, "m6i.xlarge")
+	out, err := runVMCmd(t, f.deps, "", "export", "--vm", "dev", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "projects were left out") || strings.Contains(out, `"projects"`) {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestParseProjectSources(t *testing.T) {
+	got := parseProjectSources("web\thttps://github.com/acme/web.git\tmain\nlocal\t\t\n\napi\tgit@github.com:acme/api.git\t\n")
+	want := []vmSpecProject{
+		{Name: "api", Repo: "git@github.com:acme/api.git"},
+		{Name: "local"},
+		{Name: "web", Repo: "https://github.com/acme/web.git", Branch: "main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProjectSources = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanVMApply(t *testing.T) {
+	running := &vm.VM{ID: "i-dev", State: "running", InstanceType: "m6i.xlarge", Tags: map[string]string{"Team": "infra"}}
+	stopped := &vm.VM{ID: "i-dev", State: "stopped", InstanceType: "m6i.xlarge"}
+	live := func(found *vm.VM, projects ...string) *vmLiveState {
+		return &vmLiveState{
+			found: found, volumeID: "vol-proj", volumeGB: 100, volumeIOPS: 3000, volumeThroughput: 125,
+			config: config.Defaults(), projects: projects, projectsLive: found == running,
+		}
+	}
+	web := vmSpecProject{Name: "web", Repo: "https://github.com/acme/web.git", Branch: "main"}
+
+	tests := []struct {
+		name       string
+		spec       vmSpec
+		live       *vmLiveState
+		wantConfig []string
+		wantSteps  []string
+		wantManual []string
+	}{
+		{
+			name: "matching",
+			spec: vmSpec{InstanceType: "m6i.xlarge", VolumeSizeGB: 100, IdleTimeout: "1h", Projects: []vmSpecProject{web}},
+			live: live(running, "web"),
+		},
+		{
+			name:       "missing VM",
+			spec:       vmSpec{InstanceType: "m6i.2xlarge", VolumeSizeGB: 200, Projects: []vmSpecProject{web}},
+			live:       &vmLiveState{config: config.Defaults()},
+			wantConfig: []string{"instance_type", "volume_size_gb"},
+			wantSteps:  []string{"up", "project add https://github.com/acme/web.git --name web --branch main"},
+		},
+		{
+			name:       "instance type differs",
+			spec:       vmSpec{InstanceType: "m6i.2xlarge", VolumeSizeGB: 200},
+			live:       live(stopped),
+			wantConfig: []string{"instance_type"},
+			wantSteps:  []string{"recreate --instance-type m6i.2xlarge", "volume grow --size 200"},
+		},
+		{
+			name:       "shrink",
+			spec:       vmSpec{VolumeSizeGB: 50},
+			live:       live(stopped),
+			wantManual: []string{"EBS volumes cannot shrink"},
+		},
+		{
+			name:      "grow on stopped VM",
+			spec:      vmSpec{VolumeSizeGB: 150},
+			live:      live(stopped),
+			wantSteps: []string{"up", "volume grow --size 150"},
+		},
+		{
+			name:       "volume performance and extra tags",
+			spec:       vmSpec{VolumeIOPS: 6000, VolumeThroughputMB: 250, ExtraTags: map[string]string{"Team": "data"}},
+			live:       live(running),
+			wantManual: []string{"has 3000 IOPS, not 6000", "throughput of 125 MB/s, not 250", "extra tags {Team=infra}, not {Team=data}"},
+		},
+		{
+			name:       "idle timeout of an existing VM",
+			spec:       vmSpec{IdleTimeout: "2h"},
+			live:       live(running),
+			wantConfig: []string{"idle_timeout"},
+			wantManual: []string{"to apply idle_timeout 120m"},
+		},
+		{
+			name: "projects",
+			spec: vmSpec{Projects: []vmSpecProject{
+				web,
+				{Name: "api", Repo: "git@github.com:acme/mono.git", Subdir: "services/api", NoDevcontainer: true},
+				{Name: "scratch"},
+			}},
+			live:      live(running, "web", "old"),
+			wantSteps: []string{"project add git@github.com:acme/mono.git --name api --subdir services/api --no-devcontainer"},
+			wantManual: []string{
+				`Project "scratch" has no repository URL`,
+				`Project "old" is on the VM but not in the file`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planVMApply(&tt.spec, "dev", tt.live)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var gotConfig, gotSteps []string
+			for _, c := range plan.configChanges {
+				gotConfig = append(gotConfig, c.key)
+			}
+			for _, s := range plan.steps {
+				gotSteps = append(gotSteps, strings.Join(s.args, " "))
+			}
+			if !reflect.DeepEqual(gotConfig, tt.wantConfig) {
+				t.Errorf("config changes = %v, want %v", gotConfig, tt.wantConfig)
+			}
+			if !reflect.DeepEqual(gotSteps, tt.wantSteps) {
+				t.Errorf("steps = %q, want %q", gotSteps, tt.wantSteps)
+			}
+			if len(plan.manual) != len(tt.wantManual) {
+				t.Fatalf("manual = %q, want %d", plan.manual, len(tt.wantManual))
+			}
+			for i, want := range tt.wantManual {
+				if !strings.Contains(plan.manual[i], want) {
+					t.Errorf("manual[%d] = %q, want it to contain %q", i, plan.manual[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanVMApplyRejectsInvalidValues(t *testing.T) {
+	live := &vmLiveState{config: config.Defaults()}
+	if _, err := planVMApply(&vmSpec{IdleTimeout: "soon"}, "dev", live); err == nil {
+		t.Error("expected an error for an invalid idle_timeout")
+	}
+}
+
+func TestVMApply(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	path := writeVMSpec(t, `version: 1
+vm: dev
+instance_type: m6i.2xlarge
+volume_size_gb: 100
+projects:
+  - name: web
+    repo: https://github.com/acme/web.git
+  - name: docs
+    repo: https://github.com/acme/docs.git
+`)
+	out, err := runVMCmd(t, f.deps, "", "apply", path, "--yes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	want := [][]string{
+		{"recreate", "--instance-type", "m6i.2xlarge"},
+		{"project", "add", "https://github.com/acme/docs.git", "--name", "docs"},
+	}
+	if !reflect.DeepEqual(f.ran, want) {
+		t.Errorf("ran %q, want %q", f.ran, want)
+	}
+	for _, s := range []string{
+		"instance_type: m6i.xlarge → m6i.2xlarge",
+		`Project "api" is on the VM but not in the file`,
+		`VM "dev" now matches`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("output missing %q:\n%s", s, out)
+		}
+	}
+
+	cfg, err := config.Load(f.deps.configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.VMOverrides["dev"]["instance_type"]; got != "m6i.2xlarge" {
+		t.Errorf("[vm.dev] instance_type in config.toml = %q, want m6i.2xlarge", got)
+	}
+	if got := f.deps.mintConfig.VMOverrides["dev"]["instance_type"]; got != "m6i.2xlarge" {
+		t.Errorf("[vm.dev] instance_type in the loaded config = %q, want m6i.2xlarge", got)
+	}
+}
+
+func TestVMApplyUsesVMFlag(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	f.deps.describe = &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{}}
+	out, err := runVMCmd(t, f.deps, "", "apply", writeVMSpec(t, "version: 1\nvm: dev\n"), "--vm", "dev2", "--yes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `Provision VM "dev2"`) {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestVMApplyDeclined(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	path := writeVMSpec(t, "version: 1\nvm: dev\nvolume_size_gb: 200\n")
+	out, err := runVMCmd(t, f.deps, "n\n", "apply", path)
+	if err == nil || !strings.Contains(err.Error(), "apply aborted") {
+		t.Fatalf("error = %v, want apply aborted\n%s", err, out)
+	}
+	if len(f.ran) != 0 {
+		t.Errorf("ran %q after the plan was declined", f.ran)
+	}
+	if _, err := os.Stat(filepath.Join(f.deps.configDir, "config.toml")); !os.IsNotExist(err) {
+		t.Errorf("config.toml written after the plan was declined: %v", err)
+	}
+}
+
+func TestVMApplyDryRun(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	path := writeVMSpec(t, "version: 1\nvm: dev\nvolume_size_gb: 200\n")
+	out, err := runVMCmd(t, f.deps, "", "apply", path, "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "mint volume grow --size 200") || len(f.ran) != 0 {
+		t.Errorf("ran %q:\n%s", f.ran, out)
+	}
+}
+
+func TestVMApplyIsIdempotent(t *testing.T) {
+	f := newVMFixture(t, ec2types.InstanceStateNameRunning, "m6i.xlarge")
+	path := writeVMSpec(t, "version: 1\nvm: dev\nidle_timeout: 2h\nforwards: [3000, 5173]\n")
+	if out, err := runVMCmd(t, f.deps, "", "apply", path, "--yes"); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	out, err := runVMCmd(t, f.deps, "", "apply", path, "--yes")
+	if err != nil {
+		t.Fatalf("second apply: unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "already matches") {
+		t.Errorf("second apply:\n%s", out)
+	}
+}
+
+func TestReadVMSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "json", content: `{"version": 1, "vm": "dev", "instance_type": "m6i.xlarge"}`},
+		{name: "unknown field", content: "version: 1\ninstance_typ: m6i.xlarge\n", wantErr: "instance_typ"},
+		{name: "newer version", content: "version: 2\n", wantErr: "written by a newer mint"},
+		{name: "duplicate project", content: "projects:\n  - name: web\n  - name: web\n", wantErr: `project "web" is listed twice`},
+		{name: "bad project name", content: "projects:\n  - name: ../web\n", wantErr: `project "../web"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readVMSpec(writeVMSpec(t, tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
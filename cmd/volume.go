@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/spf13/cobra"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/progress"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// maxProjectVolumeGiB is the largest gp3 volume EBS allows.
+const maxProjectVolumeGiB = 16384
+
+// volumeModificationPollInterval is how often mint volume grow checks on
+// the size change.
+const volumeModificationPollInterval = 5 * time.Second
+
+// volumeModificationTimeout bounds the wait for a size change to reach the
+// optimizing state, from which the new size is usable.
+const volumeModificationTimeout = 15 * time.Minute
+
+// volumeDeps holds the injectable dependencies for the volume commands.
+type volumeDeps struct {
+	describe                     mintaws.DescribeInstancesAPI
+	describeVolumes              mintaws.DescribeVolumesAPI
+	modifyVolume                 mintaws.ModifyVolumeAPI
+	describeVolumesModifications mintaws.DescribeVolumesModificationsAPI
+	createTags                   mintaws.CreateTagsAPI
+	sendKey                      mintaws.SendSSHPublicKeyAPI
+	remoteRun                    RemoteCommandRunner
+	sshPort                      int // ssh_port from config; zero means defaultSSHPort
+	owner                        string
+	locker                       *provision.Locker // nil takes no operation lock
+	// sleep waits between modification checks. nil uses a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newVolumeCommand creates the production volume command group.
+func newVolumeCommand() *cobra.Command {
+	return newVolumeCommandWithDeps(nil)
+}
+
+// newVolumeCommandWithDeps creates the volume command group with explicit
+// dependencies for testing. When deps is nil, each subcommand wires real
+// AWS clients.
+func newVolumeCommandWithDeps(deps *volumeDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "volume",
+		Short: "Manage the project volume",
+		Long:  "Manage the VM's project EBS volume, mounted at /mint/projects.",
+	}
+
+	cmd.AddCommand(newVolumeGrowCommand(deps))
+
+	return cmd
+}
+
+// resolveVolumeDeps returns deps, or the production dependencies when deps
+// is nil.
+func resolveVolumeDeps(cmd *cobra.Command, deps *volumeDeps) (*volumeDeps, error) {
+	if deps != nil {
+		return deps, nil
+	}
+	clients := awsClientsFromContext(cmd.Context())
+	if clients == nil {
+		return nil, fmt.Errorf("AWS clients not configured")
+	}
+	return &volumeDeps{
+		describe:                     clients.ec2Client,
+		describeVolumes:              clients.ec2Client,
+		modifyVolume:                 clients.ec2Client,
+		describeVolumesModifications: clients.ec2Client,
+		createTags:                   clients.ec2Client,
+		sendKey:                      clients.sendKey,
+		remoteRun:                    clients.remoteRunner(),
+		sshPort:                      clients.sshOptions.Port,
+		owner:                        clients.owner,
+		locker:                       newOperationLocker(cmd, clients.ec2Client),
+	}, nil
+}
+
+func newVolumeGrowCommand(deps *volumeDeps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grow",
+		Short: "Grow the project volume",
+		Long: "Grow the VM's project volume to --size and, when the VM is running, grow its " +
+			"filesystem (ext4 or XFS) so the space is usable immediately. The VM stays up.\n\n" +
+			"EBS volumes cannot shrink, and a volume can be modified again only after the " +
+			"previous modification finishes. When the VM is stopped, the filesystem is grown " +
+			"the next time the command is run with the VM running.\n\n" +
+			"The grow is refused while another mint command holds the VM's operation lock; " +
+			"--steal-lock takes it anyway.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			d, err := resolveVolumeDeps(cmd, deps)
+			if err != nil {
+				return err
+			}
+			return runVolumeGrow(cmd, d)
+		},
+	}
+
+	cmd.Flags().String("size", "", "New size of the project volume (e.g. 100, 200GiB, 1TiB)")
+	_ = cmd.MarkFlagRequired("size")
+	addStealLockFlag(cmd)
+
+	return cmd
+}
+
+// volumeGrowJSON is the --json output of mint volume grow.
+type volumeGrowJSON struct {
+	VolumeID           string `json:"volume_id"`
+	OldSizeGB          int    `json:"old_size_gb"`
+	NewSizeGB          int    `json:"new_size_gb"`
+	FilesystemResized  bool   `json:"filesystem_resized"`
+	Filesystem         string `json:"filesystem,omitempty"`
+	ModificationState  string `json:"modification_state,omitempty"`
+	ModificationStatus string `json:"modification_status,omitempty"`
+}
+
+// runVolumeGrow executes the volume grow command logic.
+func runVolumeGrow(cmd *cobra.Command, deps *volumeDeps) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cliCtx := cli.FromCommand(cmd)
+	vmName := "default"
+	jsonOutput := false
+	if cliCtx != nil {
+		vmName = cliCtx.VM
+		jsonOutput = cliCtx.JSON
+	}
+	w := cmd.OutOrStdout()
+
+	sizeFlag, _ := cmd.Flags().GetString("size")
+	newSize, err := format.ParseSizeGiB(sizeFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --size: %w", err)
+	}
+	if newSize > maxProjectVolumeGiB {
+		return fmt.Errorf("invalid --size: gp3 volumes are at most %s", format.FormatGiB(maxProjectVolumeGiB))
+	}
+
+	found, err := vm.FindVM(ctx, deps.describe, deps.owner, vmName)
+	if err != nil {
+		return fmt.Errorf("discovering VM: %w", err)
+	}
+	if found == nil {
+		return fmt.Errorf("no VM %q found — run %s to list VMs", vmName, hint.Cmd("mint list"))
+	}
+
+	volume, err := lookupProjectVolume(ctx, deps.describeVolumes, deps.owner, vmName)
+	if err != nil {
+		return err
+	}
+	volumeID := aws.ToString(volume.VolumeId)
+	oldSize := int(aws.ToInt32(volume.Size))
+	if newSize < oldSize {
+		return fmt.Errorf("refusing to shrink project volume %s from %s to %s — EBS volumes can only grow",
+			volumeID, format.FormatGiB(oldSize), format.FormatGiB(newSize))
+	}
+
+	release, err := lockVM(ctx, cmd, deps.locker, found, "volume grow")
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	result := volumeGrowJSON{VolumeID: volumeID, OldSizeGB: oldSize, NewSizeGB: newSize}
+	sp := progress.NewCommandSpinner(w, jsonOutput)
+
+	// A volume already at the size only needs its filesystem grown, which
+	// lets a grow made while the VM was stopped be finished later.
+	if newSize > oldSize {
+		current, err := latestVolumeModification(ctx, deps.describeVolumesModifications, volumeID)
+		if err != nil {
+			return err
+		}
+		if current != nil && volumeModificationInProgress(current.ModificationState) {
+			return fmt.Errorf("project volume %s is already being modified (%s, %d%% done) — wait for it to finish, then try again",
+				volumeID, current.ModificationState, aws.ToInt64(current.Progress))
+		}
+
+		sp.Start(fmt.Sprintf("Growing project volume %s from %s to %s...", volumeID, format.FormatGiB(oldSize), format.FormatGiB(newSize)))
+		if _, err := deps.modifyVolume.ModifyVolume(ctx, growVolumeInput(volume, int32(newSize))); err != nil {
+			sp.Fail(err.Error())
+			return fmt.Errorf("modifying volume %s: %w", volumeID, err)
+		}
+		cliCtx.TouchResource(cli.ResourceVolume, volumeID)
+
+		mod, err := waitVolumeModification(ctx, deps, volumeID, func(m *ec2types.VolumeModification) {
+			sp.Update(fmt.Sprintf("Growing project volume %s (%s, %d%%)...", volumeID, m.ModificationState, aws.ToInt64(m.Progress)))
+		})
+		if err != nil {
+			sp.Fail(err.Error())
+			return err
+		}
+		result.ModificationState = string(mod.ModificationState)
+		result.ModificationStatus = aws.ToString(mod.StatusMessage)
+
+		if _, err := deps.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{found.ID},
+			Tags: []ec2types.Tag{
+				{Key: aws.String(tags.TagProjectVolumeGB), Value: aws.String(strconv.Itoa(newSize))},
+			},
+		}); err != nil {
+			sp.Fail(err.Error())
+			return fmt.Errorf("tagging instance %s with the new volume size: %w", found.ID, err)
+		}
+	}
+
+	running := found.State == string(ec2types.InstanceStateNameRunning)
+	if running {
+		sp.Start("Growing the filesystem on /mint/projects...")
+		remoteRun := deps.remoteRun
+		if remoteRun == nil {
+			remoteRun = defaultRemoteRunner
+		}
+		out, err := remoteRun(ctx, deps.sendKey, found.ID, found.AvailabilityZone, found.PublicIP,
+			sshPortOrDefault(deps.sshPort), defaultSSHUser, buildGrowFilesystemCommand())
+		if err != nil {
+			sp.Fail(err.Error())
+			return fmt.Errorf("project volume %s is now %s, but growing its filesystem failed: %w\n%s",
+				volumeID, format.FormatGiB(newSize), err,
+				hint.Suggest("Retry", fmt.Sprintf("mint volume grow --size %d", newSize)))
+		}
+		result.FilesystemResized = true
+		result.Filesystem = strings.TrimSpace(string(out))
+	}
+	sp.Stop("")
+
+	if jsonOutput {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	if newSize == oldSize {
+		fmt.Fprintf(w, "Project volume %s is already %s.\n", volumeID, format.FormatGiB(newSize))
+	} else {
+		fmt.Fprintf(w, "Project volume %s grown from %s to %s.\n", volumeID, format.FormatGiB(oldSize), format.FormatGiB(newSize))
+	}
+	if result.FilesystemResized {
+		fmt.Fprintf(w, "Filesystem (%s) on /mint/projects resized.\n", result.Filesystem)
+	} else {
+		fmt.Fprintf(w, "VM %q is %s, so the filesystem was not resized. Start it with %s, then run %s.\n",
+			vmName, found.State, hint.Cmd("mint up"), hint.Cmd(fmt.Sprintf("mint volume grow --size %d", newSize)))
+	}
+	if result.ModificationState == string(ec2types.VolumeModificationStateOptimizing) {
+		fmt.Fprintln(w, "EBS is still optimizing the volume in the background; performance may vary until it finishes.")
+	}
+	return nil
+}
+
+// growVolumeInput returns the modification that grows volume to newSize.
+// A gp3 volume's IOPS and throughput are sent along unchanged, so a grow
+// never resets a throughput raised with --volume-throughput.
+func growVolumeInput(volume ec2types.Volume, newSize int32) *ec2.ModifyVolumeInput {
+	input := &ec2.ModifyVolumeInput{
+		VolumeId: volume.VolumeId,
+		Size:     aws.Int32(newSize),
+	}
+	if volume.VolumeType == ec2types.VolumeTypeGp3 {
+		input.Iops = volume.Iops
+		input.Throughput = volume.Throughput
+	}
+	return input
+}
+
+// lookupProjectVolume returns the project EBS volume of vmName, found by
+// its tags.
+func lookupProjectVolume(ctx context.Context, describe mintaws.DescribeVolumesAPI, owner, vmName string) (ec2types.Volume, error) {
+	out, err := describe.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{
+		Filters: componentFilters(owner, vmName, tags.ComponentProjectVolume),
+	})
+	if err != nil {
+		return ec2types.Volume{}, fmt.Errorf("describe volumes: %w", err)
+	}
+	if len(out.Volumes) == 0 {
+		return ec2types.Volume{}, fmt.Errorf("no project volume found for owner %q, vm %q", owner, vmName)
+	}
+	return out.Volumes[0], nil
+}
+
+// latestVolumeModification returns the most recent modification of
+// volumeID, or nil when it has never been modified.
+func latestVolumeModification(ctx context.Context, describe mintaws.DescribeVolumesModificationsAPI, volumeID string) (*ec2types.VolumeModification, error) {
+	out, err := describe.DescribeVolumesModifications(ctx, &ec2.DescribeVolumesModificationsInput{
+		VolumeIds: []string{volumeID},
+	})
+	if err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "InvalidVolumeModification.NotFound" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("describe volume modifications: %w", err)
+	}
+	var latest *ec2types.VolumeModification
+	for i, m := range out.VolumesModifications {
+		if latest == nil || aws.ToTime(m.StartTime).After(aws.ToTime(latest.StartTime)) {
+			latest = &out.VolumesModifications[i]
+		}
+	}
+	return latest, nil
+}
+
+// volumeModificationInProgress reports whether a modification in state
+// blocks another one.
+func volumeModificationInProgress(state ec2types.VolumeModificationState) bool {
+	return state == ec2types.VolumeModificationStateModifying || state == ec2types.VolumeModificationStateOptimizing
+}
+
+// waitVolumeModification polls the modification of volumeID until it
+// reaches optimizing or completed, when the new size is usable, calling
+// update with each state seen.
+func waitVolumeModification(ctx context.Context, deps *volumeDeps, volumeID string, update func(*ec2types.VolumeModification)) (*ec2types.VolumeModification, error) {
+	sleep := deps.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	ctx, cancel := context.WithTimeout(ctx, volumeModificationTimeout)
+	defer cancel()
+
+	for {
+		mod, err := latestVolumeModification(ctx, deps.describeVolumesModifications, volumeID)
+		if err != nil {
+			return nil, err
+		}
+		if mod != nil {
+			update(mod)
+			switch mod.ModificationState {
+			case ec2types.VolumeModificationStateOptimizing, ec2types.VolumeModificationStateCompleted:
+				return mod, nil
+			case ec2types.VolumeModificationStateFailed:
+				return nil, fmt.Errorf("modifying volume %s failed: %s", volumeID, aws.ToString(mod.StatusMessage))
+			}
+		}
+		if err := sleep(ctx, volumeModificationPollInterval); err != nil {
+			return nil, fmt.Errorf("waiting for volume %s to grow: %w", volumeID, err)
+		}
+	}
+}
+
+// buildGrowFilesystemCommand grows the filesystem on /mint/projects to fill
+// its device and prints the filesystem type. Bootstrap formats the whole
+// device, but a partitioned one has its partition grown first; growpart
+// exits 1 when there is nothing to grow.
+func buildGrowFilesystemCommand() []string {
+	script := `set -e
+src=$(findmnt -no SOURCE /mint/projects)
+fstype=$(findmnt -no FSTYPE /mint/projects)
+parent=$(lsblk -no PKNAME "$src" | head -n 1)
+if [ -n "$parent" ]; then
+  sudo growpart "/dev/$parent" "$(cat "/sys/class/block/$(basename "$src")/partition")" || [ $? -eq 1 ]
+fi
+case "$fstype" in
+  ext2|ext3|ext4) sudo resize2fs "$src" >&2 ;;
+  xfs) sudo xfs_growfs /mint/projects >&2 ;;
+  *) echo "unsupported filesystem $fstype on /mint/projects" >&2; exit 1 ;;
+esac
+echo "$fstype"`
+	return []string{"sh", "-c", shellQuote(script)}
+}
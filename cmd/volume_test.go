@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/SpiceLabsHQ/Mint/cmd/internal/cmdtest"
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/provision"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+type captureModifyVolume struct {
+	input *ec2.ModifyVolumeInput
+}
+
+func (m *captureModifyVolume) ModifyVolume(ctx context.Context, params *ec2.ModifyVolumeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVolumeOutput, error) {
+	m.input = params
+	return &ec2.ModifyVolumeOutput{}, nil
+}
+
+// sequenceVolumeModifications answers each DescribeVolumesModifications
+// call with the next state, repeating the last one. No states, or a zero
+// state, is a volume that was never modified.
+type sequenceVolumeModifications struct {
+	states []ec2types.VolumeModification
+	calls  int
+}
+
+func (m *sequenceVolumeModifications) DescribeVolumesModifications(ctx context.Context, params *ec2.DescribeVolumesModificationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesModificationsOutput, error) {
+	i := min(m.calls, len(m.states)-1)
+	m.calls++
+	if len(m.states) == 0 || m.states[i].ModificationState == "" {
+		return nil, &smithy.GenericAPIError{Code: "InvalidVolumeModification.NotFound"}
+	}
+	return &ec2.DescribeVolumesModificationsOutput{VolumesModifications: []ec2types.VolumeModification{m.states[i]}}, nil
+}
+
+func volumeModification(state ec2types.VolumeModificationState, progress int64) ec2types.VolumeModification {
+	return ec2types.VolumeModification{
+		VolumeId:          aws.String("vol-proj"),
+		ModificationState: state,
+		Progress:          aws.Int64(progress),
+		StartTime:         aws.Time(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)),
+	}
+}
+
+// volumeFixture bundles volume deps with the mocks tests assert on.
+type volumeFixture struct {
+	deps       *volumeDeps
+	modify     *captureModifyVolume
+	mods       *sequenceVolumeModifications
+	createTags *cmdtest.CreateTags
+	remote     [][]string
+}
+
+// newVolumeFixture wires VM "default" in state with a 50 GB ext4 project
+// volume vol-proj.
+func newVolumeFixture(state ec2types.InstanceStateName, mods ...ec2types.VolumeModification) *volumeFixture {
+	f := &volumeFixture{
+		modify:     &captureModifyVolume{},
+		mods:       &sequenceVolumeModifications{states: mods},
+		createTags: &cmdtest.CreateTags{},
+	}
+	f.deps = &volumeDeps{
+		describe: &cmdtest.DescribeInstances{Output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{
+				InstanceId:      aws.String("i-abc123"),
+				PublicIpAddress: aws.String("1.2.3.4"),
+				State:           &ec2types.InstanceState{Name: state},
+				Placement:       &ec2types.Placement{AvailabilityZone: aws.String("us-east-1a")},
+				Tags: []ec2types.Tag{
+					{Key: aws.String(tags.TagVM), Value: aws.String("default")},
+					{Key: aws.String(tags.TagOwner), Value: aws.String("alice")},
+				},
+			}}}},
+		}},
+		describeVolumes: &cmdtest.DescribeVolumesByTag{Key: tags.TagComponent, ByValue: map[string][]ec2types.Volume{
+			tags.ComponentProjectVolume: {{VolumeId: aws.String("vol-proj"), Size: aws.Int32(50)}},
+		}},
+		modifyVolume:                 f.modify,
+		describeVolumesModifications: f.mods,
+		createTags:                   f.createTags,
+		owner:                        "alice",
+		sleep:                        func(context.Context, time.Duration) error { return nil },
+	}
+	f.deps.remoteRun = func(ctx context.Context, sendKey mintaws.SendSSHPublicKeyAPI, instanceID, az, host string, port int, user string, command []string) ([]byte, error) {
+		f.remote = append(f.remote, command)
+		return []byte("ext4\n"), nil
+	}
+	return f
+}
+
+func runVolumeCmd(t *testing.T, deps *volumeDeps, args ...string) (string, error) {
+	t.Helper()
+	root := cmdtest.NewRoot()
+	root.AddCommand(newVolumeCommandWithDeps(deps))
+	var buf bytes.Buffer
+	root.SetOut(&buf)
+	root.SetErr(&buf)
+	root.SetArgs(append([]string{"volume"}, args...))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+func TestVolumeGrow(t *testing.T) {
+	f := newVolumeFixture(ec2types.InstanceStateNameRunning,
+		volumeModification(ec2types.VolumeModificationStateCompleted, 100), // the previous grow
+		volumeModification(ec2types.VolumeModificationStateModifying, 10),
+		volumeModification(ec2types.VolumeModificationStateOptimizing, 40),
+	)
+	out, err := runVolumeCmd(t, f.deps, "grow", "--size", "100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+
+	if f.modify.input == nil || aws.ToString(f.modify.input.VolumeId) != "vol-proj" || aws.ToInt32(f.modify.input.Size) != 100 {
+		t.Fatalf("ModifyVolume input = %+v", f.modify.input)
+	}
+	if f.mods.calls != 3 {
+		t.Errorf("DescribeVolumesModifications calls = %d, want 3 (check, modifying, optimizing)", f.mods.calls)
+	}
+	if len(f.createTags.Inputs) != 1 {
+		t.Fatalf("CreateTags calls = %d, want 1", len(f.createTags.Inputs))
+	}
+	tagged := f.createTags.Inputs[0]
+	if tagged.Resources[0] != "i-abc123" || aws.ToString(tagged.Tags[0].Key) != tags.TagProjectVolumeGB || aws.ToString(tagged.Tags[0].Value) != "100" {
+		t.Errorf("CreateTags = %v %+v", tagged.Resources, tagged.Tags)
+	}
+	if len(f.remote) != 1 || !strings.Contains(strings.Join(f.remote[0], " "), "resize2fs") {
+		t.Errorf("remote commands = %v, want the filesystem grow", f.remote)
+	}
+	for _, want := range []string{
+		"Project volume vol-proj grown from 50 GiB to 100 GiB.",
+		"Filesystem (ext4) on /mint/projects resized.",
+		"still optimizing",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestVolumeGrowKeepsThroughput(t *testing.T) {
+	f := newVolumeFixture(ec2types.InstanceStateNameStopped)
+	f.deps.describeVolumesModifications = &sequenceVolumeModifications{states: []ec2types.VolumeModification{
+		{}, volumeModification(ec2types.VolumeModificationStateCompleted, 100),
+	}}
+	f.deps.describeVolumes = &cmdtest.DescribeVolumesByTag{Key: tags.TagComponent, ByValue: map[string][]ec2types.Volume{
+		tags.ComponentProjectVolume: {{
+			VolumeId:   aws.String("vol-proj"),
+			Size:       aws.Int32(50),
+			VolumeType: ec2types.VolumeTypeGp3,
+			Iops:       aws.Int32(6000),
+			Throughput: aws.Int32(500),
+		}},
+	}}
+	if out, err := runVolumeCmd(t, f.deps, "grow", "--size", "100"); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	in := f.modify.input
+	if aws.ToInt32(in.Size) != 100 || aws.ToInt32(in.Iops) != 6000 || aws.ToInt32(in.Throughput) != 500 {
+		t.Errorf("ModifyVolume size/IOPS/throughput = %d/%d/%d, want 100/6000/500",
+			aws.ToInt32(in.Size), aws.ToInt32(in.Iops), aws.ToInt32(in.Throughput))
+	}
+}
+
+func TestVolumeGrowJSON(t *testing.T) {
+	f := newVolumeFixture(ec2types.InstanceStateNameStopped)
+	f.deps.describeVolumesModifications = &sequenceVolumeModifications{states: []ec2types.VolumeModification{
+		{}, volumeModification(ec2types.VolumeModificationStateCompleted, 100),
+	}}
+
+	out, err := runVolumeCmd(t, f.deps, "grow", "--size", "200GiB", "--json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	var got volumeGrowJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	want := volumeGrowJSON{VolumeID: "vol-proj", OldSizeGB: 50, NewSizeGB: 200, ModificationState: "completed"}
+	if got != want {
+		t.Errorf("JSON = %+v, want %+v", got, want)
+	}
+	if len(f.remote) != 0 {
+		t.Errorf("stopped VM got remote commands %v", f.remote)
+	}
+}
+
+func TestVolumeGrowRefusals(t *testing.T) {
+	tests := []struct {
+		name string
+		size string
+		mods []ec2types.VolumeModification
+		want string
+	}{
+		{"shrink", "40", nil, "refusing to shrink project volume vol-proj from 50 GiB to 40 GiB"},
+		{"in progress", "100", []ec2types.VolumeModification{volumeModification(ec2types.VolumeModificationStateOptimizing, 37)},
+			"already being modified (optimizing, 37% done)"},
+		{"too large", "20TiB", nil, "invalid --size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newVolumeFixture(ec2types.InstanceStateNameRunning, tt.mods...)
+			_, err := runVolumeCmd(t, f.deps, "grow", "--size", tt.size)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if f.modify.input != nil || len(f.createTags.Inputs) != 0 || len(f.remote) != 0 {
+				t.Error("a refused grow changed something")
+			}
+		})
+	}
+}
+
+func TestVolumeGrowSameSizeOnlyResizesFilesystem(t *testing.T) {
+	f := newVolumeFixture(ec2types.InstanceStateNameRunning)
+	out, err := runVolumeCmd(t, f.deps, "grow", "--size", "50")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.modify.input != nil || len(f.createTags.Inputs) != 0 {
+		t.Error("a volume already at the size was modified")
+	}
+	if len(f.remote) != 1 || !strings.Contains(out, "already 50 GiB") {
+		t.Errorf("remote = %v, output:\n%s", f.remote, out)
+	}
+}
+
+func TestVolumeGrowOperationLock(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantModify bool
+	}{
+		{"lock respected", nil, "locked by mint recreate", false},
+		{"lock stolen", []string{"--steal-lock"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newVolumeFixture(ec2types.InstanceStateNameStopped,
+				volumeModification(ec2types.VolumeModificationStateCompleted, 100),
+				volumeModification(ec2types.VolumeModificationStateOptimizing, 40),
+			)
+			describe := f.deps.describe.(*cmdtest.DescribeInstances)
+			inst := &describe.Output.Reservations[0].Instances[0]
+			inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagOperation), Value: aws.String("recreate:desktop:4242:2026-10-15T11:50:00Z")})
+			f.deps.locker = provision.NewLocker(describe, &cmdtest.CreateTags{}, &cmdtest.DeleteTags{}).
+				WithWarnings(new(bytes.Buffer)).
+				WithIdentity("laptop", 100, func() time.Time { return now })
+
+			out, err := runVolumeCmd(t, f.deps, append([]string{"grow", "--size", "100"}, tt.args...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out)
+			}
+			if modified := f.modify.input != nil; modified != tt.wantModify {
+				t.Errorf("ModifyVolume called = %v, want %v", modified, tt.wantModify)
+			}
+		})
+	}
+}
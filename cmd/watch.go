@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// watchInterval is how often a --watch command redraws.
+const watchInterval = 5 * time.Second
+
+// errWatchDone is returned by a watch render to end the watch without an
+// error, once what it was waiting for has happened.
+var errWatchDone = errors.New("watch done")
+
+// watchLoop redraws a command's output every interval until its context is
+// canceled, as the first Ctrl-C does. Cancellation or errWatchDone ends the
+// watch without an error; any other render error ends it with that error.
+type watchLoop struct {
+	interval time.Duration
+	// sleep waits between renders. nil uses a timer.
+	sleep func(ctx context.Context, d time.Duration) error
+	// clear reports whether the screen is cleared before each render. nil
+	// clears only when w is a terminal.
+	clear func(w io.Writer) bool
+}
+
+// run calls render once, then again after every interval.
+func (l watchLoop) run(ctx context.Context, w io.Writer, render func(ctx context.Context) error) error {
+	sleep := l.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	clear := l.clear
+	if clear == nil {
+		clear = isTerminalWriter
+	}
+	interval := l.interval
+	if interval <= 0 {
+		interval = watchInterval
+	}
+
+	for {
+		if clear(w) {
+			fmt.Fprint(w, "\033[H\033[2J")
+		}
+		if err := render(ctx); err != nil {
+			if errors.Is(err, errWatchDone) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := sleep(ctx, interval); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// isTerminalWriter reports whether w is a terminal.
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
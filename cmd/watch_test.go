@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWatchLoopStopsOnRenderError(t *testing.T) {
+	renders := 0
+	l := watchLoop{
+		sleep: func(context.Context, time.Duration) error { return nil },
+		clear: func(io.Writer) bool { return true },
+	}
+	var buf bytes.Buffer
+	err := l.run(context.Background(), &buf, func(context.Context) error {
+		if renders++; renders == 2 {
+			return errors.New("ssh down")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "ssh down" {
+		t.Fatalf("error = %v, want ssh down", err)
+	}
+	if got := bytes.Count(buf.Bytes(), []byte("\033[2J")); got != 2 {
+		t.Errorf("cleared %d times, want 2", got)
+	}
+}
+
+func TestWatchLoopCanceledContextIsNotAnError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := watchLoop{interval: time.Hour, clear: func(io.Writer) bool { return false }}
+	err := l.run(ctx, io.Discard, func(context.Context) error {
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+}
+
+func TestWatchLoopStopsOnDone(t *testing.T) {
+	renders := 0
+	l := watchLoop{
+		sleep: func(context.Context, time.Duration) error { return nil },
+		clear: func(io.Writer) bool { return false },
+	}
+	err := l.run(context.Background(), io.Discard, func(context.Context) error {
+		if renders++; renders == 3 {
+			return errWatchDone
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("error = %v, want nil", err)
+	}
+	if renders != 3 {
+		t.Errorf("rendered %d times, want 3", renders)
+	}
+}
@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SpiceLabsHQ/Mint/internal/sg"
+)
+
+// TestAdminTemplateMatchesSGSpec keeps the CloudFormation admin security
+// group in step with sg.AdminRules, which mint doctor verifies against.
+func TestAdminTemplateMatchesSGSpec(t *testing.T) {
+	for _, r := range sg.Required(sg.AdminRules(), sg.Ingress) {
+		want := []string{
+			fmt.Sprintf("IpProtocol: %s", r.Protocol),
+			fmt.Sprintf("FromPort: %d", r.FromPort),
+			fmt.Sprintf("ToPort: %d", r.ToPort),
+		}
+		if r.Group == sg.Self {
+			want = append(want, "SourceSecurityGroupId: !Ref MintEfsSecurityGroup")
+		}
+		for _, w := range want {
+			if !strings.Contains(adminTemplate, w) {
+				t.Errorf("admin template missing %q for rule %s", w, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// AuditedOperation is a mutating AWS operation seen by the audit middleware.
+type AuditedOperation struct {
+	// Service is the lowercased service ID, e.g. "ec2".
+	Service string
+	// Name is the operation, e.g. "RunInstances".
+	Name string
+	// ResourcesIn are the resource IDs named in the request and
+	// ResourcesOut those returned in the response.
+	ResourcesIn  []string
+	ResourcesOut []string
+	// Duration covers the whole call, retries included.
+	Duration time.Duration
+	Err      error
+}
+
+// readOnlyPrefixes are operation name prefixes of calls that change nothing.
+var readOnlyPrefixes = []string{"Describe", "Get", "List", "Search", "Lookup", "Validate", "Estimate"}
+
+// nonMutatingOperations change no resource even though their names do not
+// say so. SendSSHPublicKey pushes a key that expires after 60 seconds and
+// runs before every remote command; AssumeRole only issues credentials.
+var nonMutatingOperations = map[string]bool{
+	"SendSSHPublicKey": true,
+	"AssumeRole":       true,
+}
+
+// IsMutatingOperation reports whether the AWS operation name changes state:
+// anything that is not a describe, get, list, or similar read.
+func IsMutatingOperation(name string) bool {
+	if name == "" || nonMutatingOperations[name] {
+		return false
+	}
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithOperationAudit returns an SDK API option that passes every mutating
+// operation made by clients built with it to record, after the call
+// returns. Append it to aws.Config.APIOptions before creating clients.
+// Read-only calls are not recorded.
+func WithOperationAudit(record func(AuditedOperation)) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("MintOperationAudit",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				name := awsmiddleware.GetOperationName(ctx)
+				if !IsMutatingOperation(name) {
+					return next.HandleInitialize(ctx, in)
+				}
+				start := time.Now()
+				out, md, err := next.HandleInitialize(ctx, in)
+				record(AuditedOperation{
+					Service:      strings.ToLower(awsmiddleware.GetServiceID(ctx)),
+					Name:         name,
+					ResourcesIn:  resourceIDs(in.Parameters),
+					ResourcesOut: outputResourceIDs(out.Result),
+					Duration:     time.Since(start),
+					Err:          err,
+				})
+				return out, md, err
+			}), middleware.After)
+	}
+}
+
+// outputResourceIDs returns the IDs of the resources a response describes.
+// Responses that embed whole resource descriptions, such as RunInstances,
+// are reduced to the created resources' own IDs; the rest use resourceIDs.
+func outputResourceIDs(result any) []string {
+	switch out := result.(type) {
+	case nil:
+		return nil
+	case *ec2.RunInstancesOutput:
+		var ids []string
+		for _, inst := range out.Instances {
+			if inst.InstanceId != nil {
+				ids = append(ids, *inst.InstanceId)
+			}
+		}
+		return ids
+	case *ec2.CreateVolumeOutput:
+		return nonEmpty(out.VolumeId)
+	case *ec2.CreateSnapshotOutput:
+		return nonEmpty(out.SnapshotId)
+	case *ec2.AllocateAddressOutput:
+		return nonEmpty(out.AllocationId)
+	case *ec2.AssociateAddressOutput:
+		return nonEmpty(out.AssociationId)
+	case *ec2.AttachVolumeOutput, *ec2.DetachVolumeOutput:
+		// These echo the request's volume and instance.
+		return nil
+	}
+	return resourceIDs(result)
+}
+
+// resourceIDs collects the top-level string fields of a request or response
+// struct that name resources: those ending in "Id" or "Ids", and the
+// Resources list of a tagging call.
+func resourceIDs(v any) []string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var ids []string
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !strings.HasSuffix(field.Name, "Id") && !strings.HasSuffix(field.Name, "Ids") && field.Name != "Resources" {
+			continue
+		}
+		switch fv := rv.Field(i).Interface().(type) {
+		case string:
+			ids = append(ids, nonEmpty(&fv)...)
+		case *string:
+			ids = append(ids, nonEmpty(fv)...)
+		case []string:
+			for _, id := range fv {
+				ids = append(ids, nonEmpty(&id)...)
+			}
+		}
+	}
+	return ids
+}
+
+// nonEmpty returns *s as a one-element slice, or nil when s is nil or empty.
+func nonEmpty(s *string) []string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return []string{*s}
+}
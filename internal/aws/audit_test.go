@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// invokeAudited runs input through a stack carrying the audit middleware
+// as the named EC2 operation, with a handler that returns output and err.
+func invokeAudited(t *testing.T, operation string, input, output any, err error) []AuditedOperation {
+	t.Helper()
+	var recorded []AuditedOperation
+	stack := middleware.NewStack(operation, func() any { return nil })
+	if e := stack.Initialize.Add(&awsmiddleware.RegisterServiceMetadata{ServiceID: "EC2", OperationName: operation}, middleware.Before); e != nil {
+		t.Fatal(e)
+	}
+	// Stand in for the SDK's deserializer, which turns the raw response
+	// into the operation's output.
+	deserialize := middleware.DeserializeMiddlewareFunc("deserialize", func(ctx context.Context, in middleware.DeserializeInput, next middleware.DeserializeHandler) (middleware.DeserializeOutput, middleware.Metadata, error) {
+		out, md, err := next.HandleDeserialize(ctx, in)
+		out.Result = out.RawResponse
+		return out, md, err
+	})
+	if e := stack.Deserialize.Add(deserialize, middleware.After); e != nil {
+		t.Fatal(e)
+	}
+	if e := WithOperationAudit(func(op AuditedOperation) { recorded = append(recorded, op) })(stack); e != nil {
+		t.Fatal(e)
+	}
+	handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, in any) (any, middleware.Metadata, error) {
+		return output, middleware.Metadata{}, err
+	}), stack)
+	_, _, _ = handler.Handle(context.Background(), input)
+	return recorded
+}
+
+func TestOperationAuditRecordsMutatingCalls(t *testing.T) {
+	recorded := invokeAudited(t, "RunInstances",
+		&ec2.RunInstancesInput{ImageId: aws.String("ami-1"), SubnetId: aws.String("subnet-1"), SecurityGroupIds: []string{"sg-1", "sg-2"}},
+		&ec2.RunInstancesOutput{Instances: []ec2types.Instance{{InstanceId: aws.String("i-new"), ImageId: aws.String("ami-1")}}},
+		nil)
+	if len(recorded) != 1 {
+		t.Fatalf("recorded %d operations, want 1", len(recorded))
+	}
+	op := recorded[0]
+	if op.Service != "ec2" || op.Name != "RunInstances" || op.Err != nil {
+		t.Errorf("operation = %+v", op)
+	}
+	if want := []string{"ami-1", "sg-1", "sg-2", "subnet-1"}; !slices.Equal(op.ResourcesIn, want) {
+		t.Errorf("ResourcesIn = %v, want %v", op.ResourcesIn, want)
+	}
+	if want := []string{"i-new"}; !slices.Equal(op.ResourcesOut, want) {
+		t.Errorf("ResourcesOut = %v, want %v", op.ResourcesOut, want)
+	}
+}
+
+func TestOperationAuditRecordsFailures(t *testing.T) {
+	recorded := invokeAudited(t, "CreateTags",
+		&ec2.CreateTagsInput{Resources: []string{"i-1", "vol-1"}},
+		nil, errors.New("UnauthorizedOperation"))
+	if len(recorded) != 1 {
+		t.Fatalf("recorded %d operations, want 1", len(recorded))
+	}
+	if op := recorded[0]; op.Err == nil || !slices.Equal(op.ResourcesIn, []string{"i-1", "vol-1"}) || op.ResourcesOut != nil {
+		t.Errorf("operation = %+v", op)
+	}
+}
+
+func TestOperationAuditSkipsReads(t *testing.T) {
+	for _, name := range []string{"DescribeInstances", "GetConsoleOutput", "SendSSHPublicKey"} {
+		if recorded := invokeAudited(t, name, &ec2.DescribeInstancesInput{}, &ec2.DescribeInstancesOutput{}, nil); len(recorded) != 0 {
+			t.Errorf("%s recorded %+v, want nothing", name, recorded)
+		}
+	}
+}
+
+func TestIsMutatingOperation(t *testing.T) {
+	for name, want := range map[string]bool{
+		"RunInstances":       true,
+		"TerminateInstances": true,
+		"CreateTags":         true,
+		"ModifyVolume":       true,
+		"DescribeVolumes":    false,
+		"ListStacks":         false,
+		"SendSSHPublicKey":   false,
+		"":                   false,
+	} {
+		if got := IsMutatingOperation(name); got != want {
+			t.Errorf("IsMutatingOperation(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Package aws provides thin wrappers around AWS SDK clients used by Mint.
+// This file defines narrow interfaces for the CloudWatch operations mint
+// status --deep uses to read EBS volume metrics.
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+)
+
+// GetMetricDataAPI defines the subset of the CloudWatch API used for reading
+// metric datapoints.
+type GetMetricDataAPI interface {
+	GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+// Compile-time interface satisfaction check.
+var _ GetMetricDataAPI = (*cloudwatch.Client)(nil)
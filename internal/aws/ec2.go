@@ -5,45 +5,306 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
+// instanceTypeCacheTTL is how long a region's cached instance type catalog
+// remains valid. New instance types launch rarely; --skip-type-validation
+// covers the gap for one that is newer than the cache.
+const instanceTypeCacheTTL = 24 * time.Hour
+
+// maxSuggestionDistance is the largest edit distance for which a "did you
+// mean" suggestion is offered. Beyond it the suggestion is more likely to
+// mislead than help.
+const maxSuggestionDistance = 3
+
+// modernFamilies maps previous-generation instance families to the current
+// family mint suggests in their place.
+var modernFamilies = map[string]string{
+	"m1": "m6i", "m2": "m6i", "m3": "m6i", "m4": "m6i",
+	"c1": "c6i", "c3": "c6i", "c4": "c6i",
+	"r3": "r6i", "r4": "r6i",
+	"t1": "t3", "t2": "t3",
+	"i2": "i4i", "i3": "i4i",
+	"d2": "d3",
+	"x1": "x2idn",
+}
+
 // DescribeInstanceTypesAPI defines the subset of the EC2 API used for instance
 // type validation. This interface enables mock injection for testing.
 type DescribeInstanceTypesAPI interface {
 	DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error)
 }
 
+// UnknownInstanceTypeError is returned when an instance type is not in the
+// region's catalog. Suggestion is the closest known type, or empty.
+type UnknownInstanceTypeError struct {
+	InstanceType string
+	Region       string
+	Suggestion   string
+}
+
+func (e *UnknownInstanceTypeError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown instance type %s in %s — did you mean %s?", e.InstanceType, e.Region, e.Suggestion)
+	}
+	return fmt.Sprintf("instance type %q is not available in %s", e.InstanceType, e.Region)
+}
+
 // InstanceTypeValidator validates that an EC2 instance type exists in a region
-// by calling the DescribeInstanceTypes API.
+// by checking it against the region's DescribeInstanceTypes catalog. The
+// catalog is fetched once per region and cached in memory and, when a cache
+// directory is set, on disk so repeated commands do not re-list it.
 type InstanceTypeValidator struct {
-	client DescribeInstanceTypesAPI
+	client   DescribeInstanceTypesAPI
+	cacheDir string
+
+	mu       sync.Mutex
+	catalogs map[string]instanceTypeCatalog
+}
+
+// instanceTypeCatalog maps each instance type offered in a region to whether
+// AWS marks it previous-generation.
+type instanceTypeCatalog map[string]bool
+
+// instanceTypeCacheFile is the on-disk representation of a cached catalog.
+type instanceTypeCacheFile struct {
+	FetchedAt time.Time           `json:"fetched_at"`
+	Types     instanceTypeCatalog `json:"types"`
 }
 
 // NewInstanceTypeValidator creates a validator with the given EC2 client.
 func NewInstanceTypeValidator(client DescribeInstanceTypesAPI) *InstanceTypeValidator {
-	return &InstanceTypeValidator{client: client}
+	return &InstanceTypeValidator{
+		client:   client,
+		catalogs: make(map[string]instanceTypeCatalog),
+	}
+}
+
+// WithCacheDir persists each region's catalog under dir for 24 hours. When
+// unset, the catalog is cached for the lifetime of the validator only.
+func (v *InstanceTypeValidator) WithCacheDir(dir string) *InstanceTypeValidator {
+	v.cacheDir = dir
+	return v
 }
 
 // Validate checks that instanceType is a valid EC2 instance type in the given region.
 // Returns nil if the instance type exists, or an error with context including
-// the instance type and region.
+// the instance type and region, and a suggestion when the name looks like a
+// typo of a known type.
 func (v *InstanceTypeValidator) Validate(ctx context.Context, instanceType, region string) error {
-	input := &ec2.DescribeInstanceTypesInput{
-		InstanceTypes: []types.InstanceType{types.InstanceType(instanceType)},
+	_, err := v.Check(ctx, instanceType, region)
+	return err
+}
+
+// Check validates instanceType like Validate and additionally returns a
+// warning (empty when none) when the type is previous-generation.
+func (v *InstanceTypeValidator) Check(ctx context.Context, instanceType, region string) (warning string, err error) {
+	catalog, err := v.catalog(ctx, region)
+	if err != nil {
+		return "", err
+	}
+
+	previous, ok := catalog[instanceType]
+	if !ok {
+		return "", &UnknownInstanceTypeError{
+			InstanceType: instanceType,
+			Region:       region,
+			Suggestion:   suggestInstanceType(instanceType, catalog),
+		}
+	}
+
+	if previous {
+		return previousGenerationWarning(instanceType, catalog), nil
+	}
+	return "", nil
+}
+
+// catalog returns the instance type catalog for region from memory, the disk
+// cache, or the API, in that order.
+func (v *InstanceTypeValidator) catalog(ctx context.Context, region string) (instanceTypeCatalog, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if c, ok := v.catalogs[region]; ok {
+		return c, nil
+	}
+	if c, ok := v.readCache(region); ok {
+		v.catalogs[region] = c
+		return c, nil
+	}
+
+	c := make(instanceTypeCatalog)
+	var nextToken *string
+	for {
+		out, err := v.client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ec2 describe-instance-types: %w", err)
+		}
+		for _, info := range out.InstanceTypes {
+			// Treat a missing flag as current so only types AWS explicitly
+			// marks previous-generation produce a warning.
+			c[string(info.InstanceType)] = info.CurrentGeneration != nil && !aws.ToBool(info.CurrentGeneration)
+		}
+		if aws.ToString(out.NextToken) == "" {
+			break
+		}
+		nextToken = out.NextToken
+	}
+
+	v.catalogs[region] = c
+	v.writeCache(region, c)
+	return c, nil
+}
+
+// cachePath returns the disk cache file for region.
+func (v *InstanceTypeValidator) cachePath(region string) string {
+	return filepath.Join(v.cacheDir, fmt.Sprintf("instance-types-%s.json", region))
+}
+
+// readCache returns a region's cached catalog when it exists and is fresh.
+func (v *InstanceTypeValidator) readCache(region string) (instanceTypeCatalog, bool) {
+	if v.cacheDir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(v.cachePath(region))
+	if err != nil {
+		return nil, false
+	}
+	var cache instanceTypeCacheFile
+	if err := json.Unmarshal(data, &cache); err != nil || len(cache.Types) == 0 {
+		return nil, false
 	}
+	if time.Since(cache.FetchedAt) > instanceTypeCacheTTL {
+		return nil, false
+	}
+	return cache.Types, true
+}
 
-	out, err := v.client.DescribeInstanceTypes(ctx, input)
+// writeCache stores a region's catalog on disk. Errors are silently
+// ignored: the cache only saves API calls.
+func (v *InstanceTypeValidator) writeCache(region string, c instanceTypeCatalog) {
+	if v.cacheDir == "" || len(c) == 0 {
+		return
+	}
+	data, err := json.Marshal(instanceTypeCacheFile{FetchedAt: time.Now(), Types: c})
 	if err != nil {
-		return fmt.Errorf("ec2 describe-instance-types: %w", err)
+		return
 	}
+	_ = os.MkdirAll(v.cacheDir, 0o700)
+	_ = os.WriteFile(v.cachePath(region), data, 0o644)
+}
 
-	if len(out.InstanceTypes) == 0 {
-		return fmt.Errorf("instance type %q is not available in %s", instanceType, region)
+// instanceFamily returns the family part of an instance type ("m6i" for
+// "m6i.xlarge").
+func instanceFamily(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return family
+}
+
+// suggestInstanceType returns the known type closest to instanceType by edit
+// distance, considering types in the same family prefix (the same leading
+// letter, so "m6l.xlarge" still finds "m6i.xlarge"). Ties prefer the same
+// family, then alphabetical order. Returns "" when nothing is close enough.
+func suggestInstanceType(instanceType string, catalog instanceTypeCatalog) string {
+	known := make([]string, 0, len(catalog))
+	for t := range catalog {
+		known = append(known, t)
 	}
+	if closest := closestInstanceTypes(instanceType, known, 1); len(closest) > 0 {
+		return closest[0]
+	}
+	return ""
+}
 
-	return nil
+// closestInstanceTypes returns up to n of the known types closest to
+// instanceType, ranked as suggestInstanceType ranks them.
+func closestInstanceTypes(instanceType string, known []string, n int) []string {
+	if instanceType == "" {
+		return nil
+	}
+	family := instanceFamily(instanceType)
+
+	type candidate struct {
+		name       string
+		dist       int
+		sameFamily bool
+	}
+	var candidates []candidate
+	for _, k := range known {
+		if k == "" || k[0] != instanceType[0] || k == instanceType {
+			continue
+		}
+		if d := editDistance(instanceType, k); d <= maxSuggestionDistance {
+			candidates = append(candidates, candidate{k, d, instanceFamily(k) == family})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		if a.sameFamily != b.sameFamily {
+			return a.sameFamily
+		}
+		return a.name < b.name
+	})
+
+	names := make([]string, 0, min(n, len(candidates)))
+	for _, c := range candidates[:min(n, len(candidates))] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// previousGenerationWarning describes a previous-generation type and, when
+// the mapping knows one, the modern equivalent. The same size in the modern
+// family is suggested when the region offers it.
+func previousGenerationWarning(instanceType string, catalog instanceTypeCatalog) string {
+	msg := fmt.Sprintf("instance type %s is previous-generation", instanceType)
+
+	family, size, _ := strings.Cut(instanceType, ".")
+	modern, ok := modernFamilies[family]
+	if !ok {
+		return msg
+	}
+	if candidate := modern + "." + size; size != "" {
+		if _, offered := catalog[candidate]; offered {
+			return fmt.Sprintf("%s — consider %s", msg, candidate)
+		}
+	}
+	return fmt.Sprintf("%s — consider the %s family", msg, modern)
 }
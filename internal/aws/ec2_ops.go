@@ -51,6 +51,15 @@ type WaitInstanceStoppedAPI interface {
 // Compile-time check: ec2.InstanceStoppedWaiter satisfies the interface.
 var _ WaitInstanceStoppedAPI = (*ec2.InstanceStoppedWaiter)(nil)
 
+// WaitSnapshotCompletedAPI defines the interface for waiting until an EBS
+// snapshot reaches the completed state. Wraps ec2.SnapshotCompletedWaiter.Wait.
+type WaitSnapshotCompletedAPI interface {
+	Wait(ctx context.Context, params *ec2.DescribeSnapshotsInput, maxWaitDur time.Duration, optFns ...func(*ec2.SnapshotCompletedWaiterOptions)) error
+}
+
+// Compile-time check: ec2.SnapshotCompletedWaiter satisfies the interface.
+var _ WaitSnapshotCompletedAPI = (*ec2.SnapshotCompletedWaiter)(nil)
+
 // ---------------------------------------------------------------------------
 // AMI resolution
 // ---------------------------------------------------------------------------
@@ -89,6 +98,12 @@ type DescribeInstancesAPI interface {
 	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
 }
 
+// DescribeInstanceStatusAPI defines the subset of the EC2 API used for
+// reading instance status, including scheduled events such as retirement.
+type DescribeInstanceStatusAPI interface {
+	DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error)
+}
+
 // ModifyInstanceAttributeAPI defines the subset of the EC2 API used for modifying
 // instance attributes (e.g., instance type on a stopped instance).
 type ModifyInstanceAttributeAPI interface {
@@ -124,6 +139,27 @@ type DescribeVolumesAPI interface {
 	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
 }
 
+// ModifyVolumeAPI defines the subset of the EC2 API used for resizing EBS volumes.
+type ModifyVolumeAPI interface {
+	ModifyVolume(ctx context.Context, params *ec2.ModifyVolumeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVolumeOutput, error)
+}
+
+// DescribeVolumesModificationsAPI defines the subset of the EC2 API used for
+// following the progress of EBS volume modifications.
+type DescribeVolumesModificationsAPI interface {
+	DescribeVolumesModifications(ctx context.Context, params *ec2.DescribeVolumesModificationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesModificationsOutput, error)
+}
+
+// CreateSnapshotAPI defines the subset of the EC2 API used for snapshotting EBS volumes.
+type CreateSnapshotAPI interface {
+	CreateSnapshot(ctx context.Context, params *ec2.CreateSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.CreateSnapshotOutput, error)
+}
+
+// DescribeSnapshotsAPI defines the subset of the EC2 API used for listing EBS snapshots.
+type DescribeSnapshotsAPI interface {
+	DescribeSnapshots(ctx context.Context, params *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error)
+}
+
 // ---------------------------------------------------------------------------
 // Elastic IP management
 // ---------------------------------------------------------------------------
@@ -153,6 +189,12 @@ type DisassociateAddressAPI interface {
 	DisassociateAddress(ctx context.Context, params *ec2.DisassociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.DisassociateAddressOutput, error)
 }
 
+// DescribeAccountAttributesAPI defines the subset of the EC2 API used for
+// reading account limits, such as the Elastic IP quota.
+type DescribeAccountAttributesAPI interface {
+	DescribeAccountAttributes(ctx context.Context, params *ec2.DescribeAccountAttributesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAccountAttributesOutput, error)
+}
+
 // ---------------------------------------------------------------------------
 // Security group management
 // ---------------------------------------------------------------------------
@@ -168,6 +210,12 @@ type AuthorizeSecurityGroupIngressAPI interface {
 	AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
 }
 
+// AuthorizeSecurityGroupEgressAPI defines the subset of the EC2 API used for
+// adding outbound rules to security groups.
+type AuthorizeSecurityGroupEgressAPI interface {
+	AuthorizeSecurityGroupEgress(ctx context.Context, params *ec2.AuthorizeSecurityGroupEgressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupEgressOutput, error)
+}
+
 // DescribeSecurityGroupsAPI defines the subset of the EC2 API used for describing security groups.
 type DescribeSecurityGroupsAPI interface {
 	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
@@ -192,6 +240,44 @@ type DescribeVpcsAPI interface {
 	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error)
 }
 
+// ---------------------------------------------------------------------------
+// Instance Connect Endpoint operations
+// ---------------------------------------------------------------------------
+
+// DescribeInstanceConnectEndpointsAPI defines the subset of the EC2 API used
+// for discovering EC2 Instance Connect Endpoints in a VPC.
+type DescribeInstanceConnectEndpointsAPI interface {
+	DescribeInstanceConnectEndpoints(ctx context.Context, params *ec2.DescribeInstanceConnectEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceConnectEndpointsOutput, error)
+}
+
+// CreateInstanceConnectEndpointAPI defines the subset of the EC2 API used for
+// creating an EC2 Instance Connect Endpoint.
+type CreateInstanceConnectEndpointAPI interface {
+	CreateInstanceConnectEndpoint(ctx context.Context, params *ec2.CreateInstanceConnectEndpointInput, optFns ...func(*ec2.Options)) (*ec2.CreateInstanceConnectEndpointOutput, error)
+}
+
+// ---------------------------------------------------------------------------
+// Serial console operations
+// ---------------------------------------------------------------------------
+
+// GetSerialConsoleAccessStatusAPI defines the subset of the EC2 API used for
+// checking whether EC2 Serial Console access is enabled for the account.
+type GetSerialConsoleAccessStatusAPI interface {
+	GetSerialConsoleAccessStatus(ctx context.Context, params *ec2.GetSerialConsoleAccessStatusInput, optFns ...func(*ec2.Options)) (*ec2.GetSerialConsoleAccessStatusOutput, error)
+}
+
+// EnableSerialConsoleAccessAPI defines the subset of the EC2 API used for
+// enabling EC2 Serial Console access for the account.
+type EnableSerialConsoleAccessAPI interface {
+	EnableSerialConsoleAccess(ctx context.Context, params *ec2.EnableSerialConsoleAccessInput, optFns ...func(*ec2.Options)) (*ec2.EnableSerialConsoleAccessOutput, error)
+}
+
+// GetConsoleOutputAPI defines the subset of the EC2 API used for reading an
+// instance's console output when it cannot be reached over SSH.
+type GetConsoleOutputAPI interface {
+	GetConsoleOutput(ctx context.Context, params *ec2.GetConsoleOutputInput, optFns ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error)
+}
+
 // ---------------------------------------------------------------------------
 // Compile-time interface satisfaction checks
 // ---------------------------------------------------------------------------
@@ -202,21 +288,33 @@ var (
 	_ StopInstancesAPI                 = (*ec2.Client)(nil)
 	_ TerminateInstancesAPI            = (*ec2.Client)(nil)
 	_ DescribeInstancesAPI             = (*ec2.Client)(nil)
+	_ DescribeInstanceStatusAPI        = (*ec2.Client)(nil)
 	_ ModifyInstanceAttributeAPI       = (*ec2.Client)(nil)
 	_ CreateVolumeAPI                  = (*ec2.Client)(nil)
 	_ AttachVolumeAPI                  = (*ec2.Client)(nil)
 	_ DetachVolumeAPI                  = (*ec2.Client)(nil)
 	_ DeleteVolumeAPI                  = (*ec2.Client)(nil)
 	_ DescribeVolumesAPI               = (*ec2.Client)(nil)
+	_ CreateSnapshotAPI                = (*ec2.Client)(nil)
+	_ DescribeSnapshotsAPI             = (*ec2.Client)(nil)
 	_ AllocateAddressAPI               = (*ec2.Client)(nil)
 	_ AssociateAddressAPI              = (*ec2.Client)(nil)
 	_ ReleaseAddressAPI                = (*ec2.Client)(nil)
 	_ DescribeAddressesAPI             = (*ec2.Client)(nil)
 	_ DisassociateAddressAPI           = (*ec2.Client)(nil)
+	_ DescribeAccountAttributesAPI     = (*ec2.Client)(nil)
 	_ CreateSecurityGroupAPI           = (*ec2.Client)(nil)
 	_ AuthorizeSecurityGroupIngressAPI = (*ec2.Client)(nil)
+	_ AuthorizeSecurityGroupEgressAPI  = (*ec2.Client)(nil)
 	_ DescribeSecurityGroupsAPI        = (*ec2.Client)(nil)
 	_ CreateTagsAPI                    = (*ec2.Client)(nil)
 	_ DescribeSubnetsAPI               = (*ec2.Client)(nil)
 	_ DescribeVpcsAPI                  = (*ec2.Client)(nil)
+
+	_ DescribeInstanceConnectEndpointsAPI = (*ec2.Client)(nil)
+	_ CreateInstanceConnectEndpointAPI    = (*ec2.Client)(nil)
+
+	_ GetSerialConsoleAccessStatusAPI = (*ec2.Client)(nil)
+	_ EnableSerialConsoleAccessAPI    = (*ec2.Client)(nil)
+	_ GetConsoleOutputAPI             = (*ec2.Client)(nil)
 )
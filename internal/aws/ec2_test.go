@@ -2,9 +2,15 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
@@ -110,3 +116,158 @@ func searchSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// countingDescribeInstanceTypes serves a paginated catalog and counts calls.
+type countingDescribeInstanceTypes struct {
+	pages [][]types.InstanceTypeInfo
+	calls int
+}
+
+func (m *countingDescribeInstanceTypes) DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	m.calls++
+	page := 0
+	if params.NextToken != nil {
+		fmt.Sscanf(*params.NextToken, "%d", &page)
+	}
+	out := &ec2.DescribeInstanceTypesOutput{InstanceTypes: m.pages[page]}
+	if page+1 < len(m.pages) {
+		out.NextToken = aws.String(fmt.Sprintf("%d", page+1))
+	}
+	return out, nil
+}
+
+func typeInfo(name string, current bool) types.InstanceTypeInfo {
+	return types.InstanceTypeInfo{InstanceType: types.InstanceType(name), CurrentGeneration: aws.Bool(current)}
+}
+
+func testCatalog() *countingDescribeInstanceTypes {
+	return &countingDescribeInstanceTypes{pages: [][]types.InstanceTypeInfo{
+		{typeInfo("m6i.large", true), typeInfo("m6i.xlarge", true), typeInfo("m6i.2xlarge", true)},
+		{typeInfo("m4.xlarge", false), typeInfo("m4.10xlarge", false), typeInfo("c4.large", false)},
+		{typeInfo("c6i.large", true), typeInfo("z1d.large", false), typeInfo("t3.micro", true)},
+	}}
+}
+
+func TestInstanceTypeCheckSuggestsTypo(t *testing.T) {
+	tests := []struct {
+		typo string
+		want string
+	}{
+		{"m6i.xlrage", "did you mean m6i.xlarge?"},
+		{"m6l.xlarge", "did you mean m6i.xlarge?"},
+		{"c6i.lrge", "did you mean c6i.large?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typo, func(t *testing.T) {
+			_, err := NewInstanceTypeValidator(testCatalog()).Check(context.Background(), tt.typo, "us-east-1")
+			if err == nil {
+				t.Fatal("expected error for typo")
+			}
+			want := "unknown instance type " + tt.typo + " in us-east-1 — " + tt.want
+			if err.Error() != want {
+				t.Errorf("error = %q, want %q", err.Error(), want)
+			}
+			var unknown *UnknownInstanceTypeError
+			if !errors.As(err, &unknown) {
+				t.Errorf("error should be *UnknownInstanceTypeError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestInstanceTypeCheckNoSuggestionWhenNothingClose(t *testing.T) {
+	_, err := NewInstanceTypeValidator(testCatalog()).Check(context.Background(), "q9zz.gigantic", "us-east-1")
+	if err == nil || err.Error() != `instance type "q9zz.gigantic" is not available in us-east-1` {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestInstanceTypeCheckPreviousGeneration(t *testing.T) {
+	tests := []struct {
+		instType string
+		want     string
+	}{
+		{"m4.xlarge", "instance type m4.xlarge is previous-generation — consider m6i.xlarge"},
+		{"m4.10xlarge", "instance type m4.10xlarge is previous-generation — consider the m6i family"},
+		{"c4.large", "instance type c4.large is previous-generation — consider c6i.large"},
+		{"z1d.large", "instance type z1d.large is previous-generation"},
+		{"m6i.xlarge", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instType, func(t *testing.T) {
+			warning, err := NewInstanceTypeValidator(testCatalog()).Check(context.Background(), tt.instType, "us-east-1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if warning != tt.want {
+				t.Errorf("warning = %q, want %q", warning, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceTypeCheckMissingGenerationFlagIsCurrent(t *testing.T) {
+	client := &mockDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: []types.InstanceTypeInfo{{InstanceType: types.InstanceTypeM6iXlarge}},
+	}}
+	warning, err := NewInstanceTypeValidator(client).Check(context.Background(), "m6i.xlarge", "us-east-1")
+	if err != nil || warning != "" {
+		t.Errorf("Check = %q, %v; want no warning", warning, err)
+	}
+}
+
+func TestInstanceTypeCatalogIsCached(t *testing.T) {
+	ctx := context.Background()
+	client := testCatalog()
+	dir := t.TempDir()
+
+	v := NewInstanceTypeValidator(client).WithCacheDir(dir)
+	if err := v.Validate(ctx, "m6i.xlarge", "us-east-1"); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls != 3 {
+		t.Fatalf("first check made %d calls, want 3 (one per page)", client.calls)
+	}
+	if err := v.Validate(ctx, "c6i.large", "us-east-1"); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls != 3 {
+		t.Errorf("repeated check in one process re-listed the catalog (%d calls)", client.calls)
+	}
+
+	// A new validator — a later mint command — reads the disk cache.
+	if err := NewInstanceTypeValidator(client).WithCacheDir(dir).Validate(ctx, "t3.micro", "us-east-1"); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls != 3 {
+		t.Errorf("later command re-listed the catalog despite the disk cache (%d calls)", client.calls)
+	}
+
+	// Another region has its own catalog.
+	if err := NewInstanceTypeValidator(client).WithCacheDir(dir).Validate(ctx, "t3.micro", "eu-west-1"); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls != 6 {
+		t.Errorf("new region made %d total calls, want 6", client.calls)
+	}
+}
+
+func TestInstanceTypeCatalogCacheExpires(t *testing.T) {
+	dir := t.TempDir()
+	stale := instanceTypeCacheFile{
+		FetchedAt: time.Now().Add(-2 * instanceTypeCacheTTL),
+		Types:     instanceTypeCatalog{"m6i.xlarge": false},
+	}
+	data, _ := json.Marshal(stale)
+	if err := os.WriteFile(filepath.Join(dir, "instance-types-us-east-1.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := testCatalog()
+	if err := NewInstanceTypeValidator(client).WithCacheDir(dir).Validate(context.Background(), "m6i.xlarge", "us-east-1"); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls == 0 {
+		t.Error("stale cache was used instead of re-listing the catalog")
+	}
+}
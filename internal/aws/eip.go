@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+)
+
+// WaitEIPAssociatedAPI waits until an Elastic IP association is visible to
+// the rest of EC2. Wait returns how long it waited. Implemented by
+// EIPAssociatedWaiter.
+type WaitEIPAssociatedAPI interface {
+	Wait(ctx context.Context, allocationID, instanceID string, maxWaitDur time.Duration) (time.Duration, error)
+}
+
+// Compile-time check: EIPAssociatedWaiter satisfies the interface.
+var _ WaitEIPAssociatedAPI = (*EIPAssociatedWaiter)(nil)
+
+// defaultEIPPollInterval is how often EIPAssociatedWaiter polls.
+const defaultEIPPollInterval = 2 * time.Second
+
+// EIPAssociatedWaiter polls until an AssociateAddress call has propagated:
+// first DescribeAddresses shows the address associated with the instance,
+// then DescribeInstances reports it as the instance's public IP. Until both
+// agree, an SSH connection to the address can still reach the previous
+// instance, or nothing at all.
+type EIPAssociatedWaiter struct {
+	addrs     DescribeAddressesAPI
+	instances DescribeInstancesAPI
+	interval  time.Duration
+}
+
+// NewEIPAssociatedWaiter returns a waiter polling addrs and instances every
+// two seconds.
+func NewEIPAssociatedWaiter(addrs DescribeAddressesAPI, instances DescribeInstancesAPI) *EIPAssociatedWaiter {
+	return &EIPAssociatedWaiter{addrs: addrs, instances: instances, interval: defaultEIPPollInterval}
+}
+
+// WithInterval sets the poll interval (for testing).
+func (w *EIPAssociatedWaiter) WithInterval(d time.Duration) *EIPAssociatedWaiter {
+	w.interval = d
+	return w
+}
+
+// EIPPropagationError reports an Elastic IP association that did not take
+// effect within the wait. It is an AWS-side failure: SSH was never tried.
+type EIPPropagationError struct {
+	AllocationID string
+	InstanceID   string
+	Waited       time.Duration
+	// Reason describes the last state seen, such as "DescribeAddresses
+	// shows it associated with i-0old".
+	Reason string
+}
+
+func (e *EIPPropagationError) Error() string {
+	return fmt.Sprintf("Elastic IP %s did not reach instance %s within %s (%s) — "+
+		"the association did not take effect in AWS; this is not an SSH problem. "+
+		"Check the address in the EC2 console, then run the command again",
+		e.AllocationID, e.InstanceID, format.FormatDuration(e.Waited), e.Reason)
+}
+
+// Wait polls until allocationID is associated with instanceID and the
+// instance reports the address as its public IP, or maxWaitDur passes. API
+// errors are retried until then. It returns how long it waited, and an
+// *EIPPropagationError on timeout or ctx's error when ctx is done.
+func (w *EIPAssociatedWaiter) Wait(ctx context.Context, allocationID, instanceID string, maxWaitDur time.Duration) (time.Duration, error) {
+	start := time.Now()
+	timeout := time.After(maxWaitDur)
+	var publicIP string
+	reason := "no answer from DescribeAddresses"
+	for {
+		done, why := w.check(ctx, allocationID, instanceID, &publicIP)
+		if done {
+			return time.Since(start), nil
+		}
+		reason = why
+
+		select {
+		case <-ctx.Done():
+			return time.Since(start), ctx.Err()
+		case <-timeout:
+			return time.Since(start), &EIPPropagationError{
+				AllocationID: allocationID,
+				InstanceID:   instanceID,
+				Waited:       time.Since(start),
+				Reason:       reason,
+			}
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// check runs one poll. publicIP holds the address once DescribeAddresses
+// has shown the association, so later polls only check the instance.
+func (w *EIPAssociatedWaiter) check(ctx context.Context, allocationID, instanceID string, publicIP *string) (done bool, reason string) {
+	if *publicIP == "" {
+		out, err := w.addrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+			AllocationIds: []string{allocationID},
+		})
+		switch {
+		case err != nil:
+			return false, fmt.Sprintf("DescribeAddresses failed: %v", err)
+		case len(out.Addresses) == 0:
+			return false, "DescribeAddresses does not list it"
+		}
+		addr := out.Addresses[0]
+		switch associated := aws.ToString(addr.InstanceId); associated {
+		case instanceID:
+			*publicIP = aws.ToString(addr.PublicIp)
+		case "":
+			return false, "DescribeAddresses shows it associated with no instance"
+		default:
+			return false, fmt.Sprintf("DescribeAddresses shows it associated with %s", associated)
+		}
+	}
+
+	out, err := w.instances.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return false, fmt.Sprintf("DescribeInstances failed: %v", err)
+	}
+	for _, r := range out.Reservations {
+		for _, inst := range r.Instances {
+			if got := aws.ToString(inst.PublicIpAddress); got == *publicIP {
+				return true, ""
+			} else if got != "" {
+				return false, fmt.Sprintf("the instance still reports public IP %s, not %s", got, *publicIP)
+			}
+		}
+	}
+	return false, fmt.Sprintf("the instance does not report public IP %s yet", *publicIP)
+}
+
+// IsEIPPropagationError reports whether err is an *EIPPropagationError.
+func IsEIPPropagationError(err error) bool {
+	var perr *EIPPropagationError
+	return errors.As(err, &perr)
+}
@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// seqDescribeAddresses answers each DescribeAddresses call with the next
+// associated instance ID in seq, repeating the last one.
+type seqDescribeAddresses struct {
+	seq   []string
+	calls int
+}
+
+func (m *seqDescribeAddresses) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	id := m.seq[min(m.calls, len(m.seq)-1)]
+	m.calls++
+	return &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{{
+		AllocationId: aws.String(params.AllocationIds[0]),
+		PublicIp:     aws.String("54.1.2.3"),
+		InstanceId:   aws.String(id),
+	}}}, nil
+}
+
+// seqDescribeInstances answers each DescribeInstances call with the next
+// public IP in seq, repeating the last one.
+type seqDescribeInstances struct {
+	seq   []string
+	calls int
+}
+
+func (m *seqDescribeInstances) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	ip := m.seq[min(m.calls, len(m.seq)-1)]
+	m.calls++
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{
+		Instances: []ec2types.Instance{{InstanceId: aws.String(params.InstanceIds[0]), PublicIpAddress: aws.String(ip)}},
+	}}}, nil
+}
+
+func TestEIPAssociatedWaiterImmediate(t *testing.T) {
+	addrs := &seqDescribeAddresses{seq: []string{"i-new"}}
+	instances := &seqDescribeInstances{seq: []string{"54.1.2.3"}}
+	w := NewEIPAssociatedWaiter(addrs, instances).WithInterval(time.Millisecond)
+
+	if _, err := w.Wait(context.Background(), "eipalloc-1", "i-new", time.Second); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if addrs.calls != 1 || instances.calls != 1 {
+		t.Errorf("calls = %d DescribeAddresses, %d DescribeInstances, want 1 each", addrs.calls, instances.calls)
+	}
+}
+
+func TestEIPAssociatedWaiterSlowPropagation(t *testing.T) {
+	// The address still points at the old instance twice, then the new
+	// instance keeps its auto-assigned IP for two more polls.
+	addrs := &seqDescribeAddresses{seq: []string{"i-old", "", "i-new"}}
+	instances := &seqDescribeInstances{seq: []string{"3.3.3.3", "3.3.3.3", "54.1.2.3"}}
+	w := NewEIPAssociatedWaiter(addrs, instances).WithInterval(time.Millisecond)
+
+	if _, err := w.Wait(context.Background(), "eipalloc-1", "i-new", time.Second); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if addrs.calls != 3 {
+		t.Errorf("DescribeAddresses calls = %d, want 3 (not polled once associated)", addrs.calls)
+	}
+	if instances.calls != 3 {
+		t.Errorf("DescribeInstances calls = %d, want 3", instances.calls)
+	}
+}
+
+func TestEIPAssociatedWaiterBrokenAssociationTimesOut(t *testing.T) {
+	addrs := &seqDescribeAddresses{seq: []string{"i-old"}}
+	instances := &seqDescribeInstances{seq: []string{"54.1.2.3"}}
+	w := NewEIPAssociatedWaiter(addrs, instances).WithInterval(time.Millisecond)
+
+	_, err := w.Wait(context.Background(), "eipalloc-1", "i-new", 20*time.Millisecond)
+	if !IsEIPPropagationError(err) {
+		t.Fatalf("err = %v, want an *EIPPropagationError", err)
+	}
+	for _, want := range []string{"eipalloc-1", "i-new", "associated with i-old", "not an SSH problem"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if instances.calls != 0 {
+		t.Errorf("DescribeInstances called %d times before the address was associated", instances.calls)
+	}
+}
+
+func TestEIPAssociatedWaiterRetriesAPIErrors(t *testing.T) {
+	addrs := &mockDescribeAddresses{err: errors.New("throttled")}
+	w := NewEIPAssociatedWaiter(addrs, &seqDescribeInstances{seq: []string{""}}).WithInterval(time.Millisecond)
+
+	_, err := w.Wait(context.Background(), "eipalloc-1", "i-new", 10*time.Millisecond)
+	if !IsEIPPropagationError(err) || !strings.Contains(err.Error(), "throttled") {
+		t.Errorf("err = %v, want a timeout naming the last API error", err)
+	}
+}
+
+func TestEIPAssociatedWaiterContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := NewEIPAssociatedWaiter(&seqDescribeAddresses{seq: []string{"i-old"}}, &seqDescribeInstances{seq: []string{""}})
+
+	_, err := w.Wait(ctx, "eipalloc-1", "i-new", time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,52 @@
+// Package aws provides thin wrappers around AWS SDK clients used by Mint.
+// This file detects NVIDIA GPU instance types and checks that the AMI a GPU
+// instance would launch from can take the NVIDIA driver.
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// gpuFamilies are the x86_64 instance families with NVIDIA GPUs that mint
+// installs the driver for at bootstrap.
+var gpuFamilies = map[string]bool{
+	"g4dn": true, "g5": true,
+	"p3": true, "p3dn": true,
+	"p4d": true, "p4de": true,
+	"p5": true, "p5e": true, "p5en": true,
+}
+
+// IsGPUInstanceType reports whether instanceType belongs to an NVIDIA GPU
+// family mint supports ("g5.xlarge", "p4d.24xlarge", ...).
+func IsGPUInstanceType(instanceType string) bool {
+	return gpuFamilies[instanceFamily(instanceType)]
+}
+
+// CheckGPUImage returns an error unless amiID is an x86_64 Ubuntu image, the
+// only kind bootstrap can install the NVIDIA driver on. It runs before a GPU
+// instance is launched, so a resolver returning some other image fails
+// before anything is created.
+func CheckGPUImage(ctx context.Context, client DescribeImagesAPI, amiID, instanceType string) error {
+	out, err := client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		ImageIds: []string{amiID},
+	})
+	if err != nil {
+		return fmt.Errorf("describe image %s: %w", amiID, err)
+	}
+	if len(out.Images) == 0 {
+		return fmt.Errorf("GPU instance type %s: AMI %s not found", instanceType, amiID)
+	}
+	img := out.Images[0]
+	name := aws.ToString(img.Name)
+	if img.Architecture != ec2types.ArchitectureValuesX8664 || !strings.Contains(name, "ubuntu") {
+		return fmt.Errorf("GPU instance type %s needs an x86_64 Ubuntu AMI for the NVIDIA driver, but AMI %s is %s %q",
+			instanceType, amiID, img.Architecture, name)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestIsGPUInstanceType(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		want         bool
+	}{
+		{"g4dn.xlarge", true},
+		{"g5.2xlarge", true},
+		{"p3.2xlarge", true},
+		{"p4d.24xlarge", true},
+		{"p5.48xlarge", true},
+		{"m6i.xlarge", false},
+		{"t3.medium", false},
+		{"g5g.xlarge", false},  // Graviton: arm64, no x86_64 driver
+		{"g4ad.xlarge", false}, // AMD GPU
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instanceType, func(t *testing.T) {
+			if got := IsGPUInstanceType(tt.instanceType); got != tt.want {
+				t.Errorf("IsGPUInstanceType(%q) = %v, want %v", tt.instanceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckGPUImage(t *testing.T) {
+	image := func(arch ec2types.ArchitectureValues, name string) *mockDescribeImages {
+		return &mockDescribeImages{output: &ec2.DescribeImagesOutput{Images: []ec2types.Image{
+			{ImageId: aws.String("ami-1"), Architecture: arch, Name: aws.String(name)},
+		}}}
+	}
+	tests := []struct {
+		name    string
+		client  DescribeImagesAPI
+		wantErr string
+	}{
+		{
+			name:   "ubuntu x86_64",
+			client: image(ec2types.ArchitectureValuesX8664, "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-20260218"),
+		},
+		{
+			name:    "arm64",
+			client:  image(ec2types.ArchitectureValuesArm64, "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-arm64-server-20260218"),
+			wantErr: "needs an x86_64 Ubuntu AMI",
+		},
+		{
+			name:    "not ubuntu",
+			client:  image(ec2types.ArchitectureValuesX8664, "al2023-ami-2023.6-x86_64"),
+			wantErr: "needs an x86_64 Ubuntu AMI",
+		},
+		{
+			name:    "not found",
+			client:  &mockDescribeImages{output: &ec2.DescribeImagesOutput{}},
+			wantErr: "AMI ami-1 not found",
+		},
+		{
+			name:    "API error",
+			client:  &mockDescribeImages{err: errors.New("access denied")},
+			wantErr: "access denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckGPUImage(context.Background(), tt.client, "ami-1", "g5.xlarge")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package aws provides thin wrappers around AWS SDK clients used by Mint.
+// This file checks that instance types can hibernate and reports the memory
+// a hibernation-enabled root volume must hold.
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// UnsupportedHibernationError is returned when hibernate is set for
+// instance types that cannot hibernate.
+type UnsupportedHibernationError struct {
+	InstanceTypes []string
+}
+
+func (e *UnsupportedHibernationError) Error() string {
+	return fmt.Sprintf("hibernation is not supported by instance type(s) %s; set hibernate = false or choose a type that supports it",
+		strings.Join(e.InstanceTypes, ", "))
+}
+
+// HibernationMemory returns the memory, in MiB, of each of instanceTypes,
+// all of which must support hibernation. Types that do not, or that EC2
+// does not know, are listed in an *UnsupportedHibernationError.
+func HibernationMemory(ctx context.Context, client DescribeInstanceTypesAPI, instanceTypes ...string) (map[string]int64, error) {
+	input := &ec2.DescribeInstanceTypesInput{}
+	for _, t := range instanceTypes {
+		input.InstanceTypes = append(input.InstanceTypes, ec2types.InstanceType(t))
+	}
+	out, err := client.DescribeInstanceTypes(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("ec2 describe-instance-types: %w", err)
+	}
+
+	memory := make(map[string]int64, len(out.InstanceTypes))
+	for _, info := range out.InstanceTypes {
+		if !aws.ToBool(info.HibernationSupported) || info.MemoryInfo == nil {
+			continue
+		}
+		memory[string(info.InstanceType)] = aws.ToInt64(info.MemoryInfo.SizeInMiB)
+	}
+
+	var unsupported []string
+	for _, t := range instanceTypes {
+		if _, ok := memory[t]; !ok {
+			unsupported = append(unsupported, t)
+		}
+	}
+	if len(unsupported) > 0 {
+		return nil, &UnsupportedHibernationError{InstanceTypes: unsupported}
+	}
+	return memory, nil
+}
@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestHibernationMemory(t *testing.T) {
+	info := func(instanceType string, supported bool, mib int64) ec2types.InstanceTypeInfo {
+		return ec2types.InstanceTypeInfo{
+			InstanceType:         ec2types.InstanceType(instanceType),
+			HibernationSupported: aws.Bool(supported),
+			MemoryInfo:           &ec2types.MemoryInfo{SizeInMiB: aws.Int64(mib)},
+		}
+	}
+	tests := []struct {
+		name          string
+		client        DescribeInstanceTypesAPI
+		instanceTypes []string
+		want          map[string]int64
+		wantErr       string
+		wantTypes     []string
+	}{
+		{
+			name: "supported",
+			client: &mockDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: []ec2types.InstanceTypeInfo{info("m6i.xlarge", true, 16384)},
+			}},
+			instanceTypes: []string{"m6i.xlarge"},
+			want:          map[string]int64{"m6i.xlarge": 16384},
+		},
+		{
+			name: "unsupported types are listed",
+			client: &mockDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+				InstanceTypes: []ec2types.InstanceTypeInfo{
+					info("m6i.xlarge", true, 16384),
+					info("u-6tb1.metal", false, 6291456),
+				},
+			}},
+			instanceTypes: []string{"m6i.xlarge", "u-6tb1.metal", "x9.nope"},
+			wantErr:       "hibernation is not supported by instance type(s) u-6tb1.metal, x9.nope",
+			wantTypes:     []string{"u-6tb1.metal", "x9.nope"},
+		},
+		{
+			name:          "API error",
+			client:        &mockDescribeInstanceTypes{err: errors.New("access denied")},
+			instanceTypes: []string{"m6i.xlarge"},
+			wantErr:       "access denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HibernationMemory(context.Background(), tt.client, tt.instanceTypes...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				var unsupported *UnsupportedHibernationError
+				if tt.wantTypes != nil {
+					if !errors.As(err, &unsupported) {
+						t.Fatalf("error = %T, want *UnsupportedHibernationError", err)
+					}
+					if !reflect.DeepEqual(unsupported.InstanceTypes, tt.wantTypes) {
+						t.Errorf("InstanceTypes = %v, want %v", unsupported.InstanceTypes, tt.wantTypes)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("memory = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
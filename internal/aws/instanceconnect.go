@@ -16,5 +16,15 @@ type SendSSHPublicKeyAPI interface {
 	SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error)
 }
 
-// Compile-time interface satisfaction check.
-var _ SendSSHPublicKeyAPI = (*ec2instanceconnect.Client)(nil)
+// SendSerialConsoleSSHPublicKeyAPI defines the subset of the EC2 Instance
+// Connect API used for pushing an ephemeral SSH public key to an instance's
+// serial console.
+type SendSerialConsoleSSHPublicKeyAPI interface {
+	SendSerialConsoleSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSerialConsoleSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSerialConsoleSSHPublicKeyOutput, error)
+}
+
+// Compile-time interface satisfaction checks.
+var (
+	_ SendSSHPublicKeyAPI              = (*ec2instanceconnect.Client)(nil)
+	_ SendSerialConsoleSSHPublicKeyAPI = (*ec2instanceconnect.Client)(nil)
+)
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// IPv6NetworkInterface returns the primary network interface for an
+// instance launched with one IPv6 address, in subnetID with groups. The
+// subnet's auto-assigned public IPv4 address is suppressed unless
+// publicIPv4 is set. RunInstances rejects SubnetId and SecurityGroupIds
+// alongside NetworkInterfaces, so callers must leave those unset.
+func IPv6NetworkInterface(subnetID string, groups []string, publicIPv4 bool) ec2types.InstanceNetworkInterfaceSpecification {
+	nic := ec2types.InstanceNetworkInterfaceSpecification{
+		DeviceIndex:      aws.Int32(0),
+		SubnetId:         aws.String(subnetID),
+		Groups:           groups,
+		Ipv6AddressCount: aws.Int32(1),
+	}
+	if !publicIPv4 {
+		nic.AssociatePublicIpAddress = aws.Bool(false)
+	}
+	return nic
+}
+
+// InstanceIPv6Address returns the first IPv6 address of inst, or "" when
+// it has none.
+func InstanceIPv6Address(inst ec2types.Instance) string {
+	if addr := aws.ToString(inst.Ipv6Address); addr != "" {
+		return addr
+	}
+	for _, nic := range inst.NetworkInterfaces {
+		for _, addr := range nic.Ipv6Addresses {
+			if ip := aws.ToString(addr.Ipv6Address); ip != "" {
+				return ip
+			}
+		}
+	}
+	return ""
+}
+
+// SubnetHasIPv6 reports whether subnet has an associated IPv6 CIDR block.
+func SubnetHasIPv6(subnet ec2types.Subnet) bool {
+	for _, assoc := range subnet.Ipv6CidrBlockAssociationSet {
+		if assoc.Ipv6CidrBlockState != nil &&
+			assoc.Ipv6CidrBlockState.State == ec2types.SubnetCidrBlockStateCodeAssociated {
+			return true
+		}
+	}
+	return false
+}
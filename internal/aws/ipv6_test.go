@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestIPv6NetworkInterface(t *testing.T) {
+	nic := IPv6NetworkInterface("subnet-1", []string{"sg-1", "sg-2"}, false)
+	if aws.ToInt32(nic.DeviceIndex) != 0 || aws.ToString(nic.SubnetId) != "subnet-1" || len(nic.Groups) != 2 {
+		t.Errorf("nic = %+v", nic)
+	}
+	if aws.ToInt32(nic.Ipv6AddressCount) != 1 {
+		t.Errorf("Ipv6AddressCount = %d, want 1", aws.ToInt32(nic.Ipv6AddressCount))
+	}
+	if nic.AssociatePublicIpAddress == nil || *nic.AssociatePublicIpAddress {
+		t.Errorf("AssociatePublicIpAddress = %v, want false", nic.AssociatePublicIpAddress)
+	}
+
+	nic = IPv6NetworkInterface("subnet-1", nil, true)
+	if nic.AssociatePublicIpAddress != nil {
+		t.Errorf("AssociatePublicIpAddress = %v, want the subnet default", *nic.AssociatePublicIpAddress)
+	}
+}
+
+func TestInstanceIPv6Address(t *testing.T) {
+	tests := []struct {
+		name string
+		inst ec2types.Instance
+		want string
+	}{
+		{"top level", ec2types.Instance{Ipv6Address: aws.String("2600:1f14::1")}, "2600:1f14::1"},
+		{"network interface", ec2types.Instance{NetworkInterfaces: []ec2types.InstanceNetworkInterface{{
+			Ipv6Addresses: []ec2types.InstanceIpv6Address{{Ipv6Address: aws.String("2600:1f14::2")}},
+		}}}, "2600:1f14::2"},
+		{"none", ec2types.Instance{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InstanceIPv6Address(tt.inst); got != tt.want {
+				t.Errorf("InstanceIPv6Address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubnetHasIPv6(t *testing.T) {
+	withState := func(state ec2types.SubnetCidrBlockStateCode) ec2types.Subnet {
+		return ec2types.Subnet{Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{{
+			Ipv6CidrBlock:      aws.String("2600:1f14::/64"),
+			Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: state},
+		}}}
+	}
+	if !SubnetHasIPv6(withState(ec2types.SubnetCidrBlockStateCodeAssociated)) {
+		t.Error("associated block not detected")
+	}
+	if SubnetHasIPv6(withState(ec2types.SubnetCidrBlockStateCodeDisassociated)) {
+		t.Error("disassociated block counted")
+	}
+	if SubnetHasIPv6(ec2types.Subnet{}) {
+		t.Error("subnet without IPv6 counted")
+	}
+}
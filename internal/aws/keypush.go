@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/aws/smithy-go"
+)
+
+// keyPushTTL is how long a pushed key is treated as still authorized.
+// Instance Connect keeps a key for 60 seconds; the margin covers the SSH
+// handshake that follows the push.
+const keyPushTTL = 50 * time.Second
+
+// keyPushBackoff is the wait before each retry of a throttled push.
+var keyPushBackoff = []time.Duration{2 * time.Second, 5 * time.Second}
+
+// keyPushKey identifies one authorized key on one instance.
+type keyPushKey struct {
+	instanceID string
+	osUser     string
+	publicKey  string
+}
+
+// KeyPushCoordinator wraps SendSSHPublicKeyAPI to keep a single command from
+// tripping the account-level Instance Connect rate limit. It is shared by
+// every command in the process and:
+//
+//   - skips a push when the same key was pushed to the same instance and OS
+//     user within the last 50 seconds. A different key is always pushed,
+//     because Instance Connect only authorizes the key it was sent;
+//   - serializes pushes to the same instance, so concurrent callers with
+//     the same key make one API call;
+//   - retries a ThrottlingException twice with backoff before failing with
+//     an error that names the Instance Connect quota.
+type KeyPushCoordinator struct {
+	client  SendSSHPublicKeyAPI
+	ttl     time.Duration
+	backoff []time.Duration
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	instances map[string]*sync.Mutex
+	pushed    map[keyPushKey]time.Time
+}
+
+// NewKeyPushCoordinator returns a coordinator that pushes keys with client.
+func NewKeyPushCoordinator(client SendSSHPublicKeyAPI) *KeyPushCoordinator {
+	return &KeyPushCoordinator{
+		client:    client,
+		ttl:       keyPushTTL,
+		backoff:   keyPushBackoff,
+		now:       time.Now,
+		sleep:     sleepContext,
+		instances: make(map[string]*sync.Mutex),
+		pushed:    make(map[keyPushKey]time.Time),
+	}
+}
+
+// WithClock overrides the time source and the backoff sleep (for testing).
+func (c *KeyPushCoordinator) WithClock(now func() time.Time, sleep func(ctx context.Context, d time.Duration) error) *KeyPushCoordinator {
+	c.now = now
+	c.sleep = sleep
+	return c
+}
+
+// SendSSHPublicKey pushes params.SSHPublicKey unless it is already
+// authorized on the instance. A skipped push returns a successful output.
+func (c *KeyPushCoordinator) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
+	key := keyPushKey{
+		instanceID: aws.ToString(params.InstanceId),
+		osUser:     aws.ToString(params.InstanceOSUser),
+		publicKey:  aws.ToString(params.SSHPublicKey),
+	}
+
+	lock := c.instanceLock(key.instanceID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if c.recentlyPushed(key) {
+		return &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		out, err := c.client.SendSSHPublicKey(ctx, params, optFns...)
+		if err == nil {
+			c.mu.Lock()
+			c.pushed[key] = c.now()
+			c.mu.Unlock()
+			return out, nil
+		}
+		if !isThrottling(err) {
+			return nil, err
+		}
+		if attempt >= len(c.backoff) {
+			return nil, fmt.Errorf("throttled after %d attempts — the Instance Connect SendSSHPublicKey rate "+
+				"quota is shared by everyone in the AWS account; wait a minute and retry: %w", attempt+1, err)
+		}
+		if sleepErr := c.sleep(ctx, c.backoff[attempt]); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
+
+// instanceLock returns the mutex that serializes pushes to instanceID.
+func (c *KeyPushCoordinator) instanceLock(instanceID string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lock, ok := c.instances[instanceID]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.instances[instanceID] = lock
+	}
+	return lock
+}
+
+// recentlyPushed reports whether key was pushed within the TTL.
+func (c *KeyPushCoordinator) recentlyPushed(key keyPushKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.pushed[key]
+	return ok && c.now().Sub(at) < c.ttl
+}
+
+// isThrottling reports whether err is an Instance Connect throttling error.
+func isThrottling(err error) bool {
+	var ae smithy.APIError
+	return errors.As(err, &ae) && ae.ErrorCode() == "ThrottlingException"
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+var _ SendSSHPublicKeyAPI = (*KeyPushCoordinator)(nil)
@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2instanceconnect"
+	"github.com/aws/smithy-go"
+)
+
+// countingSendKey counts pushes and returns errs in order, then success.
+type countingSendKey struct {
+	calls atomic.Int32
+	errs  []error
+	delay time.Duration
+}
+
+func (m *countingSendKey) SendSSHPublicKey(ctx context.Context, params *ec2instanceconnect.SendSSHPublicKeyInput, optFns ...func(*ec2instanceconnect.Options)) (*ec2instanceconnect.SendSSHPublicKeyOutput, error) {
+	n := int(m.calls.Add(1))
+	time.Sleep(m.delay)
+	if n <= len(m.errs) {
+		return nil, m.errs[n-1]
+	}
+	return &ec2instanceconnect.SendSSHPublicKeyOutput{Success: true}, nil
+}
+
+func keyInput(instanceID, pubKey string) *ec2instanceconnect.SendSSHPublicKeyInput {
+	return &ec2instanceconnect.SendSSHPublicKeyInput{
+		InstanceId:     aws.String(instanceID),
+		InstanceOSUser: aws.String("ubuntu"),
+		SSHPublicKey:   aws.String(pubKey),
+	}
+}
+
+// fakeClock is a settable time source whose sleep records durations.
+type fakeClock struct {
+	mu     sync.Mutex
+	t      time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func (c *fakeClock) sleep(_ context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	return nil
+}
+
+func TestKeyPushCoordinatorMemoizesWithinWindow(t *testing.T) {
+	client := &countingSendKey{}
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	c := NewKeyPushCoordinator(client).WithClock(clock.now, clock.sleep)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		out, err := c.SendSSHPublicKey(ctx, keyInput("i-1", "key-a"))
+		if err != nil || !out.Success {
+			t.Fatalf("push %d: out=%v err=%v", i, out, err)
+		}
+		clock.advance(10 * time.Second)
+	}
+	if got := client.calls.Load(); got != 1 {
+		t.Errorf("calls within window = %d, want 1", got)
+	}
+
+	// A different key or instance is always pushed.
+	c.SendSSHPublicKey(ctx, keyInput("i-1", "key-b"))
+	c.SendSSHPublicKey(ctx, keyInput("i-2", "key-a"))
+	if got := client.calls.Load(); got != 3 {
+		t.Errorf("calls after new key and instance = %d, want 3", got)
+	}
+
+	// After the window the original key is pushed again.
+	clock.advance(50 * time.Second)
+	c.SendSSHPublicKey(ctx, keyInput("i-1", "key-a"))
+	if got := client.calls.Load(); got != 4 {
+		t.Errorf("calls after window = %d, want 4", got)
+	}
+}
+
+func TestKeyPushCoordinatorSingleflight(t *testing.T) {
+	client := &countingSendKey{delay: 20 * time.Millisecond}
+	c := NewKeyPushCoordinator(client)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.SendSSHPublicKey(context.Background(), keyInput("i-1", "key-a"))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got := client.calls.Load(); got != 1 {
+		t.Errorf("concurrent pushes of the same key made %d calls, want 1", got)
+	}
+}
+
+func TestKeyPushCoordinatorThrottleRetry(t *testing.T) {
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int32
+		wantErr   string
+	}{
+		{name: "succeeds after two throttles", errs: []error{throttled, throttled}, wantCalls: 3},
+		{name: "fails after three throttles", errs: []error{throttled, throttled, throttled}, wantCalls: 3, wantErr: "Instance Connect SendSSHPublicKey rate quota"},
+		{name: "other errors are not retried", errs: []error{errors.New("instance not found")}, wantCalls: 1, wantErr: "instance not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &countingSendKey{errs: tt.errs}
+			clock := &fakeClock{t: time.Unix(1000, 0)}
+			c := NewKeyPushCoordinator(client).WithClock(clock.now, clock.sleep)
+
+			_, err := c.SendSSHPublicKey(context.Background(), keyInput("i-1", "key-a"))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+			if got := client.calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantCalls == 3 && len(clock.sleeps) != 2 {
+				t.Errorf("backoff sleeps = %v, want 2", clock.sleeps)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// DescribeSubnet returns the subnet subnetID, checking that it exists and,
+// when vpcID is set, that it is in that VPC.
+func DescribeSubnet(ctx context.Context, client DescribeSubnetsAPI, subnetID, vpcID string) (ec2types.Subnet, error) {
+	out, err := client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		SubnetIds: []string{subnetID},
+	})
+	notFound := fmt.Errorf("subnet %s not found in this region", subnetID)
+	if err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "InvalidSubnetID.NotFound" {
+			return ec2types.Subnet{}, notFound
+		}
+		return ec2types.Subnet{}, fmt.Errorf("describe subnet %s: %w", subnetID, err)
+	}
+	if len(out.Subnets) == 0 {
+		return ec2types.Subnet{}, notFound
+	}
+
+	subnet := out.Subnets[0]
+	if vpc := aws.ToString(subnet.VpcId); vpcID != "" && vpc != vpcID {
+		return ec2types.Subnet{}, fmt.Errorf("subnet %s is in %s, not %s", subnetID, vpc, vpcID)
+	}
+	return subnet, nil
+}
+
+// CheckSecurityGroups checks that every group in groupIDs exists and is in
+// vpcID, the VPC of the subnet the instance launches in. The error names
+// each group that is not.
+func CheckSecurityGroups(ctx context.Context, client DescribeSecurityGroupsAPI, groupIDs []string, subnetID, vpcID string) error {
+	out, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		GroupIds: groupIDs,
+	})
+	if err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "InvalidGroup.NotFound" {
+			return fmt.Errorf("security group not found: %s", ae.ErrorMessage())
+		}
+		return fmt.Errorf("describe security groups: %w", err)
+	}
+
+	groupVPCs := make(map[string]string, len(out.SecurityGroups))
+	for _, group := range out.SecurityGroups {
+		groupVPCs[aws.ToString(group.GroupId)] = aws.ToString(group.VpcId)
+	}
+	var missing, mismatched []string
+	for _, id := range groupIDs {
+		vpc, ok := groupVPCs[id]
+		switch {
+		case !ok:
+			missing = append(missing, id)
+		case vpc != vpcID:
+			mismatched = append(mismatched, fmt.Sprintf("%s (%s)", id, vpc))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("security group not found: %s", strings.Join(missing, ", "))
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("security groups %s are not in %s, the VPC of subnet %s",
+			strings.Join(mismatched, ", "), vpcID, subnetID)
+	}
+	return nil
+}
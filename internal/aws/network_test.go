@@ -0,0 +1,116 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+type fakeNetwork struct {
+	subnets []ec2types.Subnet
+	groups  []ec2types.SecurityGroup
+	err     error
+}
+
+func (f *fakeNetwork) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out ec2.DescribeSubnetsOutput
+	for _, s := range f.subnets {
+		if aws.ToString(s.SubnetId) == params.SubnetIds[0] {
+			out.Subnets = append(out.Subnets, s)
+		}
+	}
+	return &out, nil
+}
+
+func (f *fakeNetwork) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out ec2.DescribeSecurityGroupsOutput
+	for _, g := range f.groups {
+		for _, id := range params.GroupIds {
+			if aws.ToString(g.GroupId) == id {
+				out.SecurityGroups = append(out.SecurityGroups, g)
+			}
+		}
+	}
+	return &out, nil
+}
+
+func TestDescribeSubnet(t *testing.T) {
+	f := &fakeNetwork{subnets: []ec2types.Subnet{{
+		SubnetId:         aws.String("subnet-0a1b2c3d"),
+		VpcId:            aws.String("vpc-11111111"),
+		AvailabilityZone: aws.String("us-west-2b"),
+	}}}
+
+	subnet, err := DescribeSubnet(context.Background(), f, "subnet-0a1b2c3d", "")
+	if err != nil || aws.ToString(subnet.AvailabilityZone) != "us-west-2b" {
+		t.Fatalf("DescribeSubnet = %+v, %v", subnet, err)
+	}
+	if _, err := DescribeSubnet(context.Background(), f, "subnet-0a1b2c3d", "vpc-11111111"); err != nil {
+		t.Errorf("matching vpc_id: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		client   *fakeNetwork
+		subnetID string
+		vpcID    string
+		want     string
+	}{
+		{"other VPC", f, "subnet-0a1b2c3d", "vpc-22222222", "subnet subnet-0a1b2c3d is in vpc-11111111, not vpc-22222222"},
+		{"empty result", f, "subnet-99999999", "", "subnet subnet-99999999 not found"},
+		{"not found", &fakeNetwork{err: &smithy.GenericAPIError{Code: "InvalidSubnetID.NotFound"}}, "subnet-99999999", "", "subnet subnet-99999999 not found"},
+		{"API error", &fakeNetwork{err: &smithy.GenericAPIError{Code: "Throttling"}}, "subnet-0a1b2c3d", "", "describe subnet subnet-0a1b2c3d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DescribeSubnet(context.Background(), tt.client, tt.subnetID, tt.vpcID)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSecurityGroups(t *testing.T) {
+	f := &fakeNetwork{groups: []ec2types.SecurityGroup{
+		{GroupId: aws.String("sg-aaaaaaaa"), VpcId: aws.String("vpc-11111111")},
+		{GroupId: aws.String("sg-bbbbbbbb"), VpcId: aws.String("vpc-11111111")},
+		{GroupId: aws.String("sg-cccccccc"), VpcId: aws.String("vpc-22222222")},
+	}}
+
+	if err := CheckSecurityGroups(context.Background(), f, []string{"sg-aaaaaaaa", "sg-bbbbbbbb"}, "subnet-0a1b2c3d", "vpc-11111111"); err != nil {
+		t.Errorf("groups in the subnet's VPC: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		client *fakeNetwork
+		ids    []string
+		want   string
+	}{
+		{"other VPC", f, []string{"sg-aaaaaaaa", "sg-cccccccc"},
+			"security groups sg-cccccccc (vpc-22222222) are not in vpc-11111111, the VPC of subnet subnet-0a1b2c3d"},
+		{"missing", f, []string{"sg-aaaaaaaa", "sg-dddddddd"}, "security group not found: sg-dddddddd"},
+		{"not found", &fakeNetwork{err: &smithy.GenericAPIError{Code: "InvalidGroup.NotFound", Message: "The security group 'sg-dddddddd' does not exist"}},
+			[]string{"sg-dddddddd"}, "security group not found: The security group 'sg-dddddddd' does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSecurityGroups(context.Background(), tt.client, tt.ids, "subnet-0a1b2c3d", "vpc-11111111")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// maxOfferingSuggestions is how many similar offered types an
+// InstanceTypeNotOfferedError names.
+const maxOfferingSuggestions = 3
+
+// DescribeInstanceTypeOfferingsAPI defines the subset of the EC2 API used to
+// check where an instance type can launch.
+type DescribeInstanceTypeOfferingsAPI interface {
+	DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error)
+}
+
+// InstanceTypeNotOfferedError is returned when an instance type cannot
+// launch in a region or availability zone. Suggestions are the most similar
+// types that can, closest first.
+type InstanceTypeNotOfferedError struct {
+	InstanceType string
+	Location     string
+	Suggestions  []string
+}
+
+func (e *InstanceTypeNotOfferedError) Error() string {
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf("instance type %s is not offered in %s — did you mean %s?",
+			e.InstanceType, e.Location, strings.Join(e.Suggestions, ", "))
+	}
+	return fmt.Sprintf("instance type %s is not offered in %s", e.InstanceType, e.Location)
+}
+
+// InstanceTypeOfferings checks instance types against the offerings of a
+// region or availability zone. Each location's offerings are listed once
+// and kept for the lifetime of the value, so one command checking several
+// types or subnets in the same place calls the API once. Offerings change
+// as AWS adds capacity, so they are never cached on disk.
+type InstanceTypeOfferings struct {
+	client DescribeInstanceTypeOfferingsAPI
+
+	mu        sync.Mutex
+	locations map[string]map[string]bool
+}
+
+// NewInstanceTypeOfferings creates an offerings checker with the given EC2
+// client.
+func NewInstanceTypeOfferings(client DescribeInstanceTypeOfferingsAPI) *InstanceTypeOfferings {
+	return &InstanceTypeOfferings{
+		client:    client,
+		locations: make(map[string]map[string]bool),
+	}
+}
+
+// CheckAZ returns an *InstanceTypeNotOfferedError when instanceType cannot
+// launch in the availability zone az.
+func (o *InstanceTypeOfferings) CheckAZ(ctx context.Context, instanceType, az string) error {
+	return o.check(ctx, instanceType, types.LocationTypeAvailabilityZone, az)
+}
+
+// CheckRegion returns an *InstanceTypeNotOfferedError when instanceType
+// cannot launch in any availability zone of region, which must be the
+// client's region.
+func (o *InstanceTypeOfferings) CheckRegion(ctx context.Context, instanceType, region string) error {
+	return o.check(ctx, instanceType, types.LocationTypeRegion, region)
+}
+
+func (o *InstanceTypeOfferings) check(ctx context.Context, instanceType string, locationType types.LocationType, location string) error {
+	offered, err := o.offerings(ctx, locationType, location)
+	if err != nil {
+		return err
+	}
+	if offered[instanceType] {
+		return nil
+	}
+
+	known := make([]string, 0, len(offered))
+	for t := range offered {
+		known = append(known, t)
+	}
+	return &InstanceTypeNotOfferedError{
+		InstanceType: instanceType,
+		Location:     location,
+		Suggestions:  closestInstanceTypes(instanceType, known, maxOfferingSuggestions),
+	}
+}
+
+// offerings returns the set of instance types offered at location, listing
+// them on first use.
+func (o *InstanceTypeOfferings) offerings(ctx context.Context, locationType types.LocationType, location string) (map[string]bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := string(locationType) + "/" + location
+	if offered, ok := o.locations[key]; ok {
+		return offered, nil
+	}
+
+	offered := make(map[string]bool)
+	var nextToken *string
+	for {
+		out, err := o.client.DescribeInstanceTypeOfferings(ctx, &ec2.DescribeInstanceTypeOfferingsInput{
+			LocationType: locationType,
+			Filters: []types.Filter{
+				{Name: aws.String("location"), Values: []string{location}},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ec2 describe-instance-type-offerings: %w", err)
+		}
+		for _, offering := range out.InstanceTypeOfferings {
+			offered[string(offering.InstanceType)] = true
+		}
+		if aws.ToString(out.NextToken) == "" {
+			break
+		}
+		nextToken = out.NextToken
+	}
+
+	o.locations[key] = offered
+	return offered, nil
+}
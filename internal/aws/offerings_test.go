@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeOfferings serves instance type offerings per location, two to a page,
+// and records the locations it was asked for.
+type fakeOfferings struct {
+	byLocation map[string][]string
+	err        error
+	calls      []string
+}
+
+func (f *fakeOfferings) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	location := params.Filters[0].Values[0]
+	f.calls = append(f.calls, string(params.LocationType)+"/"+location)
+
+	start := 0
+	if params.NextToken != nil {
+		fmt.Sscanf(*params.NextToken, "%d", &start)
+	}
+	all := f.byLocation[location]
+	end := min(start+2, len(all))
+	out := &ec2.DescribeInstanceTypeOfferingsOutput{}
+	for _, t := range all[start:end] {
+		out.InstanceTypeOfferings = append(out.InstanceTypeOfferings, types.InstanceTypeOffering{
+			InstanceType: types.InstanceType(t),
+			LocationType: params.LocationType,
+			Location:     aws.String(location),
+		})
+	}
+	if end < len(all) {
+		out.NextToken = aws.String(fmt.Sprint(end))
+	}
+	return out, nil
+}
+
+func testOfferings() *fakeOfferings {
+	return &fakeOfferings{byLocation: map[string][]string{
+		"us-east-1a": {"m6i.large", "m6i.xlarge", "m6i.2xlarge", "m6a.xlarge", "c6i.large"},
+		"us-east-1e": {"m6i.large", "m6i.2xlarge", "m5.xlarge", "t3.micro", "c5.large"},
+		"us-east-1":  {"m6i.large", "m6i.xlarge", "m6i.2xlarge", "m5.xlarge"},
+	}}
+}
+
+func TestInstanceTypeOfferingsCheckAZ(t *testing.T) {
+	offerings := NewInstanceTypeOfferings(testOfferings())
+	ctx := context.Background()
+
+	if err := offerings.CheckAZ(ctx, "m6a.xlarge", "us-east-1a"); err != nil {
+		t.Errorf("m6a.xlarge in us-east-1a: %v", err)
+	}
+
+	err := offerings.CheckAZ(ctx, "m6i.xlarge", "us-east-1e")
+	want := "instance type m6i.xlarge is not offered in us-east-1e — did you mean m6i.2xlarge, m6i.large, m5.xlarge?"
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	var notOffered *InstanceTypeNotOfferedError
+	if !errors.As(err, &notOffered) || notOffered.Location != "us-east-1e" {
+		t.Errorf("error should be *InstanceTypeNotOfferedError for us-east-1e, got %#v", err)
+	}
+}
+
+func TestInstanceTypeOfferingsNoSuggestionWhenNothingClose(t *testing.T) {
+	err := NewInstanceTypeOfferings(testOfferings()).CheckAZ(context.Background(), "q9zz.gigantic", "us-east-1a")
+	if err == nil || err.Error() != "instance type q9zz.gigantic is not offered in us-east-1a" {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestInstanceTypeOfferingsCheckRegion(t *testing.T) {
+	client := testOfferings()
+	if err := NewInstanceTypeOfferings(client).CheckRegion(context.Background(), "m5.xlarge", "us-east-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.calls) == 0 || client.calls[0] != "region/us-east-1" {
+		t.Errorf("calls = %v, want region/us-east-1", client.calls)
+	}
+}
+
+func TestInstanceTypeOfferingsListsEachLocationOnce(t *testing.T) {
+	client := testOfferings()
+	offerings := NewInstanceTypeOfferings(client)
+	ctx := context.Background()
+
+	for range 3 {
+		_ = offerings.CheckAZ(ctx, "m6i.xlarge", "us-east-1a")
+		_ = offerings.CheckAZ(ctx, "m6i.xlarge", "us-east-1e")
+	}
+	// Each AZ has five types, listed in three pages of two.
+	if len(client.calls) != 6 {
+		t.Errorf("DescribeInstanceTypeOfferings called %d times, want 6 (3 pages per AZ): %v", len(client.calls), client.calls)
+	}
+}
+
+func TestInstanceTypeOfferingsAPIError(t *testing.T) {
+	client := &fakeOfferings{err: errors.New("UnauthorizedOperation")}
+	err := NewInstanceTypeOfferings(client).CheckAZ(context.Background(), "m6i.xlarge", "us-east-1a")
+	var notOffered *InstanceTypeNotOfferedError
+	if err == nil || errors.As(err, &notOffered) {
+		t.Errorf("error = %v, want the API error", err)
+	}
+}
@@ -0,0 +1,227 @@
+package retry
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// EC2 is an EC2 client whose calls are retried under a Policy. It has the
+// methods of *ec2.Client that mint calls, so it satisfies the same narrow
+// API interfaces and SDK waiters.
+type EC2 struct {
+	client *ec2.Client
+	policy *Policy
+}
+
+// NewEC2 returns an EC2 client built from cfg whose calls are retried
+// under p. The SDK's own retryer is left retrying only failed connections,
+// so a throttled call is not retried by both.
+func NewEC2(cfg aws.Config, p *Policy) *EC2 {
+	client := ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+		o.Retryer = awsretry.NewStandard(func(so *awsretry.StandardOptions) {
+			so.Retryables = []awsretry.IsErrorRetryable{
+				awsretry.NoRetryCanceledError{},
+				awsretry.RetryableConnectionError{},
+			}
+		})
+	})
+	return &EC2{client: client, policy: p}
+}
+
+// Options returns the options of the wrapped client.
+func (c *EC2) Options() ec2.Options {
+	return c.client.Options()
+}
+
+// AllocateAddress calls ec2 AllocateAddress under the policy.
+func (c *EC2) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
+	return Call(ctx, c.policy, c.client.AllocateAddress, params, optFns...)
+}
+
+// AssociateAddress calls ec2 AssociateAddress under the policy.
+func (c *EC2) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	return Call(ctx, c.policy, c.client.AssociateAddress, params, optFns...)
+}
+
+// AttachVolume calls ec2 AttachVolume under the policy.
+func (c *EC2) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
+	return Call(ctx, c.policy, c.client.AttachVolume, params, optFns...)
+}
+
+// AuthorizeSecurityGroupEgress calls ec2 AuthorizeSecurityGroupEgress under the policy.
+func (c *EC2) AuthorizeSecurityGroupEgress(ctx context.Context, params *ec2.AuthorizeSecurityGroupEgressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupEgressOutput, error) {
+	return Call(ctx, c.policy, c.client.AuthorizeSecurityGroupEgress, params, optFns...)
+}
+
+// AuthorizeSecurityGroupIngress calls ec2 AuthorizeSecurityGroupIngress under the policy.
+func (c *EC2) AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	return Call(ctx, c.policy, c.client.AuthorizeSecurityGroupIngress, params, optFns...)
+}
+
+// CreateInstanceConnectEndpoint calls ec2 CreateInstanceConnectEndpoint under the policy.
+func (c *EC2) CreateInstanceConnectEndpoint(ctx context.Context, params *ec2.CreateInstanceConnectEndpointInput, optFns ...func(*ec2.Options)) (*ec2.CreateInstanceConnectEndpointOutput, error) {
+	return Call(ctx, c.policy, c.client.CreateInstanceConnectEndpoint, params, optFns...)
+}
+
+// CreateSecurityGroup calls ec2 CreateSecurityGroup under the policy.
+func (c *EC2) CreateSecurityGroup(ctx context.Context, params *ec2.CreateSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.CreateSecurityGroupOutput, error) {
+	return Call(ctx, c.policy, c.client.CreateSecurityGroup, params, optFns...)
+}
+
+// CreateSnapshot calls ec2 CreateSnapshot under the policy.
+func (c *EC2) CreateSnapshot(ctx context.Context, params *ec2.CreateSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.CreateSnapshotOutput, error) {
+	return Call(ctx, c.policy, c.client.CreateSnapshot, params, optFns...)
+}
+
+// CreateTags calls ec2 CreateTags under the policy.
+func (c *EC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	return Call(ctx, c.policy, c.client.CreateTags, params, optFns...)
+}
+
+// CreateVolume calls ec2 CreateVolume under the policy.
+func (c *EC2) CreateVolume(ctx context.Context, params *ec2.CreateVolumeInput, optFns ...func(*ec2.Options)) (*ec2.CreateVolumeOutput, error) {
+	return Call(ctx, c.policy, c.client.CreateVolume, params, optFns...)
+}
+
+// DeleteTags calls ec2 DeleteTags under the policy.
+func (c *EC2) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return Call(ctx, c.policy, c.client.DeleteTags, params, optFns...)
+}
+
+// DeleteVolume calls ec2 DeleteVolume under the policy.
+func (c *EC2) DeleteVolume(ctx context.Context, params *ec2.DeleteVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVolumeOutput, error) {
+	return Call(ctx, c.policy, c.client.DeleteVolume, params, optFns...)
+}
+
+// DescribeAccountAttributes calls ec2 DescribeAccountAttributes under the policy.
+func (c *EC2) DescribeAccountAttributes(ctx context.Context, params *ec2.DescribeAccountAttributesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAccountAttributesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeAccountAttributes, params, optFns...)
+}
+
+// DescribeAddresses calls ec2 DescribeAddresses under the policy.
+func (c *EC2) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeAddresses, params, optFns...)
+}
+
+// DescribeImages calls ec2 DescribeImages under the policy.
+func (c *EC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeImages, params, optFns...)
+}
+
+// DescribeInstanceConnectEndpoints calls ec2 DescribeInstanceConnectEndpoints under the policy.
+func (c *EC2) DescribeInstanceConnectEndpoints(ctx context.Context, params *ec2.DescribeInstanceConnectEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceConnectEndpointsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeInstanceConnectEndpoints, params, optFns...)
+}
+
+// DescribeInstanceStatus calls ec2 DescribeInstanceStatus under the policy.
+func (c *EC2) DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeInstanceStatus, params, optFns...)
+}
+
+// DescribeInstanceTypeOfferings calls ec2 DescribeInstanceTypeOfferings under the policy.
+func (c *EC2) DescribeInstanceTypeOfferings(ctx context.Context, params *ec2.DescribeInstanceTypeOfferingsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeInstanceTypeOfferings, params, optFns...)
+}
+
+// DescribeInstanceTypes calls ec2 DescribeInstanceTypes under the policy.
+func (c *EC2) DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeInstanceTypes, params, optFns...)
+}
+
+// DescribeInstances calls ec2 DescribeInstances under the policy.
+func (c *EC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeInstances, params, optFns...)
+}
+
+// DescribeSecurityGroups calls ec2 DescribeSecurityGroups under the policy.
+func (c *EC2) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeSecurityGroups, params, optFns...)
+}
+
+// DescribeSnapshots calls ec2 DescribeSnapshots under the policy.
+func (c *EC2) DescribeSnapshots(ctx context.Context, params *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeSnapshots, params, optFns...)
+}
+
+// DescribeSubnets calls ec2 DescribeSubnets under the policy.
+func (c *EC2) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeSubnets, params, optFns...)
+}
+
+// DescribeVolumes calls ec2 DescribeVolumes under the policy.
+func (c *EC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeVolumes, params, optFns...)
+}
+
+// DescribeVolumesModifications calls ec2 DescribeVolumesModifications under the policy.
+func (c *EC2) DescribeVolumesModifications(ctx context.Context, params *ec2.DescribeVolumesModificationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesModificationsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeVolumesModifications, params, optFns...)
+}
+
+// DescribeVpcs calls ec2 DescribeVpcs under the policy.
+func (c *EC2) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return Call(ctx, c.policy, c.client.DescribeVpcs, params, optFns...)
+}
+
+// DetachVolume calls ec2 DetachVolume under the policy.
+func (c *EC2) DetachVolume(ctx context.Context, params *ec2.DetachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.DetachVolumeOutput, error) {
+	return Call(ctx, c.policy, c.client.DetachVolume, params, optFns...)
+}
+
+// DisassociateAddress calls ec2 DisassociateAddress under the policy.
+func (c *EC2) DisassociateAddress(ctx context.Context, params *ec2.DisassociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.DisassociateAddressOutput, error) {
+	return Call(ctx, c.policy, c.client.DisassociateAddress, params, optFns...)
+}
+
+// EnableSerialConsoleAccess calls ec2 EnableSerialConsoleAccess under the policy.
+func (c *EC2) EnableSerialConsoleAccess(ctx context.Context, params *ec2.EnableSerialConsoleAccessInput, optFns ...func(*ec2.Options)) (*ec2.EnableSerialConsoleAccessOutput, error) {
+	return Call(ctx, c.policy, c.client.EnableSerialConsoleAccess, params, optFns...)
+}
+
+// GetConsoleOutput calls ec2 GetConsoleOutput under the policy.
+func (c *EC2) GetConsoleOutput(ctx context.Context, params *ec2.GetConsoleOutputInput, optFns ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error) {
+	return Call(ctx, c.policy, c.client.GetConsoleOutput, params, optFns...)
+}
+
+// GetSerialConsoleAccessStatus calls ec2 GetSerialConsoleAccessStatus under the policy.
+func (c *EC2) GetSerialConsoleAccessStatus(ctx context.Context, params *ec2.GetSerialConsoleAccessStatusInput, optFns ...func(*ec2.Options)) (*ec2.GetSerialConsoleAccessStatusOutput, error) {
+	return Call(ctx, c.policy, c.client.GetSerialConsoleAccessStatus, params, optFns...)
+}
+
+// ModifyInstanceAttribute calls ec2 ModifyInstanceAttribute under the policy.
+func (c *EC2) ModifyInstanceAttribute(ctx context.Context, params *ec2.ModifyInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyInstanceAttributeOutput, error) {
+	return Call(ctx, c.policy, c.client.ModifyInstanceAttribute, params, optFns...)
+}
+
+// ModifyVolume calls ec2 ModifyVolume under the policy.
+func (c *EC2) ModifyVolume(ctx context.Context, params *ec2.ModifyVolumeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVolumeOutput, error) {
+	return Call(ctx, c.policy, c.client.ModifyVolume, params, optFns...)
+}
+
+// ReleaseAddress calls ec2 ReleaseAddress under the policy.
+func (c *EC2) ReleaseAddress(ctx context.Context, params *ec2.ReleaseAddressInput, optFns ...func(*ec2.Options)) (*ec2.ReleaseAddressOutput, error) {
+	return Call(ctx, c.policy, c.client.ReleaseAddress, params, optFns...)
+}
+
+// RunInstances calls ec2 RunInstances under the policy.
+func (c *EC2) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	return Call(ctx, c.policy, c.client.RunInstances, params, optFns...)
+}
+
+// StartInstances calls ec2 StartInstances under the policy.
+func (c *EC2) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return Call(ctx, c.policy, c.client.StartInstances, params, optFns...)
+}
+
+// StopInstances calls ec2 StopInstances under the policy.
+func (c *EC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return Call(ctx, c.policy, c.client.StopInstances, params, optFns...)
+}
+
+// TerminateInstances calls ec2 TerminateInstances under the policy.
+func (c *EC2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	return Call(ctx, c.policy, c.client.TerminateInstances, params, optFns...)
+}
@@ -0,0 +1,134 @@
+// Package retry retries AWS calls that were throttled or hit a transient
+// server error, with exponential backoff and jitter. EC2 wraps the SDK
+// client so every narrow API interface in internal/aws it is passed as gets
+// the retries without its callers knowing.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// baseDelay is the backoff before the first retry. It doubles for each
+// retry after that, up to maxDelay.
+const (
+	baseDelay = time.Second
+	maxDelay  = 20 * time.Second
+)
+
+// Policy is how a failed call is retried. A nil *Policy attempts every
+// call once.
+type Policy struct {
+	attempts int
+	progress io.Writer
+	jitter   func(d time.Duration) time.Duration
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// NewPolicy returns a policy that attempts a throttled or transiently
+// failing call up to attempts times, the first included.
+func NewPolicy(attempts int) *Policy {
+	return &Policy{
+		attempts: attempts,
+		jitter:   randomJitter,
+		sleep:    sleepContext,
+	}
+}
+
+// SetProgress sets where a notice is written before each retry. Nil, the
+// default, retries silently.
+func (p *Policy) SetProgress(w io.Writer) {
+	p.progress = w
+}
+
+// WithClock overrides the jitter and the backoff sleep (for testing).
+func (p *Policy) WithClock(jitter func(d time.Duration) time.Duration, sleep func(ctx context.Context, d time.Duration) error) *Policy {
+	p.jitter = jitter
+	p.sleep = sleep
+	return p
+}
+
+// Call calls fn with params, retrying it under p while it fails with a
+// throttling or transient server error. Any other error is returned as is,
+// as is the last error once the attempts run out. A canceled ctx ends the
+// retries at once with ctx's error.
+func Call[In, Out, Opt any](ctx context.Context, p *Policy, fn func(context.Context, *In, ...func(*Opt)) (*Out, error), params *In, optFns ...func(*Opt)) (*Out, error) {
+	attempts := 1
+	if p != nil && p.attempts > 1 {
+		attempts = p.attempts
+	}
+	for attempt := 1; ; attempt++ {
+		out, err := fn(ctx, params, optFns...)
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return out, err
+		}
+		reason := classify(err)
+		if reason == "" {
+			return out, err
+		}
+
+		d := p.delay(attempt)
+		if p.progress != nil {
+			fmt.Fprintf(p.progress, "AWS %s, retrying in %.1fs (attempt %d/%d)\n", reason, d.Seconds(), attempt+1, attempts)
+		}
+		if err := p.sleep(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// delay returns the backoff before retry n, 1 being the first: baseDelay
+// doubled per retry up to maxDelay, of which the upper half is random.
+func (p *Policy) delay(n int) time.Duration {
+	d := maxDelay
+	if n <= 5 {
+		d = min(baseDelay<<(n-1), maxDelay)
+	}
+	return d/2 + p.jitter(d/2)
+}
+
+// throttles and transient recognize the errors worth retrying, with the
+// SDK's own lists: throttling error codes such as RequestLimitExceeded,
+// and 5xx responses and request timeouts.
+var (
+	throttles = awsretry.IsErrorThrottles(awsretry.DefaultThrottles)
+	transient = awsretry.IsErrorRetryables{
+		awsretry.RetryableHTTPStatusCode{Codes: awsretry.DefaultRetryableHTTPStatusCodes},
+		awsretry.RetryableErrorCode{Codes: awsretry.DefaultRetryableErrorCodes},
+	}
+)
+
+// classify describes why err is worth retrying, or returns "" when it is
+// not.
+func classify(err error) string {
+	if throttles.IsErrorThrottle(err) == aws.TrueTernary {
+		return "throttled"
+	}
+	if transient.IsErrorRetryable(err) == aws.TrueTernary {
+		return "returned a server error"
+	}
+	return ""
+}
+
+// randomJitter returns a random duration in [0, d].
+func randomJitter(d time.Duration) time.Duration {
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,238 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+)
+
+// statusError is an error carrying an HTTP status code, as SDK response
+// errors do.
+type statusError struct{ code int }
+
+func (e statusError) Error() string       { return fmt.Sprintf("http %d", e.code) }
+func (e statusError) HTTPStatusCode() int { return e.code }
+
+// scriptedDescribe returns errs in turn, then succeeds.
+type scriptedDescribe struct {
+	errs  []error
+	calls int
+}
+
+func (s *scriptedDescribe) DescribeInstances(_ context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return nil, s.errs[s.calls-1]
+	}
+	return &ec2.DescribeInstancesOutput{}, nil
+}
+
+func TestCall(t *testing.T) {
+	throttle := &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded."}
+	denied := &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "not allowed"}
+
+	tests := []struct {
+		name      string
+		attempts  int
+		errs      []error
+		wantErr   error
+		wantCalls int
+		wantSleep []time.Duration
+	}{
+		{
+			name:      "success is not retried",
+			attempts:  5,
+			wantCalls: 1,
+		},
+		{
+			name:      "throttle is retried until it succeeds",
+			attempts:  5,
+			errs:      []error{throttle, throttle},
+			wantCalls: 3,
+			wantSleep: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "5xx is retried",
+			attempts:  5,
+			errs:      []error{statusError{503}},
+			wantCalls: 2,
+			wantSleep: []time.Duration{time.Second},
+		},
+		{
+			name:      "non-retryable error passes through",
+			attempts:  5,
+			errs:      []error{denied},
+			wantErr:   denied,
+			wantCalls: 1,
+		},
+		{
+			name:      "4xx is not retried",
+			attempts:  5,
+			errs:      []error{statusError{400}},
+			wantErr:   statusError{400},
+			wantCalls: 1,
+		},
+		{
+			name:      "plain error is not retried",
+			attempts:  5,
+			errs:      []error{errors.New("throttled")},
+			wantErr:   errors.New("throttled"),
+			wantCalls: 1,
+		},
+		{
+			name:      "last error once attempts run out",
+			attempts:  3,
+			errs:      []error{throttle, throttle, throttle, throttle},
+			wantErr:   throttle,
+			wantCalls: 3,
+			wantSleep: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:      "one attempt disables retries",
+			attempts:  1,
+			errs:      []error{throttle},
+			wantErr:   throttle,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slept []time.Duration
+			p := NewPolicy(tt.attempts).WithClock(
+				func(d time.Duration) time.Duration { return d }, // upper bound
+				func(_ context.Context, d time.Duration) error {
+					slept = append(slept, d)
+					return nil
+				})
+			api := &scriptedDescribe{errs: tt.errs}
+
+			_, err := Call(context.Background(), p, api.DescribeInstances, &ec2.DescribeInstancesInput{})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if api.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", api.calls, tt.wantCalls)
+			}
+			if fmt.Sprint(slept) != fmt.Sprint(tt.wantSleep) {
+				t.Errorf("slept %v, want %v", slept, tt.wantSleep)
+			}
+		})
+	}
+}
+
+func TestCallProgress(t *testing.T) {
+	throttle := &smithy.GenericAPIError{Code: "RequestLimitExceeded"}
+	var out bytes.Buffer
+	p := NewPolicy(5).WithClock(
+		func(d time.Duration) time.Duration { return d * 6 / 10 },
+		func(context.Context, time.Duration) error { return nil })
+	p.SetProgress(&out)
+	api := &scriptedDescribe{errs: []error{throttle, throttle, statusError{500}}}
+
+	if _, err := Call(context.Background(), p, api.DescribeInstances, &ec2.DescribeInstancesInput{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "AWS throttled, retrying in 0.8s (attempt 2/5)\n" +
+		"AWS throttled, retrying in 1.6s (attempt 3/5)\n" +
+		"AWS returned a server error, retrying in 3.2s (attempt 4/5)\n"
+	if out.String() != want {
+		t.Errorf("progress =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestCallContextCanceled(t *testing.T) {
+	throttle := &smithy.GenericAPIError{Code: "RequestLimitExceeded"}
+
+	t.Run("during backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := NewPolicy(5).WithClock(
+			func(d time.Duration) time.Duration { return d },
+			func(ctx context.Context, _ time.Duration) error {
+				cancel()
+				return ctx.Err()
+			})
+		api := &scriptedDescribe{errs: []error{throttle, throttle}}
+
+		_, err := Call(ctx, p, api.DescribeInstances, &ec2.DescribeInstancesInput{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error = %v, want context.Canceled", err)
+		}
+		if api.calls != 1 {
+			t.Errorf("calls = %d, want 1", api.calls)
+		}
+	})
+
+	t.Run("before a retry", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p := NewPolicy(5).WithClock(
+			func(d time.Duration) time.Duration { return d },
+			func(context.Context, time.Duration) error {
+				t.Fatal("slept after the context was canceled")
+				return nil
+			})
+		api := &scriptedDescribe{errs: []error{throttle}}
+
+		_, err := Call(ctx, p, api.DescribeInstances, &ec2.DescribeInstancesInput{})
+		if err != throttle {
+			t.Fatalf("error = %v, want the throttle error", err)
+		}
+		if api.calls != 1 {
+			t.Errorf("calls = %d, want 1", api.calls)
+		}
+	})
+
+	t.Run("real sleep returns at once", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		api := &scriptedDescribe{errs: []error{throttle, throttle}}
+
+		start := time.Now()
+		_, err := Call(ctx, NewPolicy(5), api.DescribeInstances, &ec2.DescribeInstancesInput{})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+			t.Errorf("took %s, want the backoff cut short", elapsed)
+		}
+	})
+}
+
+func TestCallNilPolicy(t *testing.T) {
+	throttle := &smithy.GenericAPIError{Code: "RequestLimitExceeded"}
+	api := &scriptedDescribe{errs: []error{throttle}}
+
+	if _, err := Call(context.Background(), nil, api.DescribeInstances, &ec2.DescribeInstancesInput{}); err != throttle {
+		t.Fatalf("error = %v, want the throttle error", err)
+	}
+	if api.calls != 1 {
+		t.Errorf("calls = %d, want 1", api.calls)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	p := NewPolicy(10).WithClock(func(d time.Duration) time.Duration { return 0 }, nil)
+	want := []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second,
+		8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}
+	for i, w := range want {
+		if got := p.delay(i + 1); got != w {
+			t.Errorf("delay(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+	for range 100 {
+		if d := randomJitter(time.Second); d < 0 || d > time.Second {
+			t.Fatalf("randomJitter(1s) = %s, out of range", d)
+		}
+	}
+}
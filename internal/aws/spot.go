@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// SpotMarketOptions returns the RunInstances market options for a spot
+// instance. The request is persistent and the instance stops, rather than
+// terminates, when EC2 reclaims the capacity, so the VM keeps its volumes
+// and Elastic IP and can be started again.
+func SpotMarketOptions() *ec2types.InstanceMarketOptionsRequest {
+	return &ec2types.InstanceMarketOptionsRequest{
+		MarketType: ec2types.MarketTypeSpot,
+		SpotOptions: &ec2types.SpotMarketOptions{
+			SpotInstanceType:             ec2types.SpotInstanceTypePersistent,
+			InstanceInterruptionBehavior: ec2types.InstanceInterruptionBehaviorStop,
+		},
+	}
+}
+
+// spotCapacityErrorCodes are the RunInstances error codes for which a spot
+// launch may be retried on demand.
+var spotCapacityErrorCodes = map[string]bool{
+	"SpotMaxPriceTooLow":           true,
+	"InsufficientInstanceCapacity": true,
+}
+
+// SpotCapacityErrorCode returns the error code of err when it is a spot
+// launch failure an on-demand launch may not hit: the spot price is above
+// the maximum price, or there is no spot capacity. It returns "" otherwise.
+func SpotCapacityErrorCode(err error) string {
+	var ae smithy.APIError
+	if errors.As(err, &ae) && spotCapacityErrorCodes[ae.ErrorCode()] {
+		return ae.ErrorCode()
+	}
+	return ""
+}
+
+// SpotFallbackWarning describes an on-demand launch made after a spot launch
+// of instanceType failed with code.
+func SpotFallbackWarning(instanceType, code string) string {
+	return fmt.Sprintf("no spot capacity for %s (%s) — launched an on-demand instance instead", instanceType, code)
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+func TestSpotMarketOptions(t *testing.T) {
+	opts := SpotMarketOptions()
+	if opts.MarketType != ec2types.MarketTypeSpot {
+		t.Errorf("MarketType = %q, want spot", opts.MarketType)
+	}
+	if opts.SpotOptions.SpotInstanceType != ec2types.SpotInstanceTypePersistent {
+		t.Errorf("SpotInstanceType = %q, want persistent", opts.SpotOptions.SpotInstanceType)
+	}
+	if opts.SpotOptions.InstanceInterruptionBehavior != ec2types.InstanceInterruptionBehaviorStop {
+		t.Errorf("InstanceInterruptionBehavior = %q, want stop", opts.SpotOptions.InstanceInterruptionBehavior)
+	}
+}
+
+func TestSpotCapacityErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"max price", &smithy.GenericAPIError{Code: "SpotMaxPriceTooLow"}, "SpotMaxPriceTooLow"},
+		{"wrapped capacity", fmt.Errorf("run instances: %w", &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity"}), "InsufficientInstanceCapacity"},
+		{"other API error", &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, ""},
+		{"plain error", errors.New("boom"), ""},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SpotCapacityErrorCode(tt.err); got != tt.want {
+				t.Errorf("SpotCapacityErrorCode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// Package aws provides thin wrappers around AWS SDK clients used by Mint.
+// This file defines narrow interfaces for STS operations needed by the admin
+// commands to run under an assumed role. Each interface wraps exactly one AWS
+// SDK method, enabling mock injection in tests.
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// ---------------------------------------------------------------------------
+// STS interfaces
+// ---------------------------------------------------------------------------
+
+// AssumeRoleAPI defines the subset of the STS API used for obtaining
+// temporary credentials for a role. Used by the admin commands when
+// --admin-role or admin_role_arn is set.
+type AssumeRoleAPI interface {
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+}
+
+// ---------------------------------------------------------------------------
+// Compile-time interface satisfaction checks
+// ---------------------------------------------------------------------------
+
+var _ AssumeRoleAPI = (*sts.Client)(nil)
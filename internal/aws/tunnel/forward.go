@@ -0,0 +1,141 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Forwarder listens on a loopback port and relays every accepted connection
+// through its own tunnel to the Target. It lets unmodified TCP clients (the
+// ssh binary) reach a private instance by connecting to 127.0.0.1:Port().
+type Forwarder struct {
+	listener net.Listener
+	dialer   Dialer
+	target   Target
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	conns   []io.Closer
+	dialErr error
+	wg      sync.WaitGroup
+}
+
+// Forward starts a Forwarder for target on an ephemeral loopback port. The
+// caller must Close it when done.
+func Forward(ctx context.Context, dialer Dialer, target Target) (*Forwarder, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listening for tunnel clients: %w", err)
+	}
+
+	fctx, cancel := context.WithCancel(ctx)
+	f := &Forwarder{
+		listener: ln,
+		dialer:   dialer,
+		target:   target,
+		ctx:      fctx,
+		cancel:   cancel,
+	}
+	f.wg.Add(1)
+	go f.acceptLoop()
+	return f, nil
+}
+
+// Port returns the loopback port clients should connect to.
+func (f *Forwarder) Port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Err returns the first error opening a tunnel for an accepted client, or
+// nil. A client whose tunnel could not be opened only sees its connection
+// closed, so callers use Err to report the underlying cause.
+func (f *Forwarder) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dialErr
+}
+
+// Close stops accepting connections, closes every open tunnel, and waits for
+// the relay goroutines to exit.
+func (f *Forwarder) Close() error {
+	f.cancel()
+	err := f.listener.Close()
+
+	f.mu.Lock()
+	for _, c := range f.conns {
+		c.Close()
+	}
+	f.conns = nil
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return err
+}
+
+// acceptLoop accepts local clients until the listener is closed.
+func (f *Forwarder) acceptLoop() {
+	defer f.wg.Done()
+	for {
+		local, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.wg.Add(1)
+		go f.relay(local)
+	}
+}
+
+// relay dials a tunnel for one local client and copies bytes both ways until
+// either side closes.
+func (f *Forwarder) relay(local net.Conn) {
+	defer f.wg.Done()
+
+	remote, err := f.dialer.Dial(f.ctx, f.target)
+	if err != nil {
+		f.mu.Lock()
+		if f.dialErr == nil {
+			f.dialErr = err
+		}
+		f.mu.Unlock()
+		local.Close()
+		return
+	}
+	if !f.track(local, remote) {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+
+	// When either direction finishes the session is over; closing both ends
+	// unblocks the other copy.
+	<-done
+	local.Close()
+	remote.Close()
+	<-done
+}
+
+// track records open connections so Close can tear them down. It returns
+// false (after closing both) when the Forwarder is already closed.
+func (f *Forwarder) track(local, remote io.Closer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ctx.Err() != nil {
+		local.Close()
+		remote.Close()
+		return false
+	}
+	f.conns = append(f.conns, local, remote)
+	return true
+}
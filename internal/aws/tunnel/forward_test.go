@@ -0,0 +1,112 @@
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// echoDialer returns in-memory streams that echo whatever is written.
+type echoDialer struct {
+	mu      sync.Mutex
+	targets []Target
+}
+
+func (d *echoDialer) Dial(_ context.Context, target Target) (io.ReadWriteCloser, error) {
+	d.mu.Lock()
+	d.targets = append(d.targets, target)
+	d.mu.Unlock()
+
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		_, _ = io.Copy(server, server)
+	}()
+	return client, nil
+}
+
+type failingDialer struct{}
+
+func (failingDialer) Dial(context.Context, Target) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("endpoint unavailable")
+}
+
+func TestForwarderRelaysThroughDialer(t *testing.T) {
+	dialer := &echoDialer{}
+	fwd, err := Forward(context.Background(), dialer, testTarget())
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	defer fwd.Close()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", fwd.Port()))
+	if err != nil {
+		t.Fatalf("dial forwarder: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("SSH-2.0-mint\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, len("SSH-2.0-mint\r\n"))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buf) != "SSH-2.0-mint\r\n" {
+		t.Errorf("echo = %q", buf)
+	}
+
+	if err := fwd.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+	if len(dialer.targets) != 1 || dialer.targets[0] != testTarget() {
+		t.Errorf("dialer targets = %+v, want one dial to %+v", dialer.targets, testTarget())
+	}
+}
+
+func TestForwarderClosesClientWhenDialFails(t *testing.T) {
+	fwd, err := Forward(context.Background(), failingDialer{}, testTarget())
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	defer fwd.Close()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", fwd.Port()))
+	if err != nil {
+		t.Fatalf("dial forwarder: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expected read error after failed tunnel dial")
+	}
+	if err := fwd.Err(); err == nil || err.Error() != "endpoint unavailable" {
+		t.Errorf("Err() = %v, want the dial error", err)
+	}
+}
+
+func TestForwarderCloseTearsDownOpenTunnels(t *testing.T) {
+	fwd, err := Forward(context.Background(), &echoDialer{}, testTarget())
+	if err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", fwd.Port()))
+	if err != nil {
+		t.Fatalf("dial forwarder: %v", err)
+	}
+	defer conn.Close()
+	// Round-trip once so the relay is established before closing.
+	conn.Write([]byte("a"))
+	io.ReadFull(conn, make([]byte, 1))
+
+	fwd.Close()
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expected read error after Close")
+	}
+}
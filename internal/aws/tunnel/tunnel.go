@@ -0,0 +1,134 @@
+// Package tunnel opens TCP byte streams to instances that have no public IP
+// by way of an EC2 Instance Connect Endpoint (EICE). The endpoint exposes an
+// OpenTunnel websocket API; each websocket carries one TCP connection to a
+// private IP and port inside the endpoint's VPC.
+package tunnel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// signingService is the SigV4 service name for the OpenTunnel API.
+const signingService = "ec2-instance-connect"
+
+// DefaultMaxDuration is the tunnel lifetime requested from the endpoint.
+// Remote commands finish well within this; the endpoint enforces its own
+// upper bound of one hour.
+const DefaultMaxDuration = time.Hour
+
+// presignExpiry is how long the signed OpenTunnel URL stays valid. The URL
+// is used immediately, so a short window is sufficient.
+const presignExpiry = 60 * time.Second
+
+// emptyPayloadHash is the SHA-256 of an empty body, used when signing the
+// websocket upgrade request.
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// Target identifies the private address to reach and the endpoint to reach
+// it through.
+type Target struct {
+	EndpointID  string // eice-... identifier
+	EndpointDNS string // endpoint DNS name hosting the OpenTunnel API
+	PrivateIP   string // instance private IPv4 address
+	Port        int    // remote TCP port on the instance
+	MaxDuration time.Duration
+}
+
+// Dialer opens a byte stream to a Target. Production code uses
+// WebSocketDialer; tests inject an in-memory fake.
+type Dialer interface {
+	Dial(ctx context.Context, target Target) (io.ReadWriteCloser, error)
+}
+
+// WebSocketDialer implements Dialer using the OpenTunnel websocket API,
+// signing each request with SigV4 query authentication.
+type WebSocketDialer struct {
+	credentials aws.CredentialsProvider
+	region      string
+	signer      *v4.Signer
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewWebSocketDialer creates a WebSocketDialer from an AWS SDK config.
+func NewWebSocketDialer(cfg aws.Config) *WebSocketDialer {
+	return &WebSocketDialer{
+		credentials: cfg.Credentials,
+		region:      cfg.Region,
+		signer:      v4.NewSigner(),
+		client:      newWebSocketClient(),
+		now:         time.Now,
+	}
+}
+
+// Dial opens a tunnel to target and returns the established stream.
+func (d *WebSocketDialer) Dial(ctx context.Context, target Target) (io.ReadWriteCloser, error) {
+	signed, err := d.presign(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialWebSocket(ctx, d.client, signed)
+	if err != nil {
+		return nil, fmt.Errorf("opening tunnel via %s: %w", target.EndpointID, err)
+	}
+	return conn, nil
+}
+
+// presign returns the SigV4-signed OpenTunnel URL for target.
+func (d *WebSocketDialer) presign(ctx context.Context, target Target) (string, error) {
+	if d.credentials == nil {
+		return "", fmt.Errorf("no AWS credentials available to sign tunnel request")
+	}
+	creds, err := d.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("retrieving credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, openTunnelURL(target), nil)
+	if err != nil {
+		return "", fmt.Errorf("building tunnel request: %w", err)
+	}
+
+	signed, _, err := d.signer.PresignHTTP(ctx, creds, req, emptyPayloadHash, signingService, d.region, d.now())
+	if err != nil {
+		return "", fmt.Errorf("signing tunnel request: %w", err)
+	}
+	return signed, nil
+}
+
+// openTunnelURL builds the unsigned OpenTunnel URL for target.
+func openTunnelURL(target Target) string {
+	maxDuration := target.MaxDuration
+	if maxDuration <= 0 {
+		maxDuration = DefaultMaxDuration
+	}
+
+	q := url.Values{}
+	q.Set("instanceConnectEndpointId", target.EndpointID)
+	q.Set("maxTunnelDuration", strconv.Itoa(int(maxDuration/time.Second)))
+	q.Set("privateIpAddress", target.PrivateIP)
+	q.Set("remotePort", strconv.Itoa(target.Port))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(presignExpiry/time.Second)))
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     target.EndpointDNS,
+		Path:     "/openTunnel",
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,89 @@
+package tunnel
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func testTarget() Target {
+	return Target{
+		EndpointID:  "eice-0123456789abcdef0",
+		EndpointDNS: "eice-0123456789abcdef0.abcd1234.ec2-instance-connect-endpoint.us-east-1.amazonaws.com",
+		PrivateIP:   "172.31.10.20",
+		Port:        41122,
+	}
+}
+
+func TestOpenTunnelURL(t *testing.T) {
+	u, err := url.Parse(openTunnelURL(testTarget()))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if u.Path != "/openTunnel" {
+		t.Errorf("path = %q, want /openTunnel", u.Path)
+	}
+	q := u.Query()
+	want := map[string]string{
+		"instanceConnectEndpointId": "eice-0123456789abcdef0",
+		"privateIpAddress":          "172.31.10.20",
+		"remotePort":                "41122",
+		"maxTunnelDuration":         "3600",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestOpenTunnelURLCustomDuration(t *testing.T) {
+	target := testTarget()
+	target.MaxDuration = 10 * time.Minute
+	u, _ := url.Parse(openTunnelURL(target))
+	if got := u.Query().Get("maxTunnelDuration"); got != "600" {
+		t.Errorf("maxTunnelDuration = %q, want 600", got)
+	}
+}
+
+func TestPresignSignsForInstanceConnect(t *testing.T) {
+	d := NewWebSocketDialer(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+	})
+	d.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	signed, err := d.presign(context.Background(), testTarget())
+	if err != nil {
+		t.Fatalf("presign: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Signature") == "" {
+		t.Error("signed URL missing X-Amz-Signature")
+	}
+	if cred := q.Get("X-Amz-Credential"); !strings.Contains(cred, "/us-east-1/ec2-instance-connect/aws4_request") {
+		t.Errorf("credential scope = %q, want ec2-instance-connect in us-east-1", cred)
+	}
+	if q.Get("X-Amz-Expires") != "60" {
+		t.Errorf("X-Amz-Expires = %q, want 60", q.Get("X-Amz-Expires"))
+	}
+	if q.Get("privateIpAddress") != "172.31.10.20" {
+		t.Error("signed URL lost tunnel parameters")
+	}
+}
+
+func TestPresignRequiresCredentials(t *testing.T) {
+	d := NewWebSocketDialer(aws.Config{Region: "us-east-1"})
+	if _, err := d.presign(context.Background(), testTarget()); err == nil {
+		t.Fatal("expected error without credentials")
+	}
+}
@@ -0,0 +1,256 @@
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is the fixed GUID from RFC 6455 used to derive the
+// Sec-WebSocket-Accept header.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// errConnClosed is returned when writing to a closed wsConn.
+var errConnClosed = errors.New("tunnel closed")
+
+// maxFramePayload bounds a single inbound frame. OpenTunnel frames are a few
+// kilobytes; anything larger indicates a protocol error.
+const maxFramePayload = 16 << 20
+
+// Websocket opcodes (RFC 6455 section 5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// newWebSocketClient returns an HTTP client suitable for websocket upgrades.
+// HTTP/2 is disabled because the upgrade handshake is HTTP/1.1 only.
+func newWebSocketClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:        http.ProxyFromEnvironment,
+			TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
+		},
+	}
+}
+
+// dialWebSocket performs the websocket upgrade handshake against rawURL and
+// returns a stream that carries binary frames.
+func dialWebSocket(ctx context.Context, client *http.Client, rawURL string) (io.ReadWriteCloser, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("generating websocket key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building upgrade request: %w", err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("endpoint rejected tunnel: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("upgrade response body is not writable")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		rwc.Close()
+		return nil, fmt.Errorf("invalid Sec-WebSocket-Accept in upgrade response")
+	}
+
+	return newWSConn(rwc), nil
+}
+
+// acceptKey derives the expected Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsConn adapts a websocket to an io.ReadWriteCloser. Each Write is sent as
+// one masked binary frame; Read returns the payloads of inbound data frames
+// in order, answering pings transparently.
+type wsConn struct {
+	rwc io.ReadWriteCloser
+	br  *bufio.Reader
+
+	// buf holds the unread remainder of the last data frame.
+	buf []byte
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+func newWSConn(rwc io.ReadWriteCloser) *wsConn {
+	return &wsConn{rwc: rwc, br: bufio.NewReader(rwc)}
+}
+
+// Read implements io.Reader.
+func (c *wsConn) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case opBinary, opText, opContinuation:
+			c.buf = payload
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, err
+			}
+		case opPong:
+			// Unsolicited pongs are ignored.
+		case opClose:
+			_ = c.writeFrame(opClose, payload)
+			return 0, io.EOF
+		default:
+			return 0, fmt.Errorf("unexpected websocket opcode %#x", op)
+		}
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// Write implements io.Writer.
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(opBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close sends a close frame (best effort) and closes the underlying stream.
+func (c *wsConn) Close() error {
+	c.wmu.Lock()
+	if c.closed {
+		c.wmu.Unlock()
+		return nil
+	}
+	_ = c.writeFrameLocked(opClose, nil)
+	c.closed = true
+	c.wmu.Unlock()
+	return c.rwc.Close()
+}
+
+// readFrame reads one complete frame and returns its opcode and unmasked
+// payload.
+func (c *wsConn) readFrame() (byte, []byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	op := hdr[0] & 0x0F
+	masked := hdr[1]&0x80 != 0
+
+	length := uint64(hdr[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxFramePayload {
+		return 0, nil, fmt.Errorf("websocket frame too large (%d bytes)", length)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		maskBytes(payload, mask)
+	}
+	return op, payload, nil
+}
+
+// writeFrame sends a single final frame with the given opcode. Client frames
+// are always masked (RFC 6455 section 5.3).
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return errConnClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+// writeFrameLocked encodes and writes a frame; the caller holds wmu.
+func (c *wsConn) writeFrameLocked(op byte, payload []byte) error {
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return fmt.Errorf("generating frame mask: %w", err)
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, mask[:]...)
+
+	start := len(frame)
+	frame = append(frame, payload...)
+	maskBytes(frame[start:], mask)
+
+	_, err := c.rwc.Write(frame)
+	return err
+}
+
+// maskBytes XORs b in place with the 4-byte mask key.
+func maskBytes(b []byte, mask [4]byte) {
+	for i := range b {
+		b[i] ^= mask[i%4]
+	}
+}
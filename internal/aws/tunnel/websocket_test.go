@@ -0,0 +1,149 @@
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serverFrame encodes an unmasked server-to-client frame.
+func serverFrame(op byte, payload []byte) []byte {
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	return append(frame, payload...)
+}
+
+// readClientFrame decodes one client frame from r using the production
+// decoder, asserting that it was masked.
+func readClientFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	peek, err := r.Peek(2)
+	if err != nil {
+		t.Fatalf("peek frame: %v", err)
+	}
+	if peek[1]&0x80 == 0 {
+		t.Fatal("client frame is not masked")
+	}
+	c := &wsConn{br: r}
+	op, payload, err := c.readFrame()
+	if err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	return op, payload
+}
+
+func TestWSConnWriteSendsMaskedBinaryFrames(t *testing.T) {
+	for _, size := range []int{5, 300, 70000} {
+		client, server := net.Pipe()
+		conn := newWSConn(client)
+		payload := bytes.Repeat([]byte("x"), size)
+
+		go func() { _, _ = conn.Write(payload) }()
+
+		op, got := readClientFrame(t, bufio.NewReader(server))
+		if op != opBinary {
+			t.Errorf("size %d: opcode = %#x, want binary", size, op)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("size %d: payload mismatch", size)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+func TestWSConnReadAnswersPingAndStopsOnClose(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := newWSConn(client)
+
+	go func() {
+		_, _ = server.Write(serverFrame(opPing, []byte("hb")))
+		op, payload := readClientFrame(t, bufio.NewReader(server))
+		if op != opPong || string(payload) != "hb" {
+			t.Errorf("got opcode %#x payload %q, want pong hb", op, payload)
+		}
+		_, _ = server.Write(serverFrame(opBinary, []byte("SSH-2.0-OpenSSH\r\n")))
+		_, _ = server.Write(serverFrame(opClose, nil))
+		_, _ = io.Copy(io.Discard, server)
+	}()
+
+	got, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "SSH-2.0-OpenSSH\r\n" {
+		t.Errorf("read %q", got)
+	}
+}
+
+func TestDialWebSocketHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(w, "not a websocket request", http.StatusBadRequest)
+			return
+		}
+		hj, _ := w.(http.Hijacker)
+		nc, rw, err := hj.Hijack()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+
+		// Echo one frame back.
+		_, payload := readClientFrame(t, rw.Reader)
+		nc.Write(serverFrame(opBinary, payload))
+	}))
+	defer srv.Close()
+
+	conn, err := dialWebSocket(context.Background(), newWebSocketClient(), srv.URL+"/openTunnel")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo = %q, want ping", buf)
+	}
+}
+
+func TestDialWebSocketRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "signature expired", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, err := dialWebSocket(context.Background(), newWebSocketClient(), srv.URL+"/openTunnel")
+	if err == nil {
+		t.Fatal("expected error for non-101 response")
+	}
+	if !bytes.Contains([]byte(err.Error()), []byte("signature expired")) {
+		t.Errorf("error %q does not include response body", err)
+	}
+}
@@ -5,4 +5,4 @@ package bootstrap
 // ScriptSHA256 is the expected SHA256 hash of scripts/bootstrap.sh,
 // computed at build time via go:generate. Used to verify script integrity
 // before sending user-data to EC2 (ADR-0009).
-const ScriptSHA256 = "678ab4a7774536f5619fee962a15923696135516b0dc93f5100b256cda5186f5"
+const ScriptSHA256 = "2a1384a10ab8ca7b9776dac52b439b7e2489fb3f0b0cd2c495d5a4ec7275352b"
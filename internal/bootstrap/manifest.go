@@ -0,0 +1,346 @@
+package bootstrap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// manifestPublicKey is the base64 Ed25519 public key that bootstrap.json
+// signatures are verified against. The matching private key is held by the
+// release pipeline, which signs each published manifest.
+const manifestPublicKey = "pDy1djr6G5UnkIrzGLUerbZwQrXQnRdu4ST2TGFRXCE="
+
+// SourceEmbedded labels a Source that uses the hash compiled into the binary.
+const SourceEmbedded = "embedded"
+
+// maxManifestBytes bounds how much of bootstrap.json and its signature is
+// read. A real manifest is a few hundred bytes.
+const maxManifestBytes = 64 << 10
+
+// maxScriptBytes bounds how much of a mirrored bootstrap.sh is read.
+const maxScriptBytes = 1 << 20
+
+// ManifestURL returns the URL of the signed bootstrap manifest. Unlike
+// ScriptURL it does not follow the CLI version: the manifest is published
+// from the default branch so bootstrap.sh can change without a CLI release.
+// Development builds read the develop branch's manifest.
+func ManifestURL(version string) string {
+	if version == "" || version == "dev" {
+		return bootstrapRawBase + "/develop/bootstrap.json"
+	}
+	return bootstrapRawBase + "/main/bootstrap.json"
+}
+
+// Manifest describes a published bootstrap.sh. It is served as bootstrap.json
+// next to a detached signature, bootstrap.json.sig, holding the base64
+// Ed25519 signature of the exact manifest bytes.
+type Manifest struct {
+	// Version is the manifest's own revision, incremented on every publish.
+	Version int `json:"version"`
+
+	// SHA256 is the hex digest of the script at URL.
+	SHA256 string `json:"sha256"`
+
+	// MinCLIVersion is the oldest mint release that can provision with this
+	// script, e.g. "1.4.0". Empty means any.
+	MinCLIVersion string `json:"min_cli_version"`
+
+	// URL is where the published bootstrap.sh is fetched from.
+	URL string `json:"url"`
+}
+
+// ParseManifest verifies sig against data with pub and decodes the manifest.
+// The signature is checked before the content is trusted, so a tampered
+// hash or URL fails as a bad signature.
+func ParseManifest(data, sig []byte, pub ed25519.PublicKey) (*Manifest, error) {
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding bootstrap manifest signature: %w", err)
+	}
+	if !ed25519.Verify(pub, data, rawSig) {
+		return nil, fmt.Errorf("bootstrap manifest signature is invalid")
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing bootstrap manifest: %w", err)
+	}
+	if m.Version <= 0 {
+		return nil, fmt.Errorf("bootstrap manifest has invalid version %d", m.Version)
+	}
+	if err := validateSHA256(m.SHA256); err != nil {
+		return nil, fmt.Errorf("bootstrap manifest v%d: %w", m.Version, err)
+	}
+	if !strings.HasPrefix(m.URL, "https://") {
+		return nil, fmt.Errorf("bootstrap manifest v%d: script URL %q must use https", m.Version, m.URL)
+	}
+	return &m, nil
+}
+
+// Source is the hash and URL a VM's bootstrap stub is rendered with, and
+// where they came from.
+type Source struct {
+	SHA256 string
+	URL    string
+
+	// Inline is bootstrap.sh itself, gzipped and base64-encoded, when it is
+	// embedded in user-data instead of downloaded from URL (see
+	// InlineSource).
+	Inline string
+
+	// Label names the source for verbose output and the
+	// mint:bootstrap-source tag: "manifest v12" or "embedded".
+	Label string
+}
+
+// EmbeddedSource returns the offline source: ScriptSHA256 and the script
+// URL for this CLI's version.
+func EmbeddedSource(url string) Source {
+	return Source{SHA256: ScriptSHA256, URL: url, Label: SourceEmbedded}
+}
+
+// manifestSource returns the source described by m.
+func manifestSource(m *Manifest) Source {
+	return Source{SHA256: m.SHA256, URL: m.URL, Label: fmt.Sprintf("manifest v%d", m.Version)}
+}
+
+// VerifySource checks that src is usable for rendering the bootstrap stub.
+// The stub re-verifies the downloaded script against src.SHA256 on the
+// instance (ADR-0009), so a malformed hash must never reach EC2.
+func VerifySource(src Source) error {
+	if src.SHA256 == "" && src.Label == SourceEmbedded {
+		return fmt.Errorf("ScriptSHA256 is empty — run go generate ./internal/bootstrap/...")
+	}
+	if err := validateSHA256(src.SHA256); err != nil {
+		return fmt.Errorf("%s bootstrap source: %w", src.Label, err)
+	}
+	return nil
+}
+
+// InlineSource returns src with script embedded in the stub, which then
+// skips the download. script must be the one src pins. It is gzipped so the
+// full bootstrap.sh fits in user-data.
+func InlineSource(src Source, script []byte) (Source, error) {
+	if err := VerifySource(src); err != nil {
+		return Source{}, err
+	}
+	if err := VerifyScript(script, src.SHA256); err != nil {
+		return Source{}, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(script); err != nil {
+		return Source{}, fmt.Errorf("compressing bootstrap script: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return Source{}, fmt.Errorf("compressing bootstrap script: %w", err)
+	}
+	src.Inline = base64.StdEncoding.EncodeToString(buf.Bytes())
+	return src, nil
+}
+
+// VerifyScript checks that script hashes to sha256, the digest a source
+// pins. It runs where mint itself reads the script (a mirror or a local
+// file), before the stub checks it again on the instance.
+func VerifyScript(script []byte, sha256Hex string) error {
+	sum := sha256.Sum256(script)
+	if got := hex.EncodeToString(sum[:]); got != sha256Hex {
+		return fmt.Errorf("bootstrap script has sha256 %s, want the published %s", got, sha256Hex)
+	}
+	return nil
+}
+
+// validateSHA256 checks that s is a lowercase hex SHA-256 digest.
+func validateSHA256(s string) error {
+	if len(s) != 64 || strings.ToLower(s) != s {
+		return fmt.Errorf("sha256 %q is not a 64-character lowercase hex digest", s)
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return fmt.Errorf("sha256 %q is not a 64-character lowercase hex digest", s)
+	}
+	return nil
+}
+
+// CLITooOldError is returned when the published manifest requires a newer
+// mint than the one running.
+type CLITooOldError struct {
+	ManifestVersion int
+	Required        string
+	Current         string
+}
+
+func (e *CLITooOldError) Error() string {
+	return fmt.Sprintf("bootstrap manifest v%d requires mint v%s or newer (this is v%s) — run %s to upgrade",
+		e.ManifestVersion, strings.TrimPrefix(e.Required, "v"), strings.TrimPrefix(e.Current, "v"), hint.Cmd("mint update"))
+}
+
+// Resolver picks the bootstrap source for a provision: the signed manifest
+// when it can be fetched, otherwise the embedded hash.
+type Resolver struct {
+	// Client is the HTTP client used to fetch the manifest.
+	// If nil, a default client with a 10s timeout is used.
+	Client *http.Client
+
+	// ManifestURL is the bootstrap.json URL; the signature is read from
+	// ManifestURL + ".sig".
+	ManifestURL string
+
+	// ScriptURL is the script URL used with the embedded hash.
+	ScriptURL string
+
+	// MirrorURL replaces the script URL of whichever source Resolve picks
+	// (bootstrap_url). Resolve fetches the script there and checks it
+	// against the source's hash first.
+	MirrorURL string
+
+	// CLIVersion is the running mint version, checked against the
+	// manifest's min_cli_version. Development builds skip the check.
+	CLIVersion string
+
+	// PublicKey overrides the embedded manifest signing key. Used for testing.
+	PublicKey ed25519.PublicKey
+}
+
+// Resolve returns the source to provision with. A manifest that cannot be
+// fetched falls back to the embedded hash so provisioning works offline. A
+// manifest that was fetched but fails verification, or that requires a newer
+// CLI, is an error: silently ignoring it would hide tampering or provision a
+// script this CLI does not support. With MirrorURL set, the script there
+// must match the chosen source's hash.
+func (r *Resolver) Resolve(ctx context.Context) (Source, error) {
+	src, err := r.resolve(ctx)
+	if err != nil || r.MirrorURL == "" {
+		return src, err
+	}
+	if err := VerifySource(src); err != nil {
+		return Source{}, err
+	}
+	script, err := r.fetch(ctx, r.MirrorURL, maxScriptBytes)
+	if err != nil {
+		return Source{}, fmt.Errorf("fetching bootstrap script from bootstrap_url: %w", err)
+	}
+	if err := VerifyScript(script, src.SHA256); err != nil {
+		return Source{}, fmt.Errorf("bootstrap_url %s: %w", r.MirrorURL, err)
+	}
+	src.URL = r.MirrorURL
+	return src, nil
+}
+
+// resolve picks the source before any mirror is applied.
+func (r *Resolver) resolve(ctx context.Context) (Source, error) {
+	if r.ManifestURL == "" {
+		return EmbeddedSource(r.ScriptURL), nil
+	}
+
+	data, err := r.fetch(ctx, r.ManifestURL, maxManifestBytes)
+	if err != nil {
+		return EmbeddedSource(r.ScriptURL), nil
+	}
+	sig, err := r.fetch(ctx, r.ManifestURL+".sig", maxManifestBytes)
+	if err != nil {
+		return EmbeddedSource(r.ScriptURL), nil
+	}
+
+	pub, err := r.publicKey()
+	if err != nil {
+		return Source{}, err
+	}
+	m, err := ParseManifest(data, sig, pub)
+	if err != nil {
+		return Source{}, err
+	}
+	if cliOlderThan(r.CLIVersion, m.MinCLIVersion) {
+		return Source{}, &CLITooOldError{ManifestVersion: m.Version, Required: m.MinCLIVersion, Current: r.CLIVersion}
+	}
+	return manifestSource(m), nil
+}
+
+func (r *Resolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (r *Resolver) publicKey() (ed25519.PublicKey, error) {
+	if r.PublicKey != nil {
+		return r.PublicKey, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(manifestPublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("embedded bootstrap manifest key is invalid")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// fetch GETs url and returns up to limit bytes of the body. Any non-200
+// response is an error.
+func (r *Resolver) fetch(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// cliOlderThan reports whether current is an older release than required.
+// Development builds and unparseable versions are never considered older.
+func cliOlderThan(current, required string) bool {
+	if required == "" {
+		return false
+	}
+	cur, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	req, ok := parseSemver(required)
+	if !ok {
+		return false
+	}
+	for i := range cur {
+		if cur[i] != req[i] {
+			return cur[i] < req[i]
+		}
+	}
+	return false
+}
+
+// parseSemver parses "v1.2.3" or "1.2.3" into its components.
+func parseSemver(v string) ([3]int, bool) {
+	var out [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
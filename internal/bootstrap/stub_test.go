@@ -1,10 +1,32 @@
 package bootstrap
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
 
+// fullStub returns a template containing every placeholder RenderStub
+// fills, followed by extra.
+func fullStub(extra string) []byte {
+	return []byte(`#!/bin/bash
+export MINT_EFS_ID="__MINT_EFS_ID__"
+export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
+export MINT_VM_NAME="__MINT_VM_NAME__"
+export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
+export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
+_URL="__MINT_BOOTSTRAP_URL__"
+_SHA="__MINT_BOOTSTRAP_SHA256__"
+_INLINE="__MINT_BOOTSTRAP_INLINE__"
+` + extra)
+}
+
 func TestSetStubAndGetStub(t *testing.T) {
 	original := embeddedStub
 	defer func() { embeddedStub = original }()
@@ -24,7 +46,7 @@ func TestRenderStubReturnsErrorWhenNotLoaded(t *testing.T) {
 
 	embeddedStub = nil
 
-	_, err := RenderStub("sha", "url", "efs-id", "/dev/xvdf", "default", "60", "")
+	_, err := RenderStub("sha", "url", "", "efs-id", "/dev/xvdf", "default", "60", "", "", false, nil)
 	if err == nil {
 		t.Fatal("expected error when stub template not loaded, got nil")
 	}
@@ -37,25 +59,20 @@ func TestRenderStubSubstitutesAllPlaceholders(t *testing.T) {
 	original := embeddedStub
 	defer func() { embeddedStub = original }()
 
-	template := `#!/bin/bash
-export MINT_EFS_ID="__MINT_EFS_ID__"
-export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
-export MINT_VM_NAME="__MINT_VM_NAME__"
-export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
-export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
-_URL="__MINT_BOOTSTRAP_URL__"
-_SHA="__MINT_BOOTSTRAP_SHA256__"
-`
-	embeddedStub = []byte(template)
+	embeddedStub = fullStub("")
 
 	rendered, err := RenderStub(
 		"abc123sha",
 		"https://example.com/bootstrap.sh",
+		"",
 		"fs-0abc123",
 		"/dev/xvdf",
 		"myvm",
 		"120",
+		"2222",
 		"",
+		false,
+		nil,
 	)
 	if err != nil {
 		t.Fatalf("RenderStub returned unexpected error: %v", err)
@@ -74,6 +91,7 @@ _SHA="__MINT_BOOTSTRAP_SHA256__"
 		{"project dev", "__MINT_PROJECT_DEV__", "/dev/xvdf"},
 		{"vm name", "__MINT_VM_NAME__", "myvm"},
 		{"idle timeout", "__MINT_IDLE_TIMEOUT__", "120"},
+		{"ssh port", "__MINT_SSH_PORT__", "2222"},
 	}
 
 	for _, c := range checks {
@@ -107,20 +125,24 @@ func TestRenderStubNoRemainingPlaceholders(t *testing.T) {
 	original := embeddedStub
 	defer func() { embeddedStub = original }()
 
-	// Use a template containing all seven __PLACEHOLDER__ tokens defined in
+	// Use a template containing all ten __PLACEHOLDER__ tokens defined in
 	// scripts/bootstrap-stub.sh to verify none survive substitution.
 	template := `#!/bin/bash
 export MINT_EFS_ID="__MINT_EFS_ID__"
 export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
 export MINT_VM_NAME="__MINT_VM_NAME__"
 export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
 export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
 _URL="__MINT_BOOTSTRAP_URL__"
 _SHA="__MINT_BOOTSTRAP_SHA256__"
+_INLINE="__MINT_BOOTSTRAP_INLINE__"
 `
 	embeddedStub = []byte(template)
 
-	rendered, err := RenderStub("sha", "url", "efs", "dev", "vm", "60", "")
+	rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", "", false, nil)
 	if err != nil {
 		t.Fatalf("RenderStub error: %v", err)
 	}
@@ -130,17 +152,28 @@ _SHA="__MINT_BOOTSTRAP_SHA256__"
 	}
 }
 
+func TestRenderStubDefaultSSHPort(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	embeddedStub = fullStub("")
+
+	rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", "", false, nil)
+	if err != nil {
+		t.Fatalf("RenderStub returned unexpected error: %v", err)
+	}
+	if !strings.Contains(string(rendered), `export MINT_SSH_PORT="41122"`) {
+		t.Errorf("empty sshPort should render the default port:\n%s", rendered)
+	}
+}
+
 func TestRenderStubUserBootstrapEmpty(t *testing.T) {
 	original := embeddedStub
 	defer func() { embeddedStub = original }()
 
-	template := `#!/bin/bash
-export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
-exec /tmp/bootstrap.sh
-`
-	embeddedStub = []byte(template)
+	embeddedStub = fullStub("exec /tmp/bootstrap.sh\n")
 
-	rendered, err := RenderStub("sha", "url", "efs", "dev", "vm", "60", "")
+	rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", "", false, nil)
 	if err != nil {
 		t.Fatalf("RenderStub returned unexpected error: %v", err)
 	}
@@ -158,14 +191,10 @@ func TestRenderStubUserBootstrapNonEmpty(t *testing.T) {
 	original := embeddedStub
 	defer func() { embeddedStub = original }()
 
-	template := `#!/bin/bash
-export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
-exec /tmp/bootstrap.sh
-`
-	embeddedStub = []byte(template)
+	embeddedStub = fullStub("exec /tmp/bootstrap.sh\n")
 
 	userScript := "aGVsbG8=" // base64("hello")
-	rendered, err := RenderStub("sha", "url", "efs", "dev", "vm", "60", userScript)
+	rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", userScript, false, nil)
 	if err != nil {
 		t.Fatalf("RenderStub returned unexpected error: %v", err)
 	}
@@ -178,3 +207,267 @@ exec /tmp/bootstrap.sh
 		t.Errorf("RenderStub missing userBootstrap value %q in result:\n%s", userScript, result)
 	}
 }
+
+func TestRenderStubPrefetchImages(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	embeddedStub = fullStub("")
+
+	tests := []struct {
+		name   string
+		images []string
+		want   string
+	}{
+		{"none", nil, `export MINT_PREFETCH_IMAGES=""`},
+		{"one", []string{"node:22"}, `export MINT_PREFETCH_IMAGES="node:22"`},
+		{"several", []string{"node:22", "postgres:16"}, `export MINT_PREFETCH_IMAGES="node:22 postgres:16"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", "", false, tt.images)
+			if err != nil {
+				t.Fatalf("RenderStub returned unexpected error: %v", err)
+			}
+			if !strings.Contains(string(rendered), tt.want+"\n") {
+				t.Errorf("rendered stub missing %q:\n%s", tt.want, rendered)
+			}
+		})
+	}
+}
+
+func TestFitPrefetchImages(t *testing.T) {
+	images := []string{"aaaa", "bbbb", "cccc"} // 4 bytes each, 14 joined
+
+	tests := []struct {
+		name        string
+		stubSize    int
+		wantFit     []string
+		wantDropped int
+	}{
+		{"all fit", MaxUserDataBytes - 14, images, 0},
+		{"one byte short drops oldest", MaxUserDataBytes - 13, images[:2], 1},
+		{"exactly two", MaxUserDataBytes - 9, images[:2], 1},
+		{"only first", MaxUserDataBytes - 8, images[:1], 2},
+		{"none fit", MaxUserDataBytes - 3, []string{}, 3},
+		{"stub already full", MaxUserDataBytes, []string{}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fit, dropped := FitPrefetchImages(images, tt.stubSize)
+			if strings.Join(fit, " ") != strings.Join(tt.wantFit, " ") || dropped != tt.wantDropped {
+				t.Errorf("FitPrefetchImages(%d) = %v, %d; want %v, %d", tt.stubSize, fit, dropped, tt.wantFit, tt.wantDropped)
+			}
+		})
+	}
+
+	fit, dropped := FitPrefetchImages(nil, 100)
+	if len(fit) != 0 || dropped != 0 {
+		t.Errorf("FitPrefetchImages(nil) = %v, %d; want none", fit, dropped)
+	}
+}
+
+func TestListPlaceholders(t *testing.T) {
+	template := []byte(`A="__MINT_EFS_ID__" B="__MINT_BOOTSTRAP_SHA256__" C="__MINT_EFS_ID__" D="__OTHER__" E="$MINT_VM_NAME"`)
+	got := ListPlaceholders(template)
+	want := []string{"__MINT_EFS_ID__", "__MINT_BOOTSTRAP_SHA256__"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListPlaceholders() = %v, want %v", got, want)
+	}
+	if got := ListPlaceholders([]byte("#!/bin/bash\n")); got != nil {
+		t.Errorf("ListPlaceholders() without tokens = %v, want nil", got)
+	}
+}
+
+func TestFindMintVars(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []Unreplaced
+	}{
+		{name: "none", script: "#!/bin/bash\necho \"${PATH}\" \"$MINT_VM_NAME\"\n"},
+		{name: "bare", script: "#!/bin/bash\nEFS=\"${MINT_EFS_IDD}\"\n", want: []Unreplaced{{Name: "${MINT_EFS_IDD}", Line: 2}}},
+		{
+			name:   "bash defaults",
+			script: "A=${MINT_A:-60}\nB=${HOME:-/root}\nC=${MINT_C-}\n",
+			want:   []Unreplaced{{Name: "${MINT_A:-60}", Line: 1}, {Name: "${MINT_C-}", Line: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindMintVars([]byte(tt.script)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindMintVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckStub(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	embeddedStub = fullStub("")
+	if err := CheckStub(); err != nil {
+		t.Errorf("CheckStub() = %v, want nil", err)
+	}
+	embeddedStub = nil
+	if err := CheckStub(); err == nil {
+		t.Error("CheckStub() without a template = nil, want an error")
+	}
+}
+
+func TestRenderStubRejectsDrift(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	tests := []struct {
+		name           string
+		template       []byte
+		wantUnrendered []Unreplaced
+		wantUnused     []string
+	}{
+		{
+			name:           "placeholder the code does not fill",
+			template:       fullStub(`export MINT_REGION="__MINT_REGION__"` + "\n"),
+			wantUnrendered: []Unreplaced{{Name: "__MINT_REGION__", Line: 13}},
+		},
+		{
+			name:       "value the template does not use",
+			template:   []byte(strings.Replace(string(fullStub("")), `export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"`, "", 1)),
+			wantUnused: []string{"__MINT_PREFETCH_IMAGES__"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embeddedStub = tt.template
+			rendered, err := RenderStub("sha", "url", "", "efs", "dev", "vm", "60", "", "", false, nil)
+			var mismatch *StubMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("RenderStub() = %q, %v; want a *StubMismatchError", rendered, err)
+			}
+			if !reflect.DeepEqual(mismatch.Unrendered, tt.wantUnrendered) || !reflect.DeepEqual(mismatch.Unused, tt.wantUnused) {
+				t.Errorf("mismatch = %+v, want unrendered %v, unused %v", mismatch, tt.wantUnrendered, tt.wantUnused)
+			}
+			want := tt.wantUnused
+			for _, u := range tt.wantUnrendered {
+				want = append(want, u.String())
+			}
+			for _, token := range want {
+				if !strings.Contains(err.Error(), token) {
+					t.Errorf("error %q does not name %s", err, token)
+				}
+			}
+			if checkErr := CheckStub(); !errors.As(checkErr, &mismatch) || checkErr.Error() != err.Error() {
+				t.Errorf("CheckStub() = %v, want %v", checkErr, err)
+			}
+		})
+	}
+}
+
+// TestRenderEmbeddedStub renders the real scripts/bootstrap-stub.sh that
+// main.go embeds, so a placeholder added to the script without a value in
+// RenderStub (or the reverse) fails here rather than on a VM.
+func TestRenderEmbeddedStub(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	stub, err := os.ReadFile(filepath.Join("..", "..", "scripts", "bootstrap-stub.sh"))
+	if err != nil {
+		t.Fatalf("reading embedded stub: %v", err)
+	}
+	SetStub(stub)
+
+	rendered, err := RenderStub(ScriptSHA256, ScriptURL("1.2.3"), "", "fs-0abc123", "/dev/xvdf", "default", "60", "2222", "aGVsbG8=", false, []string{"node:22"})
+	if err != nil {
+		t.Fatalf("RenderStub(scripts/bootstrap-stub.sh): %v", err)
+	}
+	if left := ListPlaceholders(rendered); len(left) > 0 {
+		t.Errorf("rendered stub still contains %v", left)
+	}
+}
+
+func TestRenderStubInline(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	embeddedStub = fullStub("")
+
+	rendered, err := RenderStub("sha", "url", "H4sIAAAA", "efs", "dev", "vm", "60", "", "", false, nil)
+	if err != nil {
+		t.Fatalf("RenderStub returned unexpected error: %v", err)
+	}
+	if !strings.Contains(string(rendered), `_INLINE="H4sIAAAA"`) {
+		t.Errorf("rendered stub missing inline script:\n%s", rendered)
+	}
+}
+
+func TestCheckUserDataSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		inline   string
+		wantErr  bool
+		wantText []string
+	}{
+		{name: "at the limit", size: MaxUserDataBytes},
+		{
+			name:     "user bootstrap over",
+			size:     MaxUserDataBytes + 10,
+			wantErr:  true,
+			wantText: []string{"user-bootstrap.sh too large", "10 bytes over limit"},
+		},
+		{
+			name:     "inline script over",
+			size:     MaxUserDataBytes + 200,
+			inline:   strings.Repeat("A", 12000),
+			wantErr:  true,
+			wantText: []string{"bootstrap file too large to embed", "12000 of them the inline bootstrap.sh", "200 bytes over limit", "bootstrap_url"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckUserDataSize(make([]byte, tt.size), tt.inline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckUserDataSize() = %v, want error %v", err, tt.wantErr)
+			}
+			var sizeErr *UserDataSizeError
+			if tt.wantErr && !errors.As(err, &sizeErr) {
+				t.Fatalf("error %T is not a *UserDataSizeError", err)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q missing %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestEmbeddedStubFitsInlineBootstrap checks that the real bootstrap.sh,
+// embedded by --bootstrap-file, fits in user-data with room to spare.
+func TestEmbeddedStubFitsInlineBootstrap(t *testing.T) {
+	original := embeddedStub
+	defer func() { embeddedStub = original }()
+
+	stub, err := os.ReadFile(filepath.Join("..", "..", "scripts", "bootstrap-stub.sh"))
+	if err != nil {
+		t.Fatalf("reading embedded stub: %v", err)
+	}
+	script, err := os.ReadFile(filepath.Join("..", "..", "scripts", "bootstrap.sh"))
+	if err != nil {
+		t.Fatalf("reading bootstrap.sh: %v", err)
+	}
+	SetStub(stub)
+
+	src, err := InlineSource(EmbeddedSource(ScriptURL("1.2.3")), script)
+	if err != nil {
+		t.Fatalf("InlineSource: %v", err)
+	}
+	rendered, err := RenderStub(src.SHA256, src.URL, src.Inline, "fs-0abc123", "/dev/xvdf", "default", "60", "", "", false, nil)
+	if err != nil {
+		t.Fatalf("RenderStub: %v", err)
+	}
+	if err := CheckUserDataSize(rendered, src.Inline); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,67 @@
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// TimingsPath is the file on the VM where bootstrap.sh records when each of
+// its phases started and ended.
+const TimingsPath = "/mint/.mint/bootstrap-timings.json"
+
+// PhaseTiming is one bootstrap phase. End is zero for a phase that never
+// finished: bootstrap is still in it, or died in it.
+type PhaseTiming struct {
+	Phase string
+	Start time.Time
+	End   time.Time
+}
+
+// Complete reports whether the phase finished.
+func (p PhaseTiming) Complete() bool {
+	return !p.End.IsZero()
+}
+
+// Duration returns how long the phase took, or zero when it did not finish.
+func (p PhaseTiming) Duration() time.Duration {
+	if !p.Complete() {
+		return 0
+	}
+	return p.End.Sub(p.Start)
+}
+
+// timingRecord is a PhaseTiming as bootstrap.sh writes it.
+type timingRecord struct {
+	Phase string `json:"phase"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ParseTimings parses the timings file bootstrap.sh writes: a JSON array of
+// {"phase", "start", "end"} records with RFC 3339 times, where the phase in
+// progress has no end. It never fails. An empty or missing file (a VM
+// bootstrapped before timings were recorded) returns nil, a file cut off
+// mid-write returns the records before the cut, and records without a
+// phase or with a start that does not parse are skipped. An end that does
+// not parse leaves the phase unfinished.
+func ParseTimings(data []byte) []PhaseTiming {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil
+	}
+	var timings []PhaseTiming
+	for dec.More() {
+		var rec timingRecord
+		if err := dec.Decode(&rec); err != nil {
+			break
+		}
+		start, err := time.Parse(time.RFC3339, rec.Start)
+		if rec.Phase == "" || err != nil {
+			continue
+		}
+		end, _ := time.Parse(time.RFC3339, rec.End)
+		timings = append(timings, PhaseTiming{Phase: rec.Phase, Start: start, End: end})
+	}
+	return timings
+}
@@ -0,0 +1,68 @@
+package bootstrap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimings(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string // "phase duration", or "phase incomplete"
+	}{
+		{
+			name: "complete",
+			data: `[{"phase":"docker","start":"2025-01-20T10:00:00Z","end":"2025-01-20T10:02:10Z"},` +
+				`{"phase":"efs-mount","start":"2025-01-20T10:02:10Z","end":"2025-01-20T10:02:14Z"}]`,
+			want: []string{"docker 2m10s", "efs-mount 4s"},
+		},
+		{
+			name: "died mid-phase",
+			data: `[{"phase":"docker","start":"2025-01-20T10:00:00Z","end":"2025-01-20T10:02:10Z"},` +
+				`{"phase":"user-hook","start":"2025-01-20T10:02:10Z"}]`,
+			want: []string{"docker 2m10s", "user-hook incomplete"},
+		},
+		{
+			name: "cut off mid-write",
+			data: `[{"phase":"docker","start":"2025-01-20T10:00:00Z","end":"2025-01-20T10:02:10Z"},{"phase":"efs-m`,
+			want: []string{"docker 2m10s"},
+		},
+		{
+			name: "bad records skipped",
+			data: `[{"start":"2025-01-20T10:00:00Z"},{"phase":"x","start":"yesterday"},` +
+				`{"phase":"packages","start":"2025-01-20T10:00:00Z","end":"later"}]`,
+			want: []string{"packages incomplete"},
+		},
+		{name: "empty", data: "", want: nil},
+		{name: "not an array", data: `{"phase":"docker"}`, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timings := ParseTimings([]byte(tt.data))
+			var got []string
+			for _, p := range timings {
+				if p.Complete() {
+					got = append(got, p.Phase+" "+p.Duration().String())
+				} else {
+					got = append(got, p.Phase+" incomplete")
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTimings = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("phase %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPhaseTimingDurationIncomplete(t *testing.T) {
+	p := PhaseTiming{Phase: "docker", Start: time.Now()}
+	if p.Complete() || p.Duration() != 0 {
+		t.Errorf("unfinished phase: Complete() = %v, Duration() = %s", p.Complete(), p.Duration())
+	}
+}
@@ -1,8 +1,10 @@
 // Package bootstrap provides integrity verification for the EC2 bootstrap
-// script and template rendering for the bootstrap stub. The real bootstrap.sh
-// SHA256 hash is embedded at compile time (via go generate) so that the stub
-// can pass a pinned hash to EC2; the stub fetches and re-verifies at runtime
-// (ADR-0009).
+// script and template rendering for the bootstrap stub. The hash the stub
+// pins comes from a signed manifest (bootstrap.json) published alongside the
+// script, so bootstrap.sh can change without a CLI release. The real
+// bootstrap.sh SHA256 hash is also embedded at compile time (via go generate)
+// as the offline fallback; either way the stub fetches and re-verifies at
+// runtime (ADR-0009).
 package bootstrap
 
 import "fmt"
@@ -3,6 +3,7 @@ package cli
 
 import (
 	"context"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +20,100 @@ type CLIContext struct {
 	Yes     bool
 	VM      string
 	Profile string
+	// RoleARN is the role to assume before any AWS call, from --role-arn.
+	// Empty means the role_arn config setting, if any.
+	RoleARN string
+	// Offline skips AWS: commands with a local fallback use it and the
+	// rest fail fast. Set by --offline, or when AWS is found unreachable.
+	Offline bool
+	// SSHArgs are extra ssh arguments from repeated --ssh-arg flags, added
+	// to the ssh_extra_args config setting.
+	SSHArgs []string
+	// NoSSHMux is true when --no-ssh-mux asks for a new SSH connection per
+	// remote command instead of one shared connection per VM.
+	NoSSHMux bool
+	// RemoteWrites is true when the command modifies files on the VM,
+	// declared with the AnnotationRemoteWrites annotation.
+	RemoteWrites bool
+	// Command is the command path without the root name, e.g.
+	// "project add".
+	Command string
+	// DefaultConfig is true when config.toml is corrupted and the command,
+	// being read-only, runs on the default settings instead of failing.
+	DefaultConfig bool
+
+	// resources lists the AWS resources the command created, changed, or
+	// deleted, in the order they were recorded. The root command reads it
+	// after execution to write the history log.
+	resources []Resource
+
+	// agentVersions caches each VM's agent version by instance ID so it is
+	// read at most once per invocation.
+	agentVersions map[string]int
+}
+
+// AnnotationRemoteWrites marks a command that modifies files on the VM. Set
+// it to "true" in the command's Annotations.
+const AnnotationRemoteWrites = "mint:remote-writes"
+
+// Resource identifies an AWS resource a command acted on.
+type Resource struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// Resource kinds recorded by mutating commands.
+const (
+	ResourceInstance = "instance"
+	ResourceVolume   = "volume"
+	ResourceEIP      = "eip"
+	ResourceSnapshot = "snapshot"
+)
+
+// TouchResource records that the command acted on the resource with the
+// given kind and ID. Empty IDs and duplicates are ignored, and it is safe to
+// call on a nil CLIContext so commands run without a root (tests) need no
+// special handling.
+func (c *CLIContext) TouchResource(kind, id string) {
+	if c == nil || id == "" {
+		return
+	}
+	for _, r := range c.resources {
+		if r.Kind == kind && r.ID == id {
+			return
+		}
+	}
+	c.resources = append(c.resources, Resource{Kind: kind, ID: id})
+}
+
+// TouchedResources returns the resources recorded by TouchResource.
+func (c *CLIContext) TouchedResources() []Resource {
+	if c == nil {
+		return nil
+	}
+	return c.resources
+}
+
+// AgentVersion returns the cached agent version of the VM with the given
+// instance ID. It is safe to call on a nil CLIContext.
+func (c *CLIContext) AgentVersion(instanceID string) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+	v, ok := c.agentVersions[instanceID]
+	return v, ok
+}
+
+// SetAgentVersion caches the agent version of the VM with the given
+// instance ID. It is a no-op on a nil CLIContext.
+func (c *CLIContext) SetAgentVersion(instanceID string, version int) {
+	if c == nil {
+		return
+	}
+	if c.agentVersions == nil {
+		c.agentVersions = make(map[string]int)
+	}
+	c.agentVersions[instanceID] = version
 }
 
 // NewCLIContext extracts global flag values from a cobra command's persistent
@@ -33,14 +128,24 @@ func NewCLIContext(cmd *cobra.Command) *CLIContext {
 	yes, _ := pflags.GetBool("yes")
 	vm, _ := pflags.GetString("vm")
 	profile, _ := pflags.GetString("profile")
+	roleARN, _ := pflags.GetString("role-arn")
+	sshArgs, _ := pflags.GetStringArray("ssh-arg")
+	offline, _ := pflags.GetBool("offline")
+	noSSHMux, _ := pflags.GetBool("no-ssh-mux")
 
 	return &CLIContext{
-		Verbose: verbose,
-		Debug:   debug,
-		JSON:    jsonFlag,
-		Yes:     yes,
-		VM:      vm,
-		Profile: profile,
+		Verbose:      verbose,
+		Debug:        debug,
+		JSON:         jsonFlag,
+		Yes:          yes,
+		VM:           vm,
+		Profile:      profile,
+		RoleARN:      roleARN,
+		SSHArgs:      sshArgs,
+		NoSSHMux:     noSSHMux,
+		Offline:      offline,
+		RemoteWrites: cmd.Annotations[AnnotationRemoteWrites] == "true",
+		Command:      strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
 	}
 }
 
@@ -16,6 +16,7 @@ func newTestCommand(flags map[string]any) *cobra.Command {
 	cmd.PersistentFlags().Bool("yes", false, "")
 	cmd.PersistentFlags().String("vm", "default", "")
 	cmd.PersistentFlags().String("profile", "", "")
+	cmd.PersistentFlags().Bool("no-ssh-mux", false, "")
 
 	// Override values by parsing args
 	var args []string
@@ -56,11 +57,12 @@ func TestNewCLIContextDefaults(t *testing.T) {
 
 func TestNewCLIContextCapturesFlags(t *testing.T) {
 	cmd := newTestCommand(map[string]any{
-		"verbose": true,
-		"debug":   true,
-		"json":    true,
-		"yes":     true,
-		"vm":      "staging",
+		"verbose":    true,
+		"debug":      true,
+		"json":       true,
+		"yes":        true,
+		"vm":         "staging",
+		"no-ssh-mux": true,
 	})
 	ctx := NewCLIContext(cmd)
 
@@ -79,6 +81,21 @@ func TestNewCLIContextCapturesFlags(t *testing.T) {
 	if ctx.VM != "staging" {
 		t.Errorf("VM should be %q, got %q", "staging", ctx.VM)
 	}
+	if !ctx.NoSSHMux {
+		t.Error("NoSSHMux should be true")
+	}
+}
+
+func TestNewCLIContextCommandPath(t *testing.T) {
+	root := newTestCommand(nil)
+	parent := &cobra.Command{Use: "project"}
+	child := &cobra.Command{Use: "add"}
+	root.AddCommand(parent)
+	parent.AddCommand(child)
+
+	if got := NewCLIContext(child).Command; got != "project add" {
+		t.Errorf("Command = %q, want %q", got, "project add")
+	}
 }
 
 func TestNewCLIContextPartialFlags(t *testing.T) {
@@ -234,3 +251,61 @@ func TestFromContextRoundTripProfile(t *testing.T) {
 		t.Errorf("Profile mismatch after round-trip: got %q, want %q", retrieved.Profile, original.Profile)
 	}
 }
+
+func TestTouchResourceRecordsInOrderWithoutDuplicates(t *testing.T) {
+	ctx := &CLIContext{}
+	ctx.TouchResource(ResourceInstance, "i-1")
+	ctx.TouchResource(ResourceVolume, "vol-1")
+	ctx.TouchResource(ResourceInstance, "i-1")
+	ctx.TouchResource("eip", "")
+
+	got := ctx.TouchedResources()
+	want := []Resource{{Kind: "instance", ID: "i-1"}, {Kind: "volume", ID: "vol-1"}}
+	if len(got) != len(want) {
+		t.Fatalf("TouchedResources() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resource %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTouchResourceNilSafe(t *testing.T) {
+	var ctx *CLIContext
+	ctx.TouchResource(ResourceInstance, "i-1")
+	if ctx.TouchedResources() != nil {
+		t.Error("nil CLIContext should report no resources")
+	}
+}
+
+func TestNewCLIContextRemoteWritesAnnotation(t *testing.T) {
+	parent := newTestCommand(nil)
+	reader := &cobra.Command{Use: "reader"}
+	writer := &cobra.Command{Use: "writer", Annotations: map[string]string{AnnotationRemoteWrites: "true"}}
+	parent.AddCommand(reader, writer)
+
+	if NewCLIContext(reader).RemoteWrites {
+		t.Error("RemoteWrites should be false without the annotation")
+	}
+	if !NewCLIContext(writer).RemoteWrites {
+		t.Error("RemoteWrites should be true for an annotated command")
+	}
+}
+
+func TestAgentVersionCache(t *testing.T) {
+	ctx := &CLIContext{}
+	if _, ok := ctx.AgentVersion("i-1"); ok {
+		t.Error("empty cache should miss")
+	}
+	ctx.SetAgentVersion("i-1", 2)
+	if v, ok := ctx.AgentVersion("i-1"); !ok || v != 2 {
+		t.Errorf("AgentVersion(i-1) = %d, %v; want 2, true", v, ok)
+	}
+
+	var nilCtx *CLIContext
+	nilCtx.SetAgentVersion("i-1", 2)
+	if _, ok := nilCtx.AgentVersion("i-1"); ok {
+		t.Error("nil CLIContext should not cache")
+	}
+}
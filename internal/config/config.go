@@ -4,6 +4,8 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,8 +13,16 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
+
+	"github.com/SpiceLabsHQ/Mint/internal/format"
+	"github.com/SpiceLabsHQ/Mint/internal/notify"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 )
 
 // InstanceTypeValidatorFunc validates that an instance type exists in the
@@ -28,9 +38,114 @@ type Config struct {
 	InstanceType       string `mapstructure:"instance_type"       toml:"instance_type"`
 	VolumeSizeGB       int    `mapstructure:"volume_size_gb"      toml:"volume_size_gb"`
 	VolumeIOPS         int    `mapstructure:"volume_iops"         toml:"volume_iops"`
+	VolumeThroughputMB int    `mapstructure:"volume_throughput_mb" toml:"volume_throughput_mb"`
 	IdleTimeoutMinutes int    `mapstructure:"idle_timeout_minutes" toml:"idle_timeout_minutes"`
 	SSHConfigApproved  bool   `mapstructure:"ssh_config_approved" toml:"ssh_config_approved"`
 	AWSProfile         string `mapstructure:"aws_profile"         toml:"aws_profile"`
+	HistoryEnabled     bool   `mapstructure:"history_enabled"     toml:"history_enabled"`
+
+	// AuditLog turns on the audit log of mutating AWS operations at
+	// ~/.config/mint/audit.log (see mint audit show). MINT_AUDIT_LOG turns
+	// it on as well.
+	AuditLog bool `mapstructure:"audit_log" toml:"audit_log"`
+
+	// SSH settings for corporate policies, applied to every ssh mint runs.
+	SSHExtraArgs       []string `mapstructure:"ssh_extra_args"       toml:"ssh_extra_args"`
+	SSHIdentityFile    string   `mapstructure:"ssh_identity_file"    toml:"ssh_identity_file"`
+	SSHCertificateFile string   `mapstructure:"ssh_certificate_file" toml:"ssh_certificate_file"`
+	// SSHUser is the login user on the VM. Empty means the Ubuntu AMI's
+	// default, ubuntu.
+	SSHUser string `mapstructure:"ssh_user" toml:"ssh_user"`
+
+	// AdminRoleARN is the role the admin commands assume for infrastructure
+	// changes. Overridden by --admin-role.
+	AdminRoleARN string `mapstructure:"admin_role_arn" toml:"admin_role_arn"`
+
+	// RoleARN is a role every command assumes before making AWS calls, for
+	// organizations that scope developers to a project role. ExternalID is
+	// passed to sts:AssumeRole when the role's trust policy requires one.
+	// RoleARN is overridden by --role-arn.
+	RoleARN    string `mapstructure:"role_arn"    toml:"role_arn"`
+	ExternalID string `mapstructure:"external_id" toml:"external_id"`
+
+	// DestroyPlanMaxAge is how long a mint destroy --plan document can be
+	// applied. Stored as a duration such as "1h" under destroy_plan_max_age.
+	DestroyPlanMaxAge time.Duration `mapstructure:"-" toml:"-"`
+
+	// BootstrapPhaseThreshold is how long a bootstrap phase may take before
+	// mint up --verbose flags it as slow. Stored as a duration such as "5m"
+	// under bootstrap_phase_threshold.
+	BootstrapPhaseThreshold time.Duration `mapstructure:"-" toml:"-"`
+
+	// BootstrapURL is a mirror new VMs download bootstrap.sh from instead
+	// of GitHub, for networks that block it. The script there must match
+	// the published hash.
+	BootstrapURL string `mapstructure:"bootstrap_url" toml:"bootstrap_url"`
+
+	// ReleaseEIPAfterStoppedDays is how many days a VM may stay stopped
+	// before mint gc releases its Elastic IP. Zero disables the policy.
+	ReleaseEIPAfterStoppedDays int `mapstructure:"release_eip_after_stopped_days" toml:"release_eip_after_stopped_days"`
+
+	// TemplateRepo is the team template mint init --from-template applied,
+	// and TemplateCommit the commit it was at. mint doctor compares the
+	// commit with the template's HEAD. TemplateCommit is written by init
+	// only and is not a settable key.
+	TemplateRepo   string `mapstructure:"template_repo"   toml:"template_repo"`
+	TemplateCommit string `mapstructure:"template_commit" toml:"template_commit"`
+
+	// Notify is how long-running commands announce that they finished:
+	// "off" (the default), "auto", "bell", or "desktop".
+	Notify string `mapstructure:"notify" toml:"notify"`
+
+	// IPMode is how new VMs are addressed: "eip" (the default),
+	// "dualstack", or "ipv6-only" (see the tags.IPMode constants).
+	IPMode string `mapstructure:"ip_mode" toml:"ip_mode"`
+
+	// Hibernate launches new VMs hibernation-enabled, so mint down
+	// --hibernate can keep their memory across a stop.
+	Hibernate bool `mapstructure:"hibernate" toml:"hibernate"`
+
+	// KMSKeyID is the KMS key new VM volumes are encrypted with: a key ID,
+	// key ARN, alias name, or alias ARN. Empty uses the account's default
+	// EBS key.
+	KMSKeyID string `mapstructure:"kms_key_id" toml:"kms_key_id"`
+
+	// InstanceProfile is the IAM instance profile VMs are launched with,
+	// for accounts that run more than one mint deployment.
+	InstanceProfile string `mapstructure:"instance_profile" toml:"instance_profile"`
+
+	// SSHPort is the port sshd listens on and every ssh mint runs connects
+	// to.
+	SSHPort int `mapstructure:"ssh_port" toml:"ssh_port"`
+
+	// APIRetryAttempts is how many times an EC2 call that was throttled or
+	// hit a transient server error is attempted before mint gives up.
+	APIRetryAttempts int `mapstructure:"api_retry_attempts" toml:"api_retry_attempts"`
+
+	// Forwards are the local port forwards mint connect opens and the VM's
+	// ssh config Host block carries, each "3000" or "8080:80" (local:remote).
+	Forwards []string `mapstructure:"forwards" toml:"forwards"`
+
+	// SubnetID, SecurityGroupIDs, and VPCID launch VMs into an existing
+	// network instead of the default VPC's subnets and the security groups
+	// mint init created. VPCID alone picks among that VPC's subnets.
+	SubnetID         string   `mapstructure:"subnet_id"          toml:"subnet_id"`
+	SecurityGroupIDs []string `mapstructure:"security_group_ids" toml:"security_group_ids"`
+	VPCID            string   `mapstructure:"vpc_id"             toml:"vpc_id"`
+
+	// VMOverrides holds the [vm.<name>] tables: values for VMKeys that
+	// apply to one VM only, by lowercased VM name and then key, in the form
+	// Set accepts. ForVM applies them.
+	VMOverrides map[string]map[string]string `mapstructure:"-" toml:"-"`
+
+	// ExtraTags holds the [extra_tags] table: tags added to every AWS
+	// resource mint creates, alongside its own. Keys keep their case.
+	ExtraTags map[string]string `mapstructure:"-" toml:"-"`
+
+	// LegacyIdleTimeoutKey is true when the file sets the deprecated integer
+	// idle_timeout_minutes key instead of idle_timeout. Save migrates it.
+	// Not serialized.
+	LegacyIdleTimeoutKey bool `mapstructure:"-" toml:"-"`
 
 	// InstanceTypeValidator is an optional callback for AWS API validation.
 	// Set by the cmd layer when an EC2 client is available. Not serialized.
@@ -46,11 +161,87 @@ var validators = map[string]validator{
 	"instance_type":        validateInstanceType,
 	"volume_size_gb":       validateVolumeSizeGB,
 	"volume_iops":          validateVolumeIOPS,
+	"volume_throughput_mb": validateVolumeThroughputMB,
+	"idle_timeout":         validateIdleTimeout,
 	"idle_timeout_minutes": validateIdleTimeoutMinutes,
 	"ssh_config_approved":  validateSSHConfigApproved,
 	"aws_profile":          validateAWSProfile,
+	"history_enabled":      validateHistoryEnabled,
+	"audit_log":            validateAuditLog,
+	"ssh_extra_args":       validateSSHExtraArgs,
+	"ssh_identity_file":    validateSSHFilePath,
+	"ssh_certificate_file": validateSSHFilePath,
+	"ssh_user":             validateSSHUser,
+	"admin_role_arn":       validateAdminRoleARN,
+	"role_arn":             validateAdminRoleARN,
+	"external_id":          validateExternalID,
+	"destroy_plan_max_age": validateDestroyPlanMaxAge,
+	"template_repo":        ValidateTemplateRepo,
+	"notify":               validateNotify,
+	"ip_mode":              ValidateIPMode,
+	"hibernate":            validateHibernate,
+	"kms_key_id":           ValidateKMSKeyID,
+	"instance_profile":     validateInstanceProfile,
+	"ssh_port":             validateSSHPort,
+	"api_retry_attempts":   validateAPIRetryAttempts,
+	"forwards":             validateForwards,
+	"subnet_id":            ValidateSubnetID,
+	"security_group_ids":   ValidateSecurityGroupIDs,
+	"vpc_id":               ValidateVPCID,
+
+	"release_eip_after_stopped_days": validateReleaseEIPAfterStoppedDays,
+	"bootstrap_phase_threshold":      validateBootstrapPhaseThreshold,
+	"bootstrap_url":                  validateBootstrapURL,
+}
+
+// vmKeys are the keys a [vm.<name>] table may set: the settings a VM is
+// provisioned with. Everything else applies to the CLI as a whole.
+var vmKeys = map[string]bool{
+	"instance_type":        true,
+	"volume_size_gb":       true,
+	"volume_iops":          true,
+	"volume_throughput_mb": true,
+	"idle_timeout":         true,
+	"idle_timeout_minutes": true,
+	"ip_mode":              true,
+	"hibernate":            true,
+	"forwards":             true,
+	"kms_key_id":           true,
+	"instance_profile":     true,
+	"subnet_id":            true,
+	"security_group_ids":   true,
+	"vpc_id":               true,
 }
 
+// VMKeys returns the sorted list of keys a [vm.<name>] table may set.
+func VMKeys() []string {
+	keys := make([]string, 0, len(vmKeys))
+	for k := range vmKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DefaultDestroyPlanMaxAge is the destroy_plan_max_age used when the config
+// file does not set one.
+const DefaultDestroyPlanMaxAge = time.Hour
+
+// DefaultBootstrapPhaseThreshold is the bootstrap_phase_threshold used when
+// the config file does not set one.
+const DefaultBootstrapPhaseThreshold = 5 * time.Minute
+
+// DefaultInstanceProfile is the instance_profile the admin CloudFormation
+// stack creates.
+const DefaultInstanceProfile = "mint-instance-profile"
+
+// DefaultSSHPort is the non-standard ssh_port per ADR-0016.
+const DefaultSSHPort = 41122
+
+// DefaultAPIRetryAttempts is the api_retry_attempts used when the config
+// file does not set one.
+const DefaultAPIRetryAttempts = 5
+
 // ValidKeys returns the sorted list of valid config key names.
 func ValidKeys() []string {
 	keys := make([]string, 0, len(validators))
@@ -61,74 +252,406 @@ func ValidKeys() []string {
 	return keys
 }
 
-// DefaultConfigDir returns the default config directory path (~/.config/mint).
-// If MINT_CONFIG_DIR is set, that value is used instead.
+// DefaultConfigDir returns the default config directory path
+// (~/.config/mint, or %APPDATA%\mint on Windows). If MINT_CONFIG_DIR is
+// set, that value is used instead. See paths.ConfigDir.
 func DefaultConfigDir() string {
-	if dir := os.Getenv("MINT_CONFIG_DIR"); dir != "" {
-		return dir
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(".", ".config", "mint")
-	}
-	return filepath.Join(home, ".config", "mint")
+	return paths.ConfigDir()
 }
 
 // Load reads the config file from configDir/config.toml and returns a Config
 // with defaults applied for any missing keys. If the file does not exist,
-// all defaults are returned without error.
+// all defaults are returned without error. A file that does not parse
+// returns a *ParseError.
 func Load(configDir string) (*Config, error) {
+	v := newViper()
+	v.AddConfigPath(configDir)
+
+	if err := v.ReadInConfig(); err != nil {
+		var parseErr viper.ConfigParseError
+		switch {
+		case errors.As(err, &parseErr):
+			return nil, newParseError(configDir, parseErr.Unwrap())
+		case errors.As(err, new(viper.ConfigFileNotFoundError)), os.IsNotExist(err):
+			// Ignore missing file, return defaults
+		default:
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, newParseError(configDir, err)
+	}
+
+	// idle_timeout ("90m", "2h") supersedes the legacy integer-minutes key.
+	if v.InConfig("idle_timeout") {
+		d, err := format.ParseDuration(v.GetString("idle_timeout"))
+		if err != nil {
+			return nil, fmt.Errorf("read config: idle_timeout: %w", err)
+		}
+		cfg.IdleTimeoutMinutes = int(d / time.Minute)
+	} else if v.InConfig("idle_timeout_minutes") {
+		cfg.LegacyIdleTimeoutKey = true
+	}
+
+	cfg.VMOverrides = vmOverrides(v)
+	extra, err := extraTags(v.ConfigFileUsed())
+	if err != nil {
+		return nil, newParseError(configDir, err)
+	}
+	cfg.ExtraTags = extra
+
+	cfg.DestroyPlanMaxAge = DefaultDestroyPlanMaxAge
+	if v.InConfig("destroy_plan_max_age") {
+		d, err := format.ParseDuration(v.GetString("destroy_plan_max_age"))
+		if err != nil {
+			return nil, fmt.Errorf("read config: destroy_plan_max_age: %w", err)
+		}
+		cfg.DestroyPlanMaxAge = d
+	}
+
+	cfg.BootstrapPhaseThreshold = DefaultBootstrapPhaseThreshold
+	if v.InConfig("bootstrap_phase_threshold") {
+		d, err := format.ParseDuration(v.GetString("bootstrap_phase_threshold"))
+		if err != nil {
+			return nil, fmt.Errorf("read config: bootstrap_phase_threshold: %w", err)
+		}
+		cfg.BootstrapPhaseThreshold = d
+	}
+
+	return cfg, nil
+}
+
+// vmOverrides reads the [vm.<name>] tables of v, or returns nil when there
+// are none. Values are kept as written; ForVM validates them.
+func vmOverrides(v *viper.Viper) map[string]map[string]string {
+	tables := v.GetStringMap("vm")
+	if len(tables) == 0 {
+		return nil
+	}
+	overrides := make(map[string]map[string]string, len(tables))
+	for name, raw := range tables {
+		table, ok := raw.(map[string]any)
+		if !ok {
+			continue // Validate reports it
+		}
+		values := make(map[string]string, len(table))
+		for key, value := range table {
+			// Lists such as forwards are kept space-separated, as Set
+			// takes them.
+			if list, ok := value.([]any); ok {
+				fields := make([]string, len(list))
+				for i, item := range list {
+					fields[i] = fmt.Sprint(item)
+				}
+				values[key] = strings.Join(fields, " ")
+				continue
+			}
+			values[key] = fmt.Sprint(value)
+		}
+		overrides[name] = values
+	}
+	return overrides
+}
+
+// extraTags reads the [extra_tags] table of the config file at path, or
+// returns nil when there is none. Viper lowercases keys and tag keys are
+// case-sensitive, so the table is decoded from the file itself.
+func extraTags(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil // a missing file has no table
+	}
+	var file struct {
+		ExtraTags map[string]any `toml:"extra_tags"`
+	}
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.ExtraTags) == 0 {
+		return nil, nil
+	}
+	extra := make(map[string]string, len(file.ExtraTags))
+	for key, value := range file.ExtraTags {
+		extra[key] = fmt.Sprint(value)
+	}
+	return extra, nil
+}
+
+// ForVM returns the config vmName is provisioned with: c with the values
+// of its [vm.<name>] table applied over the top-level ones. It fails,
+// naming the table and key, when the table sets a key that is not in
+// VMKeys or a value Set rejects.
+func (c *Config) ForVM(vmName string) (*Config, error) {
+	table := c.VMOverrides[strings.ToLower(vmName)]
+	out := *c
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !vmKeys[key] {
+			return nil, fmt.Errorf("[vm.%s]: %s", vmName, unknownVMKey(key))
+		}
+		// idle_timeout supersedes the legacy key, as at the top level.
+		if key == "idle_timeout_minutes" && table["idle_timeout"] != "" {
+			continue
+		}
+		if err := out.Set(key, table[key]); err != nil {
+			return nil, fmt.Errorf("[vm.%s]: %w", vmName, err)
+		}
+	}
+	return &out, nil
+}
+
+// OverriddenKeys returns the sorted keys vmName's [vm.<name>] table sets,
+// with idle_timeout_minutes reported as idle_timeout.
+func (c *Config) OverriddenKeys(vmName string) []string {
+	table := c.VMOverrides[strings.ToLower(vmName)]
+	seen := make(map[string]bool, len(table))
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		if key == "idle_timeout_minutes" {
+			key = "idle_timeout"
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unknownVMKey describes a key a [vm.<name>] table may not set.
+func unknownVMKey(key string) string {
+	return fmt.Sprintf("unknown key %q (a [vm.<name>] table may set %s)", key, strings.Join(VMKeys(), ", "))
+}
+
+// newViper returns a viper for config.toml with the defaults set.
+func newViper() *viper.Viper {
 	v := viper.New()
 	v.SetConfigName("config")
 	v.SetConfigType("toml")
-	v.AddConfigPath(configDir)
 
 	// Set defaults per SPEC.md
 	v.SetDefault("region", "")
 	v.SetDefault("instance_type", "m6i.xlarge")
 	v.SetDefault("volume_size_gb", 50)
 	v.SetDefault("volume_iops", 3000)
+	v.SetDefault("volume_throughput_mb", 125)
 	v.SetDefault("idle_timeout_minutes", 60)
 	v.SetDefault("ssh_config_approved", false)
+	v.SetDefault("history_enabled", true)
+	v.SetDefault("audit_log", false)
+	v.SetDefault("notify", notify.ModeOff)
+	v.SetDefault("ip_mode", tags.IPModeEIP)
+	v.SetDefault("hibernate", false)
+	v.SetDefault("instance_profile", DefaultInstanceProfile)
+	v.SetDefault("ssh_port", DefaultSSHPort)
+	v.SetDefault("api_retry_attempts", DefaultAPIRetryAttempts)
+	return v
+}
+
+// Defaults returns the config Load returns when there is no config file.
+func Defaults() *Config {
+	cfg := &Config{}
+	_ = newViper().Unmarshal(cfg) // defaults always decode
+	cfg.DestroyPlanMaxAge = DefaultDestroyPlanMaxAge
+	cfg.BootstrapPhaseThreshold = DefaultBootstrapPhaseThreshold
+	return cfg
+}
 
+// Validate checks configDir/config.toml: every key must be known and every
+// value must pass the checks Set applies. It returns one problem per bad
+// key, sorted. A missing file has no problems, and a file that does not
+// parse returns a *ParseError.
+func Validate(configDir string) ([]string, error) {
+	cfg, err := Load(configDir)
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(configDir, configFile))
+	v.SetConfigType("toml")
 	if err := v.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// Ignore missing file, return defaults
-			if !os.IsNotExist(err) {
-				return nil, fmt.Errorf("read config: %w", err)
-			}
+		if os.IsNotExist(err) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("read config: %w", err)
 	}
 
-	cfg := &Config{}
-	if err := v.Unmarshal(cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal config: %w", err)
+	var problems []string
+	for _, key := range v.AllKeys() {
+		if rest, ok := strings.CutPrefix(key, "vm."); ok {
+			problems = append(problems, validateVMKey(cfg, rest)...)
+			continue
+		}
+		if strings.HasPrefix(key, "extra_tags.") {
+			continue // checked as a whole below
+		}
+		validate, ok := validators[key]
+		switch {
+		case key == "template_commit":
+		case !ok:
+			problems = append(problems, fmt.Sprintf("unknown key %q", key))
+		default:
+			if value := cfg.Value(key); value != "" {
+				if err := validate(value); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+				}
+			}
+		}
 	}
+	for _, problem := range tags.ValidateExtra(cfg.ExtraTags) {
+		problems = append(problems, "extra_tags: "+problem)
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
 
-	return cfg, nil
+// validateVMKey checks one "<name>.<key>" entry of a [vm.<name>] table.
+func validateVMKey(cfg *Config, path string) []string {
+	name, key, ok := strings.Cut(path, ".")
+	if !ok {
+		return []string{fmt.Sprintf("vm.%s: must be a [vm.%s] table", path, path)}
+	}
+	if !vmKeys[key] {
+		return []string{fmt.Sprintf("vm.%s: %s", name, unknownVMKey(key))}
+	}
+	if err := validators[key](cfg.VMOverrides[name][key]); err != nil {
+		return []string{fmt.Sprintf("vm.%s.%s: %v", name, key, err)}
+	}
+	return nil
 }
 
 // Save writes the config to configDir/config.toml, creating the directory
-// if it does not exist.
+// if it does not exist. The idle timeout is always written as idle_timeout,
+// which migrates a file still using idle_timeout_minutes. The file is
+// replaced atomically, and the previous version is kept as a backup (see
+// Backups).
 func Save(cfg *Config, configDir string) error {
 	if err := os.MkdirAll(configDir, 0o700); err != nil {
 		return fmt.Errorf("create config dir: %w", err)
 	}
 
 	v := viper.New()
+	v.SetConfigType("toml")
 	v.Set("region", cfg.Region)
 	v.Set("instance_type", cfg.InstanceType)
 	v.Set("volume_size_gb", cfg.VolumeSizeGB)
 	v.Set("volume_iops", cfg.VolumeIOPS)
-	v.Set("idle_timeout_minutes", cfg.IdleTimeoutMinutes)
+	v.Set("volume_throughput_mb", cfg.VolumeThroughputMB)
+	v.Set("idle_timeout", fmt.Sprintf("%dm", cfg.IdleTimeoutMinutes))
 	v.Set("ssh_config_approved", cfg.SSHConfigApproved)
 	v.Set("aws_profile", cfg.AWSProfile)
+	v.Set("history_enabled", cfg.HistoryEnabled)
+	v.Set("audit_log", cfg.AuditLog)
+	if len(cfg.SSHExtraArgs) > 0 {
+		v.Set("ssh_extra_args", cfg.SSHExtraArgs)
+	}
+	if cfg.SSHIdentityFile != "" {
+		v.Set("ssh_identity_file", cfg.SSHIdentityFile)
+	}
+	if cfg.SSHCertificateFile != "" {
+		v.Set("ssh_certificate_file", cfg.SSHCertificateFile)
+	}
+	if cfg.SSHUser != "" {
+		v.Set("ssh_user", cfg.SSHUser)
+	}
+	if cfg.AdminRoleARN != "" {
+		v.Set("admin_role_arn", cfg.AdminRoleARN)
+	}
+	if cfg.RoleARN != "" {
+		v.Set("role_arn", cfg.RoleARN)
+	}
+	if cfg.ExternalID != "" {
+		v.Set("external_id", cfg.ExternalID)
+	}
+	if cfg.DestroyPlanMaxAge != 0 && cfg.DestroyPlanMaxAge != DefaultDestroyPlanMaxAge {
+		v.Set("destroy_plan_max_age", format.FormatDuration(cfg.DestroyPlanMaxAge))
+	}
+	if cfg.BootstrapPhaseThreshold != 0 && cfg.BootstrapPhaseThreshold != DefaultBootstrapPhaseThreshold {
+		v.Set("bootstrap_phase_threshold", format.FormatDuration(cfg.BootstrapPhaseThreshold))
+	}
+	if cfg.BootstrapURL != "" {
+		v.Set("bootstrap_url", cfg.BootstrapURL)
+	}
+	if cfg.ReleaseEIPAfterStoppedDays > 0 {
+		v.Set("release_eip_after_stopped_days", cfg.ReleaseEIPAfterStoppedDays)
+	}
+	if cfg.TemplateRepo != "" {
+		v.Set("template_repo", cfg.TemplateRepo)
+	}
+	if cfg.TemplateCommit != "" {
+		v.Set("template_commit", cfg.TemplateCommit)
+	}
+	if cfg.Notify != "" && cfg.Notify != notify.ModeOff {
+		v.Set("notify", cfg.Notify)
+	}
+	if cfg.IPMode != "" && cfg.IPMode != tags.IPModeEIP {
+		v.Set("ip_mode", cfg.IPMode)
+	}
+	if cfg.Hibernate {
+		v.Set("hibernate", true)
+	}
+	if cfg.KMSKeyID != "" {
+		v.Set("kms_key_id", cfg.KMSKeyID)
+	}
+	if cfg.InstanceProfile != "" && cfg.InstanceProfile != DefaultInstanceProfile {
+		v.Set("instance_profile", cfg.InstanceProfile)
+	}
+	if cfg.SSHPort != 0 && cfg.SSHPort != DefaultSSHPort {
+		v.Set("ssh_port", cfg.SSHPort)
+	}
+	if cfg.APIRetryAttempts != 0 && cfg.APIRetryAttempts != DefaultAPIRetryAttempts {
+		v.Set("api_retry_attempts", cfg.APIRetryAttempts)
+	}
+	if len(cfg.Forwards) > 0 {
+		v.Set("forwards", cfg.Forwards)
+	}
+	if cfg.SubnetID != "" {
+		v.Set("subnet_id", cfg.SubnetID)
+	}
+	if len(cfg.SecurityGroupIDs) > 0 {
+		v.Set("security_group_ids", cfg.SecurityGroupIDs)
+	}
+	if cfg.VPCID != "" {
+		v.Set("vpc_id", cfg.VPCID)
+	}
+	for name, table := range cfg.VMOverrides {
+		for key, value := range table {
+			// Write numbers as TOML integers, as the top-level keys are.
+			if key == "forwards" {
+				v.Set("vm."+name+"."+key, strings.Fields(value))
+			} else if n, err := strconv.Atoi(value); err == nil {
+				v.Set("vm."+name+"."+key, n)
+			} else {
+				v.Set("vm."+name+"."+key, value)
+			}
+		}
+	}
 
-	path := filepath.Join(configDir, "config.toml")
-	if err := v.WriteConfigAs(path); err != nil {
-		return err
+	var buf bytes.Buffer
+	if err := v.WriteConfigTo(&buf); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	// Viper would lowercase the tag keys, so the table is encoded here and
+	// appended after everything else.
+	if len(cfg.ExtraTags) > 0 {
+		table, err := toml.Marshal(map[string]map[string]string{"extra_tags": cfg.ExtraTags})
+		if err != nil {
+			return fmt.Errorf("encode config: %w", err)
+		}
+		buf.WriteString("\n")
+		buf.Write(table)
 	}
-	return os.Chmod(path, 0o600)
+	return writeConfigFile(configDir, buf.Bytes())
 }
 
 // Set validates and applies a single key-value pair to the config.
@@ -158,11 +681,17 @@ func (c *Config) Set(key, value string) error {
 	case "instance_type":
 		c.InstanceType = value
 	case "volume_size_gb":
-		n, _ := strconv.Atoi(value) // already validated
+		n, _ := format.ParseSizeGiB(value) // already validated
 		c.VolumeSizeGB = n
 	case "volume_iops":
 		n, _ := strconv.Atoi(value) // already validated
 		c.VolumeIOPS = n
+	case "volume_throughput_mb":
+		n, _ := strconv.Atoi(value) // already validated
+		c.VolumeThroughputMB = n
+	case "idle_timeout":
+		d, _ := format.ParseDuration(value) // already validated
+		c.IdleTimeoutMinutes = int(d / time.Minute)
 	case "idle_timeout_minutes":
 		n, _ := strconv.Atoi(value) // already validated
 		c.IdleTimeoutMinutes = n
@@ -170,11 +699,207 @@ func (c *Config) Set(key, value string) error {
 		c.SSHConfigApproved = value == "true"
 	case "aws_profile":
 		c.AWSProfile = value
+	case "history_enabled":
+		c.HistoryEnabled = value == "true"
+	case "audit_log":
+		c.AuditLog = value == "true"
+	case "ssh_extra_args":
+		c.SSHExtraArgs = strings.Fields(value)
+	case "ssh_identity_file":
+		c.SSHIdentityFile = value
+	case "ssh_certificate_file":
+		c.SSHCertificateFile = value
+	case "ssh_user":
+		c.SSHUser = value
+	case "admin_role_arn":
+		c.AdminRoleARN = value
+	case "role_arn":
+		c.RoleARN = value
+	case "external_id":
+		c.ExternalID = value
+	case "destroy_plan_max_age":
+		d, _ := format.ParseDuration(value) // already validated
+		c.DestroyPlanMaxAge = d
+	case "release_eip_after_stopped_days":
+		n, _ := strconv.Atoi(value) // already validated
+		c.ReleaseEIPAfterStoppedDays = n
+	case "bootstrap_phase_threshold":
+		d, _ := format.ParseDuration(value) // already validated
+		c.BootstrapPhaseThreshold = d
+	case "bootstrap_url":
+		c.BootstrapURL = value
+	case "template_repo":
+		if value != c.TemplateRepo {
+			c.TemplateCommit = ""
+		}
+		c.TemplateRepo = value
+	case "notify":
+		c.Notify = value
+	case "ip_mode":
+		c.IPMode = value
+	case "hibernate":
+		c.Hibernate = value == "true"
+	case "kms_key_id":
+		c.KMSKeyID = value
+	case "instance_profile":
+		c.InstanceProfile = value
+	case "ssh_port":
+		n, _ := strconv.Atoi(value) // already validated
+		c.SSHPort = n
+	case "api_retry_attempts":
+		n, _ := strconv.Atoi(value) // already validated
+		c.APIRetryAttempts = n
+	case "forwards":
+		c.Forwards = strings.Fields(value)
+	case "subnet_id":
+		c.SubnetID = value
+	case "security_group_ids":
+		c.SecurityGroupIDs = strings.Fields(value)
+	case "vpc_id":
+		c.VPCID = value
 	}
 
 	return nil
 }
 
+// builtinDefaults are the values Load applies for keys the file does not
+// set, in the form Set accepts.
+var builtinDefaults = map[string]string{
+	"instance_type":        "m6i.xlarge",
+	"volume_size_gb":       "50",
+	"volume_iops":          "3000",
+	"volume_throughput_mb": "125",
+	"idle_timeout":         "60m",
+	"ssh_config_approved":  "false",
+	"history_enabled":      "true",
+	"audit_log":            "false",
+	"destroy_plan_max_age": "1h",
+	"notify":               notify.ModeOff,
+	"ip_mode":              tags.IPModeEIP,
+	"hibernate":            "false",
+	"instance_profile":     DefaultInstanceProfile,
+	"ssh_port":             strconv.Itoa(DefaultSSHPort),
+	"api_retry_attempts":   strconv.Itoa(DefaultAPIRetryAttempts),
+
+	"bootstrap_phase_threshold": "5m",
+}
+
+// ExplicitKeys returns the keys configDir/config.toml sets to something
+// other than the built-in default. Save writes every basic key, so a key
+// merely being present does not mean the user chose it. The legacy
+// idle_timeout_minutes key is reported as idle_timeout. A missing file
+// returns an empty set.
+func ExplicitKeys(configDir string) (map[string]bool, error) {
+	cfg, err := Load(configDir)
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(configDir, "config.toml"))
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		if os.IsNotExist(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	explicit := make(map[string]bool)
+	for key := range validators {
+		if !v.InConfig(key) {
+			continue
+		}
+		canonical := key
+		if key == "idle_timeout_minutes" {
+			canonical = "idle_timeout"
+		}
+		if value := cfg.Value(canonical); value != "" && value != builtinDefaults[canonical] {
+			explicit[canonical] = true
+		}
+	}
+	return explicit, nil
+}
+
+// Value returns key's value in the form Set accepts, or "" when it is
+// unset.
+func (c *Config) Value(key string) string {
+	switch key {
+	case "region":
+		return c.Region
+	case "instance_type":
+		return c.InstanceType
+	case "volume_size_gb":
+		return strconv.Itoa(c.VolumeSizeGB)
+	case "volume_iops":
+		return strconv.Itoa(c.VolumeIOPS)
+	case "volume_throughput_mb":
+		return strconv.Itoa(c.VolumeThroughputMB)
+	case "idle_timeout":
+		return fmt.Sprintf("%dm", c.IdleTimeoutMinutes)
+	case "idle_timeout_minutes":
+		return strconv.Itoa(c.IdleTimeoutMinutes)
+	case "ssh_config_approved":
+		return strconv.FormatBool(c.SSHConfigApproved)
+	case "aws_profile":
+		return c.AWSProfile
+	case "history_enabled":
+		return strconv.FormatBool(c.HistoryEnabled)
+	case "audit_log":
+		return strconv.FormatBool(c.AuditLog)
+	case "ssh_extra_args":
+		return strings.Join(c.SSHExtraArgs, " ")
+	case "ssh_identity_file":
+		return c.SSHIdentityFile
+	case "ssh_certificate_file":
+		return c.SSHCertificateFile
+	case "ssh_user":
+		return c.SSHUser
+	case "admin_role_arn":
+		return c.AdminRoleARN
+	case "role_arn":
+		return c.RoleARN
+	case "external_id":
+		return c.ExternalID
+	case "destroy_plan_max_age":
+		return format.FormatDuration(c.DestroyPlanMaxAge)
+	case "release_eip_after_stopped_days":
+		if c.ReleaseEIPAfterStoppedDays == 0 {
+			return ""
+		}
+		return strconv.Itoa(c.ReleaseEIPAfterStoppedDays)
+	case "bootstrap_phase_threshold":
+		return format.FormatDuration(c.BootstrapPhaseThreshold)
+	case "bootstrap_url":
+		return c.BootstrapURL
+	case "template_repo":
+		return c.TemplateRepo
+	case "notify":
+		return c.Notify
+	case "ip_mode":
+		return c.IPMode
+	case "hibernate":
+		return strconv.FormatBool(c.Hibernate)
+	case "kms_key_id":
+		return c.KMSKeyID
+	case "instance_profile":
+		return c.InstanceProfile
+	case "ssh_port":
+		return strconv.Itoa(c.SSHPort)
+	case "api_retry_attempts":
+		return strconv.Itoa(c.APIRetryAttempts)
+	case "forwards":
+		return strings.Join(c.Forwards, " ")
+	case "subnet_id":
+		return c.SubnetID
+	case "security_group_ids":
+		return strings.Join(c.SecurityGroupIDs, " ")
+	case "vpc_id":
+		return c.VPCID
+	default:
+		return ""
+	}
+}
+
 // regionPattern matches valid AWS region formats like us-west-2, eu-central-1.
 var regionPattern = regexp.MustCompile(`^[a-z]{2}-[a-z]+-\d+$`)
 
@@ -195,10 +920,12 @@ func validateInstanceType(value string) error {
 	return nil
 }
 
+// validateVolumeSizeGB accepts a bare number of GiB or a size such as
+// "200GiB" or "1TiB".
 func validateVolumeSizeGB(value string) error {
-	n, err := strconv.Atoi(value)
+	n, err := format.ParseSizeGiB(value)
 	if err != nil {
-		return fmt.Errorf("%q is not a valid integer", value)
+		return err
 	}
 	if n < 50 {
 		return fmt.Errorf("must be >= 50 (got %d)", n)
@@ -220,6 +947,40 @@ func validateVolumeIOPS(value string) error {
 	return nil
 }
 
+func validateVolumeThroughputMB(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid integer", value)
+	}
+	if n < 125 {
+		return fmt.Errorf("must be >= 125 (got %d)", n)
+	}
+	if n > 1000 {
+		return fmt.Errorf("must be <= 1000 (got %d)", n)
+	}
+	return nil
+}
+
+// minIdleTimeout is the shortest idle timeout the idle detector supports.
+const minIdleTimeout = 15 * time.Minute
+
+// validateIdleTimeout accepts a duration such as "90m", "2h", or "1d".
+func validateIdleTimeout(value string) error {
+	d, err := format.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d%time.Minute != 0 {
+		return fmt.Errorf("must be a whole number of minutes (got %s)", format.FormatDuration(d))
+	}
+	if d < minIdleTimeout {
+		return fmt.Errorf("must be at least %s (got %s)", format.FormatDuration(minIdleTimeout), format.FormatDuration(d))
+	}
+	return nil
+}
+
+// validateIdleTimeoutMinutes validates the deprecated integer-minutes key,
+// still accepted for existing scripts.
 func validateIdleTimeoutMinutes(value string) error {
 	n, err := strconv.Atoi(value)
 	if err != nil {
@@ -238,6 +999,64 @@ func validateSSHConfigApproved(value string) error {
 	return nil
 }
 
+func validateNotify(value string) error {
+	switch value {
+	case notify.ModeOff, notify.ModeAuto, notify.ModeBell, notify.ModeDesktop:
+		return nil
+	}
+	return fmt.Errorf("%q is not a notify mode (use auto, off, bell, or desktop)", value)
+}
+
+// ValidateIPMode checks that value is an IP mode. Shared with the
+// --ip-mode flag.
+func ValidateIPMode(value string) error {
+	switch value {
+	case tags.IPModeEIP, tags.IPModeDualStack, tags.IPModeIPv6Only:
+		return nil
+	}
+	return fmt.Errorf("%q is not an IP mode (use eip, dualstack, or ipv6-only)", value)
+}
+
+// kmsKeyIDPattern matches a KMS key ID, including multi-Region keys.
+var kmsKeyIDPattern = regexp.MustCompile(`^(mrk-[0-9a-f]{32}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// ValidateKMSKeyID checks a KMS key reference: a key ID, a key or alias
+// ARN, or an alias/ name. An empty value clears it. Shared with the
+// --kms-key-id flag.
+func ValidateKMSKeyID(value string) error {
+	switch {
+	case value == "", kmsKeyIDPattern.MatchString(value):
+		return nil
+	case strings.HasPrefix(value, "alias/") && len(value) > len("alias/") && !strings.ContainsAny(value, " \t\n"):
+		return nil
+	case strings.HasPrefix(value, "arn:") && strings.Contains(value, ":kms:") &&
+		(strings.Contains(value, ":key/") || strings.Contains(value, ":alias/")):
+		return nil
+	}
+	return fmt.Errorf("%q is not a KMS key ID, key ARN, alias/<name>, or alias ARN", value)
+}
+
+func validateHistoryEnabled(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("%q is not a valid boolean (use true or false)", value)
+	}
+	return nil
+}
+
+func validateAuditLog(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("%q is not a valid boolean (use true or false)", value)
+	}
+	return nil
+}
+
+func validateHibernate(value string) error {
+	if value != "true" && value != "false" {
+		return fmt.Errorf("%q is not a valid boolean (use true or false)", value)
+	}
+	return nil
+}
+
 // validateAWSProfile accepts any non-empty string (no format constraint beyond
 // being a valid profile name) or an empty string to clear the setting.
 func validateAWSProfile(value string) error {
@@ -245,3 +1064,223 @@ func validateAWSProfile(value string) error {
 	// have no enforced format constraint in the SDK.
 	return nil
 }
+
+// validateSSHExtraArgs accepts space-separated ssh options such as
+// "-o ProxyJump=bastion -A", or an empty string to clear them. Options mint
+// manages itself (-p, -i) are rejected.
+func validateSSHExtraArgs(value string) error {
+	return sshconfig.ValidateExtraArgs(strings.Fields(value))
+}
+
+// validateSSHFilePath accepts a file path, or an empty string to clear it. The
+// file is not required to exist yet: certificates are often issued later.
+func validateSSHFilePath(value string) error {
+	if strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("path must be a single line")
+	}
+	return nil
+}
+
+// sshUserPattern matches a POSIX login name as useradd accepts it.
+var sshUserPattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// validateSSHUser accepts a login name, or an empty string to restore the
+// default.
+func validateSSHUser(value string) error {
+	if value != "" && !sshUserPattern.MatchString(value) {
+		return fmt.Errorf("%q is not a valid login name (lowercase letters, digits, '_', and '-')", value)
+	}
+	return nil
+}
+
+// instanceProfilePattern matches an IAM instance profile name.
+var instanceProfilePattern = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+
+func validateInstanceProfile(value string) error {
+	if !instanceProfilePattern.MatchString(value) {
+		return fmt.Errorf("%q is not a valid instance profile name (letters, digits, and +=,.@_-, up to 128 characters)", value)
+	}
+	return nil
+}
+
+// validateSSHPort accepts a TCP port number.
+func validateSSHPort(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid integer", value)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("must be between 1 and 65535 (got %d)", n)
+	}
+	return nil
+}
+
+// validateAPIRetryAttempts accepts how many times a throttled AWS call is
+// attempted, including the first: 1 (no retries) to 10.
+func validateAPIRetryAttempts(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid integer", value)
+	}
+	if n < 1 || n > 10 {
+		return fmt.Errorf("must be between 1 and 10 (got %d)", n)
+	}
+	return nil
+}
+
+// validateForwards accepts space-separated port forwards such as
+// "3000 8080:80", or an empty string to clear them.
+func validateForwards(value string) error {
+	_, err := sshconfig.ParseForwards(strings.Fields(value))
+	return err
+}
+
+// Subnet, security group, and VPC IDs: a type prefix and 8 or 17 hex digits.
+var (
+	subnetIDPattern        = regexp.MustCompile(`^subnet-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	securityGroupIDPattern = regexp.MustCompile(`^sg-([0-9a-f]{8}|[0-9a-f]{17})$`)
+	vpcIDPattern           = regexp.MustCompile(`^vpc-([0-9a-f]{8}|[0-9a-f]{17})$`)
+)
+
+// ValidateSubnetID accepts a subnet ID, or an empty string to go back to
+// the default subnets. Shared with the --subnet-id flag.
+func ValidateSubnetID(value string) error {
+	if value != "" && !subnetIDPattern.MatchString(value) {
+		return fmt.Errorf("%q is not a subnet ID (subnet- followed by 8 or 17 hex digits)", value)
+	}
+	return nil
+}
+
+// ValidateSecurityGroupIDs accepts space-separated security group IDs, or
+// an empty string to go back to the groups mint init created. Shared with
+// the --security-group-ids flag.
+func ValidateSecurityGroupIDs(value string) error {
+	for _, id := range strings.Fields(value) {
+		if !securityGroupIDPattern.MatchString(id) {
+			return fmt.Errorf("%q is not a security group ID (sg- followed by 8 or 17 hex digits)", id)
+		}
+	}
+	return nil
+}
+
+// ValidateVPCID accepts a VPC ID, or an empty string to go back to the
+// default VPC. Shared with the --vpc-id flag.
+func ValidateVPCID(value string) error {
+	if value != "" && !vpcIDPattern.MatchString(value) {
+		return fmt.Errorf("%q is not a VPC ID (vpc- followed by 8 or 17 hex digits)", value)
+	}
+	return nil
+}
+
+// roleARNPattern matches an IAM role ARN in any partition, including roles
+// with a path such as arn:aws:iam::123456789012:role/ops/MintAdmin.
+var roleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d{12}:role/[\w+=,.@/-]+$`)
+
+// ValidateRoleARN checks that value is an IAM role ARN. Shared with the
+// --admin-role flag.
+func ValidateRoleARN(value string) error {
+	if !roleARNPattern.MatchString(value) {
+		return fmt.Errorf("%q is not an IAM role ARN (e.g., arn:aws:iam::123456789012:role/MintAdmin)", value)
+	}
+	return nil
+}
+
+// validateAdminRoleARN accepts an IAM role ARN, or an empty string to clear it.
+func validateAdminRoleARN(value string) error {
+	if value == "" {
+		return nil
+	}
+	return ValidateRoleARN(value)
+}
+
+// externalIDPattern matches the characters STS accepts in an AssumeRole
+// external ID.
+var externalIDPattern = regexp.MustCompile(`^[\w+=,.@:/-]+$`)
+
+// validateExternalID accepts an sts:AssumeRole external ID, or an empty
+// string to clear it.
+func validateExternalID(value string) error {
+	if value == "" || (len(value) >= 2 && len(value) <= 1224 && externalIDPattern.MatchString(value)) {
+		return nil
+	}
+	return fmt.Errorf("%q is not a valid external ID (2 to 1224 letters, digits, or +=,.@:/-)", value)
+}
+
+// validateDestroyPlanMaxAge accepts a duration of at least a minute, such as
+// "30m" or "4h".
+func validateDestroyPlanMaxAge(value string) error {
+	d, err := format.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < time.Minute {
+		return fmt.Errorf("must be at least 1m (got %s)", format.FormatDuration(d))
+	}
+	return nil
+}
+
+// validateBootstrapPhaseThreshold accepts a duration of at least a second,
+// such as "90s" or "5m".
+func validateBootstrapPhaseThreshold(value string) error {
+	d, err := format.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < time.Second {
+		return fmt.Errorf("must be at least 1s (got %s)", format.FormatDuration(d))
+	}
+	return nil
+}
+
+// validateReleaseEIPAfterStoppedDays accepts a whole number of days, or 0 to
+// disable Elastic IP release.
+func validateReleaseEIPAfterStoppedDays(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid integer", value)
+	}
+	if n < 0 {
+		return fmt.Errorf("must be >= 0 (got %d)", n)
+	}
+	return nil
+}
+
+// validateBootstrapURL checks a bootstrap.sh mirror: an https:// URL. An
+// empty value clears it.
+func validateBootstrapURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	if strings.ContainsAny(value, " \t\n\"'") {
+		return fmt.Errorf("must not contain whitespace or quotes")
+	}
+	if !strings.HasPrefix(value, "https://") || len(value) == len("https://") {
+		return fmt.Errorf("must be an https:// URL")
+	}
+	return nil
+}
+
+// scpLikeURLPattern matches scp-style git remotes like
+// git@github.com:org/repo.git.
+var scpLikeURLPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:\S+$`)
+
+// ValidateTemplateRepo checks a team template location: an https:// or
+// ssh:// git URL, an scp-style git remote, or an absolute path to a local
+// repository. An empty value clears it.
+func ValidateTemplateRepo(value string) error {
+	if value == "" || filepath.IsAbs(value) {
+		return nil
+	}
+	if strings.ContainsAny(value, " \t\n") {
+		return fmt.Errorf("must not contain whitespace")
+	}
+	for _, scheme := range []string{"https://", "ssh://"} {
+		if strings.HasPrefix(value, scheme) && len(value) > len(scheme) {
+			return nil
+		}
+	}
+	if scpLikeURLPattern.MatchString(value) {
+		return nil
+	}
+	return fmt.Errorf("must be an https:// or ssh:// git URL, user@host:path, or an absolute path")
+}
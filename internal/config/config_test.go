@@ -2,9 +2,12 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadReturnsDefaults(t *testing.T) {
@@ -137,6 +140,10 @@ func TestSetValidatesVolumeSizeGB(t *testing.T) {
 		{"below minimum 30", "30", true},
 		{"below minimum 49", "49", true},
 		{"not a number", "abc", true},
+		{"GiB size", "200GiB", false},
+		{"TiB size", "1TiB", false},
+		{"size below minimum", "40GiB", true},
+		{"fractional GiB", "50.5GiB", true},
 	}
 
 	for _, tt := range tests {
@@ -181,6 +188,107 @@ func TestSetValidatesIdleTimeoutMinutes(t *testing.T) {
 	}
 }
 
+func TestSetIdleTimeout(t *testing.T) {
+	tests := []struct {
+		value       string
+		wantMinutes int
+		wantErr     string
+	}{
+		{value: "90m", wantMinutes: 90},
+		{value: "2h", wantMinutes: 120},
+		{value: "1d", wantMinutes: 1440},
+		{value: "1h30m", wantMinutes: 90},
+		{value: "15m", wantMinutes: 15},
+		{value: "10m", wantErr: "must be at least 15m (got 10m)"},
+		{value: "20m30s", wantErr: "whole number of minutes"},
+		{value: "90", wantErr: "missing unit"},
+		{value: "-2h", wantErr: "positive amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg, _ := Load(t.TempDir())
+			err := cfg.Set("idle_timeout", tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Set(idle_timeout, %q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set(idle_timeout, %q) unexpected error: %v", tt.value, err)
+			}
+			if cfg.IdleTimeoutMinutes != tt.wantMinutes {
+				t.Errorf("IdleTimeoutMinutes = %d, want %d", cfg.IdleTimeoutMinutes, tt.wantMinutes)
+			}
+		})
+	}
+}
+
+func TestLoadIdleTimeoutDuration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("idle_timeout = \"2h\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.IdleTimeoutMinutes != 120 {
+		t.Errorf("IdleTimeoutMinutes = %d, want 120", cfg.IdleTimeoutMinutes)
+	}
+	if cfg.LegacyIdleTimeoutKey {
+		t.Error("LegacyIdleTimeoutKey = true for idle_timeout")
+	}
+}
+
+func TestLoadRejectsInvalidIdleTimeout(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("idle_timeout = \"2 hours\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), "idle_timeout") {
+		t.Errorf("Load() error = %v, want idle_timeout parse error", err)
+	}
+}
+
+func TestLoadLegacyIdleTimeoutMinutesAndMigrate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("idle_timeout_minutes = 90\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.IdleTimeoutMinutes != 90 || !cfg.LegacyIdleTimeoutKey {
+		t.Fatalf("IdleTimeoutMinutes = %d, LegacyIdleTimeoutKey = %v; want 90, true", cfg.IdleTimeoutMinutes, cfg.LegacyIdleTimeoutKey)
+	}
+
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "idle_timeout_minutes") || !strings.Contains(string(data), `idle_timeout = '90m'`) {
+		t.Errorf("saved config did not migrate the legacy key:\n%s", data)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after migration error: %v", err)
+	}
+	if loaded.IdleTimeoutMinutes != 90 || loaded.LegacyIdleTimeoutKey {
+		t.Errorf("after migration IdleTimeoutMinutes = %d, LegacyIdleTimeoutKey = %v", loaded.IdleTimeoutMinutes, loaded.LegacyIdleTimeoutKey)
+	}
+}
+
 func TestSetValidatesInstanceType(t *testing.T) {
 	dir := t.TempDir()
 	cfg, _ := Load(dir)
@@ -247,9 +355,37 @@ func TestValidKeys(t *testing.T) {
 		"instance_type":        true,
 		"volume_size_gb":       true,
 		"volume_iops":          true,
+		"volume_throughput_mb": true,
+		"idle_timeout":         true,
 		"idle_timeout_minutes": true,
 		"ssh_config_approved":  true,
 		"aws_profile":          true,
+		"history_enabled":      true,
+		"audit_log":            true,
+		"ssh_extra_args":       true,
+		"ssh_identity_file":    true,
+		"ssh_certificate_file": true,
+		"ssh_user":             true,
+		"admin_role_arn":       true,
+		"role_arn":             true,
+		"external_id":          true,
+		"destroy_plan_max_age": true,
+		"template_repo":        true,
+		"notify":               true,
+		"ip_mode":              true,
+		"hibernate":            true,
+		"kms_key_id":           true,
+		"instance_profile":     true,
+		"ssh_port":             true,
+		"api_retry_attempts":   true,
+		"forwards":             true,
+		"subnet_id":            true,
+		"security_group_ids":   true,
+		"vpc_id":               true,
+
+		"release_eip_after_stopped_days": true,
+		"bootstrap_phase_threshold":      true,
+		"bootstrap_url":                  true,
 	}
 
 	if len(keys) != len(expected) {
@@ -263,6 +399,235 @@ func TestValidKeys(t *testing.T) {
 	}
 }
 
+func TestHistoryEnabledDefaultsAndRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.HistoryEnabled {
+		t.Fatal("history_enabled should default to true")
+	}
+
+	if err := cfg.Set("history_enabled", "maybe"); err == nil {
+		t.Error("Set(history_enabled, maybe) expected error")
+	}
+	if err := cfg.Set("history_enabled", "false"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.HistoryEnabled {
+		t.Error("history_enabled = true after saving false")
+	}
+}
+
+func TestAuditLogDefaultsAndRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.AuditLog {
+		t.Fatal("audit_log should default to false")
+	}
+
+	if err := cfg.Set("audit_log", "yes"); err == nil {
+		t.Error("Set(audit_log, yes) expected error")
+	}
+	if err := cfg.Set("audit_log", "true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if !loaded.AuditLog {
+		t.Error("audit_log = false after saving true")
+	}
+}
+
+func TestHibernateDefaultsAndRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Hibernate {
+		t.Fatal("hibernate should default to false")
+	}
+
+	if err := cfg.Set("hibernate", "on"); err == nil {
+		t.Error("Set(hibernate, on) expected error")
+	}
+	if err := cfg.Set("hibernate", "true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if !loaded.Hibernate || loaded.Value("hibernate") != "true" {
+		t.Error("hibernate = false after saving true")
+	}
+}
+
+func TestNotifyDefaultsAndRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Notify != "off" {
+		t.Fatalf("notify = %q, want off by default", cfg.Notify)
+	}
+
+	if err := cfg.Set("notify", "loud"); err == nil {
+		t.Error("Set(notify, loud) expected error")
+	}
+	if err := cfg.Set("notify", "desktop"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.Notify != "desktop" || loaded.Value("notify") != "desktop" {
+		t.Errorf("notify = %q after saving desktop", loaded.Notify)
+	}
+}
+
+func TestIPModeDefaultsAndRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.IPMode != "eip" {
+		t.Fatalf("ip_mode = %q, want eip by default", cfg.IPMode)
+	}
+
+	if err := cfg.Set("ip_mode", "ipv4"); err == nil || !strings.Contains(err.Error(), "eip, dualstack, or ipv6-only") {
+		t.Errorf("Set(ip_mode, ipv4) error = %v", err)
+	}
+	if err := cfg.Set("ip_mode", "ipv6-only"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.IPMode != "ipv6-only" || loaded.Value("ip_mode") != "ipv6-only" {
+		t.Errorf("ip_mode = %q after saving ipv6-only", loaded.IPMode)
+	}
+}
+
+func TestInstanceProfileAndSSHPortRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.InstanceProfile != "mint-instance-profile" || cfg.SSHPort != 41122 {
+		t.Fatalf("defaults = %q, %d, want mint-instance-profile, 41122", cfg.InstanceProfile, cfg.SSHPort)
+	}
+
+	for key, invalid := range map[string][]string{
+		"instance_profile": {"", "has space", strings.Repeat("a", 129)},
+		"ssh_port":         {"0", "65536", "ssh"},
+	} {
+		for _, value := range invalid {
+			if err := cfg.Set(key, value); err == nil {
+				t.Errorf("Set(%s, %q) = nil, want an error", key, value)
+			}
+		}
+	}
+
+	if err := cfg.Set("instance_profile", "mint-experiments-profile"); err != nil {
+		t.Fatalf("Set instance_profile: %v", err)
+	}
+	if err := cfg.Set("ssh_port", "2222"); err != nil {
+		t.Fatalf("Set ssh_port: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.InstanceProfile != "mint-experiments-profile" || loaded.SSHPort != 2222 {
+		t.Errorf("after save: instance_profile = %q, ssh_port = %d", loaded.InstanceProfile, loaded.SSHPort)
+	}
+	if got := loaded.Value("ssh_port"); got != "2222" {
+		t.Errorf("Value(ssh_port) = %q, want 2222", got)
+	}
+}
+
+func TestKMSKeyIDValidatesAndRoundTrips(t *testing.T) {
+	for _, valid := range []string{
+		"",
+		"1234abcd-12ab-34cd-56ef-1234567890ab",
+		"mrk-1234abcd12ab34cd56ef1234567890ab",
+		"alias/mint-volumes",
+		"arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"arn:aws:kms:us-east-1:111122223333:alias/mint-volumes",
+	} {
+		if err := ValidateKMSKeyID(valid); err != nil {
+			t.Errorf("ValidateKMSKeyID(%q) = %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"mint-volumes", "alias/", "arn:aws:iam::111122223333:role/x", "1234"} {
+		if err := ValidateKMSKeyID(invalid); err == nil {
+			t.Errorf("ValidateKMSKeyID(%q) = nil, want an error", invalid)
+		}
+	}
+
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.KMSKeyID != "" {
+		t.Fatalf("kms_key_id = %q, want empty by default", cfg.KMSKeyID)
+	}
+	if err := cfg.Set("kms_key_id", "alias/mint-volumes"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.KMSKeyID != "alias/mint-volumes" || loaded.Value("kms_key_id") != "alias/mint-volumes" {
+		t.Errorf("kms_key_id = %q after saving alias/mint-volumes", loaded.KMSKeyID)
+	}
+}
+
 func TestSetAWSProfile(t *testing.T) {
 	dir := t.TempDir()
 	cfg, _ := Load(dir)
@@ -409,6 +774,52 @@ func TestSetVolumeIOPSUpdatesConfig(t *testing.T) {
 	}
 }
 
+func TestSetValidatesVolumeThroughputMB(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+	if cfg.VolumeThroughputMB != 125 {
+		t.Errorf("default VolumeThroughputMB = %d, want 125", cfg.VolumeThroughputMB)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{"minimum 125", "125", false},
+		{"maximum 1000", "1000", false},
+		{"below minimum 124", "124", true},
+		{"above maximum 1001", "1001", true},
+		{"not a number", "fast", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.Set("volume_throughput_mb", tt.value)
+			if tt.wantErr && err == nil {
+				t.Errorf("Set(volume_throughput_mb, %q) expected error, got nil", tt.value)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Set(volume_throughput_mb, %q) unexpected error: %v", tt.value, err)
+			}
+		})
+	}
+
+	if err := cfg.Set("volume_throughput_mb", "500"); err != nil {
+		t.Fatalf("Set(volume_throughput_mb, 500): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.VolumeThroughputMB != 500 {
+		t.Errorf("loaded VolumeThroughputMB = %d, want 500", loaded.VolumeThroughputMB)
+	}
+}
+
 func TestSaveAndLoadVolumeIOPS(t *testing.T) {
 	dir := t.TempDir()
 	cfg := &Config{
@@ -549,3 +960,748 @@ func TestSetAndSaveRoundtrip(t *testing.T) {
 		t.Errorf("VolumeSizeGB = %d, want 200", loaded.VolumeSizeGB)
 	}
 }
+
+func TestSSHSettingsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := cfg.Set("ssh_extra_args", "-o ProxyJump=bastion.corp -A"); err != nil {
+		t.Fatalf("Set(ssh_extra_args): %v", err)
+	}
+	if err := cfg.Set("ssh_identity_file", "~/.ssh/corp_ed25519"); err != nil {
+		t.Fatalf("Set(ssh_identity_file): %v", err)
+	}
+	if err := cfg.Set("ssh_certificate_file", "~/.ssh/corp_ed25519-cert.pub"); err != nil {
+		t.Fatalf("Set(ssh_certificate_file): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	wantArgs := []string{"-o", "ProxyJump=bastion.corp", "-A"}
+	if strings.Join(loaded.SSHExtraArgs, " ") != strings.Join(wantArgs, " ") {
+		t.Errorf("SSHExtraArgs = %q, want %q", loaded.SSHExtraArgs, wantArgs)
+	}
+	if loaded.SSHIdentityFile != "~/.ssh/corp_ed25519" {
+		t.Errorf("SSHIdentityFile = %q", loaded.SSHIdentityFile)
+	}
+	if loaded.SSHCertificateFile != "~/.ssh/corp_ed25519-cert.pub" {
+		t.Errorf("SSHCertificateFile = %q", loaded.SSHCertificateFile)
+	}
+
+	// An empty value clears the setting.
+	if err := loaded.Set("ssh_extra_args", ""); err != nil {
+		t.Fatalf("Set(ssh_extra_args, \"\"): %v", err)
+	}
+	if len(loaded.SSHExtraArgs) != 0 {
+		t.Errorf("SSHExtraArgs = %q after clearing", loaded.SSHExtraArgs)
+	}
+}
+
+func TestSSHExtraArgsRejectsConflicts(t *testing.T) {
+	cfg, _ := Load(t.TempDir())
+	for _, value := range []string{"-p 2222", "-i ~/.ssh/id", "-o Port=22", "bastion"} {
+		if err := cfg.Set("ssh_extra_args", value); err == nil {
+			t.Errorf("Set(ssh_extra_args, %q) expected error", value)
+		}
+	}
+}
+
+func TestSetSSHUser(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+
+	for _, value := range []string{"Dev", "1dev", "dev user", "dev;id", strings.Repeat("a", 33)} {
+		if err := cfg.Set("ssh_user", value); err == nil {
+			t.Errorf("Set(ssh_user, %q) expected error", value)
+		}
+	}
+
+	if err := cfg.Set("ssh_user", "dev"); err != nil {
+		t.Fatalf("Set(ssh_user): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.SSHUser != "dev" {
+		t.Errorf("SSHUser = %q, want dev", loaded.SSHUser)
+	}
+
+	// An empty value restores the default.
+	if err := loaded.Set("ssh_user", ""); err != nil {
+		t.Fatalf("Set(ssh_user, \"\"): %v", err)
+	}
+	if loaded.SSHUser != "" {
+		t.Errorf("SSHUser = %q after clearing", loaded.SSHUser)
+	}
+}
+
+func TestSetTemplateRepo(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+
+	for _, value := range []string{"github.com/acme/tmpl", "http://example.com/t", "https://", "relative/dir", "https://x.com/a b", "-uhack"} {
+		if err := cfg.Set("template_repo", value); err == nil {
+			t.Errorf("Set(template_repo, %q) expected error", value)
+		}
+	}
+	for _, value := range []string{"https://github.com/acme/tmpl", "ssh://git@github.com/acme/tmpl.git", "git@github.com:acme/tmpl.git", "/srv/tmpl"} {
+		if err := cfg.Set("template_repo", value); err != nil {
+			t.Errorf("Set(template_repo, %q) = %v", value, err)
+		}
+	}
+
+	cfg.TemplateRepo = "https://github.com/acme/mint-template"
+	cfg.TemplateCommit = "0123456789abcdef0123456789abcdef01234567"
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.TemplateRepo != cfg.TemplateRepo || loaded.TemplateCommit != cfg.TemplateCommit {
+		t.Errorf("template = %q@%q, want %q@%q", loaded.TemplateRepo, loaded.TemplateCommit, cfg.TemplateRepo, cfg.TemplateCommit)
+	}
+
+	// Pointing at another template forgets the recorded commit.
+	if err := loaded.Set("template_repo", "/srv/mint-template"); err != nil {
+		t.Fatalf("Set(template_repo): %v", err)
+	}
+	if loaded.TemplateCommit != "" {
+		t.Errorf("TemplateCommit = %q after changing template_repo", loaded.TemplateCommit)
+	}
+}
+
+func TestSetBootstrapURL(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+
+	for _, value := range []string{"http://mirror.internal/bootstrap.sh", "https://", "mirror.internal/bootstrap.sh", "https://mirror.internal/a b.sh", `https://mirror.internal/"x`} {
+		if err := cfg.Set("bootstrap_url", value); err == nil {
+			t.Errorf("Set(bootstrap_url, %q) expected error", value)
+		}
+	}
+	if err := cfg.Set("bootstrap_url", "https://mirror.internal/mint/bootstrap.sh"); err != nil {
+		t.Fatalf("Set(bootstrap_url): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.BootstrapURL != "https://mirror.internal/mint/bootstrap.sh" {
+		t.Errorf("BootstrapURL = %q after round trip", loaded.BootstrapURL)
+	}
+
+	// An empty value goes back to GitHub.
+	if err := loaded.Set("bootstrap_url", ""); err != nil {
+		t.Fatalf("Set(bootstrap_url, \"\"): %v", err)
+	}
+	if loaded.BootstrapURL != "" {
+		t.Errorf("BootstrapURL = %q after clearing", loaded.BootstrapURL)
+	}
+}
+
+func TestExplicitKeys(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := ExplicitKeys(dir)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("ExplicitKeys() without a file = %v, %v; want none", keys, err)
+	}
+
+	// Save writes every basic key; only values that differ from the
+	// defaults count as the user's.
+	cfg, _ := Load(dir)
+	if err := cfg.Set("region", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("idle_timeout", "2h"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	keys, err = ExplicitKeys(dir)
+	if err != nil {
+		t.Fatalf("ExplicitKeys(): %v", err)
+	}
+	want := map[string]bool{"region": true, "idle_timeout": true}
+	if len(keys) != len(want) {
+		t.Errorf("ExplicitKeys() = %v, want %v", keys, want)
+	}
+	for k := range want {
+		if !keys[k] {
+			t.Errorf("ExplicitKeys() missing %q", k)
+		}
+	}
+}
+
+func TestExplicitKeysLegacyIdleTimeout(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("idle_timeout_minutes = 90\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ExplicitKeys(dir)
+	if err != nil {
+		t.Fatalf("ExplicitKeys(): %v", err)
+	}
+	if !keys["idle_timeout"] || keys["idle_timeout_minutes"] {
+		t.Errorf("ExplicitKeys() = %v, want idle_timeout only", keys)
+	}
+}
+
+func TestSetAdminRoleARN(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+
+	for _, value := range []string{"MintAdmin", "arn:aws:iam::123:role/MintAdmin", "arn:aws:iam::123456789012:user/alice"} {
+		if err := cfg.Set("admin_role_arn", value); err == nil {
+			t.Errorf("Set(admin_role_arn, %q) expected error", value)
+		}
+	}
+
+	arn := "arn:aws:iam::123456789012:role/ops/MintAdmin"
+	if err := cfg.Set("admin_role_arn", arn); err != nil {
+		t.Fatalf("Set(admin_role_arn): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.AdminRoleARN != arn {
+		t.Errorf("AdminRoleARN = %q, want %q", loaded.AdminRoleARN, arn)
+	}
+
+	// An empty value clears the setting.
+	if err := loaded.Set("admin_role_arn", ""); err != nil {
+		t.Fatalf("Set(admin_role_arn, \"\"): %v", err)
+	}
+	if loaded.AdminRoleARN != "" {
+		t.Errorf("AdminRoleARN = %q after clearing", loaded.AdminRoleARN)
+	}
+}
+
+func TestSetRoleARNAndExternalID(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+
+	if err := cfg.Set("role_arn", "arn:aws:iam::123456789012:user/alice"); err == nil {
+		t.Error("Set(role_arn, user ARN) expected error")
+	}
+	for _, value := range []string{"x", "has space", "semi;colon"} {
+		if err := cfg.Set("external_id", value); err == nil {
+			t.Errorf("Set(external_id, %q) expected error", value)
+		}
+	}
+
+	arn := "arn:aws:iam::123456789012:role/ProjectDev"
+	if err := cfg.Set("role_arn", arn); err != nil {
+		t.Fatalf("Set(role_arn): %v", err)
+	}
+	if err := cfg.Set("external_id", "team-42:mint"); err != nil {
+		t.Fatalf("Set(external_id): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.RoleARN != arn || loaded.ExternalID != "team-42:mint" {
+		t.Errorf("RoleARN, ExternalID = %q, %q", loaded.RoleARN, loaded.ExternalID)
+	}
+}
+
+func TestSetDestroyPlanMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+	if cfg.DestroyPlanMaxAge != DefaultDestroyPlanMaxAge {
+		t.Errorf("default DestroyPlanMaxAge = %s, want %s", cfg.DestroyPlanMaxAge, DefaultDestroyPlanMaxAge)
+	}
+
+	for _, value := range []string{"", "soon", "30s"} {
+		if err := cfg.Set("destroy_plan_max_age", value); err == nil {
+			t.Errorf("Set(destroy_plan_max_age, %q) expected error", value)
+		}
+	}
+
+	if err := cfg.Set("destroy_plan_max_age", "4h"); err != nil {
+		t.Fatalf("Set(destroy_plan_max_age): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.DestroyPlanMaxAge != 4*time.Hour {
+		t.Errorf("DestroyPlanMaxAge = %s, want 4h", loaded.DestroyPlanMaxAge)
+	}
+}
+
+func TestSetBootstrapPhaseThreshold(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+	if cfg.BootstrapPhaseThreshold != DefaultBootstrapPhaseThreshold {
+		t.Errorf("default BootstrapPhaseThreshold = %s, want %s", cfg.BootstrapPhaseThreshold, DefaultBootstrapPhaseThreshold)
+	}
+
+	for _, value := range []string{"", "slow", "0s"} {
+		if err := cfg.Set("bootstrap_phase_threshold", value); err == nil {
+			t.Errorf("Set(bootstrap_phase_threshold, %q) expected error", value)
+		}
+	}
+
+	if err := cfg.Set("bootstrap_phase_threshold", "90s"); err != nil {
+		t.Fatalf("Set(bootstrap_phase_threshold): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.BootstrapPhaseThreshold != 90*time.Second {
+		t.Errorf("BootstrapPhaseThreshold = %s, want 1m 30s", loaded.BootstrapPhaseThreshold)
+	}
+}
+
+func TestSetReleaseEIPAfterStoppedDays(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+	if cfg.ReleaseEIPAfterStoppedDays != 0 {
+		t.Errorf("default ReleaseEIPAfterStoppedDays = %d, want 0 (disabled)", cfg.ReleaseEIPAfterStoppedDays)
+	}
+
+	for _, value := range []string{"", "two weeks", "-1"} {
+		if err := cfg.Set("release_eip_after_stopped_days", value); err == nil {
+			t.Errorf("Set(release_eip_after_stopped_days, %q) expected error", value)
+		}
+	}
+
+	if err := cfg.Set("release_eip_after_stopped_days", "14"); err != nil {
+		t.Fatalf("Set(release_eip_after_stopped_days): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if loaded.ReleaseEIPAfterStoppedDays != 14 {
+		t.Errorf("ReleaseEIPAfterStoppedDays = %d, want 14", loaded.ReleaseEIPAfterStoppedDays)
+	}
+}
+
+func TestSetAPIRetryAttempts(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := Load(dir)
+	if cfg.APIRetryAttempts != DefaultAPIRetryAttempts {
+		t.Errorf("default APIRetryAttempts = %d, want %d", cfg.APIRetryAttempts, DefaultAPIRetryAttempts)
+	}
+
+	for _, value := range []string{"", "five", "0", "11"} {
+		if err := cfg.Set("api_retry_attempts", value); err == nil {
+			t.Errorf("Set(api_retry_attempts, %q) expected error", value)
+		}
+	}
+
+	if err := cfg.Set("api_retry_attempts", "8"); err != nil {
+		t.Fatalf("Set(api_retry_attempts): %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load after save: %v", err)
+	}
+	if got := loaded.Value("api_retry_attempts"); got != "8" {
+		t.Errorf("Value(api_retry_attempts) = %q, want 8", got)
+	}
+}
+
+const vmOverrideConfig = `instance_type = "m6i.xlarge"
+volume_size_gb = 50
+idle_timeout = "1h"
+
+[vm.gpu]
+instance_type = "g5.2xlarge"
+volume_size_gb = 200
+idle_timeout = "3h"
+`
+
+func TestForVMAppliesOverrides(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(vmOverrideConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	gpu, err := cfg.ForVM("gpu")
+	if err != nil {
+		t.Fatalf("ForVM(gpu) error: %v", err)
+	}
+	if gpu.InstanceType != "g5.2xlarge" || gpu.VolumeSizeGB != 200 || gpu.IdleTimeoutMinutes != 180 {
+		t.Errorf("gpu = %s/%d/%d, want g5.2xlarge/200/180", gpu.InstanceType, gpu.VolumeSizeGB, gpu.IdleTimeoutMinutes)
+	}
+	if gpu.VolumeIOPS != 3000 {
+		t.Errorf("gpu VolumeIOPS = %d, want the top-level 3000", gpu.VolumeIOPS)
+	}
+	if got := strings.Join(cfg.OverriddenKeys("gpu"), ","); got != "idle_timeout,instance_type,volume_size_gb" {
+		t.Errorf("OverriddenKeys(gpu) = %s", got)
+	}
+
+	def, err := cfg.ForVM("default")
+	if err != nil {
+		t.Fatalf("ForVM(default) error: %v", err)
+	}
+	if def.InstanceType != "m6i.xlarge" || def.VolumeSizeGB != 50 || len(cfg.OverriddenKeys("default")) != 0 {
+		t.Errorf("default = %s/%d, want the top-level values", def.InstanceType, def.VolumeSizeGB)
+	}
+	if cfg.InstanceType != "m6i.xlarge" {
+		t.Errorf("ForVM changed the receiver: InstanceType = %s", cfg.InstanceType)
+	}
+}
+
+func TestForVMAppliesNetworkAndEncryptionOverrides(t *testing.T) {
+	dir := t.TempDir()
+	toml := `kms_key_id = "alias/team"
+subnet_id = "subnet-0aaa1111"
+
+[vm.secure]
+kms_key_id = "alias/secure"
+instance_profile = "mint-secure"
+subnet_id = "subnet-0bbb2222"
+security_group_ids = ["sg-0ccc3333", "sg-0ddd4444"]
+vpc_id = "vpc-0eee5555"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	secure, err := cfg.ForVM("secure")
+	if err != nil {
+		t.Fatalf("ForVM(secure) error: %v", err)
+	}
+	if secure.KMSKeyID != "alias/secure" || secure.InstanceProfile != "mint-secure" {
+		t.Errorf("secure kms/profile = %s/%s, want alias/secure/mint-secure", secure.KMSKeyID, secure.InstanceProfile)
+	}
+	if secure.SubnetID != "subnet-0bbb2222" || secure.VPCID != "vpc-0eee5555" {
+		t.Errorf("secure subnet/vpc = %s/%s", secure.SubnetID, secure.VPCID)
+	}
+	if got := strings.Join(secure.SecurityGroupIDs, ","); got != "sg-0ccc3333,sg-0ddd4444" {
+		t.Errorf("secure SecurityGroupIDs = %s", got)
+	}
+
+	def, err := cfg.ForVM("default")
+	if err != nil {
+		t.Fatalf("ForVM(default) error: %v", err)
+	}
+	if def.KMSKeyID != "alias/team" || def.SubnetID != "subnet-0aaa1111" {
+		t.Errorf("default kms/subnet = %s/%s, want the top-level values", def.KMSKeyID, def.SubnetID)
+	}
+}
+
+// TestVMKeysCoverProvisioningSettings classifies every config key as one a
+// [vm.<name>] table may set or one that deliberately applies to the CLI as a
+// whole, so a new provisioning key cannot be left out of vmKeys by accident.
+func TestVMKeysCoverProvisioningSettings(t *testing.T) {
+	perVM := map[string]bool{
+		"instance_type":        true,
+		"volume_size_gb":       true,
+		"volume_iops":          true,
+		"volume_throughput_mb": true,
+		"idle_timeout":         true,
+		"idle_timeout_minutes": true,
+		"ip_mode":              true,
+		"hibernate":            true,
+		"forwards":             true,
+		"kms_key_id":           true,
+		"instance_profile":     true,
+		"subnet_id":            true,
+		"security_group_ids":   true,
+		"vpc_id":               true,
+	}
+	excluded := map[string]string{
+		"region":                         "VMs are looked up in one region",
+		"aws_profile":                    "credentials are per CLI",
+		"admin_role_arn":                 "credentials are per CLI",
+		"role_arn":                       "credentials are per CLI",
+		"external_id":                    "credentials are per CLI",
+		"api_retry_attempts":             "applies to every AWS call",
+		"ssh_config_approved":            "consent for the whole ~/.ssh/config",
+		"ssh_extra_args":                 "client-side ssh option",
+		"ssh_identity_file":              "client-side ssh option",
+		"ssh_certificate_file":           "client-side ssh option",
+		"ssh_user":                       "one login user per AMI, shared by all VMs",
+		"ssh_port":                       "the security group opens one port for all VMs",
+		"bootstrap_url":                  "every VM bootstraps from the same script",
+		"bootstrap_phase_threshold":      "CLI progress reporting",
+		"history_enabled":                "local history file",
+		"audit_log":                      "local audit file",
+		"destroy_plan_max_age":           "local destroy plans",
+		"template_repo":                  "used by mint project add, not provisioning",
+		"notify":                         "local notifications",
+		"release_eip_after_stopped_days": "account-wide doctor policy",
+	}
+
+	for _, key := range ValidKeys() {
+		_, isExcluded := excluded[key]
+		switch {
+		case perVM[key] && isExcluded:
+			t.Errorf("%s is both a VM key and excluded", key)
+		case perVM[key] && !vmKeys[key]:
+			t.Errorf("%s is a provisioning setting but missing from vmKeys", key)
+		case !perVM[key] && !isExcluded:
+			t.Errorf("%s is unclassified: add it to vmKeys and perVM, or to excluded with a reason", key)
+		case isExcluded && vmKeys[key]:
+			t.Errorf("%s is in vmKeys but listed as excluded", key)
+		}
+	}
+	for key := range vmKeys {
+		if !perVM[key] {
+			t.Errorf("vmKeys has %s, which this test does not classify as a VM key", key)
+		}
+	}
+}
+
+func TestForVMRejectsUnknownAndInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{"not a VM key", "[vm.gpu]\nregion = \"us-west-2\"\n", `[vm.gpu]: unknown key "region"`},
+		{"misspelled", "[vm.gpu]\ninstance_typ = \"g5.2xlarge\"\n", `unknown key "instance_typ"`},
+		{"invalid value", "[vm.gpu]\nvolume_size_gb = 10\n", "[vm.gpu]: invalid value for volume_size_gb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(tt.toml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(dir)
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if _, err := cfg.ForVM("gpu"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ForVM() error = %v, want %q", err, tt.want)
+			}
+			if _, err := cfg.ForVM("default"); err != nil {
+				t.Errorf("ForVM(default) error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidateVMTables(t *testing.T) {
+	dir := t.TempDir()
+	toml := vmOverrideConfig + "\n[vm.big]\nvolume_iops = 99999\n"
+	toml = strings.Replace(toml, "[vm.gpu]\n", "[vm.gpu]\nssh_usr = \"x\"\n", 1)
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := Validate(dir)
+	if err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	got := strings.Join(problems, "\n")
+	for _, want := range []string{
+		`vm.gpu: unknown key "ssh_usr"`,
+		"vm.big.volume_iops: must be <= 16000",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems missing %q:\n%s", want, got)
+		}
+	}
+	if len(problems) != 2 {
+		t.Errorf("got %d problems, want 2:\n%s", len(problems), got)
+	}
+}
+
+func TestSavePreservesVMTables(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(vmOverrideConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if err := cfg.Set("region", "us-west-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "[vm.gpu]") || !strings.Contains(string(data), "volume_size_gb = 200") {
+		t.Errorf("saved config lost the [vm.gpu] table:\n%s", data)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after Save error: %v", err)
+	}
+	gpu, err := loaded.ForVM("gpu")
+	if err != nil {
+		t.Fatalf("ForVM(gpu) error: %v", err)
+	}
+	if gpu.InstanceType != "g5.2xlarge" || gpu.VolumeSizeGB != 200 || gpu.IdleTimeoutMinutes != 180 {
+		t.Errorf("round-tripped gpu = %s/%d/%d", gpu.InstanceType, gpu.VolumeSizeGB, gpu.IdleTimeoutMinutes)
+	}
+}
+
+func TestVMForwardsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	toml := "forwards = [\"8080:80\"]\n\n[vm.web]\nforwards = [\"3000\", 5432]\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after Save error: %v", err)
+	}
+
+	web, err := loaded.ForVM("web")
+	if err != nil {
+		t.Fatalf("ForVM(web) error: %v", err)
+	}
+	if got := strings.Join(web.Forwards, " "); got != "3000 5432" {
+		t.Errorf("web forwards = %q, want \"3000 5432\"", got)
+	}
+	if got := strings.Join(loaded.Forwards, " "); got != "8080:80" {
+		t.Errorf("top-level forwards = %q, want 8080:80", got)
+	}
+
+	for _, bad := range []string{"0", "3000 3000:80", "web"} {
+		if err := loaded.Set("forwards", bad); err == nil {
+			t.Errorf("Set(forwards, %q) expected error", bad)
+		}
+	}
+}
+
+func TestNetworkKeysRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Defaults()
+	for key, value := range map[string]string{
+		"subnet_id":          "subnet-0a1b2c3d4e5f60718",
+		"security_group_ids": "sg-0a0a0a0a sg-0b0b0b0b",
+		"vpc_id":             "vpc-0c0c0c0c",
+	} {
+		if err := cfg.Set(key, value); err != nil {
+			t.Fatalf("Set(%s, %q) error: %v", key, value, err)
+		}
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after Save error: %v", err)
+	}
+	if loaded.SubnetID != "subnet-0a1b2c3d4e5f60718" || loaded.VPCID != "vpc-0c0c0c0c" {
+		t.Errorf("subnet_id = %q, vpc_id = %q", loaded.SubnetID, loaded.VPCID)
+	}
+	if got := loaded.Value("security_group_ids"); got != "sg-0a0a0a0a sg-0b0b0b0b" {
+		t.Errorf("security_group_ids = %q", got)
+	}
+
+	for key, bad := range map[string]string{
+		"subnet_id":          "subnet-xyz",
+		"security_group_ids": "sg-0a0a0a0a default",
+		"vpc_id":             "vpc-0c0c0c0",
+	} {
+		if err := loaded.Set(key, bad); err == nil {
+			t.Errorf("Set(%s, %q) expected error", key, bad)
+		}
+	}
+}
+
+func TestExtraTagsKeepCaseThroughSave(t *testing.T) {
+	dir := t.TempDir()
+	toml := "region = \"us-east-1\"\n\n[extra_tags]\nCostCenter = \"1234\"\nTeam = \"platform\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := map[string]string{"CostCenter": "1234", "Team": "platform"}
+	if !maps.Equal(cfg.ExtraTags, want) {
+		t.Fatalf("ExtraTags = %v, want %v", cfg.ExtraTags, want)
+	}
+	if problems, err := Validate(dir); err != nil || len(problems) != 0 {
+		t.Errorf("Validate() = %v, %v; want no problems", problems, err)
+	}
+
+	if err := cfg.Set("instance_type", "m6i.2xlarge"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg, dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() after Save error: %v", err)
+	}
+	if !maps.Equal(loaded.ExtraTags, want) || loaded.InstanceType != "m6i.2xlarge" {
+		t.Errorf("after Save: ExtraTags = %v, instance_type = %s", loaded.ExtraTags, loaded.InstanceType)
+	}
+}
+
+func TestValidateReportsBadExtraTags(t *testing.T) {
+	dir := t.TempDir()
+	toml := "[extra_tags]\n\"mint:owner\" = \"bob\"\n\"aws:team\" = \"x\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := Validate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], "extra_tags: ") {
+		t.Errorf("problems = %q, want 2 extra_tags problems", problems)
+	}
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
+)
+
+// configFile is the name of the config file in the config directory.
+const configFile = "config.toml"
+
+// backupDir holds the previous versions of config.toml that Save keeps,
+// relative to the config directory.
+const backupDir = "config.d/backups"
+
+// maxBackups is how many previous versions Save keeps.
+const maxBackups = 3
+
+// backupIDLayout formats a backup's ID from the time it was taken, in UTC.
+const backupIDLayout = "2006-01-02T15:04:05Z"
+
+// nowFunc returns the current time. Tests replace it.
+var nowFunc = time.Now
+
+// ParseError reports a config file that is not valid TOML, or whose values
+// have the wrong types, such as a file truncated mid-write.
+type ParseError struct {
+	Path string
+	// Line and Column locate a TOML syntax error, counting from 1. They are
+	// zero for other errors.
+	Line, Column int
+	Err          error
+	// LatestBackup is the ID of the newest backup, empty when there is none.
+	LatestBackup string
+}
+
+func (e *ParseError) Error() string {
+	where := ""
+	if e.Line > 0 {
+		where = fmt.Sprintf(" (line %d, column %d)", e.Line, e.Column)
+	}
+	msg := fmt.Sprintf("config file %s is corrupted%s: %v", e.Path, where, e.Err)
+	if e.LatestBackup != "" {
+		return msg + " — restore the last good version with " + hint.Cmd("mint config restore --backup "+e.LatestBackup)
+	}
+	return msg + " — fix it in an editor, or delete it to start over from the defaults"
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns the ParseError for err reading the config file in
+// configDir, locating TOML syntax errors.
+func newParseError(configDir string, err error) *ParseError {
+	perr := &ParseError{Path: filepath.Join(configDir, configFile), Err: err}
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		perr.Line, perr.Column = decodeErr.Position()
+		perr.Err = decodeErr
+	}
+	if backups, _ := Backups(configDir); len(backups) > 0 {
+		perr.LatestBackup = backups[0].ID
+	}
+	return perr
+}
+
+// Backup is a previous version of config.toml kept by Save.
+type Backup struct {
+	// ID is the UTC time the version was replaced, e.g.
+	// "2025-01-20T10:11:05Z".
+	ID   string
+	Path string
+}
+
+// backupFileName returns the file name of the backup with the given ID.
+// Colons are not valid in Windows file names.
+func backupFileName(id string) string {
+	return strings.ReplaceAll(id, ":", "-") + ".toml"
+}
+
+// Backups returns the backups in configDir, newest first.
+func Backups(configDir string) ([]Backup, error) {
+	dir := filepath.Join(configDir, backupDir)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config backups: %w", err)
+	}
+	var backups []Backup
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".toml")
+		if !ok || e.IsDir() {
+			continue
+		}
+		t, err := time.Parse("2006-01-02T15-04-05Z", name)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{ID: t.Format(backupIDLayout), Path: filepath.Join(dir, e.Name())})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ID > backups[j].ID })
+	return backups, nil
+}
+
+// Restore replaces config.toml in configDir with a backup: the one whose
+// ID starts with id, or the newest when id is empty. The current file is
+// backed up first when it is valid, so a restore can itself be undone.
+func Restore(configDir, id string) (Backup, error) {
+	backups, err := Backups(configDir)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("no config backups in %s", filepath.Join(configDir, backupDir))
+	}
+	var matches []Backup
+	for _, b := range backups {
+		if id == "" || strings.HasPrefix(b.ID, id) {
+			matches = append(matches, b)
+		}
+	}
+	switch {
+	case id == "":
+		matches = matches[:1]
+	case len(matches) == 0:
+		return Backup{}, fmt.Errorf("no config backup %q — run %s to list them", id, hint.Cmd("mint config restore"))
+	case len(matches) > 1:
+		return Backup{}, fmt.Errorf("config backup %q is ambiguous: it matches %s and %d more", id, matches[0].ID, len(matches)-1)
+	}
+
+	b := matches[0]
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return Backup{}, fmt.Errorf("read config backup: %w", err)
+	}
+	if !validTOML(data) {
+		return Backup{}, fmt.Errorf("config backup %s is not valid TOML", b.ID)
+	}
+	if err := writeConfigFile(configDir, data); err != nil {
+		return Backup{}, err
+	}
+	return b, nil
+}
+
+// writeConfigFile replaces config.toml in configDir with data, keeping the
+// previous version as a backup. The file is written to a temporary file,
+// synced, and renamed into place, so a crash mid-write leaves either the
+// old or the new version, never a truncated one.
+func writeConfigFile(configDir string, data []byte) error {
+	path := filepath.Join(configDir, configFile)
+	if err := backupConfig(configDir, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// backupConfig copies the current config.toml into the backups before it
+// is replaced with data, and removes all but the newest maxBackups. A file
+// that is missing, unchanged, or not valid TOML is not backed up: a backup
+// is only useful if it can be restored.
+func backupConfig(configDir string, data []byte) error {
+	current, err := os.ReadFile(filepath.Join(configDir, configFile))
+	if err != nil || bytes.Equal(current, data) || !validTOML(current) {
+		return nil
+	}
+
+	dir := filepath.Join(configDir, backupDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create config backup dir: %w", err)
+	}
+	id := nowFunc().UTC().Format(backupIDLayout)
+	if err := writeFileAtomic(filepath.Join(dir, backupFileName(id)), current); err != nil {
+		return fmt.Errorf("back up config: %w", err)
+	}
+
+	backups, err := Backups(configDir)
+	if err != nil {
+		return err
+	}
+	for i := maxBackups; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return fmt.Errorf("remove old config backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// validTOML reports whether data parses as TOML.
+func validTOML(data []byte) bool {
+	var m map[string]any
+	return toml.Unmarshal(data, &m) == nil
+}
+
+// writeFileAtomic writes data to path with mode 0600 through a synced
+// temporary file in the same directory, renamed into place.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := paths.Chmod(tmp.Name(), paths.PrivateFileMode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	// Sync the directory so the rename itself survives a crash. Not every
+	// platform supports syncing a directory, so failures are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// stepClock makes nowFunc advance a minute per call from 2025-01-20T10:00Z.
+func stepClock(t *testing.T) {
+	t.Helper()
+	orig := nowFunc
+	now := time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC)
+	nowFunc = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	t.Cleanup(func() { nowFunc = orig })
+}
+
+func saveRegion(t *testing.T, dir, region string) {
+	t.Helper()
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Region = region
+	if err := Save(cfg, dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func backupIDs(t *testing.T, dir string) []string {
+	t.Helper()
+	backups, err := Backups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, b := range backups {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestSaveRotatesBackups(t *testing.T) {
+	stepClock(t)
+	dir := t.TempDir()
+
+	// The first save has nothing to back up.
+	saveRegion(t, dir, "us-east-1")
+	if ids := backupIDs(t, dir); ids != nil {
+		t.Fatalf("backups after first save = %q", ids)
+	}
+
+	for _, region := range []string{"us-east-2", "us-west-1", "us-west-2", "eu-west-1"} {
+		saveRegion(t, dir, region)
+	}
+	want := []string{"2025-01-20T10:04:00Z", "2025-01-20T10:03:00Z", "2025-01-20T10:02:00Z"}
+	if ids := backupIDs(t, dir); !reflect.DeepEqual(ids, want) {
+		t.Errorf("backups = %q, want %q", ids, want)
+	}
+
+	// The newest backup is the version before the last save.
+	data, err := os.ReadFile(filepath.Join(dir, backupDir, "2025-01-20T10-04-00Z.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `region = 'us-west-2'`) {
+		t.Errorf("newest backup holds:\n%s", data)
+	}
+
+	// Saving unchanged content adds no backup.
+	saveRegion(t, dir, "eu-west-1")
+	if ids := backupIDs(t, dir); !reflect.DeepEqual(ids, want) {
+		t.Errorf("unchanged save rotated backups: %q", ids)
+	}
+
+	// No temporary files are left behind.
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Errorf("temporary file left: %s", e.Name())
+		}
+	}
+}
+
+func TestSaveDoesNotBackUpCorruptFile(t *testing.T) {
+	stepClock(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("region = \"us-"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(Defaults(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if ids := backupIDs(t, dir); ids != nil {
+		t.Errorf("corrupt file was backed up: %q", ids)
+	}
+	if _, err := Load(dir); err != nil {
+		t.Errorf("saved file does not load: %v", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	stepClock(t)
+	dir := t.TempDir()
+	saveRegion(t, dir, "us-east-1")
+	saveRegion(t, dir, "us-east-2") // backs up us-east-1 as 10:01
+	saveRegion(t, dir, "us-west-2") // backs up us-east-2 as 10:02
+
+	// Truncate the file, as a crash mid-write would.
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("region = \"us-w"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Restore(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID != "2025-01-20T10:02:00Z" {
+		t.Errorf("restored %s, want the newest backup", restored.ID)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "us-east-2" {
+		t.Errorf("region = %q after restoring latest, want us-east-2", cfg.Region)
+	}
+
+	// Restoring over a valid file backs it up first, and an ID prefix
+	// selects the backup.
+	if _, err := Restore(dir, "2025-01-20T10:01"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := Load(dir); cfg.Region != "us-east-1" {
+		t.Errorf("region = %q after restoring 10:01, want us-east-1", cfg.Region)
+	}
+	if ids := backupIDs(t, dir); len(ids) != 3 {
+		t.Errorf("backups = %q, want the restored-over version added", ids)
+	}
+
+	for _, tt := range []struct{ id, wantErr string }{
+		{"2024", `no config backup "2024"`},
+		{"2025-01-20T10:0", "is ambiguous"},
+	} {
+		if _, err := Restore(dir, tt.id); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Restore(%q) error = %v, want %q", tt.id, err, tt.wantErr)
+		}
+	}
+	if _, err := Restore(t.TempDir(), ""); err == nil || !strings.Contains(err.Error(), "no config backups") {
+		t.Errorf("Restore with no backups error = %v", err)
+	}
+}
+
+func TestLoadParseErrorGuidance(t *testing.T) {
+	hint.IsTTY = false
+	stepClock(t)
+	dir := t.TempDir()
+
+	corrupt := "region = \"us-east-1\"\ninstance_type = \"m6i.xl"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(corrupt), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(dir)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("error = %v, want *ParseError", err)
+	}
+	if perr.Line != 2 || perr.Column == 0 {
+		t.Errorf("position = %d:%d, want line 2", perr.Line, perr.Column)
+	}
+	if !strings.Contains(err.Error(), "config.toml is corrupted (line 2, column") ||
+		!strings.Contains(err.Error(), "fix it in an editor, or delete it to start over from the defaults") {
+		t.Errorf("error without a backup = %v", err)
+	}
+
+	// With a backup, the error offers the newest one.
+	backups := filepath.Join(dir, backupDir)
+	os.MkdirAll(backups, 0o700)
+	for _, name := range []string{"2025-01-20T10-11-00Z.toml", "2025-01-19T08-00-00Z.toml"} {
+		if err := os.WriteFile(filepath.Join(backups, name), []byte("region = \"us-east-1\"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = Load(dir)
+	if want := "restore the last good version with `mint config restore --backup 2025-01-20T10:11:00Z`"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestLoadWrongTypeIsParseError(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("volume_size_gb = \"lots\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(dir)
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 0 {
+		t.Errorf("error = %v, want a *ParseError without a position", err)
+	}
+}
+
+func TestDefaultsMatchMissingFile(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Defaults(); !reflect.DeepEqual(got, cfg) {
+		t.Errorf("Defaults() = %+v, want %+v", got, cfg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	if problems, err := Validate(dir); err != nil || problems != nil {
+		t.Errorf("missing file: problems %q, err %v", problems, err)
+	}
+
+	content := "region = \"us-east-1\"\nvolume_size_gb = 10\nregoin = \"x\"\ntemplate_commit = \"abc\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := Validate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 || problems[0] != `unknown key "regoin"` || !strings.HasPrefix(problems[1], "volume_size_gb: ") {
+		t.Errorf("problems = %q", problems)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("region = "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var perr *ParseError
+	if _, err := Validate(dir); !errors.As(err, &perr) {
+		t.Errorf("error = %v, want *ParseError", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package devcontainer merges a laptop-side override into a repository's
+// devcontainer.json.
+//
+// Both documents may be JSONC (JSON with // and /* */ comments and trailing
+// commas), the dialect the devcontainer CLI accepts. The merge is a deep
+// merge: objects merge key by key, arrays are unioned with the base order
+// kept and new override elements appended, and any other value in the
+// override replaces the base value.
+package devcontainer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Parse decodes a JSONC document whose top-level value must be an object.
+// Numbers are kept as json.Number so re-encoding does not change them.
+func Parse(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(StripJSONC(data)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected content after the top-level value")
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("top-level value must be an object")
+	}
+	return obj, nil
+}
+
+// StripJSONC removes comments and trailing commas from a JSONC document,
+// leaving plain JSON. String literals are copied untouched, so "//" or "/*"
+// inside a string survive. Comments become whitespace so line numbers in
+// decode errors still match the input.
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end := min(j+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end - 1
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				}
+				i++
+			}
+			i++ // skip the closing '/'
+		case c == ',':
+			if next := nextSignificant(data, i+1); next == '}' || next == ']' {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// nextSignificant returns the first byte at or after i that is neither
+// whitespace nor part of a comment, or 0 at the end of the input.
+func nextSignificant(data []byte, i int) byte {
+	for i < len(data) {
+		switch {
+		case data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r':
+			i++
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case data[i] == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i < len(data) && !(data[i] == '*' && i+1 < len(data) && data[i+1] == '/') {
+				i++
+			}
+			i += 2
+		default:
+			return data[i]
+		}
+	}
+	return 0
+}
+
+// Merge deep-merges override into base and returns the result. Neither
+// input is modified.
+func Merge(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, ov := range override {
+		merged[k] = mergeValue(merged[k], ov)
+	}
+	return merged
+}
+
+func mergeValue(base, override any) any {
+	switch o := override.(type) {
+	case map[string]any:
+		if b, ok := base.(map[string]any); ok {
+			return Merge(b, o)
+		}
+	case []any:
+		if b, ok := base.([]any); ok {
+			return union(b, o)
+		}
+	}
+	return override
+}
+
+// union returns base followed by the elements of override not already in
+// it.
+func union(base, override []any) []any {
+	out := append([]any(nil), base...)
+	for _, v := range override {
+		if !containsValue(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsValue(list []any, v any) bool {
+	for _, e := range list {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// RebasePaths rewrites the relative file references in cfg so they still
+// resolve after the document moves to another directory. The devcontainer
+// CLI resolves dockerFile, context, dockerComposeFile and the build
+// equivalents relative to the config file; rel is the path from the new
+// location to the original config's directory, e.g. "../.devcontainer".
+func RebasePaths(cfg map[string]any, rel string) {
+	rebase := func(v any) any {
+		switch p := v.(type) {
+		case string:
+			return rebasePath(p, rel)
+		case []any:
+			out := make([]any, len(p))
+			for i, e := range p {
+				if s, ok := e.(string); ok {
+					out[i] = rebasePath(s, rel)
+				} else {
+					out[i] = e
+				}
+			}
+			return out
+		}
+		return v
+	}
+	for _, key := range []string{"dockerFile", "context", "dockerComposeFile"} {
+		if v, ok := cfg[key]; ok {
+			cfg[key] = rebase(v)
+		}
+	}
+	if build, ok := cfg["build"].(map[string]any); ok {
+		rebased := make(map[string]any, len(build))
+		for k, v := range build {
+			rebased[k] = v
+		}
+		for _, key := range []string{"dockerfile", "context"} {
+			if v, ok := rebased[key]; ok {
+				rebased[key] = rebase(v)
+			}
+		}
+		cfg["build"] = rebased
+	}
+}
+
+// rebasePath joins rel onto a relative path. Absolute paths and paths
+// using ${...} variables are left alone.
+func rebasePath(p, rel string) string {
+	if p == "" || path.IsAbs(p) || strings.Contains(p, "${") {
+		return p
+	}
+	return path.Join(rel, p)
+}
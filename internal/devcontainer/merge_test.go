@@ -0,0 +1,161 @@
+package devcontainer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) map[string]any {
+	t.Helper()
+	cfg, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return cfg
+}
+
+// canonical re-encodes v so tests compare JSON rather than Go values.
+func canonical(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		want     string
+	}{
+		{
+			name:     "objects deep-merge",
+			base:     `{"customizations": {"vscode": {"extensions": ["a"], "settings": {"x": 1}}}}`,
+			override: `{"customizations": {"vscode": {"settings": {"y": 2}}}}`,
+			want:     `{"customizations":{"vscode":{"extensions":["a"],"settings":{"x":1,"y":2}}}}`,
+		},
+		{
+			name:     "arrays union in base order",
+			base:     `{"forwardPorts": [3000, 8080], "runArgs": ["--init"]}`,
+			override: `{"forwardPorts": [8080, 5432], "runArgs": ["--cap-add=SYS_PTRACE"]}`,
+			want:     `{"forwardPorts":[3000,8080,5432],"runArgs":["--init","--cap-add=SYS_PTRACE"]}`,
+		},
+		{
+			name:     "arrays of objects union by value",
+			base:     `{"mounts": [{"source": "a", "target": "/a"}]}`,
+			override: `{"mounts": [{"source": "a", "target": "/a"}, {"source": "b", "target": "/b"}]}`,
+			want:     `{"mounts":[{"source":"a","target":"/a"},{"source":"b","target":"/b"}]}`,
+		},
+		{
+			name:     "scalars override",
+			base:     `{"image": "node:20", "remoteUser": "node", "init": false}`,
+			override: `{"image": "node:22", "init": true}`,
+			want:     `{"image":"node:22","init":true,"remoteUser":"node"}`,
+		},
+		{
+			name:     "type mismatch takes override",
+			base:     `{"postCreateCommand": ["npm", "ci"]}`,
+			override: `{"postCreateCommand": "make setup"}`,
+			want:     `{"postCreateCommand":"make setup"}`,
+		},
+		{
+			name:     "new keys added",
+			base:     `{"image": "node:20"}`,
+			override: `{"containerEnv": {"DEBUG": "1"}}`,
+			want:     `{"containerEnv":{"DEBUG":"1"},"image":"node:20"}`,
+		},
+		{
+			name:     "numbers kept verbatim",
+			base:     `{"hostRequirements": {"memory": "8gb", "cpus": 4}}`,
+			override: `{"hostRequirements": {"cpus": 8.0}}`,
+			want:     `{"hostRequirements":{"cpus":8.0,"memory":"8gb"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(mustParse(t, tt.base), mustParse(t, tt.override))
+			if c := canonical(t, got); c != tt.want {
+				t.Errorf("Merge = %s, want %s", c, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotModifyInputs(t *testing.T) {
+	base := mustParse(t, `{"a": {"b": 1}, "list": [1]}`)
+	override := mustParse(t, `{"a": {"c": 2}, "list": [2]}`)
+	Merge(base, override)
+	if c := canonical(t, base); c != `{"a":{"b":1},"list":[1]}` {
+		t.Errorf("base modified: %s", c)
+	}
+}
+
+func TestParseJSONC(t *testing.T) {
+	input := `// devcontainer for the API
+{
+	/* block
+	   comment */
+	"name": "api", // trailing comment
+	"image": "mcr.microsoft.com/devcontainers/go:1", /* inline */
+	"postCreateCommand": "echo // not a comment && echo /* nor this */",
+	"containerEnv": {
+		"QUOTE": "say \"hi\" // still a string",
+	},
+	"forwardPorts": [8080, 9090,],
+}
+`
+	got := mustParse(t, input)
+	want := map[string]any{
+		"name":              "api",
+		"image":             "mcr.microsoft.com/devcontainers/go:1",
+		"postCreateCommand": "echo // not a comment && echo /* nor this */",
+		"containerEnv":      map[string]any{"QUOTE": `say "hi" // still a string`},
+		"forwardPorts":      []any{json.Number("8080"), json.Number("9090")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		`["not", "an", "object"]`,
+		`{"unterminated": `,
+		`{} {}`,
+		``,
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestStripJSONCKeepsLineNumbers(t *testing.T) {
+	input := "{\n/* one\ntwo */\n\"a\": 1 // x\n}"
+	got := string(StripJSONC([]byte(input)))
+	want := "{\n\n\n\"a\": 1 \n}"
+	if got != want {
+		t.Errorf("StripJSONC = %q, want %q", got, want)
+	}
+}
+
+func TestRebasePaths(t *testing.T) {
+	cfg := mustParse(t, `{
+		"build": {"dockerfile": "Dockerfile", "context": "..", "args": {"X": "1"}},
+		"dockerComposeFile": ["docker-compose.yml", "/abs/compose.yml"],
+		"dockerFile": "${localWorkspaceFolder}/Dockerfile"
+	}`)
+	RebasePaths(cfg, "../.devcontainer")
+
+	// path.Join cleans "../.devcontainer/.." to "..".
+	want := `{"build":{"args":{"X":"1"},"context":"..","dockerfile":"../.devcontainer/Dockerfile"},` +
+		`"dockerComposeFile":["../.devcontainer/docker-compose.yml","/abs/compose.yml"],` +
+		`"dockerFile":"${localWorkspaceFolder}/Dockerfile"}`
+	if c := canonical(t, cfg); c != want {
+		t.Errorf("RebasePaths = %s\nwant %s", c, want)
+	}
+}
@@ -0,0 +1,192 @@
+// Package dirarchive packs a local directory into a gzipped tar stream for
+// mint project add to unpack on the VM. Files matched by the directory's
+// .gitignore files are left out, and so are git objects over a size limit,
+// which would otherwise dominate the upload of a repository with large
+// history.
+package dirarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options controls what Scan includes.
+type Options struct {
+	// MaxGitObjectSize is the largest file under .git/objects that is
+	// included. Zero includes every object.
+	MaxGitObjectSize int64
+}
+
+// Entry is one file, directory, or symlink in a Tree.
+type Entry struct {
+	// Path is slash-separated and relative to the Tree's root.
+	Path string
+	Info fs.FileInfo
+	// Link is the target of a symlink.
+	Link string
+}
+
+// Skipped is a git object left out for being over Options.MaxGitObjectSize.
+type Skipped struct {
+	Path string
+	Size int64
+}
+
+// Tree is the contents of a directory that Write archives.
+type Tree struct {
+	Dir     string
+	Entries []Entry
+	// Bytes is the total size of the regular files in Entries.
+	Bytes int64
+	// Skipped lists the git objects left out, in walk order.
+	Skipped []Skipped
+	// Git reports whether the directory is a git repository, that is, has
+	// a .git directory at its root.
+	Git bool
+}
+
+// Files returns the number of regular files in the tree.
+func (t *Tree) Files() int {
+	n := 0
+	for _, e := range t.Entries {
+		if e.Info.Mode().IsRegular() {
+			n++
+		}
+	}
+	return n
+}
+
+// Scan walks dir and returns what Write would archive. The .git directory
+// is never subject to .gitignore rules; sockets, devices, and other special
+// files are left out.
+func Scan(dir string, opts Options) (*Tree, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	t := &Tree{Dir: root}
+	if fi, err := os.Stat(filepath.Join(root, ".git")); err == nil && fi.IsDir() {
+		t.Git = true
+	}
+
+	var rules ignoreRules
+	if err := rules.load(root, ""); err != nil {
+		return nil, fmt.Errorf("reading .gitignore: %w", err)
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		inGit := rel == ".git" || strings.HasPrefix(rel, ".git/")
+
+		if !inGit && rules.ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := Entry{Path: rel, Info: info}
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			if !inGit {
+				if err := rules.load(root, rel); err != nil {
+					return fmt.Errorf("reading %s/.gitignore: %w", rel, err)
+				}
+			}
+		case mode&fs.ModeSymlink != 0:
+			if entry.Link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if opts.MaxGitObjectSize > 0 && strings.HasPrefix(rel, ".git/objects/") && info.Size() > opts.MaxGitObjectSize {
+				t.Skipped = append(t.Skipped, Skipped{Path: rel, Size: info.Size()})
+				return nil
+			}
+			t.Bytes += info.Size()
+		default:
+			return nil
+		}
+		t.Entries = append(t.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Write writes the tree to w as a gzipped tar with paths relative to its
+// root, reading the files as it goes.
+func (t *Tree) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, e := range t.Entries {
+		if err := t.writeEntry(tw, e); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (t *Tree) writeEntry(tw *tar.Writer, e Entry) error {
+	hdr, err := tar.FileInfoHeader(e.Info, e.Link)
+	if err != nil {
+		return fmt.Errorf("%s: %w", e.Path, err)
+	}
+	hdr.Name = e.Path
+	if e.Info.IsDir() {
+		hdr.Name += "/"
+	}
+	// Local owners mean nothing on the VM; the files belong to whoever
+	// unpacks them.
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("%s: %w", e.Path, err)
+	}
+	if !e.Info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(t.Dir, filepath.FromSlash(e.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// A file that changed size since Scan would corrupt the archive, so
+	// exactly the header's size is copied.
+	if _, err := io.CopyN(tw, f, hdr.Size); err != nil {
+		return fmt.Errorf("%s changed while uploading: %w", e.Path, err)
+	}
+	return nil
+}
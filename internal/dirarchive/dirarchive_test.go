@@ -0,0 +1,186 @@
+package dirarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeFiles creates files under dir from a map of slash paths to contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func entryPaths(tree *Tree) []string {
+	var paths []string
+	for _, e := range tree.Entries {
+		paths = append(paths, e.Path)
+	}
+	return paths
+}
+
+func TestScanHonorsGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":         "*.log\n/build/\n!keep.log\n# comment\n\nnode_modules\n",
+		"main.go":            "package main",
+		"debug.log":          "noise",
+		"keep.log":           "kept",
+		"build/out":          "binary",
+		"web/build/index.js": "not the root build",
+		"web/node_modules/x": "dep",
+		"web/.gitignore":     "*.tmp\n",
+		"web/a.tmp":          "scratch",
+		"other/a.tmp":        "not under web",
+	})
+
+	tree, err := Scan(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".gitignore", "keep.log", "main.go", "other", "other/a.tmp", "web", "web/.gitignore", "web/build", "web/build/index.js"}
+	if got := entryPaths(tree); !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+	if tree.Git {
+		t.Error("Git = true without a .git directory")
+	}
+}
+
+func TestScanSkipsLargeGitObjects(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":                 "*.pack\n",
+		".git/HEAD":                  "ref: refs/heads/main\n",
+		".git/objects/ab/cdef":       "small",
+		".git/objects/pack/big.pack": "0123456789",
+		"README":                     "hello",
+	})
+
+	tree, err := Scan(dir, Options{MaxGitObjectSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.Git {
+		t.Error("Git = false with a .git directory")
+	}
+	paths := entryPaths(tree)
+	if !slices.Contains(paths, ".git/objects/ab/cdef") || slices.Contains(paths, ".git/objects/pack/big.pack") {
+		t.Errorf("entries = %v, want small objects only", paths)
+	}
+	if len(tree.Skipped) != 1 || tree.Skipped[0] != (Skipped{Path: ".git/objects/pack/big.pack", Size: 10}) {
+		t.Errorf("Skipped = %+v", tree.Skipped)
+	}
+	if tree.Files() != 4 || tree.Bytes != int64(len("*.pack\n")+len("ref: refs/heads/main\n")+len("small")+len("hello")) {
+		t.Errorf("Files() = %d, Bytes = %d", tree.Files(), tree.Bytes)
+	}
+}
+
+func TestScanRejectsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"file": "x"})
+	if _, err := Scan(filepath.Join(dir, "file"), Options{}); err == nil {
+		t.Error("expected an error for a file")
+	}
+}
+
+func TestWriteRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/main.go": "package main", "README": "hello"})
+	if err := os.Symlink("README", filepath.Join(dir, "README.link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := Scan(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tree.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	got := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Uname != "" || hdr.Uid != 0 {
+			t.Errorf("%s keeps local owner %s/%d", hdr.Name, hdr.Uname, hdr.Uid)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			got[hdr.Name] = "-> " + hdr.Linkname
+		case tar.TypeReg:
+			data, _ := io.ReadAll(tr)
+			got[hdr.Name] = string(data)
+		default:
+			got[hdr.Name] = ""
+		}
+	}
+	want := map[string]string{"README": "hello", "README.link": "-> README", "src/": "", "src/main.go": "package main"}
+	if len(got) != len(want) {
+		t.Fatalf("archive = %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		base    string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.o", "", "a/b/c.o", false, true},
+		{"/*.o", "", "a/c.o", false, false},
+		{"/*.o", "", "c.o", false, true},
+		{"docs/**/*.md", "", "docs/a/b/x.md", false, true},
+		{"docs/**/*.md", "", "docs/x.md", false, true},
+		{"**/cache", "", "a/b/cache", true, true},
+		{"logs/**", "", "logs/x/y", false, true},
+		{"file[0-9]", "", "file7", false, true},
+		{"file[!0-9]", "", "file7", false, false},
+		{"tmp/", "", "tmp", false, false},
+		{"tmp/", "", "tmp", true, true},
+		{"*.tmp", "web/", "other/a.tmp", false, false},
+		{"a?c", "", "a/c", false, false},
+	}
+	for _, tt := range tests {
+		rule, ok := parseIgnoreLine(tt.base, tt.pattern)
+		if !ok {
+			t.Fatalf("parseIgnoreLine(%q) rejected the pattern", tt.pattern)
+		}
+		if got := (ignoreRules{rule}).ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q (base %q) ignores %q = %v, want %v", tt.pattern, tt.base, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package dirarchive
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one pattern line of a .gitignore file.
+type ignoreRule struct {
+	// base is the directory holding the .gitignore, relative to the
+	// archive root with a trailing slash; "" for the root.
+	base string
+	re   *regexp.Regexp
+	// anchored rules match the path relative to base; the others match
+	// the last path segment at any depth below it.
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// ignoreRules holds the .gitignore rules seen so far in a walk. Rules of
+// deeper files come later, so the last rule that matches decides, as in git.
+type ignoreRules []ignoreRule
+
+// load appends the rules of the .gitignore in dir, a path relative to the
+// archive root ("" for the root itself). A missing file adds nothing.
+func (r *ignoreRules) load(root, dir string) error {
+	f, err := os.Open(path.Join(root, dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	base := ""
+	if dir != "" {
+		base = dir + "/"
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rule, ok := parseIgnoreLine(base, sc.Text()); ok {
+			*r = append(*r, rule)
+		}
+	}
+	return sc.Err()
+}
+
+// parseIgnoreLine parses one .gitignore line. Blank lines and comments
+// report false.
+func parseIgnoreLine(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// ignored reports whether rel, a slash-separated path relative to the
+// archive root, is excluded by the rules.
+func (r ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if !strings.HasPrefix(rel, rule.base) {
+			continue
+		}
+		subject := strings.TrimPrefix(rel, rule.base)
+		if !rule.anchored {
+			subject = path.Base(subject)
+		}
+		if rule.re.MatchString(subject) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates a gitignore glob to a regular expression: "*"
+// and "?" stop at slashes, "**" spans them, and bracket expressions are
+// kept, with a leading "!" negating them as in git.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
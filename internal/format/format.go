@@ -0,0 +1,240 @@
+// Package format parses and renders human-friendly durations and sizes.
+//
+// Durations use whole-number units from largest to smallest: "90m", "2h",
+// "1d", "1h30m", or "2d 3h". Sizes use a number and a unit: "50GiB",
+// "1.5 TiB", "512M". Binary units (KiB, MiB, GiB, TiB and the K, M, G, T
+// shorthands) are powers of 1024; decimal units (KB, MB, GB, TB) are powers
+// of 1000. EBS volume sizes are whole GiB.
+//
+// Both are for human input and output only. JSON output keeps raw numbers
+// (seconds and bytes) so scripts never have to parse these strings.
+package format
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Day is the length of the "d" duration unit. Mint has no notion of
+// calendar days, so a day is always 24 hours.
+const Day = 24 * time.Hour
+
+// Binary size units.
+const (
+	KiB int64 = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+)
+
+// durationUnits lists the duration units from largest to smallest.
+var durationUnits = []struct {
+	suffix string
+	size   time.Duration
+}{
+	{"d", Day},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// ParseDuration parses a duration such as "90m", "2h", "1d", "1h30m", or
+// "2d 3h". Every number needs a unit, units must appear at most once and
+// from largest to smallest, and negative or fractional values are rejected.
+func ParseDuration(s string) (time.Duration, error) {
+	in := strings.ToLower(strings.TrimSpace(s))
+	if in == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if in[0] == '-' || in[0] == '+' {
+		return 0, fmt.Errorf("invalid duration %q: must be a positive amount such as 90m, 2h, or 1d", s)
+	}
+
+	var total time.Duration
+	next := 0 // index into durationUnits of the largest unit still allowed
+	rest := in
+	for rest != "" {
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 {
+			return 0, fmt.Errorf("invalid duration %q: expected a number at %q", s, rest)
+		}
+		n, err := strconv.ParseInt(rest[:digits], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		rest = rest[digits:]
+
+		unitLen := 0
+		for unitLen < len(rest) && rest[unitLen] >= 'a' && rest[unitLen] <= 'z' {
+			unitLen++
+		}
+		if unitLen == 0 {
+			return 0, fmt.Errorf("invalid duration %q: missing unit after %d (use d, h, m, or s)", s, n)
+		}
+		unit := rest[:unitLen]
+		rest = strings.TrimLeft(rest[unitLen:], " ")
+
+		idx := -1
+		for i, u := range durationUnits {
+			if u.suffix == unit {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return 0, fmt.Errorf("invalid duration %q: unknown unit %q (use d, h, m, or s)", s, unit)
+		}
+		if idx < next {
+			return 0, fmt.Errorf("invalid duration %q: units must go from largest to smallest and appear once", s)
+		}
+		next = idx + 1
+
+		size := durationUnits[idx].size
+		if n > int64(math.MaxInt64/size) || total > time.Duration(math.MaxInt64)-time.Duration(n)*size {
+			return 0, fmt.Errorf("invalid duration %q: too large", s)
+		}
+		total += time.Duration(n) * size
+	}
+	return total, nil
+}
+
+// FormatDuration renders d using its two largest non-zero units, such as
+// "2d 3h", "1h 30m", or "47m". Durations under a minute render in seconds,
+// and anything below a second renders as "0s".
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int64(d/time.Second))
+	}
+
+	var parts []string
+	rem := d
+	for _, u := range durationUnits {
+		if len(parts) == 2 {
+			break
+		}
+		n := rem / u.size
+		rem -= n * u.size
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.suffix))
+		} else if len(parts) > 0 {
+			// Stop at the first gap so "1d 0h 5m" renders as "1d".
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// sizeUnits maps lower-cased unit names to their size in bytes.
+var sizeUnits = map[string]int64{
+	"b":   1,
+	"k":   KiB,
+	"kib": KiB,
+	"m":   MiB,
+	"mib": MiB,
+	"g":   GiB,
+	"gib": GiB,
+	"t":   TiB,
+	"tib": TiB,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+}
+
+// ParseSize parses a size such as "50GiB", "1.5 TiB", "512M", or "20GB" and
+// returns it in bytes. A unit is required; negative values are rejected.
+func ParseSize(s string) (int64, error) {
+	in := strings.TrimSpace(s)
+	if in == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	end := 0
+	for end < len(in) && (in[end] >= '0' && in[end] <= '9' || in[end] == '.') {
+		end++
+	}
+	if end == 0 {
+		return 0, fmt.Errorf("invalid size %q: must be a positive amount such as 50GiB or 1TiB", s)
+	}
+	n, err := strconv.ParseFloat(in[:end], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: bad number %q", s, in[:end])
+	}
+
+	unit := strings.ToLower(strings.TrimSpace(in[end:]))
+	if unit == "" {
+		return 0, fmt.Errorf("invalid size %q: missing unit (e.g. GiB)", s)
+	}
+	mult, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q (use B, KiB, MiB, GiB, TiB, or KB, MB, GB, TB)", s, in[end:])
+	}
+
+	bytes := n * float64(mult)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(math.Round(bytes)), nil
+}
+
+// ParseSizeGiB parses a size that must be a whole number of GiB, as EBS
+// volume sizes are. A bare number is taken as GiB for compatibility with
+// the integer volume size settings.
+func ParseSizeGiB(s string) (int, error) {
+	in := strings.TrimSpace(s)
+	if n, err := strconv.Atoi(in); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid size %q: must not be negative", s)
+		}
+		return n, nil
+	}
+	b, err := ParseSize(in)
+	if err != nil {
+		return 0, err
+	}
+	if b%GiB != 0 {
+		return 0, fmt.Errorf("invalid size %q: must be a whole number of GiB", s)
+	}
+	if b/GiB > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int(b / GiB), nil
+}
+
+// FormatSize renders a byte count in the largest binary unit it fills, with
+// at most one decimal: "50 GiB", "1.5 TiB", "512 MiB", "900 B".
+func FormatSize(bytes int64) string {
+	if bytes < 0 {
+		return "-" + FormatSize(-bytes)
+	}
+	units := []struct {
+		name string
+		size int64
+	}{
+		{"TiB", TiB},
+		{"GiB", GiB},
+		{"MiB", MiB},
+		{"KiB", KiB},
+	}
+	for _, u := range units {
+		if bytes >= u.size {
+			v := strconv.FormatFloat(float64(bytes)/float64(u.size), 'f', 1, 64)
+			return strings.TrimSuffix(v, ".0") + " " + u.name
+		}
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
+// FormatGiB renders a size given in whole GiB, as EBS volume sizes are.
+func FormatGiB(gib int) string {
+	return FormatSize(int64(gib) * GiB)
+}
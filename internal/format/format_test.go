@@ -0,0 +1,196 @@
+package format
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"90m", 90 * time.Minute},
+		{"2h", 2 * time.Hour},
+		{"1d", 24 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+		{"2d 3h", 51 * time.Hour},
+		{"1d2h3m4s", Day + 2*time.Hour + 3*time.Minute + 4*time.Second},
+		{"45s", 45 * time.Second},
+		{"0m", 0},
+		{" 15M ", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationRejects(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "empty duration"},
+		{"-5m", "positive amount"},
+		{"+5m", "positive amount"},
+		{"90", "missing unit"},
+		{"1h30", "missing unit"},
+		{"1.5h", "missing unit"},
+		{"5x", `unknown unit "x"`},
+		{"2hm", `unknown unit "hm"`},
+		{"1m2h", "largest to smallest"},
+		{"1h1h", "largest to smallest"},
+		{"h", "expected a number"},
+		{"5m garbage", "expected a number"},
+		{"99999999999999999999d", "invalid duration"},
+		{"200000d", "too large"},
+	}
+	for _, tt := range tests {
+		_, err := ParseDuration(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseDuration(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{500 * time.Millisecond, "0s"},
+		{45 * time.Second, "45s"},
+		{47 * time.Minute, "47m"},
+		{47*time.Minute + 59*time.Second, "47m 59s"},
+		{90 * time.Minute, "1h 30m"},
+		{2 * time.Hour, "2h"},
+		{51*time.Hour + 20*time.Minute, "2d 3h"},
+		{Day + 5*time.Minute, "1d"},
+		{time.Duration(math.MaxInt64), "106751d 23h"},
+		{-90 * time.Minute, "-1h 30m"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDurationRoundTrips(t *testing.T) {
+	for _, d := range []time.Duration{15 * time.Minute, 90 * time.Minute, 2 * time.Hour, 51 * time.Hour} {
+		got, err := ParseDuration(FormatDuration(d))
+		if err != nil || got != d {
+			t.Errorf("ParseDuration(FormatDuration(%v)) = %v, %v", d, got, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"50GiB", 50 * GiB},
+		{"50 GiB", 50 * GiB},
+		{"50G", 50 * GiB},
+		{"50gib", 50 * GiB},
+		{"1.5TiB", 1536 * GiB},
+		{"512M", 512 * MiB},
+		{"20GB", 20_000_000_000},
+		{"1kb", 1000},
+		{"900B", 900},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSizeRejects(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "empty size"},
+		{"-50GiB", "positive amount"},
+		{"GiB", "positive amount"},
+		{"50", "missing unit"},
+		{"1.2.3GiB", "bad number"},
+		{"50GiB2", "unknown unit"},
+		{"50 GiB 10 MiB", "unknown unit"},
+		{"50XB", "unknown unit"},
+		{"99999999999TiB", "too large"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSize(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseSize(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseSizeGiB(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr string
+	}{
+		{in: "200", want: 200},
+		{in: "200GiB", want: 200},
+		{in: "1TiB", want: 1024},
+		{in: "-5", wantErr: "must not be negative"},
+		{in: "1.5GiB", wantErr: "whole number of GiB"},
+		{in: "50GB", wantErr: "whole number of GiB"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSizeGiB(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseSizeGiB(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSizeGiB(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{0, "0 B"},
+		{900, "900 B"},
+		{1536, "1.5 KiB"},
+		{512 * MiB, "512 MiB"},
+		{50 * GiB, "50 GiB"},
+		{1536 * GiB, "1.5 TiB"},
+		{20_000_000_000, "18.6 GiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.in); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := FormatGiB(200); got != "200 GiB" {
+		t.Errorf("FormatGiB(200) = %q", got)
+	}
+}
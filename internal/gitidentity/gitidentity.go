@@ -0,0 +1,275 @@
+// Package gitidentity renders the git config that maps repository URLs to
+// commit identities on a Mint VM.
+//
+// The identities live in an index file, IndexFile, that the global git
+// config includes. The index records every identity in a mint-identity
+// section so it can be read back, sets the default identity (one without
+// match patterns) as a plain [user] section, and has one includeIf
+// "hasconfig:remote.*.url:..." section per match pattern. Each includeIf
+// points at the identity's own file under IdentityDir, which holds only its
+// [user] section. Git applies the last value it reads, so a matching
+// identity overrides the default, which overrides whatever ~/.gitconfig set.
+package gitidentity
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Paths relative to the VM user's home directory.
+const (
+	// ConfigDir is git's XDG config directory.
+	ConfigDir = ".config/git"
+	// IndexFile is the index the global config includes, in ConfigDir.
+	IndexFile = "mint-identities.conf"
+	// IdentityDir holds one file per matched identity, in ConfigDir.
+	IdentityDir = "mint-identities.d"
+)
+
+// IncludePath is the include.path value added to the global git config.
+// Git expands the leading ~/.
+const IncludePath = "~/" + ConfigDir + "/" + IndexFile
+
+// indexHeader starts every rendered index.
+const indexHeader = "# Managed by mint git-identity. Changes made here are overwritten.\n"
+
+// Identity is a commit identity and the repository URL patterns it applies
+// to. An identity without patterns is the default.
+type Identity struct {
+	Name  string   `json:"name"`
+	User  string   `json:"user"`
+	Email string   `json:"email"`
+	Match []string `json:"match,omitempty"`
+}
+
+// IsDefault reports whether id applies to repositories no pattern matches.
+func (id Identity) IsDefault() bool { return len(id.Match) == 0 }
+
+// FileName returns the path of id's file relative to ConfigDir.
+func (id Identity) FileName() string { return IdentityDir + "/" + id.Name + ".conf" }
+
+var (
+	namePattern  = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+	emailPattern = regexp.MustCompile(`^[^\s@"\\<>]+@[^\s@"\\<>]+$`)
+	// urlPattern is the character set allowed in a match pattern: URL
+	// characters plus the glob characters includeIf understands. Quotes,
+	// backslashes, and whitespace would need escaping inside the includeIf
+	// subsection name and never appear in a repository URL.
+	urlPattern = regexp.MustCompile(`^[A-Za-z0-9._~:/@*?!+=%\[\]-]+$`)
+)
+
+// Validate checks every field of id.
+func (id Identity) Validate() error {
+	if !namePattern.MatchString(id.Name) {
+		return fmt.Errorf("invalid identity name %q: must start with a letter or digit and contain only letters, digits, '.', '_', and '-'", id.Name)
+	}
+	if strings.TrimSpace(id.User) == "" {
+		return fmt.Errorf("user name is required")
+	}
+	if strings.ContainsFunc(id.User, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return fmt.Errorf("user name %q contains control characters", id.User)
+	}
+	if !emailPattern.MatchString(id.Email) {
+		return fmt.Errorf("invalid email %q", id.Email)
+	}
+	for _, p := range id.Match {
+		if err := ValidatePattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidatePattern checks that p can be used as an includeIf
+// hasconfig:remote.*.url: pattern, e.g. "github.com/acme/*" or
+// "https://gitlab.example.com/**".
+func ValidatePattern(p string) error {
+	if p == "" {
+		return fmt.Errorf("match pattern is empty")
+	}
+	if !urlPattern.MatchString(p) {
+		return fmt.Errorf("invalid match pattern %q: only URL and glob characters (* ? [ ]) are allowed", p)
+	}
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, "-") {
+		return fmt.Errorf("invalid match pattern %q: must start with a host or a URL scheme", p)
+	}
+	depth := 0
+	for _, r := range p {
+		switch r {
+		case '[':
+			if depth > 0 {
+				return fmt.Errorf("invalid match pattern %q: nested '['", p)
+			}
+			depth++
+		case ']':
+			if depth == 0 {
+				return fmt.Errorf("invalid match pattern %q: unmatched ']'", p)
+			}
+			depth--
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("invalid match pattern %q: unmatched '['", p)
+	}
+	return nil
+}
+
+// Conditions returns the includeIf conditions for pattern p. A pattern with
+// a scheme is used as is. A scheme-less host/path pattern covers the three
+// forms a remote URL for it can take: https://, ssh://git@, and the scp-like
+// git@host:path. A bare host matches every repository on it.
+func Conditions(p string) []string {
+	if strings.Contains(p, "://") {
+		return []string{"hasconfig:remote.*.url:" + p}
+	}
+	host, path, ok := strings.Cut(p, "/")
+	if !ok || path == "" {
+		path = "**"
+	}
+	return []string{
+		"hasconfig:remote.*.url:https://" + host + "/" + path,
+		"hasconfig:remote.*.url:ssh://git@" + host + "/" + path,
+		"hasconfig:remote.*.url:git@" + host + ":" + path,
+	}
+}
+
+// quote renders s as a quoted git config value.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// RenderIdentity returns the contents of id's file under IdentityDir.
+func RenderIdentity(id Identity) []byte {
+	var b bytes.Buffer
+	b.WriteString(indexHeader)
+	writeUser(&b, id)
+	return b.Bytes()
+}
+
+func writeUser(b *bytes.Buffer, id Identity) {
+	fmt.Fprintf(b, "[user]\n\tname = %s\n\temail = %s\n", quote(id.User), quote(id.Email))
+}
+
+// RenderIndex returns the contents of IndexFile for identities, sorted by
+// name so the output is stable.
+func RenderIndex(identities []Identity) []byte {
+	sorted := append([]Identity(nil), identities...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b bytes.Buffer
+	b.WriteString(indexHeader)
+	for _, id := range sorted {
+		fmt.Fprintf(&b, "[mint-identity %s]\n\tuser = %s\n\temail = %s\n", quote(id.Name), quote(id.User), quote(id.Email))
+		for _, p := range id.Match {
+			fmt.Fprintf(&b, "\tmatch = %s\n", quote(p))
+		}
+	}
+	for _, id := range sorted {
+		if id.IsDefault() {
+			writeUser(&b, id)
+		}
+	}
+	for _, id := range sorted {
+		for _, p := range id.Match {
+			for _, cond := range Conditions(p) {
+				fmt.Fprintf(&b, "[includeIf %s]\n\tpath = %s\n", quote(cond), id.FileName())
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+var (
+	identitySection = regexp.MustCompile(`^\[mint-identity "((?:[^"\\]|\\.)*)"\]$`)
+	keyValue        = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)\s*=\s*(.*)$`)
+)
+
+// ParseIndex reads the identities recorded in an index written by
+// RenderIndex. Other sections are skipped.
+func ParseIndex(data []byte) ([]Identity, error) {
+	var identities []Identity
+	var cur *Identity
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			cur = nil
+			if m := identitySection.FindStringSubmatch(text); m != nil {
+				identities = append(identities, Identity{Name: unescape(m[1])})
+				cur = &identities[len(identities)-1]
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		m := keyValue.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: malformed entry %q", line, text)
+		}
+		value, err := unquote(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch strings.ToLower(m[1]) {
+		case "user":
+			cur.User = value
+		case "email":
+			cur.Email = value
+		case "match":
+			cur.Match = append(cur.Match, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// unquote decodes a git config value as written by quote. Unquoted values
+// are returned trimmed.
+func unquote(v string) (string, error) {
+	if !strings.HasPrefix(v, `"`) {
+		return strings.TrimSpace(v), nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			if i+1 == len(v) {
+				return "", fmt.Errorf("unterminated quoted value %s", v)
+			}
+			i++
+			b.WriteByte(v[i])
+		case '"':
+			if i != len(v)-1 {
+				return "", fmt.Errorf("unexpected text after quoted value %s", v)
+			}
+			return b.String(), nil
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted value %s", v)
+}
+
+// unescape removes the backslash escapes quote adds.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
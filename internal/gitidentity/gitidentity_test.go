@@ -0,0 +1,156 @@
+package gitidentity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderIndex(t *testing.T) {
+	got := string(RenderIndex([]Identity{
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}},
+		{Name: "personal", User: `Jane "JD" Doe`, Email: "jane@example.com"},
+	}))
+	want := `# Managed by mint git-identity. Changes made here are overwritten.
+[mint-identity "personal"]
+	user = "Jane \"JD\" Doe"
+	email = "jane@example.com"
+[mint-identity "work"]
+	user = "Jane Doe"
+	email = "jane@acme.com"
+	match = "github.com/acme/*"
+[user]
+	name = "Jane \"JD\" Doe"
+	email = "jane@example.com"
+[includeIf "hasconfig:remote.*.url:https://github.com/acme/*"]
+	path = mint-identities.d/work.conf
+[includeIf "hasconfig:remote.*.url:ssh://git@github.com/acme/*"]
+	path = mint-identities.d/work.conf
+[includeIf "hasconfig:remote.*.url:git@github.com:acme/*"]
+	path = mint-identities.d/work.conf
+`
+	if got != want {
+		t.Errorf("RenderIndex() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderIdentity(t *testing.T) {
+	got := string(RenderIdentity(Identity{Name: "work", User: `A\B`, Email: "a@b.c", Match: []string{"x.com"}}))
+	want := "# Managed by mint git-identity. Changes made here are overwritten.\n" +
+		"[user]\n\tname = \"A\\\\B\"\n\temail = \"a@b.c\"\n"
+	if got != want {
+		t.Errorf("RenderIdentity() = %q, want %q", got, want)
+	}
+}
+
+func TestParseIndexRoundTrip(t *testing.T) {
+	identities := []Identity{
+		{Name: "oss", User: `O'Brien \ "Bob"`, Email: "bob@example.org", Match: []string{"github.com/*", "https://gitlab.com/oss/**"}},
+		{Name: "work", User: "Jane Doe", Email: "jane@acme.com"},
+	}
+	got, err := ParseIndex(RenderIndex(identities))
+	if err != nil {
+		t.Fatalf("ParseIndex() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, identities) {
+		t.Errorf("ParseIndex() = %+v, want %+v", got, identities)
+	}
+}
+
+func TestParseIndexEmpty(t *testing.T) {
+	got, err := ParseIndex(nil)
+	if err != nil || len(got) != 0 {
+		t.Errorf("ParseIndex(nil) = %v, %v; want none", got, err)
+	}
+}
+
+func TestParseIndexMalformed(t *testing.T) {
+	for _, data := range []string{
+		"[mint-identity \"a\"]\n\tuser\n",
+		"[mint-identity \"a\"]\n\tuser = \"unterminated\n",
+		"[mint-identity \"a\"]\n\tuser = \"a\" trailing\n",
+	} {
+		if _, err := ParseIndex([]byte(data)); err == nil {
+			t.Errorf("ParseIndex(%q) should fail", data)
+		}
+	}
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"https://gitlab.example.com/team/**", []string{"hasconfig:remote.*.url:https://gitlab.example.com/team/**"}},
+		{"github.com/acme/*", []string{
+			"hasconfig:remote.*.url:https://github.com/acme/*",
+			"hasconfig:remote.*.url:ssh://git@github.com/acme/*",
+			"hasconfig:remote.*.url:git@github.com:acme/*",
+		}},
+		{"bitbucket.org", []string{
+			"hasconfig:remote.*.url:https://bitbucket.org/**",
+			"hasconfig:remote.*.url:ssh://git@bitbucket.org/**",
+			"hasconfig:remote.*.url:git@bitbucket.org:**",
+		}},
+	}
+	for _, tt := range tests {
+		if got := Conditions(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Conditions(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	valid := []string{"github.com/acme/*", "https://gitlab.com/**", "github.com/acme/[ab]*", "git.example.com:8443/x/?", "github.com"}
+	for _, p := range valid {
+		if err := ValidatePattern(p); err != nil {
+			t.Errorf("ValidatePattern(%q) = %v, want nil", p, err)
+		}
+	}
+	invalid := map[string]string{
+		"":                     "empty",
+		"github.com/acme/ x":   "only URL and glob characters",
+		`github.com/"acme"`:    "only URL and glob characters",
+		`github.com\acme`:      "only URL and glob characters",
+		"/srv/git/*":           "must start with a host",
+		"--config":             "must start with a host",
+		"github.com/[acme/*":   "unmatched '['",
+		"github.com/acme]/*":   "unmatched ']'",
+		"github.com/[[a]]":     "nested '['",
+		"github.com/acme\n[x]": "only URL and glob characters",
+	}
+	for p, want := range invalid {
+		err := ValidatePattern(p)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidatePattern(%q) = %v, want error containing %q", p, err, want)
+		}
+	}
+}
+
+func TestIdentityValidate(t *testing.T) {
+	ok := Identity{Name: "work", User: "Jane Doe", Email: "jane@acme.com", Match: []string{"github.com/acme/*"}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(*Identity)
+		want   string
+	}{
+		{"bad name", func(id *Identity) { id.Name = "../etc" }, "invalid identity name"},
+		{"no user", func(id *Identity) { id.User = " " }, "user name is required"},
+		{"newline in user", func(id *Identity) { id.User = "Jane\n[core]" }, "control characters"},
+		{"bad email", func(id *Identity) { id.Email = "jane at acme" }, "invalid email"},
+		{"quote in email", func(id *Identity) { id.Email = `j"@acme.com` }, "invalid email"},
+		{"bad pattern", func(id *Identity) { id.Match = []string{"github.com/a b"} }, "invalid match pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := ok
+			tt.modify(&id)
+			if err := id.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
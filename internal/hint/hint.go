@@ -4,7 +4,8 @@
 // Cmd formats a command for inline use. Block formats one or more commands as
 // an indented block. Suggest formats a labeled command suggestion. All three
 // adapt their output based on whether stderr is a TTY (colored ANSI) or not
-// (plain text with backtick wrapping).
+// (plain text with backtick wrapping). Alert highlights text that needs
+// attention in red on a TTY.
 package hint
 
 import (
@@ -15,9 +16,11 @@ import (
 	"golang.org/x/term"
 )
 
-// ANSI escape sequences for bold mint green (256-color palette index 48).
+// ANSI escape sequences for bold mint green (256-color palette index 48)
+// and bold red.
 const (
 	colorMintGreen = "\033[1;38;5;48m"
+	colorRed       = "\033[1;31m"
 	colorReset     = "\033[0m"
 )
 
@@ -63,3 +66,12 @@ func Block(cmds ...string) string {
 func Suggest(label, cmd string) string {
 	return fmt.Sprintf("  %s:  %s", label, Cmd(cmd))
 }
+
+// Alert formats text that needs attention, such as an expiry that has
+// passed. TTY: bold red ANSI. Non-TTY: the text unchanged.
+func Alert(text string) string {
+	if IsTTY {
+		return colorRed + text + colorReset
+	}
+	return text
+}
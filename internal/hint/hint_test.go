@@ -305,3 +305,23 @@ func TestSuggest_TTY_LongLabel(t *testing.T) {
 		t.Errorf("Suggest() TTY long label = %q, want %q", got, want)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Alert()
+// ---------------------------------------------------------------------------
+
+func TestAlert_NonTTY_Unchanged(t *testing.T) {
+	IsTTY = false
+	if got := Alert("EXPIRED 3h ago"); got != "EXPIRED 3h ago" {
+		t.Errorf("Alert non-TTY = %q, want the text unchanged", got)
+	}
+}
+
+func TestAlert_TTY_BoldRed(t *testing.T) {
+	IsTTY = true
+	defer func() { IsTTY = false }()
+	want := "\033[1;31mEXPIRED 3h ago" + testColorReset
+	if got := Alert("EXPIRED 3h ago"); got != want {
+		t.Errorf("Alert TTY = %q, want %q", got, want)
+	}
+}
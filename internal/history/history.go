@@ -0,0 +1,145 @@
+// Package history maintains mint's local audit log: one NDJSON record per
+// command invocation, stored under the user's state directory. Writing is
+// best-effort — a history failure never fails the command that produced it.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+)
+
+// MaxBytes is the size at which the log is rotated. One rotation
+// (history.ndjson.1) is kept, bounding disk use to roughly twice this.
+const MaxBytes = 5 << 20
+
+// Exit categories recorded for each invocation.
+const (
+	ExitOK                  = "ok"
+	ExitError               = "error"
+	ExitUserBootstrapFailed = "user-bootstrap-failed"
+)
+
+// Record is a single command invocation.
+type Record struct {
+	Time       time.Time      `json:"time"`
+	Command    string         `json:"command"`
+	Flags      []string       `json:"flags,omitempty"`
+	VM         string         `json:"vm,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	Exit       string         `json:"exit"`
+	Resources  []cli.Resource `json:"resources,omitempty"`
+}
+
+// DefaultPath returns the history log location:
+// $XDG_STATE_HOME/mint/history.ndjson, falling back to
+// ~/.local/state/mint/history.ndjson.
+func DefaultPath() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "mint", "history.ndjson")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".local", "state", "mint", "history.ndjson")
+	}
+	return filepath.Join(home, ".local", "state", "mint", "history.ndjson")
+}
+
+// Append writes rec as one line to the log at path, rotating first when the
+// line would push the file past maxBytes. The record is encoded up front and
+// written with a single call so concurrent mint processes do not interleave
+// partial lines.
+func Append(path string, rec Record, maxBytes int64) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encoding history record: %w", err)
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating history dir: %w", err)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxBytes {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("rotating history: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns every record in the rotated and current logs, oldest first.
+// Missing files yield no records; malformed lines (for example a line cut
+// short by a crash) are skipped.
+func Read(path string) ([]Record, error) {
+	var records []Record
+	for _, p := range []string{path + ".1", path} {
+		recs, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recs...)
+	}
+	return records, nil
+}
+
+func readFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Decode reads NDJSON records from r, skipping malformed lines.
+func Decode(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	return records, nil
+}
+
+// Filter returns the records for vm (all VMs when empty) at or after since
+// (no lower bound when zero).
+func Filter(records []Record, vm string, since time.Time) []Record {
+	var out []Record
+	for _, rec := range records {
+		if vm != "" && rec.VM != vm {
+			continue
+		}
+		if !since.IsZero() && rec.Time.Before(since) {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
@@ -0,0 +1,171 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/cli"
+)
+
+func sampleRecord(vm string, at time.Time) Record {
+	return Record{
+		Time:       at,
+		Command:    "destroy",
+		Flags:      []string{"--yes"},
+		VM:         vm,
+		DurationMS: 1234,
+		Exit:       ExitOK,
+		Resources: []cli.Resource{
+			{Kind: "instance", ID: "i-abc"},
+			{Kind: "volume", ID: "vol-abc"},
+		},
+	}
+}
+
+func TestAppendWritesOneJSONLinePerRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mint", "history.ndjson")
+	at := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	if err := Append(path, sampleRecord("default", at), MaxBytes); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Append(path, sampleRecord("staging", at), MaxBytes); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &raw); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	for _, key := range []string{"time", "command", "flags", "vm", "duration_ms", "exit", "resources"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("record missing %q: %s", key, lines[0])
+		}
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("history mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestAppendRotatesAtSizeCap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	at := time.Now()
+	line, _ := json.Marshal(sampleRecord("default", at))
+	maxBytes := int64(len(line)+1) * 3
+
+	for i := 0; i < 7; i++ {
+		if err := Append(path, sampleRecord("default", at), maxBytes); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if info.Size() > maxBytes {
+			t.Errorf("%s is %d bytes, over the %d cap", p, info.Size(), maxBytes)
+		}
+	}
+	if _, err := os.Stat(path + ".2"); !os.IsNotExist(err) {
+		t.Error("only one rotation should be kept")
+	}
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	// 7 writes with 3 per file: the oldest rotation was overwritten.
+	if len(records) != 4 {
+		t.Errorf("Read returned %d records, want 4", len(records))
+	}
+}
+
+func TestReadSkipsMalformedLinesAndMissingFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	records, err := Read(path)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("Read of missing log = %v, %v; want empty, nil", records, err)
+	}
+
+	good, _ := json.Marshal(sampleRecord("default", time.Now()))
+	content := string(good) + "\n{\"time\":\"trunc\n" + string(good) + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	records, err = Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Read returned %d records, want 2", len(records))
+	}
+}
+
+func TestReadReturnsRotatedRecordsFirst(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	older, _ := json.Marshal(sampleRecord("old", time.Now()))
+	newer, _ := json.Marshal(sampleRecord("new", time.Now()))
+	os.WriteFile(path+".1", append(older, '\n'), 0o600)
+	os.WriteFile(path, append(newer, '\n'), 0o600)
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 2 || records[0].VM != "old" || records[1].VM != "new" {
+		t.Errorf("records = %+v, want old then new", records)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	records := []Record{
+		sampleRecord("default", now.Add(-10*24*time.Hour)),
+		sampleRecord("staging", now.Add(-2*24*time.Hour)),
+		sampleRecord("default", now.Add(-1*time.Hour)),
+	}
+
+	tests := []struct {
+		name  string
+		vm    string
+		since time.Time
+		want  int
+	}{
+		{"no filters", "", time.Time{}, 3},
+		{"vm only", "default", time.Time{}, 2},
+		{"since only", "", now.Add(-7 * 24 * time.Hour), 2},
+		{"vm and since", "default", now.Add(-7 * 24 * time.Hour), 1},
+		{"no match", "other", time.Time{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Filter(records, tt.vm, tt.since); len(got) != tt.want {
+				t.Errorf("Filter returned %d records, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultPathHonoursXDGStateHome(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if got := DefaultPath(); got != "/tmp/state/mint/history.ndjson" {
+		t.Errorf("DefaultPath() = %q", got)
+	}
+}
@@ -4,21 +4,68 @@
 package identity
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// MaxOwnerLength is the longest owner name NormalizeARN returns. The owner
+// is embedded in tag values, the security group name (mint-<owner>), and
+// IAM paths, so it is kept well inside their limits.
+const MaxOwnerLength = 64
+
+// ownerHashLength is the number of hex digits of the identifier hash used
+// for fallback and truncated owner names.
+const ownerHashLength = 8
+
 // nonAlphanumeric matches any character that is not a lowercase letter or digit.
 var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
 
+// transliterations maps accented and ligature Latin letters to ASCII, so a
+// display name such as "José García" becomes "jose-garcia" rather than
+// "jos-garc-a". Input is lowercased first, so only lowercase forms appear.
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'ĥ': "h", 'ħ': "h",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'ĵ': "j",
+	'ķ': "k",
+	'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'œ': "oe",
+	'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s", 'ß': "ss",
+	'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ŵ': "w",
+	'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
 // NormalizeARN extracts the trailing identifier from an AWS ARN and normalizes
 // it to a friendly owner name. The normalization rules (from ADR-0013) are:
-//   - Extract the last path segment of the ARN resource
+//   - Extract the last path segment of the ARN resource, less the time
+//     suffix of a role_arn session (see RoleSessionName)
 //   - Strip @domain from email addresses
-//   - Lowercase
+//   - Lowercase and transliterate accented Latin letters to ASCII
 //   - Replace runs of non-alphanumeric characters with a single hyphen
 //   - Trim leading and trailing hyphens
+//   - Shorten names over MaxOwnerLength, ending them with a hash of the
+//     identifier so long names that share a prefix stay distinct
+//   - Fall back to "user-" and the hash when nothing is left, such as for
+//     an identifier made only of emoji
+//
+// The result depends only on the identifier, so the same person resolves to
+// the same owner on every command, whichever role they assumed.
 func NormalizeARN(arn string) (string, error) {
 	if arn == "" {
 		return "", fmt.Errorf("empty ARN")
@@ -39,8 +86,7 @@ func NormalizeARN(arn string) (string, error) {
 	// Extract the trailing identifier (last segment after /).
 	// For "user/ryan" -> "ryan", for "assumed-role/Role/session" -> "session",
 	// for "root" -> "root".
-	segments := strings.Split(resource, "/")
-	identifier := segments[len(segments)-1]
+	identifier := trailingIdentifier(resource)
 
 	if identifier == "" {
 		return "", fmt.Errorf("malformed ARN: empty trailing identifier")
@@ -51,18 +97,92 @@ func NormalizeARN(arn string) (string, error) {
 		identifier = identifier[:idx]
 	}
 
-	// Lowercase.
-	identifier = strings.ToLower(identifier)
+	sum := sha256.Sum256([]byte(identifier))
+	hash := hex.EncodeToString(sum[:])[:ownerHashLength]
+
+	// Lowercase and transliterate.
+	name := transliterate(strings.ToLower(identifier))
 
 	// Replace runs of non-alphanumeric characters with a single hyphen.
-	identifier = nonAlphanumeric.ReplaceAllString(identifier, "-")
+	name = nonAlphanumeric.ReplaceAllString(name, "-")
 
 	// Trim leading and trailing hyphens.
-	identifier = strings.Trim(identifier, "-")
+	name = strings.Trim(name, "-")
 
-	if identifier == "" {
-		return "", fmt.Errorf("ARN normalized to empty string: %s", arn)
+	if name == "" {
+		return "user-" + hash, nil
+	}
+	if len(name) > MaxOwnerLength {
+		name = strings.TrimRight(name[:MaxOwnerLength-ownerHashLength-1], "-") + "-" + hash
+	}
+	return name, nil
+}
+
+// transliterate replaces the letters in transliterations with their ASCII
+// forms and leaves every other rune alone.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if ascii, ok := transliterations[r]; ok {
+			b.WriteString(ascii)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SamePrincipal reports whether two caller ARNs belong to the same person:
+// the same account and the same trailing identifier. An IAM Identity Center
+// user keeps one identity across permission sets even though each set is a
+// different role in the ARN. Unparseable ARNs are only the same when equal.
+func SamePrincipal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	accountA, idA, okA := principalOf(a)
+	accountB, idB, okB := principalOf(b)
+	return okA && okB && accountA == accountB && strings.EqualFold(idA, idB)
+}
+
+// principalOf returns the account and trailing resource identifier of arn.
+func principalOf(arn string) (account, identifier string, ok bool) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[5] == "" {
+		return "", "", false
+	}
+	return parts[4], trailingIdentifier(parts[5]), true
+}
+
+// maxRoleSessionNameLen is the STS limit on RoleSessionName.
+const maxRoleSessionNameLen = 64
+
+// roleSessionPattern matches the session names RoleSessionName returns,
+// capturing the owner.
+var roleSessionPattern = regexp.MustCompile(`^mint-([a-z0-9-]+)-\d+$`)
+
+// RoleSessionName returns the STS session name mint uses when it assumes
+// role_arn for owner: mint-<owner>-<unix time>. The time keeps sessions
+// distinct in CloudTrail; NormalizeARN and SamePrincipal drop it again, so
+// the assumed-role ARN still resolves to owner on every command. Owners too
+// long for the 64-character limit are shortened.
+func RoleSessionName(owner string, now time.Time) string {
+	suffix := fmt.Sprintf("-%d", now.Unix())
+	if max := maxRoleSessionNameLen - len("mint-") - len(suffix); len(owner) > max {
+		owner = strings.TrimRight(owner[:max], "-")
 	}
+	return "mint-" + owner + suffix
+}
 
-	return identifier, nil
+// trailingIdentifier returns the last path segment of an ARN resource. For
+// an assumed-role session named by RoleSessionName it is the owner.
+func trailingIdentifier(resource string) string {
+	segments := strings.Split(resource, "/")
+	identifier := segments[len(segments)-1]
+	if segments[0] == "assumed-role" {
+		if m := roleSessionPattern.FindStringSubmatch(identifier); m != nil {
+			return m[1]
+		}
+	}
+	return identifier
 }
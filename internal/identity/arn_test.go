@@ -1,6 +1,19 @@
 package identity
 
-import "testing"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hashOf is the owner hash NormalizeARN derives from identifier.
+func hashOf(identifier string) string {
+	sum := sha256.Sum256([]byte(identifier))
+	return hex.EncodeToString(sum[:])[:ownerHashLength]
+}
 
 func TestNormalizeARN(t *testing.T) {
 	tests := []struct {
@@ -74,6 +87,51 @@ func TestNormalizeARN(t *testing.T) {
 			arn:      "arn:aws:sts::123456789012:federated-user/developer",
 			wantName: "developer",
 		},
+		{
+			name:     "accented Identity Center name transliterated",
+			arn:      "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_PowerUserAccess_abc123/José García",
+			wantName: "jose-garcia",
+		},
+		{
+			name:     "ligatures and sharp s expanded",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/Æsa.Strauß",
+			wantName: "aesa-strauss",
+		},
+		{
+			name:     "unmapped letters dropped",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/Zoë 张伟",
+			wantName: "zoe",
+		},
+		{
+			name:     "emoji around a name dropped",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/🚀ryan🚀",
+			wantName: "ryan",
+		},
+		{
+			name:     "emoji-only name falls back to a hash",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/🚀🚀",
+			wantName: "user-" + hashOf("🚀🚀"),
+		},
+		{
+			name:     "non-Latin name falls back to a hash",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/张伟@example.com",
+			wantName: "user-" + hashOf("张伟"),
+		},
+		{
+			name:     "long name truncated with a hash",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/" + strings.Repeat("a", 70),
+			wantName: strings.Repeat("a", 55) + "-" + hashOf(strings.Repeat("a", 70)),
+		},
+		{
+			name:     "truncation does not leave a double hyphen",
+			arn:      "arn:aws:sts::123456789012:assumed-role/Role/" + strings.Repeat("a", 54) + ".bbbbbbbbbbbbbbbb",
+			wantName: strings.Repeat("a", 54) + "-" + hashOf(strings.Repeat("a", 54)+".bbbbbbbbbbbbbbbb"),
+		},
+		{
+			name:     "name at the limit kept whole",
+			arn:      "arn:aws:iam::123456789012:user/" + strings.Repeat("b", MaxOwnerLength),
+			wantName: strings.Repeat("b", MaxOwnerLength),
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,3 +152,113 @@ func TestNormalizeARN(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeARNDeterministicAndTagSafe(t *testing.T) {
+	tagSafe := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	arns := []string{
+		"arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Dev_abc/José García",
+		"arn:aws:sts::123456789012:assumed-role/Role/🦄✨",
+		"arn:aws:sts::123456789012:assumed-role/Role/" + strings.Repeat("Ñandú ", 20),
+	}
+	for _, arn := range arns {
+		first, err := NormalizeARN(arn)
+		if err != nil {
+			t.Fatalf("NormalizeARN(%q): %v", arn, err)
+		}
+		if again, _ := NormalizeARN(arn); again != first {
+			t.Errorf("NormalizeARN(%q) = %q then %q, want a stable owner", arn, first, again)
+		}
+		if len(first) > MaxOwnerLength || !tagSafe.MatchString(first) {
+			t.Errorf("NormalizeARN(%q) = %q, want at most %d tag-safe characters", arn, first, MaxOwnerLength)
+		}
+	}
+
+	// Two long names that share a prefix must not collide.
+	a, _ := NormalizeARN("arn:aws:iam::123456789012:user/" + strings.Repeat("x", 70) + "a")
+	b, _ := NormalizeARN("arn:aws:iam::123456789012:user/" + strings.Repeat("x", 70) + "b")
+	if a == b {
+		t.Errorf("long names sharing a prefix both normalized to %q", a)
+	}
+}
+
+func TestSamePrincipal(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{
+			name: "identical",
+			a:    "arn:aws:iam::123456789012:user/ryan",
+			b:    "arn:aws:iam::123456789012:user/ryan",
+			want: true,
+		},
+		{
+			name: "same Identity Center user under another permission set",
+			a:    "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_PowerUserAccess_abc123/ryan@example.com",
+			b:    "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_ReadOnly_def456/ryan@example.com",
+			want: true,
+		},
+		{
+			name: "accented and plain names that collide",
+			a:    "arn:aws:sts::123456789012:assumed-role/Role/José",
+			b:    "arn:aws:sts::123456789012:assumed-role/Role/jose",
+			want: false,
+		},
+		{
+			name: "same local part at different domains",
+			a:    "arn:aws:sts::123456789012:assumed-role/Role/ryan@company.com",
+			b:    "arn:aws:sts::123456789012:assumed-role/Role/ryan@other.com",
+			want: false,
+		},
+		{
+			name: "role_arn sessions started at different times",
+			a:    "arn:aws:sts::123456789012:assumed-role/ProjectDev/mint-ryan-1700000000",
+			b:    "arn:aws:sts::123456789012:assumed-role/ProjectDev/mint-ryan-1700003600",
+			want: true,
+		},
+		{
+			name: "different accounts",
+			a:    "arn:aws:iam::123456789012:user/ryan",
+			b:    "arn:aws:iam::210987654321:user/ryan",
+			want: false,
+		},
+		{
+			name: "unparseable",
+			a:    "ryan",
+			b:    "arn:aws:iam::123456789012:user/ryan",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SamePrincipal(tt.a, tt.b); got != tt.want {
+				t.Errorf("SamePrincipal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleSessionNameMapsBackToOwner(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	for _, owner := range []string{"ryan", "jose-garcia", "user-" + hashOf("🙂"), strings.Repeat("a", MaxOwnerLength)} {
+		session := RoleSessionName(owner, now)
+		if len(session) > 64 || !regexp.MustCompile(`^[\w+=,.@-]+$`).MatchString(session) {
+			t.Errorf("RoleSessionName(%q) = %q, not a valid STS session name", owner, session)
+		}
+		got, err := NormalizeARN("arn:aws:sts::123456789012:assumed-role/ProjectDev/" + session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Owners too long for a session name keep their first 48 characters.
+		want := owner[:min(len(owner), 48)]
+		if got != want {
+			t.Errorf("session %q normalizes to %q, want %q", session, got, want)
+		}
+	}
+
+	// Only assumed-role sessions are stripped.
+	if got, _ := NormalizeARN("arn:aws:iam::123456789012:user/mint-ryan-1700000000"); got != "mint-ryan-1700000000" {
+		t.Errorf("IAM user normalizes to %q", got)
+	}
+}
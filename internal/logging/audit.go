@@ -1,13 +1,19 @@
 package logging
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
+// TypeOperation is the AuditEntry type of a mutating AWS operation.
+const TypeOperation = "aws_operation"
+
 // Auditor defines the interface for command invocation audit logging.
 // Each command execution is recorded with its context for traceability.
 type Auditor interface {
@@ -15,9 +21,26 @@ type Auditor interface {
 	LogResourceCreate(resourceType, resourceID, vmName, callerARN string) error
 	LogResourceDestroy(resourceType, resourceID, vmName, callerARN string) error
 	LogError(command, vmName, callerARN string, err error) error
+	LogOperation(op Operation) error
 	Close() error
 }
 
+// Operation is one mutating AWS API call made on behalf of a command.
+type Operation struct {
+	Command   string
+	VMName    string
+	CallerARN string
+	// Service and Name identify the call, e.g. "ec2" and "RunInstances".
+	Service string
+	Name    string
+	// ResourcesIn are the resource IDs the request named; ResourcesOut are
+	// those the response returned, such as a new instance's ID.
+	ResourcesIn  []string
+	ResourcesOut []string
+	Duration     time.Duration
+	Err          error
+}
+
 // AuditEntry represents a single audit record that can represent any event type.
 type AuditEntry struct {
 	Timestamp    string `json:"timestamp"`
@@ -28,6 +51,13 @@ type AuditEntry struct {
 	ResourceType string `json:"resource_type,omitempty"`
 	ResourceID   string `json:"resource_id,omitempty"`
 	Error        string `json:"error,omitempty"`
+
+	// Fields of TypeOperation entries.
+	Service      string   `json:"service,omitempty"`
+	Operation    string   `json:"operation,omitempty"`
+	ResourcesIn  []string `json:"resources_in,omitempty"`
+	ResourcesOut []string `json:"resources_out,omitempty"`
+	DurationMs   int64    `json:"duration_ms,omitempty"`
 }
 
 // AuditLogEntry represents a single command invocation audit record.
@@ -39,8 +69,10 @@ type AuditLogEntry struct {
 	CallerARN string `json:"caller_arn"`
 }
 
-// auditLogger appends JSON Lines entries to a single audit log file.
+// auditLogger appends JSON Lines entries to a single audit log file. It is
+// safe for concurrent use, as the provisioner makes AWS calls in parallel.
 type auditLogger struct {
+	mu   sync.Mutex
 	file *os.File
 }
 
@@ -122,6 +154,27 @@ func (a *auditLogger) LogError(command, vmName, callerARN string, err error) err
 	})
 }
 
+// LogOperation records a mutating AWS operation as a JSON Lines entry.
+func (a *auditLogger) LogOperation(op Operation) error {
+	var errMsg string
+	if op.Err != nil {
+		errMsg = op.Err.Error()
+	}
+	return a.writeEntry(AuditEntry{
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Type:         TypeOperation,
+		Command:      op.Command,
+		VMName:       op.VMName,
+		CallerARN:    op.CallerARN,
+		Service:      op.Service,
+		Operation:    op.Name,
+		ResourcesIn:  op.ResourcesIn,
+		ResourcesOut: op.ResourcesOut,
+		DurationMs:   op.Duration.Milliseconds(),
+		Error:        errMsg,
+	})
+}
+
 // writeEntry marshals and appends an AuditEntry as a JSON Lines entry.
 func (a *auditLogger) writeEntry(entry AuditEntry) error {
 	data, err := json.Marshal(entry)
@@ -130,6 +183,8 @@ func (a *auditLogger) writeEntry(entry AuditEntry) error {
 	}
 
 	data = append(data, '\n')
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if _, err := a.file.Write(data); err != nil {
 		return fmt.Errorf("write audit entry: %w", err)
 	}
@@ -141,3 +196,32 @@ func (a *auditLogger) writeEntry(entry AuditEntry) error {
 func (a *auditLogger) Close() error {
 	return a.file.Close()
 }
+
+// ReadAuditLog returns the entries of the audit log at path, oldest first.
+// A missing file has no entries. Lines that are not valid JSON, such as one
+// cut short by a crash, are skipped.
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return entries, nil
+}
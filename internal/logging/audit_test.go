@@ -3,10 +3,12 @@ package logging
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewAuditLoggerCreatesFile(t *testing.T) {
@@ -283,3 +285,58 @@ func TestAuditLoggerCloses(t *testing.T) {
 		t.Fatalf("Close() error: %v", err)
 	}
 }
+
+func TestLogOperationRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewAuditLogger(path)
+	if err != nil {
+		t.Fatalf("NewAuditLogger() unexpected error: %v", err)
+	}
+	if err := logger.LogCommand("up", "default", "arn:aws:iam::123456789012:user/alice"); err != nil {
+		t.Fatalf("LogCommand() error: %v", err)
+	}
+	op := Operation{
+		Command:      "up",
+		VMName:       "default",
+		CallerARN:    "arn:aws:iam::123456789012:user/alice",
+		Service:      "ec2",
+		Name:         "RunInstances",
+		ResourcesIn:  []string{"sg-1"},
+		ResourcesOut: []string{"i-abc"},
+		Duration:     1500 * time.Millisecond,
+		Err:          errors.New("boom"),
+	}
+	if err := logger.LogOperation(op); err != nil {
+		t.Fatalf("LogOperation() error: %v", err)
+	}
+	// A line cut short by a crash is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"timestamp":`)
+	f.Close()
+
+	entries, err := ReadAuditLog(path)
+	if err != nil {
+		t.Fatalf("ReadAuditLog() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	got := entries[1]
+	if got.Type != TypeOperation || got.Service != "ec2" || got.Operation != "RunInstances" ||
+		got.Command != "up" || got.CallerARN != op.CallerARN || got.DurationMs != 1500 || got.Error != "boom" {
+		t.Errorf("entry = %+v", got)
+	}
+	if len(got.ResourcesIn) != 1 || got.ResourcesIn[0] != "sg-1" || len(got.ResourcesOut) != 1 || got.ResourcesOut[0] != "i-abc" {
+		t.Errorf("resources = %v -> %v", got.ResourcesIn, got.ResourcesOut)
+	}
+}
+
+func TestReadAuditLogMissingFile(t *testing.T) {
+	entries, err := ReadAuditLog(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil || entries != nil {
+		t.Errorf("ReadAuditLog() = %v, %v, want no entries", entries, err)
+	}
+}
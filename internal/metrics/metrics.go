@@ -0,0 +1,110 @@
+// Package metrics renders metrics in the Prometheus text exposition format
+// (version 0.0.4), for mint status --metrics and anything else that exposes
+// VM state to a scraper.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Gauge is the # TYPE of a metric whose value can go up and down.
+const Gauge = "gauge"
+
+// Label is one name="value" pair of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metric is one sample. Samples sharing a Name form one metric family; the
+// Help and Type of the first are the family's.
+type Metric struct {
+	Name   string
+	Help   string
+	Type   string
+	Labels []Label
+	Value  float64
+}
+
+// Render writes metrics in the text exposition format. Each family is
+// written once, with its # HELP and # TYPE lines, in the order its first
+// sample appears; its samples keep their order. Label values and help text
+// are escaped as the format requires.
+func Render(w io.Writer, metrics []Metric) error {
+	var names []string
+	families := make(map[string][]Metric)
+	for _, m := range metrics {
+		if _, ok := families[m.Name]; !ok {
+			names = append(names, m.Name)
+		}
+		families[m.Name] = append(families[m.Name], m)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		samples := families[name]
+		if help := samples[0].Help; help != "" {
+			bw.WriteString("# HELP " + name + " " + escapeHelp(help) + "\n")
+		}
+		if typ := samples[0].Type; typ != "" {
+			bw.WriteString("# TYPE " + name + " " + typ + "\n")
+		}
+		for _, m := range samples {
+			bw.WriteString(name)
+			writeLabels(bw, m.Labels)
+			bw.WriteString(" " + formatValue(m.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// writeLabels writes {name="value",...}, or nothing when there are no
+// labels.
+func writeLabels(w *bufio.Writer, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(l.Name + `="` + EscapeLabelValue(l.Value) + `"`)
+	}
+	w.WriteByte('}')
+}
+
+// labelValueEscaper escapes a label value: backslash, double quote, and
+// line feed.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// helpEscaper escapes help text, in which double quotes are left as they
+// are.
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// EscapeLabelValue escapes v for use between the quotes of a label value.
+func EscapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// formatValue formats a sample value: the shortest representation that
+// round-trips, and NaN, +Inf, and -Inf as the format spells them.
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "default", want: "default"},
+		{name: "double quote", value: `say "hi"`, want: `say \"hi\"`},
+		{name: "backslash", value: `C:\mint`, want: `C:\\mint`},
+		{name: "line feed", value: "a\nb", want: `a\nb`},
+		{name: "escaped sequence stays literal", value: `\n`, want: `\\n`},
+		{name: "unicode", value: "café", want: "café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeLabelValue(tt.value); got != tt.want {
+				t.Errorf("EscapeLabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	vm := func(name string) []Label { return []Label{{"vm", name}, {"owner", "alice"}} }
+	metrics := []Metric{
+		{Name: "mint_vm_state", Help: "VM state.", Type: Gauge, Labels: vm("default"), Value: 1},
+		{Name: "mint_disk_used_percent", Help: "Disk used, in percent.\nPer mount.", Type: Gauge,
+			Labels: append(vm("default"), Label{"mount", "/"}), Value: 42},
+		// A second sample of the first family is written with it.
+		{Name: "mint_vm_state", Help: "ignored", Type: Gauge, Labels: vm(`we"ird\vm`), Value: 3},
+		{Name: "mint_up", Value: 0.25},
+		{Name: "mint_ratio", Type: Gauge, Value: math.NaN()},
+		{Name: "mint_limit", Type: Gauge, Value: math.Inf(1)},
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, metrics); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := `# HELP mint_vm_state VM state.
+# TYPE mint_vm_state gauge
+mint_vm_state{vm="default",owner="alice"} 1
+mint_vm_state{vm="we\"ird\\vm",owner="alice"} 3
+# HELP mint_disk_used_percent Disk used, in percent.\nPer mount.
+# TYPE mint_disk_used_percent gauge
+mint_disk_used_percent{vm="default",owner="alice",mount="/"} 42
+mint_up 0.25
+# TYPE mint_ratio gauge
+mint_ratio NaN
+# TYPE mint_limit gauge
+mint_limit +Inf
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Render output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, nil); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Render(nil) wrote %q, want nothing", buf.String())
+	}
+}
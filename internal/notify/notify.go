@@ -0,0 +1,166 @@
+// Package notify tells the user that a long-running mint command has
+// finished: a terminal bell and, where the platform has one, a desktop
+// notification. Desktop notifications run a platform tool (osascript on
+// macOS, notify-send on Linux, PowerShell on Windows). Every failure is
+// silent: a notification that cannot be shown must never affect the command.
+package notify
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Modes, matching the notify config key.
+const (
+	ModeOff     = "off"
+	ModeAuto    = "auto"
+	ModeBell    = "bell"
+	ModeDesktop = "desktop"
+)
+
+// Notifier shows a notification. Implementations ignore their own failures.
+type Notifier interface {
+	Notify(title, message string)
+}
+
+// Nop is a Notifier that does nothing.
+type Nop struct{}
+
+// Notify implements Notifier.
+func (Nop) Notify(string, string) {}
+
+// Bell rings the terminal bell by writing BEL to W.
+type Bell struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (b Bell) Notify(string, string) {
+	_, _ = io.WriteString(b.W, "\a")
+}
+
+// multi notifies with each of its Notifiers in turn.
+type multi []Notifier
+
+func (m multi) Notify(title, message string) {
+	for _, n := range m {
+		n.Notify(title, message)
+	}
+}
+
+// execTimeout bounds how long a desktop notification tool may run.
+const execTimeout = 5 * time.Second
+
+// Runner runs a command and waits for it. Replaced in tests.
+type Runner func(ctx context.Context, name string, args ...string) error
+
+// runCommand is the production Runner.
+func runCommand(ctx context.Context, name string, args ...string) error {
+	return exec.CommandContext(ctx, name, args...).Run()
+}
+
+// command is a Notifier that runs a platform notification tool.
+type command struct {
+	name string
+	args func(title, message string) []string
+	run  Runner
+}
+
+func (c command) Notify(title, message string) {
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+	_ = c.run(ctx, c.name, c.args(title, message)...)
+}
+
+// Desktop returns the desktop Notifier for this platform, or nil when the
+// platform's notification tool is not installed.
+func Desktop() Notifier {
+	return desktopFor(runtime.GOOS, exec.LookPath, runCommand)
+}
+
+// desktopFor returns the desktop Notifier for goos, using lookPath to check
+// that its tool is installed.
+func desktopFor(goos string, lookPath func(string) (string, error), run Runner) Notifier {
+	var c command
+	switch goos {
+	case "darwin":
+		c = command{name: "osascript", args: func(title, message string) []string {
+			return []string{"-e", "display notification " + appleScriptString(message) + " with title " + appleScriptString(title)}
+		}}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		c = command{name: "notify-send", args: func(title, message string) []string {
+			return []string{"--app-name=mint", title, message}
+		}}
+	case "windows":
+		c = command{name: "powershell", args: func(title, message string) []string {
+			return []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message)}
+		}}
+	default:
+		return nil
+	}
+	if _, err := lookPath(c.name); err != nil {
+		return nil
+	}
+	c.run = run
+	return c
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// windowsToastScript is a PowerShell script that shows a toast notification
+// through the Windows Runtime notification API.
+func windowsToastScript(title, message string) string {
+	return strings.Join([]string{
+		"$ErrorActionPreference = 'Stop'",
+		"[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null",
+		"$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)",
+		"$text = $xml.GetElementsByTagName('text')",
+		"$text.Item(0).AppendChild($xml.CreateTextNode(" + powerShellString(title) + ")) > $null",
+		"$text.Item(1).AppendChild($xml.CreateTextNode(" + powerShellString(message) + ")) > $null",
+		"[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('mint').Show([Windows.UI.Notifications.ToastNotification]::new($xml))",
+	}, "; ")
+}
+
+// ForMode returns the Notifier for mode. The bell is written to bell.
+//
+//   - auto: the bell, plus a desktop notification where available
+//   - bell: the bell only
+//   - desktop: a desktop notification, or the bell where none is available
+//   - off, or anything else: nothing
+func ForMode(mode string, bell io.Writer) Notifier {
+	return forMode(mode, bell, Desktop)
+}
+
+// forMode is ForMode with the desktop Notifier lookup injected.
+func forMode(mode string, bell io.Writer, desktop func() Notifier) Notifier {
+	switch mode {
+	case ModeAuto:
+		if d := desktop(); d != nil {
+			return multi{Bell{W: bell}, d}
+		}
+		return Bell{W: bell}
+	case ModeBell:
+		return Bell{W: bell}
+	case ModeDesktop:
+		if d := desktop(); d != nil {
+			return d
+		}
+		return Bell{W: bell}
+	default:
+		return Nop{}
+	}
+}
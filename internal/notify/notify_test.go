@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder is a Runner that records the commands it is asked to run.
+type recorder struct {
+	calls [][]string
+	err   error
+}
+
+func (r *recorder) run(_ context.Context, name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return r.err
+}
+
+func found(string) (string, error)   { return "/usr/bin/tool", nil }
+func missing(string) (string, error) { return "", errors.New("not found") }
+
+func TestDesktopFor(t *testing.T) {
+	tests := []struct {
+		goos string
+		want []string
+	}{
+		{goos: "linux", want: []string{"notify-send", "--app-name=mint", "mint up", `done "now"`}},
+		{goos: "darwin", want: []string{"osascript", "-e", `display notification "done \"now\"" with title "mint up"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			r := &recorder{}
+			n := desktopFor(tt.goos, found, r.run)
+			if n == nil {
+				t.Fatal("desktopFor returned nil with the tool installed")
+			}
+			n.Notify("mint up", `done "now"`)
+			if len(r.calls) != 1 || !reflect.DeepEqual(r.calls[0], tt.want) {
+				t.Errorf("ran %q, want %q", r.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestDesktopForWindowsQuotes(t *testing.T) {
+	r := &recorder{}
+	desktopFor("windows", found, r.run).Notify("mint up", "it's done")
+	if len(r.calls) != 1 || r.calls[0][0] != "powershell" {
+		t.Fatalf("ran %q, want powershell", r.calls)
+	}
+	if script := r.calls[0][len(r.calls[0])-1]; !strings.Contains(script, "CreateTextNode('it''s done')") {
+		t.Errorf("script does not quote the message:\n%s", script)
+	}
+}
+
+func TestDesktopForUnavailable(t *testing.T) {
+	if n := desktopFor("linux", missing, (&recorder{}).run); n != nil {
+		t.Error("desktopFor should return nil when notify-send is not installed")
+	}
+	if n := desktopFor("plan9", found, (&recorder{}).run); n != nil {
+		t.Error("desktopFor should return nil on an unsupported platform")
+	}
+}
+
+func TestDesktopFailureIsSilent(t *testing.T) {
+	r := &recorder{err: errors.New("exit status 1")}
+	desktopFor("linux", found, r.run).Notify("mint up", "done")
+	if len(r.calls) != 1 {
+		t.Errorf("calls = %v", r.calls)
+	}
+}
+
+func TestForMode(t *testing.T) {
+	r := &recorder{}
+	withDesktop := func() Notifier { return desktopFor("linux", found, r.run) }
+	noDesktop := func() Notifier { return nil }
+
+	tests := []struct {
+		mode     string
+		desktop  func() Notifier
+		wantBell bool
+		wantExec bool
+	}{
+		{mode: ModeAuto, desktop: withDesktop, wantBell: true, wantExec: true},
+		{mode: ModeAuto, desktop: noDesktop, wantBell: true},
+		{mode: ModeBell, desktop: withDesktop, wantBell: true},
+		{mode: ModeDesktop, desktop: withDesktop, wantExec: true},
+		{mode: ModeDesktop, desktop: noDesktop, wantBell: true},
+		{mode: ModeOff, desktop: withDesktop},
+		{mode: "", desktop: withDesktop},
+	}
+	for _, tt := range tests {
+		r.calls = nil
+		bell := new(bytes.Buffer)
+		forMode(tt.mode, bell, tt.desktop).Notify("mint up", "done")
+		if got := bell.String() == "\a"; got != tt.wantBell {
+			t.Errorf("mode %q: bell = %q, want rung=%v", tt.mode, bell.String(), tt.wantBell)
+		}
+		if got := len(r.calls) == 1; got != tt.wantExec {
+			t.Errorf("mode %q: desktop calls = %v, want %v", tt.mode, r.calls, tt.wantExec)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package paths locates mint's files on the local machine: its config
+// directory, the user's SSH config, and mint's known_hosts store.
+//
+// On Linux and macOS the config directory is ~/.config/mint. On Windows it
+// is %APPDATA%\mint, and the user's home directory is %USERPROFILE%.
+// MINT_CONFIG_DIR overrides the config directory on every platform.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDirEnv names the environment variable that overrides the config
+// directory.
+const ConfigDirEnv = "MINT_CONFIG_DIR"
+
+// KnownHostsFile is the name of mint's host key store in the config
+// directory.
+const KnownHostsFile = "known_hosts"
+
+// File modes for files and directories only the user may read. Windows has
+// no POSIX permission bits; see Chmod.
+const (
+	PrivateFileMode os.FileMode = 0o600
+	PrivateDirMode  os.FileMode = 0o700
+)
+
+// ConfigDir returns mint's config directory.
+func ConfigDir() string {
+	return configDir(runtime.GOOS, os.Getenv)
+}
+
+// SSHConfigPath returns the path of the user's SSH config file,
+// ~/.ssh/config (%USERPROFILE%\.ssh\config on Windows).
+func SSHConfigPath() string {
+	return sshConfigPath(runtime.GOOS, os.Getenv)
+}
+
+// KnownHostsPath returns the path of mint's host key store.
+func KnownHostsPath() string {
+	return filepath.Join(ConfigDir(), KnownHostsFile)
+}
+
+// Chmod sets the permission bits of the file at path. On Windows, where
+// files under the user's profile are private through their ACLs and
+// os.File.Chmod is unsupported, it does nothing.
+func Chmod(path string, mode os.FileMode) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return os.Chmod(path, mode)
+}
+
+// configDir is ConfigDir for the platform goos, reading the environment
+// through getenv.
+func configDir(goos string, getenv func(string) string) string {
+	if dir := getenv(ConfigDirEnv); dir != "" {
+		return dir
+	}
+	if goos == "windows" {
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "mint")
+		}
+		return filepath.Join(homeDir(goos, getenv), "AppData", "Roaming", "mint")
+	}
+	return filepath.Join(homeDir(goos, getenv), ".config", "mint")
+}
+
+// sshConfigPath is SSHConfigPath for the platform goos.
+func sshConfigPath(goos string, getenv func(string) string) string {
+	return filepath.Join(homeDir(goos, getenv), ".ssh", "config")
+}
+
+// homeDir returns the user's home directory: %USERPROFILE% on Windows,
+// $HOME elsewhere. Without one it falls back to the current directory.
+func homeDir(goos string, getenv func(string) string) string {
+	env := "HOME"
+	if goos == "windows" {
+		env = "USERPROFILE"
+	}
+	if home := getenv(env); home != "" {
+		return home
+	}
+	if goos == runtime.GOOS {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home
+		}
+	}
+	return "."
+}
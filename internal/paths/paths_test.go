@@ -0,0 +1,100 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// env returns a getenv backed by vars.
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestConfigDir(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		vars map[string]string
+		want string
+	}{
+		{
+			name: "linux home",
+			goos: "linux",
+			vars: map[string]string{"HOME": "/home/alice"},
+			want: filepath.Join("/home/alice", ".config", "mint"),
+		},
+		{
+			name: "override wins on linux",
+			goos: "linux",
+			vars: map[string]string{"HOME": "/home/alice", ConfigDirEnv: "/tmp/mint"},
+			want: "/tmp/mint",
+		},
+		{
+			name: "windows appdata",
+			goos: "windows",
+			vars: map[string]string{"APPDATA": `C:\Users\alice\AppData\Roaming`, "USERPROFILE": `C:\Users\alice`},
+			want: filepath.Join(`C:\Users\alice\AppData\Roaming`, "mint"),
+		},
+		{
+			name: "windows without appdata uses the profile",
+			goos: "windows",
+			vars: map[string]string{"USERPROFILE": `C:\Users\alice`},
+			want: filepath.Join(`C:\Users\alice`, "AppData", "Roaming", "mint"),
+		},
+		{
+			name: "override wins on windows",
+			goos: "windows",
+			vars: map[string]string{"APPDATA": `C:\Users\alice\AppData\Roaming`, ConfigDirEnv: `D:\mint`},
+			want: `D:\mint`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := configDir(tt.goos, env(tt.vars)); got != tt.want {
+				t.Errorf("configDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSHConfigPath(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		vars map[string]string
+		want string
+	}{
+		{
+			name: "linux",
+			goos: "linux",
+			vars: map[string]string{"HOME": "/home/alice", "USERPROFILE": `C:\Users\bob`},
+			want: filepath.Join("/home/alice", ".ssh", "config"),
+		},
+		{
+			name: "windows honors USERPROFILE",
+			goos: "windows",
+			vars: map[string]string{"HOME": "/home/alice", "USERPROFILE": `C:\Users\bob`},
+			want: filepath.Join(`C:\Users\bob`, ".ssh", "config"),
+		},
+		{
+			name: "config dir override does not move it",
+			goos: "linux",
+			vars: map[string]string{"HOME": "/home/alice", ConfigDirEnv: "/tmp/mint"},
+			want: filepath.Join("/home/alice", ".ssh", "config"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sshConfigPath(tt.goos, env(tt.vars)); got != tt.want {
+				t.Errorf("sshConfigPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownHostsPath(t *testing.T) {
+	t.Setenv(ConfigDirEnv, "/tmp/mint-config")
+	if got, want := KnownHostsPath(), filepath.Join("/tmp/mint-config", KnownHostsFile); got != want {
+		t.Errorf("KnownHostsPath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// DefaultBatchConcurrency is how many VMs a batch provisions or destroys at
+// once. It keeps a batch under EC2 API rate limits and limits EIP churn.
+const DefaultBatchConcurrency = 3
+
+// BatchProvisionFunc provisions a single VM of a batch. Each call should use
+// its own Provisioner so runs do not share per-VM state.
+type BatchProvisionFunc func(ctx context.Context, vmName string) (*ProvisionResult, error)
+
+// BatchResult is the outcome of provisioning one VM of a batch. Exactly one
+// of Result and Err is set.
+type BatchResult struct {
+	VMName string
+	Result *ProvisionResult
+	Err    error
+}
+
+// BatchVMNames returns count VM names made of prefix and a 1-based index,
+// zero-padded to at least two digits ("workshop-01" … "workshop-15").
+func BatchVMNames(prefix string, count int) []string {
+	width := max(2, len(strconv.Itoa(count)))
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s%0*d", prefix, width, i+1)
+	}
+	return names
+}
+
+// RunBounded calls fn(i) for every i in [0, n), with at most limit calls in
+// flight at once, and returns when all calls have finished. A limit below 1
+// is treated as 1.
+func RunBounded(n, limit int, fn func(i int)) {
+	sem := make(chan struct{}, max(1, limit))
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// RunBatch provisions every VM in vmNames through fn, at most concurrency at
+// a time. A failed VM does not stop the others. Results are returned in
+// vmNames order. VMs not yet started when ctx is cancelled fail with the
+// context error.
+func RunBatch(ctx context.Context, vmNames []string, concurrency int, fn BatchProvisionFunc) []BatchResult {
+	results := make([]BatchResult, len(vmNames))
+	RunBounded(len(vmNames), concurrency, func(i int) {
+		results[i].VMName = vmNames[i]
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			return
+		}
+		results[i].Result, results[i].Err = fn(ctx, vmNames[i])
+	})
+	return results
+}
+
+// countOwnerEIPs returns how many Mint Elastic IPs owner holds.
+func countOwnerEIPs(ctx context.Context, client mintaws.DescribeAddressesAPI, owner string) (int, error) {
+	out, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag:" + tags.TagMint), Values: []string{"true"}},
+			{Name: aws.String("tag:" + tags.TagOwner), Values: []string{owner}},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("checking EIP quota: %w", err)
+	}
+	return len(out.Addresses), nil
+}
+
+// eipLimitAttribute is the account attribute holding the Elastic IP quota
+// of the region.
+const eipLimitAttribute = "vpc-max-elastic-ips"
+
+// accountEIPLimit returns the account's Elastic IP quota in the region.
+func accountEIPLimit(ctx context.Context, client mintaws.DescribeAccountAttributesAPI) (int, error) {
+	out, err := client.DescribeAccountAttributes(ctx, &ec2.DescribeAccountAttributesInput{
+		AttributeNames: []ec2types.AccountAttributeName{eipLimitAttribute},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("checking EIP quota: %w", err)
+	}
+	for _, attr := range out.AccountAttributes {
+		if aws.ToString(attr.AttributeName) != eipLimitAttribute || len(attr.AttributeValues) == 0 {
+			continue
+		}
+		limit, err := strconv.Atoi(aws.ToString(attr.AttributeValues[0].AttributeValue))
+		if err != nil {
+			return 0, fmt.Errorf("checking EIP quota: %s is %q", eipLimitAttribute, aws.ToString(attr.AttributeValues[0].AttributeValue))
+		}
+		return limit, nil
+	}
+	return 0, fmt.Errorf("checking EIP quota: the account has no %s attribute", eipLimitAttribute)
+}
+
+// CheckBatchEIPQuota verifies up front that the region has room for the
+// Elastic IPs of the batch VMs in vmNames, so a batch fails before creating
+// anything rather than partway through. Every allocation in the region
+// counts against the account's quota, whoever made it. A VM with a free
+// Elastic IP of owner tagged for it, such as one kept by mint destroy
+// --keep-eip, reuses that address and needs no new allocation. The error
+// reports exactly how many allocations are available.
+func CheckBatchEIPQuota(ctx context.Context, addrs mintaws.DescribeAddressesAPI, attrs mintaws.DescribeAccountAttributesAPI, owner string, vmNames []string) error {
+	if len(vmNames) == 0 {
+		return nil
+	}
+	limit, err := accountEIPLimit(ctx, attrs)
+	if err != nil {
+		return err
+	}
+	out, err := addrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return fmt.Errorf("checking EIP quota: %w", err)
+	}
+
+	batch := make(map[string]bool, len(vmNames))
+	for _, name := range vmNames {
+		batch[name] = true
+	}
+	reusable := make(map[string]bool)
+	for _, addr := range out.Addresses {
+		addrTags := tags.ToMap(addr.Tags)
+		if addrTags[tags.TagMint] != "true" || addrTags[tags.TagOwner] != owner ||
+			addrTags[tags.TagComponent] != tags.ComponentElasticIP || !batch[addrTags[tags.TagVM]] {
+			continue
+		}
+		if aws.ToString(addr.AssociationId) == "" && aws.ToString(addr.InstanceId) == "" {
+			reusable[addrTags[tags.TagVM]] = true
+		}
+	}
+
+	used := len(out.Addresses)
+	needed := len(vmNames) - len(reusable)
+	available := max(0, limit-used)
+	if needed > available {
+		reused := ""
+		if len(reusable) > 0 {
+			reused = fmt.Sprintf(", %d of them kept for these VMs", len(reusable))
+		}
+		return fmt.Errorf(
+			"EIP quota exceeded: the batch needs %d new Elastic IPs but only %d of the region's %d are available (%d allocated in the account%s) — "+
+				"reduce --count, run %s on unused VMs, or request a higher quota for EC2-VPC Elastic IPs in Service Quotas",
+			needed, available, limit, used, reused, hint.Cmd("mint destroy"),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+func TestBatchVMNames(t *testing.T) {
+	tests := []struct {
+		prefix string
+		count  int
+		first  string
+		last   string
+	}{
+		{"workshop-", 15, "workshop-01", "workshop-15"},
+		{"ws", 3, "ws01", "ws03"},
+		{"lab-", 120, "lab-001", "lab-120"},
+	}
+	for _, tt := range tests {
+		names := BatchVMNames(tt.prefix, tt.count)
+		if len(names) != tt.count {
+			t.Fatalf("BatchVMNames(%q, %d) returned %d names", tt.prefix, tt.count, len(names))
+		}
+		if names[0] != tt.first || names[len(names)-1] != tt.last {
+			t.Errorf("BatchVMNames(%q, %d) = %s..%s, want %s..%s",
+				tt.prefix, tt.count, names[0], names[len(names)-1], tt.first, tt.last)
+		}
+	}
+}
+
+func TestRunBoundedLimitsParallelism(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	var calls atomic.Int32
+
+	RunBounded(10, 3, func(i int) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		calls.Add(1)
+	})
+
+	if calls.Load() != 10 {
+		t.Errorf("fn called %d times, want 10", calls.Load())
+	}
+	if peak.Load() > 3 {
+		t.Errorf("peak parallelism = %d, want at most 3", peak.Load())
+	}
+	if peak.Load() < 2 {
+		t.Errorf("peak parallelism = %d, want calls to overlap", peak.Load())
+	}
+}
+
+func TestRunBatchFailuresDoNotAbort(t *testing.T) {
+	names := BatchVMNames("workshop-", 6)
+	failing := map[string]bool{"workshop-02": true, "workshop-05": true}
+
+	var mu sync.Mutex
+	called := map[string]bool{}
+	results := RunBatch(context.Background(), names, 2, func(ctx context.Context, vmName string) (*ProvisionResult, error) {
+		mu.Lock()
+		called[vmName] = true
+		mu.Unlock()
+		if failing[vmName] {
+			return nil, fmt.Errorf("launch failed for %s", vmName)
+		}
+		return &ProvisionResult{InstanceID: "i-" + vmName}, nil
+	})
+
+	if len(called) != len(names) {
+		t.Errorf("provisioned %d VMs, want all %d", len(called), len(names))
+	}
+	for i, r := range results {
+		if r.VMName != names[i] {
+			t.Errorf("results[%d].VMName = %q, want %q (input order)", i, r.VMName, names[i])
+		}
+		if failing[r.VMName] {
+			if r.Err == nil || r.Result != nil {
+				t.Errorf("%s: want error only, got result=%v err=%v", r.VMName, r.Result, r.Err)
+			}
+		} else if r.Err != nil || r.Result.InstanceID != "i-"+r.VMName {
+			t.Errorf("%s: want result only, got result=%v err=%v", r.VMName, r.Result, r.Err)
+		}
+	}
+}
+
+func TestRunBatchCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := RunBatch(ctx, []string{"a", "b"}, 1, func(ctx context.Context, vmName string) (*ProvisionResult, error) {
+		t.Errorf("fn called for %s after cancellation", vmName)
+		return nil, nil
+	})
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context.Canceled", r.VMName, r.Err)
+		}
+	}
+}
+
+type mockDescribeAccountAttributes struct {
+	output *ec2.DescribeAccountAttributesOutput
+	err    error
+}
+
+func (m *mockDescribeAccountAttributes) DescribeAccountAttributes(ctx context.Context, params *ec2.DescribeAccountAttributesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAccountAttributesOutput, error) {
+	return m.output, m.err
+}
+
+func eipLimit(limit string) *mockDescribeAccountAttributes {
+	return &mockDescribeAccountAttributes{output: &ec2.DescribeAccountAttributesOutput{
+		AccountAttributes: []ec2types.AccountAttribute{
+			{AttributeName: aws.String("supported-platforms"), AttributeValues: []ec2types.AccountAttributeValue{{AttributeValue: aws.String("VPC")}}},
+			{AttributeName: aws.String("vpc-max-elastic-ips"), AttributeValues: []ec2types.AccountAttributeValue{{AttributeValue: aws.String(limit)}}},
+		},
+	}}
+}
+
+// keptEIP returns a free Elastic IP of owner tagged for vmName.
+func keptEIP(owner, vmName string) ec2types.Address {
+	return ec2types.Address{
+		AllocationId: aws.String("eipalloc-kept-" + vmName),
+		Tags: []ec2types.Tag{
+			{Key: aws.String(tags.TagMint), Value: aws.String("true")},
+			{Key: aws.String(tags.TagOwner), Value: aws.String(owner)},
+			{Key: aws.String(tags.TagVM), Value: aws.String(vmName)},
+			{Key: aws.String(tags.TagComponent), Value: aws.String(tags.ComponentElasticIP)},
+			{Key: aws.String(tags.TagRetained), Value: aws.String("true")},
+		},
+	}
+}
+
+func TestCheckBatchEIPQuota(t *testing.T) {
+	// addrs returns n allocations nobody in the batch can reuse, plus extra.
+	addrs := func(n int, extra ...ec2types.Address) *ec2.DescribeAddressesOutput {
+		out := &ec2.DescribeAddressesOutput{}
+		for i := 0; i < n; i++ {
+			out.Addresses = append(out.Addresses, ec2types.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))})
+		}
+		out.Addresses = append(out.Addresses, extra...)
+		return out
+	}
+	associated := keptEIP("alice", "ws-01")
+	associated.AssociationId = aws.String("eipassoc-1")
+
+	tests := []struct {
+		name    string
+		limit   string
+		addrs   *ec2.DescribeAddressesOutput
+		vmNames []string
+		wantErr string
+	}{
+		{name: "fits exactly", limit: "5", addrs: addrs(2), vmNames: BatchVMNames("ws-", 3)},
+		{name: "nothing needed", limit: "5", addrs: addrs(5), vmNames: nil},
+		{name: "one short", limit: "5", addrs: addrs(2), vmNames: BatchVMNames("ws-", 4),
+			wantErr: "needs 4 new Elastic IPs but only 3 of the region's 5 are available (2 allocated in the account)"},
+		{name: "none available", limit: "5", addrs: addrs(5), vmNames: BatchVMNames("ws-", 1), wantErr: "only 0 of the region's 5 are available"},
+		{name: "raised quota fits a workshop", limit: "20", addrs: addrs(4), vmNames: BatchVMNames("workshop-", 15)},
+		{name: "raised quota still short", limit: "20", addrs: addrs(6), vmNames: BatchVMNames("workshop-", 15),
+			wantErr: "needs 15 new Elastic IPs but only 14 of the region's 20 are available (6 allocated in the account)"},
+		{name: "kept Elastic IPs are reused", limit: "5",
+			addrs: addrs(2, keptEIP("alice", "ws-01"), keptEIP("alice", "ws-02")), vmNames: BatchVMNames("ws-", 3)},
+		{name: "kept Elastic IPs are reported", limit: "5",
+			addrs: addrs(3, keptEIP("alice", "ws-01")), vmNames: BatchVMNames("ws-", 3),
+			wantErr: "needs 2 new Elastic IPs but only 1 of the region's 5 are available (4 allocated in the account, 1 of them kept for these VMs)"},
+		{name: "another owner's Elastic IP is not reused", limit: "5",
+			addrs: addrs(3, keptEIP("bob", "ws-01")), vmNames: BatchVMNames("ws-", 2), wantErr: "needs 2 new Elastic IPs but only 1"},
+		{name: "associated Elastic IP is not reused", limit: "5",
+			addrs: addrs(3, associated), vmNames: BatchVMNames("ws-", 2), wantErr: "needs 2 new Elastic IPs but only 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckBatchEIPQuota(context.Background(), &mockUpDescribeAddresses{output: tt.addrs}, eipLimit(tt.limit), "alice", tt.vmNames)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	errTests := []struct {
+		name    string
+		addrs   *mockUpDescribeAddresses
+		attrs   *mockDescribeAccountAttributes
+		wantErr string
+	}{
+		{"DescribeAddresses fails", &mockUpDescribeAddresses{err: errors.New("throttled")}, eipLimit("5"), "checking EIP quota: throttled"},
+		{"DescribeAccountAttributes fails", &mockUpDescribeAddresses{output: addrs(0)}, &mockDescribeAccountAttributes{err: errors.New("denied")}, "checking EIP quota: denied"},
+		{"no quota attribute", &mockUpDescribeAddresses{output: addrs(0)}, &mockDescribeAccountAttributes{output: &ec2.DescribeAccountAttributesOutput{}}, "no vpc-max-elastic-ips attribute"},
+		{"bad quota value", &mockUpDescribeAddresses{output: addrs(0)}, eipLimit("many"), `vpc-max-elastic-ips is "many"`},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckBatchEIPQuota(context.Background(), tt.addrs, tt.attrs, "alice", []string{"ws-01"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package provision
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"golang.org/x/term"
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/format"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
 	"github.com/SpiceLabsHQ/Mint/internal/vm"
 )
@@ -79,12 +81,23 @@ func NewBootstrapPoller(
 	}
 }
 
+// WithNonInteractive disables the timeout prompt, as if stdin were not a
+// terminal. Batch provisioning uses it because concurrent VMs cannot share
+// one prompt.
+func (bp *BootstrapPoller) WithNonInteractive() *BootstrapPoller {
+	bp.isTerminal = func() bool { return false }
+	return bp
+}
+
 // Poll checks the instance's mint:bootstrap tag at regular intervals until it
 // reads "complete", the timeout expires, or the context is cancelled.
 //
 // On success (bootstrap=complete), returns nil.
-// On bootstrap=failed, returns an error immediately (phase included when present).
-// On timeout, presents three interactive options to the user.
+// On bootstrap=complete with a failed user hook (mint:user-bootstrap=failed:<code>),
+// returns a *UserBootstrapError; the VM is usable and callers should warn.
+// On bootstrap=failed, returns a *BootstrapFailedError immediately.
+// On timeout, presents three interactive options to the user; without a
+// terminal it returns a *BootstrapTimeoutError instead.
 // On context cancellation, returns the context error.
 func (bp *BootstrapPoller) Poll(ctx context.Context, owner, vmName, instanceID string) error {
 	ticker := time.NewTicker(bp.Config.Interval)
@@ -94,16 +107,19 @@ func (bp *BootstrapPoller) Poll(ctx context.Context, owner, vmName, instanceID s
 	defer deadline.Stop()
 
 	start := time.Now()
+	// lastStatus is the mint:bootstrap value of the last successful check,
+	// reported on timeout.
+	lastStatus := ""
 
 	// Check immediately before the first tick.
 	found, err := bp.checkBootstrap(ctx, owner, vmName)
 	if err == nil && found != nil {
+		lastStatus = found.BootstrapStatus
 		switch found.BootstrapStatus {
 		case tags.BootstrapComplete:
-			fmt.Fprintln(bp.output, "Bootstrap complete.")
-			return nil
+			return bp.bootstrapComplete(found, instanceID)
 		case tags.BootstrapFailed:
-			return bootstrapFailedError(instanceID, bootstrapFailurePhase(found))
+			return bootstrapFailedError(instanceID, found)
 		}
 	}
 
@@ -115,7 +131,11 @@ func (bp *BootstrapPoller) Poll(ctx context.Context, owner, vmName, instanceID s
 			return fmt.Errorf("bootstrap poll cancelled: %w", ctx.Err())
 
 		case <-deadline.C:
-			return bp.handleTimeout(ctx, instanceID)
+			return bp.handleTimeout(ctx, &BootstrapTimeoutError{
+				InstanceID: instanceID,
+				Elapsed:    time.Since(start),
+				LastStatus: lastStatus,
+			})
 
 		case <-ticker.C:
 			found, err := bp.checkBootstrap(ctx, owner, vmName)
@@ -124,13 +144,13 @@ func (bp *BootstrapPoller) Poll(ctx context.Context, owner, vmName, instanceID s
 				fmt.Fprintf(bp.output, "Waiting for bootstrap... %s (check failed: %v)\n", formatElapsed(time.Since(start)), err)
 				continue
 			}
+			lastStatus = found.BootstrapStatus
 
 			switch found.BootstrapStatus {
 			case tags.BootstrapComplete:
-				fmt.Fprintln(bp.output, "Bootstrap complete.")
-				return nil
+				return bp.bootstrapComplete(found, instanceID)
 			case tags.BootstrapFailed:
-				return bootstrapFailedError(instanceID, bootstrapFailurePhase(found))
+				return bootstrapFailedError(instanceID, found)
 			default:
 				fmt.Fprintf(bp.output, "Waiting for bootstrap... %s\n", formatElapsed(time.Since(start)))
 			}
@@ -138,6 +158,40 @@ func (bp *BootstrapPoller) Poll(ctx context.Context, owner, vmName, instanceID s
 	}
 }
 
+// bootstrapComplete reports a completed core bootstrap and inspects the
+// mint:user-bootstrap tag. It returns a *UserBootstrapError when the user hook
+// failed and nil otherwise. An unparseable tag is reported but not treated as
+// a failure.
+func (bp *BootstrapPoller) bootstrapComplete(v *vm.VM, instanceID string) error {
+	fmt.Fprintln(bp.output, "Bootstrap complete.")
+
+	status, err := tags.ParseUserBootstrapStatus(v.UserBootstrapStatus)
+	if err != nil {
+		fmt.Fprintf(bp.output, "Warning: %v\n", err)
+		return nil
+	}
+	if !status.Ran {
+		return nil
+	}
+	if status.Failed {
+		return &UserBootstrapError{InstanceID: instanceID, ExitCode: status.ExitCode}
+	}
+	fmt.Fprintln(bp.output, "User bootstrap hook complete.")
+	return nil
+}
+
+// UserBootstrapError is returned by Poll when core bootstrap completed but
+// the user-bootstrap.sh hook exited non-zero. The VM is usable, so callers
+// should surface a warning rather than treat the VM as failed.
+type UserBootstrapError struct {
+	InstanceID string
+	ExitCode   int
+}
+
+func (e *UserBootstrapError) Error() string {
+	return fmt.Sprintf("user bootstrap hook failed on instance %s (exit %d)", e.InstanceID, e.ExitCode)
+}
+
 // checkBootstrap uses FindVM to get the current VM state including all tags.
 // It returns an error when the VM is not found or the describe call fails.
 func (bp *BootstrapPoller) checkBootstrap(ctx context.Context, owner, vmName string) (*vm.VM, error) {
@@ -151,39 +205,83 @@ func (bp *BootstrapPoller) checkBootstrap(ctx context.Context, owner, vmName str
 	return found, nil
 }
 
-// bootstrapFailurePhase extracts the mint:bootstrap-failure-phase tag value
-// from a VM. Returns an empty string when the tag is absent (older bootstrap
-// scripts that predate the phase instrumentation).
-func bootstrapFailurePhase(v *vm.VM) string {
-	if v == nil {
-		return ""
+// ErrBootstrapFailed and ErrBootstrapTimeout are matched with errors.Is by
+// the errors Poll returns when bootstrap failed or did not finish in time.
+var (
+	ErrBootstrapFailed  = errors.New("bootstrap failed")
+	ErrBootstrapTimeout = errors.New("bootstrap timed out")
+)
+
+// BootstrapFailedError is returned by Poll when the instance is tagged
+// mint:bootstrap=failed. Phase and Step come from the
+// mint:bootstrap-failure-phase and mint:bootstrap-error tags; either is
+// empty when bootstrap.sh predates it.
+type BootstrapFailedError struct {
+	InstanceID string
+	Phase      string
+	Step       string
+}
+
+func (e *BootstrapFailedError) Error() string {
+	switch {
+	case e.Phase != "" && e.Step != "":
+		return fmt.Sprintf("bootstrap failed on instance %s (phase: %s, step: %s)", e.InstanceID, e.Phase, e.Step)
+	case e.Phase != "":
+		return fmt.Sprintf("bootstrap failed on instance %s (phase: %s)", e.InstanceID, e.Phase)
+	case e.Step != "":
+		return fmt.Sprintf("bootstrap failed on instance %s (step: %s)", e.InstanceID, e.Step)
 	}
-	return v.Tags[tags.TagBootstrapFailurePhase]
+	return fmt.Sprintf("bootstrap failed on instance %s", e.InstanceID)
 }
 
-// bootstrapFailedError constructs the error returned when mint:bootstrap=failed
-// is detected. When phase is non-empty it is embedded in the message so the
-// operator knows exactly which phase the script was executing when it failed.
-func bootstrapFailedError(instanceID, phase string) error {
-	if phase != "" {
-		return fmt.Errorf("bootstrap failed on instance %s (phase: %s)", instanceID, phase)
+func (e *BootstrapFailedError) Unwrap() error { return ErrBootstrapFailed }
+
+// BootstrapTimeoutError is returned by Poll when bootstrap did not finish
+// within the poll timeout. The script may still be running: LastStatus is
+// the mint:bootstrap value last observed, empty when the tag was never set
+// or never read.
+type BootstrapTimeoutError struct {
+	InstanceID string
+	Elapsed    time.Duration
+	LastStatus string
+}
+
+func (e *BootstrapTimeoutError) Error() string {
+	status := e.LastStatus
+	if status == "" {
+		status = "unknown"
+	}
+	return fmt.Sprintf("bootstrap timed out for instance %s after %s (last status: %s)",
+		e.InstanceID, formatElapsed(e.Elapsed), status)
+}
+
+func (e *BootstrapTimeoutError) Unwrap() error { return ErrBootstrapTimeout }
+
+// bootstrapFailedError constructs the error returned when
+// mint:bootstrap=failed is detected on v, carrying the failing phase and
+// step so the operator knows where the script stopped.
+func bootstrapFailedError(instanceID string, v *vm.VM) error {
+	return &BootstrapFailedError{
+		InstanceID: instanceID,
+		Phase:      v.Tags[tags.TagBootstrapFailurePhase],
+		Step:       v.Tags[tags.TagBootstrapError],
 	}
-	return fmt.Errorf("bootstrap failed on instance %s", instanceID)
 }
 
 // handleTimeout presents the user with three options when bootstrap does not
 // complete within the timeout window. In non-interactive (non-TTY) contexts
-// it skips the prompt, logs a message, and returns an error so the caller
+// it skips the prompt, logs a message, and returns timeout so the caller
 // exits non-zero — CI pipelines and piped invocations must not silently
 // succeed when bootstrap has not completed.
-func (bp *BootstrapPoller) handleTimeout(ctx context.Context, instanceID string) error {
+func (bp *BootstrapPoller) handleTimeout(ctx context.Context, timeout *BootstrapTimeoutError) error {
+	instanceID := timeout.InstanceID
 	if !bp.isTerminal() {
 		fmt.Fprintf(bp.output, "Bootstrap timed out. Instance %s left running — SSH in or run 'mint doctor' to investigate.\n", instanceID)
-		return fmt.Errorf("bootstrap timed out for instance %s", instanceID)
+		return timeout
 	}
 
 	fmt.Fprintln(bp.output, "")
-	fmt.Fprintln(bp.output, "Bootstrap did not complete within the timeout period.")
+	fmt.Fprintf(bp.output, "Bootstrap did not complete within %s.\n", format.FormatDuration(bp.Config.Timeout))
 	fmt.Fprintln(bp.output, "")
 	fmt.Fprintln(bp.output, "What would you like to do?")
 	fmt.Fprintln(bp.output, "  1) Stop the instance (can restart later)")
@@ -240,13 +338,8 @@ func (bp *BootstrapPoller) handleTimeout(ctx context.Context, instanceID string)
 	}
 }
 
-// formatElapsed formats a duration as "Xm Ys" for progress output.
+// formatElapsed formats a duration to the second, such as "3m 12s", for
+// progress output.
 func formatElapsed(d time.Duration) string {
-	d = d.Round(time.Second)
-	m := int(d.Minutes())
-	s := int(d.Seconds()) % 60
-	if m > 0 {
-		return fmt.Sprintf("%dm %ds", m, s)
-	}
-	return fmt.Sprintf("%ds", s)
+	return format.FormatDuration(d.Round(time.Second))
 }
@@ -3,6 +3,7 @@ package provision
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -646,3 +647,148 @@ func TestBootstrapFailedPhaseInTickerLoop(t *testing.T) {
 		t.Errorf("error %q does not contain phase %q", err.Error(), "efs-mount")
 	}
 }
+
+// TestBootstrapFailedErrorCarriesStep asserts a failed bootstrap returns a
+// *BootstrapFailedError with the phase and the mint:bootstrap-error step.
+func TestBootstrapFailedErrorCarriesStep(t *testing.T) {
+	out := vmResponseWithPhase("i-abc123", tags.BootstrapFailed, "packages")
+	inst := &out.Reservations[0].Instances[0]
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagBootstrapError), Value: aws.String("claude")})
+
+	poller := NewBootstrapPoller(
+		&mockPollDescribeInstances{responses: []describeResponse{{output: out}}},
+		&mockPollStopInstances{},
+		&mockPollTerminateInstances{},
+		&mockPollCreateTags{},
+		&bytes.Buffer{},
+		&bytes.Buffer{},
+	)
+	poller.Config = fastPollConfig()
+
+	err := poller.Poll(context.Background(), "alice", "default", "i-abc123")
+	if !errors.Is(err, ErrBootstrapFailed) {
+		t.Fatalf("error = %v, want ErrBootstrapFailed", err)
+	}
+	var failedErr *BootstrapFailedError
+	if !errors.As(err, &failedErr) {
+		t.Fatalf("error = %T, want *BootstrapFailedError", err)
+	}
+	if failedErr.Phase != "packages" || failedErr.Step != "claude" {
+		t.Errorf("phase, step = %q, %q, want packages, claude", failedErr.Phase, failedErr.Step)
+	}
+	if !strings.Contains(err.Error(), "step: claude") {
+		t.Errorf("error %q does not name the step", err.Error())
+	}
+}
+
+// TestBootstrapTimeoutErrorCarriesLastStatus asserts a non-interactive
+// timeout returns a *BootstrapTimeoutError with the elapsed time and the
+// last observed mint:bootstrap value.
+func TestBootstrapTimeoutErrorCarriesLastStatus(t *testing.T) {
+	poller := NewBootstrapPoller(
+		&mockPollDescribeInstances{responses: []describeResponse{{output: vmResponse("i-abc123", tags.BootstrapPending)}}},
+		&mockPollStopInstances{},
+		&mockPollTerminateInstances{},
+		&mockPollCreateTags{},
+		&bytes.Buffer{},
+		&bytes.Buffer{},
+	).WithNonInteractive()
+	poller.Config = fastPollConfig()
+
+	err := poller.Poll(context.Background(), "alice", "default", "i-abc123")
+	if !errors.Is(err, ErrBootstrapTimeout) || errors.Is(err, ErrBootstrapFailed) {
+		t.Fatalf("error = %v, want only ErrBootstrapTimeout", err)
+	}
+	var timeoutErr *BootstrapTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("error = %T, want *BootstrapTimeoutError", err)
+	}
+	if timeoutErr.LastStatus != tags.BootstrapPending {
+		t.Errorf("LastStatus = %q, want %q", timeoutErr.LastStatus, tags.BootstrapPending)
+	}
+	if timeoutErr.Elapsed < poller.Config.Timeout {
+		t.Errorf("Elapsed = %s, want at least the %s timeout", timeoutErr.Elapsed, poller.Config.Timeout)
+	}
+	if !strings.Contains(err.Error(), "last status: pending") {
+		t.Errorf("error %q does not report the last status", err.Error())
+	}
+}
+
+// vmResponseWithUserBootstrap builds a DescribeInstances response with a core
+// bootstrap status and an optional mint:user-bootstrap tag. Pass an empty
+// string for userStatus to omit the tag (no user hook configured).
+func vmResponseWithUserBootstrap(instanceID, bootstrapStatus, userStatus string) *ec2.DescribeInstancesOutput {
+	out := vmResponse(instanceID, bootstrapStatus)
+	if userStatus != "" {
+		inst := &out.Reservations[0].Instances[0]
+		inst.Tags = append(inst.Tags, ec2types.Tag{
+			Key:   aws.String(tags.TagUserBootstrap),
+			Value: aws.String(userStatus),
+		})
+	}
+	return out
+}
+
+// TestBootstrapPollerUserBootstrap covers the core/user-hook combinations:
+// a failed user hook returns *UserBootstrapError (VM usable), while a failed
+// core bootstrap is a regular failure regardless of the hook.
+func TestBootstrapPollerUserBootstrap(t *testing.T) {
+	tests := []struct {
+		name         string
+		core         string
+		user         string
+		wantErr      bool
+		wantUserErr  bool
+		wantExitCode int
+		wantOutput   string
+	}{
+		{name: "ok/ok", core: tags.BootstrapComplete, user: "ok", wantOutput: "User bootstrap hook complete."},
+		{name: "ok/absent", core: tags.BootstrapComplete, user: ""},
+		{name: "ok/failed", core: tags.BootstrapComplete, user: "failed:3", wantErr: true, wantUserErr: true, wantExitCode: 3},
+		{name: "failed/-", core: tags.BootstrapFailed, user: "", wantErr: true},
+		{name: "ok/malformed", core: tags.BootstrapComplete, user: "bogus", wantOutput: "Warning: invalid mint:user-bootstrap"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descMock := &mockPollDescribeInstances{
+				responses: []describeResponse{
+					{output: vmResponseWithUserBootstrap("i-abc123", tt.core, tt.user)},
+				},
+			}
+
+			var output bytes.Buffer
+			poller := NewBootstrapPoller(
+				descMock,
+				&mockPollStopInstances{},
+				&mockPollTerminateInstances{},
+				&mockPollCreateTags{},
+				&output,
+				&bytes.Buffer{},
+			)
+			poller.Config = fastPollConfig()
+
+			err := poller.Poll(context.Background(), "alice", "default", "i-abc123")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Poll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var userErr *UserBootstrapError
+			isUserErr := errors.As(err, &userErr)
+			if isUserErr != tt.wantUserErr {
+				t.Fatalf("errors.As(*UserBootstrapError) = %v, want %v (err: %v)", isUserErr, tt.wantUserErr, err)
+			}
+			if isUserErr {
+				if userErr.ExitCode != tt.wantExitCode {
+					t.Errorf("ExitCode = %d, want %d", userErr.ExitCode, tt.wantExitCode)
+				}
+				if userErr.InstanceID != "i-abc123" {
+					t.Errorf("InstanceID = %q, want %q", userErr.InstanceID, "i-abc123")
+				}
+			}
+			if tt.wantOutput != "" && !strings.Contains(output.String(), tt.wantOutput) {
+				t.Errorf("output %q does not contain %q", output.String(), tt.wantOutput)
+			}
+		})
+	}
+}
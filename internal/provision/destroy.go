@@ -25,7 +25,12 @@ type DestroyResult struct {
 	InstanceID     string
 	VolumesDeleted int
 	EIPReleased    bool
-	Warnings       []string
+	// KeptAllocationID and KeptPublicIP are the Elastic IP left allocated
+	// and tagged mint:retained by WithKeepEIP, for the next mint up of the
+	// VM to reuse.
+	KeptAllocationID string
+	KeptPublicIP     string
+	Warnings         []string
 }
 
 // Destroyer terminates a VM and cleans up all associated resources.
@@ -40,6 +45,10 @@ type Destroyer struct {
 	describeAddrs   mintaws.DescribeAddressesAPI
 	releaseAddr     mintaws.ReleaseAddressAPI
 
+	// keepEIP leaves the Elastic IP allocated and tagged; see WithKeepEIP.
+	keepEIP    bool
+	createTags mintaws.CreateTagsAPI
+
 	logger logging.Logger
 }
 
@@ -81,6 +90,17 @@ func (d *Destroyer) WithWaitTerminated(w mintaws.WaitInstanceTerminatedAPI) *Des
 	return d
 }
 
+// WithKeepEIP leaves the VM's Elastic IP allocated and tagged instead of
+// releasing it, so the next mint up of the VM reuses the same address.
+// Terminating the instance disassociates it, so the address is also tagged
+// mint:retained=true through createTags, which keeps mint doctor --fix and
+// mint gc from releasing it in the meantime.
+func (d *Destroyer) WithKeepEIP(keep bool, createTags mintaws.CreateTagsAPI) *Destroyer {
+	d.keepEIP = keep
+	d.createTags = createTags
+	return d
+}
+
 // Run executes the full destroy flow. It requires confirmed=true to proceed.
 func (d *Destroyer) Run(ctx context.Context, owner, vmName string, confirmed bool) error {
 	_, err := d.RunWithResult(ctx, owner, vmName, confirmed)
@@ -138,7 +158,7 @@ func (d *Destroyer) RunWithResult(ctx context.Context, owner, vmName string, con
 	// Step 3: Discover and delete project EBS volumes.
 	d.cleanupProjectVolumes(ctx, owner, vmName, result)
 
-	// Step 4: Discover and release Elastic IP.
+	// Step 4: Discover and release Elastic IP, unless it is kept.
 	d.cleanupElasticIP(ctx, owner, vmName, result)
 
 	return result, nil
@@ -205,7 +225,8 @@ func (d *Destroyer) cleanupProjectVolumes(ctx context.Context, owner, vmName str
 	}
 }
 
-// cleanupElasticIP discovers the Elastic IP by tags and releases it.
+// cleanupElasticIP discovers the Elastic IP by tags and releases it, or
+// with keepEIP records it in result.
 // Errors are non-fatal: logged as warnings and added to result.
 func (d *Destroyer) cleanupElasticIP(ctx context.Context, owner, vmName string, result *DestroyResult) {
 	filters := append(
@@ -226,6 +247,32 @@ func (d *Destroyer) cleanupElasticIP(ctx context.Context, owner, vmName string,
 		return
 	}
 
+	if d.keepEIP {
+		if len(out.Addresses) == 0 {
+			return
+		}
+		allocID := aws.ToString(out.Addresses[0].AllocationId)
+		result.KeptAllocationID = allocID
+		result.KeptPublicIP = aws.ToString(out.Addresses[0].PublicIp)
+		if d.createTags == nil {
+			return
+		}
+		ctStart := time.Now()
+		_, err := d.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: []string{allocID},
+			Tags:      []ec2types.Tag{{Key: aws.String(tags.TagRetained), Value: aws.String("true")}},
+		})
+		if d.logger != nil {
+			d.logger.Log("ec2", "CreateTags", time.Since(ctStart), err)
+		}
+		if err != nil {
+			warn := fmt.Sprintf("failed to tag kept Elastic IP %s %s=true: %v", allocID, tags.TagRetained, err)
+			result.Warnings = append(result.Warnings, warn)
+			log.Println(warn)
+		}
+		return
+	}
+
 	for _, addr := range out.Addresses {
 		allocID := aws.ToString(addr.AllocationId)
 		raStart := time.Now()
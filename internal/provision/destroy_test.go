@@ -11,6 +11,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
 )
 
 // ---------------------------------------------------------------------------
@@ -484,6 +486,47 @@ func TestDestroyReleasesElasticIP(t *testing.T) {
 	}
 }
 
+func TestDestroyKeepEIP(t *testing.T) {
+	m := newDestroyHappyMocks()
+	createTags := &mockUpCreateTags{output: &ec2.CreateTagsOutput{}}
+	d := m.build().WithKeepEIP(true, createTags)
+
+	result, err := d.RunWithResult(context.Background(), "alice", "default", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.releaseAddr.called {
+		t.Error("ReleaseAddress should not be called with WithKeepEIP")
+	}
+	if !m.terminate.called || !m.deleteVolume.called {
+		t.Error("the instance and volume should still be destroyed")
+	}
+	if result.EIPReleased || result.KeptAllocationID != "eipalloc-abc123" || result.KeptPublicIP != "1.2.3.4" {
+		t.Errorf("result = released %v, kept %q %q, want kept eipalloc-abc123 1.2.3.4",
+			result.EIPReleased, result.KeptAllocationID, result.KeptPublicIP)
+	}
+	if !createTags.called || createTags.input.Resources[0] != "eipalloc-abc123" ||
+		tags.ToMap(createTags.input.Tags)[tags.TagRetained] != "true" {
+		t.Errorf("CreateTags input = %+v, want %s=true on eipalloc-abc123", createTags.input, tags.TagRetained)
+	}
+}
+
+func TestDestroyKeepEIPTagFailureIsWarning(t *testing.T) {
+	m := newDestroyHappyMocks()
+	createTags := &mockUpCreateTags{err: fmt.Errorf("access denied")}
+	d := m.build().WithKeepEIP(true, createTags)
+
+	result, err := d.RunWithResult(context.Background(), "alice", "default", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.KeptAllocationID != "eipalloc-abc123" || len(result.Warnings) != 1 ||
+		!strings.Contains(result.Warnings[0], "failed to tag kept Elastic IP eipalloc-abc123") {
+		t.Errorf("result = kept %q, warnings %q", result.KeptAllocationID, result.Warnings)
+	}
+}
+
 func TestDestroyMultipleVolumes(t *testing.T) {
 	m := newDestroyHappyMocks()
 	m.describeVolumes.output = &ec2.DescribeVolumesOutput{
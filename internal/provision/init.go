@@ -22,6 +22,7 @@ import (
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/sg"
 	"github.com/SpiceLabsHQ/Mint/internal/tags"
 )
 
@@ -32,12 +33,17 @@ const defaultInstanceProfileName = "mint-instance-profile"
 
 // InitResult holds the outcome of a successful init run.
 type InitResult struct {
-	VPCID          string
-	EFSID          string
-	SecurityGroup  string
-	AccessPointID  string
-	SGCreated      bool
-	APCreated      bool
+	VPCID         string
+	EFSID         string
+	SecurityGroup string
+	AccessPointID string
+	SGCreated     bool
+	APCreated     bool
+
+	// EndpointID is the EC2 Instance Connect Endpoint in the VPC. It is only
+	// populated when the Initializer was built WithInstanceConnectEndpoint.
+	EndpointID      string
+	EndpointCreated bool
 }
 
 // Initializer validates prerequisites and creates per-user resources.
@@ -53,6 +59,21 @@ type Initializer struct {
 	createTags      mintaws.CreateTagsAPI
 	describeAPs     mintaws.DescribeAccessPointsAPI
 	createAP        mintaws.CreateAccessPointAPI
+
+	// Optional: ensure an EC2 Instance Connect Endpoint exists in the VPC.
+	describeEndpoints mintaws.DescribeInstanceConnectEndpointsAPI
+	createEndpoint    mintaws.CreateInstanceConnectEndpointAPI
+
+	// instanceProfileName is the profile validated; empty means
+	// defaultInstanceProfileName.
+	instanceProfileName string
+
+	// extraTags are added to the EC2 resources Run creates.
+	extraTags map[string]string
+
+	// sshPort is the port the security group opens for SSH; zero means
+	// sg.DefaultSSHPort.
+	sshPort int
 }
 
 // NewInitializer creates an Initializer with all required AWS interfaces.
@@ -82,6 +103,35 @@ func NewInitializer(
 	}
 }
 
+// WithInstanceConnectEndpoint makes Run ensure an EC2 Instance Connect
+// Endpoint exists in the VPC, so VMs without a public IP remain reachable.
+func (i *Initializer) WithInstanceConnectEndpoint(describe mintaws.DescribeInstanceConnectEndpointsAPI, create mintaws.CreateInstanceConnectEndpointAPI) *Initializer {
+	i.describeEndpoints = describe
+	i.createEndpoint = create
+	return i
+}
+
+// WithInstanceProfileName makes Run validate the named instance profile
+// instead of mint-instance-profile.
+func (i *Initializer) WithInstanceProfileName(name string) *Initializer {
+	i.instanceProfileName = name
+	return i
+}
+
+// WithExtraTags makes Run add extra to the tags of the security group and
+// Instance Connect Endpoint it creates.
+func (i *Initializer) WithExtraTags(extra map[string]string) *Initializer {
+	i.extraTags = extra
+	return i
+}
+
+// WithSSHPort makes Run open port instead of sg.DefaultSSHPort for SSH in
+// the security group it creates.
+func (i *Initializer) WithSSHPort(port int) *Initializer {
+	i.sshPort = port
+	return i
+}
+
 // Run executes the full init flow: validate prerequisites, then create
 // per-user resources idempotently.
 func (i *Initializer) Run(ctx context.Context, owner, ownerARN, vmName string) (*InitResult, error) {
@@ -114,14 +164,26 @@ func (i *Initializer) Run(ctx context.Context, owner, ownerARN, vmName string) (
 		return nil, fmt.Errorf("access point: %w", err)
 	}
 
-	return &InitResult{
+	result := &InitResult{
 		VPCID:         vpcID,
 		EFSID:         efsID,
 		SecurityGroup: sgResult.groupID,
 		SGCreated:     sgResult.created,
 		AccessPointID: apResult.accessPointID,
 		APCreated:     apResult.created,
-	}, nil
+	}
+
+	// Step 5 (optional): Ensure an Instance Connect Endpoint exists.
+	if i.describeEndpoints != nil && i.createEndpoint != nil {
+		endpointID, created, err := i.ensureInstanceConnectEndpoint(ctx, vpcID, owner, ownerARN, vmName)
+		if err != nil {
+			return nil, fmt.Errorf("instance connect endpoint: %w", err)
+		}
+		result.EndpointID = endpointID
+		result.EndpointCreated = created
+	}
+
+	return result, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -179,21 +241,25 @@ func (i *Initializer) validateVPC(ctx context.Context) (string, error) {
 // exists. Without this profile, EC2 instances cannot use Instance Connect,
 // mount EFS, perform self-stop, or update bootstrap tags.
 func (i *Initializer) validateInstanceProfile(ctx context.Context) error {
+	name := i.instanceProfileName
+	if name == "" {
+		name = defaultInstanceProfileName
+	}
 	_, err := i.instanceProfile.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
-		InstanceProfileName: aws.String(defaultInstanceProfileName),
+		InstanceProfileName: aws.String(name),
 	})
 	if err != nil {
 		var noSuchEntity *iamtypes.NoSuchEntityException
 		if errors.As(err, &noSuchEntity) {
 			return fmt.Errorf("instance profile %q not found; run the admin setup "+
-				"CloudFormation stack first (see docs/admin-setup.md)", defaultInstanceProfileName)
+				"CloudFormation stack first (see docs/admin-setup.md)", name)
 		}
 		var ae smithy.APIError
 		if errors.As(err, &ae) && ae.ErrorCode() == "AccessDenied" {
-			log.Printf("Warning: cannot verify instance profile %q (iam:GetInstanceProfile permission denied) — assuming profile exists. Ask your admin to run %s if provisioning fails.", defaultInstanceProfileName, hint.Cmd("mint admin setup"))
+			log.Printf("Warning: cannot verify instance profile %q (iam:GetInstanceProfile permission denied) — assuming profile exists. Ask your admin to run %s if provisioning fails.", name, hint.Cmd("mint admin setup"))
 			return nil
 		}
-		return fmt.Errorf("get instance profile %q: %w", defaultInstanceProfileName, err)
+		return fmt.Errorf("get instance profile %q: %w", name, err)
 	}
 	return nil
 }
@@ -265,36 +331,24 @@ func (i *Initializer) ensureSecurityGroup(ctx context.Context, vpcID, owner, own
 
 	sgID := aws.ToString(createOut.GroupId)
 
-	// Add ingress rules: TCP 41122 and UDP 60000-61000 from 0.0.0.0/0 (ADR-0016).
+	// Add the ingress rules from the shared spec (ADR-0016); mint doctor
+	// verifies the group against the same spec. New groups already carry
+	// AWS's default allow-all egress rule.
 	_, err = i.authorizeIn.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
-		GroupId: aws.String(sgID),
-		IpPermissions: []ec2types.IpPermission{
-			{
-				IpProtocol: aws.String("tcp"),
-				FromPort:   aws.Int32(41122),
-				ToPort:     aws.Int32(41122),
-				IpRanges: []ec2types.IpRange{
-					{CidrIp: aws.String("0.0.0.0/0"), Description: aws.String("SSH on non-standard port")},
-				},
-			},
-			{
-				IpProtocol: aws.String("udp"),
-				FromPort:   aws.Int32(60000),
-				ToPort:     aws.Int32(61000),
-				IpRanges: []ec2types.IpRange{
-					{CidrIp: aws.String("0.0.0.0/0"), Description: aws.String("Mosh UDP range")},
-				},
-			},
-		},
+		GroupId:       aws.String(sgID),
+		IpPermissions: sg.Permissions(sg.Required(sg.UserRules(int32(i.sshPort)), sg.Ingress), sgID),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("authorize ingress on %s: %w", sgID, err)
 	}
 
 	// Tag the security group with full Mint tag schema.
-	ec2Tags := tags.NewTagBuilder(owner, ownerARN, vmName).
+	ec2Tags, err := tags.MergeExtra(tags.NewTagBuilder(owner, ownerARN, vmName).
 		WithComponent(tags.ComponentSecurityGroup).
-		Build()
+		Build(), i.extraTags)
+	if err != nil {
+		return nil, err
+	}
 
 	_, err = i.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
 		Resources: []string{sgID},
@@ -307,6 +361,66 @@ func (i *Initializer) ensureSecurityGroup(ctx context.Context, vpcID, owner, own
 	return &sgResult{groupID: sgID, created: true}, nil
 }
 
+// ---------------------------------------------------------------------------
+// Instance Connect Endpoint
+// ---------------------------------------------------------------------------
+
+// ensureInstanceConnectEndpoint reuses any pending or ready endpoint in the
+// VPC, and otherwise creates one in the VPC's first subnet. Endpoints are a
+// per-VPC resource, so an endpoint created by another user is reused.
+func (i *Initializer) ensureInstanceConnectEndpoint(ctx context.Context, vpcID, owner, ownerARN, vmName string) (string, bool, error) {
+	descOut, err := i.describeEndpoints.DescribeInstanceConnectEndpoints(ctx, &ec2.DescribeInstanceConnectEndpointsInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+			{Name: aws.String("state"), Values: []string{
+				string(ec2types.Ec2InstanceConnectEndpointStateCreateInProgress),
+				string(ec2types.Ec2InstanceConnectEndpointStateCreateComplete),
+			}},
+		},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("describe instance connect endpoints: %w", err)
+	}
+	if len(descOut.InstanceConnectEndpoints) > 0 {
+		return aws.ToString(descOut.InstanceConnectEndpoints[0].InstanceConnectEndpointId), false, nil
+	}
+
+	subOut, err := i.subnets.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("vpc-id"), Values: []string{vpcID}},
+		},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("describe subnets for VPC %s: %w", vpcID, err)
+	}
+	if len(subOut.Subnets) == 0 {
+		return "", false, fmt.Errorf("no subnets found in VPC %s", vpcID)
+	}
+	subnetID := aws.ToString(subOut.Subnets[0].SubnetId)
+
+	ec2Tags, err := tags.MergeExtra(tags.NewTagBuilder(owner, ownerARN, vmName).
+		WithComponent(tags.ComponentInstanceConnectEndpoint).
+		Build(), i.extraTags)
+	if err != nil {
+		return "", false, err
+	}
+
+	createOut, err := i.createEndpoint.CreateInstanceConnectEndpoint(ctx, &ec2.CreateInstanceConnectEndpointInput{
+		SubnetId: aws.String(subnetID),
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeInstanceConnectEndpoint, Tags: ec2Tags},
+		},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("create instance connect endpoint in %s: %w", subnetID, err)
+	}
+	if createOut.InstanceConnectEndpoint == nil {
+		return "", false, fmt.Errorf("create instance connect endpoint in %s: empty response", subnetID)
+	}
+
+	return aws.ToString(createOut.InstanceConnectEndpoint.InstanceConnectEndpointId), true, nil
+}
+
 // ---------------------------------------------------------------------------
 // EFS access point
 // ---------------------------------------------------------------------------
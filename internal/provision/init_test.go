@@ -3,6 +3,7 @@ package provision
 import (
 	"context"
 	"errors"
+	"slices"
 	"strings"
 	"testing"
 
@@ -68,9 +69,11 @@ func (m *mockCreateSecurityGroup) CreateSecurityGroup(ctx context.Context, param
 type mockAuthorizeIngress struct {
 	output *ec2.AuthorizeSecurityGroupIngressOutput
 	err    error
+	input  *ec2.AuthorizeSecurityGroupIngressInput
 }
 
 func (m *mockAuthorizeIngress) AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	m.input = params
 	return m.output, m.err
 }
 
@@ -499,6 +502,35 @@ func TestEnsureSecurityGroup(t *testing.T) {
 	}
 }
 
+func TestEnsureSecurityGroupOpensSSHPort(t *testing.T) {
+	tests := []struct {
+		name string
+		port int
+		want int32
+	}{
+		{"default port", 0, 41122},
+		{"configured ssh_port", 2222, 2222},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newHappyMocks()
+			init := m.build().WithSSHPort(tt.port)
+			if _, err := init.ensureSecurityGroup(context.Background(), "vpc-abc", "testowner", "arn:aws:iam::123456789012:user/testowner", "default"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var tcp []int32
+			for _, p := range m.authorizeIn.input.IpPermissions {
+				if aws.ToString(p.IpProtocol) == "tcp" {
+					tcp = append(tcp, aws.ToInt32(p.FromPort), aws.ToInt32(p.ToPort))
+				}
+			}
+			if len(tcp) != 4 || slices.ContainsFunc(tcp, func(p int32) bool { return p != tt.want }) {
+				t.Errorf("tcp ports = %v, want %d over IPv4 and IPv6", tcp, tt.want)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: Access point creation
 // ---------------------------------------------------------------------------
@@ -838,3 +870,111 @@ func TestRunFullFlow(t *testing.T) {
 	}
 }
 
+
+// ---------------------------------------------------------------------------
+// Tests: Instance Connect Endpoint
+// ---------------------------------------------------------------------------
+
+type mockDescribeInstanceConnectEndpoints struct {
+	output   *ec2.DescribeInstanceConnectEndpointsOutput
+	err      error
+	captured *ec2.DescribeInstanceConnectEndpointsInput
+}
+
+func (m *mockDescribeInstanceConnectEndpoints) DescribeInstanceConnectEndpoints(ctx context.Context, params *ec2.DescribeInstanceConnectEndpointsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceConnectEndpointsOutput, error) {
+	m.captured = params
+	return m.output, m.err
+}
+
+type mockCreateInstanceConnectEndpoint struct {
+	output   *ec2.CreateInstanceConnectEndpointOutput
+	err      error
+	captured *ec2.CreateInstanceConnectEndpointInput
+}
+
+func (m *mockCreateInstanceConnectEndpoint) CreateInstanceConnectEndpoint(ctx context.Context, params *ec2.CreateInstanceConnectEndpointInput, optFns ...func(*ec2.Options)) (*ec2.CreateInstanceConnectEndpointOutput, error) {
+	m.captured = params
+	return m.output, m.err
+}
+
+func TestEnsureInstanceConnectEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    []ec2types.Ec2InstanceConnectEndpoint
+		createErr   error
+		wantID      string
+		wantCreated bool
+		wantErr     string
+	}{
+		{
+			name:        "creates endpoint when none exists",
+			wantID:      "eice-new",
+			wantCreated: true,
+		},
+		{
+			name: "reuses existing endpoint in VPC",
+			existing: []ec2types.Ec2InstanceConnectEndpoint{
+				{InstanceConnectEndpointId: aws.String("eice-existing")},
+			},
+			wantID: "eice-existing",
+		},
+		{
+			name:      "create failure is reported",
+			createErr: errors.New("quota exceeded"),
+			wantErr:   "quota exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newHappyMocks()
+			describe := &mockDescribeInstanceConnectEndpoints{
+				output: &ec2.DescribeInstanceConnectEndpointsOutput{InstanceConnectEndpoints: tt.existing},
+			}
+			create := &mockCreateInstanceConnectEndpoint{
+				output: &ec2.CreateInstanceConnectEndpointOutput{
+					InstanceConnectEndpoint: &ec2types.Ec2InstanceConnectEndpoint{
+						InstanceConnectEndpointId: aws.String("eice-new"),
+					},
+				},
+				err: tt.createErr,
+			}
+			init := m.build().WithInstanceConnectEndpoint(describe, create)
+
+			result, err := init.Run(context.Background(), "testowner", "arn:aws:iam::123:user/testowner", "default")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.EndpointID != tt.wantID || result.EndpointCreated != tt.wantCreated {
+				t.Errorf("endpoint = %q created=%v, want %q created=%v",
+					result.EndpointID, result.EndpointCreated, tt.wantID, tt.wantCreated)
+			}
+			if tt.wantCreated {
+				if got := aws.ToString(create.captured.SubnetId); got != "subnet-1" {
+					t.Errorf("endpoint subnet = %q, want subnet-1", got)
+				}
+				if create.captured.TagSpecifications[0].ResourceType != ec2types.ResourceTypeInstanceConnectEndpoint {
+					t.Error("endpoint not tagged at creation")
+				}
+			} else if create.captured != nil {
+				t.Error("CreateInstanceConnectEndpoint called despite existing endpoint")
+			}
+		})
+	}
+}
+
+func TestRunSkipsInstanceConnectEndpointByDefault(t *testing.T) {
+	result, err := newHappyMocks().build().Run(context.Background(), "testowner", "arn:aws:iam::123:user/testowner", "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.EndpointID != "" {
+		t.Errorf("EndpointID = %q, want empty without WithInstanceConnectEndpoint", result.EndpointID)
+	}
+}
@@ -0,0 +1,36 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+)
+
+// InterruptedError is returned by Run when ctx is cancelled, typically by
+// Ctrl-C. Run never abandons a mutating call halfway: RunInstances, the
+// project volume step, and the Elastic IP calls run to completion, so every
+// resource they create is recorded in the journal. Run then stops at the
+// next step boundary and the next Run resumes from the journal.
+type InterruptedError struct {
+	// Step is the last completed step; empty when nothing was created.
+	Step       JournalStep
+	InstanceID string
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Step == "" {
+		return "interrupted before any resources were created"
+	}
+	return fmt.Sprintf("interrupted after step %q (instance %s)", e.Step, e.InstanceID)
+}
+
+// Unwrap makes errors.Is(err, context.Canceled) hold for an interrupt.
+func (e *InterruptedError) Unwrap() error { return context.Canceled }
+
+// checkInterrupted returns an InterruptedError for j's progress when ctx has
+// been cancelled, and nil otherwise. It is called at each step boundary.
+func checkInterrupted(ctx context.Context, j *Journal) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return &InterruptedError{Step: j.Step, InstanceID: j.InstanceID}
+}
@@ -0,0 +1,81 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// cancelingRunInstances cancels the run's context while RunInstances is in
+// flight, the way Ctrl-C would, and records the context it was called with.
+type cancelingRunInstances struct {
+	next   mintaws.RunInstancesAPI
+	cancel context.CancelFunc
+	ctxErr error
+}
+
+func (m *cancelingRunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	m.cancel()
+	m.ctxErr = ctx.Err()
+	return m.next.RunInstances(ctx, params, optFns...)
+}
+
+func TestProvisionerInterruptedDuringLaunchRecordsInstance(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	p := m.build(WithJournal(store))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := &cancelingRunInstances{next: m.runInstances, cancel: cancel}
+	p.runInstances = run
+
+	_, err := p.Run(ctx, "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+
+	var ierr *InterruptedError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("Run() error = %v, want InterruptedError", err)
+	}
+	if ierr.Step != StepLaunched || ierr.InstanceID != "i-new123" {
+		t.Errorf("InterruptedError = %+v, want step %s for i-new123", ierr, StepLaunched)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("InterruptedError should match context.Canceled")
+	}
+	if run.ctxErr != nil {
+		t.Errorf("RunInstances saw a cancelled context: %v", run.ctxErr)
+	}
+	j, _ := store.Load("alice", "default")
+	if j == nil || j.Step != StepLaunched || j.InstanceID != "i-new123" {
+		t.Errorf("journal = %+v, want i-new123 at %s", j, StepLaunched)
+	}
+	if m.createTags.called || m.allocateAddr.called {
+		t.Error("no step after the launch should run once interrupted")
+	}
+}
+
+func TestProvisionerInterruptedBeforeLaunch(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	p := m.build(WithJournal(store))
+	ctx, cancel := context.WithCancel(context.Background())
+	p.resolveAMI = func(context.Context, mintaws.DescribeImagesAPI) (string, error) {
+		cancel()
+		return "ami-ubuntu2404", nil
+	}
+
+	_, err := p.Run(ctx, "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+
+	var ierr *InterruptedError
+	if !errors.As(err, &ierr) || ierr.Step != "" {
+		t.Fatalf("Run() error = %v, want InterruptedError before any step", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances called after the interrupt")
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("journal written for a run that created nothing: %+v", j)
+	}
+}
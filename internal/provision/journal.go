@@ -0,0 +1,211 @@
+package provision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JournalStep is a provisioning step recorded in a Journal. A journal's
+// Step is the last step that completed.
+type JournalStep string
+
+// Journal steps, in the order mint up completes them. Bootstrap polling is
+// the final step; once it finishes the journal is removed rather than
+// advanced.
+const (
+	// StepStarted is recorded just before RunInstances. The instance may or
+	// may not exist: the call can succeed even when its response is lost.
+	StepStarted JournalStep = "started"
+	// StepLaunched records the instance ID returned by RunInstances.
+	StepLaunched JournalStep = "launched"
+	// StepVolumeReady records the project volume once it is tagged (or, for
+	// a pending-attach volume, attached).
+	StepVolumeReady JournalStep = "volume-ready"
+	// StepEIPAllocated records the Elastic IP allocation.
+	StepEIPAllocated JournalStep = "eip-allocated"
+	// StepEIPAssociated records that the Elastic IP is associated with the
+	// instance, or for an ipv6-only VM that its IPv6 address is known.
+	// Only bootstrap polling remains.
+	StepEIPAssociated JournalStep = "eip-associated"
+)
+
+var journalSteps = []JournalStep{StepStarted, StepLaunched, StepVolumeReady, StepEIPAllocated, StepEIPAssociated}
+
+// rank returns the position of s in the step order, or -1 for an unknown step.
+func (s JournalStep) rank() int {
+	for i, step := range journalSteps {
+		if step == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Journal records the progress of one mint up run for an owner's VM so an
+// interrupted run can be resumed instead of leaking resources.
+type Journal struct {
+	Owner        string      `json:"owner"`
+	VM           string      `json:"vm"`
+	StartedAt    time.Time   `json:"started_at"`
+	Step         JournalStep `json:"step"`
+	InstanceID   string      `json:"instance_id,omitempty"`
+	VolumeID     string      `json:"volume_id,omitempty"`
+	VolumeSizeGB int32       `json:"volume_size_gb,omitempty"`
+	AllocationID string      `json:"allocation_id,omitempty"`
+	PublicIP     string      `json:"public_ip,omitempty"`
+	// IPMode is the IP mode the instance was launched with; IPv6Address
+	// its IPv6 address, once known. An empty IPMode is eip.
+	IPMode      string `json:"ip_mode,omitempty"`
+	IPv6Address string `json:"ipv6_address,omitempty"`
+	// PrefetchImages are the container images rendered into the instance's
+	// user-data; PrefetchDropped counts those that did not fit.
+	PrefetchImages  []string `json:"prefetch_images,omitempty"`
+	PrefetchDropped int      `json:"prefetch_dropped,omitempty"`
+	// Expires is the mint:expires value the instance, its project volume,
+	// and its Elastic IP are tagged with; empty when they do not expire.
+	Expires string `json:"expires,omitempty"`
+}
+
+// done reports whether step has completed.
+func (j *Journal) done(step JournalStep) bool {
+	return j.Step.rank() >= step.rank()
+}
+
+// JournalStore keeps provisioning journals as JSON files under a directory,
+// one file per owner and VM.
+type JournalStore struct {
+	dir string
+}
+
+// NewJournalStore returns a store that keeps journals under
+// configDir/journal.
+func NewJournalStore(configDir string) *JournalStore {
+	return &JournalStore{dir: filepath.Join(configDir, "journal")}
+}
+
+// path returns the journal file for owner's VM. Owner names come from IAM
+// and may contain characters that are not safe in a file name.
+func (s *JournalStore) path(owner, vmName string) string {
+	safe := func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}
+	return filepath.Join(s.dir, strings.Map(safe, owner), strings.Map(safe, vmName)+".json")
+}
+
+// prefetchPath returns the file holding the prefetch list for owner's VM.
+// It lives in a subdirectory so it can never collide with a journal.
+func (s *JournalStore) prefetchPath(owner, vmName string) string {
+	journal := s.path(owner, vmName)
+	return filepath.Join(filepath.Dir(journal), "prefetch", filepath.Base(journal))
+}
+
+// Load returns the journal for owner's VM, or nil when there is none. A
+// journal that cannot be parsed is an error so it is never silently
+// ignored; the caller can discard it with Remove.
+func (s *JournalStore) Load(owner, vmName string) (*Journal, error) {
+	data, err := os.ReadFile(s.path(owner, vmName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading provisioning journal: %w", err)
+	}
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("parsing provisioning journal: %w", err)
+	}
+	if j.Owner != owner || j.VM != vmName {
+		return nil, fmt.Errorf("provisioning journal is for %s/%s, not %s/%s", j.Owner, j.VM, owner, vmName)
+	}
+	if j.Step.rank() < 0 {
+		return nil, fmt.Errorf("provisioning journal has unknown step %q", j.Step)
+	}
+	return &j, nil
+}
+
+// Save writes j, replacing any previous journal for the same VM. The file
+// is written to a temporary name and renamed so a crash mid-write never
+// leaves a truncated journal behind.
+func (s *JournalStore) Save(j *Journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding provisioning journal: %w", err)
+	}
+	return writeAtomic(s.path(j.Owner, j.VM), data, "provisioning journal")
+}
+
+// writeAtomic writes data to a temporary file next to path and renames it
+// into place. what names the file in errors.
+func writeAtomic(path string, data []byte, what string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", what, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing %s: %w", what, err)
+	}
+	return nil
+}
+
+// Remove deletes the journal for owner's VM. A missing journal is not an
+// error.
+func (s *JournalStore) Remove(owner, vmName string) error {
+	err := os.Remove(s.path(owner, vmName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing provisioning journal: %w", err)
+	}
+	return nil
+}
+
+// SavePrefetch records the container images mint recreate captured from
+// owner's VM before terminating it. When the recreate stops before the
+// replacement is launched, the mint up that finishes it launches with
+// these images.
+func (s *JournalStore) SavePrefetch(owner, vmName string, images []string) error {
+	data, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding prefetch list: %w", err)
+	}
+	return writeAtomic(s.prefetchPath(owner, vmName), data, "prefetch list")
+}
+
+// LoadPrefetch returns the images saved by SavePrefetch for owner's VM, or
+// nil when there are none.
+func (s *JournalStore) LoadPrefetch(owner, vmName string) ([]string, error) {
+	data, err := os.ReadFile(s.prefetchPath(owner, vmName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading prefetch list: %w", err)
+	}
+	var images []string
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, fmt.Errorf("parsing prefetch list: %w", err)
+	}
+	return images, nil
+}
+
+// RemovePrefetch deletes the prefetch list for owner's VM. A missing list
+// is not an error.
+func (s *JournalStore) RemovePrefetch(owner, vmName string) error {
+	err := os.Remove(s.prefetchPath(owner, vmName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing prefetch list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalStoreRoundTrip(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	want := &Journal{
+		Owner:        "alice",
+		VM:           "default",
+		StartedAt:    time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC),
+		Step:         StepEIPAllocated,
+		InstanceID:   "i-new123",
+		VolumeID:     "vol-proj1",
+		VolumeSizeGB: 50,
+		AllocationID: "eipalloc-new1",
+		PublicIP:     "54.1.2.3",
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	got, err := store.Load("alice", "default")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	// Saving again replaces the journal and leaves no temp file behind.
+	want.Step = StepEIPAssociated
+	if err := store.Save(want); err != nil {
+		t.Fatalf("second Save() error: %v", err)
+	}
+	if got, _ := store.Load("alice", "default"); got.Step != StepEIPAssociated {
+		t.Errorf("Step after second save = %q", got.Step)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(store.path("alice", "default")))
+	if len(entries) != 1 {
+		t.Errorf("journal directory has %d entries, want 1", len(entries))
+	}
+
+	if err := store.Remove("alice", "default"); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if got, err := store.Load("alice", "default"); got != nil || err != nil {
+		t.Errorf("Load() after Remove = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestJournalStoreMissingAndRemoveMissing(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if got, err := store.Load("alice", "default"); got != nil || err != nil {
+		t.Errorf("Load() = %v, %v; want nil, nil", got, err)
+	}
+	if err := store.Remove("alice", "default"); err != nil {
+		t.Errorf("Remove() of missing journal error: %v", err)
+	}
+}
+
+func TestJournalStoreKeepsVMsApart(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	for _, j := range []*Journal{
+		{Owner: "alice", VM: "default", Step: StepLaunched, InstanceID: "i-a"},
+		{Owner: "alice", VM: "dev", Step: StepLaunched, InstanceID: "i-b"},
+		{Owner: "bob", VM: "default", Step: StepLaunched, InstanceID: "i-c"},
+	} {
+		if err := store.Save(j); err != nil {
+			t.Fatalf("Save(%s/%s) error: %v", j.Owner, j.VM, err)
+		}
+	}
+	got, err := store.Load("alice", "dev")
+	if err != nil || got.InstanceID != "i-b" {
+		t.Errorf("Load(alice, dev) = %+v, %v", got, err)
+	}
+}
+
+func TestJournalStorePathIsSafe(t *testing.T) {
+	store := NewJournalStore("/cfg")
+	got := store.path("../alice/x", "default")
+	if want := filepath.Join("/cfg", "journal", ".._alice_x", "default.json"); got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+}
+
+func TestJournalStoreLoadRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "corrupt", content: "{not json", wantErr: "parsing provisioning journal"},
+		{name: "other VM", content: `{"owner":"alice","vm":"dev","step":"launched"}`, wantErr: "is for alice/dev"},
+		{name: "unknown step", content: `{"owner":"alice","vm":"default","step":"exploded"}`, wantErr: `unknown step "exploded"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewJournalStore(t.TempDir())
+			path := store.path("alice", "default")
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := store.Load("alice", "default")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJournalDone(t *testing.T) {
+	j := &Journal{Step: StepVolumeReady}
+	for step, want := range map[JournalStep]bool{
+		StepStarted:       true,
+		StepLaunched:      true,
+		StepVolumeReady:   true,
+		StepEIPAllocated:  false,
+		StepEIPAssociated: false,
+	} {
+		if got := j.done(step); got != want {
+			t.Errorf("done(%s) = %v, want %v", step, got, want)
+		}
+	}
+}
+
+func TestJournalStorePrefetchRoundTrip(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+
+	if images, err := store.LoadPrefetch("alice", "default"); err != nil || images != nil {
+		t.Fatalf("LoadPrefetch() before save = %v, %v; want nil, nil", images, err)
+	}
+	want := []string{"node:22", "postgres:16"}
+	if err := store.SavePrefetch("alice", "default", want); err != nil {
+		t.Fatalf("SavePrefetch() error: %v", err)
+	}
+	// The list sits beside journals without colliding with one.
+	if j, err := store.Load("alice", "default"); err != nil || j != nil {
+		t.Errorf("Load() after SavePrefetch = %+v, %v; want no journal", j, err)
+	}
+	got, err := store.LoadPrefetch("alice", "default")
+	if err != nil {
+		t.Fatalf("LoadPrefetch() error: %v", err)
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("LoadPrefetch() = %v, want %v", got, want)
+	}
+	if err := store.RemovePrefetch("alice", "default"); err != nil {
+		t.Fatalf("RemovePrefetch() error: %v", err)
+	}
+	if err := store.RemovePrefetch("alice", "default"); err != nil {
+		t.Errorf("RemovePrefetch() of a missing list: %v", err)
+	}
+	if images, _ := store.LoadPrefetch("alice", "default"); images != nil {
+		t.Errorf("LoadPrefetch() after remove = %v", images)
+	}
+}
@@ -0,0 +1,253 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// OperationLockExpiry is how long an operation lock holds. A lock older
+// than this is taken to belong to a run that died without releasing it.
+const OperationLockExpiry = 30 * time.Minute
+
+// LockHolder is the run named by a mint:operation tag.
+type LockHolder struct {
+	Operation string
+	Host      string
+	PID       int
+	Since     time.Time
+}
+
+// ParseLockHolder parses a mint:operation tag value.
+func ParseLockHolder(value string) (LockHolder, error) {
+	parts := strings.SplitN(value, ":", 4)
+	if len(parts) != 4 {
+		return LockHolder{}, fmt.Errorf("invalid %s value %q: expected <operation>:<host>:<pid>:<time>", tags.TagOperation, value)
+	}
+	pid, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return LockHolder{}, fmt.Errorf("invalid %s value %q: bad pid: %w", tags.TagOperation, value, err)
+	}
+	since, err := time.Parse(time.RFC3339, parts[3])
+	if err != nil {
+		return LockHolder{}, fmt.Errorf("invalid %s value %q: bad time: %w", tags.TagOperation, value, err)
+	}
+	return LockHolder{Operation: parts[0], Host: parts[1], PID: pid, Since: since}, nil
+}
+
+// String returns h as a mint:operation tag value.
+func (h LockHolder) String() string {
+	return fmt.Sprintf("%s:%s:%d:%s", h.Operation, h.Host, h.PID, h.Since.UTC().Format(time.RFC3339))
+}
+
+// describe returns h for messages, e.g. `mint recreate on host "laptop"
+// (pid 4242) since 2026-10-15 10:04:05 UTC`.
+func (h LockHolder) describe() string {
+	return fmt.Sprintf("mint %s on host %q (pid %d) since %s",
+		h.Operation, h.Host, h.PID, h.Since.UTC().Format("2006-01-02 15:04:05 UTC"))
+}
+
+// LockedError is returned when another run holds the operation lock on a
+// VM and it has not expired.
+type LockedError struct {
+	VMName     string
+	InstanceID string
+	Holder     LockHolder
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("VM %q (%s) is locked by %s — wait for it to finish (the lock expires at %s), or pass %s if it is no longer running",
+		e.VMName, e.InstanceID, e.Holder.describe(),
+		e.Holder.Since.Add(OperationLockExpiry).UTC().Format("15:04:05 UTC"), hint.Cmd("--steal-lock"))
+}
+
+// Locker takes per-VM operation locks: a mint:operation tag on the
+// instance naming the run that holds it, so two mutating lifecycle
+// commands for the same VM do not interleave.
+type Locker struct {
+	describe   mintaws.DescribeInstancesAPI
+	createTags mintaws.CreateTagsAPI
+	deleteTags DeleteTagsAPI
+	warn       io.Writer
+	host       string
+	pid        int
+	now        func() time.Time
+}
+
+// NewLocker creates a Locker identifying this process by its hostname and
+// pid. Warnings about stolen locks are discarded until WithWarnings is
+// called.
+func NewLocker(describe mintaws.DescribeInstancesAPI, createTags mintaws.CreateTagsAPI, deleteTags DeleteTagsAPI) *Locker {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &Locker{
+		describe:   describe,
+		createTags: createTags,
+		deleteTags: deleteTags,
+		warn:       io.Discard,
+		host:       host,
+		pid:        os.Getpid(),
+		now:        time.Now,
+	}
+}
+
+// WithWarnings sets where the warning printed on stealing a lock goes.
+func (l *Locker) WithWarnings(w io.Writer) *Locker {
+	l.warn = w
+	return l
+}
+
+// WithIdentity overrides the hostname, pid, and clock the Locker records
+// (for testing).
+func (l *Locker) WithIdentity(host string, pid int, now func() time.Time) *Locker {
+	l.host, l.pid, l.now = host, pid, now
+	return l
+}
+
+// OperationLock is a held operation lock. Release removes it.
+type OperationLock struct {
+	instanceID string
+	value      string
+	deleteTags DeleteTagsAPI
+}
+
+// Acquire takes the operation lock on found for operation. A lock another
+// run holds is refused with a *LockedError unless it has expired or steal
+// is set, in which case a warning is printed and the lock is overwritten.
+// After writing the tag, Acquire reads it back: when a new lock from
+// another run is there, that run wrote it in the meantime and wins, and
+// this one is refused.
+func (l *Locker) Acquire(ctx context.Context, found *vm.VM, operation string, steal bool) (*OperationLock, error) {
+	now := l.now()
+	previous := found.Tags[tags.TagOperation]
+	if value := previous; value != "" {
+		// An unparseable value names no run that could still hold it.
+		if holder, err := ParseLockHolder(value); err == nil && !l.ownedBy(holder) &&
+			now.Sub(holder.Since) < OperationLockExpiry {
+			if !steal {
+				return nil, &LockedError{VMName: found.Name, InstanceID: found.ID, Holder: holder}
+			}
+			fmt.Fprintf(l.warn, "WARNING: stealing the operation lock on VM %q (%s) from %s.\n", found.Name, found.ID, holder.describe())
+			fmt.Fprintf(l.warn, "WARNING: if that command is still running, both will change the VM at once and may leave it broken.\n")
+		}
+	}
+
+	mine := LockHolder{Operation: operation, Host: l.host, PID: l.pid, Since: now}
+	lock := &OperationLock{instanceID: found.ID, value: mine.String(), deleteTags: l.deleteTags}
+	if _, err := l.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{found.ID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagOperation), Value: aws.String(lock.value)}},
+	}); err != nil {
+		return nil, fmt.Errorf("taking the operation lock on %s: %w", found.ID, err)
+	}
+
+	current, err := vm.FindVMByID(ctx, l.describe, found.ID)
+	if err != nil {
+		_ = lock.Release(context.WithoutCancel(ctx))
+		return nil, fmt.Errorf("verifying the operation lock on %s: %w", found.ID, err)
+	}
+	// DescribeInstances is eventually consistent, so a missing tag or the
+	// value this run overwrote is not proof of a lost race; only a new lock
+	// from another run is.
+	if current != nil {
+		if value := current.Tags[tags.TagOperation]; value != "" && value != lock.value && value != previous {
+			if holder, err := ParseLockHolder(value); err == nil && !l.ownedBy(holder) {
+				return nil, &LockedError{VMName: found.Name, InstanceID: found.ID, Holder: holder}
+			}
+		}
+	}
+	return lock, nil
+}
+
+// ownedBy reports whether holder is this process.
+func (l *Locker) ownedBy(holder LockHolder) bool {
+	return holder.Host == l.host && holder.PID == l.pid
+}
+
+// Release removes the lock. The tag is deleted only while it still holds
+// this run's value, so a lock stolen from this run is left in place.
+// Releasing a nil lock does nothing.
+func (lk *OperationLock) Release(ctx context.Context) error {
+	if lk == nil || lk.deleteTags == nil {
+		return nil
+	}
+	_, err := lk.deleteTags.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{lk.instanceID},
+		Tags:      []ec2types.Tag{{Key: aws.String(tags.TagOperation), Value: aws.String(lk.value)}},
+	})
+	if err != nil {
+		return fmt.Errorf("releasing the operation lock on %s: %w", lk.instanceID, err)
+	}
+	return nil
+}
+
+// LaunchRaceError is returned when another run launched an instance for
+// the same VM at the same time and this run's instance, the younger, was
+// terminated.
+type LaunchRaceError struct {
+	VMName     string
+	InstanceID string // this run's instance, now terminating
+	Winner     string // the other run's instance, which is kept
+}
+
+func (e *LaunchRaceError) Error() string {
+	return fmt.Sprintf("another mint run launched instance %s for VM %q at the same time — terminated this run's duplicate %s; check the VM with %s",
+		e.Winner, e.VMName, e.InstanceID, hint.Cmd("mint status"))
+}
+
+// ResolveLaunchRace looks for other instances of owner's VM vmName after
+// this run launched instanceID. When two runs launch at once, each sees
+// both instances; the younger one (by launch time, then instance ID) is
+// terminated by the run that launched it, which gets a *LaunchRaceError.
+// The older instance is left to its own run.
+func ResolveLaunchRace(ctx context.Context, describe mintaws.DescribeInstancesAPI, terminate mintaws.TerminateInstancesAPI, owner, vmName, instanceID string) error {
+	instances, err := vm.FindVMInstances(ctx, describe, owner, vmName)
+	if err != nil {
+		return fmt.Errorf("checking for a concurrent launch: %w", err)
+	}
+	var mine *vm.VM
+	for _, inst := range instances {
+		if inst.ID == instanceID {
+			mine = inst
+		}
+	}
+	if mine == nil {
+		return nil
+	}
+	for _, other := range instances {
+		if other.ID != instanceID && launchedBefore(other, mine) {
+			if _, err := terminate.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{instanceID},
+			}); err != nil {
+				return fmt.Errorf("another mint run launched instance %s for VM %q at the same time; terminating this run's duplicate %s: %w",
+					other.ID, vmName, instanceID, err)
+			}
+			return &LaunchRaceError{VMName: vmName, InstanceID: instanceID, Winner: other.ID}
+		}
+	}
+	return nil
+}
+
+// launchedBefore reports whether a is older than b. Instances launched in
+// the same second are ordered by ID so both runs agree on the winner.
+func launchedBefore(a, b *vm.VM) bool {
+	if !a.LaunchTime.Equal(b.LaunchTime) {
+		return a.LaunchTime.Before(b.LaunchTime)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,280 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// fakeTaggedInstance is one instance whose tags CreateTags and DeleteTags
+// change and DescribeInstances reports.
+type fakeTaggedInstance struct {
+	id   string
+	tags map[string]string
+	// overwrite, when set, replaces mint:operation right after CreateTags,
+	// as a concurrent run writing its own lock would.
+	overwrite string
+}
+
+func (f *fakeTaggedInstance) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	inst := ec2types.Instance{
+		InstanceId: aws.String(f.id),
+		State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+	}
+	for k, v := range f.tags {
+		inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{inst}}}}, nil
+}
+
+func (f *fakeTaggedInstance) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	for _, t := range params.Tags {
+		f.tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	if f.overwrite != "" {
+		f.tags[tags.TagOperation] = f.overwrite
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *fakeTaggedInstance) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	for _, t := range params.Tags {
+		if t.Value == nil || f.tags[aws.ToString(t.Key)] == aws.ToString(t.Value) {
+			delete(f.tags, aws.ToString(t.Key))
+		}
+	}
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+var lockNow = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestLocker(f *fakeTaggedInstance) (*Locker, *bytes.Buffer) {
+	var warn bytes.Buffer
+	l := NewLocker(f, f, f).WithWarnings(&warn).WithIdentity("laptop", 100, func() time.Time { return lockNow })
+	return l, &warn
+}
+
+func lockedVM(f *fakeTaggedInstance) *vm.VM {
+	return &vm.VM{ID: f.id, Name: "default", Tags: f.tags}
+}
+
+func TestParseLockHolder(t *testing.T) {
+	h, err := ParseLockHolder("recreate:desktop:4242:2026-10-15T11:50:00Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := LockHolder{Operation: "recreate", Host: "desktop", PID: 4242, Since: time.Date(2026, 10, 15, 11, 50, 0, 0, time.UTC)}
+	if h != want {
+		t.Errorf("holder = %+v, want %+v", h, want)
+	}
+	if h.String() != "recreate:desktop:4242:2026-10-15T11:50:00Z" {
+		t.Errorf("String() = %q", h.String())
+	}
+
+	for _, bad := range []string{"recreate", "up:host:pid:2026-10-15T11:50:00Z", "up:host:1:yesterday"} {
+		if _, err := ParseLockHolder(bad); err == nil {
+			t.Errorf("ParseLockHolder(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestLockerAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		steal    bool
+		wantErr  bool
+		wantWarn bool
+	}{
+		{"unlocked", "", false, false, false},
+		{"lock respected", "recreate:desktop:4242:2026-10-15T11:50:00Z", false, true, false},
+		{"lock expired", "recreate:desktop:4242:2026-10-15T11:20:00Z", false, false, false},
+		{"lock stolen", "recreate:desktop:4242:2026-10-15T11:50:00Z", true, false, true},
+		{"own lock", "recreate:laptop:100:2026-10-15T11:50:00Z", false, false, false},
+		{"unparseable lock", "garbage", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeTaggedInstance{id: "i-abc", tags: map[string]string{}}
+			if tt.existing != "" {
+				f.tags[tags.TagOperation] = tt.existing
+			}
+			l, warn := newTestLocker(f)
+
+			lock, err := l.Acquire(context.Background(), lockedVM(f), "up", tt.steal)
+			if tt.wantErr {
+				var locked *LockedError
+				if !errors.As(err, &locked) {
+					t.Fatalf("error = %v, want a *LockedError", err)
+				}
+				if locked.Holder.Host != "desktop" || !strings.Contains(err.Error(), "--steal-lock") {
+					t.Errorf("error = %q, want the holder and --steal-lock", err)
+				}
+				if f.tags[tags.TagOperation] != tt.existing {
+					t.Errorf("lock tag = %q, want it untouched", f.tags[tags.TagOperation])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := f.tags[tags.TagOperation]; got != "up:laptop:100:2026-10-15T12:00:00Z" {
+				t.Errorf("lock tag = %q, want this run's lock", got)
+			}
+			if gotWarn := strings.Contains(warn.String(), "WARNING: stealing"); gotWarn != tt.wantWarn {
+				t.Errorf("steal warning printed = %v, want %v:\n%s", gotWarn, tt.wantWarn, warn.String())
+			}
+
+			if err := lock.Release(context.Background()); err != nil {
+				t.Fatalf("Release: %v", err)
+			}
+			if v, ok := f.tags[tags.TagOperation]; ok {
+				t.Errorf("lock tag = %q after Release, want it removed", v)
+			}
+		})
+	}
+}
+
+func TestLockerAcquireLostRace(t *testing.T) {
+	other := "recreate:desktop:4242:2026-10-15T12:00:00Z"
+	f := &fakeTaggedInstance{id: "i-abc", tags: map[string]string{}, overwrite: other}
+	l, _ := newTestLocker(f)
+
+	_, err := l.Acquire(context.Background(), lockedVM(f), "up", false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder.PID != 4242 {
+		t.Fatalf("error = %v, want a *LockedError naming the other run", err)
+	}
+	if f.tags[tags.TagOperation] != other {
+		t.Errorf("lock tag = %q, want the other run's lock", f.tags[tags.TagOperation])
+	}
+}
+
+func TestOperationLockReleaseKeepsStolenLock(t *testing.T) {
+	f := &fakeTaggedInstance{id: "i-abc", tags: map[string]string{}}
+	l, _ := newTestLocker(f)
+	lock, err := l.Acquire(context.Background(), lockedVM(f), "recreate", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	thief := "up:desktop:4242:2026-10-15T12:05:00Z"
+	f.tags[tags.TagOperation] = thief
+	if err := lock.Release(context.Background()); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if f.tags[tags.TagOperation] != thief {
+		t.Errorf("lock tag = %q, want the stealer's lock kept", f.tags[tags.TagOperation])
+	}
+}
+
+func TestResolveLaunchRace(t *testing.T) {
+	t0 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	instance := func(id string, launched time.Time) ec2types.Instance {
+		return ec2types.Instance{
+			InstanceId: aws.String(id),
+			LaunchTime: aws.Time(launched),
+			State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNamePending},
+		}
+	}
+	tests := []struct {
+		name          string
+		instances     []ec2types.Instance
+		wantTerminate bool
+		wantWinner    string
+	}{
+		{"alone", []ec2types.Instance{instance("i-mine", t0)}, false, ""},
+		{"ours is older", []ec2types.Instance{instance("i-mine", t0), instance("i-other", t0.Add(time.Second))}, false, ""},
+		{"ours is younger", []ec2types.Instance{instance("i-other", t0), instance("i-mine", t0.Add(time.Second))}, true, "i-other"},
+		{"same second, lower ID wins", []ec2types.Instance{instance("i-mine", t0), instance("i-aaa", t0)}, true, "i-aaa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			describe := &mockUpDescribeInstances{output: &ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{{Instances: tt.instances}},
+			}}
+			terminate := &mockTerminateInstances{}
+
+			err := ResolveLaunchRace(context.Background(), describe, terminate, "alice", "default", "i-mine")
+			if !tt.wantTerminate {
+				if err != nil || terminate.called {
+					t.Errorf("err = %v, terminated = %v; want the instance kept", err, terminate.called)
+				}
+				return
+			}
+			var race *LaunchRaceError
+			if !errors.As(err, &race) || race.Winner != tt.wantWinner {
+				t.Fatalf("error = %v, want a *LaunchRaceError won by %s", err, tt.wantWinner)
+			}
+			if !terminate.called || terminate.input.InstanceIds[0] != "i-mine" {
+				t.Errorf("terminated %+v, want i-mine", terminate.input)
+			}
+		})
+	}
+}
+
+// stoppedVMWithTags returns a DescribeInstances output of alice's stopped
+// VM default with extra tags.
+func stoppedVMWithTags(extra ...ec2types.Tag) *ec2.DescribeInstancesOutput {
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:      aws.String("i-stopped1"),
+				State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+				PublicIpAddress: aws.String("54.0.0.1"),
+				Tags: append([]ec2types.Tag{
+					{Key: aws.String(tags.TagVM), Value: aws.String("default")},
+					{Key: aws.String(tags.TagOwner), Value: aws.String("alice")},
+				}, extra...),
+			}},
+		}},
+	}
+}
+
+func TestRunLocksExistingVM(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = stoppedVMWithTags()
+	locker := NewLocker(m.describeInstances, m.createTags, m.deleteTags).
+		WithIdentity("laptop", 100, func() time.Time { return lockNow })
+	p := m.build(WithOperationLock(locker, false))
+
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !m.startInstances.called {
+		t.Error("StartInstances should be called for an unlocked stopped VM")
+	}
+	if !m.deleteTags.called || aws.ToString(m.deleteTags.input.Tags[0].Key) != tags.TagOperation ||
+		aws.ToString(m.deleteTags.input.Tags[0].Value) != "up:laptop:100:2026-10-15T12:00:00Z" {
+		t.Errorf("DeleteTags input = %+v, want this run's lock released", m.deleteTags.input)
+	}
+}
+
+func TestRunRefusesLockedExistingVM(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = stoppedVMWithTags(
+		ec2types.Tag{Key: aws.String(tags.TagOperation), Value: aws.String("recreate:desktop:4242:2026-10-15T11:50:00Z")},
+	)
+	locker := NewLocker(m.describeInstances, m.createTags, m.deleteTags).
+		WithIdentity("laptop", 100, func() time.Time { return lockNow })
+	p := m.build(WithOperationLock(locker, false))
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("error = %v, want a *LockedError", err)
+	}
+	if m.startInstances.called {
+		t.Error("StartInstances called on a locked VM")
+	}
+}
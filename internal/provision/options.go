@@ -0,0 +1,348 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/logging"
+)
+
+// Clients holds the AWS APIs a Provisioner cannot run without. Every field
+// is required; EC2Clients fills them all from one EC2 client.
+type Clients struct {
+	DescribeInstances mintaws.DescribeInstancesAPI
+	StartInstances    mintaws.StartInstancesAPI
+	RunInstances      mintaws.RunInstancesAPI
+	DescribeSGs       mintaws.DescribeSecurityGroupsAPI
+	DescribeSubnets   mintaws.DescribeSubnetsAPI
+	CreateVolume      mintaws.CreateVolumeAPI
+	AttachVolume      mintaws.AttachVolumeAPI
+	AllocateAddr      mintaws.AllocateAddressAPI
+	AssociateAddr     mintaws.AssociateAddressAPI
+	DescribeAddrs     mintaws.DescribeAddressesAPI
+	CreateTags        mintaws.CreateTagsAPI
+	DescribeImages    mintaws.DescribeImagesAPI
+}
+
+// EC2API is the union of the Clients APIs, all of which *ec2.Client
+// implements.
+type EC2API interface {
+	mintaws.DescribeInstancesAPI
+	mintaws.StartInstancesAPI
+	mintaws.RunInstancesAPI
+	mintaws.DescribeSecurityGroupsAPI
+	mintaws.DescribeSubnetsAPI
+	mintaws.CreateVolumeAPI
+	mintaws.AttachVolumeAPI
+	mintaws.AllocateAddressAPI
+	mintaws.AssociateAddressAPI
+	mintaws.DescribeAddressesAPI
+	mintaws.CreateTagsAPI
+	mintaws.DescribeImagesAPI
+}
+
+// EC2Clients returns Clients with every field set to c.
+func EC2Clients(c EC2API) Clients {
+	return Clients{
+		DescribeInstances: c,
+		StartInstances:    c,
+		RunInstances:      c,
+		DescribeSGs:       c,
+		DescribeSubnets:   c,
+		CreateVolume:      c,
+		AttachVolume:      c,
+		AllocateAddr:      c,
+		AssociateAddr:     c,
+		DescribeAddrs:     c,
+		CreateTags:        c,
+		DescribeImages:    c,
+	}
+}
+
+// missing returns the names of the unset fields of c, in field order.
+func (c Clients) missing() []string {
+	var names []string
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"DescribeInstances", c.DescribeInstances != nil},
+		{"StartInstances", c.StartInstances != nil},
+		{"RunInstances", c.RunInstances != nil},
+		{"DescribeSGs", c.DescribeSGs != nil},
+		{"DescribeSubnets", c.DescribeSubnets != nil},
+		{"CreateVolume", c.CreateVolume != nil},
+		{"AttachVolume", c.AttachVolume != nil},
+		{"AllocateAddr", c.AllocateAddr != nil},
+		{"AssociateAddr", c.AssociateAddr != nil},
+		{"DescribeAddrs", c.DescribeAddrs != nil},
+		{"CreateTags", c.CreateTags != nil},
+		{"DescribeImages", c.DescribeImages != nil},
+	} {
+		if !f.set {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// Option configures an optional dependency of a Provisioner.
+type Option func(*Provisioner)
+
+// NewProvisioner creates a Provisioner from its required clients and any
+// options. It returns an error naming every unset field of c.
+func NewProvisioner(c Clients, opts ...Option) (*Provisioner, error) {
+	if missing := c.missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("provisioner missing required clients: %s", strings.Join(missing, ", "))
+	}
+	p := &Provisioner{
+		describeInstances: c.DescribeInstances,
+		startInstances:    c.StartInstances,
+		runInstances:      c.RunInstances,
+		describeSGs:       c.DescribeSGs,
+		describeSubnets:   c.DescribeSubnets,
+		createVolume:      c.CreateVolume,
+		attachVolume:      c.AttachVolume,
+		allocateAddr:      c.AllocateAddr,
+		associateAddr:     c.AssociateAddr,
+		describeAddrs:     c.DescribeAddrs,
+		createTags:        c.CreateTags,
+		describeImages:    c.DescribeImages,
+		verifyBootstrap:   bootstrap.Verify,
+		resolveAMI:        mintaws.ResolveAMI,
+		rollbackEnabled:   true,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// WithWaitRunning sets the waiter used to block until the instance is running
+// before attaching the EBS volume. When nil, no wait is performed (tests).
+func WithWaitRunning(w mintaws.WaitInstanceRunningAPI) Option {
+	return func(p *Provisioner) { p.waitRunning = w }
+}
+
+// WithWaitVolumeAvailable sets the waiter used to block until the EBS volume
+// is available before attaching it. When nil, no wait is performed (tests).
+func WithWaitVolumeAvailable(w mintaws.WaitVolumeAvailableAPI) Option {
+	return func(p *Provisioner) { p.waitVolumeAvailable = w }
+}
+
+// WithWaitEIPAssociated sets the waiter used to block until a newly
+// associated Elastic IP is the instance's public IP. When nil, no wait is
+// performed (tests).
+func WithWaitEIPAssociated(w mintaws.WaitEIPAssociatedAPI) Option {
+	return func(p *Provisioner) { p.waitEIP = w }
+}
+
+// WithDescribeVolumes sets the DescribeVolumes client for pending-attach recovery.
+func WithDescribeVolumes(dv mintaws.DescribeVolumesAPI) Option {
+	return func(p *Provisioner) { p.describeVolumes = dv }
+}
+
+// WithDescribeInstanceTypes sets the DescribeInstanceTypes client a
+// hibernation-enabled launch checks its instance type with. Run fails
+// before creating anything when cfg.Hibernate is set without it.
+func WithDescribeInstanceTypes(dt mintaws.DescribeInstanceTypesAPI) Option {
+	return func(p *Provisioner) { p.describeTypes = dt }
+}
+
+// WithDeleteTags sets the DeleteTags client for pending-attach tag cleanup.
+func WithDeleteTags(dt DeleteTagsAPI) Option {
+	return func(p *Provisioner) { p.deleteTags = dt }
+}
+
+// WithTerminateInstances sets the TerminateInstances client used to roll
+// back an instance launched by a failed run. When nil, rollback leaves the
+// instance and reports it.
+func WithTerminateInstances(t mintaws.TerminateInstancesAPI) Option {
+	return func(p *Provisioner) { p.terminateInstances = t }
+}
+
+// WithReleaseAddress sets the ReleaseAddress client used to roll back an
+// Elastic IP allocated by a failed run. When nil, rollback leaves the
+// address and reports it.
+func WithReleaseAddress(r mintaws.ReleaseAddressAPI) Option {
+	return func(p *Provisioner) { p.releaseAddr = r }
+}
+
+// WithBootstrapVerifier overrides the default bootstrap verifier (for testing).
+func WithBootstrapVerifier(v BootstrapVerifier) Option {
+	return func(p *Provisioner) { p.verifyBootstrap = v }
+}
+
+// WithLogger sets the structured logger for AWS API call timing and error logging.
+// When nil (the default), logging is skipped and there is no behavioral change.
+func WithLogger(l logging.Logger) Option {
+	return func(p *Provisioner) { p.logger = l }
+}
+
+// WithAMIResolver overrides the default AMI resolver (for testing).
+func WithAMIResolver(r AMIResolver) Option {
+	return func(p *Provisioner) { p.resolveAMI = r }
+}
+
+// WithBootstrapPollFunc sets a function to poll for bootstrap completion.
+// When set, Run() calls this after EIP allocation on fresh provisions (not restarts).
+// Use WithBootstrapPoller for production; this option enables test injection.
+func WithBootstrapPollFunc(fn BootstrapPollFunc) Option {
+	return func(p *Provisioner) { p.pollBootstrap = fn }
+}
+
+// WithBootstrapPoller sets a BootstrapPoller to poll for bootstrap completion.
+// Wraps the poller's Poll method as a BootstrapPollFunc.
+func WithBootstrapPoller(bp *BootstrapPoller) Option {
+	return func(p *Provisioner) { p.pollBootstrap = bp.Poll }
+}
+
+// WithInstanceTypeCheck sets the check run on the configured instance type
+// before any resources are created. When nil (the default), the type is not
+// checked and an invalid one surfaces only when RunInstances rejects it.
+func WithInstanceTypeCheck(fn InstanceTypeCheckFunc) Option {
+	return func(p *Provisioner) { p.checkType = fn }
+}
+
+// WithInstanceTypeOffering sets the check run on the configured instance
+// type in the AZ of each candidate subnet before any resources are created.
+// Subnets in AZs that do not offer the type are skipped. When nil (the
+// default), every subnet is tried and RunInstances reports an unoffered type.
+func WithInstanceTypeOffering(fn InstanceTypeOfferingFunc) Option {
+	return func(p *Provisioner) { p.checkOffering = fn }
+}
+
+// WithExtraTags sets tags added to every resource Run creates or tags: the
+// instance, its project volume, and its Elastic IP. Run fails before
+// creating anything when one would override a tag mint sets itself.
+func WithExtraTags(extra map[string]string) Option {
+	return func(p *Provisioner) { p.extraTags = extra }
+}
+
+// WithJournal sets the store for provisioning journals. With a store, Run
+// records each completed step and resumes an interrupted run for the same
+// VM instead of starting over. When nil (the default), nothing is recorded.
+func WithJournal(s *JournalStore) Option {
+	return func(p *Provisioner) { p.journal = s }
+}
+
+// WithDryRun makes Run plan rather than provision: it makes every read-only
+// call a real run makes, then returns a ProvisionResult whose Plan describes
+// what it would create, without creating, starting, or tagging anything or
+// writing the journal.
+func WithDryRun(dryRun bool) Option {
+	return func(p *Provisioner) { p.dryRun = dryRun }
+}
+
+// WithRollback sets whether a fresh provision that fails after launching
+// removes what it created, newest first: the Elastic IP it allocated, then
+// the instance. The error reports what was rolled back and what was left.
+// An interrupted run never rolls back; the journal resumes it instead.
+// Rollback is on by default.
+func WithRollback(enabled bool) Option {
+	return func(p *Provisioner) { p.rollbackEnabled = enabled }
+}
+
+// WithOperationLock makes Run hold l's operation lock on an existing VM
+// while it starts or repairs it, refusing to run while another command
+// holds it unless steal is set. For a fresh provision, where there is no
+// instance to lock yet, Run instead checks after launching for an
+// instance a concurrent run launched, and terminates its own if it is the
+// younger (this needs WithTerminateInstances). When nil (the default),
+// nothing is locked or checked.
+func WithOperationLock(l *Locker, steal bool) Option {
+	return func(p *Provisioner) {
+		p.locker = l
+		p.stealLock = steal
+	}
+}
+
+// With applies opts to p and returns it.
+//
+// Deprecated: pass the options to NewProvisioner. With and the WithX
+// methods below remain for one release while callers migrate.
+func (p *Provisioner) With(opts ...Option) *Provisioner {
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithWaitRunning applies the WithWaitRunning option.
+//
+// Deprecated: pass WithWaitRunning to NewProvisioner.
+func (p *Provisioner) WithWaitRunning(w mintaws.WaitInstanceRunningAPI) *Provisioner {
+	return p.With(WithWaitRunning(w))
+}
+
+// WithWaitVolumeAvailable applies the WithWaitVolumeAvailable option.
+//
+// Deprecated: pass WithWaitVolumeAvailable to NewProvisioner.
+func (p *Provisioner) WithWaitVolumeAvailable(w mintaws.WaitVolumeAvailableAPI) *Provisioner {
+	return p.With(WithWaitVolumeAvailable(w))
+}
+
+// WithDescribeVolumes applies the WithDescribeVolumes option.
+//
+// Deprecated: pass WithDescribeVolumes to NewProvisioner.
+func (p *Provisioner) WithDescribeVolumes(dv mintaws.DescribeVolumesAPI) *Provisioner {
+	return p.With(WithDescribeVolumes(dv))
+}
+
+// WithDeleteTags applies the WithDeleteTags option.
+//
+// Deprecated: pass WithDeleteTags to NewProvisioner.
+func (p *Provisioner) WithDeleteTags(dt DeleteTagsAPI) *Provisioner {
+	return p.With(WithDeleteTags(dt))
+}
+
+// WithBootstrapVerifier applies the WithBootstrapVerifier option.
+//
+// Deprecated: pass WithBootstrapVerifier to NewProvisioner.
+func (p *Provisioner) WithBootstrapVerifier(v BootstrapVerifier) *Provisioner {
+	return p.With(WithBootstrapVerifier(v))
+}
+
+// WithLogger applies the WithLogger option.
+//
+// Deprecated: pass WithLogger to NewProvisioner.
+func (p *Provisioner) WithLogger(l logging.Logger) *Provisioner {
+	return p.With(WithLogger(l))
+}
+
+// WithAMIResolver applies the WithAMIResolver option.
+//
+// Deprecated: pass WithAMIResolver to NewProvisioner.
+func (p *Provisioner) WithAMIResolver(r AMIResolver) *Provisioner {
+	return p.With(WithAMIResolver(r))
+}
+
+// WithBootstrapPollFunc applies the WithBootstrapPollFunc option.
+//
+// Deprecated: pass WithBootstrapPollFunc to NewProvisioner.
+func (p *Provisioner) WithBootstrapPollFunc(fn BootstrapPollFunc) *Provisioner {
+	return p.With(WithBootstrapPollFunc(fn))
+}
+
+// WithBootstrapPoller applies the WithBootstrapPoller option.
+//
+// Deprecated: pass WithBootstrapPoller to NewProvisioner.
+func (p *Provisioner) WithBootstrapPoller(bp *BootstrapPoller) *Provisioner {
+	return p.With(WithBootstrapPoller(bp))
+}
+
+// WithInstanceTypeCheck applies the WithInstanceTypeCheck option.
+//
+// Deprecated: pass WithInstanceTypeCheck to NewProvisioner.
+func (p *Provisioner) WithInstanceTypeCheck(fn InstanceTypeCheckFunc) *Provisioner {
+	return p.With(WithInstanceTypeCheck(fn))
+}
+
+// WithJournal applies the WithJournal option.
+//
+// Deprecated: pass WithJournal to NewProvisioner.
+func (p *Provisioner) WithJournal(s *JournalStore) *Provisioner {
+	return p.With(WithJournal(s))
+}
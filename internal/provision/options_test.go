@@ -0,0 +1,90 @@
+package provision
+
+import (
+	"testing"
+)
+
+// happyClients returns Clients over the happy-path mocks.
+func happyClients() Clients {
+	m := newUpHappyMocks()
+	return Clients{
+		DescribeInstances: m.describeInstances,
+		StartInstances:    m.startInstances,
+		RunInstances:      m.runInstances,
+		DescribeSGs:       m.describeSGs,
+		DescribeSubnets:   m.describeSubnets,
+		CreateVolume:      m.createVolume,
+		AttachVolume:      m.attachVolume,
+		AllocateAddr:      m.allocateAddr,
+		AssociateAddr:     m.associateAddr,
+		DescribeAddrs:     m.describeAddrs,
+		CreateTags:        m.createTags,
+		DescribeImages:    m.describeImages,
+	}
+}
+
+func TestNewProvisionerNamesMissingClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		unset   func(c *Clients)
+		wantErr string
+	}{
+		{
+			name:    "one",
+			unset:   func(c *Clients) { c.AssociateAddr = nil },
+			wantErr: "provisioner missing required clients: AssociateAddr",
+		},
+		{
+			name: "several",
+			unset: func(c *Clients) {
+				c.RunInstances = nil
+				c.DescribeImages = nil
+			},
+			wantErr: "provisioner missing required clients: RunInstances, DescribeImages",
+		},
+		{
+			name:    "all",
+			unset:   func(c *Clients) { *c = Clients{} },
+			wantErr: "provisioner missing required clients: DescribeInstances, StartInstances, RunInstances, DescribeSGs, DescribeSubnets, CreateVolume, AttachVolume, AllocateAddr, AssociateAddr, DescribeAddrs, CreateTags, DescribeImages",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := happyClients()
+			tt.unset(&c)
+			p, err := NewProvisioner(c)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if p != nil {
+				t.Error("NewProvisioner returned a Provisioner with an error")
+			}
+		})
+	}
+}
+
+func TestNewProvisionerAppliesOptions(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	logger := &mockLogger{}
+	p, err := NewProvisioner(happyClients(), WithJournal(store), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewProvisioner: %v", err)
+	}
+	if p.journal != store || p.logger != logger {
+		t.Error("options were not applied")
+	}
+	if p.verifyBootstrap == nil || p.resolveAMI == nil {
+		t.Error("default bootstrap verifier and AMI resolver should be set")
+	}
+}
+
+func TestDeprecatedSettersMatchOptions(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	p, err := NewProvisioner(happyClients())
+	if err != nil {
+		t.Fatalf("NewProvisioner: %v", err)
+	}
+	if p.WithJournal(store) != p || p.journal != store {
+		t.Error("WithJournal should set the journal and return the Provisioner")
+	}
+}
@@ -0,0 +1,170 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// PlanAction is what a dry run found Run would do with the VM.
+type PlanAction string
+
+const (
+	// PlanLaunch launches a fresh instance.
+	PlanLaunch PlanAction = "launch"
+	// PlanStart starts the VM's stopped instance.
+	PlanStart PlanAction = "start"
+	// PlanNone leaves the VM's running instance as it is.
+	PlanNone PlanAction = "none"
+	// PlanResume resumes the interrupted run recorded in the journal.
+	PlanResume PlanAction = "resume"
+)
+
+// Values of ProvisionPlan.EIP.
+const (
+	PlanEIPAllocate = "allocate" // a new Elastic IP is allocated
+	PlanEIPReuse    = "reuse"    // the VM keeps, or is given, an existing allocation
+	PlanEIPNone     = "none"     // the VM has no Elastic IP (ipv6-only)
+)
+
+// ProvisionPlan is what Run would do, as found by a dry run. The launch
+// fields are set only for PlanLaunch.
+type ProvisionPlan struct {
+	Action PlanAction
+	// InstanceID is the existing instance a start, resume, or no-op acts on.
+	InstanceID string
+	// ResumeStep is where a resumed run picks up, such as "volume" or
+	// "allocate-eip".
+	ResumeStep string
+
+	InstanceType string
+	AMI          string
+	IPMode       string
+	Spot         bool
+	Hibernate    bool
+	// SubnetID and AvailabilityZone are the subnet tried first. A launch
+	// that finds no capacity there moves on to the other default subnets.
+	SubnetID         string
+	AvailabilityZone string
+	SecurityGroupIDs []string
+
+	RootVolumeGB      int32
+	ProjectVolumeGB   int32 // 0 when a pending-attach volume is adopted
+	ProjectVolumeIOPS int32
+	// ProjectVolumeThroughput is the project volume's gp3 throughput in
+	// MB/s; 0 when a pending-attach volume is adopted.
+	ProjectVolumeThroughput int32
+	// AdoptVolumeID is a project volume left with the pending-attach tag by
+	// an interrupted mint recreate, attached instead of creating one.
+	AdoptVolumeID string
+
+	// EIP is PlanEIPAllocate, PlanEIPReuse, or PlanEIPNone; empty when the
+	// running VM's address is left alone. AllocationID is the allocation
+	// reused, when known.
+	EIP          string
+	AllocationID string
+
+	// UserDataBytes is the size of the rendered bootstrap stub, before
+	// base64 encoding, and PrefetchImages the container images that fit
+	// in it.
+	UserDataBytes  int
+	PrefetchImages int
+}
+
+// planResume is the dry-run counterpart of resume: it inspects what the
+// interrupted run in j left behind and reports where it would resume. When
+// nothing but a reusable Elastic IP survives, it resets j and returns nil so
+// the caller plans a fresh launch.
+func (p *Provisioner) planResume(ctx context.Context, j *Journal, _ string) (*ProvisionResult, error) {
+	existing, state, err := p.inspectJournal(ctx, j)
+	if err != nil {
+		return nil, fmt.Errorf("checking resources of interrupted run: %w", err)
+	}
+	action := decideResume(j, state)
+	if action == resumeRelaunch {
+		j.Step = StepStarted
+		j.InstanceID, j.VolumeID, j.VolumeSizeGB = "", "", 0
+		j.IPv6Address = ""
+		return nil, nil
+	}
+	return &ProvisionResult{
+		Resumed: true,
+		Plan: &ProvisionPlan{
+			Action:     PlanResume,
+			InstanceID: existing.ID,
+			ResumeStep: string(action),
+		},
+	}, nil
+}
+
+// planExisting is the dry-run counterpart of handleExistingVM and what Run
+// does after it: a stopped VM would be started, with a fresh Elastic IP if
+// mint gc released its own, and a running VM would adopt a pending-attach
+// volume.
+func (p *Provisioner) planExisting(ctx context.Context, existing *vm.VM, owner, vmName string) (*ProvisionResult, error) {
+	plan := &ProvisionPlan{
+		Action:       PlanNone,
+		InstanceID:   existing.ID,
+		InstanceType: existing.InstanceType,
+	}
+	if existing.State == string(ec2types.InstanceStateNameStopped) {
+		plan.Action = PlanStart
+		plan.EIP = PlanEIPReuse
+		if existing.Tags[tags.TagEIP] == tags.EIPReleasedByGC {
+			if err := p.checkEIPQuota(ctx, owner); err != nil {
+				return nil, err
+			}
+			plan.EIP = PlanEIPAllocate
+		}
+		return &ProvisionResult{Plan: plan}, nil
+	}
+
+	pendingVolID, _, err := p.findPendingAttachVolume(ctx, owner, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("checking pending-attach volumes for running VM: %w", err)
+	}
+	plan.AdoptVolumeID = pendingVolID
+	return &ProvisionResult{Plan: plan}, nil
+}
+
+// planLaunch renders the user-data a fresh instance would be launched with,
+// checking its size as launchInstance does, and returns the plan for the
+// launch.
+func planLaunch(j *Journal, cfg ProvisionConfig, amiID string, sgIDs []string, subnets []launchSubnet, rootVolSize, volumeSize, volumeIOPS, volumeThroughput int32, pendingVolID string) (*ProvisionResult, error) {
+	stub, prefetch, _, err := renderUserData(cfg, j.VM)
+	if err != nil {
+		return nil, fmt.Errorf("launching instance: %w", err)
+	}
+
+	plan := &ProvisionPlan{
+		Action:                  PlanLaunch,
+		InstanceType:            cfg.InstanceType,
+		AMI:                     amiID,
+		IPMode:                  j.IPMode,
+		Spot:                    cfg.Spot,
+		Hibernate:               cfg.Hibernate,
+		SubnetID:                subnets[0].ID,
+		AvailabilityZone:        subnets[0].AZ,
+		SecurityGroupIDs:        sgIDs,
+		RootVolumeGB:            rootVolSize,
+		ProjectVolumeGB:         volumeSize,
+		ProjectVolumeIOPS:       volumeIOPS,
+		ProjectVolumeThroughput: volumeThroughput,
+		AdoptVolumeID:           pendingVolID,
+		EIP:                     PlanEIPAllocate,
+		AllocationID:            j.AllocationID,
+		UserDataBytes:           len(stub),
+		PrefetchImages:          len(prefetch),
+	}
+	switch {
+	case j.IPMode == tags.IPModeIPv6Only:
+		plan.EIP, plan.AllocationID = PlanEIPNone, ""
+	case j.AllocationID != "":
+		plan.EIP = PlanEIPReuse
+	}
+	return &ProvisionResult{Plan: plan}, nil
+}
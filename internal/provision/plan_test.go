@@ -0,0 +1,293 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+// errDryRunMutation is returned by mutationGuard so a dry run that makes a
+// mutating call also fails.
+var errDryRunMutation = errors.New("mutating call during dry run")
+
+// mutationGuard fails the test on any mutating EC2 call.
+type mutationGuard struct{ t *testing.T }
+
+func (g mutationGuard) fail(op string) error {
+	g.t.Helper()
+	g.t.Errorf("dry run called %s", op)
+	return errDryRunMutation
+}
+
+func (g mutationGuard) StartInstances(ctx context.Context, params *ec2.StartInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return nil, g.fail("StartInstances")
+}
+
+func (g mutationGuard) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	return nil, g.fail("RunInstances")
+}
+
+func (g mutationGuard) CreateVolume(ctx context.Context, params *ec2.CreateVolumeInput, optFns ...func(*ec2.Options)) (*ec2.CreateVolumeOutput, error) {
+	return nil, g.fail("CreateVolume")
+}
+
+func (g mutationGuard) AttachVolume(ctx context.Context, params *ec2.AttachVolumeInput, optFns ...func(*ec2.Options)) (*ec2.AttachVolumeOutput, error) {
+	return nil, g.fail("AttachVolume")
+}
+
+func (g mutationGuard) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
+	return nil, g.fail("AllocateAddress")
+}
+
+func (g mutationGuard) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	return nil, g.fail("AssociateAddress")
+}
+
+func (g mutationGuard) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	return nil, g.fail("CreateTags")
+}
+
+func (g mutationGuard) DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return nil, g.fail("DeleteTags")
+}
+
+// buildDryRun returns a dry-run Provisioner over m's read-only mocks whose
+// mutating clients fail the test.
+func buildDryRun(t *testing.T, m *upMocks, opts ...Option) *Provisioner {
+	t.Helper()
+	guard := mutationGuard{t: t}
+	p, err := NewProvisioner(Clients{
+		DescribeInstances: m.describeInstances,
+		StartInstances:    guard,
+		RunInstances:      guard,
+		DescribeSGs:       m.describeSGs,
+		DescribeSubnets:   m.describeSubnets,
+		CreateVolume:      guard,
+		AttachVolume:      guard,
+		AllocateAddr:      guard,
+		AssociateAddr:     guard,
+		DescribeAddrs:     m.describeAddrs,
+		CreateTags:        guard,
+		DescribeImages:    m.describeImages,
+	}, append([]Option{
+		WithBootstrapVerifier(m.bootstrapVerifier),
+		WithAMIResolver(m.amiResolver),
+		WithDescribeVolumes(m.describeVolumes),
+		WithDeleteTags(guard),
+		WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+			t.Error("dry run polled bootstrap")
+			return nil
+		}),
+		WithDryRun(true),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDryRunPlansFreshLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	store := NewJournalStore(t.TempDir())
+	cfg := defaultConfig()
+	cfg.VolumeIOPS = 6000
+	cfg.PrefetchImages = []string{"node:22"}
+
+	result, err := buildDryRun(t, m, WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	plan := result.Plan
+	if plan == nil {
+		t.Fatal("dry run returned no plan")
+	}
+	want := ProvisionPlan{
+		Action:                  PlanLaunch,
+		InstanceType:            "m6i.xlarge",
+		AMI:                     "ami-ubuntu2404",
+		IPMode:                  tags.IPModeEIP,
+		SubnetID:                "subnet-abc",
+		AvailabilityZone:        "us-east-1a",
+		SecurityGroupIDs:        []string{"sg-user1", "sg-admin1"},
+		RootVolumeGB:            200,
+		ProjectVolumeGB:         50,
+		ProjectVolumeIOPS:       6000,
+		ProjectVolumeThroughput: 125,
+		EIP:                     PlanEIPAllocate,
+		UserDataBytes:           plan.UserDataBytes,
+		PrefetchImages:          1,
+	}
+	if !reflect.DeepEqual(*plan, want) {
+		t.Errorf("plan = %+v\nwant %+v", *plan, want)
+	}
+
+	stub, _, _, err := renderUserData(cfg, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.UserDataBytes != len(stub) || plan.UserDataBytes == 0 {
+		t.Errorf("UserDataBytes = %d, want %d", plan.UserDataBytes, len(stub))
+	}
+	if result.InstanceID != "" {
+		t.Errorf("dry run reported an instance: %+v", result)
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("dry run wrote a journal: %+v", j)
+	}
+}
+
+func TestDryRunAdoptsPendingAttachVolume(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+		{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+		{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+	}}
+	m.describeVolumes.output = &ec2.DescribeVolumesOutput{Volumes: []ec2types.Volume{
+		{VolumeId: aws.String("vol-pending"), AvailabilityZone: aws.String("us-east-1b")},
+	}}
+
+	result, err := buildDryRun(t, m).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	plan := result.Plan
+	if plan.AdoptVolumeID != "vol-pending" || plan.ProjectVolumeGB != 0 || plan.ProjectVolumeIOPS != 0 {
+		t.Errorf("plan = %+v, want the pending-attach volume adopted", plan)
+	}
+	if plan.SubnetID != "subnet-b" || plan.AvailabilityZone != "us-east-1b" {
+		t.Errorf("plan subnet = %s in %s, want subnet-b in the volume's AZ", plan.SubnetID, plan.AvailabilityZone)
+	}
+}
+
+func TestDryRunIPv6OnlyHasNoElasticIP(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{{
+		SubnetId:         aws.String("subnet-v6"),
+		AvailabilityZone: aws.String("us-east-1a"),
+		Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{{
+			Ipv6CidrBlock:      aws.String("2600:1f18::/64"),
+			Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeAssociated},
+		}},
+	}}}
+	cfg := defaultConfig()
+	cfg.IPMode = tags.IPModeIPv6Only
+
+	result, err := buildDryRun(t, m).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Plan.EIP != PlanEIPNone || result.Plan.IPMode != tags.IPModeIPv6Only {
+		t.Errorf("plan = %+v, want no Elastic IP", result.Plan)
+	}
+}
+
+func TestDryRunRejectsOversizedUserData(t *testing.T) {
+	m := newUpHappyMocks()
+	cfg := defaultConfig()
+	cfg.UserBootstrapScript = []byte(strings.Repeat("x", bootstrap.MaxUserDataBytes))
+
+	_, err := buildDryRun(t, m).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err == nil || !strings.Contains(err.Error(), "user-bootstrap.sh too large") {
+		t.Fatalf("Run() error = %v, want the user-data size error", err)
+	}
+}
+
+func TestDryRunPlansExistingVM(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances *ec2.DescribeInstancesOutput
+		volumes   []ec2types.Volume
+		want      ProvisionPlan
+	}{
+		{
+			name:      "stopped VM is started",
+			instances: stoppedVMInstance("i-stopped1", "54.0.0.1", "complete"),
+			want:      ProvisionPlan{Action: PlanStart, InstanceID: "i-stopped1", InstanceType: "m6i.xlarge", EIP: PlanEIPReuse},
+		},
+		{
+			name:      "running VM adopts a pending-attach volume",
+			instances: runningVMInstance("i-run1", "54.0.0.2", "complete"),
+			volumes:   []ec2types.Volume{{VolumeId: aws.String("vol-pending"), AvailabilityZone: aws.String("us-east-1a")}},
+			want:      ProvisionPlan{Action: PlanNone, InstanceID: "i-run1", InstanceType: "m6i.xlarge", AdoptVolumeID: "vol-pending"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			m.describeInstances.output = tt.instances
+			m.describeVolumes.output = &ec2.DescribeVolumesOutput{Volumes: tt.volumes}
+
+			result, err := buildDryRun(t, m).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if !reflect.DeepEqual(*result.Plan, tt.want) {
+				t.Errorf("plan = %+v\nwant %+v", *result.Plan, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunStoppedVMWithReleasedEIPAllocates(t *testing.T) {
+	m := newUpHappyMocks()
+	out := stoppedVMInstance("i-stopped1", "", "complete")
+	out.Reservations[0].Instances[0].Tags = append(out.Reservations[0].Instances[0].Tags,
+		ec2types.Tag{Key: aws.String(tags.TagEIP), Value: aws.String(tags.EIPReleasedByGC)})
+	m.describeInstances.output = out
+
+	result, err := buildDryRun(t, m).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Plan.Action != PlanStart || result.Plan.EIP != PlanEIPAllocate {
+		t.Errorf("plan = %+v, want a start with a new Elastic IP", result.Plan)
+	}
+}
+
+func TestDryRunResumesJournal(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	saved := &Journal{Owner: "alice", VM: "default", Step: StepLaunched, InstanceID: "i-new123", VolumeSizeGB: 50}
+	if err := store.Save(saved); err != nil {
+		t.Fatal(err)
+	}
+	m := newUpHappyMocks()
+	m.describeInstances.output = runningVMInstance("i-new123", "", "pending")
+
+	result, err := buildDryRun(t, m, WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	want := ProvisionPlan{Action: PlanResume, InstanceID: "i-new123", ResumeStep: string(resumeVolume)}
+	if !reflect.DeepEqual(*result.Plan, want) || !result.Resumed {
+		t.Errorf("result = %+v, plan = %+v, want %+v", result, *result.Plan, want)
+	}
+	if j, _ := store.Load("alice", "default"); j == nil || j.Step != StepLaunched {
+		t.Errorf("dry run changed the journal: %+v", j)
+	}
+}
+
+func TestDryRunRelaunchReusesJournalAllocation(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if err := store.Save(&Journal{Owner: "alice", VM: "default", Step: StepEIPAllocated, InstanceID: "i-gone", AllocationID: "eipalloc-keep"}); err != nil {
+		t.Fatal(err)
+	}
+	m := newUpHappyMocks() // no instance found
+	m.describeAddrs.output = vmAddress("eipalloc-keep", "54.7.7.7", "")
+
+	result, err := buildDryRun(t, m, WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Plan.Action != PlanLaunch || result.Plan.EIP != PlanEIPReuse || result.Plan.AllocationID != "eipalloc-keep" {
+		t.Errorf("plan = %+v, want a launch reusing eipalloc-keep", result.Plan)
+	}
+}
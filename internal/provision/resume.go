@@ -0,0 +1,303 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+	"github.com/SpiceLabsHQ/Mint/internal/vm"
+)
+
+// resumeAction is where an interrupted mint up picks up again.
+type resumeAction string
+
+const (
+	// resumeRelaunch provisions from scratch: the journal's instance is
+	// gone, or was never created. An unassociated Elastic IP from the
+	// journal is still reused.
+	resumeRelaunch resumeAction = "relaunch"
+	// resumeVolume tags (or attaches) the project volume, then continues
+	// with the Elastic IP and bootstrap polling.
+	resumeVolume resumeAction = "volume"
+	// resumeAllocateEIP allocates and associates a new Elastic IP.
+	resumeAllocateEIP resumeAction = "allocate-eip"
+	// resumeAssociateEIP associates the journal's existing allocation.
+	resumeAssociateEIP resumeAction = "associate-eip"
+	// resumePollBootstrap only waits for bootstrap to finish.
+	resumePollBootstrap resumeAction = "poll-bootstrap"
+)
+
+// journalState is what AWS reports for the resources a journal recorded.
+type journalState struct {
+	// InstanceFound is true when the journal's instance still exists and is
+	// not terminated or shutting down.
+	InstanceFound bool
+	// AllocationFound is true when the journal's Elastic IP still exists
+	// and is free or already associated with the journal's instance.
+	AllocationFound bool
+	// AllocationAssociated is true when the allocation is associated with
+	// the journal's instance.
+	AllocationAssociated bool
+}
+
+// decideResume returns where an interrupted run recorded in j resumes,
+// given what still exists in AWS.
+func decideResume(j *Journal, s journalState) resumeAction {
+	switch {
+	case !s.InstanceFound:
+		return resumeRelaunch
+	case !j.done(StepVolumeReady):
+		return resumeVolume
+	case j.IPMode == tags.IPModeIPv6Only:
+		// There is no Elastic IP to allocate or associate.
+		return resumePollBootstrap
+	case s.AllocationAssociated:
+		return resumePollBootstrap
+	case s.AllocationFound:
+		return resumeAssociateEIP
+	default:
+		return resumeAllocateEIP
+	}
+}
+
+// inspectJournal checks which of j's resources still exist and reconciles
+// j with what it finds. When the journal has no instance ID (the
+// RunInstances response was lost), the VM's tagged instance is adopted.
+// When it has no allocation ID (the run died before recording it), a free
+// Elastic IP tagged for the VM is adopted, so it is associated rather than
+// leaked. The returned VM is nil when the instance is gone.
+func (p *Provisioner) inspectJournal(ctx context.Context, j *Journal) (*vm.VM, journalState, error) {
+	var state journalState
+
+	existing, err := vm.FindVM(ctx, p.describeInstances, j.Owner, j.VM)
+	if err != nil {
+		return nil, state, err
+	}
+	if existing != nil && (j.InstanceID == "" || existing.ID == j.InstanceID) {
+		state.InstanceFound = true
+	} else {
+		existing = nil
+	}
+
+	out, err := p.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: append(tags.FilterByOwnerAndVM(j.Owner, j.VM),
+			ec2types.Filter{Name: aws.String("tag:" + tags.TagComponent), Values: []string{tags.ComponentElasticIP}},
+		),
+	})
+	if err != nil {
+		return nil, state, fmt.Errorf("describe addresses: %w", err)
+	}
+	allocID, publicIP := "", ""
+	for _, addr := range out.Addresses {
+		if j.AllocationID != "" && aws.ToString(addr.AllocationId) != j.AllocationID {
+			continue
+		}
+		associatedWith := aws.ToString(addr.InstanceId)
+		if associatedWith != "" && (existing == nil || associatedWith != existing.ID) {
+			// Associated with some other instance: not ours to take.
+			continue
+		}
+		allocID, publicIP = aws.ToString(addr.AllocationId), aws.ToString(addr.PublicIp)
+		state.AllocationFound = true
+		state.AllocationAssociated = associatedWith != ""
+		break
+	}
+	j.AllocationID, j.PublicIP = allocID, publicIP
+	if existing != nil {
+		j.IPv6Address = existing.IPv6Address
+		if j.IPMode == tags.IPModeIPv6Only {
+			j.PublicIP = existing.PublicIP
+		}
+	}
+
+	return existing, state, nil
+}
+
+// resume continues the interrupted run recorded in j. It returns a nil
+// result when nothing of the run survives but a reusable Elastic IP, and
+// resets j so the caller provisions from scratch.
+func (p *Provisioner) resume(ctx context.Context, j *Journal, ownerARN string) (*ProvisionResult, error) {
+	existing, state, err := p.inspectJournal(ctx, j)
+	if err != nil {
+		return nil, fmt.Errorf("checking resources of interrupted run: %w", err)
+	}
+
+	action := decideResume(j, state)
+	if action == resumeRelaunch {
+		j.Step = StepStarted
+		j.InstanceID, j.VolumeID, j.VolumeSizeGB = "", "", 0
+		j.IPv6Address = ""
+		return nil, nil
+	}
+	j.InstanceID = existing.ID
+
+	if existing.State == string(ec2types.InstanceStateNameStopped) {
+		if _, err := p.startInstances.StartInstances(ctx, &ec2.StartInstancesInput{
+			InstanceIds: []string{existing.ID},
+		}); err != nil {
+			return nil, fmt.Errorf("starting stopped VM %s: %w", existing.ID, err)
+		}
+	}
+	if err := p.waitForRunning(ctx, existing.ID); err != nil {
+		return nil, err
+	}
+
+	if action == resumeVolume {
+		pendingVolID, pendingVolAZ, err := p.findPendingAttachVolume(ctx, j.Owner, j.VM)
+		if err != nil {
+			return nil, fmt.Errorf("checking pending-attach volumes: %w", err)
+		}
+		volumeID, err := p.readyVolume(ctx, j, ownerARN, existing.AvailabilityZone, "", pendingVolID, pendingVolAZ)
+		if err != nil {
+			return nil, err
+		}
+		j.VolumeID = volumeID
+		p.recordStep(j, StepVolumeReady)
+		if j.IPMode == tags.IPModeIPv6Only {
+			action = resumePollBootstrap
+		}
+	}
+	ipv6, err := p.readyIPv6(ctx, j)
+	if err != nil {
+		return nil, err
+	}
+	if ipv6 != "" {
+		j.setIPv6Address(ipv6)
+	}
+
+	result, err := p.finish(ctx, j, action, ownerARN)
+	if err != nil {
+		return nil, err
+	}
+	result.Resumed = true
+	return result, nil
+}
+
+// finish runs the steps after the project volume, starting at from:
+// allocating and associating the Elastic IP, then polling for bootstrap.
+// Each step is recorded in j; the journal is removed once bootstrap polling
+// has finished.
+func (p *Provisioner) finish(ctx context.Context, j *Journal, from resumeAction, ownerARN string) (*ProvisionResult, error) {
+	// Step 11: Allocate and associate Elastic IP. Both calls ignore
+	// interrupts so an allocated address is always recorded; waiting for
+	// the association to reach the instance does not.
+	var eipWait time.Duration
+	if from != resumePollBootstrap {
+		err := p.readyEIP(context.WithoutCancel(ctx), j, ownerARN, func(allocID, publicIP string) {
+			j.AllocationID, j.PublicIP = allocID, publicIP
+			p.recordStep(j, StepEIPAllocated)
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.recordStep(j, StepEIPAssociated)
+		if eipWait, err = p.waitForEIP(ctx, j.AllocationID, j.InstanceID); err != nil {
+			if ierr := checkInterrupted(ctx, j); ierr != nil {
+				return nil, ierr
+			}
+			return nil, err
+		}
+	}
+	if err := checkInterrupted(ctx, j); err != nil {
+		return nil, err
+	}
+
+	// Step 12: Poll for bootstrap completion (if poller configured).
+	var pollErr error
+	if p.pollBootstrap != nil {
+		pollErr = p.pollBootstrap(ctx, j.Owner, j.VM, j.InstanceID)
+	}
+	result := p.complete(j, pollErr)
+	result.EIPPropagation = eipWait
+	return result, nil
+}
+
+// readyEIP allocates an Elastic IP for j's instance, unless j already
+// records one, and associates it. allocated is called as soon as a new
+// allocation exists so the caller can record it.
+func (p *Provisioner) readyEIP(ctx context.Context, j *Journal, ownerARN string, allocated func(allocID, publicIP string)) error {
+	allocID := j.AllocationID
+	if allocID == "" {
+		newID, publicIP, err := p.allocateEIP(ctx, j.Owner, ownerARN, j.VM, j.Expires)
+		if err != nil {
+			return fmt.Errorf("allocating Elastic IP: %w", err)
+		}
+		allocated(newID, publicIP)
+		allocID = newID
+	}
+	if err := p.associateEIP(ctx, allocID, j.InstanceID); err != nil {
+		return fmt.Errorf("allocating Elastic IP: %w", err)
+	}
+	return nil
+}
+
+// complete builds the result of a run whose Elastic IP is associated, given
+// the outcome of bootstrap polling, and removes the journal. An interrupted
+// poll has not seen bootstrap finish, so the journal is kept for the next
+// run to poll again.
+func (p *Provisioner) complete(j *Journal, pollErr error) *ProvisionResult {
+	result := &ProvisionResult{
+		InstanceID:   j.InstanceID,
+		PublicIP:     j.PublicIP,
+		VolumeID:     j.VolumeID,
+		VolumeSizeGB: j.VolumeSizeGB,
+		AllocationID: j.AllocationID,
+		IPv6Address:  j.IPv6Address,
+
+		PrefetchQueued:  len(j.PrefetchImages),
+		PrefetchDropped: j.PrefetchDropped,
+		Expires:         j.Expires,
+	}
+	if pollErr != nil {
+		var userErr *UserBootstrapError
+		if errors.As(pollErr, &userErr) {
+			result.BootstrapStatus = tags.BootstrapComplete
+			result.UserBootstrapStatus = fmt.Sprintf("%s%d", tags.UserBootstrapFailedPrefix, userErr.ExitCode)
+			result.UserBootstrapError = pollErr
+		} else {
+			result.BootstrapError = pollErr
+		}
+		if errors.Is(pollErr, context.Canceled) {
+			return result
+		}
+	}
+	p.removeJournal(j)
+	return result
+}
+
+// loadJournal returns the journal of an interrupted run for owner's VM, or
+// nil when there is none or no journal store is configured.
+func (p *Provisioner) loadJournal(owner, vmName string) (*Journal, error) {
+	if p.journal == nil {
+		return nil, nil
+	}
+	return p.journal.Load(owner, vmName)
+}
+
+// saveJournal writes j when a journal store is configured.
+func (p *Provisioner) saveJournal(j *Journal) error {
+	if p.journal == nil {
+		return nil
+	}
+	return p.journal.Save(j)
+}
+
+// recordStep marks step as completed in j and saves it. A failed write is
+// not fatal: the next run still finds the VM's resources by their tags.
+func (p *Provisioner) recordStep(j *Journal, step JournalStep) {
+	j.Step = step
+	_ = p.saveJournal(j)
+}
+
+// removeJournal deletes j's file when a journal store is configured.
+func (p *Provisioner) removeJournal(j *Journal) {
+	if p.journal != nil {
+		_ = p.journal.Remove(j.Owner, j.VM)
+	}
+}
@@ -0,0 +1,373 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/tags"
+)
+
+func TestDecideResume(t *testing.T) {
+	tests := []struct {
+		name   string
+		step   JournalStep
+		ipMode string
+		state  journalState
+		want   resumeAction
+	}{
+		{name: "instance never recorded or gone", step: StepStarted, want: resumeRelaunch},
+		{name: "instance gone after EIP allocated", step: StepEIPAllocated, state: journalState{AllocationFound: true}, want: resumeRelaunch},
+		{name: "lost RunInstances response, instance adopted", step: StepStarted, state: journalState{InstanceFound: true}, want: resumeVolume},
+		{name: "launched, volume not ready", step: StepLaunched, state: journalState{InstanceFound: true}, want: resumeVolume},
+		{name: "volume ready, no allocation", step: StepVolumeReady, state: journalState{InstanceFound: true}, want: resumeAllocateEIP},
+		{name: "allocation made before it was recorded", step: StepVolumeReady, state: journalState{InstanceFound: true, AllocationFound: true}, want: resumeAssociateEIP},
+		{name: "allocated, never associated", step: StepEIPAllocated, state: journalState{InstanceFound: true, AllocationFound: true}, want: resumeAssociateEIP},
+		{name: "allocated but since released", step: StepEIPAllocated, state: journalState{InstanceFound: true}, want: resumeAllocateEIP},
+		{name: "associated before it was recorded", step: StepEIPAllocated, state: journalState{InstanceFound: true, AllocationFound: true, AllocationAssociated: true}, want: resumePollBootstrap},
+		{name: "associated, bootstrap never polled", step: StepEIPAssociated, state: journalState{InstanceFound: true, AllocationFound: true, AllocationAssociated: true}, want: resumePollBootstrap},
+		{name: "associated but since released", step: StepEIPAssociated, state: journalState{InstanceFound: true}, want: resumeAllocateEIP},
+		{name: "ipv6-only, volume not ready", step: StepLaunched, ipMode: tags.IPModeIPv6Only, state: journalState{InstanceFound: true}, want: resumeVolume},
+		{name: "ipv6-only, volume ready", step: StepVolumeReady, ipMode: tags.IPModeIPv6Only, state: journalState{InstanceFound: true}, want: resumePollBootstrap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decideResume(&Journal{Step: tt.step, IPMode: tt.ipMode}, tt.state); got != tt.want {
+				t.Errorf("decideResume(%s, %+v) = %s, want %s", tt.step, tt.state, got, tt.want)
+			}
+		})
+	}
+}
+
+// vmAddress returns a DescribeAddresses output with one Elastic IP, associated
+// with instanceID unless it is empty.
+func vmAddress(allocID, publicIP, instanceID string) *ec2.DescribeAddressesOutput {
+	addr := ec2types.Address{AllocationId: aws.String(allocID), PublicIp: aws.String(publicIP)}
+	if instanceID != "" {
+		addr.InstanceId = aws.String(instanceID)
+		addr.AssociationId = aws.String("eipassoc-1")
+	}
+	return &ec2.DescribeAddressesOutput{Addresses: []ec2types.Address{addr}}
+}
+
+func TestInspectJournal(t *testing.T) {
+	tests := []struct {
+		name      string
+		journal   Journal
+		instances *ec2.DescribeInstancesOutput
+		addresses *ec2.DescribeAddressesOutput
+		want      journalState
+		wantAlloc string
+	}{
+		{
+			name:      "recorded instance and free allocation",
+			journal:   Journal{InstanceID: "i-new123", AllocationID: "eipalloc-new1"},
+			instances: runningVMInstance("i-new123", "", "pending"),
+			addresses: vmAddress("eipalloc-new1", "54.1.2.3", ""),
+			want:      journalState{InstanceFound: true, AllocationFound: true},
+			wantAlloc: "eipalloc-new1",
+		},
+		{
+			name:      "allocation already associated with the instance",
+			journal:   Journal{InstanceID: "i-new123", AllocationID: "eipalloc-new1"},
+			instances: runningVMInstance("i-new123", "54.1.2.3", "pending"),
+			addresses: vmAddress("eipalloc-new1", "54.1.2.3", "i-new123"),
+			want:      journalState{InstanceFound: true, AllocationFound: true, AllocationAssociated: true},
+			wantAlloc: "eipalloc-new1",
+		},
+		{
+			name:      "unrecorded instance and allocation are adopted by tags",
+			instances: runningVMInstance("i-lost", "", "pending"),
+			addresses: vmAddress("eipalloc-lost", "54.9.9.9", ""),
+			want:      journalState{InstanceFound: true, AllocationFound: true},
+			wantAlloc: "eipalloc-lost",
+		},
+		{
+			name:      "a different instance is not the journal's",
+			journal:   Journal{InstanceID: "i-new123"},
+			instances: runningVMInstance("i-other", "", "complete"),
+			addresses: &ec2.DescribeAddressesOutput{},
+		},
+		{
+			name:      "recorded allocation was released",
+			journal:   Journal{InstanceID: "i-new123", AllocationID: "eipalloc-new1"},
+			instances: runningVMInstance("i-new123", "", "pending"),
+			addresses: &ec2.DescribeAddressesOutput{},
+			want:      journalState{InstanceFound: true},
+		},
+		{
+			name:      "allocation associated elsewhere is left alone",
+			journal:   Journal{InstanceID: "i-new123"},
+			instances: runningVMInstance("i-new123", "", "pending"),
+			addresses: vmAddress("eipalloc-other", "54.1.1.1", "i-other"),
+			want:      journalState{InstanceFound: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			m.describeInstances.output = tt.instances
+			m.describeAddrs.output = tt.addresses
+			j := tt.journal
+			j.Owner, j.VM = "alice", "default"
+
+			_, got, err := m.build().inspectJournal(context.Background(), &j)
+			if err != nil {
+				t.Fatalf("inspectJournal() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("state = %+v, want %+v", got, tt.want)
+			}
+			if j.AllocationID != tt.wantAlloc {
+				t.Errorf("AllocationID = %q, want %q", j.AllocationID, tt.wantAlloc)
+			}
+		})
+	}
+}
+
+// countingAllocate counts AllocateAddress calls.
+type countingAllocate struct {
+	mockUpAllocateAddress
+	calls int
+}
+
+func (c *countingAllocate) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
+	c.calls++
+	return c.mockUpAllocateAddress.AllocateAddress(ctx, params, optFns...)
+}
+
+func buildWithAllocate(m *upMocks, alloc *countingAllocate, store *JournalStore, opts ...Option) *Provisioner {
+	p := m.build(append([]Option{WithJournal(store)}, opts...)...)
+	p.allocateAddr = alloc
+	return p
+}
+
+func TestProvisionerResumesAfterCrashFollowingAllocateAddress(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	alloc := &countingAllocate{mockUpAllocateAddress: mockUpAllocateAddress{
+		output: &ec2.AllocateAddressOutput{AllocationId: aws.String("eipalloc-new1"), PublicIp: aws.String("54.1.2.3")},
+	}}
+
+	// First run: the network drops right after AllocateAddress returns.
+	m := newUpHappyMocks()
+	m.associateAddr.err = errors.New("dial tcp: network is unreachable")
+	if _, err := buildWithAllocate(m, alloc, store).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err == nil {
+		t.Fatal("expected the interrupted run to fail")
+	}
+	j, err := store.Load("alice", "default")
+	if err != nil || j == nil {
+		t.Fatalf("journal after crash = %v, %v", j, err)
+	}
+	if j.Step != StepEIPAllocated || j.AllocationID != "eipalloc-new1" || j.InstanceID != "i-new123" {
+		t.Fatalf("journal after crash = %+v", j)
+	}
+
+	// Second run: the instance and the free allocation still exist.
+	m2 := newUpHappyMocks()
+	m2.describeInstances.output = runningVMInstance("i-new123", "", "pending")
+	m2.describeAddrs.output = vmAddress("eipalloc-new1", "54.1.2.3", "")
+	polled := false
+	p := buildWithAllocate(m2, alloc, store, WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+		polled = instanceID == "i-new123"
+		return nil
+	}))
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("resumed run error: %v", err)
+	}
+	if alloc.calls != 1 {
+		t.Errorf("AllocateAddress called %d times, want 1 (the allocation must be reused)", alloc.calls)
+	}
+	if got := aws.ToString(m2.associateAddr.input.AllocationId); got != "eipalloc-new1" {
+		t.Errorf("associated allocation = %q, want eipalloc-new1", got)
+	}
+	if m2.runInstances.called {
+		t.Error("RunInstances called on resume")
+	}
+	if m2.createTags.called {
+		t.Error("project volume re-tagged although the journal recorded it")
+	}
+	if !polled {
+		t.Error("bootstrap was not polled for the resumed instance")
+	}
+	if !result.Resumed || result.InstanceID != "i-new123" || result.PublicIP != "54.1.2.3" || result.VolumeID != "vol-proj1" {
+		t.Errorf("result = %+v", result)
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("journal not removed after success: %+v", j)
+	}
+}
+
+func TestProvisionerResumeAfterLaunchReadiesVolume(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if err := store.Save(&Journal{Owner: "alice", VM: "default", Step: StepLaunched, InstanceID: "i-new123", VolumeSizeGB: 50}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newUpHappyMocks()
+	out := runningVMInstance("i-new123", "", "pending")
+	out.Reservations[0].Instances[0].BlockDeviceMappings = []ec2types.InstanceBlockDeviceMapping{{
+		DeviceName: aws.String("/dev/xvdf"),
+		Ebs:        &ec2types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-proj1")},
+	}}
+	m.describeInstances.output = out
+
+	result, err := m.build(WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances called on resume")
+	}
+	if !m.createTags.called {
+		t.Error("project volume was not tagged")
+	}
+	if !m.allocateAddr.called || !m.associateAddr.called {
+		t.Error("Elastic IP was not allocated and associated")
+	}
+	if result.VolumeID != "vol-proj1" || result.VolumeSizeGB != 50 || !result.Resumed {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestProvisionerResumeStartsStoppedInstance(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if err := store.Save(&Journal{Owner: "alice", VM: "default", Step: StepEIPAssociated, InstanceID: "i-stopped1", VolumeID: "vol-proj1", AllocationID: "eipalloc-1"}); err != nil {
+		t.Fatal(err)
+	}
+	m := newUpHappyMocks()
+	m.describeInstances.output = stoppedVMInstance("i-stopped1", "54.0.0.1", "pending")
+	m.describeAddrs.output = vmAddress("eipalloc-1", "54.0.0.1", "i-stopped1")
+
+	result, err := m.build(WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !m.startInstances.called {
+		t.Error("stopped instance was not started")
+	}
+	if m.associateAddr.called || m.allocateAddr.called {
+		t.Error("Elastic IP touched although it is already associated")
+	}
+	if result.Restarted || !result.Resumed {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestProvisionerResumeRelaunchesAndReusesAllocation(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if err := store.Save(&Journal{Owner: "alice", VM: "default", Step: StepEIPAllocated, InstanceID: "i-gone", VolumeID: "vol-gone", AllocationID: "eipalloc-keep"}); err != nil {
+		t.Fatal(err)
+	}
+	m := newUpHappyMocks() // no instance found
+	m.describeAddrs.output = vmAddress("eipalloc-keep", "54.7.7.7", "")
+
+	result, err := m.build(WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !m.runInstances.called {
+		t.Error("instance was not relaunched")
+	}
+	if m.allocateAddr.called {
+		t.Error("AllocateAddress called although a free allocation was recorded")
+	}
+	if got := aws.ToString(m.associateAddr.input.AllocationId); got != "eipalloc-keep" {
+		t.Errorf("associated allocation = %q, want eipalloc-keep", got)
+	}
+	if result.InstanceID != "i-new123" || result.PublicIP != "54.7.7.7" || !result.Resumed {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestProvisionerStaleJournalWithExistingVMIsDiscarded(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	if err := store.Save(&Journal{Owner: "alice", VM: "default", Step: StepLaunched, InstanceID: "i-gone"}); err != nil {
+		t.Fatal(err)
+	}
+	m := newUpHappyMocks()
+	m.describeInstances.output = runningVMInstance("i-other", "54.0.0.2", "complete")
+
+	result, err := m.build(WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !result.AlreadyRunning || result.InstanceID != "i-other" {
+		t.Errorf("result = %+v, want the existing VM", result)
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("stale journal kept: %+v", j)
+	}
+}
+
+func TestProvisionerJournalRecordsFreshRun(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	var seen *Journal
+	p := m.build(WithJournal(store), WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+		seen, _ = store.Load(owner, vmName)
+		return nil
+	}))
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Resumed {
+		t.Error("fresh run reported as resumed")
+	}
+	// Polling starts alongside the volume and Elastic IP steps, so the
+	// journal it sees has at least the launched instance.
+	if seen == nil || !seen.done(StepLaunched) || seen.InstanceID != "i-new123" {
+		t.Errorf("journal while polling = %+v", seen)
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("journal not removed after success: %+v", j)
+	}
+}
+
+func TestProvisionerInterruptedPollKeepsJournal(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	p := m.build(WithJournal(store), WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+		return fmt.Errorf("polling bootstrap: %w", context.Canceled)
+	}))
+
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	j, _ := store.Load("alice", "default")
+	if j == nil || j.Step != StepEIPAssociated {
+		t.Errorf("journal after interrupted poll = %+v, want one at %s", j, StepEIPAssociated)
+	}
+}
+
+func TestProvisionerUnreadableJournalPointsToAbandon(t *testing.T) {
+	dir := t.TempDir()
+	store := NewJournalStore(dir)
+	path := store.path("alice", "default")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newUpHappyMocks()
+	_, err := m.build(WithJournal(store)).Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "mint up --abandon-journal") {
+		t.Errorf("error = %v, want a hint to abandon the journal", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances called despite an unreadable journal")
+	}
+}
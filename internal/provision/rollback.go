@@ -0,0 +1,169 @@
+package provision
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// RollbackError is returned when a fresh provision fails after creating
+// resources and Run tried to remove them again. Err is the failure itself;
+// RolledBack lists the resources that were removed and Left those that
+// remain, each with the reason it does, in the order they were handled.
+type RollbackError struct {
+	Err        error
+	RolledBack []string
+	Left       []string
+}
+
+func (e *RollbackError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	if len(e.RolledBack) > 0 {
+		fmt.Fprintf(&b, "\nrolled back: %s", strings.Join(e.RolledBack, "; "))
+	}
+	if len(e.Left) > 0 {
+		fmt.Fprintf(&b, "\nleft for manual cleanup: %s", strings.Join(e.Left, "; "))
+	}
+	return b.String()
+}
+
+func (e *RollbackError) Unwrap() error { return e.Err }
+
+// createdResource is a resource a fresh provision created. undo removes it,
+// after which forget clears it from the journal; when undo is nil the
+// resource is left, for the reason in keep.
+type createdResource struct {
+	name   string
+	undo   func(ctx context.Context) error
+	forget func(j *Journal)
+	done   string
+	keep   string
+}
+
+// createdResources returns what the run recorded in j created, in the order
+// it created them: the instance, its project volume, then the Elastic IP.
+// priorAllocID is the allocation j held before the launch; an address a
+// previous run allocated is not this run's to release. A pending-attach
+// volume holds the project data of a mint recreate, so an instance it was
+// attached to is kept rather than terminated.
+func (p *Provisioner) createdResources(j *Journal, bdmVolumeID, pendingVolID, priorAllocID string) []createdResource {
+	var created []createdResource
+
+	instance := createdResource{
+		name: "instance " + j.InstanceID,
+		done: "terminated instance " + j.InstanceID,
+		forget: func(j *Journal) {
+			j.Step = StepStarted
+			j.InstanceID, j.VolumeID, j.IPv6Address = "", "", ""
+		},
+	}
+	switch {
+	case pendingVolID != "":
+		instance.keep = fmt.Sprintf("kept because it may hold the project volume %s from mint recreate", pendingVolID)
+	case p.terminateInstances == nil:
+		instance.keep = "rollback cannot terminate instances"
+	default:
+		id := j.InstanceID
+		instance.undo = func(ctx context.Context) error { return p.terminateInstance(ctx, id) }
+	}
+	created = append(created, instance)
+
+	if volumeID := cmp.Or(j.VolumeID, bdmVolumeID); pendingVolID == "" && volumeID != "" {
+		created = append(created, createdResource{
+			name: "project volume " + volumeID,
+			keep: "it outlives the instance; delete it once the instance has terminated",
+		})
+	}
+
+	if j.AllocationID != "" && j.AllocationID != priorAllocID {
+		eip := createdResource{
+			name:   "Elastic IP " + j.AllocationID,
+			done:   "released Elastic IP " + j.AllocationID,
+			forget: func(j *Journal) { j.AllocationID, j.PublicIP = "", "" },
+		}
+		if p.releaseAddr == nil {
+			eip.keep = "rollback cannot release Elastic IPs"
+		} else {
+			allocID := j.AllocationID
+			eip.undo = func(ctx context.Context) error { return p.releaseAddress(ctx, allocID) }
+		}
+		created = append(created, eip)
+	}
+	return created
+}
+
+// rollback removes the resources a failed fresh provision created, newest
+// first, and returns cause wrapped in a *RollbackError reporting what was
+// removed and what was left. A resource that cannot be removed is a
+// warning in that report, not a second failure. j is updated to match:
+// it is removed when neither its instance nor an Elastic IP remains, and
+// otherwise kept so the next mint up resumes with what is left.
+func (p *Provisioner) rollback(ctx context.Context, j *Journal, created []createdResource, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+	rerr := &RollbackError{Err: cause}
+	for i := len(created) - 1; i >= 0; i-- {
+		r := created[i]
+		if r.undo == nil {
+			rerr.Left = append(rerr.Left, fmt.Sprintf("%s (%s)", r.name, r.keep))
+			continue
+		}
+		if err := r.undo(ctx); err != nil {
+			rerr.Left = append(rerr.Left, fmt.Sprintf("%s (%v)", r.name, err))
+			continue
+		}
+		rerr.RolledBack = append(rerr.RolledBack, r.done)
+		r.forget(j)
+	}
+
+	if j.InstanceID == "" && j.AllocationID == "" {
+		p.removeJournal(j)
+	} else {
+		_ = p.saveJournal(j)
+	}
+	return rerr
+}
+
+// terminateInstance terminates the instance a failed run launched.
+func (p *Provisioner) terminateInstance(ctx context.Context, instanceID string) error {
+	start := time.Now()
+	_, err := p.terminateInstances.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if p.logger != nil {
+		p.logger.Log("ec2", "TerminateInstances", time.Since(start), err)
+	}
+	if err != nil {
+		return fmt.Errorf("terminate failed: %w", err)
+	}
+	return nil
+}
+
+// releaseAddress releases the Elastic IP a failed run allocated.
+func (p *Provisioner) releaseAddress(ctx context.Context, allocID string) error {
+	start := time.Now()
+	_, err := p.releaseAddr.ReleaseAddress(ctx, &ec2.ReleaseAddressInput{
+		AllocationId: aws.String(allocID),
+	})
+	if p.logger != nil {
+		p.logger.Log("ec2", "ReleaseAddress", time.Since(start), err)
+	}
+	if err != nil {
+		return fmt.Errorf("release failed: %w", err)
+	}
+	return nil
+}
+
+// shouldRollback reports whether a fresh provision that failed with err
+// rolls back. An interrupted run keeps its resources for the next run to
+// resume.
+func (p *Provisioner) shouldRollback(err error) bool {
+	var interrupted *InterruptedError
+	return p.rollbackEnabled && !errors.As(err, &interrupted)
+}
@@ -0,0 +1,144 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// rollbackMocks returns happy-path mocks and the rollback clients a
+// provisioner built from them with rollbackOptions uses.
+func rollbackMocks() (*upMocks, *mockTerminateInstances, *mockReleaseAddress) {
+	return newUpHappyMocks(),
+		&mockTerminateInstances{output: &ec2.TerminateInstancesOutput{}},
+		&mockReleaseAddress{output: &ec2.ReleaseAddressOutput{}}
+}
+
+func rollbackOptions(terminate *mockTerminateInstances, release *mockReleaseAddress, opts ...Option) []Option {
+	return append([]Option{WithTerminateInstances(terminate), WithReleaseAddress(release)}, opts...)
+}
+
+func TestRollbackAfterAssociateFails(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m, terminate, release := rollbackMocks()
+	m.associateAddr.err = fmt.Errorf("association failed")
+	p := m.build(rollbackOptions(terminate, release, WithJournal(store))...)
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected the association error")
+	}
+	if !release.called || aws.ToString(release.input.AllocationId) != "eipalloc-new1" {
+		t.Errorf("ReleaseAddress called = %v with %+v, want eipalloc-new1", release.called, release.input)
+	}
+	if !terminate.called || terminate.input.InstanceIds[0] != "i-new123" {
+		t.Errorf("TerminateInstances called = %v with %+v, want i-new123", terminate.called, terminate.input)
+	}
+
+	for _, want := range []string{
+		"association failed",
+		"rolled back: released Elastic IP eipalloc-new1; terminated instance i-new123",
+		"left for manual cleanup: project volume vol-proj1",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+	var rerr *RollbackError
+	if !errors.As(err, &rerr) || !strings.Contains(rerr.Err.Error(), "association failed") {
+		t.Errorf("error should be a *RollbackError wrapping the failure, got %T", err)
+	}
+	if j, _ := store.Load("alice", "default"); j != nil {
+		t.Errorf("journal kept after a full rollback: %+v", j)
+	}
+}
+
+func TestRollbackAfterVolumeTagFails(t *testing.T) {
+	m, terminate, release := rollbackMocks()
+	m.createTags.err = fmt.Errorf("tag limit exceeded")
+	p := m.build(rollbackOptions(terminate, release)...)
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "tagging project volume") {
+		t.Fatalf("error = %v, want the tagging error", err)
+	}
+	if !terminate.called || terminate.input.InstanceIds[0] != "i-new123" {
+		t.Errorf("TerminateInstances called = %v, want i-new123 terminated", terminate.called)
+	}
+	// The Elastic IP branch ran to completion alongside the failed tag.
+	if !release.called {
+		t.Error("ReleaseAddress not called for the Elastic IP this run allocated")
+	}
+}
+
+func TestRollbackFailureIsReportedNotFatal(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m, terminate, release := rollbackMocks()
+	m.associateAddr.err = fmt.Errorf("association failed")
+	release.err = fmt.Errorf("AuthFailure")
+	p := m.build(rollbackOptions(terminate, release, WithJournal(store))...)
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected the association error")
+	}
+	for _, want := range []string{
+		"rolled back: terminated instance i-new123",
+		"Elastic IP eipalloc-new1 (release failed: AuthFailure)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+	if !terminate.called {
+		t.Error("a failed release stopped the instance from being terminated")
+	}
+	// The journal keeps the address so the next mint up reuses it.
+	j, _ := store.Load("alice", "default")
+	if j == nil || j.AllocationID != "eipalloc-new1" || j.InstanceID != "" || j.Step != StepStarted {
+		t.Errorf("journal = %+v, want only the allocation at step %s", j, StepStarted)
+	}
+}
+
+func TestRollbackDisabled(t *testing.T) {
+	m, terminate, release := rollbackMocks()
+	m.associateAddr.err = fmt.Errorf("association failed")
+	p := m.build(rollbackOptions(terminate, release, WithRollback(false))...)
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil || strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("error = %v, want the failure alone", err)
+	}
+	if terminate.called || release.called {
+		t.Error("rolled back with rollback disabled")
+	}
+}
+
+func TestRollbackKeepsInstanceWithPendingAttachVolume(t *testing.T) {
+	p := newUpHappyMocks().build(WithTerminateInstances(&mockTerminateInstances{}))
+	j := &Journal{Owner: "alice", VM: "default", InstanceID: "i-new123"}
+
+	created := p.createdResources(j, "", "vol-recreated", "")
+	if len(created) != 1 || created[0].undo != nil {
+		t.Fatalf("created = %+v, want only the instance, kept", created)
+	}
+	err := p.rollback(context.Background(), j, created, errors.New("attach failed"))
+	if !strings.Contains(err.Error(), "instance i-new123 (kept because it may hold the project volume vol-recreated from mint recreate)") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestInterruptedRunDoesNotRollBack(t *testing.T) {
+	p := newUpHappyMocks().build()
+	if p.shouldRollback(&InterruptedError{}) {
+		t.Error("an interrupted run should be resumed, not rolled back")
+	}
+	if !p.shouldRollback(errors.New("association failed")) {
+		t.Error("rollback should be on by default")
+	}
+}
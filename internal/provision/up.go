@@ -7,14 +7,18 @@ package provision
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"golang.org/x/sync/errgroup"
 
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
@@ -27,28 +31,190 @@ import (
 // DefaultEIPLimit is the default per-user EIP allocation limit.
 const DefaultEIPLimit = 5
 
+// rootVolumeSizeGB is the size of a new instance's root volume (ADR-0004).
+const rootVolumeSizeGB = 200
+
+// gp3 throughput limits, in MB/s. A volume gets at most
+// maxThroughputPerIOPS MB/s for each provisioned IOPS.
+const (
+	defaultVolumeThroughput = 125
+	maxVolumeThroughput     = 1000
+	maxThroughputPerIOPS    = 0.25
+)
+
+// validateVolumeThroughput checks a project volume's throughput against
+// the gp3 range and the throughput its IOPS allow.
+func validateVolumeThroughput(throughput, iops int32) error {
+	if throughput < defaultVolumeThroughput || throughput > maxVolumeThroughput {
+		return fmt.Errorf("project volume throughput %d MB/s is out of range: gp3 allows %d-%d MB/s",
+			throughput, defaultVolumeThroughput, maxVolumeThroughput)
+	}
+	if limit := int32(float64(iops) * maxThroughputPerIOPS); throughput > limit {
+		return fmt.Errorf("project volume throughput %d MB/s is too high for %d IOPS: gp3 allows at most %d MB/s at that IOPS (%.2f MB/s per IOPS); raise the IOPS or lower the throughput",
+			throughput, iops, limit, maxThroughputPerIOPS)
+	}
+	return nil
+}
+
 // ProvisionConfig holds the user-provided configuration for provisioning.
 type ProvisionConfig struct {
 	InstanceType         string
 	VolumeSize           int32
 	VolumeIOPS           int32  // IOPS for the project gp3 EBS volume (0 defaults to 3000)
+	VolumeThroughput     int32  // Throughput in MB/s for the project gp3 EBS volume (0 defaults to 125)
 	BootstrapScript      []byte
 	BootstrapURL         string // URL to fetch bootstrap.sh at instance startup (from bootstrap.ScriptURL)
+	// Bootstrap is the resolved bootstrap.sh hash and URL, or the script
+	// itself when --bootstrap-file embeds it. When zero, the embedded hash
+	// is used with BootstrapURL.
+	Bootstrap            bootstrap.Source
 	EFSID                string // EFS filesystem ID for user storage
 	IdleTimeout          int    // Idle timeout in minutes (0 defaults to 60)
 	UserBootstrapScript  []byte // Optional user-bootstrap.sh content; base64-encoded into user-data
+	// PrefetchImages are container images the new instance pulls in the
+	// background after bootstrap, most recently built first. The list is
+	// truncated to fit the user-data limit.
+	PrefetchImages []string
+	// SSHUser is the login user recorded in the mint:ssh-user tag. Empty
+	// leaves the tag off.
+	SSHUser string
+	// Spot launches a fresh instance on the spot market, tagged mint:spot.
+	// With SpotFallback, a launch that finds no spot capacity or too low a
+	// maximum price is retried on demand.
+	Spot         bool
+	SpotFallback bool
+	// IPMode is how the fresh instance is addressed: tags.IPModeEIP (the
+	// default when empty), tags.IPModeDualStack, or tags.IPModeIPv6Only,
+	// which launches without an Elastic IP or public IPv4 address.
+	IPMode string
+	// KMSKeyID is the KMS key the root and project volumes are encrypted
+	// with. Empty uses the account's default EBS key.
+	KMSKeyID string
+	// InstanceProfile is the IAM instance profile the instance is launched
+	// with. Empty uses mint-instance-profile.
+	InstanceProfile string
+	// SSHPort is the port the bootstrap configures sshd on. Zero uses the
+	// stub's default, 41122.
+	SSHPort int
+	// SubnetID launches the instance in that subnet instead of a default
+	// one, and VPCID restricts subnet discovery to that VPC. With
+	// SecurityGroupIDs the instance gets those groups instead of the ones
+	// mint init and the admin stack created; they must be in the subnet's
+	// VPC.
+	SubnetID         string
+	SecurityGroupIDs []string
+	VPCID            string
+	// Hibernate launches the instance hibernation-enabled, with an
+	// encrypted root volume large enough to hold its memory.
+	Hibernate bool
+	// ExpiresAt tags a fresh instance, its project volume, and its Elastic
+	// IP with mint:expires. Zero leaves them without an expiry.
+	ExpiresAt time.Time
 }
 
 // ProvisionResult holds the outcome of a successful provision run.
 type ProvisionResult struct {
 	InstanceID      string
-	PublicIP        string
+	PublicIP        string // the Elastic IP, or the IPv6 address of an ipv6-only VM
 	VolumeID        string
+	VolumeSizeGB    int32 // size of a freshly created project volume; 0 when an existing volume was attached
 	AllocationID    string
+	IPv6Address     string // the instance's IPv6 address; empty in eip mode
 	Restarted       bool
 	AlreadyRunning  bool   // true when the VM was already running (not freshly provisioned or restarted)
 	BootstrapStatus string // the mint:bootstrap tag value at the time of the call ("pending", "complete", "failed", or "")
 	BootstrapError  error  // non-nil if bootstrap polling failed/timed out, or if an existing VM's bootstrap has failed
+
+	// UserBootstrapStatus is the mint:user-bootstrap tag value when known
+	// ("ok", "failed:<code>", or "" when no hook ran or the outcome is unknown).
+	UserBootstrapStatus string
+	// UserBootstrapError is a *UserBootstrapError when core bootstrap completed
+	// but the user hook failed. The VM is usable; BootstrapError stays nil.
+	UserBootstrapError error
+
+	// InstanceTypeWarning is a non-fatal note from the instance type check,
+	// such as the type being previous-generation. Empty when there is none.
+	InstanceTypeWarning string
+
+	// Resumed is true when the run picked up an interrupted mint up from
+	// its provisioning journal.
+	Resumed bool
+
+	// BootstrapSource is the label of the bootstrap source a fresh instance
+	// was launched with ("manifest v12" or "embedded"). Empty otherwise.
+	BootstrapSource string
+
+	// EIPReallocated is true when a restarted VM whose Elastic IP was
+	// released by mint gc was given a fresh one.
+	EIPReallocated bool
+
+	// EIPReused is true when a fresh instance was given a free Elastic IP
+	// already tagged for the VM instead of a new allocation.
+	EIPReused bool
+
+	// Spot is true when the fresh instance was launched on the spot market.
+	// SpotWarning is set when a spot launch fell back to on-demand.
+	Spot        bool
+	SpotWarning string
+
+	// EIPPropagation is how long the Elastic IP took to reach the instance
+	// after it was associated. Zero when no association was made.
+	EIPPropagation time.Duration
+
+	// PrefetchQueued is the number of container images the new instance
+	// was asked to prefetch; PrefetchDropped is how many more did not fit
+	// in user-data.
+	PrefetchQueued  int
+	PrefetchDropped int
+
+	// BootstrapTimings are the phases bootstrap.sh recorded on a fresh
+	// instance. The Provisioner does not fill them in: the command fetches
+	// them over SSH after polling, and leaves them nil when it could not.
+	BootstrapTimings []bootstrap.PhaseTiming
+	// BootstrapPhaseThreshold is how long a phase may take before it is
+	// flagged as slow. Zero flags none.
+	BootstrapPhaseThreshold time.Duration
+
+	// Expires is the mint:expires value a fresh VM was tagged with; empty
+	// when it does not expire.
+	Expires string
+
+	// Plan is what a dry run found Run would do. It is nil for a real run,
+	// and a dry run sets no other field but InstanceTypeWarning.
+	Plan *ProvisionPlan
+}
+
+// ipMode returns the IP mode to launch with, defaulting to eip.
+func (c ProvisionConfig) ipMode() string {
+	if c.IPMode == "" {
+		return tags.IPModeEIP
+	}
+	return c.IPMode
+}
+
+// instanceProfile returns the IAM instance profile name to launch with.
+func (c ProvisionConfig) instanceProfile() string {
+	if c.InstanceProfile == "" {
+		return defaultInstanceProfileName
+	}
+	return c.InstanceProfile
+}
+
+// sshPort returns the port rendered into the stub; "" leaves the stub's
+// default.
+func (c ProvisionConfig) sshPort() string {
+	if c.SSHPort == 0 {
+		return ""
+	}
+	return strconv.Itoa(c.SSHPort)
+}
+
+// bootstrapSource returns the source the stub is rendered with.
+func (c ProvisionConfig) bootstrapSource() bootstrap.Source {
+	if c.Bootstrap.SHA256 == "" && c.Bootstrap.Label == "" {
+		return bootstrap.EmbeddedSource(c.BootstrapURL)
+	}
+	return c.Bootstrap
 }
 
 // BootstrapVerifier is a function that verifies bootstrap script integrity.
@@ -63,6 +229,16 @@ type BootstrapPollFunc func(ctx context.Context, owner, vmName, instanceID strin
 // Defaults to mintaws.ResolveAMI; overridden in tests.
 type AMIResolver func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error)
 
+// InstanceTypeCheckFunc validates an instance type before provisioning. It
+// returns an error for a type the region does not offer and a non-empty
+// warning for one that is usable but discouraged.
+type InstanceTypeCheckFunc func(ctx context.Context, instanceType string) (warning string, err error)
+
+// InstanceTypeOfferingFunc checks that an instance type can launch in an
+// availability zone. It returns an *mintaws.InstanceTypeNotOfferedError when
+// AWS does not offer the type there.
+type InstanceTypeOfferingFunc func(ctx context.Context, instanceType, az string) error
+
 // DeleteTagsAPI defines the subset of the EC2 API used for removing tags.
 type DeleteTagsAPI interface {
 	DeleteTags(ctx context.Context, params *ec2.DeleteTagsInput, optFns ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error)
@@ -85,111 +261,68 @@ type Provisioner struct {
 	describeImages    mintaws.DescribeImagesAPI
 	waitRunning          mintaws.WaitInstanceRunningAPI
 	waitVolumeAvailable  mintaws.WaitVolumeAvailableAPI
+	waitEIP              mintaws.WaitEIPAssociatedAPI
 	describeVolumes      mintaws.DescribeVolumesAPI
+	describeTypes        mintaws.DescribeInstanceTypesAPI
 	deleteTags        DeleteTagsAPI
+	terminateInstances mintaws.TerminateInstancesAPI
+	releaseAddr        mintaws.ReleaseAddressAPI
+
+	journal *JournalStore
 
 	verifyBootstrap BootstrapVerifier
 	resolveAMI      AMIResolver
 	pollBootstrap   BootstrapPollFunc
+	checkType       InstanceTypeCheckFunc
+	checkOffering   InstanceTypeOfferingFunc
+	extraTags       map[string]string
 
-	logger logging.Logger
-}
-
-// NewProvisioner creates a Provisioner with all required AWS interfaces.
-func NewProvisioner(
-	describeInstances mintaws.DescribeInstancesAPI,
-	startInstances mintaws.StartInstancesAPI,
-	runInstances mintaws.RunInstancesAPI,
-	describeSGs mintaws.DescribeSecurityGroupsAPI,
-	describeSubnets mintaws.DescribeSubnetsAPI,
-	createVolume mintaws.CreateVolumeAPI,
-	attachVolume mintaws.AttachVolumeAPI,
-	allocateAddr mintaws.AllocateAddressAPI,
-	associateAddr mintaws.AssociateAddressAPI,
-	describeAddrs mintaws.DescribeAddressesAPI,
-	createTags mintaws.CreateTagsAPI,
-	describeImages mintaws.DescribeImagesAPI,
-) *Provisioner {
-	return &Provisioner{
-		describeInstances: describeInstances,
-		startInstances:    startInstances,
-		runInstances:      runInstances,
-		describeSGs:       describeSGs,
-		describeSubnets:   describeSubnets,
-		createVolume:      createVolume,
-		attachVolume:      attachVolume,
-		allocateAddr:      allocateAddr,
-		associateAddr:     associateAddr,
-		describeAddrs:     describeAddrs,
-		createTags:        createTags,
-		describeImages:    describeImages,
-		verifyBootstrap:   bootstrap.Verify,
-		resolveAMI:        mintaws.ResolveAMI,
-	}
-}
-
-// WithWaitRunning sets the waiter used to block until the instance is running
-// before attaching the EBS volume. When nil, no wait is performed (tests).
-func (p *Provisioner) WithWaitRunning(w mintaws.WaitInstanceRunningAPI) *Provisioner {
-	p.waitRunning = w
-	return p
-}
-
-// WithWaitVolumeAvailable sets the waiter used to block until the EBS volume
-// is available before attaching it. When nil, no wait is performed (tests).
-func (p *Provisioner) WithWaitVolumeAvailable(w mintaws.WaitVolumeAvailableAPI) *Provisioner {
-	p.waitVolumeAvailable = w
-	return p
-}
-
-// WithDescribeVolumes sets the DescribeVolumes client for pending-attach recovery.
-func (p *Provisioner) WithDescribeVolumes(dv mintaws.DescribeVolumesAPI) *Provisioner {
-	p.describeVolumes = dv
-	return p
-}
-
-// WithDeleteTags sets the DeleteTags client for pending-attach tag cleanup.
-func (p *Provisioner) WithDeleteTags(dt DeleteTagsAPI) *Provisioner {
-	p.deleteTags = dt
-	return p
-}
+	// dryRun stops Run before its first mutating call; see WithDryRun.
+	dryRun bool
 
-// WithBootstrapVerifier overrides the default bootstrap verifier (for testing).
-func (p *Provisioner) WithBootstrapVerifier(v BootstrapVerifier) *Provisioner {
-	p.verifyBootstrap = v
-	return p
-}
+	// rollbackEnabled removes what a failed fresh provision created; see
+	// WithRollback.
+	rollbackEnabled bool
 
-// WithLogger sets the structured logger for AWS API call timing and error logging.
-// When nil (the default), logging is skipped and there is no behavioral change.
-func (p *Provisioner) WithLogger(l logging.Logger) *Provisioner {
-	p.logger = l
-	return p
-}
+	// locker and stealLock guard the run against a concurrent one for the
+	// same VM; see WithOperationLock.
+	locker    *Locker
+	stealLock bool
 
-// WithAMIResolver overrides the default AMI resolver (for testing).
-func (p *Provisioner) WithAMIResolver(r AMIResolver) *Provisioner {
-	p.resolveAMI = r
-	return p
-}
-
-// WithBootstrapPollFunc sets a function to poll for bootstrap completion.
-// When set, Run() calls this after EIP allocation on fresh provisions (not restarts).
-// Use WithBootstrapPoller for production; this method enables test injection.
-func (p *Provisioner) WithBootstrapPollFunc(fn BootstrapPollFunc) *Provisioner {
-	p.pollBootstrap = fn
-	return p
-}
-
-// WithBootstrapPoller sets a BootstrapPoller to poll for bootstrap completion.
-// Wraps the poller's Poll method as a BootstrapPollFunc.
-func (p *Provisioner) WithBootstrapPoller(bp *BootstrapPoller) *Provisioner {
-	p.pollBootstrap = bp.Poll
-	return p
+	logger logging.Logger
 }
 
 // Run executes the full provision flow.
 func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, cfg ProvisionConfig) (*ProvisionResult, error) {
+	if _, err := tags.MergeExtra(nil, p.extraTags); err != nil {
+		return nil, err
+	}
+
+	// Step 0: Resume an interrupted run recorded in the journal.
+	j, err := p.loadJournal(owner, vmName)
+	if err != nil {
+		return nil, fmt.Errorf("%w — run %s to discard it", err, hint.Cmd("mint up --abandon-journal"))
+	}
+	resumed := j != nil
+	if resumed {
+		resume := p.resume
+		if p.dryRun {
+			resume = p.planResume
+		}
+		result, err := resume(ctx, j, ownerARN)
+		if err != nil || result != nil {
+			return result, err
+		}
+		// The instance is gone: provision from scratch, reusing any
+		// Elastic IP the interrupted run allocated.
+	} else {
+		j = &Journal{Owner: owner, VM: vmName}
+	}
+	j.IPMode = cfg.ipMode()
+	if !cfg.ExpiresAt.IsZero() {
+		j.Expires = tags.FormatExpires(cfg.ExpiresAt)
+	}
+
 	// Step 1: Check for existing VM.
 	existing, err := vm.FindVM(ctx, p.describeInstances, owner, vmName)
 	if err != nil {
@@ -197,10 +330,28 @@ func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, c
 	}
 
 	if existing != nil {
+		if p.dryRun {
+			return p.planExisting(ctx, existing, owner, vmName)
+		}
+		// A journal whose instance is gone says nothing about this VM.
+		p.removeJournal(j)
+		if p.locker != nil {
+			lock, err := p.locker.Acquire(ctx, existing, "up", p.stealLock)
+			if err != nil {
+				return nil, err
+			}
+			// Best effort: a lock left behind expires on its own.
+			defer func() { _ = lock.Release(context.WithoutCancel(ctx)) }()
+		}
 		result, err := p.handleExistingVM(ctx, existing)
 		if err != nil {
 			return nil, err
 		}
+		if result.Restarted && existing.Tags[tags.TagEIP] == tags.EIPReleasedByGC {
+			if err := p.reallocateEIP(ctx, existing, owner, ownerARN, result); err != nil {
+				return nil, err
+			}
+		}
 		// For already-running VMs, check for a pending-attach volume left by a
 		// failed mint recreate and attach it. The Restarted path does not need
 		// this because recreate stops the instance before detaching the volume,
@@ -235,45 +386,119 @@ func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, c
 		return result, nil
 	}
 
-	// Step 2: Verify bootstrap script integrity (ADR-0009).
+	// Step 1a: A stub this build cannot render fails before any other AWS
+	// call. An existing VM keeps its user-data, so only fresh provisions
+	// need it.
+	if err := bootstrap.CheckStub(); err != nil {
+		return nil, BootstrapRenderError(err)
+	}
+
+	// Step 2: Check the instance type before any slow or billable step. An
+	// existing VM keeps its own type, so only fresh provisions are checked.
+	var typeWarning string
+	if p.checkType != nil {
+		typeWarning, err = p.checkType(ctx, cfg.InstanceType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid instance type: %w", err)
+		}
+	}
+	rootVolSize, err := p.rootVolumeSize(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2a: Verify bootstrap script integrity (ADR-0009) against
+	// whichever source the stub will pin.
 	if err := p.verifyBootstrap(cfg.BootstrapScript); err != nil {
 		return nil, fmt.Errorf("bootstrap verification failed: %w", err)
 	}
+	if err := bootstrap.VerifySource(cfg.bootstrapSource()); err != nil {
+		return nil, fmt.Errorf("bootstrap verification failed: %w", err)
+	}
 
 	// Step 3: Resolve Ubuntu 24.04 AMI.
 	amiID, err := p.resolveAMI(ctx, p.describeImages)
 	if err != nil {
 		return nil, fmt.Errorf("resolving AMI: %w", err)
 	}
+	// A GPU instance needs an image bootstrap can install the NVIDIA
+	// driver on.
+	if mintaws.IsGPUInstanceType(cfg.InstanceType) {
+		if err := mintaws.CheckGPUImage(ctx, p.describeImages, amiID, cfg.InstanceType); err != nil {
+			return nil, err
+		}
+	}
 
-	// Step 4: Check EIP quota.
-	if err := p.checkEIPQuota(ctx, owner); err != nil {
-		return nil, err
+	// Step 4: Reuse a free Elastic IP tagged for the VM, such as one kept by
+	// mint destroy --keep-eip, or check the EIP quota for a new one. An
+	// ipv6-only VM has no Elastic IP.
+	var eipReused bool
+	if j.IPMode != tags.IPModeIPv6Only {
+		if j.AllocationID == "" {
+			allocID, publicIP, err := p.findFreeEIP(ctx, owner, vmName)
+			if err != nil {
+				return nil, fmt.Errorf("checking EIP quota: %w", err)
+			}
+			if allocID != "" {
+				j.AllocationID, j.PublicIP = allocID, publicIP
+				eipReused = true
+			}
+		}
+		if j.AllocationID == "" {
+			if err := p.checkEIPQuota(ctx, owner); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// Step 5: Find user's security group.
-	userSGID, err := p.findSecurityGroup(ctx, owner, tags.ComponentSecurityGroup)
-	if err != nil {
-		return nil, fmt.Errorf("finding user security group: %w", err)
+	// Steps 5-6: Find the user's and the admin EFS security groups, unless
+	// security_group_ids names the groups to use. Those are checked against
+	// the subnet's VPC once it is known.
+	sgIDs := cfg.SecurityGroupIDs
+	if len(sgIDs) == 0 {
+		userSGID, err := p.findSecurityGroup(ctx, owner, tags.ComponentSecurityGroup)
+		if err != nil {
+			return nil, fmt.Errorf("finding user security group: %w", err)
+		}
+		adminSGID, err := p.findAdminSecurityGroup(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("finding admin security group: %w", err)
+		}
+		sgIDs = []string{userSGID, adminSGID}
 	}
 
-	// Step 6: Find admin EFS security group.
-	adminSGID, err := p.findAdminSecurityGroup(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("finding admin security group: %w", err)
+	// Step 7: Check for a pending-attach volume BEFORE launch so we know
+	// whether to include BlockDeviceMappings in RunInstances, and which AZ
+	// the instance must launch in to attach it.
+	pendingVolID, pendingVolAZ, pendingErr := p.findPendingAttachVolume(ctx, owner, vmName)
+	if pendingErr != nil {
+		return nil, fmt.Errorf("checking pending-attach volumes: %w", pendingErr)
 	}
 
-	// Step 7: Find a subnet in the default VPC.
-	subnetID, az, err := p.findSubnet(ctx)
+	// Step 7.5: Find the subnets to launch in: the configured subnet_id,
+	// or else the default (or vpc_id) subnets, restricted to the AZ of any
+	// pending-attach volume, and to subnets with an IPv6 CIDR block when
+	// the instance needs an IPv6 address.
+	var subnets []launchSubnet
+	if cfg.SubnetID != "" {
+		subnets, err = p.configuredSubnet(ctx, cfg.SubnetID, cfg.VPCID, pendingVolID, pendingVolAZ, j.IPMode)
+	} else {
+		subnets, err = p.findSubnets(ctx, pendingVolAZ, j.IPMode, cfg.VPCID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding subnet: %w", err)
 	}
+	if len(cfg.SecurityGroupIDs) > 0 {
+		if err := mintaws.CheckSecurityGroups(ctx, p.describeSGs, sgIDs, subnets[0].ID, subnets[0].VPC); err != nil {
+			return nil, fmt.Errorf("security_group_ids: %w", err)
+		}
+	}
 
-	// Step 7.5: Check for a pending-attach volume BEFORE launch so we know
-	// whether to include BlockDeviceMappings in RunInstances.
-	pendingVolID, pendingVolAZ, pendingErr := p.findPendingAttachVolume(ctx, owner, vmName)
-	if pendingErr != nil {
-		return nil, fmt.Errorf("checking pending-attach volumes: %w", pendingErr)
+	// Step 7.6: Drop the subnets whose AZ does not offer the instance type,
+	// failing before anything is created when none does.
+	subnets, err = p.offeredSubnets(ctx, cfg.InstanceType, subnets)
+	if err != nil {
+		return nil, err
 	}
 
 	volumeSize := cfg.VolumeSize
@@ -284,38 +509,287 @@ func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, c
 	if volumeIOPS == 0 {
 		volumeIOPS = 3000
 	}
+	volumeThroughput := cfg.VolumeThroughput
+	if volumeThroughput == 0 {
+		volumeThroughput = defaultVolumeThroughput
+	}
+	if err := validateVolumeThroughput(volumeThroughput, volumeIOPS); err != nil {
+		return nil, err
+	}
 
 	// For fresh provisions, create the project EBS via BlockDeviceMappings so
 	// the device is attached before user-data runs (eliminates the race where
 	// bootstrap reaches the EBS step before the volume is attached).
 	// For pending-attach recovery, skip BDM and attach the existing volume after launch.
-	launchVolSize, launchVolIOPS := volumeSize, volumeIOPS
+	launchVolSize, launchVolIOPS, launchVolThroughput := volumeSize, volumeIOPS, volumeThroughput
 	if pendingVolID != "" {
 		launchVolSize = 0
 		launchVolIOPS = 0
+		launchVolThroughput = 0
 	}
 
-	// Step 8: Launch EC2 instance.
-	instanceID, bdmVolumeID, err := p.launchInstance(ctx, amiID, cfg, userSGID, adminSGID, subnetID, owner, ownerARN, vmName, launchVolSize, launchVolIOPS)
+	// A dry run stops here, before anything is created.
+	if p.dryRun {
+		result, err := planLaunch(j, cfg, amiID, sgIDs, subnets, rootVolSize, launchVolSize, launchVolIOPS, launchVolThroughput, pendingVolID)
+		if err != nil {
+			return nil, err
+		}
+		result.InstanceTypeWarning = typeWarning
+		result.Resumed = resumed
+		return result, nil
+	}
+
+	// Nothing has been created yet, so an interrupt up to here is free.
+	if ctx.Err() != nil {
+		return nil, &InterruptedError{}
+	}
+
+	// Step 8: Launch EC2 instance. The journal is written first so a run
+	// that dies while RunInstances is in flight still leaves a record.
+	// The launch itself ignores interrupts: a cancelled RunInstances may
+	// still start an instance whose ID would then never be recorded.
+	j.Step = StepStarted
+	j.StartedAt = time.Now().UTC()
+	if err := p.saveJournal(j); err != nil {
+		return nil, err
+	}
+	launched, err := p.launchInstance(context.WithoutCancel(ctx), j, amiID, cfg, sgIDs, subnets, ownerARN, rootVolSize, launchVolSize, launchVolIOPS, launchVolThroughput)
 	if err != nil {
 		return nil, fmt.Errorf("launching instance: %w", err)
 	}
+	j.InstanceID = launched.ID
+	j.VolumeSizeGB = launchVolSize
+	j.IPv6Address = launched.IPv6Address
+	p.recordStep(j, StepLaunched)
+
+	// Step 8.5: With an operation lock, a concurrent run for the same VM
+	// that found no instance either may have launched one too. The run
+	// with the younger instance terminates it and stops.
+	if p.locker != nil && p.terminateInstances != nil {
+		if err := ResolveLaunchRace(context.WithoutCancel(ctx), p.describeInstances, p.terminateInstances, owner, vmName, launched.ID); err != nil {
+			var race *LaunchRaceError
+			if errors.As(err, &race) {
+				p.removeJournal(j)
+			}
+			return nil, err
+		}
+	}
+	if err := checkInterrupted(ctx, j); err != nil {
+		return nil, err
+	}
+
+	// Steps 9–12: Project volume, Elastic IP, and bootstrap polling, run
+	// concurrently. A failure removes what this run created (the Elastic
+	// IP, then the instance) unless the run was interrupted.
+	priorAllocID := j.AllocationID
+	result, err := p.afterLaunch(ctx, j, ownerARN, launched.AZ, launched.BDMVolumeID, pendingVolID, pendingVolAZ)
+	if err != nil {
+		if p.shouldRollback(err) {
+			return nil, p.rollback(ctx, j, p.createdResources(j, launched.BDMVolumeID, pendingVolID, priorAllocID), err)
+		}
+		return nil, err
+	}
+	// A reused Elastic IP is in use again, so mint gc may release it once
+	// the VM has been stopped long enough.
+	if eipReused && p.deleteTags != nil {
+		_, err := p.deleteTags.DeleteTags(context.WithoutCancel(ctx), &ec2.DeleteTagsInput{
+			Resources: []string{j.AllocationID},
+			Tags:      []ec2types.Tag{{Key: aws.String(tags.TagRetained)}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("removing %s tag from %s: %w", tags.TagRetained, j.AllocationID, err)
+		}
+	}
+	result.InstanceTypeWarning = typeWarning
+	result.Spot = cfg.Spot && launched.SpotWarning == ""
+	result.SpotWarning = launched.SpotWarning
+	result.Resumed = resumed
+	result.BootstrapSource = cfg.bootstrapSource().Label
+	result.EIPReused = eipReused
+	return result, nil
+}
+
+// launchProgress records which of the concurrent steps after launch have
+// completed.
+type launchProgress struct {
+	volumeReady  bool
+	eipAllocated bool
+}
+
+// step returns the journal step for the progress made. Journal steps are
+// ordered, so an Elastic IP allocated before the volume is ready is recorded
+// only by its allocation ID; a resumed run finds it there.
+func (lp launchProgress) step() JournalStep {
+	switch {
+	case !lp.volumeReady:
+		return StepLaunched
+	case lp.eipAllocated:
+		return StepEIPAllocated
+	default:
+		return StepVolumeReady
+	}
+}
+
+// afterLaunch finishes a fresh launch of j's instance. The project volume
+// (tagged, or attached once the instance is running), the Elastic IP
+// (AssociateAddress accepts a pending instance), and bootstrap polling
+// proceed concurrently. The volume and Elastic IP calls ignore interrupts,
+// as in sequence; a failure in either cancels polling and fails the run,
+// leaving what completed in the journal for the next run to resume from.
+func (p *Provisioner) afterLaunch(ctx context.Context, j *Journal, ownerARN, az, bdmVolumeID, pendingVolID, pendingVolAZ string) (*ProvisionResult, error) {
+	var (
+		mu       sync.Mutex
+		progress launchProgress
+	)
+	record := func(update func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		update()
+		p.recordStep(j, progress.step())
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		if err := p.waitForRunning(gctx, j.InstanceID); err != nil {
+			return err
+		}
+		ipv6, err := p.readyIPv6(gctx, j)
+		if err != nil {
+			return err
+		}
+		if ipv6 != "" {
+			record(func() { j.setIPv6Address(ipv6) })
+		}
+		// Attaching a pending-attach volume and removing its tag happen
+		// together or not at all.
+		volumeID, err := p.readyVolume(context.WithoutCancel(ctx), j, ownerARN, az, bdmVolumeID, pendingVolID, pendingVolAZ)
+		if err != nil {
+			return err
+		}
+		record(func() {
+			j.VolumeID = volumeID
+			progress.volumeReady = true
+		})
+		return nil
+	})
+	var eipWait time.Duration
+	g.Go(func() error {
+		if j.IPMode == tags.IPModeIPv6Only {
+			return nil
+		}
+		err := p.readyEIP(context.WithoutCancel(ctx), j, ownerARN, func(allocID, publicIP string) {
+			record(func() {
+				j.AllocationID, j.PublicIP = allocID, publicIP
+				progress.eipAllocated = true
+			})
+		})
+		if err != nil {
+			return err
+		}
+		eipWait, err = p.waitForEIP(gctx, j.AllocationID, j.InstanceID)
+		return err
+	})
+	var pollErr error
+	if p.pollBootstrap != nil {
+		g.Go(func() error {
+			pollErr = p.pollBootstrap(gctx, j.Owner, j.VM, j.InstanceID)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if ierr := checkInterrupted(ctx, j); ierr != nil {
+			return nil, ierr
+		}
+		return nil, err
+	}
 
-	// Step 9: Wait for instance to reach running state.
-	if p.waitRunning != nil {
-		if err := p.waitRunning.Wait(ctx, &ec2.DescribeInstancesInput{
-			InstanceIds: []string{instanceID},
-		}, 5*time.Minute); err != nil {
-			return nil, fmt.Errorf("waiting for instance %s to be running: %w", instanceID, err)
+	p.recordStep(j, StepEIPAssociated)
+	if p.pollBootstrap == nil {
+		if err := checkInterrupted(ctx, j); err != nil {
+			return nil, err
 		}
 	}
+	result := p.complete(j, pollErr)
+	result.EIPPropagation = eipWait
+	return result, nil
+}
+
+// readyIPv6 returns the IPv6 address of j's instance for the caller to
+// record, looking it up when the instance was launched with one and the
+// RunInstances response did not report it. It returns "" for an instance
+// launched without one.
+func (p *Provisioner) readyIPv6(ctx context.Context, j *Journal) (string, error) {
+	if j.IPMode != tags.IPModeDualStack && j.IPMode != tags.IPModeIPv6Only {
+		return "", nil
+	}
+	if j.IPv6Address != "" {
+		return j.IPv6Address, nil
+	}
+	found, err := vm.FindVMByID(ctx, p.describeInstances, j.InstanceID)
+	if err != nil {
+		return "", fmt.Errorf("reading IPv6 address of %s: %w", j.InstanceID, err)
+	}
+	if found == nil || found.IPv6Address == "" {
+		return "", fmt.Errorf("instance %s has no IPv6 address", j.InstanceID)
+	}
+	return found.IPv6Address, nil
+}
+
+// setIPv6Address records addr as the IPv6 address of j's instance. An
+// ipv6-only instance is reached at that address, so it is also its PublicIP.
+func (j *Journal) setIPv6Address(addr string) {
+	j.IPv6Address = addr
+	if j.IPMode == tags.IPModeIPv6Only {
+		j.PublicIP = addr
+	}
+}
+
+// waitForRunning blocks until instanceID is running. It is a no-op when no
+// waiter is configured (tests).
+func (p *Provisioner) waitForRunning(ctx context.Context, instanceID string) error {
+	if p.waitRunning == nil {
+		return nil
+	}
+	if err := p.waitRunning.Wait(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	}, 5*time.Minute); err != nil {
+		return fmt.Errorf("waiting for instance %s to be running: %w", instanceID, err)
+	}
+	return nil
+}
+
+// defaultEIPWait bounds how long the Elastic IP association may take to
+// reach the instance.
+const defaultEIPWait = 2 * time.Minute
+
+// waitForEIP blocks until the Elastic IP allocID, just associated with
+// instanceID, is the instance's public IP, and returns how long that took.
+// Until then an SSH connection to the address can reach the previous
+// instance or nothing. It is a no-op when no waiter is configured (tests).
+func (p *Provisioner) waitForEIP(ctx context.Context, allocID, instanceID string) (time.Duration, error) {
+	if p.waitEIP == nil {
+		return 0, nil
+	}
+	waited, err := p.waitEIP.Wait(ctx, allocID, instanceID, defaultEIPWait)
+	if p.logger != nil {
+		p.logger.Log("ec2", "WaitEIPAssociated", waited, err)
+	}
+	return waited, err
+}
 
-	// Step 10: Handle project EBS volume.
-	var volumeID string
+// readyVolume makes the project volume usable by j's instance and returns
+// its ID for the caller to record. A pending-attach volume left by mint recreate is attached (it must be
+// in the instance's AZ); otherwise the volume created through
+// BlockDeviceMappings is tagged. bdmVolumeID is the volume ID from the
+// RunInstances response, if it was populated.
+func (p *Provisioner) readyVolume(ctx context.Context, j *Journal, ownerARN, az, bdmVolumeID, pendingVolID, pendingVolAZ string) (string, error) {
+	instanceID := j.InstanceID
 	if pendingVolID != "" {
 		// Attach the pending-attach volume from a previous mint recreate.
+		// It is attached as it is, encrypted or not: only volumes created
+		// at launch pick up the encryption settings.
 		if pendingVolAZ != az {
-			return nil, fmt.Errorf(
+			return "", fmt.Errorf(
 				"pending-attach volume %s is in %s but instance launched in %s — "+
 					"run %s and start fresh to resolve this AZ mismatch",
 				pendingVolID, pendingVolAZ, az, hint.Cmd("mint destroy"),
@@ -327,7 +801,7 @@ func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, c
 			Device:     aws.String("/dev/xvdf"),
 		})
 		if attachErr != nil {
-			return nil, fmt.Errorf("attaching pending-attach volume %s to %s: %w", pendingVolID, instanceID, attachErr)
+			return "", fmt.Errorf("attaching pending-attach volume %s to %s: %w", pendingVolID, instanceID, attachErr)
 		}
 		if p.deleteTags != nil {
 			_, delErr := p.deleteTags.DeleteTags(ctx, &ec2.DeleteTagsInput{
@@ -337,48 +811,27 @@ func (p *Provisioner) Run(ctx context.Context, owner, ownerARN, vmName string, c
 				},
 			})
 			if delErr != nil {
-				return nil, fmt.Errorf("removing pending-attach tag from %s: %w", pendingVolID, delErr)
-			}
-		}
-		volumeID = pendingVolID
-	} else {
-		// Volume was created via BlockDeviceMappings at launch.
-		volumeID = bdmVolumeID
-		if volumeID == "" {
-			// Fallback: BDM volume ID not yet populated in RunInstances response;
-			// describe the running instance to get it.
-			var getErr error
-			volumeID, getErr = p.getBDMVolumeID(ctx, instanceID)
-			if getErr != nil {
-				return nil, fmt.Errorf("getting project volume ID for instance %s: %w", instanceID, getErr)
+				return "", fmt.Errorf("removing pending-attach tag from %s: %w", pendingVolID, delErr)
 			}
 		}
-		if tagErr := p.tagVolume(ctx, volumeID, owner, ownerARN, vmName); tagErr != nil {
-			return nil, fmt.Errorf("tagging project volume: %w", tagErr)
-		}
-	}
-
-	// Step 11: Allocate and associate Elastic IP.
-	allocID, publicIP, err := p.allocateAndAssociateEIP(ctx, instanceID, owner, ownerARN, vmName)
-	if err != nil {
-		return nil, fmt.Errorf("allocating Elastic IP: %w", err)
+		return pendingVolID, nil
 	}
 
-	result := &ProvisionResult{
-		InstanceID:   instanceID,
-		PublicIP:     publicIP,
-		VolumeID:     volumeID,
-		AllocationID: allocID,
-	}
-
-	// Step 12: Poll for bootstrap completion (if poller configured).
-	if p.pollBootstrap != nil {
-		if pollErr := p.pollBootstrap(ctx, owner, vmName, instanceID); pollErr != nil {
-			result.BootstrapError = pollErr
+	// Volume was created via BlockDeviceMappings at launch.
+	volumeID := bdmVolumeID
+	if volumeID == "" {
+		// Fallback: BDM volume ID not yet populated in RunInstances response;
+		// describe the running instance to get it.
+		var getErr error
+		volumeID, getErr = p.getBDMVolumeID(ctx, instanceID)
+		if getErr != nil {
+			return "", fmt.Errorf("getting project volume ID for instance %s: %w", instanceID, getErr)
 		}
 	}
-
-	return result, nil
+	if tagErr := p.tagVolume(ctx, volumeID, j.Owner, ownerARN, j.VM, j.Expires); tagErr != nil {
+		return "", fmt.Errorf("tagging project volume: %w", tagErr)
+	}
+	return volumeID, nil
 }
 
 // handleExistingVM starts a stopped VM or returns info about a running VM.
@@ -393,10 +846,12 @@ func (p *Provisioner) handleExistingVM(ctx context.Context, existing *vm.VM) (*P
 			return nil, fmt.Errorf("starting stopped VM %s: %w", existing.ID, err)
 		}
 		result := &ProvisionResult{
-			InstanceID:      existing.ID,
-			PublicIP:        existing.PublicIP,
-			Restarted:       true,
-			BootstrapStatus: existing.BootstrapStatus,
+			InstanceID:          existing.ID,
+			PublicIP:            existing.PublicIP,
+			IPv6Address:         existing.IPv6Address,
+			Restarted:           true,
+			BootstrapStatus:     existing.BootstrapStatus,
+			UserBootstrapStatus: existing.UserBootstrapStatus,
 		}
 		if existing.BootstrapStatus == tags.BootstrapFailed {
 			result.BootstrapError = fmt.Errorf(
@@ -411,10 +866,12 @@ func (p *Provisioner) handleExistingVM(ctx context.Context, existing *vm.VM) (*P
 	// Reflect the actual mint:bootstrap tag so callers never infer success
 	// from the absence of an error when bootstrap may still be pending.
 	result := &ProvisionResult{
-		InstanceID:      existing.ID,
-		PublicIP:        existing.PublicIP,
-		AlreadyRunning:  true,
-		BootstrapStatus: existing.BootstrapStatus,
+		InstanceID:          existing.ID,
+		PublicIP:            existing.PublicIP,
+		IPv6Address:         existing.IPv6Address,
+		AlreadyRunning:      true,
+		BootstrapStatus:     existing.BootstrapStatus,
+		UserBootstrapStatus: existing.UserBootstrapStatus,
 	}
 
 	if existing.BootstrapStatus == tags.BootstrapFailed {
@@ -427,19 +884,47 @@ func (p *Provisioner) handleExistingVM(ctx context.Context, existing *vm.VM) (*P
 	return result, nil
 }
 
+// reallocateEIP gives a restarted VM whose Elastic IP mint gc released a
+// fresh one and clears the mint:eip tag, so the SSH config written after
+// mint up points at the new address.
+func (p *Provisioner) reallocateEIP(ctx context.Context, existing *vm.VM, owner, ownerARN string, result *ProvisionResult) error {
+	if err := p.checkEIPQuota(ctx, owner); err != nil {
+		return err
+	}
+	allocID, publicIP, err := p.allocateEIP(ctx, owner, ownerARN, existing.Name, existing.Tags[tags.TagExpires])
+	if err != nil {
+		return fmt.Errorf("allocating Elastic IP for restarted VM: %w", err)
+	}
+	if err := p.associateEIP(ctx, allocID, existing.ID); err != nil {
+		return err
+	}
+	waited, err := p.waitForEIP(ctx, allocID, existing.ID)
+	if err != nil {
+		return err
+	}
+	result.EIPPropagation = waited
+	if p.deleteTags != nil {
+		_, err := p.deleteTags.DeleteTags(ctx, &ec2.DeleteTagsInput{
+			Resources: []string{existing.ID},
+			Tags:      []ec2types.Tag{{Key: aws.String(tags.TagEIP)}},
+		})
+		if err != nil {
+			return fmt.Errorf("removing %s tag from %s: %w", tags.TagEIP, existing.ID, err)
+		}
+	}
+	result.AllocationID = allocID
+	result.PublicIP = publicIP
+	result.EIPReallocated = true
+	return nil
+}
+
 // checkEIPQuota checks if the user has room for another EIP allocation.
 func (p *Provisioner) checkEIPQuota(ctx context.Context, owner string) error {
-	out, err := p.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
-		Filters: []ec2types.Filter{
-			{Name: aws.String("tag:" + tags.TagMint), Values: []string{"true"}},
-			{Name: aws.String("tag:" + tags.TagOwner), Values: []string{owner}},
-		},
-	})
+	count, err := countOwnerEIPs(ctx, p.describeAddrs, owner)
 	if err != nil {
-		return fmt.Errorf("checking EIP quota: %w", err)
+		return err
 	}
 
-	count := len(out.Addresses)
 	if count >= DefaultEIPLimit {
 		return fmt.Errorf(
 			"EIP quota exceeded: you have %d of %d allowed Elastic IPs — "+
@@ -491,30 +976,147 @@ func (p *Provisioner) findAdminSecurityGroup(ctx context.Context) (string, error
 	return aws.ToString(out.SecurityGroups[0].GroupId), nil
 }
 
-// findSubnet finds the first public subnet in the default VPC.
-func (p *Provisioner) findSubnet(ctx context.Context) (subnetID, az string, err error) {
+// launchSubnet is a subnet an instance can launch in.
+type launchSubnet struct {
+	ID  string
+	AZ  string
+	VPC string
+}
+
+// configuredSubnet returns the subnet_id subnet, checking that it exists,
+// is in vpcID when that is set, can attach a pending-attach volume in
+// pinnedAZ, and has an IPv6 CIDR block when ipMode needs one.
+func (p *Provisioner) configuredSubnet(ctx context.Context, subnetID, vpcID, pendingVolID, pinnedAZ, ipMode string) ([]launchSubnet, error) {
+	subnet, err := mintaws.DescribeSubnet(ctx, p.describeSubnets, subnetID, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	az := aws.ToString(subnet.AvailabilityZone)
+	if pinnedAZ != "" && az != pinnedAZ {
+		return nil, fmt.Errorf("subnet_id %s is in %s, but project volume %s is in %s — set subnet_id to a subnet in %s",
+			subnetID, az, pendingVolID, pinnedAZ, pinnedAZ)
+	}
+	if (ipMode == tags.IPModeDualStack || ipMode == tags.IPModeIPv6Only) && !mintaws.SubnetHasIPv6(subnet) {
+		return nil, fmt.Errorf("ip_mode %s needs a subnet with an IPv6 CIDR block, and subnet_id %s has none", ipMode, subnetID)
+	}
+	return []launchSubnet{{ID: subnetID, AZ: az, VPC: aws.ToString(subnet.VpcId)}}, nil
+}
+
+// findSubnets returns the public subnets of the default VPC, or every
+// subnet of vpcID when that is set, in the order launches try them. When
+// pinnedAZ is set only the subnets in that AZ are returned; if there are
+// none, the first subnet is, and the pending-attach volume check reports
+// the mismatch after launch. In the dualstack and ipv6-only IP modes only
+// subnets with an IPv6 CIDR block are returned, and having none is an
+// error.
+func (p *Provisioner) findSubnets(ctx context.Context, pinnedAZ, ipMode, vpcID string) ([]launchSubnet, error) {
+	filter := ec2types.Filter{Name: aws.String("default-for-az"), Values: []string{"true"}}
+	if vpcID != "" {
+		filter = ec2types.Filter{Name: aws.String("vpc-id"), Values: []string{vpcID}}
+	}
 	out, err := p.describeSubnets.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
-		Filters: []ec2types.Filter{
-			{Name: aws.String("default-for-az"), Values: []string{"true"}},
-		},
+		Filters: []ec2types.Filter{filter},
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("describe subnets: %w", err)
+		return nil, fmt.Errorf("describe subnets: %w", err)
 	}
 
+	if len(out.Subnets) == 0 && vpcID != "" {
+		return nil, fmt.Errorf("no subnets found in vpc_id %s", vpcID)
+	}
 	if len(out.Subnets) == 0 {
-		return "", "", fmt.Errorf("no default subnets found — mint requires a default VPC with subnets (ADR-0010)")
+		return nil, fmt.Errorf("no default subnets found — mint requires a default VPC with subnets (ADR-0010), "+
+			"or your own network in the subnet_id, security_group_ids, and vpc_id config keys.\n%s",
+			hint.Suggest("Launch into your own subnet with", "mint config set subnet_id <subnet-id>"))
+	}
+
+	candidates := out.Subnets
+	if ipMode == tags.IPModeDualStack || ipMode == tags.IPModeIPv6Only {
+		candidates = nil
+		for _, subnet := range out.Subnets {
+			if mintaws.SubnetHasIPv6(subnet) {
+				candidates = append(candidates, subnet)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("ip_mode %s needs a subnet with an IPv6 CIDR block, and no default subnet has one — "+
+				"associate an IPv6 CIDR block with the default VPC and its subnets, or set ip_mode to eip", ipMode)
+		}
+	}
+
+	var subnets []launchSubnet
+	for _, subnet := range candidates {
+		az := aws.ToString(subnet.AvailabilityZone)
+		if pinnedAZ == "" || az == pinnedAZ {
+			subnets = append(subnets, launchSubnet{ID: aws.ToString(subnet.SubnetId), AZ: az, VPC: aws.ToString(subnet.VpcId)})
+		}
+	}
+	if len(subnets) == 0 {
+		first := candidates[0]
+		subnets = append(subnets, launchSubnet{ID: aws.ToString(first.SubnetId), AZ: aws.ToString(first.AvailabilityZone), VPC: aws.ToString(first.VpcId)})
+	}
+	return subnets, nil
+}
+
+// offeredSubnets returns the subnets whose AZ offers instanceType, in
+// order. When none does, the error names every AZ and suggests similar
+// types the first one offers. Without an offering check, subnets is
+// returned unchanged.
+func (p *Provisioner) offeredSubnets(ctx context.Context, instanceType string, subnets []launchSubnet) ([]launchSubnet, error) {
+	if p.checkOffering == nil {
+		return subnets, nil
+	}
+
+	var offered []launchSubnet
+	var first *mintaws.InstanceTypeNotOfferedError
+	var azs []string
+	for _, subnet := range subnets {
+		err := p.checkOffering(ctx, instanceType, subnet.AZ)
+		var notOffered *mintaws.InstanceTypeNotOfferedError
+		switch {
+		case err == nil:
+			offered = append(offered, subnet)
+		case errors.As(err, &notOffered):
+			if first == nil {
+				first = notOffered
+			}
+			azs = append(azs, subnet.AZ)
+		default:
+			return nil, fmt.Errorf("checking instance type offerings in %s: %w", subnet.AZ, err)
+		}
+	}
+	if len(offered) == 0 && first != nil {
+		return nil, &mintaws.InstanceTypeNotOfferedError{
+			InstanceType: instanceType,
+			Location:     strings.Join(azs, ", "),
+			Suggestions:  first.Suggestions,
+		}
 	}
+	return offered, nil
+}
 
-	subnet := out.Subnets[0]
-	return aws.ToString(subnet.SubnetId), aws.ToString(subnet.AvailabilityZone), nil
+// UnreplacedVariablesError reports ${MINT_*} expressions left in a script
+// after InterpolateBootstrap, which would otherwise reach the VM as empty
+// strings or shell defaults.
+type UnreplacedVariablesError struct {
+	Vars []bootstrap.Unreplaced
+}
+
+func (e *UnreplacedVariablesError) Error() string {
+	vars := make([]string, len(e.Vars))
+	for i, v := range e.Vars {
+		vars[i] = v.String()
+	}
+	return "no value for " + strings.Join(vars, ", ")
 }
 
 // InterpolateBootstrap substitutes Mint-specific variables in the bootstrap
 // script. Only variables present in the vars map are replaced; all other
 // ${...} expressions (including bash defaults like ${VAR:-default}) are left
-// untouched so the shell can evaluate them normally.
-func InterpolateBootstrap(script []byte, vars map[string]string) []byte {
+// untouched so the shell can evaluate them normally. A ${MINT_*} expression
+// still in the result, such as a typo'd ${MINT_EFS_IDD}, is an
+// *UnreplacedVariablesError naming each one and its line.
+func InterpolateBootstrap(script []byte, vars map[string]string) ([]byte, error) {
 	result := string(script)
 	for name, value := range vars {
 		// Replace ${VAR:-default} patterns first (bash default syntax).
@@ -522,7 +1124,21 @@ func InterpolateBootstrap(script []byte, vars map[string]string) []byte {
 		// Find and replace any ${NAME...} where NAME matches our variable.
 		result = ReplaceBashVar(result, name, value)
 	}
-	return []byte(result)
+	if left := bootstrap.FindMintVars([]byte(result)); len(left) > 0 {
+		return nil, &UnreplacedVariablesError{Vars: left}
+	}
+	return []byte(result), nil
+}
+
+// BootstrapRenderError wraps an error rendering a VM's user-data, telling
+// the user to reinstall mint when the binary and its embedded stub
+// disagree on the placeholders.
+func BootstrapRenderError(err error) error {
+	var mismatch *bootstrap.StubMismatchError
+	if errors.As(err, &mismatch) {
+		return fmt.Errorf("bootstrap rendering failed: this mint binary and its embedded bootstrap stub are out of sync; reinstall mint or rebuild it from a clean checkout: %w", err)
+	}
+	return fmt.Errorf("bootstrap rendering failed: %w", err)
 }
 
 // ReplaceBashVar replaces all occurrences of ${name}, ${name:-...}, and
@@ -593,12 +1209,17 @@ func (p *Provisioner) getBDMVolumeID(ctx context.Context, instanceID string) (st
 }
 
 // tagVolume applies Mint project-volume tags to an EBS volume via CreateTags.
-func (p *Provisioner) tagVolume(ctx context.Context, volumeID, owner, ownerARN, vmName string) error {
-	volumeTags := tags.NewTagBuilder(owner, ownerARN, vmName).
+// A non-empty expires is written as mint:expires.
+func (p *Provisioner) tagVolume(ctx context.Context, volumeID, owner, ownerARN, vmName, expires string) error {
+	volumeTags, err := tags.MergeExtra(tags.NewTagBuilder(owner, ownerARN, vmName).
 		WithComponent(tags.ComponentProjectVolume).
-		Build()
+		WithExpires(expires).
+		Build(), p.extraTags)
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	_, err := p.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
+	_, err = p.createTags.CreateTags(ctx, &ec2.CreateTagsInput{
 		Resources: []string{volumeID},
 		Tags:      volumeTags,
 	})
@@ -611,53 +1232,79 @@ func (p *Provisioner) tagVolume(ctx context.Context, volumeID, owner, ownerARN,
 	return nil
 }
 
-// launchInstance runs a new EC2 instance with the given configuration.
+// launchedInstance is an instance started by launchInstance.
+type launchedInstance struct {
+	ID string
+	// BDMVolumeID is the project volume created through BlockDeviceMappings,
+	// when the RunInstances response reports it.
+	BDMVolumeID string
+	// AZ is the availability zone of the subnet the instance launched in.
+	AZ string
+	// SpotWarning is set when a spot launch fell back to on-demand.
+	SpotWarning string
+	// IPv6Address is the instance's IPv6 address when the RunInstances
+	// response reports one.
+	IPv6Address string
+}
+
+// rootVolumeSize returns the size of a fresh instance's root volume.
+func (p *Provisioner) rootVolumeSize(ctx context.Context, cfg ProvisionConfig) (int32, error) {
+	return RootVolumeSize(ctx, p.describeTypes, cfg.InstanceType, cfg.Hibernate)
+}
+
+// RootVolumeSize returns the size in GiB of the root volume an instance of
+// instanceType is launched with: 200 (ADR-0004), plus the instance's
+// memory when it is hibernation-enabled, since hibernation writes RAM to
+// the root volume. client is only called with hibernate, and a type that
+// cannot hibernate fails with an *mintaws.UnsupportedHibernationError.
+func RootVolumeSize(ctx context.Context, client mintaws.DescribeInstanceTypesAPI, instanceType string, hibernate bool) (int32, error) {
+	if !hibernate {
+		return rootVolumeSizeGB, nil
+	}
+	if client == nil {
+		return 0, fmt.Errorf("hibernate is set but no DescribeInstanceTypes client is configured")
+	}
+	memory, err := mintaws.HibernationMemory(ctx, client, instanceType)
+	if err != nil {
+		return 0, err
+	}
+	memGB := (memory[instanceType] + 1023) / 1024
+	return rootVolumeSizeGB + int32(memGB), nil
+}
+
+// launchInstance runs a new EC2 instance with the given configuration in
+// the first of subnets with capacity for it.
 // When projectVolSize > 0, the project EBS volume is created via
 // BlockDeviceMappings so the device is attached before user-data runs.
-// Returns the instance ID and (if available in the response) the BDM volume ID.
+// The prefetch images that fit in user-data are recorded in j.
 func (p *Provisioner) launchInstance(
 	ctx context.Context,
+	j *Journal,
 	amiID string,
 	cfg ProvisionConfig,
-	userSGID, adminSGID, subnetID string,
-	owner, ownerARN, vmName string,
+	sgIDs []string,
+	subnets []launchSubnet,
+	ownerARN string,
+	rootVolSize int32,
 	projectVolSize int32,
 	projectVolIOPS int32,
-) (instanceID, bdmVolumeID string, err error) {
-	idleTimeout := cfg.IdleTimeout
-	if idleTimeout == 0 {
-		idleTimeout = 60
-	}
-
-	userBootstrapB64 := ""
-	if len(cfg.UserBootstrapScript) > 0 {
-		userBootstrapB64 = base64.StdEncoding.EncodeToString(cfg.UserBootstrapScript)
-	}
-
-	stub, err := bootstrap.RenderStub(
-		bootstrap.ScriptSHA256,
-		cfg.BootstrapURL,
-		cfg.EFSID,
-		"/dev/xvdf",
-		vmName,
-		strconv.Itoa(idleTimeout),
-		userBootstrapB64,
-	)
+	projectVolThroughput int32,
+) (*launchedInstance, error) {
+	owner, vmName := j.Owner, j.VM
+	src := cfg.bootstrapSource()
+	stub, prefetch, dropped, err := renderUserData(cfg, vmName)
 	if err != nil {
-		return "", "", fmt.Errorf("rendering bootstrap stub: %w", err)
-	}
-
-	const maxUserDataBytes = 16384
-	if len(stub) > maxUserDataBytes {
-		return "", "", fmt.Errorf("user-bootstrap.sh too large: rendered user-data is %d bytes, max is %d (%d bytes over limit)",
-			len(stub), maxUserDataBytes, len(stub)-maxUserDataBytes)
+		return nil, err
 	}
+	j.PrefetchImages = prefetch
+	j.PrefetchDropped = dropped
 
 	userData := base64.StdEncoding.EncodeToString(stub)
 
 	instanceTags := tags.NewTagBuilder(owner, ownerARN, vmName).
 		WithComponent(tags.ComponentInstance).
 		WithBootstrap(tags.BootstrapPending).
+		WithExpires(j.Expires).
 		Build()
 
 	// Add volume size tags for mint status to read back (ADR-0004).
@@ -667,9 +1314,31 @@ func (p *Provisioner) launchInstance(
 		displayVolSize = 50
 	}
 	instanceTags = append(instanceTags,
-		ec2types.Tag{Key: aws.String(tags.TagRootVolumeGB), Value: aws.String("200")},
+		ec2types.Tag{Key: aws.String(tags.TagRootVolumeGB), Value: aws.String(strconv.Itoa(int(rootVolSize)))},
 		ec2types.Tag{Key: aws.String(tags.TagProjectVolumeGB), Value: aws.String(strconv.Itoa(int(displayVolSize)))},
+		ec2types.Tag{Key: aws.String(tags.TagBootstrapSource), Value: aws.String(src.Label)},
 	)
+	// A pending-attach volume is launched without a mapping; tag the
+	// throughput it was created with, as configured.
+	tagThroughput := projectVolThroughput
+	if tagThroughput == 0 {
+		tagThroughput = cfg.VolumeThroughput
+	}
+	if tagThroughput > 0 {
+		instanceTags = append(instanceTags, ec2types.Tag{
+			Key: aws.String(tags.TagProjectVolumeThroughput), Value: aws.String(strconv.Itoa(int(tagThroughput))),
+		})
+	}
+	if cfg.SSHUser != "" {
+		instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(tags.TagSSHUser), Value: aws.String(cfg.SSHUser)})
+	}
+	ipMode := cfg.ipMode()
+	if ipMode != tags.IPModeEIP {
+		instanceTags = append(instanceTags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(ipMode)})
+	}
+	if instanceTags, err = tags.MergeExtra(instanceTags, p.extraTags); err != nil {
+		return nil, err
+	}
 
 	instanceType := ec2types.InstanceType(cfg.InstanceType)
 
@@ -678,14 +1347,10 @@ func (p *Provisioner) launchInstance(
 		InstanceType: instanceType,
 		MinCount:     aws.Int32(1),
 		MaxCount:     aws.Int32(1),
-		SubnetId:     aws.String(subnetID),
-		SecurityGroupIds: []string{
-			userSGID,
-			adminSGID,
-		},
-		UserData: aws.String(userData),
+		SecurityGroupIds: sgIDs,
+		UserData:         aws.String(userData),
 		IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{
-			Name: aws.String("mint-instance-profile"),
+			Name: aws.String(cfg.instanceProfile()),
 		},
 		TagSpecifications: []ec2types.TagSpecification{
 			{
@@ -694,16 +1359,29 @@ func (p *Provisioner) launchInstance(
 			},
 		},
 	}
+	if cfg.Hibernate {
+		input.HibernationOptions = &ec2types.HibernationOptionsRequest{Configured: aws.Bool(true)}
+	}
 
-	// Always override the root EBS to 200GB gp3 (ADR-0004). The AMI default
-	// is 8GB which is insufficient for devcontainer builds.
+	// Both volumes are encrypted, with the configured KMS key or else the
+	// account's default EBS key.
+	var kmsKeyID *string
+	if cfg.KMSKeyID != "" {
+		kmsKeyID = aws.String(cfg.KMSKeyID)
+	}
+
+	// Always override the root EBS to 200GB gp3 (ADR-0004), more with
+	// hibernation. The AMI default is 8GB which is insufficient for
+	// devcontainer builds.
 	bdms := []ec2types.BlockDeviceMapping{
 		{
 			DeviceName: aws.String("/dev/sda1"),
 			Ebs: &ec2types.EbsBlockDevice{
-				VolumeSize:          aws.Int32(200),
+				VolumeSize:          aws.Int32(rootVolSize),
 				VolumeType:          ec2types.VolumeTypeGp3,
 				DeleteOnTermination: aws.Bool(true),
+				Encrypted:           aws.Bool(true),
+				KmsKeyId:            kmsKeyID,
 			},
 		},
 	}
@@ -717,34 +1395,160 @@ func (p *Provisioner) launchInstance(
 				VolumeSize:          aws.Int32(projectVolSize),
 				VolumeType:          ec2types.VolumeTypeGp3,
 				Iops:                aws.Int32(projectVolIOPS),
+				Throughput:          aws.Int32(projectVolThroughput),
 				DeleteOnTermination: aws.Bool(false),
+				Encrypted:           aws.Bool(true),
+				KmsKeyId:            kmsKeyID,
 			},
 		})
 	}
 	input.BlockDeviceMappings = bdms
 
-	start := time.Now()
-	out, launchErr := p.runInstances.RunInstances(ctx, input)
-	if p.logger != nil {
-		p.logger.Log("ec2", "RunInstances", time.Since(start), launchErr)
+	// An IPv6 address is requested on the primary network interface,
+	// which then carries the subnet and security groups.
+	if ipMode != tags.IPModeEIP {
+		input.NetworkInterfaces = []ec2types.InstanceNetworkInterfaceSpecification{
+			mintaws.IPv6NetworkInterface("", input.SecurityGroupIds, ipMode != tags.IPModeIPv6Only),
+		}
+		input.SecurityGroupIds = nil
+	}
+
+	if cfg.Spot {
+		input.InstanceMarketOptions = mintaws.SpotMarketOptions()
+		input.TagSpecifications[0].Tags = append(instanceTags[:len(instanceTags):len(instanceTags)],
+			ec2types.Tag{Key: aws.String(tags.TagSpot), Value: aws.String("true")})
+	}
+
+	launched := &launchedInstance{}
+	out, subnet, launchErr := p.runInstancesInSubnets(ctx, input, subnets)
+	if code := mintaws.SpotCapacityErrorCode(launchErr); cfg.Spot && cfg.SpotFallback && code != "" {
+		launched.SpotWarning = mintaws.SpotFallbackWarning(cfg.InstanceType, code)
+		input.InstanceMarketOptions = nil
+		input.TagSpecifications[0].Tags = instanceTags
+		out, subnet, launchErr = p.runInstancesInSubnets(ctx, input, subnets)
 	}
 	if launchErr != nil {
-		return "", "", fmt.Errorf("run instances: %w", launchErr)
+		return nil, fmt.Errorf("run instances: %w", launchErr)
 	}
 
 	if len(out.Instances) == 0 {
-		return "", "", fmt.Errorf("run instances returned no instances")
+		return nil, fmt.Errorf("run instances returned no instances")
 	}
 
-	instanceID = aws.ToString(out.Instances[0].InstanceId)
+	launched.ID = aws.ToString(out.Instances[0].InstanceId)
+	launched.AZ = subnet.AZ
+	if ipMode != tags.IPModeEIP {
+		launched.IPv6Address = mintaws.InstanceIPv6Address(out.Instances[0])
+	}
 
 	// Try to get the BDM volume ID from the RunInstances response.
 	// AWS populates this when the volume is created synchronously at launch.
 	if projectVolSize > 0 {
-		bdmVolumeID = findBDMVolumeID(out.Instances[0].BlockDeviceMappings, "/dev/xvdf")
+		launched.BDMVolumeID = findBDMVolumeID(out.Instances[0].BlockDeviceMappings, "/dev/xvdf")
+	}
+
+	return launched, nil
+}
+
+// renderUserData renders the bootstrap stub a fresh instance of vmName is
+// launched with, with as many of cfg's prefetch images as fit. It returns
+// the stub, the images it includes, and how many more did not fit.
+func renderUserData(cfg ProvisionConfig, vmName string) (stub []byte, prefetch []string, dropped int, err error) {
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout == 0 {
+		idleTimeout = 60
+	}
+
+	userBootstrapB64 := ""
+	if len(cfg.UserBootstrapScript) > 0 {
+		userBootstrapB64 = base64.StdEncoding.EncodeToString(cfg.UserBootstrapScript)
+	}
+
+	src := cfg.bootstrapSource()
+	render := func(images []string) ([]byte, error) {
+		return bootstrap.RenderStub(
+			src.SHA256,
+			src.URL,
+			src.Inline,
+			cfg.EFSID,
+			"/dev/xvdf",
+			vmName,
+			strconv.Itoa(idleTimeout),
+			cfg.sshPort(),
+			userBootstrapB64,
+			mintaws.IsGPUInstanceType(cfg.InstanceType),
+			images,
+		)
+	}
+	stub, err = render(nil)
+	if err != nil {
+		return nil, nil, 0, BootstrapRenderError(err)
+	}
+
+	if err := bootstrap.CheckUserDataSize(stub, src.Inline); err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Prefetch images only use the space the rest of user-data leaves.
+	prefetch, dropped = bootstrap.FitPrefetchImages(cfg.PrefetchImages, len(stub))
+	if len(prefetch) > 0 {
+		if stub, err = render(prefetch); err != nil {
+			return nil, nil, 0, BootstrapRenderError(err)
+		}
+	}
+	return stub, prefetch, dropped, nil
+}
+
+// capacityErrorCodes are the RunInstances error codes that only rule out
+// the subnet's AZ: it has no capacity for the instance type, or does not
+// offer it.
+var capacityErrorCodes = map[string]bool{
+	"InsufficientInstanceCapacity": true,
+	"Unsupported":                  true,
+}
+
+// runInstancesInSubnets launches input in each of subnets in turn until
+// one has capacity. Any other error stops at once. When every subnet's AZ
+// is out of capacity the error lists the AZs tried and wraps the last
+// failure.
+func (p *Provisioner) runInstancesInSubnets(ctx context.Context, input *ec2.RunInstancesInput, subnets []launchSubnet) (*ec2.RunInstancesOutput, launchSubnet, error) {
+	var (
+		tried   []string
+		lastErr error
+	)
+	for _, subnet := range subnets {
+		if len(input.NetworkInterfaces) > 0 {
+			input.NetworkInterfaces[0].SubnetId = aws.String(subnet.ID)
+		} else {
+			input.SubnetId = aws.String(subnet.ID)
+		}
+		out, err := p.runInstance(ctx, input, subnet.AZ)
+		if err == nil {
+			return out, subnet, nil
+		}
+		var ae smithy.APIError
+		if !errors.As(err, &ae) || !capacityErrorCodes[ae.ErrorCode()] {
+			return nil, subnet, err
+		}
+		tried = append(tried, subnet.AZ)
+		lastErr = err
 	}
+	return nil, launchSubnet{}, fmt.Errorf("no capacity for %s in %s: %w",
+		input.InstanceType, strings.Join(tried, ", "), lastErr)
+}
 
-	return instanceID, bdmVolumeID, nil
+// runInstance calls RunInstances in az, logging the call.
+func (p *Provisioner) runInstance(ctx context.Context, input *ec2.RunInstancesInput, az string) (*ec2.RunInstancesOutput, error) {
+	start := time.Now()
+	out, err := p.runInstances.RunInstances(ctx, input)
+	if p.logger != nil {
+		logErr := err
+		if err != nil {
+			logErr = fmt.Errorf("in %s: %w", az, err)
+		}
+		p.logger.Log("ec2", "RunInstances", time.Since(start), logErr)
+	}
+	return out, err
 }
 
 // findPendingAttachVolume checks for a project EBS volume with the
@@ -779,15 +1583,16 @@ func (p *Provisioner) findPendingAttachVolume(ctx context.Context, owner, vmName
 	return aws.ToString(vol.VolumeId), aws.ToString(vol.AvailabilityZone), nil
 }
 
-// allocateAndAssociateEIP allocates an Elastic IP and associates it with the instance.
-func (p *Provisioner) allocateAndAssociateEIP(
-	ctx context.Context,
-	instanceID string,
-	owner, ownerARN, vmName string,
-) (allocID, publicIP string, err error) {
-	eipTags := tags.NewTagBuilder(owner, ownerARN, vmName).
+// allocateEIP allocates an Elastic IP tagged for owner's VM. A non-empty
+// expires is written as mint:expires.
+func (p *Provisioner) allocateEIP(ctx context.Context, owner, ownerARN, vmName, expires string) (allocID, publicIP string, err error) {
+	eipTags, err := tags.MergeExtra(tags.NewTagBuilder(owner, ownerARN, vmName).
 		WithComponent(tags.ComponentElasticIP).
-		Build()
+		WithExpires(expires).
+		Build(), p.extraTags)
+	if err != nil {
+		return "", "", err
+	}
 
 	aaStart := time.Now()
 	allocOut, err := p.allocateAddr.AllocateAddress(ctx, &ec2.AllocateAddressInput{
@@ -806,11 +1611,39 @@ func (p *Provisioner) allocateAndAssociateEIP(
 		return "", "", fmt.Errorf("allocate address: %w", err)
 	}
 
-	allocID = aws.ToString(allocOut.AllocationId)
-	publicIP = aws.ToString(allocOut.PublicIp)
+	return aws.ToString(allocOut.AllocationId), aws.ToString(allocOut.PublicIp), nil
+}
+
+// findFreeEIP returns the first Elastic IP tagged for owner's VM that is not
+// associated with anything, or empty strings when there is none. Tags are
+// checked again here in case the filters were not applied.
+func (p *Provisioner) findFreeEIP(ctx context.Context, owner, vmName string) (allocID, publicIP string, err error) {
+	out, err := p.describeAddrs.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: append(tags.FilterByOwnerAndVM(owner, vmName),
+			ec2types.Filter{Name: aws.String("tag:" + tags.TagComponent), Values: []string{tags.ComponentElasticIP}},
+		),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("describe addresses: %w", err)
+	}
+	for _, addr := range out.Addresses {
+		addrTags := tags.ToMap(addr.Tags)
+		if addrTags[tags.TagOwner] != owner || addrTags[tags.TagVM] != vmName ||
+			addrTags[tags.TagComponent] != tags.ComponentElasticIP {
+			continue
+		}
+		if aws.ToString(addr.AssociationId) != "" || aws.ToString(addr.InstanceId) != "" {
+			continue
+		}
+		return aws.ToString(addr.AllocationId), aws.ToString(addr.PublicIp), nil
+	}
+	return "", "", nil
+}
 
+// associateEIP associates the Elastic IP allocID with the instance.
+func (p *Provisioner) associateEIP(ctx context.Context, allocID, instanceID string) error {
 	assocStart := time.Now()
-	_, err = p.associateAddr.AssociateAddress(ctx, &ec2.AssociateAddressInput{
+	_, err := p.associateAddr.AssociateAddress(ctx, &ec2.AssociateAddressInput{
 		AllocationId: aws.String(allocID),
 		InstanceId:   aws.String(instanceID),
 	})
@@ -818,8 +1651,7 @@ func (p *Provisioner) allocateAndAssociateEIP(
 		p.logger.Log("ec2", "AssociateAddress", time.Since(assocStart), err)
 	}
 	if err != nil {
-		return "", "", fmt.Errorf("associate address %s to %s: %w", allocID, instanceID, err)
+		return fmt.Errorf("associate address %s to %s: %w", allocID, instanceID, err)
 	}
-
-	return allocID, publicIP, nil
+	return nil
 }
@@ -3,7 +3,9 @@ package provision
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"slices"
 	"io"
 	"strings"
 	"sync"
@@ -13,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
 	"github.com/SpiceLabsHQ/Mint/internal/bootstrap"
 	"github.com/SpiceLabsHQ/Mint/internal/hint"
@@ -26,9 +29,13 @@ export MINT_EFS_ID="__MINT_EFS_ID__"
 export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
 export MINT_VM_NAME="__MINT_VM_NAME__"
 export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
 export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
 _STUB_URL="__MINT_BOOTSTRAP_URL__"
 _STUB_SHA256="__MINT_BOOTSTRAP_SHA256__"
+_STUB_INLINE="__MINT_BOOTSTRAP_INLINE__"
 exec /tmp/bootstrap.sh
 `
 
@@ -72,11 +79,28 @@ type mockRunInstances struct {
 	err    error
 	called bool
 	input  *ec2.RunInstancesInput
+	// errs, when set, are returned by successive calls before err.
+	errs []error
+	// markets and subnets record each call's InstanceMarketOptions and
+	// SubnetId.
+	markets []*ec2types.InstanceMarketOptionsRequest
+	subnets []string
 }
 
 func (m *mockRunInstances) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
 	m.called = true
 	m.input = params
+	m.markets = append(m.markets, params.InstanceMarketOptions)
+	subnet := aws.ToString(params.SubnetId)
+	if len(params.NetworkInterfaces) > 0 {
+		subnet = aws.ToString(params.NetworkInterfaces[0].SubnetId)
+	}
+	m.subnets = append(m.subnets, subnet)
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return m.output, err
+	}
 	return m.output, m.err
 }
 
@@ -84,11 +108,13 @@ type mockUpDescribeSecurityGroups struct {
 	outputs []*ec2.DescribeSecurityGroupsOutput
 	errs    []error
 	calls   int
+	input   *ec2.DescribeSecurityGroupsInput
 }
 
 func (m *mockUpDescribeSecurityGroups) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
 	idx := m.calls
 	m.calls++
+	m.input = params
 	if idx < len(m.outputs) {
 		var err error
 		if idx < len(m.errs) {
@@ -102,9 +128,11 @@ func (m *mockUpDescribeSecurityGroups) DescribeSecurityGroups(ctx context.Contex
 type mockUpDescribeSubnets struct {
 	output *ec2.DescribeSubnetsOutput
 	err    error
+	input  *ec2.DescribeSubnetsInput
 }
 
 func (m *mockUpDescribeSubnets) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	m.input = params
 	return m.output, m.err
 }
 
@@ -138,10 +166,12 @@ type mockUpAllocateAddress struct {
 	output *ec2.AllocateAddressOutput
 	err    error
 	called bool
+	input  *ec2.AllocateAddressInput
 }
 
 func (m *mockUpAllocateAddress) AllocateAddress(ctx context.Context, params *ec2.AllocateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AllocateAddressOutput, error) {
 	m.called = true
+	m.input = params
 	return m.output, m.err
 }
 
@@ -171,10 +201,12 @@ type mockUpCreateTags struct {
 	output *ec2.CreateTagsOutput
 	err    error
 	called bool
+	input  *ec2.CreateTagsInput
 }
 
 func (m *mockUpCreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
 	m.called = true
+	m.input = params
 	return m.output, m.err
 }
 
@@ -326,28 +358,35 @@ func newUpHappyMocks() *upMocks {
 	}
 }
 
-func (m *upMocks) build() *Provisioner {
-	p := NewProvisioner(
-		m.describeInstances,
-		m.startInstances,
-		m.runInstances,
-		m.describeSGs,
-		m.describeSubnets,
-		m.createVolume,
-		m.attachVolume,
-		m.allocateAddr,
-		m.associateAddr,
-		m.describeAddrs,
-		m.createTags,
-		m.describeImages,
-	)
-	p.WithBootstrapVerifier(m.bootstrapVerifier)
-	p.WithAMIResolver(m.amiResolver)
+// build returns a Provisioner over the mocks with opts applied after the
+// mocks' own verifier, AMI resolver, and optional clients.
+func (m *upMocks) build(opts ...Option) *Provisioner {
+	mockOpts := []Option{
+		WithBootstrapVerifier(m.bootstrapVerifier),
+		WithAMIResolver(m.amiResolver),
+	}
 	if m.describeVolumes != nil {
-		p.WithDescribeVolumes(m.describeVolumes)
+		mockOpts = append(mockOpts, WithDescribeVolumes(m.describeVolumes))
 	}
 	if m.deleteTags != nil {
-		p.WithDeleteTags(m.deleteTags)
+		mockOpts = append(mockOpts, WithDeleteTags(m.deleteTags))
+	}
+	p, err := NewProvisioner(Clients{
+		DescribeInstances: m.describeInstances,
+		StartInstances:    m.startInstances,
+		RunInstances:      m.runInstances,
+		DescribeSGs:       m.describeSGs,
+		DescribeSubnets:   m.describeSubnets,
+		CreateVolume:      m.createVolume,
+		AttachVolume:      m.attachVolume,
+		AllocateAddr:      m.allocateAddr,
+		AssociateAddr:     m.associateAddr,
+		DescribeAddrs:     m.describeAddrs,
+		CreateTags:        m.createTags,
+		DescribeImages:    m.describeImages,
+	}, append(mockOpts, opts...)...)
+	if err != nil {
+		panic(err)
 	}
 	return p
 }
@@ -404,6 +443,76 @@ func TestProvisionerRestartStoppedVM(t *testing.T) {
 	}
 }
 
+func TestProvisionerRestartReallocatesReleasedEIP(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:   aws.String("i-stopped1"),
+				InstanceType: ec2types.InstanceTypeM6iXlarge,
+				State: &ec2types.InstanceState{
+					Name: ec2types.InstanceStateNameStopped,
+				},
+				Tags: []ec2types.Tag{
+					{Key: aws.String("mint:vm"), Value: aws.String("default")},
+					{Key: aws.String("mint:owner"), Value: aws.String("alice")},
+					{Key: aws.String(tags.TagEIP), Value: aws.String(tags.EIPReleasedByGC)},
+				},
+			}},
+		}},
+	}
+	p := m.build()
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !m.allocateAddr.called {
+		t.Fatal("AllocateAddress should be called when mint gc released the EIP")
+	}
+	if got := aws.ToString(m.associateAddr.input.InstanceId); got != "i-stopped1" {
+		t.Errorf("AssociateAddress instance = %q, want i-stopped1", got)
+	}
+	if !m.deleteTags.called || aws.ToString(m.deleteTags.input.Tags[0].Key) != tags.TagEIP {
+		t.Errorf("mint:eip tag should be removed, DeleteTags input = %+v", m.deleteTags.input)
+	}
+	if !result.Restarted || !result.EIPReallocated {
+		t.Errorf("result = %+v, want Restarted and EIPReallocated", result)
+	}
+	if result.PublicIP != "54.1.2.3" || result.AllocationID != "eipalloc-new1" {
+		t.Errorf("result IP = %q (%s), want the new EIP", result.PublicIP, result.AllocationID)
+	}
+}
+
+func TestProvisionerRestartKeepsEIPWithoutGCTag(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:      aws.String("i-stopped1"),
+				State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+				PublicIpAddress: aws.String("54.0.0.1"),
+				Tags: []ec2types.Tag{
+					{Key: aws.String("mint:vm"), Value: aws.String("default")},
+					{Key: aws.String("mint:owner"), Value: aws.String("alice")},
+				},
+			}},
+		}},
+	}
+
+	result, err := m.build().Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.allocateAddr.called || result.EIPReallocated {
+		t.Error("a restarted VM that kept its EIP should not get a new one")
+	}
+	if result.PublicIP != "54.0.0.1" {
+		t.Errorf("result.PublicIP = %q, want 54.0.0.1", result.PublicIP)
+	}
+}
+
 func TestProvisionerExistingRunningVM(t *testing.T) {
 	m := newUpHappyMocks()
 	m.describeInstances.output = &ec2.DescribeInstancesOutput{
@@ -585,6 +694,137 @@ func TestProvisionerBootstrapVerificationFailure(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Tests: Instance type check
+// ---------------------------------------------------------------------------
+
+func TestProvisionerInstanceTypeCheckFailsBeforeSlowSteps(t *testing.T) {
+	m := newUpHappyMocks()
+	amiResolved := false
+	m.amiResolver = func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+		amiResolved = true
+		return "ami-test123", nil
+	}
+	var checked string
+	p := m.build(WithInstanceTypeCheck(func(ctx context.Context, instanceType string) (string, error) {
+		checked = instanceType
+		return "", fmt.Errorf("unknown instance type m6i.xlrage in us-east-1 — did you mean m6i.xlarge?")
+	}))
+
+	cfg := defaultConfig()
+	cfg.InstanceType = "m6i.xlrage"
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err == nil || !strings.Contains(err.Error(), "did you mean m6i.xlarge?") {
+		t.Fatalf("error = %v, want the typo suggestion", err)
+	}
+	if checked != "m6i.xlrage" {
+		t.Errorf("checked %q, want the configured type", checked)
+	}
+	if amiResolved || m.runInstances.called {
+		t.Error("provisioning continued past a failed instance type check")
+	}
+}
+
+func TestProvisionerInstanceTypeWarningInResult(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build(WithInstanceTypeCheck(func(ctx context.Context, instanceType string) (string, error) {
+		return "instance type m4.xlarge is previous-generation — consider m6i.xlarge", nil
+	}))
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result.InstanceTypeWarning, "consider m6i.xlarge") {
+		t.Errorf("InstanceTypeWarning = %q", result.InstanceTypeWarning)
+	}
+}
+
+func TestProvisionerInstanceTypeCheckSkippedForExistingVM(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:   aws.String("i-existing"),
+				InstanceType: ec2types.InstanceTypeM4Xlarge,
+				State: &ec2types.InstanceState{
+					Name: ec2types.InstanceStateNameRunning,
+				},
+				Tags: []ec2types.Tag{
+					{Key: aws.String("mint:vm"), Value: aws.String("default")},
+					{Key: aws.String("mint:owner"), Value: aws.String("alice")},
+				},
+			}},
+		}},
+	}
+	called := false
+	p := m.build(WithInstanceTypeCheck(func(ctx context.Context, instanceType string) (string, error) {
+		called = true
+		return "", fmt.Errorf("should not be called")
+	}))
+
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called {
+		t.Error("instance type checked for a VM that already exists")
+	}
+}
+
+// offeredIn returns an offering check that offers every type in the given
+// AZs only, suggesting m6i.2xlarge elsewhere.
+func offeredIn(azs ...string) InstanceTypeOfferingFunc {
+	return func(ctx context.Context, instanceType, az string) error {
+		if slices.Contains(azs, az) {
+			return nil
+		}
+		return &mintaws.InstanceTypeNotOfferedError{InstanceType: instanceType, Location: az, Suggestions: []string{"m6i.2xlarge"}}
+	}
+}
+
+func TestProvisionerSkipsAZsThatDoNotOfferType(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = threeAZSubnets()
+	p := m.build(WithInstanceTypeOffering(offeredIn("us-east-1c")))
+
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"subnet-c"}; !slices.Equal(m.runInstances.subnets, want) {
+		t.Errorf("launched in subnets %v, want %v", m.runInstances.subnets, want)
+	}
+}
+
+func TestProvisionerTypeNotOfferedFailsBeforeLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = threeAZSubnets()
+	p := m.build(WithInstanceTypeOffering(offeredIn()))
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	want := "instance type m6i.xlarge is not offered in us-east-1a, us-east-1b, us-east-1c — did you mean m6i.2xlarge?"
+	if err == nil || err.Error() != want {
+		t.Fatalf("error = %v, want %q", err, want)
+	}
+	if m.runInstances.called || m.allocateAddr.called {
+		t.Error("provisioning continued past an instance type no AZ offers")
+	}
+}
+
+func TestProvisionerOfferingCheckError(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build(WithInstanceTypeOffering(func(ctx context.Context, instanceType, az string) error {
+		return fmt.Errorf("UnauthorizedOperation")
+	}))
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "checking instance type offerings in us-east-1a: UnauthorizedOperation") {
+		t.Fatalf("error = %v", err)
+	}
+	if m.runInstances.called {
+		t.Error("launched after the offering check failed")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: EIP quota exceeded
 // ---------------------------------------------------------------------------
@@ -622,6 +862,68 @@ func TestProvisionerEIPQuotaExceeded(t *testing.T) {
 	}
 }
 
+func TestProvisionerReusesFreeEIP(t *testing.T) {
+	eipTags := tags.NewTagBuilder("alice", "", "default").WithComponent(tags.ComponentElasticIP).Build()
+	tests := []struct {
+		name      string
+		kept      ec2types.Address
+		wantReuse bool
+	}{
+		{
+			name:      "free EIP tagged for the VM",
+			kept:      ec2types.Address{AllocationId: aws.String("eipalloc-kept"), PublicIp: aws.String("54.9.9.9"), Tags: eipTags},
+			wantReuse: true,
+		},
+		{
+			name: "EIP associated with another instance",
+			kept: ec2types.Address{AllocationId: aws.String("eipalloc-kept"), PublicIp: aws.String("54.9.9.9"), Tags: eipTags,
+				AssociationId: aws.String("eipassoc-1"), InstanceId: aws.String("i-other")},
+		},
+		{
+			name: "free EIP of another VM",
+			kept: ec2types.Address{AllocationId: aws.String("eipalloc-kept"), PublicIp: aws.String("54.9.9.9"),
+				Tags: tags.NewTagBuilder("alice", "", "other").WithComponent(tags.ComponentElasticIP).Build()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			// With the kept EIP the quota is full, so only reuse succeeds.
+			addrs := []ec2types.Address{tt.kept}
+			for i := 1; i < DefaultEIPLimit; i++ {
+				addrs = append(addrs, ec2types.Address{AllocationId: aws.String(fmt.Sprintf("eipalloc-%d", i))})
+			}
+			m.describeAddrs.output = &ec2.DescribeAddressesOutput{Addresses: addrs}
+			p := m.build()
+
+			result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+			if !tt.wantReuse {
+				if err == nil || !strings.Contains(err.Error(), "EIP quota exceeded") {
+					t.Fatalf("error = %v, want EIP quota exceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.allocateAddr.called {
+				t.Error("AllocateAddress should not be called when an EIP is reused")
+			}
+			if got := aws.ToString(m.associateAddr.input.AllocationId); got != "eipalloc-kept" {
+				t.Errorf("associated %q, want eipalloc-kept", got)
+			}
+			if !result.EIPReused || result.AllocationID != "eipalloc-kept" || result.PublicIP != "54.9.9.9" {
+				t.Errorf("result = reused %v, %s %s, want reused eipalloc-kept 54.9.9.9",
+					result.EIPReused, result.AllocationID, result.PublicIP)
+			}
+			del := m.deleteTags.input
+			if del == nil || del.Resources[0] != "eipalloc-kept" || aws.ToString(del.Tags[0].Key) != tags.TagRetained {
+				t.Errorf("DeleteTags input = %+v, want %s removed from eipalloc-kept", del, tags.TagRetained)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: AMI resolution failure
 // ---------------------------------------------------------------------------
@@ -724,8 +1026,10 @@ func TestProvisionerNoSubnetsFound(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error when no subnets found")
 	}
-	if !strings.Contains(err.Error(), "no default subnets") {
-		t.Errorf("error = %q, want substring %q", err.Error(), "no default subnets")
+	for _, want := range []string{"no default subnets", "mint config set subnet_id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want substring %q", err.Error(), want)
+		}
 	}
 }
 
@@ -743,6 +1047,116 @@ func TestProvisionerSubnetDescribeError(t *testing.T) {
 	}
 }
 
+// customNetworkMocks returns happy mocks for an account whose subnet
+// subnet-0c0ffee1 is in vpc-0c0ffee1 in us-east-1b.
+func customNetworkMocks(groups ...ec2types.SecurityGroup) *upMocks {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{{
+		SubnetId:         aws.String("subnet-0c0ffee1"),
+		VpcId:            aws.String("vpc-0c0ffee1"),
+		AvailabilityZone: aws.String("us-east-1b"),
+	}}}
+	m.describeSGs = &mockUpDescribeSecurityGroups{
+		outputs: []*ec2.DescribeSecurityGroupsOutput{{SecurityGroups: groups}},
+	}
+	return m
+}
+
+func TestProvisionerConfiguredNetwork(t *testing.T) {
+	m := customNetworkMocks(
+		ec2types.SecurityGroup{GroupId: aws.String("sg-0c0ffee1"), VpcId: aws.String("vpc-0c0ffee1")},
+		ec2types.SecurityGroup{GroupId: aws.String("sg-0c0ffee2"), VpcId: aws.String("vpc-0c0ffee1")},
+	)
+	cfg := defaultConfig()
+	cfg.SubnetID = "subnet-0c0ffee1"
+	cfg.SecurityGroupIDs = []string{"sg-0c0ffee1", "sg-0c0ffee2"}
+	cfg.VPCID = "vpc-0c0ffee1"
+
+	if _, err := m.build().Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := m.describeSubnets.input.SubnetIds; !slices.Equal(got, []string{"subnet-0c0ffee1"}) {
+		t.Errorf("DescribeSubnets SubnetIds = %v, want the configured subnet only", got)
+	}
+	if m.describeSGs.calls != 1 || !slices.Equal(m.describeSGs.input.GroupIds, cfg.SecurityGroupIDs) {
+		t.Errorf("DescribeSecurityGroups calls = %d, input = %+v; want one lookup of the configured groups", m.describeSGs.calls, m.describeSGs.input)
+	}
+	input := m.runInstances.input
+	if aws.ToString(input.SubnetId) != "subnet-0c0ffee1" || !slices.Equal(input.SecurityGroupIds, cfg.SecurityGroupIDs) {
+		t.Errorf("RunInstances SubnetId = %q, SecurityGroupIds = %v", aws.ToString(input.SubnetId), input.SecurityGroupIds)
+	}
+}
+
+func TestProvisionerConfiguredNetworkErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []ec2types.SecurityGroup
+		cfg    func(*ProvisionConfig)
+		want   string
+	}{
+		{
+			name:   "group in another VPC",
+			groups: []ec2types.SecurityGroup{{GroupId: aws.String("sg-0c0ffee1"), VpcId: aws.String("vpc-0bad0001")}},
+			cfg: func(c *ProvisionConfig) {
+				c.SubnetID = "subnet-0c0ffee1"
+				c.SecurityGroupIDs = []string{"sg-0c0ffee1"}
+			},
+			want: "security groups sg-0c0ffee1 (vpc-0bad0001) are not in vpc-0c0ffee1, the VPC of subnet subnet-0c0ffee1",
+		},
+		{
+			name: "subnet outside vpc_id",
+			cfg: func(c *ProvisionConfig) {
+				c.SubnetID = "subnet-0c0ffee1"
+				c.VPCID = "vpc-0bad0001"
+			},
+			want: "subnet subnet-0c0ffee1 is in vpc-0c0ffee1, not vpc-0bad0001",
+		},
+		{
+			name: "subnet without IPv6",
+			cfg: func(c *ProvisionConfig) {
+				c.SubnetID = "subnet-0c0ffee1"
+				c.IPMode = tags.IPModeDualStack
+			},
+			want: "subnet_id subnet-0c0ffee1 has none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := customNetworkMocks(tt.groups...)
+			if len(tt.groups) == 0 {
+				m.describeSGs = newUpHappyMocks().describeSGs
+			}
+			cfg := defaultConfig()
+			tt.cfg(&cfg)
+			_, err := m.build().Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+			if m.runInstances.called {
+				t.Error("RunInstances was called")
+			}
+		})
+	}
+}
+
+func TestProvisionerVPCIDDiscovery(t *testing.T) {
+	m := customNetworkMocks()
+	m.describeSGs = newUpHappyMocks().describeSGs
+	cfg := defaultConfig()
+	cfg.VPCID = "vpc-0c0ffee1"
+
+	if _, err := m.build().Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filters := m.describeSubnets.input.Filters
+	if len(filters) != 1 || aws.ToString(filters[0].Name) != "vpc-id" || filters[0].Values[0] != "vpc-0c0ffee1" {
+		t.Errorf("DescribeSubnets filters = %+v, want vpc-id=vpc-0c0ffee1 only", filters)
+	}
+	if aws.ToString(m.runInstances.input.SubnetId) != "subnet-0c0ffee1" {
+		t.Errorf("SubnetId = %q, want subnet-0c0ffee1", aws.ToString(m.runInstances.input.SubnetId))
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: Launch instance errors
 // ---------------------------------------------------------------------------
@@ -809,63 +1223,241 @@ func TestProvisionerAssociateAddressError(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Tests: VM discovery error
-// ---------------------------------------------------------------------------
-
-func TestProvisionerDiscoverVMError(t *testing.T) {
+func TestProvisionerVolumeTagErrorKeepsAllocation(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
 	m := newUpHappyMocks()
-	m.describeInstances.err = fmt.Errorf("describe instances throttled")
-	p := m.build()
+	m.createTags.err = fmt.Errorf("tag limit exceeded")
+	p := m.build(WithJournal(store))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
-	if err == nil {
-		t.Fatal("expected error on DescribeInstances failure")
+	if err == nil || !strings.Contains(err.Error(), "tagging project volume: tagging volume vol-proj1: tag limit exceeded") {
+		t.Fatalf("Run() error = %v, want the tagging error", err)
 	}
-	if !strings.Contains(err.Error(), "discovering VM") {
-		t.Errorf("error = %q, want substring %q", err.Error(), "discovering VM")
+	// The Elastic IP branch ran to completion; its allocation is recorded
+	// but the journal does not claim the volume is ready.
+	j, _ := store.Load("alice", "default")
+	if j == nil || j.Step != StepLaunched || j.AllocationID != "eipalloc-new1" {
+		t.Errorf("journal = %+v, want step %s with the allocation", j, StepLaunched)
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Tests: Start stopped VM error
-// ---------------------------------------------------------------------------
+// fakeEIPWaiter is a WaitEIPAssociatedAPI that records its call and
+// reports waited and err.
+type fakeEIPWaiter struct {
+	waited time.Duration
+	err    error
 
-func TestProvisionerStartStoppedVMError(t *testing.T) {
+	called                   bool
+	allocationID, instanceID string
+}
+
+func (f *fakeEIPWaiter) Wait(ctx context.Context, allocationID, instanceID string, maxWaitDur time.Duration) (time.Duration, error) {
+	f.called = true
+	f.allocationID, f.instanceID = allocationID, instanceID
+	return f.waited, f.err
+}
+
+func TestProvisionerWaitsForEIPPropagation(t *testing.T) {
+	m := newUpHappyMocks()
+	waiter := &fakeEIPWaiter{waited: 7 * time.Second}
+	p := m.build(WithWaitEIPAssociated(waiter))
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !waiter.called || waiter.allocationID != "eipalloc-new1" || waiter.instanceID != "i-new123" {
+		t.Errorf("waiter = %+v, want a wait for eipalloc-new1 on i-new123", waiter)
+	}
+	if result.EIPPropagation != 7*time.Second {
+		t.Errorf("EIPPropagation = %v, want 7s", result.EIPPropagation)
+	}
+}
+
+func TestProvisionerEIPPropagationTimeoutFailsRun(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	waiter := &fakeEIPWaiter{err: &mintaws.EIPPropagationError{
+		AllocationID: "eipalloc-new1", InstanceID: "i-new123", Waited: 2 * time.Minute,
+		Reason: "DescribeAddresses shows it associated with no instance",
+	}}
+	p := m.build(WithJournal(store), WithWaitEIPAssociated(waiter))
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if !mintaws.IsEIPPropagationError(err) {
+		t.Fatalf("Run() error = %v, want the propagation error", err)
+	}
+	// The allocation is recorded so the next run re-associates it.
+	j, _ := store.Load("alice", "default")
+	if j == nil || j.AllocationID != "eipalloc-new1" {
+		t.Errorf("journal = %+v, want the allocation recorded", j)
+	}
+}
+
+func TestProvisionerRestartWaitsForReallocatedEIP(t *testing.T) {
 	m := newUpHappyMocks()
 	m.describeInstances.output = &ec2.DescribeInstancesOutput{
 		Reservations: []ec2types.Reservation{{
 			Instances: []ec2types.Instance{{
 				InstanceId:   aws.String("i-stopped1"),
 				InstanceType: ec2types.InstanceTypeM6iXlarge,
-				State: &ec2types.InstanceState{
-					Name: ec2types.InstanceStateNameStopped,
-				},
+				State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
 				Tags: []ec2types.Tag{
 					{Key: aws.String("mint:vm"), Value: aws.String("default")},
 					{Key: aws.String("mint:owner"), Value: aws.String("alice")},
+					{Key: aws.String(tags.TagEIP), Value: aws.String(tags.EIPReleasedByGC)},
 				},
 			}},
 		}},
 	}
-	m.startInstances.err = fmt.Errorf("cannot start instance")
-	p := m.build()
+	waiter := &fakeEIPWaiter{waited: 3 * time.Second}
+	p := m.build(WithWaitEIPAssociated(waiter))
 
-	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
-	if err == nil {
-		t.Fatal("expected error on StartInstances failure")
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
 	}
-	if !strings.Contains(err.Error(), "cannot start instance") {
-		t.Errorf("error = %q, want substring %q", err.Error(), "cannot start instance")
+	if waiter.instanceID != "i-stopped1" || result.EIPPropagation != 3*time.Second {
+		t.Errorf("waiter = %+v, EIPPropagation = %v, want a 3s wait on i-stopped1", waiter, result.EIPPropagation)
 	}
 }
 
 // ---------------------------------------------------------------------------
-// Tests: Default volume size
+// Tests: concurrent steps after launch
 // ---------------------------------------------------------------------------
 
-func TestProvisionerDefaultVolumeSize(t *testing.T) {
-	m := newUpHappyMocks()
+// delayedAssociate is an AssociateAddress mock that takes delay to answer
+// and records when it was called.
+type delayedAssociate struct {
+	mockUpAssociateAddress
+	delay time.Duration
+
+	mu       sync.Mutex
+	start    time.Time
+	finished time.Time
+}
+
+func (m *delayedAssociate) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	m.mu.Lock()
+	m.start = time.Now()
+	m.mu.Unlock()
+	time.Sleep(m.delay)
+	m.mu.Lock()
+	m.finished = time.Now()
+	m.mu.Unlock()
+	return m.mockUpAssociateAddress.AssociateAddress(ctx, params, optFns...)
+}
+
+// delayedCreateTags is a CreateTags mock that takes delay to answer and
+// records when it was called.
+type delayedCreateTags struct {
+	mockUpCreateTags
+	delay time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+}
+
+func (m *delayedCreateTags) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	m.mu.Lock()
+	m.start = time.Now()
+	m.mu.Unlock()
+	time.Sleep(m.delay)
+	return m.mockUpCreateTags.CreateTags(ctx, params, optFns...)
+}
+
+func TestProvisionerStepsAfterLaunchOverlap(t *testing.T) {
+	const delay = 2 * time.Second
+	m := newUpHappyMocks()
+	associate := &delayedAssociate{mockUpAssociateAddress: *m.associateAddr, delay: delay}
+	createTags := &delayedCreateTags{mockUpCreateTags: *m.createTags, delay: delay}
+	var pollStart time.Time
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+		pollStart = time.Now()
+		return nil
+	}))
+	p.associateAddr = associate
+	p.createTags = createTags
+
+	start := time.Now()
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.PublicIP != "54.1.2.3" || result.VolumeID != "vol-proj1" {
+		t.Errorf("result = %+v", result)
+	}
+
+	// In sequence the two delays add up; run concurrently they overlap.
+	if elapsed >= 2*delay-delay/4 {
+		t.Errorf("Run took %v, want well under %v: volume tagging and the Elastic IP did not overlap", elapsed, 2*delay)
+	}
+	if d := associate.start.Sub(createTags.start); d > delay/2 || d < -delay/2 {
+		t.Errorf("AssociateAddress and CreateTags started %v apart, want together", d)
+	}
+	if pollStart.IsZero() || !pollStart.Before(associate.finished) {
+		t.Errorf("bootstrap polling started at %v, want before AssociateAddress finished at %v", pollStart, associate.finished)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: VM discovery error
+// ---------------------------------------------------------------------------
+
+func TestProvisionerDiscoverVMError(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.err = fmt.Errorf("describe instances throttled")
+	p := m.build()
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected error on DescribeInstances failure")
+	}
+	if !strings.Contains(err.Error(), "discovering VM") {
+		t.Errorf("error = %q, want substring %q", err.Error(), "discovering VM")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: Start stopped VM error
+// ---------------------------------------------------------------------------
+
+func TestProvisionerStartStoppedVMError(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeInstances.output = &ec2.DescribeInstancesOutput{
+		Reservations: []ec2types.Reservation{{
+			Instances: []ec2types.Instance{{
+				InstanceId:   aws.String("i-stopped1"),
+				InstanceType: ec2types.InstanceTypeM6iXlarge,
+				State: &ec2types.InstanceState{
+					Name: ec2types.InstanceStateNameStopped,
+				},
+				Tags: []ec2types.Tag{
+					{Key: aws.String("mint:vm"), Value: aws.String("default")},
+					{Key: aws.String("mint:owner"), Value: aws.String("alice")},
+				},
+			}},
+		}},
+	}
+	m.startInstances.err = fmt.Errorf("cannot start instance")
+	p := m.build()
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected error on StartInstances failure")
+	}
+	if !strings.Contains(err.Error(), "cannot start instance") {
+		t.Errorf("error = %q, want substring %q", err.Error(), "cannot start instance")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: Default volume size
+// ---------------------------------------------------------------------------
+
+func TestProvisionerDefaultVolumeSize(t *testing.T) {
+	m := newUpHappyMocks()
 	p := m.build()
 
 	cfg := defaultConfig()
@@ -904,6 +1496,84 @@ func TestProvisionerCustomVolumeSize(t *testing.T) {
 	}
 }
 
+func TestProvisionerEncryptsVolumes(t *testing.T) {
+	tests := []struct {
+		name     string
+		kmsKeyID string
+	}{
+		{"default key", ""},
+		{"customer key", "alias/mint-volumes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.KMSKeyID = tt.kmsKeyID
+
+			if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			bdms := m.runInstances.input.BlockDeviceMappings
+			if len(bdms) != 2 {
+				t.Fatalf("expected root and project BlockDeviceMappings, got %d", len(bdms))
+			}
+			for _, bdm := range bdms {
+				if !aws.ToBool(bdm.Ebs.Encrypted) {
+					t.Errorf("%s: Encrypted = false, want true", aws.ToString(bdm.DeviceName))
+				}
+				if got := aws.ToString(bdm.Ebs.KmsKeyId); got != tt.kmsKeyID {
+					t.Errorf("%s: KmsKeyId = %q, want %q", aws.ToString(bdm.DeviceName), got, tt.kmsKeyID)
+				}
+				if tt.kmsKeyID == "" && bdm.Ebs.KmsKeyId != nil {
+					t.Errorf("%s: KmsKeyId should be unset to use the default key", aws.ToString(bdm.DeviceName))
+				}
+			}
+		})
+	}
+}
+
+func TestProvisionerInstanceProfileAndSSHPort(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		port        int
+		wantProfile string
+		wantPort    string
+	}{
+		{"defaults", "", 0, "mint-instance-profile", "41122"},
+		{"configured", "team-mint-profile", 2222, "team-mint-profile", "2222"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.InstanceProfile = tt.profile
+			cfg.SSHPort = tt.port
+
+			if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			input := m.runInstances.input
+			if got := aws.ToString(input.IamInstanceProfile.Name); got != tt.wantProfile {
+				t.Errorf("IamInstanceProfile.Name = %q, want %q", got, tt.wantProfile)
+			}
+			userData, err := base64.StdEncoding.DecodeString(aws.ToString(input.UserData))
+			if err != nil {
+				t.Fatalf("UserData is not valid base64: %v", err)
+			}
+			if want := `MINT_SSH_PORT="` + tt.wantPort + `"`; !strings.Contains(string(userData), want) {
+				t.Errorf("UserData missing %s:\n%s", want, userData)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: Instance tagging
 // ---------------------------------------------------------------------------
@@ -912,7 +1582,10 @@ func TestProvisionerInstanceTags(t *testing.T) {
 	m := newUpHappyMocks()
 	p := m.build()
 
-	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	cfg := defaultConfig()
+	cfg.SSHUser = "ubuntu"
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -942,6 +1615,7 @@ func TestProvisionerInstanceTags(t *testing.T) {
 		tags.TagName:           "mint/alice/default",
 		tags.TagRootVolumeGB:   "200",
 		tags.TagProjectVolumeGB: "50",
+		tags.TagSSHUser:         "ubuntu",
 	}
 
 	for key, want := range assertions {
@@ -951,6 +1625,351 @@ func TestProvisionerInstanceTags(t *testing.T) {
 	}
 }
 
+func TestProvisionerExtraTags(t *testing.T) {
+	m := newUpHappyMocks()
+	extra := map[string]string{"CostCenter": "1234", "Team": "platform"}
+	p := m.build(WithExtraTags(extra))
+
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	volumeTags := tags.ToMap(m.createTags.input.Tags)
+	if volumeTags[tags.TagComponent] != tags.ComponentProjectVolume {
+		t.Fatalf("CreateTags tagged %v, want the BDM project volume", volumeTags)
+	}
+	eipTags := tags.ToMap(m.allocateAddr.input.TagSpecifications[0].Tags)
+	for name, got := range map[string]map[string]string{
+		"RunInstances":    instanceTagMap(m.runInstances.input),
+		"CreateTags":      volumeTags,
+		"AllocateAddress": eipTags,
+	} {
+		for key, want := range extra {
+			if got[key] != want {
+				t.Errorf("%s tag %q = %q, want %q", name, key, got[key], want)
+			}
+		}
+		if got[tags.TagOwner] != "alice" {
+			t.Errorf("%s lost mint:owner: %v", name, got)
+		}
+	}
+}
+
+func TestProvisionerExpiresTags(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.ExpiresAt = time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	if _, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, got := range map[string]map[string]string{
+		"RunInstances":    instanceTagMap(m.runInstances.input),
+		"CreateTags":      tags.ToMap(m.createTags.input.Tags),
+		"AllocateAddress": tags.ToMap(m.allocateAddr.input.TagSpecifications[0].Tags),
+	} {
+		if got[tags.TagExpires] != "2026-10-18T10:00:00Z" {
+			t.Errorf("%s %s = %q, want 2026-10-18T10:00:00Z", name, tags.TagExpires, got[tags.TagExpires])
+		}
+	}
+}
+
+func TestProvisionerNoExpiresByDefault(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	if _, err := p.Run(context.Background(), "alice", "", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := instanceTagMap(m.runInstances.input)[tags.TagExpires]; ok {
+		t.Errorf("instance tagged %s without an expiry", tags.TagExpires)
+	}
+}
+
+func TestProvisionerExtraTagsConflictFailsBeforeLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build(WithExtraTags(map[string]string{"mint:owner": "bob"}))
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "mint:owner") {
+		t.Fatalf("error = %v, want a mint:owner conflict", err)
+	}
+	if m.runInstances.input != nil {
+		t.Error("RunInstances was called despite the conflict")
+	}
+}
+
+func instanceTagMap(input *ec2.RunInstancesInput) map[string]string {
+	tagMap := make(map[string]string)
+	for _, tag := range input.TagSpecifications[0].Tags {
+		tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tagMap
+}
+
+func TestProvisionerSpotLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.Spot = true
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	market := m.runInstances.input.InstanceMarketOptions
+	if market == nil || market.MarketType != ec2types.MarketTypeSpot {
+		t.Fatalf("InstanceMarketOptions = %+v, want spot", market)
+	}
+	if market.SpotOptions.SpotInstanceType != ec2types.SpotInstanceTypePersistent ||
+		market.SpotOptions.InstanceInterruptionBehavior != ec2types.InstanceInterruptionBehaviorStop {
+		t.Errorf("SpotOptions = %+v, want persistent, stop on interruption", market.SpotOptions)
+	}
+	if got := instanceTagMap(m.runInstances.input)[tags.TagSpot]; got != "true" {
+		t.Errorf("tag %q = %q, want true", tags.TagSpot, got)
+	}
+	if !result.Spot || result.SpotWarning != "" {
+		t.Errorf("result Spot = %v, SpotWarning = %q, want spot without warning", result.Spot, result.SpotWarning)
+	}
+}
+
+func TestProvisionerOnDemandByDefault(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	if _, err := p.Run(context.Background(), "alice", "", "default", defaultConfig()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.runInstances.input.InstanceMarketOptions != nil {
+		t.Errorf("InstanceMarketOptions = %+v, want nil", m.runInstances.input.InstanceMarketOptions)
+	}
+	if _, ok := instanceTagMap(m.runInstances.input)[tags.TagSpot]; ok {
+		t.Errorf("on-demand instance tagged %s", tags.TagSpot)
+	}
+}
+
+// ipv6Subnets returns a DescribeSubnets output with one subnet without an
+// IPv6 CIDR block, then one with.
+func ipv6Subnets() *ec2.DescribeSubnetsOutput {
+	return &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+		{SubnetId: aws.String("subnet-v4"), AvailabilityZone: aws.String("us-east-1a")},
+		{
+			SubnetId:         aws.String("subnet-v6"),
+			AvailabilityZone: aws.String("us-east-1b"),
+			Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{{
+				Ipv6CidrBlock:      aws.String("2600:1f14::/64"),
+				Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeAssociated},
+			}},
+		},
+	}}
+}
+
+func TestProvisionerIPv6OnlyLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = ipv6Subnets()
+	m.runInstances.output.Instances[0].Ipv6Address = aws.String("2600:1f14::10")
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.IPMode = tags.IPModeIPv6Only
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input := m.runInstances.input
+	if input.SubnetId != nil || input.SecurityGroupIds != nil {
+		t.Errorf("SubnetId = %v, SecurityGroupIds = %v, want both on the network interface", input.SubnetId, input.SecurityGroupIds)
+	}
+	if len(input.NetworkInterfaces) != 1 {
+		t.Fatalf("NetworkInterfaces = %+v, want one", input.NetworkInterfaces)
+	}
+	nic := input.NetworkInterfaces[0]
+	if aws.ToString(nic.SubnetId) != "subnet-v6" || aws.ToInt32(nic.Ipv6AddressCount) != 1 {
+		t.Errorf("network interface = %+v, want one IPv6 address in subnet-v6", nic)
+	}
+	if nic.AssociatePublicIpAddress == nil || *nic.AssociatePublicIpAddress {
+		t.Error("ipv6-only network interface should not get a public IPv4 address")
+	}
+	if got := instanceTagMap(input)[tags.TagIPMode]; got != tags.IPModeIPv6Only {
+		t.Errorf("tag %q = %q, want %q", tags.TagIPMode, got, tags.IPModeIPv6Only)
+	}
+	if m.allocateAddr.called || m.associateAddr.called {
+		t.Error("ipv6-only launch should not allocate or associate an Elastic IP")
+	}
+	if result.PublicIP != "2600:1f14::10" || result.IPv6Address != "2600:1f14::10" || result.AllocationID != "" {
+		t.Errorf("result PublicIP = %q, IPv6Address = %q, AllocationID = %q", result.PublicIP, result.IPv6Address, result.AllocationID)
+	}
+}
+
+func TestProvisionerDualStackLaunch(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = ipv6Subnets()
+	m.runInstances.output.Instances[0].Ipv6Address = aws.String("2600:1f14::11")
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.IPMode = tags.IPModeDualStack
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	nic := m.runInstances.input.NetworkInterfaces[0]
+	if nic.AssociatePublicIpAddress != nil {
+		t.Error("dualstack network interface should keep the subnet's public IPv4 setting")
+	}
+	if !m.allocateAddr.called || !m.associateAddr.called {
+		t.Error("dualstack launch should allocate and associate an Elastic IP")
+	}
+	if result.PublicIP != "54.1.2.3" || result.IPv6Address != "2600:1f14::11" {
+		t.Errorf("result PublicIP = %q, IPv6Address = %q", result.PublicIP, result.IPv6Address)
+	}
+}
+
+// describeLaunchedIPv6 reports no VM to the lookup before launch and the
+// launched instance, with its IPv6 address, to a lookup by instance ID.
+type describeLaunchedIPv6 struct {
+	instanceID, ipv6 string
+}
+
+func (m *describeLaunchedIPv6) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	for _, f := range params.Filters {
+		if aws.ToString(f.Name) == "instance-id" && len(f.Values) == 1 && f.Values[0] == m.instanceID {
+			return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{
+				InstanceId:  aws.String(m.instanceID),
+				State:       &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Ipv6Address: aws.String(m.ipv6),
+			}}}}}, nil
+		}
+	}
+	return &ec2.DescribeInstancesOutput{}, nil
+}
+
+// associateSignal closes associated once the Elastic IP is associated.
+type associateSignal struct {
+	next       mintaws.AssociateAddressAPI
+	associated chan struct{}
+}
+
+func (m *associateSignal) AssociateAddress(ctx context.Context, params *ec2.AssociateAddressInput, optFns ...func(*ec2.Options)) (*ec2.AssociateAddressOutput, error) {
+	defer close(m.associated)
+	return m.next.AssociateAddress(ctx, params, optFns...)
+}
+
+// tagAfterAssociate holds the project volume's tagging until the Elastic IP
+// is associated.
+type tagAfterAssociate struct {
+	next       mintaws.CreateTagsAPI
+	associated chan struct{}
+}
+
+func (m *tagAfterAssociate) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	<-m.associated
+	return m.next.CreateTags(ctx, params, optFns...)
+}
+
+// TestProvisionerDualStackLooksUpIPv6WithJournal covers a dual-stack launch
+// whose RunInstances response lacks the IPv6 address: the address is looked
+// up while the Elastic IP goroutine saves its allocation to the journal. The
+// volume is tagged only after the association, so that save falls between
+// the lookup and the volume being recorded. Run it with -race; the launch is
+// repeated because the race detector only sees the interleavings a run
+// happens to take.
+func TestProvisionerDualStackLooksUpIPv6WithJournal(t *testing.T) {
+	for range 50 {
+		store := NewJournalStore(t.TempDir())
+		m := newUpHappyMocks()
+		m.describeSubnets.output = ipv6Subnets()
+		instanceID := aws.ToString(m.runInstances.output.Instances[0].InstanceId)
+		p := m.build(WithJournal(store))
+		p.describeInstances = &describeLaunchedIPv6{instanceID: instanceID, ipv6: "2600:1f14::12"}
+		associated := make(chan struct{})
+		p.associateAddr = &associateSignal{next: m.associateAddr, associated: associated}
+		p.createTags = &tagAfterAssociate{next: m.createTags, associated: associated}
+
+		cfg := defaultConfig()
+		cfg.IPMode = tags.IPModeDualStack
+		result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.PublicIP != "54.1.2.3" || result.IPv6Address != "2600:1f14::12" {
+			t.Fatalf("result PublicIP = %q, IPv6Address = %q", result.PublicIP, result.IPv6Address)
+		}
+	}
+}
+
+func TestProvisionerIPv6WithoutIPv6Subnet(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.IPMode = tags.IPModeIPv6Only
+	_, err := p.Run(context.Background(), "alice", "", "default", cfg)
+	if err == nil {
+		t.Fatal("expected error when no subnet has an IPv6 CIDR block")
+	}
+	if !strings.Contains(err.Error(), "IPv6 CIDR block") {
+		t.Errorf("error %q does not explain the missing IPv6 CIDR block", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances should not be called")
+	}
+}
+
+func TestProvisionerSpotFallback(t *testing.T) {
+	for _, code := range []string{"SpotMaxPriceTooLow", "InsufficientInstanceCapacity"} {
+		t.Run(code, func(t *testing.T) {
+			m := newUpHappyMocks()
+			m.runInstances.errs = []error{&smithy.GenericAPIError{Code: code, Message: "no capacity"}}
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.Spot = true
+			cfg.SpotFallback = true
+
+			result, err := p.Run(context.Background(), "alice", "", "default", cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.runInstances.markets) != 2 || m.runInstances.markets[0] == nil || m.runInstances.markets[1] != nil {
+				t.Fatalf("RunInstances markets = %v, want spot then on-demand", m.runInstances.markets)
+			}
+			if _, ok := instanceTagMap(m.runInstances.input)[tags.TagSpot]; ok {
+				t.Errorf("on-demand fallback tagged %s", tags.TagSpot)
+			}
+			if result.Spot {
+				t.Error("result.Spot = true after falling back to on-demand")
+			}
+			if !strings.Contains(result.SpotWarning, code) || !strings.Contains(result.SpotWarning, "on-demand") {
+				t.Errorf("SpotWarning = %q, want it to name %s and the on-demand launch", result.SpotWarning, code)
+			}
+		})
+	}
+}
+
+func TestProvisionerSpotCapacityErrorWithoutFallback(t *testing.T) {
+	m := newUpHappyMocks()
+	m.runInstances.errs = []error{&smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: "no capacity"}}
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.Spot = true
+
+	_, err := p.Run(context.Background(), "alice", "", "default", cfg)
+	if err == nil || !strings.Contains(err.Error(), "InsufficientInstanceCapacity") {
+		t.Fatalf("err = %v, want the spot capacity error", err)
+	}
+	if len(m.runInstances.markets) != 1 {
+		t.Errorf("RunInstances called %d times, want 1 (no fallback)", len(m.runInstances.markets))
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: EIP quota check error
 // ---------------------------------------------------------------------------
@@ -974,7 +1993,10 @@ echo done`)
 		"MINT_IDLE_TIMEOUT": "90",
 	}
 
-	result := InterpolateBootstrap(script, vars)
+	result, err := InterpolateBootstrap(script, vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	expected := `#!/bin/bash
 EFS_ID="fs-abc123"
@@ -1000,7 +2022,10 @@ BARE_KNOWN="${MINT_VM_NAME}"`)
 		"MINT_VM_NAME": "myvm",
 	}
 
-	result := InterpolateBootstrap(script, vars)
+	result, err := InterpolateBootstrap(script, vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	// Known variables should be replaced
 	if !strings.Contains(string(result), `KNOWN="fs-xyz"`) {
@@ -1027,13 +2052,45 @@ func TestInterpolateBootstrapHandlesBashDefaults(t *testing.T) {
 		"MINT_IDLE_TIMEOUT": "120",
 	}
 
-	result := InterpolateBootstrap(script, vars)
+	result, err := InterpolateBootstrap(script, vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
 	if !strings.Contains(string(result), `TIMEOUT="120"`) {
 		t.Errorf("expected bash default expression to be replaced, got:\n%s", string(result))
 	}
 }
 
+func TestInterpolateBootstrapRejectsUnreplacedMintVariables(t *testing.T) {
+	script := []byte(`#!/bin/bash
+EFS_ID="${MINT_EFS_IDD}"
+VM_NAME="${MINT_VM_NAME}"
+export PATH="${PATH}:/usr/local/bin"
+TIMEOUT="${MINT_IDLE_TIMOUT:-60}"`)
+	vars := map[string]string{
+		"MINT_EFS_ID":       "fs-xyz",
+		"MINT_VM_NAME":      "myvm",
+		"MINT_IDLE_TIMEOUT": "90",
+	}
+
+	result, err := InterpolateBootstrap(script, vars)
+	var unreplaced *UnreplacedVariablesError
+	if !errors.As(err, &unreplaced) {
+		t.Fatalf("InterpolateBootstrap() = %q, %v; want an *UnreplacedVariablesError", result, err)
+	}
+	want := []bootstrap.Unreplaced{
+		{Name: "${MINT_EFS_IDD}", Line: 2},
+		{Name: "${MINT_IDLE_TIMOUT:-60}", Line: 5},
+	}
+	if !slices.Equal(unreplaced.Vars, want) {
+		t.Errorf("Vars = %v, want %v", unreplaced.Vars, want)
+	}
+	if want := "no value for ${MINT_EFS_IDD} (line 2), ${MINT_IDLE_TIMOUT:-60} (line 5)"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
+
 func TestLaunchInstanceInterpolatesBootstrapScript(t *testing.T) {
 	m := newUpHappyMocks()
 	p := m.build()
@@ -1230,17 +2287,16 @@ func TestProvisionerRun(t *testing.T) {
 
 func TestProvisionerCallsPollOnFreshProvision(t *testing.T) {
 	m := newUpHappyMocks()
-	p := m.build()
 
 	pollCalled := false
 	var pollOwner, pollVM, pollInstance string
-	p.WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
 		pollCalled = true
 		pollOwner = owner
 		pollVM = vmName
 		pollInstance = instanceID
 		return nil
-	})
+	}))
 
 	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1266,12 +2322,11 @@ func TestProvisionerCallsPollOnFreshProvision(t *testing.T) {
 
 func TestProvisionerBootstrapPollFailureSetsBootstrapError(t *testing.T) {
 	m := newUpHappyMocks()
-	p := m.build()
 
 	pollErr := fmt.Errorf("bootstrap timed out")
-	p.WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
 		return pollErr
-	})
+	}))
 
 	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	// Run itself should succeed -- the instance exists.
@@ -1295,6 +2350,32 @@ func TestProvisionerBootstrapPollFailureSetsBootstrapError(t *testing.T) {
 	}
 }
 
+func TestProvisionerUserBootstrapFailureIsNotBootstrapError(t *testing.T) {
+	m := newUpHappyMocks()
+
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+		return &UserBootstrapError{InstanceID: instanceID, ExitCode: 3}
+	}))
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("Run should not return error on user hook failure, got: %v", err)
+	}
+
+	if result.BootstrapError != nil {
+		t.Errorf("BootstrapError should be nil when only the user hook failed, got: %v", result.BootstrapError)
+	}
+	if result.UserBootstrapError == nil {
+		t.Fatal("UserBootstrapError should be non-nil when the user hook failed")
+	}
+	if result.BootstrapStatus != tags.BootstrapComplete {
+		t.Errorf("BootstrapStatus = %q, want %q", result.BootstrapStatus, tags.BootstrapComplete)
+	}
+	if result.UserBootstrapStatus != "failed:3" {
+		t.Errorf("UserBootstrapStatus = %q, want %q", result.UserBootstrapStatus, "failed:3")
+	}
+}
+
 func TestProvisionerNoPollWhenPollerNil(t *testing.T) {
 	m := newUpHappyMocks()
 	p := m.build()
@@ -1328,13 +2409,12 @@ func TestProvisionerNoPollOnRestart(t *testing.T) {
 			}},
 		}},
 	}
-	p := m.build()
 
 	pollCalled := false
-	p.WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
 		pollCalled = true
 		return nil
-	})
+	}))
 
 	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1423,6 +2503,84 @@ func TestLaunchInstanceIncludesVolumeTagsCustom(t *testing.T) {
 	}
 }
 
+func TestLaunchInstanceUsesBootstrapSource(t *testing.T) {
+	manifestHash := strings.Repeat("ab", 32)
+	tests := []struct {
+		name      string
+		source    bootstrap.Source
+		wantHash  string
+		wantURL   string
+		wantLabel string
+	}{
+		{
+			name:      "embedded when unset",
+			wantHash:  bootstrap.ScriptSHA256,
+			wantURL:   "https://example.com/bootstrap.sh",
+			wantLabel: "embedded",
+		},
+		{
+			name:      "manifest",
+			source:    bootstrap.Source{SHA256: manifestHash, URL: "https://example.com/v12/bootstrap.sh", Label: "manifest v12"},
+			wantHash:  manifestHash,
+			wantURL:   "https://example.com/v12/bootstrap.sh",
+			wantLabel: "manifest v12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.BootstrapURL = "https://example.com/bootstrap.sh"
+			cfg.Bootstrap = tt.source
+
+			result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.BootstrapSource != tt.wantLabel {
+				t.Errorf("BootstrapSource = %q, want %q", result.BootstrapSource, tt.wantLabel)
+			}
+
+			ud, err := base64.StdEncoding.DecodeString(aws.ToString(m.runInstances.input.UserData))
+			if err != nil {
+				t.Fatalf("failed to decode UserData: %v", err)
+			}
+			for _, want := range []string{tt.wantHash, tt.wantURL} {
+				if !strings.Contains(string(ud), want) {
+					t.Errorf("UserData missing %q", want)
+				}
+			}
+
+			tagMap := make(map[string]string)
+			for _, tag := range m.runInstances.input.TagSpecifications[0].Tags {
+				tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			if got := tagMap[tags.TagBootstrapSource]; got != tt.wantLabel {
+				t.Errorf("tag %q = %q, want %q", tags.TagBootstrapSource, got, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestProvisionerRejectsMalformedBootstrapSource(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.Bootstrap = bootstrap.Source{SHA256: "not-a-hash", URL: "https://example.com/bootstrap.sh", Label: "manifest v3"}
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err == nil || !strings.Contains(err.Error(), "bootstrap verification failed") {
+		t.Fatalf("expected bootstrap verification error, got %v", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances should not be called with a malformed bootstrap hash")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: Pending-attach volume recovery
 // ---------------------------------------------------------------------------
@@ -1454,66 +2612,206 @@ func TestProvisionerPendingAttachHappyPath(t *testing.T) {
 		t.Errorf("result.VolumeID = %q, want %q", result.VolumeID, "vol-pending1")
 	}
 
-	// CreateVolume should NOT be called (volume already exists).
-	if m.createVolume.called {
-		t.Error("CreateVolume should NOT be called when pending-attach volume exists")
+	// CreateVolume should NOT be called (volume already exists).
+	if m.createVolume.called {
+		t.Error("CreateVolume should NOT be called when pending-attach volume exists")
+	}
+
+	// AttachVolume should be called with the pending volume.
+	if !m.attachVolume.called {
+		t.Fatal("AttachVolume was not called")
+	}
+	if aws.ToString(m.attachVolume.input.VolumeId) != "vol-pending1" {
+		t.Errorf("AttachVolume VolumeId = %q, want %q", aws.ToString(m.attachVolume.input.VolumeId), "vol-pending1")
+	}
+	if aws.ToString(m.attachVolume.input.Device) != "/dev/xvdf" {
+		t.Errorf("AttachVolume Device = %q, want %q", aws.ToString(m.attachVolume.input.Device), "/dev/xvdf")
+	}
+
+	// DeleteTags should be called to remove the pending-attach tag.
+	if !m.deleteTags.called {
+		t.Fatal("DeleteTags was not called to remove pending-attach tag")
+	}
+	if len(m.deleteTags.input.Resources) != 1 || m.deleteTags.input.Resources[0] != "vol-pending1" {
+		t.Errorf("DeleteTags resources = %v, want [vol-pending1]", m.deleteTags.input.Resources)
+	}
+	foundTag := false
+	for _, tag := range m.deleteTags.input.Tags {
+		if aws.ToString(tag.Key) == tags.TagPendingAttach {
+			foundTag = true
+		}
+	}
+	if !foundTag {
+		t.Error("DeleteTags did not include mint:pending-attach tag")
+	}
+}
+
+func TestProvisionerPendingAttachAZMismatch(t *testing.T) {
+	// When a pending-attach volume is in a different AZ than the instance,
+	// the provisioner should fail fast with guidance.
+	m := newUpHappyMocks()
+	m.describeVolumes = &mockUpDescribeVolumes{
+		output: &ec2.DescribeVolumesOutput{
+			Volumes: []ec2types.Volume{{
+				VolumeId:         aws.String("vol-wrongaz"),
+				AvailabilityZone: aws.String("us-west-2a"), // Different AZ
+			}},
+		},
+	}
+	p := m.build()
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected error for AZ mismatch")
+	}
+	if !strings.Contains(err.Error(), "AZ mismatch") {
+		t.Errorf("error = %q, want substring %q", err.Error(), "AZ mismatch")
+	}
+	if !strings.Contains(err.Error(), "mint destroy") {
+		t.Errorf("error should mention 'mint destroy', got: %q", err.Error())
+	}
+	if !strings.Contains(err.Error(), "vol-wrongaz") {
+		t.Errorf("error should include volume ID, got: %q", err.Error())
+	}
+}
+
+func TestProvisionerPendingAttachPrefersVolumeAZ(t *testing.T) {
+	// With several default subnets, the instance launches in the subnet that
+	// matches the pending-attach volume's AZ instead of the first one.
+	m := newUpHappyMocks()
+	m.describeSubnets.output = &ec2.DescribeSubnetsOutput{
+		Subnets: []ec2types.Subnet{
+			{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+			{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+		},
+	}
+	m.describeVolumes = &mockUpDescribeVolumes{
+		output: &ec2.DescribeVolumesOutput{
+			Volumes: []ec2types.Volume{{
+				VolumeId:         aws.String("vol-clone"),
+				AvailabilityZone: aws.String("us-east-1b"),
+			}},
+		},
+	}
+	m.deleteTags = &mockUpDeleteTags{}
+	p := m.build()
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := aws.ToString(m.runInstances.input.SubnetId); got != "subnet-b" {
+		t.Errorf("SubnetId = %q, want %q", got, "subnet-b")
+	}
+	if result.VolumeID != "vol-clone" {
+		t.Errorf("VolumeID = %q, want %q", result.VolumeID, "vol-clone")
+	}
+}
+
+// threeAZSubnets returns default subnets in us-east-1a, 1b, and 1c.
+func threeAZSubnets() *ec2.DescribeSubnetsOutput {
+	return &ec2.DescribeSubnetsOutput{
+		Subnets: []ec2types.Subnet{
+			{SubnetId: aws.String("subnet-a"), AvailabilityZone: aws.String("us-east-1a")},
+			{SubnetId: aws.String("subnet-b"), AvailabilityZone: aws.String("us-east-1b")},
+			{SubnetId: aws.String("subnet-c"), AvailabilityZone: aws.String("us-east-1c")},
+		},
+	}
+}
+
+func TestProvisionerRetriesLaunchInOtherAZs(t *testing.T) {
+	for _, code := range []string{"InsufficientInstanceCapacity", "Unsupported"} {
+		t.Run(code, func(t *testing.T) {
+			m := newUpHappyMocks()
+			m.describeSubnets.output = threeAZSubnets()
+			m.runInstances.errs = []error{&smithy.GenericAPIError{Code: code, Message: "not in this AZ"}}
+			logger := &mockLogger{}
+			p := m.build(WithLogger(logger))
+
+			if _, err := p.Run(context.Background(), "alice", "", "default", defaultConfig()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Join(m.runInstances.subnets, ","); got != "subnet-a,subnet-b" {
+				t.Errorf("RunInstances subnets = %s, want subnet-a,subnet-b", got)
+			}
+
+			var launches []mockLogEntry
+			for _, e := range logger.entries {
+				if e.operation == "RunInstances" {
+					launches = append(launches, e)
+				}
+			}
+			if len(launches) != 2 {
+				t.Fatalf("logged %d RunInstances calls, want 2", len(launches))
+			}
+			if launches[0].err == nil || !strings.Contains(launches[0].err.Error(), "us-east-1a") {
+				t.Errorf("first attempt logged err = %v, want the us-east-1a failure", launches[0].err)
+			}
+			if launches[1].err != nil {
+				t.Errorf("second attempt logged err = %v, want nil", launches[1].err)
+			}
+		})
 	}
+}
 
-	// AttachVolume should be called with the pending volume.
-	if !m.attachVolume.called {
-		t.Fatal("AttachVolume was not called")
+func TestProvisionerLaunchCapacityExhausted(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = threeAZSubnets()
+	m.runInstances.err = &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: "no capacity"}
+	p := m.build()
+
+	_, err := p.Run(context.Background(), "alice", "", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected an error when every AZ is out of capacity")
 	}
-	if aws.ToString(m.attachVolume.input.VolumeId) != "vol-pending1" {
-		t.Errorf("AttachVolume VolumeId = %q, want %q", aws.ToString(m.attachVolume.input.VolumeId), "vol-pending1")
+	if len(m.runInstances.subnets) != 3 {
+		t.Errorf("RunInstances called %d times, want 3", len(m.runInstances.subnets))
 	}
-	if aws.ToString(m.attachVolume.input.Device) != "/dev/xvdf" {
-		t.Errorf("AttachVolume Device = %q, want %q", aws.ToString(m.attachVolume.input.Device), "/dev/xvdf")
+	if !strings.Contains(err.Error(), "us-east-1a, us-east-1b, us-east-1c") {
+		t.Errorf("error = %q, want the AZs tried", err)
 	}
+}
 
-	// DeleteTags should be called to remove the pending-attach tag.
-	if !m.deleteTags.called {
-		t.Fatal("DeleteTags was not called to remove pending-attach tag")
-	}
-	if len(m.deleteTags.input.Resources) != 1 || m.deleteTags.input.Resources[0] != "vol-pending1" {
-		t.Errorf("DeleteTags resources = %v, want [vol-pending1]", m.deleteTags.input.Resources)
-	}
-	foundTag := false
-	for _, tag := range m.deleteTags.input.Tags {
-		if aws.ToString(tag.Key) == tags.TagPendingAttach {
-			foundTag = true
-		}
+func TestProvisionerLaunchOtherErrorDoesNotRetry(t *testing.T) {
+	m := newUpHappyMocks()
+	m.describeSubnets.output = threeAZSubnets()
+	m.runInstances.err = &smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: "denied"}
+	p := m.build()
+
+	if _, err := p.Run(context.Background(), "alice", "", "default", defaultConfig()); err == nil {
+		t.Fatal("expected an error")
 	}
-	if !foundTag {
-		t.Error("DeleteTags did not include mint:pending-attach tag")
+	if len(m.runInstances.subnets) != 1 {
+		t.Errorf("RunInstances called %d times, want 1", len(m.runInstances.subnets))
 	}
 }
 
-func TestProvisionerPendingAttachAZMismatch(t *testing.T) {
-	// When a pending-attach volume is in a different AZ than the instance,
-	// the provisioner should fail fast with guidance.
+func TestProvisionerPendingAttachPinsLaunchAZ(t *testing.T) {
+	// A pending-attach volume can only be attached in its own AZ, so a
+	// capacity failure there is not retried elsewhere.
 	m := newUpHappyMocks()
+	m.describeSubnets.output = threeAZSubnets()
 	m.describeVolumes = &mockUpDescribeVolumes{
 		output: &ec2.DescribeVolumesOutput{
 			Volumes: []ec2types.Volume{{
-				VolumeId:         aws.String("vol-wrongaz"),
-				AvailabilityZone: aws.String("us-west-2a"), // Different AZ
+				VolumeId:         aws.String("vol-pinned"),
+				AvailabilityZone: aws.String("us-east-1b"),
 			}},
 		},
 	}
+	m.deleteTags = &mockUpDeleteTags{}
+	m.runInstances.err = &smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: "no capacity"}
 	p := m.build()
 
-	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	_, err := p.Run(context.Background(), "alice", "", "default", defaultConfig())
 	if err == nil {
-		t.Fatal("expected error for AZ mismatch")
-	}
-	if !strings.Contains(err.Error(), "AZ mismatch") {
-		t.Errorf("error = %q, want substring %q", err.Error(), "AZ mismatch")
+		t.Fatal("expected an error")
 	}
-	if !strings.Contains(err.Error(), "mint destroy") {
-		t.Errorf("error should mention 'mint destroy', got: %q", err.Error())
+	if got := strings.Join(m.runInstances.subnets, ","); got != "subnet-b" {
+		t.Errorf("RunInstances subnets = %s, want subnet-b only", got)
 	}
-	if !strings.Contains(err.Error(), "vol-wrongaz") {
-		t.Errorf("error should include volume ID, got: %q", err.Error())
+	if !strings.Contains(err.Error(), "in us-east-1b:") {
+		t.Errorf("error = %q, want it to name us-east-1b", err)
 	}
 }
 
@@ -1746,6 +3044,61 @@ func TestProvisionerMaxVolumeIOPS(t *testing.T) {
 	}
 }
 
+func TestProvisionerVolumeThroughput(t *testing.T) {
+	tests := []struct {
+		name       string
+		iops       int32
+		throughput int32
+		want       int32
+		wantErr    string
+	}{
+		{name: "default", throughput: 0, want: 125},
+		{name: "raised", iops: 6000, throughput: 500, want: 500},
+		{name: "maximum", iops: 4000, throughput: 1000, want: 1000},
+		{name: "too high for the IOPS", iops: 3000, throughput: 800, wantErr: "at most 750 MB/s"},
+		{name: "above gp3 range", iops: 16000, throughput: 1001, wantErr: "gp3 allows 125-1000 MB/s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.VolumeIOPS = tt.iops
+			cfg.VolumeThroughput = tt.throughput
+
+			_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if m.runInstances.called {
+					t.Error("RunInstances called despite the invalid throughput")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			input := m.runInstances.input
+			if got := aws.ToInt32(input.BlockDeviceMappings[1].Ebs.Throughput); got != tt.want {
+				t.Errorf("BDM Throughput = %d, want %d", got, tt.want)
+			}
+			want := fmt.Sprint(tt.want)
+			found := false
+			for _, tag := range input.TagSpecifications[0].Tags {
+				if aws.ToString(tag.Key) == tags.TagProjectVolumeThroughput {
+					found = aws.ToString(tag.Value) == want
+				}
+			}
+			if !found {
+				t.Errorf("instance not tagged %s=%s", tags.TagProjectVolumeThroughput, want)
+			}
+		})
+	}
+}
+
 func TestProvisionerNoPollOnRunningVM(t *testing.T) {
 	m := newUpHappyMocks()
 	m.describeInstances.output = &ec2.DescribeInstancesOutput{
@@ -1764,13 +3117,12 @@ func TestProvisionerNoPollOnRunningVM(t *testing.T) {
 			}},
 		}},
 	}
-	p := m.build()
 
 	pollCalled := false
-	p.WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
+	p := m.build(WithBootstrapPollFunc(func(ctx context.Context, owner, vmName, instanceID string) error {
 		pollCalled = true
 		return nil
-	})
+	}))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1828,10 +3180,8 @@ func (l *mockLogger) findEntry(operation string) (mockLogEntry, bool) {
 
 func TestProvisionerWithLoggerLogsRunInstances(t *testing.T) {
 	m := newUpHappyMocks()
-	p := m.build()
-
 	logger := &mockLogger{}
-	p.WithLogger(logger)
+	p := m.build(WithLogger(logger))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1855,10 +3205,8 @@ func TestProvisionerWithLoggerLogsRunInstances(t *testing.T) {
 
 func TestProvisionerWithLoggerLogsAllocateAddress(t *testing.T) {
 	m := newUpHappyMocks()
-	p := m.build()
-
 	logger := &mockLogger{}
-	p.WithLogger(logger)
+	p := m.build(WithLogger(logger))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1876,10 +3224,8 @@ func TestProvisionerWithLoggerLogsAllocateAddress(t *testing.T) {
 
 func TestProvisionerWithLoggerLogsAssociateAddress(t *testing.T) {
 	m := newUpHappyMocks()
-	p := m.build()
-
 	logger := &mockLogger{}
-	p.WithLogger(logger)
+	p := m.build(WithLogger(logger))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err != nil {
@@ -1898,10 +3244,8 @@ func TestProvisionerWithLoggerLogsAssociateAddress(t *testing.T) {
 func TestProvisionerWithLoggerLogsErrorOnRunInstancesFailure(t *testing.T) {
 	m := newUpHappyMocks()
 	m.runInstances.err = fmt.Errorf("insufficient capacity")
-	p := m.build()
-
 	logger := &mockLogger{}
-	p.WithLogger(logger)
+	p := m.build(WithLogger(logger))
 
 	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
 	if err == nil {
@@ -2239,6 +3583,193 @@ func TestPendingAttachNoneForAlreadyRunningVM(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Tests: GPU instance types
+// ---------------------------------------------------------------------------
+
+func TestProvisionerGPUInstanceType(t *testing.T) {
+	image := func(arch ec2types.ArchitectureValues, name string) *ec2.DescribeImagesOutput {
+		return &ec2.DescribeImagesOutput{Images: []ec2types.Image{
+			{ImageId: aws.String("ami-ubuntu2404"), Architecture: arch, Name: aws.String(name)},
+		}}
+	}
+	ubuntu := "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-20260218"
+	tests := []struct {
+		name         string
+		instanceType string
+		images       *ec2.DescribeImagesOutput
+		wantErr      string
+		wantGPU      string
+	}{
+		{
+			name:         "GPU type installs the driver",
+			instanceType: "g5.xlarge",
+			images:       image(ec2types.ArchitectureValuesX8664, ubuntu),
+			wantGPU:      `MINT_GPU="true"`,
+		},
+		{
+			name:         "GPU type with an arm64 AMI fails before launch",
+			instanceType: "g4dn.xlarge",
+			images:       image(ec2types.ArchitectureValuesArm64, "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-arm64-server-20260218"),
+			wantErr:      "GPU instance type g4dn.xlarge needs an x86_64 Ubuntu AMI",
+		},
+		{
+			name:         "non-GPU type does not look up the AMI",
+			instanceType: "m6i.xlarge",
+			images:       &ec2.DescribeImagesOutput{},
+			wantGPU:      `MINT_GPU="false"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			m.describeImages.output = tt.images
+			p := m.build()
+
+			cfg := defaultConfig()
+			cfg.InstanceType = tt.instanceType
+			_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if m.runInstances.called {
+					t.Error("RunInstances called after a failed GPU image check")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ud, err := base64.StdEncoding.DecodeString(aws.ToString(m.runInstances.input.UserData))
+			if err != nil {
+				t.Fatalf("UserData is not valid base64: %v", err)
+			}
+			if !strings.Contains(string(ud), tt.wantGPU) {
+				t.Errorf("UserData missing %s:\n%s", tt.wantGPU, ud)
+			}
+		})
+	}
+}
+
+// mockDescribeInstanceTypes implements mintaws.DescribeInstanceTypesAPI.
+type mockDescribeInstanceTypes struct {
+	output *ec2.DescribeInstanceTypesOutput
+	err    error
+}
+
+func (m *mockDescribeInstanceTypes) DescribeInstanceTypes(ctx context.Context, params *ec2.DescribeInstanceTypesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
+	return m.output, m.err
+}
+
+func TestProvisionerHibernate(t *testing.T) {
+	types := &mockDescribeInstanceTypes{output: &ec2.DescribeInstanceTypesOutput{
+		InstanceTypes: []ec2types.InstanceTypeInfo{
+			{
+				InstanceType:         ec2types.InstanceTypeM6iXlarge,
+				HibernationSupported: aws.Bool(true),
+				MemoryInfo:           &ec2types.MemoryInfo{SizeInMiB: aws.Int64(16384)},
+			},
+			{
+				InstanceType:         ec2types.InstanceTypeP4d24xlarge,
+				HibernationSupported: aws.Bool(false),
+				MemoryInfo:           &ec2types.MemoryInfo{SizeInMiB: aws.Int64(1179648)},
+			},
+		},
+	}}
+	tests := []struct {
+		name         string
+		instanceType string
+		hibernate    bool
+		wantErr      string
+		wantRootGB   int32
+	}{
+		{
+			name:         "hibernation-enabled launch sizes the root volume to RAM",
+			instanceType: "m6i.xlarge",
+			hibernate:    true,
+			wantRootGB:   216,
+		},
+		{
+			name:         "unsupported type fails before launch",
+			instanceType: "p4d.24xlarge",
+			hibernate:    true,
+			wantErr:      "hibernation is not supported by instance type(s) p4d.24xlarge",
+		},
+		{
+			name:         "hibernate off keeps the default root volume",
+			instanceType: "m6i.xlarge",
+			wantRootGB:   200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newUpHappyMocks()
+			p := m.build(WithDescribeInstanceTypes(types))
+
+			cfg := defaultConfig()
+			cfg.InstanceType = tt.instanceType
+			cfg.Hibernate = tt.hibernate
+			_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				var unsupported *mintaws.UnsupportedHibernationError
+				if !errors.As(err, &unsupported) {
+					t.Errorf("error = %T, want *UnsupportedHibernationError", err)
+				}
+				if m.runInstances.called {
+					t.Error("RunInstances called for a type that cannot hibernate")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			input := m.runInstances.input
+			if tt.hibernate {
+				if input.HibernationOptions == nil || !aws.ToBool(input.HibernationOptions.Configured) {
+					t.Errorf("HibernationOptions = %+v, want Configured true", input.HibernationOptions)
+				}
+			} else if input.HibernationOptions != nil {
+				t.Errorf("HibernationOptions = %+v, want nil", input.HibernationOptions)
+			}
+			root := input.BlockDeviceMappings[0]
+			if aws.ToString(root.DeviceName) != "/dev/sda1" {
+				t.Fatalf("first block device = %s, want /dev/sda1", aws.ToString(root.DeviceName))
+			}
+			if got := aws.ToInt32(root.Ebs.VolumeSize); got != tt.wantRootGB {
+				t.Errorf("root VolumeSize = %d, want %d", got, tt.wantRootGB)
+			}
+			if !aws.ToBool(root.Ebs.Encrypted) {
+				t.Error("root volume not encrypted")
+			}
+			tagMap := make(map[string]string)
+			for _, tag := range input.TagSpecifications[0].Tags {
+				tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			if got, want := tagMap[tags.TagRootVolumeGB], fmt.Sprint(tt.wantRootGB); got != want {
+				t.Errorf("tag %s = %q, want %q", tags.TagRootVolumeGB, got, want)
+			}
+		})
+	}
+}
+
+func TestProvisionerHibernateNeedsInstanceTypesClient(t *testing.T) {
+	m := newUpHappyMocks()
+	cfg := defaultConfig()
+	cfg.Hibernate = true
+	_, err := m.build().Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err == nil || !strings.Contains(err.Error(), "no DescribeInstanceTypes client") {
+		t.Fatalf("error = %v, want missing client", err)
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances called without a hibernation check")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: UserBootstrapScript pipeline
 // ---------------------------------------------------------------------------
@@ -2350,3 +3881,153 @@ func TestUserBootstrapScriptTooLargeReturnsError(t *testing.T) {
 		t.Error("RunInstances should NOT be called when user-data exceeds the size limit")
 	}
 }
+
+// TestInlineBootstrapTooLargeSuggestsMirror verifies that an embedded
+// bootstrap.sh that pushes user-data over the limit reports the overage and
+// points at bootstrap_url.
+func TestInlineBootstrapTooLargeSuggestsMirror(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.Bootstrap = bootstrap.Source{
+		SHA256: strings.Repeat("ab", 32),
+		URL:    "https://example.com/bootstrap.sh",
+		Inline: strings.Repeat("A", bootstrap.MaxUserDataBytes),
+		Label:  "manifest v12",
+	}
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	var sizeErr *bootstrap.UserDataSizeError
+	if !errors.As(err, &sizeErr) {
+		t.Fatalf("error = %v, want a *bootstrap.UserDataSizeError", err)
+	}
+	for _, want := range []string{"bootstrap file too large to embed", "bytes over limit", "bootstrap_url"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want substring %q", err.Error(), want)
+		}
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances should NOT be called when user-data exceeds the size limit")
+	}
+}
+
+// TestStubPlaceholderDriftReturnsOutOfSyncError verifies that a stub with a
+// placeholder RenderStub does not fill stops the launch with an error that
+// tells the user the CLI and stub disagree.
+func TestStubPlaceholderDriftReturnsOutOfSyncError(t *testing.T) {
+	bootstrap.SetStub([]byte(testStubTemplate + "export MINT_REGION=\"__MINT_REGION__\"\n"))
+	defer bootstrap.SetStub([]byte(testStubTemplate))
+
+	m := newUpHappyMocks()
+	resolved := false
+	m.amiResolver = func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+		resolved = true
+		return "ami-test", nil
+	}
+	p := m.build()
+
+	_, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", defaultConfig())
+	if err == nil {
+		t.Fatal("expected error for a drifted stub, got nil")
+	}
+	for _, want := range []string{"out of sync", "__MINT_REGION__"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %q, want substring %q", err.Error(), want)
+		}
+	}
+	if m.runInstances.called {
+		t.Error("RunInstances should NOT be called with a drifted stub")
+	}
+	if !strings.HasPrefix(err.Error(), "bootstrap rendering failed: ") {
+		t.Errorf("error = %q, want it to start with %q", err, "bootstrap rendering failed: ")
+	}
+	if resolved {
+		t.Error("the AMI should NOT be resolved with a drifted stub")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tests: image prefetch
+// ---------------------------------------------------------------------------
+
+func TestProvisionerRendersPrefetchImages(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	cfg := defaultConfig()
+	cfg.PrefetchImages = []string{"node:22", "postgres:16"}
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(m.runInstances.input.UserData))
+	if err != nil {
+		t.Fatalf("failed to decode UserData: %v", err)
+	}
+	if !strings.Contains(string(ud), `MINT_PREFETCH_IMAGES="node:22 postgres:16"`) {
+		t.Errorf("UserData missing prefetch list:\n%s", ud)
+	}
+	if result.PrefetchQueued != 2 || result.PrefetchDropped != 0 {
+		t.Errorf("PrefetchQueued/Dropped = %d/%d, want 2/0", result.PrefetchQueued, result.PrefetchDropped)
+	}
+}
+
+func TestProvisionerJournalsPrefetchImages(t *testing.T) {
+	store := NewJournalStore(t.TempDir())
+	m := newUpHappyMocks()
+	p := m.build(WithJournal(store))
+	// Interrupt during the launch so the journal is kept for inspection.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.runInstances = &cancelingRunInstances{next: m.runInstances, cancel: cancel}
+
+	cfg := defaultConfig()
+	cfg.PrefetchImages = []string{"node:22"}
+	if _, err := p.Run(ctx, "alice", "arn:aws:iam::123:user/alice", "default", cfg); err == nil {
+		t.Fatal("expected an interrupt")
+	}
+
+	j, err := store.Load("alice", "default")
+	if err != nil || j == nil {
+		t.Fatalf("Load() = %v, %v", j, err)
+	}
+	if len(j.PrefetchImages) != 1 || j.PrefetchImages[0] != "node:22" {
+		t.Errorf("journal PrefetchImages = %v, want [node:22]", j.PrefetchImages)
+	}
+}
+
+func TestProvisionerTruncatesPrefetchImagesToUserDataLimit(t *testing.T) {
+	m := newUpHappyMocks()
+	p := m.build()
+
+	// Each image is 1000 bytes, so only a handful fit in 16KB.
+	var images []string
+	for i := 0; i < 30; i++ {
+		images = append(images, fmt.Sprintf("registry.example.com/%02d/%s:latest", i, strings.Repeat("x", 1000-len("registry.example.com/00/:latest"))))
+	}
+	cfg := defaultConfig()
+	cfg.PrefetchImages = images
+
+	result, err := p.Run(context.Background(), "alice", "arn:aws:iam::123:user/alice", "default", cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ud, err := base64.StdEncoding.DecodeString(aws.ToString(m.runInstances.input.UserData))
+	if err != nil {
+		t.Fatalf("failed to decode UserData: %v", err)
+	}
+	if len(ud) > bootstrap.MaxUserDataBytes {
+		t.Errorf("user-data is %d bytes, over the %d limit", len(ud), bootstrap.MaxUserDataBytes)
+	}
+	if result.PrefetchQueued == 0 || result.PrefetchQueued+result.PrefetchDropped != len(images) {
+		t.Errorf("PrefetchQueued/Dropped = %d/%d, want a split of %d", result.PrefetchQueued, result.PrefetchDropped, len(images))
+	}
+	// The most recently built images (first in the list) are kept.
+	if !strings.Contains(string(ud), images[0]) || strings.Contains(string(ud), images[len(images)-1]) {
+		t.Error("truncation should keep the head of the list and drop the tail")
+	}
+}
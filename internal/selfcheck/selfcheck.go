@@ -0,0 +1,96 @@
+// Package selfcheck detects when mint is running on the EC2 instance it is
+// about to act on. Mutating commands use it to avoid stopping or terminating
+// the machine that hosts the user's own shell.
+package selfcheck
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// ProbeTimeout caps the IMDS lookup so hosts outside EC2 never notice it.
+const ProbeTimeout = 100 * time.Millisecond
+
+// MetadataAPI is the subset of the IMDS client used to read the local
+// instance ID. Satisfied by *imds.Client.
+type MetadataAPI interface {
+	GetMetadata(ctx context.Context, params *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error)
+}
+
+// Compile-time check: imds.Client satisfies the interface.
+var _ MetadataAPI = (*imds.Client)(nil)
+
+// Detector looks up the local instance ID at most once and caches the result.
+type Detector struct {
+	client MetadataAPI
+
+	once       sync.Once
+	instanceID string
+}
+
+// NewDetector creates a Detector backed by the given IMDS client.
+func NewDetector(client MetadataAPI) *Detector {
+	return &Detector{client: client}
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultDetector *Detector
+)
+
+// Default returns the process-wide Detector backed by the real IMDS client.
+// Retries are disabled; ProbeTimeout bounds the single attempt.
+func Default() *Detector {
+	defaultOnce.Do(func() {
+		defaultDetector = NewDetector(imds.New(imds.Options{
+			Retryer: aws.NopRetryer{},
+		}))
+	})
+	return defaultDetector
+}
+
+// LocalInstanceID returns the ID of the EC2 instance this process runs on, or
+// "" when IMDS is unreachable or returns anything unexpected. Errors fail
+// open. The first call probes IMDS; later calls return the cached value.
+func (d *Detector) LocalInstanceID(ctx context.Context) string {
+	d.once.Do(func() {
+		d.instanceID = d.probe(ctx)
+	})
+	return d.instanceID
+}
+
+// IsLocalInstance reports whether instanceID is the instance this process
+// runs on.
+func (d *Detector) IsLocalInstance(ctx context.Context, instanceID string) bool {
+	if instanceID == "" {
+		return false
+	}
+	return d.LocalInstanceID(ctx) == instanceID
+}
+
+func (d *Detector) probe(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	out, err := d.client.GetMetadata(ctx, &imds.GetMetadataInput{Path: "instance-id"})
+	if err != nil || out == nil || out.Content == nil {
+		return ""
+	}
+	defer out.Content.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Content, 64))
+	if err != nil {
+		return ""
+	}
+	id := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(id, "i-") {
+		return ""
+	}
+	return id
+}
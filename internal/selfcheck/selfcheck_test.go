@@ -0,0 +1,85 @@
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+type fakeIMDS struct {
+	body  string
+	err   error
+	block bool
+	calls int
+}
+
+func (f *fakeIMDS) GetMetadata(ctx context.Context, params *imds.GetMetadataInput, optFns ...func(*imds.Options)) (*imds.GetMetadataOutput, error) {
+	f.calls++
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &imds.GetMetadataOutput{Content: io.NopCloser(strings.NewReader(f.body))}, nil
+}
+
+func TestLocalInstanceID(t *testing.T) {
+	tests := []struct {
+		name string
+		imds *fakeIMDS
+		want string
+	}{
+		{name: "on EC2", imds: &fakeIMDS{body: "i-0abc123\n"}, want: "i-0abc123"},
+		{name: "IMDS error fails open", imds: &fakeIMDS{err: errors.New("connection refused")}, want: ""},
+		{name: "unexpected body", imds: &fakeIMDS{body: "<html>captive portal</html>"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(tt.imds)
+			if got := d.LocalInstanceID(context.Background()); got != tt.want {
+				t.Errorf("LocalInstanceID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalInstanceIDIsCached(t *testing.T) {
+	f := &fakeIMDS{body: "i-0abc123"}
+	d := NewDetector(f)
+	d.LocalInstanceID(context.Background())
+	d.LocalInstanceID(context.Background())
+	if f.calls != 1 {
+		t.Errorf("GetMetadata called %d times, want 1", f.calls)
+	}
+}
+
+func TestLocalInstanceIDTimeout(t *testing.T) {
+	d := NewDetector(&fakeIMDS{block: true})
+	start := time.Now()
+	if got := d.LocalInstanceID(context.Background()); got != "" {
+		t.Errorf("LocalInstanceID() = %q, want empty on timeout", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*ProbeTimeout {
+		t.Errorf("probe took %v, want about %v", elapsed, ProbeTimeout)
+	}
+}
+
+func TestIsLocalInstance(t *testing.T) {
+	d := NewDetector(&fakeIMDS{body: "i-0abc123"})
+	if !d.IsLocalInstance(context.Background(), "i-0abc123") {
+		t.Error("expected match for local instance ID")
+	}
+	if d.IsLocalInstance(context.Background(), "i-other") {
+		t.Error("expected no match for a different instance ID")
+	}
+	if d.IsLocalInstance(context.Background(), "") {
+		t.Error("expected no match for empty instance ID")
+	}
+}
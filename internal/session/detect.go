@@ -1,6 +1,7 @@
 // Package session implements idle detection criteria from ADR-0018.
 // It checks for active SSH/mosh sessions, tmux clients, claude processes
-// in containers, and manual extend timestamps on a remote VM.
+// in containers, and manual extend timestamps on a remote VM, and reads
+// the guard files automation writes to protect unattended jobs.
 package session
 
 import (
@@ -22,32 +23,40 @@ type RemoteExecutor func(ctx context.Context, command []string) ([]byte, error)
 
 // ActiveSessions holds the results of all four ADR-0018 idle detection
 // criteria. Each field is populated independently; any non-empty field
-// indicates activity.
+// indicates activity. It is also the session report in --json output.
 type ActiveSessions struct {
 	// TmuxClients contains the formatted tmux client list, or empty if
 	// no attached clients were found.
-	TmuxClients string
+	TmuxClients string `json:"tmux_clients"`
 
 	// SSHConnections contains the formatted `who` output, or empty if
 	// no active SSH/mosh connections were found.
-	SSHConnections string
+	SSHConnections string `json:"ssh_connections"`
 
 	// ClaudeProcesses contains formatted docker top matches for claude
 	// processes running inside containers, or empty if none found.
-	ClaudeProcesses string
+	ClaudeProcesses string `json:"claude_processes"`
 
 	// ExtendedUntil is the manual extend timestamp if it is still in the
 	// future, or nil if no extend is active.
-	ExtendedUntil *time.Time
+	ExtendedUntil *time.Time `json:"extended_until,omitempty"`
+
+	// Guards are the unexpired automation guards in GuardDir.
+	Guards []Guard `json:"guards,omitempty"`
+
+	// GuardNotes explain guards that were ignored because they expired or
+	// could not be read. They do not indicate activity.
+	GuardNotes []string `json:"guard_notes,omitempty"`
 }
 
 // HasActivity returns true if any of the four ADR-0018 criteria indicate
-// the VM is actively in use.
+// the VM is actively in use, or an automation guard is active.
 func (a *ActiveSessions) HasActivity() bool {
 	return a.TmuxClients != "" ||
 		a.SSHConnections != "" ||
 		a.ClaudeProcesses != "" ||
-		a.ExtendedUntil != nil
+		a.ExtendedUntil != nil ||
+		len(a.Guards) > 0
 }
 
 // Summary returns a formatted multi-line summary of all active session
@@ -68,6 +77,9 @@ func (a *ActiveSessions) Summary() string {
 	if a.ExtendedUntil != nil {
 		parts = append(parts, fmt.Sprintf("  Manual extend active until %s", a.ExtendedUntil.Format(time.RFC3339)))
 	}
+	if len(a.Guards) > 0 {
+		parts = append(parts, GuardSummary(a.Guards))
+	}
 
 	return strings.Join(parts, "\n")
 }
@@ -99,6 +111,10 @@ func DetectActiveSessions(ctx context.Context, exec RemoteExecutor) (*ActiveSess
 	// Criterion 4: Manual extend timestamp.
 	detectExtend(ctx, exec, result)
 
+	// Automation guards. A failure to read them is treated like a missing
+	// extend file: the connection itself was already checked above.
+	result.Guards, result.GuardNotes, _ = DetectGuards(ctx, exec)
+
 	return result, nil
 }
 
@@ -184,25 +200,33 @@ func detectClaude(ctx context.Context, exec RemoteExecutor, result *ActiveSessio
 // RFC3339 string. We try epoch first since that is the current write
 // format, then fall back to RFC3339 for backwards compatibility.
 func detectExtend(ctx context.Context, exec RemoteExecutor, result *ActiveSessions) {
+	result.ExtendedUntil = DetectExtendedUntil(ctx, exec)
+}
+
+// DetectExtendedUntil reads the manual extend timestamp written by
+// `mint extend`. It returns nil when the file is missing or unreadable, or
+// the extension has already expired.
+func DetectExtendedUntil(ctx context.Context, exec RemoteExecutor) *time.Time {
 	output, err := exec(ctx, []string{"cat", ExtendTimestampPath})
 	if err != nil {
 		// File not found or unreadable -- no extend active.
-		return
+		return nil
 	}
 
 	tsStr := strings.TrimSpace(string(output))
 	if tsStr == "" {
-		return
+		return nil
 	}
 
 	ts, ok := parseExtendTimestamp(tsStr)
 	if !ok {
-		return
+		return nil
 	}
 
 	if nowFunc().Before(ts) {
-		result.ExtendedUntil = &ts
+		return &ts
 	}
+	return nil
 }
 
 // parseExtendTimestamp attempts to parse a timestamp string as a Unix epoch
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GuardDir is the directory on the VM where automation writes guard marker
+// files, one <name>.json per guard.
+const GuardDir = "/mint/.mint/guards"
+
+// guardHeader precedes each guard file in the GuardReadCommand output.
+const guardHeader = "== "
+
+// Guard is a marker file that an unattended job, such as a nightly build
+// run from cron or systemd, writes to keep the VM from being recreated,
+// stopped, or destroyed under it. Jobs like these have no tmux client or
+// SSH connection for the other criteria to see.
+type Guard struct {
+	// Name is the file name without .json; it is not read from the file.
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the guard no longer blocks at now.
+func (g Guard) Expired(now time.Time) bool {
+	return !now.Before(g.ExpiresAt)
+}
+
+// String describes the guard for CLI output, e.g.
+// `nightly: "nightly build" (owner ci, expires 2026-10-16T06:00:00Z)`.
+func (g Guard) String() string {
+	owner := g.Owner
+	if owner == "" {
+		owner = "unknown"
+	}
+	return fmt.Sprintf("%s: %q (owner %s, expires %s)", g.Name, g.Reason, owner, g.ExpiresAt.Format(time.RFC3339))
+}
+
+// GuardReadCommand prints every guard file in GuardDir, each preceded by a
+// "== <name>" line. A file that cannot be read prints only its header, and
+// a missing directory prints nothing.
+func GuardReadCommand() []string {
+	script := `for f in ` + GuardDir + `/*.json; do [ -e "$f" ] || continue; ` +
+		`echo "` + guardHeader + `$(basename "$f" .json)"; cat "$f" 2>/dev/null; echo; done`
+	return []string{"sh", "-c", "'" + script + "'"}
+}
+
+// ParseGuards parses the output of GuardReadCommand into guards, in file
+// order. Files that are empty, not JSON, or have no expiry are returned by
+// name in unreadable instead.
+func ParseGuards(output []byte) (guards []Guard, unreadable []string) {
+	var name string
+	var body bytes.Buffer
+	flush := func() {
+		if name == "" {
+			return
+		}
+		var g Guard
+		if err := json.Unmarshal(bytes.TrimSpace(body.Bytes()), &g); err != nil || g.ExpiresAt.IsZero() {
+			unreadable = append(unreadable, name)
+		} else {
+			g.Name = name
+			guards = append(guards, g)
+		}
+		name = ""
+		body.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, guardHeader); ok {
+			flush()
+			name = strings.TrimSpace(rest)
+			continue
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	flush()
+	return guards, unreadable
+}
+
+// DetectGuards reads GuardDir on the VM and returns the guards that have
+// not expired, with a note for each guard it ignored because it expired or
+// could not be read.
+func DetectGuards(ctx context.Context, exec RemoteExecutor) (active []Guard, notes []string, err error) {
+	output, err := exec(ctx, GuardReadCommand())
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading guards: %w", err)
+	}
+	guards, unreadable := ParseGuards(output)
+	now := nowFunc()
+	for _, g := range guards {
+		if g.Expired(now) {
+			notes = append(notes, fmt.Sprintf("guard %q expired at %s — ignored", g.Name, g.ExpiresAt.Format(time.RFC3339)))
+			continue
+		}
+		active = append(active, g)
+	}
+	for _, name := range unreadable {
+		notes = append(notes, fmt.Sprintf("guard %q could not be read — ignored", name))
+	}
+	return active, notes, nil
+}
+
+// GuardSummary formats active guards for CLI output in the style of
+// ActiveSessions.Summary.
+func GuardSummary(guards []Guard) string {
+	if len(guards) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(guards))
+	for _, g := range guards {
+		lines = append(lines, g.String())
+	}
+	return "  Automation guards:\n    " + strings.Join(lines, "\n    ")
+}
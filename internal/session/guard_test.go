@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGuards(t *testing.T) {
+	output := "== nightly\n" +
+		`{"owner":"ci","reason":"nightly build","expires_at":"2026-10-16T06:00:00Z"}` + "\n" +
+		"== empty\n" +
+		"\n" +
+		"== garbage\n" +
+		"not json\n" +
+		"== no-expiry\n" +
+		`{"owner":"ci","reason":"forever"}` + "\n"
+
+	guards, unreadable := ParseGuards([]byte(output))
+	if len(guards) != 1 {
+		t.Fatalf("guards = %+v, want 1", guards)
+	}
+	want := Guard{Name: "nightly", Owner: "ci", Reason: "nightly build", ExpiresAt: time.Date(2026, 10, 16, 6, 0, 0, 0, time.UTC)}
+	if guards[0] != want {
+		t.Errorf("guard = %+v, want %+v", guards[0], want)
+	}
+	if strings.Join(unreadable, ",") != "empty,garbage,no-expiry" {
+		t.Errorf("unreadable = %q", unreadable)
+	}
+}
+
+func TestDetectGuardsSkipsExpired(t *testing.T) {
+	origNow := nowFunc
+	nowFunc = func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) }
+	defer func() { nowFunc = origNow }()
+
+	mock := newMockExecutor()
+	mock.set(strings.Join(GuardReadCommand(), " "), []byte(
+		"== nightly\n"+`{"owner":"ci","reason":"nightly build","expires_at":"2026-10-16T06:00:00Z"}`+"\n"+
+			"== old\n"+`{"owner":"ci","reason":"yesterday","expires_at":"2026-10-15T11:00:00Z"}`+"\n"+
+			"== locked\n"), nil)
+
+	active, notes, err := DetectGuards(context.Background(), mock.run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].Name != "nightly" {
+		t.Errorf("active = %+v, want nightly", active)
+	}
+	wantNotes := []string{
+		`guard "old" expired at 2026-10-15T11:00:00Z — ignored`,
+		`guard "locked" could not be read — ignored`,
+	}
+	if strings.Join(notes, "\n") != strings.Join(wantNotes, "\n") {
+		t.Errorf("notes = %q, want %q", notes, wantNotes)
+	}
+}
+
+func TestDetectActiveSessions_GuardActive(t *testing.T) {
+	mock := newMockExecutor()
+	mock.set("tmux list-clients -F #{client_name} #{session_name}",
+		nil, fmt.Errorf("no server running on /tmp/tmux-1000/default"))
+	mock.set("who", []byte(""), nil)
+	mock.set("docker ps -q", nil, fmt.Errorf("docker: command not found"))
+	mock.set("cat "+ExtendTimestampPath, nil, fmt.Errorf("No such file or directory"))
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	mock.set(strings.Join(GuardReadCommand(), " "), []byte(
+		"== nightly\n"+fmt.Sprintf(`{"owner":"ci","reason":"nightly build","expires_at":%q}`, expires.Format(time.RFC3339))+"\n"), nil)
+
+	result, err := DetectActiveSessions(context.Background(), mock.run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.HasActivity() {
+		t.Error("expected an active guard to count as activity")
+	}
+	want := "  Automation guards:\n    nightly: \"nightly build\" (owner ci, expires " + expires.Format(time.RFC3339) + ")"
+	if result.Summary() != want {
+		t.Errorf("summary = %q, want %q", result.Summary(), want)
+	}
+}
@@ -0,0 +1,319 @@
+// Package sg is the single source of truth for the rules on the security
+// groups mint manages: the per-user group created by mint init and the admin
+// (EFS) group created by the admin CloudFormation stack.
+//
+// mint init creates the user group from UserRules, and mint doctor diffs the
+// live groups against UserRules and AdminRules and, with --fix, adds any
+// missing rules. Repair only ever authorizes rules; nothing in this package
+// can revoke one, so rules mint does not recognize are always left alone.
+package sg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+)
+
+// Ports used by the managed rules. DefaultSSHPort is the SSH port when
+// ssh_port is not set.
+const (
+	DefaultSSHPort int32 = 41122
+	MoshFromPort   int32 = 60000
+	MoshToPort     int32 = 61000
+	NFSPort        int32 = 2049
+)
+
+const (
+	anyIPv4      = "0.0.0.0/0"
+	anyIPv6      = "::/0"
+	allProtocols = "-1"
+)
+
+// Self is the Group value of a rule whose peer is the group itself.
+const Self = "self"
+
+// Direction is the direction of a security group rule.
+type Direction string
+
+// Rule directions.
+const (
+	Ingress Direction = "ingress"
+	Egress  Direction = "egress"
+)
+
+// Rule is a single security group rule with exactly one peer.
+type Rule struct {
+	Direction  Direction
+	Protocol   string // "tcp", "udp", or "-1" for all traffic
+	FromPort   int32
+	ToPort     int32
+	CIDR       string // IPv4 peer
+	IPv6CIDR   string // IPv6 peer
+	Group      string // peer security group ID, or Self
+	PrefixList string // peer prefix list ID (only seen on live rules)
+
+	Description string
+
+	// Optional rules are allowed but not required: they are never reported
+	// as missing or as unrecognized. Used for the default egress rules AWS
+	// adds to new groups.
+	Optional bool
+}
+
+// UserRules returns the rules of the per-user security group (ADR-0016):
+// SSH on sshPort and the mosh UDP range from anywhere, over IPv4 and IPv6
+// for VMs with an IPv6 address, and all outbound traffic, which bootstrap
+// needs to download packages. A zero sshPort means DefaultSSHPort.
+func UserRules(sshPort int32) []Rule {
+	if sshPort == 0 {
+		sshPort = DefaultSSHPort
+	}
+	return []Rule{
+		{Direction: Ingress, Protocol: "tcp", FromPort: sshPort, ToPort: sshPort, CIDR: anyIPv4, Description: "SSH on non-standard port"},
+		{Direction: Ingress, Protocol: "udp", FromPort: MoshFromPort, ToPort: MoshToPort, CIDR: anyIPv4, Description: "Mosh UDP range"},
+		{Direction: Egress, Protocol: allProtocols, CIDR: anyIPv4, Description: "All outbound traffic"},
+		{Direction: Egress, Protocol: allProtocols, IPv6CIDR: anyIPv6, Optional: true},
+		{Direction: Ingress, Protocol: "tcp", FromPort: sshPort, ToPort: sshPort, IPv6CIDR: anyIPv6, Description: "SSH on non-standard port over IPv6"},
+		{Direction: Ingress, Protocol: "udp", FromPort: MoshFromPort, ToPort: MoshToPort, IPv6CIDR: anyIPv6, Description: "Mosh UDP range over IPv6"},
+	}
+}
+
+// AdminRules returns the rules of the admin (EFS) security group: NFS from
+// the group itself, so only Mint VMs can reach the EFS mount targets. The
+// admin CloudFormation template must create the same rule.
+func AdminRules() []Rule {
+	return []Rule{
+		{Direction: Ingress, Protocol: "tcp", FromPort: NFSPort, ToPort: NFSPort, Group: Self, Description: "NFS access from Mint VMs to EFS mount targets"},
+		{Direction: Egress, Protocol: allProtocols, CIDR: anyIPv4, Optional: true},
+		{Direction: Egress, Protocol: allProtocols, IPv6CIDR: anyIPv6, Optional: true},
+	}
+}
+
+// Required returns the non-optional rules in the given direction.
+func Required(rules []Rule, dir Direction) []Rule {
+	var out []Rule
+	for _, r := range rules {
+		if r.Direction == dir && !r.Optional {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// String describes the rule, e.g. "ingress tcp 41122 from 0.0.0.0/0".
+func (r Rule) String() string {
+	var b strings.Builder
+	b.WriteString(string(r.Direction))
+	b.WriteByte(' ')
+	switch {
+	case r.Protocol == allProtocols:
+		b.WriteString("all traffic")
+	case r.FromPort == r.ToPort:
+		fmt.Fprintf(&b, "%s %d", r.Protocol, r.FromPort)
+	default:
+		fmt.Fprintf(&b, "%s %d-%d", r.Protocol, r.FromPort, r.ToPort)
+	}
+	if r.Direction == Ingress {
+		b.WriteString(" from ")
+	} else {
+		b.WriteString(" to ")
+	}
+	b.WriteString(r.peer())
+	return b.String()
+}
+
+func (r Rule) peer() string {
+	switch {
+	case r.CIDR != "":
+		return r.CIDR
+	case r.IPv6CIDR != "":
+		return r.IPv6CIDR
+	case r.Group != "":
+		return r.Group
+	default:
+		return r.PrefixList
+	}
+}
+
+// key identifies a rule for exact comparison; descriptions are ignored.
+func (r Rule) key() string {
+	return fmt.Sprintf("%s|%s|%d|%d|%s|%s|%s|%s",
+		r.Direction, r.Protocol, r.FromPort, r.ToPort, r.CIDR, r.IPv6CIDR, r.Group, r.PrefixList)
+}
+
+// covers reports whether the live rule r allows at least the traffic of
+// want: the same direction and peer, and the same or a wider protocol and
+// port range.
+func (r Rule) covers(want Rule) bool {
+	if r.Direction != want.Direction || r.CIDR != want.CIDR || r.IPv6CIDR != want.IPv6CIDR ||
+		r.Group != want.Group || r.PrefixList != want.PrefixList {
+		return false
+	}
+	if r.Protocol == allProtocols {
+		return true
+	}
+	return r.Protocol == want.Protocol && r.FromPort <= want.FromPort && r.ToPort >= want.ToPort
+}
+
+// normalizeProtocol maps protocol numbers to the names AWS uses for the
+// rules mint creates.
+func normalizeProtocol(p string) string {
+	switch strings.ToLower(p) {
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "all":
+		return allProtocols
+	default:
+		return strings.ToLower(p)
+	}
+}
+
+// FromPermissions flattens live permissions into one Rule per peer. A peer
+// group equal to groupID becomes Self.
+func FromPermissions(dir Direction, perms []ec2types.IpPermission, groupID string) []Rule {
+	var rules []Rule
+	for _, p := range perms {
+		base := Rule{Direction: dir, Protocol: normalizeProtocol(aws.ToString(p.IpProtocol))}
+		if base.Protocol != allProtocols {
+			base.FromPort = aws.ToInt32(p.FromPort)
+			base.ToPort = aws.ToInt32(p.ToPort)
+		}
+		for _, r := range p.IpRanges {
+			rule := base
+			rule.CIDR = aws.ToString(r.CidrIp)
+			rule.Description = aws.ToString(r.Description)
+			rules = append(rules, rule)
+		}
+		for _, r := range p.Ipv6Ranges {
+			rule := base
+			rule.IPv6CIDR = aws.ToString(r.CidrIpv6)
+			rule.Description = aws.ToString(r.Description)
+			rules = append(rules, rule)
+		}
+		for _, g := range p.UserIdGroupPairs {
+			rule := base
+			rule.Group = aws.ToString(g.GroupId)
+			if rule.Group == groupID {
+				rule.Group = Self
+			}
+			rule.Description = aws.ToString(g.Description)
+			rules = append(rules, rule)
+		}
+		for _, pl := range p.PrefixListIds {
+			rule := base
+			rule.PrefixList = aws.ToString(pl.PrefixListId)
+			rule.Description = aws.ToString(pl.Description)
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Result is the difference between a live group and its spec.
+type Result struct {
+	// Missing lists required rules that no live rule covers.
+	Missing []Rule
+	// Extra lists live rules that are not in the spec. They are reported
+	// but never revoked.
+	Extra []Rule
+}
+
+// Diff compares a live security group against spec.
+func Diff(spec []Rule, group ec2types.SecurityGroup) Result {
+	groupID := aws.ToString(group.GroupId)
+	live := append(
+		FromPermissions(Ingress, group.IpPermissions, groupID),
+		FromPermissions(Egress, group.IpPermissionsEgress, groupID)...,
+	)
+
+	var res Result
+	for _, want := range spec {
+		if want.Optional {
+			continue
+		}
+		covered := false
+		for _, l := range live {
+			if l.covers(want) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			res.Missing = append(res.Missing, want)
+		}
+	}
+
+	known := make(map[string]bool, len(spec))
+	for _, r := range spec {
+		known[r.key()] = true
+	}
+	for _, l := range live {
+		if !known[l.key()] {
+			res.Extra = append(res.Extra, l)
+		}
+	}
+	return res
+}
+
+// Permissions converts rules into EC2 permissions for groupID, one
+// permission per rule. Self peers resolve to groupID.
+func Permissions(rules []Rule, groupID string) []ec2types.IpPermission {
+	perms := make([]ec2types.IpPermission, 0, len(rules))
+	for _, r := range rules {
+		p := ec2types.IpPermission{IpProtocol: aws.String(r.Protocol)}
+		if r.Protocol != allProtocols {
+			p.FromPort = aws.Int32(r.FromPort)
+			p.ToPort = aws.Int32(r.ToPort)
+		}
+		var desc *string
+		if r.Description != "" {
+			desc = aws.String(r.Description)
+		}
+		switch {
+		case r.CIDR != "":
+			p.IpRanges = []ec2types.IpRange{{CidrIp: aws.String(r.CIDR), Description: desc}}
+		case r.IPv6CIDR != "":
+			p.Ipv6Ranges = []ec2types.Ipv6Range{{CidrIpv6: aws.String(r.IPv6CIDR), Description: desc}}
+		case r.Group != "":
+			peer := r.Group
+			if peer == Self {
+				peer = groupID
+			}
+			p.UserIdGroupPairs = []ec2types.UserIdGroupPair{{GroupId: aws.String(peer), Description: desc}}
+		case r.PrefixList != "":
+			p.PrefixListIds = []ec2types.PrefixListId{{PrefixListId: aws.String(r.PrefixList), Description: desc}}
+		}
+		perms = append(perms, p)
+	}
+	return perms
+}
+
+// Repair authorizes the missing rules on groupID: one ingress call and one
+// egress call at most. It never revokes anything.
+func Repair(ctx context.Context, ingress mintaws.AuthorizeSecurityGroupIngressAPI, egress mintaws.AuthorizeSecurityGroupEgressAPI, groupID string, missing []Rule) error {
+	if in := Required(missing, Ingress); len(in) > 0 {
+		if _, err := ingress.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: Permissions(in, groupID),
+		}); err != nil {
+			return fmt.Errorf("authorize ingress on %s: %w", groupID, err)
+		}
+	}
+	if out := Required(missing, Egress); len(out) > 0 {
+		if _, err := egress.AuthorizeSecurityGroupEgress(ctx, &ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: Permissions(out, groupID),
+		}); err != nil {
+			return fmt.Errorf("authorize egress on %s: %w", groupID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package sg
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const testGroupID = "sg-user"
+
+// liveGroup builds a security group whose rules are exactly rules.
+func liveGroup(groupID string, rules ...Rule) ec2types.SecurityGroup {
+	var in, out []Rule
+	for _, r := range rules {
+		if r.Direction == Ingress {
+			in = append(in, r)
+		} else {
+			out = append(out, r)
+		}
+	}
+	return ec2types.SecurityGroup{
+		GroupId:             aws.String(groupID),
+		IpPermissions:       Permissions(in, groupID),
+		IpPermissionsEgress: Permissions(out, groupID),
+	}
+}
+
+func ruleStrings(rules []Rule) []string {
+	var out []string
+	for _, r := range rules {
+		out = append(out, r.String())
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	ssh, mosh, egress, egress6 := UserRules(0)[0], UserRules(0)[1], UserRules(0)[2], UserRules(0)[3]
+	ssh6, mosh6 := UserRules(0)[4], UserRules(0)[5]
+	rdp := Rule{Direction: Ingress, Protocol: "tcp", FromPort: 3389, ToPort: 3389, CIDR: "10.0.0.0/8"}
+	allTCP := Rule{Direction: Ingress, Protocol: "tcp", FromPort: 0, ToPort: 65535, CIDR: "0.0.0.0/0"}
+
+	tests := []struct {
+		name        string
+		group       ec2types.SecurityGroup
+		wantMissing []string
+		wantExtra   []string
+	}{
+		{
+			name:  "exact match",
+			group: liveGroup(testGroupID, ssh, mosh, ssh6, mosh6, egress),
+		},
+		{
+			name:  "optional IPv6 egress is neither missing nor extra",
+			group: liveGroup(testGroupID, ssh, mosh, ssh6, mosh6, egress, egress6),
+		},
+		{
+			name:        "missing SSH ingress and egress",
+			group:       liveGroup(testGroupID, mosh, ssh6, mosh6),
+			wantMissing: []string{"ingress tcp 41122 from 0.0.0.0/0", "egress all traffic to 0.0.0.0/0"},
+		},
+		{
+			name:        "group created before the IPv6 rules",
+			group:       liveGroup(testGroupID, ssh, mosh, egress),
+			wantMissing: []string{"ingress tcp 41122 from ::/0", "ingress udp 60000-61000 from ::/0"},
+		},
+		{
+			name:      "unknown extra rule",
+			group:     liveGroup(testGroupID, ssh, mosh, ssh6, mosh6, egress, rdp),
+			wantExtra: []string{"ingress tcp 3389 from 10.0.0.0/8"},
+		},
+		{
+			name:      "wider rule covers a required one but is still unknown",
+			group:     liveGroup(testGroupID, allTCP, mosh, ssh6, mosh6, egress),
+			wantExtra: []string{"ingress tcp 0-65535 from 0.0.0.0/0"},
+		},
+		{
+			name:        "same port from a different peer does not cover",
+			group:       liveGroup(testGroupID, Rule{Direction: Ingress, Protocol: "tcp", FromPort: DefaultSSHPort, ToPort: DefaultSSHPort, CIDR: "203.0.113.0/24"}, mosh, ssh6, mosh6, egress),
+			wantMissing: []string{"ingress tcp 41122 from 0.0.0.0/0"},
+			wantExtra:   []string{"ingress tcp 41122 from 203.0.113.0/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Diff(UserRules(0), tt.group)
+			if got := ruleStrings(res.Missing); !reflect.DeepEqual(got, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", got, tt.wantMissing)
+			}
+			if got := ruleStrings(res.Extra); !reflect.DeepEqual(got, tt.wantExtra) {
+				t.Errorf("Extra = %v, want %v", got, tt.wantExtra)
+			}
+		})
+	}
+}
+
+func TestDiffAdminSelfReference(t *testing.T) {
+	nfs := AdminRules()[0]
+
+	res := Diff(AdminRules(), liveGroup("sg-admin", nfs, AdminRules()[1]))
+	if len(res.Missing) != 0 || len(res.Extra) != 0 {
+		t.Errorf("self-referencing NFS rule: Missing = %v, Extra = %v", res.Missing, res.Extra)
+	}
+
+	// NFS from some other group is not the self reference.
+	other := nfs
+	other.Group = "sg-other"
+	res = Diff(AdminRules(), liveGroup("sg-admin", other))
+	if got := ruleStrings(res.Missing); !reflect.DeepEqual(got, []string{"ingress tcp 2049 from self"}) {
+		t.Errorf("Missing = %v", got)
+	}
+	if got := ruleStrings(res.Extra); !reflect.DeepEqual(got, []string{"ingress tcp 2049 from sg-other"}) {
+		t.Errorf("Extra = %v", got)
+	}
+}
+
+func TestFromPermissionsNormalizes(t *testing.T) {
+	perms := []ec2types.IpPermission{
+		{
+			IpProtocol: aws.String("6"),
+			FromPort:   aws.Int32(22),
+			ToPort:     aws.Int32(22),
+			IpRanges:   []ec2types.IpRange{{CidrIp: aws.String("1.2.3.4/32")}, {CidrIp: aws.String("5.6.7.8/32")}},
+		},
+		{
+			// AWS reports -1 ports for all-traffic rules.
+			IpProtocol: aws.String("-1"),
+			FromPort:   aws.Int32(-1),
+			ToPort:     aws.Int32(-1),
+			PrefixListIds: []ec2types.PrefixListId{
+				{PrefixListId: aws.String("pl-123")},
+			},
+		},
+	}
+	got := ruleStrings(FromPermissions(Ingress, perms, testGroupID))
+	want := []string{"ingress tcp 22 from 1.2.3.4/32", "ingress tcp 22 from 5.6.7.8/32", "ingress all traffic from pl-123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromPermissions = %v, want %v", got, want)
+	}
+}
+
+// recordingEC2 records authorize calls and fails if anything else is used.
+type recordingEC2 struct {
+	ingress []*ec2.AuthorizeSecurityGroupIngressInput
+	egress  []*ec2.AuthorizeSecurityGroupEgressInput
+	err     error
+}
+
+func (r *recordingEC2) AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	r.ingress = append(r.ingress, params)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, r.err
+}
+
+func (r *recordingEC2) AuthorizeSecurityGroupEgress(ctx context.Context, params *ec2.AuthorizeSecurityGroupEgressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupEgressOutput, error) {
+	r.egress = append(r.egress, params)
+	return &ec2.AuthorizeSecurityGroupEgressOutput{}, r.err
+}
+
+func TestUserRulesUseConfiguredSSHPort(t *testing.T) {
+	defaults := UserRules(0)
+	custom := UserRules(2222)
+	for _, i := range []int{0, 4} {
+		if custom[i].FromPort != 2222 || custom[i].ToPort != 2222 {
+			t.Errorf("rule %d = %s, want tcp 2222", i, custom[i])
+		}
+	}
+
+	// A group opened for the default port is missing the configured one,
+	// and its 41122 rules are no longer recognized.
+	group := liveGroup(testGroupID, defaults...)
+	res := Diff(custom, group)
+	if got := ruleStrings(res.Missing); !reflect.DeepEqual(got, []string{custom[0].String(), custom[4].String()}) {
+		t.Errorf("Missing = %v, want the SSH rules for 2222", got)
+	}
+	if got := ruleStrings(res.Extra); !reflect.DeepEqual(got, []string{defaults[0].String(), defaults[4].String()}) {
+		t.Errorf("Extra = %v, want the SSH rules for 41122", got)
+	}
+}
+
+func TestRepairAuthorizesOnlyMissingRules(t *testing.T) {
+	rdp := Rule{Direction: Ingress, Protocol: "tcp", FromPort: 3389, ToPort: 3389, CIDR: "10.0.0.0/8"}
+	group := liveGroup(testGroupID, UserRules(0)[1], UserRules(0)[4], UserRules(0)[5], rdp)
+	res := Diff(UserRules(0), group)
+
+	rec := &recordingEC2{}
+	if err := Repair(context.Background(), rec, rec, testGroupID, res.Missing); err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+
+	if len(rec.ingress) != 1 || len(rec.egress) != 1 {
+		t.Fatalf("got %d ingress and %d egress calls, want 1 each", len(rec.ingress), len(rec.egress))
+	}
+	in := rec.ingress[0]
+	if aws.ToString(in.GroupId) != testGroupID || len(in.IpPermissions) != 1 {
+		t.Fatalf("ingress call = %+v", in)
+	}
+	p := in.IpPermissions[0]
+	if aws.ToString(p.IpProtocol) != "tcp" || aws.ToInt32(p.FromPort) != DefaultSSHPort || aws.ToInt32(p.ToPort) != DefaultSSHPort ||
+		aws.ToString(p.IpRanges[0].CidrIp) != "0.0.0.0/0" {
+		t.Errorf("ingress permission = %+v, want tcp 41122 from 0.0.0.0/0", p)
+	}
+	e := rec.egress[0].IpPermissions[0]
+	if aws.ToString(e.IpProtocol) != "-1" || e.FromPort != nil || aws.ToString(e.IpRanges[0].CidrIp) != "0.0.0.0/0" {
+		t.Errorf("egress permission = %+v, want all traffic to 0.0.0.0/0", e)
+	}
+
+	// The unknown RDP rule is reported but never part of any call.
+	for _, call := range rec.ingress {
+		for _, p := range call.IpPermissions {
+			if aws.ToInt32(p.FromPort) == 3389 {
+				t.Error("unknown rule was sent to AWS")
+			}
+		}
+	}
+	if len(res.Extra) != 1 {
+		t.Errorf("Extra = %v, want the RDP rule", res.Extra)
+	}
+}
+
+func TestRepairResolvesSelfAndSkipsEmpty(t *testing.T) {
+	rec := &recordingEC2{}
+	if err := Repair(context.Background(), rec, rec, "sg-admin", nil); err != nil {
+		t.Fatalf("Repair(nil) error: %v", err)
+	}
+	if len(rec.ingress)+len(rec.egress) != 0 {
+		t.Fatal("Repair with nothing missing called AWS")
+	}
+
+	if err := Repair(context.Background(), rec, rec, "sg-admin", Required(AdminRules(), Ingress)); err != nil {
+		t.Fatalf("Repair() error: %v", err)
+	}
+	pair := rec.ingress[0].IpPermissions[0].UserIdGroupPairs[0]
+	if aws.ToString(pair.GroupId) != "sg-admin" {
+		t.Errorf("self peer resolved to %q, want sg-admin", aws.ToString(pair.GroupId))
+	}
+	if len(rec.egress) != 0 {
+		t.Error("optional admin egress rules must not be authorized")
+	}
+}
+
+func TestRepairError(t *testing.T) {
+	rec := &recordingEC2{err: errors.New("UnauthorizedOperation")}
+	err := Repair(context.Background(), rec, rec, testGroupID, Required(UserRules(0), Ingress))
+	if err == nil || !strings.Contains(err.Error(), "authorize ingress on sg-user: UnauthorizedOperation") {
+		t.Errorf("error = %v", err)
+	}
+}
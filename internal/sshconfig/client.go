@@ -0,0 +1,229 @@
+package sshconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a major.minor version of the laptop's OpenSSH client or of
+// the VS Code Remote-SSH extension. The zero value means the version is
+// unknown, and nothing is gated on it.
+type Version struct {
+	Major, Minor int
+}
+
+// IsZero reports whether v is unknown.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Before reports whether v is older than o.
+func (v Version) Before(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	return v.Minor < o.Minor
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// sshVersionPattern matches the version in `ssh -V` output, such as
+// "OpenSSH_9.6p1, LibreSSL 3.3.6" or the Windows build's
+// "OpenSSH_for_Windows_8.1p1, LibreSSL 3.0.2".
+var sshVersionPattern = regexp.MustCompile(`OpenSSH_(?:for_Windows_)?(\d+)\.(\d+)`)
+
+// ParseClientVersion parses `ssh -V` output. ok is false when the output
+// is not OpenSSH's.
+func ParseClientVersion(output string) (v Version, ok bool) {
+	m := sshVersionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return Version{}, false
+	}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	return v, true
+}
+
+// RemoteSSHExtension is the ID of VS Code's Remote-SSH extension.
+const RemoteSSHExtension = "ms-vscode-remote.remote-ssh"
+
+// MinRemoteSSHVersion is the oldest Remote-SSH release that honours the
+// RemoteCommand directive of project Host blocks.
+var MinRemoteSSHVersion = Version{0, 65}
+
+// ParseRemoteSSHVersion finds the Remote-SSH extension in the output of
+// `code --list-extensions --show-versions`, one "<id>@<version>" per line.
+// ok is false when the extension is not installed.
+func ParseRemoteSSHVersion(output string) (v Version, ok bool) {
+	for _, line := range strings.Split(output, "\n") {
+		id, version, found := strings.Cut(strings.TrimSpace(line), "@")
+		if !found || !strings.EqualFold(id, RemoteSSHExtension) {
+			continue
+		}
+		parts := strings.SplitN(version, ".", 3)
+		if len(parts) < 2 {
+			return Version{}, false
+		}
+		major, err1 := strconv.Atoi(parts[0])
+		minor, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			return Version{}, false
+		}
+		return Version{major, minor}, true
+	}
+	return Version{}, false
+}
+
+// clientFeature is an ssh_config directive that older OpenSSH clients
+// reject with "Bad configuration option" or "unsupported option".
+type clientFeature struct {
+	// label names the directive in notes and warnings.
+	label string
+	// keyword is the ssh_config keyword, lower-cased.
+	keyword string
+	// match reports whether a value needs the feature; nil matches any.
+	match func(value string) bool
+	// min is the first OpenSSH release that supports the feature.
+	min Version
+	// renamed is the keyword that releases from renamedMin up to min know
+	// the option by. Empty when the option had no earlier name.
+	renamed    string
+	renamedMin Version
+	// required is set for directives a Host block cannot work without;
+	// they are kept, and the client must be upgraded.
+	required bool
+	// effect says what leaving the directive out changes.
+	effect string
+}
+
+// clientFeatures is the matrix of directives mint's Host blocks, or the
+// user options copied into them, may hold that not every OpenSSH client
+// supports. UserKnownHostsFile is not listed: mint never writes it to a
+// Host block (ValidateExtraArgs rejects it) and only passes it on the
+// command line.
+var clientFeatures = []clientFeature{
+	{
+		label:   "StrictHostKeyChecking accept-new",
+		keyword: "stricthostkeychecking",
+		match:   func(v string) bool { return strings.EqualFold(v, "accept-new") },
+		min:     Version{7, 6},
+		effect:  "ssh asks to confirm the VM's host key on first connection",
+	},
+	{
+		label:   "ControlPersist",
+		keyword: "controlpersist",
+		min:     Version{5, 6},
+		effect:  "a shared connection closes with its last session",
+	},
+	{
+		label:   "ControlPath %C",
+		keyword: "controlpath",
+		match:   func(v string) bool { return strings.Contains(v, "%C") },
+		min:     Version{6, 7},
+		effect:  "connections are not shared",
+	},
+	{
+		label:    "ProxyJump",
+		keyword:  "proxyjump",
+		min:      Version{7, 3},
+		required: true,
+	},
+	{
+		label:      "PubkeyAcceptedAlgorithms",
+		keyword:    "pubkeyacceptedalgorithms",
+		min:        Version{8, 5},
+		renamed:    "PubkeyAcceptedKeyTypes",
+		renamedMin: Version{7, 0},
+		effect:     "the client's default public key algorithms apply",
+	},
+	{
+		label:    "RequestTTY",
+		keyword:  "requesttty",
+		min:      Version{5, 9},
+		required: true,
+	},
+	{
+		label:    "RemoteCommand",
+		keyword:  "remotecommand",
+		min:      Version{7, 6},
+		required: true,
+	},
+}
+
+// ClientIssue is a directive a Host block would hold that the client does
+// not support.
+type ClientIssue struct {
+	// Directive names the directive, e.g. "StrictHostKeyChecking accept-new".
+	Directive string
+	// Min is the first OpenSSH release that supports it.
+	Min Version
+	// Renamed is the keyword it was written as instead, for an option the
+	// client knows by an earlier name.
+	Renamed string
+	// Required is set when the directive was kept because the block cannot
+	// work without it: the client rejects the block until it is upgraded.
+	Required bool
+	// Effect says what leaving the directive out changes. Empty when it
+	// was renamed or kept.
+	Effect string
+}
+
+func (i ClientIssue) String() string {
+	switch {
+	case i.Required:
+		return fmt.Sprintf("%s needs OpenSSH %s or later", i.Directive, i.Min)
+	case i.Renamed != "":
+		return fmt.Sprintf("%s needs OpenSSH %s or later — written as %s", i.Directive, i.Min, i.Renamed)
+	default:
+		return fmt.Sprintf("%s needs OpenSSH %s or later — left out, so %s", i.Directive, i.Min, i.Effect)
+	}
+}
+
+// lookupFeature returns the feature that keyword with value needs, if any.
+func lookupFeature(keyword, value string) (clientFeature, bool) {
+	keyword = strings.ToLower(keyword)
+	for _, f := range clientFeatures {
+		if f.keyword == keyword && (f.match == nil || f.match(value)) {
+			return f, true
+		}
+	}
+	return clientFeature{}, false
+}
+
+// fitClient fits Host block directives, one per line as in a generated
+// block, to client. A directive the client does not support is left out,
+// or rewritten under the option's earlier name, or kept when the block
+// needs it; each is returned as an issue. An unknown client keeps every
+// directive.
+func fitClient(client Version, directives string) (string, []ClientIssue) {
+	if client.IsZero() {
+		return directives, nil
+	}
+	var b strings.Builder
+	var issues []ClientIssue
+	for _, line := range strings.SplitAfter(directives, "\n") {
+		keyword, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		f, ok := lookupFeature(keyword, value)
+		if !ok || !client.Before(f.min) {
+			b.WriteString(line)
+			continue
+		}
+		issue := ClientIssue{Directive: f.label, Min: f.min}
+		switch {
+		case f.required:
+			issue.Required = true
+			b.WriteString(line)
+		case f.renamed != "" && !client.Before(f.renamedMin):
+			issue.Renamed = f.renamed
+			b.WriteString(strings.Replace(line, keyword, f.renamed, 1))
+		default:
+			issue.Effect = f.effect
+		}
+		issues = append(issues, issue)
+	}
+	return b.String(), issues
+}
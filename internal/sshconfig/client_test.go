@@ -0,0 +1,160 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseClientVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   Version
+		ok     bool
+	}{
+		{"OpenSSH_9.6p1, LibreSSL 3.3.6\n", Version{9, 6}, true},
+		{"OpenSSH_8.9p1 Ubuntu-3ubuntu0.6, OpenSSL 3.0.2 15 Mar 2022\n", Version{8, 9}, true},
+		{"OpenSSH_for_Windows_8.1p1, LibreSSL 3.0.2\r\n", Version{8, 1}, true},
+		{"OpenSSH_7.4p1, OpenSSL 1.0.2k-fips  26 Jan 2017\n", Version{7, 4}, true},
+		{"OpenSSH_10.0p2, OpenSSL 3.5.0\n", Version{10, 0}, true},
+		{"Dropbear v2022.83\n", Version{}, false},
+		{"", Version{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseClientVersion(tt.output)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseClientVersion(%q) = %v, %v, want %v, %v", tt.output, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseRemoteSSHVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   Version
+		ok     bool
+	}{
+		{"installed", "golang.go@0.41.4\nms-vscode-remote.remote-ssh@0.113.1\nms-vscode-remote.remote-ssh-edit@0.86.0\n", Version{0, 113}, true},
+		{"old", "ms-vscode-remote.remote-ssh@0.51.0\n", Version{0, 51}, true},
+		{"case-insensitive ID", "MS-vscode-remote.Remote-SSH@0.70.0\n", Version{0, 70}, true},
+		{"not installed", "golang.go@0.41.4\nms-vscode-remote.remote-ssh-edit@0.86.0\n", Version{}, false},
+		{"no versions", "ms-vscode-remote.remote-ssh\n", Version{}, false},
+		{"malformed", "ms-vscode-remote.remote-ssh@latest\n", Version{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseRemoteSSHVersion(tt.output)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ParseRemoteSSHVersion = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFitClient(t *testing.T) {
+	directives := "Host mint-default\n" +
+		"    StrictHostKeyChecking accept-new\n" +
+		"    ControlMaster auto\n" +
+		"    ControlPath ~/.ssh/cm-%C\n" +
+		"    ControlPersist 10m\n" +
+		"    PubkeyAcceptedAlgorithms +ssh-rsa\n" +
+		"    ProxyJump bastion\n"
+
+	tests := []struct {
+		name       string
+		client     Version
+		wantLines  []string
+		wantAbsent []string
+		wantIssues []ClientIssue
+	}{
+		{
+			name:      "unknown client keeps everything",
+			wantLines: []string{"StrictHostKeyChecking accept-new", "ControlPath ~/.ssh/cm-%C", "PubkeyAcceptedAlgorithms +ssh-rsa", "ProxyJump bastion"},
+		},
+		{
+			name:      "modern client keeps everything",
+			client:    Version{9, 6},
+			wantLines: []string{"StrictHostKeyChecking accept-new", "ControlPersist 10m", "PubkeyAcceptedAlgorithms +ssh-rsa", "ProxyJump bastion"},
+		},
+		{
+			name:       "8.4 renames PubkeyAcceptedAlgorithms",
+			client:     Version{8, 4},
+			wantLines:  []string{"StrictHostKeyChecking accept-new", "PubkeyAcceptedKeyTypes +ssh-rsa"},
+			wantAbsent: []string{"PubkeyAcceptedAlgorithms"},
+			wantIssues: []ClientIssue{{Directive: "PubkeyAcceptedAlgorithms", Min: Version{8, 5}, Renamed: "PubkeyAcceptedKeyTypes"}},
+		},
+		{
+			name:       "7.4 omits accept-new and keeps ProxyJump",
+			client:     Version{7, 4},
+			wantLines:  []string{"ControlPath ~/.ssh/cm-%C", "PubkeyAcceptedKeyTypes +ssh-rsa", "ProxyJump bastion"},
+			wantAbsent: []string{"StrictHostKeyChecking"},
+			wantIssues: []ClientIssue{
+				{Directive: "StrictHostKeyChecking accept-new", Min: Version{7, 6}, Effect: "ssh asks to confirm the VM's host key on first connection"},
+				{Directive: "PubkeyAcceptedAlgorithms", Min: Version{8, 5}, Renamed: "PubkeyAcceptedKeyTypes"},
+			},
+		},
+		{
+			name:       "6.6 omits ControlPath %C and requires ProxyJump",
+			client:     Version{6, 6},
+			wantLines:  []string{"ControlMaster auto", "ControlPersist 10m", "ProxyJump bastion"},
+			wantAbsent: []string{"ControlPath", "PubkeyAccepted", "StrictHostKeyChecking"},
+			wantIssues: []ClientIssue{
+				{Directive: "StrictHostKeyChecking accept-new", Min: Version{7, 6}, Effect: "ssh asks to confirm the VM's host key on first connection"},
+				{Directive: "ControlPath %C", Min: Version{6, 7}, Effect: "connections are not shared"},
+				{Directive: "PubkeyAcceptedAlgorithms", Min: Version{8, 5}, Effect: "the client's default public key algorithms apply"},
+				{Directive: "ProxyJump", Min: Version{7, 3}, Required: true},
+			},
+		},
+		{
+			name:       "5.5 omits ControlPersist",
+			client:     Version{5, 5},
+			wantLines:  []string{"ControlMaster auto"},
+			wantAbsent: []string{"ControlPersist"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, issues := fitClient(tt.client, directives)
+			if !strings.HasPrefix(got, "Host mint-default\n") {
+				t.Errorf("Host line lost:\n%s", got)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(got, "    "+line+"\n") {
+					t.Errorf("missing %q:\n%s", line, got)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(got, absent) {
+					t.Errorf("%q not left out:\n%s", absent, got)
+				}
+			}
+			if tt.wantIssues != nil && !reflect.DeepEqual(issues, tt.wantIssues) {
+				t.Errorf("issues = %+v, want %+v", issues, tt.wantIssues)
+			}
+			if tt.client.IsZero() || tt.client == (Version{9, 6}) {
+				if got != directives || issues != nil {
+					t.Errorf("directives changed:\n%s\nissues %+v", got, issues)
+				}
+			}
+		})
+	}
+}
+
+func TestClientIssueString(t *testing.T) {
+	tests := []struct {
+		issue ClientIssue
+		want  string
+	}{
+		{ClientIssue{Directive: "ProxyJump", Min: Version{7, 3}, Required: true}, "ProxyJump needs OpenSSH 7.3 or later"},
+		{ClientIssue{Directive: "PubkeyAcceptedAlgorithms", Min: Version{8, 5}, Renamed: "PubkeyAcceptedKeyTypes"},
+			"PubkeyAcceptedAlgorithms needs OpenSSH 8.5 or later — written as PubkeyAcceptedKeyTypes"},
+		{ClientIssue{Directive: "ControlPersist", Min: Version{5, 6}, Effect: "a shared connection closes with its last session"},
+			"ControlPersist needs OpenSSH 5.6 or later — left out, so a shared connection closes with its last session"},
+	}
+	for _, tt := range tests {
+		if got := tt.issue.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
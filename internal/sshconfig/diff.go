@@ -0,0 +1,132 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// edit is one line of a line diff: kept (' '), removed ('-'), or added ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// Diff returns a unified-style diff from before to after, both contents of
+// the SSH config file at path, or "" when they are the same. Line endings
+// are compared as LF.
+func Diff(path, before, after string) string {
+	before, _ = normalizeEOL(before)
+	after, _ = normalizeEOL(after)
+	if before == after {
+		return ""
+	}
+	edits := lineEdits(splitLines(before), splitLines(after))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+	for start := 0; start < len(edits); {
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		// A hunk runs until the unchanged lines between two changes are
+		// too many to share one.
+		last := first
+		for i := first + 1; i < len(edits) && i-last <= 2*diffContext; i++ {
+			if edits[i].op != ' ' {
+				last = i
+			}
+		}
+		lo := max(first-diffContext, start)
+		hi := min(last+1+diffContext, len(edits))
+		writeHunk(&out, edits, lo, hi)
+		start = hi
+	}
+	return out.String()
+}
+
+// writeHunk writes edits[lo:hi] as one hunk with its @@ header.
+func writeHunk(out *strings.Builder, edits []edit, lo, hi int) {
+	oldStart, newStart := 1, 1
+	for _, e := range edits[:lo] {
+		if e.op != '+' {
+			oldStart++
+		}
+		if e.op != '-' {
+			newStart++
+		}
+	}
+	var oldCount, newCount int
+	for _, e := range edits[lo:hi] {
+		if e.op != '+' {
+			oldCount++
+		}
+		if e.op != '-' {
+			newCount++
+		}
+	}
+	// An empty side starts at the line before it, as diff -u writes it.
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, e := range edits[lo:hi] {
+		out.WriteByte(e.op)
+		out.WriteString(e.line)
+		out.WriteByte('\n')
+	}
+}
+
+// splitLines splits content into lines without their newlines.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// lineEdits returns a shortest edit script from a to b, from their longest
+// common subsequence. SSH config files are small enough for the quadratic
+// table.
+func lineEdits(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		default:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		}
+	}
+	return edits
+}
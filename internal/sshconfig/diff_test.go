@@ -0,0 +1,47 @@
+package sshconfig
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{name: "same", before: "a\nb\n", after: "a\nb\n", want: ""},
+		{name: "CRLF only", before: "a\r\nb\r\n", after: "a\nb\n", want: ""},
+		{
+			name:   "new file",
+			before: "",
+			after:  "a\nb\n",
+			want:   "--- config\n+++ config\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "changed line with context",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want:   "--- config\n+++ config\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "distant changes make two hunks",
+			before: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			after:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "--- config\n+++ config\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+				"@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name:   "close changes share a hunk",
+			before: "a\n1\n2\nb\n",
+			after:  "A\n1\n2\nB\n",
+			want:   "--- config\n+++ config\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n-b\n+B\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("config", tt.before, tt.after); got != tt.want {
+				t.Errorf("Diff =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Forward is a local port forward: connections to Local on the laptop's
+// loopback interface reach Remote on the VM.
+type Forward struct {
+	Local  int
+	Remote int
+}
+
+// ParseForward parses a forward written as "3000" (the same port on both
+// ends) or "8080:80" (local:remote).
+func ParseForward(spec string) (Forward, error) {
+	localSpec, remoteSpec, ok := strings.Cut(spec, ":")
+	if !ok {
+		remoteSpec = localSpec
+	}
+	local, err := parsePort(localSpec)
+	if err != nil {
+		return Forward{}, fmt.Errorf("forward %q: %w", spec, err)
+	}
+	remote, err := parsePort(remoteSpec)
+	if err != nil {
+		return Forward{}, fmt.Errorf("forward %q: %w", spec, err)
+	}
+	return Forward{Local: local, Remote: remote}, nil
+}
+
+// ParseForwards parses each spec with ParseForward. Two forwards may not
+// share a local port.
+func ParseForwards(specs []string) ([]Forward, error) {
+	var forwards []Forward
+	seen := make(map[int]string, len(specs))
+	for _, spec := range specs {
+		f, err := ParseForward(spec)
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := seen[f.Local]; ok {
+			return nil, fmt.Errorf("forwards %q and %q both use local port %d", prev, spec, f.Local)
+		}
+		seen[f.Local] = spec
+		forwards = append(forwards, f)
+	}
+	return forwards, nil
+}
+
+// parsePort parses a TCP port number, 1 through 65535.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port number (1-65535)", s)
+	}
+	return port, nil
+}
+
+// String returns f in the form ParseForward accepts.
+func (f Forward) String() string {
+	if f.Local == f.Remote {
+		return strconv.Itoa(f.Local)
+	}
+	return fmt.Sprintf("%d:%d", f.Local, f.Remote)
+}
+
+// Arg returns f as the value of an ssh -L option.
+func (f Forward) Arg() string {
+	return fmt.Sprintf("%d:localhost:%d", f.Local, f.Remote)
+}
+
+// ConfigLine returns f as an ssh_config LocalForward directive.
+func (f Forward) ConfigLine() string {
+	return fmt.Sprintf("LocalForward %d localhost:%d", f.Local, f.Remote)
+}
@@ -0,0 +1,60 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseForward(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Forward
+		wantErr string
+	}{
+		{spec: "3000", want: Forward{Local: 3000, Remote: 3000}},
+		{spec: "8080:80", want: Forward{Local: 8080, Remote: 80}},
+		{spec: "65535:1", want: Forward{Local: 65535, Remote: 1}},
+		{spec: "0", wantErr: "not a port number"},
+		{spec: "70000", wantErr: "not a port number"},
+		{spec: "8080:", wantErr: `"" is not a port number`},
+		{spec: "web", wantErr: "not a port number"},
+		{spec: "1:2:3", wantErr: "not a port number"},
+	}
+	for _, tt := range tests {
+		got, err := ParseForward(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseForward(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseForward(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseForwardsRejectsSharedLocalPort(t *testing.T) {
+	got, err := ParseForwards([]string{"3000", "5432:5432"})
+	if err != nil {
+		t.Fatalf("ParseForwards: %v", err)
+	}
+	if want := []Forward{{3000, 3000}, {5432, 5432}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseForwards = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseForwards([]string{"3000", "3000:80"}); err == nil || !strings.Contains(err.Error(), "local port 3000") {
+		t.Errorf("error = %v, want shared local port", err)
+	}
+}
+
+func TestForwardForms(t *testing.T) {
+	f := Forward{Local: 8080, Remote: 80}
+	if f.String() != "8080:80" || f.Arg() != "8080:localhost:80" || f.ConfigLine() != "LocalForward 8080 localhost:80" {
+		t.Errorf("forms = %q, %q, %q", f.String(), f.Arg(), f.ConfigLine())
+	}
+	if same := (Forward{Local: 3000, Remote: 3000}); same.String() != "3000" {
+		t.Errorf("String() = %q, want 3000", same.String())
+	}
+}
@@ -2,39 +2,84 @@ package sshconfig
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 )
 
+// HostKeyFile is the name of the host key store's file in the config
+// directory.
+const HostKeyFile = paths.KnownHostsFile
+
 // HostKeyStore manages SSH host key fingerprints for mint VMs using
 // trust-on-first-use (TOFU) semantics per ADR-0019. Keys are stored
 // in a simple key=value file at <configDir>/known_hosts, keyed by VM name.
+// Each value is the fingerprint, optionally followed by a space and the
+// RFC 3339 time it was recorded.
+//
+// Changes take an exclusive lock on <configDir>/known_hosts.lock and
+// replace the file atomically, so concurrent mint invocations neither lose
+// each other's keys nor see a half-written file.
 type HostKeyStore struct {
 	dir string
+	now func() time.Time
+}
+
+// HostKey is a fingerprint recorded for a VM. RecordedAt is zero for keys
+// recorded before mint kept the time.
+type HostKey struct {
+	VM          string
+	Fingerprint string
+	RecordedAt  time.Time
+}
+
+// HostKeyConflict is an imported key whose fingerprint differs from the
+// one already recorded for its VM.
+type HostKeyConflict struct {
+	VM       string
+	Existing string
+	Imported string
+}
+
+// ImportResult reports what ImportKeys did with each imported key, by VM
+// name.
+type ImportResult struct {
+	Added     []string
+	Unchanged []string
+	// Replaced lists the conflicting keys overwritten with force.
+	Replaced []string
+	// Conflicts lists the keys that differ from the recorded ones. Without
+	// force nothing is written when there are any.
+	Conflicts []HostKeyConflict
 }
 
 // NewHostKeyStore creates a HostKeyStore that reads and writes keys
 // in the given directory.
 func NewHostKeyStore(configDir string) *HostKeyStore {
-	return &HostKeyStore{dir: configDir}
+	return &HostKeyStore{dir: configDir, now: time.Now}
 }
 
 // path returns the filesystem path to the known_hosts file.
 func (s *HostKeyStore) path() string {
-	return filepath.Join(s.dir, "known_hosts")
+	return filepath.Join(s.dir, HostKeyFile)
 }
 
 // RecordKey saves or updates the fingerprint for the given VM name.
+// Recording the fingerprint already stored keeps its original time.
 func (s *HostKeyStore) RecordKey(vmName, fingerprint string) error {
-	entries, err := s.readAll()
-	if err != nil {
-		return err
-	}
-
-	entries[vmName] = fingerprint
-	return s.writeAll(entries)
+	return s.update(func(entries map[string]HostKey) (bool, error) {
+		if existing, ok := entries[vmName]; ok && existing.Fingerprint == fingerprint {
+			return false, nil
+		}
+		entries[vmName] = HostKey{VM: vmName, Fingerprint: fingerprint, RecordedAt: s.now().UTC()}
+		return true, nil
+	})
 }
 
 // CheckKey compares the given fingerprint against the stored one for vmName.
@@ -51,63 +96,197 @@ func (s *HostKeyStore) CheckKey(vmName, fingerprint string) (matched bool, exist
 		return false, "", nil
 	}
 
-	return existing == fingerprint, existing, nil
+	return existing.Fingerprint == fingerprint, existing.Fingerprint, nil
 }
 
 // RemoveKey deletes the stored fingerprint for the given VM name.
 // Does not error if the VM has no stored key.
 func (s *HostKeyStore) RemoveKey(vmName string) error {
+	return s.update(func(entries map[string]HostKey) (bool, error) {
+		if _, ok := entries[vmName]; !ok {
+			return false, nil
+		}
+		delete(entries, vmName)
+		return true, nil
+	})
+}
+
+// ListKeys returns every stored key, sorted by VM name.
+func (s *HostKeyStore) ListKeys() ([]HostKey, error) {
 	entries, err := s.readAll()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, ok := entries[vmName]; !ok {
-		return nil
+	keys := make([]HostKey, 0, len(entries))
+	for _, k := range entries {
+		keys = append(keys, k)
 	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].VM < keys[j].VM })
+	return keys, nil
+}
 
-	delete(entries, vmName)
-	return s.writeAll(entries)
+// ImportKeys merges keys into the store. Keys for VMs without a stored key
+// are added, keeping their RecordedAt (or now, when zero). A key whose
+// fingerprint differs from the stored one is a conflict: with force it
+// replaces the stored key, and without force the whole import is refused
+// and nothing is written.
+func (s *HostKeyStore) ImportKeys(keys []HostKey, force bool) (ImportResult, error) {
+	var result ImportResult
+	err := s.update(func(entries map[string]HostKey) (bool, error) {
+		result = ImportResult{}
+		changed := false
+		for _, k := range keys {
+			if k.RecordedAt.IsZero() {
+				k.RecordedAt = s.now().UTC()
+			}
+			existing, ok := entries[k.VM]
+			switch {
+			case !ok:
+				result.Added = append(result.Added, k.VM)
+			case existing.Fingerprint == k.Fingerprint:
+				result.Unchanged = append(result.Unchanged, k.VM)
+				continue
+			default:
+				result.Conflicts = append(result.Conflicts, HostKeyConflict{VM: k.VM, Existing: existing.Fingerprint, Imported: k.Fingerprint})
+				if !force {
+					continue
+				}
+				result.Replaced = append(result.Replaced, k.VM)
+			}
+			entries[k.VM] = k
+			changed = true
+		}
+		if len(result.Conflicts) > 0 && !force {
+			return false, nil
+		}
+		return changed, nil
+	})
+	return result, err
 }
 
-// readAll parses the known_hosts file into a map of vmName -> fingerprint.
-func (s *HostKeyStore) readAll() (map[string]string, error) {
-	entries := make(map[string]string)
+// update applies fn to the stored entries under the store's lock and writes
+// them back when fn reports a change.
+func (s *HostKeyStore) update(fn func(entries map[string]HostKey) (bool, error)) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	unlock, err := lockFile(s.path() + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	changed, err := fn(entries)
+	if err != nil || !changed {
+		return err
+	}
+	return s.writeAll(entries)
+}
 
-	f, err := os.Open(s.path())
+// readAll parses the known_hosts file into a map of vmName -> key.
+func (s *HostKeyStore) readAll() (map[string]HostKey, error) {
+	data, err := os.ReadFile(s.path())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return entries, nil
+			return make(map[string]HostKey), nil
 		}
 		return nil, fmt.Errorf("open known_hosts: %w", err)
 	}
-	defer f.Close()
+	return parseHostKeyEntries(data)
+}
 
-	scanner := bufio.NewScanner(f)
+// ParseHostKeys parses the contents of a known_hosts file into a map of
+// vmName -> fingerprint. Blank lines and # comments are skipped.
+func ParseHostKeys(data []byte) (map[string]string, error) {
+	entries, err := parseHostKeyEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	fingerprints := make(map[string]string, len(entries))
+	for vm, k := range entries {
+		fingerprints[vm] = k.Fingerprint
+	}
+	return fingerprints, nil
+}
+
+// parseHostKeyEntries parses the contents of a known_hosts file, including
+// the recorded times. A time that does not parse is left zero.
+func parseHostKeyEntries(data []byte) (map[string]HostKey, error) {
+	entries := make(map[string]HostKey)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			entries[parts[0]] = parts[1]
+		if len(parts) != 2 {
+			continue
 		}
+		k := HostKey{VM: parts[0]}
+		fields := strings.Fields(parts[1])
+		if len(fields) > 0 {
+			k.Fingerprint = fields[0]
+		}
+		if len(fields) > 1 {
+			k.RecordedAt, _ = time.Parse(time.RFC3339, fields[1])
+		}
+		entries[k.VM] = k
 	}
 
 	return entries, scanner.Err()
 }
 
-// writeAll persists the entries map to the known_hosts file with 0600 permissions.
-func (s *HostKeyStore) writeAll(entries map[string]string) error {
+// FormatHostKeys renders entries as the contents of a known_hosts file,
+// sorted by VM name.
+func FormatHostKeys(entries map[string]string) []byte {
+	keys := make(map[string]HostKey, len(entries))
+	for vm, fp := range entries {
+		keys[vm] = HostKey{VM: vm, Fingerprint: fp}
+	}
+	return formatHostKeyEntries(keys)
+}
+
+// formatHostKeyEntries renders entries, with their recorded times, as the
+// contents of a known_hosts file sorted by VM name.
+func formatHostKeyEntries(entries map[string]HostKey) []byte {
+	names := make([]string, 0, len(entries))
+	for vm := range entries {
+		names = append(names, vm)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, vm := range names {
+		k := entries[vm]
+		if k.RecordedAt.IsZero() {
+			fmt.Fprintf(&b, "%s=%s\n", vm, k.Fingerprint)
+		} else {
+			fmt.Fprintf(&b, "%s=%s %s\n", vm, k.Fingerprint, k.RecordedAt.UTC().Format(time.RFC3339))
+		}
+	}
+	return b.Bytes()
+}
+
+// writeAll persists the entries to the known_hosts file with 0600
+// permissions, through a temporary file renamed into place.
+func (s *HostKeyStore) writeAll(entries map[string]HostKey) error {
 	if err := os.MkdirAll(s.dir, 0o700); err != nil {
 		return fmt.Errorf("create config dir: %w", err)
 	}
 
-	var b strings.Builder
-	for vm, fp := range entries {
-		fmt.Fprintf(&b, "%s=%s\n", vm, fp)
+	tmp := s.path() + ".tmp"
+	if err := os.WriteFile(tmp, formatHostKeyEntries(entries), 0o600); err != nil {
+		return fmt.Errorf("write known_hosts: %w", err)
 	}
-
-	return os.WriteFile(s.path(), []byte(b.String()), 0o600)
+	if err := os.Rename(tmp, s.path()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write known_hosts: %w", err)
+	}
+	return nil
 }
@@ -1,9 +1,13 @@
 package sshconfig
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func newTestStore(t *testing.T) *HostKeyStore {
@@ -165,3 +169,136 @@ func TestHostKeyStoreFilePermissions(t *testing.T) {
 		t.Errorf("permissions = %o, want 0600", perm)
 	}
 }
+
+func TestRecordKeyKeepsTimeOfUnchangedKey(t *testing.T) {
+	store := newTestStore(t)
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	store.now = func() time.Time { return first }
+	if err := store.RecordKey("myvm", "SHA256:abc123"); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	store.now = func() time.Time { return first.Add(time.Hour) }
+	if err := store.RecordKey("myvm", "SHA256:abc123"); err != nil {
+		t.Fatalf("re-record: %v", err)
+	}
+
+	keys, err := store.ListKeys()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	want := []HostKey{{VM: "myvm", Fingerprint: "SHA256:abc123", RecordedAt: first}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %+v, want %+v", keys, want)
+	}
+}
+
+func TestListKeysReadsLegacyEntries(t *testing.T) {
+	store := newTestStore(t)
+	data := "# mint host keys\nold=SHA256:aaa\nnew=SHA256:bbb 2026-03-04T05:06:07Z\n"
+	if err := os.WriteFile(filepath.Join(store.dir, HostKeyFile), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := store.ListKeys()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	want := []HostKey{
+		{VM: "new", Fingerprint: "SHA256:bbb", RecordedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{VM: "old", Fingerprint: "SHA256:aaa"},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %+v, want %+v", keys, want)
+	}
+	if matched, _, _ := store.CheckKey("new", "SHA256:bbb"); !matched {
+		t.Error("timestamped entry should match its fingerprint")
+	}
+	if got, _ := ParseHostKeys([]byte(data)); !reflect.DeepEqual(got, map[string]string{"old": "SHA256:aaa", "new": "SHA256:bbb"}) {
+		t.Errorf("ParseHostKeys = %v", got)
+	}
+}
+
+func TestImportKeys(t *testing.T) {
+	recorded := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	imported := []HostKey{
+		{VM: "same", Fingerprint: "SHA256:same"},
+		{VM: "new", Fingerprint: "SHA256:new", RecordedAt: recorded},
+		{VM: "changed", Fingerprint: "SHA256:theirs"},
+	}
+
+	t.Run("conflict refuses everything", func(t *testing.T) {
+		store := newTestStore(t)
+		_ = store.RecordKey("same", "SHA256:same")
+		_ = store.RecordKey("changed", "SHA256:mine")
+
+		result, err := store.ImportKeys(imported, false)
+		if err != nil {
+			t.Fatalf("import: %v", err)
+		}
+		want := []HostKeyConflict{{VM: "changed", Existing: "SHA256:mine", Imported: "SHA256:theirs"}}
+		if !reflect.DeepEqual(result.Conflicts, want) {
+			t.Errorf("conflicts = %+v, want %+v", result.Conflicts, want)
+		}
+		if _, existing, _ := store.CheckKey("new", ""); existing != "" {
+			t.Errorf("new key %q written despite a conflict", existing)
+		}
+		if _, existing, _ := store.CheckKey("changed", ""); existing != "SHA256:mine" {
+			t.Errorf("changed = %q, want SHA256:mine", existing)
+		}
+	})
+
+	t.Run("force replaces", func(t *testing.T) {
+		store := newTestStore(t)
+		_ = store.RecordKey("same", "SHA256:same")
+		_ = store.RecordKey("changed", "SHA256:mine")
+
+		result, err := store.ImportKeys(imported, true)
+		if err != nil {
+			t.Fatalf("import: %v", err)
+		}
+		if !reflect.DeepEqual(result.Added, []string{"new"}) ||
+			!reflect.DeepEqual(result.Replaced, []string{"changed"}) ||
+			!reflect.DeepEqual(result.Unchanged, []string{"same"}) {
+			t.Errorf("result = %+v", result)
+		}
+		keys, _ := store.ListKeys()
+		got := map[string]HostKey{}
+		for _, k := range keys {
+			got[k.VM] = k
+		}
+		if got["changed"].Fingerprint != "SHA256:theirs" {
+			t.Errorf("changed = %q, want SHA256:theirs", got["changed"].Fingerprint)
+		}
+		if !got["new"].RecordedAt.Equal(recorded) {
+			t.Errorf("new recorded at %v, want %v", got["new"].RecordedAt, recorded)
+		}
+	})
+}
+
+func TestRecordKeyConcurrent(t *testing.T) {
+	store := newTestStore(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each goroutine uses its own store, as separate mint
+			// processes would.
+			s := NewHostKeyStore(store.dir)
+			if err := s.RecordKey(fmt.Sprintf("vm-%02d", i), fmt.Sprintf("SHA256:%02d", i)); err != nil {
+				t.Errorf("record vm-%02d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	keys, err := store.ListKeys()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(keys) != 20 {
+		t.Errorf("got %d keys after concurrent records, want 20", len(keys))
+	}
+}
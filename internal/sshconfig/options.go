@@ -0,0 +1,184 @@
+package sshconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Options are user SSH settings applied to every ssh mint runs against a
+// VM, for policies mint cannot know about: a mandatory ProxyJump, a
+// short-lived certificate, or an extra identity. They come from the
+// ssh_extra_args, ssh_identity_file, ssh_certificate_file, ssh_user, and
+// ssh_port config keys and the --ssh-arg flag.
+type Options struct {
+	// User is the login user on the VM, for AMIs whose default user is not
+	// the caller's. Empty keeps the caller's user.
+	User string
+	// Port is the port sshd listens on, for accounts whose security group
+	// policy rules out the default. Zero keeps the caller's port.
+	Port int
+	// ExtraArgs are passed to ssh verbatim, after mint's own options.
+	ExtraArgs []string
+	// IdentityFile is offered after the Instance Connect ephemeral key.
+	IdentityFile string
+	// CertificateFile is presented with the identities.
+	CertificateFile string
+	// Forwards are the VM's local port forwards. They are written to the
+	// VM's Host block only: Args leaves them out so that the ssh commands
+	// mint runs do not compete for the ports, and mint connect adds them
+	// itself.
+	Forwards []Forward
+	// Client is the laptop's OpenSSH version. Host block directives it
+	// does not support are left out (see ClientIssues). Zero keeps them
+	// all.
+	Client Version
+}
+
+// IsZero reports whether o adds nothing to an ssh invocation.
+func (o Options) IsZero() bool {
+	return o.User == "" && o.Port == 0 && len(o.ExtraArgs) == 0 && o.IdentityFile == "" && o.CertificateFile == ""
+}
+
+// LoginUser returns o.User, or fallback when it is not set.
+func (o Options) LoginUser(fallback string) string {
+	if o.User != "" {
+		return o.User
+	}
+	return fallback
+}
+
+// LoginPort returns o.Port, or fallback when it is not set.
+func (o Options) LoginPort(fallback int) int {
+	if o.Port != 0 {
+		return o.Port
+	}
+	return fallback
+}
+
+// Args returns the ssh arguments for o. Callers place them after mint's own
+// -i, -p, and -o options: ssh tries identities in the order given and keeps
+// the first value of any option, so the ephemeral key is offered first and
+// mint's port and host key settings cannot be overridden.
+func (o Options) Args() []string {
+	var args []string
+	if o.IdentityFile != "" {
+		args = append(args, "-i", o.IdentityFile)
+	}
+	if o.CertificateFile != "" {
+		args = append(args, "-o", "CertificateFile="+o.CertificateFile)
+	}
+	return append(args, o.ExtraArgs...)
+}
+
+// ConfigLines returns o as ssh_config directives for the managed Host
+// block, so tools that read ~/.ssh/config (VS Code Remote-SSH) apply the
+// same settings. Extra arguments are translated where ssh_config has an
+// equivalent: -o Key=Value, -J, -A, and -C. Others have no ssh_config form
+// and are left out.
+func (o Options) ConfigLines() []string {
+	var lines []string
+	if o.IdentityFile != "" {
+		lines = append(lines, "IdentityFile "+o.IdentityFile)
+	}
+	if o.CertificateFile != "" {
+		lines = append(lines, "CertificateFile "+o.CertificateFile)
+	}
+	_ = walkArgs(o.ExtraArgs, func(flag byte, value string) error {
+		switch flag {
+		case 'o':
+			key, val := splitOption(value)
+			if key != "" {
+				lines = append(lines, key+" "+val)
+			}
+		case 'J':
+			lines = append(lines, "ProxyJump "+value)
+		case 'A':
+			lines = append(lines, "ForwardAgent yes")
+		case 'C':
+			lines = append(lines, "Compression yes")
+		}
+		return nil
+	})
+	return lines
+}
+
+// managedOptions are the ssh_config options mint sets itself, lower-cased.
+var managedOptions = map[string]string{
+	"port":                  "mint always connects on its own port",
+	"identityfile":          "use ssh_identity_file to add an identity",
+	"certificatefile":       "use ssh_certificate_file",
+	"stricthostkeychecking": "mint verifies host keys itself",
+	"userknownhostsfile":    "mint verifies host keys itself",
+}
+
+// ValidateExtraArgs checks user-supplied ssh arguments. Each must be an
+// option: a bare argument would become the destination. Options mint
+// manages (-p, -i, and their -o forms) are rejected so they cannot
+// silently conflict with the port and Instance Connect identity.
+func ValidateExtraArgs(args []string) error {
+	return walkArgs(args, func(flag byte, value string) error {
+		switch flag {
+		case 'p':
+			return fmt.Errorf("-p conflicts with mint's SSH port")
+		case 'i':
+			return fmt.Errorf("-i conflicts with mint's Instance Connect identity — use ssh_identity_file to add an identity")
+		case 'o':
+			key, _ := splitOption(value)
+			if key == "" {
+				return fmt.Errorf("-o %q is not a Key=Value option", value)
+			}
+			if why, ok := managedOptions[strings.ToLower(key)]; ok {
+				return fmt.Errorf("-o %s conflicts with a mint-managed option (%s)", key, why)
+			}
+		}
+		return nil
+	})
+}
+
+// sshValueFlags are the ssh flags that take a value.
+const sshValueFlags = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// walkArgs parses ssh arguments the way ssh does, calling fn for each flag
+// with its value (empty for flags that take none). Grouped flags such as
+// -4v and attached values such as -p22 are supported.
+func walkArgs(args []string, fn func(flag byte, value string) error) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' {
+			return fmt.Errorf("unexpected argument %q: only options are allowed", arg)
+		}
+		for j := 1; j < len(arg); j++ {
+			flag := arg[j]
+			if !strings.ContainsRune(sshValueFlags, rune(flag)) {
+				if err := fn(flag, ""); err != nil {
+					return err
+				}
+				continue
+			}
+			value := arg[j+1:]
+			if value == "" {
+				if i+1 >= len(args) {
+					return fmt.Errorf("-%c requires a value", flag)
+				}
+				i++
+				value = args[i]
+			}
+			if err := fn(flag, value); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// splitOption splits an -o value ("Key=Value" or "Key Value") into its key
+// and value. The key is empty when the value is malformed.
+func splitOption(s string) (key, value string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexAny(s, "= \t")
+	if i <= 0 {
+		return "", ""
+	}
+	return s[:i], strings.TrimLeft(s[i:], "= \t")
+}
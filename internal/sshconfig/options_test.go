@@ -0,0 +1,126 @@
+package sshconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionsArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{name: "zero", opts: Options{}, want: nil},
+		{
+			name: "identity, certificate, then extra args",
+			opts: Options{
+				ExtraArgs:       []string{"-o", "ProxyJump=bastion", "-A"},
+				IdentityFile:    "~/.ssh/corp",
+				CertificateFile: "~/.ssh/corp-cert.pub",
+			},
+			want: []string{"-i", "~/.ssh/corp", "-o", "CertificateFile=~/.ssh/corp-cert.pub", "-o", "ProxyJump=bastion", "-A"},
+		},
+		{
+			name: "extra args only",
+			opts: Options{ExtraArgs: []string{"-J", "bastion"}},
+			want: []string{"-J", "bastion"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Args(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+			if tt.opts.IsZero() != (tt.want == nil) {
+				t.Errorf("IsZero() = %v", tt.opts.IsZero())
+			}
+		})
+	}
+}
+
+func TestOptionsLoginPort(t *testing.T) {
+	if got := (Options{}).LoginPort(41122); got != 41122 {
+		t.Errorf("LoginPort() = %d, want the fallback", got)
+	}
+	opts := Options{Port: 2222}
+	if got := opts.LoginPort(41122); got != 2222 {
+		t.Errorf("LoginPort() = %d, want 2222", got)
+	}
+	if opts.IsZero() {
+		t.Error("IsZero() = true with a port set")
+	}
+}
+
+func TestOptionsLoginUser(t *testing.T) {
+	if got := (Options{}).LoginUser("ubuntu"); got != "ubuntu" {
+		t.Errorf("LoginUser() = %q, want the fallback", got)
+	}
+	opts := Options{User: "dev"}
+	if got := opts.LoginUser("ubuntu"); got != "dev" {
+		t.Errorf("LoginUser() = %q, want dev", got)
+	}
+	if opts.IsZero() {
+		t.Error("IsZero() = true with a user set")
+	}
+}
+
+func TestOptionsConfigLines(t *testing.T) {
+	opts := Options{
+		ExtraArgs:       []string{"-o", "ProxyJump=bastion", "-oServerAliveInterval 30", "-AC", "-J", "jump2", "-v", "-L", "8080:localhost:8080"},
+		IdentityFile:    "~/.ssh/corp",
+		CertificateFile: "~/.ssh/corp-cert.pub",
+	}
+	want := []string{
+		"IdentityFile ~/.ssh/corp",
+		"CertificateFile ~/.ssh/corp-cert.pub",
+		"ProxyJump bastion",
+		"ServerAliveInterval 30",
+		"ForwardAgent yes",
+		"Compression yes",
+		"ProxyJump jump2",
+	}
+	if got := opts.ConfigLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigLines() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateExtraArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "empty", args: nil},
+		{name: "proxy jump", args: []string{"-o", "ProxyJump=bastion.corp"}},
+		{name: "grouped flags", args: []string{"-4Av"}},
+		{name: "attached value", args: []string{"-JBastion", "-oServerAliveInterval=30"}},
+		{name: "port", args: []string{"-p", "2222"}, wantErr: "-p conflicts"},
+		{name: "attached port", args: []string{"-p2222"}, wantErr: "-p conflicts"},
+		{name: "grouped port", args: []string{"-Ap", "2222"}, wantErr: "-p conflicts"},
+		{name: "identity", args: []string{"-i", "~/.ssh/id"}, wantErr: "use ssh_identity_file"},
+		{name: "port option", args: []string{"-o", "Port=22"}, wantErr: "-o Port conflicts"},
+		{name: "identity option any case", args: []string{"-o", "identityfile ~/.ssh/id"}, wantErr: "-o identityfile conflicts"},
+		{name: "host key option", args: []string{"-oStrictHostKeyChecking=no"}, wantErr: "mint verifies host keys"},
+		{name: "known hosts option", args: []string{"-o", "UserKnownHostsFile=/dev/null"}, wantErr: "mint verifies host keys"},
+		{name: "certificate option", args: []string{"-o", "CertificateFile=x"}, wantErr: "use ssh_certificate_file"},
+		{name: "malformed option", args: []string{"-o", "=x"}, wantErr: "not a Key=Value option"},
+		{name: "destination", args: []string{"bastion"}, wantErr: "only options are allowed"},
+		{name: "missing value", args: []string{"-J"}, wantErr: "-J requires a value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExtraArgs(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateExtraArgs(%q) error: %v", tt.args, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateExtraArgs(%q) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package sshconfig
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Project Host blocks let an SSH client, such as VS Code's Dev Containers
+// extension, land directly in a project's devcontainer. Each is a managed
+// block of its own, delimited by project markers that never match the VM
+// markers, holding a copy of the VM's connection directives plus a
+// RemoteCommand that execs into the container.
+const (
+	projectBeginPrefix = "# mint:project-begin "
+	projectEndPrefix   = "# mint:project-end "
+)
+
+// Container describes a project's devcontainer for its Host block.
+type Container struct {
+	// Project is the project name, the directory under /mint/projects.
+	Project string
+	// LocalFolder is the container's devcontainer.local_folder label, the
+	// folder on the VM it was built from.
+	LocalFolder string
+	// Workspace is the workspace folder inside the container.
+	Workspace string
+}
+
+// ProjectHost returns the Host alias of a project's container on a VM.
+func ProjectHost(vmName, project string) string {
+	return fmt.Sprintf("mint-%s-%s", vmName, project)
+}
+
+// projectMarkers returns the markers of a project's Host block.
+func projectMarkers(vmName, project string) markers {
+	key := vmName + "/" + project
+	return markers{begin: projectBeginPrefix + key, end: projectEndPrefix + key}
+}
+
+// containerCommand is the RemoteCommand that opens a shell in the
+// container built from localFolder, resolved by label when the connection
+// is made so the block survives the container being recreated.
+func containerCommand(localFolder, workspace string) string {
+	return fmt.Sprintf(`docker exec -it -w %s $(docker ps -q --filter %s | head -n 1) /bin/bash`,
+		shellQuote(workspace), shellQuote("label=devcontainer.local_folder="+localFolder))
+}
+
+// shellQuote quotes s for the remote shell that runs a RemoteCommand.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// GenerateProjectBlock creates the managed Host block for c's container on
+// the VM vmName. The connection directives match GenerateBlockWithOptions;
+// RequestTTY and a RemoteCommand that execs into the container follow them.
+func GenerateProjectBlock(vmName, hostname, user string, port int, instanceID, az, profile, region string, opts Options, c Container) string {
+	inner := hostDirectives(ProjectHost(vmName, c.Project), vmName, hostname, user, port, instanceID, az, profile, region,
+		projectDirectives(c))
+	for _, line := range opts.ConfigLines() {
+		inner += "    " + line + "\n"
+	}
+	inner, _ = fitClient(opts.Client, inner)
+	return wrapBlock(projectMarkers(vmName, c.Project), inner)
+}
+
+// projectDirectives returns the directives a project Host block adds to
+// the VM's connection directives.
+func projectDirectives(c Container) string {
+	return "    RequestTTY yes\n" +
+		"    RemoteCommand " + containerCommand(c.LocalFolder, c.Workspace) + "\n"
+}
+
+// ProjectClientIssues returns the directives a project Host block adds to
+// the VM's that o.Client does not support. Both of them are required, so
+// the issues are all kept directives.
+func (o Options) ProjectClientIssues() []ClientIssue {
+	_, issues := fitClient(o.Client, projectDirectives(Container{}))
+	return issues
+}
+
+// ProjectBlocks returns the names of the projects with a Host block for
+// vmName in the SSH config content, sorted.
+func ProjectBlocks(configContent, vmName string) []string {
+	prefix := projectBeginPrefix + vmName + "/"
+	var projects []string
+	configContent, _ = normalizeEOL(configContent)
+	for _, line := range strings.Split(configContent, "\n") {
+		if project, ok := strings.CutPrefix(line, prefix); ok && project != "" {
+			projects = append(projects, project)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// SyncProjectBlocks makes the project Host blocks for vmName in the SSH
+// config file exactly blocks, keyed by project name: each is written or
+// replaced, and the blocks of projects not in blocks are removed. It
+// returns the removed projects. With no blocks, a missing file is left
+// missing.
+func SyncProjectBlocks(configPath, vmName string, blocks map[string]string) ([]string, error) {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && len(blocks) == 0 {
+		return nil, nil
+	}
+	var pruned []string
+	err := updateConfig(configPath, func(content string) string {
+		content, pruned = ReplaceProjectBlocks(content, vmName, blocks)
+		return content
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// ReplaceProjectBlocks returns the SSH config content with the project Host
+// blocks for vmName made exactly blocks, as SyncProjectBlocks writes them,
+// and the removed projects. A block that is kept is replaced where it
+// stands; a new one is appended.
+func ReplaceProjectBlocks(configContent, vmName string, blocks map[string]string) (string, []string) {
+	content, crlf := normalizeEOL(configContent)
+	var pruned []string
+	for _, project := range ProjectBlocks(content, vmName) {
+		if _, keep := blocks[project]; !keep {
+			pruned = append(pruned, project)
+			content = removeBlock(content, projectMarkers(vmName, project))
+		}
+	}
+	projects := make([]string, 0, len(blocks))
+	for project := range blocks {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		content = replaceBlock(content, projectMarkers(vmName, project), blocks[project])
+	}
+	return restoreEOL(content, crlf), pruned
+}
+
+// RemoveProjectBlocks removes every project Host block for vmName from the
+// SSH config file and returns the removed projects.
+func RemoveProjectBlocks(configPath, vmName string) ([]string, error) {
+	return SyncProjectBlocks(configPath, vmName, nil)
+}
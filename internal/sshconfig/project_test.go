@@ -0,0 +1,172 @@
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func apiContainer() Container {
+	return Container{Project: "api", LocalFolder: "/mint/projects/api", Workspace: "/workspaces/api"}
+}
+
+func generateTestProjectBlock(c Container) string {
+	return GenerateProjectBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "", Options{}, c)
+}
+
+func TestGenerateProjectBlock(t *testing.T) {
+	block := generateTestProjectBlock(apiContainer())
+
+	for _, want := range []string{
+		"# mint:project-begin default/api\nHost mint-default-api\n",
+		"    HostName 1.2.3.4\n",
+		"    Port 41122\n",
+		"--instance-id i-abc",
+		"    RequestTTY yes\n",
+		"    RemoteCommand docker exec -it -w '/workspaces/api' $(docker ps -q --filter 'label=devcontainer.local_folder=/mint/projects/api' | head -n 1) /bin/bash\n",
+		"# mint:project-end default/api\n# mint:checksum:",
+	} {
+		if !strings.Contains(block, want) {
+			t.Errorf("block missing %q:\n%s", want, block)
+		}
+	}
+	if strings.Contains(block, "# mint:begin ") {
+		t.Error("project block must not use VM markers")
+	}
+}
+
+func TestGenerateProjectBlockOptionsFollowMintDirectives(t *testing.T) {
+	opts := Options{ExtraArgs: []string{"-o", "ProxyJump=bastion"}}
+	block := GenerateProjectBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "", opts, apiContainer())
+	if strings.Index(block, "ProxyJump bastion") < strings.Index(block, "RemoteCommand") {
+		t.Errorf("user options should follow mint's directives:\n%s", block)
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readConfig(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSyncProjectBlocksWritesAndPrunes(t *testing.T) {
+	vmBlock := GenerateBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "")
+	path := writeConfig(t, "Host github.com\n    User git\n\n"+vmBlock)
+
+	web := Container{Project: "web", LocalFolder: "/mint/projects/web", Workspace: "/workspaces/web"}
+	if _, err := SyncProjectBlocks(path, "default", map[string]string{
+		"api": generateTestProjectBlock(apiContainer()),
+		"web": generateTestProjectBlock(web),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	content := readConfig(t, path)
+	if got := ProjectBlocks(content, "default"); !reflect.DeepEqual(got, []string{"api", "web"}) {
+		t.Fatalf("ProjectBlocks = %v", got)
+	}
+
+	pruned, err := SyncProjectBlocks(path, "default", map[string]string{"api": generateTestProjectBlock(apiContainer())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pruned, []string{"web"}) {
+		t.Errorf("pruned = %v, want [web]", pruned)
+	}
+	content = readConfig(t, path)
+	if strings.Contains(content, "mint-default-web") {
+		t.Errorf("web block not pruned:\n%s", content)
+	}
+	if !strings.Contains(content, "Host github.com\n") {
+		t.Error("unrelated host removed")
+	}
+	if block, ok := ReadManagedBlock(content, "default"); !ok || block != vmBlock || HasHandEdits(content, "default") {
+		t.Errorf("VM block changed:\n%s", content)
+	}
+}
+
+func TestSyncProjectBlocksReplacesOnRebuild(t *testing.T) {
+	path := writeConfig(t, "")
+	if _, err := SyncProjectBlocks(path, "default", map[string]string{"api": generateTestProjectBlock(apiContainer())}); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilt := apiContainer()
+	rebuilt.Workspace = "/workspace"
+	if _, err := SyncProjectBlocks(path, "default", map[string]string{"api": generateTestProjectBlock(rebuilt)}); err != nil {
+		t.Fatal(err)
+	}
+	content := readConfig(t, path)
+	if n := strings.Count(content, "Host mint-default-api\n"); n != 1 {
+		t.Errorf("%d api blocks, want 1:\n%s", n, content)
+	}
+	if !strings.Contains(content, "-w '/workspace' ") || strings.Contains(content, "/workspaces/api") {
+		t.Errorf("block not regenerated:\n%s", content)
+	}
+}
+
+func TestSyncProjectBlocksKeepsOtherVMs(t *testing.T) {
+	path := writeConfig(t, "")
+	staging := GenerateProjectBlock("staging", "5.6.7.8", "ubuntu", 41122, "i-def", "us-east-1b", "", "", Options{}, apiContainer())
+	if _, err := SyncProjectBlocks(path, "staging", map[string]string{"api": staging}); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := RemoveProjectBlocks(path, "default")
+	if err != nil || len(pruned) != 0 {
+		t.Fatalf("RemoveProjectBlocks = %v, %v", pruned, err)
+	}
+	if got := ProjectBlocks(readConfig(t, path), "staging"); !reflect.DeepEqual(got, []string{"api"}) {
+		t.Errorf("staging blocks = %v", got)
+	}
+}
+
+func TestRemoveProjectBlocksMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if pruned, err := RemoveProjectBlocks(path, "default"); err != nil || pruned != nil {
+		t.Fatalf("RemoveProjectBlocks = %v, %v", pruned, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("RemoveProjectBlocks created the config file")
+	}
+}
+
+func TestVMBlockLookupMatchesWholeMarkerLine(t *testing.T) {
+	dev2 := GenerateBlock("dev2", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "")
+	dev := GenerateBlock("dev", "5.6.7.8", "ubuntu", 41122, "i-def", "us-east-1b", "", "")
+	content := dev2 + "\n" + dev
+	if block, ok := ReadManagedBlock(content, "dev"); !ok || block != dev {
+		t.Errorf("ReadManagedBlock(dev) = %q", block)
+	}
+}
+
+func TestGenerateProjectBlockKeepsRequiredDirectivesForOldClient(t *testing.T) {
+	opts := Options{Client: Version{7, 4}}
+	block := GenerateProjectBlock("default", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "", opts, apiContainer())
+	if strings.Contains(block, "StrictHostKeyChecking") {
+		t.Errorf("accept-new not left out for OpenSSH 7.4:\n%s", block)
+	}
+	if !strings.Contains(block, "    RemoteCommand docker exec") {
+		t.Errorf("RemoteCommand must be kept:\n%s", block)
+	}
+
+	want := []ClientIssue{{Directive: "RemoteCommand", Min: Version{7, 6}, Required: true}}
+	if got := opts.ProjectClientIssues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ProjectClientIssues() = %+v, want %+v", got, want)
+	}
+	if got := (Options{Client: Version{9, 6}}).ProjectClientIssues(); got != nil {
+		t.Errorf("ProjectClientIssues() for 9.6 = %+v, want none", got)
+	}
+}
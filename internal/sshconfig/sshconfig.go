@@ -9,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/SpiceLabsHQ/Mint/internal/paths"
 )
 
 // beginMarker returns the begin marker for a VM's managed block.
@@ -21,6 +23,17 @@ func endMarker(vmName string) string {
 	return fmt.Sprintf("# mint:end %s", vmName)
 }
 
+// markers are the begin and end lines that delimit a managed block.
+type markers struct {
+	begin string
+	end   string
+}
+
+// vmMarkers returns the markers of a VM's managed block.
+func vmMarkers(vmName string) markers {
+	return markers{begin: beginMarker(vmName), end: endMarker(vmName)}
+}
+
 // checksumPrefix is the prefix for the checksum line.
 const checksumPrefix = "# mint:checksum:"
 
@@ -44,6 +57,44 @@ func computeChecksum(innerContent string) string {
 // flags are added to the aws CLI invocation inside the ProxyCommand so that
 // the shelled-out aws command uses the same credentials as the Go SDK.
 func GenerateBlock(vmName, hostname, user string, port int, instanceID, az, profile, region string) string {
+	return GenerateBlockWithOptions(vmName, hostname, user, port, instanceID, az, profile, region, Options{})
+}
+
+// GenerateBlockWithOptions is GenerateBlock with the user's SSH options
+// appended to the Host block as ssh_config directives, and a LocalForward
+// line for each of opts.Forwards. They follow mint's own directives, so for
+// any option set in both, mint's value wins, as it does on the ssh command
+// line. Directives opts.Client does not support are
+// left out or rewritten (see Options.ClientIssues).
+func GenerateBlockWithOptions(vmName, hostname, user string, port int, instanceID, az, profile, region string, opts Options) string {
+	inner, _ := vmDirectives(vmName, hostname, user, port, instanceID, az, profile, region, opts)
+	return wrapBlock(vmMarkers(vmName), inner)
+}
+
+// vmDirectives returns the directives of the VM Host block fitted to
+// opts.Client, with the issues fitting them raised.
+func vmDirectives(vmName, hostname, user string, port int, instanceID, az, profile, region string, opts Options) (string, []ClientIssue) {
+	inner := hostDirectives("mint-"+vmName, vmName, hostname, user, port, instanceID, az, profile, region, "")
+	for _, line := range opts.ConfigLines() {
+		inner += "    " + line + "\n"
+	}
+	for _, f := range opts.Forwards {
+		inner += "    " + f.ConfigLine() + "\n"
+	}
+	return fitClient(opts.Client, inner)
+}
+
+// ClientIssues returns the directives of a VM Host block for o that
+// o.Client does not support, as GenerateBlockWithOptions handles them.
+func (o Options) ClientIssues() []ClientIssue {
+	_, issues := vmDirectives("", "", o.LoginUser(""), 0, "", "", "", "", o)
+	return issues
+}
+
+// hostDirectives returns the Host block for alias that connects to the VM
+// vmName, with extra (mint's own further directives, each ending in a
+// newline) after the connection directives.
+func hostDirectives(alias, vmName, hostname, user string, port int, instanceID, az, profile, region, extra string) string {
 	keyPath := fmt.Sprintf("~/.config/mint/ssh_key_%s", vmName)
 
 	// Build optional --profile / --region flags for the aws CLI command.
@@ -75,31 +126,70 @@ func GenerateBlock(vmName, hostname, user string, port int, instanceID, az, prof
 			"--no-cli-pager >/dev/null && nc %%h %%p'",
 		keyPath, awsFlags, instanceID, user, az)
 
-	inner := fmt.Sprintf("Host mint-%s\n"+
+	return fmt.Sprintf("Host %s\n"+
 		"    HostName %s\n"+
 		"    User %s\n"+
 		"    Port %d\n"+
 		"    StrictHostKeyChecking accept-new\n"+
 		"    IdentityFile %s\n"+
 		"    IdentitiesOnly yes\n"+
-		"    ProxyCommand %s\n",
-		vmName, hostname, user, port, keyPath, proxyCmd)
-
-	begin := beginMarker(vmName)
-	end := endMarker(vmName)
-	checksum := computeChecksum(inner)
+		"    ProxyCommand %s\n%s",
+		alias, hostname, user, port, keyPath, proxyCmd, extra)
+}
 
-	return fmt.Sprintf("%s\n%s%s\n%s%s\n", begin, inner, end, checksumPrefix, checksum)
+// wrapBlock wraps the Host block inner in m and a checksum of inner.
+func wrapBlock(m markers, inner string) string {
+	return fmt.Sprintf("%s\n%s%s\n%s%s\n", m.begin, inner, m.end, checksumPrefix, computeChecksum(inner))
 }
 
 // ReadManagedBlock extracts the managed block for the given VM from the SSH
 // config content. Returns the full block (including markers and checksum) and
 // true if found, or empty string and false if not present.
 func ReadManagedBlock(configContent, vmName string) (string, bool) {
-	begin := beginMarker(vmName)
-	end := endMarker(vmName)
+	configContent, _ = normalizeEOL(configContent)
+	return readBlock(configContent, vmMarkers(vmName))
+}
+
+// normalizeEOL returns content with CRLF line endings, as written by
+// Windows editors, converted to LF, and whether it had any so a rewrite
+// can restore them.
+func normalizeEOL(content string) (string, bool) {
+	if !strings.Contains(content, "\r\n") {
+		return content, false
+	}
+	return strings.ReplaceAll(content, "\r\n", "\n"), true
+}
 
-	beginIdx := strings.Index(configContent, begin)
+// restoreEOL converts the LF line endings of content back to CRLF when
+// crlf is set.
+func restoreEOL(content string, crlf bool) string {
+	if !crlf {
+		return content
+	}
+	return strings.ReplaceAll(content, "\n", "\r\n")
+}
+
+// lineIndex returns the index of the first line of content that is exactly
+// line, or -1. Matching whole lines keeps the block for VM "dev" apart from
+// the block for "dev2".
+func lineIndex(content, line string) int {
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], line)
+		if i == -1 {
+			return -1
+		}
+		i += offset
+		after := i + len(line)
+		if (i == 0 || content[i-1] == '\n') && (after == len(content) || content[after] == '\n') {
+			return i
+		}
+		offset = i + 1
+	}
+}
+
+// readBlock extracts the managed block delimited by m from content.
+func readBlock(configContent string, m markers) (string, bool) {
+	beginIdx := lineIndex(configContent, m.begin)
 	if beginIdx == -1 {
 		return "", false
 	}
@@ -112,7 +202,7 @@ func ReadManagedBlock(configContent, vmName string) (string, bool) {
 	for _, line := range lines {
 		block.WriteString(line)
 		trimmed := strings.TrimRight(line, "\n")
-		if strings.HasPrefix(trimmed, end) {
+		if trimmed == m.end {
 			foundEnd = true
 			continue
 		}
@@ -133,22 +223,25 @@ func ReadManagedBlock(configContent, vmName string) (string, bool) {
 // hand-edited by comparing the stored checksum against a fresh computation
 // of the inner content. Returns false if no block is found.
 func HasHandEdits(configContent, vmName string) bool {
-	block, ok := ReadManagedBlock(configContent, vmName)
+	configContent, _ = normalizeEOL(configContent)
+	return hasHandEdits(configContent, vmMarkers(vmName))
+}
+
+// hasHandEdits is HasHandEdits for the block delimited by m.
+func hasHandEdits(configContent string, m markers) bool {
+	block, ok := readBlock(configContent, m)
 	if !ok {
 		return false
 	}
 
-	begin := beginMarker(vmName)
-	end := endMarker(vmName)
-
 	// Extract inner content between begin and end markers.
-	beginIdx := strings.Index(block, begin)
-	endIdx := strings.Index(block, end)
+	beginIdx := lineIndex(block, m.begin)
+	endIdx := lineIndex(block, m.end)
 	if beginIdx == -1 || endIdx == -1 {
 		return false
 	}
 
-	inner := block[beginIdx+len(begin)+1 : endIdx]
+	inner := block[beginIdx+len(m.begin)+1 : endIdx]
 
 	// Extract stored checksum.
 	checksumIdx := strings.Index(block, checksumPrefix)
@@ -164,9 +257,60 @@ func HasHandEdits(configContent, vmName string) bool {
 // in the SSH config file. Creates the file and parent directories if they
 // don't exist. Sets file permissions to 0600.
 func WriteManagedBlock(configPath, vmName, block string) error {
+	return updateConfig(configPath, func(content string) string {
+		return appendBlock(removeBlock(content, vmMarkers(vmName)), block)
+	})
+}
+
+// ReplaceManagedBlock returns the SSH config content with the managed block
+// for the given VM replaced by block where it stands, or block appended when
+// there is none. Content outside the block is kept byte for byte, and so are
+// CRLF line endings.
+func ReplaceManagedBlock(configContent, vmName, block string) string {
+	content, crlf := normalizeEOL(configContent)
+	return restoreEOL(replaceBlock(content, vmMarkers(vmName), block), crlf)
+}
+
+// IsStale reports whether the SSH config content has a managed block for
+// the given VM that no longer connects to hostname and instanceID, as after
+// the VM was recreated or came back with a new address. A missing block is
+// not stale.
+func IsStale(configContent, vmName, hostname, instanceID string) bool {
+	block, ok := ReadManagedBlock(configContent, vmName)
+	if !ok {
+		return false
+	}
+	return lineIndex(block, "    HostName "+hostname) == -1 ||
+		!strings.Contains(block, "--instance-id "+instanceID+" ")
+}
+
+// ReadConfig returns the content of the SSH config file, or "" when it does
+// not exist.
+func ReadConfig(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read ssh config: %w", err)
+	}
+	return string(data), nil
+}
+
+// WriteConfig writes content to the SSH config file. Creates the file (0600)
+// and parent directories if they don't exist; an existing file keeps its
+// permissions.
+func WriteConfig(configPath, content string) error {
+	if err := os.MkdirAll(filepath.Dir(configPath), paths.PrivateDirMode); err != nil {
+		return fmt.Errorf("create ssh config dir: %w", err)
+	}
+	return os.WriteFile(configPath, []byte(content), paths.PrivateFileMode)
+}
+
+// updateConfig rewrites the SSH config file with update applied to its
+// content. Creates the file and parent directories if they don't exist.
+// update sees LF line endings; a file that used CRLF keeps them.
+func updateConfig(configPath string, update func(content string) string) error {
 	// Ensure parent directory exists.
 	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0o700); err != nil {
+	if err := os.MkdirAll(dir, paths.PrivateDirMode); err != nil {
 		return fmt.Errorf("create ssh config dir: %w", err)
 	}
 
@@ -175,21 +319,20 @@ func WriteManagedBlock(configPath, vmName, block string) error {
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("read ssh config: %w", err)
 	}
-	content := string(data)
 
-	// Remove existing block for this VM if present.
-	content = removeManagedBlockFromContent(content, vmName)
+	content, crlf := normalizeEOL(string(data))
+	return os.WriteFile(configPath, []byte(restoreEOL(update(content), crlf)), paths.PrivateFileMode)
+}
 
-	// Append the new block.
+// appendBlock appends block to content, separated by a blank line.
+func appendBlock(content, block string) string {
 	if len(content) > 0 && !strings.HasSuffix(content, "\n") {
 		content += "\n"
 	}
 	if len(content) > 0 && !strings.HasSuffix(content, "\n\n") {
 		content += "\n"
 	}
-	content += block
-
-	return os.WriteFile(configPath, []byte(content), 0o600)
+	return content + block
 }
 
 // RemoveManagedBlock removes the managed block for the given VM from the
@@ -205,12 +348,12 @@ func RemoveManagedBlock(configPath, vmName string) (bool, error) {
 		return false, fmt.Errorf("read ssh config: %w", err)
 	}
 
-	content := string(data)
+	content, crlf := normalizeEOL(string(data))
 	// Check whether the block is actually present before removing it.
 	_, found := ReadManagedBlock(content, vmName)
 
-	updated := removeManagedBlockFromContent(content, vmName)
-	if err := os.WriteFile(configPath, []byte(updated), 0o600); err != nil {
+	updated := restoreEOL(removeManagedBlockFromContent(content, vmName), crlf)
+	if err := os.WriteFile(configPath, []byte(updated), paths.PrivateFileMode); err != nil {
 		return false, err
 	}
 	return found, nil
@@ -219,23 +362,56 @@ func RemoveManagedBlock(configPath, vmName string) (bool, error) {
 // removeManagedBlockFromContent removes the managed block for vmName from
 // the content string, including the checksum line.
 func removeManagedBlockFromContent(content, vmName string) string {
-	begin := beginMarker(vmName)
-	end := endMarker(vmName)
+	return removeBlock(content, vmMarkers(vmName))
+}
 
-	beginIdx := strings.Index(content, begin)
-	if beginIdx == -1 {
+// removeBlock removes the managed block delimited by m from content,
+// including the checksum line.
+func removeBlock(content string, m markers) string {
+	start, end, ok := blockSpan(content, m)
+	if !ok {
 		return content
 	}
 
+	result := content[:start] + content[end:]
+
+	// Clean up extra blank lines.
+	for strings.Contains(result, "\n\n\n") {
+		result = strings.ReplaceAll(result, "\n\n\n", "\n\n")
+	}
+
+	return result
+}
+
+// replaceBlock replaces the managed block delimited by m in content with
+// block where it stands, leaving the rest of content untouched, or appends
+// block when content has none.
+func replaceBlock(content string, m markers, block string) string {
+	start, end, ok := blockSpan(content, m)
+	if !ok {
+		return appendBlock(content, block)
+	}
+	return content[:start] + block + content[end:]
+}
+
+// blockSpan returns the byte range in content of the managed block
+// delimited by m, from its begin marker through the newline ending its
+// checksum line.
+func blockSpan(content string, m markers) (start, end int, ok bool) {
+	beginIdx := lineIndex(content, m.begin)
+	if beginIdx == -1 {
+		return 0, 0, false
+	}
+
 	rest := content[beginIdx:]
-	endIdx := strings.Index(rest, end)
+	endIdx := lineIndex(rest, m.end)
 	if endIdx == -1 {
-		return content
+		return 0, 0, false
 	}
 
 	// Find end of end-marker line.
-	afterEnd := rest[endIdx+len(end):]
-	cutEnd := endIdx + len(end)
+	afterEnd := rest[endIdx+len(m.end):]
+	cutEnd := endIdx + len(m.end)
 
 	// Skip newline after end marker.
 	if len(afterEnd) > 0 && afterEnd[0] == '\n' {
@@ -253,12 +429,5 @@ func removeManagedBlockFromContent(content, vmName string) string {
 		}
 	}
 
-	result := content[:beginIdx] + rest[cutEnd:]
-
-	// Clean up extra blank lines.
-	for strings.Contains(result, "\n\n\n") {
-		result = strings.ReplaceAll(result, "\n\n\n", "\n\n")
-	}
-
-	return result
+	return beginIdx, beginIdx + cutEnd, true
 }
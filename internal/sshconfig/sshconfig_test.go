@@ -392,6 +392,76 @@ func TestRemoveManagedBlock_FileNotExist(t *testing.T) {
 	}
 }
 
+// crlf converts content to CRLF line endings, as a Windows editor saves it.
+func crlf(content string) string {
+	return strings.ReplaceAll(content, "\n", "\r\n")
+}
+
+func TestReadManagedBlock_CRLF(t *testing.T) {
+	block := GenerateBlock("testvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "")
+	content := crlf("Host example\n\n" + block)
+
+	got, ok := ReadManagedBlock(content, "testvm")
+	if !ok {
+		t.Fatal("expected to find the block in a CRLF file")
+	}
+	if got != block {
+		t.Errorf("ReadManagedBlock() = %q, want %q", got, block)
+	}
+	if HasHandEdits(content, "testvm") {
+		t.Error("CRLF line endings alone should not report hand edits")
+	}
+}
+
+func TestWriteManagedBlock_PreservesCRLF(t *testing.T) {
+	// A backslash is an ordinary file name character here and a separator
+	// on Windows; either way the path must be used as given.
+	dir := filepath.Join(t.TempDir(), `Users\alice`)
+	path := filepath.Join(dir, ".ssh", "config")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	old := GenerateBlock("testvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "")
+	if err := os.WriteFile(path, []byte(crlf("Host example\n    HostName example.com\n\n"+old)), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	block := GenerateBlock("testvm", "5.6.7.8", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "")
+	if err := WriteManagedBlock(path, "testvm", block); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+	want := crlf("Host example\n    HostName example.com\n\n" + block)
+	if string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestRemoveManagedBlock_PreservesCRLF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	block := GenerateBlock("testvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "")
+	if err := os.WriteFile(path, []byte(crlf("Host example\n"+block+"Host other\n")), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	found, err := RemoveManagedBlock(path, "testvm")
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if !found {
+		t.Error("expected found=true when block was present")
+	}
+
+	data, _ := os.ReadFile(path)
+	if want := crlf("Host example\nHost other\n"); string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
 func TestGenerateBlockWithProfile(t *testing.T) {
 	block := GenerateBlock("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "my-sso-profile", "")
 
@@ -442,3 +512,158 @@ func TestGenerateBlockStderrNotSuppressed(t *testing.T) {
 		t.Errorf("aws CLI stdout should still be suppressed via >/dev/null, got:\n%s", block)
 	}
 }
+
+func TestGenerateBlockWithOptions(t *testing.T) {
+	opts := Options{
+		ExtraArgs:    []string{"-o", "ProxyJump=bastion.corp"},
+		IdentityFile: "~/.ssh/corp",
+	}
+	block := GenerateBlockWithOptions("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "", opts)
+
+	proxyCmd := strings.Index(block, "ProxyCommand")
+	for _, line := range []string{"    IdentityFile ~/.ssh/corp\n", "    ProxyJump bastion.corp\n"} {
+		i := strings.Index(block, line)
+		if i < 0 {
+			t.Errorf("missing %q in block:\n%s", line, block)
+			continue
+		}
+		// mint's directives come first so they win for any option set twice.
+		if i < proxyCmd {
+			t.Errorf("%q should follow mint's directives, got:\n%s", line, block)
+		}
+	}
+
+	// Options are covered by the checksum.
+	if block == GenerateBlock("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "") {
+		t.Error("options did not change the block")
+	}
+	if HasHandEdits(block, "myvm") {
+		t.Error("generated block with options reports hand edits")
+	}
+}
+
+func TestGenerateBlockLocalForwards(t *testing.T) {
+	opts := Options{Forwards: []Forward{{Local: 3000, Remote: 3000}, {Local: 8080, Remote: 80}}}
+	block := GenerateBlockWithOptions("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "", opts)
+	for _, line := range []string{"    LocalForward 3000 localhost:3000\n", "    LocalForward 8080 localhost:80\n"} {
+		if !strings.Contains(block, line) {
+			t.Errorf("missing %q in block:\n%s", line, block)
+		}
+	}
+
+	// Only the VM's block forwards, so a project host connected alongside
+	// it does not compete for the ports.
+	project := GenerateProjectBlock("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "", opts,
+		Container{Project: "app", LocalFolder: "/home/ubuntu/app", Workspace: "/workspaces/app"})
+	if strings.Contains(project, "LocalForward") {
+		t.Errorf("project block has forwards:\n%s", project)
+	}
+}
+
+func TestGenerateBlockFitsClientVersion(t *testing.T) {
+	opts := Options{ExtraArgs: []string{"-o", "ControlPersist=10m", "-o", "PubkeyAcceptedAlgorithms=+ssh-rsa"}}
+	tests := []struct {
+		client Version
+		want   []string
+		absent []string
+		issues int
+	}{
+		{Version{}, []string{"StrictHostKeyChecking accept-new", "ControlPersist 10m", "PubkeyAcceptedAlgorithms +ssh-rsa"}, nil, 0},
+		{Version{9, 6}, []string{"StrictHostKeyChecking accept-new", "ControlPersist 10m", "PubkeyAcceptedAlgorithms +ssh-rsa"}, nil, 0},
+		{Version{8, 2}, []string{"StrictHostKeyChecking accept-new", "PubkeyAcceptedKeyTypes +ssh-rsa"}, []string{"PubkeyAcceptedAlgorithms"}, 1},
+		{Version{7, 4}, []string{"ControlPersist 10m", "ProxyCommand"}, []string{"StrictHostKeyChecking"}, 2},
+		{Version{5, 3}, []string{"IdentitiesOnly yes"}, []string{"StrictHostKeyChecking", "ControlPersist", "PubkeyAccepted"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.client.String(), func(t *testing.T) {
+			opts.Client = tt.client
+			block := GenerateBlockWithOptions("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc123", "us-east-1a", "", "", opts)
+			for _, want := range tt.want {
+				if !strings.Contains(block, "    "+want) {
+					t.Errorf("missing %q:\n%s", want, block)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(block, absent) {
+					t.Errorf("%q not left out:\n%s", absent, block)
+				}
+			}
+			if HasHandEdits(block, "myvm") {
+				t.Error("fitted block reports hand edits")
+			}
+			if got := len(opts.ClientIssues()); got != tt.issues {
+				t.Errorf("ClientIssues() = %+v, want %d", opts.ClientIssues(), tt.issues)
+			}
+		})
+	}
+}
+
+func TestReplaceManagedBlockInPlace(t *testing.T) {
+	oldBlock := GenerateBlock("myvm", "1.2.3.4", "ubuntu", 41122, "i-old", "us-east-1a", "", "")
+	newBlock := GenerateBlock("myvm", "5.6.7.8", "ubuntu", 41122, "i-new", "us-east-1a", "", "")
+	content := "Host a\n    User x\n\n" + oldBlock + "\nHost b\n    User y\n"
+
+	got := ReplaceManagedBlock(content, "myvm", newBlock)
+	want := "Host a\n    User x\n\n" + newBlock + "\nHost b\n    User y\n"
+	if got != want {
+		t.Errorf("ReplaceManagedBlock =\n%s\nwant:\n%s", got, want)
+	}
+
+	crlf := strings.ReplaceAll(content, "\n", "\r\n")
+	if got := ReplaceManagedBlock(crlf, "myvm", newBlock); got != strings.ReplaceAll(want, "\n", "\r\n") {
+		t.Errorf("CRLF not kept:\n%q", got)
+	}
+
+	if got := ReplaceManagedBlock("Host a\n", "myvm", newBlock); got != "Host a\n\n"+newBlock {
+		t.Errorf("missing block not appended:\n%s", got)
+	}
+}
+
+func TestIsStale(t *testing.T) {
+	content := "Host a\n\n" + GenerateBlock("myvm", "1.2.3.4", "ubuntu", 41122, "i-abc", "us-east-1a", "", "")
+	tests := []struct {
+		name       string
+		vmName     string
+		hostname   string
+		instanceID string
+		want       bool
+	}{
+		{name: "current", vmName: "myvm", hostname: "1.2.3.4", instanceID: "i-abc", want: false},
+		{name: "new address", vmName: "myvm", hostname: "1.2.3.40", instanceID: "i-abc", want: true},
+		{name: "new instance", vmName: "myvm", hostname: "1.2.3.4", instanceID: "i-ab", want: true},
+		{name: "no block", vmName: "other", hostname: "9.9.9.9", instanceID: "i-x", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStale(content, tt.vmName, tt.hostname, tt.instanceID); got != tt.want {
+				t.Errorf("IsStale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteConfigKeepsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ssh", "config")
+	if err := WriteConfig(path, "Host a\n"); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("new file permissions = %o, want 0600", info.Mode().Perm())
+	}
+
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteConfig(path, "Host b\n"); err != nil {
+		t.Fatalf("WriteConfig: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("permissions = %o, want the existing 0640", info.Mode().Perm())
+	}
+	if got, _ := ReadConfig(path); got != "Host b\n" {
+		t.Errorf("content = %q", got)
+	}
+	if got, err := ReadConfig(filepath.Join(t.TempDir(), "missing")); got != "" || err != nil {
+		t.Errorf("ReadConfig(missing) = %q, %v; want empty", got, err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package sshmux keeps one multiplexed OpenSSH connection (ControlMaster)
+// per destination for the life of a command, so the command's remote calls
+// share a single handshake and Instance Connect key push instead of paying
+// for both on every call.
+package sshmux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPersist is how long a master connection outlives its last session.
+// Close ends masters at once; the timeout only reaps those left behind by a
+// process that was killed.
+const DefaultPersist = time.Minute
+
+// Dest identifies one master connection. The instance ID is part of it so
+// a command that replaces the instance behind an address, such as mint
+// recreate, never reaches the new instance over the old one's connection.
+type Dest struct {
+	InstanceID string
+	Host       string
+	Port       int
+	User       string
+}
+
+// target is the ssh destination argument for d.
+func (d Dest) target() string {
+	return d.User + "@" + d.Host
+}
+
+// ExecFunc runs ssh with args, writing its stderr to stderr.
+type ExecFunc func(ctx context.Context, stderr io.Writer, args ...string) error
+
+// StartFunc prepares a new master connection. It returns the ssh arguments
+// that authenticate to the destination (options and user@host, no remote
+// command), and done, called once the master has started or failed, to
+// clean up what the arguments refer to, such as an ephemeral key file.
+type StartFunc func(ctx context.Context) (args []string, done func(), err error)
+
+// MasterError is returned by Connect when ssh fails to start a master
+// connection. Stderr holds what ssh printed, which tells a refused login
+// from an unreachable host.
+type MasterError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *MasterError) Error() string {
+	return fmt.Sprintf("starting SSH master: %v (stderr: %s)", e.Err, e.Stderr)
+}
+
+func (e *MasterError) Unwrap() error { return e.Err }
+
+// master is the state of one destination's connection.
+type master struct {
+	mu   sync.Mutex
+	path string
+	up   bool
+}
+
+// Pool holds the master connections of one command. Its control sockets
+// live in a private temporary directory created on first use. The caller
+// must Close it when the command ends.
+type Pool struct {
+	exec    ExecFunc
+	persist time.Duration
+
+	mu      sync.Mutex
+	dir     string
+	masters map[Dest]*master
+	closed  bool
+}
+
+// NewPool returns a Pool that runs the ssh binary.
+func NewPool() *Pool {
+	return &Pool{
+		exec:    execSSH,
+		persist: DefaultPersist,
+		masters: make(map[Dest]*master),
+	}
+}
+
+// WithExec overrides how ssh is run (for testing).
+func (p *Pool) WithExec(fn ExecFunc) *Pool {
+	p.exec = fn
+	return p
+}
+
+// Connect returns the control socket of d's master connection, starting
+// one with start when there is none or the previous one has died. start is
+// only called when a new connection is made, so a caller that pushes an
+// Instance Connect key in it pushes one per connection, not per command.
+// An error from start is returned unchanged; ssh failing to start the
+// master is a *MasterError.
+func (p *Pool) Connect(ctx context.Context, d Dest, start StartFunc) (string, error) {
+	m, err := p.master(d)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.up && p.exec(ctx, io.Discard, "-S", m.path, "-O", "check", d.target()) == nil {
+		return m.path, nil
+	}
+	m.up = false
+
+	args, done, err := start(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	// -f returns once the master has authenticated and is listening on its
+	// control socket. Its stderr goes to a file: a pipe would be held open
+	// by the backgrounded master, and the command would never finish.
+	stderr, err := os.CreateTemp(p.dir, "err-")
+	if err != nil {
+		return "", fmt.Errorf("starting SSH master: %w", err)
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+
+	masterArgs := append([]string{
+		"-M", "-N", "-f",
+		"-S", m.path,
+		"-o", "ControlPersist=" + strconv.Itoa(int(p.persist.Seconds())),
+	}, args...)
+	if err := p.exec(ctx, stderr, masterArgs...); err != nil {
+		msg, _ := os.ReadFile(stderr.Name())
+		return "", &MasterError{Err: err, Stderr: strings.TrimSpace(string(msg))}
+	}
+	m.up = true
+	return m.path, nil
+}
+
+// master returns d's connection state, creating the socket directory and
+// assigning d a socket in it on first use. Sockets are numbered, not named
+// after d, because a Unix socket path is limited to about 100 bytes.
+func (p *Pool) master(d Dest) (*master, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, fmt.Errorf("SSH connection pool is closed")
+	}
+	if m, ok := p.masters[d]; ok {
+		return m, nil
+	}
+	if p.dir == "" {
+		dir, err := os.MkdirTemp("", "mint-ssh-")
+		if err != nil {
+			return nil, fmt.Errorf("creating SSH control socket directory: %w", err)
+		}
+		p.dir = dir
+	}
+	m := &master{path: fmt.Sprintf("%s/%d", p.dir, len(p.masters))}
+	p.masters[d] = m
+	return m, nil
+}
+
+// Args returns the ssh options that run a session over the master
+// connection listening on path. ControlMaster=no keeps ssh from becoming a
+// master itself.
+func Args(path string) []string {
+	return []string{"-S", path, "-o", "ControlMaster=no"}
+}
+
+// Close ends every master connection and removes the socket directory. It
+// is safe to call more than once, and Connect fails after it.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for d, m := range p.masters {
+		m.mu.Lock()
+		if m.up {
+			_ = p.exec(ctx, io.Discard, "-S", m.path, "-O", "exit", d.target())
+			m.up = false
+		}
+		m.mu.Unlock()
+	}
+	if p.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(p.dir)
+}
+
+// execSSH is the production ExecFunc. A *os.File stderr is handed to ssh
+// directly, with no copying goroutine to wait on.
+func execSSH(ctx context.Context, stderr io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
@@ -0,0 +1,137 @@
+package sshmux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeSSH records the ssh invocations of a Pool and answers them: a master
+// start succeeds unless failStart is set, and -O check succeeds while the
+// master is alive.
+type fakeSSH struct {
+	calls     []string
+	alive     map[string]bool
+	failStart string
+}
+
+func (f *fakeSSH) exec(_ context.Context, stderr io.Writer, args ...string) error {
+	f.calls = append(f.calls, strings.Join(args, " "))
+	path := args[slices.Index(args, "-S")+1]
+	switch {
+	case slices.Contains(args, "-M"):
+		if f.failStart != "" {
+			fmt.Fprintln(stderr, f.failStart)
+			return fmt.Errorf("exit status 255")
+		}
+		f.alive[path] = true
+	case slices.Contains(args, "check"):
+		if !f.alive[path] {
+			return fmt.Errorf("exit status 255")
+		}
+	case slices.Contains(args, "exit"):
+		delete(f.alive, path)
+	}
+	return nil
+}
+
+// countStarts returns a StartFunc that counts its calls and whose done
+// counts the cleanups.
+func countStarts(starts, cleanups *int) StartFunc {
+	return func(context.Context) ([]string, func(), error) {
+		*starts++
+		return []string{"-i", "key", "ubuntu@1.2.3.4"}, func() { *cleanups++ }, nil
+	}
+}
+
+func TestPoolReusesMaster(t *testing.T) {
+	fake := &fakeSSH{alive: map[string]bool{}}
+	p := NewPool().WithExec(fake.exec)
+	ctx := context.Background()
+	d := Dest{InstanceID: "i-1", Host: "1.2.3.4", Port: 22, User: "ubuntu"}
+
+	var starts, cleanups int
+	var paths []string
+	for range 3 {
+		path, err := p.Connect(ctx, d, countStarts(&starts, &cleanups))
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	if starts != 1 || cleanups != 1 {
+		t.Errorf("started %d masters with %d cleanups, want 1 and 1", starts, cleanups)
+	}
+	if paths[0] != paths[1] || paths[1] != paths[2] {
+		t.Errorf("control paths = %v, want one", paths)
+	}
+	if want := "-M -N -f -S " + paths[0] + " -o ControlPersist=60 -i key ubuntu@1.2.3.4"; fake.calls[0] != want {
+		t.Errorf("master started with %q, want %q", fake.calls[0], want)
+	}
+
+	// A master that died is started again.
+	delete(fake.alive, paths[0])
+	if _, err := p.Connect(ctx, d, countStarts(&starts, &cleanups)); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if starts != 2 {
+		t.Errorf("started %d masters, want a second after the first died", starts)
+	}
+
+	// Another instance behind the same address gets its own master.
+	other, err := p.Connect(ctx, Dest{InstanceID: "i-2", Host: "1.2.3.4", Port: 22, User: "ubuntu"}, countStarts(&starts, &cleanups))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if other == paths[0] {
+		t.Errorf("a new instance shares control path %s", other)
+	}
+
+	dir := p.dir
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(fake.alive) != 0 {
+		t.Errorf("masters still alive after Close: %v", fake.alive)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("socket directory %s not removed: %v", dir, err)
+	}
+	if _, err := p.Connect(ctx, d, countStarts(&starts, &cleanups)); err == nil {
+		t.Error("Connect after Close should fail")
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestPoolStartFailure(t *testing.T) {
+	fake := &fakeSSH{alive: map[string]bool{}, failStart: "ubuntu@1.2.3.4: Permission denied (publickey)."}
+	p := NewPool().WithExec(fake.exec)
+	defer p.Close()
+
+	var starts, cleanups int
+	d := Dest{InstanceID: "i-1", Host: "1.2.3.4", Port: 22, User: "ubuntu"}
+	_, err := p.Connect(context.Background(), d, countStarts(&starts, &cleanups))
+	var masterErr *MasterError
+	if !errors.As(err, &masterErr) || masterErr.Stderr != "ubuntu@1.2.3.4: Permission denied (publickey)." {
+		t.Fatalf("error = %v, want a MasterError with the master's stderr", err)
+	}
+	if cleanups != 1 {
+		t.Errorf("cleanups = %d, want 1 after a failed start", cleanups)
+	}
+
+	// The next call tries again rather than using the failed master.
+	fake.failStart = ""
+	if _, err := p.Connect(context.Background(), d, countStarts(&starts, &cleanups)); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if starts != 2 {
+		t.Errorf("starts = %d, want 2", starts)
+	}
+}
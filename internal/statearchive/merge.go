@@ -0,0 +1,315 @@
+package statearchive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/spf13/viper"
+
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
+)
+
+// Change is one entry an import adds or may overwrite: a config key, a VM's
+// host key, or a whole file such as an override.
+type Change struct {
+	// File is the file's name in the archive, e.g. "known_hosts".
+	File string
+	// Key is the entry within File; empty for whole files.
+	Key string
+	// Mine and Theirs are the local and archived values of a config key or
+	// host key. Both are empty for whole files.
+	Mine, Theirs string
+}
+
+// String names the change for display, e.g. "known_hosts: default".
+func (c Change) String() string {
+	if c.Key == "" {
+		return c.File
+	}
+	return c.File + ": " + c.Key
+}
+
+// Plan is what importing an archive would do.
+type Plan struct {
+	// Added are entries only the archive has; Apply always adds them.
+	Added []Change
+	// Conflicts are entries both sides have with different values.
+	Conflicts []Change
+	// Redacted are secret config keys the archive could not carry and the
+	// local config does not set. They need re-entering.
+	Redacted []string
+	// NewHistory is the number of archived history records not already in
+	// the local history. History is merged, never overwritten.
+	NewHistory int
+}
+
+// fileDiff holds the local and archived entries of one file.
+type fileDiff struct {
+	name         string
+	keyed        bool // entries are keys within the file, not the whole file
+	mine, theirs map[string]string
+}
+
+// Plan compares the archive with the state in l.
+func (a *Archive) Plan(l Layout) (*Plan, error) {
+	diffs, err := a.diff(l)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plan{}
+	for _, d := range diffs {
+		for _, key := range sortedKeys(d.theirs) {
+			c := d.change(key)
+			mine, ok := d.mine[key]
+			switch {
+			case !ok:
+				p.Added = append(p.Added, c)
+			case mine != d.theirs[key]:
+				p.Conflicts = append(p.Conflicts, c)
+			}
+		}
+	}
+
+	local, err := readLocalConfig(l)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range a.Manifest.Redacted {
+		if local == nil || !local.IsSet(key) {
+			p.Redacted = append(p.Redacted, key)
+		}
+	}
+
+	_, p.NewHistory, err = a.mergedHistory(l)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Apply merges the archive into l. Entries only the archive has are added;
+// for each conflict, theirs reports whether the archived value replaces the
+// local one. History records are merged by time.
+func (a *Archive) Apply(l Layout, theirs func(Change) bool) error {
+	diffs, err := a.diff(l)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		var take []string
+		for _, key := range sortedKeys(d.theirs) {
+			mine, ok := d.mine[key]
+			if !ok || (mine != d.theirs[key] && theirs(d.change(key))) {
+				take = append(take, key)
+			}
+		}
+		if len(take) == 0 {
+			continue
+		}
+		if err := a.write(l, d, take); err != nil {
+			return err
+		}
+	}
+
+	merged, added, err := a.mergedHistory(l)
+	if err != nil || added == 0 {
+		return err
+	}
+	data, err := encodeHistory(merged)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(l.HistoryPath, data); err != nil {
+		return err
+	}
+	// The merged log holds the rotated records too.
+	if err := os.Remove(l.HistoryPath + ".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing rotated history: %w", err)
+	}
+	return nil
+}
+
+func (d fileDiff) change(key string) Change {
+	c := Change{File: d.name}
+	if d.keyed {
+		c.Key, c.Mine, c.Theirs = key, d.mine[key], d.theirs[key]
+	}
+	return c
+}
+
+// diff returns the local and archived entries of every file in the
+// archive except history.
+func (a *Archive) diff(l Layout) ([]fileDiff, error) {
+	var diffs []fileDiff
+	for _, name := range a.Manifest.Files {
+		if name == historyName {
+			continue
+		}
+		data := a.files[name]
+		local, err := os.ReadFile(filepath.Join(l.ConfigDir, filepath.FromSlash(name)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		exists := err == nil
+
+		d := fileDiff{name: name, mine: map[string]string{}, theirs: map[string]string{}}
+		switch name {
+		case teamtemplate.ConfigFile:
+			d.keyed = true
+			if d.theirs, err = configValues(data, a.Manifest.Redacted); err != nil {
+				return nil, err
+			}
+			if exists {
+				if d.mine, err = configValues(local, nil); err != nil {
+					return nil, err
+				}
+			}
+		case sshconfig.HostKeyFile:
+			d.keyed = true
+			if d.theirs, err = sshconfig.ParseHostKeys(data); err != nil {
+				return nil, err
+			}
+			if d.mine, err = sshconfig.ParseHostKeys(local); err != nil {
+				return nil, err
+			}
+		default:
+			d.theirs[""] = string(data)
+			if exists {
+				d.mine[""] = string(local)
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+// write stores the archived values of keys in the local copy of d's file.
+func (a *Archive) write(l Layout, d fileDiff, keys []string) error {
+	dst := filepath.Join(l.ConfigDir, filepath.FromSlash(d.name))
+	switch d.name {
+	case teamtemplate.ConfigFile:
+		local, err := readLocalConfig(l)
+		if err != nil {
+			return err
+		}
+		if local == nil {
+			local = viper.New()
+			local.SetConfigType("toml")
+		}
+		archived, err := parseConfig(a.files[d.name])
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			local.Set(key, archived.Get(key))
+		}
+		var buf bytes.Buffer
+		if err := local.WriteConfigTo(&buf); err != nil {
+			return fmt.Errorf("encoding %s: %w", d.name, err)
+		}
+		return writeFile(dst, buf.Bytes())
+	case sshconfig.HostKeyFile:
+		merged := d.mine
+		for _, key := range keys {
+			merged[key] = d.theirs[key]
+		}
+		return writeFile(dst, sshconfig.FormatHostKeys(merged))
+	default:
+		return writeFile(dst, a.files[d.name])
+	}
+}
+
+// mergedHistory returns the local history with the archived records it
+// lacks, oldest first, and the number of records added.
+func (a *Archive) mergedHistory(l Layout) ([]history.Record, int, error) {
+	local, err := history.Read(l.HistoryPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, ok := a.files[historyName]
+	if !ok {
+		return local, 0, nil
+	}
+	archived, err := history.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	seen := map[string]bool{}
+	for _, rec := range local {
+		seen[historyKey(rec)] = true
+	}
+	merged := local
+	for _, rec := range archived {
+		if key := historyKey(rec); !seen[key] {
+			seen[key] = true
+			merged = append(merged, rec)
+		}
+	}
+	added := len(merged) - len(local)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+	return merged, added, nil
+}
+
+// historyKey identifies a record for de-duplication.
+func historyKey(rec history.Record) string {
+	data, _ := encodeHistory([]history.Record{rec})
+	return string(data)
+}
+
+// readLocalConfig parses the local config.toml, or returns nil when there
+// is none.
+func readLocalConfig(l Layout) (*viper.Viper, error) {
+	data, err := os.ReadFile(filepath.Join(l.ConfigDir, teamtemplate.ConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", teamtemplate.ConfigFile, err)
+	}
+	return parseConfig(data)
+}
+
+// configValues returns the config keys set in data and their values as
+// text, leaving out the skip keys.
+func configValues(data []byte, skip []string) (map[string]string, error) {
+	v, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, key := range v.AllKeys() {
+		if !slices.Contains(skip, key) {
+			values[key] = fmt.Sprint(v.Get(key))
+		}
+	}
+	return values, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeFile writes data to path with owner-only permissions, creating the
+// parent directory.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
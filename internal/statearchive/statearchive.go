@@ -0,0 +1,298 @@
+// Package statearchive moves mint's local state between machines. Export
+// bundles the portable pieces — config.toml, the host key store, the user
+// bootstrap hook, devcontainer overrides, and command history — into a
+// tar.gz with a manifest; Read and Apply merge such an archive into another
+// machine's state.
+//
+// Caches (discovery, project lists, instance types, version checks) and
+// provisioning journals describe one machine's view of AWS and are never
+// exported. Config values whose keys name a secret are replaced with
+// RedactedValue and must be re-entered after import.
+package statearchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+	"github.com/SpiceLabsHQ/Mint/internal/sshconfig"
+	"github.com/SpiceLabsHQ/Mint/internal/teamtemplate"
+)
+
+// Version is the state format this mint writes. Read refuses archives with
+// a newer version; bump it when an archived file changes incompatibly.
+const Version = 1
+
+// RedactedValue replaces secret config values in an archive.
+const RedactedValue = "<redacted>"
+
+// Names inside an archive. Config-dir files keep their names there.
+const (
+	manifestName = "manifest.json"
+	historyName  = "history.ndjson"
+)
+
+// secretKeyWords mark a config key as holding a secret.
+var secretKeyWords = []string{"secret", "token", "password"}
+
+// Layout locates the state on one machine.
+type Layout struct {
+	// ConfigDir is mint's config directory, usually ~/.config/mint.
+	ConfigDir string
+	// HistoryPath is the command history log (see history.DefaultPath).
+	HistoryPath string
+}
+
+// Manifest describes an archive. It is stored as manifest.json.
+type Manifest struct {
+	StateVersion int       `json:"state_version"`
+	MintVersion  string    `json:"mint_version"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Files lists every other file in the archive, sorted.
+	Files []string `json:"files"`
+	// Redacted lists the config keys whose values were replaced with
+	// RedactedValue.
+	Redacted []string `json:"redacted,omitempty"`
+}
+
+// Archive is a read, validated state archive.
+type Archive struct {
+	Manifest Manifest
+	files    map[string][]byte
+}
+
+// isSecretKey reports whether a config key holds a secret.
+func isSecretKey(key string) bool {
+	for _, word := range secretKeyWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// portable reports whether name is a file an archive may hold.
+func portable(name string) bool {
+	switch name {
+	case teamtemplate.ConfigFile, sshconfig.HostKeyFile, teamtemplate.HookFile, historyName:
+		return true
+	}
+	dir, file := path.Split(name)
+	return dir == teamtemplate.OverridesDir+"/" && strings.HasSuffix(file, ".json")
+}
+
+// Export writes the portable state in l to w as a tar.gz archive and
+// returns its manifest. Missing files are skipped.
+func Export(w io.Writer, l Layout, mintVersion string, now time.Time) (*Manifest, error) {
+	files, redacted, err := collect(l)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		StateVersion: Version,
+		MintVersion:  mintVersion,
+		CreatedAt:    now.UTC(),
+		Redacted:     redacted,
+	}
+	for name := range files {
+		m.Files = append(m.Files, name)
+	}
+	sort.Strings(m.Files)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: m.CreatedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(manifestName, manifest); err != nil {
+		return nil, fmt.Errorf("writing archive: %w", err)
+	}
+	for _, name := range m.Files {
+		if err := add(name, files[name]); err != nil {
+			return nil, fmt.Errorf("writing archive: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("writing archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("writing archive: %w", err)
+	}
+	return m, nil
+}
+
+// collect reads the portable files in l, keyed by archive name, with secret
+// config values redacted. It also returns the redacted keys.
+func collect(l Layout) (map[string][]byte, []string, error) {
+	files := map[string][]byte{}
+	for _, name := range []string{teamtemplate.ConfigFile, sshconfig.HostKeyFile, teamtemplate.HookFile} {
+		data, err := os.ReadFile(filepath.Join(l.ConfigDir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		files[name] = data
+	}
+
+	var redacted []string
+	if data, ok := files[teamtemplate.ConfigFile]; ok {
+		v, err := parseConfig(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range v.AllKeys() {
+			if isSecretKey(key) {
+				v.Set(key, RedactedValue)
+				redacted = append(redacted, key)
+			}
+		}
+		if len(redacted) > 0 {
+			sort.Strings(redacted)
+			var buf bytes.Buffer
+			if err := v.WriteConfigTo(&buf); err != nil {
+				return nil, nil, fmt.Errorf("encoding %s: %w", teamtemplate.ConfigFile, err)
+			}
+			files[teamtemplate.ConfigFile] = buf.Bytes()
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(l.ConfigDir, teamtemplate.OverridesDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("reading %s: %w", teamtemplate.OverridesDir, err)
+	}
+	for _, e := range entries {
+		name := path.Join(teamtemplate.OverridesDir, e.Name())
+		if !e.Type().IsRegular() || !portable(name) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(l.ConfigDir, teamtemplate.OverridesDir, e.Name()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		files[name] = data
+	}
+
+	records, err := history.Read(l.HistoryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) > 0 {
+		data, err := encodeHistory(records)
+		if err != nil {
+			return nil, nil, err
+		}
+		files[historyName] = data
+	}
+	return files, redacted, nil
+}
+
+// Read reads and validates a state archive. It refuses archives written
+// with a newer state version, and archives holding files other than the
+// ones their manifest lists.
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a mint state archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("archive entry %q is not a regular file", hdr.Name)
+		}
+		if hdr.Name != manifestName && !portable(hdr.Name) {
+			return nil, fmt.Errorf("archive entry %q is not mint state", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+		files[hdr.Name] = data
+	}
+
+	raw, ok := files[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("not a mint state archive: no %s", manifestName)
+	}
+	delete(files, manifestName)
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", manifestName, err)
+	}
+	if m.StateVersion < 1 {
+		return nil, fmt.Errorf("%s has no state version", manifestName)
+	}
+	if m.StateVersion > Version {
+		return nil, fmt.Errorf("archive has state version %d, written by mint %s; this mint reads up to version %d — update mint first",
+			m.StateVersion, m.MintVersion, Version)
+	}
+
+	listed := map[string]bool{}
+	for _, name := range m.Files {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("archive is missing %s, listed in its manifest", name)
+		}
+		listed[name] = true
+	}
+	for name := range files {
+		if !listed[name] {
+			return nil, fmt.Errorf("archive entry %q is not listed in its manifest", name)
+		}
+	}
+	return &Archive{Manifest: m, files: files}, nil
+}
+
+func parseConfig(data []byte) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", teamtemplate.ConfigFile, err)
+	}
+	return v, nil
+}
+
+func encodeHistory(records []history.Record) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return nil, fmt.Errorf("encoding history: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
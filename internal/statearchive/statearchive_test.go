@@ -0,0 +1,342 @@
+package statearchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SpiceLabsHQ/Mint/internal/history"
+)
+
+var testNow = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// newLayout returns a Layout in a fresh temp dir.
+func newLayout(t *testing.T) Layout {
+	t.Helper()
+	dir := t.TempDir()
+	return Layout{ConfigDir: filepath.Join(dir, "config"), HistoryPath: filepath.Join(dir, "state", "history.ndjson")}
+}
+
+func writeState(t *testing.T, l Layout, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(l.ConfigDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readState(t *testing.T, l Layout, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(l.ConfigDir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func appendHistory(t *testing.T, l Layout, commands ...string) {
+	t.Helper()
+	for i, c := range commands {
+		rec := history.Record{Time: testNow.Add(time.Duration(i) * time.Minute), Command: c, Exit: history.ExitOK}
+		if err := history.Append(l.HistoryPath, rec, history.MaxBytes); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sourceLayout returns a Layout holding every kind of portable state, a
+// secret config value, and files that must not be exported.
+func sourceLayout(t *testing.T) Layout {
+	t.Helper()
+	l := newLayout(t)
+	writeState(t, l, map[string]string{
+		"config.toml":                      "region = 'us-west-2'\nwebhook_secret = 's3cr3t'\n",
+		"known_hosts":                      "default=SHA256:aaa\ndev=SHA256:ddd\n",
+		"user-bootstrap.sh":                "#!/bin/sh\necho hi\n",
+		"devcontainer-overrides/api.json":  `{"forwardPorts":[3000]}`,
+		"devcontainer-overrides/notes.txt": "not an override",
+		"projects-default.json":            `{"projects":[]}`,
+		"version-cache.json":               `{}`,
+		"instance-types-us-west-2.json":    `{}`,
+		"journal/default.json":             `{}`,
+	})
+	appendHistory(t, l, "up", "project add")
+	return l
+}
+
+func export(t *testing.T, l Layout) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Export(&buf, l, "1.4.0", testNow); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// archiveEntries returns the raw entries of a tar.gz archive.
+func archiveEntries(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	entries := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(tr)
+		entries[hdr.Name] = string(body)
+	}
+}
+
+// buildArchive writes a tar.gz holding entries, for archives Export would
+// not produce.
+func buildArchive(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestExportContents(t *testing.T) {
+	entries := archiveEntries(t, export(t, sourceLayout(t)))
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	wantFiles := []string{"config.toml", "devcontainer-overrides/api.json", "history.ndjson", "known_hosts", "user-bootstrap.sh"}
+	if got := len(names); got != len(wantFiles)+1 {
+		t.Errorf("archive entries = %v, want manifest.json and %v", names, wantFiles)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal([]byte(entries["manifest.json"]), &m); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	want := Manifest{
+		StateVersion: Version,
+		MintVersion:  "1.4.0",
+		CreatedAt:    testNow,
+		Files:        wantFiles,
+		Redacted:     []string{"webhook_secret"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("manifest = %+v, want %+v", m, want)
+	}
+
+	if cfg := entries["config.toml"]; strings.Contains(cfg, "s3cr3t") || !strings.Contains(cfg, RedactedValue) {
+		t.Errorf("secret not redacted:\n%s", cfg)
+	}
+	if got := strings.Count(entries["history.ndjson"], "\n"); got != 2 {
+		t.Errorf("history has %d records, want 2", got)
+	}
+}
+
+func TestRoundTripIntoEmptyState(t *testing.T) {
+	a, err := Read(bytes.NewReader(export(t, sourceLayout(t))))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	dst := newLayout(t)
+	plan, err := a.Plan(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Conflicts) != 0 {
+		t.Errorf("conflicts = %v, want none", plan.Conflicts)
+	}
+	var added []string
+	for _, c := range plan.Added {
+		added = append(added, c.String())
+	}
+	wantAdded := []string{
+		"config.toml: region",
+		"devcontainer-overrides/api.json",
+		"known_hosts: default",
+		"known_hosts: dev",
+		"user-bootstrap.sh",
+	}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added = %v, want %v", added, wantAdded)
+	}
+	if !reflect.DeepEqual(plan.Redacted, []string{"webhook_secret"}) {
+		t.Errorf("redacted = %v", plan.Redacted)
+	}
+	if plan.NewHistory != 2 {
+		t.Errorf("NewHistory = %d, want 2", plan.NewHistory)
+	}
+
+	if err := a.Apply(dst, func(Change) bool { t.Fatal("no conflict expected"); return false }); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if got := readState(t, dst, "known_hosts"); got != "default=SHA256:aaa\ndev=SHA256:ddd\n" {
+		t.Errorf("known_hosts = %q", got)
+	}
+	if got := readState(t, dst, "devcontainer-overrides/api.json"); got != `{"forwardPorts":[3000]}` {
+		t.Errorf("override = %q", got)
+	}
+	if cfg := readState(t, dst, "config.toml"); !strings.Contains(cfg, "us-west-2") || strings.Contains(cfg, "webhook_secret") {
+		t.Errorf("config.toml should hold region and no redacted key:\n%s", cfg)
+	}
+	records, err := history.Read(dst.HistoryPath)
+	if err != nil || len(records) != 2 {
+		t.Errorf("history = %v, %v", records, err)
+	}
+}
+
+func TestApplyConflicts(t *testing.T) {
+	a, err := Read(bytes.NewReader(export(t, sourceLayout(t))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		theirs     bool
+		wantHosts  string
+		wantRegion string
+	}{
+		{"mine", false, "default=SHA256:mine\ndev=SHA256:ddd\nlocal=SHA256:lll\n", "eu-west-1"},
+		{"theirs", true, "default=SHA256:aaa\ndev=SHA256:ddd\nlocal=SHA256:lll\n", "us-west-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := newLayout(t)
+			writeState(t, dst, map[string]string{
+				"config.toml": "region = 'eu-west-1'\nwebhook_secret = 'local'\n",
+				"known_hosts": "default=SHA256:mine\nlocal=SHA256:lll\n",
+			})
+
+			plan, err := a.Plan(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantConflicts := []Change{
+				{File: "config.toml", Key: "region", Mine: "eu-west-1", Theirs: "us-west-2"},
+				{File: "known_hosts", Key: "default", Mine: "SHA256:mine", Theirs: "SHA256:aaa"},
+			}
+			if !reflect.DeepEqual(plan.Conflicts, wantConflicts) {
+				t.Errorf("conflicts = %+v, want %+v", plan.Conflicts, wantConflicts)
+			}
+			if len(plan.Redacted) != 0 {
+				t.Errorf("redacted = %v, want none: the local config sets the secret", plan.Redacted)
+			}
+
+			var asked []string
+			err = a.Apply(dst, func(c Change) bool {
+				asked = append(asked, c.String())
+				return tt.theirs
+			})
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if len(asked) != 2 {
+				t.Errorf("asked about %v, want the 2 conflicts", asked)
+			}
+			if got := readState(t, dst, "known_hosts"); got != tt.wantHosts {
+				t.Errorf("known_hosts = %q, want %q", got, tt.wantHosts)
+			}
+			cfg := readState(t, dst, "config.toml")
+			if !strings.Contains(cfg, tt.wantRegion) || !strings.Contains(cfg, "local") {
+				t.Errorf("config.toml should have region %s and keep the local secret:\n%s", tt.wantRegion, cfg)
+			}
+		})
+	}
+}
+
+func TestApplyMergesHistory(t *testing.T) {
+	src := sourceLayout(t)
+	a, err := Read(bytes.NewReader(export(t, src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing into the machine that exported adds nothing.
+	if plan, err := a.Plan(src); err != nil || plan.NewHistory != 0 {
+		t.Fatalf("NewHistory = %v, %v; want 0", plan, err)
+	}
+
+	dst := newLayout(t)
+	rec := history.Record{Time: testNow.Add(30 * time.Second), Command: "ssh", Exit: history.ExitOK}
+	if err := history.Append(dst.HistoryPath, rec, history.MaxBytes); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Apply(dst, func(Change) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	records, err := history.Read(dst.HistoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, r := range records {
+		commands = append(commands, r.Command)
+	}
+	if want := []string{"up", "ssh", "project add"}; !reflect.DeepEqual(commands, want) {
+		t.Errorf("history = %v, want %v", commands, want)
+	}
+}
+
+func TestReadRefusesNewerStateVersion(t *testing.T) {
+	manifest := `{"state_version": 2, "mint_version": "9.0.0", "files": []}`
+	_, err := Read(bytes.NewReader(buildArchive(t, map[string]string{"manifest.json": manifest})))
+	if err == nil || !strings.Contains(err.Error(), "state version 2") || !strings.Contains(err.Error(), "update mint") {
+		t.Fatalf("error = %v, want a newer state version error", err)
+	}
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	valid := `{"state_version": 1, "files": ["known_hosts"]}`
+	tests := []struct {
+		name    string
+		entries map[string]string
+		wantErr string
+	}{
+		{"no manifest", map[string]string{"known_hosts": ""}, "no manifest.json"},
+		{"missing listed file", map[string]string{"manifest.json": valid}, "missing known_hosts"},
+		{"unlisted file", map[string]string{"manifest.json": valid, "known_hosts": "", "user-bootstrap.sh": ""}, "not listed"},
+		{"foreign path", map[string]string{"manifest.json": valid, "known_hosts": "", "../.bashrc": ""}, "not mint state"},
+		{"cache file", map[string]string{"manifest.json": valid, "known_hosts": "", "projects-default.json": ""}, "not mint state"},
+		{"no version", map[string]string{"manifest.json": `{"files": []}`}, "no state version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(buildArchive(t, tt.entries)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,12 @@ package tags
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -39,9 +45,26 @@ const (
 	// TagBootstrapFailurePhase records the bootstrap phase that was active when
 	// the script exited with a failure. Written by the EXIT trap in bootstrap.sh
 	// immediately before mint:bootstrap=failed. Absent on successful bootstraps.
-	// Phase values: packages, docker, ssh-known-hosts, efs-mount, systemd-units, drift-check, user-script.
+	// Phase values: packages, docker, gpu-driver, ssh-known-hosts, efs-mount, systemd-units, drift-check, user-script.
 	TagBootstrapFailurePhase = "mint:bootstrap-failure-phase"
 
+	// TagBootstrapError records the bootstrap step that was running when the
+	// script failed, such as "claude" or "project-volume". Steps are the
+	// phases of the bootstrap timings, finer-grained than the failure phase.
+	// Written by the EXIT trap in bootstrap.sh alongside the failure phase.
+	TagBootstrapError = "mint:bootstrap-error"
+
+	// TagUserBootstrap records the outcome of the optional user-bootstrap.sh
+	// hook, which runs after core bootstrap. Values: "ok" or "failed:<exit code>".
+	// Absent when no hook is configured. Written by the EXIT trap in
+	// bootstrap.sh immediately before mint:bootstrap.
+	TagUserBootstrap = "mint:user-bootstrap"
+
+	// TagBootstrapSource records where the bootstrap.sh hash a VM was
+	// provisioned with came from: "manifest v<N>" for the signed published
+	// manifest or "embedded" for the hash compiled into the CLI.
+	TagBootstrapSource = "mint:bootstrap-source"
+
 	// TagHealth tracks the health status of the resource.
 	TagHealth = "mint:health"
 
@@ -54,10 +77,77 @@ const (
 	// TagProjectVolumeGB stores the project EBS volume size in GB (ADR-0004).
 	TagProjectVolumeGB = "mint:project-volume-gb"
 
+	// TagProjectVolumeThroughput stores the project EBS volume's gp3
+	// throughput in MB/s.
+	TagProjectVolumeThroughput = "mint:project-volume-throughput"
+
 	// TagPendingAttach marks a project EBS volume during mint recreate for
 	// failure recovery. Tag existence signals pending reattachment; cleared
 	// after successful attach.
 	TagPendingAttach = "mint:pending-attach"
+
+	// TagEIP records on an instance that its Elastic IP was released while
+	// the VM was stopped. Value: EIPReleasedByGC. mint up allocates a fresh
+	// Elastic IP and clears the tag.
+	TagEIP = "mint:eip"
+
+	// TagRetained exempts a resource from mint gc when set to "true".
+	TagRetained = "mint:retained"
+
+	// TagKeep exempts a resource from mint admin orphans --delete when set
+	// to "true". Admins add it by hand; mint never sets it.
+	TagKeep = "mint:keep"
+
+	// TagSSHUser records the login user (ssh_user) an instance was
+	// provisioned for, so an SSH login failure can compare it with the user
+	// being tried.
+	TagSSHUser = "mint:ssh-user"
+
+	// TagSpot marks an instance launched on the spot market. Value: "true".
+	TagSpot = "mint:spot"
+
+	// TagIPMode records how an instance is addressed when it was launched
+	// with an IPv6 address: IPModeDualStack or IPModeIPv6Only. Instances
+	// without it use IPModeEIP.
+	TagIPMode = "mint:ip-mode"
+
+	// TagSnapshotName is the name given to a mint snapshot create snapshot,
+	// and TagSnapshotCreated when it was taken (RFC 3339, UTC).
+	TagSnapshotName    = "mint:snapshot-name"
+	TagSnapshotCreated = "mint:snapshot-created"
+
+	// TagOperation is the per-VM operation lock a mutating lifecycle
+	// command holds on the instance while it runs. Value:
+	// "<operation>:<hostname>:<pid>:<RFC 3339 timestamp>".
+	TagOperation = "mint:operation"
+
+	// TagExpires is when a VM started with mint up --ttl is due to be
+	// destroyed (RFC 3339, UTC). It is set on the instance, its project
+	// volume, and its Elastic IP. Nothing is deleted when it passes; mint
+	// status and mint doctor report it.
+	TagExpires = "mint:expires"
+
+	// TagProvisionedAt is when a fresh mint up or mint recreate last
+	// provisioned the instance (RFC 3339, UTC), TagProvisionedBy the owner
+	// who ran it, and TagCLIVersion the version of mint it ran.
+	TagProvisionedAt = "mint:provisioned-at"
+	TagProvisionedBy = "mint:provisioned-by"
+	TagCLIVersion    = "mint:cli-version"
+)
+
+// EIPReleasedByGC is the mint:eip value mint gc writes after releasing a
+// stopped VM's Elastic IP.
+const EIPReleasedByGC = "released-by-gc"
+
+// IP modes, matching the ip_mode config key and the mint:ip-mode tag.
+const (
+	// IPModeEIP reaches the VM at an Elastic IP (the default).
+	IPModeEIP = "eip"
+	// IPModeDualStack adds an IPv6 address to the Elastic IP.
+	IPModeDualStack = "dualstack"
+	// IPModeIPv6Only reaches the VM at its IPv6 address alone: no Elastic
+	// IP and no public IPv4 address.
+	IPModeIPv6Only = "ipv6-only"
 )
 
 // ---------------------------------------------------------------------------
@@ -71,6 +161,25 @@ const (
 	ComponentElasticIP      = "elastic-ip"
 	ComponentProjectVolume  = "project-volume"
 	ComponentEFSAccessPoint = "efs-access-point"
+
+	// ComponentProjectSnapshot marks an EBS snapshot of a project volume:
+	// one taken by mint snapshot create, tagged for its VM, or the one mint
+	// clone-vm takes of the source, tagged for the destination VM.
+	ComponentProjectSnapshot = "project-snapshot"
+
+	// ComponentRestoredVolume marks a volume mint snapshot restore created
+	// for a running VM. The next mint recreate attaches it as the project
+	// volume.
+	ComponentRestoredVolume = "restored-volume"
+
+	// ComponentReplacedVolume marks a project volume a restored volume
+	// replaced. It is kept, detached, until the user deletes it or destroys
+	// the VM.
+	ComponentReplacedVolume = "replaced-project-volume"
+
+	// ComponentInstanceConnectEndpoint marks an EC2 Instance Connect
+	// Endpoint created by mint init --instance-connect-endpoint.
+	ComponentInstanceConnectEndpoint = "instance-connect-endpoint"
 )
 
 // ---------------------------------------------------------------------------
@@ -83,13 +192,100 @@ const (
 	BootstrapFailed   = "failed"
 )
 
+// ---------------------------------------------------------------------------
+// User bootstrap hook status (mint:user-bootstrap)
+// ---------------------------------------------------------------------------
+
+const (
+	// UserBootstrapOK is the mint:user-bootstrap value for a hook that exited 0.
+	UserBootstrapOK = "ok"
+
+	// UserBootstrapFailedPrefix prefixes the exit code of a failed hook,
+	// e.g. "failed:3".
+	UserBootstrapFailedPrefix = "failed:"
+)
+
+// UserBootstrapStatus is the parsed form of the mint:user-bootstrap tag.
+type UserBootstrapStatus struct {
+	// Ran is true when the tag is present, i.e. a user hook was configured
+	// and the bootstrap script recorded its outcome.
+	Ran bool
+
+	// Failed is true when the hook exited non-zero.
+	Failed bool
+
+	// ExitCode is the hook's exit status. Zero unless Failed is true.
+	ExitCode int
+}
+
+// ParseUserBootstrapStatus parses a mint:user-bootstrap tag value. An empty
+// value means no hook ran and is not an error.
+func ParseUserBootstrapStatus(value string) (UserBootstrapStatus, error) {
+	switch {
+	case value == "":
+		return UserBootstrapStatus{}, nil
+	case value == UserBootstrapOK:
+		return UserBootstrapStatus{Ran: true}, nil
+	case strings.HasPrefix(value, UserBootstrapFailedPrefix):
+		code, err := strconv.Atoi(strings.TrimPrefix(value, UserBootstrapFailedPrefix))
+		if err != nil || code <= 0 {
+			return UserBootstrapStatus{}, fmt.Errorf("invalid %s value %q: exit code must be a positive integer", TagUserBootstrap, value)
+		}
+		return UserBootstrapStatus{Ran: true, Failed: true, ExitCode: code}, nil
+	default:
+		return UserBootstrapStatus{}, fmt.Errorf("invalid %s value %q: expected %q or %q<code>", TagUserBootstrap, value, UserBootstrapOK, UserBootstrapFailedPrefix)
+	}
+}
+
+// String returns a human-readable summary: "ok", "failed (exit N)", or
+// "not configured" when no hook ran.
+func (s UserBootstrapStatus) String() string {
+	switch {
+	case !s.Ran:
+		return "not configured"
+	case s.Failed:
+		return fmt.Sprintf("failed (exit %d)", s.ExitCode)
+	default:
+		return "ok"
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Expiry (mint:expires)
+// ---------------------------------------------------------------------------
+
+// ExpiryGrace is how long past its mint:expires time a resource is still
+// not reported as expired, so a local clock running a little ahead of AWS
+// does not flag a VM early.
+const ExpiryGrace = 5 * time.Minute
+
+// FormatExpires returns t as a mint:expires tag value.
+func FormatExpires(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseExpires parses a mint:expires tag value.
+func ParseExpires(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s value %q: expected an RFC 3339 time", TagExpires, value)
+	}
+	return t, nil
+}
+
+// Expired reports whether expires has passed at now by more than
+// ExpiryGrace.
+func Expired(expires, now time.Time) bool {
+	return now.Sub(expires) > ExpiryGrace
+}
+
 // ---------------------------------------------------------------------------
 // TagBuilder — fluent builder for EC2 tag sets
 // ---------------------------------------------------------------------------
 
 // TagBuilder constructs a set of EC2 tags for a Mint resource.
 // Base tags (mint, owner, owner-arn, vm, Name) are always included.
-// Optional tags (component, bootstrap) are added via fluent methods.
+// Optional tags (component, bootstrap, expires) are added via fluent methods.
 type TagBuilder struct {
 	owner    string
 	ownerARN string
@@ -97,6 +293,7 @@ type TagBuilder struct {
 
 	component string
 	bootstrap string
+	expires   string
 }
 
 // NewTagBuilder creates a TagBuilder with the required base fields.
@@ -120,6 +317,13 @@ func (b *TagBuilder) WithBootstrap(status string) *TagBuilder {
 	return b
 }
 
+// WithExpires sets the mint:expires tag value. An empty value leaves the
+// tag off.
+func (b *TagBuilder) WithExpires(value string) *TagBuilder {
+	b.expires = value
+	return b
+}
+
 // Build produces the full set of EC2 tags.
 func (b *TagBuilder) Build() []ec2types.Tag {
 	tags := []ec2types.Tag{
@@ -142,6 +346,12 @@ func (b *TagBuilder) Build() []ec2types.Tag {
 		})
 	}
 
+	if b.expires != "" {
+		tags = append(tags, ec2types.Tag{
+			Key: aws.String(TagExpires), Value: aws.String(b.expires),
+		})
+	}
+
 	return tags
 }
 
@@ -149,6 +359,14 @@ func (b *TagBuilder) Build() []ec2types.Tag {
 // Filter constructors for tag-based discovery
 // ---------------------------------------------------------------------------
 
+// FilterMint returns EC2 filters that match all Mint resources, whoever
+// owns them.
+func FilterMint() []ec2types.Filter {
+	return []ec2types.Filter{
+		{Name: aws.String("tag:" + TagMint), Values: []string{"true"}},
+	}
+}
+
 // FilterByOwner returns EC2 filters that match all Mint resources belonging
 // to the given owner.
 func FilterByOwner(owner string) []ec2types.Filter {
@@ -158,6 +376,15 @@ func FilterByOwner(owner string) []ec2types.Filter {
 	}
 }
 
+// FilterByVM returns EC2 filters that match Mint resources with the given
+// VM name, whoever owns them.
+func FilterByVM(vmName string) []ec2types.Filter {
+	return []ec2types.Filter{
+		{Name: aws.String("tag:" + TagMint), Values: []string{"true"}},
+		{Name: aws.String("tag:" + TagVM), Values: []string{vmName}},
+	}
+}
+
 // FilterByOwnerAndVM returns EC2 filters that match Mint resources belonging
 // to the given owner and VM name.
 func FilterByOwnerAndVM(owner, vmName string) []ec2types.Filter {
@@ -167,3 +394,165 @@ func FilterByOwnerAndVM(owner, vmName string) []ec2types.Filter {
 		{Name: aws.String("tag:" + TagVM), Values: []string{vmName}},
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Tag repair
+// ---------------------------------------------------------------------------
+
+// TagConflict is a canonical tag whose key is present on a resource with a
+// different value.
+type TagConflict struct {
+	Key       string
+	Current   string
+	Canonical string
+}
+
+// TagDiff is the difference between a resource's tags and its canonical set.
+type TagDiff struct {
+	// Missing are canonical tags absent from the resource, in canonical order.
+	Missing []ec2types.Tag
+
+	// Conflicts are canonical keys the resource carries with another value.
+	// Repair leaves them alone: the current value may be deliberate.
+	Conflicts []TagConflict
+}
+
+// Empty reports whether the resource already carries every canonical tag.
+func (d TagDiff) Empty() bool {
+	return len(d.Missing) == 0
+}
+
+// Diff compares current against canonical. Only absent keys are reported as
+// missing, so values the resource already has — including tags outside the
+// canonical set such as mint:bootstrap — are never overwritten by a repair.
+func Diff(current map[string]string, canonical []ec2types.Tag) TagDiff {
+	var d TagDiff
+	for _, tag := range canonical {
+		key, want := aws.ToString(tag.Key), aws.ToString(tag.Value)
+		have, ok := current[key]
+		switch {
+		case !ok:
+			d.Missing = append(d.Missing, tag)
+		case have != want:
+			d.Conflicts = append(d.Conflicts, TagConflict{Key: key, Current: have, Canonical: want})
+		}
+	}
+	return d
+}
+
+// ToMap converts EC2 tags to a key/value map.
+func ToMap(ec2Tags []ec2types.Tag) map[string]string {
+	m := make(map[string]string, len(ec2Tags))
+	for _, tag := range ec2Tags {
+		m[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return m
+}
+
+// ---------------------------------------------------------------------------
+// Extra tags (extra_tags in config.toml)
+// ---------------------------------------------------------------------------
+
+// MaxTagsPerResource is the most tags AWS allows on one EC2 resource.
+const MaxTagsPerResource = 50
+
+// mintTagKeys are the keys mint may set on a resource itself. Extra tags
+// share the per-resource limit with them.
+var mintTagKeys = []string{
+	TagMint, TagComponent, TagVM, TagOwner, TagOwnerARN, TagName,
+	TagBootstrap, TagBootstrapFailurePhase, TagBootstrapError, TagUserBootstrap, TagBootstrapSource,
+	TagHealth, TagRootVolumeGB, TagProjectVolumeGB, TagProjectVolumeThroughput, TagPendingAttach, TagEIP, TagRetained,
+	TagSSHUser, TagSpot, TagIPMode, TagSnapshotName, TagSnapshotCreated, TagOperation,
+}
+
+// MaxExtraTags is how many extra tags a resource takes alongside every tag
+// mint may set on it.
+var MaxExtraTags = MaxTagsPerResource - len(mintTagKeys)
+
+// tagCharPattern matches the characters EC2 allows in tag keys and values:
+// letters, digits, spaces, and _ . : / = + - @.
+var tagCharPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// isMintKey reports whether key is one mint owns: mint, Name, or any
+// mint:* key.
+func isMintKey(key string) bool {
+	return key == TagMint || key == TagName || strings.HasPrefix(key, "mint:")
+}
+
+// MergeExtra returns base with the extra tags appended, sorted by key.
+// base is not modified. An extra tag may not set a key mint owns or one
+// base already has; the error lists every such key.
+func MergeExtra(base []ec2types.Tag, extra map[string]string) ([]ec2types.Tag, error) {
+	if len(extra) == 0 {
+		return base, nil
+	}
+	present := make(map[string]bool, len(base))
+	for _, tag := range base {
+		present[aws.ToString(tag.Key)] = true
+	}
+
+	keys := make([]string, 0, len(extra))
+	var conflicts []string
+	for key := range extra {
+		if isMintKey(key) || present[key] {
+			conflicts = append(conflicts, key)
+		}
+		keys = append(keys, key)
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("extra tags may not override mint's own tags: %s", strings.Join(conflicts, ", "))
+	}
+	sort.Strings(keys)
+
+	merged := make([]ec2types.Tag, len(base), len(base)+len(keys))
+	copy(merged, base)
+	for _, key := range keys {
+		merged = append(merged, ec2types.Tag{Key: aws.String(key), Value: aws.String(extra[key])})
+	}
+	return merged, nil
+}
+
+// Extra returns the tags of all that are neither mint's own nor reserved
+// by AWS: the extra tags a resource was given.
+func Extra(all map[string]string) map[string]string {
+	extra := make(map[string]string)
+	for key, value := range all {
+		if !isMintKey(key) && !strings.HasPrefix(strings.ToLower(key), "aws:") {
+			extra[key] = value
+		}
+	}
+	return extra
+}
+
+// ValidateExtra checks extra tags against the EC2 tag constraints: keys of
+// 1 to 128 characters and values of at most 256, in the allowed character
+// set, no aws: prefix, no key mint owns, and no more than MaxExtraTags. It
+// returns one problem per violation, sorted.
+func ValidateExtra(extra map[string]string) []string {
+	var problems []string
+	for key, value := range extra {
+		switch {
+		case key == "" || utf8.RuneCountInString(key) > 128:
+			problems = append(problems, fmt.Sprintf("tag key %q must be 1 to 128 characters", key))
+		case !tagCharPattern.MatchString(key):
+			problems = append(problems, fmt.Sprintf("tag key %q may only contain letters, digits, spaces, and _ . : / = + - @", key))
+		case strings.HasPrefix(strings.ToLower(key), "aws:"):
+			problems = append(problems, fmt.Sprintf("tag key %q uses the reserved aws: prefix", key))
+		case isMintKey(key):
+			problems = append(problems, fmt.Sprintf("tag key %q would override one of mint's own tags", key))
+		}
+		switch {
+		case utf8.RuneCountInString(value) > 256:
+			problems = append(problems, fmt.Sprintf("value of tag %q is longer than 256 characters", key))
+		case !tagCharPattern.MatchString(value):
+			problems = append(problems, fmt.Sprintf("value of tag %q may only contain letters, digits, spaces, and _ . : / = + - @", key))
+		}
+	}
+	if len(extra) > MaxExtraTags {
+		problems = append(problems, fmt.Sprintf("%d extra tags is more than %d: a resource takes %d tags and mint may set %d of its own",
+			len(extra), MaxExtraTags, MaxTagsPerResource, len(mintTagKeys)))
+	}
+	sort.Strings(problems)
+	return problems
+}
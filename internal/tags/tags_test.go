@@ -1,7 +1,11 @@
 package tags
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
@@ -201,6 +205,29 @@ func TestTagBuilderFluent(t *testing.T) {
 	}
 }
 
+func TestTagBuilderWithExpires(t *testing.T) {
+	tagMap := tagsToMap(NewTagBuilder("alice", "arn:fake", "default").
+		WithExpires("2026-10-18T10:00:00Z").
+		Build())
+	if got := tagMap[TagExpires]; got != "2026-10-18T10:00:00Z" {
+		t.Errorf("expires tag = %q, want 2026-10-18T10:00:00Z", got)
+	}
+
+	tagMap = tagsToMap(NewTagBuilder("alice", "arn:fake", "default").WithExpires("").Build())
+	if _, ok := tagMap[TagExpires]; ok {
+		t.Error("empty expiry should leave the tag off")
+	}
+}
+
+func TestFilterMint(t *testing.T) {
+	filterMap := filtersToMap(FilterMint())
+
+	assertFilterValue(t, filterMap, TagMint, "true")
+	if len(filterMap) != 1 {
+		t.Errorf("FilterMint should only filter on %s, got %v", TagMint, filterMap)
+	}
+}
+
 func TestFilterByOwner(t *testing.T) {
 	filters := FilterByOwner("alice")
 
@@ -226,6 +253,16 @@ func TestFilterByOwnerAndVM(t *testing.T) {
 	assertFilterValue(t, filterMap, TagVM, "dev-box")
 }
 
+func TestFilterByVM(t *testing.T) {
+	filterMap := filtersToMap(FilterByVM("dev-box"))
+
+	assertFilterValue(t, filterMap, TagMint, "true")
+	assertFilterValue(t, filterMap, TagVM, "dev-box")
+	if _, ok := filterMap[TagOwner]; ok {
+		t.Error("FilterByVM should not filter on the owner")
+	}
+}
+
 // --- helpers ---
 
 func tagsToMap(tags []ec2types.Tag) map[string]string {
@@ -260,3 +297,201 @@ func assertFilterValue(t *testing.T, filterMap map[string][]string, key, wantVal
 		t.Errorf("filter %q values = %v, want [%q]", key, values, wantValue)
 	}
 }
+
+func TestParseUserBootstrapStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    UserBootstrapStatus
+		str     string
+		wantErr bool
+	}{
+		{name: "absent", value: "", want: UserBootstrapStatus{}, str: "not configured"},
+		{name: "ok", value: "ok", want: UserBootstrapStatus{Ran: true}, str: "ok"},
+		{name: "failed", value: "failed:3", want: UserBootstrapStatus{Ran: true, Failed: true, ExitCode: 3}, str: "failed (exit 3)"},
+		{name: "failed high code", value: "failed:127", want: UserBootstrapStatus{Ran: true, Failed: true, ExitCode: 127}, str: "failed (exit 127)"},
+		{name: "failed without code", value: "failed:", wantErr: true},
+		{name: "failed zero code", value: "failed:0", wantErr: true},
+		{name: "failed non-numeric", value: "failed:abc", wantErr: true},
+		{name: "unknown value", value: "complete", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUserBootstrapStatus(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseUserBootstrapStatus(%q) expected error, got %+v", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseUserBootstrapStatus(%q) unexpected error: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseUserBootstrapStatus(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q, want %q", got.String(), tt.str)
+			}
+		})
+	}
+}
+
+func TestExpires(t *testing.T) {
+	expires := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	value := FormatExpires(expires.In(time.FixedZone("PDT", -7*3600)))
+	if value != "2026-10-18T10:00:00Z" {
+		t.Fatalf("FormatExpires = %q, want UTC", value)
+	}
+	got, err := ParseExpires(value)
+	if err != nil || !got.Equal(expires) {
+		t.Fatalf("ParseExpires(%q) = %v, %v", value, got, err)
+	}
+	if _, err := ParseExpires("tomorrow"); err == nil {
+		t.Error("ParseExpires accepted a non-RFC 3339 value")
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "before expiry", now: expires.Add(-time.Hour), want: false},
+		{name: "just past, within the grace window", now: expires.Add(ExpiryGrace - time.Second), want: false},
+		{name: "at the end of the grace window", now: expires.Add(ExpiryGrace), want: false},
+		{name: "past the grace window", now: expires.Add(ExpiryGrace + time.Second), want: true},
+		{name: "long past", now: expires.Add(72 * time.Hour), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Expired(expires, tt.now); got != tt.want {
+				t.Errorf("Expired at %s = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffKeepsExistingValues(t *testing.T) {
+	canonical := NewTagBuilder("alice", "arn:aws:iam::123456789012:user/alice", "dev").
+		WithComponent(ComponentInstance).
+		Build()
+
+	// A hygiene script removed mint and mint:owner; Name was edited by hand
+	// and the bootstrap status must survive untouched.
+	current := map[string]string{
+		TagOwnerARN:  "arn:aws:iam::123456789012:user/alice",
+		TagVM:        "dev",
+		TagName:      "alice dev box",
+		TagComponent: ComponentInstance,
+		TagBootstrap: BootstrapComplete,
+	}
+
+	d := Diff(current, canonical)
+	if d.Empty() {
+		t.Fatal("Diff reported no missing tags")
+	}
+
+	var missing []string
+	for _, tag := range d.Missing {
+		missing = append(missing, aws.ToString(tag.Key)+"="+aws.ToString(tag.Value))
+	}
+	want := []string{TagMint + "=true", TagOwner + "=alice"}
+	if len(missing) != len(want) || missing[0] != want[0] || missing[1] != want[1] {
+		t.Errorf("Missing = %v, want %v", missing, want)
+	}
+
+	if len(d.Conflicts) != 1 {
+		t.Fatalf("Conflicts = %+v, want one Name conflict", d.Conflicts)
+	}
+	if c := d.Conflicts[0]; c.Key != TagName || c.Current != "alice dev box" || c.Canonical != "mint/alice/dev" {
+		t.Errorf("conflict = %+v", c)
+	}
+}
+
+func TestDiffComplete(t *testing.T) {
+	canonical := NewTagBuilder("alice", "arn", "dev").WithComponent(ComponentElasticIP).Build()
+	current := ToMap(canonical)
+	current[TagBootstrap] = BootstrapPending
+
+	if d := Diff(current, canonical); !d.Empty() || len(d.Conflicts) != 0 {
+		t.Errorf("Diff = %+v, want empty", d)
+	}
+}
+
+func TestMergeExtra(t *testing.T) {
+	base := NewTagBuilder("alice", "arn:aws:iam::123456789012:user/alice", "default").Build()
+	merged, err := MergeExtra(base, map[string]string{"Team": "platform", "CostCenter": "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 5 {
+		t.Errorf("base was modified: %d tags", len(base))
+	}
+	got := ToMap(merged)
+	if len(merged) != 7 || got["CostCenter"] != "1234" || got["Team"] != "platform" || got[TagOwner] != "alice" {
+		t.Errorf("merged = %v", got)
+	}
+	// Extras follow the base tags, sorted by key.
+	if aws.ToString(merged[5].Key) != "CostCenter" || aws.ToString(merged[6].Key) != "Team" {
+		t.Errorf("extra tag order = %s, %s", aws.ToString(merged[5].Key), aws.ToString(merged[6].Key))
+	}
+
+	if same, err := MergeExtra(base, nil); err != nil || len(same) != len(base) {
+		t.Errorf("no extras = %v, %v", same, err)
+	}
+}
+
+func TestMergeExtraRefusesMintKeys(t *testing.T) {
+	base := []ec2types.Tag{{Key: aws.String("Team"), Value: aws.String("infra")}}
+	_, err := MergeExtra(base, map[string]string{"mint:owner": "bob", "Name": "x", "Team": "platform", "CostCenter": "1"})
+	want := "extra tags may not override mint's own tags: Name, Team, mint:owner"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestExtra(t *testing.T) {
+	got := Extra(map[string]string{
+		TagMint:                         "true",
+		TagOwner:                        "alice",
+		TagName:                         "mint/alice/default",
+		"aws:cloudformation:stack-name": "x",
+		"Team":                          "infra",
+		"CostCenter":                    "1234",
+	})
+	want := map[string]string{"Team": "infra", "CostCenter": "1234"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extra = %v, want %v", got, want)
+	}
+}
+
+func TestValidateExtra(t *testing.T) {
+	if problems := ValidateExtra(map[string]string{"CostCenter": "1234", "Team": "data platform/ml"}); problems != nil {
+		t.Errorf("valid tags reported %v", problems)
+	}
+
+	problems := ValidateExtra(map[string]string{
+		"aws:created":            "x",
+		"mint:team":              "x",
+		"Team#1":                 "x",
+		strings.Repeat("k", 129): "x",
+		"Owner":                  strings.Repeat("v", 257),
+	})
+	if len(problems) != 5 {
+		t.Fatalf("problems = %q, want 5", problems)
+	}
+	for _, want := range []string{"reserved aws: prefix", "override one of mint's own tags", "may only contain", "1 to 128 characters", "longer than 256"} {
+		if !strings.Contains(strings.Join(problems, "\n"), want) {
+			t.Errorf("problems missing %q: %q", want, problems)
+		}
+	}
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxExtraTags; i++ {
+		tooMany[fmt.Sprintf("tag%d", i)] = "x"
+	}
+	if problems := ValidateExtra(tooMany); len(problems) != 1 || !strings.Contains(problems[0], "extra tags is more than") {
+		t.Errorf("problems = %q", problems)
+	}
+}
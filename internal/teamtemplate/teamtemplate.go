@@ -0,0 +1,188 @@
+// Package teamtemplate reads an org-level mint template: a small git
+// repository of defaults that mint init --from-template applies to a new
+// user's configuration.
+//
+// A template repository may contain, at its root:
+//
+//	config.toml              config defaults, using mint's config keys
+//	user-bootstrap.sh        the user bootstrap hook
+//	devcontainer-overrides/  <project>.json devcontainer overrides
+//	instance-matrix.toml     recommended instance types and regions
+//
+// Every file is optional.
+package teamtemplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/SpiceLabsHQ/Mint/internal/config"
+)
+
+// File and directory names inside a template repository.
+const (
+	ConfigFile   = "config.toml"
+	HookFile     = "user-bootstrap.sh"
+	OverridesDir = "devcontainer-overrides"
+	MatrixFile   = "instance-matrix.toml"
+)
+
+// excludedKeys are config keys a template may not set: consent to edit
+// ~/.ssh/config stays with the user, and the template keys are recorded by
+// init itself.
+var excludedKeys = map[string]bool{
+	"ssh_config_approved": true,
+	"template_repo":       true,
+}
+
+// Recommendation is one row of the instance-matrix.toml matrix.
+type Recommendation struct {
+	Purpose      string   `mapstructure:"purpose"       json:"purpose"`
+	InstanceType string   `mapstructure:"instance_type" json:"instance_type"`
+	Regions      []string `mapstructure:"regions"       json:"regions"`
+}
+
+// Template is the content of a fetched template repository.
+type Template struct {
+	// Config maps mint config keys to values in the form config.Set takes.
+	Config map[string]string
+	// Ignored lists config.toml keys that are not mint config keys or that
+	// a template may not set.
+	Ignored []string
+	// Hook is the user-bootstrap.sh content, nil when absent.
+	Hook []byte
+	// Overrides maps override file names (<project>.json) to content.
+	Overrides map[string][]byte
+	Matrix    []Recommendation
+}
+
+// overrideName matches the override files a template may install.
+var overrideName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*\.json$`)
+
+// Load reads the template checked out at dir.
+func Load(dir string) (*Template, error) {
+	t := &Template{Config: map[string]string{}, Overrides: map[string][]byte{}}
+
+	if v, err := readTOML(filepath.Join(dir, ConfigFile)); err != nil {
+		return nil, err
+	} else if v != nil {
+		valid := map[string]bool{}
+		for _, k := range config.ValidKeys() {
+			valid[k] = true
+		}
+		for _, key := range v.AllKeys() {
+			if !valid[key] || excludedKeys[key] {
+				t.Ignored = append(t.Ignored, key)
+				continue
+			}
+			if key == "ssh_extra_args" {
+				t.Config[key] = strings.Join(v.GetStringSlice(key), " ")
+			} else {
+				t.Config[key] = v.GetString(key)
+			}
+		}
+		sort.Strings(t.Ignored)
+	}
+
+	hook, err := os.ReadFile(filepath.Join(dir, HookFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %s: %w", HookFile, err)
+	}
+	t.Hook = hook
+
+	entries, err := os.ReadDir(filepath.Join(dir, OverridesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %s: %w", OverridesDir, err)
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !overrideName.MatchString(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, OverridesDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s/%s: %w", OverridesDir, e.Name(), err)
+		}
+		t.Overrides[e.Name()] = data
+	}
+
+	if v, err := readTOML(filepath.Join(dir, MatrixFile)); err != nil {
+		return nil, err
+	} else if v != nil {
+		if err := v.UnmarshalKey("recommend", &t.Matrix); err != nil {
+			return nil, fmt.Errorf("read %s: %w", MatrixFile, err)
+		}
+	}
+	return t, nil
+}
+
+// readTOML reads path, returning nil when it does not exist.
+func readTOML(path string) (*viper.Viper, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return v, nil
+}
+
+// Fetcher checks out the template at url into the empty directory dest and
+// returns the commit it is at.
+type Fetcher func(ctx context.Context, url, dest string) (commit string, err error)
+
+// HeadResolver returns the commit the template at url's HEAD points to.
+type HeadResolver func(ctx context.Context, url string) (commit string, err error)
+
+// GitFetch is the production Fetcher: a shallow clone of the default
+// branch.
+func GitFetch(ctx context.Context, url, dest string) (string, error) {
+	if _, err := runGit(ctx, "", "clone", "--quiet", "--depth", "1", "--", url, dest); err != nil {
+		return "", err
+	}
+	out, err := runGit(ctx, dest, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// GitRemoteHead is the production HeadResolver.
+func GitRemoteHead(ctx context.Context, url string) (string, error) {
+	out, err := runGit(ctx, "", "ls-remote", "--", url, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	commit, _, _ := strings.Cut(strings.TrimSpace(out), "\t")
+	if commit == "" {
+		return "", fmt.Errorf("git ls-remote %s: no HEAD", url)
+	}
+	return commit, nil
+}
+
+// runGit runs git non-interactively in dir and returns its stdout.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never prompt for credentials: a private template without a
+	// credential helper fails instead of hanging init.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w (%s)", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,145 @@
+package teamtemplate
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ConfigFile), `
+instance_type = "m6i.2xlarge"
+volume_size_gb = 100
+ssh_extra_args = ["-o", "ServerAliveInterval=30"]
+ssh_config_approved = true
+template_repo = "https://example.com/other"
+color = "blue"
+`)
+	writeFile(t, filepath.Join(dir, HookFile), "#!/bin/bash\necho hi\n")
+	writeFile(t, filepath.Join(dir, OverridesDir, "api.json"), "{}")
+	writeFile(t, filepath.Join(dir, OverridesDir, "README.md"), "not an override")
+	writeFile(t, filepath.Join(dir, MatrixFile), `
+[[recommend]]
+purpose = "ml"
+instance_type = "g5.2xlarge"
+regions = ["us-west-2"]
+`)
+
+	tmpl, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	wantConfig := map[string]string{
+		"instance_type":  "m6i.2xlarge",
+		"volume_size_gb": "100",
+		"ssh_extra_args": "-o ServerAliveInterval=30",
+	}
+	if !reflect.DeepEqual(tmpl.Config, wantConfig) {
+		t.Errorf("Config = %v, want %v", tmpl.Config, wantConfig)
+	}
+	if want := []string{"color", "ssh_config_approved", "template_repo"}; !reflect.DeepEqual(tmpl.Ignored, want) {
+		t.Errorf("Ignored = %v, want %v", tmpl.Ignored, want)
+	}
+	if string(tmpl.Hook) != "#!/bin/bash\necho hi\n" {
+		t.Errorf("Hook = %q", tmpl.Hook)
+	}
+	if len(tmpl.Overrides) != 1 || tmpl.Overrides["api.json"] == nil {
+		t.Errorf("Overrides = %v, want only api.json", tmpl.Overrides)
+	}
+	want := []Recommendation{{Purpose: "ml", InstanceType: "g5.2xlarge", Regions: []string{"us-west-2"}}}
+	if !reflect.DeepEqual(tmpl.Matrix, want) {
+		t.Errorf("Matrix = %+v, want %+v", tmpl.Matrix, want)
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	tmpl, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(tmpl.Config) != 0 || tmpl.Hook != nil || len(tmpl.Overrides) != 0 || tmpl.Matrix != nil {
+		t.Errorf("empty template = %+v", tmpl)
+	}
+}
+
+func TestLoadInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ConfigFile), "region = \n")
+	if _, err := Load(dir); err == nil || !strings.Contains(err.Error(), ConfigFile) {
+		t.Errorf("Load() error = %v, want a config.toml parse error", err)
+	}
+}
+
+// gitRepo creates a local repository with one commit and returns its path
+// and HEAD.
+func gitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, HookFile), "#!/bin/bash\n")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "template")
+	return dir, git("rev-parse", "HEAD")
+}
+
+func TestGitFetchAndRemoteHead(t *testing.T) {
+	repo, head := gitRepo(t)
+	ctx := context.Background()
+
+	dest := filepath.Join(t.TempDir(), "checkout")
+	commit, err := GitFetch(ctx, "file://"+repo, dest)
+	if err != nil {
+		t.Fatalf("GitFetch: %v", err)
+	}
+	if commit != head {
+		t.Errorf("GitFetch commit = %s, want %s", commit, head)
+	}
+	if _, err := os.Stat(filepath.Join(dest, HookFile)); err != nil {
+		t.Errorf("checkout missing %s: %v", HookFile, err)
+	}
+
+	got, err := GitRemoteHead(ctx, repo)
+	if err != nil || got != head {
+		t.Errorf("GitRemoteHead = %s, %v; want %s", got, err, head)
+	}
+}
+
+func TestGitFetchMissingRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := GitFetch(context.Background(), filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "dest"))
+	if err == nil || !strings.HasPrefix(err.Error(), "git clone:") {
+		t.Errorf("GitFetch() error = %v, want a git clone error", err)
+	}
+}
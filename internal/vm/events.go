@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	mintaws "github.com/SpiceLabsHQ/Mint/internal/aws"
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+// ScheduledEvent is an EC2 scheduled event for a VM's instance, such as a
+// retirement or a system reboot. AWS announces these by email to the account
+// root, which nobody reads; mint surfaces them in status, doctor, and up.
+type ScheduledEvent struct {
+	InstanceID  string     `json:"instance_id"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	NotBefore   time.Time  `json:"not_before"`
+	NotAfter    *time.Time `json:"not_after,omitempty"`
+}
+
+// Scheduled event descriptions for events that no longer apply start with
+// one of these markers. They stay listed for about a week after.
+var finishedEventPrefixes = []string{"[Completed]", "[Canceled]"}
+
+// ScheduledEvents returns the pending scheduled events for the given
+// instances, keyed by instance ID and sorted by NotBefore. Completed and
+// canceled events are filtered out. Instances without events have no entry.
+// Stopped instances are included: a retirement is scheduled against them too.
+func ScheduledEvents(ctx context.Context, client mintaws.DescribeInstanceStatusAPI, instanceIDs []string) (map[string][]ScheduledEvent, error) {
+	events := make(map[string][]ScheduledEvent)
+	if len(instanceIDs) == 0 {
+		return events, nil
+	}
+
+	input := &ec2.DescribeInstanceStatusInput{
+		InstanceIds:         instanceIDs,
+		IncludeAllInstances: aws.Bool(true),
+	}
+	for {
+		out, err := client.DescribeInstanceStatus(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("describe instance status: %w", err)
+		}
+		for _, status := range out.InstanceStatuses {
+			id := aws.ToString(status.InstanceId)
+			for _, ev := range status.Events {
+				if eventFinished(ev) {
+					continue
+				}
+				events[id] = append(events[id], ScheduledEvent{
+					InstanceID:  id,
+					Code:        string(ev.Code),
+					Description: aws.ToString(ev.Description),
+					NotBefore:   aws.ToTime(ev.NotBefore),
+					NotAfter:    ev.NotAfter,
+				})
+			}
+		}
+		if aws.ToString(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	for _, list := range events {
+		sort.Slice(list, func(i, j int) bool { return list[i].NotBefore.Before(list[j].NotBefore) })
+	}
+	return events, nil
+}
+
+// eventFinished reports whether ev is a completed or canceled event.
+func eventFinished(ev ec2types.InstanceStatusEvent) bool {
+	desc := aws.ToString(ev.Description)
+	for _, prefix := range finishedEventPrefixes {
+		if strings.HasPrefix(desc, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the event in one line for human output, for example
+// "instance scheduled for retirement on 2025-02-01".
+func (e ScheduledEvent) Summary() string {
+	what := "scheduled event " + e.Code
+	switch ec2types.EventCode(e.Code) {
+	case ec2types.EventCodeInstanceRetirement:
+		what = "instance scheduled for retirement"
+	case ec2types.EventCodeInstanceStop:
+		what = "instance scheduled to stop"
+	case ec2types.EventCodeInstanceReboot:
+		what = "instance scheduled for reboot"
+	case ec2types.EventCodeSystemReboot:
+		what = "host scheduled for reboot"
+	case ec2types.EventCodeSystemMaintenance:
+		what = "host scheduled for maintenance"
+	}
+	if e.NotBefore.IsZero() {
+		return what
+	}
+	return what + " on " + e.NotBefore.UTC().Format("2006-01-02")
+}
+
+// Guidance returns what the user should do about the event.
+func (e ScheduledEvent) Guidance() string {
+	switch ec2types.EventCode(e.Code) {
+	case ec2types.EventCodeInstanceRetirement, ec2types.EventCodeInstanceStop:
+		return "run " + hint.Cmd("mint recreate") + " before then; your project volume and EIP will be preserved"
+	case ec2types.EventCodeInstanceReboot, ec2types.EventCodeSystemReboot:
+		return "the VM will reboot; running sessions will end, so save your work before then"
+	case ec2types.EventCodeSystemMaintenance:
+		return "the VM may be briefly unreachable during the maintenance window"
+	default:
+		return "see the event in the EC2 console"
+	}
+}
@@ -0,0 +1,178 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/SpiceLabsHQ/Mint/internal/hint"
+)
+
+type mockDescribeInstanceStatus struct {
+	pages    []*ec2.DescribeInstanceStatusOutput
+	err      error
+	captured []*ec2.DescribeInstanceStatusInput
+}
+
+func (m *mockDescribeInstanceStatus) DescribeInstanceStatus(ctx context.Context, params *ec2.DescribeInstanceStatusInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	m.captured = append(m.captured, params)
+	if m.err != nil {
+		return nil, m.err
+	}
+	page := m.pages[len(m.captured)-1]
+	return page, nil
+}
+
+func statusWithEvents(id string, events ...ec2types.InstanceStatusEvent) ec2types.InstanceStatus {
+	return ec2types.InstanceStatus{InstanceId: aws.String(id), Events: events}
+}
+
+func TestScheduledEvents(t *testing.T) {
+	retireAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	rebootAt := time.Date(2025, 1, 20, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		pages []*ec2.DescribeInstanceStatusOutput
+		want  map[string][]string // instance ID -> event codes in order
+	}{
+		{
+			name:  "no events",
+			pages: []*ec2.DescribeInstanceStatusOutput{{InstanceStatuses: []ec2types.InstanceStatus{statusWithEvents("i-a")}}},
+			want:  map[string][]string{},
+		},
+		{
+			name: "retirement event",
+			pages: []*ec2.DescribeInstanceStatusOutput{{InstanceStatuses: []ec2types.InstanceStatus{
+				statusWithEvents("i-a", ec2types.InstanceStatusEvent{
+					Code:        ec2types.EventCodeInstanceRetirement,
+					Description: aws.String("The instance is running on degraded hardware"),
+					NotBefore:   aws.Time(retireAt),
+				}),
+			}}},
+			want: map[string][]string{"i-a": {"instance-retirement"}},
+		},
+		{
+			name: "completed and canceled events are filtered out",
+			pages: []*ec2.DescribeInstanceStatusOutput{{InstanceStatuses: []ec2types.InstanceStatus{
+				statusWithEvents("i-a",
+					ec2types.InstanceStatusEvent{
+						Code:        ec2types.EventCodeSystemReboot,
+						Description: aws.String("[Completed] Scheduled reboot"),
+						NotBefore:   aws.Time(rebootAt),
+					},
+					ec2types.InstanceStatusEvent{
+						Code:        ec2types.EventCodeInstanceStop,
+						Description: aws.String("[Canceled] Scheduled stop"),
+						NotBefore:   aws.Time(rebootAt),
+					},
+				),
+			}}},
+			want: map[string][]string{},
+		},
+		{
+			name: "sorted by not-before across pages",
+			pages: []*ec2.DescribeInstanceStatusOutput{
+				{
+					InstanceStatuses: []ec2types.InstanceStatus{statusWithEvents("i-a", ec2types.InstanceStatusEvent{
+						Code: ec2types.EventCodeInstanceRetirement, NotBefore: aws.Time(retireAt),
+					})},
+					NextToken: aws.String("page2"),
+				},
+				{
+					InstanceStatuses: []ec2types.InstanceStatus{
+						statusWithEvents("i-a", ec2types.InstanceStatusEvent{
+							Code: ec2types.EventCodeSystemReboot, NotBefore: aws.Time(rebootAt),
+						}),
+						statusWithEvents("i-b", ec2types.InstanceStatusEvent{
+							Code: ec2types.EventCodeSystemMaintenance, NotBefore: aws.Time(retireAt),
+						}),
+					},
+				},
+			},
+			want: map[string][]string{
+				"i-a": {"system-reboot", "instance-retirement"},
+				"i-b": {"system-maintenance"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockDescribeInstanceStatus{pages: tt.pages}
+			got, err := ScheduledEvents(context.Background(), mock, []string{"i-a", "i-b"})
+			if err != nil {
+				t.Fatalf("ScheduledEvents() error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ScheduledEvents() = %+v, want codes %v", got, tt.want)
+			}
+			for id, codes := range tt.want {
+				var gotCodes []string
+				for _, ev := range got[id] {
+					if ev.InstanceID != id {
+						t.Errorf("event InstanceID = %q, want %q", ev.InstanceID, id)
+					}
+					gotCodes = append(gotCodes, ev.Code)
+				}
+				if strings.Join(gotCodes, ",") != strings.Join(codes, ",") {
+					t.Errorf("events for %s = %v, want %v", id, gotCodes, codes)
+				}
+			}
+
+			first := mock.captured[0]
+			if !aws.ToBool(first.IncludeAllInstances) {
+				t.Error("IncludeAllInstances should be set so stopped VMs are included")
+			}
+			if len(mock.captured) != len(tt.pages) {
+				t.Errorf("DescribeInstanceStatus called %d times, want %d", len(mock.captured), len(tt.pages))
+			}
+		})
+	}
+}
+
+func TestScheduledEventsNoInstances(t *testing.T) {
+	mock := &mockDescribeInstanceStatus{}
+	got, err := ScheduledEvents(context.Background(), mock, nil)
+	if err != nil || len(got) != 0 {
+		t.Errorf("ScheduledEvents(nil) = %v, %v; want empty", got, err)
+	}
+	if len(mock.captured) != 0 {
+		t.Error("DescribeInstanceStatus should not be called without instances")
+	}
+}
+
+func TestScheduledEventsError(t *testing.T) {
+	mock := &mockDescribeInstanceStatus{err: errors.New("throttled")}
+	if _, err := ScheduledEvents(context.Background(), mock, []string{"i-a"}); err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Errorf("error = %v, want throttled", err)
+	}
+}
+
+func TestScheduledEventSummaryAndGuidance(t *testing.T) {
+	hint.IsTTY = false
+	ev := ScheduledEvent{
+		Code:      string(ec2types.EventCodeInstanceRetirement),
+		NotBefore: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if got := ev.Summary(); got != "instance scheduled for retirement on 2025-02-01" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if got := ev.Guidance(); !strings.Contains(got, "`mint recreate`") || !strings.Contains(got, "project volume and EIP will be preserved") {
+		t.Errorf("Guidance() = %q", got)
+	}
+
+	reboot := ScheduledEvent{Code: string(ec2types.EventCodeSystemReboot)}
+	if got := reboot.Summary(); got != "host scheduled for reboot" {
+		t.Errorf("Summary() without date = %q", got)
+	}
+	if got := reboot.Guidance(); !strings.Contains(got, "reboot") {
+		t.Errorf("Guidance() = %q", got)
+	}
+}
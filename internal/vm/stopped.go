@@ -0,0 +1,44 @@
+package vm
+
+import (
+	"regexp"
+	"time"
+)
+
+// transitionTimePattern matches the timestamp EC2 appends to a state
+// transition reason, e.g. "User initiated (2025-01-03 21:14:05 GMT)".
+var transitionTimePattern = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) (GMT|UTC)\)`)
+
+// transitionTimeLayout is the layout of the matched timestamp, without zone.
+const transitionTimeLayout = "2006-01-02 15:04:05"
+
+// ParseStopTime extracts when a stopped instance was stopped from its state
+// transition reason. EC2 does not expose the stop time directly, so this is
+// an approximation. ok is false when the reason carries no timestamp, as
+// with "Server.ScheduledStop" or an empty reason; callers must treat the
+// stop age as unknown rather than guess.
+func ParseStopTime(reason string) (stoppedAt time.Time, ok bool) {
+	m := transitionTimePattern.FindAllStringSubmatch(reason, -1)
+	if len(m) == 0 {
+		return time.Time{}, false
+	}
+	// The last timestamp is the most recent transition.
+	t, err := time.ParseInLocation(transitionTimeLayout, m[len(m)-1][1], time.UTC)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// StoppedFor returns how long v has been stopped as of now. ok is false when
+// v is not stopped or its stop time is unknown or in the future.
+func (v *VM) StoppedFor(now time.Time) (d time.Duration, ok bool) {
+	if v.State != "stopped" {
+		return 0, false
+	}
+	stoppedAt, ok := ParseStopTime(v.StateTransitionReason)
+	if !ok || stoppedAt.After(now) {
+		return 0, false
+	}
+	return now.Sub(stoppedAt), true
+}
@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStopTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "user initiated",
+			reason: "User initiated (2025-01-03 21:14:05 GMT)",
+			want:   time.Date(2025, 1, 3, 21, 14, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "UTC zone",
+			reason: "User initiated (2025-01-03 21:14:05 UTC)",
+			want:   time.Date(2025, 1, 3, 21, 14, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "surrounding whitespace",
+			reason: "  User initiated (2025-01-03 21:14:05 GMT)\n",
+			want:   time.Date(2025, 1, 3, 21, 14, 5, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "last timestamp wins",
+			reason: "User initiated (2025-01-03 21:14:05 GMT) after (2025-01-01 08:00:00 GMT)",
+			want:   time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{name: "empty", reason: ""},
+		{name: "no timestamp", reason: "Server.ScheduledStop: Stopped due to scheduled retirement"},
+		{name: "shutdown without time", reason: "Client.UserInitiatedShutdown: User initiated shutdown"},
+		{name: "unknown zone", reason: "User initiated (2025-01-03 21:14:05 PST)"},
+		{name: "impossible date", reason: "User initiated (2025-13-45 21:14:05 GMT)"},
+		{name: "truncated", reason: "User initiated (2025-01-03 21:14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseStopTime(tt.reason)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseStopTime(%q) ok = %v, want %v", tt.reason, ok, tt.wantOK)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseStopTime(%q) = %s, want %s", tt.reason, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoppedFor(t *testing.T) {
+	now := time.Date(2025, 1, 20, 21, 14, 5, 0, time.UTC)
+	reason := "User initiated (2025-01-03 21:14:05 GMT)"
+
+	v := &VM{State: "stopped", StateTransitionReason: reason}
+	if d, ok := v.StoppedFor(now); !ok || d != 17*24*time.Hour {
+		t.Errorf("StoppedFor = %s, %v; want 408h, true", d, ok)
+	}
+
+	for _, v := range []*VM{
+		{State: "running", StateTransitionReason: reason},
+		{State: "stopped", StateTransitionReason: ""},
+		{State: "stopped", StateTransitionReason: "User initiated (2025-02-01 00:00:00 GMT)"},
+	} {
+		if d, ok := v.StoppedFor(now); ok {
+			t.Errorf("StoppedFor(%s, %q) = %s, want unknown", v.State, v.StateTransitionReason, d)
+		}
+	}
+}
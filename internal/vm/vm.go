@@ -7,6 +7,8 @@ package vm
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
 	"time"
 
@@ -24,20 +26,57 @@ type VM struct {
 	Name             string
 	State            string
 	PublicIP         string
+	PrivateIP        string
+	VpcID            string
 	InstanceType     string
+	ImageID          string
 	AvailabilityZone string
 	LaunchTime       time.Time
-	BootstrapStatus  string
-	RootVolumeGB     int
-	ProjectVolumeGB  int
-	Tags             map[string]string
+	// StateTransitionReason is EC2's free-text reason for the last state
+	// change, e.g. "User initiated (2025-01-03 21:14:05 GMT)".
+	StateTransitionReason string
+	BootstrapStatus       string
+	UserBootstrapStatus   string
+	RootVolumeGB          int
+	ProjectVolumeGB       int
+	// SSHUser is the login user the instance was provisioned for, from
+	// mint:ssh-user. Empty on instances launched before the tag existed.
+	SSHUser string
+	// Spot is true for an instance launched on the spot market.
+	Spot bool
+	// IPMode is the mint:ip-mode tag, or tags.IPModeEIP when absent. An
+	// ipv6-only VM has no public IPv4 address, so PublicIP holds its IPv6
+	// address, the one it is reached at.
+	IPMode string
+	// IPv6Address is the instance's IPv6 address, empty when it has none.
+	IPv6Address string
+	// HibernationConfigured is true for an instance launched
+	// hibernation-enabled, which mint down --hibernate can hibernate.
+	HibernationConfigured bool
+	// Hibernated is true for a stopping or stopped instance whose state
+	// reason says it was hibernated rather than shut down.
+	Hibernated bool
+	Tags       map[string]string
+}
+
+// hibernateStateReason is the state reason code EC2 gives an instance that
+// was stopped with hibernation.
+const hibernateStateReason = "Client.UserInitiatedHibernate"
+
+// DisplayState is the instance state for people to read: the EC2 state,
+// or "stopped (hibernated)" for a hibernated VM.
+func (v *VM) DisplayState() string {
+	if v.Hibernated {
+		return v.State + " (hibernated)"
+	}
+	return v.State
 }
 
 // FindVM discovers a single VM by owner and VM name. It returns nil (without
 // error) when no matching instance is found, and an error when multiple
 // non-terminated instances match.
 func FindVM(ctx context.Context, client mintaws.DescribeInstancesAPI, owner, vmName string) (*VM, error) {
-	vms, err := describeAndParse(ctx, client, tags.FilterByOwnerAndVM(owner, vmName))
+	vms, err := FindVMInstances(ctx, client, owner, vmName)
 	if err != nil {
 		return nil, err
 	}
@@ -52,12 +91,56 @@ func FindVM(ctx context.Context, client mintaws.DescribeInstancesAPI, owner, vmN
 	}
 }
 
+// FindVMInstances returns every non-terminated instance of owner's VM
+// vmName. FindVM treats more than one as an error; this lets a caller that
+// raced another run to launch the VM see both instances.
+func FindVMInstances(ctx context.Context, client mintaws.DescribeInstancesAPI, owner, vmName string) ([]*VM, error) {
+	return describeAndParse(ctx, client, tags.FilterByOwnerAndVM(owner, vmName))
+}
+
+// FindVMOwners returns the owners of the non-terminated VMs named vmName,
+// sorted and without duplicates. It lets a command that found no VM for the
+// caller say whose VM of that name exists.
+func FindVMOwners(ctx context.Context, client mintaws.DescribeInstancesAPI, vmName string) ([]string, error) {
+	vms, err := describeAndParse(ctx, client, tags.FilterByVM(vmName))
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []string
+	for _, v := range vms {
+		if owner := v.Tags[tags.TagOwner]; owner != "" && !slices.Contains(owners, owner) {
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	return owners, nil
+}
+
+// FindVMByID returns the VM with the given instance ID, or nil (without
+// error) when it does not exist or is terminated.
+func FindVMByID(ctx context.Context, client mintaws.DescribeInstancesAPI, instanceID string) (*VM, error) {
+	vms, err := describeAndParse(ctx, client, []ec2types.Filter{
+		{Name: aws.String("instance-id"), Values: []string{instanceID}},
+	})
+	if err != nil || len(vms) == 0 {
+		return nil, err
+	}
+	return vms[0], nil
+}
+
 // ListVMs discovers all VMs belonging to the given owner. Terminated and
 // shutting-down instances are excluded.
 func ListVMs(ctx context.Context, client mintaws.DescribeInstancesAPI, owner string) ([]*VM, error) {
 	return describeAndParse(ctx, client, tags.FilterByOwner(owner))
 }
 
+// ListAllVMs discovers every Mint VM in the account and region, whoever
+// owns it. Terminated and shutting-down instances are excluded.
+func ListAllVMs(ctx context.Context, client mintaws.DescribeInstancesAPI) ([]*VM, error) {
+	return describeAndParse(ctx, client, tags.FilterMint())
+}
+
 // describeAndParse calls DescribeInstances with the given filters and converts
 // the response into VM structs, filtering out terminated/shutting-down instances.
 func describeAndParse(ctx context.Context, client mintaws.DescribeInstancesAPI, filters []ec2types.Filter) ([]*VM, error) {
@@ -101,12 +184,24 @@ func parseInstance(inst ec2types.Instance) *VM {
 		ID:           aws.ToString(inst.InstanceId),
 		State:        string(inst.State.Name),
 		InstanceType: string(inst.InstanceType),
+		ImageID:      aws.ToString(inst.ImageId),
 		Tags:         tagMap,
 	}
 
 	if inst.PublicIpAddress != nil {
 		vm.PublicIP = aws.ToString(inst.PublicIpAddress)
 	}
+	vm.PrivateIP = aws.ToString(inst.PrivateIpAddress)
+	vm.IPv6Address = mintaws.InstanceIPv6Address(inst)
+	vm.StateTransitionReason = aws.ToString(inst.StateTransitionReason)
+	if inst.HibernationOptions != nil {
+		vm.HibernationConfigured = aws.ToBool(inst.HibernationOptions.Configured)
+	}
+	if inst.StateReason != nil && aws.ToString(inst.StateReason.Code) == hibernateStateReason {
+		vm.Hibernated = vm.State == string(ec2types.InstanceStateNameStopping) ||
+			vm.State == string(ec2types.InstanceStateNameStopped)
+	}
+	vm.VpcID = aws.ToString(inst.VpcId)
 	if inst.Placement != nil && inst.Placement.AvailabilityZone != nil {
 		vm.AvailabilityZone = aws.ToString(inst.Placement.AvailabilityZone)
 	}
@@ -116,6 +211,16 @@ func parseInstance(inst ec2types.Instance) *VM {
 
 	vm.Name = tagMap[tags.TagVM]
 	vm.BootstrapStatus = tagMap[tags.TagBootstrap]
+	vm.UserBootstrapStatus = tagMap[tags.TagUserBootstrap]
+	vm.SSHUser = tagMap[tags.TagSSHUser]
+	vm.Spot = tagMap[tags.TagSpot] == "true" || inst.InstanceLifecycle == ec2types.InstanceLifecycleTypeSpot
+	vm.IPMode = tagMap[tags.TagIPMode]
+	if vm.IPMode == "" {
+		vm.IPMode = tags.IPModeEIP
+	}
+	if vm.PublicIP == "" && vm.IPMode == tags.IPModeIPv6Only {
+		vm.PublicIP = vm.IPv6Address
+	}
 
 	if v, ok := tagMap[tags.TagRootVolumeGB]; ok {
 		if n, err := strconv.Atoi(v); err == nil {
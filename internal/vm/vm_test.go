@@ -3,6 +3,7 @@ package vm
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -107,6 +108,138 @@ func TestFindVM_Found(t *testing.T) {
 	}
 }
 
+func TestParseSpotInstance(t *testing.T) {
+	tagged := makeInstance("i-tag", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+	tagged.Tags = append(tagged.Tags, ec2types.Tag{Key: aws.String(tags.TagSpot), Value: aws.String("true")})
+	lifecycle := makeInstance("i-life", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+	lifecycle.InstanceLifecycle = ec2types.InstanceLifecycleTypeSpot
+	onDemand := makeInstance("i-od", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+
+	for _, tt := range []struct {
+		inst ec2types.Instance
+		want bool
+	}{{tagged, true}, {lifecycle, true}, {onDemand, false}} {
+		mock := &mockDescribeInstances{
+			output: &ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{makeReservation(tt.inst)},
+			},
+		}
+		vm, err := FindVMByID(context.Background(), mock, aws.ToString(tt.inst.InstanceId))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vm.Spot != tt.want {
+			t.Errorf("%s: Spot = %v, want %v", vm.ID, vm.Spot, tt.want)
+		}
+	}
+}
+
+func TestParseIPv6Instance(t *testing.T) {
+	ipv6Only := makeInstance("i-v6", "running", "", "t3.micro", "default", "alice", "complete", time.Now())
+	ipv6Only.Ipv6Address = aws.String("2600:1f14::10")
+	ipv6Only.Tags = append(ipv6Only.Tags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(tags.IPModeIPv6Only)})
+	dualStack := makeInstance("i-ds", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+	dualStack.Ipv6Address = aws.String("2600:1f14::20")
+	dualStack.Tags = append(dualStack.Tags, ec2types.Tag{Key: aws.String(tags.TagIPMode), Value: aws.String(tags.IPModeDualStack)})
+	eip := makeInstance("i-eip", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+
+	for _, tt := range []struct {
+		inst                     ec2types.Instance
+		wantMode, wantIP, wantV6 string
+	}{
+		{ipv6Only, tags.IPModeIPv6Only, "2600:1f14::10", "2600:1f14::10"},
+		{dualStack, tags.IPModeDualStack, "1.2.3.4", "2600:1f14::20"},
+		{eip, tags.IPModeEIP, "1.2.3.4", ""},
+	} {
+		mock := &mockDescribeInstances{
+			output: &ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{makeReservation(tt.inst)},
+			},
+		}
+		vm, err := FindVMByID(context.Background(), mock, aws.ToString(tt.inst.InstanceId))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vm.IPMode != tt.wantMode || vm.PublicIP != tt.wantIP || vm.IPv6Address != tt.wantV6 {
+			t.Errorf("%s: IPMode/PublicIP/IPv6Address = %q/%q/%q, want %q/%q/%q",
+				vm.ID, vm.IPMode, vm.PublicIP, vm.IPv6Address, tt.wantMode, tt.wantIP, tt.wantV6)
+		}
+	}
+}
+
+func TestParseHibernatedInstance(t *testing.T) {
+	hibernate := func(id, state, reason string) ec2types.Instance {
+		inst := makeInstance(id, state, "", "m6i.xlarge", "default", "alice", "complete", time.Now())
+		inst.HibernationOptions = &ec2types.HibernationOptions{Configured: aws.Bool(true)}
+		if reason != "" {
+			inst.StateReason = &ec2types.StateReason{Code: aws.String(reason)}
+		}
+		return inst
+	}
+
+	for _, tt := range []struct {
+		inst                       ec2types.Instance
+		wantConfigured, wantHibern bool
+		wantState                  string
+	}{
+		{hibernate("i-hib", "stopped", "Client.UserInitiatedHibernate"), true, true, "stopped (hibernated)"},
+		{hibernate("i-stop", "stopped", "Client.UserInitiatedShutdown"), true, false, "stopped"},
+		{hibernate("i-run", "running", "Client.UserInitiatedHibernate"), true, false, "running"},
+		{makeInstance("i-plain", "stopped", "", "m6i.xlarge", "default", "alice", "complete", time.Now()), false, false, "stopped"},
+	} {
+		mock := &mockDescribeInstances{
+			output: &ec2.DescribeInstancesOutput{
+				Reservations: []ec2types.Reservation{makeReservation(tt.inst)},
+			},
+		}
+		vm, err := FindVMByID(context.Background(), mock, aws.ToString(tt.inst.InstanceId))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if vm.HibernationConfigured != tt.wantConfigured || vm.Hibernated != tt.wantHibern || vm.DisplayState() != tt.wantState {
+			t.Errorf("%s: HibernationConfigured/Hibernated/DisplayState = %v/%v/%q, want %v/%v/%q",
+				vm.ID, vm.HibernationConfigured, vm.Hibernated, vm.DisplayState(), tt.wantConfigured, tt.wantHibern, tt.wantState)
+		}
+	}
+}
+
+func TestFindVMByID(t *testing.T) {
+	inst := makeInstance("i-abc123", "running", "1.2.3.4", "t3.micro", "default", "alice", "complete", time.Now())
+	inst.ImageId = aws.String("ami-0123456789")
+	inst.Tags = append(inst.Tags, ec2types.Tag{Key: aws.String(tags.TagSSHUser), Value: aws.String("ec2-user")})
+	mock := &mockDescribeInstances{
+		output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{makeReservation(inst)},
+		},
+	}
+
+	vm, err := FindVMByID(context.Background(), mock, "i-abc123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vm == nil {
+		t.Fatal("expected VM, got nil")
+	}
+	if vm.ImageID != "ami-0123456789" {
+		t.Errorf("ImageID = %q, want %q", vm.ImageID, "ami-0123456789")
+	}
+	if vm.SSHUser != "ec2-user" {
+		t.Errorf("SSHUser = %q, want %q", vm.SSHUser, "ec2-user")
+	}
+	filters := mock.captured.Filters
+	if len(filters) != 1 || aws.ToString(filters[0].Name) != "instance-id" || filters[0].Values[0] != "i-abc123" {
+		t.Errorf("filters = %+v, want instance-id i-abc123", filters)
+	}
+}
+
+func TestFindVMByID_NotFound(t *testing.T) {
+	mock := &mockDescribeInstances{output: &ec2.DescribeInstancesOutput{}}
+	vm, err := FindVMByID(context.Background(), mock, "i-gone")
+	if err != nil || vm != nil {
+		t.Errorf("FindVMByID() = %v, %v; want nil, nil", vm, err)
+	}
+}
+
 func TestFindVM_NotFound(t *testing.T) {
 	mock := &mockDescribeInstances{
 		output: &ec2.DescribeInstancesOutput{
@@ -149,6 +282,31 @@ func TestFindVM_MultipleMatchesError(t *testing.T) {
 	}
 }
 
+func TestFindVMInstances(t *testing.T) {
+	now := time.Now()
+	mock := &mockDescribeInstances{
+		output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{
+				makeReservation(makeInstance("i-111", "running", "", "t3.micro", "default", "alice", "", now)),
+				makeReservation(makeInstance("i-222", "pending", "", "t3.micro", "default", "alice", "", now)),
+				makeReservation(makeInstance("i-333", "terminated", "", "t3.micro", "default", "alice", "", now)),
+			},
+		},
+	}
+
+	vms, err := FindVMInstances(context.Background(), mock, "alice", "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, v := range vms {
+		ids = append(ids, v.ID)
+	}
+	if !slices.Equal(ids, []string{"i-111", "i-222"}) {
+		t.Errorf("instances = %v, want [i-111 i-222]", ids)
+	}
+}
+
 func TestFindVM_APIError(t *testing.T) {
 	mock := &mockDescribeInstances{
 		err: errors.New("access denied"),
@@ -215,6 +373,8 @@ func TestFindVM_FiltersShuttingDownInstances(t *testing.T) {
 func TestFindVM_NoPublicIP(t *testing.T) {
 	now := time.Now()
 	inst := makeInstance("i-nopub", "running", "", "t3.micro", "default", "alice", "", now)
+	inst.PrivateIpAddress = aws.String("172.31.4.9")
+	inst.VpcId = aws.String("vpc-private")
 
 	mock := &mockDescribeInstances{
 		output: &ec2.DescribeInstancesOutput{
@@ -229,6 +389,12 @@ func TestFindVM_NoPublicIP(t *testing.T) {
 	if vm.PublicIP != "" {
 		t.Errorf("PublicIP = %q, want empty", vm.PublicIP)
 	}
+	if vm.PrivateIP != "172.31.4.9" {
+		t.Errorf("PrivateIP = %q, want %q", vm.PrivateIP, "172.31.4.9")
+	}
+	if vm.VpcID != "vpc-private" {
+		t.Errorf("VpcID = %q, want %q", vm.VpcID, "vpc-private")
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -296,6 +462,31 @@ func TestListVMs_Multiple(t *testing.T) {
 	}
 }
 
+func TestListAllVMs(t *testing.T) {
+	now := time.Now()
+	inst1 := makeInstance("i-one", "running", "1.1.1.1", "t3.micro", "default", "alice", "", now)
+	inst2 := makeInstance("i-two", "stopped", "", "m6i.xlarge", "default", "bob", "", now)
+
+	mock := &mockDescribeInstances{
+		output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{makeReservation(inst1, inst2)},
+		},
+	}
+
+	vms, err := ListAllVMs(context.Background(), mock)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(vms) != 2 {
+		t.Fatalf("expected 2 VMs, got %d", len(vms))
+	}
+	for _, f := range mock.captured.Filters {
+		if aws.ToString(f.Name) == "tag:"+tags.TagOwner {
+			t.Errorf("ListAllVMs should not filter on owner, got %v", f.Values)
+		}
+	}
+}
+
 func TestListVMs_FiltersTerminated(t *testing.T) {
 	now := time.Now()
 	running := makeInstance("i-run", "running", "1.1.1.1", "t3.micro", "default", "alice", "", now)
@@ -453,3 +644,30 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFindVMOwners(t *testing.T) {
+	now := time.Now()
+	mock := &mockDescribeInstances{
+		output: &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{
+				makeReservation(makeInstance("i-b1", "running", "", "t3.micro", "default", "bob", "", now)),
+				makeReservation(makeInstance("i-a1", "stopped", "", "t3.micro", "default", "alice", "", now)),
+				makeReservation(makeInstance("i-b2", "running", "", "t3.micro", "default", "bob", "", now)),
+				makeReservation(makeInstance("i-c1", "terminated", "", "t3.micro", "default", "carol", "", now)),
+			},
+		},
+	}
+
+	owners, err := FindVMOwners(context.Background(), mock, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"alice", "bob"}; !slices.Equal(owners, want) {
+		t.Errorf("owners = %v, want %v", owners, want)
+	}
+	for _, f := range mock.captured.Filters {
+		if aws.ToString(f.Name) == "tag:"+tags.TagOwner {
+			t.Errorf("FindVMOwners filtered on the owner: %v", f.Values)
+		}
+	}
+}
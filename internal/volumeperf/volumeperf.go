@@ -0,0 +1,72 @@
+// Package volumeperf turns EBS CloudWatch datapoints into the saturation
+// signals mint status --deep reports. It has no AWS dependencies: callers
+// fetch the datapoints and pass them in.
+package volumeperf
+
+import (
+	"sort"
+	"time"
+)
+
+// Point is one CloudWatch datapoint.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Usage is consumption as a percentage of what the volume is provisioned
+// for.
+type Usage struct {
+	// AvgPct is the mean over the whole window.
+	AvgPct float64
+	// PeakPct is the busiest single period.
+	PeakPct float64
+}
+
+// Combine sums several series by timestamp, e.g. VolumeReadOps and
+// VolumeWriteOps into total operations. The result is sorted by time.
+func Combine(series ...[]Point) []Point {
+	sums := make(map[time.Time]float64)
+	for _, s := range series {
+		for _, p := range s {
+			sums[p.Time] += p.Value
+		}
+	}
+	out := make([]Point, 0, len(sums))
+	for t, v := range sums {
+		out = append(out, Point{Time: t, Value: v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
+
+// Saturation compares per-period Sum datapoints against a provisioned rate
+// per second, such as IOPS or bytes per second. The average is taken over
+// the whole window, so periods CloudWatch left out count as idle. ok is
+// false when there are no datapoints or nothing is provisioned.
+func Saturation(points []Point, period, window time.Duration, provisioned float64) (u Usage, ok bool) {
+	if len(points) == 0 || provisioned <= 0 || period <= 0 || window <= 0 {
+		return Usage{}, false
+	}
+	var total, peak float64
+	for _, p := range points {
+		total += p.Value
+		peak = max(peak, p.Value)
+	}
+	return Usage{
+		AvgPct:  total / window.Seconds() / provisioned * 100,
+		PeakPct: peak / period.Seconds() / provisioned * 100,
+	}, true
+}
+
+// Latest returns the most recent datapoint's value, e.g. the current
+// BurstBalance of a gp2 volume. ok is false when there are no datapoints.
+func Latest(points []Point) (value float64, ok bool) {
+	var latest Point
+	for _, p := range points {
+		if !ok || p.Time.After(latest.Time) {
+			latest, ok = p, true
+		}
+	}
+	return latest.Value, ok
+}
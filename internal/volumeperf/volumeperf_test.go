@@ -0,0 +1,75 @@
+package volumeperf
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 10, 1, 15, 0, 0, 0, time.UTC)
+
+func minute(i int, v float64) Point {
+	return Point{Time: t0.Add(time.Duration(i) * time.Minute), Value: v}
+}
+
+func TestCombine(t *testing.T) {
+	reads := []Point{minute(1, 10), minute(0, 5)}
+	writes := []Point{minute(0, 1), minute(2, 7)}
+	got := Combine(reads, writes)
+	want := []Point{minute(0, 6), minute(1, 10), minute(2, 7)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Combine() = %v, want %v", got, want)
+	}
+}
+
+func TestSaturation(t *testing.T) {
+	// 3000 IOPS provisioned; one minute at 2700 IOPS, one at 1500.
+	points := []Point{minute(0, 2700*60), minute(1, 1500*60)}
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		want   Usage
+	}{
+		{"window fully covered", 2 * time.Minute, Usage{AvgPct: 70, PeakPct: 90}},
+		{"missing periods count as idle", 15 * time.Minute, Usage{AvgPct: 9.333, PeakPct: 90}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Saturation(points, time.Minute, tt.window, 3000)
+			if !ok {
+				t.Fatal("Saturation() not ok")
+			}
+			if math.Abs(got.AvgPct-tt.want.AvgPct) > 0.01 || math.Abs(got.PeakPct-tt.want.PeakPct) > 0.01 {
+				t.Errorf("Saturation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaturationIdle(t *testing.T) {
+	got, ok := Saturation([]Point{minute(0, 0), minute(1, 0)}, time.Minute, 15*time.Minute, 3000)
+	if !ok || got.AvgPct != 0 || got.PeakPct != 0 {
+		t.Errorf("Saturation() = %+v, %v; want zero usage", got, ok)
+	}
+}
+
+func TestSaturationNoData(t *testing.T) {
+	if _, ok := Saturation(nil, time.Minute, 15*time.Minute, 3000); ok {
+		t.Error("no datapoints should not be ok")
+	}
+	if _, ok := Saturation([]Point{minute(0, 60)}, time.Minute, 15*time.Minute, 0); ok {
+		t.Error("nothing provisioned should not be ok")
+	}
+}
+
+func TestLatest(t *testing.T) {
+	got, ok := Latest([]Point{minute(2, 40), minute(5, 35), minute(1, 90)})
+	if !ok || got != 35 {
+		t.Errorf("Latest() = %v, %v; want 35, true", got, ok)
+	}
+	if _, ok := Latest(nil); ok {
+		t.Error("Latest(nil) should not be ok")
+	}
+}
@@ -34,6 +34,6 @@ func main() {
 		if msg := err.Error(); msg != "" {
 			fmt.Fprintln(os.Stderr, msg)
 		}
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
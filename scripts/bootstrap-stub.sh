@@ -1,8 +1,9 @@
 #!/bin/bash
 # Mint bootstrap stub — EC2 user-data, Ubuntu 24.04 LTS.
 # This tiny stub is sent as EC2 user-data. It fetches the real bootstrap.sh
-# from GitHub, verifies its SHA256, then execs it. All __PLACEHOLDER__ tokens
-# are substituted by Go before the stub is sent to EC2.
+# from GitHub (or the bootstrap_url mirror), or unpacks the copy mint up
+# --bootstrap-file embedded in it, verifies its SHA256, then execs it. All
+# __PLACEHOLDER__ tokens are substituted by Go before the stub is sent to EC2.
 
 set -euo pipefail
 
@@ -10,15 +11,25 @@ export MINT_EFS_ID="__MINT_EFS_ID__"
 export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
 export MINT_VM_NAME="__MINT_VM_NAME__"
 export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
 export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
 
 _STUB_URL="__MINT_BOOTSTRAP_URL__"
 _STUB_SHA256="__MINT_BOOTSTRAP_SHA256__"
+# bootstrap.sh itself, gzipped and base64-encoded, when it is embedded
+# instead of downloaded.
+_STUB_INLINE="__MINT_BOOTSTRAP_INLINE__"
 
 _tmp=$(mktemp)
 trap 'rm -f "$_tmp"' EXIT
 
-curl -fsSL --retry 3 --retry-delay 2 -o "$_tmp" "$_STUB_URL"
+if [ -n "$_STUB_INLINE" ]; then
+    echo "$_STUB_INLINE" | base64 -d | gunzip > "$_tmp"
+else
+    curl -fsSL --retry 3 --retry-delay 2 -o "$_tmp" "$_STUB_URL"
+fi
 
 _actual=$(sha256sum "$_tmp" | awk '{print $1}')
 if [ "$_actual" != "$_STUB_SHA256" ]; then
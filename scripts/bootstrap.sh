@@ -7,11 +7,15 @@ set -euo pipefail
 BOOTSTRAP_VERSION="1.0.0"
 MINT_STATE_DIR="/var/lib/mint"
 MINT_IDLE_TIMEOUT="${MINT_IDLE_TIMEOUT:-60}"
+MINT_SSH_PORT="${MINT_SSH_PORT:-41122}"
 
 # Track whether bootstrap completed successfully (used by EXIT trap).
 _bootstrap_ok=false
 # Track the active bootstrap phase so the EXIT trap can tag it on failure.
 _bootstrap_failure_phase=""
+# Outcome of the user bootstrap hook ("ok" or "failed:<code>"); empty when no
+# hook is configured. Tagged as mint:user-bootstrap by the EXIT trap.
+_user_bootstrap_status=""
 
 export DEBIAN_FRONTEND=noninteractive
 # HOME may be unset in EC2 user-data; export it so all child processes
@@ -24,6 +28,37 @@ log() {
     echo "[mint-bootstrap] $(date -u '+%Y-%m-%dT%H:%M:%SZ') $*"
 }
 
+# --- Phase timings ---
+# Each phase's start and end are written to _TIMINGS_FILE as a JSON array,
+# replaced atomically, so mint up --verbose can show where bootstrap spent
+# its time. The phase in progress has no end; if bootstrap dies in it, it
+# keeps none.
+
+_TIMINGS_FILE="/mint/.mint/bootstrap-timings.json"
+_timings_done=""
+_timing_phase=""
+_timing_start=""
+
+# timing_phase NAME ends the current phase and starts NAME. With no NAME it
+# only ends the current phase. Failures are ignored: timings are diagnostic.
+timing_phase() {
+    local _now _open=""
+    _now=$(date -u '+%Y-%m-%dT%H:%M:%SZ')
+    if [ -n "${_timing_phase}" ]; then
+        _timings_done="${_timings_done:+${_timings_done},}{\"phase\":\"${_timing_phase}\",\"start\":\"${_timing_start}\",\"end\":\"${_now}\"}"
+    fi
+    _timing_phase="${1:-}"
+    _timing_start="${_now}"
+    if [ -n "${_timing_phase}" ]; then
+        _open="{\"phase\":\"${_timing_phase}\",\"start\":\"${_now}\"}"
+    fi
+    {
+        mkdir -p "$(dirname "${_TIMINGS_FILE}")" \
+            && printf '[%s]\n' "${_timings_done}${_timings_done:+${_open:+,}}${_open}" > "${_TIMINGS_FILE}.tmp" \
+            && mv -f "${_TIMINGS_FILE}.tmp" "${_TIMINGS_FILE}"
+    } 2>/dev/null || true
+}
+
 # Fetch instance identity once — reused by EXIT trap and EFS mount.
 _IMDS_TOKEN=$(curl -s -X PUT "http://169.254.169.254/latest/api/token" \
     -H "X-aws-ec2-metadata-token-ttl-seconds: 21600" 2>/dev/null) || true
@@ -33,7 +68,9 @@ _TRAP_REGION=$(curl -s -H "X-aws-ec2-metadata-token: ${_IMDS_TOKEN}" \
     http://169.254.169.254/latest/meta-data/placement/region 2>/dev/null) || true
 
 # EXIT trap: tag instance mint:bootstrap=complete or failed.
-# On failure, also writes mint:bootstrap-failure-phase when _bootstrap_failure_phase is set.
+# On failure, also writes mint:bootstrap-failure-phase when _bootstrap_failure_phase is set,
+# and mint:bootstrap-error with the step (timing phase) that was running.
+# When a user hook ran, also writes mint:user-bootstrap with its outcome.
 _bootstrap_exit() {
     local _tag_value
     if [ "$_bootstrap_ok" = true ]; then
@@ -52,6 +89,23 @@ _bootstrap_exit() {
                 && log "Tagged instance ${_TRAP_INSTANCE_ID} with mint:bootstrap-failure-phase=${_bootstrap_failure_phase}" \
                 || log "WARNING: Failed to set mint:bootstrap-failure-phase=${_bootstrap_failure_phase} tag"
         fi
+        if [ "$_tag_value" = "failed" ] && [ -n "${_timing_phase:-}" ]; then
+            aws ec2 create-tags \
+                --resources "${_TRAP_INSTANCE_ID}" \
+                --tags "Key=mint:bootstrap-error,Value=${_timing_phase}" \
+                --region "${_TRAP_REGION}" 2>/dev/null \
+                && log "Tagged instance ${_TRAP_INSTANCE_ID} with mint:bootstrap-error=${_timing_phase}" \
+                || log "WARNING: Failed to set mint:bootstrap-error=${_timing_phase} tag"
+        fi
+        # Likewise write the user hook outcome before the status tag.
+        if [ -n "${_user_bootstrap_status:-}" ]; then
+            aws ec2 create-tags \
+                --resources "${_TRAP_INSTANCE_ID}" \
+                --tags "Key=mint:user-bootstrap,Value=${_user_bootstrap_status}" \
+                --region "${_TRAP_REGION}" 2>/dev/null \
+                && log "Tagged instance ${_TRAP_INSTANCE_ID} with mint:user-bootstrap=${_user_bootstrap_status}" \
+                || log "WARNING: Failed to set mint:user-bootstrap=${_user_bootstrap_status} tag"
+        fi
         aws ec2 create-tags \
             --resources "${_TRAP_INSTANCE_ID}" \
             --tags "Key=mint:bootstrap,Value=${_tag_value}" \
@@ -67,6 +121,7 @@ log "Starting bootstrap v${BOOTSTRAP_VERSION}"
 # --- System updates / packages ---
 
 _bootstrap_failure_phase="packages"
+timing_phase "packages"
 log "Updating system packages"
 apt-get update -qq
 apt-get upgrade -y -qq
@@ -79,6 +134,7 @@ apt-get install -y -qq git
 # --- Docker Engine (official apt repository) ---
 
 _bootstrap_failure_phase="docker"
+timing_phase "docker"
 log "Installing Docker Engine"
 apt-get install -y -qq ca-certificates curl gnupg
 
@@ -97,8 +153,43 @@ systemctl enable docker
 systemctl start docker
 usermod -aG docker ubuntu
 
+# --- NVIDIA driver and container toolkit (GPU instance types only) ---
+
+if [ "${MINT_GPU:-false}" = "true" ]; then
+    _bootstrap_failure_phase="gpu-driver"
+    timing_phase "gpu-driver"
+    log "Installing NVIDIA driver"
+    apt-get install -y -qq ubuntu-drivers-common
+    # The newest server driver branch for this GPU, e.g. 570-server.
+    NVIDIA_BRANCH=$(ubuntu-drivers list --gpgpu 2>/dev/null \
+        | grep -o 'nvidia-driver-[0-9]*-server' | sed 's/^nvidia-driver-//' | sort -V | tail -n 1)
+    if [ -z "${NVIDIA_BRANCH}" ]; then
+        log "ERROR: ubuntu-drivers found no NVIDIA driver for this instance"
+        exit 1
+    fi
+    ubuntu-drivers install --gpgpu "nvidia:${NVIDIA_BRANCH}"
+    apt-get install -y -qq "nvidia-utils-${NVIDIA_BRANCH}"
+    # The prebuilt module matches the running kernel unless the upgrade
+    # above replaced it; then it loads after the next reboot.
+    modprobe nvidia || log "WARNING: nvidia module not loaded; it loads on the next boot"
+
+    log "Installing NVIDIA Container Toolkit"
+    NVIDIA_KEYRING="/etc/apt/keyrings/nvidia-container-toolkit.gpg"
+    curl -fsSL https://nvidia.github.io/libnvidia-container/gpgkey \
+        | gpg --dearmor -o "${NVIDIA_KEYRING}"
+    chmod a+r "${NVIDIA_KEYRING}"
+    curl -fsSL https://nvidia.github.io/libnvidia-container/stable/deb/nvidia-container-toolkit.list \
+        | sed "s#deb https://#deb [signed-by=${NVIDIA_KEYRING}] https://#g" \
+        > /etc/apt/sources.list.d/nvidia-container-toolkit.list
+    apt-get update -qq
+    apt-get install -y -qq nvidia-container-toolkit
+    nvidia-ctk runtime configure --runtime=docker
+    systemctl restart docker
+fi
+
 # --- Node.js LTS ---
 
+timing_phase "node"
 log "Installing Node.js LTS"
 NODESOURCE_KEYRING="/etc/apt/keyrings/nodesource.gpg"
 curl -fsSL https://deb.nodesource.com/gpgkey/nodesource-repo.gpg.key \
@@ -112,11 +203,13 @@ apt-get install -y -qq nodejs
 
 # --- devcontainer CLI ---
 
+timing_phase "devcontainer-cli"
 log "Installing devcontainer CLI"
 npm install -g @devcontainers/cli
 
 # --- Claude Code CLI ---
 
+timing_phase "claude"
 log "Installing Claude Code CLI"
 curl -fsSL https://claude.ai/install.sh | bash
 # The standalone installer places the binary at ~/.claude/claude.
@@ -126,6 +219,7 @@ chmod 755 /usr/local/bin/claude
 
 # --- tmux ---
 
+timing_phase "tools"
 log "Installing tmux"
 apt-get install -y -qq tmux
 
@@ -180,14 +274,18 @@ apt-get install -y -qq ec2-instance-connect
 
 # --- SSH configuration (ADR-0016) ---
 
-log "Configuring SSH on port 41122"
-cat > /etc/ssh/sshd_config.d/mint.conf << 'SSH_CONF'
+timing_phase "ssh"
+log "Configuring SSH on port ${MINT_SSH_PORT}"
+cat > /etc/ssh/sshd_config.d/mint.conf << SSH_CONF
 # Mint SSH configuration (ADR-0016)
-Port 41122
+Port ${MINT_SSH_PORT}
 PasswordAuthentication no
 ChallengeResponseAuthentication no
 SSH_CONF
 
+# Persist the port so boot reconciliation checks sshd against it.
+echo "MINT_SSH_PORT=${MINT_SSH_PORT}" > /etc/default/mint-ssh
+
 systemctl disable --now ssh.socket
 systemctl enable ssh
 systemctl restart ssh
@@ -205,6 +303,7 @@ chmod 600 /root/.ssh/known_hosts
 # --- Storage mounts (ADR-0004) ---
 
 _bootstrap_failure_phase="efs-mount"
+timing_phase "efs-mount"
 log "Setting up storage mounts"
 mkdir -p /mint/projects "${MINT_STATE_DIR}"
 
@@ -248,6 +347,7 @@ fi
 
 # Format and mount project EBS at /mint/projects
 if [ -n "${MINT_PROJECT_DEV:-}" ]; then
+    timing_phase "project-volume"
     _dev="${MINT_PROJECT_DEV}"
     # Poll up to 90s for block device (handles NVMe naming on Nitro instances).
     _t=90
@@ -277,6 +377,7 @@ fi
 # --- Boot reconciliation service ---
 
 _bootstrap_failure_phase="systemd-units"
+timing_phase "systemd-units"
 log "Installing boot reconciliation systemd service"
 
 cat > /etc/systemd/system/mint-reconcile.service << 'RECONCILE_SERVICE'
@@ -302,6 +403,9 @@ log() { logger -t mint-reconcile "$*"; }
 
 log "Starting boot reconciliation"
 
+MINT_SSH_PORT=41122
+[ -f /etc/default/mint-ssh ] && . /etc/default/mint-ssh
+
 # Fix #197: Clear stale idle state from previous boot cycle.
 # /var/lib/mint/idle-since lives on root EBS which persists across stop/start.
 # A stale timestamp causes the first idle check to compute elapsed time from
@@ -343,7 +447,7 @@ DRIFT_ISSUES=()
 ! command -v docker &> /dev/null && DRIFT_ISSUES+=("docker_missing") \
     || ! systemctl is-active --quiet docker && DRIFT_ISSUES+=("docker_not_running") || true
 ! command -v node &> /dev/null && DRIFT_ISSUES+=("nodejs_missing") || true
-! grep -q "^Port 41122" /etc/ssh/sshd_config.d/mint.conf 2>/dev/null && DRIFT_ISSUES+=("ssh_port_drift") || true
+! grep -q "^Port ${MINT_SSH_PORT}$" /etc/ssh/sshd_config.d/mint.conf 2>/dev/null && DRIFT_ISSUES+=("ssh_port_drift") || true
 ! command -v mosh-server &> /dev/null && DRIFT_ISSUES+=("mosh_missing") || true
 ! command -v tmux &> /dev/null && DRIFT_ISSUES+=("tmux_missing") || true
 
@@ -518,9 +622,23 @@ systemctl start mint-idle-check.timer
 log "Writing bootstrap version"
 echo "${BOOTSTRAP_VERSION}" > /var/lib/mint/bootstrap-version
 
+# --- Agent version ---
+# The CLI reads this to learn which VM-side conventions it can rely on.
+# Bump it together with agentVersionMax in cmd/agent_version.go whenever the
+# files the CLI reads or writes on the VM change.
+
+log "Writing agent version"
+mkdir -p /mint/.mint
+echo "3" > /mint/.mint/agent-version
+
+# Automation guards (mint guard): unattended jobs running as ubuntu write
+# marker files here to block recreate, down, and destroy.
+install -d -o ubuntu -g ubuntu /mint/.mint/guards
+
 # --- Health check / drift-check ---
 
 _bootstrap_failure_phase="drift-check"
+timing_phase "health-check"
 log "Running health check"
 HEALTH_OK=true
 HEALTH_ERRORS=""
@@ -551,6 +669,10 @@ check_command claude
 check_command aws
 check_service docker
 check_service ssh
+if [ "${MINT_GPU:-false}" = "true" ]; then
+    check_command nvidia-smi
+    check_command nvidia-ctk
+fi
 
 if [ "$HEALTH_OK" = true ]; then
     log "Health check passed"
@@ -562,10 +684,28 @@ else
     exit 1
 fi
 
+# --- Container image prefetch ---
+# mint recreate passes the registry images the previous VM's devcontainers
+# used, most recently built first. They are pulled by a transient systemd
+# unit so bootstrap completion never waits on them; a failed pull only means
+# the first project build pulls the image itself.
+
+if [ -n "${MINT_PREFETCH_IMAGES:-}" ]; then
+    _prefetch_count=$(echo "${MINT_PREFETCH_IMAGES}" | wc -w)
+    log "Queueing background prefetch of ${_prefetch_count} container image(s)"
+    systemd-run --unit=mint-image-prefetch --collect --no-block \
+        --setenv=MINT_PREFETCH_IMAGES="${MINT_PREFETCH_IMAGES}" \
+        /bin/sh -c 'for image in $MINT_PREFETCH_IMAGES; do docker pull --quiet "$image" || echo "prefetch of $image failed"; done' \
+        || log "WARNING: could not start image prefetch — continuing without it"
+fi
+
 # --- User bootstrap hook ---
+# Runs after core bootstrap. A failing hook does not fail bootstrap — the VM
+# is usable — so its exit status is recorded separately in mint:user-bootstrap.
 
 if [ -n "${MINT_USER_BOOTSTRAP:-}" ]; then
     _bootstrap_failure_phase="user-script"
+    timing_phase "user-hook"
     log "Running user bootstrap hook as ubuntu"
     _user_script=$(mktemp)
     trap 'rm -f "$_user_script"; _bootstrap_exit' EXIT
@@ -576,7 +716,8 @@ if [ -n "${MINT_USER_BOOTSTRAP:-}" ]; then
     # GIT_CONFIG_COUNT injects SSH→HTTPS URL rewrites for this execution only,
     # so git clones of public repos work without an SSH key during bootstrap
     # without permanently altering ubuntu's ~/.gitconfig.
-    if ! sudo -u ubuntu -H \
+    _user_bootstrap_rc=0
+    sudo -u ubuntu -H \
         env \
         GIT_CONFIG_COUNT=3 \
         GIT_CONFIG_KEY_0="url.https://github.com/.insteadOf" \
@@ -585,16 +726,19 @@ if [ -n "${MINT_USER_BOOTSTRAP:-}" ]; then
         GIT_CONFIG_VALUE_1="git@gitlab.com:" \
         GIT_CONFIG_KEY_2="url.https://bitbucket.org/.insteadOf" \
         GIT_CONFIG_VALUE_2="git@bitbucket.org:" \
-        bash "${_user_script}"; then
-        log "User bootstrap hook exited with non-zero status — marking bootstrap failed"
-        _bootstrap_ok=false
-        exit 1
-    fi
+        bash "${_user_script}" || _user_bootstrap_rc=$?
     rm -f "${_user_script}"
-    log "User bootstrap hook completed"
+    if [ "${_user_bootstrap_rc}" -eq 0 ]; then
+        _user_bootstrap_status="ok"
+        log "User bootstrap hook completed"
+    else
+        _user_bootstrap_status="failed:${_user_bootstrap_rc}"
+        log "WARNING: User bootstrap hook exited with status ${_user_bootstrap_rc} — core bootstrap unaffected"
+    fi
 fi
 
 # Signal the EXIT trap that bootstrap completed successfully.
+timing_phase
 _bootstrap_ok=true
 _bootstrap_failure_phase=""
 log "Bootstrap v${BOOTSTRAP_VERSION} finished"
@@ -272,55 +272,67 @@ func makeE2EDescribeOutput(instances ...ec2types.Instance) *ec2.DescribeInstance
 // Provisioner builders
 // ---------------------------------------------------------------------------
 
+// testProvisioner returns provision.NewProvisioner(c, opts...), panicking
+// when a client is missing.
+func testProvisioner(c provision.Clients, opts ...provision.Option) *provision.Provisioner {
+	p, err := provision.NewProvisioner(c, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
 // newFreshProvisioner returns a Provisioner that will provision a brand-new VM.
 // DescribeInstances returns empty (no existing VM), so RunInstances is called.
 func newFreshProvisioner(instanceID, volumeID, allocationID, publicIP string) *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubStartInstances{},
-		&stubRunInstances{instanceID: instanceID, volumeID: volumeID},
-		&stubDescribeSGsDouble{},
-		&stubDescribeSubnets{},
-		&stubCreateVolume{volumeID: volumeID},
-		&stubAttachVolume{},
-		&stubAllocateAddress{allocationID: allocationID, publicIP: publicIP},
-		&stubAssociateAddress{},
-		&stubDescribeAddresses{},
-		&stubCreateTags{},
-		&stubDescribeImages{},
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &stubDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &stubStartInstances{},
+		RunInstances:      &stubRunInstances{instanceID: instanceID, volumeID: volumeID},
+		DescribeSGs:       &stubDescribeSGsDouble{},
+		DescribeSubnets:   &stubDescribeSubnets{},
+		CreateVolume:      &stubCreateVolume{volumeID: volumeID},
+		AttachVolume:      &stubAttachVolume{},
+		AllocateAddr:      &stubAllocateAddress{allocationID: allocationID, publicIP: publicIP},
+		AssociateAddr:     &stubAssociateAddress{},
+		DescribeAddrs:     &stubDescribeAddresses{},
+		CreateTags:        &stubCreateTags{},
+		DescribeImages:    &stubDescribeImages{},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-e2e-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-e2e-test", nil
-	})
 	return p
 }
 
 // newRestartProvisioner returns a Provisioner that will restart a stopped VM.
 // DescribeInstances returns a stopped VM, so StartInstances is called.
 func newRestartProvisioner(instanceID, vmName, owner, publicIP string) *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubDescribeInstances{
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &stubDescribeInstances{
 			output: makeE2EDescribeOutput(
 				makeE2EInstance(instanceID, vmName, owner, "stopped", publicIP, "m6i.xlarge"),
 			),
 		},
-		&stubStartInstances{},
-		&stubRunInstances{instanceID: instanceID},
-		&stubDescribeSGsDouble{},
-		&stubDescribeSubnets{},
-		&stubCreateVolume{volumeID: "vol-restart-e2e"},
-		&stubAttachVolume{},
-		&stubAllocateAddress{allocationID: "eipalloc-restart-e2e", publicIP: publicIP},
-		&stubAssociateAddress{},
-		&stubDescribeAddresses{},
-		&stubCreateTags{},
-		&stubDescribeImages{},
+		StartInstances:  &stubStartInstances{},
+		RunInstances:    &stubRunInstances{instanceID: instanceID},
+		DescribeSGs:     &stubDescribeSGsDouble{},
+		DescribeSubnets: &stubDescribeSubnets{},
+		CreateVolume:    &stubCreateVolume{volumeID: "vol-restart-e2e"},
+		AttachVolume:    &stubAttachVolume{},
+		AllocateAddr:    &stubAllocateAddress{allocationID: "eipalloc-restart-e2e", publicIP: publicIP},
+		AssociateAddr:   &stubAssociateAddress{},
+		DescribeAddrs:   &stubDescribeAddresses{},
+		CreateTags:      &stubCreateTags{},
+		DescribeImages:  &stubDescribeImages{},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-e2e-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-e2e-test", nil
-	})
 	return p
 }
 
@@ -328,24 +340,25 @@ func newRestartProvisioner(instanceID, vmName, owner, publicIP string) *provisio
 // caller-owned captureRunInstances so the caller can inspect lastInput after
 // the provision completes (e.g., to assert IOPS in BlockDeviceMappings).
 func newFreshProvisionerCapturingRun(ri *captureRunInstances, allocationID, publicIP string) *provision.Provisioner {
-	p := provision.NewProvisioner(
-		&stubDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
-		&stubStartInstances{},
-		ri,
-		&stubDescribeSGsDouble{},
-		&stubDescribeSubnets{},
-		&stubCreateVolume{volumeID: ri.volumeID},
-		&stubAttachVolume{},
-		&stubAllocateAddress{allocationID: allocationID, publicIP: publicIP},
-		&stubAssociateAddress{},
-		&stubDescribeAddresses{},
-		&stubCreateTags{},
-		&stubDescribeImages{},
+	p := testProvisioner(provision.Clients{
+		DescribeInstances: &stubDescribeInstances{output: &ec2.DescribeInstancesOutput{}},
+		StartInstances:    &stubStartInstances{},
+		RunInstances:      ri,
+		DescribeSGs:       &stubDescribeSGsDouble{},
+		DescribeSubnets:   &stubDescribeSubnets{},
+		CreateVolume:      &stubCreateVolume{volumeID: ri.volumeID},
+		AttachVolume:      &stubAttachVolume{},
+		AllocateAddr:      &stubAllocateAddress{allocationID: allocationID, publicIP: publicIP},
+		AssociateAddr:     &stubAssociateAddress{},
+		DescribeAddrs:     &stubDescribeAddresses{},
+		CreateTags:        &stubCreateTags{},
+		DescribeImages:    &stubDescribeImages{},
+	},
+		provision.WithBootstrapVerifier(func(content []byte) error { return nil }),
+		provision.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
+			return "ami-e2e-test", nil
+		}),
 	)
-	p.WithBootstrapVerifier(func(content []byte) error { return nil })
-	p.WithAMIResolver(func(ctx context.Context, client mintaws.DescribeImagesAPI) (string, error) {
-		return "ami-e2e-test", nil
-	})
 	return p
 }
 
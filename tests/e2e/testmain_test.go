@@ -14,9 +14,13 @@ export MINT_EFS_ID="__MINT_EFS_ID__"
 export MINT_PROJECT_DEV="__MINT_PROJECT_DEV__"
 export MINT_VM_NAME="__MINT_VM_NAME__"
 export MINT_IDLE_TIMEOUT="__MINT_IDLE_TIMEOUT__"
+export MINT_SSH_PORT="__MINT_SSH_PORT__"
 export MINT_USER_BOOTSTRAP="__MINT_USER_BOOTSTRAP__"
+export MINT_GPU="__MINT_GPU__"
+export MINT_PREFETCH_IMAGES="__MINT_PREFETCH_IMAGES__"
 _STUB_URL="__MINT_BOOTSTRAP_URL__"
 _STUB_SHA256="__MINT_BOOTSTRAP_SHA256__"
+_STUB_INLINE="__MINT_BOOTSTRAP_INLINE__"
 exec /tmp/bootstrap.sh
 `
 